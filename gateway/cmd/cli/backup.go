@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/backup"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+)
+
+// passphraseEnv 非交互场景下的备份口令
+const passphraseEnv = "NGOCLAW_BACKUP_PASSPHRASE"
+
+// ─── Backup / Restore ───
+
+func newBackupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "备份与恢复网关全部状态 (数据库、~/.ngoclaw、运行转录)",
+	}
+
+	create := &cobra.Command{
+		Use:   "create [file]",
+		Short: "将数据库、配置、prompts、skills、记忆与运行转录打包为 tar.gz",
+		Long: "打包 sqlite 数据库 (运行中也可安全快照)、~/.ngoclaw 全部内容 (logs/ 除外) " +
+			"以及 log.transcripts.dir 指向的外部转录目录. 默认文件名 ngoclaw-backup-<时间>.tar.gz. " +
+			"--exclude-secrets 清空配置中的 API key / token / 密码并跳过 .env; " +
+			"--encrypt 用口令加密整个归档 (口令取自 " + passphraseEnv + " 或交互输入).",
+		Args: cobra.MaximumNArgs(1),
+		RunE: runBackupCreate,
+	}
+	create.Flags().Bool("exclude-secrets", false, "不包含密钥 (恢复后需重新填写)")
+	create.Flags().Bool("encrypt", false, "用口令加密归档")
+
+	restore := &cobra.Command{
+		Use:   "restore <file>",
+		Short: "从备份恢复网关状态 (先停止 serve)",
+		Long: "将归档中的 ~/.ngoclaw 内容、外部转录目录与 sqlite 数据库写回原位; 数据库写到恢复后 " +
+			"config.yaml 中 database.dsn 指向的位置. 已有 config.yaml 或数据库时需 --force. " +
+			"加密归档的口令取自 " + passphraseEnv + " 或交互输入.",
+		Args: cobra.ExactArgs(1),
+		RunE: runBackupRestore,
+	}
+	restore.Flags().BoolP("force", "f", false, "覆盖已有的配置与数据库")
+
+	cmd.AddCommand(create, restore)
+	return cmd
+}
+
+func runBackupCreate(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	opts := backup.Options{
+		Home:     config.HomeDir(),
+		Database: cfg.Database,
+		Extra:    []string{cfg.Log.Transcripts.Dir},
+		Version:  cliVersion,
+	}
+	opts.ExcludeSecrets, _ = cmd.Flags().GetBool("exclude-secrets")
+	if encrypt, _ := cmd.Flags().GetBool("encrypt"); encrypt {
+		if opts.Passphrase, err = readPassphrase(true); err != nil {
+			return err
+		}
+	}
+
+	path := "ngoclaw-backup-" + time.Now().Format("20060102-150405") + ".tar.gz"
+	if opts.Passphrase != "" {
+		path += ".enc"
+	}
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if abs, _ := filepath.Abs(path); strings.HasPrefix(abs, opts.Home+string(filepath.Separator)) {
+		return fmt.Errorf("备份文件不能放在 %s 内", opts.Home)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	res, err := backup.Create(ctx, f, opts)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	info, _ := os.Stat(path)
+	fmt.Printf("✓ %s: %d 个文件, %s\n", path, res.Files, humanSize(info.Size()))
+	if res.Manifest.DatabaseNote != "" {
+		fmt.Printf("⚠ %s\n", res.Manifest.DatabaseNote)
+	}
+	if opts.ExcludeSecrets {
+		fmt.Println("  密钥已清空, 恢复后需在 config.yaml / mcp.json 中重新填写")
+	} else if opts.Passphrase == "" {
+		fmt.Println("  归档包含 API key 等密钥, 请妥善保管 (或使用 --encrypt / --exclude-secrets)")
+	}
+	return nil
+}
+
+func runBackupRestore(cmd *cobra.Command, args []string) error {
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	opts := backup.RestoreOptions{
+		Home:       config.HomeDir(),
+		Passphrase: os.Getenv(passphraseEnv),
+		// 数据库位置以恢复后的配置为准
+		DatabasePath: func() (string, error) {
+			cfg, err := config.Load()
+			if err != nil {
+				return "", fmt.Errorf("config: %w", err)
+			}
+			if cfg.Database.Type != "sqlite" {
+				return "", nil
+			}
+			return backup.SQLitePath(cfg.Database.DSN), nil
+		},
+	}
+	opts.Force, _ = cmd.Flags().GetBool("force")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	res, err := backup.Restore(ctx, f, opts)
+	if errors.Is(err, backup.ErrNeedPassphrase) {
+		if opts.Passphrase, err = readPassphrase(false); err != nil {
+			return err
+		}
+		if _, err = f.Seek(0, 0); err != nil {
+			return err
+		}
+		res, err = backup.Restore(ctx, f, opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	m := res.Manifest
+	fmt.Printf("✓ 已恢复 %d 个文件 (%s) → %s\n", res.Files, humanSize(res.Bytes), opts.Home)
+	fmt.Printf("  备份于 %s, 来自 %s (ngoclaw %s)\n", m.CreatedAt.Local().Format("2006-01-02 15:04"), m.Host, m.Version)
+	if m.DatabaseNote != "" {
+		fmt.Printf("⚠ %s\n", m.DatabaseNote)
+	}
+	if m.SecretsExcluded {
+		fmt.Println("⚠ 备份不含密钥, 请在 config.yaml / mcp.json 中重新填写 API key 与 bot token")
+	}
+	return nil
+}
+
+// readPassphrase reads the archive passphrase from NGOCLAW_BACKUP_PASSPHRASE
+// or the terminal; confirm asks twice (create).
+func readPassphrase(confirm bool) (string, error) {
+	if p := os.Getenv(passphraseEnv); p != "" {
+		return p, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("需要口令: 设置 %s 或在终端中运行", passphraseEnv)
+	}
+	fmt.Fprint(os.Stderr, "口令: ")
+	p, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if len(p) == 0 {
+		return "", fmt.Errorf("口令不能为空")
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "确认口令: ")
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if string(again) != string(p) {
+			return "", fmt.Errorf("两次输入的口令不一致")
+		}
+	}
+	return string(p), nil
+}
+
+// humanSize formats a byte count (1.2 MB).
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/application"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/logger"
+	toolpkg "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tool"
+)
+
+// ─── Commit Message Generation ───
+
+func newCommitCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "commit",
+		Short: "根据暂存区 diff 生成 Conventional Commit 并提交",
+		Long: "检查 git 暂存区, 生成带 scope 的 Conventional Commit 信息, " +
+			"可选更新 CHANGELOG.md, 确认后提交",
+		Args: cobra.NoArgs,
+		RunE: runCommit,
+	}
+	cmd.Flags().BoolP("changelog", "c", false, "同时更新 CHANGELOG.md")
+	cmd.Flags().BoolP("yes", "y", false, "跳过确认直接提交")
+	cmd.Flags().StringP("model", "m", "", "指定模型 (覆盖配置)")
+	return cmd
+}
+
+func runCommit(cmd *cobra.Command, args []string) error {
+	log, err := logger.NewLogger(logger.Config{
+		Level:      "error",
+		Format:     "console",
+		OutputPath: "/dev/null",
+	})
+	if err != nil {
+		return fmt.Errorf("logger init: %w", err)
+	}
+	defer log.Sync()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	if m, _ := cmd.Flags().GetString("model"); m != "" {
+		cfg.Agent.DefaultModel = m
+	}
+
+	app, err := application.NewAppCLI(cfg, log)
+	if err != nil {
+		return fmt.Errorf("初始化失败: %w", err)
+	}
+
+	ctx := context.Background()
+	fmt.Print("\033[90m⏳ 分析暂存区...\033[0m")
+	suggester := toolpkg.NewCommitSuggester(app.LLMClient(), cfg.Agent.DefaultModel, log)
+	s, err := suggester.Suggest(ctx, ".")
+	fmt.Print("\r\033[2K")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("◇ %d 个暂存文件\n\n", len(s.Files))
+	fmt.Printf("\033[1m%s\033[0m\n", s.Header())
+	if s.Body != "" {
+		fmt.Printf("\n%s\n", s.Body)
+	}
+	fmt.Println()
+
+	withChangelog, _ := cmd.Flags().GetBool("changelog")
+	if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+		reader := bufio.NewReader(os.Stdin)
+		fmt.Print("提交? [Y/n/e(编辑标题)] ")
+		answer, _ := reader.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "", "y", "yes":
+		case "e", "edit":
+			fmt.Print("新标题: ")
+			header, _ := reader.ReadString('\n')
+			header = strings.TrimSpace(header)
+			if header == "" {
+				fmt.Println("已取消")
+				return nil
+			}
+			if err := toolpkg.ParseCommitHeader(s, header); err != nil {
+				return err
+			}
+		default:
+			fmt.Println("已取消")
+			return nil
+		}
+	}
+
+	if withChangelog {
+		updated, err := toolpkg.UpdateChangelog(".", s)
+		if err != nil {
+			return fmt.Errorf("更新 CHANGELOG.md 失败: %w", err)
+		}
+		if updated {
+			if out, err := exec.Command("git", "add", "CHANGELOG.md").CombinedOutput(); err != nil {
+				return fmt.Errorf("git add CHANGELOG.md: %s", strings.TrimSpace(string(out)))
+			}
+			fmt.Println("✓ CHANGELOG.md 已更新")
+		}
+	}
+
+	gitCmd := exec.Command("git", "commit", "-F", "-")
+	gitCmd.Stdin = strings.NewReader(s.Message() + "\n")
+	gitCmd.Stdout = os.Stdout
+	gitCmd.Stderr = os.Stderr
+	return gitCmd.Run()
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/application"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/eval"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/logger"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/prompt"
+)
+
+// ─── Eval Suite ───
+
+func newEvalCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "eval",
+		Short: "运行评测套件, 比较模型 / 提示词版本",
+	}
+
+	run := &cobra.Command{
+		Use:   "run [suite-dir]",
+		Short: "在配置的模型上执行评测任务 (默认 ./evals)",
+		Long: "加载目录下的 YAML 任务, 为每个 (任务, 模型) 复制夹具到临时工作区并运行 agent, " +
+			"检查断言后把结果追加到 results.jsonl. 有任务失败时退出码为 1.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: runEval,
+	}
+	run.Flags().StringSliceP("model", "m", nil, "参与评测的模型, 可重复或逗号分隔 (默认 agent.default_model)")
+	run.Flags().StringSliceP("task", "t", nil, "只运行指定任务")
+	run.Flags().String("tag", "", "只运行带该标签的任务")
+	run.Flags().StringP("label", "l", "", "本次运行的版本标签 (如 soul-v2), 显示在成绩单中")
+	run.Flags().String("results", eval.DefaultResultsPath(), "结果文件")
+	run.Flags().Bool("keep", false, "保留任务工作区以便排查")
+
+	report := &cobra.Command{
+		Use:   "report",
+		Short: "显示历次评测的成绩单",
+		Args:  cobra.NoArgs,
+		RunE:  runEvalReport,
+	}
+	report.Flags().String("results", eval.DefaultResultsPath(), "结果文件")
+	report.Flags().StringP("model", "m", "", "只显示该模型")
+	report.Flags().IntP("last", "n", 20, "显示最近 N 行")
+
+	cmd.AddCommand(run, report)
+	return cmd
+}
+
+func runEval(cmd *cobra.Command, args []string) error {
+	suiteDir := "evals"
+	if len(args) > 0 {
+		suiteDir = args[0]
+	}
+	tasks, err := eval.LoadSuite(suiteDir)
+	if err != nil {
+		return err
+	}
+	tasks = filterTasks(cmd, tasks)
+	if len(tasks) == 0 {
+		return fmt.Errorf("%s: no matching tasks", suiteDir)
+	}
+
+	log, err := logger.NewLogger(logger.Config{
+		Level:      "error",
+		Format:     "console",
+		OutputPath: "/dev/null",
+	})
+	if err != nil {
+		return fmt.Errorf("logger init: %w", err)
+	}
+	defer log.Sync()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	models, _ := cmd.Flags().GetStringSlice("model")
+	if len(models) == 0 {
+		models = []string{cfg.Agent.DefaultModel}
+	}
+
+	app, err := application.NewAppCLI(cfg, log)
+	if err != nil {
+		return fmt.Errorf("初始化失败: %w", err)
+	}
+
+	engine := app.PromptEngine()
+	promptVersion := "none"
+	if engine != nil {
+		promptVersion = engine.Version()
+	}
+	keep, _ := cmd.Flags().GetBool("keep")
+	runner := &eval.Runner{
+		Loop:          app.AgentLoop(),
+		KeepWorkspace: keep,
+		Logger:        log,
+		SystemPrompt: func(task *eval.Task, model, workspace string) string {
+			if engine == nil {
+				return ""
+			}
+			return engine.Assemble(prompt.PromptContext{
+				Channel:     "cli",
+				ModelName:   model,
+				UserMessage: task.Prompt,
+				Workspace:   workspace,
+				SkipMemory:  true,
+			})
+		},
+	}
+	if sbx := app.Sandbox(); sbx != nil {
+		runner.Sandbox = sbx
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	label, _ := cmd.Flags().GetString("label")
+	runID := time.Now().Format("20060102-150405")
+	fmt.Printf("◇ eval %s · %d 个任务 × %d 个模型 · prompt %s\n\n", runID, len(tasks), len(models), promptVersion)
+
+	var records []eval.Record
+	failed := 0
+	for _, model := range models {
+		for _, task := range tasks {
+			if ctx.Err() != nil {
+				break
+			}
+			fmt.Printf("  %s · %s ", model, task.Name)
+			out := runner.Run(ctx, task, model)
+			records = append(records, eval.NewRecord(runID, time.Now(), label, promptVersion, out))
+			printOutcome(out)
+			if !out.Passed() {
+				failed++
+			}
+		}
+	}
+
+	resultsPath, _ := cmd.Flags().GetString("results")
+	if err := eval.AppendRecords(resultsPath, records); err != nil {
+		return fmt.Errorf("保存结果失败: %w", err)
+	}
+
+	fmt.Println()
+	all, err := eval.LoadRecords(resultsPath)
+	if err != nil {
+		return err
+	}
+	eval.WriteScorecard(os.Stdout, lastRows(eval.BuildScorecard(all), "", 10))
+	fmt.Printf("\n结果已保存到 %s\n", resultsPath)
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("已中断")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d/%d 个运行未通过", failed, len(records))
+	}
+	return nil
+}
+
+func runEvalReport(cmd *cobra.Command, args []string) error {
+	resultsPath, _ := cmd.Flags().GetString("results")
+	records, err := eval.LoadRecords(resultsPath)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		fmt.Printf("%s 中没有评测记录, 先运行 ngoclaw eval run\n", resultsPath)
+		return nil
+	}
+
+	model, _ := cmd.Flags().GetString("model")
+	last, _ := cmd.Flags().GetInt("last")
+	rows := lastRows(eval.BuildScorecard(records), model, last)
+
+	eval.WriteScorecard(os.Stdout, rows)
+	fmt.Println()
+	eval.WriteTaskMatrix(os.Stdout, rows)
+	return nil
+}
+
+func filterTasks(cmd *cobra.Command, tasks []*eval.Task) []*eval.Task {
+	names, _ := cmd.Flags().GetStringSlice("task")
+	tag, _ := cmd.Flags().GetString("tag")
+
+	var out []*eval.Task
+	for _, t := range tasks {
+		if len(names) > 0 && !containsString(names, t.Name) {
+			continue
+		}
+		if tag != "" && !t.HasTag(tag) {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// lastRows 按模型过滤并保留最近 n 行
+func lastRows(rows []*eval.ScoreRow, model string, n int) []*eval.ScoreRow {
+	if model != "" {
+		filtered := rows[:0:0]
+		for _, r := range rows {
+			if r.Model == model {
+				filtered = append(filtered, r)
+			}
+		}
+		rows = filtered
+	}
+	if n > 0 && len(rows) > n {
+		rows = rows[len(rows)-n:]
+	}
+	return rows
+}
+
+func printOutcome(out *eval.Outcome) {
+	steps, tokens := 0, 0
+	if out.Result != nil {
+		steps, tokens = out.Result.TotalSteps, out.Result.TotalTokens
+	}
+	stats := fmt.Sprintf("\033[90m%d steps · %d tokens · %s\033[0m", steps, tokens, out.Duration.Round(time.Second))
+	if out.Passed() {
+		fmt.Printf("\033[92m✓\033[0m %s\n", stats)
+		return
+	}
+	fmt.Printf("\033[91m✗\033[0m %s\n", stats)
+	if out.Error != "" {
+		fmt.Printf("      error: %s\n", out.Error)
+	}
+	for _, f := range out.Failures {
+		fmt.Printf("      - %s\n", strings.ReplaceAll(f, "\n", "\n        "))
+	}
+	// 仅 --keep 时工作区仍存在
+	if _, err := os.Stat(out.Workspace); out.Workspace != "" && err == nil {
+		fmt.Printf("      workspace: %s\n", out.Workspace)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
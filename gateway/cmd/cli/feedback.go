@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/repository"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/persistence"
+)
+
+// ─── Feedback Export ───
+
+func newFeedbackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "feedback",
+		Short: "用户反馈数据集 (👍/👎 反应与 /feedback)",
+	}
+
+	export := &cobra.Command{
+		Use:   "export",
+		Short: "导出反馈为 JSONL (每行一个 prompt / context / answer / label 样本)",
+		Long: "从数据库读取用户反馈, 每行输出一个 JSON 对象, 字段: id, source, chat_id, model, " +
+			"prompt, context, answer, label (good / bad / comment), comment, created_at. " +
+			"可直接用于构建微调或评测数据集.",
+		Args: cobra.NoArgs,
+		RunE: runFeedbackExport,
+	}
+	export.Flags().StringP("output", "o", "", "输出文件 (默认标准输出)")
+	export.Flags().String("since", "", "只导出此后的反馈: 时长 (7d, 12h) 或日期 (2026-01-02)")
+	export.Flags().StringP("label", "l", "", "只导出该标签: good | bad | comment")
+
+	cmd.AddCommand(export)
+	return cmd
+}
+
+func runFeedbackExport(cmd *cobra.Command, args []string) error {
+	filter := repository.FeedbackFilter{}
+	if s, _ := cmd.Flags().GetString("since"); s != "" {
+		since, err := parseSince(s, time.Now())
+		if err != nil {
+			return err
+		}
+		filter.Since = since
+	}
+	filter.Label, _ = cmd.Flags().GetString("label")
+	switch filter.Label {
+	case "", entity.FeedbackGood, entity.FeedbackBad, entity.FeedbackComment:
+	default:
+		return fmt.Errorf("unknown label %q (good | bad | comment)", filter.Label)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	db, err := persistence.NewDBConnectionSilent(&cfg.Database)
+	if err != nil {
+		return err
+	}
+	items, err := persistence.NewGormFeedbackRepository(db).List(context.Background(), filter)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if path, _ := cmd.Flags().GetString("output"); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := writeFeedbackJSONL(out, items); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "✓ %d 条反馈\n", len(items))
+	return nil
+}
+
+// writeFeedbackJSONL writes one JSON object per line.
+func writeFeedbackJSONL(w io.Writer, items []*entity.Feedback) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, item := range items {
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseSince accepts a duration back from now ("7d", "12h", "90m") or a date.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use 7d, 12h or 2006-01-02", s)
+}
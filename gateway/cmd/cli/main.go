@@ -16,6 +16,7 @@ import (
 	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/logger"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/interfaces/cli"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
 )
 
 const (
@@ -35,6 +36,16 @@ func main() {
 	rootCmd.Flags().StringP("model", "m", "", "指定模型 (覆盖配置)")
 	rootCmd.Flags().BoolP("no-approve", "y", false, "跳过工具审批 (YOLO 模式)")
 	rootCmd.Flags().StringP("workspace", "w", "", "工作目录")
+	rootCmd.Flags().BoolP("verbose", "v", false, "显示完整工具输出 (默认折叠为前几行)")
+	rootCmd.PersistentFlags().String("profile", "", "配置 profile (~/.ngoclaw/profiles/<name>/config.yaml, default 表示不使用)")
+
+	// --profile 经环境变量传给 config.Load, 所有子命令统一生效
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if p, _ := cmd.Flags().GetString("profile"); p != "" {
+			return os.Setenv(config.ProfileEnv, p)
+		}
+		return nil
+	}
 
 	// --- Subcommands ---
 
@@ -59,6 +70,17 @@ func main() {
 		RunE:  runDoctor,
 	})
 
+	rootCmd.AddCommand(newCommitCmd())
+	rootCmd.AddCommand(newEvalCmd())
+	rootCmd.AddCommand(newPromptCmd())
+	rootCmd.AddCommand(newSyncCmd())
+	rootCmd.AddCommand(newFeedbackCmd())
+	rootCmd.AddCommand(newToolsCmd())
+	rootCmd.AddCommand(newBackupCmd())
+	rootCmd.AddCommand(newTunnelCmd())
+	rootCmd.AddCommand(newReplayCmd())
+	rootCmd.AddCommand(newPolicyCmd())
+
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
@@ -84,9 +106,13 @@ func runInteractive(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("config: %w", err)
 	}
 
-	// CLI flag overrides
+	// CLI flag overrides (--model 也覆盖 channels.cli.default_model)
 	if m, _ := cmd.Flags().GetString("model"); m != "" {
 		cfg.Agent.DefaultModel = m
+		if ch, ok := cfg.Channels["cli"]; ok {
+			ch.DefaultModel = m
+			cfg.Channels["cli"] = ch
+		}
 	}
 	// Workspace: always use CWD (where user launched ngoclaw)
 	// --workspace flag overrides CWD; config workspace is for gateway mode only
@@ -95,6 +121,7 @@ func runInteractive(cmd *cobra.Command, args []string) error {
 		workspace = w
 	}
 	noApprove, _ := cmd.Flags().GetBool("no-approve")
+	verbose, _ := cmd.Flags().GetBool("verbose")
 
 	// Init app (CLI mode — no HTTP/TG/gRPC servers, silent DB)
 	fmt.Print("\033[90m⏳ 初始化中...\033[0m")
@@ -116,12 +143,25 @@ func runInteractive(cmd *cobra.Command, args []string) error {
 		initPrompt = strings.Join(args, " ")
 	}
 
+	// Locale: config.yaml `locale` wins, otherwise follow $LANG
+	locale := i18n.FromEnv()
+	if l, ok := i18n.Parse(cfg.Locale); ok {
+		locale = l
+	}
+
 	replCfg := cli.REPLConfig{
-		Model:      cfg.Agent.DefaultModel,
+		Model:      cfg.ChannelModel("cli"),
+		Profile:    cfg.Profile,
 		Workspace:  workspace,
 		ToolCount:  toolCount,
 		NoApprove:  noApprove,
 		InitPrompt: initPrompt,
+		Locale:     locale,
+		Verbose:    verbose,
+		Output:     app.OutputPipeline(),
+		CarryOver:  app.CarryOverPolicy(),
+		Mentions:   app.MentionPreprocessor(workspace),
+		Tools:      app.ToolCatalog,
 	}
 
 	return cli.RunREPL(app.AgentLoop(), app.PromptEngine(), replCfg)
@@ -165,8 +205,14 @@ func runServe(cmd *cobra.Command, args []string) error {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
-	sig := <-quit
-	log.Info("Received shutdown signal", zap.String("signal", sig.String()))
+	restart := false
+	select {
+	case sig := <-quit:
+		log.Info("Received shutdown signal", zap.String("signal", sig.String()))
+	case <-app.RestartRequested():
+		log.Info("Restarting gateway for config profile switch")
+		restart = true
+	}
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer shutdownCancel()
@@ -176,10 +222,42 @@ func runServe(cmd *cobra.Command, args []string) error {
 		os.Exit(1)
 	}
 
+	if restart {
+		return reexec()
+	}
+
 	log.Info("Application stopped successfully")
 	return nil
 }
 
+// reexec 以相同参数重新执行自身 (/profile 切换后). 去掉 --profile 与
+// NGOCLAW_PROFILE, 让新进程读取 /profile 保存的 ~/.ngoclaw/profile.
+func reexec() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("restart: %w", err)
+	}
+	args := []string{os.Args[0]}
+	for i := 1; i < len(os.Args); i++ {
+		a := os.Args[i]
+		if a == "--profile" {
+			i++
+			continue
+		}
+		if strings.HasPrefix(a, "--profile=") {
+			continue
+		}
+		args = append(args, a)
+	}
+	var env []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, config.ProfileEnv+"=") {
+			env = append(env, kv)
+		}
+	}
+	return syscall.Exec(exe, args, env)
+}
+
 // ─── Doctor ───
 
 func runDoctor(cmd *cobra.Command, args []string) error {
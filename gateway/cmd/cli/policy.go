@@ -0,0 +1,134 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/policy"
+)
+
+// ─── Organization Policy ───
+
+func newPolicyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "组织策略包: 生成密钥、签名与校验",
+	}
+
+	keygen := &cobra.Command{
+		Use:   "keygen",
+		Short: "生成 ed25519 签名密钥对",
+		Long: "输出 base64 编码的公钥与私钥. 公钥写入各网关的 policy.public_key; " +
+			"私钥只用于 ngoclaw policy sign, 应妥善保管.",
+		Args: cobra.NoArgs,
+		RunE: runPolicyKeygen,
+	}
+
+	sign := &cobra.Command{
+		Use:   "sign <payload.yaml>",
+		Short: "校验策略内容并签名, 输出可发布到 policy.url 的策略包",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runPolicySign,
+	}
+	sign.Flags().StringP("key", "k", "", "私钥文件 (keygen 输出的 base64 私钥)")
+	sign.Flags().StringP("output", "o", "", "输出文件 (默认标准输出)")
+	_ = sign.MarkFlagRequired("key")
+
+	verify := &cobra.Command{
+		Use:   "verify <bundle.json>",
+		Short: "用 policy.public_key 校验策略包签名并显示内容摘要",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runPolicyVerify,
+	}
+	verify.Flags().String("public-key", "", "公钥 (覆盖 policy.public_key)")
+
+	cmd.AddCommand(keygen, sign, verify)
+	return cmd
+}
+
+func runPolicyKeygen(cmd *cobra.Command, args []string) error {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("public_key:  %s\n", base64.StdEncoding.EncodeToString(pub))
+	fmt.Printf("private_key: %s\n", base64.StdEncoding.EncodeToString(priv.Seed()))
+	return nil
+}
+
+func runPolicySign(cmd *cobra.Command, args []string) error {
+	keyFile, _ := cmd.Flags().GetString("key")
+	keyData, err := os.ReadFile(keyFile)
+	if err != nil {
+		return err
+	}
+	key, err := policy.ParsePrivateKey(string(keyData))
+	if err != nil {
+		return err
+	}
+	payload, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	// 签名前先按网关的规则校验, 避免发布一个所有网关都会拒绝的策略包
+	if _, err := policy.Parse(payload, "."); err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	bundle, err := policy.Sign(payload, key)
+	if err != nil {
+		return err
+	}
+	if out, _ := cmd.Flags().GetString("output"); out != "" {
+		return os.WriteFile(out, append(bundle, '\n'), 0o644)
+	}
+	fmt.Println(string(bundle))
+	return nil
+}
+
+func runPolicyVerify(cmd *cobra.Command, args []string) error {
+	pubKey, _ := cmd.Flags().GetString("public-key")
+	if pubKey == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+		pubKey = cfg.Policy.PublicKey
+	}
+	if pubKey == "" {
+		return fmt.Errorf("未配置公钥: 在 ~/.ngoclaw/config.yaml 中设置 policy.public_key, 或使用 --public-key")
+	}
+	key, err := policy.ParsePublicKey(pubKey)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	payload, err := policy.Verify(data, key)
+	if err != nil {
+		return err
+	}
+	p, err := policy.Parse(payload, ".")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ 签名有效 (version %s", p.Version)
+	if !p.Issued.IsZero() {
+		fmt.Printf(", issued %s", p.Issued.Format("2006-01-02 15:04"))
+	}
+	fmt.Println(")")
+	if p.ApprovalMode != "" {
+		fmt.Printf("  approval_mode:   %s\n", p.ApprovalMode)
+	}
+	fmt.Printf("  dangerous_tools: %d\n", len(p.DangerousTools))
+	fmt.Printf("  guards:          %d\n", p.Guards.Len())
+	fmt.Printf("  blocked_models:  %d\n", len(p.BlockedModels))
+	fmt.Printf("  budgets:         %d\n", len(p.Budgets))
+	return nil
+}
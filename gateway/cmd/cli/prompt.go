@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/application"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/logger"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/prompt"
+)
+
+// ─── Prompt Lint ───
+
+func newPromptCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prompt",
+		Short: "提示词组件工具",
+	}
+
+	lint := &cobra.Command{
+		Use:   "lint",
+		Short: "检查各层提示词组件的重名、未注册工具、超预算和矛盾指令",
+		Long: "扫描系统层 / 工作区层 / 渠道层的 soul.md、prompts/*.md 和 variants, 报告: " +
+			"跨层或同层重名组件、requires 引用的未注册工具、超出 token 预算的段落、" +
+			"以及互相矛盾的指令 (启发式, 如 always X / never X). 存在 error 时退出码为 1.",
+		Args: cobra.NoArgs,
+		RunE: runPromptLint,
+	}
+	lint.Flags().Bool("json", false, "输出 JSON (机器可读)")
+	lint.Flags().StringP("workspace", "w", "", "工作区目录 (默认 agent.workspace)")
+	lint.Flags().Int("max-section-tokens", prompt.DefaultLintSectionTokens, "单个段落的 token 预算")
+	lint.Flags().Int("max-total-tokens", prompt.DefaultLintTotalTokens, "单个渠道静态提示词的 token 预算")
+
+	cmd.AddCommand(lint)
+	return cmd
+}
+
+// promptLintReport is the --json output.
+type promptLintReport struct {
+	Issues   []prompt.LintIssue `json:"issues"`
+	Errors   int                `json:"errors"`
+	Warnings int                `json:"warnings"`
+}
+
+func runPromptLint(cmd *cobra.Command, args []string) error {
+	log, err := logger.NewLogger(logger.Config{
+		Level:      "error",
+		Format:     "console",
+		OutputPath: "/dev/null",
+	})
+	if err != nil {
+		return fmt.Errorf("logger init: %w", err)
+	}
+	defer log.Sync()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	workspace := cfg.Agent.Workspace
+	if w, _ := cmd.Flags().GetString("workspace"); w != "" {
+		workspace = w
+	}
+
+	opts := prompt.LintOptions{}
+	opts.MaxSectionTokens, _ = cmd.Flags().GetInt("max-section-tokens")
+	opts.MaxTotalTokens, _ = cmd.Flags().GetInt("max-total-tokens")
+
+	// Registered tools come from the real tool layer (builtin + MCP + skills)
+	if app, err := application.NewAppCLI(cfg, log); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ 初始化失败, 跳过工具检查: %v\n", err)
+	} else if reg := app.ToolRegistry(); reg != nil {
+		opts.RegisteredTools = []string{}
+		for _, def := range reg.List() {
+			opts.RegisteredTools = append(opts.RegisteredTools, def.Name)
+		}
+	}
+
+	issues := prompt.NewPromptEngine(workspace, log).Lint(opts)
+
+	report := promptLintReport{Issues: issues}
+	for _, is := range issues {
+		if is.Severity == prompt.LintError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		if report.Issues == nil {
+			report.Issues = []prompt.LintIssue{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printLintIssues(report)
+	}
+
+	if report.Errors > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d 个错误", report.Errors)
+	}
+	return nil
+}
+
+func printLintIssues(report promptLintReport) {
+	if len(report.Issues) == 0 {
+		fmt.Println("\033[92m✓\033[0m 未发现问题")
+		return
+	}
+	for _, is := range report.Issues {
+		icon := "\033[93m⚠\033[0m"
+		if is.Severity == prompt.LintError {
+			icon = "\033[91m✗\033[0m"
+		}
+		where := is.File
+		if where == "" {
+			where = is.Layer
+		}
+		fmt.Printf("%s %s \033[90m[%s]\033[0m\n    %s\n", icon, where, is.Rule, is.Message)
+	}
+	fmt.Printf("\n%d 个错误, %d 个警告\n", report.Errors, report.Warnings)
+}
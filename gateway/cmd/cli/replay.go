@@ -0,0 +1,411 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/application"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/journal"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/logger"
+)
+
+// replayPreview 逐步查看时单条消息 / 工具输出显示的最大字符数 (prompt 与 full 命令显示全文)
+const replayPreview = 600
+
+// ─── Run Replay ───
+
+func newReplayCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay [run-id]",
+		Short: "回放运行日志: 逐步查看提示词、模型响应与工具调用, 或从某一步重新执行",
+		Long: "读取 log.journal 记录的运行日志. 不带参数时列出最近的运行; run-id 可以是唯一前缀.\n" +
+			"交互模式下回车 / n 下一步, p 上一步, 数字跳转, prompt 显示该步完整提示词, " +
+			"full 显示未截断的响应与工具结果, q 退出.\n" +
+			"--from-step N --live 以第 N 步发给模型的消息为起点重新执行 (真实调用模型与工具, " +
+			"在当前目录运行), 可用 --note / --system-file / --edit 修改上下文.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: runReplay,
+	}
+	cmd.Flags().String("dir", "", "运行日志目录 (默认 log.journal.dir 或 ~/.ngoclaw/journal)")
+	cmd.Flags().IntP("last", "n", 20, "列出最近 N 次运行")
+	cmd.Flags().Int("step", 0, "只打印第 N 步后退出 (非交互)")
+	cmd.Flags().Int("from-step", 0, "从第 N 步开始重新执行 (配合 --live)")
+	cmd.Flags().Bool("live", false, "真实执行: 调用模型与工具, 从 --from-step 继续运行")
+	cmd.Flags().StringP("model", "m", "", "重新执行时使用的模型 (默认该步原来的模型)")
+	cmd.Flags().String("note", "", "重新执行前追加到上下文的用户消息")
+	cmd.Flags().String("system-file", "", "重新执行时用该文件内容替换系统提示词")
+	cmd.Flags().Bool("edit", false, "重新执行前在 $EDITOR 中编辑消息 (JSON)")
+	return cmd
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	dir, _ := cmd.Flags().GetString("dir")
+	if dir == "" {
+		if cfg, err := config.Load(); err == nil {
+			dir = cfg.Log.Journal.Dir
+		}
+	}
+	if dir == "" {
+		dir = journal.DefaultDir()
+	}
+
+	if len(args) == 0 {
+		last, _ := cmd.Flags().GetInt("last")
+		return listRuns(dir, last)
+	}
+
+	run, err := journal.Load(dir, args[0])
+	if err != nil {
+		return err
+	}
+	if len(run.Steps) == 0 {
+		return fmt.Errorf("%s: the journal has no steps", run.ID)
+	}
+
+	live, _ := cmd.Flags().GetBool("live")
+	fromStep, _ := cmd.Flags().GetInt("from-step")
+	if live {
+		if fromStep == 0 {
+			return fmt.Errorf("--live requires --from-step N")
+		}
+		return replayLive(cmd, run, fromStep)
+	}
+	if fromStep != 0 {
+		return fmt.Errorf("--from-step requires --live (use --step N to view a step)")
+	}
+
+	if n, _ := cmd.Flags().GetInt("step"); n != 0 {
+		step := run.Step(n)
+		if step == nil {
+			return fmt.Errorf("%s has steps 1-%d", run.ID, len(run.Steps))
+		}
+		printRunHeader(run)
+		printStep(run, step, false, false)
+		return nil
+	}
+	return stepThrough(run)
+}
+
+func listRuns(dir string, limit int) error {
+	runs, err := journal.List(dir, limit)
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 {
+		fmt.Printf("%s 中没有运行日志 (需启用 log.journal.enabled)\n", dir)
+		return nil
+	}
+	for _, r := range runs {
+		fmt.Printf("%-26s  %s  %-18s  %2d steps  %-10s  %s\n",
+			r.ID, r.StartedAt.Format("01-02 15:04"), r.Source, len(r.Steps), r.Status(),
+			oneLine(r.UserMessage, 50))
+	}
+	return nil
+}
+
+// stepThrough 交互式逐步查看
+func stepThrough(run *journal.Run) error {
+	printRunHeader(run)
+	in := bufio.NewScanner(os.Stdin)
+	i := 0
+	printStep(run, run.Steps[i], false, false)
+	for {
+		fmt.Printf("\n\033[90m[%d/%d] 回车/n 下一步 · p 上一步 · 数字跳转 · prompt · full · q>\033[0m ",
+			i+1, len(run.Steps))
+		if !in.Scan() {
+			fmt.Println()
+			return in.Err()
+		}
+		switch input := strings.TrimSpace(in.Text()); input {
+		case "", "n":
+			if i == len(run.Steps)-1 {
+				printRunEnd(run)
+				continue
+			}
+			i++
+			printStep(run, run.Steps[i], false, false)
+		case "p":
+			if i > 0 {
+				i--
+			}
+			printStep(run, run.Steps[i], false, false)
+		case "prompt":
+			printStep(run, run.Steps[i], true, false)
+		case "full":
+			printStep(run, run.Steps[i], false, true)
+		case "q", "quit", "exit":
+			return nil
+		default:
+			n, err := strconv.Atoi(input)
+			if err != nil {
+				fmt.Printf("unknown command %q\n", input)
+				continue
+			}
+			step := run.Step(n)
+			if step == nil {
+				fmt.Printf("no step %d (1-%d)\n", n, len(run.Steps))
+				continue
+			}
+			i = n - 1
+			printStep(run, step, false, false)
+		}
+	}
+}
+
+func printRunHeader(run *journal.Run) {
+	fmt.Printf("◇ %s · %s · %s · %s · %d steps\n", run.ID, run.StartedAt.Format("2006-01-02 15:04:05"),
+		run.Source, run.Model, len(run.Steps))
+	fmt.Printf("  \033[1m%s\033[0m\n", oneLine(run.UserMessage, 200))
+}
+
+func printRunEnd(run *journal.Run) {
+	if run.End == nil {
+		fmt.Println("\n── 运行未结束 (仍在运行或进程已退出) ──")
+		return
+	}
+	fmt.Printf("\n── 结束: %s · %d steps · %d tokens ──\n", run.Status(), run.End.Steps, run.End.Tokens)
+	if run.End.Final != "" {
+		fmt.Println(run.End.Final)
+	}
+}
+
+// printStep 打印一步: 新增的消息 (all = 完整提示词), 模型响应与工具结果
+func printStep(run *journal.Run, step *journal.Step, all, full bool) {
+	fmt.Printf("\n\033[1m── step %d/%d\033[0m \033[90m· %s · %s · %d messages · %d tools\033[0m\n",
+		step.N, len(run.Steps), step.Time.Format("15:04:05"), step.Model, len(step.Messages), len(step.Tools))
+
+	from := step.New
+	if all {
+		from = 0
+	} else if from > 0 {
+		fmt.Printf("\033[90m   (%d earlier messages, \"prompt\" shows all)\033[0m\n", from)
+	}
+	for i, m := range step.Messages[from:] {
+		printMessage(from+i, m, all || full)
+	}
+
+	fmt.Println("\n\033[1m▸ response\033[0m")
+	switch {
+	case step.Error != "":
+		fmt.Printf("  \033[91merror:\033[0m %s\n", step.Error)
+	case step.Response == nil:
+		fmt.Println("  \033[90m(none)\033[0m")
+	default:
+		if step.Response.Content != "" {
+			fmt.Println(indent(clip(step.Response.Content, full)))
+		}
+		for _, tc := range step.Response.ToolCalls {
+			fmt.Printf("  → %s %s\n", tc.Name, clip(toJSON(tc.Arguments), full))
+		}
+	}
+	for _, r := range step.Results {
+		mark := "\033[92m✓\033[0m"
+		if !r.Success {
+			mark = "\033[91m✗\033[0m"
+		}
+		name := ""
+		if r.ToolCall != nil {
+			name = r.ToolCall.Name
+		}
+		fmt.Printf("\n%s %s \033[90m%s\033[0m\n", mark, name, r.Duration.Round(time.Millisecond))
+		fmt.Println(indent(clip(r.Output, full)))
+	}
+}
+
+func printMessage(i int, m service.LLMMessage, full bool) {
+	content := m.Content
+	for _, p := range m.Parts {
+		if p.Type == "text" {
+			content += p.Text
+		} else {
+			content += fmt.Sprintf("[%s %s]", p.Type, p.MediaURL)
+		}
+	}
+	label := m.Role
+	if m.Name != "" {
+		label += " " + m.Name
+	}
+	fmt.Printf("\033[36m#%d %s\033[0m\n", i, label)
+	if content != "" {
+		fmt.Println(indent(clip(content, full)))
+	}
+	for _, tc := range m.ToolCalls {
+		fmt.Printf("  → %s %s\n", tc.Name, clip(toJSON(tc.Arguments), full))
+	}
+}
+
+// replayLive 以第 n 步的请求为起点重新执行
+func replayLive(cmd *cobra.Command, run *journal.Run, n int) error {
+	step := run.Step(n)
+	if step == nil {
+		return fmt.Errorf("%s has steps 1-%d", run.ID, len(run.Steps))
+	}
+	msgs := append([]service.LLMMessage(nil), step.Messages...)
+
+	if path, _ := cmd.Flags().GetString("system-file"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		system := service.LLMMessage{Role: "system", Content: string(data)}
+		if len(msgs) > 0 && msgs[0].Role == "system" {
+			msgs[0] = system
+		} else {
+			msgs = append([]service.LLMMessage{system}, msgs...)
+		}
+	}
+	if note, _ := cmd.Flags().GetString("note"); note != "" {
+		// 接在用户消息后面而不是另起一条, 避免连续两条 user 消息
+		if last := len(msgs) - 1; last >= 0 && msgs[last].Role == "user" && len(msgs[last].Parts) == 0 {
+			msgs[last].Content += "\n\n" + note
+		} else {
+			msgs = append(msgs, service.LLMMessage{Role: "user", Content: note})
+		}
+	}
+	if edit, _ := cmd.Flags().GetBool("edit"); edit {
+		edited, err := editMessages(msgs)
+		if err != nil {
+			return err
+		}
+		msgs = edited
+	}
+	if len(msgs) == 0 {
+		return fmt.Errorf("no messages to resume from")
+	}
+
+	log, err := logger.NewLogger(logger.Config{
+		Level:      "error",
+		Format:     "console",
+		OutputPath: "/dev/null",
+	})
+	if err != nil {
+		return fmt.Errorf("logger init: %w", err)
+	}
+	defer log.Sync()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	app, err := application.NewAppCLI(cfg, log)
+	if err != nil {
+		return fmt.Errorf("初始化失败: %w", err)
+	}
+
+	model, _ := cmd.Flags().GetString("model")
+	if model == "" {
+		model = step.Model
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx = service.WithResumeMessages(ctx, msgs)
+	ctx = service.WithTranscriptSource(ctx, "replay:"+run.ID)
+
+	fmt.Printf("◇ 从 %s 第 %d 步重新执行 · %s · %d messages\n\n", run.ID, n, model, len(msgs))
+	result, eventCh := app.AgentLoop().Run(ctx, "", run.UserMessage, nil, model)
+	for ev := range eventCh {
+		switch ev.Type {
+		case entity.EventTextDelta:
+			fmt.Print(ev.Content)
+		case entity.EventToolCall:
+			if ev.ToolCall != nil {
+				fmt.Printf("\n\033[90m→ %s %s\033[0m\n", ev.ToolCall.Name, oneLine(toJSON(ev.ToolCall.Arguments), 120))
+			}
+		case entity.EventToolResult:
+			if ev.ToolCall != nil {
+				mark := "\033[92m✓\033[0m"
+				if !ev.ToolCall.Success {
+					mark = "\033[91m✗\033[0m"
+				}
+				fmt.Printf("%s %s \033[90m%s\033[0m\n", mark, ev.ToolCall.Name, oneLine(ev.ToolCall.Output, 120))
+			}
+		case entity.EventError:
+			fmt.Printf("\n\033[91merror:\033[0m %s\n", ev.Error)
+		}
+	}
+	fmt.Printf("\n\n\033[90m%d steps · %d tokens\033[0m\n", result.TotalSteps, result.TotalTokens)
+	if ctx.Err() != nil {
+		return fmt.Errorf("已中断")
+	}
+	return nil
+}
+
+// editMessages 在 $EDITOR 中编辑消息 JSON
+func editMessages(msgs []service.LLMMessage) ([]service.LLMMessage, error) {
+	f, err := os.CreateTemp("", "ngoclaw-replay-*.json")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(msgs); err != nil {
+		f.Close()
+		return nil, err
+	}
+	f.Close()
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	c := exec.Command("sh", "-c", editor+` "$1"`, "editor", f.Name())
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		return nil, fmt.Errorf("editor: %w", err)
+	}
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return nil, err
+	}
+	var edited []service.LLMMessage
+	if err := json.Unmarshal(data, &edited); err != nil {
+		return nil, fmt.Errorf("edited messages: %w", err)
+	}
+	return edited, nil
+}
+
+func clip(s string, full bool) string {
+	if full {
+		return s
+	}
+	if r := []rune(s); len(r) > replayPreview {
+		return string(r[:replayPreview]) + fmt.Sprintf(" … (+%d chars)", len(r)-replayPreview)
+	}
+	return s
+}
+
+func oneLine(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
+
+func indent(s string) string {
+	return "  " + strings.ReplaceAll(strings.TrimRight(s, "\n"), "\n", "\n  ")
+}
+
+func toJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/bundle"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+)
+
+// ─── Bundle Sync ───
+
+func newSyncCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "从 git 仓库同步团队共享的 soul.md / prompts / skills",
+		Long: "拉取 sync.repo 配置的 git 仓库, 将固定版本 (--ref 或 sync.ref) 中的 soul.md、" +
+			"prompts/、skills/ 复制到 ~/.ngoclaw. 本地修改过的文件不会被覆盖或删除 (--force 除外). " +
+			"--ref 指定的版本会被记住, 之后的 sync 保持在该版本.",
+		Args: cobra.NoArgs,
+		RunE: runSync,
+	}
+	cmd.Flags().String("ref", "", "同步的版本 (tag / commit / 分支, 覆盖 sync.ref)")
+	cmd.Flags().String("repo", "", "仓库地址 (覆盖 sync.repo)")
+	cmd.Flags().BoolP("force", "f", false, "覆盖本地修改过的文件")
+	cmd.Flags().BoolP("dry-run", "n", false, "只显示将要修改的文件")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "显示当前同步版本和本地修改",
+		Args:  cobra.NoArgs,
+		RunE:  runSyncStatus,
+	})
+	return cmd
+}
+
+// syncConfig maps the sync section of the config to the bundle syncer.
+func syncConfig(cmd *cobra.Command) (bundle.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return bundle.Config{}, fmt.Errorf("config: %w", err)
+	}
+	bc := bundle.Config{
+		Repo:  cfg.Sync.Repo,
+		Ref:   cfg.Sync.Ref,
+		Paths: cfg.Sync.Paths,
+		Home:  config.HomeDir(),
+	}
+	if r, _ := cmd.Flags().GetString("repo"); r != "" {
+		bc.Repo = r
+	}
+	return bc, nil
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	bc, err := syncConfig(cmd)
+	if err != nil {
+		return err
+	}
+	if bc.Repo == "" {
+		return fmt.Errorf("%w: 在 ~/.ngoclaw/config.yaml 中设置 sync.repo, 或使用 --repo", bundle.ErrNoRepo)
+	}
+	opts := bundle.Options{}
+	opts.Ref, _ = cmd.Flags().GetString("ref")
+	opts.Force, _ = cmd.Flags().GetBool("force")
+	opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Print("\033[90m⏳ 拉取 " + bc.Repo + "...\033[0m")
+	res, err := bundle.Sync(ctx, bc, opts)
+	fmt.Print("\r\033[2K")
+	if err != nil {
+		return err
+	}
+
+	version := shortCommit(res.Commit)
+	if res.Ref != "" {
+		version = res.Ref + " @ " + version
+	}
+	switch {
+	case res.PrevCommit == "":
+		fmt.Printf("◇ %s → %s\n", res.Repo, version)
+	case res.PrevCommit != res.Commit:
+		fmt.Printf("◇ %s: %s → %s\n", res.Repo, shortCommit(res.PrevCommit), version)
+	default:
+		fmt.Printf("◇ %s: %s\n", res.Repo, version)
+	}
+
+	printPaths("\033[32m+\033[0m", res.Added)
+	printPaths("\033[33m~\033[0m", res.Updated)
+	printPaths("\033[31m-\033[0m", res.Removed)
+	if len(res.Conflicts) > 0 && !opts.Force {
+		fmt.Println("\n⚠ 以下文件有本地修改, 已保留 (--force 覆盖):")
+		printPaths("  !", res.Conflicts)
+	}
+
+	switch {
+	case opts.DryRun:
+		fmt.Println("\n(dry run, 未写入任何文件)")
+	case !res.Changed():
+		fmt.Printf("✓ 已是最新 (%d 个文件)\n", res.Unchanged)
+	default:
+		fmt.Printf("\n✓ 新增 %d, 更新 %d, 删除 %d. 运行中的 serve 需 /restart 生效\n",
+			len(res.Added), len(res.Updated), len(res.Removed))
+	}
+	return nil
+}
+
+func runSyncStatus(cmd *cobra.Command, args []string) error {
+	bc, err := syncConfig(cmd)
+	if err != nil {
+		return err
+	}
+	m, err := bundle.LoadManifest(bc)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		fmt.Println("尚未同步. 设置 sync.repo 后运行 ngoclaw sync")
+		return nil
+	}
+
+	pin := m.Ref
+	if pin == "" {
+		pin = "(默认分支)"
+	}
+	fmt.Printf("仓库:   %s\n版本:   %s @ %s\n同步于: %s\n文件:   %d\n",
+		m.Repo, pin, shortCommit(m.Commit), m.SyncedAt, len(m.Files))
+	if bc.Repo != "" && bc.Repo != m.Repo {
+		fmt.Printf("⚠ sync.repo 已改为 %s, 下次 sync 生效\n", bc.Repo)
+	}
+
+	modified, deleted, err := bundle.LocalChanges(bc)
+	if err != nil {
+		return err
+	}
+	if len(modified)+len(deleted) == 0 {
+		fmt.Println("\n✓ 无本地修改")
+		return nil
+	}
+	fmt.Println("\n本地修改 (sync 时保留, --force 覆盖):")
+	printPaths("  M", modified)
+	printPaths("  D", deleted)
+	return nil
+}
+
+func printPaths(mark string, paths []string) {
+	for _, p := range paths {
+		fmt.Printf("%s %s\n", mark, p)
+	}
+}
+
+func shortCommit(c string) string {
+	if len(c) > 12 {
+		return c[:12]
+	}
+	return c
+}
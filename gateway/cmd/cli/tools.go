@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/application"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/logger"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/interfaces/cli"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
+)
+
+// ─── Tool Catalog Export ───
+
+func newToolsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tools",
+		Short: "工具目录 (内置 + MCP + 技能)",
+	}
+
+	export := &cobra.Command{
+		Use:   "export",
+		Short: "导出工具定义 (名称 / 描述 / 参数 schema / kind / 风险等级 / 审批要求)",
+		Long: "初始化工具层后导出全部已注册工具, 与网关 GET /v1/tools 内容一致. " +
+			"--format json 输出 {version, tools}; --format openapi 输出 OpenAPI 3.1 文档, " +
+			"每个工具一个 POST /tools/{name} 操作, kind / risk / approval 放在 x-ngoclaw-* 扩展字段. " +
+			"version 是内容哈希, 可用于检测工具集变化.",
+		Args: cobra.NoArgs,
+		RunE: runToolsExport,
+	}
+	export.Flags().StringP("output", "o", "", "输出文件 (默认标准输出)")
+	export.Flags().StringP("format", "f", "json", "输出格式: json | openapi")
+
+	list := &cobra.Command{
+		Use:   "list [类型]",
+		Short: "按类型列出工具 (read / search / edit / execute / fetch / mcp ...), 标出需要审批的和已关闭的",
+		Long: "初始化工具层后按类型分组列出全部已注册工具. 🔒 表示当前审批模式下每次调用都需审批, " +
+			"❔ 表示视参数而定; 配置中关闭的工具单独列出. 指定类型时显示该组工具的说明.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: runToolsList,
+	}
+
+	cmd.AddCommand(export, list)
+	return cmd
+}
+
+func runToolsList(cmd *cobra.Command, args []string) error {
+	app, cfg, err := newToolsApp()
+	if err != nil {
+		return err
+	}
+	category := ""
+	if len(args) > 0 {
+		category = strings.ToLower(args[0])
+	}
+	locale := i18n.FromEnv()
+	if l, ok := i18n.Parse(cfg.Locale); ok {
+		locale = l
+	}
+	cli.PrintToolCatalog(os.Stdout, app.ToolCatalog(), category, locale)
+	return nil
+}
+
+// newToolsApp 以静默日志初始化工具层
+func newToolsApp() (*application.App, *config.Config, error) {
+	log, err := logger.NewLogger(logger.Config{
+		Level:      "error",
+		Format:     "console",
+		OutputPath: "/dev/null",
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("logger init: %w", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("config: %w", err)
+	}
+	app, err := application.NewAppCLI(cfg, log)
+	if err != nil {
+		return nil, nil, fmt.Errorf("初始化失败: %w", err)
+	}
+	return app, cfg, nil
+}
+
+func runToolsExport(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	if format != "json" && format != "openapi" {
+		return fmt.Errorf("unknown format %q (json | openapi)", format)
+	}
+
+	app, _, err := newToolsApp()
+	if err != nil {
+		return err
+	}
+
+	catalog := app.ToolCatalog()
+	var doc interface{} = catalog
+	if format == "openapi" {
+		doc = catalog.OpenAPI()
+	}
+
+	var out io.Writer = os.Stdout
+	if path, _ := cmd.Flags().GetString("output"); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "✓ %d 个工具 (version %s)\n", len(catalog.Tools), catalog.Version)
+	return nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/github"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tunnel"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/interfaces/telegram"
+)
+
+// ─── Dev Tunnel ───
+
+func newTunnelCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tunnel",
+		Short: "开发用: 通过 SSH 反向隧道暴露本地网关, 并把 Telegram / GitHub webhook 指向隧道",
+		Long: "建立到 tunnel.provider (localhost.run / pinggy / 自建 ssh) 的反向隧道, 把公网地址转发到本地网关端口. " +
+			"telegram.mode 为 webhook 时向 Telegram 注册 <隧道>/webhooks/telegram; github.enabled 且设置了 github.token 时" +
+			"为 github.repos 中的仓库创建指向 <隧道>/webhooks/github 的 webhook. " +
+			"退出 (Ctrl+C) 时删除创建的 webhook, Telegram 恢复为 telegram.webhook_url (未设置则删除). " +
+			"网关需另外用 ngoclaw serve 启动.",
+		Args: cobra.NoArgs,
+		RunE: runTunnel,
+	}
+	cmd.Flags().String("provider", "", "隧道服务 (覆盖 tunnel.provider)")
+	cmd.Flags().Int("port", 0, "本地网关端口 (默认 gateway.port)")
+	cmd.Flags().Bool("no-telegram", false, "不修改 Telegram webhook")
+	cmd.Flags().Bool("no-github", false, "不创建 GitHub webhook")
+	return cmd
+}
+
+func runTunnel(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	tc := tunnel.Config{
+		Provider:   cfg.Tunnel.Provider,
+		Server:     cfg.Tunnel.Server,
+		User:       cfg.Tunnel.User,
+		KeyFile:    cfg.Tunnel.KeyFile,
+		RemotePort: cfg.Tunnel.RemotePort,
+		PublicURL:  cfg.Tunnel.PublicURL,
+		KnownHosts: cfg.Tunnel.KnownHosts,
+	}
+	if p, _ := cmd.Flags().GetString("provider"); p != "" {
+		tc.Provider = p
+	}
+	port := cfg.Gateway.Port
+	if p, _ := cmd.Flags().GetInt("port"); p > 0 {
+		port = p
+	}
+	tc.Local = fmt.Sprintf("127.0.0.1:%d", port)
+	if tc.Provider == "" {
+		tc.Provider = tunnel.DefaultProvider
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Print("\033[90m⏳ 连接 " + tc.Provider + "...\033[0m")
+	t, err := tunnel.Open(ctx, tc)
+	fmt.Print("\r\033[2K")
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+	fmt.Printf("◇ %s → %s\n", t.URL, tc.Local)
+
+	served := make(chan error, 1)
+	go func() { served <- t.Serve() }()
+
+	var restore []func(context.Context)
+	if skip, _ := cmd.Flags().GetBool("no-telegram"); !skip {
+		if undo := tunnelTelegram(cfg.Telegram, t.URL); undo != nil {
+			restore = append(restore, undo)
+		}
+	}
+	if skip, _ := cmd.Flags().GetBool("no-github"); !skip {
+		restore = append(restore, tunnelGitHub(ctx, cfg.GitHub, t.URL)...)
+	}
+
+	fmt.Println("\n隧道已就绪, Ctrl+C 退出并恢复 webhook 设置")
+	select {
+	case <-ctx.Done():
+	case err = <-served:
+		fmt.Printf("\n✗ %v\n", err)
+	}
+
+	cleanup, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	for i := len(restore) - 1; i >= 0; i-- {
+		restore[i](cleanup)
+	}
+	return err
+}
+
+// tunnelTelegram points the bot's webhook at the tunnel and returns how to
+// undo it, or nil when nothing was changed.
+func tunnelTelegram(tg config.TelegramConfig, base string) func(context.Context) {
+	if tg.BotToken == "" {
+		return nil
+	}
+	if tg.Mode != "webhook" {
+		// 网关仍在轮询时注册 webhook 会使 getUpdates 失败
+		fmt.Println("- Telegram: telegram.mode 不是 webhook, 跳过 (设置 mode: webhook 后重启 serve)")
+		return nil
+	}
+	if tg.WebhookSecret == "" {
+		// 网关在 webhook 模式下同样拒绝启动; 无 secret 的公网地址任何人都能推送伪造更新
+		fmt.Println("✗ Telegram: 未设置 telegram.webhook_secret, 跳过")
+		return nil
+	}
+	url := base + telegram.WebhookPath
+	if err := telegram.RegisterWebhook(tg.BotToken, url, tg.WebhookSecret); err != nil {
+		fmt.Printf("✗ Telegram: %v\n", err)
+		return nil
+	}
+	fmt.Printf("✓ Telegram webhook → %s\n", url)
+
+	return func(context.Context) {
+		var err error
+		if tg.WebhookURL != "" {
+			err = telegram.RegisterWebhook(tg.BotToken, tg.WebhookURL, tg.WebhookSecret)
+		} else {
+			err = telegram.RemoveWebhook(tg.BotToken)
+		}
+		switch {
+		case err != nil:
+			fmt.Printf("✗ Telegram: 恢复 webhook 失败: %v\n", err)
+		case tg.WebhookURL != "":
+			fmt.Printf("✓ Telegram webhook 已恢复为 %s\n", tg.WebhookURL)
+		default:
+			fmt.Println("✓ Telegram webhook 已删除")
+		}
+	}
+}
+
+// tunnelGitHub creates a webhook to the tunnel on each configured repository
+// and returns the deletions to run on exit. A hook that already points at the
+// tunnel URL (fixed public_url) is reused and kept.
+func tunnelGitHub(ctx context.Context, gh config.GitHubConfig, base string) []func(context.Context) {
+	if !gh.Enabled {
+		return nil
+	}
+	if gh.Token == "" {
+		fmt.Println("- GitHub: 创建 webhook 需要 github.token (对仓库有 admin 权限的 PAT), 跳过")
+		return nil
+	}
+	client, err := github.NewClient(github.ClientConfig{APIURL: gh.APIURL, Token: gh.Token})
+	if err != nil {
+		fmt.Printf("✗ GitHub: %v\n", err)
+		return nil
+	}
+
+	url := base + github.WebhookPath
+	var undo []func(context.Context)
+	for _, r := range gh.Repos {
+		repo := r.Name
+		if strings.Contains(repo, "*") {
+			fmt.Printf("- GitHub %s: 通配符仓库, 跳过 (请在 App / 组织设置中手动配置)\n", repo)
+			continue
+		}
+		hooks, err := client.ListHooks(ctx, repo)
+		if err != nil {
+			fmt.Printf("✗ GitHub %s: %v\n", repo, err)
+			continue
+		}
+		existing := false
+		for _, h := range hooks {
+			if h.Config.URL == url {
+				existing = true
+				break
+			}
+		}
+		if existing {
+			fmt.Printf("✓ GitHub %s: webhook 已指向 %s\n", repo, url)
+			continue
+		}
+		id, err := client.CreateHook(ctx, repo, url, gh.WebhookSecret)
+		if err != nil {
+			fmt.Printf("✗ GitHub %s: %v\n", repo, err)
+			continue
+		}
+		fmt.Printf("✓ GitHub %s: webhook → %s\n", repo, url)
+		undo = append(undo, func(ctx context.Context) {
+			if err := client.DeleteHook(ctx, repo, id); err != nil {
+				fmt.Printf("✗ GitHub %s: 删除 webhook %d 失败: %v\n", repo, id, err)
+				return
+			}
+			fmt.Printf("✓ GitHub %s: webhook 已删除\n", repo)
+		})
+	}
+	if len(undo) > 0 {
+		fmt.Println("  ⚠ 仓库原有的 webhook 仍然有效, 若生产网关也在运行, 提及会被处理两次")
+	}
+	return undo
+}
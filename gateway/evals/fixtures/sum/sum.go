@@ -0,0 +1,10 @@
+package sum
+
+// Sum returns the sum of all numbers in xs.
+func Sum(xs []int) int {
+	total := 0
+	for i := 1; i < len(xs); i++ {
+		total += xs[i]
+	}
+	return total
+}
@@ -0,0 +1,18 @@
+package sum
+
+import "testing"
+
+func TestSum(t *testing.T) {
+	for _, tc := range []struct {
+		xs   []int
+		want int
+	}{
+		{xs: nil, want: 0},
+		{xs: []int{5}, want: 5},
+		{xs: []int{1, 2, 3, 4}, want: 10},
+	} {
+		if got := Sum(tc.xs); got != tc.want {
+			t.Errorf("Sum(%v) = %d, want %d", tc.xs, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/approval"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/eventbus"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/janitor"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/llm"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/interfaces/http/handlers"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/interfaces/telegram"
+)
+
+// Admin dashboard (/admin): an operator view fed by the event bus.
+
+const (
+	adminMaxErrors     = 50  // recent errors kept
+	adminSubscriberBuf = 128 // live events queued per dashboard before dropping
+	adminTextLimit     = 200 // chars of messages / args / output in summaries
+)
+
+// adminRun is an active run as shown on the dashboard.
+type adminRun struct {
+	TraceID   string    `json:"trace_id"`
+	Source    string    `json:"source"`
+	Model     string    `json:"model"`
+	Message   string    `json:"message"`
+	StartedAt time.Time `json:"started_at"`
+	Step      int       `json:"step"`
+	Tool      string    `json:"tool,omitempty"` // tool currently running
+	ToolCalls int       `json:"tool_calls"`
+	Tokens    int       `json:"tokens"`
+}
+
+// adminError is a failed run, failed tool call or denied approval.
+type adminError struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"` // event topic
+	Source  string    `json:"source"`
+	TraceID string    `json:"trace_id"`
+	Text    string    `json:"text"`
+}
+
+// adminUsage aggregates runs per source ("telegram:<chat>", "job:<id>", ...)
+// since the gateway started.
+type adminUsage struct {
+	Source     string    `json:"source"`
+	Runs       int       `json:"runs"`
+	Tokens     int       `json:"tokens"`
+	ToolCalls  int       `json:"tool_calls"`
+	Errors     int       `json:"errors"`
+	LastActive time.Time `json:"last_active"`
+}
+
+// adminEvent is one line of the live event stream.
+type adminEvent struct {
+	Time    time.Time `json:"time"`
+	Topic   string    `json:"topic"`
+	TraceID string    `json:"trace_id"`
+	Source  string    `json:"source"`
+	Summary string    `json:"summary"`
+}
+
+// adminOverview is the GET /admin/api/overview payload.
+type adminOverview struct {
+	Now        time.Time            `json:"now"`
+	StartedAt  time.Time            `json:"started_at"`
+	ActiveRuns []adminRun           `json:"active_runs"`
+	Errors     []adminError         `json:"errors"`
+	Usage      []adminUsage         `json:"usage"`
+	Providers  []llm.ProviderStatus `json:"providers"`
+	Models     []entity.ModelStats  `json:"models"`
+	Approvals  int                  `json:"approvals"`
+	Janitor    *janitor.Stats       `json:"janitor,omitempty"`
+}
+
+// adminMonitor tracks runs, errors and usage from the event bus and fans
+// events out to connected dashboards.
+type adminMonitor struct {
+	mu     sync.Mutex
+	runs   map[string]*adminRun // by trace ID
+	errors []adminError         // oldest first
+	usage  map[string]*adminUsage
+	subs   map[chan interface{}]struct{}
+}
+
+func newAdminMonitor(bus eventbus.Bus) *adminMonitor {
+	m := &adminMonitor{
+		runs:  make(map[string]*adminRun),
+		usage: make(map[string]*adminUsage),
+		subs:  make(map[chan interface{}]struct{}),
+	}
+	bus.Subscribe("*", m.handle)
+	return m
+}
+
+func (m *adminMonitor) handle(_ context.Context, ev eventbus.Event) {
+	now := ev.Timestamp()
+	view := adminEvent{Time: now, Topic: ev.Type()}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch p := ev.Payload().(type) {
+	case RunEvent:
+		view.TraceID, view.Source = p.TraceID, sourceLabel(p.EventMeta)
+		u := m.usageFor(view.Source, now)
+		switch ev.Type() {
+		case TopicRunStarted:
+			u.Runs++
+			m.runs[p.TraceID] = &adminRun{
+				TraceID:   p.TraceID,
+				Source:    view.Source,
+				Model:     p.Model,
+				Message:   clip(p.Message),
+				StartedAt: now,
+			}
+			view.Summary = fmt.Sprintf("%s · %s", p.Model, clip(p.Message))
+		case TopicRunCompleted:
+			delete(m.runs, p.TraceID)
+			if p.Result != nil {
+				view.Summary = fmt.Sprintf("%d steps · %d tokens", p.Result.TotalSteps, p.Result.TotalTokens)
+			}
+		case TopicRunAborted:
+			delete(m.runs, p.TraceID)
+			view.Summary = string(p.Reason)
+		case TopicRunFailed:
+			delete(m.runs, p.TraceID)
+			u.Errors++
+			view.Summary = clip(p.Error)
+			m.addError(view)
+		}
+
+	case ToolEvent:
+		view.TraceID, view.Source = p.TraceID, sourceLabel(p.EventMeta)
+		run := m.runs[p.TraceID]
+		switch ev.Type() {
+		case TopicToolCall:
+			m.usageFor(view.Source, now).ToolCalls++
+			if run != nil {
+				run.Tool = p.Tool
+				run.ToolCalls++
+			}
+			args, _ := json.Marshal(p.Args)
+			view.Summary = fmt.Sprintf("%s %s", p.Tool, clip(string(args)))
+		case TopicToolResult:
+			if run != nil {
+				run.Tool = ""
+			}
+			status := "✓"
+			if !p.Success {
+				status = "✗"
+			}
+			view.Summary = fmt.Sprintf("%s %s %s", status, p.Tool, clip(firstLine(p.Output)))
+			if !p.Success {
+				m.usageFor(view.Source, now).Errors++
+				m.addError(view)
+			}
+		}
+
+	case LLMEvent:
+		view.TraceID, view.Source = p.TraceID, sourceLabel(p.EventMeta)
+		run := m.runs[p.TraceID]
+		switch ev.Type() {
+		case TopicLLMRequest:
+			if run != nil {
+				run.Step = p.Step
+			}
+			view.Summary = fmt.Sprintf("step %d · %s · %d messages", p.Step, p.Model, p.Messages)
+		case TopicLLMResponse:
+			m.usageFor(view.Source, now).Tokens += p.TokensUsed
+			if run != nil {
+				run.Tokens += p.TokensUsed
+			}
+			view.Summary = fmt.Sprintf("step %d · %d tokens · %d tool calls", p.Step, p.TokensUsed, p.ToolCalls)
+		}
+
+	case SecurityEvent:
+		view.TraceID, view.Source = p.TraceID, sourceLabel(p.EventMeta)
+		view.Summary = p.Tool
+		if p.Risk != "" {
+			view.Summary += " · risk " + p.Risk
+		}
+		if p.Guard != "" {
+			view.Summary += " · guard " + p.Guard
+		}
+		if p.Error != "" {
+			view.Summary += " · " + clip(p.Error)
+		}
+		if ev.Type() == TopicSecurityDenied || ev.Type() == TopicSecurityBlocked {
+			m.addError(view)
+		}
+
+	default:
+		return // not an application event
+	}
+
+	for ch := range m.subs {
+		select {
+		case ch <- view:
+		default: // slow dashboard: drop rather than stall the bus
+		}
+	}
+}
+
+// usageFor returns the usage row of source; callers hold m.mu.
+func (m *adminMonitor) usageFor(source string, now time.Time) *adminUsage {
+	u := m.usage[source]
+	if u == nil {
+		u = &adminUsage{Source: source}
+		m.usage[source] = u
+	}
+	u.LastActive = now
+	return u
+}
+
+// addError records a recent error; callers hold m.mu.
+func (m *adminMonitor) addError(ev adminEvent) {
+	m.errors = append(m.errors, adminError{
+		Time:    ev.Time,
+		Kind:    ev.Topic,
+		Source:  ev.Source,
+		TraceID: ev.TraceID,
+		Text:    ev.Summary,
+	})
+	if len(m.errors) > adminMaxErrors {
+		m.errors = m.errors[len(m.errors)-adminMaxErrors:]
+	}
+}
+
+// snapshot returns active runs (oldest first), errors (newest first) and
+// usage (most tokens first).
+func (m *adminMonitor) snapshot() ([]adminRun, []adminError, []adminUsage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	runs := make([]adminRun, 0, len(m.runs))
+	for _, r := range m.runs {
+		runs = append(runs, *r)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].StartedAt.Before(runs[j].StartedAt) })
+
+	errs := make([]adminError, len(m.errors))
+	for i, e := range m.errors {
+		errs[len(errs)-1-i] = e
+	}
+
+	usage := make([]adminUsage, 0, len(m.usage))
+	for _, u := range m.usage {
+		usage = append(usage, *u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Tokens != usage[j].Tokens {
+			return usage[i].Tokens > usage[j].Tokens
+		}
+		return usage[i].Source < usage[j].Source
+	})
+	return runs, errs, usage
+}
+
+func (m *adminMonitor) subscribe() (<-chan interface{}, func()) {
+	ch := make(chan interface{}, adminSubscriberBuf)
+	m.mu.Lock()
+	m.subs[ch] = struct{}{}
+	m.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			m.mu.Lock()
+			delete(m.subs, ch)
+			m.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// sourceLabel names the origin of a run for grouping.
+func sourceLabel(meta EventMeta) string {
+	switch {
+	case meta.Source != "":
+		return meta.Source
+	case meta.ChatID != 0:
+		return fmt.Sprintf("telegram:%d", meta.ChatID)
+	default:
+		return "api"
+	}
+}
+
+func clip(s string) string {
+	s = strings.TrimSpace(s)
+	if r := []rune(s); len(r) > adminTextLimit {
+		return string(r[:adminTextLimit]) + "…"
+	}
+	return s
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// adminSource implements handlers.AdminSource on top of the app.
+type adminSource struct {
+	app       *App
+	monitor   *adminMonitor
+	startedAt time.Time
+}
+
+var _ handlers.AdminSource = (*adminSource)(nil)
+
+func (s *adminSource) Overview(ctx context.Context) interface{} {
+	runs, errs, usage := s.monitor.snapshot()
+	ov := adminOverview{
+		Now:        time.Now(),
+		StartedAt:  s.startedAt,
+		ActiveRuns: runs,
+		Errors:     errs,
+		Usage:      usage,
+		Approvals:  len(s.Approvals()),
+	}
+	if s.app.llmRouter != nil {
+		ov.Providers = s.app.llmRouter.ListProviders(ctx)
+	}
+	if s.app.modelStats != nil {
+		ov.Models = s.app.modelStats.Snapshot()
+	}
+	if s.app.janitor != nil {
+		stats := s.app.janitor.Stats()
+		ov.Janitor = &stats
+	}
+	return ov
+}
+
+func (s *adminSource) Config() interface{} {
+	return config.Redacted(s.app.config)
+}
+
+func (s *adminSource) Approvals() []handlers.AdminApproval {
+	var list []handlers.AdminApproval
+	if tg := s.app.telegramAdapter; tg != nil {
+		for _, r := range tg.PendingApprovals() {
+			list = append(list, handlers.AdminApproval{
+				ID:        r.ID,
+				Channel:   "telegram",
+				ChatID:    r.ChatID,
+				Tool:      r.ToolName,
+				Args:      r.ToolArgs,
+				CreatedAt: r.CreatedAt,
+			})
+		}
+	}
+	if q := s.app.approvalQueue; q != nil {
+		for _, p := range q.List() {
+			args, _ := json.Marshal(p.Args)
+			list = append(list, handlers.AdminApproval{
+				ID:        p.ID,
+				Channel:   "http",
+				Tool:      p.ToolName,
+				Args:      string(args),
+				CreatedAt: p.CreatedAt,
+			})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list
+}
+
+func (s *adminSource) ResolveApproval(ctx context.Context, id string, approved bool) error {
+	if tg := s.app.telegramAdapter; tg != nil {
+		err := tg.ResolveApproval(ctx, id, approved)
+		if !errors.Is(err, telegram.ErrApprovalNotFound) {
+			return err
+		}
+	}
+	if q := s.app.approvalQueue; q != nil {
+		return q.Resolve(id, approved)
+	}
+	return approval.ErrNotFound
+}
+
+func (s *adminSource) Subscribe() (<-chan interface{}, func()) {
+	return s.monitor.subscribe()
+}
+
+// initAdmin exposes the /admin dashboard when gateway.admin_token or
+// gateway.oidc is set.
+func (app *App) initAdmin() {
+	token := app.config.Gateway.AdminToken
+	if (token == "" && app.config.Gateway.OIDC.Issuer == "") || app.events == nil {
+		return
+	}
+	app.httpServer.SetAdmin(token, &adminSource{
+		app:       app,
+		monitor:   newAdminMonitor(app.events),
+		startedAt: time.Now(),
+	})
+	app.logger.Info("Admin dashboard enabled at /admin")
+}
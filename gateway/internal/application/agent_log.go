@@ -0,0 +1,144 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/agentlog"
+	toolpkg "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tool"
+	"go.uber.org/zap"
+)
+
+// agentLogStale drops run records whose end was never reported.
+const agentLogStale = 6 * time.Hour
+
+// agentLogHook appends an entry to <workspace>/.ngoclaw/AGENT_LOG.md after
+// each run that changed files (log.agent_log). Changed files come from the
+// FileGuard's per-run edit record, so edits made by shell commands are not
+// listed.
+type agentLogHook struct {
+	service.NoOpHook
+	guard   *toolpkg.FileGuard
+	workDir func() string // fallback workspace for files outside a git repo
+	logger  *zap.Logger
+	now     func() time.Time
+
+	mu   sync.Mutex
+	runs map[string]agentLogRun // trace ID → run
+}
+
+type agentLogRun struct {
+	message string
+	model   string
+	started time.Time
+}
+
+var (
+	_ service.AgentHook        = (*agentLogHook)(nil)
+	_ service.RunLifecycleHook = (*agentLogHook)(nil)
+)
+
+func newAgentLogHook(guard *toolpkg.FileGuard, workDir func() string, logger *zap.Logger) *agentLogHook {
+	return &agentLogHook{
+		guard:   guard,
+		workDir: workDir,
+		logger:  logger.With(zap.String("component", "agent_log")),
+		now:     time.Now,
+		runs:    make(map[string]agentLogRun),
+	}
+}
+
+func (h *agentLogHook) OnRunStart(ctx context.Context, userMessage, model string) {
+	trace := service.TraceIDFromContext(ctx)
+	if trace == "" {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := h.now()
+	for id, r := range h.runs {
+		if now.Sub(r.started) > agentLogStale {
+			delete(h.runs, id)
+		}
+	}
+	h.runs[trace] = agentLogRun{message: userMessage, model: model, started: now}
+}
+
+func (h *agentLogHook) OnComplete(ctx context.Context, result *service.AgentResult) {
+	h.finish(ctx, "completed", result)
+}
+
+func (h *agentLogHook) OnRunAbort(ctx context.Context, reason service.AbortReason, result *service.AgentResult) {
+	h.finish(ctx, fmt.Sprintf("aborted (%s)", reason), result)
+}
+
+// OnError ends the run; a budget abort that follows finds it already logged.
+func (h *agentLogHook) OnError(ctx context.Context, err error, step int) {
+	outcome := "failed"
+	if err != nil {
+		outcome = "failed: " + firstLine(err.Error())
+	}
+	h.finish(ctx, outcome, nil)
+}
+
+func (h *agentLogHook) finish(ctx context.Context, outcome string, result *service.AgentResult) {
+	trace := service.TraceIDFromContext(ctx)
+	if trace == "" {
+		return
+	}
+	h.mu.Lock()
+	run, ok := h.runs[trace]
+	delete(h.runs, trace)
+	h.mu.Unlock()
+	if !ok {
+		return
+	}
+	files := h.guard.EditedFiles(ctx)
+	if len(files) == 0 {
+		return
+	}
+
+	entry := agentlog.Entry{
+		Time:    h.now(),
+		Source:  service.TranscriptSourceFromContext(ctx),
+		Model:   run.model,
+		Request: run.message,
+		Outcome: outcome,
+	}
+	if result != nil {
+		if result.ModelUsed != "" {
+			entry.Model = result.ModelUsed
+		}
+		entry.Verification = verificationSummary(result.TestReport)
+	}
+	fallback := ""
+	if h.workDir != nil {
+		fallback = h.workDir()
+	}
+	for root, rel := range agentlog.Group(files, fallback) {
+		entry.Files = rel
+		if err := agentlog.Append(root, entry); err != nil {
+			h.logger.Warn("Failed to append agent log", zap.String("workspace", root), zap.Error(err))
+		}
+	}
+}
+
+// verificationSummary describes the run's last run_tests result.
+func verificationSummary(r *entity.TestReport) string {
+	if r == nil {
+		return ""
+	}
+	if r.Passed {
+		return fmt.Sprintf("✅ tests passed (`%s`)", r.Command)
+	}
+	s := fmt.Sprintf("❌ tests failed (`%s`)", r.Command)
+	if len(r.Failed) > 0 {
+		s += ": " + strings.Join(r.Failed, ", ")
+	}
+	return s
+}
@@ -3,11 +3,17 @@ package application
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
+	"net"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ngoclaw/ngoclaw/gateway/internal/application/usecase"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
@@ -15,18 +21,35 @@ import (
 	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
 	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/valueobject"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/approval"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/eventbus"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/github"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/guards"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/janitor"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/jobqueue"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/journal"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/linkfetch"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/llm"
 	_ "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/llm/anthropic" // register anthropic provider factory
 	_ "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/llm/gemini"    // register gemini provider factory
+	_ "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/llm/llamacpp"  // register llamacpp provider factory
 	_ "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/llm/openai"    // register openai provider factory
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/notify"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/persistence"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/policy"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/postprocess"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/prompt"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/retention"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/sandbox"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/share"
 	toolpkg "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tool"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/transcript"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/workflow"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/interfaces/agentgrpc"
 	httpServer "github.com/ngoclaw/ngoclaw/gateway/internal/interfaces/http"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/interfaces/telegram"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -39,8 +62,13 @@ type App struct {
 	db     *gorm.DB
 
 	// 仓储层
-	agentRepo   repository.AgentRepository
-	messageRepo repository.MessageRepository
+	agentRepo        repository.AgentRepository
+	messageRepo      repository.MessageRepository
+	modelStatsRepo   repository.ModelStatsRepository
+	chatSettingsRepo repository.ChatSettingsRepository
+	feedbackRepo     repository.FeedbackRepository
+	scheduleRepo     repository.ScheduleRepository
+	budgetRepo       repository.BudgetRepository
 
 	// 领域服务
 	agentSelector service.AgentSelector
@@ -52,19 +80,47 @@ type App struct {
 	// 基础设施
 	toolRegistry    domaintool.Registry
 	toolExecutor    *toolpkg.Executor
+	fileGuard       *toolpkg.FileGuard
+	sandbox         *sandbox.ProcessSandbox
 	llmRouter       *llm.Router
+	modelStats      *llm.ModelStatsTracker
 	mcpManager      *toolpkg.MCPManager
+	workspaceIndex  *toolpkg.WorkspaceIndex  // repo_map / grep_search 的常驻索引, 见 workspace_index.go
+	buildWarmer     *toolpkg.BuildWarmer     // agent.tools.warm, nil = 未启用
+	terminals       *toolpkg.TerminalManager // terminal 工具的持久 PTY 会话
+	lsp             *toolpkg.LSPTool         // lsp 工具的语言服务器, 由 janitor 回收
 	agentLoop       *service.AgentLoop
 	securityHook    *service.SecurityHook
 	grpcAgentSrv    *agentgrpc.Server
 	telegramAdapter *telegram.Adapter
 	httpServer      *httpServer.Server
+	jobPool         *jobqueue.Pool
+	githubResponder *githubResponder
+	workflows       *workflowRunner // /workflow, nil without Telegram
+	events          *eventbus.InMemoryBus // run / tool / llm / security events, see events.go
+	notifier        *notify.Notifier      // notifications.targets, nil = none
+	approvalQueue   *approval.Queue       // HTTP fallback approvals (fallback_approval: http)
+	transcripts     *transcript.Writer    // nil unless log.transcripts.enabled
+	journals        *journal.Writer       // nil unless log.journal.enabled
+	shares          *share.Store          // nil unless gateway.share.enabled
+	retention       *retention.Scrubber   // retention.* TTL sweeps and /forgetme, see retention.go
+	budgets         *service.BudgetManager // agent.budgets, nil = no budget pools
+	orgPolicy       *policy.Client         // policy.url organization bundle, see policy.go
+	archive         *toolpkg.RunArchive    // search_past_runs / read_artifact / /recall
+	janitor         *janitor.Janitor      // janitor.* leaked resource reaper, see janitor.go
+	cron            *telegram.CronService     // /cron jobs, nil without Telegram
+	heartbeat       *service.HeartbeatService // HEARTBEAT.md, nil without Telegram
+	output          *service.OutputPipeline // agent.output post-processing, nil = none
 
 	// 记忆系统
 
 
 	// Prompt 引擎
 	promptEngine   *prompt.PromptEngine
+
+	// 重启请求 (/profile 切换后), 见 profile.go
+	restart     chan struct{}
+	restartOnce sync.Once
 }
 
 // NewApp 创建应用程序（依赖注入容器）
@@ -75,8 +131,12 @@ func NewApp(cfg *config.Config, logger *zap.Logger) (*App, error) {
 	}
 
 	app := &App{
-		config: cfg,
-		logger: logger,
+		config:  cfg,
+		logger:  logger,
+		restart: make(chan struct{}),
+	}
+	if cfg.Profile != "" {
+		logger.Info("Using config profile", zap.String("profile", cfg.Profile))
 	}
 
 	// 初始化各层组件
@@ -117,8 +177,9 @@ func NewAppCLI(cfg *config.Config, logger *zap.Logger) (*App, error) {
 	}
 
 	app := &App{
-		config: cfg,
-		logger: logger,
+		config:  cfg,
+		logger:  logger,
+		restart: make(chan struct{}),
 	}
 
 	// DB with silent logging (no SQL spam)
@@ -157,6 +218,11 @@ func (app *App) initRepositories() error {
 	// 初始化 GORM 仓储
 	app.agentRepo = persistence.NewGormAgentRepository(db)
 	app.messageRepo = persistence.NewGormMessageRepository(db)
+	app.modelStatsRepo = persistence.NewGormModelStatsRepository(db)
+	app.chatSettingsRepo = persistence.NewGormChatSettingsRepository(db)
+	app.feedbackRepo = persistence.NewGormFeedbackRepository(db)
+	app.scheduleRepo = persistence.NewGormScheduleRepository(db)
+	app.budgetRepo = persistence.NewGormBudgetRepository(db)
 
 	return nil
 }
@@ -170,6 +236,11 @@ func (app *App) initRepositoriesSilent() error {
 	app.db = db
 	app.agentRepo = persistence.NewGormAgentRepository(db)
 	app.messageRepo = persistence.NewGormMessageRepository(db)
+	app.modelStatsRepo = persistence.NewGormModelStatsRepository(db)
+	app.chatSettingsRepo = persistence.NewGormChatSettingsRepository(db)
+	app.feedbackRepo = persistence.NewGormFeedbackRepository(db)
+	app.scheduleRepo = persistence.NewGormScheduleRepository(db)
+	app.budgetRepo = persistence.NewGormBudgetRepository(db)
 	return nil
 }
 
@@ -192,6 +263,9 @@ func (app *App) initInfrastructure() error {
 
 	// Tool Registry + Executor
 	app.toolRegistry = domaintool.NewInMemoryRegistry()
+	if err := app.initToolMock(); err != nil {
+		return err
+	}
 	homeDir, _ := os.UserHomeDir()
 	systemSkillsDir := filepath.Join(homeDir, ".ngoclaw", "skills")
 
@@ -208,10 +282,24 @@ func (app *App) initInfrastructure() error {
 	if app.config.Agent.Runtime.ToolTimeout > 0 {
 		sbxCfg.Timeout = app.config.Agent.Runtime.ToolTimeout
 	}
+	limits := app.config.Sandbox.Limits
+	sbxCfg.CPUSeconds = limits.CPUSeconds
+	sbxCfg.MemoryLimit = int64(limits.MemoryMB) << 20
+	sbxCfg.MaxProcesses = limits.MaxProcesses
+	sbxCfg.MaxOutput = limits.MaxOutputKB << 10
+	sbxCfg.EnableNetwork = !limits.IsolateNetwork
 	sbx, sbxErr := sandbox.NewProcessSandbox(sbxCfg, app.logger)
 	if sbxErr != nil {
 		app.logger.Warn("Sandbox init failed, tools will run unsandboxed", zap.Error(sbxErr))
 	}
+	app.sandbox = sbx
+
+	// 并发编辑协调: 同一文件的写操作串行, 并检测 run 之间的覆盖冲突
+	var workDir func() string
+	if sbx != nil {
+		workDir = sbx.GetWorkDir
+	}
+	app.fileGuard = toolpkg.NewFileGuard(workDir)
 
 	// Executor (只负责执行，不再负责注册)
 	app.toolExecutor = toolpkg.NewExecutor(
@@ -219,10 +307,15 @@ func (app *App) initInfrastructure() error {
 		&domaintool.Policy{Profile: "full"},
 		sbx, nil, app.logger,
 	)
+	app.toolExecutor.SetFileGuard(app.fileGuard)
 
 	// LLM Router (modular provider factory with failover)
 	// NOTE: must be initialized BEFORE RegisterAllTools because sub_agent depends on it.
 	app.llmRouter = llm.NewRouter(app.logger)
+	app.llmRouter.SetStreamThresholds(llm.StreamThresholds{
+		SlowFirstToken: app.config.Agent.Runtime.SlowFirstToken,
+		Stall:          app.config.Agent.Runtime.StreamStall,
+	})
 	for _, p := range app.config.Agent.Providers {
 		provider, err := llm.CreateProvider(llm.ProviderConfig{
 			Name:     p.Name,
@@ -231,6 +324,17 @@ func (app *App) initInfrastructure() error {
 			APIKey:   p.APIKey,
 			Models:   p.Models,
 			Priority: p.Priority,
+			Network: llm.NetworkConfig{
+				Proxy:              p.Proxy,
+				CAFile:             p.CAFile,
+				InsecureSkipVerify: p.InsecureSkipVerify,
+			},
+			Llama: llm.LlamaConfig{
+				ModelPath:   p.ModelPath,
+				ContextSize: p.ContextSize,
+				GPULayers:   p.GPULayers,
+				Threads:     p.Threads,
+			},
 		}, app.logger)
 		if err != nil {
 			app.logger.Error("Failed to create LLM provider",
@@ -240,17 +344,46 @@ func (app *App) initInfrastructure() error {
 			)
 			continue
 		}
+		if p.Proxy != "" || p.CAFile != "" {
+			app.logger.Info("LLM provider network settings",
+				zap.String("name", p.Name),
+				zap.String("proxy", llm.NetworkConfig{Proxy: p.Proxy}.ProxyLabel()),
+				zap.String("ca_file", p.CAFile),
+			)
+		}
+		if p.InsecureSkipVerify {
+			app.logger.Warn("TLS certificate verification disabled for provider",
+				zap.String("name", p.Name),
+			)
+		}
 		app.llmRouter.AddProvider(provider)
 	}
 	app.logger.Info("LLM Router initialized",
 		zap.Int("providers", len(app.config.Agent.Providers)),
 	)
 
+	// Per provider+model usage stats (persisted, for capacity planning)
+	app.modelStats = llm.NewModelStatsTracker(app.modelStatsRepo, app.logger)
+	if err := app.modelStats.Load(context.Background()); err != nil {
+		app.logger.Warn("Failed to load model stats", zap.Error(err))
+	}
+	app.modelStats.Start(time.Minute)
+	app.llmRouter.SetStatsTracker(app.modelStats)
+
 	// MCP Manager (hot-pluggable, reads ~/.ngoclaw/mcp.json)
 	homeDir, _ = os.UserHomeDir()
 	mcpConfigPath := filepath.Join(homeDir, ".ngoclaw", "mcp.json")
 	app.mcpManager = toolpkg.NewMCPManager(mcpConfigPath, app.toolRegistry, app.logger)
 
+	app.workspaceIndex = app.newWorkspaceIndex()
+	app.buildWarmer = app.newBuildWarmer()
+	app.terminals = terminalManager(app.config.Agent.Tools.Terminal, sbx, app.logger)
+	lspRoot := app.config.Agent.Workspace
+	if lspRoot == "" {
+		lspRoot, _ = os.Getwd()
+	}
+	app.lsp = toolpkg.NewLSPTool(lspRoot, app.logger)
+
 	// ── Unified Tool Registration (single entry point) ──
 	subMaxSteps := app.config.Agent.Runtime.SubAgentMaxSteps
 	if subMaxSteps <= 0 {
@@ -272,6 +405,12 @@ func (app *App) initInfrastructure() error {
 		}
 	}
 
+	// 过往运行检索: 产物、转录与运行日志 (未启用的日志目录为空, 不影响检索)
+	app.archive = toolpkg.NewRunArchive(toolpkg.RunArchiveConfig{
+		TranscriptDir: app.config.Log.Transcripts.Dir,
+		JournalDir:    app.config.Log.Journal.Dir,
+	})
+
 	toolpkg.RegisterAllTools(toolpkg.ToolLayerDeps{
 		Registry:         app.toolRegistry,
 		Sandbox:          sbx,
@@ -282,20 +421,43 @@ func (app *App) initInfrastructure() error {
 		ResearchLLMKey:   researchKey,
 		ResearchLLMModel: researchModel,
 		Workspace:        app.config.Agent.Workspace,
+		LSP:              app.lsp,
+		ReadPrefetch:     app.config.Agent.Runtime.ReadPrefetch,
+		Tests: toolpkg.TestConfig{
+			Timeout:  app.config.Agent.Tools.Tests.Timeout,
+			Baseline: app.config.Agent.Tools.Tests.Baseline,
+		},
+		Deps: toolpkg.DepsConfig{
+			GoProxy:     app.config.Agent.Tools.Deps.GoProxy,
+			NPMRegistry: app.config.Agent.Tools.Deps.NPMRegistry,
+			PyPI:        app.config.Agent.Tools.Deps.PyPI,
+			Timeout:     app.config.Agent.Tools.Deps.Timeout,
+		},
+		WorkspaceIndex:   app.workspaceIndex,
+		FileGuard:        app.fileGuard,
+		Terminals:        app.terminals,
+		Docs:             docsLookupConfig(app.config.Agent.Tools.Docs),
+		Remote:           remoteToolsConfig(app.config.Agent.Tools.Remote, workDir, app.logger),
+		RemoteAgent:      remoteAgentConfig(app.config.Agent.Tools.Peers, app.logger),
+		SQL:              sqlToolConfig(app.config.Agent.Tools.SQL, app.logger),
+		Vision:           visionToolConfig(app.config.Agent, app.llmRouter, workDir),
 		MCPManager:       app.mcpManager,
+		Archive:          app.archive,
 		SubAgent: &toolpkg.SubAgentDeps{
 			LLMClient:    app.llmRouter,
-			ToolExecutor: &toolBridge{registry: app.toolRegistry},
+			ToolExecutor: &toolBridge{registry: app.toolRegistry, guard: app.fileGuard},
 			DefaultModel: app.config.Agent.DefaultModel,
 			MaxSteps:     subMaxSteps,
 			Timeout:      app.config.Agent.Runtime.SubAgentTimeout,
 		},
-		Logger: app.logger,
+		CommandTools: commandToolSpecs(app.config.Agent.Tools.Registry, app.logger),
+		Logger:       app.logger,
 	})
 
 
 	// Prompt Engine (hot-pluggable system prompt assembly — System + Workspace layers)
 	app.promptEngine = prompt.NewPromptEngine(app.config.Agent.Workspace, app.logger)
+	app.promptEngine.SetVars(app.config.Agent.PromptVars)
 	if err := app.promptEngine.Discover(); err != nil {
 		app.logger.Warn("Prompt engine discovery failed, will use empty system prompt",
 			zap.Error(err),
@@ -309,6 +471,13 @@ func (app *App) initInfrastructure() error {
 func (app *App) initApplicationServices() error {
 	app.logger.Info("Initializing application services")
 
+	// 最终回复的后处理 (agent.output); 配置有误时拒绝启动, 不静默跳过策略
+	output, err := postprocess.Build(app.config.Agent.Output, app.logger)
+	if err != nil {
+		return fmt.Errorf("agent.output: %w", err)
+	}
+	app.output = output
+
 	// ProcessMessageUseCase (legacy HTTP/REPL path — uses llmRouter directly)
 	app.processMessageUseCase = usecase.NewProcessMessageUseCase(
 		app.messageRepo,
@@ -318,7 +487,7 @@ func (app *App) initApplicationServices() error {
 	)
 
 	// Agent Loop (ReAct Engine) — uses LLM Router + Tool Bridge
-	loopTools := &toolBridge{registry: app.toolRegistry}
+	loopTools := &toolBridge{registry: app.toolRegistry, guard: app.fileGuard}
 
 
 	loopCfg := service.DefaultAgentLoopConfig()
@@ -332,21 +501,33 @@ func (app *App) initApplicationServices() error {
 				RepairToolPairing:   cfgPolicy.RepairToolPairing,
 				EnforceTurnOrdering: cfgPolicy.EnforceTurnOrdering,
 				ReasoningFormat:     cfgPolicy.ReasoningFormat,
+				ToolHistory:         cfgPolicy.ToolHistory,
 				ProgressInterval:    cfgPolicy.ProgressInterval,
 				ProgressEscalation:  cfgPolicy.ProgressEscalation,
 				PromptStyle:         cfgPolicy.PromptStyle,
 				SystemRoleSupport:   cfgPolicy.SystemRoleSupport,
 				ThinkingTagHint:     cfgPolicy.ThinkingTagHint,
+				ContextWindow:       cfgPolicy.ContextWindow,
+				ThinkingControl:     cfgPolicy.ThinkingControl,
+				InputPrice:          cfgPolicy.InputPrice,
+				OutputPrice:         cfgPolicy.OutputPrice,
+
+				MaxParallelToolCalls: cfgPolicy.MaxParallelToolCalls,
+				SerialEditTools:      cfgPolicy.SerialEditTools,
 			}
 			loopCfg.ModelPolicies[key] = override
 		}
 	}
+	if app.config.Agent.Guardrails.ContextMaxTokens > 0 {
+		loopCfg.ContextMaxTokens = app.config.Agent.Guardrails.ContextMaxTokens
+	}
 	if app.config.Agent.Guardrails.LoopDetectThreshold > 0 {
 		loopCfg.DoomLoopThreshold = app.config.Agent.Guardrails.LoopDetectThreshold
 	}
 	if app.config.Agent.Guardrails.LoopNameThreshold > 0 {
 		loopCfg.LoopNameThreshold = app.config.Agent.Guardrails.LoopNameThreshold
 	}
+	loopCfg.PreflightTokens = app.config.Agent.Guardrails.PreflightTokens
 
 	// Retry config from config.yaml
 	if app.config.Agent.Runtime.MaxRetries > 0 {
@@ -355,6 +536,9 @@ func (app *App) initApplicationServices() error {
 	if app.config.Agent.Runtime.RetryBaseWait > 0 {
 		loopCfg.RetryBaseWait = app.config.Agent.Runtime.RetryBaseWait
 	}
+	loopCfg.StreamStallRetries = app.config.Agent.Runtime.StreamStallRetries
+	loopCfg.StreamAcceptChars = app.config.Agent.Runtime.StreamAcceptChars
+	loopCfg.FilterRetry = app.config.Agent.Runtime.FilterRetry
 
 	// Compaction config from config.yaml
 	if app.config.Agent.Compaction.MessageThreshold > 0 {
@@ -363,6 +547,15 @@ func (app *App) initApplicationServices() error {
 	if app.config.Agent.Compaction.KeepRecent > 0 {
 		loopCfg.CompactKeepLast = app.config.Agent.Compaction.KeepRecent
 	}
+	// run_tests (含 HEAD 基线)、update_deps (安装 + 验证) 与 remote_agent 可能跑几分钟, 不受默认单工具超时限制
+	loopCfg.ToolTimeouts = make(map[string]time.Duration)
+	for _, name := range []string{"run_tests", "update_deps", "remote_agent"} {
+		if t, ok := app.toolRegistry.Get(name); ok {
+			if tt, ok := t.(interface{ Timeout() time.Duration }); ok {
+				loopCfg.ToolTimeouts[name] = tt.Timeout()
+			}
+		}
+	}
 
 
 	app.agentLoop = service.NewAgentLoop(
@@ -374,6 +567,34 @@ func (app *App) initApplicationServices() error {
 	app.logger.Info("Agent Loop initialized",
 		zap.String("model", loopCfg.Model),
 	)
+	// 渠道级覆盖 (channels.<渠道>): CLI 用编码模型、TG 用便宜的聊天模型等, 运行时按来源选用
+	if len(app.config.Channels) > 0 {
+		channels := make(map[string]service.ChannelConfig, len(app.config.Channels))
+		for name, ch := range app.config.Channels {
+			switch name {
+			case "telegram", "cli", "http", "api":
+			default:
+				app.logger.Warn("Unknown channel in config, ignored", zap.String("channel", name))
+				continue
+			}
+			g := ch.Guardrails
+			channels[name] = service.ChannelConfig{
+				Model:             ch.DefaultModel,
+				ContextMaxTokens:  g.ContextMaxTokens,
+				ContextWarnRatio:  g.ContextWarnRatio,
+				ContextHardRatio:  g.ContextHardRatio,
+				LoopWindowSize:    g.LoopDetectWindow,
+				DoomLoopThreshold: g.LoopDetectThreshold,
+				LoopNameThreshold: g.LoopNameThreshold,
+				PreflightTokens:   g.PreflightTokens,
+			}
+			app.logger.Info("Channel overrides loaded",
+				zap.String("channel", name),
+				zap.String("model", app.config.ChannelModel(name)),
+			)
+		}
+		app.agentLoop.SetChannelConfigs(channels)
+	}
 
 	// Create SecurityHook and attach to agent loop
 	app.securityHook = service.NewSecurityHook(
@@ -381,7 +602,121 @@ func (app *App) initApplicationServices() error {
 		nil, // approvalFunc is set later in initInterfaces after TG adapter creation
 		app.logger,
 	)
-	app.agentLoop.SetHooks(app.securityHook)
+
+	// Event bus: lifecycle callbacks published as typed events (events.go)
+	app.events = eventbus.NewInMemoryBus(app.logger, eventBusBuffer)
+	busHook := newBusHook(app.events)
+	// 外部通知 (notifications.targets): 运行完成/失败、定时任务结果、预算告警; 配置有误时只告警, 不影响启动
+	if notifier, err := notify.New(app.config.Notifications, app.logger); err != nil {
+		app.logger.Warn("Notifications disabled", zap.Error(err))
+	} else if notifier != nil {
+		app.notifier = notifier
+		subscribeNotifications(app.events, notifier)
+		app.logger.Info("Notifications enabled", zap.Int("targets", len(app.config.Notifications.Targets)))
+	}
+	app.securityHook.SetDecisionObserver(busHook.onSecurityDecision)
+	// 项目守卫规则 (.ngoclaw/guards.yaml): 在审批策略之前执行, 修改后下一次工具调用生效
+	guardRoot := app.config.Agent.Workspace
+	if guardRoot == "" {
+		guardRoot, _ = os.Getwd()
+	}
+	var guardWorkDir func() string
+	if app.sandbox != nil {
+		guardWorkDir = app.sandbox.GetWorkDir
+	}
+	app.securityHook.SetGuards(guards.Open(guardRoot, app.logger), guardWorkDir)
+	hooks := service.NewHookChain(app.securityHook, busHook)
+	// 工作区变更日志 (.ngoclaw/AGENT_LOG.md)
+	if app.config.Log.AgentLog.Enabled {
+		hooks.Add(newAgentLogHook(app.fileGuard, app.agentLogWorkDir, app.logger))
+	}
+	// 预算池 (agent.budgets): 每次 LLM 调用的用量计入运行所属用户/会话的池
+	if app.budgets = app.newBudgetManager(loopCfg.ModelPolicies); app.budgets != nil {
+		hooks.Add(service.NewBudgetHook(app.budgets))
+		app.budgets.SetExhaustedObserver(func(ctx context.Context, status service.BudgetStatus, subject string) {
+			busHook.publish(ctx, TopicBudgetExhausted, BudgetEvent{EventMeta: eventMeta(ctx), Status: status, Subject: subject})
+		})
+	}
+	// 组织策略包 (policy.url): 校验签名后覆盖本地安全配置, 追加守卫规则、禁用模型与预算池
+	if err := app.initOrgPolicy(guardRoot); err != nil {
+		return err
+	}
+	app.agentLoop.SetHooks(hooks)
+
+	// Human-readable per-day run transcripts (optional)
+	var sinks service.TranscriptSinks
+	if tc := app.config.Log.Transcripts; tc.Enabled {
+		writer, err := transcript.NewWriter(transcript.Config{
+			Dir:           tc.Dir,
+			MaxSizeBytes:  int64(tc.MaxSizeMB) << 20,
+			RetentionDays: tc.RetentionDays,
+		}, app.logger)
+		if err != nil {
+			app.logger.Warn("Transcript logging disabled", zap.Error(err))
+		} else {
+			app.transcripts = writer
+			sinks = append(sinks, writer)
+			app.logger.Info("Transcript logging enabled", zap.String("dir", writer.Dir()))
+		}
+	}
+	// 运行记录分享链接 (gateway.share): 保留最近运行, 按需渲染为脱敏的只读页面
+	if sc := app.config.Gateway.Share; sc.Enabled {
+		store, err := share.NewStore(share.Config{
+			Dir:     sc.Dir,
+			TTL:     sc.TTL,
+			MaxTTL:  sc.MaxTTL,
+			Keep:    sc.Keep,
+			Secrets: config.SecretValues(app.config),
+		}, app.logger)
+		if err != nil {
+			app.logger.Warn("Share links disabled", zap.Error(err))
+		} else {
+			app.shares = store
+			sinks = append(sinks, store)
+		}
+	}
+	if len(sinks) > 0 {
+		app.agentLoop.SetTranscriptSink(sinks)
+	}
+	// 运行日志 (ngoclaw replay)
+	if jc := app.config.Log.Journal; jc.Enabled {
+		writer, err := journal.NewWriter(journal.Config{
+			Dir:           jc.Dir,
+			RetentionDays: jc.RetentionDays,
+		}, app.logger)
+		if err != nil {
+			app.logger.Warn("Run journal disabled", zap.Error(err))
+		} else {
+			app.journals = writer
+			app.agentLoop.SetJournalSink(writer)
+			app.logger.Info("Run journal enabled", zap.String("dir", writer.Dir()))
+		}
+	}
+	// 每步只发送相关的工具定义, 其余经 request_tool 调入
+	if sc := app.config.Agent.Tools.Subset; sc.Enabled {
+		app.agentLoop.SetToolSelector(service.NewToolSelector(service.ToolSelectorConfig{
+			TopK:   sc.TopK,
+			Always: sc.Always,
+		}, app.logger))
+	}
+	// 交付前自检: 便宜模型检查最终回复, 低分附加注意事项或退回修正一次
+	if rc := app.config.Agent.Review; rc.Enabled {
+		model := rc.Model
+		if model == "" {
+			model = app.config.Agent.Tools.Summarize.Model
+		}
+		if model == "" {
+			model = app.config.Agent.DefaultModel
+		}
+		app.agentLoop.SetReviewPolicy(&service.ReviewPolicy{
+			Model:        model,
+			RetryBelow:   rc.RetryBelow,
+			CaveatBelow:  rc.CaveatBelow,
+			ToolRunsOnly: rc.ToolRunsOnly,
+		})
+	}
+	app.retention = app.newRetentionScrubber()
+	app.janitor = app.newJanitor()
 
 	// Middleware pipeline (data-transformation hooks around LLM calls)
 	mwPipeline := service.NewMiddlewarePipeline(app.logger)
@@ -392,6 +727,28 @@ func (app *App) initApplicationServices() error {
 		// that polluted the system prompt and caused context poisoning.
 		// Future: agent writes memory via file tools (OpenClaw pattern).
 	)
+	// 大工具结果摘要 (agent.tools.summarize), 全文存档在 research 目录下
+	if sc := app.config.Agent.Tools.Summarize; sc.Enabled {
+		model := sc.Model
+		if model == "" {
+			model = app.config.Agent.DefaultModel
+		}
+		mwPipeline.Use(service.NewToolSummaryMiddleware(app.llmRouter, toolpkg.NewOutputArchive(""), service.ToolSummaryConfig{
+			Model:     model,
+			MinTokens: sc.ThresholdTokens,
+			MaxTokens: sc.MaxTokens,
+			Wait:      sc.Wait,
+			SkipTools: sc.SkipTools,
+		}, app.logger))
+	}
+	// 编辑后自动类型检查 (agent.tools.typecheck.auto_after_edit)
+	if tc := app.config.Agent.Tools.Typecheck; tc.AutoAfterEdit {
+		if t, ok := app.toolRegistry.Get("typecheck"); ok {
+			if checker, ok := t.(service.Typechecker); ok {
+				mwPipeline.Use(service.NewTypecheckMiddleware(checker, tc.Timeout, app.logger))
+			}
+		}
+	}
 	app.agentLoop.SetMiddleware(mwPipeline)
 	app.logger.Info("Middleware pipeline configured",
 		zap.Int("middlewares", mwPipeline.Len()),
@@ -416,12 +773,138 @@ func ChatIDFromContext(ctx context.Context) int64 {
 	return 0
 }
 
+// newModelRouter 由 agent.routing 构建自动选模型路由表, 无规则时返回 nil
+func newModelRouter(cfg config.RoutingConfig) *service.ModelRouter {
+	if len(cfg.Rules) == 0 {
+		return nil
+	}
+	rules := make([]service.RouteRule, len(cfg.Rules))
+	for i, r := range cfg.Rules {
+		rules[i] = service.RouteRule{
+			Name:      r.Name,
+			Intents:   r.Intent,
+			Images:    r.Images,
+			MinTokens: r.MinTokens,
+			MaxTokens: r.MaxTokens,
+			Model:     r.Model,
+		}
+	}
+	return service.NewModelRouter(rules)
+}
+
+// newDraftPolicy 由 agent.draft 构建草稿策略, 未配置起草模型时返回 nil
+func newDraftPolicy(cfg config.DraftConfig) *service.DraftPolicy {
+	if cfg.Model == "" {
+		return nil
+	}
+	return &service.DraftPolicy{
+		Model:     cfg.Model,
+		Models:    cfg.Models,
+		MaxTokens: cfg.MaxTokens,
+	}
+}
+
+// newCarryOverPolicy 按 agent.carry_over 创建 /new 的要点延续策略; 未启用时返回 nil
+func (app *App) newCarryOverPolicy() *service.CarryOverPolicy {
+	cc := app.config.Agent.CarryOver
+	if !cc.Enabled {
+		return nil
+	}
+	model := cc.Model
+	if model == "" {
+		model = app.config.Agent.Tools.Summarize.Model
+	}
+	return &service.CarryOverPolicy{Model: model, MaxTokens: cc.MaxTokens}
+}
+
+// newBudgetManager 按 agent.budgets 创建预算池; 未配置 (且没有组织策略) 或没有数据库时返回 nil
+func (app *App) newBudgetManager(policies map[string]*service.ModelPolicyOverride) *service.BudgetManager {
+	orgPools := app.config.Policy.URL != "" // 组织策略包可能下发预算池
+	if (len(app.config.Agent.Budgets) == 0 && !orgPools) || app.budgetRepo == nil {
+		return nil
+	}
+	pools := make([]service.BudgetPool, 0, len(app.config.Agent.Budgets))
+	for _, cfg := range app.config.Agent.Budgets {
+		pool := service.BudgetPool{
+			Name:   cfg.Name,
+			Scope:  service.BudgetScope(strings.ToLower(cfg.Scope)),
+			Window: service.BudgetWindow(strings.ToLower(cfg.Window)),
+			Tokens: cfg.Tokens,
+			USD:    cfg.USD,
+		}
+		if pool.Scope == "" {
+			pool.Scope = service.BudgetGlobal
+		}
+		if pool.Window == "" {
+			pool.Window = service.BudgetMonthly
+		}
+		if pool.Name == "" {
+			pool.Name = string(pool.Scope) + "-" + string(pool.Window)
+		}
+		if pool.Tokens <= 0 && pool.USD <= 0 {
+			app.logger.Warn("Budget pool has no limit, ignored", zap.String("pool", pool.Name))
+			continue
+		}
+		pools = append(pools, pool)
+	}
+	if len(pools) == 0 && !orgPools {
+		return nil
+	}
+	app.logger.Info("Budget pools enabled", zap.Int("pools", len(pools)))
+	return service.NewBudgetManager(pools, app.budgetRepo, func(model string) service.ModelPolicy {
+		return service.ResolveModelPolicy(model, policies)
+	}, app.logger)
+}
+
+// newLinkPreprocessor 按 agent.links 创建链接预读取; mode: off 时返回 nil
+func (app *App) newLinkPreprocessor() *service.LinkPreprocessor {
+	cfg := app.config.Agent.Links
+	if cfg.Mode == "off" {
+		return nil
+	}
+	model := cfg.SummaryModel
+	if model == "" {
+		model = app.config.Agent.Tools.Summarize.Model
+	}
+	if model == "" {
+		model = app.config.Agent.DefaultModel
+	}
+	return service.NewLinkPreprocessor(linkfetch.New(linkfetch.Config{AllowPrivate: cfg.AllowPrivate}), app.llmRouter, service.LinkPolicy{
+		MaxLinks:      cfg.MaxLinks,
+		MaxChars:      cfg.MaxChars,
+		SummarizeOver: cfg.SummarizeOver,
+		Model:         model,
+		Timeout:       cfg.Timeout,
+		AllowDomains:  cfg.AllowDomains,
+		DenyDomains:   cfg.DenyDomains,
+	}, app.logger)
+}
+
+// newMentionPreprocessor 按 agent.mentions 创建 @文件 / #符号 的预加载, 在 root 工作区中解析; mode: off 时返回 nil
+func (app *App) newMentionPreprocessor(root string) *service.MentionPreprocessor {
+	cfg := app.config.Agent.Mentions
+	if cfg.Mode == "off" {
+		return nil
+	}
+	if root == "" {
+		root, _ = os.Getwd()
+	}
+	mp := service.MentionPolicy{MaxMentions: cfg.MaxMentions, MaxChars: cfg.MaxChars}
+	if hook := app.securityHook; hook != nil {
+		// 附带的文件内容与 read_file 受同样的守卫规则约束
+		mp.Allow = func(path string) bool {
+			return hook.GuardAllows("read_file", map[string]interface{}{"path": path})
+		}
+	}
+	return service.NewMentionPreprocessor(toolpkg.NewWorkspaceMentions(root, app.workspaceIndex, app.logger), mp, app.logger)
+}
+
 // initInterfaces 初始化接口层
 func (app *App) initInterfaces() error {
 	app.logger.Info("Initializing interfaces")
 
 	// HTTP服务器
-	loopToolsBridge := &toolBridge{registry: app.toolRegistry}
+	loopToolsBridge := &toolBridge{registry: app.toolRegistry, guard: app.fileGuard}
 	app.httpServer = httpServer.NewServer(
 		httpServer.Config{
 			Host: app.config.Gateway.Host,
@@ -434,6 +917,36 @@ func (app *App) initInterfaces() error {
 		app.promptEngine,
 		app.logger,
 	)
+	app.httpServer.SetModelStats(app.modelStats)
+	app.httpServer.SetOutputPipeline(app.output)
+	app.httpServer.SetToolCatalog(app.ToolCatalog)
+	if err := app.httpServer.SetAPIAuth(apiTokens(app.config.Gateway.APITokens), app.config.Gateway.APIAllowIPs); err != nil {
+		return fmt.Errorf("gateway.api_allow_ips: %w", err)
+	}
+	if err := app.httpServer.SetOIDC(oidcConfig(app.config.Gateway.OIDC)); err != nil {
+		return fmt.Errorf("gateway.oidc: %w", err)
+	}
+	app.httpServer.SetShare(app.shares, app.config.Gateway.Share.BaseURL)
+
+	// 异步任务队列 (可选)
+	if app.config.Jobs.Enabled {
+		if err := app.initJobQueue(loopToolsBridge); err != nil {
+			app.logger.Warn("Job queue disabled", zap.Error(err))
+		}
+	}
+
+	// GitHub webhook (可选)
+	if app.config.GitHub.Enabled {
+		if err := app.initGitHub(loopToolsBridge); err != nil {
+			app.logger.Warn("GitHub integration disabled", zap.Error(err))
+		}
+	}
+
+	// 无 Telegram chatID 时的审批通道 (HTTP API / gRPC 调用方)
+	fallbackApproval := app.initFallbackApproval()
+	if app.securityHook != nil {
+		app.securityHook.SetApprovalFunc(fallbackApproval)
+	}
 
 	// Telegram适配器
 	if app.config.Telegram.BotToken != "" {
@@ -445,6 +958,11 @@ func (app *App) initInterfaces() error {
 				DMPolicy:       app.config.Telegram.DMPolicy,
 				GroupPolicy:    app.config.Telegram.GroupPolicy,
 				GroupAllowFrom: app.config.Telegram.GroupAllowFrom,
+				Webhook:        app.config.Telegram.Mode == "webhook",
+				WebhookURL:     app.config.Telegram.WebhookURL,
+				WebhookSecret:  app.config.Telegram.WebhookSecret,
+
+				LongReplyDocument: app.config.Telegram.LongReplyDocument,
 			},
 			app.logger,
 		)
@@ -452,33 +970,103 @@ func (app *App) initInterfaces() error {
 			return fmt.Errorf("failed to create telegram adapter: %w", err)
 		}
 
+		if app.config.Telegram.Mode == "webhook" {
+			app.httpServer.SetTelegramWebhook(telegram.WebhookPath, app.telegramAdapter.WebhookHandler())
+		}
+
+		// 控制群: 配置修改、审批拒绝/超时、预算耗尽、提供商故障、重启等事件实时通知管理员
+		if tc := app.config.Telegram; tc.ControlChat != 0 {
+			adapter := app.telegramAdapter
+			adapter.SetControlChat(tc.ControlChat, tc.ControlEvents)
+			app.llmRouter.SetCircuitListener(func(provider string, state llm.CircuitState, err error) {
+				if state == llm.CircuitOpen {
+					adapter.Audit(telegram.AuditProvider, 0, 0, "audit.provider_down", provider, err.Error())
+					return
+				}
+				adapter.Audit(telegram.AuditProvider, 0, 0, "audit.provider_up", provider)
+			})
+		}
+
 		// Register media tools (TG-only, delayed because adapter created here)
 		app.toolRegistry.Register(toolpkg.NewSendPhotoTool(app.telegramAdapter, app.logger))
 		app.toolRegistry.Register(toolpkg.NewSendDocumentTool(app.telegramAdapter, app.logger))
 		app.logger.Info("Registered TG media tools (send_photo, send_document)")
 
+		// 用户发来的文档 (PDF/DOCX/文本) 建立 chat 级临时索引, 由 query_document 按需检索
+		var documents *toolpkg.DocumentIndex
+		if docCfg := app.config.Agent.Tools.Documents; !docCfg.Disabled {
+			documents = toolpkg.NewDocumentIndex(toolpkg.DocumentIndexConfig{
+				MaxBytes:   docCfg.MaxBytes,
+				ChunkChars: docCfg.ChunkChars,
+				TTL:        docCfg.TTL,
+				MaxPerChat: docCfg.MaxPerChat,
+			}, app.logger)
+			app.toolRegistry.Register(toolpkg.NewQueryDocumentTool(documents, app.logger))
+		}
+
 		// 创建会话管理器
-		sessionManager := telegram.NewDefaultSessionManager(app.config.Agent.DefaultModel)
+		sessionManager := telegram.NewDefaultSessionManager(app.config.ChannelModel("telegram"))
+		sessionManager.SetDefaultLocale(string(i18n.Resolve(app.config.Locale)))
+		app.telegramAdapter.SetLocaleResolver(func(chatID int64) i18n.Locale {
+			return i18n.Resolve(sessionManager.GetLocale(chatID))
+		})
 
 		// 从配置加载模型列表
 		if len(app.config.Agent.Models) > 0 {
 			models := make([]telegram.ModelInfo, len(app.config.Agent.Models))
 			for i, m := range app.config.Agent.Models {
 				models[i] = telegram.ModelInfo{
-					ID:          m.ID,
-					Alias:       m.Alias,
-					Provider:    m.Provider,
-					Description: m.Description,
+					ID:           m.ID,
+					Alias:        m.Alias,
+					Provider:     m.Provider,
+					Description:  m.Description,
+					Capabilities: m.Capabilities,
 				}
 			}
 			sessionManager.SetAvailableModels(models)
 		}
 
+		// 恢复持久化的会话偏好 (模型、思考级别、安全模式、TTS)
+		if app.chatSettingsRepo != nil {
+			sessionManager.SetStore(app.chatSettingsRepo, app.logger)
+			if err := sessionManager.Load(context.Background()); err != nil {
+				app.logger.Warn("Failed to restore chat sessions", zap.Error(err))
+			}
+			if mode := sessionManager.LatestSecurityProfile(); mode != "" && app.securityHook != nil {
+				app.securityHook.SetApprovalMode(mode)
+				app.logger.Info("Restored approval mode from /security", zap.String("mode", mode))
+			}
+		}
+
 		// 创建命令注册表
 		cmdRegistry := telegram.NewCommandRegistry()
 
+		// 管理员命令权限: admin_ids, 未配置时沿用 allow_ids
+		adminIDs := app.config.Telegram.AdminIDs
+		if len(adminIDs) == 0 {
+			adminIDs = app.config.Telegram.AllowIDs
+		}
+		cmdRegistry.SetAdminIDs(adminIDs)
+
 		// 设置会话管理器
 		cmdRegistry.SetSessionManager(sessionManager)
+		cmdRegistry.SetSessionSettings(sessionManager)
+		cmdRegistry.SetModelStatsProvider(app.modelStats)
+		cmdRegistry.SetModelProber(app.llmRouter)
+		modelRouter := newModelRouter(app.config.Agent.Routing)
+		cmdRegistry.SetModelRouter(modelRouter, app.config.Agent.Routing.Enabled)
+		if app.budgets != nil {
+			cmdRegistry.SetBudgetReporter(app.budgets)
+		}
+		if app.archive != nil {
+			cmdRegistry.SetPastRunSearcher(app.archive)
+		}
+		draftPolicy := newDraftPolicy(app.config.Agent.Draft)
+		cmdRegistry.SetDraftPolicy(draftPolicy, app.config.Agent.Draft.Enabled)
+		cmdRegistry.SetAgentRepository(app.agentRepo)
+		cmdRegistry.SetTemplateStore(prompt.NewTemplateStore(""))
+		cmdRegistry.SetPromptVarDefaults(app.config.Agent.PromptVars)
+		cmdRegistry.SetToolCatalog(app.ToolCatalog)
 
 		// 创建技能管理器
 		skillHome, _ := os.UserHomeDir()
@@ -487,6 +1075,14 @@ func (app *App) initInterfaces() error {
 		cmdRegistry.SetSkillManager(skillManager)
 		app.logger.Info("Skill manager initialized", zap.String("dir", skillDir), zap.Int("count", len(skillManager.List())))
 
+		// /workflow 命名流水线: 每个 stage 作为独立的 agent 运行, 可由 /cron 定时触发
+		app.workflows = newWorkflowRunner(workflow.DefaultDir(), app.agentLoop, loopToolsBridge, app.promptEngine, skillManager, app.telegramAdapter, app.logger)
+		cmdRegistry.SetWorkflowRunner(app.workflows)
+
+		// /tts 语音回复: 开启后最终回复另以语音消息发送 (tts.* 配置提供方)
+		ttsCtl := app.newTTSController(sessionManager, app.telegramAdapter)
+		cmdRegistry.SetTtsController(ttsCtl)
+
 		// 注册内置命令
 		app.telegramAdapter.RegisterBuiltinCommands(cmdRegistry, app.securityHook)
 
@@ -497,11 +1093,27 @@ func (app *App) initInterfaces() error {
 		msgHandler := &telegramMessageHandler{
 			agentLoop:      app.agentLoop,
 			toolExec:       loopToolsBridge,
+			toolRegistry:   app.toolRegistry,
 			promptEngine:   app.promptEngine,
 			tgAdapter:      app.telegramAdapter,
 			logger:         app.logger,
 			sessionManager: sessionManager,
 			workspaceDir:   app.config.Agent.Workspace,
+			longRunNotice:  app.config.Telegram.LongRunNotice,
+			idleNotice:     app.config.Telegram.IdleNotice,
+			steerMode:      app.config.Telegram.BusyMode == "steer",
+			modelRouter:    modelRouter,
+			routeDefault:   app.config.Agent.Routing.Enabled,
+			draftPolicy:    draftPolicy,
+			draftDefault:   app.config.Agent.Draft.Enabled,
+			agentRepo:      app.agentRepo,
+			documents:      documents,
+			links:          app.newLinkPreprocessor(),
+			mentions:       app.newMentionPreprocessor(app.config.Agent.Workspace),
+			budgets:        app.budgets,
+			output:         app.output,
+			carryOver:      app.newCarryOverPolicy(),
+			tts:            ttsCtl,
 		}
 		app.telegramAdapter.SetMessageHandler(msgHandler)
 
@@ -511,7 +1123,7 @@ func (app *App) initInterfaces() error {
 			app.securityHook.SetApprovalFunc(func(ctx context.Context, toolName string, args map[string]interface{}) (bool, error) {
 				chatID := ChatIDFromContext(ctx)
 				if chatID == 0 {
-					return true, nil // No chatID in context — auto-approve (e.g. HTTP API)
+					return fallbackApproval(ctx, toolName, args)
 				}
 				argsJSON, _ := json.Marshal(args)
 				return adapter.RequestApproval(ctx, chatID, toolName, string(argsJSON))
@@ -520,6 +1132,67 @@ func (app *App) initInterfaces() error {
 
 		// 允许 /new /clear /reset 命令清除对话历史
 		cmdRegistry.SetHistoryClearer(msgHandler)
+		if msgHandler.carryOver != nil {
+			cmdRegistry.SetSessionCarrier(msgHandler)
+		}
+
+		// 👍/👎 反应和 /feedback 存入反馈仓储 (ngoclaw feedback export 导出)
+		var collector *feedbackCollector
+		if app.feedbackRepo != nil {
+			collector = newFeedbackCollector(app.feedbackRepo, app.logger)
+			msgHandler.feedback = collector
+			app.telegramAdapter.SetReactionHandler(collector)
+			cmdRegistry.SetFeedbackRecorder(collector)
+		}
+
+		// /cron 定时任务与 HEARTBEAT.md 心跳: 调度状态持久化, 重启后按 scheduler.catch_up 补跑错过的运行
+		adapter := app.telegramAdapter
+		runScheduled := func(ctx context.Context, chatID int64, command string) error {
+			return adapter.RunScheduled(ctx, chatID, command)
+		}
+		catchUp := service.CatchUpPolicy{Mode: app.config.Scheduler.CatchUp, Max: app.config.Scheduler.MaxCatchUp}
+		for _, mode := range []string{catchUp.Mode, app.config.Heartbeat.CatchUp} {
+			if !service.ValidCatchUp(mode) {
+				app.logger.Warn("Unknown catch-up policy, using once", zap.String("catch_up", mode))
+			}
+		}
+		app.cron = telegram.NewCronService(app.scheduleRepo, catchUp, app.logger)
+		app.cron.SetExecutor(func(ctx context.Context, chatID int64, command string) error {
+			return runScheduled(withCronCommand(ctx, command), chatID, command)
+		})
+		cmdRegistry.SetCronService(app.cron)
+
+		hb := app.config.Heartbeat
+		app.heartbeat = service.NewHeartbeatService(service.HeartbeatConfig{
+			FilePath: hb.FilePath,
+			Interval: time.Duration(hb.Interval) * time.Minute,
+			ChatID:   hb.ChatID,
+			Enabled:  hb.Enabled,
+			CatchUp:  catchUp.With(hb.CatchUp),
+		}, app.logger)
+		app.heartbeat.SetStore(app.scheduleRepo)
+		app.heartbeat.SetExecutor(func(ctx context.Context, chatID int64, command string) (string, error) {
+			return "", runScheduled(ctx, chatID, command)
+		})
+		cmdRegistry.SetHeartbeatStatus(app.heartbeat)
+
+		// /forgetme 删除本 chat 的全部数据
+		cmdRegistry.SetDataEraser(&chatEraser{
+			scrubber: app.retention,
+			sessions: sessionManager,
+			runs:     msgHandler,
+			history:  msgHandler,
+			feedback: collector,
+			cron:     app.cron,
+		})
+
+		// /share 上一次运行的只读分享链接
+		if app.shares != nil {
+			cmdRegistry.SetRunSharer(&runSharer{store: app.shares, baseURL: app.config.Gateway.Share.BaseURL})
+		}
+
+		// /profile 切换配置 profile 并重启网关
+		cmdRegistry.SetProfileSwitcher(&profileSwitcher{app: app})
 
 		// 允许 /stop 命令和对话打断
 		cmdRegistry.SetRunController(msgHandler)
@@ -535,25 +1208,194 @@ func (app *App) initInterfaces() error {
 	if grpcPort == 0 {
 		grpcPort = 50052
 	}
-	loopTools := &toolBridge{registry: app.toolRegistry}
+	loopTools := &toolBridge{registry: app.toolRegistry, guard: app.fileGuard}
 	app.grpcAgentSrv = agentgrpc.NewServer(app.agentLoop, loopTools, grpcPort, app.logger)
 	app.logger.Info("gRPC agent server created", zap.Int("port", grpcPort))
 
+	// 管理面板 (gateway.admin_token)
+	app.initAdmin()
+
 	return nil
 
 }
 
 
 
+// initJobQueue connects the configured job queue backend, exposes
+// POST /api/v1/jobs and prepares the worker pool (started in Start).
+func (app *App) initJobQueue(toolExec service.ToolExecutor) error {
+	cfg := app.config.Jobs
+
+	var queue jobqueue.Queue
+	switch cfg.Backend {
+	case "memory":
+		queue = jobqueue.NewMemoryQueue()
+	case "redis", "":
+		rq, err := jobqueue.NewRedisQueue(jobqueue.RedisConfig{
+			Addr:     cfg.RedisAddr,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+			Prefix:   cfg.Prefix,
+		})
+		if err != nil {
+			return err
+		}
+		queue = rq
+	default:
+		return fmt.Errorf("unknown jobs.backend %q (want redis or memory)", cfg.Backend)
+	}
+
+	runner := &jobRunner{
+		agentLoop:    app.agentLoop,
+		toolExec:     toolExec,
+		promptEngine: app.promptEngine,
+		output:       app.output,
+	}
+	app.jobPool = jobqueue.NewPool(queue, runner, jobqueue.PoolConfig{
+		Consumer:    cfg.Consumer,
+		Concurrency: cfg.Concurrency,
+		ReclaimIdle: cfg.ReclaimIdle,
+		JobTimeout:  cfg.JobTimeout,
+		MaxAttempts: cfg.MaxAttempts,
+	}, app.logger)
+	app.httpServer.SetJobQueue(queue)
+
+	app.logger.Info("Job queue initialized",
+		zap.String("backend", cfg.Backend),
+		zap.Int("concurrency", cfg.Concurrency),
+	)
+	return nil
+}
+
+// initGitHub exposes POST /webhooks/github and answers @mentions on the
+// allowlisted repositories with an agent run.
+func (app *App) initGitHub(toolExec service.ToolExecutor) error {
+	cfg := app.config.GitHub
+	if cfg.WebhookSecret == "" {
+		return fmt.Errorf("github.webhook_secret is required")
+	}
+	if len(cfg.Repos) == 0 {
+		app.logger.Warn("github.repos is empty — all mentions will be rejected")
+	}
+
+	client, err := github.NewClient(github.ClientConfig{
+		APIURL:         cfg.APIURL,
+		AppID:          cfg.AppID,
+		PrivateKeyPath: cfg.PrivateKeyPath,
+		Token:          cfg.Token,
+	})
+	if err != nil {
+		return err
+	}
+
+	runner := &jobRunner{
+		agentLoop:    app.agentLoop,
+		toolExec:     toolExec,
+		promptEngine: app.promptEngine,
+		output:       app.output,
+	}
+	app.githubResponder = newGitHubResponder(cfg, client, runner, app.logger)
+	app.httpServer.SetGitHubWebhook(cfg.WebhookSecret, cfg.Mention, app.githubResponder)
+
+	app.logger.Info("GitHub webhook enabled",
+		zap.String("mention", cfg.Mention),
+		zap.Int("repos", len(cfg.Repos)),
+	)
+	return nil
+}
+
+// initToolMock wraps the tool registry when agent.tools.mock.mode (or
+// NGOCLAW_TOOLS_MOCK) is set, so tool calls are served from recorded
+// fixtures instead of touching the filesystem, network or LSP servers.
+func (app *App) initToolMock() error {
+	cfg := app.config.Agent.Tools.Mock
+	mode, err := toolpkg.ParseMockMode(cfg.Mode)
+	if err != nil {
+		return err
+	}
+	if mode == toolpkg.MockOff {
+		return nil
+	}
+
+	path := cfg.Fixtures
+	if path == "" {
+		path = toolpkg.DefaultMockFixtures()
+	}
+	store, err := toolpkg.LoadMockStore(path)
+	if err != nil {
+		return fmt.Errorf("load tool fixtures: %w", err)
+	}
+	app.toolRegistry = toolpkg.NewMockRegistry(app.toolRegistry, store, mode, app.logger)
+
+	app.logger.Warn("Tool mock mode enabled",
+		zap.String("mode", mode),
+		zap.String("fixtures", path),
+		zap.Int("loaded", store.Len()),
+	)
+	return nil
+}
+
+// initFallbackApproval builds the approval channel used when a tool call has
+// no Telegram chat to ask in, per agent.security.fallback_approval.
+func (app *App) initFallbackApproval() service.ApprovalFunc {
+	secCfg := app.config.Agent.Security
+	mode := strings.ToLower(secCfg.FallbackApproval)
+
+	deny := func(context.Context, string, map[string]interface{}) (bool, error) {
+		return false, nil
+	}
+	switch mode {
+	case "auto":
+		app.logger.Warn("Fallback approval is auto — non-Telegram callers bypass tool approval")
+		return func(context.Context, string, map[string]interface{}) (bool, error) {
+			return true, nil
+		}
+	case "deny":
+		return deny
+	case "console":
+		console := approval.NewConsole(os.Stdin, os.Stderr, secCfg.ApprovalTimeout)
+		app.logger.Info("Fallback approval via gateway console")
+		return console.Request
+	default:
+		if mode != "" && mode != "http" {
+			app.logger.Warn("Unknown fallback_approval mode, using http", zap.String("mode", mode))
+		}
+		// 审批人必须有调用方以外的凭证: API 令牌 / OIDC 登录, 或 /admin 面板的 admin_token
+		if !app.httpServer.APIAuthEnabled() && app.config.Gateway.AdminToken == "" {
+			app.logger.Warn("Fallback approval http needs gateway.api_tokens, gateway.oidc or gateway.admin_token — denying instead")
+			return deny
+		}
+		queue := approval.NewQueue(secCfg.ApprovalTimeout, secCfg.ApprovalWebhook, app.logger)
+		app.approvalQueue = queue
+		app.httpServer.SetApprovalQueue(queue)
+		endpoint := "/api/v1/approvals"
+		if !app.httpServer.APIAuthEnabled() {
+			endpoint = "/admin"
+		}
+		app.logger.Info("Fallback approval via HTTP API",
+			zap.String("endpoint", endpoint),
+			zap.Bool("webhook", secCfg.ApprovalWebhook != ""),
+		)
+		return queue.Request
+	}
+}
+
 // seedData 初始化默认数据
 func (app *App) seedData() error {
 	app.logger.Info("Seeding default data")
 
 	ctx := context.Background()
 
-	// 创建默认代理
+	// 创建默认代理; 已存在时保留 (其他代理由 /agent 创建, 不随启动重置)
+	exists, err := app.agentRepo.Exists(ctx, entity.DefaultAgentID)
+	if err != nil {
+		return fmt.Errorf("failed to check default agent: %w", err)
+	}
+	if exists {
+		return nil
+	}
 	defaultAgent, err := entity.NewAgent(
-		"default",
+		entity.DefaultAgentID,
 		"默认助手",
 		valueobject.DefaultModelConfig(),
 	)
@@ -591,6 +1433,42 @@ func (app *App) Start(ctx context.Context) error {
 		}
 	}
 
+	// 定期刷新组织策略包
+	if app.orgPolicy != nil {
+		app.orgPolicy.Start()
+	}
+
+	// 启动定时任务与心跳 (先补跑停机期间错过的运行)
+	if app.cron != nil {
+		if err := app.cron.Start(); err != nil {
+			app.logger.Warn("Cron service failed to start", zap.Error(err))
+		}
+	}
+	if app.heartbeat != nil {
+		app.heartbeat.Start()
+	}
+
+	// 启动异步任务工作池
+	if app.jobPool != nil {
+		app.jobPool.Start(ctx)
+	}
+
+	// 启动工作区索引 (后台扫描, 之后按文件变更增量更新)
+	app.startWorkspaceIndex()
+
+	// 预热工作区项目的构建缓存, 依赖文件变更后重新预热
+	app.startBuildWarmer()
+
+	// 按保留策略定期清理过期数据
+	if app.retention != nil {
+		app.retention.Start()
+	}
+
+	// 定期清理运行泄漏的资源 (审批、语言服务器、残留进程、临时文件)
+	if app.janitor != nil {
+		app.janitor.Start()
+	}
+
 	// 启动 gRPC Agent Server
 	if app.grpcAgentSrv != nil {
 		if err := app.grpcAgentSrv.Start(); err != nil {
@@ -611,6 +1489,17 @@ func (app *App) Stop(ctx context.Context) error {
 		app.grpcAgentSrv.Stop()
 	}
 
+	// 停止组织策略刷新
+	app.orgPolicy.Stop()
+
+	// 停止定时任务与心跳
+	if app.cron != nil {
+		app.cron.Stop()
+	}
+	if app.heartbeat != nil {
+		app.heartbeat.Stop()
+	}
+
 	// 停止Telegram适配器
 	if app.telegramAdapter != nil {
 		app.telegramAdapter.Stop()
@@ -621,6 +1510,68 @@ func (app *App) Stop(ctx context.Context) error {
 		app.logger.Error("Failed to stop HTTP server", zap.Error(err))
 	}
 
+	// 取消进行中的 GitHub 回复
+	if app.githubResponder != nil {
+		app.githubResponder.Stop()
+	}
+
+	// 取消进行中的流水线
+	if app.workflows != nil {
+		app.workflows.Stop()
+	}
+
+	// 停止任务工作池（执行中的任务保持未确认，重启后接管）
+	if app.jobPool != nil {
+		app.jobPool.Stop()
+	}
+
+	// 停止工作区索引监听
+	if app.workspaceIndex != nil {
+		app.workspaceIndex.Close()
+	}
+
+	// 停止构建缓存预热
+	if app.buildWarmer != nil {
+		app.buildWarmer.Close()
+	}
+
+	// 停止泄漏资源清理
+	if app.janitor != nil {
+		app.janitor.Stop()
+	}
+
+	// 结束 terminal 工具的 shell 会话
+	if app.terminals != nil {
+		app.terminals.Close()
+	}
+
+	// 关闭语言服务器
+	if app.lsp != nil {
+		app.lsp.Shutdown()
+	}
+
+	// 断开 MCP 服务器连接
+	if app.mcpManager != nil {
+		app.mcpManager.Close()
+	}
+
+	// 停止数据保留清理（需在关闭数据库前）
+	if app.retention != nil {
+		app.retention.Stop()
+	}
+
+	// 关闭事件总线（所有 run 已停止，排空剩余事件）
+	if app.events != nil {
+		app.events.Close()
+	}
+	// 等待进行中的通知投递（各自受 timeout 限制）
+	app.notifier.Close()
+
+	// 刷写模型统计（需在关闭数据库前）
+	if app.modelStats != nil {
+		app.modelStats.Stop()
+	}
+
 
 
 
@@ -654,6 +1605,22 @@ func (app *App) AppConfig() *config.Config {
 	return app.config
 }
 
+// OutputPipeline returns the agent.output post-processing pipeline (nil = none)
+func (app *App) OutputPipeline() *service.OutputPipeline {
+	return app.output
+}
+
+// CarryOverPolicy returns the agent.carry_over policy for /new (nil = disabled)
+func (app *App) CarryOverPolicy() *service.CarryOverPolicy {
+	return app.newCarryOverPolicy()
+}
+
+// MentionPreprocessor resolves @file / #symbol mentions against the given
+// workspace (agent.mentions; nil = disabled)
+func (app *App) MentionPreprocessor(workspace string) *service.MentionPreprocessor {
+	return app.newMentionPreprocessor(workspace)
+}
+
 // AgentLoop returns the agent loop instance (used by CLI/TUI)
 func (app *App) AgentLoop() *service.AgentLoop {
 	return app.agentLoop
@@ -664,61 +1631,297 @@ func (app *App) PromptEngine() *prompt.PromptEngine {
 	return app.promptEngine
 }
 
+// LLMClient returns the LLM router (used by CLI one-shot commands)
+func (app *App) LLMClient() service.LLMClient {
+	return app.llmRouter
+}
+
 // ToolRegistry returns the tool registry (used by CLI/TUI)
 func (app *App) ToolRegistry() domaintool.Registry {
 	return app.toolRegistry
 }
 
+// ToolCatalog describes every registered tool with its kind, risk class and
+// approval requirement under the current security config (GET /v1/tools, CLI export)
+func (app *App) ToolCatalog() *toolpkg.ToolCatalog {
+	var approval func(string) string
+	if app.securityHook != nil {
+		approval = app.securityHook.ApprovalPolicy
+	}
+	catalog := toolpkg.DescribeTools(app.toolRegistry, approval)
+	catalog.Disabled = disabledTools(app.config.Agent.Tools)
+	if app.securityHook != nil {
+		catalog.ApprovalMode = app.securityHook.GetConfig().ApprovalMode
+	}
+	return catalog
+}
+
+// disabledTools 配置中关闭的工具: enabled=false 的 agent.tools.registry 条目, docs.disabled
+func disabledTools(cfg config.ToolsConfig) []string {
+	var names []string
+	for _, reg := range cfg.Registry {
+		if !reg.Enabled {
+			names = append(names, reg.Name)
+		}
+	}
+	if cfg.Docs.Disabled {
+		names = append(names, "docs_lookup")
+	}
+	return names
+}
+
+// Sandbox returns the tool sandbox, nil if it failed to initialize (used by evals)
+func (app *App) Sandbox() *sandbox.ProcessSandbox {
+	return app.sandbox
+}
+
 // telegramMessageHandler 实现 telegram.MessageHandler + telegram.RunController 接口
 // 通过 agentLoop.Run() + DraftStream 实现流式 TG 消息输出
-// 支持对话打断: 新消息自动取消旧的运行中 agent loop
+// 支持对话打断: 新消息自动取消旧的运行中 agent loop;
+// steer 模式下新消息改为在下一步注入当前运行 (service.SteeringQueue)
 type telegramMessageHandler struct {
 	agentLoop      *service.AgentLoop
 	toolExec       service.ToolExecutor
+	toolRegistry   domaintool.Registry // 工具分组 (系统提示词 Tooling 按类型列出)
 	promptEngine   *prompt.PromptEngine
 	tgAdapter      *telegram.Adapter
 	logger         *zap.Logger
 	sessionManager telegram.SessionManager
 	workspaceDir   string
+	// 长时间运行: 超过 longRunNotice 发进度提醒; 超过 idleNotice 且用户未操作时完成后再提醒
+	longRunNotice time.Duration
+	idleNotice    time.Duration
+	// steer 模式 (telegram.busy_mode: steer): 运行中的新消息作为补充指令注入, /stop 仍是硬中止
+	steerMode bool
+	// 按任务自动选模型 (agent.routing); routeDefault 为未用 /route 覆盖的会话的开关
+	modelRouter  *service.ModelRouter
+	routeDefault bool
+	// 草稿模式 (agent.draft); draftDefault 为未用 /draft 覆盖的会话的开关
+	draftPolicy  *service.DraftPolicy
+	draftDefault bool
+	// 命名代理 (/agent switch): 人设、可用工具、模型与温度
+	agentRepo repository.AgentRepository
+	// 用户发来的文档索引 (query_document), nil = 未启用
+	documents *toolpkg.DocumentIndex
+	// 消息中链接的预读取 (agent.links), nil = 关闭
+	links *service.LinkPreprocessor
+	// 消息中 @文件 / #符号 的预加载 (agent.mentions), nil = 关闭
+	mentions *service.MentionPreprocessor
+	// 投递前的后处理 (agent.output), nil = 原样投递
+	output *service.OutputPipeline
+	// 预算池 (agent.budgets), nil = 不限额
+	budgets *service.BudgetManager
+	// /new 的会话要点延续 (agent.carry_over), nil = 未启用
+	carryOver *service.CarryOverPolicy
+	// /tts 语音回复, nil = 未启用
+	tts *ttsController
 	// 每个 chatID 的对话历史
 	histories sync.Map // map[int64][]service.LLMMessage
-	// 每个 chatID 的活跃运行 (用于打断)
-	activeRuns sync.Map // map[int64]context.CancelFunc
+	// 每个 chatID 的活跃运行 (用于打断与补充指令)
+	activeRuns sync.Map // map[int64]*activeRun
+	// 👍/👎 反应与 /feedback 的样本来源, nil = 不记录
+	feedback *feedbackCollector
+}
+
+// activeRun 一次进行中的运行
+type activeRun struct {
+	cancel context.CancelCauseFunc // cause 为 *service.AbortError
+	steer  *service.SteeringQueue  // nil = 非 steer 模式
+}
+
+// routeModel 为本条消息自动选模型; 会话 /route 设置优先于配置默认开关
+func (h *telegramMessageHandler) routeModel(msg *telegram.IncomingMessage, history []service.LLMMessage) (service.RouteDecision, bool) {
+	if h.modelRouter == nil {
+		return service.RouteDecision{}, false
+	}
+	rs, _ := h.sessionManager.(telegram.RouteSettings)
+	enabled := h.routeDefault
+	if rs != nil {
+		switch rs.GetRouteMode(msg.ChatID) {
+		case "on":
+			enabled = true
+		case "off":
+			enabled = false
+		}
+	}
+	if !enabled {
+		return service.RouteDecision{}, false
+	}
+
+	d, ok := h.modelRouter.Route(service.RouteInput{
+		Intent:        prompt.AnalyzeIntent(msg.Text).String(),
+		ContextTokens: service.EstimateTokens(history) + service.EstimateTokens([]service.LLMMessage{{Role: "user", Content: msg.Text}}),
+		HasImages:     hasImages(msg),
+	})
+	if !ok {
+		return d, false
+	}
+	h.logger.Info("Model routed",
+		zap.Int64("chat_id", msg.ChatID),
+		zap.String("model", d.Model),
+		zap.String("route", d.String()),
+	)
+	if rs != nil {
+		rs.SetLastRoute(msg.ChatID, d.String())
+	}
+	return d, true
+}
+
+// draftEnabled 会话是否启用草稿模式: /draft 的选择, 未设置时用配置默认
+func (h *telegramMessageHandler) draftEnabled(chatID int64) bool {
+	if h.draftPolicy == nil {
+		return false
+	}
+	if ds, ok := h.sessionManager.(telegram.DraftSettings); ok {
+		switch ds.GetDraftMode(chatID) {
+		case "on":
+			return true
+		case "off":
+			return false
+		}
+	}
+	return h.draftDefault
+}
+
+// agentProfile 会话通过 /agent switch 选择的代理; 默认代理或代理已被删除时返回 false
+func (h *telegramMessageHandler) agentProfile(ctx context.Context, chatID int64) (service.AgentProfile, bool) {
+	as, ok := h.sessionManager.(telegram.AgentSettings)
+	if !ok || h.agentRepo == nil {
+		return service.AgentProfile{}, false
+	}
+	id := as.GetAgent(chatID)
+	if id == "" || id == entity.DefaultAgentID {
+		return service.AgentProfile{}, false
+	}
+	agent, err := h.agentRepo.FindByID(ctx, id)
+	if err != nil {
+		h.logger.Warn("Chat agent not found, using the default agent",
+			zap.Int64("chat_id", chatID),
+			zap.String("agent", id),
+			zap.Error(err),
+		)
+		return service.AgentProfile{}, false
+	}
+	return service.AgentProfileOf(agent), true
+}
+
+// hasImages reports whether the message carries a photo or image file.
+func hasImages(msg *telegram.IncomingMessage) bool {
+	isImage := func(m telegram.MediaInfo) bool {
+		return m.Type == telegram.MediaTypePhoto || strings.HasPrefix(m.MimeType, "image/")
+	}
+	if msg.Media != nil && isImage(*msg.Media) {
+		return true
+	}
+	for _, m := range msg.MediaGroup {
+		if isImage(m) {
+			return true
+		}
+	}
+	return false
 }
 
 // maxHistoryPairs 最多保留的对话对数 (user+assistant = 1 pair)
 const maxHistoryPairs = 30
 
 func (h *telegramMessageHandler) HandleMessage(ctx context.Context, msg *telegram.IncomingMessage) (*telegram.OutgoingMessage, error) {
-	// ===== 打断机制: 取消此 chatID 之前的运行 =====
-	if oldCancel, ok := h.activeRuns.Load(msg.ChatID); ok {
-		oldCancel.(context.CancelFunc)()
+	// 预算池: 用完时不开始运行 (也不打断进行中的运行), 告知剩余额度与重置时间
+	budgetSubject := telegram.BudgetSubject(msg.ChatID, msg.UserID)
+	if h.budgets != nil {
+		if status, exhausted := h.budgets.Check(ctx, budgetSubject); exhausted {
+			return &telegram.OutgoingMessage{
+				ChatID:    msg.ChatID,
+				Text:      telegram.FormatBudgetRefusal(h.locale(msg.ChatID), status),
+				ParseMode: "HTML",
+			}, nil
+		}
+	}
+	if v, ok := h.activeRuns.Load(msg.ChatID); ok {
+		old := v.(*activeRun)
+		// ===== steer 模式: 纯文本消息排队, 在下一步注入当前运行 =====
+		if old.steer != nil && msg.Media == nil && len(msg.MediaGroup) == 0 && strings.TrimSpace(msg.Text) != "" {
+			if old.steer.Push(msg.Text) {
+				h.logger.Info("Queued steering message for active run",
+					zap.Int64("chat_id", msg.ChatID),
+				)
+				h.tgAdapter.SendTyping(msg.ChatID)
+				return nil, nil
+			}
+		}
+		// ===== 打断机制: 取消此 chatID 之前的运行 =====
+		old.cancel(service.NewAbortError(service.AbortInterrupted, ""))
 		h.logger.Info("Interrupted previous run",
 			zap.Int64("chat_id", msg.ChatID),
 		)
 	}
 
 	// 创建可取消的上下文, 注册到 activeRuns
-	runCtx, runCancel := context.WithCancel(ctx)
+	runCtx, runCancel := context.WithCancelCause(ctx)
 	runCtx = WithChatID(runCtx, msg.ChatID)     // for SecurityHook
 	runCtx = toolpkg.WithChatID(runCtx, msg.ChatID) // for media tools (send_photo, send_document)
-	h.activeRuns.Store(msg.ChatID, runCancel)
+	runCtx = service.WithTranscriptSource(runCtx, fmt.Sprintf("telegram:%d", msg.ChatID))
+	runCtx = service.WithChannel(runCtx, "telegram")
+	runCtx = service.WithBudgetSubject(runCtx, budgetSubject)
+	run := &activeRun{cancel: runCancel}
+	if h.steerMode {
+		run.steer = service.NewSteeringQueue()
+		runCtx = service.WithSteering(runCtx, run.steer)
+	}
+	h.activeRuns.Store(msg.ChatID, run)
 	defer func() {
-		runCancel()
-		h.activeRuns.Delete(msg.ChatID)
+		stopped := service.AbortReasonFromContext(runCtx) == service.AbortUserStop
+		runCancel(nil)
+		h.activeRuns.CompareAndDelete(msg.ChatID, run)
+		if run.steer != nil {
+			// 最后一步之后才到的补充指令: /stop 时丢弃, 否则作为新一轮运行
+			if rest := run.steer.Close(); len(rest) > 0 && !stopped {
+				go h.HandleMessage(ctx, &telegram.IncomingMessage{
+					MessageID: msg.MessageID,
+					ChatID:    msg.ChatID,
+					UserID:    msg.UserID,
+					Username:  msg.Username,
+					Text:      strings.Join(rest, "\n\n"),
+					Timestamp: time.Now(),
+				})
+			}
+		}
 	}()
 
 	// 发送 typing 状态
 	h.tgAdapter.SendTyping(msg.ChatID)
 
+	// 文档附件: 建索引, 消息里只放摘要, 全文由 query_document 检索
+	msg = h.ingestDocument(runCtx, msg)
+
+	// 消息里的 @文件 / #符号: 内容随用户消息交给模型, 提及改写为解析到的路径与位置
+	if h.mentions != nil {
+		if text, parts := h.mentions.Prepare(runCtx, msg.Text); len(parts) > 0 {
+			msg.Text = text
+			runCtx = service.WithContextParts(runCtx, parts...)
+		}
+	}
+
+	// 消息里的链接: 运行前抓取 (过长则摘要), 随用户消息一起交给模型
+	if h.links != nil {
+		if parts := h.links.Prepare(runCtx, msg.Text); len(parts) > 0 {
+			runCtx = service.WithContextParts(runCtx, parts...)
+		}
+	}
+
 	// 组装 system prompt (两层架构)
 	toolNames := make([]string, 0)
 	toolSummaries := make(map[string]string)
+	toolCategories := make(map[string]string)
 	for _, d := range h.toolExec.GetDefinitions() {
 		toolNames = append(toolNames, d.Name)
 		if d.Description != "" {
 			toolSummaries[d.Name] = d.Description
 		}
+		if h.toolRegistry != nil {
+			if t, ok := h.toolRegistry.Get(d.Name); ok {
+				toolCategories[d.Name] = toolpkg.ToolCategory(t)
+			}
+		}
 	}
 
 	// 获取当前模型名称
@@ -726,24 +1929,83 @@ func (h *telegramMessageHandler) HandleMessage(ctx context.Context, msg *telegra
 	if h.sessionManager != nil {
 		modelName = h.sessionManager.GetCurrentModel(msg.ChatID)
 	}
+	// 会话级采样参数 (/params), 由 agent loop 写入每次 LLM 请求
+	if ps, ok := h.sessionManager.(telegram.ModelParamsSettings); ok {
+		runCtx = service.WithModelParams(runCtx, ps.GetModelParams(msg.ChatID))
+	}
+	// 思考级别 (/think), 由 agent loop 按模型策略映射到请求参数
+	if ts, ok := h.sessionManager.(telegram.SessionSettings); ok {
+		runCtx = service.WithThinkLevel(runCtx, ts.GetThinkLevel(msg.ChatID))
+	}
+
+	// 加载对话历史
+	history := h.getHistory(msg.ChatID)
+
+	// 回复语言: /lang 显式选择, 否则跟随对话的语言, 都无法判断时用界面语言
+	replyLang := ""
+	if rs, ok := h.sessionManager.(telegram.ReplyLanguageSettings); ok {
+		replyLang = rs.GetReplyLanguage(msg.ChatID)
+	}
+	if replyLang = service.ReplyLanguage(replyLang, msg.Text, history); replyLang == "" {
+		replyLang = string(h.locale(msg.ChatID))
+	}
+	runCtx = service.WithReplyLanguage(runCtx, replyLang)
+	// 任务意图: 工具定义裁剪 (agent.tools.subset) 按它排序
+	runCtx = service.WithTaskIntent(runCtx, prompt.AnalyzeIntent(msg.Text).String())
+
+	// 命名代理: 固定了模型时替代会话模型与自动选模型; 工具与温度由 agent loop 应用
+	profile, hasProfile := h.agentProfile(ctx, msg.ChatID)
+	if hasProfile {
+		runCtx = service.WithAgentProfile(runCtx, profile)
+		if profile.Model != "" {
+			modelName = profile.Model
+		}
+	}
+
+	// 自动选模型: 按意图、上下文大小、是否带图片匹配路由表, 未命中则用会话模型
+	if !hasProfile || profile.Model == "" {
+		if d, ok := h.routeModel(msg, history); ok {
+			modelName = d.Model
+			runCtx = service.WithRouteDecision(runCtx, d)
+		}
+	}
+	// 草稿模式: 便宜模型先起草, 昂贵模型只审核 (第一步有效, 草稿需要工具时回到单模型)
+	if h.draftEnabled(msg.ChatID) {
+		runCtx = service.WithDraft(runCtx, *h.draftPolicy)
+	}
 
 	// Build unified system prompt (channel-aware assembly)
 	systemPrompt := ""
 	if h.promptEngine != nil {
+		// 会话提示词变量 (/setvar), 覆盖 agent.prompt_vars
+		var vars map[string]string
+		if pv, ok := h.sessionManager.(telegram.PromptVarSettings); ok {
+			vars = pv.GetPromptVars(msg.ChatID)
+		}
+		if hasProfile && len(profile.Tools) > 0 {
+			allowed := toolNames[:0]
+			for _, name := range toolNames {
+				if profile.AllowsTool(name) {
+					allowed = append(allowed, name)
+				}
+			}
+			toolNames = allowed
+		}
 		systemPrompt = h.promptEngine.Assemble(prompt.PromptContext{
 			Channel:         "telegram",
 			RegisteredTools: toolNames,
 			ToolSummaries:   toolSummaries,
+			ToolCategories:  toolCategories,
 			ModelName:       modelName,
 			UserMessage:     msg.Text,
 			Workspace:       h.workspaceDir,
+			Vars:            vars,
+			Language:        replyLang,
+			AgentName:       profile.Name,
+			Persona:         profile.Persona,
 		})
 	}
 
-
-	// 加载对话历史
-	history := h.getHistory(msg.ChatID)
-
 	// 运行 agent loop (异步, 通过 eventCh 流式输出)
 	result, eventCh := h.agentLoop.Run(runCtx, systemPrompt, msg.Text, history, modelName)
 
@@ -752,9 +2014,13 @@ func (h *telegramMessageHandler) HandleMessage(ctx context.Context, msg *telegra
 	// Phase 2: 删除状态消息 → 发送完整回复
 	staged := h.tgAdapter.CreateStagedReply(msg.ChatID)
 	_ = staged.StatusThinking()
+	runStart := time.Now()
+	stopNotice := h.startLongRunNotice(msg.ChatID, staged, runStart)
+	defer stopNotice()
 
 	var lastSegment strings.Builder // Accumulated text from final segment (after last tool result)
 	interrupted := false
+	runFailed := false // 非中止的错误 (LLM 失败等), 结果不写入历史
 
 	for event := range eventCh {
 		// 检查是否被打断
@@ -783,24 +2049,62 @@ func (h *telegramMessageHandler) HandleMessage(ctx context.Context, msg *telegra
 			}
 
 		case entity.EventError:
-			_ = staged.StatusCustom("❌ " + event.Error)
+			if event.AbortReason != "" {
+				_ = staged.StatusCustom(h.locale(msg.ChatID).T(service.AbortReason(event.AbortReason).MessageKey()))
+				break
+			}
+			runFailed = true
+			kind := service.ClassifyError(errors.New(event.Error), "", "").Kind
+			_ = staged.StatusCustom("❌ " + h.locale(msg.ChatID).T(kind.MessageKey()) + "\n" + event.Error)
 
 		case entity.EventStepDone:
 			if event.StepInfo != nil {
 				_ = staged.StatusStep(event.StepInfo.Step, 0)
 			}
 			h.tgAdapter.SendTyping(msg.ChatID)
+
+		case entity.EventSteer:
+			// 之前的文字是对旧指令的回答, 最终回复只取补充指令之后的部分
+			lastSegment.Reset()
+			_ = staged.StatusCustom(h.locale(msg.ChatID).T("run.steered"))
+
+		case entity.EventCostWarning:
+			if p := event.Preflight; p != nil {
+				loc := h.locale(msg.ChatID)
+				text := loc.Tf("run.large_req", p.InputTokens/1000, p.Model)
+				if p.CostUSD > 0 {
+					text += loc.Tf("run.large_cost", p.CostUSD)
+				}
+				_ = staged.StatusCustom(text)
+			}
+
+		case entity.EventQueued:
+			if q := event.Queue; q != nil {
+				_ = staged.StatusCustom(h.locale(msg.ChatID).Tf("run.queued", q.Provider, q.Position, q.Wait.Round(time.Second)))
+			}
+		case entity.EventSlow:
+			if sl := event.Slow; sl != nil {
+				_ = staged.StatusCustom(h.locale(msg.ChatID).Tf(sl.MessageKey(), sl.Provider, sl.Waited.Round(time.Second)))
+			}
 		}
 	}
 
-	// 处理被打断的情况
+	stopNotice()
+
+	// 处理中止 (/stop、新消息打断、预算耗尽、超时): 按原因提示, 部分输出带标记写入历史
+	abort := result.AbortReason
 	if interrupted {
-		partial := lastSegment.String()
-		if partial == "" {
-			partial = "(被用户打断)"
+		// 提前退出事件循环时 loop 仍可能在写 result, 只读 ctx 上的原因
+		abort = service.AbortReasonFromContext(runCtx)
+	}
+	if abort != service.AbortNone {
+		if abort == service.AbortBudget {
+			h.tgAdapter.Audit(telegram.AuditBudget, msg.ChatID, msg.UserID, "audit.budget", result.TotalTokens)
+		}
+		h.deliverAborted(msg, run.userTurn(msg.Text), staged, abort, lastSegment.String())
+		if !abort.UserInitiated() {
+			h.notifyIfIdle(msg, runStart, "run.ended_idle")
 		}
-		h.appendHistory(msg.ChatID, msg.Text, partial+" [已打断]")
-		_ = staged.DeliverWithSuffix(h.tgAdapter, partial, "⏹ <i>已打断</i>")
 		return nil, nil
 	}
 
@@ -815,7 +2119,7 @@ func (h *telegramMessageHandler) HandleMessage(ctx context.Context, msg *telegra
 
 	isEmpty := strings.TrimSpace(finalText) == ""
 	if isEmpty {
-		finalText = "(无输出)"
+		finalText = h.locale(msg.ChatID).T("run.no_output")
 	}
 
 	h.logger.Info("[DIAG] Delivering final response to TG",
@@ -827,31 +2131,168 @@ func (h *telegramMessageHandler) HandleMessage(ctx context.Context, msg *telegra
 
 	// Only append valid responses to history — empty/failed responses pollute context
 	// and cause the model to ignore subsequent user prompts.
-	if !isEmpty {
-		h.appendHistory(msg.ChatID, msg.Text, finalText)
+	// 被内容过滤拦截的回合同样不写入，否则下一轮会带着拒答继续被拦截。
+	keepHistory := !isEmpty && !runFailed && !result.Filtered
+	if keepHistory {
+		h.appendHistory(msg.ChatID, run.userTurn(msg.Text), finalText)
 	} else {
 		h.logger.Warn("[DIAG] Skipping history append for empty response",
 			zap.Int64("chat_id", msg.ChatID),
+			zap.Bool("filtered", result.Filtered),
 			zap.String("raw_final", result.FinalContent),
 			zap.String("raw_segment", lastSegment.String()),
 		)
 	}
 
-	if err := staged.DeliverWithSuffix(h.tgAdapter, finalText, "<i>— NGOClaw</i>"); err != nil {
+	if !isEmpty {
+		finalText = h.processOutput(runCtx, msg.ChatID, finalText, result.ModelUsed)
+	}
+
+	suffix := "<i>— NGOClaw</i>"
+	if result.Draft == service.DraftApproved || result.Draft == service.DraftEdited {
+		suffix = "<i>" + html.EscapeString(h.locale(msg.ChatID).Tf("draft."+string(result.Draft), h.draftPolicy.Model, result.ModelUsed)) + "</i>\n" + suffix
+	}
+	if result.TestReport != nil {
+		suffix = "<i>🧪 " + html.EscapeString(result.TestReport.Summary()) + "</i>\n" + suffix
+	}
+	if err := staged.DeliverWithSuffix(h.tgAdapter, finalText, suffix); err != nil {
 		h.logger.Error("[DIAG] TG delivery FAILED", zap.Error(err), zap.Int64("chat_id", msg.ChatID))
 	} else {
 		h.logger.Info("[DIAG] TG delivery succeeded", zap.Int64("chat_id", msg.ChatID))
+		if h.feedback != nil && keepHistory {
+			usedModel := result.ModelUsed
+			if usedModel == "" {
+				usedModel = modelName
+			}
+			h.feedback.RecordRun(msg.ChatID, staged.DeliveredMessageIDs(), usedModel, msg.Text, history, finalText)
+		}
+		// 开启 /tts 的会话: 文字回复之后再发一条语音 (合成较慢, 不阻塞本轮结束)
+		if h.tts != nil && !isEmpty && !runFailed {
+			go h.tts.SpeakReply(msg.ChatID, msg.MessageID, finalText)
+		}
 	}
+	h.notifyIfIdle(msg, runStart, "run.done_idle")
 	return nil, nil
 }
 
+// startLongRunNotice 运行超过 longRunNotice 后发送带停止按钮的进度摘要, 之后每隔
+// 同样时长换一条新的 (新消息才会推送)。返回的函数可重复调用, 结束提醒并删除消息。
+func (h *telegramMessageHandler) startLongRunNotice(chatID int64, staged *telegram.StagedReply, start time.Time) func() {
+	if h.longRunNotice <= 0 {
+		return func() {}
+	}
+	notice := h.tgAdapter.NewRunNotice(chatID)
+	done := make(chan struct{})
+	exited := make(chan struct{})
+
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(h.longRunNotice)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				completed, active := staged.Progress()
+				loc := h.locale(chatID)
+				text := telegram.FormatRunProgress(loc, time.Since(start), completed, active)
+				if err := notice.Post(text, loc); err != nil {
+					h.logger.Warn("Long-run notice failed", zap.Int64("chat_id", chatID), zap.Error(err))
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-exited
+			notice.Clear()
+		})
+	}
+}
+
+// notifyIfIdle 运行超过 idleNotice 且用户在运行期间没有任何操作时, 回复用户的原消息
+// 提醒结果已出 (群聊里回复会通知到提问者; 结果本身可能已被后续消息淹没)。
+func (h *telegramMessageHandler) notifyIfIdle(msg *telegram.IncomingMessage, start time.Time, key string) {
+	elapsed := time.Since(start)
+	if h.idleNotice <= 0 || elapsed < h.idleNotice {
+		return
+	}
+	if h.tgAdapter.LastActivity(msg.ChatID).After(start) {
+		return // 用户还在 (点过按钮或发过命令), 不打扰
+	}
+	_ = h.tgAdapter.SendMessage(&telegram.OutgoingMessage{
+		ChatID:    msg.ChatID,
+		Text:      h.locale(msg.ChatID).Tf(key, telegram.FormatRunDuration(elapsed)),
+		ParseMode: "HTML",
+		ReplyToID: msg.MessageID,
+	})
+}
+
+
+// deliverAborted 发送中止提示并保留部分输出。
+// 用户主动停止/打断时总是记入历史 (下一轮知道上一问没答完); 预算、超时等
+// 只在有部分输出时记入, 避免空的失败回合污染上下文。
+func (h *telegramMessageHandler) deliverAborted(msg *telegram.IncomingMessage, userTurn string, staged *telegram.StagedReply, abort service.AbortReason, segment string) {
+	notice := h.locale(msg.ChatID).T(abort.MessageKey())
+	partial := strings.TrimSpace(service.StripReasoningTags(segment))
+
+	h.logger.Info("Run aborted",
+		zap.Int64("chat_id", msg.ChatID),
+		zap.String("reason", string(abort)),
+		zap.Int("partial_len", len(partial)),
+	)
+
+	if partial == "" {
+		if abort.UserInitiated() {
+			h.appendHistory(msg.ChatID, userTurn, "(no output) "+abort.HistoryMarker())
+		}
+		_ = staged.DeliverWithSuffix(h.tgAdapter, notice, "")
+		return
+	}
+	h.appendHistory(msg.ChatID, userTurn, partial+" "+abort.HistoryMarker())
+	partial = h.processOutput(context.Background(), msg.ChatID, partial, "")
+	_ = staged.DeliverWithSuffix(h.tgAdapter, partial, "<i>"+notice+"</i>")
+}
+
+// processOutput 投递前执行 agent.output 后处理 (历史中保留原文);
+// on_error: withhold 的处理器失败时改为投递拦截提示
+func (h *telegramMessageHandler) processOutput(ctx context.Context, chatID int64, text, model string) string {
+	out, err := h.output.Process(ctx, text, service.OutputInfo{Channel: "telegram", Model: model})
+	if err != nil {
+		return h.locale(chatID).T("run.withheld")
+	}
+	return out
+}
+
+// userTurn 写入历史的用户回合: 原消息 + 运行中注入的补充指令
+func (r *activeRun) userTurn(text string) string {
+	if r.steer == nil {
+		return text
+	}
+	for _, extra := range r.steer.Injected() {
+		text += "\n\n" + extra
+	}
+	return text
+}
+
+// locale 返回指定 chat 的界面语言
+func (h *telegramMessageHandler) locale(chatID int64) i18n.Locale {
+	if ls, ok := h.sessionManager.(telegram.LocaleSettings); ok {
+		return i18n.Resolve(ls.GetLocale(chatID))
+	}
+	return i18n.Default
+}
 
 // ===== RunController 接口实现 =====
 
 // AbortRun 中止指定 chatID 的当前运行 (供 /stop 命令调用)
 func (h *telegramMessageHandler) AbortRun(chatID int64) bool {
-	if cancel, ok := h.activeRuns.Load(chatID); ok {
-		cancel.(context.CancelFunc)()
+	if run, ok := h.activeRuns.Load(chatID); ok {
+		run.(*activeRun).cancel(service.NewAbortError(service.AbortUserStop, ""))
 		return true
 	}
 	return false
@@ -876,6 +2317,78 @@ func (h *telegramMessageHandler) GetRunState(chatID int64) string {
 // ClearHistory 清除指定 chatID 的对话历史
 func (h *telegramMessageHandler) ClearHistory(chatID int64) {
 	h.histories.Delete(chatID)
+	if h.documents != nil {
+		h.documents.Clear(chatID)
+	}
+}
+
+// ===== SessionCarrier 接口实现 =====
+
+// carryOverTimeout 限制 /new 等待要点总结的时间
+const carryOverTimeout = 30 * time.Second
+
+// CarryOver 用会话当前模型 (或 agent.carry_over.model) 总结当前对话中值得延续的事实
+func (h *telegramMessageHandler) CarryOver(ctx context.Context, chatID int64) (string, error) {
+	history := h.getHistory(chatID)
+	if len(history) == 0 {
+		return "", nil
+	}
+	model := ""
+	if h.sessionManager != nil {
+		model = h.sessionManager.GetCurrentModel(chatID)
+	}
+	ctx, cancel := context.WithTimeout(ctx, carryOverTimeout)
+	defer cancel()
+	summary, err := h.agentLoop.CarryOverSummary(ctx, history, *h.carryOver, model)
+	if err != nil {
+		h.logger.Warn("Carry-over summary failed", zap.Int64("chat_id", chatID), zap.Error(err))
+	}
+	return summary, err
+}
+
+// SeedHistory 以延续摘要开始新会话的对话历史
+func (h *telegramMessageHandler) SeedHistory(chatID int64, summary string) {
+	h.histories.Store(chatID, service.CarryOverHistory(summary))
+}
+
+// ingestDocument 把文档附件加入 chat 的文档索引, 返回正文前附上文档说明的消息副本;
+// 不是文档、不支持的格式或未启用索引时原样返回
+func (h *telegramMessageHandler) ingestDocument(ctx context.Context, msg *telegram.IncomingMessage) *telegram.IncomingMessage {
+	if h.documents == nil || msg.Media == nil || msg.Media.Type != telegram.MediaTypeDocument || len(msg.MediaData) == 0 {
+		return msg
+	}
+	name := msg.Media.FileName
+	if name == "" {
+		name = "document"
+	}
+	info, err := h.documents.Ingest(ctx, msg.ChatID, name, msg.Media.MimeType, msg.MediaData)
+	if errors.Is(err, toolpkg.ErrUnsupportedDocument) {
+		return msg
+	}
+
+	var note string
+	if err != nil {
+		h.logger.Warn("Document ingestion failed",
+			zap.Int64("chat_id", msg.ChatID),
+			zap.String("file", name),
+			zap.Error(err),
+		)
+		note = fmt.Sprintf("[Document received: %s — could not read it: %v]", name, err)
+	} else {
+		size := fmt.Sprintf("%d passages, ~%d tokens", info.Chunks, info.Tokens)
+		if info.Pages > 0 {
+			size = fmt.Sprintf("%d pages, %s", info.Pages, size)
+		}
+		note = fmt.Sprintf("[Document received: %s — %s. It is indexed for this chat: use query_document to look up the passages you need instead of guessing.]\nBeginning:\n%s",
+			name, size, info.Intro)
+	}
+
+	out := *msg
+	out.Text = note
+	if strings.TrimSpace(msg.Text) != "" {
+		out.Text = note + "\n\n" + msg.Text
+	}
+	return &out
 }
 
 // GetHistory returns conversation history as simplified messages for session-memory saving.
@@ -922,3 +2435,235 @@ func (h *telegramMessageHandler) appendHistory(chatID int64, userText, assistant
 	h.histories.Store(chatID, history)
 }
 
+// docsLookupConfig maps agent.tools.docs onto the docs_lookup tool config;
+// nil when the tool is disabled.
+func docsLookupConfig(cfg config.DocsLookupConfig) *toolpkg.DocsLookupConfig {
+	if cfg.Disabled {
+		return nil
+	}
+	return &toolpkg.DocsLookupConfig{
+		MCPEndpoint: cfg.MCPEndpoint,
+		APIURL:      cfg.APIURL,
+		APIKey:      cfg.APIKey,
+		MaxTokens:   cfg.MaxTokens,
+	}
+}
+
+// remoteToolsConfig maps agent.tools.remote onto the remote_file /
+// remote_exec tool config; nil when no hosts are configured. Local paths
+// for upload/download resolve against the sandbox work dir.
+func remoteToolsConfig(cfg config.RemoteConfig, localDir func() string, logger *zap.Logger) *toolpkg.RemoteConfig {
+	var hosts []toolpkg.RemoteHost
+	for _, h := range cfg.Hosts {
+		if h.Alias == "" || h.Host == "" || h.User == "" {
+			logger.Warn("Remote host needs alias, host and user, skipping", zap.String("alias", h.Alias))
+			continue
+		}
+		port := h.Port
+		if port <= 0 {
+			port = 22
+		}
+		hosts = append(hosts, toolpkg.RemoteHost{
+			Alias:                 h.Alias,
+			Addr:                  net.JoinHostPort(h.Host, strconv.Itoa(port)),
+			User:                  h.User,
+			KeyPath:               h.KeyPath,
+			WorkDir:               h.WorkDir,
+			Description:           h.Description,
+			ReadOnly:              h.ReadOnly,
+			InsecureIgnoreHostKey: h.InsecureIgnoreHostKey,
+		})
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+	return &toolpkg.RemoteConfig{
+		Hosts:          hosts,
+		KnownHosts:     cfg.KnownHosts,
+		ConnectTimeout: cfg.ConnectTimeout,
+		CommandTimeout: cfg.CommandTimeout,
+		LocalDir:       localDir,
+	}
+}
+
+// visionToolConfig picks the analyze_image model: agent.tools.vision.model,
+// else the first agent.models entry with the vision capability; nil when
+// there is none.
+func visionToolConfig(cfg config.AgentConfig, client service.LLMClient, workDir func() string) *toolpkg.VisionConfig {
+	model := cfg.Tools.Vision.Model
+	if model == "" {
+	models:
+		for _, m := range cfg.Models {
+			for _, c := range m.Capabilities {
+				if strings.EqualFold(c, "vision") {
+					model = m.ID
+					break models
+				}
+			}
+		}
+	}
+	if model == "" {
+		return nil
+	}
+	return &toolpkg.VisionConfig{
+		LLM:       client,
+		Model:     model,
+		MaxBytes:  cfg.Tools.Vision.MaxBytes,
+		MaxTokens: cfg.Tools.Vision.MaxTokens,
+		WorkDir:   workDir,
+	}
+}
+
+// remoteAgentConfig converts agent.tools.peers into the remote_agent tool
+// config; nil when no usable peer is configured.
+func remoteAgentConfig(cfg config.PeersConfig, logger *zap.Logger) *toolpkg.RemoteAgentConfig {
+	var peers []toolpkg.PeerGateway
+	for _, p := range cfg.Gateways {
+		if p.Name == "" || p.URL == "" {
+			logger.Warn("Peer gateway needs name and url, skipping", zap.String("name", p.Name))
+			continue
+		}
+		transport, err := llm.NewTransport(llm.NetworkConfig{
+			Proxy:              p.Proxy,
+			CAFile:             p.CAFile,
+			InsecureSkipVerify: p.InsecureSkipVerify,
+		})
+		if err != nil {
+			logger.Warn("Peer gateway network config invalid, skipping", zap.String("name", p.Name), zap.Error(err))
+			continue
+		}
+		peers = append(peers, toolpkg.PeerGateway{
+			Name:        p.Name,
+			URL:         p.URL,
+			Token:       p.Token,
+			Model:       p.Model,
+			Description: p.Description,
+			Timeout:     p.Timeout,
+			HTTPClient:  &http.Client{Transport: transport},
+		})
+	}
+	if len(peers) == 0 {
+		return nil
+	}
+	return &toolpkg.RemoteAgentConfig{Peers: peers, MaxHops: cfg.MaxHops}
+}
+
+// apiTokens maps gateway.api_tokens to token → caller name.
+func apiTokens(cfg []config.APITokenConfig) map[string]string {
+	tokens := make(map[string]string, len(cfg))
+	for i, t := range cfg {
+		if t.Token == "" {
+			continue
+		}
+		name := t.Name
+		if name == "" {
+			name = "token" + strconv.Itoa(i+1)
+		}
+		tokens[t.Token] = name
+	}
+	return tokens
+}
+
+// agentLogWorkDir is the workspace for agent log entries about files outside
+// any git repository: the sandbox work dir, else agent.workspace.
+func (app *App) agentLogWorkDir() string {
+	if app.sandbox != nil {
+		if dir := app.sandbox.GetWorkDir(); dir != "" {
+			return dir
+		}
+	}
+	return app.config.Agent.Workspace
+}
+
+// oidcConfig maps gateway.oidc to the HTTP server's OIDC settings.
+func oidcConfig(cfg config.OIDCConfig) httpServer.OIDCConfig {
+	return httpServer.OIDCConfig{
+		Issuer:        cfg.Issuer,
+		ClientID:      cfg.ClientID,
+		ClientSecret:  cfg.ClientSecret,
+		RedirectURL:   cfg.RedirectURL,
+		Scopes:        cfg.Scopes,
+		GroupsClaim:   cfg.GroupsClaim,
+		Roles:         cfg.Roles,
+		DefaultRole:   cfg.DefaultRole,
+		SessionSecret: cfg.SessionSecret,
+		SessionTTL:    cfg.SessionTTL,
+	}
+}
+
+// terminalManager creates the terminal tool's session manager from
+// agent.tools.terminal; nil when disabled or the sandbox is unavailable.
+func terminalManager(cfg config.TerminalConfig, sbx *sandbox.ProcessSandbox, logger *zap.Logger) *toolpkg.TerminalManager {
+	if !cfg.Enabled || sbx == nil {
+		return nil
+	}
+	return toolpkg.NewTerminalManager(sbx, toolpkg.TerminalConfig{
+		MaxSessions: cfg.MaxSessions,
+		IdleTimeout: cfg.IdleTimeout,
+	}, logger)
+}
+
+// sqlToolConfig maps agent.tools.sql onto the sql_query tool config; nil
+// when no connections are configured.
+func sqlToolConfig(cfg config.SQLConfig, logger *zap.Logger) *toolpkg.SQLConfig {
+	var conns []toolpkg.SQLConnection
+	for _, c := range cfg.Connections {
+		if c.Name == "" || c.Driver == "" || c.DSN == "" {
+			logger.Warn("SQL connection needs name, driver and dsn, skipping", zap.String("name", c.Name))
+			continue
+		}
+		conns = append(conns, toolpkg.SQLConnection{
+			Name:        c.Name,
+			Driver:      strings.ToLower(c.Driver),
+			DSN:         c.DSN,
+			ReadWrite:   c.Mode == "read_write",
+			Description: c.Description,
+		})
+	}
+	if len(conns) == 0 {
+		return nil
+	}
+	return &toolpkg.SQLConfig{
+		Connections: conns,
+		MaxRows:     cfg.MaxRows,
+		MaxChars:    cfg.MaxChars,
+		Timeout:     cfg.Timeout,
+	}
+}
+
+// commandToolSpecs 从 agent.tools.registry 中取出已启用的 backend=command 工具
+func commandToolSpecs(regs []config.ToolRegConfig, logger *zap.Logger) []toolpkg.CommandToolSpec {
+	var specs []toolpkg.CommandToolSpec
+	for _, reg := range regs {
+		if !reg.Enabled {
+			continue
+		}
+		if reg.Backend != "command" {
+			logger.Warn("Tool registry backend not supported, skipping",
+				zap.String("tool", reg.Name),
+				zap.String("backend", reg.Backend),
+			)
+			continue
+		}
+		params := make([]toolpkg.CommandToolParam, len(reg.Parameters))
+		for i, p := range reg.Parameters {
+			params[i] = toolpkg.CommandToolParam{
+				Name:        p.Name,
+				Type:        p.Type,
+				Description: p.Description,
+				Required:    p.Required,
+				Enum:        p.Enum,
+			}
+		}
+		specs = append(specs, toolpkg.CommandToolSpec{
+			Name:        reg.Name,
+			Description: reg.Description,
+			Kind:        domaintool.Kind(reg.Kind),
+			Command:     reg.Command,
+			ArgsFormat:  reg.ArgsFormat,
+			Parameters:  params,
+			Timeout:     reg.Timeout,
+		})
+	}
+	return specs
+}
@@ -5,12 +5,14 @@ import (
 	"fmt"
 
 	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	toolpkg "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tool"
 )
 
 // toolBridge adapts domaintool.Registry → service.ToolExecutor.
 // This allows the AgentLoop to discover and execute tools through the shared registry.
 type toolBridge struct {
 	registry domaintool.Registry
+	guard    *toolpkg.FileGuard // nil = no per-file locking
 }
 
 // Execute implements service.ToolExecutor.Execute
@@ -23,6 +25,18 @@ func (b *toolBridge) Execute(ctx context.Context, name string, args map[string]i
 			Error:   fmt.Sprintf("tool '%s' not registered", name),
 		}, nil
 	}
+	// Reject malformed arguments before the tool runs
+	if invalid := domaintool.CheckArgs(tool, args); invalid != nil {
+		return invalid, nil
+	}
+	// Serialize writes to the same file across concurrent runs
+	if b.guard != nil {
+		unlock, err := b.guard.LockCall(ctx, tool.Kind(), args)
+		if err != nil {
+			return &domaintool.Result{Output: err.Error(), Success: false, Error: err.Error()}, nil
+		}
+		defer unlock()
+	}
 	return tool.Execute(ctx, args)
 }
 
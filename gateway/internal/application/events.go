@@ -0,0 +1,208 @@
+package application
+
+import (
+	"context"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/eventbus"
+)
+
+// Event bus topics. Subscribe to a single topic, a family ("run.*") or "*":
+//
+//	app.Events().Subscribe("tool.*", func(ctx context.Context, ev eventbus.Event) {
+//		te := ev.Payload().(ToolEvent)
+//		...
+//	})
+//
+// Handlers run asynchronously and must not block for long; events are
+// dropped (with a warning) when the bus buffer is full.
+const (
+	TopicRunStarted   = "run.started"   // RunEvent
+	TopicRunCompleted = "run.completed" // RunEvent, Result set
+	TopicRunFailed    = "run.failed"    // RunEvent, Error set
+	TopicRunAborted   = "run.aborted"   // RunEvent, Reason and Result set
+
+	TopicToolCall   = "tool.call"   // ToolEvent — passed security, about to run
+	TopicToolResult = "tool.result" // ToolEvent, Output and Success set
+
+	TopicLLMRequest  = "llm.request"  // LLMEvent
+	TopicLLMResponse = "llm.response" // LLMEvent, TokensUsed and ToolCalls set
+
+	TopicSecurityApprovalRequested = "security.approval_requested" // SecurityEvent
+	TopicSecurityApproved          = "security.approved"           // SecurityEvent
+	TopicSecurityDenied            = "security.denied"             // SecurityEvent, Error set on channel failure
+	TopicSecurityBlocked           = "security.blocked"            // SecurityEvent, call blocked by a guard rule without asking
+
+	TopicBudgetExhausted = "budget.exhausted" // BudgetEvent — once per pool, subject and window
+)
+
+// eventBusBuffer is the number of events queued before the bus starts dropping.
+const eventBusBuffer = 1024
+
+// EventMeta identifies the run an event belongs to.
+type EventMeta struct {
+	TraceID string // service.TraceIDFromContext
+	Source  string // transcript label, e.g. "telegram:12345", "job:<id>"
+	ChatID  int64  // Telegram chat, 0 for other channels
+	Cron    string // command of the cron job that started the run, "" otherwise
+}
+
+// RunEvent is the payload of run.* topics.
+type RunEvent struct {
+	EventMeta
+	Message string // user message that started the run
+	Model   string
+	Result  *service.AgentResult // completed / aborted
+	Reason  service.AbortReason  // aborted
+	Error   string               // failed
+}
+
+// ToolEvent is the payload of tool.* topics.
+type ToolEvent struct {
+	EventMeta
+	Tool    string
+	Args    map[string]interface{}
+	Output  string // result only
+	Success bool   // result only
+}
+
+// LLMEvent is the payload of llm.* topics.
+type LLMEvent struct {
+	EventMeta
+	Step       int
+	Model      string
+	Messages   int // request only
+	TokensUsed int // response only
+	ToolCalls  int // response only
+}
+
+// SecurityEvent is the payload of security.* topics.
+type SecurityEvent struct {
+	EventMeta
+	Tool  string
+	Args  map[string]interface{}
+	Mode  string // approval_mode in effect
+	Risk  string // shell risk level, empty when not analyzed
+	Guard string // project guard rule involved, if any
+	Error string
+}
+
+// BudgetEvent is the payload of budget.* topics.
+type BudgetEvent struct {
+	EventMeta
+	Status  service.BudgetStatus
+	Subject string // "user:<id>" or "chat:<id>", "" for global pools
+}
+
+// Events returns the application event bus, for extensions (metrics,
+// audit, notifications, plugins) that observe runs without touching the
+// agent loop.
+func (app *App) Events() eventbus.Bus {
+	return app.events
+}
+
+// busHook publishes agent loop lifecycle callbacks to the event bus.
+type busHook struct {
+	service.NoOpHook
+	bus eventbus.Bus
+}
+
+var (
+	_ service.AgentHook        = (*busHook)(nil)
+	_ service.RunLifecycleHook = (*busHook)(nil)
+)
+
+func newBusHook(bus eventbus.Bus) *busHook {
+	return &busHook{bus: bus}
+}
+
+// publish detaches ctx from cancellation: aborted runs publish with an
+// already-cancelled context, and handlers run after the call returns.
+func (h *busHook) publish(ctx context.Context, topic string, payload any) {
+	h.bus.Publish(context.WithoutCancel(ctx), eventbus.NewEvent(topic, payload))
+}
+
+func eventMeta(ctx context.Context) EventMeta {
+	return EventMeta{
+		TraceID: service.TraceIDFromContext(ctx),
+		Source:  service.TranscriptSourceFromContext(ctx),
+		ChatID:  ChatIDFromContext(ctx),
+		Cron:    cronCommandFromContext(ctx),
+	}
+}
+
+type cronCommandKey struct{}
+
+// withCronCommand marks the runs started with ctx as a cron job's.
+func withCronCommand(ctx context.Context, command string) context.Context {
+	return context.WithValue(ctx, cronCommandKey{}, command)
+}
+
+func cronCommandFromContext(ctx context.Context) string {
+	command, _ := ctx.Value(cronCommandKey{}).(string)
+	return command
+}
+
+func (h *busHook) OnRunStart(ctx context.Context, userMessage, model string) {
+	h.publish(ctx, TopicRunStarted, RunEvent{EventMeta: eventMeta(ctx), Message: userMessage, Model: model})
+}
+
+func (h *busHook) OnRunAbort(ctx context.Context, reason service.AbortReason, result *service.AgentResult) {
+	h.publish(ctx, TopicRunAborted, RunEvent{EventMeta: eventMeta(ctx), Model: result.ModelUsed, Result: result, Reason: reason})
+}
+
+func (h *busHook) OnComplete(ctx context.Context, result *service.AgentResult) {
+	h.publish(ctx, TopicRunCompleted, RunEvent{EventMeta: eventMeta(ctx), Model: result.ModelUsed, Result: result})
+}
+
+func (h *busHook) OnError(ctx context.Context, err error, step int) {
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+	h.publish(ctx, TopicRunFailed, RunEvent{EventMeta: eventMeta(ctx), Error: msg})
+}
+
+func (h *busHook) BeforeLLMCall(ctx context.Context, req *service.LLMRequest, step int) {
+	h.publish(ctx, TopicLLMRequest, LLMEvent{EventMeta: eventMeta(ctx), Step: step, Model: req.Model, Messages: len(req.Messages)})
+}
+
+func (h *busHook) AfterLLMCall(ctx context.Context, resp *service.LLMResponse, step int) {
+	h.publish(ctx, TopicLLMResponse, LLMEvent{
+		EventMeta:  eventMeta(ctx),
+		Step:       step,
+		Model:      resp.ModelUsed,
+		TokensUsed: resp.TokensUsed,
+		ToolCalls:  len(resp.ToolCalls),
+	})
+}
+
+func (h *busHook) BeforeToolCall(ctx context.Context, toolName string, args map[string]interface{}) bool {
+	h.publish(ctx, TopicToolCall, ToolEvent{EventMeta: eventMeta(ctx), Tool: toolName, Args: args})
+	return true
+}
+
+func (h *busHook) AfterToolCall(ctx context.Context, toolName string, output string, success bool) {
+	h.publish(ctx, TopicToolResult, ToolEvent{EventMeta: eventMeta(ctx), Tool: toolName, Output: output, Success: success})
+}
+
+// onSecurityDecision is the SecurityHook decision observer.
+func (h *busHook) onSecurityDecision(ctx context.Context, d service.SecurityDecision) {
+	topic := TopicSecurityApprovalRequested
+	switch d.Outcome {
+	case "approved":
+		topic = TopicSecurityApproved
+	case "denied":
+		topic = TopicSecurityDenied
+	case "blocked":
+		topic = TopicSecurityBlocked
+	}
+	ev := SecurityEvent{EventMeta: eventMeta(ctx), Tool: d.Tool, Args: d.Args, Mode: d.Mode, Guard: d.Guard}
+	if d.Risk != nil {
+		ev.Risk = d.Risk.Level.String()
+	}
+	if d.Err != nil {
+		ev.Error = d.Err.Error()
+	}
+	h.publish(ctx, topic, ev)
+}
@@ -0,0 +1,182 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/repository"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/interfaces/telegram"
+	"go.uber.org/zap"
+)
+
+const (
+	// feedbackTrackedMessages 可被反应关联的回复消息上限 (超出后最早的失效)
+	feedbackTrackedMessages = 2000
+	// feedbackContextTurns 上下文摘要保留的最近对话条数
+	feedbackContextTurns = 6
+	// feedbackContextChars 上下文摘要中每条消息的字符上限
+	feedbackContextChars = 300
+)
+
+// feedbackRun 一次已送达的运行, 反馈时据此组装 (prompt, context, answer) 样本
+type feedbackRun struct {
+	chatID  int64 // 会话键 (论坛话题独立)
+	model   string
+	prompt  string
+	context string
+	answer  string
+}
+
+// feedbackMessageKey 回复消息的定位键 (反应更新只带真实 chatID)
+type feedbackMessageKey struct {
+	chatID    int64
+	messageID int
+}
+
+// feedbackCollector 记录 Telegram 运行结果, 把 👍/👎 反应与 /feedback 文字存入反馈仓储。
+// 实现 telegram.ReactionHandler 与 telegram.FeedbackRecorder。
+type feedbackCollector struct {
+	repo   repository.FeedbackRepository
+	logger *zap.Logger
+
+	mu        sync.Mutex
+	last      map[int64]*feedbackRun // 会话键 → 最近一次运行 (/feedback)
+	byMessage map[feedbackMessageKey]*feedbackRun
+	order     []feedbackMessageKey
+}
+
+func newFeedbackCollector(repo repository.FeedbackRepository, logger *zap.Logger) *feedbackCollector {
+	return &feedbackCollector{
+		repo:      repo,
+		logger:    logger,
+		last:      make(map[int64]*feedbackRun),
+		byMessage: make(map[feedbackMessageKey]*feedbackRun),
+	}
+}
+
+// RecordRun 登记已送达的回复; history 为本次运行前的对话 (生成上下文摘要)
+func (c *feedbackCollector) RecordRun(chatID int64, messageIDs []int, model, prompt string, history []service.LLMMessage, answer string) {
+	run := &feedbackRun{
+		chatID:  chatID,
+		model:   model,
+		prompt:  prompt,
+		context: summarizeFeedbackContext(history),
+		answer:  answer,
+	}
+	realChat, _, _ := telegram.SplitTopicKey(chatID)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.last[chatID] = run
+	for _, id := range messageIDs {
+		key := feedbackMessageKey{chatID: realChat, messageID: id}
+		c.byMessage[key] = run
+		c.order = append(c.order, key)
+	}
+	for len(c.order) > feedbackTrackedMessages {
+		delete(c.byMessage, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// HandleReaction 实现 telegram.ReactionHandler: 👍 (save_memory) / 👎 (retry) 记为 good / bad
+func (c *feedbackCollector) HandleReaction(ctx context.Context, chatID int64, messageID int, action string) error {
+	label := ""
+	switch action {
+	case "save_memory":
+		label = entity.FeedbackGood
+	case "retry":
+		label = entity.FeedbackBad
+	default:
+		return telegram.ErrReactionIgnored
+	}
+
+	c.mu.Lock()
+	run := c.byMessage[feedbackMessageKey{chatID: chatID, messageID: messageID}]
+	c.mu.Unlock()
+	if run == nil {
+		return telegram.ErrReactionIgnored
+	}
+	return c.save(ctx, run, &entity.Feedback{
+		ChatID:    run.chatID,
+		Label:     label,
+		MessageID: messageID,
+	})
+}
+
+// RecordComment 实现 telegram.FeedbackRecorder (/feedback <text>)
+func (c *feedbackCollector) RecordComment(ctx context.Context, chatID, userID int64, text string) (bool, error) {
+	c.mu.Lock()
+	run := c.last[chatID]
+	c.mu.Unlock()
+	if run == nil {
+		return false, nil
+	}
+	err := c.save(ctx, run, &entity.Feedback{
+		ChatID:  chatID,
+		UserID:  userID,
+		Label:   entity.FeedbackComment,
+		Comment: text,
+	})
+	return err == nil, err
+}
+
+// Forget 丢弃该会话尚未反馈的运行 (/forgetme)
+func (c *feedbackCollector) Forget(chatID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.last, chatID)
+	for key, run := range c.byMessage {
+		if run.chatID == chatID {
+			delete(c.byMessage, key)
+		}
+	}
+}
+
+func (c *feedbackCollector) save(ctx context.Context, run *feedbackRun, f *entity.Feedback) error {
+	f.Source = "telegram"
+	f.Model = run.model
+	f.Prompt = run.prompt
+	f.Context = run.context
+	f.Answer = run.answer
+	f.CreatedAt = time.Now()
+	if err := c.repo.Save(ctx, f); err != nil {
+		return fmt.Errorf("save feedback: %w", err)
+	}
+	c.logger.Info("Feedback recorded",
+		zap.Int64("chat_id", f.ChatID),
+		zap.String("label", f.Label),
+		zap.String("model", f.Model),
+	)
+	return nil
+}
+
+// summarizeFeedbackContext 最近几条对话, 每条截断, 作为样本的上下文摘要
+func summarizeFeedbackContext(history []service.LLMMessage) string {
+	if len(history) > feedbackContextTurns {
+		history = history[len(history)-feedbackContextTurns:]
+	}
+	var b strings.Builder
+	for _, msg := range history {
+		text := strings.Join(strings.Fields(msg.TextContent()), " ")
+		if r := []rune(text); len(r) > feedbackContextChars {
+			text = string(r[:feedbackContextChars]) + "…"
+		}
+		if text == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "%s: %s\n", msg.Role, text)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Compile-time checks
+var (
+	_ telegram.ReactionHandler  = (*feedbackCollector)(nil)
+	_ telegram.FeedbackRecorder = (*feedbackCollector)(nil)
+)
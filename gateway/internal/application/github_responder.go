@@ -0,0 +1,211 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/github"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/jobqueue"
+	"go.uber.org/zap"
+)
+
+// maxCommentLen GitHub 评论正文上限 65536 字符, 留出标记与截断提示的余量
+const maxCommentLen = 60000
+
+// githubResponder runs the agent for @mentions received on /webhooks/github
+// and posts the answer back as an issue / PR comment. Mentions run in the
+// background (GitHub expects the webhook to answer within 10s), at most
+// cfg.Concurrency at a time.
+type githubResponder struct {
+	cfg    config.GitHubConfig
+	client *github.Client
+	runner *jobRunner
+	logger *zap.Logger
+
+	sem    chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newGitHubResponder(cfg config.GitHubConfig, client *github.Client, runner *jobRunner, logger *zap.Logger) *githubResponder {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &githubResponder{
+		cfg:    cfg,
+		client: client,
+		runner: runner,
+		logger: logger,
+		sem:    make(chan struct{}, cfg.Concurrency),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Dispatch implements handlers.GitHubDispatcher
+func (r *githubResponder) Dispatch(m *github.Mention) error {
+	repo := r.repoConfig(m.Repo)
+	if repo == nil || !r.associationAllowed(m.Association) {
+		return github.ErrNotAllowed
+	}
+	if r.ctx.Err() != nil {
+		return fmt.Errorf("github responder is shutting down")
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		select {
+		case r.sem <- struct{}{}:
+			defer func() { <-r.sem }()
+		case <-r.ctx.Done():
+			return
+		}
+		r.respond(m, repo)
+	}()
+	return nil
+}
+
+// Stop 取消进行中的运行并等待退出
+func (r *githubResponder) Stop() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+// repoConfig 在允许列表中查找仓库 (owner/repo 或 owner/*), 不区分大小写
+func (r *githubResponder) repoConfig(fullName string) *config.GitHubRepoConfig {
+	owner, _, _ := strings.Cut(fullName, "/")
+	for i := range r.cfg.Repos {
+		name := r.cfg.Repos[i].Name
+		if strings.EqualFold(name, fullName) || strings.EqualFold(name, owner+"/*") {
+			return &r.cfg.Repos[i]
+		}
+	}
+	return nil
+}
+
+// associationAllowed 未配置 allowed_associations 时任何人都可触发
+func (r *githubResponder) associationAllowed(association string) bool {
+	if len(r.cfg.Associations) == 0 {
+		return true
+	}
+	for _, a := range r.cfg.Associations {
+		if strings.EqualFold(a, association) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *githubResponder) respond(m *github.Mention, repo *config.GitHubRepoConfig) {
+	ctx := r.ctx
+	if r.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.cfg.Timeout)
+		defer cancel()
+	}
+	log := r.logger.With(zap.String("repo", m.Repo), zap.Int("number", m.Number))
+
+	var diff string
+	var truncated bool
+	if m.IsPullRequest {
+		var err error
+		diff, truncated, err = r.client.PullRequestDiff(ctx, m.InstallationID, m.Repo, m.Number, r.cfg.MaxDiffKB*1024)
+		if err != nil {
+			// 没有 diff 仍可回答评论中的问题
+			log.Warn("Fetch PR diff failed", zap.Error(err))
+		}
+	}
+
+	model := repo.Model
+	if model == "" {
+		model = r.cfg.Model
+	}
+	job := &jobqueue.Job{
+		ID:           fmt.Sprintf("github:%s#%d:%d", m.Repo, m.Number, time.Now().Unix()),
+		Prompt:       buildGitHubPrompt(m, diff, truncated),
+		SystemPrompt: githubInstructions(repo.Instructions),
+		Model:        model,
+	}
+
+	start := time.Now()
+	out, err := r.runner.RunJob(ctx, job, func(entity.AgentEvent) {})
+
+	var reply string
+	switch {
+	case err != nil:
+		log.Error("GitHub agent run failed", zap.Error(err))
+		reply = fmt.Sprintf("⚠️ Sorry, I couldn't finish this request: %s", err)
+	case out == nil || strings.TrimSpace(out.Content) == "":
+		reply = "⚠️ The agent finished without producing an answer."
+	default:
+		reply = out.Content
+	}
+	if runes := []rune(reply); len(runes) > maxCommentLen {
+		reply = string(runes[:maxCommentLen]) + "\n\n… (truncated)"
+	}
+	reply += "\n\n" + github.ReplyMarker
+
+	// 运行可能因超时结束, 回帖使用独立的短超时
+	postCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := r.client.CreateComment(postCtx, m.InstallationID, m.Repo, m.Number, reply); err != nil {
+		log.Error("Post GitHub comment failed", zap.Error(err))
+		return
+	}
+	log.Info("GitHub mention answered", zap.Duration("duration", time.Since(start)))
+}
+
+// githubInstructions 附加到 "api" 渠道系统提示词之后
+func githubInstructions(repoInstructions string) string {
+	s := "You are replying on GitHub. Your final answer is posted verbatim as a comment, " +
+		"so write GitHub-flavored Markdown, reference files and lines from the diff, and keep it focused. " +
+		"When reviewing a pull request, lead with bugs and risky changes, then smaller suggestions; " +
+		"do not restate what the diff already shows."
+	if repoInstructions != "" {
+		s += "\n\n" + repoInstructions
+	}
+	return s
+}
+
+// buildGitHubPrompt 组装提及上下文: 标题、正文、触发评论与 PR diff
+func buildGitHubPrompt(m *github.Mention, diff string, truncated bool) string {
+	kind := "issue"
+	if m.IsPullRequest {
+		kind = "pull request"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "@%s mentioned you on %s %s#%d: %q\n%s\n", m.Author, kind, m.Repo, m.Number, m.Title, m.URL)
+	if body := strings.TrimSpace(m.Body); body != "" {
+		fmt.Fprintf(&b, "\n## Description\n%s\n", body)
+	}
+	if m.Comment != "" {
+		fmt.Fprintf(&b, "\n## Comment\n%s\n", strings.TrimSpace(m.Comment))
+	}
+	if diff != "" {
+		b.WriteString("\n## Diff")
+		if truncated {
+			b.WriteString(" (truncated)")
+		}
+		fmt.Fprintf(&b, "\n```diff\n%s\n```\n", strings.TrimRight(diff, "\n"))
+	}
+
+	b.WriteString("\n")
+	switch {
+	case m.Comment != "":
+		b.WriteString("Respond to the request in the comment above.")
+	case m.IsPullRequest:
+		b.WriteString("Review this pull request.")
+	default:
+		b.WriteString("Respond to this issue.")
+	}
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/janitor"
+)
+
+// 清理类别 (管理面板与日志中的名称)
+const (
+	reapApprovals   = "approvals"
+	reapLSPServers  = "lsp_servers"
+	reapOrphans     = "sandbox_orphans"
+	reapTempFiles   = "temp_files"
+	reapShares      = "share_snapshots"
+	reapTranscripts = "transcripts"
+	reapJournals    = "journals"
+)
+
+// gatewayTempPatterns 网关在系统临时目录中创建的目录与文件 (run_tests 基线、eval 工作区、PDF 抽取)
+var gatewayTempPatterns = []string{"ngoclaw-baseline-*", "ngoclaw-eval-*", "ngoclaw-doc-*"}
+
+// newJanitor 按 janitor.* 创建泄漏资源清理器; 定时清理只在 App.Start (网关模式) 中启动.
+// 清理函数在执行时读取 App 字段, Telegram 适配器等稍后创建的组件也能被清理
+func (app *App) newJanitor() *janitor.Janitor {
+	cfg := app.config.Janitor
+	if cfg.Interval <= 0 {
+		return nil
+	}
+	j := janitor.New(cfg.Interval, app.logger)
+
+	j.Add(reapApprovals, func(context.Context) (int, error) {
+		if app.telegramAdapter == nil {
+			return 0, nil
+		}
+		return app.telegramAdapter.ReapApprovals(cfg.ApprovalMaxAge), nil
+	})
+	j.Add(reapLSPServers, func(context.Context) (int, error) {
+		if app.lsp == nil {
+			return 0, nil
+		}
+		return app.lsp.ReapServers(cfg.LSPIdle), nil
+	})
+	if cfg.OrphanGrace > 0 {
+		j.Add(reapOrphans, func(context.Context) (int, error) {
+			if app.sandbox == nil {
+				return 0, nil
+			}
+			return app.sandbox.ReapOrphans(cfg.OrphanGrace), nil
+		})
+	}
+	if cfg.TempMaxAge > 0 {
+		j.Add(reapTempFiles, func(context.Context) (int, error) {
+			now := time.Now()
+			n, err := janitor.RemoveStale(os.TempDir(), gatewayTempPatterns, cfg.TempMaxAge, now)
+			if app.sandbox != nil {
+				// 沙箱临时目录是子进程的 TMPDIR, 其中的内容都由沙箱命令产生
+				m, sbErr := janitor.RemoveStale(app.sandbox.TempDir(), []string{"*"}, cfg.TempMaxAge, now)
+				n += m
+				err = errors.Join(err, sbErr)
+			}
+			return n, err
+		})
+	}
+	j.Add(reapShares, func(context.Context) (int, error) {
+		if app.shares == nil {
+			return 0, nil
+		}
+		return app.shares.Sweep(), nil
+	})
+	j.Add(reapTranscripts, func(context.Context) (int, error) {
+		if app.transcripts == nil {
+			return 0, nil
+		}
+		return app.transcripts.Prune(), nil
+	})
+	j.Add(reapJournals, func(context.Context) (int, error) {
+		if app.journals == nil {
+			return 0, nil
+		}
+		return app.journals.Prune(), nil
+	})
+	return j
+}
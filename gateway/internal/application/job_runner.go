@@ -0,0 +1,78 @@
+package application
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/jobqueue"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/prompt"
+)
+
+// jobRunner adapts the agent loop → jobqueue.Runner.
+// Jobs run with the "api" channel prompt; tool approvals go through the
+// fallback approval channel since there is no Telegram chat.
+type jobRunner struct {
+	agentLoop    *service.AgentLoop
+	toolExec     service.ToolExecutor
+	promptEngine *prompt.PromptEngine
+	output       *service.OutputPipeline // agent.output, channel "api"
+}
+
+// RunJob implements jobqueue.Runner
+func (r *jobRunner) RunJob(ctx context.Context, job *jobqueue.Job, emit func(entity.AgentEvent)) (*jobqueue.RunOutput, error) {
+	lang := service.DetectLanguage(job.Prompt)
+	systemPrompt := job.SystemPrompt
+	if r.promptEngine != nil {
+		toolNames := make([]string, 0)
+		for _, d := range r.toolExec.GetDefinitions() {
+			toolNames = append(toolNames, d.Name)
+		}
+		systemPrompt = r.promptEngine.Assemble(prompt.PromptContext{
+			Channel:         "api",
+			RegisteredTools: toolNames,
+			ModelName:       job.Model,
+			UserMessage:     job.Prompt,
+			Language:        lang,
+		})
+		if job.SystemPrompt != "" {
+			systemPrompt += "\n\n---\n\n## Additional Instructions\n" + job.SystemPrompt
+		}
+	}
+
+	ctx = service.WithTranscriptSource(ctx, "job:"+job.ID)
+	ctx = service.WithChannel(ctx, "api")
+	ctx = service.WithReplyLanguage(ctx, lang)
+	result, eventCh := r.agentLoop.Run(ctx, systemPrompt, job.Prompt, nil, job.Model)
+
+	var lastErr string
+	for ev := range eventCh {
+		if ev.Type == entity.EventError {
+			lastErr = ev.Error
+		}
+		emit(ev)
+	}
+
+	out := &jobqueue.RunOutput{
+		Content:     result.FinalContent,
+		TotalSteps:  result.TotalSteps,
+		TotalTokens: result.TotalTokens,
+		ModelUsed:   result.ModelUsed,
+		Tests:       result.TestReport,
+	}
+	if err := ctx.Err(); err != nil {
+		return out, err
+	}
+	if lastErr != "" {
+		// agent loop 的 error 事件都是终止性的
+		return out, errors.New(lastErr)
+	}
+	content, err := r.output.Process(ctx, out.Content, service.OutputInfo{Channel: "api", Model: result.ModelUsed})
+	if err != nil {
+		out.Content = ""
+		return out, err
+	}
+	out.Content = content
+	return out, nil
+}
@@ -0,0 +1,105 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/eventbus"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/notify"
+)
+
+// subscribeNotifications forwards run, cron and budget events from the bus
+// to the notification targets (notifications.targets in config.yaml).
+func subscribeNotifications(bus eventbus.Bus, n *notify.Notifier) {
+	forward := func(ctx context.Context, ev eventbus.Event) {
+		var out notify.Event
+		var ok bool
+		switch p := ev.Payload().(type) {
+		case RunEvent:
+			out, ok = runNotification(ev.Type(), p)
+		case BudgetEvent:
+			out, ok = budgetNotification(p), true
+		}
+		if ok {
+			out.Time = ev.Timestamp()
+			n.Notify(ctx, out)
+		}
+	}
+	for _, topic := range []string{TopicRunCompleted, TopicRunFailed, TopicRunAborted, TopicBudgetExhausted} {
+		bus.Subscribe(topic, forward)
+	}
+}
+
+// runNotification maps a finished run to its notification. Runs started by
+// cron report as cron.result whatever their outcome; other aborted runs are
+// only reported when their token or time budget ran out.
+func runNotification(topic string, ev RunEvent) (notify.Event, bool) {
+	out := notify.Event{
+		Source:  ev.Source,
+		ChatID:  ev.ChatID,
+		Model:   ev.Model,
+		Command: ev.Cron,
+		Error:   ev.Error,
+	}
+	if r := ev.Result; r != nil {
+		out.Text = r.FinalContent
+		out.Tokens = r.TotalTokens
+		out.Steps = r.TotalSteps
+	}
+	switch {
+	case ev.Cron != "":
+		out.Type = notify.EventCronResult
+		out.Title = "Cron: " + ev.Cron
+		switch topic {
+		case TopicRunFailed:
+			out.Title += " (failed)"
+		case TopicRunAborted:
+			out.Title += " (aborted)"
+			out.Error = service.NewAbortError(ev.Reason, "").Error()
+		}
+	case topic == TopicRunCompleted:
+		out.Type = notify.EventRunCompleted
+		out.Title = "Run completed"
+	case topic == TopicRunFailed:
+		out.Type = notify.EventRunFailed
+		out.Title = "Run failed"
+	case ev.Reason == service.AbortBudget:
+		out.Type = notify.EventBudgetExhausted
+		out.Title = "Run stopped: budget exhausted"
+		out.Error = service.NewAbortError(ev.Reason, "").Error()
+		out.Text = fmt.Sprintf("%s after %d steps and %d tokens", out.Error, out.Steps, out.Tokens)
+	default:
+		return out, false
+	}
+	if out.Source != "" {
+		out.Title += " · " + out.Source
+	}
+	if out.Text == "" {
+		out.Text = out.Error
+	}
+	return out, true
+}
+
+func budgetNotification(ev BudgetEvent) notify.Event {
+	s := ev.Status
+	var limits []string
+	if s.Pool.Tokens > 0 {
+		limits = append(limits, fmt.Sprintf("%d/%d tokens", s.Tokens, s.Pool.Tokens))
+	}
+	if s.Pool.USD > 0 {
+		limits = append(limits, fmt.Sprintf("$%.2f/$%.2f", s.CostUSD, s.Pool.USD))
+	}
+	scope := ev.Subject
+	if scope == "" {
+		scope = "global"
+	}
+	return notify.Event{
+		Type:   notify.EventBudgetExhausted,
+		Title:  "Budget exhausted: " + s.Pool.Name,
+		Text:   fmt.Sprintf("%s (%s, %s): %s; resets %s", s.Pool.Name, s.Pool.Window, scope, strings.Join(limits, ", "), s.Resets.Format("2006-01-02 15:04")),
+		Source: ev.Source,
+		ChatID: ev.ChatID,
+	}
+}
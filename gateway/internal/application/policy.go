@@ -0,0 +1,50 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/policy"
+)
+
+// initOrgPolicy loads the organization policy bundle (policy.url) and
+// applies it before the first run. A bundle that cannot be loaded stops
+// startup with policy.required and is otherwise retried on every refresh.
+func (app *App) initOrgPolicy(root string) error {
+	cfg := app.config.Policy
+	client, err := policy.New(cfg, root, app.applyOrgPolicy, app.logger)
+	if err != nil {
+		if cfg.Required {
+			return fmt.Errorf("organization policy: %w", err)
+		}
+		app.logger.Warn("Organization policy disabled", zap.Error(err))
+		return nil
+	}
+	if client == nil {
+		return nil
+	}
+	app.orgPolicy = client
+	if err := client.Load(context.Background()); err != nil {
+		if cfg.Required {
+			return fmt.Errorf("organization policy required but unavailable: %w", err)
+		}
+		app.logger.Warn("Organization policy not loaded yet, running on the local config", zap.Error(err))
+	}
+	return nil
+}
+
+// applyOrgPolicy enforces p: security overrides and guard rules in the
+// SecurityHook, blocked models in the LLM router, budget pools in the
+// budget manager.
+func (app *App) applyOrgPolicy(p *service.OrgPolicy) {
+	app.securityHook.SetOrgPolicy(p)
+	app.llmRouter.SetModelFilter(p.CheckModel)
+	if app.budgets != nil {
+		app.budgets.SetOrgPools(p.Budgets)
+	} else if len(p.Budgets) > 0 {
+		app.logger.Warn("Organization budget pools ignored: no database for budget usage")
+	}
+}
@@ -0,0 +1,53 @@
+package application
+
+import (
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+// restartDelay 留出时间把 /profile 的回复发出去
+const restartDelay = 2 * time.Second
+
+// RestartRequested 在网关需要以新配置重启时关闭 (/profile 切换后).
+// serve 收到后 Stop 并重新执行自身; 其他模式忽略即可.
+func (app *App) RestartRequested() <-chan struct{} {
+	return app.restart
+}
+
+func (app *App) requestRestart() {
+	app.restartOnce.Do(func() { close(app.restart) })
+}
+
+// profileSwitcher 实现 telegram.ProfileSwitcher: 校验并保存 profile, 随后请求重启.
+// provider、安全策略、工作区都在启动时装配, 只能整体重启生效.
+type profileSwitcher struct {
+	app *App
+}
+
+func (p *profileSwitcher) ActiveProfile() string {
+	return p.app.config.Profile
+}
+
+func (p *profileSwitcher) ListProfiles() ([]string, error) {
+	return config.ListProfiles()
+}
+
+func (p *profileSwitcher) SwitchProfile(name string) error {
+	if name != config.DefaultProfile {
+		// 先完整加载一遍, 配置写错时不重启
+		if _, err := config.LoadProfile(name); err != nil {
+			return err
+		}
+	}
+	if err := config.SetSavedProfile(name); err != nil {
+		return err
+	}
+	p.app.logger.Info("Config profile switched, restarting gateway",
+		zap.String("from", p.app.config.Profile),
+		zap.String("to", name),
+	)
+	time.AfterFunc(restartDelay, p.app.requestRestart)
+	return nil
+}
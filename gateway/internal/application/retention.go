@@ -0,0 +1,91 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/journal"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/retention"
+	toolpkg "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tool"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/transcript"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/interfaces/telegram"
+)
+
+// newRetentionScrubber 按 retention.* 创建数据清理器; 定时清理只在 App.Start (网关模式) 中启动.
+// 转录未启用时仍清理配置目录下的历史文件.
+func (app *App) newRetentionScrubber() *retention.Scrubber {
+	stores := retention.Stores{
+		Messages:    app.messageRepo,
+		Feedback:    app.feedbackRepo,
+		ArtifactDir: toolpkg.DefaultArtifactDir(),
+	}
+	if app.transcripts != nil {
+		stores.Transcripts = app.transcripts.Purge
+	} else {
+		dir := app.config.Log.Transcripts.Dir
+		if dir == "" {
+			dir = transcript.DefaultDir()
+		}
+		stores.Transcripts = func(match func(string, time.Time) bool) (int, error) {
+			return transcript.PurgeDir(dir, match)
+		}
+	}
+	// 分享快照包含转录内容, 随转录一起过期 / 删除
+	if app.shares != nil {
+		purgeTranscripts := stores.Transcripts
+		stores.Transcripts = func(match func(string, time.Time) bool) (int, error) {
+			n, err := purgeTranscripts(match)
+			m, shareErr := app.shares.Purge(match)
+			return n + m, errors.Join(err, shareErr)
+		}
+	}
+	// 运行日志包含完整的请求内容, 同样随转录过期 / 删除
+	purgeJournals := func(match func(string, time.Time) bool) (int, error) {
+		dir := app.config.Log.Journal.Dir
+		if dir == "" {
+			dir = journal.DefaultDir()
+		}
+		return journal.PurgeDir(dir, match)
+	}
+	if app.journals != nil {
+		purgeJournals = app.journals.Purge
+	}
+	purgeTranscripts := stores.Transcripts
+	stores.Transcripts = func(match func(string, time.Time) bool) (int, error) {
+		n, err := purgeTranscripts(match)
+		m, journalErr := purgeJournals(match)
+		return n + m, errors.Join(err, journalErr)
+	}
+	return retention.NewScrubber(app.config.Retention, stores, app.logger)
+}
+
+// chatEraser 实现 telegram.DataEraser: 先停止运行并清除内存中的会话状态, 再删除持久化数据
+type chatEraser struct {
+	scrubber *retention.Scrubber
+	sessions *telegram.DefaultSessionManager
+	runs     telegram.RunController
+	history  telegram.HistoryClearer
+	feedback *feedbackCollector // nil = 未启用反馈
+	cron     *telegram.CronService
+}
+
+func (e *chatEraser) ForgetChat(ctx context.Context, chatID int64) (int, error) {
+	// 进行中的运行结束时会写转录, 先停止
+	e.runs.AbortRun(chatID)
+	e.history.ClearHistory(chatID)
+	if e.feedback != nil {
+		e.feedback.Forget(chatID)
+	}
+
+	errs := []error{e.sessions.ForgetSession(ctx, chatID)}
+	jobs := 0
+	if e.cron != nil {
+		n, err := e.cron.ForgetChat(ctx, chatID)
+		jobs = n
+		errs = append(errs, err)
+	}
+	report, err := e.scrubber.ForgetChat(ctx, chatID)
+	errs = append(errs, err)
+	return report.Total() + jobs, errors.Join(errs...)
+}
@@ -0,0 +1,28 @@
+package application
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/share"
+)
+
+// runSharer 实现 telegram.RunSharer: 分享该 chat 最近一次运行.
+// 未设置 gateway.share.base_url 时返回 /share/<token> 相对路径.
+type runSharer struct {
+	store   *share.Store
+	baseURL string
+}
+
+func (s *runSharer) ShareLast(chatID int64, ttl time.Duration) (string, time.Time, error) {
+	trace := s.store.Last(fmt.Sprintf("telegram:%d", chatID))
+	if trace == "" {
+		return "", time.Time{}, nil
+	}
+	link, err := s.store.Create(trace, ttl)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return strings.TrimRight(s.baseURL, "/") + "/share/" + link.Token, link.ExpiresAt, nil
+}
@@ -0,0 +1,208 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tts"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/interfaces/telegram"
+)
+
+// Per-chat bounds of /tts limit, and the default when tts.limit is unset.
+const (
+	ttsMinLimit     = 100
+	ttsMaxLimit     = 4096
+	ttsDefaultLimit = 1500
+	ttsTimeout      = 60 * time.Second
+)
+
+// ttsSettings stores the per-chat /tts preferences (the Telegram session
+// manager, persisted with the other chat settings).
+type ttsSettings interface {
+	GetTTS(chatID int64) telegram.TTSSettings
+	SetTTS(chatID int64, tts telegram.TTSSettings)
+}
+
+// voiceSender delivers synthesized audio as a voice message.
+type voiceSender interface {
+	SendVoiceData(chatID int64, replyToID int, audio []byte) error
+}
+
+// ttsController implements telegram.TtsController over the providers
+// configured in tts.* of config.yaml, and speaks the final replies of chats
+// that turned /tts on.
+type ttsController struct {
+	defaultProvider string
+	defaultLimit    int
+	timeout         time.Duration
+	providers       map[string]tts.Provider
+	sessions        ttsSettings
+	speech          *service.SpeechPreparer
+	voice           voiceSender
+	logger          *zap.Logger
+}
+
+var _ telegram.TtsController = (*ttsController)(nil)
+
+// newTTSController creates the /tts controller. Summaries use
+// tts.summary_model, then the tool-summary and default models.
+func (app *App) newTTSController(sessions ttsSettings, voice voiceSender) *ttsController {
+	cfg := app.config.TTS
+	model := cfg.SummaryModel
+	if model == "" {
+		model = app.config.Agent.Tools.Summarize.Model
+	}
+	if model == "" {
+		model = app.config.Agent.DefaultModel
+	}
+	providers := tts.Providers(cfg, app.logger)
+	if len(providers) > 0 {
+		app.logger.Info("TTS providers available", zap.Strings("providers", tts.Names(providers)))
+	}
+	c := &ttsController{
+		defaultProvider: cfg.Provider,
+		defaultLimit:    cfg.Limit,
+		timeout:         cfg.Timeout,
+		providers:       providers,
+		sessions:        sessions,
+		speech:          service.NewSpeechPreparer(app.llmRouter, model, app.logger),
+		voice:           voice,
+		logger:          app.logger,
+	}
+	if c.defaultProvider == "" {
+		c.defaultProvider = tts.ProviderOpenAI
+	}
+	if c.defaultLimit <= 0 {
+		c.defaultLimit = ttsDefaultLimit
+	}
+	if c.timeout <= 0 {
+		c.timeout = ttsTimeout
+	}
+	return c
+}
+
+func (c *ttsController) IsEnabled(chatID int64) bool {
+	return c.sessions.GetTTS(chatID).Enabled
+}
+
+func (c *ttsController) SetEnabled(chatID int64, on bool) {
+	s := c.sessions.GetTTS(chatID)
+	s.Enabled = on
+	c.sessions.SetTTS(chatID, s)
+}
+
+func (c *ttsController) GetProvider(chatID int64) string {
+	if p := c.sessions.GetTTS(chatID).Provider; p != "" {
+		return p
+	}
+	return c.defaultProvider
+}
+
+func (c *ttsController) SetProvider(chatID int64, provider string) error {
+	known := false
+	for _, name := range tts.Known {
+		known = known || name == provider
+	}
+	if !known {
+		return fmt.Errorf("unknown TTS provider %q (available: %s)", provider, strings.Join(tts.Known, ", "))
+	}
+	if c.providers[provider] == nil {
+		return fmt.Errorf("TTS provider %s is not configured (tts.%s in config.yaml)", provider, provider)
+	}
+	s := c.sessions.GetTTS(chatID)
+	s.Provider = provider
+	c.sessions.SetTTS(chatID, s)
+	return nil
+}
+
+func (c *ttsController) GetLimit(chatID int64) int {
+	limit := c.sessions.GetTTS(chatID).Limit
+	if limit <= 0 {
+		limit = c.defaultLimit
+	}
+	return min(max(limit, ttsMinLimit), ttsMaxLimit)
+}
+
+func (c *ttsController) SetLimit(chatID int64, limit int) error {
+	if limit < ttsMinLimit || limit > ttsMaxLimit {
+		return fmt.Errorf("limit must be between %d and %d", ttsMinLimit, ttsMaxLimit)
+	}
+	s := c.sessions.GetTTS(chatID)
+	s.Limit = limit
+	c.sessions.SetTTS(chatID, s)
+	return nil
+}
+
+func (c *ttsController) IsSummaryEnabled(chatID int64) bool {
+	return c.sessions.GetTTS(chatID).Summary
+}
+
+func (c *ttsController) SetSummaryEnabled(chatID int64, on bool) {
+	s := c.sessions.GetTTS(chatID)
+	s.Summary = on
+	c.sessions.SetTTS(chatID, s)
+}
+
+// GenerateAudio speaks text as given (/tts audio), within the chat's limit.
+func (c *ttsController) GenerateAudio(ctx context.Context, chatID int64, text string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return "", c.speak(ctx, chatID, 0, c.speech.Prepare(ctx, text, c.GetLimit(chatID), false))
+}
+
+func (c *ttsController) GetStatus(chatID int64) *telegram.TtsStatus {
+	provider := c.GetProvider(chatID)
+	return &telegram.TtsStatus{
+		Enabled:       c.IsEnabled(chatID),
+		Provider:      provider,
+		ProviderReady: c.providers[provider] != nil,
+		TextLimit:     c.GetLimit(chatID),
+		AutoSummary:   c.IsSummaryEnabled(chatID),
+	}
+}
+
+// SpeakReply sends the final reply of a run as a voice message after its
+// text, when the chat has /tts on. Long replies are summarized with
+// /tts summary on, and cut at the chat's limit otherwise. Failures are
+// logged only: the written reply has been delivered already.
+func (c *ttsController) SpeakReply(chatID int64, replyToID int, reply string) {
+	s := c.sessions.GetTTS(chatID)
+	if !s.Enabled {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	start := time.Now()
+	text := c.speech.Prepare(ctx, reply, c.GetLimit(chatID), s.Summary)
+	if err := c.speak(ctx, chatID, replyToID, text); err != nil {
+		c.logger.Warn("Voice reply failed", zap.Int64("chat_id", chatID), zap.Error(err))
+		return
+	}
+	c.logger.Info("Voice reply sent",
+		zap.Int64("chat_id", chatID),
+		zap.String("provider", c.GetProvider(chatID)),
+		zap.Int("chars", len([]rune(text))),
+		zap.Duration("took", time.Since(start)),
+	)
+}
+
+func (c *ttsController) speak(ctx context.Context, chatID int64, replyToID int, text string) error {
+	if text == "" {
+		return fmt.Errorf("nothing to read aloud")
+	}
+	name := c.GetProvider(chatID)
+	provider := c.providers[name]
+	if provider == nil {
+		return fmt.Errorf("TTS provider %s is not configured (tts.%s in config.yaml)", name, name)
+	}
+	audio, err := provider.Synthesize(ctx, text)
+	if err != nil {
+		return err
+	}
+	return c.voice.SendVoiceData(chatID, replyToID, audio)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/ngoclaw/ngoclaw/gateway/internal/application/usecase"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/repository"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/valueobject"
 	"go.uber.org/zap"
@@ -37,6 +38,10 @@ func (m *MockMessageRepository) Count(ctx context.Context, conversationID string
 	return 0, nil
 }
 
+func (m *MockMessageRepository) Purge(ctx context.Context, filter repository.PurgeFilter) (int64, error) {
+	return 0, nil
+}
+
 // MockMessageRouter 模拟消息路由
 type MockMessageRouter struct {
 	agent *entity.Agent
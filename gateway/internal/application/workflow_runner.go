@@ -0,0 +1,136 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/prompt"
+	toolpkg "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tool"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/workflow"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/interfaces/telegram"
+	"go.uber.org/zap"
+)
+
+// workflowRunner runs the named pipelines of ~/.ngoclaw/workflows for
+// /workflow run (also when scheduled with /cron). Workflows run in the
+// background; the stage-by-stage summary and the last stage's output are
+// sent to the chat when they finish. A workflow runs at most once per chat
+// at a time.
+type workflowRunner struct {
+	dir     string
+	orch    *workflow.Orchestrator
+	adapter *telegram.Adapter
+	logger  *zap.Logger
+
+	running sync.Map // "<chatID>/<name>" → struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func newWorkflowRunner(dir string, agent workflow.Agent, toolExec service.ToolExecutor, engine *prompt.PromptEngine, skills *toolpkg.SkillManager, adapter *telegram.Adapter, logger *zap.Logger) *workflowRunner {
+	orch := workflow.NewOrchestrator(agent, logger.Named("workflow"))
+	if engine != nil {
+		orch.SetPromptBuilder(func(ctx context.Context, st *workflow.Stage, model string) string {
+			var tools []string
+			profile := service.AgentProfile{Tools: st.Tools}
+			for _, d := range toolExec.GetDefinitions() {
+				if profile.AllowsTool(d.Name) {
+					tools = append(tools, d.Name)
+				}
+			}
+			return engine.Assemble(prompt.PromptContext{
+				Channel:         "telegram",
+				RegisteredTools: tools,
+				ModelName:       model,
+				UserMessage:     st.Prompt,
+				Language:        service.ReplyLanguageFromContext(ctx),
+			})
+		})
+	}
+	if skills != nil {
+		orch.SetSkillLoader(func(id string) (string, error) {
+			s := skills.Get(id)
+			if s == nil {
+				return "", fmt.Errorf("not installed")
+			}
+			data, err := os.ReadFile(filepath.Join(s.Path, "SKILL.md"))
+			return string(data), err
+		})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &workflowRunner{
+		dir:     dir,
+		orch:    orch,
+		adapter: adapter,
+		logger:  logger,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// List implements telegram.WorkflowRunner
+func (r *workflowRunner) List() ([]*workflow.Workflow, []error) {
+	return workflow.LoadDir(r.dir)
+}
+
+// Start implements telegram.WorkflowRunner. Stages answer in the language of
+// input, else in the reply language carried by ctx.
+func (r *workflowRunner) Start(ctx context.Context, chatID int64, name, input string) (*workflow.Workflow, error) {
+	w, err := workflow.Find(r.dir, name)
+	if err != nil {
+		return nil, err
+	}
+	if r.ctx.Err() != nil {
+		return nil, fmt.Errorf("shutting down")
+	}
+	key := fmt.Sprintf("%d/%s", chatID, w.Name)
+	if _, busy := r.running.LoadOrStore(key, struct{}{}); busy {
+		return nil, fmt.Errorf("workflow %s is already running in this chat", w.Name)
+	}
+
+	// 与触发它的命令无关: 命令返回后继续运行, 关闭网关时取消
+	runCtx := WithChatID(r.ctx, chatID)
+	runCtx = toolpkg.WithChatID(runCtx, chatID)
+	runCtx = service.WithTranscriptSource(runCtx, fmt.Sprintf("telegram:%d", chatID))
+	runCtx = service.WithChannel(runCtx, "telegram")
+	lang := service.ReplyLanguage("", input, nil)
+	if lang == "" {
+		lang = service.ReplyLanguageFromContext(ctx)
+	}
+	runCtx = service.WithReplyLanguage(runCtx, lang)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer r.running.Delete(key)
+		report := r.orch.Run(runCtx, w, input, nil)
+		r.deliver(chatID, report)
+	}()
+	return w, nil
+}
+
+// deliver sends the summary, then the output of the last stage that ran
+func (r *workflowRunner) deliver(chatID int64, report *workflow.Report) {
+	summary := &telegram.OutgoingMessage{ChatID: chatID, Text: html.EscapeString(report.Summary()), ParseMode: "HTML"}
+	if err := r.adapter.SendMessage(summary); err != nil {
+		r.logger.Warn("Failed to deliver workflow summary", zap.String("workflow", report.Workflow), zap.Error(err))
+		return
+	}
+	if out := report.Output(); out != "" {
+		for _, part := range telegram.SplitReply(out, telegram.ReplyPartLimit) {
+			r.adapter.SendMessage(&telegram.OutgoingMessage{ChatID: chatID, Text: telegram.MarkdownToTelegramHTML(part), ParseMode: "HTML"})
+		}
+	}
+}
+
+// Stop cancels running workflows and waits for them to finish
+func (r *workflowRunner) Stop() {
+	r.cancel()
+	r.wg.Wait()
+}
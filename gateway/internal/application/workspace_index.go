@@ -0,0 +1,86 @@
+package application
+
+import (
+	"os"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/embedding"
+	toolpkg "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tool"
+	"go.uber.org/zap"
+)
+
+// newWorkspaceIndex 按配置创建工作区索引 (agent.tools.index); 未启用时返回 nil.
+// 索引只在 App.Start (网关模式) 中启动, CLI 模式下 repo_map / grep_search 照常实时扫描.
+func (app *App) newWorkspaceIndex() *toolpkg.WorkspaceIndex {
+	cfg := app.config.Agent.Tools.Index
+	if !cfg.Enabled {
+		return nil
+	}
+	root := app.config.Agent.Workspace
+	if root == "" {
+		root, _ = os.Getwd()
+	}
+	return toolpkg.NewWorkspaceIndex(root, cfg.MaxFiles, app.logger)
+}
+
+// newBuildWarmer 按配置创建构建缓存预热器 (agent.tools.warm); 未启用时返回 nil.
+// 与索引一样只在 App.Start 中启动, CLI 模式不预热.
+func (app *App) newBuildWarmer() *toolpkg.BuildWarmer {
+	cfg := app.config.Agent.Tools.Warm
+	if !cfg.Enabled {
+		return nil
+	}
+	root := app.config.Agent.Workspace
+	if root == "" {
+		root, _ = os.Getwd()
+	}
+	return toolpkg.NewBuildWarmer(root, app.sandbox, toolpkg.WarmConfig{
+		Go:      cfg.Go,
+		Node:    cfg.Node,
+		Python:  cfg.Python,
+		Timeout: cfg.Timeout,
+	}, app.logger)
+}
+
+// startBuildWarmer 后台预热构建缓存并监听依赖文件
+func (app *App) startBuildWarmer() {
+	if app.buildWarmer == nil {
+		return
+	}
+	if err := app.buildWarmer.Start(); err != nil {
+		app.logger.Warn("Build cache warming disabled", zap.Error(err))
+		app.buildWarmer = nil
+	}
+}
+
+// startWorkspaceIndex 后台构建索引并开始监听; 启用嵌入时连接 Ollama 补做嵌入索引
+func (app *App) startWorkspaceIndex() {
+	if app.workspaceIndex == nil {
+		return
+	}
+	if err := app.workspaceIndex.Start(); err != nil {
+		app.logger.Warn("Workspace index disabled", zap.Error(err))
+		return
+	}
+	if !app.config.Agent.Tools.Index.Embeddings {
+		return
+	}
+
+	mem := app.config.Memory
+	if mem.OllamaURL == "" || mem.EmbedModel == "" {
+		app.logger.Warn("Workspace embeddings need memory.ollama_url and memory.embed_model; semantic search disabled")
+		return
+	}
+	go func() {
+		// 探测向量维度需要一次请求, 不阻塞启动
+		embedder, err := embedding.NewOllamaEmbedder(mem.OllamaURL, mem.EmbedModel, app.logger)
+		if err != nil {
+			app.logger.Warn("Workspace embeddings unavailable", zap.Error(err))
+			return
+		}
+		app.workspaceIndex.SetEmbedder(embedder)
+		app.logger.Info("Workspace embedding index enabled",
+			zap.String("model", mem.EmbedModel),
+			zap.Int("dimension", embedder.Dimension()),
+		)
+	}()
+}
@@ -6,12 +6,17 @@ import (
 	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/valueobject"
 )
 
+// DefaultAgentID 种子数据中的默认代理, 即不覆盖任何会话设置的代理
+const DefaultAgentID = "default"
+
 // Agent 代理聚合根
 // 代理是一个可以处理消息并生成响应的智能实体
 type Agent struct {
 	id          string
 	name        string
 	modelConfig valueobject.ModelConfig
+	persona     string   // 人设提示词, 追加在 system prompt 的 soul 之后
+	tools       []string // 允许使用的工具; 空 = 全部
 	skills      []Skill
 	workspace   string
 	createdAt   time.Time
@@ -43,6 +48,8 @@ func NewAgent(id, name string, modelConfig valueobject.ModelConfig) (*Agent, err
 func ReconstructAgent(
 	id, name string,
 	modelConfig valueobject.ModelConfig,
+	persona string,
+	tools []string,
 	skills []Skill,
 	workspace string,
 	createdAt, updatedAt time.Time,
@@ -51,6 +58,8 @@ func ReconstructAgent(
 		id:          id,
 		name:        name,
 		modelConfig: modelConfig,
+		persona:     persona,
+		tools:       tools,
 		skills:      skills,
 		workspace:   workspace,
 		createdAt:   createdAt,
@@ -73,6 +82,33 @@ func (a *Agent) ModelConfig() valueobject.ModelConfig {
 	return a.modelConfig
 }
 
+// Persona 返回人设提示词
+func (a *Agent) Persona() string {
+	return a.persona
+}
+
+// Tools 返回允许使用的工具列表 (空 = 全部)
+func (a *Agent) Tools() []string {
+	tools := make([]string, len(a.tools))
+	copy(tools, a.tools)
+	return tools
+}
+
+// Workspace 返回工作目录
+func (a *Agent) Workspace() string {
+	return a.workspace
+}
+
+// CreatedAt 返回创建时间
+func (a *Agent) CreatedAt() time.Time {
+	return a.createdAt
+}
+
+// UpdatedAt 返回更新时间
+func (a *Agent) UpdatedAt() time.Time {
+	return a.updatedAt
+}
+
 // Skills 返回技能列表
 func (a *Agent) Skills() []Skill {
 	// 返回副本以保护不变性
@@ -113,6 +149,18 @@ func (a *Agent) UpdateModelConfig(config valueobject.ModelConfig) {
 	a.updatedAt = time.Now()
 }
 
+// UpdatePersona 更新人设提示词（领域行为）
+func (a *Agent) UpdatePersona(persona string) {
+	a.persona = persona
+	a.updatedAt = time.Now()
+}
+
+// RestrictTools 限定可用工具（领域行为）; 空列表 = 不限制
+func (a *Agent) RestrictTools(tools []string) {
+	a.tools = append([]string(nil), tools...)
+	a.updatedAt = time.Now()
+}
+
 // CanProcessMessage 判断代理是否可以处理消息（领域规则）
 func (a *Agent) CanProcessMessage(msg *Message) bool {
 	// 代理需要有效的模型配置才能处理消息
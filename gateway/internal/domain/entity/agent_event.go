@@ -13,6 +13,11 @@ const (
 	EventStepDone    AgentEventType = "step_done"
 	EventDone        AgentEventType = "done"
 	EventError       AgentEventType = "error"
+	EventSteer       AgentEventType = "steer"        // user guidance received mid-run was injected (Content = message)
+	EventCostWarning AgentEventType = "cost_warning" // next LLM call is unusually large (Preflight = estimate)
+	EventQueued      AgentEventType = "queued"       // LLM call waits for a provider rate-limit window (Queue = position)
+	EventSlow        AgentEventType = "slow"         // LLM call is slow to produce its first token or stalled mid-stream (Slow = details)
+	EventFileEdit    AgentEventType = "file_edit"    // an edit-kind tool changed a file (Edit = path, hunks, hashes); follows its tool_result
 )
 
 // AgentEvent represents a single event in the agent's ReAct loop.
@@ -24,6 +29,77 @@ type AgentEvent struct {
 	StepInfo  *StepInfo      `json:"step_info,omitempty"`
 	Error     string         `json:"error,omitempty"`
 	Timestamp time.Time      `json:"timestamp"`
+
+	// AbortReason is set on EventError when the run was aborted rather than
+	// failed: user_stop | interrupted | budget | timeout | shutdown | cancelled.
+	AbortReason string `json:"abort_reason,omitempty"`
+
+	// Preflight is set on EventCostWarning.
+	Preflight *PreflightInfo `json:"preflight,omitempty"`
+
+	// Queue is set on EventQueued.
+	Queue *QueueInfo `json:"queue,omitempty"`
+
+	// Slow is set on EventSlow.
+	Slow *SlowInfo `json:"slow,omitempty"`
+
+	// Edit is set on EventFileEdit.
+	Edit *FileEdit `json:"edit,omitempty"`
+}
+
+// FileEdit is a change an edit-kind tool made to one file, structured so
+// IDE clients can decorate it inline and offer accept / reject without
+// parsing the tool's text output. Reject = apply the hunks in reverse,
+// after checking the file still hashes to AfterHash.
+type FileEdit struct {
+	ToolCallID string     `json:"tool_call_id"`
+	Path       string     `json:"path"`                  // absolute
+	BeforeHash string     `json:"before_hash,omitempty"` // sha256 (hex) of the previous content; empty = file was created
+	AfterHash  string     `json:"after_hash,omitempty"`  // sha256 (hex) of the new content; empty = file was deleted
+	Hunks      []EditHunk `json:"hunks,omitempty"`
+	Truncated  bool       `json:"truncated,omitempty"` // change too large to send; Hunks is empty, read the file
+}
+
+// EditHunk is one unified-diff hunk. Lines carry a " ", "-" or "+" prefix
+// (context, removed, added); starts are 1-based, as in "@@ -a,b +c,d @@".
+type EditHunk struct {
+	OldStart int      `json:"old_start"`
+	OldLines int      `json:"old_lines"`
+	NewStart int      `json:"new_start"`
+	NewLines int      `json:"new_lines"`
+	Lines    []string `json:"lines"`
+}
+
+// PreflightInfo is the estimate for an LLM call above the pre-flight threshold.
+type PreflightInfo struct {
+	Model       string  `json:"model"`
+	InputTokens int     `json:"input_tokens"`
+	CostUSD     float64 `json:"cost_usd,omitempty"` // input cost; 0 = model has no input_price
+}
+
+// QueueInfo is the position of an LLM call waiting for a provider's
+// Retry-After window to clear.
+type QueueInfo struct {
+	Provider string        `json:"provider"`
+	Position int           `json:"position"` // 1 = next to go
+	Wait     time.Duration `json:"wait"`     // estimated time until this call is sent
+}
+
+// SlowInfo describes a streaming LLM call that has produced no output for
+// longer than the configured threshold.
+type SlowInfo struct {
+	Provider string        `json:"provider"`
+	Model    string        `json:"model"`
+	Waited   time.Duration `json:"waited"`  // silence so far: since the request was sent, or since the last chunk
+	Stalled  bool          `json:"stalled"` // false = no first token yet, true = stalled mid-stream
+}
+
+// MessageKey returns the i18n key of the notice shown to the user.
+func (s *SlowInfo) MessageKey() string {
+	if s.Stalled {
+		return "run.stalled"
+	}
+	return "run.slow_start"
 }
 
 // ToolCallEvent describes a tool invocation within the agent loop
@@ -43,6 +119,8 @@ type StepInfo struct {
 	TokensUsed int    `json:"tokens_used"`
 	ModelUsed  string `json:"model_used"`
 	State      string `json:"state,omitempty"` // Current state machine state
+	Route      string `json:"route,omitempty"` // Auto-routing decision that picked the model, if any
+	Draft      string `json:"draft,omitempty"` // Speculative draft: "<draft model> → approved|edited|rejected"
 }
 
 // ToolCallInfo represents a tool call parsed from LLM response
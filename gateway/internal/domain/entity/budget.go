@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// BudgetUsage is the spend recorded against one budget pool for one subject
+// in one period (a day or a month).
+type BudgetUsage struct {
+	Pool      string    `json:"pool"`
+	Subject   string    `json:"subject,omitempty"` // "" for global pools, else "user:<id>" / "chat:<id>"
+	Period    string    `json:"period"`            // "2006-01-02" (daily) or "2006-01" (monthly)
+	Tokens    int64     `json:"tokens"`
+	CostUSD   float64   `json:"cost_usd"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
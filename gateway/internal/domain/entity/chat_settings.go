@@ -0,0 +1,53 @@
+package entity
+
+import "time"
+
+// ChatSettings is the persisted per-chat preference record (model selection,
+// think level, security profile, TTS, sampling parameters ...). It survives gateway restarts;
+// conversation history is stored separately.
+type ChatSettings struct {
+	ChatID int64 `json:"chat_id"`
+	UserID int64 `json:"user_id"`
+
+	Model      string `json:"model"`
+	Think      string `json:"think"` // off/low/medium/high
+	Verbose    bool   `json:"verbose"`
+	Reasoning  string `json:"reasoning"` // off/on/stream
+	Locale     string `json:"locale,omitempty"`
+	UsageMode  string `json:"usage_mode,omitempty"`  // off/tokens/full
+	Activation string `json:"activation,omitempty"`  // always/mention
+	SendPolicy string `json:"send_policy,omitempty"` // allow/deny/inherit
+
+	// SecurityProfile is the approval mode last chosen via /security
+	// (auto/ask_dangerous/ask_all); empty = config default.
+	SecurityProfile string `json:"security_profile,omitempty"`
+
+	// Route overrides agent.routing.enabled for this chat (on/off);
+	// empty = config default.
+	Route string `json:"route,omitempty"`
+
+	// Draft overrides agent.draft.enabled for this chat (on/off);
+	// empty = config default.
+	Draft string `json:"draft,omitempty"`
+
+	// Agent is the ID of the named agent chosen via /agent switch;
+	// empty = the default agent (no persona, all tools, the chat's model).
+	Agent string `json:"agent,omitempty"`
+
+	// PromptVars are values for {{name}} placeholders in prompt files set
+	// via /setvar; they override agent.prompt_vars.
+	PromptVars map[string]string `json:"prompt_vars,omitempty"`
+
+	TTSEnabled  bool   `json:"tts_enabled"`
+	TTSProvider string `json:"tts_provider,omitempty"`
+	TTSLimit    int    `json:"tts_limit,omitempty"`
+	TTSSummary  bool   `json:"tts_summary"`
+
+	// Sampling overrides set via /params; zero = default.
+	Temperature     float64 `json:"temperature,omitempty"`
+	TopP            float64 `json:"top_p,omitempty"`
+	MaxOutputTokens int     `json:"max_output_tokens,omitempty"`
+	ReasoningEffort string  `json:"reasoning_effort,omitempty"` // low/medium/high
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
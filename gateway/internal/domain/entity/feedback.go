@@ -0,0 +1,30 @@
+package entity
+
+import "time"
+
+// Feedback labels
+const (
+	FeedbackGood    = "good"    // 👍
+	FeedbackBad     = "bad"     // 👎
+	FeedbackComment = "comment" // free-form /feedback text
+)
+
+// Feedback is a user's verdict on one agent run: the (prompt, context summary,
+// answer, label) tuple operators export to build fine-tuning and eval datasets.
+type Feedback struct {
+	ID      uint   `json:"id"`
+	Source  string `json:"source"` // telegram
+	ChatID  int64  `json:"chat_id"`
+	UserID  int64  `json:"user_id,omitempty"`
+	Model   string `json:"model,omitempty"`
+	Prompt  string `json:"prompt"`
+	Context string `json:"context,omitempty"` // summary of the conversation before the prompt
+	Answer  string `json:"answer"`
+
+	Label   string `json:"label"`             // good / bad / comment
+	Comment string `json:"comment,omitempty"` // /feedback text
+	// MessageID is the reply message the user reacted to (0 for /feedback).
+	MessageID int `json:"message_id,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
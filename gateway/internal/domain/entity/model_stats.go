@@ -0,0 +1,48 @@
+package entity
+
+import "time"
+
+// ModelStats is the rolling usage record for one provider+model pair.
+// Used for capacity planning: which models are slow, expensive or erroring.
+type ModelStats struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+
+	Requests int64 `json:"requests"`
+	Failures int64 `json:"failures"`
+	Tokens   int64 `json:"tokens"`
+
+	LatencyP50Ms float64 `json:"latency_p50_ms"`
+	LatencyP95Ms float64 `json:"latency_p95_ms"`
+
+	// Streamed calls only: time to first output, and how often the first
+	// output or a later one took longer than the configured thresholds.
+	FirstTokenP50Ms float64 `json:"first_token_p50_ms"`
+	FirstTokenP95Ms float64 `json:"first_token_p95_ms"`
+	SlowStarts      int64   `json:"slow_starts"`
+	Stalls          int64   `json:"stalls"`
+
+	// Errors counts failures by category (service.LLMErrorKind labels: "transient", "auth", ...).
+	Errors map[string]int64 `json:"errors,omitempty"`
+
+	// LatencySamples holds the most recent request latencies in milliseconds,
+	// oldest first. Percentiles are computed over this rolling window.
+	LatencySamples []int64 `json:"-"`
+	// FirstTokenSamples is the same window for first-token latencies.
+	FirstTokenSamples []int64 `json:"-"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Key returns the unique identifier "provider|model".
+func (s *ModelStats) Key() string {
+	return s.Provider + "|" + s.Model
+}
+
+// ErrorRate returns failures / requests (0 when no requests).
+func (s *ModelStats) ErrorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Failures) / float64(s.Requests)
+}
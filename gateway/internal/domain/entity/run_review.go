@@ -0,0 +1,41 @@
+package entity
+
+import "fmt"
+
+// Review actions: what the self-review did with the answer.
+const (
+	ReviewAccept = "accept" // delivered as is
+	ReviewCaveat = "caveat" // delivered with the reviewer's caveats appended
+	ReviewRetry  = "retry"  // sent back for one corrective iteration
+)
+
+// RunReview is the verdict of the post-run self-review: a cheap model's
+// check of the final answer against the original request and the tool
+// outputs it should rest on.
+type RunReview struct {
+	Model    string   `json:"model"`
+	Score    int      `json:"score"`    // 1-10
+	Answered bool     `json:"answered"` // the answer addresses the request
+	Grounded bool     `json:"grounded"` // its claims are backed by tool outputs (or need none)
+	Issues   []string `json:"issues,omitempty"`
+	Caveats  []string `json:"caveats,omitempty"` // notes for the reader, appended on ReviewCaveat
+	Action   string   `json:"action"`
+}
+
+// Summary is a one-line report for run footers, e.g. "review 6/10 · caveats (2)".
+func (r *RunReview) Summary() string {
+	s := fmt.Sprintf("review %d/10", r.Score)
+	switch r.Action {
+	case ReviewCaveat:
+		s += fmt.Sprintf(" · caveats (%d)", len(r.Caveats))
+	case ReviewRetry:
+		s += " · corrected"
+	}
+	if !r.Answered {
+		s += " · not answered"
+	}
+	if !r.Grounded {
+		s += " · ungrounded"
+	}
+	return s
+}
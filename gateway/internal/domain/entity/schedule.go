@@ -0,0 +1,39 @@
+package entity
+
+import "time"
+
+// Schedule kinds
+const (
+	ScheduleCron      = "cron"      // /cron add
+	ScheduleHeartbeat = "heartbeat" // HEARTBEAT.md
+)
+
+// Last-run outcomes
+const (
+	ScheduleOK      = "ok"
+	ScheduleFailed  = "error"
+	ScheduleSkipped = "skipped" // missed while the gateway was down, not caught up
+)
+
+// Schedule is the persisted state of a recurring job: what it runs, when it
+// last ran and how that went, and when it runs next. It survives restarts so
+// runs missed while the gateway was down can be caught up.
+type Schedule struct {
+	ID      string `json:"id"`
+	Kind    string `json:"kind"` // cron / heartbeat
+	ChatID  int64  `json:"chat_id"`
+	Expr    string `json:"expr"`    // cron expression, or the heartbeat interval ("1h0m0s")
+	Command string `json:"command"` // cron command; heartbeat file path
+	// CatchUp overrides scheduler.catch_up for this job (skip / once / all).
+	CatchUp string `json:"catch_up,omitempty"`
+	Enabled bool   `json:"enabled"`
+
+	LastRun    time.Time `json:"last_run,omitempty"`
+	LastStatus string    `json:"last_status,omitempty"` // ok / error / skipped
+	LastError  string    `json:"last_error,omitempty"`
+	NextRun    time.Time `json:"next_run,omitempty"`
+	// Missed is how many runs fell into the last downtime.
+	Missed int `json:"missed,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
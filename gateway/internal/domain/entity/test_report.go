@@ -0,0 +1,78 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TestReport is the structured result of one run_tests call: whether the
+// suite passed and per-package coverage compared with a baseline, so a run
+// can tell whether its changes reduced coverage.
+type TestReport struct {
+	Command  string            `json:"command"`
+	Passed   bool              `json:"passed"`
+	Failed   []string          `json:"failed,omitempty"` // failing packages or test IDs
+	Packages []PackageCoverage `json:"packages,omitempty"`
+	// Baseline names what Baseline coverage was measured on, e.g. "HEAD 1a2b3c4";
+	// empty when no baseline was measured.
+	Baseline string `json:"baseline,omitempty"`
+}
+
+// PackageCoverage is the statement coverage of one Go package or Python directory.
+type PackageCoverage struct {
+	Package  string   `json:"package"`
+	Coverage float64  `json:"coverage"`           // percent
+	Baseline *float64 `json:"baseline,omitempty"` // nil = not measured or new package
+}
+
+// Delta returns Coverage - Baseline, and false when there is no baseline.
+func (c PackageCoverage) Delta() (float64, bool) {
+	if c.Baseline == nil {
+		return 0, false
+	}
+	return c.Coverage - *c.Baseline, true
+}
+
+// Reduced returns the packages whose coverage dropped by more than 0.05 points.
+func (r *TestReport) Reduced() []PackageCoverage {
+	var out []PackageCoverage
+	for _, p := range r.Packages {
+		if d, ok := p.Delta(); ok && d < -0.05 {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Summary is a one-line report for status lines and run footers, e.g.
+// "tests passed · coverage ↓ pkg/a 80.0% → 75.5% (-4.5)".
+func (r *TestReport) Summary() string {
+	var sb strings.Builder
+	if r.Passed {
+		sb.WriteString("tests passed")
+	} else {
+		sb.WriteString(fmt.Sprintf("tests failed (%d)", len(r.Failed)))
+	}
+	if len(r.Packages) == 0 {
+		return sb.String()
+	}
+	reduced := r.Reduced()
+	switch {
+	case len(reduced) > 0:
+		parts := make([]string, 0, 3)
+		for i, p := range reduced {
+			if i == 3 {
+				parts = append(parts, fmt.Sprintf("+%d more", len(reduced)-3))
+				break
+			}
+			d, _ := p.Delta()
+			parts = append(parts, fmt.Sprintf("%s %.1f%% → %.1f%% (%+.1f)", p.Package, *p.Baseline, p.Coverage, d))
+		}
+		sb.WriteString(" · coverage ↓ " + strings.Join(parts, ", "))
+	case r.Baseline != "":
+		sb.WriteString(fmt.Sprintf(" · coverage not reduced (%d packages vs %s)", len(r.Packages), r.Baseline))
+	default:
+		sb.WriteString(fmt.Sprintf(" · coverage measured for %d packages", len(r.Packages)))
+	}
+	return sb.String()
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+)
+
+// BudgetRepository 预算池用量仓储接口
+type BudgetRepository interface {
+	// Add 累加用量 (按 pool+subject+period 创建或更新)
+	Add(ctx context.Context, usage *entity.BudgetUsage) error
+
+	// Get 返回该周期的用量, 没有记录时返回零用量
+	Get(ctx context.Context, pool, subject, period string) (*entity.BudgetUsage, error)
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+)
+
+// ChatSettingsRepository 会话偏好仓储接口 (按 chat 持久化)
+type ChatSettingsRepository interface {
+	// FindAll 加载全部会话偏好 (启动时恢复)
+	FindAll(ctx context.Context) ([]*entity.ChatSettings, error)
+
+	// Save 保存会话偏好（按 chat_id 创建或更新）
+	Save(ctx context.Context, settings *entity.ChatSettings) error
+
+	// Delete 删除会话偏好 (/forgetme); 不存在时不报错
+	Delete(ctx context.Context, chatID int64) error
+}
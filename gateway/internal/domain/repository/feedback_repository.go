@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+)
+
+// FeedbackFilter 反馈导出条件, 零值表示不过滤
+type FeedbackFilter struct {
+	Since time.Time
+	Label string
+}
+
+// FeedbackRepository 用户反馈仓储接口
+type FeedbackRepository interface {
+	// Save 保存一条反馈
+	Save(ctx context.Context, feedback *entity.Feedback) error
+
+	// List 按时间顺序返回符合条件的反馈
+	List(ctx context.Context, filter FeedbackFilter) ([]*entity.Feedback, error)
+
+	// Purge 删除符合条件的反馈, 返回删除条数 (Chats 按 chat ID 匹配)
+	Purge(ctx context.Context, filter PurgeFilter) (int64, error)
+}
@@ -22,4 +22,7 @@ type MessageRepository interface {
 
 	// Count 统计会话中的消息数量
 	Count(ctx context.Context, conversationID string) (int64, error)
+
+	// Purge 永久删除符合条件的消息, 返回删除条数 (Chats 按 conversation ID 匹配)
+	Purge(ctx context.Context, filter PurgeFilter) (int64, error)
 }
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+)
+
+// ModelStatsRepository 模型调用统计仓储接口
+type ModelStatsRepository interface {
+	// FindAll 加载全部 provider+model 统计
+	FindAll(ctx context.Context) ([]*entity.ModelStats, error)
+
+	// Save 保存统计（按 provider+model 创建或更新）
+	Save(ctx context.Context, stats *entity.ModelStats) error
+}
@@ -0,0 +1,10 @@
+package repository
+
+import "time"
+
+// PurgeFilter 数据清除条件 (保留策略清理与 /forgetme), 零值表示全部记录
+type PurgeFilter struct {
+	Before time.Time // 只删除早于该时间的记录; 零值 = 不限时间
+	Chats  []string  // 只删除这些会话的记录 (chat ID / conversation ID); 空 = 全部会话
+	Except []string  // 跳过这些会话 (它们有单独的保留策略)
+}
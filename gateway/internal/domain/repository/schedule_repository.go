@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+)
+
+// ScheduleRepository 定时任务 / 心跳调度状态仓储接口
+type ScheduleRepository interface {
+	// List 加载指定类型的全部调度状态
+	List(ctx context.Context, kind string) ([]*entity.Schedule, error)
+
+	// Get 按 ID 加载; 不存在时返回 nil, nil
+	Get(ctx context.Context, id string) (*entity.Schedule, error)
+
+	// Save 保存调度状态 (按 ID 创建或更新)
+	Save(ctx context.Context, schedule *entity.Schedule) error
+
+	// Delete 删除调度状态; 不存在时不报错
+	Delete(ctx context.Context, id string) error
+}
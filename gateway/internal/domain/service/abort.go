@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+)
+
+// AbortReason says why a run stopped before the model finished. It travels
+// as the context cancel cause (context.WithCancelCause + NewAbortError) and
+// is surfaced on AgentEvent.AbortReason / AgentResult.AbortReason, so
+// channels can tell "/stop" from "budget ran out" from "new message".
+type AbortReason string
+
+const (
+	AbortNone        AbortReason = ""
+	AbortUserStop    AbortReason = "user_stop"   // /stop, Ctrl+C
+	AbortInterrupted AbortReason = "interrupted" // superseded by a newer message in the same chat
+	AbortBudget      AbortReason = "budget"      // token/time budget exhausted (CostGuard)
+	AbortDenied      AbortReason = "denied"      // a large LLM call was not approved (pre-flight cost check)
+	AbortTimeout     AbortReason = "timeout"     // deadline exceeded (e.g. job timeout)
+	AbortShutdown    AbortReason = "shutdown"    // gateway or worker shutting down
+	AbortCancelled   AbortReason = "cancelled"   // cancelled without a recorded reason
+)
+
+// MessageKey returns the i18n catalog key for the user-facing description.
+func (r AbortReason) MessageKey() string {
+	if r == AbortNone {
+		return "abort.cancelled"
+	}
+	return "abort." + string(r)
+}
+
+// UserInitiated reports whether the user chose to stop the run. Partial
+// output of such runs is kept in history as-is; other aborts are marked so
+// the model knows its previous answer was cut off.
+func (r AbortReason) UserInitiated() bool {
+	return r == AbortUserStop || r == AbortInterrupted || r == AbortDenied
+}
+
+// HistoryMarker is appended to partial assistant output saved in history.
+func (r AbortReason) HistoryMarker() string {
+	switch r {
+	case AbortUserStop:
+		return "[stopped by user]"
+	case AbortInterrupted:
+		return "[interrupted by a new message]"
+	case AbortBudget:
+		return "[cut off: budget exhausted]"
+	case AbortDenied:
+		return "[stopped: large request not approved]"
+	case AbortTimeout:
+		return "[cut off: timed out]"
+	case AbortShutdown:
+		return "[cut off: service restarting]"
+	default:
+		return "[cut off]"
+	}
+}
+
+// AbortError is the cancel cause carrying an AbortReason.
+type AbortError struct {
+	Reason AbortReason
+	Detail string // optional, e.g. the budget that was exceeded
+}
+
+// NewAbortError creates an abort cause for context.CancelCauseFunc.
+func NewAbortError(reason AbortReason, detail string) *AbortError {
+	return &AbortError{Reason: reason, Detail: detail}
+}
+
+// Error implements the error interface.
+func (e *AbortError) Error() string {
+	msg := "run aborted (" + string(e.Reason) + ")"
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+// AbortReasonOf classifies a cancel cause or run error; AbortNone when err
+// is not an abort.
+func AbortReasonOf(err error) AbortReason {
+	var abortErr *AbortError
+	switch {
+	case err == nil:
+		return AbortNone
+	case errors.As(err, &abortErr):
+		return abortErr.Reason
+	case errors.Is(err, context.DeadlineExceeded):
+		return AbortTimeout
+	case errors.Is(err, context.Canceled):
+		return AbortCancelled
+	}
+	return AbortNone
+}
+
+// AbortReasonFromContext returns why ctx was cancelled, AbortNone while it
+// is still live.
+func AbortReasonFromContext(ctx context.Context) AbortReason {
+	if ctx.Err() == nil {
+		return AbortNone
+	}
+	if reason := AbortReasonOf(context.Cause(ctx)); reason != AbortNone {
+		return reason
+	}
+	return AbortCancelled
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"go.uber.org/zap"
+)
+
+func TestAbortReasonFromContext(t *testing.T) {
+	if got := AbortReasonFromContext(context.Background()); got != AbortNone {
+		t.Errorf("live context = %q, want none", got)
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(NewAbortError(AbortInterrupted, ""))
+	if got := AbortReasonFromContext(ctx); got != AbortInterrupted {
+		t.Errorf("cause = %q, want interrupted", got)
+	}
+
+	plain, cancelPlain := context.WithCancel(context.Background())
+	cancelPlain()
+	if got := AbortReasonFromContext(plain); got != AbortCancelled {
+		t.Errorf("plain cancel = %q, want cancelled", got)
+	}
+
+	deadline, cancelDeadline := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancelDeadline()
+	<-deadline.Done()
+	if got := AbortReasonFromContext(deadline); got != AbortTimeout {
+		t.Errorf("deadline = %q, want timeout", got)
+	}
+
+	wrapped := errors.Join(errors.New("tool failed"), NewAbortError(AbortBudget, "50k tokens"))
+	if got := AbortReasonOf(wrapped); got != AbortBudget {
+		t.Errorf("wrapped = %q, want budget", got)
+	}
+	if got := AbortReasonOf(errors.New("503")); got != AbortNone {
+		t.Errorf("plain error = %q, want none", got)
+	}
+}
+
+// abortTestLLM cancels the run (as /stop would) while the first call is in flight.
+type abortTestLLM struct {
+	cancel context.CancelCauseFunc
+}
+
+func (l *abortTestLLM) Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error) {
+	l.cancel(NewAbortError(AbortUserStop, ""))
+	return nil, context.Cause(ctx)
+}
+
+func (l *abortTestLLM) GenerateStream(ctx context.Context, req *LLMRequest, deltaCh chan<- StreamChunk) (*LLMResponse, error) {
+	return l.Generate(ctx, req)
+}
+
+type abortTestTools struct{}
+
+func (abortTestTools) Execute(ctx context.Context, name string, args map[string]interface{}) (*domaintool.Result, error) {
+	return &domaintool.Result{Success: true}, nil
+}
+func (abortTestTools) GetDefinitions() []domaintool.Definition { return nil }
+func (abortTestTools) GetToolKind(name string) domaintool.Kind { return domaintool.KindRead }
+
+func TestAgentLoop_AbortReasonOnEventAndResult(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	loop := NewAgentLoop(&abortTestLLM{cancel: cancel}, abortTestTools{}, DefaultAgentLoopConfig(), zap.NewNop())
+
+	result, eventCh := loop.Run(ctx, "", "hi", nil, "")
+	var abortEvent *entity.AgentEvent
+	for ev := range eventCh {
+		if ev.Type == entity.EventError {
+			ev := ev
+			abortEvent = &ev
+		}
+	}
+
+	if abortEvent == nil || abortEvent.AbortReason != string(AbortUserStop) {
+		t.Fatalf("expected user_stop abort event, got %+v", abortEvent)
+	}
+	if result.AbortReason != AbortUserStop {
+		t.Errorf("result.AbortReason = %q", result.AbortReason)
+	}
+}
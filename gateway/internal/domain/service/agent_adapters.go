@@ -74,6 +74,10 @@ func (t *ToolExecutorAdapter) Execute(ctx context.Context, name string, args map
 		}, nil
 	}
 
+	if invalid := domaintool.CheckArgs(tool, args); invalid != nil {
+		return invalid, nil
+	}
+
 	return tool.Execute(ctx, args)
 }
 
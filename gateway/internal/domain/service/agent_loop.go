@@ -28,6 +28,10 @@ type AgentLoopConfig struct {
 	MaxRetries    int           // Max retries per LLM call (default: 3)
 	RetryBaseWait time.Duration // Base wait between retries (default: 2s, exponential: 2s, 4s, 8s)
 
+	// Stream stalls: failures after text has streamed (see callLLMWithRetry)
+	StreamStallRetries int // Continuations after stalls, on top of MaxRetries; 0 = off, negative = default (2)
+	StreamAcceptChars  int // Keep a stalled answer as is once this many characters have streamed (default: 4000)
+
 	// Context compaction
 	CompactThreshold int // Deprecated: use ContextGuard for token-based compaction
 	CompactKeepLast  int // Number of recent messages to preserve during compaction (default: 10)
@@ -37,16 +41,23 @@ type AgentLoopConfig struct {
 
 	// Guardrails — OpenClaw/Continue aligned: token budget is the only natural limit.
 	// No MaxSteps, no RunTimeout. Loop runs until LLM stops calling tools or tokens exhaust.
-	MaxTokenBudget      int64         // Token budget limit (0 = disabled)
-	ToolTimeout         time.Duration // Per-tool execution timeout (default 30s)
-	ContextMaxTokens    int           // Context window token limit (default 128000)
-	ContextWarnRatio    float64       // Warn when context > this ratio (default 0.7)
-	ContextHardRatio    float64       // Force compact when > this ratio (default 0.85)
-	LoopWindowSize      int           // Sliding window size for exact-match loop detection (default 10)
-	LoopDetectThreshold int           // Identical calls in window to trigger reflection (default 5)
-	LoopNameThreshold   int           // Same tool name consecutive calls to trigger reflection (default 8)
+	MaxTokenBudget      int64                    // Token budget limit (0 = disabled)
+	ToolTimeout         time.Duration            // Per-tool execution timeout (default 30s)
+	ToolTimeouts        map[string]time.Duration // Overrides ToolTimeout for long-running tools (e.g. run_tests)
+	ContextMaxTokens    int                      // Context window token limit for models without a known window (default 128000)
+	ContextWarnRatio    float64                  // Warn when context > this ratio (default 0.7)
+	ContextHardRatio    float64                  // Force compact when > this ratio (default 0.85)
+	LoopWindowSize      int                      // Sliding window size for exact-match loop detection (default 10)
+	LoopDetectThreshold int                      // Identical calls in window to trigger reflection (default 5)
+	LoopNameThreshold   int                      // Same tool name consecutive calls to trigger reflection (default 8)
+	PreflightTokens     int                      // Warn (and ask LLMCallApprover hooks) before LLM calls with more estimated input tokens (0 = disabled)
+	FilterRetry         bool                     // Retry once with a sanitized prompt when the provider's content filter blocks an answer (default true)
 }
 
+// DefaultStreamStallRetries is how many times a stalled stream is continued
+// unless the config says otherwise. 0 turns continuations off.
+const DefaultStreamStallRetries = 2
+
 // DefaultAgentLoopConfig returns production-ready defaults.
 // OpenClaw/Continue aligned: no MaxSteps, no RunTimeout.
 // Loop runs until LLM stops calling tools, guarded by token budget + ContextGuard.
@@ -57,6 +68,8 @@ func DefaultAgentLoopConfig() AgentLoopConfig {
 		Temperature:         0.7,
 		MaxRetries:          3,
 		RetryBaseWait:       2 * time.Second,
+		StreamStallRetries:  DefaultStreamStallRetries,
+		StreamAcceptChars:   4000,
 		CompactThreshold:    40,
 		CompactKeepLast:     10,
 		MaxParallelTools:    4,
@@ -67,6 +80,7 @@ func DefaultAgentLoopConfig() AgentLoopConfig {
 		LoopWindowSize:      10,
 		LoopDetectThreshold: 5,
 		LoopNameThreshold:   8,
+		FilterRetry:         true,
 	}
 }
 
@@ -87,6 +101,8 @@ type StreamChunk struct {
 	DeltaText     string               // Incremental text content
 	DeltaToolCall *entity.ToolCallInfo  // Incremental tool call (may arrive in fragments)
 	FinishReason  string               // "stop", "tool_calls", "" (not yet finished)
+	Queue         *entity.QueueInfo     // Set while the call waits for a provider rate-limit window
+	Slow          *entity.SlowInfo      // Set when the stream is slow to start or stalls
 }
 
 // LLMRequest is the request sent to the language model
@@ -96,6 +112,14 @@ type LLMRequest struct {
 	Model       string                 `json:"model"`
 	MaxTokens   int                    `json:"max_tokens,omitempty"`
 	Temperature float64                `json:"temperature"`
+
+	// Optional sampling controls (zero = provider default), see ModelParams
+	TopP            float64 `json:"top_p,omitempty"`
+	ReasoningEffort string  `json:"reasoning_effort,omitempty"` // low/medium/high
+
+	// Thinking controls set by ModelPolicy.ApplyThinking (TG /think)
+	ThinkingBudget int   `json:"thinking_budget,omitempty"` // thinking token budget; 0 = provider default
+	EnableThinking *bool `json:"enable_thinking,omitempty"` // nil = provider default
 }
 
 // LLMMessage represents a single message in the conversation
@@ -106,6 +130,8 @@ type LLMMessage struct {
 	ToolCalls  []entity.ToolCallInfo `json:"tool_calls,omitempty"`
 	ToolCallID string               `json:"tool_call_id,omitempty"`
 	Name       string               `json:"name,omitempty"`
+	Model      string               `json:"model,omitempty"` // model that produced an assistant message (see TranscodeHistory)
+	Thinking   []ThinkingBlock      `json:"thinking,omitempty"` // reasoning blocks to replay with this assistant turn
 }
 
 // ContentPart represents a multimodal content fragment.
@@ -150,6 +176,11 @@ type LLMResponse struct {
 	ToolCalls  []entity.ToolCallInfo `json:"tool_calls,omitempty"`
 	ModelUsed  string               `json:"model_used"`
 	TokensUsed int                  `json:"tokens_used"`
+	Thinking   []ThinkingBlock      `json:"thinking,omitempty"`
+
+	// FinishReason is the provider's stop reason; safety refusals and
+	// filtered output are normalized to FinishContentFilter.
+	FinishReason string `json:"finish_reason,omitempty"`
 }
 
 // ToolExecutor is the interface for executing tools within the agent loop
@@ -172,7 +203,15 @@ type AgentLoop struct {
 	hooks      AgentHook
 	middleware *MiddlewarePipeline
 	toolCache  *ToolResultCache
-	logger     *zap.Logger
+	transcript TranscriptSink // optional, see SetTranscriptSink
+	journal    JournalSink    // optional, see SetJournalSink
+	// optional, see SetToolSelector
+	toolSelector *ToolSelector
+	// optional, see SetReviewPolicy
+	review *ReviewPolicy
+	// per-channel configs, see SetChannelConfigs
+	channels map[string]*AgentLoopConfig
+	logger       *zap.Logger
 }
 
 // NewAgentLoop creates a new ReAct agent loop
@@ -189,6 +228,12 @@ func NewAgentLoop(llm LLMClient, tools ToolExecutor, config AgentLoopConfig, log
 	if config.RetryBaseWait <= 0 {
 		config.RetryBaseWait = 2 * time.Second
 	}
+	if config.StreamStallRetries < 0 {
+		config.StreamStallRetries = DefaultStreamStallRetries
+	}
+	if config.StreamAcceptChars <= 0 {
+		config.StreamAcceptChars = 4000
+	}
 	if config.CompactThreshold <= 0 {
 		config.CompactThreshold = 40
 	}
@@ -252,6 +297,27 @@ type AgentResult struct {
 	TotalTokens  int
 	ModelUsed    string
 	ToolsUsed    []string
+	AbortReason  AbortReason        // non-empty when the run was aborted, see abort.go
+	TestReport   *entity.TestReport // latest run_tests result, nil when tests were not run
+	Draft        DraftOutcome       // speculative draft result, empty when no draft was made (see draft.go)
+	Review       *entity.RunReview  // post-run self-review verdict, nil when not reviewed (see review.go)
+	Filtered     bool               // the provider's content filter blocked the answer; FinalContent is the explanation (see content_filter.go)
+}
+
+// abortRun ends an aborted run: terminal state, typed error event, and the
+// reason recorded on the result for callers deciding how to keep history.
+func (a *AgentLoop) abortRun(ctx context.Context, eventCh chan<- entity.AgentEvent, sm *StateMachine, result *AgentResult, reason AbortReason, detail string) {
+	_ = sm.Transition(StateAborted)
+	result.AbortReason = reason
+	if lh, ok := a.hooks.(RunLifecycleHook); ok {
+		lh.OnRunAbort(ctx, reason, result)
+	}
+	a.logger.Info("Agent run aborted", zap.String("reason", string(reason)), zap.String("detail", detail))
+	a.emitEvent(eventCh, entity.AgentEvent{
+		Type:        entity.EventError,
+		Error:       NewAbortError(reason, detail).Error(),
+		AbortReason: string(reason),
+	})
 }
 
 // Run executes the ReAct loop, emitting events to the provided channel.
@@ -296,6 +362,13 @@ func (a *AgentLoop) Run(ctx context.Context, systemPrompt string, userMessage st
 		a.runLoop(ctx, systemPrompt, userMessage, history, result, eventCh, sm, modelOverride)
 	}()
 
+	if a.transcript != nil {
+		model := modelOverride
+		if model == "" {
+			model = a.configFor(ctx).Model
+		}
+		return result, a.teeTranscript(ctx, userMessage, model, result, eventCh)
+	}
 	return result, eventCh
 }
 
@@ -311,6 +384,8 @@ func (a *AgentLoop) runLoop(
 ) {
 	// Store user message in context for MemoryMiddleware
 	ctx = WithUserMessage(ctx, userMessage)
+	// Defaults and guardrails of the channel the run came from (see channel_config.go)
+	cfg := a.configFor(ctx)
 
 	// Build initial messages
 	messages := make([]LLMMessage, 0, len(history)+2)
@@ -318,17 +393,27 @@ func (a *AgentLoop) runLoop(
 		messages = append(messages, LLMMessage{Role: "system", Content: systemPrompt})
 	}
 	messages = append(messages, history...)
-	messages = append(messages, LLMMessage{Role: "user", Content: userMessage})
+	user := LLMMessage{Role: "user", Content: userMessage}
+	if parts := ContextPartsFromContext(ctx); len(parts) > 0 {
+		// Pre-fetched context (e.g. pages of links in the message) travels with the user turn
+		user.Parts = append([]ContentPart{{Type: "text", Text: userMessage}}, parts...)
+	}
+	messages = append(messages, user)
+	if resumed, ok := ResumeMessagesFromContext(ctx); ok {
+		messages = append(messages[:0:0], resumed...)
+	}
 
 	toolDefs := a.tools.GetDefinitions()
 	toolsUsedSet := make(map[string]bool)
 
 	// Initialize guardrails for this run
-	loopDetector := NewLoopDetector(a.config.LoopWindowSize, a.config.LoopDetectThreshold, a.config.LoopNameThreshold, a.logger)
-	contextGuard := NewContextGuard(a.config.ContextMaxTokens, a.config.ContextWarnRatio, a.config.ContextHardRatio, a.logger)
+	loopDetector := NewLoopDetector(cfg.LoopWindowSize, cfg.LoopDetectThreshold, cfg.LoopNameThreshold, a.logger)
+	// Injected messages (continue, reflection, progress) follow the chat's reply language
+	lang := ReplyLanguageFromContext(ctx)
+	loopDetector.SetLanguage(lang)
 	var costGuard *CostGuard
-	if a.config.MaxTokenBudget > 0 {
-		costGuard = NewCostGuard(a.config.MaxTokenBudget, 0, a.logger)
+	if cfg.MaxTokenBudget > 0 {
+		costGuard = NewCostGuard(cfg.MaxTokenBudget, 0, a.logger)
 	}
 
 	// OpenClaw/Continue aligned: no RunTimeout. Token budget is the natural limit.
@@ -337,6 +422,9 @@ func (a *AgentLoop) runLoop(
 	consecutiveFailures := 0    // Track consecutive tool failures for early abort
 	overflowCompactions := 0    // Track auto-compaction retries on context overflow (max 3)
 	compactionThisTurn := false // OpenClaw pattern: auto-continue once after compaction
+	preflightApproved := 0      // largest estimate approved in this run (see preflight)
+	reviewed := false           // the final answer went through self-review (at most once per run)
+	filterRetried := false      // a content-filtered answer was retried with a sanitized prompt (see content_filter.go)
 
 	// OpenClaw pattern: collect cleaned text from every assistant turn.
 	// Many models (MiniMax, Qwen3) emit ALL useful text during intermediate
@@ -346,33 +434,86 @@ func (a *AgentLoop) runLoop(
 	var assistantTexts []string
 
 	// Determine effective model for this run
-	model := a.config.Model
+	model := cfg.Model
 	if modelOverride != "" {
 		model = modelOverride
 		a.logger.Info("Model override active", zap.String("override", modelOverride))
 	}
 
+	// Auto-routing decision that chose modelOverride (reported in StepInfo)
+	route := ""
+	if d, ok := RouteDecisionFromContext(ctx); ok {
+		route = d.String()
+	}
+
+	// Per-session sampling overrides (TG /params); zero fields keep the defaults
+	params := ModelParamsFromContext(ctx)
+
+	// Named agent (TG /agent switch): only its tools are offered, and its
+	// temperature applies unless /params sets one
+	agentProfile, hasAgentProfile := AgentProfileFromContext(ctx)
+	if hasAgentProfile {
+		toolDefs = agentProfile.FilterTools(toolDefs)
+		if params.Temperature == 0 {
+			params.Temperature = agentProfile.Temperature
+		}
+	}
+
+	// Per-step tool subsetting: only the most relevant definitions are sent,
+	// the rest stay reachable through request_tool
+	toolSubset := a.toolSelector.begin(toolDefs, TaskIntentFromContext(ctx), history)
+
+	// Think level (TG /think); an explicit /params effort takes precedence
+	thinkLevel := ThinkLevelFromContext(ctx)
+	if params.ReasoningEffort != "" {
+		thinkLevel = params.ReasoningEffort
+	}
+
 	// Resolve per-model policy for this run
-	policy := ResolveModelPolicy(model, a.config.ModelPolicies)
+	policy := ResolveModelPolicy(model, cfg.ModelPolicies)
 	a.logger.Info("Model policy resolved",
 		zap.String("model", model),
 		zap.String("reasoning_format", policy.ReasoningFormat),
+		zap.String("thinking_control", policy.ThinkingControl),
+		zap.String("think_level", thinkLevel),
 		zap.Int("progress_interval", policy.ProgressInterval),
 		zap.String("prompt_style", policy.PromptStyle),
+		zap.Int("context_window", policy.ContextWindow),
 	)
 
+	// Context window: per-model (known default or model_policies), else the global limit
+	contextWindow := policy.ContextWindow
+	if contextWindow <= 0 {
+		contextWindow = cfg.ContextMaxTokens
+	}
+	contextGuard := NewContextGuard(contextWindow, cfg.ContextWarnRatio, cfg.ContextHardRatio, a.logger)
+
+	// Hand the history over to this model: tool calls recorded by another
+	// model/provider may be invalid in this provider's format
+	if transcoded, changes := TranscodeHistory(messages, model, policy); changes > 0 {
+		messages = transcoded
+		a.logger.Info("History transcoded for model",
+			zap.String("model", model),
+			zap.String("tool_history", policy.ToolHistory),
+			zap.Int("changes", changes),
+		)
+	}
+
+	if lh, ok := a.hooks.(RunLifecycleHook); ok {
+		lh.OnRunStart(ctx, userMessage, model)
+	}
+
+	journal := a.startJournal(ctx, userMessage, model)
+	defer journal.finish(result)
+
 	// OpenClaw/Continue pattern: no MaxSteps, no RunTimeout.
 	// Loop runs until LLM stops calling tools. Safety nets: token budget, ContextGuard.
 	for step := 1; ; step++ {
 		sm.SetStep(step)
 
-		// Check cancellation (RunTimeout or user abort)
-		if err := ctx.Err(); err != nil {
-			_ = sm.Transition(StateAborted)
-			a.emitEvent(eventCh, entity.AgentEvent{
-				Type:  entity.EventError,
-				Error: "context cancelled",
-			})
+		// Check cancellation (/stop, new message, timeout, shutdown)
+		if ctx.Err() != nil {
+			a.abortRun(ctx, eventCh, sm, result, AbortReasonFromContext(ctx), "")
 			return
 		}
 
@@ -381,9 +522,15 @@ func (a *AgentLoop) runLoop(
 			zap.Int("messages", len(messages)),
 		)
 
+		// === Steering: user messages received mid-run join at the step boundary ===
+		if steer, texts := steeringMessages(ctx); len(steer) > 0 {
+			messages = append(messages, steer...)
+			a.emitSteering(eventCh, step, texts)
+		}
+
 		// === Progress injection: policy-driven interval with escalating urgency ===
 		if policy.ProgressInterval > 0 && step > 1 && step%policy.ProgressInterval == 0 {
-			if msg := policy.BuildProgressMessage(step); msg != "" {
+			if msg := policy.BuildProgressMessage(step, lang); msg != "" {
 				messages = append(messages, LLMMessage{
 					Role:    "user",
 					Content: msg,
@@ -414,16 +561,69 @@ func (a *AgentLoop) runLoop(
 		// === Middleware: BeforeModel (transform messages) ===
 		mwMessages := a.middleware.RunBeforeModel(ctx, messages, step)
 
+		// === Turn ordering for strict providers (after middleware, right before conversion) ===
+		if policy.EnforceTurnOrdering {
+			if ordered, changes := enforceTurnOrdering(mwMessages); changes > 0 {
+				mwMessages = ordered
+				a.logger.Debug("Turn ordering enforced",
+					zap.Int("step", step),
+					zap.Int("changes", changes),
+				)
+			}
+		}
+
+		stepTools := toolDefs
+		if toolSubset != nil {
+			stepTools = toolSubset.definitions(messages)
+		}
+
 		llmReq := &LLMRequest{
 			Messages:    mwMessages,
-			Tools:       toolDefs,
+			Tools:       stepTools,
 			Model:       model,
-			Temperature: a.config.Temperature,
+			Temperature: cfg.Temperature,
 		}
+		policy.ApplyThinking(llmReq, thinkLevel)
+		params.Apply(llmReq)
+		contextGuard.ClampMaxTokens(llmReq)
 
 		a.hooks.BeforeLLMCall(ctx, llmReq, step)
+		journal.request(step, llmReq)
+
+		// === Pre-flight cost check: warn / ask before unusually large requests ===
+		if info, ok := a.preflight(ctx, eventCh, llmReq, policy, &preflightApproved); !ok {
+			detail := fmt.Sprintf("request of ~%d input tokens to %s was not approved", info.InputTokens, info.Model)
+			a.abortRun(ctx, eventCh, sm, result, AbortDenied, detail)
+			result.FinalContent = "Stopped: " + detail
+			return
+		}
+
+		// === Speculative draft: a cheap model answers, the premium model only verifies ===
+		if step == 1 {
+			if draft, ok := DraftFromContext(ctx); ok && draft.AppliesTo(model) {
+				if answer, ok := a.speculate(ctx, eventCh, llmReq, draft, sm, result); ok {
+					a.emitEvent(eventCh, entity.AgentEvent{Type: entity.EventTextDelta, Content: answer})
+					result.FinalContent = answer
+					result.TotalSteps = step
+					_ = sm.Transition(StateComplete)
+					a.hooks.OnComplete(ctx, result)
+					a.emitEvent(eventCh, entity.AgentEvent{Type: entity.EventDone})
+					return
+				}
+				if ctx.Err() != nil {
+					a.abortRun(ctx, eventCh, sm, result, AbortReasonFromContext(ctx), "")
+					return
+				}
+			}
+		}
 
 		resp, err := a.callLLMWithRetry(ctx, llmReq, step, eventCh)
+		journal.response(step, resp, err)
+		if err != nil && ctx.Err() != nil {
+			// Aborted mid-stream — not an LLM failure, don't retry or compact
+			a.abortRun(ctx, eventCh, sm, result, AbortReasonFromContext(ctx), "")
+			return
+		}
 		if err != nil {
 			// OpenClaw pattern: reactive overflow detection.
 			// If the API returns a context overflow error, auto-compact and retry
@@ -449,7 +649,7 @@ func (a *AgentLoop) runLoop(
 			a.hooks.OnError(ctx, err, step)
 			a.emitEvent(eventCh, entity.AgentEvent{
 				Type:  entity.EventError,
-				Error: fmt.Sprintf("LLM error at step %d (after %d retries): %v", step, a.config.MaxRetries, err),
+				Error: fmt.Sprintf("LLM error at step %d (after %d retries): %v", step, cfg.MaxRetries, err),
 			})
 			result.FinalContent = fmt.Sprintf("Error: %v", err)
 			return
@@ -463,23 +663,13 @@ func (a *AgentLoop) runLoop(
 
 		// === CostGuard: check token + time budgets ===
 		if costGuard != nil {
-			if err := costGuard.AddTokens(int64(resp.TokensUsed)); err != nil {
-				_ = sm.Transition(StateError)
-				a.hooks.OnError(ctx, err, step)
-				a.emitEvent(eventCh, entity.AgentEvent{
-					Type:  entity.EventError,
-					Error: fmt.Sprintf("Budget exceeded: %v", err),
-				})
-				result.FinalContent = fmt.Sprintf("Stopped: %v", err)
-				return
+			err := costGuard.AddTokens(int64(resp.TokensUsed))
+			if err == nil {
+				err = costGuard.CheckBudget()
 			}
-			if err := costGuard.CheckBudget(); err != nil {
-				_ = sm.Transition(StateError)
+			if err != nil {
 				a.hooks.OnError(ctx, err, step)
-				a.emitEvent(eventCh, entity.AgentEvent{
-					Type:  entity.EventError,
-					Error: fmt.Sprintf("Budget exceeded: %v", err),
-				})
+				a.abortRun(ctx, eventCh, sm, result, AbortBudget, err.Error())
 				result.FinalContent = fmt.Sprintf("Stopped: %v", err)
 				return
 			}
@@ -499,9 +689,32 @@ func (a *AgentLoop) runLoop(
 				TokensUsed: resp.TokensUsed,
 				ModelUsed:  resp.ModelUsed,
 				State:      string(snap.State),
+				Route:      route,
 			},
 		})
 
+		// === Content filter: a blocked answer never joins the conversation ===
+		if resp.FinishReason == FinishContentFilter {
+			a.logger.Warn("Provider content filter blocked the answer",
+				zap.Int("step", step),
+				zap.String("model", resp.ModelUsed),
+				zap.Bool("retried", filterRetried),
+			)
+			if cfg.FilterRetry && !filterRetried {
+				filterRetried = true
+				messages = a.sanitizeForFilter(messages, lang)
+				continue
+			}
+			explanation := nudge(lang, "filter.refused")
+			a.emitEvent(eventCh, entity.AgentEvent{Type: entity.EventTextDelta, Content: explanation})
+			result.FinalContent = explanation
+			result.Filtered = true
+			_ = sm.Transition(StateComplete)
+			a.hooks.OnComplete(ctx, result)
+			a.emitEvent(eventCh, entity.AgentEvent{Type: entity.EventDone})
+			return
+		}
+
 		// 3. Check if there are tool calls
 		a.logger.Info("[DIAG] Post-LLM decision point",
 			zap.Int("step", step),
@@ -524,11 +737,24 @@ func (a *AgentLoop) runLoop(
 				})
 				messages = append(messages, LLMMessage{
 					Role:    "user",
-					Content: "continue",
+					Content: nudge(lang, "continue"),
 				})
 				continue // retry the loop — LLM gets fresh context after compaction
 			}
 
+			// Steering arrived while the model was answering: keep the answer as a
+			// turn and let the model address the new guidance before finishing.
+			if steer, texts := steeringMessages(ctx); len(steer) > 0 {
+				messages = append(messages, LLMMessage{
+					Role:    "assistant",
+					Content: resp.Content,
+					Model:   model,
+				})
+				messages = append(messages, steer...)
+				a.emitSteering(eventCh, step, texts)
+				continue
+			}
+
 			// No tool calls — final response
 			a.logger.Info("[DIAG] Final response path",
 				zap.Int("step", step),
@@ -551,19 +777,21 @@ func (a *AgentLoop) runLoop(
 				if last := messages[len(messages)-1]; last.Role != "assistant" {
 					messages = append(messages, LLMMessage{
 						Role:    "assistant",
-						Content: "好的，已完成工具调用。",
+						Content: nudge(lang, "summary.ack"),
 					})
 				}
 				messages = append(messages, LLMMessage{
 					Role:    "user",
-					Content: "请用简洁的文字总结你刚才执行的操作和最终结果。不要重复方案，只说结果。",
+					Content: nudge(lang, "summary.request"),
 				})
 				summaryReq := &LLMRequest{
 					Messages:    messages,
 					Tools:       nil, // No tools — force text response
 					Model:       model,
-					Temperature: a.config.Temperature,
+					Temperature: cfg.Temperature,
 				}
+				params.Apply(summaryReq)
+				contextGuard.ClampMaxTokens(summaryReq)
 				summaryResp, err := a.callLLMWithRetry(ctx, summaryReq, step+1, eventCh)
 				if err == nil && strings.TrimSpace(summaryResp.Content) != "" {
 					finalContent = StripReasoningTags(summaryResp.Content)
@@ -584,6 +812,29 @@ func (a *AgentLoop) runLoop(
 				)
 			}
 
+			// === Self-review: a cheap model checks the answer against the request ===
+			if a.review != nil && !reviewed && strings.TrimSpace(finalContent) != "" &&
+				(!a.review.ToolRunsOnly || len(toolsUsedSet) > 0) {
+				reviewed = true
+				if review := a.reviewAnswer(ctx, a.review, userMessage, messages, finalContent, sm, result); review != nil {
+					result.Review = review
+					switch review.Action {
+					case entity.ReviewRetry:
+						// One corrective iteration: the answer goes back with the issues
+						messages = append(messages,
+							LLMMessage{Role: "assistant", Content: finalContent, Model: model},
+							LLMMessage{Role: "user", Content: nudge(lang, "review.retry", "- "+strings.Join(review.Issues, "\n- "))},
+						)
+						assistantTexts = assistantTexts[:0]
+						continue
+					case entity.ReviewCaveat:
+						note := caveatNote(finalContent, lang, review.Caveats)
+						a.emitEvent(eventCh, entity.AgentEvent{Type: entity.EventTextDelta, Content: note})
+						finalContent += note
+					}
+				}
+			}
+
 			result.FinalContent = finalContent
 			_ = sm.Transition(StateComplete)
 			a.hooks.OnComplete(ctx, result)
@@ -606,15 +857,27 @@ func (a *AgentLoop) runLoop(
 			Role:      "assistant",
 			Content:   resp.Content,
 			ToolCalls: resp.ToolCalls,
+			Model:     model,
+			Thinking:  resp.Thinking,
 		})
 
 		// 5. Execute tool calls (parallel when multiple)
 		_ = sm.Transition(StateToolExec)
 
+		// Per-model cap on tool calls per step (model_policies.max_parallel_tool_calls):
+		// the excess is answered with a tool result instead of being run
+		calls, deferred := policy.SplitToolCalls(resp.ToolCalls)
+		if len(deferred) > 0 {
+			a.logger.Info("Tool calls over the model's per-step cap deferred",
+				zap.Int("requested", len(resp.ToolCalls)),
+				zap.Int("cap", policy.MaxParallelToolCalls),
+			)
+		}
+
 		// Loop detection: inject reflection prompts instead of hard-terminating.
 		// OpenClaw/Continue philosophy: let the LLM self-correct.
 		var reflectionPrompts []string
-		for _, tc := range resp.ToolCalls {
+		for _, tc := range calls {
 			kind := a.tools.GetToolKind(tc.Name)
 			if domaintool.SafeKinds[kind] {
 				continue // read-only tools don't count toward loop detection
@@ -638,7 +901,7 @@ func (a *AgentLoop) runLoop(
 		}
 
 		// Emit all tool call events
-		for _, tc := range resp.ToolCalls {
+		for _, tc := range calls {
 			a.emitEvent(eventCh, entity.AgentEvent{
 				Type: entity.EventToolCall,
 				ToolCall: &entity.ToolCallEvent{
@@ -657,16 +920,32 @@ func (a *AgentLoop) runLoop(
 			Display  string // Rich UI output from tool (may be empty)
 			Success  bool
 			Duration time.Duration
+			Tests    *entity.TestReport // run_tests structured result
+			Edits    []*entity.FileEdit // files changed by edit-kind tools
 		}
 
-		results := make([]toolExecResult, len(resp.ToolCalls))
+		results := make([]toolExecResult, len(calls))
 		var wg sync.WaitGroup
-		sem := make(chan struct{}, a.config.MaxParallelTools)
-
-		for i, tc := range resp.ToolCalls {
+		sem := make(chan struct{}, cfg.MaxParallelTools)
+
+		// Edit-kind tools run one at a time, in call order, when the policy asks
+		// (model_policies.serial_edit_tools): each waits for the previous one
+		var prevEdit chan struct{}
+		for i, tc := range calls {
+			var waitFor, done chan struct{}
+			if policy.SerialEditTools && a.tools.GetToolKind(tc.Name) == domaintool.KindEdit {
+				waitFor, done = prevEdit, make(chan struct{})
+				prevEdit = done
+			}
 			wg.Add(1)
-			go func(idx int, call entity.ToolCallInfo) {
+			go func(idx int, call entity.ToolCallInfo, waitFor <-chan struct{}, done chan<- struct{}) {
 				defer wg.Done()
+				if done != nil {
+					defer close(done)
+				}
+				if waitFor != nil {
+					<-waitFor
+				}
 
 				// Acquire semaphore slot
 				select {
@@ -676,21 +955,44 @@ func (a *AgentLoop) runLoop(
 					results[idx] = toolExecResult{
 						Index:   idx,
 						TC:      call,
-						Output:  "context cancelled",
+						Output:  NewAbortError(AbortReasonFromContext(ctx), "").Error(),
 						Success: false,
 					}
 					return
 				}
 
-				// BeforeToolCall hook — veto check
-				if !a.hooks.BeforeToolCall(ctx, call.Name, call.Arguments) {
+				// Meta-tool: load omitted tool definitions for the next step
+				if call.Name == RequestToolName && toolSubset != nil {
+					output, success := toolSubset.request(call.Arguments)
+					results[idx] = toolExecResult{Index: idx, TC: call, Output: output, Success: success}
+					return
+				}
+
+				// Tools outside the agent's set are refused even if the model names them
+				if hasAgentProfile && !agentProfile.AllowsTool(call.Name) {
+					results[idx] = toolExecResult{
+						Index:   idx,
+						TC:      call,
+						Output:  fmt.Sprintf("Tool '%s' is not available to agent '%s'", call.Name, agentProfile.Name),
+						Success: false,
+					}
+					return
+				}
+
+				// BeforeToolCall hook — veto check; a hook may explain the veto
+				vetoCtx, veto := WithVetoNote(ctx)
+				if !a.hooks.BeforeToolCall(vetoCtx, call.Name, call.Arguments) {
 					a.logger.Info("Tool call vetoed by hook",
 						zap.String("tool", call.Name),
 					)
+					output := fmt.Sprintf("Tool '%s' was blocked by security policy", call.Name)
+					if reason := veto.Reason(); reason != "" {
+						output += ". " + reason
+					}
 					results[idx] = toolExecResult{
 						Index:   idx,
 						TC:      call,
-						Output:  fmt.Sprintf("Tool '%s' was blocked by security policy", call.Name),
+						Output:  output,
 						Success: false,
 					}
 					return
@@ -716,11 +1018,16 @@ func (a *AgentLoop) runLoop(
 
 				// Per-tool timeout
 				toolCtx := ctx
-				if a.config.ToolTimeout > 0 {
+				timeout := cfg.ToolTimeout
+				if d, ok := cfg.ToolTimeouts[call.Name]; ok {
+					timeout = d
+				}
+				if timeout > 0 {
 					var toolCancel context.CancelFunc
-					toolCtx, toolCancel = context.WithTimeout(ctx, a.config.ToolTimeout)
+					toolCtx, toolCancel = context.WithTimeout(ctx, timeout)
 					defer toolCancel()
 				}
+				toolCtx = WithToolEvents(toolCtx, func(ev entity.AgentEvent) { a.emitEvent(eventCh, ev) })
 
 				toolResult, err := a.tools.Execute(toolCtx, call.Name, call.Arguments)
 				duration := time.Since(start)
@@ -738,7 +1045,10 @@ func (a *AgentLoop) runLoop(
 					)
 				} else {
 					success = toolResult.Success
-					if !success {
+					if !success && strings.HasPrefix(toolResult.Error, domaintool.InvalidArgsTag) {
+						// Schema rejection is already structured for the model; the tool never ran
+						output = toolResult.Error
+					} else if !success {
 						// Structured failure annotation — help model understand what went wrong
 						errText := toolResult.Error
 						if errText == "" {
@@ -759,15 +1069,20 @@ func (a *AgentLoop) runLoop(
 					}
 				}
 
-				output = truncateOutput(output, a.config.MaxOutputChars)
+				a.middleware.RunObserveToolOutput(ctx, call, output)
+				output = truncateOutput(output, cfg.MaxOutputChars)
 
 				// Store result in cache for deduplication
 				a.toolCache.Put(call.Name, call.Arguments, output, success)
 
 				// Capture Display for UI rendering (may be empty)
 				var display string
+				var tests *entity.TestReport
+				var edits []*entity.FileEdit
 				if toolResult != nil {
 					display = toolResult.Display
+					tests, _ = toolResult.Metadata["test_report"].(*entity.TestReport)
+					edits, _ = toolResult.Metadata["file_edits"].([]*entity.FileEdit)
 				}
 
 				results[idx] = toolExecResult{
@@ -777,8 +1092,10 @@ func (a *AgentLoop) runLoop(
 					Display:  display,
 					Success:  success,
 					Duration: duration,
+					Tests:    tests,
+					Edits:    edits,
 				}
-			}(i, tc)
+			}(i, tc, waitFor, done)
 		}
 
 		wg.Wait()
@@ -786,7 +1103,11 @@ func (a *AgentLoop) runLoop(
 		// Process results in order (preserves message ordering for LLM)
 		for _, r := range results {
 			toolsUsedSet[r.TC.Name] = true
+			toolSubset.use(r.TC.Name)
 			sm.RecordToolExec(r.TC.Name)
+			if r.Tests != nil {
+				result.TestReport = r.Tests
+			}
 
 			a.emitEvent(eventCh, entity.AgentEvent{
 				Type: entity.EventToolResult,
@@ -800,6 +1121,12 @@ func (a *AgentLoop) runLoop(
 					Duration:  r.Duration,
 				},
 			})
+			// Structured diffs for IDE clients, after the result they belong to
+			for _, e := range r.Edits {
+				edit := *e
+				edit.ToolCallID = r.TC.ID
+				a.emitEvent(eventCh, entity.AgentEvent{Type: entity.EventFileEdit, Edit: &edit})
+			}
 
 			messages = append(messages, LLMMessage{
 				Role:       "tool",
@@ -807,6 +1134,17 @@ func (a *AgentLoop) runLoop(
 				ToolCallID: r.TC.ID,
 				Name:       r.TC.Name,
 			})
+			journal.toolResult(step, r.TC, r.Output, r.Success, r.Duration)
+		}
+
+		// Calls over the cap still need a tool result to keep tool_use/tool_result paired
+		for _, tc := range deferred {
+			messages = append(messages, LLMMessage{
+				Role:       "tool",
+				Content:    policy.DeferredToolCallMessage(tc.Name, lang),
+				ToolCallID: tc.ID,
+				Name:       tc.Name,
+			})
 		}
 
 		// Track consecutive failures — if all tools in this step failed, count it
@@ -827,7 +1165,7 @@ func (a *AgentLoop) runLoop(
 		if consecutiveFailures >= 3 {
 			messages = append(messages, LLMMessage{
 				Role:    "user",
-				Content: "[SYSTEM] 工具已连续失败 3 轮。请停止重试，用中文告诉用户：遇到了什么问题、尝试了什么、建议的解决方案。",
+				Content: nudge(lang, "tools_failing"),
 			})
 			consecutiveFailures = 0
 		}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+)
+
+// AgentProfile is a named agent a chat has switched to (TG /agent switch).
+// Its persona goes into the system prompt; the agent loop applies the tool
+// set and temperature, and the caller runs the profile's model.
+type AgentProfile struct {
+	ID          string
+	Name        string
+	Persona     string
+	Tools       []string // allowed tool names; empty = every tool
+	Model       string   // "" = the chat's model
+	Temperature float64  // 0 = the chat's /params or the model default
+}
+
+// AgentProfileOf builds the run profile of a stored agent.
+func AgentProfileOf(a *entity.Agent) AgentProfile {
+	cfg := a.ModelConfig()
+	model := cfg.Model()
+	if model != "" && cfg.Provider() != "" {
+		model = cfg.FullModelName()
+	}
+	return AgentProfile{
+		ID:          a.ID(),
+		Name:        a.Name(),
+		Persona:     a.Persona(),
+		Tools:       a.Tools(),
+		Model:       model,
+		Temperature: cfg.Temperature(),
+	}
+}
+
+// AllowsTool reports whether the agent may call the named tool.
+func (p AgentProfile) AllowsTool(name string) bool {
+	if len(p.Tools) == 0 {
+		return true
+	}
+	for _, t := range p.Tools {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterTools keeps the definitions of the tools the agent may call.
+func (p AgentProfile) FilterTools(defs []domaintool.Definition) []domaintool.Definition {
+	if len(p.Tools) == 0 {
+		return defs
+	}
+	kept := make([]domaintool.Definition, 0, len(p.Tools))
+	for _, d := range defs {
+		if p.AllowsTool(d.Name) {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+type agentProfileKey struct{}
+
+// WithAgentProfile runs this request as the given agent.
+func WithAgentProfile(ctx context.Context, p AgentProfile) context.Context {
+	return context.WithValue(ctx, agentProfileKey{}, p)
+}
+
+// AgentProfileFromContext returns the run's agent profile, if one is active.
+func AgentProfileFromContext(ctx context.Context) (AgentProfile, bool) {
+	p, ok := ctx.Value(agentProfileKey{}).(AgentProfile)
+	return p, ok
+}
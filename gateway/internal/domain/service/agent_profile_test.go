@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/valueobject"
+	"go.uber.org/zap"
+)
+
+func TestAgentProfileOf(t *testing.T) {
+	agent, _ := entity.NewAgent("reviewer", "reviewer", valueobject.NewModelConfig("anthropic", "claude-opus-4", 0, 0.2, 0, true))
+	agent.UpdatePersona("You review code.")
+	agent.RestrictTools([]string{"read_file", "grep_search"})
+
+	p := AgentProfileOf(agent)
+	if p.Model != "anthropic/claude-opus-4" || p.Temperature != 0.2 || p.Persona != "You review code." {
+		t.Errorf("profile = %+v", p)
+	}
+	if !p.AllowsTool("read_file") || p.AllowsTool("bash") {
+		t.Errorf("tool set not applied: %v", p.Tools)
+	}
+	defs := p.FilterTools([]domaintool.Definition{{Name: "bash"}, {Name: "read_file"}, {Name: "grep_search"}})
+	if len(defs) != 2 || defs[0].Name != "read_file" {
+		t.Errorf("FilterTools = %v", defs)
+	}
+
+	open, _ := entity.NewAgent("any", "any", valueobject.NewModelConfig("", "", 0, 0, 0, true))
+	if p := AgentProfileOf(open); p.Model != "" || !p.AllowsTool("bash") {
+		t.Errorf("unrestricted profile = %+v", p)
+	}
+}
+
+// profileTestLLM calls bash once, then answers; it records what it was offered.
+type profileTestLLM struct {
+	tools       []string
+	temperature float64
+	results     []string
+}
+
+func (l *profileTestLLM) Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error) {
+	if l.tools == nil {
+		l.temperature = req.Temperature
+		for _, d := range req.Tools {
+			l.tools = append(l.tools, d.Name)
+		}
+		return &LLMResponse{ToolCalls: []entity.ToolCallInfo{{ID: "1", Name: "bash"}}}, nil
+	}
+	for _, m := range req.Messages {
+		if m.Role == "tool" {
+			l.results = append(l.results, m.Content)
+		}
+	}
+	return &LLMResponse{Content: "done"}, nil
+}
+
+func (l *profileTestLLM) GenerateStream(ctx context.Context, req *LLMRequest, deltaCh chan<- StreamChunk) (*LLMResponse, error) {
+	return l.Generate(ctx, req)
+}
+
+type profileTestTools struct{ abortTestTools }
+
+func (profileTestTools) GetDefinitions() []domaintool.Definition {
+	return []domaintool.Definition{{Name: "bash"}, {Name: "read_file"}}
+}
+
+func TestAgentLoop_AgentProfile(t *testing.T) {
+	llm := &profileTestLLM{}
+	loop := NewAgentLoop(llm, profileTestTools{}, DefaultAgentLoopConfig(), zap.NewNop())
+	ctx := WithAgentProfile(context.Background(), AgentProfile{Name: "reader", Tools: []string{"read_file"}, Temperature: 0.3})
+
+	result, eventCh := loop.Run(ctx, "", "list the files", nil, "")
+	for range eventCh {
+	}
+
+	if len(llm.tools) != 1 || llm.tools[0] != "read_file" {
+		t.Errorf("offered tools = %v, want [read_file]", llm.tools)
+	}
+	if llm.temperature != 0.3 {
+		t.Errorf("temperature = %v, want the agent's 0.3", llm.temperature)
+	}
+	if len(llm.results) != 1 || !strings.Contains(llm.results[0], "not available to agent 'reader'") {
+		t.Errorf("bash call was not refused: %v", llm.results)
+	}
+	if result.FinalContent != "done" {
+		t.Errorf("FinalContent = %q", result.FinalContent)
+	}
+}
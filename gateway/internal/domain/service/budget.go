@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+// BudgetScope says whose spend a budget pool counts.
+type BudgetScope string
+
+const (
+	BudgetGlobal BudgetScope = "global" // the whole deployment
+	BudgetUser   BudgetScope = "user"   // each user separately
+	BudgetChat   BudgetScope = "chat"   // each chat separately
+)
+
+// BudgetWindow is the period after which a pool's usage resets.
+type BudgetWindow string
+
+const (
+	BudgetDaily   BudgetWindow = "daily"   // resets at local midnight
+	BudgetMonthly BudgetWindow = "monthly" // resets on the 1st, local time
+)
+
+// BudgetPool is a spend cap shared by every run in its scope. With both
+// limits set, the pool is exhausted when either is reached.
+type BudgetPool struct {
+	Name   string
+	Scope  BudgetScope
+	Window BudgetWindow
+	Tokens int64   // 0 = no token limit
+	USD    float64 // 0 = no cost limit
+}
+
+// BudgetSubject identifies whom a run is for. An empty field leaves the
+// per-user or per-chat pools out; global pools always apply.
+type BudgetSubject struct {
+	User string
+	Chat string
+}
+
+// BudgetStatus is a pool's usage in its current window.
+type BudgetStatus struct {
+	Pool    BudgetPool
+	Tokens  int64
+	CostUSD float64
+	Resets  time.Time
+}
+
+// Exhausted reports whether either limit of the pool is used up.
+func (s BudgetStatus) Exhausted() bool {
+	return (s.Pool.Tokens > 0 && s.Tokens >= s.Pool.Tokens) ||
+		(s.Pool.USD > 0 && s.CostUSD >= s.Pool.USD)
+}
+
+// RemainingTokens returns the tokens left in the window (0 when the pool
+// has no token limit).
+func (s BudgetStatus) RemainingTokens() int64 {
+	return max(0, s.Pool.Tokens-s.Tokens)
+}
+
+// RemainingUSD returns the spend left in the window (0 when the pool has
+// no cost limit).
+func (s BudgetStatus) RemainingUSD() float64 {
+	return max(0, s.Pool.USD-s.CostUSD)
+}
+
+// BudgetManager enforces budget pools across runs, chats and restarts:
+// usage is persisted per pool, subject and window, checked before a run
+// starts and recorded after every LLM call (see BudgetHook). A run that
+// starts under the cap is not cut off when it crosses it; the per-run
+// CostGuard bounds that overshoot.
+type BudgetManager struct {
+	pools  []BudgetPool
+	orgMu  sync.RWMutex
+	org    []BudgetPool // organization policy pools, see SetOrgPools
+	repo   repository.BudgetRepository
+	policy func(model string) ModelPolicy // prices for the USD limits
+	now    func() time.Time
+	logger *zap.Logger
+
+	// optional, see SetExhaustedObserver
+	onExhausted func(ctx context.Context, status BudgetStatus, subject string)
+	alertMu     sync.Mutex
+	alerted     map[string]bool // "<pool>/<subject>/<period>" already reported
+}
+
+// NewBudgetManager creates a manager for pools. policy resolves the prices
+// of a model; pools with a USD limit count nothing for unpriced models.
+func NewBudgetManager(pools []BudgetPool, repo repository.BudgetRepository, policy func(model string) ModelPolicy, logger *zap.Logger) *BudgetManager {
+	return &BudgetManager{
+		pools:  pools,
+		repo:   repo,
+		policy: policy,
+		now:    time.Now,
+		logger: logger,
+	}
+}
+
+// Check returns the first exhausted pool that applies to subject, or false
+// when the run may start. Storage errors let the run through.
+func (m *BudgetManager) Check(ctx context.Context, subject BudgetSubject) (BudgetStatus, bool) {
+	now := m.now()
+	for _, pool := range m.allPools() {
+		key, ok := subject.key(pool.Scope)
+		if !ok {
+			continue
+		}
+		status, err := m.status(ctx, pool, key, now)
+		if err != nil {
+			m.logger.Warn("Budget check failed", zap.String("pool", pool.Name), zap.Error(err))
+			continue
+		}
+		if status.Exhausted() {
+			m.alert(ctx, status, key, now)
+			m.logger.Info("Run refused by budget pool",
+				zap.String("pool", pool.Name),
+				zap.String("subject", key),
+				zap.Int64("tokens", status.Tokens),
+				zap.Float64("cost_usd", status.CostUSD),
+			)
+			return status, true
+		}
+	}
+	return BudgetStatus{}, false
+}
+
+// Record adds the tokens of one LLM call by model to every pool that
+// applies to subject.
+func (m *BudgetManager) Record(ctx context.Context, subject BudgetSubject, model string, tokens int) {
+	if tokens <= 0 {
+		return
+	}
+	cost := 0.0
+	if m.policy != nil {
+		cost = float64(tokens) * blendedPrice(m.policy(model)) / 1e6
+	}
+	now := m.now()
+	// Recorded after the run's context may be cancelled; the spend happened
+	ctx = context.WithoutCancel(ctx)
+	for _, pool := range m.allPools() {
+		key, ok := subject.key(pool.Scope)
+		if !ok {
+			continue
+		}
+		period, _ := budgetPeriod(pool.Window, now)
+		err := m.repo.Add(ctx, &entity.BudgetUsage{
+			Pool:      pool.Name,
+			Subject:   key,
+			Period:    period,
+			Tokens:    int64(tokens),
+			CostUSD:   cost,
+			UpdatedAt: now,
+		})
+		if err != nil {
+			m.logger.Warn("Failed to record budget usage", zap.String("pool", pool.Name), zap.Error(err))
+			continue
+		}
+		if m.onExhausted != nil {
+			if status, err := m.status(ctx, pool, key, now); err == nil && status.Exhausted() {
+				m.alert(ctx, status, key, now)
+			}
+		}
+	}
+}
+
+// SetOrgPools replaces the pools set by the organization policy, which
+// apply on top of the configured ones. Their usage is stored under the name
+// prefixed with "org:", apart from any local pool of the same name.
+func (m *BudgetManager) SetOrgPools(pools []BudgetPool) {
+	org := make([]BudgetPool, len(pools))
+	for i, p := range pools {
+		p.Name = "org:" + p.Name
+		org[i] = p
+	}
+	m.orgMu.Lock()
+	defer m.orgMu.Unlock()
+	m.org = org
+}
+
+func (m *BudgetManager) allPools() []BudgetPool {
+	m.orgMu.RLock()
+	defer m.orgMu.RUnlock()
+	if len(m.org) == 0 {
+		return m.pools
+	}
+	return append(slices.Clip(m.pools), m.org...)
+}
+
+// SetExhaustedObserver sets fn to be called once per pool, subject and
+// window, when the pool is found used up: by the call that crosses the
+// limit, or by the first run refused after a restart. Call before the
+// first run.
+func (m *BudgetManager) SetExhaustedObserver(fn func(ctx context.Context, status BudgetStatus, subject string)) {
+	m.onExhausted = fn
+}
+
+func (m *BudgetManager) alert(ctx context.Context, status BudgetStatus, subject string, now time.Time) {
+	if m.onExhausted == nil {
+		return
+	}
+	period, _ := budgetPeriod(status.Pool.Window, now)
+	key := status.Pool.Name + "/" + subject + "/" + period
+	m.alertMu.Lock()
+	if m.alerted[key] {
+		m.alertMu.Unlock()
+		return
+	}
+	if m.alerted == nil || len(m.alerted) >= 1024 {
+		m.alerted = make(map[string]bool) // past windows; at worst one repeated alert
+	}
+	m.alerted[key] = true
+	m.alertMu.Unlock()
+	m.onExhausted(ctx, status, subject)
+}
+
+// Report returns the current usage of every pool that applies to subject.
+func (m *BudgetManager) Report(ctx context.Context, subject BudgetSubject) ([]BudgetStatus, error) {
+	now := m.now()
+	var report []BudgetStatus
+	for _, pool := range m.allPools() {
+		key, ok := subject.key(pool.Scope)
+		if !ok {
+			continue
+		}
+		status, err := m.status(ctx, pool, key, now)
+		if err != nil {
+			return nil, err
+		}
+		report = append(report, status)
+	}
+	return report, nil
+}
+
+func (m *BudgetManager) status(ctx context.Context, pool BudgetPool, key string, now time.Time) (BudgetStatus, error) {
+	period, resets := budgetPeriod(pool.Window, now)
+	usage, err := m.repo.Get(ctx, pool.Name, key, period)
+	if err != nil {
+		return BudgetStatus{}, err
+	}
+	return BudgetStatus{Pool: pool, Tokens: usage.Tokens, CostUSD: usage.CostUSD, Resets: resets}, nil
+}
+
+// key returns the stored subject of a pool scope, false when the subject
+// has no identity for it.
+func (s BudgetSubject) key(scope BudgetScope) (string, bool) {
+	switch scope {
+	case BudgetUser:
+		return "user:" + s.User, s.User != ""
+	case BudgetChat:
+		return "chat:" + s.Chat, s.Chat != ""
+	}
+	return "", true
+}
+
+// budgetPeriod returns the period key of the window containing now and
+// when it ends.
+func budgetPeriod(window BudgetWindow, now time.Time) (string, time.Time) {
+	y, mo, d := now.Date()
+	if window == BudgetDaily {
+		return now.Format("2006-01-02"), time.Date(y, mo, d+1, 0, 0, 0, 0, now.Location())
+	}
+	return now.Format("2006-01"), time.Date(y, mo+1, 1, 0, 0, 0, 0, now.Location())
+}
+
+// blendedPrice is the USD per million tokens of a model. LLM responses
+// report one token total, so input and output are priced as a 3:1 mix,
+// typical of tool-using runs whose prompts dominate.
+func blendedPrice(p ModelPolicy) float64 {
+	return (3*p.InputPrice + p.OutputPrice) / 4
+}
+
+// BudgetHook records every LLM call's tokens against the budget pools of
+// the run's subject (WithBudgetSubject).
+type BudgetHook struct {
+	NoOpHook
+	budgets *BudgetManager
+}
+
+// NewBudgetHook creates the hook recording usage into budgets.
+func NewBudgetHook(budgets *BudgetManager) *BudgetHook {
+	return &BudgetHook{budgets: budgets}
+}
+
+// AfterLLMCall records the call's tokens.
+func (h *BudgetHook) AfterLLMCall(ctx context.Context, resp *LLMResponse, _ int) {
+	h.budgets.Record(ctx, BudgetSubjectFromContext(ctx), resp.ModelUsed, resp.TokensUsed)
+}
+
+type budgetSubjectKey struct{}
+
+// WithBudgetSubject records whom the run is for; runs without a subject
+// count against the global pools only.
+func WithBudgetSubject(ctx context.Context, subject BudgetSubject) context.Context {
+	return context.WithValue(ctx, budgetSubjectKey{}, subject)
+}
+
+// BudgetSubjectFromContext returns the subject set with WithBudgetSubject.
+func BudgetSubjectFromContext(ctx context.Context) BudgetSubject {
+	subject, _ := ctx.Value(budgetSubjectKey{}).(BudgetSubject)
+	return subject
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+type memBudgetRepo struct {
+	rows map[string]*entity.BudgetUsage
+}
+
+func (r *memBudgetRepo) Add(ctx context.Context, u *entity.BudgetUsage) error {
+	key := u.Pool + "|" + u.Subject + "|" + u.Period
+	if row, ok := r.rows[key]; ok {
+		row.Tokens += u.Tokens
+		row.CostUSD += u.CostUSD
+		return nil
+	}
+	copied := *u
+	r.rows[key] = &copied
+	return nil
+}
+
+func (r *memBudgetRepo) Get(ctx context.Context, pool, subject, period string) (*entity.BudgetUsage, error) {
+	if row, ok := r.rows[pool+"|"+subject+"|"+period]; ok {
+		return row, nil
+	}
+	return &entity.BudgetUsage{Pool: pool, Subject: subject, Period: period}, nil
+}
+
+func TestBudgetManager(t *testing.T) {
+	repo := &memBudgetRepo{rows: make(map[string]*entity.BudgetUsage)}
+	pools := []BudgetPool{
+		{Name: "team", Scope: BudgetGlobal, Window: BudgetMonthly, USD: 1},
+		{Name: "per-user", Scope: BudgetUser, Window: BudgetDaily, Tokens: 1000},
+	}
+	// $2 in / $6 out per million → $3 blended
+	m := NewBudgetManager(pools, repo, func(string) ModelPolicy {
+		return ModelPolicy{InputPrice: 2, OutputPrice: 6}
+	}, zap.NewNop())
+	now := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	alice := BudgetSubject{User: "1", Chat: "10"}
+	hook := NewBudgetHook(m)
+	ctx := WithBudgetSubject(context.Background(), alice)
+	hook.AfterLLMCall(ctx, &LLMResponse{ModelUsed: "m", TokensUsed: 600}, 1)
+	if _, exhausted := m.Check(ctx, alice); exhausted {
+		t.Fatal("600/1000 tokens should not be exhausted")
+	}
+	hook.AfterLLMCall(ctx, &LLMResponse{ModelUsed: "m", TokensUsed: 400}, 2)
+
+	status, exhausted := m.Check(ctx, alice)
+	if !exhausted || status.Pool.Name != "per-user" || status.RemainingTokens() != 0 {
+		t.Fatalf("Check = %+v, %v", status, exhausted)
+	}
+	if !status.Resets.Equal(time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Resets = %v", status.Resets)
+	}
+	// Another user shares only the global pool
+	if _, exhausted := m.Check(ctx, BudgetSubject{User: "2"}); exhausted {
+		t.Error("other user refused")
+	}
+
+	// Runs without a subject count against global pools only
+	hook.AfterLLMCall(context.Background(), &LLMResponse{ModelUsed: "m", TokensUsed: 340_000}, 1)
+	if status, exhausted := m.Check(context.Background(), BudgetSubject{}); !exhausted || status.Pool.Name != "team" {
+		t.Errorf("global Check = %+v, %v", status, exhausted)
+	}
+
+	report, err := m.Report(ctx, BudgetSubject{Chat: "10"})
+	if err != nil || len(report) != 1 || report[0].Tokens != 341_000 || report[0].CostUSD < 1 {
+		t.Fatalf("Report = %+v, %v", report, err)
+	}
+
+	// The next day the per-user pool starts over
+	now = now.Add(24 * time.Hour)
+	report, _ = m.Report(ctx, BudgetSubject{User: "1"})
+	if len(report) != 2 || report[1].Tokens != 0 || report[0].Tokens != 341_000 {
+		t.Errorf("next day = %+v", report)
+	}
+}
+
+func TestBudgetPeriod(t *testing.T) {
+	now := time.Date(2026, 12, 31, 23, 0, 0, 0, time.UTC)
+	if p, resets := budgetPeriod(BudgetMonthly, now); p != "2026-12" || !resets.Equal(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("monthly = %s, %v", p, resets)
+	}
+	if p, resets := budgetPeriod(BudgetDaily, now); p != "2026-12-31" || !resets.Equal(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("daily = %s, %v", p, resets)
+	}
+}
+
+func TestBudgetManager_ExhaustedObserver(t *testing.T) {
+	repo := &memBudgetRepo{rows: make(map[string]*entity.BudgetUsage)}
+	m := NewBudgetManager([]BudgetPool{{Name: "daily", Scope: BudgetChat, Window: BudgetDaily, Tokens: 1000}}, repo, nil, zap.NewNop())
+	now := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	var alerts []string
+	m.SetExhaustedObserver(func(ctx context.Context, status BudgetStatus, subject string) {
+		alerts = append(alerts, status.Pool.Name+" "+subject)
+	})
+
+	chat := BudgetSubject{Chat: "10"}
+	m.Record(context.Background(), chat, "m", 900)
+	m.Record(context.Background(), chat, "m", 200) // crosses the limit
+	m.Record(context.Background(), chat, "m", 50)
+	m.Check(context.Background(), chat)
+	if len(alerts) != 1 || alerts[0] != "daily chat:10" {
+		t.Fatalf("alerts = %v", alerts)
+	}
+
+	now = now.Add(24 * time.Hour)
+	m.Record(context.Background(), chat, "m", 1000)
+	if len(alerts) != 2 {
+		t.Errorf("alerts after the window reset = %v", alerts)
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CarryOverPolicy configures the summary /new carries into the fresh
+// session, so a new conversation keeps the project, the user's preferences
+// and where the work stands without the full history.
+type CarryOverPolicy struct {
+	Model     string // summarizer model; "" = the session's model
+	MaxTokens int    // summary budget (0 = 200)
+}
+
+const (
+	defaultCarryOverTokens = 200
+	// carryOverMessageChars bounds each message shown to the summarizer,
+	// carryOverInputChars the whole transcript, newest messages first.
+	carryOverMessageChars = 1500
+	carryOverInputChars   = 24000
+	// carryOverNone is the summarizer's reply when nothing is worth keeping.
+	carryOverNone = "NONE"
+)
+
+const carryOverPrompt = `The user is starting a new conversation with an AI assistant. From the previous conversation below, write the durable facts worth carrying over, as short bullet points:
+- the project: name, repository, language, stack, paths
+- the user's stated preferences and conventions
+- the current task state: what was done, what is pending, open decisions
+Skip small talk, one-off questions, code, tool output and anything only relevant to the old conversation. Keep names, paths and identifiers exact. Write in the language of the conversation, at most %d tokens. Reply with ` + carryOverNone + ` if nothing is worth carrying over.`
+
+// CarryOverSummary condenses history into the facts to seed a new session
+// with. It returns "" when the history is empty or has nothing durable.
+func (a *AgentLoop) CarryOverSummary(ctx context.Context, history []LLMMessage, p CarryOverPolicy, model string) (string, error) {
+	transcript := carryOverTranscript(history)
+	if transcript == "" {
+		return "", nil
+	}
+	maxTokens := p.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultCarryOverTokens
+	}
+	if p.Model != "" {
+		model = p.Model
+	}
+	if model == "" {
+		model = a.config.Model
+	}
+	resp, err := a.llm.Generate(ctx, &LLMRequest{
+		Messages: []LLMMessage{
+			{Role: "system", Content: fmt.Sprintf(carryOverPrompt, maxTokens)},
+			{Role: "user", Content: transcript},
+		},
+		Model:       model,
+		Temperature: 0.2,
+		MaxTokens:   maxTokens,
+	})
+	if err != nil {
+		return "", err
+	}
+	summary := strings.TrimSpace(StripReasoningTags(resp.Content))
+	if strings.EqualFold(strings.Trim(summary, ".` "), carryOverNone) {
+		return "", nil
+	}
+	return summary, nil
+}
+
+// carryOverTranscript renders the user and assistant turns of history,
+// dropping the oldest ones beyond carryOverInputChars.
+func carryOverTranscript(history []LLMMessage) string {
+	var turns []string
+	size := 0
+	for i := len(history) - 1; i >= 0; i-- {
+		m := history[i]
+		if m.Role != "user" && m.Role != "assistant" {
+			continue
+		}
+		text := strings.TrimSpace(m.TextContent())
+		if text == "" {
+			continue
+		}
+		turn := "[" + m.Role + "]: " + truncateRunes(text, carryOverMessageChars)
+		if size += len(turn); size > carryOverInputChars && len(turns) > 0 {
+			break
+		}
+		turns = append(turns, turn)
+	}
+	for i, j := 0, len(turns)-1; i < j; i, j = i+1, j-1 {
+		turns[i], turns[j] = turns[j], turns[i]
+	}
+	return strings.Join(turns, "\n\n")
+}
+
+// CarryOverHistory is the history a new session starts with: the summary as
+// context the user handed over, acknowledged, so the next message is
+// answered as a new request.
+func CarryOverHistory(summary string) []LLMMessage {
+	return []LLMMessage{
+		{Role: "user", Content: "[Context carried over from the previous conversation. These are notes, not a request.]\n\n" + summary},
+		{Role: "assistant", Content: "Noted, I'll keep this context in mind."},
+	}
+}
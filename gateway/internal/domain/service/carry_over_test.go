@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// carryOverTestLLM returns reply and records the request.
+type carryOverTestLLM struct {
+	reply string
+	req   *LLMRequest
+}
+
+func (l *carryOverTestLLM) Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error) {
+	l.req = req
+	return &LLMResponse{Content: l.reply}, nil
+}
+
+func (l *carryOverTestLLM) GenerateStream(ctx context.Context, req *LLMRequest, deltaCh chan<- StreamChunk) (*LLMResponse, error) {
+	return l.Generate(ctx, req)
+}
+
+func TestAgentLoop_CarryOverSummary(t *testing.T) {
+	llm := &carryOverTestLLM{reply: "<think>hm</think>- Project: ngoclaw gateway (Go)\n- Pending: fix the cron test"}
+	loop := NewAgentLoop(llm, selectorTestTools{}, DefaultAgentLoopConfig(), zap.NewNop())
+	history := []LLMMessage{
+		{Role: "user", Content: "We're working on the ngoclaw gateway"},
+		{Role: "tool", Content: "tool output"},
+		{Role: "assistant", Content: "Got it. The cron test still fails."},
+	}
+
+	summary, err := loop.CarryOverSummary(context.Background(), history, CarryOverPolicy{}, "chat-model")
+	if err != nil || summary != "- Project: ngoclaw gateway (Go)\n- Pending: fix the cron test" {
+		t.Fatalf("summary = %q, %v", summary, err)
+	}
+	if llm.req.Model != "chat-model" || llm.req.MaxTokens != defaultCarryOverTokens ||
+		llm.req.Messages[1].Content != "[user]: We're working on the ngoclaw gateway\n\n[assistant]: Got it. The cron test still fails." {
+		t.Errorf("request = %+v", llm.req)
+	}
+
+	llm.reply = "NONE."
+	if summary, _ := loop.CarryOverSummary(context.Background(), history, CarryOverPolicy{Model: "cheap", MaxTokens: 50}, "chat-model"); summary != "" || llm.req.Model != "cheap" || llm.req.MaxTokens != 50 {
+		t.Errorf("nothing to carry = %q, request = %+v", summary, llm.req)
+	}
+	llm.req = nil
+	if summary, _ := loop.CarryOverSummary(context.Background(), nil, CarryOverPolicy{}, "m"); summary != "" || llm.req != nil {
+		t.Error("empty history should not call the model")
+	}
+}
+
+func TestCarryOverTranscriptKeepsNewest(t *testing.T) {
+	var history []LLMMessage
+	for i := 0; i < 40; i++ {
+		history = append(history, LLMMessage{Role: "user", Content: strings.Repeat(string(rune('a'+i%26)), 1000)})
+	}
+	got := carryOverTranscript(history)
+	if len(got) > carryOverInputChars || !strings.HasSuffix(got, strings.Repeat(string(rune('a'+39%26)), 1000)) {
+		t.Errorf("transcript = %d chars, ends %q", len(got), got[len(got)-5:])
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import "context"
+
+// ChannelConfig overrides the loop config for runs from one channel, e.g.
+// a premium coder model for the CLI and a cheaper chat model for Telegram.
+// Zero fields keep the loop's defaults.
+type ChannelConfig struct {
+	Model             string
+	ContextMaxTokens  int
+	ContextWarnRatio  float64
+	ContextHardRatio  float64
+	LoopWindowSize    int
+	DoomLoopThreshold int
+	LoopNameThreshold int
+	PreflightTokens   int
+}
+
+// apply returns base with the overrides of c.
+func (c ChannelConfig) apply(base AgentLoopConfig) AgentLoopConfig {
+	if c.Model != "" {
+		base.Model = c.Model
+	}
+	setInt := func(dst *int, v int) {
+		if v > 0 {
+			*dst = v
+		}
+	}
+	setInt(&base.ContextMaxTokens, c.ContextMaxTokens)
+	setInt(&base.LoopWindowSize, c.LoopWindowSize)
+	setInt(&base.DoomLoopThreshold, c.DoomLoopThreshold)
+	setInt(&base.LoopNameThreshold, c.LoopNameThreshold)
+	setInt(&base.PreflightTokens, c.PreflightTokens)
+	if c.ContextWarnRatio > 0 {
+		base.ContextWarnRatio = c.ContextWarnRatio
+	}
+	if c.ContextHardRatio > 0 {
+		base.ContextHardRatio = c.ContextHardRatio
+	}
+	return base
+}
+
+// SetChannelConfigs sets the per-channel overrides (channels.<name> in
+// config.yaml), keyed by channel: telegram, cli, http or api. Runs are
+// matched by the channel of their context (see WithChannel); runs from
+// other channels use the config passed to NewAgentLoop. Call before the
+// first run.
+func (a *AgentLoop) SetChannelConfigs(channels map[string]ChannelConfig) {
+	a.channels = make(map[string]*AgentLoopConfig, len(channels))
+	for name, c := range channels {
+		cfg := c.apply(a.config)
+		a.channels[name] = &cfg
+	}
+}
+
+// configFor returns the loop config of the run's channel.
+func (a *AgentLoop) configFor(ctx context.Context) *AgentLoopConfig {
+	if cfg, ok := a.channels[ChannelFromContext(ctx)]; ok {
+		return cfg
+	}
+	return &a.config
+}
+
+type channelKey struct{}
+
+// WithChannel tags the runs started with ctx with the channel they came
+// from (telegram, cli, http, api).
+func WithChannel(ctx context.Context, channel string) context.Context {
+	return context.WithValue(ctx, channelKey{}, channel)
+}
+
+// ChannelFromContext returns the run's channel, or "".
+func ChannelFromContext(ctx context.Context) string {
+	channel, _ := ctx.Value(channelKey{}).(string)
+	return channel
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// channelTestLLM records the model of each request.
+type channelTestLLM struct {
+	models []string
+}
+
+func (l *channelTestLLM) Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error) {
+	l.models = append(l.models, req.Model)
+	return &LLMResponse{Content: "ok"}, nil
+}
+
+func (l *channelTestLLM) GenerateStream(ctx context.Context, req *LLMRequest, deltaCh chan<- StreamChunk) (*LLMResponse, error) {
+	return l.Generate(ctx, req)
+}
+
+func TestAgentLoop_ChannelConfig(t *testing.T) {
+	cfg := DefaultAgentLoopConfig()
+	cfg.Model = "chat-model"
+	llm := &channelTestLLM{}
+	loop := NewAgentLoop(llm, abortTestTools{}, cfg, zap.NewNop())
+	loop.SetChannelConfigs(map[string]ChannelConfig{"cli": {Model: "coder-model", ContextMaxTokens: 400000}})
+
+	for _, channel := range []string{"cli", "telegram", ""} {
+		_, eventCh := loop.Run(WithChannel(context.Background(), channel), "", "hi", nil, "")
+		for range eventCh {
+		}
+	}
+	if len(llm.models) != 3 || llm.models[0] != "coder-model" || llm.models[1] != "chat-model" || llm.models[2] != "chat-model" {
+		t.Errorf("models = %v", llm.models)
+	}
+	if c := loop.configFor(WithChannel(context.Background(), "cli")); c.ContextMaxTokens != 400000 || c.ContextWarnRatio != 0.7 || c.MaxOutputChars != 32000 {
+		t.Errorf("cli config = %+v", c)
+	}
+}
@@ -0,0 +1,900 @@
+// Copyright 2026 NGOClaw. All rights reserved.
+
+package service
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// RiskLevel classifies how dangerous a shell command is.
+type RiskLevel int
+
+const (
+	RiskSafe     RiskLevel = iota // read-only commands (ls, cat, grep, git status ...)
+	RiskNormal                    // nothing notable detected
+	RiskMedium                    // worth flagging, approval policy unchanged
+	RiskHigh                      // requires approval even for trusted command prefixes
+	RiskCritical                  // requires approval in every mode, including auto
+)
+
+// String returns the lowercase level name used in logs and API payloads.
+func (l RiskLevel) String() string {
+	switch l {
+	case RiskSafe:
+		return "safe"
+	case RiskNormal:
+		return "normal"
+	case RiskMedium:
+		return "medium"
+	case RiskHigh:
+		return "high"
+	default:
+		return "critical"
+	}
+}
+
+// RiskReason is a single finding. Code is stable (used for i18n lookup),
+// Detail carries the offending fragment (path, command, ...).
+type RiskReason struct {
+	Code   string    `json:"code"`
+	Level  RiskLevel `json:"-"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// Risk reason codes
+const (
+	RiskRecursiveDelete = "recursive_delete"
+	RiskDeleteRoot      = "delete_root"
+	RiskRemoteExec      = "remote_exec"
+	RiskDecodeExec      = "decode_exec"
+	RiskDiskWrite       = "disk_write"
+	RiskRawCopy         = "raw_copy"
+	RiskWorldWritable   = "world_writable"
+	RiskPrivilege       = "privilege"
+	RiskSystemWrite     = "system_path_write"
+	RiskPersistence     = "persistence"
+	RiskNetworkSend     = "network_send"
+	RiskSensitiveRead   = "sensitive_read"
+	RiskExfiltration    = "exfiltration"
+	RiskForkBomb        = "fork_bomb"
+	RiskSystemControl   = "system_control"
+	RiskHistoryRewrite  = "history_rewrite"
+	RiskDynamicEval     = "dynamic_eval"
+)
+
+var riskDescriptions = map[string]string{
+	RiskRecursiveDelete: "recursive delete",
+	RiskDeleteRoot:      "recursive delete of root/home/system directory",
+	RiskRemoteExec:      "downloads a script and pipes it into a shell",
+	RiskDecodeExec:      "decodes data and pipes it into a shell",
+	RiskDiskWrite:       "writes to a block device or formats a disk",
+	RiskRawCopy:         "raw dd copy",
+	RiskWorldWritable:   "makes files world-writable",
+	RiskPrivilege:       "privilege escalation",
+	RiskSystemWrite:     "writes to a system path",
+	RiskPersistence:     "modifies shell profile, SSH keys or cron",
+	RiskNetworkSend:     "uploads data over the network",
+	RiskSensitiveRead:   "reads credentials or secrets",
+	RiskExfiltration:    "reads secrets and sends data over the network",
+	RiskForkBomb:        "fork bomb",
+	RiskSystemControl:   "shuts down, reboots or kills system processes",
+	RiskHistoryRewrite:  "rewrites or discards git history",
+	RiskDynamicEval:     "evaluates dynamically built code",
+}
+
+// Description returns an English description of the reason.
+func (r RiskReason) Description() string {
+	desc := riskDescriptions[r.Code]
+	if desc == "" {
+		desc = r.Code
+	}
+	if r.Detail != "" {
+		return desc + ": " + r.Detail
+	}
+	return desc
+}
+
+// CommandRisk is the result of AnalyzeCommand.
+type CommandRisk struct {
+	Command string       `json:"command"`
+	Level   RiskLevel    `json:"-"`
+	Reasons []RiskReason `json:"reasons,omitempty"`
+}
+
+// LevelName returns Level as a string (for JSON consumers).
+func (r *CommandRisk) LevelName() string { return r.Level.String() }
+
+// ReasonCodes returns the reason codes in detection order.
+func (r *CommandRisk) ReasonCodes() []string {
+	codes := make([]string, 0, len(r.Reasons))
+	for _, reason := range r.Reasons {
+		codes = append(codes, reason.Code)
+	}
+	return codes
+}
+
+func (r *CommandRisk) add(level RiskLevel, code, detail string) {
+	for _, existing := range r.Reasons {
+		if existing.Code == code && existing.Detail == detail {
+			return
+		}
+	}
+	r.Reasons = append(r.Reasons, RiskReason{Code: code, Level: level, Detail: detail})
+	if level > r.Level {
+		r.Level = level
+	}
+}
+
+// ---- Context propagation (SecurityHook → approval channels) ----
+
+type commandRiskKey struct{}
+
+// WithCommandRisk attaches a risk assessment so approval channels can show the reasons.
+func WithCommandRisk(ctx context.Context, risk *CommandRisk) context.Context {
+	return context.WithValue(ctx, commandRiskKey{}, risk)
+}
+
+// CommandRiskFromContext returns the assessment attached by SecurityHook, or nil.
+func CommandRiskFromContext(ctx context.Context) *CommandRisk {
+	risk, _ := ctx.Value(commandRiskKey{}).(*CommandRisk)
+	return risk
+}
+
+// ---- Analyzer ----
+
+var (
+	forkBombRe = regexp.MustCompile(`:\s*\(\s*\)\s*\{\s*:\s*\|\s*:\s*&\s*\}\s*;\s*:`)
+
+	// Commands that only read state. A command made solely of these (and no
+	// output redirects) is downgraded to RiskSafe.
+	readOnlyCommands = map[string]bool{
+		"ls": true, "cat": true, "head": true, "tail": true, "grep": true, "rg": true, "egrep": true,
+		"wc": true, "pwd": true, "echo": true, "printf": true, "which": true, "file": true, "stat": true,
+		"du": true, "df": true, "tree": true, "date": true, "whoami": true, "uname": true, "id": true,
+		"sort": true, "uniq": true, "cut": true, "tr": true, "less": true, "diff": true, "basename": true,
+		"dirname": true, "realpath": true, "ps": true, "true": true, "test": true, "[": true, "jq": true,
+	}
+	readOnlySubcommands = map[string]map[string]bool{
+		"git": {"status": true, "log": true, "diff": true, "show": true, "branch": true, "blame": true, "rev-parse": true, "ls-files": true, "remote": true},
+		"go":  {"vet": true, "version": true, "env": true, "list": true, "doc": true},
+	}
+	// find actions that write files or run commands.
+	findWriteActions = map[string]bool{
+		"-delete": true, "-exec": true, "-execdir": true, "-ok": true, "-okdir": true,
+		"-fprint": true, "-fprint0": true, "-fprintf": true, "-fls": true,
+	}
+	// git branch flags that create, delete, rename or reconfigure branches.
+	gitBranchWriteFlags = map[string]bool{
+		"-d": true, "-D": true, "--delete": true, "-m": true, "-M": true, "--move": true,
+		"-c": true, "-C": true, "--copy": true, "-f": true, "--force": true, "-u": true,
+		"--set-upstream-to": true, "--unset-upstream": true, "--edit-description": true,
+		"-t": true, "--track": true, "--no-track": true,
+	}
+
+	shellInterpreters = map[string]bool{
+		"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true, "fish": true,
+		"python": true, "python3": true, "perl": true, "ruby": true, "node": true,
+	}
+	downloaders = map[string]bool{"curl": true, "wget": true, "fetch": true}
+	// Prefix commands that run their arguments as another command.
+	wrapperCommands = map[string]bool{
+		"env": true, "nohup": true, "time": true, "nice": true, "ionice": true, "exec": true,
+		"command": true, "xargs": true, "timeout": true, "stdbuf": true,
+	}
+	privilegeCommands = map[string]bool{"sudo": true, "su": true, "doas": true, "pkexec": true}
+
+	systemDirs = []string{"/etc", "/usr", "/bin", "/sbin", "/boot", "/lib", "/lib64", "/sys", "/proc", "/var/lib", "/opt", "/root"}
+	// Paths that, when written, change login/shell behaviour (persistence).
+	persistencePaths = []string{
+		".bashrc", ".bash_profile", ".profile", ".zshrc", ".zprofile", ".ssh/authorized_keys",
+		".ssh/config", "/etc/cron", "/var/spool/cron", "/etc/profile", "/etc/rc.local", "/etc/systemd",
+	}
+	criticalFiles     = []string{"/etc/passwd", "/etc/shadow", "/etc/sudoers", "/etc/hosts", "/etc/fstab"}
+	sensitivePatterns = []string{
+		".ssh/id_", "/etc/shadow", ".aws/credentials", ".netrc", ".env", ".pem", ".key",
+		".kube/config", ".docker/config.json", ".gnupg", ".git-credentials", ".ngoclaw/config.yaml",
+	}
+)
+
+// shellSegment is one simple command of a command line.
+type shellSegment struct {
+	args      []string
+	redirects []string // output redirect targets (> >> &>)
+	pipeIn    bool     // stdin is piped from the previous segment
+}
+
+// AnalyzeCommand parses a bash command line and reports risky patterns.
+// The parser is deliberately conservative: quotes, pipes, && || ; & and
+// redirects are understood; $(...) and `...` substitutions are analyzed
+// recursively.
+func AnalyzeCommand(command string) *CommandRisk {
+	risk := &CommandRisk{Command: command, Level: RiskNormal}
+	analyzeInto(risk, command, 0)
+
+	if len(risk.Reasons) == 0 && isReadOnlyCommand(command) {
+		risk.Level = RiskSafe
+	}
+	return risk
+}
+
+func analyzeInto(risk *CommandRisk, command string, depth int) {
+	if depth > 3 {
+		return
+	}
+	if forkBombRe.MatchString(command) {
+		risk.add(RiskCritical, RiskForkBomb, "")
+	}
+
+	segments, subs := splitShell(command)
+	for _, sub := range subs {
+		analyzeInto(risk, sub, depth+1)
+	}
+
+	readsSecret := ""
+	sendsNetwork := ""
+	for i, seg := range segments {
+		args := unwrapCommand(risk, seg.args)
+
+		for _, target := range seg.redirects {
+			checkWriteTarget(risk, target)
+		}
+		if len(args) == 0 {
+			continue
+		}
+		name := path.Base(args[0])
+		if strings.HasPrefix(name, "mkfs.") {
+			name = "mkfs"
+		}
+
+		// Piping into an interpreter: what feeds it decides the risk.
+		if seg.pipeIn && shellInterpreters[name] && i > 0 {
+			prev := unwrapCommand(nil, segments[i-1].args)
+			if len(prev) > 0 {
+				prevName := path.Base(prev[0])
+				switch {
+				case downloaders[prevName]:
+					risk.add(RiskCritical, RiskRemoteExec, prevName+" | "+name)
+				case prevName == "base64" || prevName == "xxd" || prevName == "openssl":
+					risk.add(RiskHigh, RiskDecodeExec, prevName+" | "+name)
+				}
+			}
+		}
+
+		if s := analyzeSimpleCommand(risk, name, args); s != "" {
+			readsSecret = s
+		}
+		if isNetworkSend(name, args) {
+			risk.add(RiskMedium, RiskNetworkSend, name)
+			sendsNetwork = name
+		}
+	}
+
+	if readsSecret != "" && sendsNetwork != "" {
+		risk.add(RiskCritical, RiskExfiltration, readsSecret+" → "+sendsNetwork)
+	}
+}
+
+// unwrapCommand strips env assignments and wrapper commands (sudo, env, xargs ...),
+// recording privilege escalation on the way. risk may be nil.
+func unwrapCommand(risk *CommandRisk, args []string) []string {
+	for len(args) > 0 {
+		first := args[0]
+		name := path.Base(first)
+		switch {
+		case strings.Contains(first, "=") && !strings.HasPrefix(first, "="):
+			args = args[1:] // FOO=bar cmd
+		case privilegeCommands[name]:
+			if risk != nil {
+				risk.add(RiskHigh, RiskPrivilege, name)
+			}
+			args = skipFlags(args[1:])
+		case wrapperCommands[name]:
+			args = skipFlags(args[1:])
+			if name == "timeout" && len(args) > 0 {
+				args = args[1:] // duration
+			}
+		default:
+			return args
+		}
+	}
+	return args
+}
+
+func skipFlags(args []string) []string {
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		args = args[1:]
+	}
+	return args
+}
+
+// analyzeSimpleCommand applies per-command rules. Returns the sensitive path it reads, if any.
+func analyzeSimpleCommand(risk *CommandRisk, name string, args []string) string {
+	rest := args[1:]
+	switch name {
+	case "rm":
+		recursive, force := false, false
+		var targets []string
+		for _, a := range rest {
+			switch {
+			case a == "--recursive" || a == "-R":
+				recursive = true
+			case a == "--force":
+				force = true
+			case strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "--"):
+				recursive = recursive || strings.ContainsAny(a, "rR")
+				force = force || strings.Contains(a, "f")
+			case !strings.HasPrefix(a, "-"):
+				targets = append(targets, a)
+			}
+		}
+		if recursive {
+			for _, t := range targets {
+				if isRootLikePath(t) {
+					risk.add(RiskCritical, RiskDeleteRoot, t)
+				}
+			}
+			level := RiskMedium
+			if force {
+				level = RiskHigh
+			}
+			risk.add(level, RiskRecursiveDelete, strings.Join(targets, " "))
+		}
+	case "dd":
+		for _, a := range rest {
+			if strings.HasPrefix(a, "of=/dev/") && !isHarmlessDevice(strings.TrimPrefix(a, "of=")) {
+				risk.add(RiskCritical, RiskDiskWrite, a)
+				return ""
+			}
+		}
+		risk.add(RiskHigh, RiskRawCopy, "")
+	case "mkfs", "fdisk", "sfdisk", "parted", "wipefs", "shred", "mkswap":
+		risk.add(RiskCritical, RiskDiskWrite, name)
+	case "sh", "bash", "zsh", "dash", "ksh":
+		// bash -c '...' runs its argument as a command line
+		for i, a := range rest {
+			if a == "-c" && i+1 < len(rest) {
+				analyzeInto(risk, rest[i+1], 1)
+				break
+			}
+		}
+	case "chmod":
+		for _, a := range rest {
+			if a == "777" || a == "666" || a == "a+rwx" || a == "o+w" || a == "a+w" || a == "ugo+rwx" {
+				risk.add(RiskHigh, RiskWorldWritable, strings.Join(rest, " "))
+				break
+			}
+		}
+		for _, t := range nonFlagArgs(rest) {
+			if isSystemPath(t) {
+				risk.add(RiskHigh, RiskSystemWrite, t)
+			}
+		}
+	case "chown":
+		for _, t := range nonFlagArgs(rest) {
+			if isSystemPath(t) || isRootLikePath(t) {
+				risk.add(RiskHigh, RiskSystemWrite, t)
+			}
+		}
+	case "tee", "cp", "mv", "install", "ln":
+		targets := nonFlagArgs(rest)
+		if name != "tee" && len(targets) > 0 {
+			targets = targets[len(targets)-1:] // destination
+		}
+		for _, t := range targets {
+			checkWriteTarget(risk, t)
+		}
+	case "crontab":
+		risk.add(RiskHigh, RiskPersistence, "crontab")
+	case "shutdown", "reboot", "halt", "poweroff", "killall", "pkill":
+		risk.add(RiskHigh, RiskSystemControl, name)
+	case "init", "telinit":
+		if len(rest) > 0 && (rest[0] == "0" || rest[0] == "6") {
+			risk.add(RiskHigh, RiskSystemControl, name+" "+rest[0])
+		}
+	case "kill":
+		for _, a := range nonFlagArgs(rest) {
+			if a == "1" || a == "-1" {
+				risk.add(RiskHigh, RiskSystemControl, "kill "+a)
+			}
+		}
+	case "systemctl":
+		for _, a := range rest {
+			if a == "poweroff" || a == "reboot" || a == "halt" {
+				risk.add(RiskHigh, RiskSystemControl, "systemctl "+a)
+			}
+		}
+	case "git":
+		if len(rest) > 0 {
+			switch rest[0] {
+			case "push":
+				for _, a := range rest[1:] {
+					if a == "--force" || a == "-f" || strings.HasPrefix(a, "+") {
+						risk.add(RiskMedium, RiskHistoryRewrite, "git push "+a)
+					}
+				}
+			case "reset":
+				if containsArg(rest, "--hard") {
+					risk.add(RiskMedium, RiskHistoryRewrite, "git reset --hard")
+				}
+			case "clean":
+				for _, a := range rest[1:] {
+					if strings.HasPrefix(a, "-") && strings.Contains(a, "f") {
+						risk.add(RiskMedium, RiskHistoryRewrite, "git clean "+a)
+						break
+					}
+				}
+			}
+		}
+	case "eval":
+		risk.add(RiskMedium, RiskDynamicEval, "eval")
+	case "source", ".":
+		for _, t := range nonFlagArgs(rest) {
+			if strings.HasPrefix(t, "/dev/") || strings.HasPrefix(t, "<(") {
+				risk.add(RiskMedium, RiskDynamicEval, name+" "+t)
+			}
+		}
+	}
+
+	// Reading secrets (any command argument pointing at a credential file)
+	for _, a := range rest {
+		if isSensitivePath(a) {
+			risk.add(RiskMedium, RiskSensitiveRead, a)
+			return a
+		}
+	}
+	return ""
+}
+
+// isNetworkSend reports whether the command uploads data to a remote host.
+func isNetworkSend(name string, args []string) bool {
+	rest := args[1:]
+	switch name {
+	case "curl":
+		for i, a := range rest {
+			switch {
+			case a == "-T" || a == "--upload-file" || a == "-F" || a == "--form":
+				return true
+			case a == "-d" || a == "--data" || a == "--data-binary" || a == "--data-raw" || a == "--data-urlencode":
+				return true
+			case strings.HasPrefix(a, "-d@") || strings.HasPrefix(a, "--data=@") || strings.HasPrefix(a, "--data-binary=@"):
+				return true
+			case (a == "-X" || a == "--request") && i+1 < len(rest) && (rest[i+1] == "POST" || rest[i+1] == "PUT"):
+				return true
+			}
+		}
+	case "wget":
+		for _, a := range rest {
+			if strings.HasPrefix(a, "--post-data") || strings.HasPrefix(a, "--post-file") || strings.HasPrefix(a, "--body-file") {
+				return true
+			}
+		}
+	case "nc", "ncat", "netcat", "socat", "telnet":
+		return true
+	case "scp", "rsync", "sftp":
+		// remote destination is host:path
+		targets := nonFlagArgs(rest)
+		if len(targets) > 0 && strings.Contains(targets[len(targets)-1], ":") {
+			return true
+		}
+	}
+	return false
+}
+
+func checkWriteTarget(risk *CommandRisk, target string) {
+	if target == "" || target == "/dev/null" || target == "/dev/stdout" || target == "/dev/stderr" {
+		return
+	}
+	for _, f := range criticalFiles {
+		if target == f {
+			risk.add(RiskCritical, RiskSystemWrite, target)
+			return
+		}
+	}
+	if strings.HasPrefix(target, "/dev/sd") || strings.HasPrefix(target, "/dev/nvme") ||
+		strings.HasPrefix(target, "/dev/disk") || strings.HasPrefix(target, "/dev/mmcblk") {
+		risk.add(RiskCritical, RiskDiskWrite, target)
+		return
+	}
+	for _, p := range persistencePaths {
+		if strings.Contains(target, p) {
+			risk.add(RiskHigh, RiskPersistence, target)
+			return
+		}
+	}
+	if isSystemPath(target) {
+		risk.add(RiskHigh, RiskSystemWrite, target)
+	}
+}
+
+func isSystemPath(p string) bool {
+	clean := path.Clean(p)
+	for _, dir := range systemDirs {
+		if clean == dir || strings.HasPrefix(clean, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isRootLikePath matches /, /*, ~, $HOME and top-level system directories.
+func isRootLikePath(p string) bool {
+	switch strings.TrimRight(p, "/") {
+	case "/*", "~", "~/*", "$HOME", "${HOME}", "$HOME/*", "${HOME}/*":
+		return true
+	}
+	clean := path.Clean(p)
+	if clean == "/" || clean == "/home" || clean == "/var" {
+		return true
+	}
+	for _, dir := range systemDirs {
+		if clean == dir {
+			return true
+		}
+	}
+	return false
+}
+
+func isHarmlessDevice(p string) bool {
+	return p == "/dev/null" || p == "/dev/stdout" || p == "/dev/stderr"
+}
+
+func isSensitivePath(p string) bool {
+	if strings.HasPrefix(p, "-") {
+		// --data-binary=@~/.ssh/id_rsa style
+		if i := strings.Index(p, "@"); i >= 0 {
+			p = p[i+1:]
+		} else {
+			return false
+		}
+	}
+	p = strings.TrimPrefix(p, "@")
+	for _, s := range sensitivePatterns {
+		if strings.Contains(p, s) {
+			// ".env" must be a file name, not ".envrc" / "environment"
+			if s == ".env" && !(strings.HasSuffix(p, ".env") || strings.Contains(p, ".env.")) {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func nonFlagArgs(args []string) []string {
+	var out []string
+	for _, a := range args {
+		if !strings.HasPrefix(a, "-") {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+func containsArg(args []string, want string) bool {
+	for _, a := range args {
+		if a == want {
+			return true
+		}
+	}
+	return false
+}
+
+// isReadOnlyCommand reports whether every segment is a known read-only command
+// with no output redirects or command substitutions.
+func isReadOnlyCommand(command string) bool {
+	segments, subs := splitShell(command)
+	if len(subs) > 0 || len(segments) == 0 {
+		return false
+	}
+	for _, seg := range segments {
+		for _, r := range seg.redirects {
+			if r != "/dev/null" {
+				return false
+			}
+		}
+		if len(seg.args) == 0 {
+			return false
+		}
+		name := path.Base(seg.args[0])
+		if sub, ok := readOnlySubcommands[name]; ok {
+			if len(seg.args) < 2 || !sub[seg.args[1]] {
+				return false
+			}
+		} else if name != "find" && !readOnlyCommands[name] {
+			return false
+		}
+		if writesWithArgs(name, seg.args[1:]) {
+			return false
+		}
+	}
+	return true
+}
+
+// writesWithArgs reports whether the arguments turn an otherwise read-only
+// command into one that writes files or state (sort -o, git branch -D ...).
+func writesWithArgs(name string, rest []string) bool {
+	switch name {
+	case "find":
+		for _, a := range rest {
+			if findWriteActions[a] {
+				return true
+			}
+		}
+	case "sort", "tree":
+		for _, a := range rest {
+			if strings.HasPrefix(a, "--output") || hasShortFlag(a, 'o') {
+				return true
+			}
+		}
+	case "uniq":
+		// uniq INPUT OUTPUT
+		return len(nonFlagArgs(rest)) > 1
+	case "date":
+		for _, a := range rest {
+			if strings.HasPrefix(a, "--set") || hasShortFlag(a, 's') {
+				return true
+			}
+		}
+	case "git":
+		for _, a := range rest {
+			if strings.HasPrefix(a, "--output") {
+				return true
+			}
+		}
+		switch rest[0] {
+		case "branch":
+			return gitBranchWrites(rest[1:])
+		case "remote":
+			// Only listing (git remote [-v]), show and get-url read
+			for _, a := range rest[1:] {
+				if a != "-v" && a != "--verbose" {
+					return a != "show" && a != "get-url"
+				}
+			}
+		}
+	case "go":
+		for _, a := range rest[1:] {
+			if strings.HasPrefix(a, "-toolexec") || strings.HasPrefix(a, "-vettool") ||
+				strings.HasPrefix(a, "-exec") {
+				return true
+			}
+		}
+		if rest[0] == "env" {
+			return containsArg(rest, "-w") || containsArg(rest, "-u")
+		}
+	}
+	return false
+}
+
+// gitBranchWrites reports whether git branch arguments do more than list.
+// A branch name without a list-mode flag creates the branch.
+func gitBranchWrites(rest []string) bool {
+	listMode, names := false, false
+	for _, a := range rest {
+		flag, _, _ := strings.Cut(a, "=")
+		switch {
+		case gitBranchWriteFlags[flag]:
+			return true
+		case flag == "-l" || flag == "--list" || flag == "--show-current" ||
+			flag == "--contains" || flag == "--no-contains" || flag == "--merged" ||
+			flag == "--no-merged" || flag == "--points-at":
+			listMode = true
+		case strings.HasPrefix(a, "-"):
+			if !strings.HasPrefix(a, "--") && strings.ContainsAny(a[1:], "dDmMcCfut") {
+				return true // combined short flags like -fd
+			}
+		default:
+			names = true
+		}
+	}
+	return names && !listMode
+}
+
+// hasShortFlag reports whether a is a group of short flags (-no) containing c.
+func hasShortFlag(a string, c byte) bool {
+	return len(a) > 1 && a[0] == '-' && a[1] != '-' && strings.IndexByte(a[1:], c) >= 0
+}
+
+// splitShell tokenizes a command line into simple commands, returning the
+// bodies of $(...) / `...` substitutions separately.
+func splitShell(command string) ([]shellSegment, []string) {
+	var (
+		segments []shellSegment
+		subs     []string
+		cur      shellSegment
+		word     strings.Builder
+		hasWord  bool
+		inSingle bool
+		inDouble bool
+		// what the next completed word is: 0 = argument, 1 = output redirect target, 2 = input (ignored)
+		nextKind int
+	)
+
+	flushWord := func() {
+		if !hasWord {
+			return
+		}
+		w := word.String()
+		switch nextKind {
+		case 1:
+			cur.redirects = append(cur.redirects, w)
+		case 2:
+		default:
+			cur.args = append(cur.args, w)
+		}
+		nextKind = 0
+		word.Reset()
+		hasWord = false
+	}
+	flushSegment := func(pipeNext bool) {
+		flushWord()
+		if len(cur.args) > 0 || len(cur.redirects) > 0 {
+			segments = append(segments, cur)
+		}
+		cur = shellSegment{pipeIn: pipeNext}
+	}
+
+	rs := []rune(command)
+	for i := 0; i < len(rs); i++ {
+		c := rs[i]
+		next := rune(0)
+		if i+1 < len(rs) {
+			next = rs[i+1]
+		}
+
+		if inSingle {
+			if c == '\'' {
+				inSingle = false
+			} else {
+				word.WriteRune(c)
+			}
+			continue
+		}
+
+		// Substitutions are recognized outside single quotes (also inside double quotes)
+		if c == '$' && next == '(' {
+			end := matchParen(rs, i+1)
+			subs = append(subs, string(rs[i+2:end]))
+			word.WriteString("$(...)")
+			hasWord = true
+			i = end
+			continue
+		}
+		if c == '`' {
+			end := i + 1
+			for end < len(rs) && rs[end] != '`' {
+				end++
+			}
+			subs = append(subs, string(rs[i+1:min(end, len(rs))]))
+			word.WriteString("`...`")
+			hasWord = true
+			i = end
+			continue
+		}
+
+		if inDouble {
+			switch {
+			case c == '"':
+				inDouble = false
+			case c == '\\' && i+1 < len(rs):
+				i++
+				word.WriteRune(rs[i])
+			default:
+				word.WriteRune(c)
+			}
+			continue
+		}
+
+		switch {
+		case c == '\'':
+			inSingle, hasWord = true, true
+		case c == '"':
+			inDouble, hasWord = true, true
+		case c == '\\' && i+1 < len(rs):
+			i++
+			word.WriteRune(rs[i])
+			hasWord = true
+		case c == ' ' || c == '\t':
+			flushWord()
+		case c == '\n' || c == ';':
+			flushSegment(false)
+		case c == '|':
+			if next == '|' {
+				i++
+				flushSegment(false)
+			} else {
+				if next == '&' {
+					i++ // |& pipes stderr too
+				}
+				flushSegment(true)
+			}
+		case c == '&':
+			switch next {
+			case '&':
+				i++
+				flushSegment(false)
+			case '>':
+				// &> / &>> redirect both streams
+				flushWord()
+				i++
+				if i+1 < len(rs) && rs[i+1] == '>' {
+					i++
+				}
+				nextKind = 1
+			default:
+				flushSegment(false) // background
+			}
+		case c == '>':
+			// fd prefix like 2> — drop the fd digits from the current word
+			if hasWord && isDigits(word.String()) {
+				word.Reset()
+				hasWord = false
+			}
+			flushWord()
+			if next == '>' || next == '|' {
+				i++
+			}
+			if i+1 < len(rs) && rs[i+1] == '&' {
+				// >&2 — fd duplication, no file target
+				i++
+				for i+1 < len(rs) && rs[i+1] >= '0' && rs[i+1] <= '9' {
+					i++
+				}
+				continue
+			}
+			nextKind = 1
+		case c == '<':
+			flushWord()
+			if next == '(' {
+				// process substitution <(...)
+				end := matchParen(rs, i+1)
+				subs = append(subs, string(rs[i+2:end]))
+				word.WriteString("<(...)")
+				hasWord = true
+				i = end
+				continue
+			}
+			for i+1 < len(rs) && rs[i+1] == '<' {
+				i++
+			}
+			nextKind = 2
+		default:
+			word.WriteRune(c)
+			hasWord = true
+		}
+	}
+	flushSegment(false)
+	return segments, subs
+}
+
+// matchParen returns the index of the ')' matching the '(' at open (or len-1 if unbalanced).
+func matchParen(rs []rune, open int) int {
+	depth := 0
+	for j := open; j < len(rs); j++ {
+		switch rs[j] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return len(rs) - 1
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
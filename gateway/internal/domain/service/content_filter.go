@@ -0,0 +1,41 @@
+package service
+
+import (
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+)
+
+// FinishContentFilter is the normalized LLMResponse.FinishReason for answers
+// a provider refused or cut off for safety reasons (OpenAI "content_filter"
+// or a refusal, Anthropic "refusal", Gemini SAFETY / blocked prompts, ...).
+//
+// The agent loop never keeps such a turn in the conversation: it retries once
+// with a sanitized prompt (AgentLoopConfig.FilterRetry), otherwise it ends the
+// run with an explanation and marks AgentResult.Filtered so that callers
+// leave the exchange out of chat history.
+const FinishContentFilter = "content_filter"
+
+// sanitizeForFilter prepares the retry after a filtered answer. Outside
+// material is the usual trigger, so fetch/search tool outputs are replaced by
+// a placeholder (the tool turns stay, keeping tool_use/tool_result pairs
+// intact) and context attached to user turns (pre-fetched pages, media) is
+// dropped; then the model is asked to answer without the problematic
+// material. messages is not modified.
+func (a *AgentLoop) sanitizeForFilter(messages []LLMMessage, lang string) []LLMMessage {
+	out := make([]LLMMessage, len(messages), len(messages)+1)
+	copy(out, messages)
+	for i := range out {
+		switch out[i].Role {
+		case "tool":
+			switch a.tools.GetToolKind(out[i].Name) {
+			case domaintool.KindFetch, domaintool.KindSearch:
+				out[i].Content = nudge(lang, "filter.withheld")
+				out[i].Parts = nil
+			}
+		case "user":
+			if out[i].Content != "" {
+				out[i].Parts = nil
+			}
+		}
+	}
+	return append(out, LLMMessage{Role: "user", Content: nudge(lang, "filter.retry")})
+}
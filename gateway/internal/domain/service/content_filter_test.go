@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"go.uber.org/zap"
+)
+
+type filterTestTools struct{}
+
+func (filterTestTools) Execute(ctx context.Context, name string, args map[string]interface{}) (*domaintool.Result, error) {
+	return &domaintool.Result{Success: true, Output: "page text that trips the filter"}, nil
+}
+func (filterTestTools) GetDefinitions() []domaintool.Definition { return nil }
+func (filterTestTools) GetToolKind(name string) domaintool.Kind { return domaintool.KindFetch }
+
+// filterTestLLM fetches a page, then is blocked until the fetched content is
+// gone (or always, when stubborn).
+type filterTestLLM struct {
+	stubborn bool
+	requests [][]LLMMessage
+}
+
+func (l *filterTestLLM) Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error) {
+	l.requests = append(l.requests, append([]LLMMessage(nil), req.Messages...))
+	if len(l.requests) == 1 {
+		return &LLMResponse{ToolCalls: []entity.ToolCallInfo{{ID: "c1", Name: "fetch_url", Arguments: map[string]interface{}{"url": "http://x"}}}}, nil
+	}
+	for _, m := range req.Messages {
+		if l.stubborn || m.Content == "page text that trips the filter" {
+			return &LLMResponse{Content: "I can't", FinishReason: FinishContentFilter}, nil
+		}
+	}
+	return &LLMResponse{Content: "summary without the page"}, nil
+}
+
+func (l *filterTestLLM) GenerateStream(ctx context.Context, req *LLMRequest, deltaCh chan<- StreamChunk) (*LLMResponse, error) {
+	return l.Generate(ctx, req)
+}
+
+func TestAgentLoop_ContentFilterRetry(t *testing.T) {
+	llm := &filterTestLLM{}
+	loop := NewAgentLoop(llm, filterTestTools{}, DefaultAgentLoopConfig(), zap.NewNop())
+	result, eventCh := loop.Run(WithReplyLanguage(context.Background(), LangEN), "", "summarize http://x", nil, "m1")
+	for range eventCh {
+	}
+	if result.Filtered || result.FinalContent != "summary without the page" || len(llm.requests) != 3 {
+		t.Fatalf("result = %+v, requests = %d", result, len(llm.requests))
+	}
+	retry := llm.requests[2]
+	if last := retry[len(retry)-1]; last.Role != "user" || last.Content != nudge(LangEN, "filter.retry") {
+		t.Errorf("retry ends with %+v", last)
+	}
+	for _, m := range retry {
+		if m.Content == "I can't" {
+			t.Error("the blocked answer was kept in the conversation")
+		}
+	}
+}
+
+func TestAgentLoop_ContentFilterRefused(t *testing.T) {
+	llm := &filterTestLLM{stubborn: true}
+	loop := NewAgentLoop(llm, filterTestTools{}, DefaultAgentLoopConfig(), zap.NewNop())
+	result, eventCh := loop.Run(WithReplyLanguage(context.Background(), LangEN), "", "summarize http://x", nil, "m1")
+	var streamed string
+	for ev := range eventCh {
+		if ev.Type == entity.EventTextDelta {
+			streamed += ev.Content
+		}
+	}
+	want := nudge(LangEN, "filter.refused")
+	if !result.Filtered || result.FinalContent != want || streamed != want || len(llm.requests) != 3 {
+		t.Fatalf("result = %+v, streamed = %q, requests = %d", result, streamed, len(llm.requests))
+	}
+}
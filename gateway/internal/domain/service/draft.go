@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+// DraftOutcome is how a speculative draft ended.
+type DraftOutcome string
+
+const (
+	DraftApproved DraftOutcome = "approved" // the premium model accepted the draft as is
+	DraftEdited   DraftOutcome = "edited"   // the premium model returned a corrected answer
+	DraftRejected DraftOutcome = "rejected" // the premium model rejected it; the run continues single-model
+	DraftSkipped  DraftOutcome = "skipped"  // the draft wanted tools or failed; the run continues single-model
+)
+
+// defaultDraftMaxTokens caps the draft when DraftPolicy.MaxTokens is unset.
+// Drafting is meant for short answers; a long draft costs the premium model
+// as much to read as to write.
+const defaultDraftMaxTokens = 1500
+
+// DraftPolicy configures speculative drafting for a run: a cheap model
+// answers first and the premium model only verifies or edits the draft.
+type DraftPolicy struct {
+	Model     string   // cheap drafting model
+	Models    []string // premium models (ID prefixes) drafting applies to; empty = any other model
+	MaxTokens int      // draft length cap, 0 = defaultDraftMaxTokens
+}
+
+// AppliesTo reports whether a run on model should start with a draft.
+func (p DraftPolicy) AppliesTo(model string) bool {
+	if p.Model == "" || model == "" || model == p.Model {
+		return false
+	}
+	if len(p.Models) == 0 {
+		return true
+	}
+	for _, prefix := range p.Models {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// draftVerifyPrompt asks the premium model to review the draft. It is sent
+// without tools, so a draft that needs tools can only be rejected.
+const draftVerifyPrompt = `[SYSTEM] A faster model drafted the answer below to the user's last message. Review it for correctness and completeness.
+- If it is correct and complete, reply with exactly: APPROVED
+- If it needs fixes you can make without tools, reply with the full corrected answer only, with no preamble or notes about the draft.
+- If it is wrong in ways you cannot fix without tools, or the request needs tools, files or fresh data, reply with exactly: REJECTED
+
+<draft>
+%s
+</draft>`
+
+// parseDraftVerdict interprets the premium model's review. Anything that is
+// not an APPROVED or REJECTED verdict is taken as the corrected answer.
+func parseDraftVerdict(content string) (DraftOutcome, string) {
+	text := strings.TrimSpace(StripReasoningTags(content))
+	verdict := strings.ToUpper(strings.Trim(text, " \n.!*`\"'"))
+	switch {
+	case text == "", strings.HasPrefix(verdict, "REJECTED"):
+		return DraftRejected, ""
+	case strings.HasPrefix(verdict, "APPROVED") && len(verdict) <= 80:
+		return DraftApproved, ""
+	}
+	return DraftEdited, text
+}
+
+// speculate runs the two-stage answer for the first step: the draft model
+// answers req, then req.Model verifies the draft. ok is false when the run
+// should continue single-model (the draft wanted tools, a call failed, or
+// the draft was rejected).
+func (a *AgentLoop) speculate(ctx context.Context, eventCh chan<- entity.AgentEvent, req *LLMRequest, draft DraftPolicy, sm *StateMachine, result *AgentResult) (answer string, ok bool) {
+	outcome := DraftSkipped
+	defer func() {
+		result.Draft = outcome
+		a.logger.Info("Speculative draft finished",
+			zap.String("draft_model", draft.Model),
+			zap.String("model", req.Model),
+			zap.String("outcome", string(outcome)),
+		)
+	}()
+
+	// 1. Draft: same conversation and tools, cheap model, short answer
+	draftPolicy := ResolveModelPolicy(draft.Model, a.config.ModelPolicies)
+	messages, _ := TranscodeHistory(req.Messages, draft.Model, draftPolicy)
+	maxTokens := draft.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultDraftMaxTokens
+	}
+	draftResp, err := a.llm.Generate(ctx, &LLMRequest{
+		Messages:    messages,
+		Tools:       req.Tools,
+		Model:       draft.Model,
+		Temperature: req.Temperature,
+		MaxTokens:   maxTokens,
+	})
+	if err != nil {
+		a.logger.Warn("Draft model failed, answering single-model", zap.String("model", draft.Model), zap.Error(err))
+		return "", false
+	}
+	a.addDraftTokens(draftResp, sm, result)
+	text := strings.TrimSpace(StripReasoningTags(draftResp.Content))
+	if len(draftResp.ToolCalls) > 0 || text == "" {
+		return "", false
+	}
+
+	// 2. Verify: the premium model sees the conversation plus the draft, without tools
+	verifyReq := *req
+	verifyReq.Tools = nil
+	verifyReq.Messages = append(append([]LLMMessage(nil), req.Messages...), LLMMessage{
+		Role:    "user",
+		Content: fmt.Sprintf(draftVerifyPrompt, text),
+	})
+	verifyResp, err := a.llm.Generate(ctx, &verifyReq)
+	if err != nil {
+		a.logger.Warn("Draft verification failed, answering single-model", zap.String("model", req.Model), zap.Error(err))
+		return "", false
+	}
+	a.addDraftTokens(verifyResp, sm, result)
+
+	outcome, answer = parseDraftVerdict(verifyResp.Content)
+	a.emitEvent(eventCh, entity.AgentEvent{
+		Type: entity.EventStepDone,
+		StepInfo: &entity.StepInfo{
+			Step:       1,
+			TokensUsed: draftResp.TokensUsed + verifyResp.TokensUsed,
+			ModelUsed:  verifyResp.ModelUsed,
+			State:      string(sm.Snapshot().State),
+			Draft:      draft.Model + " → " + string(outcome),
+		},
+	})
+	switch outcome {
+	case DraftApproved:
+		return text, true
+	case DraftEdited:
+		return answer, true
+	}
+	return "", false
+}
+
+// addDraftTokens books a draft or verification call on the run.
+func (a *AgentLoop) addDraftTokens(resp *LLMResponse, sm *StateMachine, result *AgentResult) {
+	result.TotalTokens += resp.TokensUsed
+	sm.AddTokens(resp.TokensUsed)
+	if resp.ModelUsed != "" {
+		result.ModelUsed = resp.ModelUsed
+		sm.SetModel(resp.ModelUsed)
+	}
+}
+
+type draftKey struct{}
+
+// WithDraft enables speculative drafting for this run (TG /draft).
+func WithDraft(ctx context.Context, p DraftPolicy) context.Context {
+	return context.WithValue(ctx, draftKey{}, p)
+}
+
+// DraftFromContext returns the run's draft policy, if drafting is on.
+func DraftFromContext(ctx context.Context) (DraftPolicy, bool) {
+	p, ok := ctx.Value(draftKey{}).(DraftPolicy)
+	return p, ok
+}
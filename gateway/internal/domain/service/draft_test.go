@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+func TestDraftPolicy_AppliesTo(t *testing.T) {
+	p := DraftPolicy{Model: "cheap", Models: []string{"claude-opus", "gpt-5"}}
+	for model, want := range map[string]bool{
+		"claude-opus-4": true,
+		"gpt-5-pro":     true,
+		"claude-haiku":  false,
+		"cheap":         false,
+		"":              false,
+	} {
+		if got := p.AppliesTo(model); got != want {
+			t.Errorf("AppliesTo(%q) = %v, want %v", model, got, want)
+		}
+	}
+	if !(DraftPolicy{Model: "cheap"}).AppliesTo("anything") {
+		t.Error("empty Models must apply to every other model")
+	}
+}
+
+func TestParseDraftVerdict(t *testing.T) {
+	tests := []struct {
+		content string
+		want    DraftOutcome
+		answer  string
+	}{
+		{"APPROVED", DraftApproved, ""},
+		{"**Approved.**", DraftApproved, ""},
+		{"<think>looks fine</think>APPROVED", DraftApproved, ""},
+		{"REJECTED", DraftRejected, ""},
+		{"", DraftRejected, ""},
+		{"The answer is 42.", DraftEdited, "The answer is 42."},
+	}
+	for _, tt := range tests {
+		got, answer := parseDraftVerdict(tt.content)
+		if got != tt.want || answer != tt.answer {
+			t.Errorf("parseDraftVerdict(%q) = %q, %q; want %q, %q", tt.content, got, answer, tt.want, tt.answer)
+		}
+	}
+}
+
+// draftTestLLM answers per model: the draft model with draft, the premium
+// model with verdict when reviewing and with full otherwise.
+type draftTestLLM struct {
+	draft   *LLMResponse
+	verdict string
+	full    string
+	models  []string
+}
+
+func (l *draftTestLLM) Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error) {
+	l.models = append(l.models, req.Model)
+	if req.Model == "cheap" {
+		return l.draft, nil
+	}
+	if last := req.Messages[len(req.Messages)-1]; strings.Contains(last.Content, "<draft>") {
+		return &LLMResponse{Content: l.verdict, ModelUsed: req.Model}, nil
+	}
+	return &LLMResponse{Content: l.full, ModelUsed: req.Model}, nil
+}
+
+func (l *draftTestLLM) GenerateStream(ctx context.Context, req *LLMRequest, deltaCh chan<- StreamChunk) (*LLMResponse, error) {
+	return l.Generate(ctx, req)
+}
+
+func TestAgentLoop_Draft(t *testing.T) {
+	tests := []struct {
+		name    string
+		llm     *draftTestLLM
+		want    string
+		outcome DraftOutcome
+		calls   int
+	}{
+		{"approved", &draftTestLLM{draft: &LLMResponse{Content: "Paris."}, verdict: "APPROVED"}, "Paris.", DraftApproved, 2},
+		{"edited", &draftTestLLM{draft: &LLMResponse{Content: "Lyon."}, verdict: "Paris."}, "Paris.", DraftEdited, 2},
+		{"rejected", &draftTestLLM{draft: &LLMResponse{Content: "Lyon."}, verdict: "REJECTED", full: "Paris, France."}, "Paris, France.", DraftRejected, 3},
+		{"tools", &draftTestLLM{draft: &LLMResponse{ToolCalls: []entity.ToolCallInfo{{ID: "1", Name: "web_search"}}}, full: "Paris, France."}, "Paris, France.", DraftSkipped, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loop := NewAgentLoop(tt.llm, abortTestTools{}, DefaultAgentLoopConfig(), zap.NewNop())
+			ctx := WithDraft(context.Background(), DraftPolicy{Model: "cheap"})
+			result, eventCh := loop.Run(ctx, "", "capital of France?", nil, "premium")
+			for range eventCh {
+			}
+			if result.FinalContent != tt.want || result.Draft != tt.outcome {
+				t.Errorf("FinalContent = %q, Draft = %q", result.FinalContent, result.Draft)
+			}
+			if len(tt.llm.models) != tt.calls || tt.llm.models[0] != "cheap" {
+				t.Errorf("calls = %v", tt.llm.models)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+)
+
+// 事件详细程度
+const (
+	VerbosityMinimal = "minimal" // 进度: 工具名称与成败、步骤、排队、响应缓慢、错误; 不含文本、思考和工具参数
+	VerbosityNormal  = "normal"  // minimal + 回复文本增量、steer、成本提醒、文件编辑; 不含思考
+	VerbosityFull    = "full"    // 全部事件 (默认)
+)
+
+// verbosityTypes 各详细程度发送的事件类型 (full 不限)
+var verbosityTypes = map[string]map[entity.AgentEventType]bool{
+	VerbosityMinimal: {
+		entity.EventToolCall: true, entity.EventToolResult: true, entity.EventStepDone: true,
+		entity.EventQueued: true, entity.EventSlow: true, entity.EventError: true, entity.EventDone: true,
+	},
+	VerbosityNormal: {
+		entity.EventToolCall: true, entity.EventToolResult: true, entity.EventStepDone: true,
+		entity.EventQueued: true, entity.EventSlow: true, entity.EventError: true, entity.EventDone: true,
+		entity.EventTextDelta: true, entity.EventSteer: true, entity.EventCostWarning: true,
+		entity.EventFileEdit: true,
+	},
+}
+
+// knownEventTypes Types 中允许的事件类型
+var knownEventTypes = map[entity.AgentEventType]bool{
+	entity.EventTextDelta: true, entity.EventToolCall: true, entity.EventToolResult: true,
+	entity.EventThinking: true, entity.EventStepDone: true, entity.EventDone: true,
+	entity.EventError: true, entity.EventSteer: true, entity.EventCostWarning: true,
+	entity.EventQueued: true, entity.EventSlow: true, entity.EventFileEdit: true,
+}
+
+// EventFilter 事件订阅过滤.
+//
+// 让状态栏、手机等轻量客户端只接收需要的事件, 不被完整的工具输出淹没.
+// 零值不过滤. error 和 done 事件总是发送, 客户端据此判断运行结束.
+type EventFilter struct {
+	// Types 只发送这些事件类型; 为空时由 Verbosity 决定
+	Types []string `json:"types,omitempty"`
+	// Verbosity minimal | normal | full (默认)
+	Verbosity string `json:"verbosity,omitempty"`
+	// ToolOutput 是否包含工具输出; 为空时只有 full 包含
+	ToolOutput *bool `json:"tool_output,omitempty"`
+}
+
+// Validate 检查事件类型和详细程度
+func (f *EventFilter) Validate() error {
+	if f == nil {
+		return nil
+	}
+	switch f.Verbosity {
+	case "", VerbosityMinimal, VerbosityNormal, VerbosityFull:
+	default:
+		return fmt.Errorf("unknown verbosity %q (want minimal, normal or full)", f.Verbosity)
+	}
+	for _, t := range f.Types {
+		if !knownEventTypes[entity.AgentEventType(t)] {
+			return fmt.Errorf("unknown event type %q", t)
+		}
+	}
+	return nil
+}
+
+// Apply 过滤一个事件: ok 为 false 时不发送. 需要去掉工具输出或参数时返回
+// 副本, 不修改原事件 (其他订阅者共享同一个 ToolCall)
+func (f *EventFilter) Apply(ev entity.AgentEvent) (out entity.AgentEvent, ok bool) {
+	if f == nil {
+		return ev, true
+	}
+	if !f.allows(ev.Type) {
+		return ev, false
+	}
+	if ev.ToolCall == nil {
+		return ev, true
+	}
+
+	full := f.Verbosity == "" || f.Verbosity == VerbosityFull
+	withOutput := full
+	if f.ToolOutput != nil {
+		withOutput = *f.ToolOutput
+	}
+	withArgs := f.Verbosity != VerbosityMinimal
+	if withOutput && withArgs {
+		return ev, true
+	}
+	tc := *ev.ToolCall
+	if !withOutput {
+		tc.Output, tc.Display = "", ""
+	}
+	if !withArgs {
+		tc.Arguments = nil
+	}
+	ev.ToolCall = &tc
+	return ev, true
+}
+
+func (f *EventFilter) allows(t entity.AgentEventType) bool {
+	if t == entity.EventError || t == entity.EventDone {
+		return true
+	}
+	if len(f.Types) > 0 {
+		for _, want := range f.Types {
+			if entity.AgentEventType(want) == t {
+				return true
+			}
+		}
+		return false
+	}
+	types, limited := verbosityTypes[f.Verbosity]
+	return !limited || types[t]
+}
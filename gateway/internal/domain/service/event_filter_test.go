@@ -0,0 +1,82 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+)
+
+func TestEventFilter_Verbosity(t *testing.T) {
+	events := []entity.AgentEventType{
+		entity.EventThinking, entity.EventTextDelta, entity.EventToolCall, entity.EventToolResult,
+		entity.EventStepDone, entity.EventSteer, entity.EventError, entity.EventDone,
+	}
+	cases := []struct {
+		filter *EventFilter
+		want   string
+	}{
+		{nil, "thinking text_delta tool_call tool_result step_done steer error done"},
+		{&EventFilter{}, "thinking text_delta tool_call tool_result step_done steer error done"},
+		{&EventFilter{Verbosity: VerbosityNormal}, "text_delta tool_call tool_result step_done steer error done"},
+		{&EventFilter{Verbosity: VerbosityMinimal}, "tool_call tool_result step_done error done"},
+		// Types 优先于 Verbosity; error 和 done 总是发送
+		{&EventFilter{Types: []string{"step_done"}, Verbosity: VerbosityFull}, "step_done error done"},
+	}
+	for _, tc := range cases {
+		var got string
+		for _, typ := range events {
+			if _, ok := tc.filter.Apply(entity.AgentEvent{Type: typ}); ok {
+				if got != "" {
+					got += " "
+				}
+				got += string(typ)
+			}
+		}
+		if got != tc.want {
+			t.Errorf("%+v: got %q, want %q", tc.filter, got, tc.want)
+		}
+	}
+}
+
+func TestEventFilter_ToolOutput(t *testing.T) {
+	orig := &entity.ToolCallEvent{
+		Name:      "read_file",
+		Arguments: map[string]interface{}{"path": "a.go"},
+		Output:    "package a",
+		Display:   "package a",
+		Success:   true,
+	}
+	ev := entity.AgentEvent{Type: entity.EventToolResult, ToolCall: orig}
+	yes, no := true, false
+
+	if out, _ := (&EventFilter{}).Apply(ev); out.ToolCall != orig {
+		t.Error("full verbosity should pass the event unchanged")
+	}
+	out, _ := (&EventFilter{Verbosity: VerbosityNormal}).Apply(ev)
+	if out.ToolCall.Output != "" || out.ToolCall.Display != "" || out.ToolCall.Arguments == nil || !out.ToolCall.Success {
+		t.Errorf("normal: %+v", out.ToolCall)
+	}
+	out, _ = (&EventFilter{Verbosity: VerbosityMinimal, ToolOutput: &yes}).Apply(ev)
+	if out.ToolCall.Output != "package a" || out.ToolCall.Arguments != nil {
+		t.Errorf("minimal with output: %+v", out.ToolCall)
+	}
+	out, _ = (&EventFilter{ToolOutput: &no}).Apply(ev)
+	if out.ToolCall.Output != "" || out.ToolCall.Arguments == nil {
+		t.Errorf("full without output: %+v", out.ToolCall)
+	}
+	if orig.Output != "package a" || orig.Arguments == nil {
+		t.Error("Apply modified the original event")
+	}
+}
+
+func TestEventFilter_Validate(t *testing.T) {
+	if err := (&EventFilter{Verbosity: "quiet"}).Validate(); err == nil {
+		t.Error("unknown verbosity accepted")
+	}
+	if err := (&EventFilter{Types: []string{"tool_output"}}).Validate(); err == nil {
+		t.Error("unknown event type accepted")
+	}
+	if err := (&EventFilter{Types: []string{"tool_call", "step_done"}, Verbosity: VerbosityMinimal}).Validate(); err != nil {
+		t.Error(err)
+	}
+}
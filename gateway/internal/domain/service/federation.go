@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+)
+
+// PeerHopsHeader carries how many gateways a forwarded task has already
+// passed through, so peers forwarding to each other cannot loop forever.
+const PeerHopsHeader = "X-NGOClaw-Hops"
+
+type peerHopsKey struct{}
+
+// WithPeerHops records that the current run arrived after n gateway hops.
+func WithPeerHops(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, peerHopsKey{}, n)
+}
+
+// PeerHopsFromContext returns the hop count of the current run (0 = local).
+func PeerHopsFromContext(ctx context.Context) int {
+	n, _ := ctx.Value(peerHopsKey{}).(int)
+	return n
+}
+
+type toolEventsKey struct{}
+
+// WithToolEvents lets a running tool emit events into its run's event
+// stream (e.g. remote_agent relaying a peer's tool calls). AgentLoop sets it
+// on every tool context.
+func WithToolEvents(ctx context.Context, emit func(entity.AgentEvent)) context.Context {
+	return context.WithValue(ctx, toolEventsKey{}, emit)
+}
+
+// EmitToolEvent sends ev to the run that is executing the tool. It reports
+// false when the tool runs outside an agent loop.
+func EmitToolEvent(ctx context.Context, ev entity.AgentEvent) bool {
+	emit, _ := ctx.Value(toolEventsKey{}).(func(entity.AgentEvent))
+	if emit == nil {
+		return false
+	}
+	emit(ev)
+	return true
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"go.uber.org/zap"
+)
+
+type editTestTools struct{}
+
+func (editTestTools) Execute(ctx context.Context, name string, args map[string]interface{}) (*domaintool.Result, error) {
+	return &domaintool.Result{Success: true, Output: "edited", Metadata: map[string]interface{}{
+		"file_edits": []*entity.FileEdit{{Path: "/w/a.go", BeforeHash: "b", AfterHash: "a",
+			Hunks: []entity.EditHunk{{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1, Lines: []string{"-x", "+y"}}}}},
+	}}, nil
+}
+func (editTestTools) GetDefinitions() []domaintool.Definition { return nil }
+func (editTestTools) GetToolKind(name string) domaintool.Kind { return domaintool.KindEdit }
+
+func TestAgentLoop_FileEditEvents(t *testing.T) {
+	loop := NewAgentLoop(&journalTestLLM{}, editTestTools{}, DefaultAgentLoopConfig(), zap.NewNop())
+	_, eventCh := loop.Run(context.Background(), "", "fix a.go", nil, "m1")
+
+	var types []entity.AgentEventType
+	var edit *entity.FileEdit
+	for ev := range eventCh {
+		switch ev.Type {
+		case entity.EventToolResult, entity.EventFileEdit:
+			types = append(types, ev.Type)
+			if ev.Edit != nil {
+				edit = ev.Edit
+			}
+		}
+	}
+	if len(types) != 2 || types[0] != entity.EventToolResult || types[1] != entity.EventFileEdit {
+		t.Fatalf("events = %v", types)
+	}
+	if edit.ToolCallID != "c1" || edit.Path != "/w/a.go" || len(edit.Hunks) != 1 {
+		t.Errorf("edit = %+v", edit)
+	}
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// GuardAction is what a matching guard rule does with a tool call.
+type GuardAction string
+
+const (
+	GuardAllow   GuardAction = "allow"            // stop evaluating rules; the approval policy still applies
+	GuardDeny    GuardAction = "deny"             // block the call, the model gets the rule's message
+	GuardApprove GuardAction = "require_approval" // ask for confirmation in every approval mode
+)
+
+// GuardRule is one entry of the project guard file (.ngoclaw/guards.yaml).
+// A rule matches a tool call when every condition it sets matches; within
+// a condition any entry may match.
+type GuardRule struct {
+	Name     string      `yaml:"name"`
+	Tools    []string    `yaml:"tools"`    // tool names, shell-style wildcards ("mcp_*")
+	Paths    []string    `yaml:"paths"`    // globs over path arguments, "**" spans directories
+	Commands []string    `yaml:"commands"` // regexes over shell command lines
+	Action   GuardAction `yaml:"action"`
+	Message  string      `yaml:"message"` // shown to the model (and in approval prompts)
+
+	commands []*regexp.Regexp
+}
+
+// Describe returns the text given to the model when the rule blocks a call.
+func (r *GuardRule) Describe() string {
+	msg := r.Message
+	if msg == "" {
+		msg = "this call is not allowed in this project"
+	}
+	if r.Name != "" {
+		return fmt.Sprintf("Blocked by guard rule %q: %s", r.Name, msg)
+	}
+	return "Blocked by guard rule: " + msg
+}
+
+// GuardRules is a compiled rule list. Rules are evaluated in file order and
+// the first match decides.
+type GuardRules struct {
+	rules []GuardRule
+	root  string // project root; relative path arguments and globs are relative to it
+}
+
+// CompileGuardRules validates rules and compiles their patterns. root is the
+// directory relative paths in rules and tool arguments refer to.
+func CompileGuardRules(rules []GuardRule, root string) (*GuardRules, error) {
+	g := &GuardRules{root: filepath.Clean(root)}
+	for i, r := range rules {
+		label := r.Name
+		if label == "" {
+			label = fmt.Sprintf("#%d", i+1)
+		}
+		switch r.Action {
+		case GuardAllow, GuardDeny, GuardApprove:
+		default:
+			return nil, fmt.Errorf("rule %s: action must be allow, deny or require_approval, got %q", label, r.Action)
+		}
+		if len(r.Tools) == 0 && len(r.Paths) == 0 && len(r.Commands) == 0 {
+			return nil, fmt.Errorf("rule %s: needs at least one of tools, paths, commands", label)
+		}
+		for _, p := range append(append([]string(nil), r.Tools...), r.Paths...) {
+			if _, err := path.Match(strings.ReplaceAll(p, "**", "*"), ""); err != nil {
+				return nil, fmt.Errorf("rule %s: bad pattern %q: %w", label, p, err)
+			}
+		}
+		r.commands = make([]*regexp.Regexp, 0, len(r.Commands))
+		for _, c := range r.Commands {
+			re, err := regexp.Compile(c)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: bad command regex %q: %w", label, c, err)
+			}
+			r.commands = append(r.commands, re)
+		}
+		g.rules = append(g.rules, r)
+	}
+	return g, nil
+}
+
+// Len returns the number of rules.
+func (g *GuardRules) Len() int {
+	if g == nil {
+		return 0
+	}
+	return len(g.rules)
+}
+
+// Match returns the first rule matching the call, or nil. workDir resolves
+// relative path arguments (the directory the tool runs in).
+func (g *GuardRules) Match(toolName string, args map[string]interface{}, workDir string) *GuardRule {
+	if g == nil {
+		return nil
+	}
+	var paths []string // computed on first use
+	for i := range g.rules {
+		r := &g.rules[i]
+		if len(r.Tools) > 0 && !matchAnyName(r.Tools, toolName) {
+			continue
+		}
+		if len(r.commands) > 0 {
+			cmd, _ := args["command"].(string)
+			if !isShellTool(toolName) || !matchAnyRegexp(r.commands, cmd) {
+				continue
+			}
+		}
+		if len(r.Paths) > 0 {
+			if paths == nil {
+				paths = g.callPaths(toolName, args, workDir)
+			}
+			if !g.matchAnyPath(r.Paths, paths) {
+				continue
+			}
+		}
+		return r
+	}
+	return nil
+}
+
+// MayRequireApproval reports whether a require_approval rule can match a
+// call to toolName (rules without a tools condition apply to every tool).
+func (g *GuardRules) MayRequireApproval(toolName string) bool {
+	if g == nil {
+		return false
+	}
+	for _, r := range g.rules {
+		if r.Action == GuardApprove && (len(r.Tools) == 0 || matchAnyName(r.Tools, toolName)) {
+			return true
+		}
+	}
+	return false
+}
+
+// guardPathArgs are the arguments tools use for file and directory paths.
+var guardPathArgs = []string{"path", "file", "file_path", "paths", "files", "target", "local_path", "repo_path", "work_dir", "source", "destination"}
+
+// callPaths returns the absolute paths a call touches.
+func (g *GuardRules) callPaths(toolName string, args map[string]interface{}, workDir string) []string {
+	if workDir == "" {
+		workDir = g.root
+	}
+	var raw []string
+	for _, key := range guardPathArgs {
+		switch v := args[key].(type) {
+		case string:
+			raw = append(raw, v)
+		case []interface{}:
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					raw = append(raw, s)
+				}
+			}
+		case []string:
+			raw = append(raw, v...)
+		}
+	}
+	paths := make([]string, 0, len(raw))
+	add := func(p, base string) {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			return
+		}
+		if rest, ok := strings.CutPrefix(p, "~/"); ok {
+			home, _ := os.UserHomeDir()
+			p = filepath.Join(home, rest)
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(base, p)
+		}
+		paths = append(paths, filepath.Clean(p))
+	}
+	for _, p := range raw {
+		add(p, workDir)
+	}
+	if toolName == "apply_patch" {
+		// patch file names are relative to work_dir
+		base := workDir
+		if dir, _ := args["work_dir"].(string); dir != "" {
+			if filepath.IsAbs(dir) {
+				base = dir
+			} else {
+				base = filepath.Join(workDir, dir)
+			}
+		}
+		patch, _ := args["patch"].(string)
+		for _, p := range guardPatchPaths(patch) {
+			add(p, base)
+		}
+	}
+	return paths
+}
+
+// guardPatchPaths returns old and new file names of a unified diff, with
+// the a/ b/ prefix removed.
+func guardPatchPaths(patch string) []string {
+	var paths []string
+	for _, line := range strings.Split(patch, "\n") {
+		if !strings.HasPrefix(line, "+++ ") && !strings.HasPrefix(line, "--- ") {
+			continue
+		}
+		p := strings.TrimSpace(line[4:])
+		if i := strings.IndexByte(p, '\t'); i >= 0 {
+			p = p[:i]
+		}
+		if p == "/dev/null" || p == "" {
+			continue
+		}
+		if i := strings.IndexByte(p, '/'); i >= 0 && (strings.HasPrefix(p, "a/") || strings.HasPrefix(p, "b/")) {
+			p = p[i+1:]
+		}
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+// matchAnyPath matches paths against globs: relative to the project root for
+// paths inside it, and as absolute paths for globs starting with "/".
+func (g *GuardRules) matchAnyPath(globs, paths []string) bool {
+	for _, p := range paths {
+		rel, err := filepath.Rel(g.root, p)
+		inside := err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+		for _, glob := range globs {
+			switch {
+			case strings.HasPrefix(glob, "/"):
+				if matchGlob(glob, filepath.ToSlash(p)) {
+					return true
+				}
+			case inside:
+				if matchGlob(glob, filepath.ToSlash(rel)) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// matchGlob matches a slash-separated path against a glob where "**" spans
+// any number of directories. A glob without "/" matches any file or
+// directory name on the path ("*.pem", "secrets"); a trailing "/" covers
+// everything below the directory.
+func matchGlob(glob, p string) bool {
+	glob = strings.TrimPrefix(glob, "./")
+	if strings.HasSuffix(glob, "/") {
+		glob += "**"
+	}
+	parts := strings.Split(strings.Trim(p, "/"), "/")
+	if !strings.Contains(glob, "/") {
+		for _, part := range parts {
+			if ok, _ := path.Match(glob, part); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return matchSegments(strings.Split(strings.Trim(glob, "/"), "/"), parts)
+}
+
+func matchSegments(glob, parts []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(glob[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(glob[0], parts[0]); !ok {
+			return false
+		}
+		glob, parts = glob[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+func matchAnyName(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAnyRegexp(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+type guardRuleKey struct{}
+
+// WithGuardRule attaches the rule that requires approval, so approval
+// channels can show its message.
+func WithGuardRule(ctx context.Context, rule *GuardRule) context.Context {
+	return context.WithValue(ctx, guardRuleKey{}, rule)
+}
+
+// GuardRuleFromContext returns the rule attached by SecurityHook, or nil.
+func GuardRuleFromContext(ctx context.Context) *GuardRule {
+	rule, _ := ctx.Value(guardRuleKey{}).(*GuardRule)
+	return rule
+}
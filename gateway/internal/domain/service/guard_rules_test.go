@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		glob, path string
+		want       bool
+	}{
+		{"config/prod/**", "config/prod/db.yaml", true},
+		{"config/prod/**", "config/prod", true},
+		{"config/prod/", "config/prod/a/b.yaml", true},
+		{"config/prod/**", "config/dev/db.yaml", false},
+		{"**/*.pem", "certs/server.pem", true},
+		{"**/*.pem", "server.pem", true},
+		{"*.pem", "deep/dir/key.pem", true},
+		{"secrets", "app/secrets/token.txt", true},
+		{".env*", "svc/.env.production", true},
+		{"deploy/*.yaml", "deploy/sub/x.yaml", false},
+		{"/etc/**", "/etc/hosts", true},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.glob, tt.path); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.glob, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestGuardRules_Match(t *testing.T) {
+	rules, err := CompileGuardRules([]GuardRule{
+		{Name: "dev-configs", Paths: []string{"config/dev/**"}, Action: GuardAllow},
+		{Name: "prod-configs", Paths: []string{"config/**"}, Tools: []string{"write_file", "edit_file", "apply_patch"}, Action: GuardDeny, Message: "never touch prod configs"},
+		{Name: "no-force-push", Commands: []string{`git\s+push\s+.*--force`}, Action: GuardDeny},
+		{Name: "deploys", Commands: []string{`^kubectl\s+apply`}, Action: GuardApprove},
+		{Name: "mcp", Tools: []string{"mcp_prod_*"}, Action: GuardApprove},
+	}, "/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		tool string
+		args map[string]interface{}
+		want string // rule name, "" = no match
+	}{
+		{"write_file", map[string]interface{}{"path": "config/prod.yaml"}, "prod-configs"},
+		{"edit_file", map[string]interface{}{"path": "/repo/config/prod/db.yaml"}, "prod-configs"},
+		{"write_file", map[string]interface{}{"path": "config/dev/db.yaml"}, "dev-configs"},
+		{"read_file", map[string]interface{}{"path": "config/prod.yaml"}, ""},
+		{"write_file", map[string]interface{}{"path": "../repo/config/x.yaml"}, "prod-configs"},
+		{"write_file", map[string]interface{}{"path": "src/main.go"}, ""},
+		{"apply_patch", map[string]interface{}{"patch": "--- a/config/app.yaml\n+++ b/config/app.yaml\n@@ -1 +1 @@\n-a\n+b\n"}, "prod-configs"},
+		{"bash", map[string]interface{}{"command": "git push origin main --force"}, "no-force-push"},
+		{"bash", map[string]interface{}{"command": "git push origin main"}, ""},
+		{"bash", map[string]interface{}{"command": "kubectl apply -f deploy.yaml"}, "deploys"},
+		{"read_file", map[string]interface{}{"command": "kubectl apply"}, ""}, // commands only match shell tools
+		{"mcp_prod_db_query", map[string]interface{}{}, "mcp"},
+	}
+	for _, tt := range tests {
+		got := ""
+		if r := rules.Match(tt.tool, tt.args, "/repo"); r != nil {
+			got = r.Name
+		}
+		if got != tt.want {
+			t.Errorf("%s %v: matched %q, want %q", tt.tool, tt.args, got, tt.want)
+		}
+	}
+
+	// relative paths resolve against the tool's working directory
+	if r := rules.Match("write_file", map[string]interface{}{"path": "prod.yaml"}, "/repo/config"); r == nil || r.Name != "prod-configs" {
+		t.Errorf("relative path from /repo/config: matched %v", r)
+	}
+}
+
+func TestCompileGuardRules_Errors(t *testing.T) {
+	tests := map[string]GuardRule{
+		"action":  {Paths: []string{"x"}, Action: "block"},
+		"empty":   {Action: GuardDeny},
+		"regex":   {Commands: []string{"("}, Action: GuardDeny},
+		"pattern": {Paths: []string{"[x"}, Action: GuardDeny},
+	}
+	for name, r := range tests {
+		if _, err := CompileGuardRules([]GuardRule{r}, "/repo"); err == nil {
+			t.Errorf("%s: invalid rule accepted", name)
+		}
+	}
+}
+
+type staticGuards struct {
+	rules *GuardRules
+	err   error
+}
+
+func (s staticGuards) Rules() (*GuardRules, error) { return s.rules, s.err }
+
+func TestSecurityHook_GuardRules(t *testing.T) {
+	rules, err := CompileGuardRules([]GuardRule{
+		{Name: "prod", Paths: []string{"config/prod/**"}, Action: GuardDeny, Message: "never touch prod configs"},
+		{Name: "deploy", Commands: []string{"^make deploy"}, Action: GuardApprove, Message: "deploys go through review"},
+	}, "/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	asked := 0
+	approve := func(ctx context.Context, toolName string, args map[string]interface{}) (bool, error) {
+		asked++
+		if g := GuardRuleFromContext(ctx); g == nil || g.Name != "deploy" {
+			t.Errorf("approval ctx guard = %v", g)
+		}
+		return false, nil
+	}
+	var decisions []string
+	h := NewSecurityHook(config.SecurityConfig{ApprovalMode: "auto"}, approve, zap.NewNop())
+	h.SetGuards(staticGuards{rules: rules}, func() string { return "/repo" })
+	h.SetDecisionObserver(func(_ context.Context, d SecurityDecision) { decisions = append(decisions, d.Outcome+":"+d.Guard) })
+
+	call := func(tool string, args map[string]interface{}) (bool, string) {
+		ctx, veto := WithVetoNote(context.Background())
+		ok := h.BeforeToolCall(ctx, tool, args)
+		return ok, veto.Reason()
+	}
+
+	// deny wins even in auto mode, and the model is told why
+	ok, reason := call("write_file", map[string]interface{}{"path": "config/prod/db.yaml"})
+	if ok || !strings.Contains(reason, "never touch prod configs") || asked != 0 {
+		t.Errorf("deny: ok=%v reason=%q asked=%d", ok, reason, asked)
+	}
+	// require_approval asks in auto mode
+	ok, reason = call("bash", map[string]interface{}{"command": "make deploy"})
+	if ok || asked != 1 || !strings.Contains(reason, "deploys go through review") {
+		t.Errorf("require_approval: ok=%v reason=%q asked=%d", ok, reason, asked)
+	}
+	if ok, _ := call("bash", map[string]interface{}{"command": "make test"}); !ok {
+		t.Error("unmatched call blocked in auto mode")
+	}
+	if strings.Join(decisions, ",") != "blocked:prod,requested:deploy,denied:deploy" {
+		t.Errorf("decisions = %v", decisions)
+	}
+
+	// require_approval without an approval channel blocks instead of auto-approving
+	h.SetApprovalFunc(nil)
+	if ok, _ := call("bash", map[string]interface{}{"command": "make deploy"}); ok {
+		t.Error("require_approval auto-approved without a channel")
+	}
+
+	// rules that cannot be loaded block everything
+	h.SetGuards(staticGuards{err: context.DeadlineExceeded}, nil)
+	if ok, reason := call("read_file", map[string]interface{}{"path": "README.md"}); ok || reason == "" {
+		t.Errorf("broken rules: ok=%v reason=%q", ok, reason)
+	}
+}
@@ -118,28 +118,33 @@ func (g *ContextGuard) Check(messages []LLMMessage) ContextCheckResult {
 	return result
 }
 
-// estimateTokens roughly estimates token count.
-// Heuristic: ~3 chars/token (blend of English ~4, CJK ~2).
-func (g *ContextGuard) estimateTokens(messages []LLMMessage) int {
-	total := 0
-	for _, msg := range messages {
-		total += len(msg.Content) / 3
-		// ContentParts: count text parts
-		for _, p := range msg.Parts {
-			if p.Type == "text" {
-				total += len(p.Text) / 3
-			} else {
-				total += 85 // image/media tokens (~85 for a typical image descriptor)
-			}
-		}
-		// Tool call arguments overhead
-		for _, tc := range msg.ToolCalls {
-			total += len(tc.Name) + 50
-		}
+// minOutputTokens is the floor ClampMaxTokens never goes below; near the
+// window limit a short answer is still better than an overflow error.
+const minOutputTokens = 256
+
+// ClampMaxTokens lowers req.MaxTokens so that prompt + output fit the
+// context window. Unset MaxTokens (provider default) is left alone.
+func (g *ContextGuard) ClampMaxTokens(req *LLMRequest) {
+	if req.MaxTokens <= 0 {
+		return
+	}
+	room := g.maxTokens - g.estimateTokens(req.Messages)
+	if room < minOutputTokens {
+		room = minOutputTokens
+	}
+	if req.MaxTokens > room {
+		g.logger.Info("Clamping max_tokens to fit context window",
+			zap.Int("requested", req.MaxTokens),
+			zap.Int("clamped", room),
+			zap.Int("window", g.maxTokens),
+		)
+		req.MaxTokens = room
 	}
-	// Per-message formatting overhead
-	total += len(messages) * 4
-	return total
+}
+
+// estimateTokens roughly estimates token count (see EstimateTokens).
+func (g *ContextGuard) estimateTokens(messages []LLMMessage) int {
+	return EstimateTokens(messages)
 }
 
 // LoopDetector detects repeated tool call patterns using two strategies:
@@ -158,6 +163,7 @@ type LoopDetector struct {
 	nameThreshold int
 	nameHistory   []string // tool names only, for frequency counting
 
+	lang   string // reply language of the reflection prompts
 	logger *zap.Logger
 }
 
@@ -201,13 +207,7 @@ func (d *LoopDetector) RecordName(toolName string) string {
 			zap.Int("window_size", len(d.nameHistory)),
 			zap.Int("threshold", d.nameThreshold),
 		)
-		return fmt.Sprintf(
-			"[SYSTEM] ⚠️ 严重警告：工具 %s 在最近 %d 次调用中出现了 %d 次。"+
-				"你很可能陷入了重试循环。你必须立即停止调用工具，"+
-				"直接用中文回复用户：(1) 你在尝试做什么 (2) 遇到了什么困难 (3) 建议用户如何解决。"+
-				"不要再调用任何工具。",
-			toolName, len(d.nameHistory), count,
-		)
+		return nudge(d.lang, "loop.name", toolName, len(d.nameHistory), count)
 	}
 	return ""
 }
@@ -244,15 +244,16 @@ func (d *LoopDetector) Record(toolName string, args ...string) string {
 			zap.String("signature", sig),
 			zap.Int("consecutive_calls", d.threshold),
 		)
-		return fmt.Sprintf(
-			"[SYSTEM] 工具 %s 以完全相同的参数被调用了 %d 次，结果不会改变。"+
-				"请停止重复调用，改用其他方法或直接告知用户结果。",
-			toolName, d.threshold,
-		)
+		return nudge(d.lang, "loop.exact", toolName, d.threshold)
 	}
 	return ""
 }
 
+// SetLanguage sets the reply language the reflection prompts are written in.
+func (d *LoopDetector) SetLanguage(lang string) {
+	d.lang = lang
+}
+
 // Reset clears all tracking state (call at start of each Run).
 func (d *LoopDetector) Reset() {
 	d.recentCalls = d.recentCalls[:0]
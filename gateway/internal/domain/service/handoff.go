@@ -0,0 +1,186 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+)
+
+// Conversation handoff between models.
+//
+// A history recorded with one model (/models switch, fallback, HTTP clients
+// sending their own history) often fails on another provider:
+//   - tool call IDs: Anthropic only accepts [a-zA-Z0-9_-], Kimi emits
+//     "functions.bash:0", Gemini has no IDs and reuses "call_bash_0" every turn
+//   - pairing: Anthropic and OpenAI need every tool result right after its call
+//   - names: Gemini matches functionResponse by tool name, not ID
+//
+// TranscodeHistory rewrites the history once at run start, driven by
+// ModelPolicy.RepairToolPairing and ModelPolicy.ToolHistory.
+
+// Tool history modes (ModelPolicy.ToolHistory).
+const (
+	ToolHistoryNative  = "native"  // keep tool calls, repair IDs and pairing
+	ToolHistoryFlatten = "flatten" // fold other models' tool calls into assistant text
+)
+
+// flattenResultChars caps each tool result kept in flattened text.
+const flattenResultChars = 2000
+
+var invalidToolCallID = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// TranscodeHistory prepares messages recorded by any model for model.
+// Assistant messages whose Model differs from model (or is unknown) count
+// as foreign. It returns the number of messages rewritten, added or dropped.
+func TranscodeHistory(messages []LLMMessage, model string, policy ModelPolicy) ([]LLMMessage, int) {
+	changes := 0
+	if policy.ToolHistory == ToolHistoryFlatten {
+		messages, changes = flattenForeignToolCalls(messages, model)
+	}
+	if policy.RepairToolPairing {
+		var n int
+		messages, n = repairToolPairs(messages)
+		changes += n
+	}
+	return messages, changes
+}
+
+// repairToolPairs gives every tool call a unique, provider-safe ID, moves its
+// result directly behind the assistant message, fills in missing results and
+// drops results that belong to no call.
+func repairToolPairs(messages []LLMMessage) ([]LLMMessage, int) {
+	out := make([]LLMMessage, 0, len(messages))
+	changes := 0
+	used := make(map[string]bool)
+	consumed := make([]bool, len(messages))
+
+	for i, msg := range messages {
+		if consumed[i] {
+			continue
+		}
+		if msg.Role == "tool" {
+			changes++ // orphan: no preceding call claimed it
+			continue
+		}
+		if msg.Role != "assistant" || len(msg.ToolCalls) == 0 {
+			out = append(out, msg)
+			continue
+		}
+
+		// Results for this message live between it and the next assistant turn
+		end := i + 1
+		for end < len(messages) && messages[end].Role != "assistant" {
+			end++
+		}
+
+		calls := make([]entity.ToolCallInfo, len(msg.ToolCalls))
+		results := make([]LLMMessage, len(msg.ToolCalls))
+		for j, tc := range msg.ToolCalls {
+			k := findToolResult(messages, consumed, i+1, end, tc)
+			if k >= 0 {
+				consumed[k] = true
+				results[j] = messages[k]
+				if k != i+1+j {
+					changes++ // moved
+				}
+			} else {
+				results[j] = LLMMessage{Role: "tool", Content: `{"output": "[no result recorded]", "success": false}`}
+				changes++
+			}
+
+			id := uniqueToolCallID(tc.ID, tc.Name, used)
+			if id != tc.ID {
+				changes++
+			}
+			tc.ID = id
+			calls[j] = tc
+			results[j].ToolCallID = id
+			results[j].Name = tc.Name
+		}
+
+		msg.ToolCalls = calls
+		out = append(out, msg)
+		out = append(out, results...)
+	}
+	return out, changes
+}
+
+// findToolResult returns the index of the result for tc in messages[from:to],
+// matching by ID, or by name for results recorded without an ID.
+func findToolResult(messages []LLMMessage, consumed []bool, from, to int, tc entity.ToolCallInfo) int {
+	byName := -1
+	for k := from; k < to; k++ {
+		m := messages[k]
+		if consumed[k] || m.Role != "tool" {
+			continue
+		}
+		if tc.ID != "" && m.ToolCallID == tc.ID {
+			return k
+		}
+		if byName < 0 && m.ToolCallID == "" && m.Name == tc.Name {
+			byName = k
+		}
+	}
+	return byName
+}
+
+// uniqueToolCallID sanitizes id to [a-zA-Z0-9_-]{1,64} and makes it unique
+// within the history.
+func uniqueToolCallID(id, name string, used map[string]bool) string {
+	clean := invalidToolCallID.ReplaceAllString(id, "_")
+	if clean == "" {
+		clean = "call_" + invalidToolCallID.ReplaceAllString(name, "_")
+	}
+	if len(clean) > 60 {
+		clean = clean[:60]
+	}
+	candidate := clean
+	for n := 2; used[candidate]; n++ {
+		candidate = fmt.Sprintf("%s_%d", clean, n)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// flattenForeignToolCalls rewrites tool calls made by other models as plain
+// assistant text with their results inlined, so the new model sees what was
+// done without having to accept another provider's tool-call format.
+func flattenForeignToolCalls(messages []LLMMessage, model string) ([]LLMMessage, int) {
+	out := make([]LLMMessage, 0, len(messages))
+	consumed := make([]bool, len(messages))
+	changes := 0
+
+	for i, msg := range messages {
+		if consumed[i] {
+			changes++ // result inlined into its call
+			continue
+		}
+		if msg.Role != "assistant" || len(msg.ToolCalls) == 0 || msg.Model == model {
+			out = append(out, msg)
+			continue
+		}
+
+		end := i + 1
+		for end < len(messages) && messages[end].Role != "assistant" {
+			end++
+		}
+		var sb strings.Builder
+		sb.WriteString(strings.TrimSpace(msg.Content))
+		for _, tc := range msg.ToolCalls {
+			args, _ := json.Marshal(tc.Arguments)
+			fmt.Fprintf(&sb, "\n\n[tool call] %s %s", tc.Name, args)
+			if k := findToolResult(messages, consumed, i+1, end, tc); k >= 0 {
+				consumed[k] = true
+				fmt.Fprintf(&sb, "\n[tool result]\n%s", truncateOutput(messages[k].Content, flattenResultChars))
+			}
+		}
+		msg.Content = strings.TrimSpace(sb.String())
+		msg.ToolCalls = nil
+		out = append(out, msg)
+		changes++
+	}
+	return out, changes
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+)
+
+// kimiHistory is a turn recorded with a model that uses non-portable tool
+// call IDs, with results out of order and one result missing.
+func kimiHistory() []LLMMessage {
+	return []LLMMessage{
+		{Role: "system", Content: "sys"},
+		{Role: "user", Content: "list and read"},
+		{Role: "assistant", Model: "moonshot/kimi-k2", ToolCalls: []entity.ToolCallInfo{
+			{ID: "functions.bash:0", Name: "bash", Arguments: map[string]interface{}{"command": "ls"}},
+			{ID: "functions.read_file:1", Name: "read_file"},
+			{ID: "functions.bash:2", Name: "bash"},
+		}},
+		{Role: "tool", ToolCallID: "functions.read_file:1", Content: "file body"},
+		{Role: "tool", ToolCallID: "functions.bash:0", Content: "a.go\nb.go"},
+		{Role: "assistant", Model: "moonshot/kimi-k2", Content: "done"},
+		{Role: "tool", ToolCallID: "stale", Content: "orphan"},
+		{Role: "user", Content: "next"},
+	}
+}
+
+func TestTranscodeHistory_Native(t *testing.T) {
+	out, changes := TranscodeHistory(kimiHistory(), "anthropic/claude-sonnet-4", DefaultModelPolicy())
+	if changes == 0 {
+		t.Fatal("no changes reported")
+	}
+
+	roles := make([]string, len(out))
+	for i, m := range out {
+		roles[i] = m.Role
+	}
+	if got := strings.Join(roles, ","); got != "system,user,assistant,tool,tool,tool,assistant,user" {
+		t.Fatalf("roles = %s", got)
+	}
+
+	calls := out[2].ToolCalls
+	for j, tc := range calls {
+		if invalidToolCallID.MatchString(tc.ID) {
+			t.Errorf("call %d: unsafe ID %q", j, tc.ID)
+		}
+		res := out[3+j]
+		if res.ToolCallID != tc.ID || res.Name != tc.Name {
+			t.Errorf("call %d: result %q/%q does not follow call %q/%q", j, res.ToolCallID, res.Name, tc.ID, tc.Name)
+		}
+	}
+	if out[3].Content != "a.go\nb.go" || out[4].Content != "file body" || !strings.Contains(out[5].Content, "no result") {
+		t.Fatalf("results not paired: %+v", out[3:6])
+	}
+}
+
+func TestTranscodeHistory_DuplicateIDsAcrossTurns(t *testing.T) {
+	// Gemini numbers calls per response, so every turn starts at call_bash_0
+	history := []LLMMessage{
+		{Role: "assistant", ToolCalls: []entity.ToolCallInfo{{ID: "call_bash_0", Name: "bash"}}},
+		{Role: "tool", ToolCallID: "call_bash_0", Content: "first"},
+		{Role: "assistant", ToolCalls: []entity.ToolCallInfo{{ID: "call_bash_0", Name: "bash"}}},
+		{Role: "tool", ToolCallID: "call_bash_0", Content: "second"},
+	}
+	out, _ := TranscodeHistory(history, "openai/gpt-4o", DefaultModelPolicy())
+	if out[0].ToolCalls[0].ID == out[2].ToolCalls[0].ID {
+		t.Fatalf("duplicate IDs kept: %q", out[0].ToolCalls[0].ID)
+	}
+	if out[3].ToolCallID != out[2].ToolCalls[0].ID || out[3].Content != "second" {
+		t.Fatalf("second result mispaired: %+v", out[3])
+	}
+}
+
+func TestTranscodeHistory_Flatten(t *testing.T) {
+	policy := DefaultModelPolicy()
+	policy.ToolHistory = ToolHistoryFlatten
+
+	history := kimiHistory()
+	history = append(history,
+		LLMMessage{Role: "assistant", Model: "google/gemini-2.5-pro", ToolCalls: []entity.ToolCallInfo{{ID: "call_x_0", Name: "x"}}},
+		LLMMessage{Role: "tool", ToolCallID: "call_x_0", Content: "own"},
+	)
+	out, _ := TranscodeHistory(history, "google/gemini-2.5-pro", policy)
+
+	flat := out[2]
+	if len(flat.ToolCalls) != 0 || !strings.Contains(flat.Content, `[tool call] bash {"command":"ls"}`) ||
+		!strings.Contains(flat.Content, "a.go\nb.go") || !strings.Contains(flat.Content, "file body") {
+		t.Fatalf("flattened message:\n%s", flat.Content)
+	}
+	for _, m := range out {
+		if m.Role == "tool" && m.ToolCallID != "call_x_0" {
+			t.Errorf("foreign tool result kept: %+v", m)
+		}
+	}
+	if last := out[len(out)-2]; len(last.ToolCalls) != 1 {
+		t.Fatal("this model's own tool calls were flattened")
+	}
+}
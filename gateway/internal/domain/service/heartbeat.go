@@ -8,15 +8,21 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/repository"
 	"go.uber.org/zap"
 )
 
+// HeartbeatScheduleID is the heartbeat's row in the schedule repository.
+const HeartbeatScheduleID = "heartbeat"
+
 // HeartbeatConfig heartbeat configuration
 type HeartbeatConfig struct {
 	FilePath string        // Path to HEARTBEAT.md
 	Interval time.Duration // Check interval (default: 1h)
 	ChatID   int64         // Target Telegram ChatID for output
 	Enabled  bool
+	CatchUp  CatchUpPolicy // Heartbeats missed while the gateway was down
 }
 
 // HeartbeatExecutor callback to execute heartbeat commands and send results
@@ -26,6 +32,7 @@ type HeartbeatExecutor func(ctx context.Context, chatID int64, command string) (
 type HeartbeatService struct {
 	config   HeartbeatConfig
 	executor HeartbeatExecutor
+	store    repository.ScheduleRepository // nil = no persisted state, run at start
 	logger   *zap.Logger
 	ctx      context.Context
 	cancel   context.CancelFunc
@@ -57,6 +64,25 @@ func (h *HeartbeatService) SetExecutor(executor HeartbeatExecutor) {
 	h.executor = executor
 }
 
+// SetStore persists last-run state, so a restart resumes the schedule and
+// catches up on missed heartbeats instead of running immediately.
+func (h *HeartbeatService) SetStore(store repository.ScheduleRepository) {
+	h.store = store
+}
+
+// Status returns the persisted schedule state, or nil when there is none.
+func (h *HeartbeatService) Status() *entity.Schedule {
+	if h.store == nil {
+		return nil
+	}
+	state, err := h.store.Get(context.Background(), HeartbeatScheduleID)
+	if err != nil {
+		h.logger.Warn("Failed to load heartbeat state", zap.Error(err))
+		return nil
+	}
+	return state
+}
+
 // Start begins the heartbeat loop
 func (h *HeartbeatService) Start() error {
 	h.mu.Lock()
@@ -64,6 +90,9 @@ func (h *HeartbeatService) Start() error {
 
 	if !h.config.Enabled {
 		h.logger.Info("Heartbeat service disabled")
+		if h.Status() != nil {
+			h.saveState(func(s *entity.Schedule) { s.Enabled = false })
+		}
 		return nil
 	}
 
@@ -96,22 +125,60 @@ func (h *HeartbeatService) Stop() {
 
 // loop runs the periodic heartbeat check
 func (h *HeartbeatService) loop() {
-	// Run once immediately on start
-	h.execute()
-
-	ticker := time.NewTicker(h.config.Interval)
-	defer ticker.Stop()
+	timer := time.NewTimer(h.catchUp(time.Now()))
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-h.ctx.Done():
 			return
-		case <-ticker.C:
+		case <-timer.C:
 			h.execute()
+			timer.Reset(h.config.Interval)
 		}
 	}
 }
 
+// catchUp runs the heartbeats due at startup and returns the wait until the
+// next one. Without persisted state it runs once immediately.
+func (h *HeartbeatService) catchUp(now time.Time) time.Duration {
+	state := h.Status()
+	if state == nil || state.LastRun.IsZero() {
+		h.execute()
+		return h.config.Interval
+	}
+
+	next := state.NextRun
+	if next.IsZero() {
+		next = state.LastRun.Add(h.config.Interval)
+	}
+	missed, following := MissedRuns(next, now, func(t time.Time) time.Time { return t.Add(h.config.Interval) })
+	runs := h.config.CatchUp.Runs(missed)
+	h.saveState(func(s *entity.Schedule) {
+		s.Missed = missed
+		if missed > 0 && runs == 0 {
+			s.LastStatus = entity.ScheduleSkipped
+			s.NextRun = following
+		}
+	})
+	if missed == 0 {
+		return next.Sub(now)
+	}
+
+	h.logger.Info("Heartbeats missed while the gateway was down",
+		zap.Int("missed", missed),
+		zap.Int("catch_up", runs),
+		zap.String("policy", h.config.CatchUp.String()),
+	)
+	for i := 0; i < runs && h.ctx.Err() == nil; i++ {
+		h.execute()
+	}
+	if runs == 0 {
+		return following.Sub(now)
+	}
+	return h.config.Interval
+}
+
 // execute reads HEARTBEAT.md and processes its commands
 func (h *HeartbeatService) execute() {
 	if h.executor == nil {
@@ -119,16 +186,19 @@ func (h *HeartbeatService) execute() {
 		return
 	}
 
+	start := time.Now()
 	commands, err := h.readHeartbeatFile()
 	if err != nil {
 		h.logger.Debug("Heartbeat file not available",
 			zap.String("path", h.config.FilePath),
 			zap.Error(err),
 		)
+		h.recordRun(start, err)
 		return
 	}
 
 	if len(commands) == 0 {
+		h.recordRun(start, nil)
 		return
 	}
 
@@ -136,6 +206,7 @@ func (h *HeartbeatService) execute() {
 		zap.Int("commands", len(commands)),
 	)
 
+	var firstErr error
 	for _, cmd := range commands {
 		result, err := h.executor(h.ctx, h.config.ChatID, cmd)
 		if err != nil {
@@ -143,6 +214,9 @@ func (h *HeartbeatService) execute() {
 				zap.String("command", cmd),
 				zap.Error(err),
 			)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", cmd, err)
+			}
 			continue
 		}
 
@@ -151,6 +225,41 @@ func (h *HeartbeatService) execute() {
 			zap.Int("result_len", len(result)),
 		)
 	}
+	h.recordRun(start, firstErr)
+}
+
+// recordRun persists the outcome of a heartbeat that started at start.
+func (h *HeartbeatService) recordRun(start time.Time, err error) {
+	h.saveState(func(s *entity.Schedule) {
+		s.LastRun = start
+		s.NextRun = time.Now().Add(h.config.Interval)
+		s.LastStatus = entity.ScheduleOK
+		s.LastError = ""
+		if err != nil {
+			s.LastStatus = entity.ScheduleFailed
+			s.LastError = err.Error()
+		}
+	})
+}
+
+// saveState applies update to the persisted state (created on first use).
+func (h *HeartbeatService) saveState(update func(s *entity.Schedule)) {
+	if h.store == nil {
+		return
+	}
+	state := h.Status()
+	if state == nil {
+		state = &entity.Schedule{ID: HeartbeatScheduleID, Kind: entity.ScheduleHeartbeat, CreatedAt: time.Now()}
+	}
+	state.ChatID = h.config.ChatID
+	state.Expr = h.config.Interval.String()
+	state.Command = h.config.FilePath
+	state.CatchUp = h.config.CatchUp.Mode
+	state.Enabled = h.config.Enabled
+	update(state)
+	if err := h.store.Save(context.Background(), state); err != nil {
+		h.logger.Warn("Failed to save heartbeat state", zap.Error(err))
+	}
 }
 
 // readHeartbeatFile reads and parses HEARTBEAT.md
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"sync"
 
 	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
 )
@@ -35,6 +36,14 @@ type AgentHook interface {
 	OnStateChange(from, to AgentState, snap StateSnapshot)
 }
 
+// RunLifecycleHook is an optional AgentHook extension for the run boundaries
+// AgentHook does not cover: the start of a run, and runs that end by abort
+// (/stop, new message, budget, shutdown) instead of OnComplete / OnError.
+type RunLifecycleHook interface {
+	OnRunStart(ctx context.Context, userMessage, model string)
+	OnRunAbort(ctx context.Context, reason AbortReason, result *AgentResult)
+}
+
 // NoOpHook provides a default no-op implementation of all hooks.
 // Embed this in your custom hook to only override methods you care about.
 type NoOpHook struct{}
@@ -110,8 +119,37 @@ func (c *HookChain) OnStateChange(from, to AgentState, snap StateSnapshot) {
 	}
 }
 
+// OnRunStart forwards to the hooks that implement RunLifecycleHook.
+func (c *HookChain) OnRunStart(ctx context.Context, userMessage, model string) {
+	for _, h := range c.hooks {
+		if lh, ok := h.(RunLifecycleHook); ok {
+			lh.OnRunStart(ctx, userMessage, model)
+		}
+	}
+}
+
+// OnRunAbort forwards to the hooks that implement RunLifecycleHook.
+func (c *HookChain) OnRunAbort(ctx context.Context, reason AbortReason, result *AgentResult) {
+	for _, h := range c.hooks {
+		if lh, ok := h.(RunLifecycleHook); ok {
+			lh.OnRunAbort(ctx, reason, result)
+		}
+	}
+}
+
+// ApproveLLMCall asks the hooks that implement LLMCallApprover; any of them can veto.
+func (c *HookChain) ApproveLLMCall(ctx context.Context, info entity.PreflightInfo) bool {
+	for _, h := range c.hooks {
+		if ap, ok := h.(LLMCallApprover); ok && !ap.ApproveLLMCall(ctx, info) {
+			return false
+		}
+	}
+	return true
+}
+
 // Compile-time check: HookChain implements AgentHook
 var _ AgentHook = (*HookChain)(nil)
+var _ RunLifecycleHook = (*HookChain)(nil)
 
 // --- Built-in Hooks ---
 
@@ -132,3 +170,41 @@ type MetricsHook struct {
 func (h *MetricsHook) AfterLLMCall(_ context.Context, _ *LLMResponse, _ int)   { h.LLMCallCount++ }
 func (h *MetricsHook) AfterToolCall(_ context.Context, _ string, _ string, _ bool) { h.ToolCallCount++ }
 func (h *MetricsHook) OnError(_ context.Context, _ error, _ int)                { h.ErrorCount++ }
+
+// ---- Veto reasons ----
+
+type vetoKey struct{}
+
+// VetoNote carries the reason a BeforeToolCall hook gives for blocking a
+// call back to the agent loop, which shows it to the model.
+type VetoNote struct {
+	mu     sync.Mutex
+	reason string
+}
+
+// WithVetoNote attaches an empty note for one tool call.
+func WithVetoNote(ctx context.Context) (context.Context, *VetoNote) {
+	n := &VetoNote{}
+	return context.WithValue(ctx, vetoKey{}, n), n
+}
+
+// SetVetoReason records why a hook blocked the call; the first reason wins.
+// Without a note on ctx it does nothing.
+func SetVetoReason(ctx context.Context, reason string) {
+	n, _ := ctx.Value(vetoKey{}).(*VetoNote)
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.reason == "" {
+		n.reason = reason
+	}
+}
+
+// Reason returns the recorded reason, or "".
+func (n *VetoNote) Reason() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.reason
+}
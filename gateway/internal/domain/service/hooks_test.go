@@ -145,8 +145,45 @@ func TestHookChain_EmptyChain(t *testing.T) {
 	}
 }
 
+// === Optional run lifecycle hooks ===
+
+func TestHookChain_RunLifecycle(t *testing.T) {
+	var calls []string
+	chain := NewHookChain(
+		&trackingHook{id: "plain", calls: &calls}, // AgentHook only, skipped
+		&lifecycleHook{calls: &calls},
+	)
+	ctx := context.Background()
+
+	chain.OnRunStart(ctx, "hi", "m")
+	chain.OnRunAbort(ctx, AbortUserStop, &AgentResult{})
+
+	want := []string{"OnRunStart:hi:m", "OnRunAbort:" + string(AbortUserStop)}
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("call %d: got %q, want %q", i, calls[i], want[i])
+		}
+	}
+}
+
 // === Test helpers ===
 
+// lifecycleHook implements RunLifecycleHook
+type lifecycleHook struct {
+	NoOpHook
+	calls *[]string
+}
+
+func (h *lifecycleHook) OnRunStart(_ context.Context, msg, model string) {
+	*h.calls = append(*h.calls, "OnRunStart:"+msg+":"+model)
+}
+func (h *lifecycleHook) OnRunAbort(_ context.Context, reason AbortReason, _ *AgentResult) {
+	*h.calls = append(*h.calls, "OnRunAbort:"+string(reason))
+}
+
 // trackingHook records all method calls
 type trackingHook struct {
 	NoOpHook
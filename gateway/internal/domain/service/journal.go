@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+)
+
+// JournalSink receives the step-by-step record of each run while it happens:
+// every request exactly as sent to the model, the response, and each tool
+// result. Unlike a transcript it is detailed enough to replay the run or
+// resume it from any step (ngoclaw replay). Records of one run arrive in
+// order; implementations must be safe for concurrent use across runs.
+type JournalSink interface {
+	WriteJournal(runID string, rec *JournalRecord)
+}
+
+// Journal record types, in the order a run writes them.
+const (
+	JournalRun      = "run"      // run header
+	JournalStep     = "step"     // LLM request of a step
+	JournalResponse = "response" // LLM response (or error) of a step
+	JournalTool     = "tool"     // one tool result
+	JournalEnd      = "end"      // how the run ended
+)
+
+// JournalRecord is one line of a run journal. Type says which fields are set.
+type JournalRecord struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	Step int       `json:"step,omitempty"`
+
+	// run
+	Source      string `json:"source,omitempty"`
+	UserMessage string `json:"user_message,omitempty"`
+
+	// step: Messages continue after the first Base messages of the previous
+	// step's request (unchanged since then); Base 0 = the full request
+	Model       string       `json:"model,omitempty"`
+	Base        int          `json:"base,omitempty"`
+	Messages    []LLMMessage `json:"messages,omitempty"`
+	Tools       []string     `json:"tools,omitempty"`
+	Temperature float64      `json:"temperature,omitempty"`
+	MaxTokens   int          `json:"max_tokens,omitempty"`
+
+	// response
+	Response *LLMResponse `json:"response,omitempty"`
+	Error    string       `json:"error,omitempty"` // also set on end
+
+	// tool
+	ToolCall *entity.ToolCallInfo `json:"tool_call,omitempty"`
+	Output   string               `json:"output,omitempty"`
+	Success  bool                 `json:"success,omitempty"`
+	Duration time.Duration        `json:"duration,omitempty"`
+
+	// end
+	Final  string            `json:"final,omitempty"`
+	Abort  AbortReason       `json:"abort,omitempty"`
+	Steps  int               `json:"steps,omitempty"`
+	Tokens int               `json:"tokens,omitempty"`
+	Review *entity.RunReview `json:"review,omitempty"`
+}
+
+// SetJournalSink enables run journals for subsequent runs (nil disables).
+func (a *AgentLoop) SetJournalSink(sink JournalSink) {
+	a.journal = sink
+}
+
+// runJournal records one run. A nil *runJournal (journal disabled) ignores
+// every call, so the loop needs no checks.
+type runJournal struct {
+	sink JournalSink
+	id   string
+	prev []LLMMessage // messages of the previous request
+}
+
+func (a *AgentLoop) startJournal(ctx context.Context, userMessage, model string) *runJournal {
+	if a.journal == nil {
+		return nil
+	}
+	j := &runJournal{sink: a.journal, id: TraceIDFromContext(ctx)}
+	j.write(&JournalRecord{
+		Type:        JournalRun,
+		Source:      TranscriptSourceFromContext(ctx),
+		Model:       model,
+		UserMessage: userMessage,
+	})
+	return j
+}
+
+func (j *runJournal) write(rec *JournalRecord) {
+	rec.Time = time.Now()
+	j.sink.WriteJournal(j.id, rec)
+}
+
+// request records the LLM request of a step, storing only the messages that
+// changed since the previous request.
+func (j *runJournal) request(step int, req *LLMRequest) {
+	if j == nil {
+		return
+	}
+	base := 0
+	for base < len(j.prev) && base < len(req.Messages) && reflect.DeepEqual(j.prev[base], req.Messages[base]) {
+		base++
+	}
+	tools := make([]string, len(req.Tools))
+	for i, t := range req.Tools {
+		tools[i] = t.Name
+	}
+	rec := &JournalRecord{
+		Type:        JournalStep,
+		Step:        step,
+		Model:       req.Model,
+		Base:        base,
+		Messages:    req.Messages[base:],
+		Tools:       tools,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+	}
+	j.write(rec)
+	j.prev = append([]LLMMessage(nil), req.Messages...)
+}
+
+// response records the model's answer to a step, or why there was none.
+func (j *runJournal) response(step int, resp *LLMResponse, err error) {
+	if j == nil {
+		return
+	}
+	rec := &JournalRecord{Type: JournalResponse, Step: step, Response: resp}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	j.write(rec)
+}
+
+// toolResult records the result of one tool call of a step.
+func (j *runJournal) toolResult(step int, call entity.ToolCallInfo, output string, success bool, d time.Duration) {
+	if j == nil {
+		return
+	}
+	j.write(&JournalRecord{
+		Type:     JournalTool,
+		Step:     step,
+		ToolCall: &call,
+		Output:   output,
+		Success:  success,
+		Duration: d,
+	})
+}
+
+// finish records how the run ended.
+func (j *runJournal) finish(result *AgentResult) {
+	if j == nil {
+		return
+	}
+	j.write(&JournalRecord{
+		Type:   JournalEnd,
+		Model:  result.ModelUsed,
+		Final:  result.FinalContent,
+		Abort:  result.AbortReason,
+		Steps:  result.TotalSteps,
+		Tokens: result.TotalTokens,
+		Review: result.Review,
+	})
+}
+
+type resumeMessagesKey struct{}
+
+// WithResumeMessages starts the run from these exact messages (system prompt
+// included, as recorded in a journal) instead of building them from the
+// system prompt, history and user message. Used by ngoclaw replay --live.
+func WithResumeMessages(ctx context.Context, messages []LLMMessage) context.Context {
+	return context.WithValue(ctx, resumeMessagesKey{}, messages)
+}
+
+// ResumeMessagesFromContext returns the messages a resumed run starts from.
+func ResumeMessagesFromContext(ctx context.Context) ([]LLMMessage, bool) {
+	msgs, ok := ctx.Value(resumeMessagesKey{}).([]LLMMessage)
+	return msgs, ok && len(msgs) > 0
+}
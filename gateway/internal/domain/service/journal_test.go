@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+type journalTestSink struct {
+	mu   sync.Mutex
+	runs map[string][]*JournalRecord
+}
+
+func (s *journalTestSink) WriteJournal(runID string, rec *JournalRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.runs == nil {
+		s.runs = make(map[string][]*JournalRecord)
+	}
+	s.runs[runID] = append(s.runs[runID], rec)
+}
+
+// journalTestLLM calls read_file once, then answers; it keeps every request.
+type journalTestLLM struct {
+	requests [][]LLMMessage
+}
+
+func (l *journalTestLLM) Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error) {
+	l.requests = append(l.requests, append([]LLMMessage(nil), req.Messages...))
+	if len(l.requests) == 1 {
+		return &LLMResponse{ToolCalls: []entity.ToolCallInfo{{ID: "c1", Name: "read_file", Arguments: map[string]interface{}{"path": "a.go"}}}}, nil
+	}
+	return &LLMResponse{Content: "done", ModelUsed: req.Model}, nil
+}
+
+func (l *journalTestLLM) GenerateStream(ctx context.Context, req *LLMRequest, deltaCh chan<- StreamChunk) (*LLMResponse, error) {
+	return l.Generate(ctx, req)
+}
+
+func TestAgentLoop_Journal(t *testing.T) {
+	llm, sink := &journalTestLLM{}, &journalTestSink{}
+	loop := NewAgentLoop(llm, abortTestTools{}, DefaultAgentLoopConfig(), zap.NewNop())
+	loop.SetJournalSink(sink)
+
+	ctx := WithTranscriptSource(context.Background(), "telegram:7")
+	result, eventCh := loop.Run(ctx, "be brief", "fix a.go", nil, "m1")
+	for range eventCh {
+	}
+	if result.FinalContent != "done" || len(sink.runs) != 1 {
+		t.Fatalf("FinalContent = %q, runs = %d", result.FinalContent, len(sink.runs))
+	}
+	var recs []*JournalRecord
+	for _, r := range sink.runs {
+		recs = r
+	}
+
+	var types []string
+	for _, r := range recs {
+		types = append(types, r.Type)
+	}
+	want := []string{JournalRun, JournalStep, JournalResponse, JournalTool, JournalStep, JournalResponse, JournalEnd}
+	if len(types) != len(want) {
+		t.Fatalf("record types = %v, want %v", types, want)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("record types = %v, want %v", types, want)
+		}
+	}
+
+	if head := recs[0]; head.Source != "telegram:7" || head.UserMessage != "fix a.go" || head.Model != "m1" {
+		t.Errorf("header = %+v", head)
+	}
+	if first := recs[1]; first.Base != 0 || len(first.Messages) != len(llm.requests[0]) {
+		t.Errorf("step 1 base = %d, messages = %d", first.Base, len(first.Messages))
+	}
+	if tool := recs[3]; tool.ToolCall == nil || tool.ToolCall.Name != "read_file" || !tool.Success {
+		t.Errorf("tool record = %+v", tool)
+	}
+	// Step 2 stores only what was added: the assistant tool call and its result
+	second := recs[4]
+	if second.Base != len(llm.requests[0]) || len(second.Messages) != 2 ||
+		second.Messages[0].Role != "assistant" || second.Messages[1].Role != "tool" {
+		t.Errorf("step 2 base = %d, messages = %+v", second.Base, second.Messages)
+	}
+	if end := recs[6]; end.Final != "done" || end.Steps != 2 {
+		t.Errorf("end = %+v", end)
+	}
+}
+
+func TestAgentLoop_ResumeMessages(t *testing.T) {
+	llm := &journalTestLLM{}
+	loop := NewAgentLoop(llm, abortTestTools{}, DefaultAgentLoopConfig(), zap.NewNop())
+
+	resumed := []LLMMessage{
+		{Role: "system", Content: "edited system"},
+		{Role: "user", Content: "fix a.go"},
+		{Role: "assistant", ToolCalls: []entity.ToolCallInfo{{ID: "c0", Name: "read_file"}}},
+		{Role: "tool", Content: "package a", ToolCallID: "c0", Name: "read_file"},
+	}
+	ctx := WithResumeMessages(context.Background(), resumed)
+	_, eventCh := loop.Run(ctx, "ignored system", "fix a.go", nil, "m1")
+	for range eventCh {
+	}
+
+	first := llm.requests[0]
+	if len(first) < len(resumed) {
+		t.Fatalf("first request = %+v", first)
+	}
+	for i, m := range resumed {
+		if first[i].Role != m.Role || first[i].Content != m.Content {
+			t.Errorf("message %d = %s %q, want %s %q", i, first[i].Role, first[i].Content, m.Role, m.Content)
+		}
+	}
+}
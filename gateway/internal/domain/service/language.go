@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Reply languages. They match the i18n locales so a chat's /lang choice can
+// be used directly.
+const (
+	LangZH = "zh"
+	LangEN = "en"
+)
+
+var (
+	langCodeBlockRe  = regexp.MustCompile("(?s)```.*?```")
+	langInlineCodeRe = regexp.MustCompile("`[^`\n]*`")
+	langURLRe        = regexp.MustCompile(`\b(?:https?|ftp)://\S+|\S+@\S+\.\w+`)
+	langWordRe       = regexp.MustCompile(`[A-Za-z]{2,}`)
+)
+
+// DetectLanguage guesses the language of a user message: LangZH, LangEN or
+// "" when the text carries no signal (a command, a path, "ok").
+//
+// Code, URLs and e-mail addresses are ignored, and one Han character weighs
+// as much as two English words, so "帮我看下 nginx 的 error log" is Chinese.
+func DetectLanguage(text string) string {
+	text = langCodeBlockRe.ReplaceAllString(text, " ")
+	text = langInlineCodeRe.ReplaceAllString(text, " ")
+	text = langURLRe.ReplaceAllString(text, " ")
+
+	han := 0
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			han++
+		}
+	}
+	words := len(langWordRe.FindAllString(text, -1))
+	switch {
+	case han > 0 && han*2 >= words:
+		return LangZH
+	case words >= 3:
+		return LangEN
+	}
+	return ""
+}
+
+// ReplyLanguage picks the language of the next reply: the explicit choice
+// (/lang) when set, else the language of userMessage, else that of the latest
+// earlier user turn that has one. "" means there is no signal at all.
+func ReplyLanguage(explicit, userMessage string, history []LLMMessage) string {
+	if explicit != "" {
+		return explicit
+	}
+	if lang := DetectLanguage(userMessage); lang != "" {
+		return lang
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role != "user" {
+			continue
+		}
+		if lang := DetectLanguage(history[i].Content); lang != "" {
+			return lang
+		}
+	}
+	return ""
+}
+
+// nudges are the messages the agent loop injects on its own (auto-continue,
+// reflection and progress prompts, steering), per reply language.
+var nudges = map[string]map[string]string{
+	LangZH: {
+		"continue":          "继续",
+		"summary.ack":       "好的，已完成工具调用。",
+		"summary.request":   "请用简洁的文字总结你刚才执行的操作和最终结果。不要重复方案，只说结果。",
+		"tools_failing":     "[SYSTEM] 工具已连续失败 3 轮。请停止重试，用中文告诉用户：遇到了什么问题、尝试了什么、建议的解决方案。",
+		"loop.name":         "[SYSTEM] ⚠️ 严重警告：工具 %s 在最近 %d 次调用中出现了 %d 次。你很可能陷入了重试循环。你必须立即停止调用工具，直接用中文回复用户：(1) 你在尝试做什么 (2) 遇到了什么困难 (3) 建议用户如何解决。不要再调用任何工具。",
+		"loop.exact":        "[SYSTEM] 工具 %s 以完全相同的参数被调用了 %d 次，结果不会改变。请停止重复调用，改用其他方法或直接告知用户结果。",
+		"progress":          "[SYSTEM] 已执行 %d 步。请简要汇报当前进展和下一步计划。",
+		"progress.early":    "[SYSTEM] 已执行 %d 步。请简要汇报当前进展。",
+		"progress.warn":     "[SYSTEM] ⚠️ 已执行 %d 步。请检查任务是否可以完成并回复用户。如果遇到无法解决的问题，请立即告知用户。",
+		"progress.critical": "[SYSTEM] 🚨 已执行 %d 步。你必须尽快完成当前任务并回复用户。如果无法完成，请告知用户当前进展和遇到的问题。",
+		"steering":          "[用户在你工作时发来的消息 — 请结合它继续]\n",
+		"review.retry":      "[SYSTEM] 交付前的自检发现以下问题：\n%s\n请修正这些问题：需要核实时可以调用工具，然后给出完整的修正后答案，不要提及自检。",
+		"review.caveats":    "⚠️ 自检提示：",
+		"filter.withheld":   "[内容已移除：上次回答被模型的内容安全过滤拦截]",
+		"filter.retry":      "[SYSTEM] 上一次回答被模型的内容安全过滤拦截。外部获取的内容已移除。请在不引用这些材料的前提下尽量回答用户的请求；如果请求本身无法回答，请简短说明原因。",
+		"filter.refused":    "⚠️ 模型的内容安全过滤拦截了这次回答，因此没有结果。可以换一种说法，或换一个模型再试。",
+		"tool.deferred":     "[DEFERRED] %s\n[REASON] 该模型每步最多执行 %d 个工具调用, 此调用未执行。\n[HINT] 如仍需要, 请在下一步重新调用。",
+	},
+	LangEN: {
+		"continue":          "continue",
+		"summary.ack":       "OK, the tool calls are done.",
+		"summary.request":   "Briefly summarize what you just did and the final result. Don't restate the plan, only the outcome.",
+		"tools_failing":     "[SYSTEM] Tools have failed 3 rounds in a row. Stop retrying and tell the user in English: what went wrong, what you tried, and what you suggest.",
+		"loop.name":         "[SYSTEM] ⚠️ Warning: tool %s appeared %[3]d times in the last %[2]d calls. You are most likely stuck in a retry loop. Stop calling tools now and reply to the user in English: (1) what you were trying to do (2) what got in the way (3) how the user could solve it. Do not call any more tools.",
+		"loop.exact":        "[SYSTEM] Tool %s was called %d times with exactly the same arguments; the result will not change. Stop repeating the call: try another approach or tell the user the result.",
+		"progress":          "[SYSTEM] %d steps done. Briefly report your progress and next step.",
+		"progress.early":    "[SYSTEM] %d steps done. Briefly report your progress.",
+		"progress.warn":     "[SYSTEM] ⚠️ %d steps done. Check whether the task can be finished and reply to the user. If you hit a problem you cannot solve, tell the user now.",
+		"progress.critical": "[SYSTEM] 🚨 %d steps done. You must finish the current task and reply to the user soon. If you cannot, tell the user how far you got and what is blocking you.",
+		"steering":          "[Message from the user while you were working — take it into account and continue]\n",
+		"review.retry":      "[SYSTEM] A review before delivery found these problems:\n%s\nFix them: call tools if you need to verify something, then give the complete corrected answer. Don't mention the review.",
+		"review.caveats":    "⚠️ Reviewer notes:",
+		"filter.withheld":   "[content removed: the previous answer was blocked by the model's content filter]",
+		"filter.retry":      "[SYSTEM] Your previous answer was blocked by the model's content filter. Fetched outside content has been removed. Answer the user's request as well as you can without that material; if the request itself cannot be answered, briefly say why.",
+		"filter.refused":    "⚠️ The model's content filter blocked this answer, so there is no result. Try rephrasing the request or switching to another model.",
+		"tool.deferred":     "[DEFERRED] %s\n[REASON] This model runs at most %d tool calls per step; this call was not executed.\n[HINT] Call it again in the next step if you still need it.",
+	},
+}
+
+// nudge returns the injected message key in lang, formatted with args.
+// Unknown or empty languages use Chinese.
+func nudge(lang, key string, args ...interface{}) string {
+	msgs, ok := nudges[lang]
+	if !ok {
+		msgs = nudges[LangZH]
+	}
+	msg := msgs[key]
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+type replyLangKey struct{}
+
+// WithReplyLanguage stores the language the agent must answer in for this
+// run. The agent loop localizes its injected messages to match.
+func WithReplyLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, replyLangKey{}, strings.ToLower(lang))
+}
+
+// ReplyLanguageFromContext returns the run's reply language ("" if unset).
+func ReplyLanguageFromContext(ctx context.Context) string {
+	lang, _ := ctx.Value(replyLangKey{}).(string)
+	return lang
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"帮我看下这个报错", LangZH},
+		{"帮我看下 nginx 的 error log", LangZH},
+		{"好", LangZH},
+		{"please check why the build fails", LangEN},
+		{"fix this: ```\n// 修复这个函数\nfunc f() {}\n```", ""},
+		{"看看 `go test ./...` 的输出", LangZH},
+		{"run `go test ./internal/...` and tell me what failed", LangEN},
+		{"https://example.com/中文/path", ""},
+		{"ls -la", ""},
+		{"ok", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.text); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestReplyLanguage(t *testing.T) {
+	history := []LLMMessage{
+		{Role: "user", Content: "what does this repo do?"},
+		{Role: "assistant", Content: "它是一个网关"},
+		{Role: "user", Content: "ok"},
+		{Role: "assistant", Content: "..."},
+	}
+	if got := ReplyLanguage(LangZH, "list the files please", history); got != LangZH {
+		t.Errorf("explicit choice ignored: %q", got)
+	}
+	if got := ReplyLanguage("", "列出文件", history); got != LangZH {
+		t.Errorf("message language: %q", got)
+	}
+	// no signal in the message: the latest user turn with one decides, assistant turns don't count
+	if got := ReplyLanguage("", "ls", history); got != LangEN {
+		t.Errorf("history language: %q", got)
+	}
+	if got := ReplyLanguage("", "ls", nil); got != "" {
+		t.Errorf("no signal: %q", got)
+	}
+}
+
+func TestNudgeLanguage(t *testing.T) {
+	if got := nudge(LangEN, "loop.name", "bash", 10, 8); !strings.Contains(got, "bash appeared 8 times in the last 10 calls") {
+		t.Errorf("en loop.name = %q", got)
+	}
+	if got := nudge("", "continue"); got != nudge(LangZH, "continue") {
+		t.Errorf("default nudge = %q", got)
+	}
+	for key := range nudges[LangZH] {
+		if nudges[LangEN][key] == "" {
+			t.Errorf("nudge %q has no English text", key)
+		}
+	}
+
+	p := &ModelPolicy{ProgressInterval: 5, ProgressEscalation: true}
+	if got := p.BuildProgressMessage(30, LangEN); !strings.Contains(got, "30 steps done") {
+		t.Errorf("progress = %q", got)
+	}
+
+	q := NewSteeringQueue()
+	q.Push("也看一下测试")
+	ctx := WithReplyLanguage(WithSteering(context.Background(), q), "ZH")
+	msgs, _ := steeringMessages(ctx)
+	if len(msgs) != 1 || !strings.HasPrefix(msgs[0].Content, nudge(LangZH, "steering")) {
+		t.Errorf("steering = %+v", msgs)
+	}
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LinkFetcher downloads a page and reduces it to readable text.
+// Implemented by infrastructure/linkfetch.
+type LinkFetcher interface {
+	FetchLink(ctx context.Context, rawURL string) (*LinkContent, error)
+}
+
+// LinkContent is the readable text of a fetched page.
+type LinkContent struct {
+	URL   string // final URL after redirects and rewrites
+	Title string
+	Text  string
+}
+
+// LinkPolicy decides which links in a message are read before the run and
+// how much of each reaches the model.
+type LinkPolicy struct {
+	MaxLinks      int           // links read per message (0 = 3)
+	MaxChars      int           // characters attached per link (0 = 6000)
+	SummarizeOver int           // pages longer than this are summarized by Model (0 = never)
+	Model         string        // summarizer model (a cheap one)
+	Timeout       time.Duration // fetching and summarizing all links (0 = 20s)
+	AllowDomains  []string      // non-empty = only these domains (and their subdomains)
+	DenyDomains   []string      // never these domains (and their subdomains)
+}
+
+// linkSummaryMaxTokens bounds one page summary.
+const linkSummaryMaxTokens = 1200
+
+// linkSummaryMaxInput caps the page text sent to the summarizer.
+const linkSummaryMaxInput = 60000
+
+const linkSummaryPrompt = `You condense a web page for a coding assistant that is about to answer the user's request.
+Keep everything the request may need verbatim: code, commands, API and option names, versions, numbers, error messages.
+Drop navigation, ads, cookie banners and other boilerplate. Do not add commentary. Use the page's language.`
+
+// linkRe matches bare http(s) URLs. CJK and full-width punctuation ends a
+// URL, so "看看这个https://x.dev/a，然后" yields https://x.dev/a.
+var linkRe = regexp.MustCompile(`https?://[^\s<>"'` + "`" + `\x{3000}-\x{303F}\x{FF00}-\x{FFEF}]+`)
+
+// ExtractLinks returns the distinct URLs in text, in order, at most max
+// (0 = all). Trailing sentence punctuation is not part of a URL; a closing
+// parenthesis is kept when it balances one in the URL (Wikipedia links).
+func ExtractLinks(text string, max int) []string {
+	seen := make(map[string]bool)
+	var links []string
+	for _, raw := range linkRe.FindAllString(text, -1) {
+		for {
+			trimmed := strings.TrimRight(raw, ".,;:!?'\"]}>*_~")
+			if strings.HasSuffix(trimmed, ")") && strings.Count(trimmed, "(") < strings.Count(trimmed, ")") {
+				trimmed = trimmed[:len(trimmed)-1]
+			}
+			if trimmed == raw {
+				break
+			}
+			raw = trimmed
+		}
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" || seen[raw] {
+			continue
+		}
+		seen[raw] = true
+		links = append(links, raw)
+		if max > 0 && len(links) == max {
+			break
+		}
+	}
+	return links
+}
+
+// Allows reports whether the policy lets the link be read.
+func (p LinkPolicy) Allows(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, d := range p.DenyDomains {
+		if domainMatches(host, d) {
+			return false
+		}
+	}
+	if len(p.AllowDomains) == 0 {
+		return true
+	}
+	for _, d := range p.AllowDomains {
+		if domainMatches(host, d) {
+			return true
+		}
+	}
+	return false
+}
+
+func domainMatches(host, domain string) bool {
+	domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "."))
+	return domain != "" && (host == domain || strings.HasSuffix(host, "."+domain))
+}
+
+// LinkPreprocessor reads the links pasted in a user message before the run
+// starts and turns them into context parts, so "look at this <url> and fix
+// the code" works in one turn without the model deciding to call web_fetch.
+// Long pages are summarized by a cheap model with the request in view.
+type LinkPreprocessor struct {
+	fetcher LinkFetcher
+	llm     LLMClient // nil = truncate instead of summarizing
+	policy  LinkPolicy
+	logger  *zap.Logger
+}
+
+// NewLinkPreprocessor creates a preprocessor.
+func NewLinkPreprocessor(fetcher LinkFetcher, llm LLMClient, policy LinkPolicy, logger *zap.Logger) *LinkPreprocessor {
+	if policy.MaxLinks <= 0 {
+		policy.MaxLinks = 3
+	}
+	if policy.MaxChars <= 0 {
+		policy.MaxChars = 6000
+	}
+	if policy.Timeout <= 0 {
+		policy.Timeout = 20 * time.Second
+	}
+	return &LinkPreprocessor{fetcher: fetcher, llm: llm, policy: policy, logger: logger}
+}
+
+// Prepare fetches the allowed links of the message in parallel and returns
+// one text part per link (a short note for links that failed). Links still
+// loading when the timeout expires are skipped.
+func (p *LinkPreprocessor) Prepare(ctx context.Context, message string) []ContentPart {
+	var links []string
+	for _, link := range ExtractLinks(message, 0) {
+		if p.policy.Allows(link) {
+			links = append(links, link)
+		}
+		if len(links) == p.policy.MaxLinks {
+			break
+		}
+	}
+	if len(links) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.policy.Timeout)
+	defer cancel()
+	parts := make([]ContentPart, len(links))
+	var wg sync.WaitGroup
+	for i, link := range links {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			parts[i] = p.prepareLink(ctx, link, message)
+		}()
+	}
+	wg.Wait()
+
+	out := parts[:0]
+	for _, part := range parts {
+		if part.Text != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func (p *LinkPreprocessor) prepareLink(ctx context.Context, link, message string) ContentPart {
+	start := time.Now()
+	page, err := p.fetcher.FetchLink(ctx, link)
+	if err != nil {
+		if ctx.Err() != nil {
+			p.logger.Info("Link fetch timed out", zap.String("url", link))
+			return ContentPart{}
+		}
+		p.logger.Info("Link fetch failed", zap.String("url", link), zap.Error(err))
+		return ContentPart{Type: "text", Text: fmt.Sprintf("[Could not read %s before this turn: %v]", link, err)}
+	}
+	text := strings.TrimSpace(page.Text)
+	if text == "" {
+		return ContentPart{Type: "text", Text: fmt.Sprintf("[%s has no readable text]", link)}
+	}
+
+	note := ""
+	switch {
+	case p.policy.SummarizeOver > 0 && len(text) > p.policy.SummarizeOver && p.llm != nil:
+		if summary, err := p.summarize(ctx, link, message, text); err == nil {
+			text, note = summary, "summary of a longer page; call web_fetch for the full text"
+			break
+		} else if ctx.Err() != nil {
+			return ContentPart{}
+		} else {
+			p.logger.Info("Link summary failed, truncating", zap.String("url", link), zap.Error(err))
+		}
+		fallthrough
+	case len([]rune(text)) > p.policy.MaxChars:
+		text, note = truncateRunes(text, p.policy.MaxChars), "truncated; call web_fetch for the full text"
+	}
+
+	p.logger.Info("Link read before run",
+		zap.String("url", link),
+		zap.Int("chars", len(text)),
+		zap.String("note", note),
+		zap.Duration("took", time.Since(start)),
+	)
+	var sb strings.Builder
+	sb.WriteString("[Linked page, read before this turn: " + page.URL)
+	if page.Title != "" {
+		sb.WriteString("\nTitle: " + page.Title)
+	}
+	if note != "" {
+		sb.WriteString("\n(" + note + ")")
+	}
+	sb.WriteString("]\n" + text)
+	return ContentPart{Type: "text", Text: sb.String()}
+}
+
+func (p *LinkPreprocessor) summarize(ctx context.Context, link, message, text string) (string, error) {
+	prompt := "User's request: " + truncateRunes(message, 500) + "\n\nPage: " + link + "\n\n" + truncateRunes(text, linkSummaryMaxInput)
+	resp, err := p.llm.Generate(ctx, &LLMRequest{
+		Model: p.policy.Model,
+		Messages: []LLMMessage{
+			{Role: "system", Content: linkSummaryPrompt},
+			{Role: "user", Content: prompt},
+		},
+		MaxTokens:   linkSummaryMaxTokens,
+		Temperature: 0,
+	})
+	if err != nil {
+		return "", err
+	}
+	summary := strings.TrimSpace(StripReasoningTags(resp.Content))
+	if summary == "" {
+		return "", fmt.Errorf("empty summary")
+	}
+	return summary, nil
+}
+
+type contextPartsKey struct{}
+
+// WithContextParts attaches extra parts (fetched pages, mentioned files,
+// ...) to the run's user message, after its text and any parts attached
+// before.
+func WithContextParts(ctx context.Context, parts ...ContentPart) context.Context {
+	if prev := ContextPartsFromContext(ctx); len(prev) > 0 {
+		parts = append(slices.Clip(prev), parts...)
+	}
+	return context.WithValue(ctx, contextPartsKey{}, parts)
+}
+
+// ContextPartsFromContext returns the parts attached with WithContextParts.
+func ContextPartsFromContext(ctx context.Context) []ContentPart {
+	parts, _ := ctx.Value(contextPartsKey{}).([]ContentPart)
+	return parts
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestExtractLinks(t *testing.T) {
+	tests := []struct {
+		text string
+		max  int
+		want []string
+	}{
+		{"看看这个 https://go.dev/doc/effect 然后改代码", 0, []string{"https://go.dev/doc/effect"}},
+		{"看看这个https://go.dev/a，然后（https://go.dev/b）。", 0, []string{"https://go.dev/a", "https://go.dev/b"}},
+		{"see https://en.wikipedia.org/wiki/Go_(language). and (https://x.dev/y)", 0,
+			[]string{"https://en.wikipedia.org/wiki/Go_(language)", "https://x.dev/y"}},
+		{"https://a.dev/x, https://a.dev/x and <https://b.dev/?q=1&r=2>", 0, []string{"https://a.dev/x", "https://b.dev/?q=1&r=2"}},
+		{"https://a.dev https://b.dev https://c.dev", 2, []string{"https://a.dev", "https://b.dev"}},
+		{"no links, just ftp://x.dev and http://", 0, nil},
+	}
+	for _, tt := range tests {
+		got := ExtractLinks(tt.text, tt.max)
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("ExtractLinks(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestLinkPolicyAllows(t *testing.T) {
+	p := LinkPolicy{AllowDomains: []string{"github.com", "go.dev"}, DenyDomains: []string{"gist.github.com"}}
+	for link, want := range map[string]bool{
+		"https://github.com/a/b":         true,
+		"https://pkg.go.dev/net/http":    true,
+		"https://gist.github.com/x":      false,
+		"https://notgithub.com/a":        false,
+		"https://example.com/github.com": false,
+	} {
+		if got := p.Allows(link); got != want {
+			t.Errorf("Allows(%s) = %v, want %v", link, got, want)
+		}
+	}
+}
+
+type linkTestFetcher struct {
+	pages map[string]string
+}
+
+func (f linkTestFetcher) FetchLink(ctx context.Context, rawURL string) (*LinkContent, error) {
+	text, ok := f.pages[rawURL]
+	if !ok {
+		return nil, errors.New("HTTP 404")
+	}
+	return &LinkContent{URL: rawURL, Title: "T " + rawURL, Text: text}, nil
+}
+
+type linkTestLLM struct {
+	mu       sync.Mutex
+	requests []*LLMRequest
+}
+
+func (l *linkTestLLM) Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.requests = append(l.requests, req)
+	return &LLMResponse{Content: "<think>hmm</think>the gist"}, nil
+}
+
+func (l *linkTestLLM) GenerateStream(ctx context.Context, req *LLMRequest, deltaCh chan<- StreamChunk) (*LLMResponse, error) {
+	return l.Generate(ctx, req)
+}
+
+func TestLinkPreprocessor_Prepare(t *testing.T) {
+	fetcher := linkTestFetcher{pages: map[string]string{
+		"https://a.dev/short": "short page",
+		"https://a.dev/mid":   strings.Repeat("m", 150),
+		"https://a.dev/long":  strings.Repeat("l", 500),
+	}}
+	llm := &linkTestLLM{}
+	p := NewLinkPreprocessor(fetcher, llm, LinkPolicy{MaxChars: 100, SummarizeOver: 300, Model: "cheap"}, zap.NewNop())
+
+	parts := p.Prepare(context.Background(),
+		"look at https://a.dev/short https://a.dev/mid https://a.dev/long https://a.dev/missing then fix it")
+	if len(parts) != 3 {
+		t.Fatalf("parts = %+v", parts)
+	}
+	if !strings.Contains(parts[0].Text, "https://a.dev/short") || !strings.HasSuffix(parts[0].Text, "]\nshort page") {
+		t.Errorf("short = %q", parts[0].Text)
+	}
+	if !strings.Contains(parts[1].Text, "truncated") || strings.Contains(parts[1].Text, strings.Repeat("m", 101)) {
+		t.Errorf("mid = %q", parts[1].Text)
+	}
+	if !strings.HasSuffix(parts[2].Text, "\nthe gist") || !strings.Contains(parts[2].Text, "summary") {
+		t.Errorf("long = %q", parts[2].Text)
+	}
+	if len(llm.requests) != 1 || llm.requests[0].Model != "cheap" ||
+		!strings.Contains(llm.requests[0].Messages[1].Content, "then fix it") {
+		t.Errorf("summary requests = %+v", llm.requests)
+	}
+
+	parts = p.Prepare(context.Background(), "https://a.dev/missing")
+	if len(parts) != 1 || !strings.Contains(parts[0].Text, "Could not read https://a.dev/missing") {
+		t.Errorf("missing = %+v", parts)
+	}
+	if parts := p.Prepare(context.Background(), "no links here"); parts != nil {
+		t.Errorf("no links = %+v", parts)
+	}
+}
+
+func TestAgentLoop_ContextParts(t *testing.T) {
+	llm := &journalTestLLM{}
+	loop := NewAgentLoop(llm, abortTestTools{}, DefaultAgentLoopConfig(), zap.NewNop())
+
+	ctx := WithContextParts(context.Background(), ContentPart{Type: "text", Text: "[Linked page]\npage text"})
+	_, eventCh := loop.Run(ctx, "sys", "see https://a.dev", nil, "m1")
+	for range eventCh {
+	}
+
+	var user *LLMMessage
+	for i, m := range llm.requests[0] {
+		if m.Role == "user" {
+			user = &llm.requests[0][i]
+		}
+	}
+	if user == nil || len(user.Parts) != 2 || user.Parts[0].Text != "see https://a.dev" || user.Parts[1].Text != "[Linked page]\npage text" {
+		t.Fatalf("user message = %+v", user)
+	}
+}
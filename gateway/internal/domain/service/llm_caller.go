@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"context"
 
@@ -14,11 +15,20 @@ import (
 // callLLMWithRetry calls the LLM with automatic retry and exponential backoff.
 // On transient errors (timeout, network), retries up to MaxRetries times.
 // Emits retry events so the user knows what's happening.
+//
+// A stream that fails after some text has reached the user is a stall, not
+// a plain failure: it has its own budget (StreamStallRetries), and the next
+// attempt continues the partial text instead of starting over, so the user
+// never sees it twice. Once StreamAcceptChars of text have streamed, the
+// partial answer is kept as the response.
 func (a *AgentLoop) callLLMWithRetry(ctx context.Context, req *LLMRequest, step int, eventCh chan<- entity.AgentEvent) (*LLMResponse, error) {
 	var lastErr error
+	var partial string // text streamed by stalled attempts
+	stalls := 0
+	resuming := false // the previous attempt stalled; it already waited
 
 	for attempt := 0; attempt <= a.config.MaxRetries; attempt++ {
-		if attempt > 0 {
+		if attempt > 0 && !resuming {
 			// Exponential backoff: 2s, 4s, 8s...
 			wait := a.config.RetryBaseWait * (1 << (attempt - 1))
 
@@ -42,59 +52,26 @@ func (a *AgentLoop) callLLMWithRetry(ctx context.Context, req *LLMRequest, step
 			}
 		}
 
-		// Try streaming first — forward text deltas in real time
-		deltaCh := make(chan StreamChunk, 128)
-
-		// Forward deltas to event channel in a goroutine
-		done := make(chan struct{})
-		go func() {
-			defer close(done)
-			for chunk := range deltaCh {
-				if chunk.DeltaText != "" {
-					a.emitEvent(eventCh, entity.AgentEvent{
-						Type:    entity.EventTextDelta,
-						Content: chunk.DeltaText,
-					})
-				}
-				// Tool call deltas are accumulated by GenerateStream
-				// and returned in the final LLMResponse — no need to emit here
-			}
-		}()
-
-		// Per-call timeout: prevent individual LLM calls from hanging forever.
-		// SSE streams can stall after headers arrive (ResponseHeaderTimeout won't help).
-		// 3 minutes is generous for any single LLM inference — retries handle transients.
-		callCtx, callCancel := context.WithTimeout(ctx, 3*time.Minute)
-
-		a.logger.Info("[DIAG] LLM GenerateStream starting",
-			zap.Int("step", step),
-			zap.Int("attempt", attempt),
-			zap.String("model", req.Model),
-		)
-
-		resp, err := a.llm.GenerateStream(callCtx, req, deltaCh)
-
-		a.logger.Info("[DIAG] LLM GenerateStream returned",
-			zap.Int("step", step),
-			zap.Bool("has_error", err != nil),
-			zap.Error(err),
-		)
-
-		callCancel()
-		close(deltaCh)
-		<-done // Wait for delta forwarding to finish
-
-		a.logger.Info("[DIAG] Delta forwarding complete",
-			zap.Int("step", step),
-		)
+		resuming = false
+		callReq := req
+		if partial != "" {
+			callReq = continuationRequest(req, partial)
+		}
+		resp, streamed, err := a.streamLLM(ctx, callReq, step, attempt, eventCh)
 
 		if err == nil {
-			if attempt > 0 {
+			if attempt > 0 || stalls > 0 {
 				a.logger.Info("LLM retry succeeded",
 					zap.Int("attempt", attempt),
+					zap.Int("stalls", stalls),
 					zap.Int("step", step),
 				)
 			}
+			if partial != "" {
+				resumed := *resp
+				resumed.Content = partial + resp.Content
+				resp = &resumed
+			}
 			return resp, nil
 		}
 
@@ -102,6 +79,7 @@ func (a *AgentLoop) callLLMWithRetry(ctx context.Context, req *LLMRequest, step
 		a.logger.Warn("LLM streaming call failed",
 			zap.Int("attempt", attempt),
 			zap.Int("step", step),
+			zap.Int("streamed_chars", len(streamed)),
 			zap.Error(err),
 		)
 
@@ -109,11 +87,128 @@ func (a *AgentLoop) callLLMWithRetry(ctx context.Context, req *LLMRequest, step
 		if !isRetryableError(err) {
 			return nil, fmt.Errorf("non-retryable LLM error: %w", err)
 		}
+		if streamed == "" {
+			continue
+		}
+
+		// Stream stall: the user already has partial + streamed
+		partial += streamed
+		if n := utf8.RuneCountInString(partial); n >= a.config.StreamAcceptChars {
+			a.logger.Info("Keeping partial LLM answer after stream stall",
+				zap.Int("step", step),
+				zap.Int("chars", n),
+			)
+			a.emitEvent(eventCh, entity.AgentEvent{
+				Type:    entity.EventThinking,
+				Content: fmt.Sprintf("⚡ LLM stream stalled after %d characters, keeping the partial answer", n),
+			})
+			return &LLMResponse{Content: partial, ModelUsed: req.Model, FinishReason: FinishStreamStalled}, nil
+		}
+		if stalls >= a.config.StreamStallRetries {
+			return nil, fmt.Errorf("LLM stream stalled %d times: %w", stalls+1, err)
+		}
+		stalls++
+		attempt-- // stalls do not use up MaxRetries
+		resuming = true
+
+		a.emitEvent(eventCh, entity.AgentEvent{
+			Type:    entity.EventThinking,
+			Content: fmt.Sprintf("⚡ LLM stream stalled, continuing (%d/%d)...", stalls, a.config.StreamStallRetries),
+		})
+		select {
+		case <-time.After(a.config.RetryBaseWait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
 	}
 
 	return nil, fmt.Errorf("LLM call failed after %d retries: %w", a.config.MaxRetries, lastErr)
 }
 
+// streamLLM makes one streaming call, forwarding deltas as events. It
+// returns the text forwarded, which on error is what the user has seen.
+func (a *AgentLoop) streamLLM(ctx context.Context, req *LLMRequest, step, attempt int, eventCh chan<- entity.AgentEvent) (*LLMResponse, string, error) {
+	// Try streaming first — forward text deltas in real time
+	deltaCh := make(chan StreamChunk, 128)
+
+	// Forward deltas to event channel in a goroutine
+	var streamed strings.Builder
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for chunk := range deltaCh {
+			if chunk.DeltaText != "" {
+				streamed.WriteString(chunk.DeltaText)
+				a.emitEvent(eventCh, entity.AgentEvent{
+					Type:    entity.EventTextDelta,
+					Content: chunk.DeltaText,
+				})
+			}
+			if chunk.Queue != nil {
+				a.emitEvent(eventCh, entity.AgentEvent{
+					Type:  entity.EventQueued,
+					Queue: chunk.Queue,
+				})
+			}
+			if chunk.Slow != nil {
+				a.emitEvent(eventCh, entity.AgentEvent{
+					Type: entity.EventSlow,
+					Slow: chunk.Slow,
+				})
+			}
+			// Tool call deltas are accumulated by GenerateStream
+			// and returned in the final LLMResponse — no need to emit here
+		}
+	}()
+
+	// Per-call timeout: prevent individual LLM calls from hanging forever.
+	// SSE streams can stall after headers arrive (ResponseHeaderTimeout won't help).
+	// 3 minutes is generous for any single LLM inference — retries handle transients.
+	callCtx, callCancel := context.WithTimeout(ctx, 3*time.Minute)
+
+	a.logger.Info("[DIAG] LLM GenerateStream starting",
+		zap.Int("step", step),
+		zap.Int("attempt", attempt),
+		zap.String("model", req.Model),
+	)
+
+	resp, err := a.llm.GenerateStream(callCtx, req, deltaCh)
+
+	a.logger.Info("[DIAG] LLM GenerateStream returned",
+		zap.Int("step", step),
+		zap.Bool("has_error", err != nil),
+		zap.Error(err),
+	)
+
+	callCancel()
+	close(deltaCh)
+	<-done // Wait for delta forwarding to finish
+
+	a.logger.Info("[DIAG] Delta forwarding complete",
+		zap.Int("step", step),
+	)
+	return resp, streamed.String(), err
+}
+
+// FinishStreamStalled is the finish reason of a partial answer kept after
+// its stream stalled (see AgentLoopConfig.StreamAcceptChars).
+const FinishStreamStalled = "stream_stalled"
+
+const continuationPrompt = "Your previous reply was cut off by a connection problem right after the text above. Continue exactly where it stopped: do not repeat any of it and do not comment on the interruption."
+
+// continuationRequest asks the model to continue partial, the text a
+// stalled stream already delivered, rather than answer again from scratch.
+func continuationRequest(req *LLMRequest, partial string) *LLMRequest {
+	cont := *req
+	cont.Messages = make([]LLMMessage, 0, len(req.Messages)+2)
+	cont.Messages = append(cont.Messages, req.Messages...)
+	cont.Messages = append(cont.Messages,
+		LLMMessage{Role: "assistant", Content: partial},
+		LLMMessage{Role: "user", Content: continuationPrompt},
+	)
+	return &cont
+}
+
 // isRetryableError determines if an LLM error is worth retrying.
 // Retryable: timeout, connection reset, 5xx server errors.
 // Non-retryable: 401 auth, 400 bad request, context cancelled.
@@ -132,6 +227,7 @@ func isRetryableError(err error) bool {
 		"bad request",
 		"invalid argument",
 		"model not found",
+		"blocked by organization policy",
 	}
 	for _, pattern := range nonRetryable {
 		if strings.Contains(errStr, pattern) {
@@ -55,6 +55,11 @@ func (k LLMErrorKind) String() string {
 	}
 }
 
+// MessageKey returns the i18n catalog key for the user-facing description of this kind.
+func (k LLMErrorKind) MessageKey() string {
+	return "error." + k.String()
+}
+
 // IsRetryable returns true if this error kind should be retried.
 func (k LLMErrorKind) IsRetryable() bool {
 	return k == ErrKindTransient
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// MentionResolver looks up the files and symbols a user mentions in a
+// message. Implemented by infrastructure/tool over the workspace (and its
+// index when enabled).
+type MentionResolver interface {
+	// ResolveFile finds the workspace file ref names: a path relative to the
+	// workspace root, or a unique path suffix / file name. It returns the
+	// candidates when ref is ambiguous, and nothing when no file matches.
+	ResolveFile(ctx context.Context, ref string) (file *MentionedFile, candidates []string, err error)
+	// ResolveSymbol returns the definitions named name ("Type.Method" for a
+	// method), at most limit.
+	ResolveSymbol(ctx context.Context, name string, limit int) ([]MentionedSymbol, error)
+}
+
+// MentionedFile is a file resolved from an @mention.
+type MentionedFile struct {
+	Path    string // relative to the workspace root
+	AbsPath string
+	Content string
+}
+
+// MentionedSymbol is a symbol definition resolved from a #mention.
+type MentionedSymbol struct {
+	Name    string
+	Kind    string
+	Path    string // relative to the workspace root
+	AbsPath string
+	Line    int
+	Source  string // the definition, from Line on
+}
+
+// MentionPolicy bounds how much mentioned code reaches the model.
+type MentionPolicy struct {
+	MaxMentions int // mentions resolved per message (0 = 5)
+	MaxChars    int // characters attached per file or definition (0 = 12000)
+	MaxSymbols  int // definitions attached per ambiguous symbol (0 = 3)
+	// Allow vets a resolved file before its content is attached, e.g. with
+	// the guard rules (nil = every file in the workspace)
+	Allow func(absPath string) bool
+}
+
+// Mention is one @file or #symbol reference in a message.
+type Mention struct {
+	Raw       string // as written, e.g. "@cmd/main.go:10-20"
+	Symbol    bool   // #symbol, otherwise @file
+	Ref       string // path or symbol name
+	StartLine int    // @file:start-end, 0 = whole file
+	EndLine   int
+}
+
+// mentionRe matches @path and #Symbol at the start of the text or after
+// whitespace or an opening bracket, so e-mail addresses and URL fragments
+// are not mentions. A path needs a "." or "/" to tell it from a Telegram
+// @username; a symbol starts with a letter, so "#123" stays an issue number.
+var mentionRe = regexp.MustCompile(`(?:^|[\s(\[{"'` + "`" + `])(@[\w.\-/]*[./][\w.\-/]*(?::\d+(?:-\d+)?)?|#[A-Za-z_]\w*(?:\.[A-Za-z_]\w*)?)`)
+
+// ExtractMentions returns the distinct mentions in text, in order, at most
+// max (0 = all).
+func ExtractMentions(text string, max int) []Mention {
+	seen := make(map[string]bool)
+	var mentions []Mention
+	for _, m := range mentionRe.FindAllStringSubmatch(text, -1) {
+		raw := strings.TrimRight(m[1], ".,;:!?")
+		if seen[raw] {
+			continue
+		}
+		seen[raw] = true
+		mention := Mention{Raw: raw}
+		if strings.HasPrefix(raw, "#") {
+			mention.Symbol = true
+			mention.Ref = raw[1:]
+		} else {
+			mention.Ref = raw[1:]
+			if i := strings.LastIndexByte(mention.Ref, ':'); i > 0 {
+				start, end, _ := strings.Cut(mention.Ref[i+1:], "-")
+				mention.StartLine, _ = strconv.Atoi(start)
+				mention.EndLine = mention.StartLine
+				if end != "" {
+					mention.EndLine, _ = strconv.Atoi(end)
+				}
+				mention.Ref = mention.Ref[:i]
+			}
+			if mention.Ref == "" || strings.Trim(mention.Ref, "./") == "" {
+				continue
+			}
+		}
+		mentions = append(mentions, mention)
+		if max > 0 && len(mentions) == max {
+			break
+		}
+	}
+	return mentions
+}
+
+// MentionPreprocessor resolves @file and #symbol mentions in a user message
+// before the run starts: the content of mentioned files and the definitions
+// of mentioned symbols travel with the message as context parts, and the
+// mentions are rewritten to the references they resolved to, so "why does
+// #callLLMWithRetry retry forever?" needs no search first.
+type MentionPreprocessor struct {
+	resolver MentionResolver
+	policy   MentionPolicy
+	logger   *zap.Logger
+}
+
+// NewMentionPreprocessor creates a preprocessor.
+func NewMentionPreprocessor(resolver MentionResolver, policy MentionPolicy, logger *zap.Logger) *MentionPreprocessor {
+	if policy.MaxMentions <= 0 {
+		policy.MaxMentions = 5
+	}
+	if policy.MaxChars <= 0 {
+		policy.MaxChars = 12000
+	}
+	if policy.MaxSymbols <= 0 {
+		policy.MaxSymbols = 3
+	}
+	return &MentionPreprocessor{resolver: resolver, policy: policy, logger: logger}
+}
+
+// Prepare returns message with its resolved mentions rewritten, and one
+// context part per resolved mention. Mentions that resolve to nothing are
+// left as written: "@team.lead" or "#todo" may not be code at all.
+func (p *MentionPreprocessor) Prepare(ctx context.Context, message string) (string, []ContentPart) {
+	var parts []ContentPart
+	refs := make(map[string]string)
+	for _, m := range ExtractMentions(message, p.policy.MaxMentions) {
+		var ref string
+		var part ContentPart
+		if m.Symbol {
+			ref, part = p.prepareSymbol(ctx, m)
+		} else {
+			ref, part = p.prepareFile(ctx, m)
+		}
+		if part.Text == "" {
+			continue
+		}
+		parts = append(parts, part)
+		if ref != "" {
+			refs[m.Raw] = ref
+		}
+	}
+	return rewriteMentions(message, refs), parts
+}
+
+func (p *MentionPreprocessor) prepareFile(ctx context.Context, m Mention) (string, ContentPart) {
+	file, candidates, err := p.resolver.ResolveFile(ctx, m.Ref)
+	switch {
+	case err != nil:
+		p.logger.Info("Mention resolution failed", zap.String("mention", m.Raw), zap.Error(err))
+		return "", ContentPart{}
+	case len(candidates) > 0:
+		return "", ContentPart{Type: "text", Text: fmt.Sprintf("[%s matches several files: %s. Ask which one is meant, or read it with read_file.]", m.Raw, strings.Join(candidates, ", "))}
+	case file == nil:
+		return "", ContentPart{}
+	case p.policy.Allow != nil && !p.policy.Allow(file.AbsPath):
+		return "`" + file.Path + "`", ContentPart{Type: "text", Text: fmt.Sprintf("[%s is mentioned by the user, but its content is not attached: a guard rule restricts it.]", file.Path)}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(file.Content, "\n"), "\n")
+	start, end := 1, len(lines)
+	ref := "`" + file.Path + "`"
+	if m.StartLine > 0 {
+		start, end = max(1, m.StartLine), min(len(lines), max(m.StartLine, m.EndLine))
+		if start > end {
+			return ref, ContentPart{Type: "text", Text: fmt.Sprintf("[%s has only %d lines]", file.Path, len(lines))}
+		}
+		ref = fmt.Sprintf("`%s` (lines %d-%d)", file.Path, start, end)
+	}
+	body, shown := truncateLines(lines[start-1:end], p.policy.MaxChars)
+	header := fmt.Sprintf("[File %s mentioned by the user", file.Path)
+	switch {
+	case shown < end-start+1:
+		header += fmt.Sprintf(", lines %d-%d of %d; read_file for the rest]", start, start+shown-1, len(lines))
+	case m.StartLine > 0 || start > 1 || end < len(lines):
+		header += fmt.Sprintf(", lines %d-%d of %d]", start, end, len(lines))
+	default:
+		header += "]"
+	}
+	return ref, ContentPart{Type: "text", Text: header + "\n" + fence(file.Path, body)}
+}
+
+func (p *MentionPreprocessor) prepareSymbol(ctx context.Context, m Mention) (string, ContentPart) {
+	defs, err := p.resolver.ResolveSymbol(ctx, m.Ref, p.policy.MaxSymbols)
+	if err != nil {
+		p.logger.Info("Mention resolution failed", zap.String("mention", m.Raw), zap.Error(err))
+		return "", ContentPart{}
+	}
+	var sb strings.Builder
+	var refs []string
+	for _, d := range defs {
+		if p.policy.Allow != nil && !p.policy.Allow(d.AbsPath) {
+			continue
+		}
+		loc := fmt.Sprintf("%s:%d", d.Path, d.Line)
+		refs = append(refs, loc)
+		body, _ := truncateLines(strings.Split(strings.TrimSuffix(d.Source, "\n"), "\n"), p.policy.MaxChars/len(defs))
+		if sb.Len() > 0 {
+			sb.WriteString("\n\n")
+		}
+		fmt.Fprintf(&sb, "[Definition of %s (%s) at %s, mentioned by the user]\n%s", d.Name, d.Kind, loc, fence(d.Path, body))
+	}
+	if len(refs) == 0 {
+		return "", ContentPart{}
+	}
+	return fmt.Sprintf("`%s` (%s)", m.Ref, strings.Join(refs, ", ")), ContentPart{Type: "text", Text: sb.String()}
+}
+
+// truncateLines joins whole lines up to maxChars and returns how many fit
+// (at least one, cut if it alone is too long).
+func truncateLines(lines []string, maxChars int) (string, int) {
+	size := 0
+	for i, line := range lines {
+		size += len(line) + 1
+		if size > maxChars {
+			if i == 0 {
+				return truncateRunes(line, maxChars), 1
+			}
+			return strings.Join(lines[:i], "\n"), i
+		}
+	}
+	return strings.Join(lines, "\n"), len(lines)
+}
+
+// fence wraps code in a Markdown fence tagged with the file's extension.
+func fence(file, code string) string {
+	marker := "```"
+	for strings.Contains(code, marker) {
+		marker += "`"
+	}
+	return marker + strings.TrimPrefix(path.Ext(file), ".") + "\n" + code + "\n" + marker
+}
+
+// rewriteMentions replaces every mention found in refs (keyed by
+// Mention.Raw) with the reference it resolved to.
+func rewriteMentions(message string, refs map[string]string) string {
+	if len(refs) == 0 {
+		return message
+	}
+	var sb strings.Builder
+	last := 0
+	for _, loc := range mentionRe.FindAllStringSubmatchIndex(message, -1) {
+		start, end := loc[2], loc[3]
+		raw := strings.TrimRight(message[start:end], ".,;:!?")
+		ref, ok := refs[raw]
+		if !ok {
+			continue
+		}
+		sb.WriteString(message[last:start])
+		sb.WriteString(ref)
+		last = start + len(raw)
+	}
+	sb.WriteString(message[last:])
+	return sb.String()
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestExtractMentions(t *testing.T) {
+	text := "look at @internal/app.go:10-20, @README.md and #AgentLoop.Run (see #42, mail me@x.io, ask @alice). " +
+		"Also @internal/app.go again and #todo"
+	got := ExtractMentions(text, 0)
+	want := []Mention{
+		{Raw: "@internal/app.go:10-20", Ref: "internal/app.go", StartLine: 10, EndLine: 20},
+		{Raw: "@README.md", Ref: "README.md"},
+		{Raw: "#AgentLoop.Run", Symbol: true, Ref: "AgentLoop.Run"},
+		{Raw: "@internal/app.go", Ref: "internal/app.go"},
+		{Raw: "#todo", Symbol: true, Ref: "todo"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("mentions = %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("mention %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if got := ExtractMentions(text, 2); len(got) != 2 {
+		t.Errorf("max 2 = %+v", got)
+	}
+}
+
+// mentionTestResolver resolves from fixed maps.
+type mentionTestResolver struct {
+	files   map[string]*MentionedFile
+	symbols map[string][]MentionedSymbol
+}
+
+func (r mentionTestResolver) ResolveFile(ctx context.Context, ref string) (*MentionedFile, []string, error) {
+	if ref == "main.go" {
+		return nil, []string{"cmd/a/main.go", "cmd/b/main.go"}, nil
+	}
+	return r.files[ref], nil, nil
+}
+
+func (r mentionTestResolver) ResolveSymbol(ctx context.Context, name string, limit int) ([]MentionedSymbol, error) {
+	return r.symbols[name], nil
+}
+
+func TestMentionPreprocessor_Prepare(t *testing.T) {
+	resolver := mentionTestResolver{
+		files: map[string]*MentionedFile{
+			"app.go":     {Path: "internal/app.go", AbsPath: "/repo/internal/app.go", Content: "package app\n\nfunc A() {}\n\nfunc B() {}\n"},
+			"secrets.go": {Path: "secrets.go", AbsPath: "/repo/secrets.go", Content: "key"},
+		},
+		symbols: map[string][]MentionedSymbol{
+			"B": {{Name: "B", Kind: "function", Path: "internal/app.go", AbsPath: "/repo/internal/app.go", Line: 5, Source: "func B() {}"}},
+		},
+	}
+	p := NewMentionPreprocessor(resolver, MentionPolicy{
+		Allow: func(path string) bool { return !strings.HasSuffix(path, "secrets.go") },
+	}, zap.NewNop())
+
+	text, parts := p.Prepare(context.Background(), "Why does #B differ from @app.go:3? Check @main.go, @secrets.go and @nope.go.")
+	if text != "Why does `B` (internal/app.go:5) differ from `internal/app.go` (lines 3-3)? Check @main.go, `secrets.go` and @nope.go." {
+		t.Errorf("text = %q", text)
+	}
+	if len(parts) != 4 {
+		t.Fatalf("parts = %+v", parts)
+	}
+	if !strings.Contains(parts[0].Text, "Definition of B (function) at internal/app.go:5") || !strings.Contains(parts[0].Text, "```go\nfunc B() {}\n```") {
+		t.Errorf("symbol part = %q", parts[0].Text)
+	}
+	if parts[1].Text != "[File internal/app.go mentioned by the user, lines 3-3 of 5]\n```go\nfunc A() {}\n```" {
+		t.Errorf("file part = %q", parts[1].Text)
+	}
+	if !strings.Contains(parts[2].Text, "cmd/a/main.go, cmd/b/main.go") {
+		t.Errorf("ambiguous part = %q", parts[2].Text)
+	}
+	if strings.Contains(parts[3].Text, "key") || !strings.Contains(parts[3].Text, "guard rule") {
+		t.Errorf("guarded part = %q", parts[3].Text)
+	}
+
+	// Nothing resolved: the message is untouched
+	if text, parts := p.Prepare(context.Background(), "ping @alice about #todo"); text != "ping @alice about #todo" || parts != nil {
+		t.Errorf("unresolved = %q, %+v", text, parts)
+	}
+}
+
+func TestMentionPreprocessor_Truncates(t *testing.T) {
+	content := strings.Repeat("0123456789\n", 100)
+	p := NewMentionPreprocessor(mentionTestResolver{files: map[string]*MentionedFile{
+		"big.txt": {Path: "big.txt", Content: content},
+	}}, MentionPolicy{MaxChars: 55}, zap.NewNop())
+	_, parts := p.Prepare(context.Background(), "@big.txt")
+	if len(parts) != 1 || !strings.HasPrefix(parts[0].Text, "[File big.txt mentioned by the user, lines 1-5 of 100; read_file for the rest]") {
+		t.Errorf("parts = %+v", parts)
+	}
+}
@@ -4,6 +4,7 @@ package service
 import (
 	"context"
 
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
 	"go.uber.org/zap"
 )
 
@@ -29,6 +30,13 @@ type Middleware interface {
 	AfterModel(ctx context.Context, resp *LLMResponse, step int) *LLMResponse
 }
 
+// ToolOutputObserver is an optional Middleware extension. It sees every tool
+// output in full as soon as the tool returns, before AgentLoop truncates it
+// to MaxOutputChars — e.g. to archive or pre-process it in the background.
+type ToolOutputObserver interface {
+	ObserveToolOutput(ctx context.Context, call entity.ToolCallInfo, output string)
+}
+
 // MiddlewarePipeline chains multiple Middleware in order.
 // BeforeModel runs in registration order (first added → first executed).
 // AfterModel runs in reverse order (last added → first executed) — like HTTP
@@ -72,6 +80,15 @@ func (p *MiddlewarePipeline) RunAfterModel(ctx context.Context, resp *LLMRespons
 	return resp
 }
 
+// RunObserveToolOutput passes a raw tool output to every ToolOutputObserver.
+func (p *MiddlewarePipeline) RunObserveToolOutput(ctx context.Context, call entity.ToolCallInfo, output string) {
+	for _, mw := range p.middlewares {
+		if o, ok := mw.(ToolOutputObserver); ok {
+			o.ObserveToolOutput(ctx, call, output)
+		}
+	}
+}
+
 // --- NoOpMiddleware for embedding ---
 
 // NoOpMiddleware provides pass-through defaults. Embed in custom middleware
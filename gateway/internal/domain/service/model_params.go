@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ModelParams are per-session sampling overrides (TG /params). Zero values
+// mean "not set": the loop default (AgentLoopConfig.Temperature) or the
+// provider default applies.
+type ModelParams struct {
+	Temperature     float64 `json:"temperature,omitempty"`
+	TopP            float64 `json:"top_p,omitempty"`
+	MaxOutputTokens int     `json:"max_output_tokens,omitempty"`
+	ReasoningEffort string  `json:"reasoning_effort,omitempty"` // low/medium/high
+}
+
+// ModelParamNames lists the keys accepted by SetModelParam, in display order.
+var ModelParamNames = []string{"temperature", "top_p", "max_tokens", "effort"}
+
+// IsZero reports whether no override is set.
+func (p ModelParams) IsZero() bool {
+	return p == ModelParams{}
+}
+
+// Apply writes the set overrides onto an LLM request.
+func (p ModelParams) Apply(req *LLMRequest) {
+	if p.Temperature > 0 {
+		req.Temperature = p.Temperature
+	}
+	if p.TopP > 0 {
+		req.TopP = p.TopP
+	}
+	if p.MaxOutputTokens > 0 {
+		req.MaxTokens = p.MaxOutputTokens
+	}
+	if p.ReasoningEffort != "" {
+		req.ReasoningEffort = p.ReasoningEffort
+	}
+}
+
+// Get returns the display value of one parameter ("" when not set).
+func (p ModelParams) Get(name string) string {
+	switch name {
+	case "temperature":
+		if p.Temperature > 0 {
+			return strconv.FormatFloat(p.Temperature, 'g', -1, 64)
+		}
+	case "top_p":
+		if p.TopP > 0 {
+			return strconv.FormatFloat(p.TopP, 'g', -1, 64)
+		}
+	case "max_tokens":
+		if p.MaxOutputTokens > 0 {
+			return strconv.Itoa(p.MaxOutputTokens)
+		}
+	case "effort":
+		return p.ReasoningEffort
+	}
+	return ""
+}
+
+// SetModelParam validates and sets one parameter by name. An empty value,
+// "default" or "reset" clears it.
+func (p *ModelParams) SetModelParam(name, value string) error {
+	name = normalizeModelParamName(name)
+	value = strings.ToLower(strings.TrimSpace(value))
+	reset := value == "" || value == "default" || value == "reset"
+
+	switch name {
+	case "temperature":
+		if reset {
+			p.Temperature = 0
+			return nil
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil || v <= 0 || v > 2 {
+			return fmt.Errorf("temperature must be in (0, 2], e.g. 0.2 (use 0.01 for near-deterministic)")
+		}
+		p.Temperature = v
+	case "top_p":
+		if reset {
+			p.TopP = 0
+			return nil
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil || v <= 0 || v > 1 {
+			return fmt.Errorf("top_p must be in (0, 1]")
+		}
+		p.TopP = v
+	case "max_tokens":
+		if reset {
+			p.MaxOutputTokens = 0
+			return nil
+		}
+		v, err := strconv.Atoi(value)
+		if err != nil || v < 1 || v > 200000 {
+			return fmt.Errorf("max_tokens must be an integer between 1 and 200000")
+		}
+		p.MaxOutputTokens = v
+	case "effort":
+		if reset {
+			p.ReasoningEffort = ""
+			return nil
+		}
+		switch value {
+		case "low", "medium", "high":
+			p.ReasoningEffort = value
+		default:
+			return fmt.Errorf("effort must be low, medium or high")
+		}
+	default:
+		return fmt.Errorf("unknown parameter %q (want %s)", name, strings.Join(ModelParamNames, ", "))
+	}
+	return nil
+}
+
+// normalizeModelParamName maps accepted aliases onto ModelParamNames.
+func normalizeModelParamName(name string) string {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "temperature", "temp", "t":
+		return "temperature"
+	case "top_p", "topp", "top-p", "p":
+		return "top_p"
+	case "max_tokens", "max_output_tokens", "max-tokens", "maxtokens", "tokens":
+		return "max_tokens"
+	case "effort", "reasoning_effort", "reasoning-effort":
+		return "effort"
+	}
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// --- Context keys ---
+
+type modelParamsKey struct{}
+
+// WithModelParams stores per-session model parameters for this run.
+func WithModelParams(ctx context.Context, p ModelParams) context.Context {
+	return context.WithValue(ctx, modelParamsKey{}, p)
+}
+
+// ModelParamsFromContext returns the run's model parameters (zero if unset).
+func ModelParamsFromContext(ctx context.Context) ModelParams {
+	p, _ := ctx.Value(modelParamsKey{}).(ModelParams)
+	return p
+}
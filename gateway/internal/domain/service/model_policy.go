@@ -1,9 +1,10 @@
 package service
 
 import (
-	"fmt"
 	"strings"
 	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
 )
 
 // ModelPolicy defines per-model runtime behavior for the agent loop.
@@ -18,8 +19,9 @@ type ModelPolicy struct {
 	// RepairToolPairing fixes orphan tool_use/tool_result blocks before sending to LLM.
 	RepairToolPairing bool
 
-	// EnforceTurnOrdering ensures strict user→assistant message alternation.
-	// Required by Gemini; harmless for others.
+	// EnforceTurnOrdering ensures strict user→assistant message alternation
+	// (merge consecutive user turns, placeholder turns, drop empty messages).
+	// Required by Gemini, Anthropic and some Qwen endpoints; harmless for others.
 	EnforceTurnOrdering bool
 
 	// ReasoningFormat controls the thinking tag style injected into the prompt.
@@ -28,6 +30,12 @@ type ModelPolicy struct {
 	//   "none"   — no reasoning tags (MiniMax, weaker models)
 	ReasoningFormat string
 
+	// ToolHistory controls how tool calls in the incoming history are handed
+	// to this model (see TranscodeHistory).
+	//   "native"  — keep tool calls; IDs and pairing repaired if RepairToolPairing
+	//   "flatten" — other models' tool calls become assistant text with results inlined
+	ToolHistory string
+
 	// --- Agent loop behavior ---
 
 	// ProgressInterval is the step interval at which progress reminders are
@@ -41,6 +49,26 @@ type ModelPolicy struct {
 	// RunTimeout overrides the default per-run timeout for this model family.
 	RunTimeout time.Duration
 
+	// ContextWindow is the model's context size in tokens, used by ContextGuard
+	// and to clamp max_tokens. 0 = unknown, AgentLoopConfig.ContextMaxTokens applies.
+	ContextWindow int
+
+	// InputPrice / OutputPrice are USD per million tokens, used by the
+	// pre-flight cost estimate. 0 = unknown (the estimate shows tokens only).
+	InputPrice  float64
+	OutputPrice float64
+
+	// --- Tool calls ---
+
+	// MaxParallelToolCalls caps the tool calls accepted from one response.
+	// The excess is not run: each gets a tool result asking the model to
+	// re-issue it in the next step. 0 = no cap.
+	MaxParallelToolCalls int
+
+	// SerialEditTools runs edit-kind tools of one step one at a time, in
+	// call order, while other tools still run in parallel.
+	SerialEditTools bool
+
 	// --- Prompt adaptation ---
 
 	// PromptStyle controls system prompt verbosity.
@@ -55,6 +83,10 @@ type ModelPolicy struct {
 	// ThinkingTagHint tells the prompt builder to include
 	// <think>...<final> format instructions in the system prompt.
 	ThinkingTagHint bool
+
+	// ThinkingControl is the request parameter that sets reasoning depth
+	// for this model (see ApplyThinking): "none" | "effort" | "budget" | "toggle".
+	ThinkingControl string
 }
 
 // DefaultModelPolicy returns a safe baseline that works with most models.
@@ -63,12 +95,14 @@ func DefaultModelPolicy() ModelPolicy {
 		RepairToolPairing:   true,
 		EnforceTurnOrdering: true,
 		ReasoningFormat:     "none",
+		ToolHistory:         ToolHistoryNative,
 		ProgressInterval:    10,
 		ProgressEscalation:  true,
 		RunTimeout:          10 * time.Minute,
 		PromptStyle:         "concise",
 		SystemRoleSupport:   true,
 		ThinkingTagHint:     false,
+		ThinkingControl:     ThinkingControlNone,
 	}
 }
 
@@ -82,6 +116,8 @@ func ResolveModelPolicy(modelID string, overrides map[string]*ModelPolicyOverrid
 
 	// --- Auto-detect from model ID ---
 	lower := strings.ToLower(modelID)
+	policy.ContextWindow = KnownContextWindow(modelID)
+	policy.ThinkingControl = knownThinkingControl(modelID)
 
 	switch {
 	case containsAny(lower, "qwen"):
@@ -140,18 +176,123 @@ func ResolveModelPolicy(modelID string, overrides map[string]*ModelPolicyOverrid
 	return policy
 }
 
+// knownContextWindows maps model ID substrings to context sizes in tokens.
+// The longest matching key wins, so specific entries ("gpt-4o") override
+// their family ("gpt-4"). Unlisted models fall back to the global
+// guardrails.context_max_tokens.
+var knownContextWindows = map[string]int{
+	// Anthropic
+	"claude": 200000,
+
+	// Google
+	"gemini":         1048576,
+	"gemini-1.5-pro": 2097152,
+	"gemma":          8192,
+	"gemma3":         131072,
+
+	// OpenAI
+	"gpt-3.5":     16385,
+	"gpt-4":       8192,
+	"gpt-4-turbo": 128000,
+	"gpt-4o":      128000,
+	"gpt-4.1":     1047576,
+	"gpt-5":       400000,
+	"o1":          200000,
+	"o3":          200000,
+	"o4-mini":     200000,
+
+	// Chinese providers
+	"qwen":             32768,
+	"qwen2.5":          131072,
+	"qwen3":            131072,
+	"qwen3-coder-plus": 1000000,
+	"qwen-long":        10000000,
+	"deepseek":         65536,
+	"deepseek-chat":    131072,
+	"minimax":          1000000,
+	"minimax-m2":       204800,
+	"glm-4":            131072,
+	"kimi":             131072,
+	"moonshot":         131072,
+
+	// Common local models (Ollama default builds)
+	"llama2":    4096,
+	"llama3":    8192,
+	"llama3.1":  131072,
+	"llama3.2":  131072,
+	"llama3.3":  131072,
+	"mistral":   32768,
+	"mixtral":   32768,
+	"phi3":      4096,
+	"phi4":      16384,
+	"codellama": 16384,
+}
+
+// KnownContextWindow returns the context size of a well-known model family,
+// or 0 if the model is not recognized.
+func KnownContextWindow(modelID string) int {
+	lower := strings.ToLower(modelID)
+	// Match on the model name, not the provider prefix ("openai/…", "google/…")
+	if i := strings.LastIndex(lower, "/"); i >= 0 {
+		lower = lower[i+1:]
+	}
+	matched, window := "", 0
+	for key, tokens := range knownContextWindows {
+		if strings.Contains(lower, key) && len(key) > len(matched) {
+			matched, window = key, tokens
+		}
+	}
+	return window
+}
+
+// knownThinkingControl returns the reasoning knob of a model family. Older
+// generations of each family reject the parameter and get "none".
+func knownThinkingControl(modelID string) string {
+	name := strings.ToLower(modelID)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	switch {
+	case strings.Contains(name, "claude"):
+		if containsAny(name, "claude-2", "claude-instant", "claude-3-opus", "claude-3-sonnet", "claude-3-haiku", "claude-3-5", "claude-3.5") {
+			return ThinkingControlNone
+		}
+		return ThinkingControlBudget
+	case containsAny(name, "gemini-2.5", "gemini-3"):
+		return ThinkingControlBudget
+	case strings.Contains(name, "qwen3"):
+		// coder / instruct variants have no thinking mode, *-thinking cannot turn it off
+		if containsAny(name, "coder", "instruct", "thinking") {
+			return ThinkingControlNone
+		}
+		return ThinkingControlToggle
+	case strings.HasPrefix(name, "gpt-5") || strings.HasPrefix(name, "o1") ||
+		strings.HasPrefix(name, "o3") || strings.HasPrefix(name, "o4"):
+		return ThinkingControlEffort
+	}
+	return ThinkingControlNone
+}
+
 // ModelPolicyOverride holds YAML-configurable per-model policy overrides.
 // All fields are pointers so nil = "don't override, use auto-detected value".
 type ModelPolicyOverride struct {
 	RepairToolPairing   *bool          `mapstructure:"repair_tool_pairing"`
 	EnforceTurnOrdering *bool          `mapstructure:"enforce_turn_ordering"`
 	ReasoningFormat     *string        `mapstructure:"reasoning_format"`
+	ToolHistory         *string        `mapstructure:"tool_history"`
 	ProgressInterval    *int           `mapstructure:"progress_interval"`
 	ProgressEscalation  *bool          `mapstructure:"progress_escalation"`
 	RunTimeout          *time.Duration `mapstructure:"run_timeout"`
+	ContextWindow       *int           `mapstructure:"context_window"`
 	PromptStyle         *string        `mapstructure:"prompt_style"`
 	SystemRoleSupport   *bool          `mapstructure:"system_role_support"`
 	ThinkingTagHint     *bool          `mapstructure:"thinking_tag_hint"`
+	ThinkingControl     *string        `mapstructure:"thinking_control"`
+	InputPrice          *float64       `mapstructure:"input_price"`
+	OutputPrice         *float64       `mapstructure:"output_price"`
+
+	MaxParallelToolCalls *int  `mapstructure:"max_parallel_tool_calls"`
+	SerialEditTools      *bool `mapstructure:"serial_edit_tools"`
 }
 
 // applyOverride merges non-nil override fields into the policy.
@@ -168,6 +309,9 @@ func applyOverride(p *ModelPolicy, o *ModelPolicyOverride) {
 	if o.ReasoningFormat != nil {
 		p.ReasoningFormat = *o.ReasoningFormat
 	}
+	if o.ToolHistory != nil {
+		p.ToolHistory = *o.ToolHistory
+	}
 	if o.ProgressInterval != nil {
 		p.ProgressInterval = *o.ProgressInterval
 	}
@@ -177,6 +321,9 @@ func applyOverride(p *ModelPolicy, o *ModelPolicyOverride) {
 	if o.RunTimeout != nil {
 		p.RunTimeout = *o.RunTimeout
 	}
+	if o.ContextWindow != nil {
+		p.ContextWindow = *o.ContextWindow
+	}
 	if o.PromptStyle != nil {
 		p.PromptStyle = *o.PromptStyle
 	}
@@ -186,27 +333,58 @@ func applyOverride(p *ModelPolicy, o *ModelPolicyOverride) {
 	if o.ThinkingTagHint != nil {
 		p.ThinkingTagHint = *o.ThinkingTagHint
 	}
+	if o.ThinkingControl != nil {
+		p.ThinkingControl = *o.ThinkingControl
+	}
+	if o.InputPrice != nil {
+		p.InputPrice = *o.InputPrice
+	}
+	if o.OutputPrice != nil {
+		p.OutputPrice = *o.OutputPrice
+	}
+	if o.MaxParallelToolCalls != nil {
+		p.MaxParallelToolCalls = *o.MaxParallelToolCalls
+	}
+	if o.SerialEditTools != nil {
+		p.SerialEditTools = *o.SerialEditTools
+	}
+}
+
+// SplitToolCalls returns the tool calls to run this step and the ones over
+// MaxParallelToolCalls, which are deferred (see DeferredToolCallMessage).
+func (p *ModelPolicy) SplitToolCalls(calls []entity.ToolCallInfo) (run, deferred []entity.ToolCallInfo) {
+	if p.MaxParallelToolCalls <= 0 || len(calls) <= p.MaxParallelToolCalls {
+		return calls, nil
+	}
+	return calls[:p.MaxParallelToolCalls], calls[p.MaxParallelToolCalls:]
+}
+
+// DeferredToolCallMessage is the tool result of a call over the per-step cap,
+// in the reply language.
+func (p *ModelPolicy) DeferredToolCallMessage(name, lang string) string {
+	return nudge(lang, "tool.deferred", name, p.MaxParallelToolCalls)
 }
 
-// BuildProgressMessage generates a step-appropriate progress reminder.
-// The urgency escalates with step count when ProgressEscalation is enabled.
-func (p *ModelPolicy) BuildProgressMessage(step int) string {
+// BuildProgressMessage generates a step-appropriate progress reminder in the
+// reply language. The urgency escalates with step count when
+// ProgressEscalation is enabled.
+func (p *ModelPolicy) BuildProgressMessage(step int, lang string) string {
 	if p.ProgressInterval <= 0 {
 		return ""
 	}
 
 	if !p.ProgressEscalation {
-		return fmt.Sprintf("[SYSTEM] 已执行 %d 步。请简要汇报当前进展和下一步计划。", step)
+		return nudge(lang, "progress", step)
 	}
 
 	// Escalating urgency based on step count
 	switch {
 	case step <= 15:
-		return fmt.Sprintf("[SYSTEM] 已执行 %d 步。请简要汇报当前进展。", step)
+		return nudge(lang, "progress.early", step)
 	case step <= 25:
-		return fmt.Sprintf("[SYSTEM] ⚠️ 已执行 %d 步。请检查任务是否可以完成并回复用户。如果遇到无法解决的问题，请立即告知用户。", step)
+		return nudge(lang, "progress.warn", step)
 	default:
-		return fmt.Sprintf("[SYSTEM] 🚨 已执行 %d 步。你必须尽快完成当前任务并回复用户。如果无法完成，请告知用户当前进展和遇到的问题。", step)
+		return nudge(lang, "progress.critical", step)
 	}
 }
 
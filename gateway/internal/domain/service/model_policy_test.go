@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"go.uber.org/zap"
+)
+
+func TestKnownContextWindow(t *testing.T) {
+	tests := []struct {
+		model string
+		want  int
+	}{
+		{"anthropic/claude-sonnet-4-20250514", 200000},
+		{"google/gemini-2.5-pro", 1048576},
+		{"gemini-1.5-pro-latest", 2097152},
+		{"openai/gpt-4o-mini", 128000},
+		{"gpt-4-0613", 8192},
+		{"gpt-4.1-mini", 1047576},
+		{"ollama/llama3:8b", 8192},
+		{"ollama/llama3.1:8b", 131072},
+		{"bailian/qwen3-coder-plus", 1000000},
+		{"MiniMax-M2", 204800},
+		{"chaos/scripted", 0},
+	}
+	for _, tt := range tests {
+		if got := KnownContextWindow(tt.model); got != tt.want {
+			t.Errorf("KnownContextWindow(%q) = %d, want %d", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestResolveModelPolicy_ContextWindowOverride(t *testing.T) {
+	small := 8192
+	overrides := map[string]*ModelPolicyOverride{"my-local": {ContextWindow: &small}}
+
+	if p := ResolveModelPolicy("ollama/my-local-model", overrides); p.ContextWindow != 8192 {
+		t.Errorf("override: ContextWindow = %d", p.ContextWindow)
+	}
+	if p := ResolveModelPolicy("anthropic/claude-opus-4", overrides); p.ContextWindow != 200000 {
+		t.Errorf("known default: ContextWindow = %d", p.ContextWindow)
+	}
+}
+
+func TestContextGuard_ClampMaxTokens(t *testing.T) {
+	g := NewContextGuard(8192, 0.7, 0.85, zap.NewNop())
+	prompt := []LLMMessage{{Role: "user", Content: strings.Repeat("x", 3*6000)}}
+
+	req := &LLMRequest{Messages: prompt, MaxTokens: 4096}
+	g.ClampMaxTokens(req)
+	if want := 8192 - g.estimateTokens(prompt); req.MaxTokens != want {
+		t.Errorf("MaxTokens = %d, want %d", req.MaxTokens, want)
+	}
+
+	req = &LLMRequest{Messages: prompt, MaxTokens: 1000}
+	g.ClampMaxTokens(req)
+	if req.MaxTokens != 1000 {
+		t.Errorf("fitting MaxTokens changed to %d", req.MaxTokens)
+	}
+
+	req = &LLMRequest{Messages: prompt}
+	g.ClampMaxTokens(req)
+	if req.MaxTokens != 0 {
+		t.Errorf("unset MaxTokens changed to %d", req.MaxTokens)
+	}
+
+	full := []LLMMessage{{Role: "user", Content: strings.Repeat("x", 3*9000)}}
+	req = &LLMRequest{Messages: full, MaxTokens: 4096}
+	g.ClampMaxTokens(req)
+	if req.MaxTokens != minOutputTokens {
+		t.Errorf("full window: MaxTokens = %d, want %d", req.MaxTokens, minOutputTokens)
+	}
+}
+
+// toolCapLLM asks for four tool calls, then answers once it sees the results.
+type toolCapLLM struct {
+	mu       sync.Mutex
+	requests [][]LLMMessage
+}
+
+func (l *toolCapLLM) Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.requests = append(l.requests, append([]LLMMessage(nil), req.Messages...))
+	if len(l.requests) > 1 {
+		return &LLMResponse{Content: "done", ModelUsed: req.Model}, nil
+	}
+	call := func(id, name string) entity.ToolCallInfo {
+		return entity.ToolCallInfo{ID: id, Name: name, Arguments: map[string]interface{}{"path": id}}
+	}
+	return &LLMResponse{ModelUsed: req.Model, ToolCalls: []entity.ToolCallInfo{
+		call("1", "write_file"), call("2", "read_file"), call("3", "write_file"), call("4", "write_file"),
+	}}, nil
+}
+
+func (l *toolCapLLM) GenerateStream(ctx context.Context, req *LLMRequest, deltaCh chan<- StreamChunk) (*LLMResponse, error) {
+	return l.Generate(ctx, req)
+}
+
+// toolCapTools records the order edits ran in and how many overlapped.
+type toolCapTools struct {
+	mu            sync.Mutex
+	running, peak int
+	edits         []string
+}
+
+func (t *toolCapTools) Execute(ctx context.Context, name string, args map[string]interface{}) (*domaintool.Result, error) {
+	if name != "write_file" {
+		return &domaintool.Result{Success: true, Output: "ok"}, nil
+	}
+	t.mu.Lock()
+	t.running++
+	t.peak = max(t.peak, t.running)
+	t.edits = append(t.edits, args["path"].(string))
+	t.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	t.mu.Lock()
+	t.running--
+	t.mu.Unlock()
+	return &domaintool.Result{Success: true, Output: "written"}, nil
+}
+func (t *toolCapTools) GetDefinitions() []domaintool.Definition { return nil }
+func (t *toolCapTools) GetToolKind(name string) domaintool.Kind {
+	if name == "write_file" {
+		return domaintool.KindEdit
+	}
+	return domaintool.KindRead
+}
+
+func TestAgentLoop_ToolCallCapAndSerialEdits(t *testing.T) {
+	limit, serial := 3, true
+	cfg := DefaultAgentLoopConfig()
+	cfg.Model = "chaos/scripted"
+	cfg.ModelPolicies = map[string]*ModelPolicyOverride{
+		"scripted": {MaxParallelToolCalls: &limit, SerialEditTools: &serial},
+	}
+	llm, tools := &toolCapLLM{}, &toolCapTools{}
+	loop := NewAgentLoop(llm, tools, cfg, zap.NewNop())
+
+	result, eventCh := loop.Run(context.Background(), "", "edit things", nil, "")
+	for range eventCh {
+	}
+	if result.FinalContent != "done" {
+		t.Fatalf("FinalContent = %q", result.FinalContent)
+	}
+
+	if tools.peak != 1 || strings.Join(tools.edits, ",") != "1,3" {
+		t.Errorf("edits ran %v with %d at once, want 1,3 one at a time", tools.edits, tools.peak)
+	}
+	var deferred *LLMMessage
+	for i, m := range llm.requests[1] {
+		if m.Role == "tool" && m.ToolCallID == "4" {
+			deferred = &llm.requests[1][i]
+		}
+	}
+	if deferred == nil || !strings.HasPrefix(deferred.Content, "[DEFERRED] write_file") || !strings.Contains(deferred.Content, "3") {
+		t.Errorf("deferred call result = %+v", deferred)
+	}
+}
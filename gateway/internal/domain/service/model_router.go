@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// RouteRule is one entry of the auto-routing table. Every condition that is
+// set must hold for the rule to match; unset (zero) conditions match anything.
+type RouteRule struct {
+	Name      string
+	Intents   []string // prompt intents: general | coding | research | finance | system | creative
+	Images    bool     // only requests carrying images
+	MinTokens int      // estimated context (history + message) lower bound
+	MaxTokens int      // upper bound, 0 = unbounded
+	Model     string
+}
+
+// RouteInput describes an incoming request for routing.
+type RouteInput struct {
+	Intent        string
+	ContextTokens int
+	HasImages     bool
+}
+
+// RouteDecision records which rule picked the model for a run.
+type RouteDecision struct {
+	RouteInput
+	Rule  string
+	Model string
+}
+
+// String renders the decision for logs and StepInfo, e.g.
+// "coding ~12k tokens → large-refactor → bailian/qwen3-coder-plus".
+func (d RouteDecision) String() string {
+	var sb strings.Builder
+	sb.WriteString(d.Intent)
+	if d.ContextTokens >= 1000 {
+		fmt.Fprintf(&sb, " ~%dk tokens", d.ContextTokens/1000)
+	} else {
+		fmt.Fprintf(&sb, " ~%d tokens", d.ContextTokens)
+	}
+	if d.HasImages {
+		sb.WriteString(" +images")
+	}
+	fmt.Fprintf(&sb, " → %s → %s", d.Rule, d.Model)
+	return sb.String()
+}
+
+// ModelRouter picks a model per request from an ordered routing table;
+// the first matching rule wins.
+type ModelRouter struct {
+	rules []RouteRule
+}
+
+// NewModelRouter creates a router. Rules without a model are dropped and
+// unnamed rules are named by position (#1, #2, ...).
+func NewModelRouter(rules []RouteRule) *ModelRouter {
+	r := &ModelRouter{}
+	for i, rule := range rules {
+		if rule.Model == "" {
+			continue
+		}
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("#%d", i+1)
+		}
+		r.rules = append(r.rules, rule)
+	}
+	return r
+}
+
+// Rules returns the routing table.
+func (r *ModelRouter) Rules() []RouteRule {
+	return r.rules
+}
+
+// Route returns the decision of the first matching rule; ok is false when
+// no rule matches and the chat's own model should be used.
+func (r *ModelRouter) Route(in RouteInput) (RouteDecision, bool) {
+	for _, rule := range r.rules {
+		if rule.matches(in) {
+			return RouteDecision{RouteInput: in, Rule: rule.Name, Model: rule.Model}, true
+		}
+	}
+	return RouteDecision{}, false
+}
+
+func (rule RouteRule) matches(in RouteInput) bool {
+	if rule.Images && !in.HasImages {
+		return false
+	}
+	if rule.MinTokens > 0 && in.ContextTokens < rule.MinTokens {
+		return false
+	}
+	if rule.MaxTokens > 0 && in.ContextTokens > rule.MaxTokens {
+		return false
+	}
+	if len(rule.Intents) == 0 {
+		return true
+	}
+	for _, intent := range rule.Intents {
+		if strings.EqualFold(intent, in.Intent) {
+			return true
+		}
+	}
+	return false
+}
+
+// EstimateTokens roughly estimates the token count of a conversation.
+// Heuristic: ~3 chars/token (blend of English ~4, CJK ~2).
+func EstimateTokens(messages []LLMMessage) int {
+	total := 0
+	for _, msg := range messages {
+		total += len(msg.Content) / 3
+		// ContentParts: count text parts
+		for _, p := range msg.Parts {
+			if p.Type == "text" {
+				total += len(p.Text) / 3
+			} else {
+				total += 85 // image/media tokens (~85 for a typical image descriptor)
+			}
+		}
+		// Tool call arguments overhead
+		for _, tc := range msg.ToolCalls {
+			total += len(tc.Name) + 50
+		}
+	}
+	// Per-message formatting overhead
+	total += len(messages) * 4
+	return total
+}
+
+// --- Context keys ---
+
+type routeDecisionKey struct{}
+
+// WithRouteDecision records the auto-routing decision for this run; the
+// loop reports it in every StepInfo.
+func WithRouteDecision(ctx context.Context, d RouteDecision) context.Context {
+	return context.WithValue(ctx, routeDecisionKey{}, d)
+}
+
+// RouteDecisionFromContext returns the run's routing decision, if any.
+func RouteDecisionFromContext(ctx context.Context) (RouteDecision, bool) {
+	d, ok := ctx.Value(routeDecisionKey{}).(RouteDecision)
+	return d, ok
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestModelRouter_FirstMatchWins(t *testing.T) {
+	r := NewModelRouter([]RouteRule{
+		{Name: "vision", Images: true, Model: "vl"},
+		{Name: "large-refactor", Intents: []string{"coding"}, MinTokens: 8000, Model: "coder-plus"},
+		{Intents: []string{"coding"}, Model: "coder"},
+		{Name: "small-talk", Intents: []string{"general"}, MaxTokens: 2000, Model: "flash"},
+		{Name: "no-model", Intents: []string{"research"}},
+	})
+
+	tests := []struct {
+		name      string
+		in        RouteInput
+		wantRule  string
+		wantModel string
+	}{
+		{"image beats intent", RouteInput{Intent: "coding", ContextTokens: 20000, HasImages: true}, "vision", "vl"},
+		{"large coding", RouteInput{Intent: "coding", ContextTokens: 12000}, "large-refactor", "coder-plus"},
+		{"small coding", RouteInput{Intent: "CODING", ContextTokens: 500}, "#3", "coder"},
+		{"small talk", RouteInput{Intent: "general", ContextTokens: 300}, "small-talk", "flash"},
+	}
+	for _, tt := range tests {
+		d, ok := r.Route(tt.in)
+		if !ok || d.Rule != tt.wantRule || d.Model != tt.wantModel {
+			t.Errorf("%s: got %+v, %v", tt.name, d, ok)
+		}
+	}
+
+	for _, in := range []RouteInput{
+		{Intent: "general", ContextTokens: 5000}, // too large for small-talk
+		{Intent: "research"},                     // rule without a model is dropped
+	} {
+		if d, ok := r.Route(in); ok {
+			t.Errorf("%+v routed to %+v", in, d)
+		}
+	}
+	if n := len(r.Rules()); n != 4 {
+		t.Errorf("rules = %d, want 4", n)
+	}
+}
+
+func TestRouteDecision_ReportedInContext(t *testing.T) {
+	d := RouteDecision{RouteInput: RouteInput{Intent: "coding", ContextTokens: 12345, HasImages: true}, Rule: "large", Model: "p/coder"}
+	if got := d.String(); got != "coding ~12k tokens +images → large → p/coder" {
+		t.Errorf("String() = %q", got)
+	}
+	ctx := WithRouteDecision(context.Background(), d)
+	if got, ok := RouteDecisionFromContext(ctx); !ok || got.Model != "p/coder" {
+		t.Errorf("from context = %+v, %v", got, ok)
+	}
+	if _, ok := RouteDecisionFromContext(context.Background()); ok {
+		t.Error("decision without routing")
+	}
+	if !strings.Contains(RouteDecision{RouteInput: RouteInput{Intent: "general", ContextTokens: 40}, Rule: "r", Model: "m"}.String(), "~40 tokens") {
+		t.Error("small contexts should be shown exactly")
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+)
+
+// OrgPolicy is the organization-wide policy distributed as a signed bundle
+// (policy.url in config.yaml). It takes precedence over the local config:
+// its approval mode and lists win over config.yaml and runtime changes
+// such as /security, its guard rules are checked before the project's, and
+// its budget pools apply on top of agent.budgets.
+type OrgPolicy struct {
+	Version string
+	Issued  time.Time // when the bundle was signed; older bundles are refused
+
+	ApprovalMode    string   // "" = keep the local mode
+	DangerousTools  []string // always dangerous, even if trusted locally
+	TrustedTools    []string // nil = keep the local list
+	TrustedCommands []string // nil = keep the local list
+	RiskAnalysis    bool     // forces shell risk analysis on
+
+	Guards        *GuardRules
+	BlockedModels []string // model names, shell-style wildcards ("openai/gpt-4*")
+	Budgets       []BudgetPool
+}
+
+// ApplySecurity returns cfg with the policy's security settings applied.
+func (p *OrgPolicy) ApplySecurity(cfg config.SecurityConfig) config.SecurityConfig {
+	if p == nil {
+		return cfg
+	}
+	if p.ApprovalMode != "" {
+		cfg.ApprovalMode = p.ApprovalMode
+	}
+	if p.TrustedTools != nil {
+		cfg.TrustedTools = p.TrustedTools
+	}
+	if p.TrustedCommands != nil {
+		cfg.TrustedCommands = p.TrustedCommands
+	}
+	if len(p.DangerousTools) > 0 {
+		cfg.TrustedTools = slices.DeleteFunc(slices.Clone(cfg.TrustedTools), func(t string) bool {
+			return slices.Contains(p.DangerousTools, t)
+		})
+		dangerous := slices.Clone(cfg.DangerousTools)
+		for _, t := range p.DangerousTools {
+			if !slices.Contains(dangerous, t) {
+				dangerous = append(dangerous, t)
+			}
+		}
+		cfg.DangerousTools = dangerous
+	}
+	cfg.RiskAnalysis = cfg.RiskAnalysis || p.RiskAnalysis
+	return cfg
+}
+
+// ModelBlocked reports whether the policy forbids model.
+func (p *OrgPolicy) ModelBlocked(model string) bool {
+	return p != nil && matchAnyName(p.BlockedModels, model)
+}
+
+// CheckModel returns the error for a request to a blocked model, or nil.
+func (p *OrgPolicy) CheckModel(model string) error {
+	if p.ModelBlocked(model) {
+		return fmt.Errorf("model %q is blocked by organization policy", model)
+	}
+	return nil
+}
+
+// Validate checks the settings that CompileGuardRules does not.
+func (p *OrgPolicy) Validate() error {
+	switch p.ApprovalMode {
+	case "", "auto", "ask_dangerous", "ask_all":
+	default:
+		return fmt.Errorf("approval_mode must be auto, ask_dangerous or ask_all, got %q", p.ApprovalMode)
+	}
+	for _, m := range p.BlockedModels {
+		if _, err := path.Match(m, ""); err != nil {
+			return fmt.Errorf("bad blocked_models pattern %q: %w", m, err)
+		}
+	}
+	for _, b := range p.Budgets {
+		switch {
+		case b.Scope != BudgetGlobal && b.Scope != BudgetUser && b.Scope != BudgetChat:
+			return fmt.Errorf("budget %s: scope must be global, user or chat, got %q", b.Name, b.Scope)
+		case b.Window != BudgetDaily && b.Window != BudgetMonthly:
+			return fmt.Errorf("budget %s: window must be daily or monthly, got %q", b.Name, b.Window)
+		case b.Tokens <= 0 && b.USD <= 0:
+			return fmt.Errorf("budget %s: needs a tokens or usd limit", b.Name)
+		}
+	}
+	return nil
+}
+
+// SetOrgPolicy installs the organization policy (nil removes it). It applies
+// from the next tool call on.
+func (h *SecurityHook) SetOrgPolicy(p *OrgPolicy) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.org = p
+}
+
+// config returns the security config in effect. Callers must hold h.mu.
+func (h *SecurityHook) config() config.SecurityConfig {
+	return h.org.ApplySecurity(h.cfg)
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+func TestOrgPolicy_ApplySecurity(t *testing.T) {
+	local := config.SecurityConfig{
+		ApprovalMode:    "auto",
+		DangerousTools:  []string{"bash"},
+		TrustedTools:    []string{"bash", "web_fetch"},
+		TrustedCommands: []string{"ls", "git"},
+	}
+	p := &OrgPolicy{ApprovalMode: "ask_dangerous", DangerousTools: []string{"web_fetch", "ssh"}, RiskAnalysis: true}
+	got := p.ApplySecurity(local)
+	if got.ApprovalMode != "ask_dangerous" || !got.RiskAnalysis ||
+		!slices.Equal(got.DangerousTools, []string{"bash", "web_fetch", "ssh"}) ||
+		!slices.Equal(got.TrustedTools, []string{"bash"}) ||
+		!slices.Equal(got.TrustedCommands, []string{"ls", "git"}) {
+		t.Errorf("applied = %+v", got)
+	}
+	if !slices.Equal(local.TrustedTools, []string{"bash", "web_fetch"}) {
+		t.Errorf("local config modified: %v", local.TrustedTools)
+	}
+
+	p = &OrgPolicy{TrustedCommands: []string{}}
+	if got := p.ApplySecurity(local); got.ApprovalMode != "auto" || len(got.TrustedCommands) != 0 {
+		t.Errorf("empty trusted_commands = %+v", got)
+	}
+	if got := (*OrgPolicy)(nil).ApplySecurity(local); got.ApprovalMode != "auto" {
+		t.Errorf("nil policy = %+v", got)
+	}
+}
+
+func TestOrgPolicy_ModelBlocked(t *testing.T) {
+	p := &OrgPolicy{BlockedModels: []string{"openai/gpt-4*", "local-uncensored"}}
+	for model, want := range map[string]bool{
+		"openai/gpt-4o":    true,
+		"openai/gpt-5":     false,
+		"local-uncensored": true,
+		"claude-sonnet":    false,
+	} {
+		if got := p.ModelBlocked(model); got != want {
+			t.Errorf("ModelBlocked(%q) = %v", model, got)
+		}
+	}
+	if err := p.CheckModel("openai/gpt-4o"); err == nil || isRetryableError(err) {
+		t.Errorf("CheckModel = %v, want a non-retryable error", err)
+	}
+	if (*OrgPolicy)(nil).ModelBlocked("x") {
+		t.Error("nil policy blocks models")
+	}
+}
+
+func TestSecurityHook_OrgPolicy(t *testing.T) {
+	project, _ := CompileGuardRules([]GuardRule{
+		{Name: "project", Paths: []string{"secrets/**"}, Action: GuardAllow},
+	}, "/repo")
+	org, err := CompileGuardRules([]GuardRule{
+		{Name: "org", Paths: []string{"secrets/**"}, Action: GuardDeny, Message: "secrets are off limits"},
+	}, "/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	asked := 0
+	h := NewSecurityHook(config.SecurityConfig{ApprovalMode: "auto"}, func(context.Context, string, map[string]interface{}) (bool, error) {
+		asked++
+		return true, nil
+	}, zap.NewNop())
+	h.SetGuards(staticGuards{rules: project}, func() string { return "/repo" })
+	h.SetOrgPolicy(&OrgPolicy{ApprovalMode: "ask_all", Guards: org})
+
+	if h.BeforeToolCall(context.Background(), "read_file", map[string]interface{}{"path": "secrets/key"}) || asked != 0 {
+		t.Errorf("org deny rule overridden by project allow (asked=%d)", asked)
+	}
+	// /security auto does not loosen the organization's mode
+	h.SetApprovalMode("auto")
+	if !h.BeforeToolCall(context.Background(), "read_file", map[string]interface{}{"path": "README.md"}) || asked != 1 {
+		t.Errorf("asked = %d, want the org approval mode", asked)
+	}
+	if mode := h.GetConfig().ApprovalMode; mode != "ask_all" {
+		t.Errorf("effective mode = %q", mode)
+	}
+
+	h.SetOrgPolicy(nil)
+	if !h.BeforeToolCall(context.Background(), "read_file", map[string]interface{}{"path": "secrets/key"}) || asked != 1 {
+		t.Error("project rules and local mode not restored")
+	}
+}
+
+func TestBudgetManager_OrgPools(t *testing.T) {
+	repo := &memBudgetRepo{rows: make(map[string]*entity.BudgetUsage)}
+	m := NewBudgetManager([]BudgetPool{{Name: "team", Scope: BudgetGlobal, Window: BudgetMonthly, Tokens: 1000}}, repo, nil, zap.NewNop())
+	m.SetOrgPools([]BudgetPool{{Name: "team", Scope: BudgetUser, Window: BudgetDaily, Tokens: 100}})
+
+	alice := BudgetSubject{User: "alice"}
+	m.Record(context.Background(), alice, "m", 150)
+	status, exhausted := m.Check(context.Background(), alice)
+	if !exhausted || status.Pool.Name != "org:team" {
+		t.Errorf("check = %+v, %v", status, exhausted)
+	}
+	if _, exhausted := m.Check(context.Background(), BudgetSubject{User: "bob"}); exhausted {
+		t.Error("bob refused by alice's pool")
+	}
+
+	m.SetOrgPools(nil)
+	if _, exhausted := m.Check(context.Background(), alice); exhausted {
+		t.Error("org pool still applies after removal")
+	}
+}
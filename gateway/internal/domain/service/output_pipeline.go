@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+// ErrOutputWithheld is returned by OutputPipeline.Process when a Withhold
+// stage fails. The processor's own error is only logged, since it may quote
+// the content it was meant to keep from the user.
+var ErrOutputWithheld = errors.New("answer withheld by output policy")
+
+// OutputInfo describes where a final answer is about to be delivered.
+type OutputInfo struct {
+	Channel string // telegram | cli | http | api
+	Model   string
+}
+
+// OutputProcessor rewrites the final answer before delivery (org output
+// policies: redact internal hostnames, enforce a footer, rewrite links).
+// Unlike Middleware it never sees intermediate LLM responses, and its result
+// is not written back into the conversation history.
+type OutputProcessor interface {
+	Name() string
+	Process(ctx context.Context, content string, info OutputInfo) (string, error)
+}
+
+// OutputStage is one processor in an OutputPipeline.
+type OutputStage struct {
+	Processor OutputProcessor
+	Channels  []string // empty = every channel
+	// Withhold makes a failure block delivery instead of passing the
+	// content on unchanged (fail closed, for compliance filters).
+	Withhold bool
+}
+
+func (s OutputStage) appliesTo(channel string) bool {
+	if len(s.Channels) == 0 {
+		return true
+	}
+	for _, c := range s.Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// OutputPipeline runs OutputProcessors in registration order. A nil pipeline
+// passes content through, so callers need no nil checks.
+type OutputPipeline struct {
+	stages []OutputStage
+	logger *zap.Logger
+}
+
+// NewOutputPipeline creates an empty pipeline.
+func NewOutputPipeline(logger *zap.Logger) *OutputPipeline {
+	return &OutputPipeline{logger: logger}
+}
+
+// Use appends a stage to the pipeline.
+func (p *OutputPipeline) Use(stage OutputStage) {
+	p.stages = append(p.stages, stage)
+}
+
+// Active reports whether any stage applies to the channel. Channels that
+// stream text deltas buffer the answer instead when it does, since partial
+// deltas cannot be filtered.
+func (p *OutputPipeline) Active(channel string) bool {
+	if p == nil {
+		return false
+	}
+	for _, s := range p.stages {
+		if s.appliesTo(channel) {
+			return true
+		}
+	}
+	return false
+}
+
+// Process runs every stage that applies to info.Channel. A failing stage is
+// logged and skipped, unless it is marked Withhold: then Process returns
+// ErrOutputWithheld and the caller must not deliver the content.
+func (p *OutputPipeline) Process(ctx context.Context, content string, info OutputInfo) (string, error) {
+	if p == nil {
+		return content, nil
+	}
+	for _, s := range p.stages {
+		if !s.appliesTo(info.Channel) {
+			continue
+		}
+		out, err := s.Processor.Process(ctx, content, info)
+		if err != nil {
+			if s.Withhold {
+				p.logger.Warn("Output processor failed, withholding answer",
+					zap.String("processor", s.Processor.Name()),
+					zap.String("channel", info.Channel),
+					zap.Error(err),
+				)
+				return "", ErrOutputWithheld
+			}
+			p.logger.Warn("Output processor failed, passing content through",
+				zap.String("processor", s.Processor.Name()),
+				zap.String("channel", info.Channel),
+				zap.Error(err),
+			)
+			continue
+		}
+		content = out
+	}
+	return content, nil
+}
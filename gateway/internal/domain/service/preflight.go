@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.uber.org/zap"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+)
+
+// PreflightApprovalTool is the tool name under which a large LLM call is put
+// through the approval channel (TG card, console, HTTP queue).
+const PreflightApprovalTool = "llm_call"
+
+// LLMCallApprover is an optional AgentHook extension that can veto an LLM
+// call whose estimated input exceeds AgentLoopConfig.PreflightTokens.
+// SecurityHook implements it for the ask_* approval modes.
+type LLMCallApprover interface {
+	ApproveLLMCall(ctx context.Context, info entity.PreflightInfo) bool
+}
+
+// EstimateRequestCost estimates the input tokens of req (messages and tool
+// schemas) and, when the policy has prices, its cost: the input plus a
+// full-length answer if max_tokens is set.
+func EstimateRequestCost(req *LLMRequest, policy ModelPolicy) entity.PreflightInfo {
+	tokens := EstimateTokens(req.Messages)
+	if len(req.Tools) > 0 {
+		if data, err := json.Marshal(req.Tools); err == nil {
+			tokens += len(data) / 3
+		}
+	}
+	cost := float64(tokens)*policy.InputPrice/1e6 + float64(req.MaxTokens)*policy.OutputPrice/1e6
+	return entity.PreflightInfo{Model: req.Model, InputTokens: tokens, CostUSD: cost}
+}
+
+// preflight runs the pre-flight cost check before an LLM call. Calls above
+// the threshold emit EventCostWarning and are put to the LLMCallApprover
+// hooks; once approved, the run is not asked again until the estimate
+// doubles. Returns the estimate and false when the call was denied.
+func (a *AgentLoop) preflight(ctx context.Context, eventCh chan<- entity.AgentEvent, req *LLMRequest, policy ModelPolicy, approved *int) (entity.PreflightInfo, bool) {
+	threshold := a.configFor(ctx).PreflightTokens
+	if threshold <= 0 {
+		return entity.PreflightInfo{}, true
+	}
+	info := EstimateRequestCost(req, policy)
+	if info.InputTokens < threshold || info.InputTokens < 2*(*approved) {
+		return info, true
+	}
+
+	a.logger.Warn("Large LLM request",
+		zap.String("model", info.Model),
+		zap.Int("estimated_input_tokens", info.InputTokens),
+		zap.Float64("estimated_cost_usd", info.CostUSD),
+	)
+	a.emitEvent(eventCh, entity.AgentEvent{Type: entity.EventCostWarning, Preflight: &info})
+
+	if ap, ok := a.hooks.(LLMCallApprover); ok && !ap.ApproveLLMCall(ctx, info) {
+		return info, false
+	}
+	*approved = info.InputTokens
+	return info, true
+}
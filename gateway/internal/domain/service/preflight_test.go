@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+// preflightTestLLM answers every call immediately and counts them.
+type preflightTestLLM struct {
+	calls int
+}
+
+func (l *preflightTestLLM) Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error) {
+	l.calls++
+	return &LLMResponse{Content: "summary", ModelUsed: req.Model}, nil
+}
+
+func (l *preflightTestLLM) GenerateStream(ctx context.Context, req *LLMRequest, deltaCh chan<- StreamChunk) (*LLMResponse, error) {
+	close(deltaCh)
+	return l.Generate(ctx, req)
+}
+
+type preflightHook struct {
+	NoOpHook
+	approve bool
+	asked   []entity.PreflightInfo
+}
+
+func (h *preflightHook) ApproveLLMCall(_ context.Context, info entity.PreflightInfo) bool {
+	h.asked = append(h.asked, info)
+	return h.approve
+}
+
+func runPreflight(t *testing.T, hook *preflightHook, message string) (*preflightTestLLM, *AgentResult, []entity.AgentEvent) {
+	t.Helper()
+	llm := &preflightTestLLM{}
+	cfg := DefaultAgentLoopConfig()
+	cfg.Model = "claude-sonnet"
+	cfg.PreflightTokens = 1000
+	price := 3.0
+	cfg.ModelPolicies = map[string]*ModelPolicyOverride{"claude": {InputPrice: &price}}
+	loop := NewAgentLoop(llm, abortTestTools{}, cfg, zap.NewNop())
+	loop.SetHooks(NewHookChain(hook))
+
+	result, eventCh := loop.Run(context.Background(), "", message, nil, "")
+	var events []entity.AgentEvent
+	for ev := range eventCh {
+		events = append(events, ev)
+	}
+	return llm, result, events
+}
+
+func TestPreflight_DeniedLargeRequestIsNotSent(t *testing.T) {
+	hook := &preflightHook{approve: false}
+	llm, result, events := runPreflight(t, hook, strings.Repeat("log line 42 ", 1000))
+
+	if llm.calls != 0 {
+		t.Errorf("denied request reached the model (%d calls)", llm.calls)
+	}
+	if result.AbortReason != AbortDenied {
+		t.Errorf("AbortReason = %q, want denied", result.AbortReason)
+	}
+	if len(hook.asked) != 1 || hook.asked[0].InputTokens < 1000 || hook.asked[0].CostUSD <= 0 {
+		t.Errorf("approval not asked with an estimate: %+v", hook.asked)
+	}
+	var warned bool
+	for _, ev := range events {
+		if ev.Type == entity.EventCostWarning && ev.Preflight != nil && ev.Preflight.Model == "claude-sonnet" {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Error("no cost warning event")
+	}
+}
+
+func TestPreflight_SmallOrApprovedRequestsRun(t *testing.T) {
+	hook := &preflightHook{approve: true}
+	llm, result, _ := runPreflight(t, hook, strings.Repeat("log line 42 ", 1000))
+	if llm.calls != 1 || result.AbortReason != AbortNone || len(hook.asked) != 1 {
+		t.Errorf("approved: calls=%d abort=%q asked=%d", llm.calls, result.AbortReason, len(hook.asked))
+	}
+
+	hook = &preflightHook{approve: false}
+	llm, _, _ = runPreflight(t, hook, "hi")
+	if llm.calls != 1 || len(hook.asked) != 0 {
+		t.Errorf("small request: calls=%d asked=%d", llm.calls, len(hook.asked))
+	}
+}
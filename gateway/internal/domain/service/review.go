@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+// ReviewPolicy configures the post-run self-review: after the loop has a
+// final answer, a cheap model checks it against the original request and
+// the tool outputs. Low scores get the reviewer's caveats appended; very
+// low scores send the answer back for one corrective iteration.
+type ReviewPolicy struct {
+	Model        string // reviewer model
+	RetryBelow   int    // scores below this trigger the corrective iteration (0 = 4)
+	CaveatBelow  int    // scores below this append caveats (0 = 7)
+	ToolRunsOnly bool   // review only runs that called tools
+}
+
+const (
+	defaultReviewRetryBelow  = 4
+	defaultReviewCaveatBelow = 7
+	// reviewEvidenceChars bounds the tool output shown to the reviewer, taken
+	// from the most recent results; reviewAnswerChars bounds the answer.
+	reviewEvidenceChars = 12000
+	reviewToolChars     = 2000
+	reviewAnswerChars   = 8000
+)
+
+// SetReviewPolicy enables the post-run self-review for subsequent runs
+// (nil disables).
+func (a *AgentLoop) SetReviewPolicy(p *ReviewPolicy) {
+	a.review = p
+}
+
+const reviewPrompt = `You review an AI assistant's final answer before it is delivered. Check it against the user's request and the tool outputs the assistant saw.
+- answered: does it actually answer the request (not just describe a plan or ask to continue)?
+- grounded: are its factual claims (file contents, command results, numbers, quotes) backed by the tool outputs? Answers that need no tools count as grounded unless they contain clear errors.
+- score: overall quality from 1 (wrong or useless) to 10 (complete and correct).
+- issues: concrete problems, written for the assistant, e.g. "claims tests pass but run_tests failed".
+- caveats: short notes for the user about what to double-check, in the language of the answer.
+Reply with JSON only: {"score": 1-10, "answered": true|false, "grounded": true|false, "issues": [...], "caveats": [...]}`
+
+// reviewAnswer runs the reviewer on the final answer. It returns nil when
+// the review was skipped or failed; the answer is then delivered as is.
+func (a *AgentLoop) reviewAnswer(ctx context.Context, p *ReviewPolicy, userMessage string, messages []LLMMessage, answer string, sm *StateMachine, result *AgentResult) *entity.RunReview {
+	var sb strings.Builder
+	sb.WriteString("<request>\n" + userMessage + "\n</request>\n\n")
+	if evidence := reviewEvidence(messages); evidence != "" {
+		sb.WriteString("<tool_outputs>\n" + evidence + "</tool_outputs>\n\n")
+	}
+	sb.WriteString("<answer>\n" + truncateRunes(answer, reviewAnswerChars) + "\n</answer>")
+
+	resp, err := a.llm.Generate(ctx, &LLMRequest{
+		Messages: []LLMMessage{
+			{Role: "system", Content: reviewPrompt},
+			{Role: "user", Content: sb.String()},
+		},
+		Model:     p.Model,
+		MaxTokens: 800,
+	})
+	if err != nil {
+		a.logger.Warn("Self-review failed, delivering unreviewed", zap.String("model", p.Model), zap.Error(err))
+		return nil
+	}
+	result.TotalTokens += resp.TokensUsed
+	sm.AddTokens(resp.TokensUsed)
+
+	review, ok := parseReview(resp.Content)
+	if !ok {
+		a.logger.Warn("Self-review returned no verdict", zap.String("model", p.Model))
+		return nil
+	}
+	review.Model = p.Model
+	if review.Model == "" {
+		review.Model = resp.ModelUsed
+	}
+
+	retryBelow, caveatBelow := p.RetryBelow, p.CaveatBelow
+	if retryBelow <= 0 {
+		retryBelow = defaultReviewRetryBelow
+	}
+	if caveatBelow <= 0 {
+		caveatBelow = defaultReviewCaveatBelow
+	}
+	switch {
+	case review.Score < retryBelow && len(review.Issues) > 0:
+		review.Action = entity.ReviewRetry
+	case review.Score < caveatBelow && (len(review.Caveats) > 0 || len(review.Issues) > 0):
+		review.Action = entity.ReviewCaveat
+		if len(review.Caveats) == 0 {
+			review.Caveats = review.Issues
+		}
+	default:
+		review.Action = entity.ReviewAccept
+	}
+	a.logger.Info("Self-review finished",
+		zap.String("model", review.Model),
+		zap.Int("score", review.Score),
+		zap.Bool("answered", review.Answered),
+		zap.Bool("grounded", review.Grounded),
+		zap.String("action", review.Action),
+	)
+	return review
+}
+
+// parseReview reads the reviewer's JSON verdict, tolerating code fences and
+// text around it.
+func parseReview(content string) (*entity.RunReview, bool) {
+	text := StripReasoningTags(content)
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end <= start {
+		return nil, false
+	}
+	var review entity.RunReview
+	if err := json.Unmarshal([]byte(text[start:end+1]), &review); err != nil {
+		return nil, false
+	}
+	if review.Score < 1 || review.Score > 10 {
+		return nil, false
+	}
+	return &review, true
+}
+
+// reviewEvidence collects the most recent tool outputs of the run, newest
+// last, within reviewEvidenceChars.
+func reviewEvidence(messages []LLMMessage) string {
+	names := make(map[string]string) // tool call ID → tool name
+	for _, m := range messages {
+		for _, tc := range m.ToolCalls {
+			names[tc.ID] = tc.Name
+		}
+	}
+	var blocks []string
+	total := 0
+	for i := len(messages) - 1; i >= 0 && total < reviewEvidenceChars; i-- {
+		m := messages[i]
+		if m.Role != "tool" {
+			continue
+		}
+		name := names[m.ToolCallID]
+		if name == "" {
+			name = m.Name
+		}
+		block := fmt.Sprintf("[%s]\n%s\n", name, truncateRunes(m.TextContent(), reviewToolChars))
+		total += len(block)
+		blocks = append(blocks, block)
+	}
+	// Oldest first, as the assistant saw them
+	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+	return strings.Join(blocks, "\n")
+}
+
+// caveatNote is the text appended to the answer for the reviewer's caveats.
+// The answer has been streamed already, so it is kept as is and the note
+// starts with the newlines that put it in a paragraph of its own.
+func caveatNote(answer, lang string, caveats []string) string {
+	trailing := len(answer) - len(strings.TrimRight(answer, "\n"))
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat("\n", max(0, 2-trailing)))
+	sb.WriteString(nudge(lang, "review.caveats"))
+	for _, c := range caveats {
+		sb.WriteString("\n- " + strings.TrimSpace(c))
+	}
+	return sb.String()
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+// reviewTestLLM runs one tool call and answers; the reviewer model returns
+// verdicts in order.
+type reviewTestLLM struct {
+	verdicts []string
+	reviews  []string // reviewer inputs
+	steps    int
+	last     []LLMMessage
+}
+
+func (l *reviewTestLLM) Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error) {
+	if req.Model == "reviewer" {
+		l.reviews = append(l.reviews, req.Messages[1].Content)
+		v := l.verdicts[0]
+		l.verdicts = l.verdicts[1:]
+		return &LLMResponse{Content: v, TokensUsed: 10}, nil
+	}
+	l.steps++
+	l.last = req.Messages
+	switch l.steps {
+	case 1:
+		return &LLMResponse{ToolCalls: []entity.ToolCallInfo{{ID: "c1", Name: "run_tests"}}}, nil
+	case 2:
+		return &LLMResponse{Content: "All tests pass."}, nil
+	}
+	return &LLMResponse{Content: "Tests were run; the tool reported ok."}, nil
+}
+
+func (l *reviewTestLLM) GenerateStream(ctx context.Context, req *LLMRequest, deltaCh chan<- StreamChunk) (*LLMResponse, error) {
+	return l.Generate(ctx, req)
+}
+
+func TestAgentLoop_ReviewRetry(t *testing.T) {
+	llm := &reviewTestLLM{verdicts: []string{
+		"```json\n{\"score\": 2, \"answered\": true, \"grounded\": false, \"issues\": [\"claims all tests pass; output only says ok\"]}\n```",
+	}}
+	loop := NewAgentLoop(llm, selectorTestTools{}, DefaultAgentLoopConfig(), zap.NewNop())
+	loop.SetReviewPolicy(&ReviewPolicy{Model: "reviewer"})
+
+	result, eventCh := loop.Run(WithReplyLanguage(context.Background(), LangEN), "", "run the tests", nil, "m1")
+	for range eventCh {
+	}
+	if len(llm.reviews) != 1 || !strings.Contains(llm.reviews[0], "[run_tests]\nok") || !strings.Contains(llm.reviews[0], "All tests pass.") {
+		t.Fatalf("reviewer input = %q", llm.reviews)
+	}
+	if llm.steps != 3 || result.FinalContent != "Tests were run; the tool reported ok." {
+		t.Fatalf("steps = %d, FinalContent = %q", llm.steps, result.FinalContent)
+	}
+	if fix := llm.last[len(llm.last)-1].Content; !strings.Contains(fix, "- claims all tests pass") {
+		t.Errorf("corrective message = %q", fix)
+	}
+	if r := result.Review; r == nil || r.Score != 2 || r.Action != entity.ReviewRetry || r.Model != "reviewer" {
+		t.Errorf("Review = %+v", r)
+	}
+}
+
+func TestAgentLoop_ReviewCaveat(t *testing.T) {
+	llm := &reviewTestLLM{verdicts: []string{
+		`{"score": 6, "answered": true, "grounded": true, "issues": ["no counts"], "caveats": ["Check the test count yourself."]}`,
+	}}
+	loop := NewAgentLoop(llm, selectorTestTools{}, DefaultAgentLoopConfig(), zap.NewNop())
+	loop.SetReviewPolicy(&ReviewPolicy{Model: "reviewer"})
+
+	result, eventCh := loop.Run(WithReplyLanguage(context.Background(), LangEN), "", "run the tests", nil, "m1")
+	var streamed strings.Builder
+	for ev := range eventCh {
+		if ev.Type == entity.EventTextDelta {
+			streamed.WriteString(ev.Content)
+		}
+	}
+	want := "All tests pass.\n\n⚠️ Reviewer notes:\n- Check the test count yourself."
+	if result.FinalContent != want || !strings.HasSuffix(streamed.String(), want[len("All tests pass."):]) {
+		t.Errorf("FinalContent = %q, streamed = %q", result.FinalContent, streamed.String())
+	}
+	if result.Review == nil || result.Review.Action != entity.ReviewCaveat || llm.steps != 2 {
+		t.Errorf("Review = %+v, steps = %d", result.Review, llm.steps)
+	}
+}
+
+func TestCaveatNote(t *testing.T) {
+	caveats := []string{" Check the test count yourself. "}
+	note := "⚠️ Reviewer notes:\n- Check the test count yourself."
+	for _, tc := range []struct {
+		answer, want string
+	}{
+		{"All tests pass.", "\n\n" + note},
+		{"All tests pass.\n", "\n" + note},
+		{"All tests pass.\n\n", note},
+		{"All tests pass.\n\n\n\n", note},
+	} {
+		if got := caveatNote(tc.answer, LangEN, caveats); got != tc.want {
+			t.Errorf("caveatNote(%q) = %q, want %q", tc.answer, got, tc.want)
+		}
+	}
+}
+
+func TestParseReview(t *testing.T) {
+	if _, ok := parseReview("looks fine"); ok {
+		t.Error("no JSON accepted")
+	}
+	if _, ok := parseReview(`{"score": 11}`); ok {
+		t.Error("out-of-range score accepted")
+	}
+	r, ok := parseReview("<think>hmm</think>Verdict: {\"score\": 8, \"answered\": true, \"grounded\": true}")
+	if !ok || r.Score != 8 || !r.Answered {
+		t.Errorf("parseReview = %+v, %v", r, ok)
+	}
+}
@@ -50,6 +50,105 @@ func sanitizeMessages(messages []LLMMessage) []LLMMessage {
 	return result
 }
 
+// Placeholders inserted by enforceTurnOrdering.
+const (
+	placeholderUserTurn   = "(continue)"
+	placeholderToolOutput = "(no output)"
+)
+
+// enforceTurnOrdering rewrites the history into the shape strict providers
+// (Anthropic, Gemini, some Qwen endpoints) accept. Applied right before the
+// request is handed to the provider when ModelPolicy.EnforceTurnOrdering is set:
+//   - empty user/assistant/system messages are dropped, empty tool results get a placeholder
+//   - consecutive user messages are merged into one
+//   - a placeholder user turn is inserted before a leading assistant message
+//     and between two consecutive assistant messages
+//
+// It returns the number of messages rewritten, added or dropped.
+func enforceTurnOrdering(messages []LLMMessage) ([]LLMMessage, int) {
+	changes := 0
+	out := make([]LLMMessage, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Role == "tool" {
+			if strings.TrimSpace(msg.Content) == "" && len(msg.Parts) == 0 {
+				msg.Content = placeholderToolOutput
+				changes++
+			}
+			out = append(out, msg)
+			continue
+		}
+		if isEmptyMessage(msg) {
+			changes++
+			continue
+		}
+
+		prev := lastTurn(out)
+		switch {
+		case msg.Role == "user" && prev != nil && prev.Role == "user":
+			*prev = mergeUserMessages(*prev, msg)
+			changes++
+			continue
+		case msg.Role == "assistant" && (prev == nil || prev.Role == "assistant"):
+			out = append(out, LLMMessage{Role: "user", Content: placeholderUserTurn})
+			changes++
+		}
+		out = append(out, msg)
+	}
+	if changes == 0 {
+		return messages, 0
+	}
+	return out, changes
+}
+
+// lastTurn returns the last non-system message, or nil if there is none.
+func lastTurn(messages []LLMMessage) *LLMMessage {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != "system" {
+			return &messages[i]
+		}
+	}
+	return nil
+}
+
+// isEmptyMessage reports whether a non-tool message carries no content at all.
+func isEmptyMessage(msg LLMMessage) bool {
+	if len(msg.ToolCalls) > 0 {
+		return false
+	}
+	for _, p := range msg.Parts {
+		if p.Type != "text" || strings.TrimSpace(p.Text) != "" {
+			return false
+		}
+	}
+	return strings.TrimSpace(msg.Content) == ""
+}
+
+// mergeUserMessages joins two user messages, keeping multimodal parts.
+func mergeUserMessages(a, b LLMMessage) LLMMessage {
+	merged := a
+	switch {
+	case a.Content == "":
+		merged.Content = b.Content
+	case b.Content != "":
+		merged.Content = a.Content + "\n\n" + b.Content
+	}
+	if len(a.Parts) > 0 || len(b.Parts) > 0 {
+		merged.Parts = append(append([]ContentPart{}, asParts(a)...), asParts(b)...)
+	}
+	return merged
+}
+
+// asParts returns msg as content parts (plain Content becomes one text part).
+func asParts(msg LLMMessage) []ContentPart {
+	if len(msg.Parts) > 0 {
+		return msg.Parts
+	}
+	if msg.Content == "" {
+		return nil
+	}
+	return []ContentPart{{Type: "text", Text: msg.Content}}
+}
+
 // truncateOutput trims tool output to maxChars, appending a notice if truncated
 func truncateOutput(output string, maxChars int) string {
 	if maxChars <= 0 || len(output) <= maxChars {
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+)
+
+func TestEnforceTurnOrdering(t *testing.T) {
+	messages := []LLMMessage{
+		{Role: "system", Content: "sys"},
+		{Role: "assistant", Content: "resumed summary"},
+		{Role: "user", Content: "first"},
+		{Role: "user", Content: "  "},
+		{Role: "user", Content: "second", Parts: []ContentPart{{Type: "image", MediaURL: "http://x/a.png"}}},
+		{Role: "assistant", ToolCalls: []entity.ToolCallInfo{{ID: "tc_1", Name: "bash"}}},
+		{Role: "tool", ToolCallID: "tc_1", Content: ""},
+		{Role: "assistant", Content: "done"},
+		{Role: "assistant", Content: "also"},
+	}
+
+	out, changes := enforceTurnOrdering(messages)
+	wantRoles := []string{"system", "user", "assistant", "user", "assistant", "tool", "assistant", "user", "assistant"}
+	if len(out) != len(wantRoles) {
+		t.Fatalf("got %d messages: %+v", len(out), out)
+	}
+	for i, role := range wantRoles {
+		if out[i].Role != role {
+			t.Fatalf("message %d role = %s, want %s", i, out[i].Role, role)
+		}
+	}
+	if changes != 5 {
+		t.Errorf("changes = %d, want 5", changes)
+	}
+	if out[1].Content != placeholderUserTurn || out[7].Content != placeholderUserTurn {
+		t.Error("placeholder user turns missing")
+	}
+	merged := out[3]
+	if merged.Content != "first\n\nsecond" || len(merged.Parts) != 2 || merged.Parts[0].Text != "first" {
+		t.Errorf("merged user message = %+v", merged)
+	}
+	if out[5].Content != placeholderToolOutput {
+		t.Errorf("empty tool result = %q", out[5].Content)
+	}
+	if messages[4].Parts[0].Type != "image" || len(messages[4].Parts) != 1 {
+		t.Error("input messages must not be mutated")
+	}
+
+	// 已经合规的历史原样返回
+	ok := []LLMMessage{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "hello"}}
+	if _, changes := enforceTurnOrdering(ok); changes != 0 {
+		t.Errorf("well-formed history changed %d times", changes)
+	}
+}
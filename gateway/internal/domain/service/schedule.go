@@ -0,0 +1,87 @@
+package service
+
+import (
+	"fmt"
+	"time"
+)
+
+// Catch-up modes for runs missed while the gateway was down
+const (
+	CatchUpSkip = "skip" // drop them, continue with the next regular run
+	CatchUpOnce = "once" // run once right away
+	CatchUpAll  = "all"  // run each missed occurrence, up to CatchUpPolicy.Max
+)
+
+// maxMissedCount bounds the walk over missed occurrences (a per-minute job
+// after a week offline); the count is reported as at least this many.
+const maxMissedCount = 10000
+
+// CatchUpPolicy decides how many missed runs are made up at startup.
+type CatchUpPolicy struct {
+	Mode string // skip / once / all; empty = once
+	Max  int    // cap for "all" (default 10)
+}
+
+// ValidCatchUp reports whether mode is a known catch-up mode ("" included).
+func ValidCatchUp(mode string) bool {
+	switch mode {
+	case "", CatchUpSkip, CatchUpOnce, CatchUpAll:
+		return true
+	}
+	return false
+}
+
+// With returns the policy with mode overridden by a per-job setting.
+func (p CatchUpPolicy) With(mode string) CatchUpPolicy {
+	if mode != "" {
+		p.Mode = mode
+	}
+	return p
+}
+
+// Runs returns how many of missed runs to make up.
+func (p CatchUpPolicy) Runs(missed int) int {
+	if missed <= 0 {
+		return 0
+	}
+	switch p.Mode {
+	case CatchUpSkip:
+		return 0
+	case CatchUpAll:
+		limit := p.Max
+		if limit <= 0 {
+			limit = 10
+		}
+		return min(missed, limit)
+	default:
+		return 1
+	}
+}
+
+// MissedRuns counts the occurrences from next up to now, stepping with
+// nextAfter, and returns the first occurrence after now. A zero next means
+// the job was never scheduled and nothing was missed.
+func MissedRuns(next, now time.Time, nextAfter func(time.Time) time.Time) (int, time.Time) {
+	missed := 0
+	for !next.IsZero() && !next.After(now) {
+		missed++
+		following := nextAfter(next)
+		if !following.After(next) || missed >= maxMissedCount {
+			return missed, nextAfter(now)
+		}
+		next = following
+	}
+	return missed, next
+}
+
+// String is a short label for status views, e.g. "all (max 10)".
+func (p CatchUpPolicy) String() string {
+	mode := p.Mode
+	if mode == "" {
+		mode = CatchUpOnce
+	}
+	if mode == CatchUpAll && p.Max > 0 {
+		return fmt.Sprintf("%s (max %d)", mode, p.Max)
+	}
+	return mode
+}
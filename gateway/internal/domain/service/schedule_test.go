@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+func TestCatchUpPolicy_Runs(t *testing.T) {
+	cases := []struct {
+		policy CatchUpPolicy
+		missed int
+		want   int
+	}{
+		{CatchUpPolicy{Mode: CatchUpSkip}, 5, 0},
+		{CatchUpPolicy{Mode: CatchUpOnce}, 5, 1},
+		{CatchUpPolicy{}, 5, 1},
+		{CatchUpPolicy{Mode: CatchUpAll, Max: 3}, 5, 3},
+		{CatchUpPolicy{Mode: CatchUpAll, Max: 3}, 2, 2},
+		{CatchUpPolicy{Mode: CatchUpAll}, 50, 10},
+		{CatchUpPolicy{Mode: CatchUpAll}, 0, 0},
+	}
+	for _, c := range cases {
+		if got := c.policy.Runs(c.missed); got != c.want {
+			t.Errorf("%+v.Runs(%d) = %d, want %d", c.policy, c.missed, got, c.want)
+		}
+	}
+	if got := (CatchUpPolicy{Mode: CatchUpAll}).With(CatchUpSkip).Runs(3); got != 0 {
+		t.Errorf("per-job override ignored: %d", got)
+	}
+}
+
+func TestMissedRuns(t *testing.T) {
+	hourly := func(t time.Time) time.Time { return t.Add(time.Hour) }
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	missed, next := MissedRuns(start, start.Add(150*time.Minute), hourly)
+	if missed != 3 || !next.Equal(start.Add(3*time.Hour)) {
+		t.Errorf("missed=%d next=%v", missed, next)
+	}
+	missed, next = MissedRuns(start, start.Add(-time.Minute), hourly)
+	if missed != 0 || !next.Equal(start) {
+		t.Errorf("not due: missed=%d next=%v", missed, next)
+	}
+	if missed, _ := MissedRuns(time.Time{}, start, hourly); missed != 0 {
+		t.Errorf("never scheduled: missed=%d", missed)
+	}
+}
+
+// memScheduleRepo in-memory repository.ScheduleRepository
+type memScheduleRepo struct {
+	mu   sync.Mutex
+	rows map[string]entity.Schedule
+}
+
+func (r *memScheduleRepo) List(_ context.Context, kind string) ([]*entity.Schedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*entity.Schedule
+	for _, s := range r.rows {
+		if s.Kind == kind {
+			s := s
+			out = append(out, &s)
+		}
+	}
+	return out, nil
+}
+
+func (r *memScheduleRepo) Get(_ context.Context, id string) (*entity.Schedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.rows[id]
+	if !ok {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+func (r *memScheduleRepo) Save(_ context.Context, s *entity.Schedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rows[s.ID] = *s
+	return nil
+}
+
+func (r *memScheduleRepo) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.rows, id)
+	return nil
+}
+
+func TestHeartbeat_CatchUp(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "HEARTBEAT.md")
+	if err := os.WriteFile(file, []byte("# checks\ncheck disk\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	lastRun := now.Add(-210 * time.Minute) // 3 hourly runs missed
+
+	for _, tc := range []struct {
+		mode     string
+		wantRuns int
+	}{
+		{CatchUpSkip, 0},
+		{CatchUpOnce, 1},
+		{CatchUpAll, 3},
+	} {
+		t.Run(tc.mode, func(t *testing.T) {
+			repo := &memScheduleRepo{rows: map[string]entity.Schedule{
+				HeartbeatScheduleID: {ID: HeartbeatScheduleID, Kind: entity.ScheduleHeartbeat, Enabled: true,
+					LastRun: lastRun, NextRun: lastRun.Add(time.Hour), LastStatus: entity.ScheduleOK},
+			}}
+			h := NewHeartbeatService(HeartbeatConfig{
+				FilePath: file, Interval: time.Hour, ChatID: 7, Enabled: true,
+				CatchUp: CatchUpPolicy{Mode: tc.mode},
+			}, zap.NewNop())
+			h.SetStore(repo)
+			runs := 0
+			h.SetExecutor(func(_ context.Context, chatID int64, cmd string) (string, error) {
+				runs++
+				return "", errors.New("disk full")
+			})
+
+			wait := h.catchUp(now)
+			if runs != tc.wantRuns {
+				t.Errorf("runs = %d, want %d", runs, tc.wantRuns)
+			}
+			state := h.Status()
+			if state.Missed != 3 || state.ChatID != 7 {
+				t.Errorf("state = %+v", state)
+			}
+			if tc.wantRuns == 0 {
+				if state.LastStatus != entity.ScheduleSkipped || wait != 30*time.Minute {
+					t.Errorf("skip: status=%s wait=%v", state.LastStatus, wait)
+				}
+				return
+			}
+			if state.LastStatus != entity.ScheduleFailed || state.LastError != "check disk: disk full" {
+				t.Errorf("status=%s error=%q", state.LastStatus, state.LastError)
+			}
+			if wait != time.Hour || !state.NextRun.After(now) {
+				t.Errorf("wait=%v next=%v", wait, state.NextRun)
+			}
+		})
+	}
+}
+
+func TestHeartbeat_FirstStartRunsImmediately(t *testing.T) {
+	repo := &memScheduleRepo{rows: map[string]entity.Schedule{}}
+	h := NewHeartbeatService(HeartbeatConfig{FilePath: filepath.Join(t.TempDir(), "missing.md"), Interval: time.Hour, Enabled: true}, zap.NewNop())
+	h.SetStore(repo)
+	h.SetExecutor(func(context.Context, int64, string) (string, error) { return "", nil })
+
+	if wait := h.catchUp(time.Now()); wait != time.Hour {
+		t.Errorf("wait = %v", wait)
+	}
+	// 心跳文件不存在也记录一次运行, /cron status 里能看到原因
+	if s := h.Status(); s == nil || s.LastRun.IsZero() || s.LastStatus != entity.ScheduleFailed {
+		t.Errorf("state = %+v", s)
+	}
+}
@@ -4,11 +4,15 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"slices"
 	"strings"
 	"sync"
 
 	"go.uber.org/zap"
 
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
 )
 
@@ -23,10 +27,33 @@ type ApprovalFunc func(ctx context.Context, toolName string, args map[string]int
 type SecurityHook struct {
 	cfg          config.SecurityConfig
 	approvalFunc ApprovalFunc
+	guards       GuardSource
+	org          *OrgPolicy    // organization policy bundle, see SetOrgPolicy
+	workDir      func() string // resolves relative path arguments for guard rules
+	observer     func(ctx context.Context, d SecurityDecision)
 	logger       *zap.Logger
 	mu           sync.RWMutex
 }
 
+// SecurityDecision describes one approval round trip, reported to the
+// decision observer (e.g. the application event bus).
+type SecurityDecision struct {
+	Tool    string
+	Args    map[string]interface{}
+	Mode    string       // approval_mode in effect
+	Risk    *CommandRisk // nil unless shell risk analysis ran
+	Guard   string       // guard rule that blocked the call or required approval
+	Outcome string       // requested | approved | denied | blocked
+	Err     error        // approval channel failure (Outcome is denied)
+}
+
+// GuardSource provides the project guard rules. Rules returns nil when the
+// project has none, and an error when they cannot be loaded, in which case
+// every tool call is blocked.
+type GuardSource interface {
+	Rules() (*GuardRules, error)
+}
+
 // NewSecurityHook creates a SecurityHook with the given config and approval callback.
 func NewSecurityHook(cfg config.SecurityConfig, approvalFunc ApprovalFunc, logger *zap.Logger) *SecurityHook {
 	return &SecurityHook{
@@ -40,46 +67,178 @@ func NewSecurityHook(cfg config.SecurityConfig, approvalFunc ApprovalFunc, logge
 
 func (h *SecurityHook) BeforeToolCall(ctx context.Context, toolName string, args map[string]interface{}) bool {
 	h.mu.RLock()
-	cfg := h.cfg
+	cfg := h.config()
 	h.mu.RUnlock()
 
-	// 1. Auto mode — always allow
-	if cfg.ApprovalMode == "auto" {
-		return true
+	// 0. Organization and project guard rules — hard boundaries, checked before the approval policy
+	guard, err := h.matchGuard(toolName, args)
+	if err != nil {
+		h.logger.Warn("Tool call blocked, guard rules unavailable",
+			zap.String("tool", toolName),
+			zap.Error(err),
+		)
+		SetVetoReason(ctx, err.Error())
+		h.notify(ctx, SecurityDecision{Tool: toolName, Args: args, Mode: cfg.ApprovalMode, Outcome: "blocked", Err: err})
+		return false
+	}
+	if guard != nil && guard.Action == GuardDeny {
+		h.logger.Info("Tool call blocked by guard rule",
+			zap.String("tool", toolName),
+			zap.String("rule", guard.Name),
+		)
+		SetVetoReason(ctx, guard.Describe())
+		h.notify(ctx, SecurityDecision{Tool: toolName, Args: args, Mode: cfg.ApprovalMode, Guard: guard.Name, Outcome: "blocked"})
+		return false
 	}
 
-	// 2. Trusted tools — always allow (highest priority)
-	if h.isTrusted(toolName, args, cfg) {
+	// 1. Shell command risk analysis — may upgrade or downgrade the requirement
+	var risk *CommandRisk
+	if cfg.RiskAnalysis && isShellTool(toolName) {
+		if cmd, _ := args["command"].(string); strings.TrimSpace(cmd) != "" {
+			risk = AnalyzeCommand(cmd)
+			ctx = WithCommandRisk(ctx, risk)
+		}
+	}
+
+	if guard != nil && guard.Action == GuardApprove {
+		return h.requestGuardApproval(ctx, toolName, args, cfg.ApprovalMode, risk, guard)
+	}
+	if !h.needsApproval(toolName, args, cfg, risk) {
 		return true
 	}
+	return h.requestApproval(ctx, toolName, args, cfg.ApprovalMode, risk)
+}
 
-	// 3. ask_dangerous — only ask for tools in the dangerous list
-	if cfg.ApprovalMode == "ask_dangerous" {
-		if !h.isDangerous(toolName, cfg) {
-			return true
+// SetGuards installs the project guard rules. workDir returns the directory
+// relative path arguments are resolved against (nil = the project root).
+func (h *SecurityHook) SetGuards(src GuardSource, workDir func() string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.guards = src
+	h.workDir = workDir
+}
+
+// matchGuard returns the guard rule deciding the call, or nil.
+func (h *SecurityHook) matchGuard(toolName string, args map[string]interface{}) (*GuardRule, error) {
+	h.mu.RLock()
+	src, workDir, org := h.guards, h.workDir, h.org
+	h.mu.RUnlock()
+	dir := ""
+	if workDir != nil && (src != nil || org != nil) {
+		dir = workDir()
+	}
+	// Organization rules come first: the project cannot allow what they deny
+	if org != nil {
+		if rule := org.Guards.Match(toolName, args, dir); rule != nil {
+			return rule, nil
 		}
 	}
-	// ask_all falls through — every non-trusted tool needs approval
+	if src == nil {
+		return nil, nil
+	}
+	rules, err := src.Rules()
+	if err != nil {
+		return nil, err
+	}
+	return rules.Match(toolName, args, dir), nil
+}
+
+// GuardAllows reports whether the guard rules let toolName run with args
+// without asking: the rules load and none that matches denies the call or
+// requires approval. Used to vet what is read on the user's behalf outside
+// a tool call, such as files mentioned in a message.
+func (h *SecurityHook) GuardAllows(toolName string, args map[string]interface{}) bool {
+	rule, err := h.matchGuard(toolName, args)
+	return err == nil && (rule == nil || rule.Action == GuardAllow)
+}
+
+// requestGuardApproval asks for confirmation required by a guard rule. Unlike
+// the approval policy this applies in auto mode too, and without an approval
+// channel the call is blocked instead of auto-approved.
+func (h *SecurityHook) requestGuardApproval(ctx context.Context, toolName string, args map[string]interface{}, mode string, risk *CommandRisk, guard *GuardRule) bool {
+	h.mu.RLock()
+	hasChannel := h.approvalFunc != nil
+	h.mu.RUnlock()
 
-	// 4. Request approval via Telegram
-	if h.approvalFunc == nil {
+	reason := fmt.Sprintf("Guard rule %q requires user approval", guard.Name)
+	if guard.Message != "" {
+		reason += ": " + guard.Message
+	}
+	if !hasChannel {
+		h.logger.Warn("Guard rule requires approval but no approval channel is set, blocking",
+			zap.String("tool", toolName),
+			zap.String("rule", guard.Name),
+		)
+		SetVetoReason(ctx, reason+" (no approval channel available)")
+		h.notify(ctx, SecurityDecision{Tool: toolName, Args: args, Mode: mode, Risk: risk, Guard: guard.Name, Outcome: "blocked"})
+		return false
+	}
+	if !h.requestApproval(WithGuardRule(ctx, guard), toolName, args, mode, risk) {
+		SetVetoReason(ctx, reason+", and the user did not approve this call")
+		return false
+	}
+	return true
+}
+
+// ApproveLLMCall implements LLMCallApprover: in the ask_* modes a request
+// above the pre-flight threshold goes through the approval channel like a
+// tool call (tool name PreflightApprovalTool); auto mode only gets the warning.
+func (h *SecurityHook) ApproveLLMCall(ctx context.Context, info entity.PreflightInfo) bool {
+	h.mu.RLock()
+	mode := h.config().ApprovalMode
+	h.mu.RUnlock()
+	if mode == "auto" {
+		return true
+	}
+	args := map[string]interface{}{
+		"model":        info.Model,
+		"input_tokens": info.InputTokens,
+	}
+	if info.CostUSD > 0 {
+		args["cost_usd"] = math.Round(info.CostUSD*100) / 100
+	}
+	return h.requestApproval(ctx, PreflightApprovalTool, args, mode, nil)
+}
+
+// requestApproval asks the approval channel and reports the round trip to
+// the decision observer.
+func (h *SecurityHook) requestApproval(ctx context.Context, toolName string, args map[string]interface{}, mode string, risk *CommandRisk) bool {
+	h.mu.RLock()
+	approvalFunc := h.approvalFunc
+	h.mu.RUnlock()
+
+	// Request approval via Telegram (or the fallback channel)
+	if approvalFunc == nil {
 		h.logger.Warn("No approval function set, auto-approving",
 			zap.String("tool", toolName),
 		)
 		return true
 	}
 
-	h.logger.Info("Requesting user approval for tool",
+	fields := []zap.Field{
 		zap.String("tool", toolName),
-		zap.String("mode", cfg.ApprovalMode),
-	)
+		zap.String("mode", mode),
+	}
+	if risk != nil {
+		fields = append(fields, zap.String("risk", risk.Level.String()), zap.Strings("risk_reasons", risk.ReasonCodes()))
+	}
+	h.logger.Info("Requesting user approval for tool", fields...)
+
+	decision := SecurityDecision{Tool: toolName, Args: args, Mode: mode, Risk: risk, Outcome: "requested"}
+	if guard := GuardRuleFromContext(ctx); guard != nil {
+		decision.Guard = guard.Name
+	}
+	h.notify(ctx, decision)
 
-	approved, err := h.approvalFunc(ctx, toolName, args)
+	approved, err := approvalFunc(ctx, toolName, args)
+	decision.Outcome = "approved"
 	if err != nil {
 		h.logger.Error("Approval request failed",
 			zap.String("tool", toolName),
 			zap.Error(err),
 		)
+		decision.Outcome, decision.Err = "denied", err
+		h.notify(ctx, decision)
 		return false
 	}
 
@@ -87,11 +246,30 @@ func (h *SecurityHook) BeforeToolCall(ctx context.Context, toolName string, args
 		h.logger.Info("Tool call denied by user",
 			zap.String("tool", toolName),
 		)
+		decision.Outcome = "denied"
 	}
+	h.notify(ctx, decision)
 
 	return approved
 }
 
+// SetDecisionObserver registers a callback for approval requests and their
+// outcomes. Calls that need no approval are not reported.
+func (h *SecurityHook) SetDecisionObserver(fn func(ctx context.Context, d SecurityDecision)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.observer = fn
+}
+
+func (h *SecurityHook) notify(ctx context.Context, d SecurityDecision) {
+	h.mu.RLock()
+	fn := h.observer
+	h.mu.RUnlock()
+	if fn != nil {
+		fn(ctx, d)
+	}
+}
+
 func (h *SecurityHook) AfterToolCall(_ context.Context, _ string, _ string, _ bool) {}
 func (h *SecurityHook) BeforeLLMCall(_ context.Context, _ *LLMRequest, _ int)       {}
 func (h *SecurityHook) AfterLLMCall(_ context.Context, _ *LLMResponse, _ int)       {}
@@ -109,22 +287,154 @@ func (h *SecurityHook) SetApprovalFunc(fn ApprovalFunc) {
 
 // ---- Policy helpers ----
 
-// isTrusted checks if a tool/command is in the trust list.
-func (h *SecurityHook) isTrusted(toolName string, args map[string]interface{}, cfg config.SecurityConfig) bool {
+// needsApproval applies the approval policy. Order matters:
+//
+//	critical risk        → always ask (even in auto mode / trusted tools)
+//	auto mode            → allow
+//	trusted tool         → allow
+//	high risk            → ask (overrides trusted_commands and the dangerous list)
+//	trusted command      → allow
+//	ask_dangerous        → ask only for dangerous tools; read-only commands are allowed
+//	ask_all              → ask
+func (h *SecurityHook) needsApproval(toolName string, args map[string]interface{}, cfg config.SecurityConfig, risk *CommandRisk) bool {
+	if risk != nil && risk.Level >= RiskCritical {
+		return true
+	}
+	if cfg.ApprovalMode == "auto" {
+		return false
+	}
 	for _, t := range cfg.TrustedTools {
 		if t == toolName {
-			return true
+			return false
 		}
 	}
+	if risk != nil && risk.Level >= RiskHigh {
+		return true
+	}
+	if isShellTool(toolName) && h.isCommandTrusted(args, cfg) {
+		return false
+	}
+	if cfg.ApprovalMode == "ask_dangerous" {
+		if risk != nil && risk.Level == RiskSafe {
+			return false
+		}
+		if toolName == "remote_file" && isRemoteFileLookup(args) {
+			return false
+		}
+		if toolName == "sql_query" && !isSQLWriteConfirm(args) {
+			return false
+		}
+		if toolName == "terminal" && isTerminalControl(args) {
+			return false
+		}
+		return h.isDangerous(toolName, cfg)
+	}
+	// ask_all — every non-trusted tool needs approval
+	return true
+}
 
-	// For shell_exec, check if the command matches a trusted command prefix
-	if toolName == "shell_exec" {
-		return h.isCommandTrusted(args, cfg)
+// Approval requirements reported by ApprovalPolicy.
+const (
+	ApprovalNever   = "never"    // runs without confirmation
+	ApprovalAlways  = "always"   // every call asks for confirmation
+	ApprovalPerCall = "per_call" // depends on the arguments (command risk, trusted prefixes, read-only actions)
+)
+
+// ApprovalPolicy summarizes what needsApproval decides for toolName under cfg
+// without looking at any arguments. Used by the tool catalog export so that
+// external approval UIs know which tools can pause a run.
+func ApprovalPolicy(toolName string, cfg config.SecurityConfig) string {
+	shell := isShellTool(toolName)
+	// Critical commands ask in every mode when risk analysis is on.
+	fallback := ApprovalNever
+	if shell && cfg.RiskAnalysis {
+		fallback = ApprovalPerCall
+	}
+	if cfg.ApprovalMode == "auto" || slices.Contains(cfg.TrustedTools, toolName) {
+		return fallback
+	}
+
+	var base string
+	switch {
+	case cfg.ApprovalMode != "ask_dangerous":
+		base = ApprovalAlways
+	case !slices.Contains(cfg.DangerousTools, toolName):
+		base = ApprovalNever
+	case toolName == "remote_file" || toolName == "sql_query":
+		return ApprovalPerCall
+	default:
+		base = ApprovalAlways
 	}
+	if !shell {
+		return base
+	}
+	if base == ApprovalNever {
+		return fallback
+	}
+	// trusted_commands, and read-only commands under ask_dangerous, skip approval
+	if len(cfg.TrustedCommands) > 0 || (cfg.RiskAnalysis && cfg.ApprovalMode == "ask_dangerous") {
+		return ApprovalPerCall
+	}
+	return ApprovalAlways
+}
+
+// ApprovalPolicy reports the approval requirement for toolName under the
+// hook's current config. Guard rules that may require approval for the tool
+// turn "never" into "per_call".
+func (h *SecurityHook) ApprovalPolicy(toolName string) string {
+	h.mu.RLock()
+	cfg, src, org := h.config(), h.guards, h.org
+	h.mu.RUnlock()
+	policy := ApprovalPolicy(toolName, cfg)
+	if policy == ApprovalNever && org != nil && org.Guards.MayRequireApproval(toolName) {
+		return ApprovalPerCall
+	}
+	if policy == ApprovalNever && src != nil {
+		if rules, err := src.Rules(); err == nil && rules.MayRequireApproval(toolName) {
+			return ApprovalPerCall
+		}
+	}
+	return policy
+}
 
+// isShellTool reports whether the tool runs a shell command line in args["command"].
+// For terminal, command is also the text typed into a running program.
+func isShellTool(toolName string) bool {
+	switch toolName {
+	case "bash", "shell_exec", "bash_exec", "shell", "remote_exec", "terminal":
+		return true
+	}
+	return false
+}
+
+// isRemoteFileLookup reports whether a remote_file call only reads the remote
+// host (read/list). download is excluded because it writes a local file.
+func isRemoteFileLookup(args map[string]interface{}) bool {
+	switch args["action"] {
+	case "read", "list":
+		return true
+	}
 	return false
 }
 
+// isTerminalControl reports whether a terminal call only reads output or
+// stops/closes a session, i.e. sends no command text.
+func isTerminalControl(args map[string]interface{}) bool {
+	switch args["action"] {
+	case "read", "interrupt", "close", "list":
+		return true
+	}
+	return false
+}
+
+// isSQLWriteConfirm reports whether a sql_query call may execute a write
+// statement. Without confirm=true the tool only runs reads and returns the
+// EXPLAIN preview of writes.
+func isSQLWriteConfirm(args map[string]interface{}) bool {
+	confirm, _ := args["confirm"].(bool)
+	return confirm
+}
+
 // isDangerous checks if a tool is in the dangerous list.
 func (h *SecurityHook) isDangerous(toolName string, cfg config.SecurityConfig) bool {
 	for _, d := range cfg.DangerousTools {
@@ -170,11 +480,12 @@ func (h *SecurityHook) UpdateConfig(cfg config.SecurityConfig) {
 	h.cfg = cfg
 }
 
-// GetConfig returns the current security config.
+// GetConfig returns the security config in effect, with the organization
+// policy applied.
 func (h *SecurityHook) GetConfig() config.SecurityConfig {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
-	return h.cfg
+	return h.config()
 }
 
 // SetApprovalMode changes the approval mode ("auto", "ask_dangerous", "ask_all").
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// speechSummaryMaxInput bounds the reply text sent to the summary model.
+	speechSummaryMaxInput = 20000
+	// speechMinLimit is the shortest speech a limit may ask for.
+	speechMinLimit = 100
+)
+
+const speechSummaryPrompt = `You turn an assistant's written reply into a short spoken version for a voice message.
+- Write at most %d characters, in the language of the reply.
+- Plain sentences only: no Markdown, lists, tables, code, URLs or emoji. Mention that code or links are in the written reply instead of reading them.
+- Keep the answer, conclusions, numbers and next steps; drop the rest.
+- No preamble such as "Here is a summary".`
+
+var (
+	speechFenceRe  = regexp.MustCompile("(?s)```.*?(```|$)")
+	speechImageRe  = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	speechLinkRe   = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	speechURLRe    = regexp.MustCompile(`https?://\S+`)
+	speechTagRe    = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	speechMarkRe   = regexp.MustCompile("(\\*\\*|__|~~|`)")
+	speechPrefixRe = regexp.MustCompile(`(?m)^\s*(#{1,6}\s+|>\s?|[-*+]\s+|\d+[.)]\s+)`)
+	speechRuleRe   = regexp.MustCompile(`(?m)^\s*(\|?\s*:?-{3,}:?\s*)+\|?\s*$|^\s*([-*_]\s*){3,}$`)
+	speechSpaceRe  = regexp.MustCompile(`[ \t]+`)
+	speechBlankRe  = regexp.MustCompile(`\n{2,}`)
+)
+
+// SpeechText turns a Markdown reply into text that reads well aloud: code
+// blocks become a short note, link targets, URLs, HTML tags and Markdown
+// markup are dropped, and table cells are separated by commas.
+func SpeechText(markdown string) string {
+	cjk := hasHan(markdown)
+	note := " (code omitted) "
+	if cjk {
+		note = "（代码略）"
+	}
+	s := speechFenceRe.ReplaceAllString(markdown, note)
+	s = speechImageRe.ReplaceAllString(s, "$1")
+	s = speechLinkRe.ReplaceAllString(s, "$1")
+	s = speechURLRe.ReplaceAllString(s, "")
+	s = speechTagRe.ReplaceAllString(s, "")
+	s = speechRuleRe.ReplaceAllString(s, "")
+	s = speechPrefixRe.ReplaceAllString(s, "")
+	s = speechMarkRe.ReplaceAllString(s, "")
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if t := strings.TrimSpace(line); strings.HasPrefix(t, "|") && strings.HasSuffix(t, "|") {
+			cells := strings.Split(strings.Trim(t, "|"), "|")
+			for j := range cells {
+				cells[j] = strings.TrimSpace(cells[j])
+			}
+			sep := ", "
+			if cjk {
+				sep = "，"
+			}
+			line = strings.Join(cells, sep)
+		}
+		lines[i] = strings.TrimSpace(speechSpaceRe.ReplaceAllString(line, " "))
+	}
+	s = strings.Join(lines, "\n")
+	return strings.TrimSpace(speechBlankRe.ReplaceAllString(s, "\n"))
+}
+
+func hasHan(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// SpeechPreparer turns a final reply into the text of its voice message.
+type SpeechPreparer struct {
+	llm    LLMClient // nil = never summarize
+	model  string
+	logger *zap.Logger
+}
+
+// NewSpeechPreparer creates a preparer; model condenses long replies when
+// summaries are on.
+func NewSpeechPreparer(llm LLMClient, model string, logger *zap.Logger) *SpeechPreparer {
+	return &SpeechPreparer{llm: llm, model: model, logger: logger}
+}
+
+// Prepare returns what is read aloud for reply, at most limit characters.
+// A longer reply is condensed by the model when summarize is set; otherwise,
+// or when that fails, it is cut at the last sentence end within the limit.
+func (p *SpeechPreparer) Prepare(ctx context.Context, reply string, limit int, summarize bool) string {
+	limit = max(limit, speechMinLimit)
+	text := SpeechText(reply)
+	if len([]rune(text)) <= limit {
+		return text
+	}
+	if summarize && p.llm != nil {
+		summary, err := p.summarize(ctx, reply, limit)
+		if err == nil && summary != "" {
+			return cutSpeech(summary, limit)
+		}
+		p.logger.Info("Speech summary failed, truncating", zap.Error(err))
+	}
+	return cutSpeech(text, limit)
+}
+
+func (p *SpeechPreparer) summarize(ctx context.Context, reply string, limit int) (string, error) {
+	resp, err := p.llm.Generate(ctx, &LLMRequest{
+		Model: p.model,
+		Messages: []LLMMessage{
+			{Role: "system", Content: fmt.Sprintf(speechSummaryPrompt, limit)},
+			{Role: "user", Content: truncateRunes(reply, speechSummaryMaxInput)},
+		},
+		// A CJK character is about one token, so this leaves room either way
+		MaxTokens:   limit + 200,
+		Temperature: 0,
+	})
+	if err != nil {
+		return "", err
+	}
+	return SpeechText(StripReasoningTags(resp.Content)), nil
+}
+
+// cutSpeech shortens text to at most limit runes, at the last sentence end
+// when one falls in the second half, so the voice does not stop mid-word.
+func cutSpeech(text string, limit int) string {
+	r := []rune(text)
+	if len(r) <= limit {
+		return text
+	}
+	r = r[:limit]
+	for i := len(r) - 1; i >= limit/2; i-- {
+		switch r[i] {
+		case '。', '！', '？', '.', '!', '?', '\n':
+			return strings.TrimSpace(string(r[:i+1]))
+		}
+	}
+	return strings.TrimSpace(string(r)) + "…"
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestSpeechText(t *testing.T) {
+	md := "## 结论\n\n修复在 **agent_loop.go**，见 [PR](https://github.com/x/y/pull/1)：\n\n" +
+		"```go\nfunc main() {}\n```\n\n- 第一步 `go test`\n- 第二步 <b>重启</b>\n\n" +
+		"| 模型 | 耗时 |\n|---|---|\n| a | 3s |\n\n> 参考 https://example.com/doc"
+	want := "结论\n修复在 agent_loop.go，见 PR：\n（代码略）\n第一步 go test\n第二步 重启\n模型，耗时\na，3s\n参考"
+	if got := SpeechText(md); got != want {
+		t.Errorf("SpeechText =\n%q\nwant\n%q", got, want)
+	}
+	if got := SpeechText("Run:\n```\nmake\n```\nthen ship."); got != "Run:\n(code omitted)\nthen ship." {
+		t.Errorf("SpeechText = %q", got)
+	}
+}
+
+func TestSpeechPreparer_Prepare(t *testing.T) {
+	ctx := context.Background()
+	long := strings.Repeat("First sentence here. ", 6) + strings.Repeat("x", 200)
+
+	// Short replies are read as they are, long ones cut at a sentence end
+	p := NewSpeechPreparer(nil, "", zap.NewNop())
+	if got := p.Prepare(ctx, "**Done.**", 100, true); got != "Done." {
+		t.Errorf("short = %q", got)
+	}
+	if got := p.Prepare(ctx, long, 100, false); got != strings.TrimSpace(strings.Repeat("First sentence here. ", 4)) {
+		t.Errorf("cut = %q", got)
+	}
+
+	llm := &linkTestLLM{}
+	p = NewSpeechPreparer(llm, "cheap", zap.NewNop())
+	if got := p.Prepare(ctx, long, 100, false); strings.Contains(got, "gist") || len(llm.requests) != 0 {
+		t.Errorf("summary off = %q", got)
+	}
+	if got := p.Prepare(ctx, long, 100, true); got != "the gist" {
+		t.Errorf("summary = %q", got)
+	}
+	if len(llm.requests) != 1 || llm.requests[0].Model != "cheap" || !strings.Contains(llm.requests[0].Messages[0].Content, "at most 100 characters") {
+		t.Errorf("requests = %+v", llm.requests)
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+// SteeringQueue holds user messages received during an active run ("steer"
+// mode). The agent loop drains it at every step boundary and appends the
+// messages as user turns, so the run adjusts course instead of restarting.
+// After Close, Push fails and the caller starts a new run instead.
+type SteeringQueue struct {
+	mu       sync.Mutex
+	pending  []string
+	injected []string
+	closed   bool
+}
+
+// NewSteeringQueue creates an open queue for one run.
+func NewSteeringQueue() *SteeringQueue {
+	return &SteeringQueue{}
+}
+
+// Push queues a message for the next step boundary. Returns false once the
+// run has finished (the message must then be handled as a new run).
+func (q *SteeringQueue) Push(text string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return false
+	}
+	q.pending = append(q.pending, text)
+	return true
+}
+
+// Drain removes and returns the queued messages, recording them as injected.
+func (q *SteeringQueue) Drain() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := q.pending
+	q.pending = nil
+	q.injected = append(q.injected, out...)
+	return out
+}
+
+// Close ends the run's steering window and returns the messages that arrived
+// too late to be injected.
+func (q *SteeringQueue) Close() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	out := q.pending
+	q.pending = nil
+	return out
+}
+
+// Injected returns every message that was handed to the model during the run.
+func (q *SteeringQueue) Injected() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]string(nil), q.injected...)
+}
+
+// steeringMessages drains the run's queue (if any) into user turns.
+func steeringMessages(ctx context.Context) ([]LLMMessage, []string) {
+	q := SteeringFromContext(ctx)
+	if q == nil {
+		return nil, nil
+	}
+	texts := q.Drain()
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	// The prefix marks guidance that arrived while the run was working, so the
+	// model treats it as a course correction rather than a brand-new task.
+	prefix := nudge(ReplyLanguageFromContext(ctx), "steering")
+	msgs := make([]LLMMessage, 0, len(texts))
+	for _, text := range texts {
+		msgs = append(msgs, LLMMessage{Role: "user", Content: prefix + strings.TrimSpace(text)})
+	}
+	return msgs, texts
+}
+
+// emitSteering reports injected guidance to consumers (status line, transcript).
+func (a *AgentLoop) emitSteering(ch chan<- entity.AgentEvent, step int, texts []string) {
+	a.logger.Info("Steering messages injected",
+		zap.Int("step", step),
+		zap.Int("count", len(texts)),
+	)
+	for _, text := range texts {
+		a.emitEvent(ch, entity.AgentEvent{Type: entity.EventSteer, Content: text})
+	}
+}
+
+// --- Context keys ---
+
+type steeringKey struct{}
+
+// WithSteering attaches a steering queue to the run started with ctx.
+func WithSteering(ctx context.Context, q *SteeringQueue) context.Context {
+	return context.WithValue(ctx, steeringKey{}, q)
+}
+
+// SteeringFromContext returns the run's steering queue, or nil.
+func SteeringFromContext(ctx context.Context) *SteeringQueue {
+	q, _ := ctx.Value(steeringKey{}).(*SteeringQueue)
+	return q
+}
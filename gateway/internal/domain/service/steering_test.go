@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+func TestSteeringQueue(t *testing.T) {
+	q := NewSteeringQueue()
+	q.Push("a")
+	q.Push("b")
+	if got := q.Drain(); len(got) != 2 || got[0] != "a" {
+		t.Fatalf("Drain = %v", got)
+	}
+	if got := q.Drain(); got != nil {
+		t.Errorf("second Drain = %v", got)
+	}
+	q.Push("late")
+	if rest := q.Close(); len(rest) != 1 || rest[0] != "late" {
+		t.Errorf("Close = %v", rest)
+	}
+	if q.Push("after close") {
+		t.Error("Push after Close must fail")
+	}
+	if got := q.Injected(); len(got) != 2 {
+		t.Errorf("Injected = %v", got)
+	}
+}
+
+// steerTestLLM answers immediately; the user sends guidance while the first
+// answer is being generated.
+type steerTestLLM struct {
+	queue *SteeringQueue
+	calls int
+	seen  []LLMMessage
+}
+
+func (l *steerTestLLM) Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error) {
+	l.calls++
+	l.seen = req.Messages
+	if l.calls == 1 {
+		l.queue.Push("use metric units")
+		return &LLMResponse{Content: "It is 70F."}, nil
+	}
+	return &LLMResponse{Content: "It is 21C."}, nil
+}
+
+func (l *steerTestLLM) GenerateStream(ctx context.Context, req *LLMRequest, deltaCh chan<- StreamChunk) (*LLMResponse, error) {
+	return l.Generate(ctx, req)
+}
+
+func TestAgentLoop_SteeringBeforeFinish(t *testing.T) {
+	q := NewSteeringQueue()
+	llm := &steerTestLLM{queue: q}
+	loop := NewAgentLoop(llm, abortTestTools{}, DefaultAgentLoopConfig(), zap.NewNop())
+
+	result, eventCh := loop.Run(WithSteering(context.Background(), q), "", "weather?", nil, "")
+	var steered []string
+	for ev := range eventCh {
+		if ev.Type == entity.EventSteer {
+			steered = append(steered, ev.Content)
+		}
+	}
+
+	if llm.calls != 2 {
+		t.Fatalf("LLM calls = %d, want 2 (answer, then follow the guidance)", llm.calls)
+	}
+	if result.FinalContent != "It is 21C." {
+		t.Errorf("FinalContent = %q", result.FinalContent)
+	}
+	if len(steered) != 1 || steered[0] != "use metric units" {
+		t.Errorf("steer events = %v", steered)
+	}
+	last := llm.seen[len(llm.seen)-1]
+	if last.Role != "user" || !strings.HasSuffix(last.Content, "use metric units") {
+		t.Errorf("last message = %+v", last)
+	}
+	if prev := llm.seen[len(llm.seen)-2]; prev.Role != "assistant" || prev.Content != "It is 70F." {
+		t.Errorf("previous answer not kept as a turn: %+v", prev)
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"strings"
+)
+
+// Think levels set per session (TG /think). "" means the provider default.
+const (
+	ThinkOff    = "off"
+	ThinkLow    = "low"
+	ThinkMedium = "medium"
+	ThinkHigh   = "high"
+)
+
+// ThinkingControl values (ModelPolicy.ThinkingControl): which request
+// parameter a model exposes for reasoning depth.
+const (
+	ThinkingControlNone   = "none"   // no knob; the think level is ignored
+	ThinkingControlEffort = "effort" // reasoning_effort (OpenAI o-series, gpt-5)
+	ThinkingControlBudget = "budget" // thinking budget tokens (Anthropic, Gemini 2.5+)
+	ThinkingControlToggle = "toggle" // enable_thinking + thinking_budget (Qwen3 on DashScope)
+)
+
+// ThinkingBudgets maps think levels onto thinking token budgets.
+var ThinkingBudgets = map[string]int{ThinkLow: 1024, ThinkMedium: 8192, ThinkHigh: 24576}
+
+// ParseThinkLevel normalizes a user-supplied think level ("med" → "medium").
+func ParseThinkLevel(s string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "off", "none", "0":
+		return ThinkOff, true
+	case "low", "min":
+		return ThinkLow, true
+	case "medium", "med", "mid":
+		return ThinkMedium, true
+	case "high", "max":
+		return ThinkHigh, true
+	}
+	return "", false
+}
+
+// ThinkingBlock is a reasoning block returned by the provider that must be
+// sent back unchanged with the assistant turn it belongs to (Anthropic
+// extended thinking with tool use). Redacted holds the opaque data of a
+// redacted_thinking block.
+type ThinkingBlock struct {
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Redacted  string `json:"redacted,omitempty"`
+}
+
+// ApplyThinking maps a think level onto the request parameter this model
+// exposes. An empty level leaves the provider default.
+//
+//	effort — reasoning_effort; reasoning models cannot switch reasoning off,
+//	         so "off" uses the lowest effort
+//	budget — ThinkingBudget tokens; "off" disables thinking
+//	toggle — EnableThinking plus ThinkingBudget
+func (p ModelPolicy) ApplyThinking(req *LLMRequest, level string) {
+	if level == "" {
+		return
+	}
+	off := false
+	switch p.ThinkingControl {
+	case ThinkingControlEffort:
+		if level == ThinkOff {
+			level = ThinkLow
+		}
+		req.ReasoningEffort = level
+	case ThinkingControlBudget:
+		if level == ThinkOff {
+			req.EnableThinking = &off
+			return
+		}
+		req.ThinkingBudget = ThinkingBudgets[level]
+	case ThinkingControlToggle:
+		enabled := level != ThinkOff
+		req.EnableThinking = &enabled
+		if enabled {
+			req.ThinkingBudget = ThinkingBudgets[level]
+		}
+	}
+}
+
+// --- Context keys ---
+
+type thinkLevelKey struct{}
+
+// WithThinkLevel stores the session think level for this run.
+func WithThinkLevel(ctx context.Context, level string) context.Context {
+	return context.WithValue(ctx, thinkLevelKey{}, level)
+}
+
+// ThinkLevelFromContext returns the run's think level ("" if unset).
+func ThinkLevelFromContext(ctx context.Context) string {
+	level, _ := ctx.Value(thinkLevelKey{}).(string)
+	return level
+}
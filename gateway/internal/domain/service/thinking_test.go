@@ -0,0 +1,75 @@
+package service
+
+import "testing"
+
+func TestResolveModelPolicy_ThinkingControl(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"anthropic/claude-sonnet-4-20250514", ThinkingControlBudget},
+		{"claude-3-7-sonnet-latest", ThinkingControlBudget},
+		{"claude-3-5-haiku-latest", ThinkingControlNone},
+		{"google/gemini-2.5-flash", ThinkingControlBudget},
+		{"gemini-2.0-flash", ThinkingControlNone},
+		{"bailian/qwen3-max-2026-01-23", ThinkingControlToggle},
+		{"bailian/qwen3-coder-plus", ThinkingControlNone},
+		{"openai/o4-mini", ThinkingControlEffort},
+		{"gpt-5-mini", ThinkingControlEffort},
+		{"openai/gpt-4o", ThinkingControlNone},
+		{"deepseek-chat", ThinkingControlNone},
+	}
+	for _, tt := range tests {
+		if got := ResolveModelPolicy(tt.model, nil).ThinkingControl; got != tt.want {
+			t.Errorf("ThinkingControl(%q) = %q, want %q", tt.model, got, tt.want)
+		}
+	}
+
+	effort := ThinkingControlEffort
+	overrides := map[string]*ModelPolicyOverride{"my-reasoner": {ThinkingControl: &effort}}
+	if got := ResolveModelPolicy("vllm/my-reasoner", overrides).ThinkingControl; got != effort {
+		t.Errorf("override: ThinkingControl = %q", got)
+	}
+}
+
+func TestModelPolicy_ApplyThinking(t *testing.T) {
+	apply := func(control, level string) *LLMRequest {
+		req := &LLMRequest{}
+		ModelPolicy{ThinkingControl: control}.ApplyThinking(req, level)
+		return req
+	}
+
+	if r := apply(ThinkingControlEffort, ThinkHigh); r.ReasoningEffort != "high" {
+		t.Errorf("effort high: %+v", r)
+	}
+	if r := apply(ThinkingControlEffort, ThinkOff); r.ReasoningEffort != "low" {
+		t.Errorf("effort off should use the lowest effort: %+v", r)
+	}
+	if r := apply(ThinkingControlBudget, ThinkMedium); r.ThinkingBudget != 8192 || r.EnableThinking != nil {
+		t.Errorf("budget medium: %+v", r)
+	}
+	if r := apply(ThinkingControlBudget, ThinkOff); r.ThinkingBudget != 0 || r.EnableThinking == nil || *r.EnableThinking {
+		t.Errorf("budget off: %+v", r)
+	}
+	if r := apply(ThinkingControlToggle, ThinkLow); r.EnableThinking == nil || !*r.EnableThinking || r.ThinkingBudget != 1024 {
+		t.Errorf("toggle low: %+v", r)
+	}
+	if r := apply(ThinkingControlToggle, ThinkOff); r.EnableThinking == nil || *r.EnableThinking {
+		t.Errorf("toggle off: %+v", r)
+	}
+	if r := apply(ThinkingControlNone, ThinkHigh); r.ReasoningEffort != "" || r.ThinkingBudget != 0 || r.EnableThinking != nil {
+		t.Errorf("none: %+v", r)
+	}
+	if r := apply(ThinkingControlBudget, ""); r.ThinkingBudget != 0 || r.EnableThinking != nil {
+		t.Errorf("unset level: %+v", r)
+	}
+
+	for in, want := range map[string]string{"med": ThinkMedium, "OFF": ThinkOff, "high": ThinkHigh} {
+		if got, ok := ParseThinkLevel(in); !ok || got != want {
+			t.Errorf("ParseThinkLevel(%q) = %q, %v", in, got, ok)
+		}
+	}
+	if _, ok := ParseThinkLevel("extreme"); ok {
+		t.Error("ParseThinkLevel accepted an unknown level")
+	}
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"go.uber.org/zap"
+)
+
+// RequestToolName is the meta-tool through which the model asks for tools
+// that were left out of the current step.
+const RequestToolName = "request_tool"
+
+// ToolSelectorConfig controls per-step tool subsetting.
+type ToolSelectorConfig struct {
+	TopK   int      // definitions sent per step, besides request_tool (0 = 12)
+	Always []string // always sent, e.g. read_file and bash
+}
+
+// ToolSelector sends only the most relevant tool definitions each step
+// instead of the full set. Tools already used in the run, tools the model
+// pulled in with request_tool and the configured Always tools are always
+// sent; the remaining slots are ranked by the task intent, words of the
+// user's message and recent use in the conversation. Runs with no more than
+// TopK tools are left alone.
+type ToolSelector struct {
+	config ToolSelectorConfig
+	logger *zap.Logger
+}
+
+// NewToolSelector creates a selector.
+func NewToolSelector(config ToolSelectorConfig, logger *zap.Logger) *ToolSelector {
+	if config.TopK <= 0 {
+		config.TopK = 12
+	}
+	return &ToolSelector{config: config, logger: logger}
+}
+
+// SetToolSelector enables per-step tool subsetting for subsequent runs
+// (nil sends every tool each step).
+func (a *AgentLoop) SetToolSelector(s *ToolSelector) {
+	a.toolSelector = s
+}
+
+// intentToolHints are tool name fragments relevant to each prompt intent
+// (see prompt.AnalyzeIntent).
+var intentToolHints = map[string][]string{
+	"coding":   {"file", "edit", "patch", "grep", "glob", "test", "typecheck", "lint", "lsp", "symbol", "repo", "git", "commit", "deps", "terminal", "bash"},
+	"research": {"web", "search", "fetch", "research", "docs", "document", "browser"},
+	"system":   {"terminal", "bash", "shell", "file", "dir", "remote", "process", "sql"},
+	"finance":  {"web", "search", "fetch", "research", "sql", "terminal"},
+	"creative": {"file", "memory", "document"},
+	"general":  {"memory", "web", "search", "fetch"},
+}
+
+// toolSubset is the selection state of one run. request_tool calls run in
+// parallel with other tools, so it is guarded by a mutex.
+type toolSubset struct {
+	selector *ToolSelector
+	all      []domaintool.Definition
+	byName   map[string]domaintool.Definition
+	intent   string
+	recent   map[string]int // tool → uses in the conversation history
+
+	mu     sync.Mutex
+	pinned map[string]bool // Always + used in this run + requested
+}
+
+// begin prepares the selection for a run over defs. It returns nil when
+// subsetting does not pay off, in which case every tool is sent.
+func (s *ToolSelector) begin(defs []domaintool.Definition, intent string, history []LLMMessage) *toolSubset {
+	if s == nil || len(defs) <= s.config.TopK {
+		return nil
+	}
+	sub := &toolSubset{
+		selector: s,
+		all:      defs,
+		byName:   make(map[string]domaintool.Definition, len(defs)),
+		intent:   intent,
+		recent:   make(map[string]int),
+		pinned:   make(map[string]bool),
+	}
+	for _, d := range defs {
+		sub.byName[d.Name] = d
+	}
+	for _, name := range s.config.Always {
+		if _, ok := sub.byName[name]; ok {
+			sub.pinned[name] = true
+		}
+	}
+	for _, m := range history {
+		for _, tc := range m.ToolCalls {
+			sub.recent[tc.Name]++
+		}
+	}
+	return sub
+}
+
+// definitions returns the tools to send for the next request: the pinned
+// ones, then the best-scoring others up to TopK, then request_tool listing
+// what was left out.
+func (t *toolSubset) definitions(messages []LLMMessage) []domaintool.Definition {
+	words := requestWords(messages)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	type scored struct {
+		def   domaintool.Definition
+		score int
+	}
+	var picked []domaintool.Definition
+	var rest []scored
+	for _, d := range t.all {
+		if t.pinned[d.Name] {
+			picked = append(picked, d)
+			continue
+		}
+		rest = append(rest, scored{def: d, score: t.score(d, words)})
+	}
+	sort.SliceStable(rest, func(i, j int) bool { return rest[i].score > rest[j].score })
+
+	var omitted []string
+	for _, r := range rest {
+		if len(picked) < t.selector.config.TopK {
+			picked = append(picked, r.def)
+		} else {
+			omitted = append(omitted, r.def.Name)
+		}
+	}
+	if len(omitted) == 0 {
+		return picked
+	}
+	sort.Strings(omitted)
+	return append(picked, requestToolDefinition(omitted))
+}
+
+// score ranks a tool for the current request. The name counts most: a tool
+// named in the message ("use sql_query") or matching its words wins.
+func (t *toolSubset) score(d domaintool.Definition, words map[string]bool) int {
+	score := 0
+	if words[strings.ToLower(d.Name)] {
+		score += 20
+	}
+	for _, part := range strings.Split(strings.ToLower(d.Name), "_") {
+		if len(part) >= 3 && words[part] {
+			score += 4
+		}
+	}
+	matches := 0
+	for _, w := range splitWords(firstSentence(d.Description)) {
+		if len(w) >= 4 && words[w] {
+			matches++
+		}
+	}
+	score += min(matches, 3)
+	for _, hint := range intentToolHints[t.intent] {
+		if strings.Contains(d.Name, hint) {
+			score += 3
+			break
+		}
+	}
+	if n := t.recent[d.Name]; n > 0 {
+		score += 3 + min(n, 3)
+	}
+	return score
+}
+
+// use pins a tool the model called, so it stays available for the rest of
+// the run.
+func (t *toolSubset) use(name string) {
+	if t == nil {
+		return
+	}
+	if _, ok := t.byName[name]; !ok {
+		return
+	}
+	t.mu.Lock()
+	t.pinned[name] = true
+	t.mu.Unlock()
+}
+
+// request handles a request_tool call.
+func (t *toolSubset) request(args map[string]interface{}) (string, bool) {
+	var names []string
+	switch v := args["names"].(type) {
+	case []interface{}:
+		for _, n := range v {
+			if s, ok := n.(string); ok {
+				names = append(names, s)
+			}
+		}
+	case string:
+		names = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+	}
+	if name, ok := args["name"].(string); ok && name != "" {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return "names is required", false
+	}
+
+	var added, unknown []string
+	t.mu.Lock()
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if _, ok := t.byName[name]; !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		t.pinned[name] = true
+		added = append(added, name)
+	}
+	t.mu.Unlock()
+
+	t.selector.logger.Info("Tools requested by model",
+		zap.Strings("added", added),
+		zap.Strings("unknown", unknown),
+	)
+	var sb strings.Builder
+	if len(added) > 0 {
+		fmt.Fprintf(&sb, "Added %s. Call them from your next step.", strings.Join(added, ", "))
+	}
+	if len(unknown) > 0 {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "No such tool: %s. Available tools: %s.", strings.Join(unknown, ", "), strings.Join(t.names(), ", "))
+	}
+	return sb.String(), len(added) > 0
+}
+
+func (t *toolSubset) names() []string {
+	names := make([]string, 0, len(t.all))
+	for _, d := range t.all {
+		names = append(names, d.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func requestToolDefinition(omitted []string) domaintool.Definition {
+	return domaintool.Definition{
+		Name: RequestToolName,
+		Description: "Make more tools available. Only the tools most relevant to this task are loaded; " +
+			"these also exist: " + strings.Join(omitted, ", ") + ". " +
+			"Call request_tool with their names, then use them from the next step.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"names": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Tool names to load",
+				},
+			},
+			"required": []string{"names"},
+		},
+	}
+}
+
+// requestWords returns the lowercased words of the recent user messages
+// (the task plus steering), which drive the ranking.
+func requestWords(messages []LLMMessage) map[string]bool {
+	words := make(map[string]bool)
+	users := 0
+	for i := len(messages) - 1; i >= 0 && users < 3; i-- {
+		if messages[i].Role != "user" {
+			continue
+		}
+		users++
+		for _, w := range splitWords(messages[i].Content) {
+			words[w] = true
+		}
+		// Tool names are matched whole too ("use sql_query")
+		for _, w := range strings.FieldsFunc(strings.ToLower(messages[i].Content), func(r rune) bool {
+			return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+		}) {
+			words[w] = true
+		}
+	}
+	return words
+}
+
+func splitWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	})
+}
+
+func firstSentence(s string) string {
+	if i := strings.IndexAny(s, ".\n"); i > 0 {
+		return s[:i]
+	}
+	return s
+}
+
+type taskIntentKey struct{}
+
+// WithTaskIntent records the detected intent of the run's message
+// (coding, research, ...), used to rank tools.
+func WithTaskIntent(ctx context.Context, intent string) context.Context {
+	return context.WithValue(ctx, taskIntentKey{}, intent)
+}
+
+// TaskIntentFromContext returns the intent set with WithTaskIntent.
+func TaskIntentFromContext(ctx context.Context) string {
+	intent, _ := ctx.Value(taskIntentKey{}).(string)
+	return intent
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"go.uber.org/zap"
+)
+
+var selectorTestDefs = []domaintool.Definition{
+	{Name: "bash", Description: "Run a shell command."},
+	{Name: "read_file", Description: "Read a file."},
+	{Name: "web_fetch", Description: "Fetch contents from a URL."},
+	{Name: "research", Description: "Deep web research on a topic."},
+	{Name: "sql_query", Description: "Run a SQL query against a configured database."},
+	{Name: "run_tests", Description: "Run the project's tests."},
+	{Name: "save_memory", Description: "Save a fact to long-term memory."},
+	{Name: "send_photo", Description: "Send a photo to the chat."},
+}
+
+func defNames(defs []domaintool.Definition) []string {
+	var names []string
+	for _, d := range defs {
+		names = append(names, d.Name)
+	}
+	return names
+}
+
+func TestToolSubset_Definitions(t *testing.T) {
+	s := NewToolSelector(ToolSelectorConfig{TopK: 4, Always: []string{"bash"}}, zap.NewNop())
+	history := []LLMMessage{{Role: "assistant", ToolCalls: []entity.ToolCallInfo{{Name: "save_memory"}}}}
+	sub := s.begin(selectorTestDefs, "coding", history)
+
+	got := defNames(sub.definitions([]LLMMessage{{Role: "user", Content: "Query the orders database with sql_query"}}))
+	// bash pinned; sql_query named; save_memory recently used; read_file matches
+	// the coding intent and comes before run_tests
+	want := "bash sql_query save_memory read_file request_tool"
+	if strings.Join(got, " ") != want {
+		t.Errorf("definitions = %v, want %s", got, want)
+	}
+
+	sub.use("web_fetch")
+	if out, ok := sub.request(map[string]interface{}{"names": []interface{}{"send_photo", "nope"}}); !ok ||
+		!strings.Contains(out, "Added send_photo") || !strings.Contains(out, "No such tool: nope") {
+		t.Errorf("request = %q, %v", out, ok)
+	}
+	got = defNames(sub.definitions([]LLMMessage{{Role: "user", Content: "hi"}}))
+	if strings.Join(got[:3], " ") != "bash web_fetch send_photo" || got[len(got)-1] != RequestToolName {
+		t.Errorf("after use/request = %v", got)
+	}
+	last := sub.definitions(nil)
+	if desc := last[len(last)-1].Description; !strings.Contains(desc, "read_file") || strings.Contains(desc, "bash") {
+		t.Errorf("request_tool description = %q", desc)
+	}
+
+	if s.begin(selectorTestDefs[:4], "", nil) != nil {
+		t.Error("no subsetting when the tools fit in TopK")
+	}
+}
+
+type selectorTestTools struct{}
+
+func (selectorTestTools) Execute(ctx context.Context, name string, args map[string]interface{}) (*domaintool.Result, error) {
+	return &domaintool.Result{Success: true, Output: "ok"}, nil
+}
+func (selectorTestTools) GetDefinitions() []domaintool.Definition { return selectorTestDefs }
+func (selectorTestTools) GetToolKind(name string) domaintool.Kind { return domaintool.KindRead }
+
+// selectorTestLLM asks for send_photo, calls it, then answers.
+type selectorTestLLM struct {
+	tools [][]string
+}
+
+func (l *selectorTestLLM) Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error) {
+	l.tools = append(l.tools, defNames(req.Tools))
+	switch len(l.tools) {
+	case 1:
+		return &LLMResponse{ToolCalls: []entity.ToolCallInfo{{ID: "c1", Name: RequestToolName,
+			Arguments: map[string]interface{}{"names": []interface{}{"send_photo"}}}}}, nil
+	case 2:
+		return &LLMResponse{ToolCalls: []entity.ToolCallInfo{{ID: "c2", Name: "send_photo"}}}, nil
+	}
+	return &LLMResponse{Content: "sent"}, nil
+}
+
+func (l *selectorTestLLM) GenerateStream(ctx context.Context, req *LLMRequest, deltaCh chan<- StreamChunk) (*LLMResponse, error) {
+	return l.Generate(ctx, req)
+}
+
+func TestAgentLoop_ToolSubset(t *testing.T) {
+	llm := &selectorTestLLM{}
+	loop := NewAgentLoop(llm, selectorTestTools{}, DefaultAgentLoopConfig(), zap.NewNop())
+	loop.SetToolSelector(NewToolSelector(ToolSelectorConfig{TopK: 3}, zap.NewNop()))
+
+	result, eventCh := loop.Run(context.Background(), "", "draw a chart", nil, "m1")
+	for range eventCh {
+	}
+	if result.FinalContent != "sent" || len(llm.tools) != 3 {
+		t.Fatalf("FinalContent = %q, steps = %d", result.FinalContent, len(llm.tools))
+	}
+	if first := llm.tools[0]; len(first) != 4 || strings.Contains(strings.Join(first, " "), "send_photo") {
+		t.Errorf("step 1 tools = %v", first)
+	}
+	if second := strings.Join(llm.tools[1], " "); !strings.HasPrefix(second, "send_photo ") {
+		t.Errorf("step 2 tools = %s", second)
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2026 NGOClaw Authors. All rights reserved.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+// ToolOutputArchive keeps the full text of tool results that were replaced by
+// a summary, and returns a path the model can read it back from.
+// Implemented by infrastructure/tool.OutputArchive (avoids import cycle).
+type ToolOutputArchive interface {
+	Save(ctx context.Context, tool, callID, content string) (string, error)
+}
+
+// ToolSummaryConfig configures ToolSummaryMiddleware.
+type ToolSummaryConfig struct {
+	Model     string        // summarizer model (a cheap one)
+	MinTokens int           // results larger than this are summarized (0 = 2000)
+	MaxTokens int           // summary length budget (0 = 800)
+	Wait      time.Duration // how long a step waits for new summaries (0 = 20s)
+	SkipTools []string      // never summarized (nil = read_file and the edit tools)
+}
+
+// toolSummaryCacheSize bounds the per-process summary cache (tool call ID → summary).
+const toolSummaryCacheSize = 256
+
+// toolSummaryTimeout bounds one summarizer call, including the time it keeps
+// running in the background after a step stopped waiting for it.
+const toolSummaryTimeout = 2 * time.Minute
+
+// toolSummaryMaxInput caps the output sent to the summarizer (the archive
+// always gets the full text).
+const toolSummaryMaxInput = 100000
+
+// toolSummary is one summarization; done is closed once summary/path are set.
+type toolSummary struct {
+	done     chan struct{}
+	summary  string
+	path     string
+	rawChars int
+	waited   bool // a step already waited for it
+}
+
+// ToolSummaryMiddleware keeps large tool results (grep hits, logs, test
+// output) from bloating the context: results above MinTokens are archived in
+// full and summarized by a cheap model, and the model sees the summary plus
+// the archive path instead of the raw text.
+//
+// Summaries start in the background as soon as a tool returns (it observes
+// the untruncated output, see ToolOutputObserver). The next step waits up to
+// Wait for them; anything slower is sent raw this once and swapped for its
+// summary on a later step. Like TypecheckMiddleware, summaries are cached by
+// tool call ID so the history stays stable between steps.
+type ToolSummaryMiddleware struct {
+	NoOpMiddleware
+	llm     LLMClient
+	archive ToolOutputArchive
+	cfg     ToolSummaryConfig
+	skip    map[string]bool
+	logger  *zap.Logger
+
+	mu        sync.Mutex
+	summaries map[string]*toolSummary
+	order     []string
+}
+
+// NewToolSummaryMiddleware creates the middleware.
+func NewToolSummaryMiddleware(llm LLMClient, archive ToolOutputArchive, cfg ToolSummaryConfig, logger *zap.Logger) *ToolSummaryMiddleware {
+	if cfg.MinTokens <= 0 {
+		cfg.MinTokens = 2000
+	}
+	if cfg.MaxTokens <= 0 {
+		cfg.MaxTokens = 800
+	}
+	if cfg.Wait <= 0 {
+		cfg.Wait = 20 * time.Second
+	}
+	if cfg.SkipTools == nil {
+		// 模型要按原文编辑的内容不能摘要
+		cfg.SkipTools = []string{"read_file", "write_file", "edit_file", "apply_patch"}
+	}
+	skip := make(map[string]bool, len(cfg.SkipTools))
+	for _, name := range cfg.SkipTools {
+		skip[name] = true
+	}
+	return &ToolSummaryMiddleware{
+		llm:       llm,
+		archive:   archive,
+		cfg:       cfg,
+		skip:      skip,
+		logger:    logger,
+		summaries: make(map[string]*toolSummary),
+	}
+}
+
+func (m *ToolSummaryMiddleware) Name() string { return "tool_summary" }
+
+// ObserveToolOutput starts summarizing a large output right after its tool
+// returns, in parallel with the rest of the tool batch.
+func (m *ToolSummaryMiddleware) ObserveToolOutput(ctx context.Context, call entity.ToolCallInfo, output string) {
+	if !m.eligible(call.ID, call.Name, output) {
+		return
+	}
+	m.start(ctx, call.ID, call.Name, formatToolArgs(call.Arguments), output)
+}
+
+// BeforeModel waits briefly for summaries started since the last step and
+// replaces every result whose summary is ready. Large results the observer
+// did not see (e.g. tool cache hits) are summarized from the message itself.
+func (m *ToolSummaryMiddleware) BeforeModel(ctx context.Context, messages []LLMMessage, step int) []LLMMessage {
+	var pending []*toolSummary
+	for _, msg := range messages {
+		if msg.Role != "tool" || len(msg.Parts) > 0 || !m.eligible(msg.ToolCallID, msg.Name, msg.Content) {
+			continue
+		}
+		m.start(ctx, msg.ToolCallID, msg.Name, toolCallArgs(messages, msg.ToolCallID), msg.Content)
+	}
+	m.mu.Lock()
+	for _, msg := range messages {
+		if s, ok := m.summaries[msg.ToolCallID]; ok && msg.Role == "tool" && !s.waited {
+			s.waited = true
+			pending = append(pending, s)
+		}
+	}
+	m.mu.Unlock()
+	m.wait(ctx, pending)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []LLMMessage
+	replaced := 0
+	for i, msg := range messages {
+		s, ok := m.summaries[msg.ToolCallID]
+		if msg.Role != "tool" || !ok || !isDone(s) || s.summary == "" {
+			continue
+		}
+		if out == nil {
+			out = make([]LLMMessage, len(messages))
+			copy(out, messages)
+		}
+		out[i].Content = fmt.Sprintf("[SUMMARIZED] %s output (~%d tokens) was condensed. Full text: %s (use read_file on it for exact lines).\n\n%s",
+			msg.Name, s.rawChars/3, s.path, s.summary)
+		replaced++
+	}
+	if out == nil {
+		return messages
+	}
+	m.logger.Debug("Tool results summarized for model",
+		zap.Int("step", step),
+		zap.Int("replaced", replaced),
+	)
+	return out
+}
+
+func (m *ToolSummaryMiddleware) eligible(id, tool, content string) bool {
+	return id != "" && !m.skip[tool] && len(content)/3 > m.cfg.MinTokens
+}
+
+// start launches the summarization of one result unless it is already known.
+func (m *ToolSummaryMiddleware) start(ctx context.Context, id, tool, args, content string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.summaries[id]; ok {
+		return
+	}
+	s := &toolSummary{done: make(chan struct{}), rawChars: len(content)}
+	m.summaries[id] = s
+	m.order = append(m.order, id)
+	if len(m.order) > toolSummaryCacheSize {
+		delete(m.summaries, m.order[0])
+		m.order = m.order[1:]
+	}
+	go m.summarize(ctx, s, id, tool, args, content)
+}
+
+// wait blocks until every summary in pending is done, Wait elapses or ctx ends.
+func (m *ToolSummaryMiddleware) wait(ctx context.Context, pending []*toolSummary) {
+	if len(pending) == 0 {
+		return
+	}
+	timer := time.NewTimer(m.cfg.Wait)
+	defer timer.Stop()
+	for _, s := range pending {
+		select {
+		case <-s.done:
+		case <-timer.C:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// summarize archives the full output, then asks the summarizer model for a
+// condensed version. On any failure the summary stays empty and the raw
+// result keeps being sent.
+func (m *ToolSummaryMiddleware) summarize(ctx context.Context, s *toolSummary, id, tool, args, content string) {
+	defer close(s.done)
+	ctx, cancel := context.WithTimeout(ctx, toolSummaryTimeout)
+	defer cancel()
+
+	start := time.Now()
+	path, err := m.archive.Save(ctx, tool, id, content)
+	if err != nil {
+		m.logger.Warn("Tool output archive failed, keeping raw result",
+			zap.String("tool", tool),
+			zap.Error(err),
+		)
+		return
+	}
+
+	var prompt strings.Builder
+	if task, _ := ctx.Value(userMessageKey{}).(string); task != "" {
+		prompt.WriteString("Agent's task: " + truncateRunes(task, 500) + "\n")
+	}
+	prompt.WriteString("Tool: " + tool + args + "\n\nOutput:\n" + truncateOutput(content, toolSummaryMaxInput))
+
+	resp, err := m.llm.Generate(ctx, &LLMRequest{
+		Model: m.cfg.Model,
+		Messages: []LLMMessage{
+			{Role: "system", Content: toolSummaryPrompt},
+			{Role: "user", Content: prompt.String()},
+		},
+		MaxTokens:   m.cfg.MaxTokens,
+		Temperature: 0,
+	})
+	if err != nil {
+		m.logger.Warn("Tool output summarization failed, keeping raw result",
+			zap.String("tool", tool),
+			zap.Error(err),
+		)
+		return
+	}
+	summary := strings.TrimSpace(StripReasoningTags(resp.Content))
+	// 摘要没有明显变短就不替换
+	if summary == "" || len(summary) > len(content)/2 {
+		return
+	}
+	s.summary, s.path = summary, path
+	m.logger.Info("Tool output summarized",
+		zap.String("tool", tool),
+		zap.Int("raw_chars", len(content)),
+		zap.Int("summary_chars", len(summary)),
+		zap.Duration("duration", time.Since(start)),
+	)
+}
+
+func isDone(s *toolSummary) bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// toolCallArgs finds the arguments of call id in the history.
+func toolCallArgs(messages []LLMMessage, id string) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		for _, tc := range messages[i].ToolCalls {
+			if tc.ID == id {
+				return formatToolArgs(tc.Arguments)
+			}
+		}
+	}
+	return ""
+}
+
+// formatToolArgs renders tool arguments as JSON for the summarizer prompt,
+// so it knows what the output answers (e.g. the grep pattern).
+func formatToolArgs(args map[string]interface{}) string {
+	data, err := json.Marshal(args)
+	if err != nil || len(args) == 0 {
+		return ""
+	}
+	return " " + truncateRunes(string(data), 500)
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "..."
+}
+
+const toolSummaryPrompt = `You condense tool output for an AI agent in the middle of a task. The agent will see only your summary (the full text stays on disk), so:
+- Copy error messages, warnings, stack frames, file paths, line numbers, URLs, identifiers, commands and all numbers exactly as they appear. Never paraphrase them.
+- Keep every distinct result that may matter for the task; collapse repetitive lines into one example plus a count.
+- Say what you left out (e.g. "412 similar INFO lines omitted").
+- No preamble, commentary or advice. Plain text only.`
+
+// Compile-time checks
+var (
+	_ Middleware         = (*ToolSummaryMiddleware)(nil)
+	_ ToolOutputObserver = (*ToolSummaryMiddleware)(nil)
+)
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+type summaryTestLLM struct {
+	mu      sync.Mutex
+	prompts []string
+	block   chan struct{} // nil = answer immediately
+}
+
+func (l *summaryTestLLM) Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error) {
+	l.mu.Lock()
+	l.prompts = append(l.prompts, req.Messages[1].Content)
+	l.mu.Unlock()
+	if l.block != nil {
+		<-l.block
+	}
+	return &LLMResponse{Content: "main.go:42: undefined: Foo (37 similar lines omitted)"}, nil
+}
+
+func (l *summaryTestLLM) GenerateStream(ctx context.Context, req *LLMRequest, deltaCh chan<- StreamChunk) (*LLMResponse, error) {
+	close(deltaCh)
+	return l.Generate(ctx, req)
+}
+
+type summaryTestArchive struct {
+	mu    sync.Mutex
+	saved map[string]string
+}
+
+func (a *summaryTestArchive) Save(ctx context.Context, tool, callID, content string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.saved[callID] = content
+	return "/tmp/tool-" + callID + ".txt", nil
+}
+
+func TestToolSummaryMiddleware_ReplacesLargeResults(t *testing.T) {
+	llm := &summaryTestLLM{}
+	archive := &summaryTestArchive{saved: map[string]string{}}
+	mw := NewToolSummaryMiddleware(llm, archive, ToolSummaryConfig{Model: "cheap", MinTokens: 100}, zap.NewNop())
+
+	big := strings.Repeat("main.go:42: undefined: Foo\n", 40)
+	raw := big + strings.Repeat("x", 2000) // 被 agent loop 截断前的全文
+	mw.ObserveToolOutput(context.Background(), entity.ToolCallInfo{ID: "tc_1", Name: "grep_search", Arguments: map[string]interface{}{"pattern": "Foo"}}, raw)
+
+	messages := []LLMMessage{
+		{Role: "user", Content: "find Foo"},
+		{Role: "assistant", ToolCalls: []entity.ToolCallInfo{{ID: "tc_1", Name: "grep_search"}, {ID: "tc_2", Name: "read_file"}}},
+		{Role: "tool", Name: "grep_search", ToolCallID: "tc_1", Content: big},
+		{Role: "tool", Name: "read_file", ToolCallID: "tc_2", Content: big},
+	}
+	out := mw.BeforeModel(context.Background(), messages, 1)
+
+	if !strings.HasPrefix(out[2].Content, "[SUMMARIZED] grep_search output") ||
+		!strings.Contains(out[2].Content, "/tmp/tool-tc_1.txt") ||
+		!strings.Contains(out[2].Content, "main.go:42: undefined: Foo (37 similar lines omitted)") {
+		t.Errorf("grep result not summarized: %q", out[2].Content)
+	}
+	if out[3].Content != big {
+		t.Error("read_file results must stay verbatim")
+	}
+	if messages[2].Content != big {
+		t.Error("input messages must not be mutated")
+	}
+	if archive.saved["tc_1"] != raw {
+		t.Error("archive should get the untruncated output")
+	}
+	if len(llm.prompts) != 1 || !strings.Contains(llm.prompts[0], `grep_search {"pattern":"Foo"}`) {
+		t.Errorf("prompts = %q", llm.prompts)
+	}
+
+	// 下一步: 使用缓存, 不再调用模型
+	mw.BeforeModel(context.Background(), messages, 2)
+	if len(llm.prompts) != 1 {
+		t.Errorf("expected cached summary, got %d calls", len(llm.prompts))
+	}
+}
+
+func TestToolSummaryMiddleware_SlowSummaryAppliesLater(t *testing.T) {
+	llm := &summaryTestLLM{block: make(chan struct{})}
+	archive := &summaryTestArchive{saved: map[string]string{}}
+	mw := NewToolSummaryMiddleware(llm, archive, ToolSummaryConfig{MinTokens: 100, Wait: 10 * time.Millisecond}, zap.NewNop())
+
+	big := strings.Repeat("2026-10-16 INFO request served in 12ms\n", 40)
+	messages := []LLMMessage{
+		{Role: "assistant", ToolCalls: []entity.ToolCallInfo{{ID: "tc_1", Name: "bash"}}},
+		{Role: "tool", Name: "bash", ToolCallID: "tc_1", Content: big},
+	}
+	// 没经过 ObserveToolOutput (如工具缓存命中) 的结果也会摘要
+	out := mw.BeforeModel(context.Background(), messages, 1)
+	if out[1].Content != big {
+		t.Fatal("result should be sent raw while the summary is pending")
+	}
+
+	close(llm.block)
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		out = mw.BeforeModel(context.Background(), messages, 2)
+		if strings.HasPrefix(out[1].Content, "[SUMMARIZED]") {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Errorf("summary never applied: %q", out[1].Content)
+}
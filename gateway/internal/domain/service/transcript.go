@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+)
+
+// TranscriptSink receives the human-readable record of each finished run.
+// Implementations must be safe for concurrent use; WriteTranscript is called
+// after the run's event channel has been closed, off the hot path.
+type TranscriptSink interface {
+	WriteTranscript(t *RunTranscript)
+}
+
+// TranscriptSinks hands each transcript to several sinks in order.
+type TranscriptSinks []TranscriptSink
+
+func (s TranscriptSinks) WriteTranscript(t *RunTranscript) {
+	for _, sink := range s {
+		sink.WriteTranscript(t)
+	}
+}
+
+// RunTranscript is what a single AgentLoop.Run did: the user message, the
+// assistant's text, a summary of every tool call and how the run ended.
+type RunTranscript struct {
+	TraceID     string
+	Source      string // e.g. "telegram:12345", "job:<id>" (WithTranscriptSource)
+	Model       string
+	UserMessage string
+	Entries     []TranscriptEntry
+	Final       string
+	Error       string
+	Steps       int
+	Tokens      int
+	Tests       *entity.TestReport // latest run_tests result
+	Review      *entity.RunReview  // post-run self-review, nil when not reviewed
+	StartedAt   time.Time
+	FinishedAt  time.Time
+}
+
+// TranscriptEntry is one line item of a transcript, in event order.
+type TranscriptEntry struct {
+	Kind     string // "assistant" | "tool" | "user" (guidance sent mid-run)
+	Text     string // assistant / user text, or the tool output preview
+	Tool     string
+	Args     map[string]interface{}
+	Success  bool
+	Duration time.Duration
+}
+
+type transcriptSourceKey struct{}
+
+// WithTranscriptSource labels the runs started with ctx in transcripts.
+func WithTranscriptSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, transcriptSourceKey{}, source)
+}
+
+// TranscriptSourceFromContext returns the transcript label, or "".
+func TranscriptSourceFromContext(ctx context.Context) string {
+	source, _ := ctx.Value(transcriptSourceKey{}).(string)
+	return source
+}
+
+// SetTranscriptSink enables transcript recording for subsequent runs (nil disables).
+func (a *AgentLoop) SetTranscriptSink(sink TranscriptSink) {
+	a.transcript = sink
+}
+
+// teeTranscript forwards events from in to the returned channel while
+// building a RunTranscript, which is handed to the sink once in closes.
+// Recording off the event stream covers every exit path (done, error,
+// timeout, cancellation) without extra hooks in the loop.
+func (a *AgentLoop) teeTranscript(ctx context.Context, userMessage, model string, result *AgentResult, in <-chan entity.AgentEvent) <-chan entity.AgentEvent {
+	out := make(chan entity.AgentEvent, cap(in))
+	sink := a.transcript
+	t := &RunTranscript{
+		TraceID:     TraceIDFromContext(ctx),
+		Source:      TranscriptSourceFromContext(ctx),
+		Model:       model,
+		UserMessage: userMessage,
+		StartedAt:   time.Now(),
+	}
+
+	go func() {
+		var text []byte
+		flushText := func() {
+			if len(text) > 0 {
+				t.Entries = append(t.Entries, TranscriptEntry{Kind: "assistant", Text: string(text)})
+				text = text[:0]
+			}
+		}
+
+		for ev := range in {
+			switch ev.Type {
+			case entity.EventTextDelta:
+				text = append(text, ev.Content...)
+			case entity.EventToolCall:
+				flushText()
+			case entity.EventToolResult:
+				flushText()
+				if ev.ToolCall != nil {
+					t.Entries = append(t.Entries, TranscriptEntry{
+						Kind:     "tool",
+						Tool:     ev.ToolCall.Name,
+						Args:     ev.ToolCall.Arguments,
+						Text:     ev.ToolCall.Output,
+						Success:  ev.ToolCall.Success,
+						Duration: ev.ToolCall.Duration,
+					})
+				}
+			case entity.EventSteer:
+				flushText()
+				t.Entries = append(t.Entries, TranscriptEntry{Kind: "user", Text: ev.Content})
+			case entity.EventError:
+				t.Error = ev.Error
+			}
+
+			select {
+			case out <- ev:
+			case <-ctx.Done():
+				// consumer may have gone away; keep draining so the loop never blocks
+			}
+		}
+		flushText()
+		close(out)
+
+		t.FinishedAt = time.Now()
+		t.Final = result.FinalContent
+		t.Steps = result.TotalSteps
+		t.Tokens = result.TotalTokens
+		t.Tests = result.TestReport
+		t.Review = result.Review
+		if result.ModelUsed != "" {
+			t.Model = result.ModelUsed
+		}
+		sink.WriteTranscript(t)
+	}()
+	return out
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 NGOClaw Authors. All rights reserved.
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Typechecker runs a fast compile/type check over files edited in the
+// current run (identified by the trace ID in ctx).
+// Implemented by infrastructure/tool.TypecheckTool (avoids import cycle).
+type Typechecker interface {
+	// Typecheck checks paths (nil = every file edited in this run) and
+	// returns a report for the model, or "" when nothing is checkable.
+	Typecheck(ctx context.Context, paths []string) string
+}
+
+// typecheckCacheSize bounds the per-process report cache (tool call ID → report).
+const typecheckCacheSize = 256
+
+// TypecheckMiddleware surfaces compile/type errors right after the model
+// edits code: when the latest tool batch contains a successful edit
+// (write_file, edit_file, apply_patch), it runs the Typechecker once and
+// appends the report to the last edit's tool result.
+//
+// Reports are cached by tool call ID and re-attached on every later step, so
+// the history the model sees stays stable (prompt cache friendly) without
+// re-running the check.
+type TypecheckMiddleware struct {
+	NoOpMiddleware
+	checker   Typechecker
+	timeout   time.Duration
+	editTools map[string]bool
+	logger    *zap.Logger
+
+	mu      sync.Mutex
+	reports map[string]string
+	order   []string
+}
+
+// NewTypecheckMiddleware creates the middleware. timeout bounds one check
+// (0 = 2 minutes).
+func NewTypecheckMiddleware(checker Typechecker, timeout time.Duration, logger *zap.Logger) *TypecheckMiddleware {
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+	return &TypecheckMiddleware{
+		checker:   checker,
+		timeout:   timeout,
+		editTools: map[string]bool{"write_file": true, "edit_file": true, "apply_patch": true},
+		logger:    logger,
+		reports:   make(map[string]string),
+	}
+}
+
+func (m *TypecheckMiddleware) Name() string { return "typecheck" }
+
+// BeforeModel checks newly edited code and attaches cached reports to their
+// tool results.
+func (m *TypecheckMiddleware) BeforeModel(ctx context.Context, messages []LLMMessage, step int) []LLMMessage {
+	m.checkLatestBatch(ctx, messages, step)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []LLMMessage
+	for i, msg := range messages {
+		report, ok := m.reports[msg.ToolCallID]
+		if msg.Role != "tool" || !ok || report == "" {
+			continue
+		}
+		if out == nil {
+			out = make([]LLMMessage, len(messages))
+			copy(out, messages)
+		}
+		out[i].Content = msg.Content + "\n\n[TYPECHECK]\n" + report
+	}
+	if out == nil {
+		return messages
+	}
+	return out
+}
+
+// checkLatestBatch runs the check when the tool results after the last
+// assistant message include a successful, not yet checked edit.
+func (m *TypecheckMiddleware) checkLatestBatch(ctx context.Context, messages []LLMMessage, step int) {
+	lastEdit := ""
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if msg.Role == "assistant" {
+			break
+		}
+		if msg.Role != "tool" || !m.editTools[msg.Name] || msg.ToolCallID == "" ||
+			strings.HasPrefix(msg.Content, "[TOOL_FAILED]") {
+			continue
+		}
+		m.mu.Lock()
+		_, seen := m.reports[msg.ToolCallID]
+		m.mu.Unlock()
+		if seen {
+			return
+		}
+		if lastEdit == "" {
+			lastEdit = msg.ToolCallID
+		}
+	}
+	if lastEdit == "" {
+		return
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+	start := time.Now()
+	report := m.checker.Typecheck(checkCtx, nil)
+	m.logger.Info("Typecheck after edit",
+		zap.Int("step", step),
+		zap.Duration("duration", time.Since(start)),
+		zap.Bool("clean", report == "" || strings.HasPrefix(report, "typecheck: no errors")),
+	)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reports[lastEdit] = report
+	m.order = append(m.order, lastEdit)
+	if len(m.order) > typecheckCacheSize {
+		delete(m.reports, m.order[0])
+		m.order = m.order[1:]
+	}
+}
+
+// Compile-time check
+var _ Middleware = (*TypecheckMiddleware)(nil)
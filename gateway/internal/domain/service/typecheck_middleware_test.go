@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type fakeTypechecker struct {
+	calls  int
+	report string
+}
+
+func (f *fakeTypechecker) Typecheck(ctx context.Context, paths []string) string {
+	f.calls++
+	return f.report
+}
+
+func TestTypecheckMiddleware_ChecksOncePerBatch(t *testing.T) {
+	checker := &fakeTypechecker{report: "typecheck: 1 error(s)\nmain.go:3:9: mismatched types"}
+	mw := NewTypecheckMiddleware(checker, 0, zap.NewNop())
+
+	messages := []LLMMessage{
+		{Role: "user", Content: "fix it"},
+		{Role: "assistant", Content: "editing"},
+		{Role: "tool", Name: "read_file", ToolCallID: "tc_1", Content: "package main"},
+		{Role: "tool", Name: "edit_file", ToolCallID: "tc_2", Content: "edited main.go"},
+		{Role: "tool", Name: "write_file", ToolCallID: "tc_3", Content: "wrote util.go"},
+	}
+
+	out := mw.BeforeModel(context.Background(), messages, 1)
+	if checker.calls != 1 {
+		t.Fatalf("expected 1 check, got %d", checker.calls)
+	}
+	if strings.Contains(out[3].Content, "[TYPECHECK]") || !strings.Contains(out[4].Content, "[TYPECHECK]\ntypecheck: 1 error(s)") {
+		t.Errorf("report should attach to the last edit only: %q / %q", out[3].Content, out[4].Content)
+	}
+	if messages[4].Content != "wrote util.go" {
+		t.Error("input messages must not be mutated")
+	}
+
+	// 下一步: 不重复检查, 但报告仍附在原位置
+	messages = append(messages,
+		LLMMessage{Role: "assistant", Content: "let me look"},
+		LLMMessage{Role: "tool", Name: "bash", ToolCallID: "tc_4", Content: "ok"},
+	)
+	out = mw.BeforeModel(context.Background(), messages, 2)
+	if checker.calls != 1 {
+		t.Errorf("expected no re-check, got %d calls", checker.calls)
+	}
+	if !strings.Contains(out[4].Content, "[TYPECHECK]") {
+		t.Error("cached report should be re-attached")
+	}
+}
+
+func TestTypecheckMiddleware_SkipsFailedEdits(t *testing.T) {
+	checker := &fakeTypechecker{report: "typecheck: no errors"}
+	mw := NewTypecheckMiddleware(checker, 0, zap.NewNop())
+
+	messages := []LLMMessage{
+		{Role: "assistant", Content: "editing"},
+		{Role: "tool", Name: "edit_file", ToolCallID: "tc_1", Content: "[TOOL_FAILED] old_text not found"},
+	}
+	out := mw.BeforeModel(context.Background(), messages, 1)
+	if checker.calls != 0 {
+		t.Errorf("failed edit should not trigger a check")
+	}
+	if out[1].Content != messages[1].Content {
+		t.Errorf("unexpected rewrite: %q", out[1].Content)
+	}
+}
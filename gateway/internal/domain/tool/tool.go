@@ -35,6 +35,22 @@ var SafeKinds = map[Kind]bool{
 	KindThink:  true,
 }
 
+// RiskClass 按操作类型粗分的风险等级 (工具目录导出用): low | medium | high
+//
+//	low    — 只读/搜索/思考 (SafeKinds)
+//	medium — 网络获取、与用户交互等不修改本机的操作
+//	high   — 修改文件、删除、执行命令 (MutatorKinds)
+func RiskClass(k Kind) string {
+	switch {
+	case SafeKinds[k]:
+		return "low"
+	case MutatorKinds[k]:
+		return "high"
+	default:
+		return "medium"
+	}
+}
+
 // Tool 工具接口 - 所有可执行工具的抽象
 type Tool interface {
 	// Name 返回工具名称
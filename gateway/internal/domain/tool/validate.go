@@ -0,0 +1,299 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// InvalidArgsTag 参数校验失败时 Result.Error 的前缀, agent loop 据此原样回传给模型
+const InvalidArgsTag = "[INVALID_ARGS]"
+
+// ArgError 一个不符合 schema 的参数
+type ArgError struct {
+	Field   string // 字段路径, 如 "path" / "edits[0].old_text"
+	Problem string // 如 "missing required field" / "expected integer, got string"
+}
+
+// ValidateArgs 按工具的 JSON Schema 校验参数, 返回所有问题 (无问题返回 nil).
+//
+// 只覆盖工具 schema 实际用到的子集: type / properties / required / enum /
+// items / additionalProperties. 对模型常见的宽松写法放行: 数字字符串可作
+// number/integer, "true"/"false" 可作 boolean, 可选字段为 null 视同未传 —
+// 这些工具内部本就会转换, 拦下来只会多浪费一步.
+func ValidateArgs(schema map[string]interface{}, args map[string]interface{}) []ArgError {
+	if len(schema) == 0 {
+		return nil
+	}
+	var errs []ArgError
+	validateObject("", schema, args, &errs)
+	return errs
+}
+
+// CheckArgs 校验一次工具调用; 参数合法时返回 nil, 否则返回不执行工具的失败结果
+func CheckArgs(t Tool, args map[string]interface{}) *Result {
+	schema := t.Schema()
+	errs := ValidateArgs(schema, args)
+	if len(errs) == 0 {
+		return nil
+	}
+	msg := FormatArgErrors(t.Name(), schema, errs)
+	return &Result{
+		Output:   msg,
+		Success:  false,
+		Error:    msg,
+		Metadata: map[string]interface{}{"invalid_args": len(errs)},
+	}
+}
+
+// FormatArgErrors 生成给模型看的结构化错误, 列出每个问题字段和期望的参数签名
+func FormatArgErrors(name string, schema map[string]interface{}, errs []ArgError) string {
+	var sb strings.Builder
+	sb.WriteString(InvalidArgsTag + " " + name + "\n")
+	for _, e := range errs {
+		sb.WriteString("- " + e.Field + ": " + e.Problem + "\n")
+	}
+	if sig := schemaSignature(schema); sig != "" {
+		sb.WriteString("[EXPECTED] " + sig + "\n")
+	}
+	sb.WriteString("[HINT] 参数不符合工具 schema, 工具未执行。请按上面列出的字段修正参数后重新调用。")
+	return sb.String()
+}
+
+func validateObject(path string, schema map[string]interface{}, obj map[string]interface{}, errs *[]ArgError) {
+	props, _ := schema["properties"].(map[string]interface{})
+
+	for _, name := range stringList(schema["required"]) {
+		if v, ok := obj[name]; !ok || v == nil {
+			*errs = append(*errs, ArgError{Field: joinPath(path, name), Problem: "missing required field"})
+		}
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		v := obj[name]
+		propSchema, known := props[name].(map[string]interface{})
+		if !known {
+			if extra, ok := schema["additionalProperties"].(bool); ok && !extra && props != nil {
+				*errs = append(*errs, ArgError{Field: joinPath(path, name), Problem: "unknown field (allowed: " + strings.Join(sortedKeys(props), ", ") + ")"})
+			}
+			continue
+		}
+		if v == nil {
+			continue // 可选字段为 null 视同未传; 必填已在上面报告
+		}
+		validateValue(joinPath(path, name), propSchema, v, errs)
+	}
+}
+
+func validateValue(path string, schema map[string]interface{}, v interface{}, errs *[]ArgError) {
+	types := stringList(schema["type"])
+	if len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if matchesType(t, v) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			*errs = append(*errs, ArgError{
+				Field:   path,
+				Problem: "expected " + strings.Join(types, " or ") + ", got " + describeValue(v),
+			})
+			return
+		}
+	}
+
+	if enum := enumValues(schema["enum"]); len(enum) > 0 {
+		s := fmt.Sprint(v)
+		found := false
+		for _, e := range enum {
+			if e == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*errs = append(*errs, ArgError{Field: path, Problem: fmt.Sprintf("%q is not one of %s", s, strings.Join(enum, ", "))})
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if _, ok := schema["properties"]; ok {
+			validateObject(path, schema, val, errs)
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range val {
+				validateValue(fmt.Sprintf("%s[%d]", path, i), items, item, errs)
+			}
+		}
+	}
+}
+
+func matchesType(t string, v interface{}) bool {
+	switch t {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		if _, ok := toFloat(v); ok {
+			return true
+		}
+	case "integer":
+		if f, ok := toFloat(v); ok {
+			return f == math.Trunc(f)
+		}
+	case "boolean":
+		switch b := v.(type) {
+		case bool:
+			return true
+		case string:
+			return b == "true" || b == "false"
+		}
+	case "array":
+		switch v.(type) {
+		case []interface{}, []string:
+			return true
+		}
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "null":
+		return v == nil
+	default:
+		return true // 未知类型不拦
+	}
+	return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func describeValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		if len(val) > 40 {
+			val = val[:40] + "…"
+		}
+		return fmt.Sprintf("string %q", val)
+	case bool:
+		return "boolean"
+	case float64, float32, int, int64, json.Number:
+		return fmt.Sprintf("number %v", val)
+	case []interface{}, []string:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// schemaSignature 如 "path (string, required), limit (integer), mode (one of: a, b)"
+func schemaSignature(schema map[string]interface{}) string {
+	props, _ := schema["properties"].(map[string]interface{})
+	if len(props) == 0 {
+		return ""
+	}
+	required := make(map[string]bool)
+	for _, r := range stringList(schema["required"]) {
+		required[r] = true
+	}
+
+	names := sortedKeys(props)
+	// 必填字段排前面
+	sort.SliceStable(names, func(i, j int) bool { return required[names[i]] && !required[names[j]] })
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		prop, _ := props[name].(map[string]interface{})
+		var attrs []string
+		if t := stringList(prop["type"]); len(t) > 0 {
+			attrs = append(attrs, strings.Join(t, "|"))
+		}
+		if required[name] {
+			attrs = append(attrs, "required")
+		}
+		if enum := enumValues(prop["enum"]); len(enum) > 0 {
+			attrs = append(attrs, "one of: "+strings.Join(enum, "/"))
+		}
+		parts = append(parts, name+" ("+strings.Join(attrs, ", ")+")")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// stringList 兼容 []string (Go 内置工具) 和 []interface{} (MCP 工具的 JSON schema)
+func stringList(v interface{}) []string {
+	switch list := v.(type) {
+	case string:
+		return []string{list}
+	case []string:
+		return list
+	case []interface{}:
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func enumValues(v interface{}) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			out = append(out, fmt.Sprint(item))
+		}
+		return out
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
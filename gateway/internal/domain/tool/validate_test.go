@@ -0,0 +1,87 @@
+package tool
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+var editSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"path":  map[string]interface{}{"type": "string"},
+		"limit": map[string]interface{}{"type": "integer"},
+		"mode":  map[string]interface{}{"type": "string", "enum": []string{"append", "replace"}},
+		"edits": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"old_text": map[string]interface{}{"type": "string"}},
+				"required":   []interface{}{"old_text"},
+			},
+		},
+	},
+	"required": []string{"path"},
+}
+
+func TestValidateArgs(t *testing.T) {
+	if errs := ValidateArgs(editSchema, map[string]interface{}{
+		"path":  "a.go",
+		"limit": "20", // numeric strings are tolerated
+		"mode":  "append",
+		"edits": []interface{}{map[string]interface{}{"old_text": "x"}},
+	}); len(errs) != 0 {
+		t.Fatalf("unexpected errors %+v", errs)
+	}
+
+	errs := ValidateArgs(editSchema, map[string]interface{}{
+		"limit": 1.5,
+		"mode":  "prepend",
+		"edits": []interface{}{map[string]interface{}{"new_text": "y"}},
+	})
+	got := make(map[string]string)
+	for _, e := range errs {
+		got[e.Field] = e.Problem
+	}
+	want := map[string]string{
+		"path":              "missing required field",
+		"limit":             "expected integer, got number 1.5",
+		"mode":              `"prepend" is not one of append, replace`,
+		"edits[0].old_text": "missing required field",
+	}
+	for field, problem := range want {
+		if got[field] != problem {
+			t.Errorf("%s: got %q, want %q", field, got[field], problem)
+		}
+	}
+	if len(errs) != len(want) {
+		t.Errorf("got %d errors: %+v", len(errs), errs)
+	}
+}
+
+type schemaTool struct{ ran bool }
+
+func (s *schemaTool) Name() string                   { return "edit_file" }
+func (s *schemaTool) Description() string            { return "" }
+func (s *schemaTool) Kind() Kind                     { return KindEdit }
+func (s *schemaTool) Schema() map[string]interface{} { return editSchema }
+func (s *schemaTool) Execute(ctx context.Context, args map[string]interface{}) (*Result, error) {
+	s.ran = true
+	return &Result{Success: true}, nil
+}
+
+func TestCheckArgs(t *testing.T) {
+	tool := &schemaTool{}
+	res := CheckArgs(tool, map[string]interface{}{"limit": true})
+	if res == nil || res.Success {
+		t.Fatalf("expected rejection, got %+v", res)
+	}
+	for _, s := range []string{InvalidArgsTag + " edit_file", "- path: missing required field", "- limit: expected integer, got boolean", "[EXPECTED] path (string, required)"} {
+		if !strings.Contains(res.Error, s) {
+			t.Errorf("missing %q in:\n%s", s, res.Error)
+		}
+	}
+	if CheckArgs(tool, map[string]interface{}{"path": "a.go"}) != nil {
+		t.Error("valid args rejected")
+	}
+}
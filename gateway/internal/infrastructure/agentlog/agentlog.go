@@ -0,0 +1,157 @@
+// Package agentlog keeps a changelog of agent runs inside each workspace
+// (<workspace>/.ngoclaw/AGENT_LOG.md): one entry per run that changed files,
+// with the request, the files and how the change was verified, so teammates
+// who didn't watch the chat can see what the agent did to the repo.
+package agentlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileName is the changelog path relative to the workspace root.
+const FileName = ".ngoclaw/AGENT_LOG.md"
+
+const (
+	maxRequestChars = 300
+	maxFiles        = 50
+)
+
+const header = "# Agent log\n\n" +
+	"Changes made by the NGOClaw agent in this workspace, newest last.\n" +
+	"Appended automatically after each run that modified files.\n"
+
+// Entry is one run that changed files in a workspace.
+type Entry struct {
+	Time         time.Time
+	Source       string // who asked, e.g. "telegram:12345", "api:office"
+	Model        string
+	Request      string   // the user message that started the run
+	Files        []string // changed files, relative to the workspace root
+	Outcome      string   // "completed", "aborted (user_stop)", "failed: ..."
+	Verification string   // e.g. "✅ tests passed (`go test ./...`)"
+}
+
+// mu serializes appends from concurrent runs.
+var mu sync.Mutex
+
+// Append adds e to the workspace's changelog, creating it with a header.
+func Append(root string, e Entry) error {
+	path := filepath.Join(root, FileName)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Dir(path), err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var sb strings.Builder
+	if st, err := f.Stat(); err == nil && st.Size() == 0 {
+		sb.WriteString(header)
+	}
+	sb.WriteString(Format(e))
+	_, err = f.WriteString(sb.String())
+	return err
+}
+
+// Format renders one entry as a Markdown section.
+func Format(e Entry) string {
+	var sb strings.Builder
+	sb.WriteString("\n## " + e.Time.Format("2006-01-02 15:04 MST"))
+	if e.Source != "" {
+		sb.WriteString(" · " + e.Source)
+	}
+	sb.WriteString("\n\n")
+
+	sb.WriteString("- **Request:** " + summarize(e.Request) + "\n")
+	if e.Model != "" {
+		sb.WriteString("- **Model:** " + e.Model + "\n")
+	}
+	outcome := e.Outcome
+	if outcome == "" {
+		outcome = "completed"
+	}
+	sb.WriteString("- **Outcome:** " + outcome + "\n")
+	verification := e.Verification
+	if verification == "" {
+		verification = "⚠️ not verified (no tests run)"
+	}
+	sb.WriteString("- **Verification:** " + verification + "\n")
+
+	sb.WriteString(fmt.Sprintf("- **Files changed (%d):**\n", len(e.Files)))
+	for i, f := range e.Files {
+		if i == maxFiles {
+			sb.WriteString(fmt.Sprintf("  - … and %d more\n", len(e.Files)-maxFiles))
+			break
+		}
+		sb.WriteString("  - `" + f + "`\n")
+	}
+	return sb.String()
+}
+
+// summarize reduces a request to one line of at most maxRequestChars.
+func summarize(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" {
+		return "(empty)"
+	}
+	if r := []rune(s); len(r) > maxRequestChars {
+		s = string(r[:maxRequestChars-1]) + "…"
+	}
+	return s
+}
+
+// Group assigns absolute file paths to workspace roots: the enclosing git
+// repository, else fallback when the file is under it. Files in neither are
+// dropped. Paths in the result are relative to their root and sorted.
+func Group(files []string, fallback string) map[string][]string {
+	groups := make(map[string][]string)
+	roots := make(map[string]string) // dir → root ("" = none), cache
+	for _, f := range files {
+		root := findRoot(filepath.Dir(f), roots)
+		if root == "" && fallback != "" && within(f, fallback) {
+			root = fallback
+		}
+		if root == "" {
+			continue
+		}
+		rel, err := filepath.Rel(root, f)
+		if err != nil {
+			continue
+		}
+		groups[root] = append(groups[root], filepath.ToSlash(rel))
+	}
+	for _, g := range groups {
+		sort.Strings(g)
+	}
+	return groups
+}
+
+func findRoot(dir string, cache map[string]string) string {
+	if root, ok := cache[dir]; ok {
+		return root
+	}
+	root := ""
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		root = dir
+	} else if parent := filepath.Dir(dir); parent != dir {
+		root = findRoot(parent, cache)
+	}
+	cache[dir] = root
+	return root
+}
+
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,80 @@
+package agentlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGroup(t *testing.T) {
+	base := t.TempDir()
+	repo := filepath.Join(base, "repo")
+	scratch := filepath.Join(base, "scratch")
+	for _, dir := range []string{filepath.Join(repo, ".git"), filepath.Join(repo, "pkg"), scratch} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	groups := Group([]string{
+		filepath.Join(repo, "pkg", "b.go"),
+		filepath.Join(repo, "README.md"),
+		filepath.Join(scratch, "notes.txt"),
+		filepath.Join(base, "elsewhere.txt"),
+	}, scratch)
+
+	if got := strings.Join(groups[repo], ","); got != "README.md,pkg/b.go" {
+		t.Errorf("repo files = %q", got)
+	}
+	if got := strings.Join(groups[scratch], ","); got != "notes.txt" {
+		t.Errorf("fallback files = %q", got)
+	}
+	if len(groups) != 2 {
+		t.Errorf("groups = %v, want file outside both dropped", groups)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	root := t.TempDir()
+	at := time.Date(2026, 3, 1, 14, 5, 0, 0, time.UTC)
+	first := Entry{
+		Time:         at,
+		Source:       "telegram:42",
+		Model:        "claude-sonnet",
+		Request:      "Fix the retry test\nit flakes on CI",
+		Files:        []string{"llm/backoff.go", "llm/backoff_test.go"},
+		Verification: "✅ tests passed (`go test ./...`)",
+	}
+	if err := Append(root, first); err != nil {
+		t.Fatal(err)
+	}
+	if err := Append(root, Entry{Time: at.Add(time.Hour), Request: "rename", Files: []string{"a.go"}, Outcome: "aborted (user_stop)"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := string(data)
+	if strings.Count(log, "# Agent log") != 1 {
+		t.Errorf("header written %d times", strings.Count(log, "# Agent log"))
+	}
+	for _, want := range []string{
+		"## 2026-03-01 14:05 UTC · telegram:42\n",
+		"- **Request:** Fix the retry test it flakes on CI\n",
+		"- **Outcome:** completed\n",
+		"- **Verification:** ✅ tests passed (`go test ./...`)\n",
+		"- **Files changed (2):**\n  - `llm/backoff.go`\n  - `llm/backoff_test.go`\n",
+		"- **Outcome:** aborted (user_stop)\n- **Verification:** ⚠️ not verified (no tests run)\n",
+	} {
+		if !strings.Contains(log, want) {
+			t.Errorf("log missing %q:\n%s", want, log)
+		}
+	}
+	if strings.Index(log, "telegram:42") > strings.Index(log, "rename") {
+		t.Error("entries out of order")
+	}
+}
@@ -0,0 +1,104 @@
+package approval
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+)
+
+// Console asks the operator on the gateway's terminal.
+// Prompts are serialized: concurrent runs wait their turn.
+type Console struct {
+	in      *bufio.Reader
+	out     io.Writer
+	timeout time.Duration
+
+	mu    sync.Mutex
+	lines chan string
+	once  sync.Once
+}
+
+// NewConsole creates a console approver reading answers from in.
+func NewConsole(in io.Reader, out io.Writer, timeout time.Duration) *Console {
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	return &Console{
+		in:      bufio.NewReader(in),
+		out:     out,
+		timeout: timeout,
+		lines:   make(chan string),
+	}
+}
+
+// readLoop forwards input lines so a prompt can time out without
+// leaving a blocked read behind that would swallow the next answer.
+func (c *Console) readLoop() {
+	for {
+		line, err := c.in.ReadString('\n')
+		if line != "" {
+			c.lines <- line
+		}
+		if err != nil {
+			close(c.lines)
+			return
+		}
+	}
+}
+
+// Request prints the tool call and waits for y/N. Matches service.ApprovalFunc.
+func (c *Console) Request(ctx context.Context, toolName string, args map[string]interface{}) (bool, error) {
+	c.once.Do(func() { go c.readLoop() })
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	argsJSON, _ := json.Marshal(args)
+	fmt.Fprintf(c.out, "\n🔧 Tool approval requested: %s\n   args: %s\n", toolName, truncate(string(argsJSON), 500))
+	if risk := service.CommandRiskFromContext(ctx); risk != nil && risk.Level >= service.RiskMedium {
+		fmt.Fprintf(c.out, "   risk: %s\n", risk.Level)
+		for _, r := range risk.Reasons {
+			fmt.Fprintf(c.out, "     - %s\n", r.Description())
+		}
+	}
+	if guard := service.GuardRuleFromContext(ctx); guard != nil {
+		fmt.Fprintf(c.out, "   guard rule: %s\n", guard.Name)
+		if guard.Message != "" {
+			fmt.Fprintf(c.out, "     %s\n", guard.Message)
+		}
+	}
+	fmt.Fprint(c.out, "   approve? [y/N] ")
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+
+	select {
+	case line, ok := <-c.lines:
+		if !ok {
+			fmt.Fprintln(c.out, "(stdin closed, denied)")
+			return false, nil
+		}
+		answer := strings.ToLower(strings.TrimSpace(line))
+		return answer == "y" || answer == "yes", nil
+	case <-timer.C:
+		fmt.Fprintln(c.out, "(timed out, denied)")
+		return false, nil
+	case <-ctx.Done():
+		fmt.Fprintln(c.out, "(cancelled)")
+		return false, ctx.Err()
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,198 @@
+// Package approval provides tool-approval channels for callers that have no
+// Telegram chat to show an inline keyboard in (HTTP API, gRPC, cron, ...).
+//
+// Queue parks requests as pending approvals that an operator resolves via the
+// HTTP API (optionally notified through a webhook); Console prompts on the
+// gateway's own terminal.
+package approval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+)
+
+// Pending 待审批的工具调用
+type Pending struct {
+	ID        string                 `json:"id"`
+	ToolName  string                 `json:"tool_name"`
+	Args      map[string]interface{} `json:"args"`
+	CreatedAt time.Time              `json:"created_at"`
+	ExpiresAt time.Time              `json:"expires_at"`
+
+	// 命令风险分析 (仅 bash 类工具, 由 SecurityHook 提供)
+	Risk        string   `json:"risk,omitempty"`
+	RiskReasons []string `json:"risk_reasons,omitempty"`
+
+	// 要求确认的项目守卫规则 (.ngoclaw/guards.yaml)
+	Guard        string `json:"guard,omitempty"`
+	GuardMessage string `json:"guard_message,omitempty"`
+
+	result chan bool
+}
+
+// ErrNotFound is returned by Resolve for unknown or already-resolved IDs.
+var ErrNotFound = fmt.Errorf("approval not found or already resolved")
+
+// Queue holds pending approvals until they are resolved, time out or the
+// requesting run is cancelled. Timeouts deny.
+type Queue struct {
+	timeout    time.Duration
+	webhookURL string
+	client     *http.Client
+	logger     *zap.Logger
+
+	mu      sync.Mutex
+	pending map[string]*Pending
+	seq     atomic.Uint64
+}
+
+// NewQueue creates a pending-approval queue. webhookURL may be empty.
+func NewQueue(timeout time.Duration, webhookURL string, logger *zap.Logger) *Queue {
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	return &Queue{
+		timeout:    timeout,
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		logger:     logger.With(zap.String("component", "approval-queue")),
+		pending:    make(map[string]*Pending),
+	}
+}
+
+// Request parks a tool call and blocks until it is resolved.
+// Matches service.ApprovalFunc.
+func (q *Queue) Request(ctx context.Context, toolName string, args map[string]interface{}) (bool, error) {
+	now := time.Now()
+	p := &Pending{
+		ID:        fmt.Sprintf("apr_%d_%d", now.Unix(), q.seq.Add(1)),
+		ToolName:  toolName,
+		Args:      args,
+		CreatedAt: now,
+		ExpiresAt: now.Add(q.timeout),
+		result:    make(chan bool, 1),
+	}
+	if risk := service.CommandRiskFromContext(ctx); risk != nil {
+		p.Risk = risk.Level.String()
+		for _, r := range risk.Reasons {
+			p.RiskReasons = append(p.RiskReasons, r.Description())
+		}
+	}
+	if guard := service.GuardRuleFromContext(ctx); guard != nil {
+		p.Guard, p.GuardMessage = guard.Name, guard.Message
+	}
+
+	q.mu.Lock()
+	q.pending[p.ID] = p
+	q.mu.Unlock()
+	defer q.remove(p.ID)
+
+	q.logger.Info("Tool call awaiting approval",
+		zap.String("id", p.ID),
+		zap.String("tool", toolName),
+	)
+	q.notify(p)
+
+	timer := time.NewTimer(q.timeout)
+	defer timer.Stop()
+
+	select {
+	case approved := <-p.result:
+		return approved, nil
+	case <-timer.C:
+		q.logger.Info("Approval timed out, denying", zap.String("id", p.ID))
+		return false, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// List returns pending approvals, oldest first.
+func (q *Queue) List() []Pending {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	result := make([]Pending, 0, len(q.pending))
+	for _, p := range q.pending {
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result
+}
+
+// Resolve approves or denies a pending request.
+func (q *Queue) Resolve(id string, approved bool) error {
+	q.mu.Lock()
+	p, ok := q.pending[id]
+	if ok {
+		delete(q.pending, id)
+	}
+	q.mu.Unlock()
+
+	if !ok {
+		return ErrNotFound
+	}
+	p.result <- approved
+	q.logger.Info("Approval resolved",
+		zap.String("id", id),
+		zap.String("tool", p.ToolName),
+		zap.Bool("approved", approved),
+	)
+	return nil
+}
+
+func (q *Queue) remove(id string) {
+	q.mu.Lock()
+	delete(q.pending, id)
+	q.mu.Unlock()
+}
+
+// notify POSTs the pending approval to the configured webhook (best effort).
+func (q *Queue) notify(p *Pending) {
+	if q.webhookURL == "" {
+		return
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"event":    "approval.requested",
+		"approval": p,
+	})
+	if err != nil {
+		return
+	}
+
+	go func() {
+		req, err := http.NewRequest(http.MethodPost, q.webhookURL, bytes.NewReader(body))
+		if err != nil {
+			q.logger.Warn("Invalid approval webhook URL", zap.Error(err))
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := q.client.Do(req)
+		if err != nil {
+			q.logger.Warn("Approval webhook failed", zap.String("id", p.ID), zap.Error(err))
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			q.logger.Warn("Approval webhook rejected",
+				zap.String("id", p.ID),
+				zap.Int("status", resp.StatusCode),
+			)
+		}
+	}()
+}
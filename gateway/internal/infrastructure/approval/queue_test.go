@@ -0,0 +1,86 @@
+package approval
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func waitPending(t *testing.T, q *Queue) Pending {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if list := q.List(); len(list) > 0 {
+			return list[0]
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("no pending approval appeared")
+	return Pending{}
+}
+
+func TestQueue_Resolve(t *testing.T) {
+	q := NewQueue(time.Minute, "", zap.NewNop())
+
+	done := make(chan bool, 1)
+	go func() {
+		ok, _ := q.Request(context.Background(), "shell_exec", map[string]interface{}{"command": "rm -rf /tmp/x"})
+		done <- ok
+	}()
+
+	p := waitPending(t, q)
+	if p.ToolName != "shell_exec" {
+		t.Fatalf("unexpected tool %q", p.ToolName)
+	}
+	if err := q.Resolve(p.ID, true); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if !<-done {
+		t.Error("expected approval")
+	}
+	if err := q.Resolve(p.ID, true); err != ErrNotFound {
+		t.Errorf("second resolve should fail, got %v", err)
+	}
+	if len(q.List()) != 0 {
+		t.Error("queue should be empty")
+	}
+}
+
+func TestQueue_TimeoutDenies(t *testing.T) {
+	q := NewQueue(20*time.Millisecond, "", zap.NewNop())
+	ok, err := q.Request(context.Background(), "write_file", nil)
+	if ok || err != nil {
+		t.Errorf("expected timeout deny, got ok=%v err=%v", ok, err)
+	}
+	if len(q.List()) != 0 {
+		t.Error("timed out request should be removed")
+	}
+}
+
+func TestQueue_Cancel(t *testing.T) {
+	q := NewQueue(time.Minute, "", zap.NewNop())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ok, err := q.Request(ctx, "write_file", nil)
+	if ok || err == nil {
+		t.Errorf("expected cancel error, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestConsole_Answers(t *testing.T) {
+	var out strings.Builder
+	c := NewConsole(strings.NewReader("y\nno\n"), &out, time.Second)
+
+	if ok, _ := c.Request(context.Background(), "shell_exec", nil); !ok {
+		t.Error("expected first answer to approve")
+	}
+	if ok, _ := c.Request(context.Background(), "shell_exec", nil); ok {
+		t.Error("expected second answer to deny")
+	}
+	if ok, _ := c.Request(context.Background(), "shell_exec", nil); ok {
+		t.Error("expected closed stdin to deny")
+	}
+}
@@ -0,0 +1,452 @@
+// Package backup archives and restores the full gateway state — the
+// database, ~/.ngoclaw (config, prompts, skills, memory, transcripts) and
+// transcript directories kept outside it — as one tar.gz, optionally with
+// credentials blanked or the whole archive encrypted with a passphrase.
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // sqlite3 driver for VACUUM INTO
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+)
+
+// FormatVersion is the archive layout version written to the manifest.
+const FormatVersion = 1
+
+// Archive layout
+const (
+	manifestName = "manifest.json"
+	homePrefix   = "home/"
+	dbEntry      = "db/database.sqlite"
+	extraPrefix  = "extra/"
+)
+
+// skipDirs 不打包的 ~/.ngoclaw 子目录 (日志与可重新生成的缓存)
+var skipDirs = map[string]bool{
+	"logs":           true,
+	".sync/repo.git": true,
+}
+
+// ErrHomeExists is returned by Restore when the target already holds a configured gateway.
+var ErrHomeExists = errors.New("target already has a config.yaml (use --force to overwrite)")
+
+// Options 备份选项
+type Options struct {
+	Home           string                // ~/.ngoclaw
+	Database       config.DatabaseConfig // sqlite 文件打包; 其他数据库需自行导出
+	Extra          []string              // Home 之外需要打包的目录 (log.transcripts.dir)
+	ExcludeSecrets bool                  // 清空配置中的密钥, 不打包 .env
+	Passphrase     string                // 非空时加密整个归档
+	Version        string                // 写入 manifest 的 ngoclaw 版本
+}
+
+// Manifest 归档的第一个条目, 描述内容与来源
+type Manifest struct {
+	Format          int       `json:"format"`
+	CreatedAt       time.Time `json:"created_at"`
+	Host            string    `json:"host,omitempty"`
+	Version         string    `json:"version,omitempty"`
+	Home            string    `json:"home"`
+	Database        string    `json:"database,omitempty"`      // "sqlite" when db/database.sqlite is included
+	DatabaseNote    string    `json:"database_note,omitempty"` // why the database is not included
+	Extra           []string  `json:"extra,omitempty"`         // original paths of extra/<i>/
+	SecretsExcluded bool      `json:"secrets_excluded"`
+	Encrypted       bool      `json:"encrypted"`
+}
+
+// Result 备份或恢复的统计
+type Result struct {
+	Manifest Manifest
+	Files    int
+	Bytes    int64
+}
+
+// Create writes a backup archive of the gateway state to w.
+func Create(ctx context.Context, w io.Writer, opts Options) (*Result, error) {
+	host, _ := os.Hostname()
+	res := &Result{Manifest: Manifest{
+		Format:          FormatVersion,
+		CreatedAt:       time.Now().UTC(),
+		Host:            host,
+		Version:         opts.Version,
+		Home:            opts.Home,
+		SecretsExcluded: opts.ExcludeSecrets,
+		Encrypted:       opts.Passphrase != "",
+	}}
+	for _, dir := range opts.Extra {
+		if dir != "" && !within(opts.Home, dir) {
+			res.Manifest.Extra = append(res.Manifest.Extra, dir)
+		}
+	}
+
+	// 数据库快照先写到临时文件, 确认可用后再写 manifest
+	dbFile, dbPath, err := snapshotDatabase(ctx, opts.Database, &res.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	if dbFile != "" {
+		defer os.Remove(dbFile)
+	}
+
+	out := w
+	var enc *encryptWriter
+	if opts.Passphrase != "" {
+		if enc, err = newEncryptWriter(w, opts.Passphrase); err != nil {
+			return nil, err
+		}
+		out = enc
+	}
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	a := &archiver{tw: tw, res: res, opts: opts, skip: map[string]bool{}}
+	if dbPath != "" {
+		for _, suffix := range []string{"", "-wal", "-shm", "-journal"} {
+			a.skip[dbPath+suffix] = true
+		}
+	}
+
+	manifest, _ := json.MarshalIndent(res.Manifest, "", "  ")
+	if err := a.addBytes(manifestName, manifest, 0o644); err != nil {
+		return nil, err
+	}
+	if err := a.addTree(ctx, opts.Home, homePrefix, true); err != nil {
+		return nil, err
+	}
+	for i, dir := range res.Manifest.Extra {
+		if err := a.addTree(ctx, dir, extraPrefix+strconv.Itoa(i)+"/", false); err != nil {
+			return nil, err
+		}
+	}
+	if dbFile != "" {
+		if err := a.addFile(dbFile, dbEntry, 0o600); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	if enc != nil {
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// snapshotDatabase copies a sqlite database with VACUUM INTO, which is
+// consistent while the gateway is running. Returns the snapshot file and
+// the live database path; other databases are noted in the manifest.
+func snapshotDatabase(ctx context.Context, cfg config.DatabaseConfig, m *Manifest) (string, string, error) {
+	if cfg.Type != "sqlite" {
+		m.DatabaseNote = cfg.Type + " database not included, export it with its own tools (e.g. pg_dump)"
+		return "", "", nil
+	}
+	dbPath := SQLitePath(cfg.DSN)
+	if dbPath == "" {
+		m.DatabaseNote = "in-memory sqlite database, nothing to back up"
+		return "", "", nil
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		m.DatabaseNote = "sqlite database " + dbPath + " not found"
+		return "", "", nil
+	}
+
+	tmp, err := os.CreateTemp("", "ngoclaw-db-*.sqlite")
+	if err != nil {
+		return "", "", err
+	}
+	tmp.Close()
+	os.Remove(tmp.Name()) // VACUUM INTO requires a new file
+
+	db, err := sql.Open("sqlite3", cfg.DSN)
+	if err != nil {
+		return "", "", err
+	}
+	defer db.Close()
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", tmp.Name()); err != nil {
+		os.Remove(tmp.Name())
+		return "", "", fmt.Errorf("snapshot sqlite %s: %w", dbPath, err)
+	}
+	m.Database = "sqlite"
+	abs, _ := filepath.Abs(dbPath)
+	return tmp.Name(), abs, nil
+}
+
+// SQLitePath extracts the file path from a sqlite DSN ("file:x.db?mode=rwc"
+// → "x.db"); "" for in-memory databases.
+func SQLitePath(dsn string) string {
+	p := strings.TrimPrefix(dsn, "file:")
+	if i := strings.IndexByte(p, '?'); i >= 0 {
+		p = p[:i]
+	}
+	if p == "" || p == ":memory:" {
+		return ""
+	}
+	return p
+}
+
+type archiver struct {
+	tw   *tar.Writer
+	res  *Result
+	opts Options
+	skip map[string]bool // absolute paths not to archive (live database files)
+}
+
+// addTree archives the regular files under root with the given entry prefix.
+// Symlinks and special files are skipped. home enables the ~/.ngoclaw
+// exclusions and secret redaction.
+func (a *archiver) addTree(ctx context.Context, root, prefix string, home bool) error {
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if home && skipDirs[rel] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || strings.HasSuffix(rel, ".tmp") {
+			return nil
+		}
+		if abs, _ := filepath.Abs(p); a.skip[abs] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if home && a.opts.ExcludeSecrets {
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			redacted, keep := redactFile(rel, data)
+			if !keep {
+				return nil
+			}
+			return a.addBytes(prefix+rel, redacted, info.Mode().Perm())
+		}
+		return a.addFile(p, prefix+rel, info.Mode().Perm())
+	})
+}
+
+func (a *archiver) addFile(src, name string, mode fs.FileMode) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{Name: name, Mode: int64(mode), Size: info.Size(), ModTime: info.ModTime(), Typeflag: tar.TypeReg}
+	if err := a.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	n, err := io.CopyN(a.tw, f, info.Size())
+	a.res.Files++
+	a.res.Bytes += n
+	return err
+}
+
+func (a *archiver) addBytes(name string, data []byte, mode fs.FileMode) error {
+	hdr := &tar.Header{Name: name, Mode: int64(mode), Size: int64(len(data)), ModTime: time.Now(), Typeflag: tar.TypeReg}
+	if err := a.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := a.tw.Write(data)
+	if name != manifestName {
+		a.res.Files++
+		a.res.Bytes += int64(len(data))
+	}
+	return err
+}
+
+// RestoreOptions 恢复选项
+type RestoreOptions struct {
+	Home       string // 恢复到的 ~/.ngoclaw
+	Passphrase string // 加密归档的口令
+	Force      bool   // 覆盖已有的 config.yaml 与数据库
+
+	// DatabasePath returns where to write the sqlite database. Called after
+	// the home directory is restored, so it can read the restored config.
+	DatabasePath func() (string, error)
+}
+
+// Restore unpacks a backup archive created by Create. Existing files are
+// overwritten, files missing from the archive are kept.
+func Restore(ctx context.Context, r io.Reader, opts RestoreOptions) (*Result, error) {
+	if !opts.Force {
+		if _, err := os.Stat(filepath.Join(opts.Home, "config.yaml")); err == nil {
+			return nil, ErrHomeExists
+		}
+	}
+
+	br := bufio.NewReader(r)
+	var in io.Reader = br
+	if isEncrypted(br) {
+		if opts.Passphrase == "" {
+			return nil, ErrNeedPassphrase
+		}
+		dec, err := newDecryptReader(br, opts.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		in = dec
+	}
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	res := &Result{}
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != manifestName {
+		return nil, fmt.Errorf("not a backup archive: missing %s", manifestName)
+	}
+	if err := json.NewDecoder(tr).Decode(&res.Manifest); err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	if res.Manifest.Format > FormatVersion {
+		return nil, fmt.Errorf("archive format %d is newer than supported (%d), upgrade ngoclaw", res.Manifest.Format, FormatVersion)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return res, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		var dest string
+		switch {
+		case strings.HasPrefix(hdr.Name, homePrefix):
+			dest, err = safeJoin(opts.Home, strings.TrimPrefix(hdr.Name, homePrefix))
+		case strings.HasPrefix(hdr.Name, extraPrefix):
+			dest, err = extraDest(res.Manifest.Extra, strings.TrimPrefix(hdr.Name, extraPrefix))
+		case hdr.Name == dbEntry:
+			dest, err = restoreDatabasePath(opts)
+		default:
+			continue
+		}
+		if err != nil {
+			return res, err
+		}
+		if dest == "" {
+			continue
+		}
+		if err := writeFile(dest, tr, fs.FileMode(hdr.Mode).Perm()); err != nil {
+			return res, err
+		}
+		res.Files++
+		res.Bytes += hdr.Size
+	}
+	return res, nil
+}
+
+// restoreDatabasePath resolves the database target and removes the stale
+// WAL files of the database being replaced.
+func restoreDatabasePath(opts RestoreOptions) (string, error) {
+	if opts.DatabasePath == nil {
+		return "", nil
+	}
+	dest, err := opts.DatabasePath()
+	if err != nil || dest == "" {
+		return "", err
+	}
+	if _, err := os.Stat(dest); err == nil && !opts.Force {
+		return "", fmt.Errorf("database %s already exists (use --force to overwrite)", dest)
+	}
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		os.Remove(dest + suffix)
+	}
+	return dest, nil
+}
+
+// extraDest maps "extra/<i>/<rel>" back to the original directory.
+func extraDest(extra []string, name string) (string, error) {
+	idx, rel, ok := strings.Cut(name, "/")
+	i, err := strconv.Atoi(idx)
+	if !ok || err != nil || i < 0 || i >= len(extra) {
+		return "", fmt.Errorf("unexpected archive entry %q", extraPrefix+name)
+	}
+	return safeJoin(extra[i], rel)
+}
+
+// safeJoin joins an archive path onto root, rejecting paths that escape it.
+func safeJoin(root, name string) (string, error) {
+	clean := path.Clean(name)
+	if path.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("unsafe archive entry %q", name)
+	}
+	return filepath.Join(root, filepath.FromSlash(clean)), nil
+}
+
+// writeFile writes r to dest through a temporary file.
+func writeFile(dest string, r io.Reader, mode fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp := dest + ".restore.tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode|0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
+
+// within reports whether path lies inside dir.
+func within(dir, p string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
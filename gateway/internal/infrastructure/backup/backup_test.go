@@ -0,0 +1,215 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+)
+
+const testConfig = `agent:
+  default_model: bailian/qwen3-max
+  max_tokens: 4096
+providers:
+  - name: bailian
+    api_key: sk-live-123
+telegram:
+  bot_token: "123:abc"
+database:
+  dsn: postgres://ngo:hunter2@db/ngoclaw
+`
+
+// gatewayFixture 一个已配置的网关: ~/.ngoclaw、外部转录目录与 sqlite 数据库
+func gatewayFixture(t *testing.T) Options {
+	t.Helper()
+	root := t.TempDir()
+	home := filepath.Join(root, "home", ".ngoclaw")
+	files := map[string]string{
+		"config.yaml":               testConfig,
+		"mcp.json":                  `{"servers":{"gh":{"command":"gh-mcp","env":{"GITHUB_TOKEN":"ghp_x"}}}}`,
+		".env":                      "OPENAI_API_KEY=sk-env\n",
+		"soul.md":                   "# Soul\n",
+		"memory.json":               `{"facts":[]}`,
+		"memory/2026-10-16.md":      "- [10:00] [memory] likes tea\n",
+		"skills/deploy/SKILL.md":    "---\nname: deploy\n---\n",
+		"logs/gateway.log":          "noise\n",
+		".sync/repo.git/HEAD":       "ref: refs/heads/main\n",
+		".sync/manifest.json":       `{"commit":"abc"}`,
+		"transcripts/2026-10-16.md": "## 10:00:00 · telegram:1 · m\n",
+	}
+	for rel, content := range files {
+		p := filepath.Join(home, filepath.FromSlash(rel))
+		os.MkdirAll(filepath.Dir(p), 0o755)
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runs := filepath.Join(root, "var", "runs")
+	os.MkdirAll(runs, 0o755)
+	os.WriteFile(filepath.Join(runs, "2026-10-15.md"), []byte("## run\n"), 0o644)
+
+	dbPath := filepath.Join(root, "ngoclaw.db")
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE messages (id TEXT, content TEXT); INSERT INTO messages VALUES ('m1', 'hello')`); err != nil {
+		t.Fatal(err)
+	}
+	return Options{
+		Home:     home,
+		Database: config.DatabaseConfig{Type: "sqlite", DSN: dbPath},
+		Extra:    []string{runs, filepath.Join(home, "transcripts")},
+		Version:  "test",
+	}
+}
+
+func readFile(t *testing.T, p string) string {
+	t.Helper()
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestBackup_RoundTrip(t *testing.T) {
+	opts := gatewayFixture(t)
+	var archive bytes.Buffer
+	created, err := Create(context.Background(), &archive, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Manifest.Database != "sqlite" || len(created.Manifest.Extra) != 1 {
+		t.Fatalf("manifest = %+v", created.Manifest)
+	}
+
+	target := t.TempDir()
+	home := filepath.Join(target, ".ngoclaw")
+	dbPath := filepath.Join(target, "restored.db")
+	os.RemoveAll(opts.Extra[0]) // restored to its original path
+	restored, err := Restore(context.Background(), bytes.NewReader(archive.Bytes()), RestoreOptions{
+		Home:         home,
+		DatabasePath: func() (string, error) { return dbPath, nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Files != created.Files {
+		t.Errorf("restored %d files, archived %d", restored.Files, created.Files)
+	}
+
+	if got := readFile(t, filepath.Join(home, "config.yaml")); got != testConfig {
+		t.Errorf("config.yaml = %q", got)
+	}
+	for _, rel := range []string{".env", "memory/2026-10-16.md", "skills/deploy/SKILL.md", ".sync/manifest.json", "transcripts/2026-10-16.md"} {
+		if _, err := os.Stat(filepath.Join(home, rel)); err != nil {
+			t.Errorf("%s not restored: %v", rel, err)
+		}
+	}
+	for _, rel := range []string{"logs/gateway.log", ".sync/repo.git/HEAD"} {
+		if _, err := os.Stat(filepath.Join(home, rel)); !os.IsNotExist(err) {
+			t.Errorf("%s should not be archived", rel)
+		}
+	}
+	if got := readFile(t, filepath.Join(opts.Extra[0], "2026-10-15.md")); got != "## run\n" {
+		t.Errorf("extra dir = %q", got)
+	}
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var content string
+	if err := db.QueryRow(`SELECT content FROM messages WHERE id = 'm1'`).Scan(&content); err != nil || content != "hello" {
+		t.Fatalf("restored database: %q %v", content, err)
+	}
+
+	// 已配置的目标需要 --force
+	_, err = Restore(context.Background(), bytes.NewReader(archive.Bytes()), RestoreOptions{Home: home})
+	if !errors.Is(err, ErrHomeExists) {
+		t.Fatalf("restore over existing home: %v", err)
+	}
+}
+
+func TestBackup_ExcludeSecrets(t *testing.T) {
+	opts := gatewayFixture(t)
+	opts.ExcludeSecrets = true
+	var archive bytes.Buffer
+	if _, err := Create(context.Background(), &archive, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	home := filepath.Join(t.TempDir(), ".ngoclaw")
+	res, err := Restore(context.Background(), &archive, RestoreOptions{Home: home})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Manifest.SecretsExcluded {
+		t.Error("manifest does not record excluded secrets")
+	}
+
+	cfg := readFile(t, filepath.Join(home, "config.yaml"))
+	for _, secret := range []string{"sk-live-123", "123:abc", "hunter2"} {
+		if strings.Contains(cfg, secret) {
+			t.Errorf("config.yaml still contains %q:\n%s", secret, cfg)
+		}
+	}
+	if !strings.Contains(cfg, "max_tokens: 4096") || !strings.Contains(cfg, "default_model: bailian/qwen3-max") {
+		t.Errorf("non-secret settings lost:\n%s", cfg)
+	}
+	if mcp := readFile(t, filepath.Join(home, "mcp.json")); strings.Contains(mcp, "ghp_x") || !strings.Contains(mcp, "gh-mcp") {
+		t.Errorf("mcp.json = %s", mcp)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".env")); !os.IsNotExist(err) {
+		t.Error(".env archived without secrets")
+	}
+}
+
+func TestBackup_Encrypted(t *testing.T) {
+	opts := gatewayFixture(t)
+	opts.Passphrase = "correct horse"
+	var archive bytes.Buffer
+	if _, err := Create(context.Background(), &archive, opts); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(archive.Bytes(), []byte("sk-live-123")) || !bytes.HasPrefix(archive.Bytes(), []byte(encMagic)) {
+		t.Fatal("archive is not encrypted")
+	}
+
+	restore := func(data []byte, pass string) error {
+		_, err := Restore(context.Background(), bytes.NewReader(data), RestoreOptions{Home: filepath.Join(t.TempDir(), "h"), Passphrase: pass})
+		return err
+	}
+	if err := restore(archive.Bytes(), ""); !errors.Is(err, ErrNeedPassphrase) {
+		t.Errorf("no passphrase: %v", err)
+	}
+	if err := restore(archive.Bytes(), "wrong"); !errors.Is(err, ErrPassphrase) {
+		t.Errorf("wrong passphrase: %v", err)
+	}
+	if err := restore(archive.Bytes()[:archive.Len()-10], "correct horse"); err == nil {
+		t.Error("truncated archive restored without error")
+	}
+	if err := restore(archive.Bytes(), "correct horse"); err != nil {
+		t.Errorf("restore: %v", err)
+	}
+}
+
+func TestSafeJoin(t *testing.T) {
+	for _, name := range []string{"../etc/passwd", "a/../../b", "/etc/passwd", ""} {
+		if _, err := safeJoin("/root", name); err == nil {
+			t.Errorf("safeJoin accepted %q", name)
+		}
+	}
+	if p, err := safeJoin("/root", "memory/../soul.md"); err != nil || p != "/root/soul.md" {
+		t.Errorf("safeJoin = %q, %v", p, err)
+	}
+}
@@ -0,0 +1,196 @@
+package backup
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Encrypted archive layout:
+//
+//	magic (8) | salt (16) | nonce (12) | chunk...
+//	chunk = uint32 header (bit 31: final, low bits: ciphertext length) | AES-256-GCM ciphertext
+//
+// Each chunk seals up to chunkSize bytes. Its nonce is the base nonce with
+// the chunk counter XORed into the last 8 bytes; the final flag is the
+// additional data, so reordered, dropped or truncated chunks fail to open.
+const (
+	encMagic   = "NGCBAK01"
+	saltSize   = 16
+	chunkSize  = 64 << 10
+	finalChunk = 1 << 31
+)
+
+// ErrPassphrase is returned when an encrypted archive cannot be opened.
+var ErrPassphrase = errors.New("wrong passphrase or corrupted archive")
+
+// ErrNeedPassphrase is returned when restoring an encrypted archive without a passphrase.
+var ErrNeedPassphrase = errors.New("archive is encrypted, passphrase required")
+
+func deriveKey(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(base []byte, seq uint64) []byte {
+	nonce := append([]byte(nil), base...)
+	tail := binary.BigEndian.Uint64(nonce[len(nonce)-8:]) ^ seq
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], tail)
+	return nonce
+}
+
+func chunkAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// encryptWriter seals everything written to it; Close writes the final chunk.
+type encryptWriter struct {
+	w     io.Writer
+	aead  cipher.AEAD
+	nonce []byte
+	seq   uint64
+	buf   []byte
+}
+
+func newEncryptWriter(w io.Writer, passphrase string) (*encryptWriter, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	header := append(append([]byte(encMagic), salt...), nonce...)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{w: w, aead: aead, nonce: nonce, buf: make([]byte, 0, chunkSize)}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		take := min(chunkSize-len(e.buf), len(p))
+		e.buf = append(e.buf, p[:take]...)
+		p, n = p[take:], n+take
+		if len(e.buf) == chunkSize {
+			if err := e.seal(false); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (e *encryptWriter) Close() error {
+	return e.seal(true)
+}
+
+func (e *encryptWriter) seal(final bool) error {
+	ct := e.aead.Seal(nil, chunkNonce(e.nonce, e.seq), e.buf, chunkAD(final))
+	e.seq++
+	e.buf = e.buf[:0]
+	header := uint32(len(ct))
+	if final {
+		header |= finalChunk
+	}
+	if err := binary.Write(e.w, binary.BigEndian, header); err != nil {
+		return err
+	}
+	_, err := e.w.Write(ct)
+	return err
+}
+
+// decryptReader opens the chunks written by encryptWriter.
+type decryptReader struct {
+	r     io.Reader
+	aead  cipher.AEAD
+	nonce []byte
+	seq   uint64
+	buf   []byte
+	done  bool
+}
+
+func newDecryptReader(r io.Reader, passphrase string) (*decryptReader, error) {
+	header := make([]byte, len(encMagic)+saltSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	aead, err := deriveKey(passphrase, header[len(encMagic):])
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(r, nonce); err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	return &decryptReader{r: r, aead: aead, nonce: nonce}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+func (d *decryptReader) open() error {
+	var header uint32
+	if err := binary.Read(d.r, binary.BigEndian, &header); err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("archive truncated: %w", io.ErrUnexpectedEOF)
+		}
+		return err
+	}
+	final := header&finalChunk != 0
+	size := header &^ finalChunk
+	if size > chunkSize+uint32(d.aead.Overhead()) {
+		return ErrPassphrase
+	}
+	ct := make([]byte, size)
+	if _, err := io.ReadFull(d.r, ct); err != nil {
+		return fmt.Errorf("archive truncated: %w", err)
+	}
+	pt, err := d.aead.Open(nil, chunkNonce(d.nonce, d.seq), ct, chunkAD(final))
+	if err != nil {
+		return ErrPassphrase
+	}
+	d.seq++
+	d.buf, d.done = pt, final
+	return nil
+}
+
+// isEncrypted reports whether the archive starts with the encryption magic.
+func isEncrypted(r *bufio.Reader) bool {
+	head, _ := r.Peek(len(encMagic))
+	return string(head) == encMagic
+}
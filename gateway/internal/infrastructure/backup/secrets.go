@@ -0,0 +1,141 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// secretKey matches config keys holding credentials (snake_case; camelCase
+// keys are converted first): api_key, bot_token, GITHUB_TOKEN, password, ...
+var secretKey = regexp.MustCompile(`(^|_)(api_?key|token|secret|password|passwd|credentials?|authorization|private_key)$`)
+
+// dsnCredentials matches a DSN that embeds a password (user:pass@host, password=...).
+var dsnCredentials = regexp.MustCompile(`://[^/@\s]+:[^/@\s]*@|(?i:password=)`)
+
+// isSecretKey reports whether a config value under key is a credential.
+func isSecretKey(key string) bool {
+	return secretKey.MatchString(snakeCase(key))
+}
+
+func snakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 && s[i-1] != '_' && !unicode.IsUpper(rune(s[i-1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// secretValue reports whether the value of key must be blanked.
+func secretValue(key, value string) bool {
+	if value == "" {
+		return false
+	}
+	if strings.EqualFold(key, "dsn") {
+		return dsnCredentials.MatchString(value)
+	}
+	return isSecretKey(key)
+}
+
+// redactFile blanks credentials in a config file. ok is false for files
+// left out of a secret-free backup entirely (.env). Files that are not
+// config or fail to parse are returned unchanged.
+func redactFile(name string, data []byte) (out []byte, ok bool) {
+	base := strings.ToLower(filepath.Base(name))
+	switch {
+	case base == ".env" || strings.HasPrefix(base, ".env."):
+		return nil, false
+	case strings.HasSuffix(base, ".yaml") || strings.HasSuffix(base, ".yml"):
+		return redactYAML(data), true
+	case strings.HasSuffix(base, ".json") && (base == "mcp.json" || strings.Contains(base, "config") || base == "openclaw.json"):
+		return redactJSON(data), true
+	}
+	return data, true
+}
+
+func redactYAML(data []byte) []byte {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return data
+	}
+	if !redactNode(&doc) {
+		return data
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return data
+	}
+	return buf.Bytes()
+}
+
+// redactNode blanks secret scalars under mapping keys; reports whether anything changed.
+func redactNode(n *yaml.Node) bool {
+	changed := false
+	if n.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, val := n.Content[i], n.Content[i+1]
+			if val.Kind == yaml.ScalarNode && secretValue(key.Value, val.Value) {
+				val.Value, val.Tag, val.Style = "", "!!str", yaml.DoubleQuotedStyle
+				changed = true
+			}
+		}
+	}
+	for _, c := range n.Content {
+		if redactNode(c) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+func redactJSON(data []byte) []byte {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return data
+	}
+	if !redactValue(v) {
+		return data
+	}
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return data
+	}
+	return append(out, '\n')
+}
+
+func redactValue(v interface{}) bool {
+	changed := false
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			if s, ok := val.(string); ok && secretValue(k, s) {
+				t[k] = ""
+				changed = true
+				continue
+			}
+			if redactValue(val) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, val := range t {
+			if redactValue(val) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,399 @@
+// Package bundle syncs a team-shared persona / prompt / skill bundle from a
+// git repository into ~/.ngoclaw.
+//
+// The repository is mirrored into ~/.ngoclaw/.sync/repo.git and the files of
+// the pinned commit are copied over the matching local paths. A manifest
+// (~/.ngoclaw/.sync/manifest.json) records the hash of every file written, so
+// the next sync can tell a file the bundle owns from one edited locally:
+// locally modified files are never overwritten or removed unless forced.
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+)
+
+// DefaultPaths are the bundle paths synced when Config.Paths is empty.
+var DefaultPaths = []string{"soul.md", "prompts", "skills"}
+
+// ErrNoRepo is returned when no repository is configured.
+var ErrNoRepo = errors.New("sync.repo is not configured")
+
+// Config describes where the bundle comes from and where it goes.
+type Config struct {
+	Repo  string   // git URL or local path
+	Ref   string   // tag, commit or branch; empty = pinned ref of the last sync, else the remote default branch
+	Paths []string // paths inside the repo, mirrored at the same place under Home
+	Home  string   // target directory (~/.ngoclaw)
+}
+
+// Options controls a single sync run.
+type Options struct {
+	Ref    string // overrides Config.Ref
+	Force  bool   // overwrite / remove locally modified files
+	DryRun bool   // report what would change without writing
+}
+
+// Manifest is the state of the last successful sync.
+type Manifest struct {
+	Repo     string            `json:"repo"`
+	Ref      string            `json:"ref,omitempty"`
+	Commit   string            `json:"commit"`
+	SyncedAt string            `json:"synced_at"`
+	Files    map[string]string `json:"files"` // slash path relative to Home → sha256 of the synced content
+}
+
+// Result lists what a sync changed (or would change, for a dry run).
+type Result struct {
+	Repo       string
+	Ref        string
+	Commit     string
+	PrevCommit string
+	Added      []string
+	Updated    []string
+	Removed    []string
+	Conflicts  []string // modified locally; left alone (or overwritten with Force)
+	Unchanged  int
+	DryRun     bool
+}
+
+// Changed reports whether the sync touched any file.
+func (r *Result) Changed() bool {
+	return len(r.Added)+len(r.Updated)+len(r.Removed) > 0
+}
+
+// bundleFile is one file of the pinned commit.
+type bundleFile struct {
+	path string // slash path relative to the repo root
+	mode os.FileMode
+	data []byte
+}
+
+func (c Config) syncDir() string      { return filepath.Join(c.Home, ".sync") }
+func (c Config) mirrorDir() string    { return filepath.Join(c.syncDir(), "repo.git") }
+func (c Config) manifestPath() string { return filepath.Join(c.syncDir(), "manifest.json") }
+
+func (c Config) paths() []string {
+	if len(c.Paths) == 0 {
+		return DefaultPaths
+	}
+	return c.Paths
+}
+
+// LoadManifest returns the manifest of the last sync, or nil if none.
+func LoadManifest(cfg Config) (*Manifest, error) {
+	data, err := os.ReadFile(cfg.manifestPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", cfg.manifestPath(), err)
+	}
+	return &m, nil
+}
+
+// LocalChanges lists synced files that were modified or deleted locally
+// since the last sync. Does not touch the network.
+func LocalChanges(cfg Config) (modified, deleted []string, err error) {
+	m, err := LoadManifest(cfg)
+	if err != nil || m == nil {
+		return nil, nil, err
+	}
+	for _, rel := range sortedKeys(m.Files) {
+		data, err := os.ReadFile(filepath.Join(cfg.Home, filepath.FromSlash(rel)))
+		switch {
+		case os.IsNotExist(err):
+			deleted = append(deleted, rel)
+		case err != nil:
+			return nil, nil, err
+		case hashOf(data) != m.Files[rel]:
+			modified = append(modified, rel)
+		}
+	}
+	return modified, deleted, nil
+}
+
+// Sync fetches the repository, resolves the pinned ref and copies its files
+// into Home.
+func Sync(ctx context.Context, cfg Config, opts Options) (*Result, error) {
+	if cfg.Repo == "" {
+		return nil, ErrNoRepo
+	}
+	prev, err := LoadManifest(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if prev == nil {
+		prev = &Manifest{Files: map[string]string{}}
+	}
+
+	ref := opts.Ref
+	if ref == "" {
+		ref = cfg.Ref
+	}
+	if ref == "" && prev.Repo == cfg.Repo {
+		ref = prev.Ref // stay on the version pinned by an earlier `sync --ref`
+	}
+
+	if err := fetchMirror(ctx, cfg); err != nil {
+		return nil, err
+	}
+	commit, err := resolveRef(ctx, cfg.mirrorDir(), ref)
+	if err != nil {
+		return nil, err
+	}
+	files, err := readTree(ctx, cfg.mirrorDir(), commit, cfg.paths())
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{Repo: cfg.Repo, Ref: ref, Commit: commit, PrevCommit: prev.Commit, DryRun: opts.DryRun}
+	next := &Manifest{Repo: cfg.Repo, Ref: ref, Commit: commit, Files: map[string]string{}}
+	seen := make(map[string]bool, len(files))
+
+	for _, f := range files {
+		seen[f.path] = true
+		want := hashOf(f.data)
+		target := filepath.Join(cfg.Home, filepath.FromSlash(f.path))
+		local, err := os.ReadFile(target)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		switch {
+		case os.IsNotExist(err):
+			res.Added = append(res.Added, f.path)
+		case hashOf(local) == want:
+			res.Unchanged++
+			next.Files[f.path] = want
+			continue
+		case pristine(prev, f.path, local):
+			res.Updated = append(res.Updated, f.path)
+		default:
+			res.Conflicts = append(res.Conflicts, f.path)
+			if !opts.Force {
+				if h, ok := prev.Files[f.path]; ok {
+					next.Files[f.path] = h // still ours, still modified
+				}
+				continue
+			}
+			res.Updated = append(res.Updated, f.path)
+		}
+
+		next.Files[f.path] = want
+		if !opts.DryRun {
+			if err := writeFile(target, f.data, f.mode); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Files dropped from the bundle
+	for _, rel := range sortedKeys(prev.Files) {
+		if seen[rel] {
+			continue
+		}
+		target := filepath.Join(cfg.Home, filepath.FromSlash(rel))
+		local, err := os.ReadFile(target)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hashOf(local) != prev.Files[rel] {
+			res.Conflicts = append(res.Conflicts, rel)
+			if !opts.Force {
+				continue // no longer tracked; the local copy is the user's now
+			}
+		}
+		res.Removed = append(res.Removed, rel)
+		if !opts.DryRun {
+			if err := os.Remove(target); err != nil {
+				return nil, err
+			}
+			pruneEmptyDirs(filepath.Dir(target), cfg.Home)
+		}
+	}
+
+	if opts.DryRun {
+		return res, nil
+	}
+	next.SyncedAt = time.Now().Format(time.RFC3339)
+	return res, saveManifest(cfg, next)
+}
+
+// pristine reports whether the local copy of rel is one we may replace:
+// the content of the last sync, or the untouched bootstrap default.
+func pristine(prev *Manifest, rel string, local []byte) bool {
+	if h, ok := prev.Files[rel]; ok {
+		return hashOf(local) == h
+	}
+	return config.IsBootstrapDefault(rel, local)
+}
+
+// fetchMirror clones the repository on first use, then fetches all refs.
+func fetchMirror(ctx context.Context, cfg Config) error {
+	dir := cfg.mirrorDir()
+	if _, err := os.Stat(filepath.Join(dir, "HEAD")); err != nil {
+		if err := os.MkdirAll(cfg.syncDir(), 0755); err != nil {
+			return err
+		}
+		_, err := git(ctx, "", "clone", "--mirror", "--quiet", cfg.Repo, dir)
+		return err
+	}
+	if _, err := git(ctx, dir, "remote", "set-url", "origin", cfg.Repo); err != nil {
+		return err
+	}
+	_, err := git(ctx, dir, "fetch", "--prune", "--quiet", "origin")
+	return err
+}
+
+// resolveRef turns a tag, branch or (abbreviated) commit into a commit hash.
+// Empty ref resolves to the remote default branch.
+func resolveRef(ctx context.Context, dir, ref string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	out, err := git(ctx, dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err == nil {
+		return strings.TrimSpace(out), nil
+	}
+	if isHex(ref) {
+		// Commits not reachable from any advertised ref must be fetched by hash
+		if _, ferr := git(ctx, dir, "fetch", "--quiet", "origin", ref); ferr == nil {
+			if out, err := git(ctx, dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err == nil {
+				return strings.TrimSpace(out), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("ref %q not found in the bundle repository", ref)
+}
+
+// readTree returns the regular files under paths at commit. Symlinks and
+// submodules are skipped.
+func readTree(ctx context.Context, dir, commit string, paths []string) ([]bundleFile, error) {
+	args := append([]string{"ls-tree", "-r", "-z", "--full-tree", commit, "--"}, paths...)
+	out, err := git(ctx, dir, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []bundleFile
+	for _, entry := range strings.Split(out, "\x00") {
+		// <mode> SP <type> SP <object> TAB <path>
+		meta, p, ok := strings.Cut(entry, "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 3 || fields[1] != "blob" || (fields[0] != "100644" && fields[0] != "100755") {
+			continue
+		}
+		if p != path.Clean(p) || strings.HasPrefix(p, "../") || path.IsAbs(p) || strings.HasPrefix(p, ".sync/") {
+			continue
+		}
+		data, err := git(ctx, dir, "cat-file", "blob", fields[2])
+		if err != nil {
+			return nil, err
+		}
+		mode := os.FileMode(0644)
+		if fields[0] == "100755" {
+			mode = 0755
+		}
+		files = append(files, bundleFile{path: p, mode: mode, data: []byte(data)})
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("commit %.12s has none of %s", commit, strings.Join(paths, ", "))
+	}
+	return files, nil
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+func writeFile(target string, data []byte, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(target, data, mode); err != nil {
+		return err
+	}
+	return os.Chmod(target, mode) // WriteFile keeps the mode of an existing file
+}
+
+// pruneEmptyDirs removes dir and its parents while they are empty, stopping at root.
+func pruneEmptyDirs(dir, root string) {
+	for dir != root && strings.HasPrefix(dir, root) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func saveManifest(cfg Config, m *Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(cfg.syncDir(), 0755); err != nil {
+		return err
+	}
+	tmp := cfg.manifestPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, cfg.manifestPath())
+}
+
+func hashOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func isHex(s string) bool {
+	if len(s) < 7 || len(s) > 40 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,174 @@
+package bundle
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+)
+
+// testRepo is a local git repository standing in for the team bundle.
+type testRepo struct {
+	t   *testing.T
+	dir string
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	r := &testRepo{t: t, dir: t.TempDir()}
+	r.git("init", "-q", "-b", "main")
+	return r
+}
+
+func (r *testRepo) git(args ...string) string {
+	r.t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+	cmd.Dir = r.dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commit writes files (empty content = delete) and commits them.
+func (r *testRepo) commit(files map[string]string) string {
+	r.t.Helper()
+	for rel, content := range files {
+		p := filepath.Join(r.dir, rel)
+		if content == "" {
+			os.Remove(p)
+			continue
+		}
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			r.t.Fatal(err)
+		}
+	}
+	r.git("add", "-A")
+	r.git("commit", "-q", "-m", "update")
+	return r.git("rev-parse", "HEAD")
+}
+
+func readHome(t *testing.T, home, rel string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(home, rel))
+	if err != nil {
+		return "<missing>"
+	}
+	return string(data)
+}
+
+func TestSync_PinningAndLocalChanges(t *testing.T) {
+	repo := newTestRepo(t)
+	v1 := repo.commit(map[string]string{
+		"soul.md":                "soul v1",
+		"prompts/rules.md":       "rules v1",
+		"skills/deploy/SKILL.md": "deploy v1",
+		"README.md":              "not synced",
+	})
+	repo.git("tag", "v1")
+	repo.commit(map[string]string{
+		"soul.md":                "soul v2",
+		"skills/deploy/SKILL.md": "",
+	})
+
+	home := t.TempDir()
+	cfg := Config{Repo: repo.dir, Home: home}
+	ctx := context.Background()
+
+	// Pin to v1
+	res, err := Sync(ctx, cfg, Options{Ref: "v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Commit != v1 || len(res.Added) != 3 || readHome(t, home, "soul.md") != "soul v1" {
+		t.Fatalf("first sync: %+v", res)
+	}
+	if readHome(t, home, "README.md") != "<missing>" {
+		t.Fatal("path outside sync.paths was copied")
+	}
+
+	// A plain sync stays on the pinned version
+	res, err = Sync(ctx, cfg, Options{})
+	if err != nil || res.Commit != v1 || res.Changed() || res.Unchanged != 3 {
+		t.Fatalf("re-sync: %+v %v", res, err)
+	}
+
+	// Local edit is detected and survives moving to main
+	os.WriteFile(filepath.Join(home, "soul.md"), []byte("my soul"), 0644)
+	if modified, _, _ := LocalChanges(cfg); len(modified) != 1 || modified[0] != "soul.md" {
+		t.Fatalf("local changes = %v", modified)
+	}
+	res, err = Sync(ctx, cfg, Options{Ref: "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Conflicts) != 1 || res.Conflicts[0] != "soul.md" || readHome(t, home, "soul.md") != "my soul" {
+		t.Fatalf("conflict not kept: %+v", res)
+	}
+	if len(res.Removed) != 1 || readHome(t, home, "skills/deploy/SKILL.md") != "<missing>" {
+		t.Fatalf("dropped skill not removed: %+v", res)
+	}
+	if _, err := os.Stat(filepath.Join(home, "skills", "deploy")); !os.IsNotExist(err) {
+		t.Fatal("empty skill dir left behind")
+	}
+
+	// Dry run reports without writing; --force overwrites
+	res, err = Sync(ctx, cfg, Options{Force: true, DryRun: true})
+	if err != nil || len(res.Updated) != 1 || readHome(t, home, "soul.md") != "my soul" {
+		t.Fatalf("dry run: %+v %v", res, err)
+	}
+	if _, err := Sync(ctx, cfg, Options{Force: true}); err != nil || readHome(t, home, "soul.md") != "soul v2" {
+		t.Fatalf("force: %v %q", err, readHome(t, home, "soul.md"))
+	}
+
+	if _, err := Sync(ctx, cfg, Options{Ref: "nope"}); err == nil {
+		t.Fatal("unknown ref accepted")
+	}
+}
+
+func TestSync_ReplacesBootstrapDefaults(t *testing.T) {
+	repo := newTestRepo(t)
+	repo.commit(map[string]string{"prompts/coding.md": "team coding rules", "prompts/finance.md": "team finance"})
+
+	home := t.TempDir()
+	os.MkdirAll(filepath.Join(home, "prompts"), 0755)
+	os.WriteFile(filepath.Join(home, "prompts", "coding.md"), []byte(defaultFileContent(t, "prompts/coding.md")), 0644)
+	os.WriteFile(filepath.Join(home, "prompts", "finance.md"), []byte("edited by hand"), 0644)
+
+	res, err := Sync(context.Background(), Config{Repo: repo.dir, Home: home}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Updated) != 1 || readHome(t, home, "prompts/coding.md") != "team coding rules" {
+		t.Fatalf("bootstrap default not replaced: %+v", res)
+	}
+	if len(res.Conflicts) != 1 || readHome(t, home, "prompts/finance.md") != "edited by hand" {
+		t.Fatalf("hand-edited file overwritten: %+v", res)
+	}
+}
+
+// defaultFileContent writes the bootstrap defaults into a scratch home and returns one of them.
+func defaultFileContent(t *testing.T, rel string) string {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	if err := config.Bootstrap(zap.NewNop()); err != nil {
+		t.Fatal(err)
+	}
+	home, _ := os.UserHomeDir()
+	data, err := os.ReadFile(filepath.Join(home, ".ngoclaw", rel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
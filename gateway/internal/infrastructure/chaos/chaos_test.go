@@ -0,0 +1,32 @@
+//go:build chaos
+
+package chaos
+
+import (
+	"context"
+	"testing"
+)
+
+// 运行: go test -tags chaos ./internal/infrastructure/chaos/...
+func TestScenarios(t *testing.T) {
+	scenarios, err := LoadScenarios("testdata")
+	if err != nil {
+		t.Fatalf("load scenarios: %v", err)
+	}
+	if len(scenarios) == 0 {
+		t.Fatal("no scenarios in testdata")
+	}
+
+	for _, sc := range scenarios {
+		sc := sc
+		t.Run(sc.Name, func(t *testing.T) {
+			report := Run(context.Background(), sc, nil)
+			for _, f := range report.Check(sc.Expect) {
+				t.Error(f)
+			}
+			if t.Failed() {
+				t.Logf("events: %s", report.typeTrace())
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+)
+
+// ErrScriptExhausted LLM 脚本已用完但 agent loop 仍在请求
+var ErrScriptExhausted = errors.New("chaos: llm script exhausted")
+
+// ScriptedLLM 按脚本逐步响应的 LLMClient
+type ScriptedLLM struct {
+	mu       sync.Mutex
+	steps    []LLMStep
+	next     int
+	requests []*service.LLMRequest
+}
+
+// NewScriptedLLM 创建脚本化 LLM
+func NewScriptedLLM(steps []LLMStep) *ScriptedLLM {
+	return &ScriptedLLM{steps: steps}
+}
+
+// Calls 返回已发生的调用次数
+func (s *ScriptedLLM) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next
+}
+
+// Requests 返回收到的全部请求 (用于断言注入的提示消息)
+func (s *ScriptedLLM) Requests() []*service.LLMRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*service.LLMRequest, len(s.requests))
+	copy(out, s.requests)
+	return out
+}
+
+func (s *ScriptedLLM) take(req *service.LLMRequest) (LLMStep, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	if s.next >= len(s.steps) {
+		s.next++
+		return LLMStep{}, s.next, ErrScriptExhausted
+	}
+	step := s.steps[s.next]
+	s.next++
+	return step, s.next, nil
+}
+
+// Generate 非流式调用, 与 GenerateStream 共享脚本
+func (s *ScriptedLLM) Generate(ctx context.Context, req *service.LLMRequest) (*service.LLMResponse, error) {
+	step, n, err := s.take(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := sleepCtx(ctx, step.Delay.D()+step.Stall.D()); err != nil {
+		return nil, err
+	}
+	if step.Error != "" {
+		return nil, errors.New(step.Error)
+	}
+	return buildResponse(step, n, req.Model), nil
+}
+
+// GenerateStream 按脚本发送 delta; 不关闭 deltaCh (由 agent loop 负责关闭)
+func (s *ScriptedLLM) GenerateStream(ctx context.Context, req *service.LLMRequest, deltaCh chan<- service.StreamChunk) (*service.LLMResponse, error) {
+	step, n, err := s.take(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := sleepCtx(ctx, step.Delay.D()); err != nil {
+		return nil, err
+	}
+
+	chunks := step.Chunks
+	if len(chunks) == 0 && step.Text != "" {
+		chunks = splitChunks(step.Text)
+	}
+
+	// Stall 发生在输出一半 delta 之后, 模拟 SSE 连接建立后卡住
+	stallAt := len(chunks) / 2
+	for i, c := range chunks {
+		if i == stallAt && step.Stall > 0 {
+			if err := sleepCtx(ctx, step.Stall.D()); err != nil {
+				return nil, fmt.Errorf("stream stalled: %w", err)
+			}
+		}
+		select {
+		case deltaCh <- service.StreamChunk{DeltaText: c}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if len(chunks) == 0 && step.Stall > 0 {
+		if err := sleepCtx(ctx, step.Stall.D()); err != nil {
+			return nil, fmt.Errorf("stream stalled: %w", err)
+		}
+	}
+
+	if step.Error != "" {
+		return nil, errors.New(step.Error)
+	}
+	return buildResponse(step, n, req.Model), nil
+}
+
+func buildResponse(step LLMStep, n int, model string) *service.LLMResponse {
+	resp := &service.LLMResponse{
+		Content:    step.Text,
+		ModelUsed:  model,
+		TokensUsed: step.Tokens,
+	}
+	if len(step.Chunks) > 0 {
+		resp.Content = strings.Join(step.Chunks, "")
+	}
+	for i, c := range step.ToolCalls {
+		id := c.ID
+		if id == "" {
+			id = fmt.Sprintf("call_%d_%d", n, i)
+		}
+		args := c.Args
+		if args == nil {
+			args = map[string]interface{}{}
+		}
+		resp.ToolCalls = append(resp.ToolCalls, entity.ToolCallInfo{ID: id, Name: c.Name, Arguments: args})
+	}
+	return resp
+}
+
+// splitChunks 按词切分文本, 模拟流式 delta
+func splitChunks(text string) []string {
+	words := strings.SplitAfter(text, " ")
+	out := make([]string, 0, len(words))
+	for _, w := range words {
+		if w != "" {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// ScriptedTools 按脚本响应的 ToolExecutor
+type ScriptedTools struct {
+	mu     sync.Mutex
+	steps  map[string][]ToolStep
+	kinds  map[string]string
+	counts map[string]int
+}
+
+// NewScriptedTools 创建脚本化工具执行器; kinds 可为 nil
+func NewScriptedTools(steps map[string][]ToolStep, kinds map[string]string) *ScriptedTools {
+	if steps == nil {
+		steps = map[string][]ToolStep{}
+	}
+	return &ScriptedTools{steps: steps, kinds: kinds, counts: make(map[string]int)}
+}
+
+// Counts 返回每个工具的执行次数
+func (t *ScriptedTools) Counts() map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make(map[string]int, len(t.counts))
+	for k, v := range t.counts {
+		out[k] = v
+	}
+	return out
+}
+
+// Execute 执行脚本中的下一步; 脚本用完后重复最后一步
+func (t *ScriptedTools) Execute(ctx context.Context, name string, args map[string]interface{}) (*domaintool.Result, error) {
+	t.mu.Lock()
+	script, ok := t.steps[name]
+	idx := t.counts[name]
+	t.counts[name]++
+	t.mu.Unlock()
+
+	if !ok || len(script) == 0 {
+		return nil, fmt.Errorf("tool not found: %s", name)
+	}
+	if idx >= len(script) {
+		idx = len(script) - 1
+	}
+	step := script[idx]
+
+	if step.Hang {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if err := sleepCtx(ctx, step.Delay.D()); err != nil {
+		return nil, err
+	}
+	if step.Fail != "" {
+		return nil, errors.New(step.Fail)
+	}
+	if step.Error != "" {
+		return &domaintool.Result{Output: step.Output, Success: false, Error: step.Error}, nil
+	}
+	return &domaintool.Result{Output: step.Output, Success: true}, nil
+}
+
+// GetDefinitions 为脚本中出现的每个工具生成最小定义
+func (t *ScriptedTools) GetDefinitions() []domaintool.Definition {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	names := make([]string, 0, len(t.steps))
+	for name := range t.steps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	defs := make([]domaintool.Definition, 0, len(names))
+	for _, name := range names {
+		defs = append(defs, domaintool.Definition{
+			Name:        name,
+			Description: "chaos scripted tool",
+			Parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+		})
+	}
+	return defs
+}
+
+// GetToolKind 返回场景中声明的 Kind, 未声明时与 ToolExecutorAdapter 一致默认 execute
+func (t *ScriptedTools) GetToolKind(name string) domaintool.Kind {
+	if k, ok := t.kinds[name]; ok && k != "" {
+		return domaintool.Kind(k)
+	}
+	return domaintool.KindExecute
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
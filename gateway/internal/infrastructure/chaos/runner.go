@@ -0,0 +1,216 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"go.uber.org/zap"
+)
+
+// Report 一次场景运行的完整记录
+type Report struct {
+	Scenario  string
+	Events    []entity.AgentEvent
+	Result    *service.AgentResult
+	LLMCalls  int
+	ToolCalls map[string]int
+	Requests  []*service.LLMRequest
+	Duration  time.Duration
+}
+
+// AgentConfig 根据场景生成 AgentLoopConfig。
+// 重试等待和工具超时默认压到毫秒级, 让场景在测试中快速跑完。
+func (sc *Scenario) AgentConfig() service.AgentLoopConfig {
+	cfg := service.DefaultAgentLoopConfig()
+	cfg.Model = "chaos/scripted"
+	cfg.RetryBaseWait = time.Millisecond
+	cfg.ToolTimeout = 2 * time.Second
+
+	o := sc.Config
+	if o.MaxRetries > 0 {
+		cfg.MaxRetries = o.MaxRetries
+	}
+	if o.RetryBaseWait > 0 {
+		cfg.RetryBaseWait = o.RetryBaseWait.D()
+	}
+	if o.StreamStallRetries != nil {
+		cfg.StreamStallRetries = *o.StreamStallRetries
+	}
+	if o.StreamAcceptChars > 0 {
+		cfg.StreamAcceptChars = o.StreamAcceptChars
+	}
+	if o.ToolTimeout > 0 {
+		cfg.ToolTimeout = o.ToolTimeout.D()
+	}
+	if o.CompactKeepLast > 0 {
+		cfg.CompactKeepLast = o.CompactKeepLast
+	}
+	if o.ContextMaxTokens > 0 {
+		cfg.ContextMaxTokens = o.ContextMaxTokens
+	}
+	if o.MaxTokenBudget > 0 {
+		cfg.MaxTokenBudget = o.MaxTokenBudget
+	}
+	if o.LoopWindowSize > 0 {
+		cfg.LoopWindowSize = o.LoopWindowSize
+	}
+	if o.LoopDetectThreshold > 0 {
+		cfg.LoopDetectThreshold = o.LoopDetectThreshold
+	}
+	if o.LoopNameThreshold > 0 {
+		cfg.LoopNameThreshold = o.LoopNameThreshold
+	}
+	if o.MaxParallelTools > 0 {
+		cfg.MaxParallelTools = o.MaxParallelTools
+	}
+	return cfg
+}
+
+// Run 在脚本化的 LLM / 工具上运行场景, 收集全部事件直到通道关闭
+func Run(ctx context.Context, sc *Scenario, logger *zap.Logger) *Report {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	ctx, cancel := context.WithTimeout(ctx, sc.Timeout.D())
+	defer cancel()
+
+	llm := NewScriptedLLM(sc.LLM)
+	tools := NewScriptedTools(sc.Tools, sc.ToolKinds)
+	loop := service.NewAgentLoop(llm, tools, sc.AgentConfig(), logger)
+
+	start := time.Now()
+	result, eventCh := loop.Run(ctx, sc.System, sc.Prompt, nil, "")
+
+	report := &Report{Scenario: sc.Name, Result: result}
+	for ev := range eventCh {
+		report.Events = append(report.Events, ev)
+	}
+	report.Duration = time.Since(start)
+	report.LLMCalls = llm.Calls()
+	report.ToolCalls = tools.Counts()
+	report.Requests = llm.Requests()
+	return report
+}
+
+// Count 返回指定类型事件的数量
+func (r *Report) Count(t entity.AgentEventType) int {
+	n := 0
+	for _, ev := range r.Events {
+		if ev.Type == t {
+			n++
+		}
+	}
+	return n
+}
+
+// Check 按场景期望校验报告, 返回所有不满足的断言 (空 = 通过)
+func (r *Report) Check(exp Expectations) []string {
+	var failures []string
+	fail := func(format string, args ...interface{}) {
+		failures = append(failures, fmt.Sprintf(format, args...))
+	}
+
+	if len(exp.Sequence) > 0 {
+		pos := 0
+		for _, ev := range r.Events {
+			if pos < len(exp.Sequence) && string(ev.Type) == exp.Sequence[pos] {
+				pos++
+			}
+		}
+		if pos < len(exp.Sequence) {
+			fail("event sequence: matched %d/%d, missing %q after %v (got %s)",
+				pos, len(exp.Sequence), exp.Sequence[pos], exp.Sequence[:pos], r.typeTrace())
+		}
+	}
+
+	for t, want := range exp.Counts {
+		if got := r.Count(entity.AgentEventType(t)); got != want {
+			fail("count(%s) = %d, want %d", t, got, want)
+		}
+	}
+	for t, want := range exp.MinCounts {
+		if got := r.Count(entity.AgentEventType(t)); got < want {
+			fail("count(%s) = %d, want >= %d", t, got, want)
+		}
+	}
+
+	for _, sub := range exp.Contains {
+		if !r.anyEventContains(sub) {
+			fail("no event contains %q", sub)
+		}
+	}
+	for _, sub := range exp.NotContains {
+		if r.anyEventContains(sub) {
+			fail("unexpected event containing %q", sub)
+		}
+	}
+
+	if exp.FinalContains != "" {
+		final := ""
+		if r.Result != nil {
+			final = r.Result.FinalContent
+		}
+		if !strings.Contains(final, exp.FinalContains) {
+			fail("final content %q does not contain %q", final, exp.FinalContains)
+		}
+	}
+
+	if exp.LLMCalls > 0 && r.LLMCalls != exp.LLMCalls {
+		fail("llm calls = %d, want %d", r.LLMCalls, exp.LLMCalls)
+	}
+	for name, want := range exp.ToolCalls {
+		if got := r.ToolCalls[name]; got != want {
+			fail("tool %s executed %d times, want %d", name, got, want)
+		}
+	}
+
+	if exp.MaxDuration > 0 && r.Duration > exp.MaxDuration.D() {
+		fail("run took %s, want <= %s", r.Duration, exp.MaxDuration.D())
+	}
+
+	if exp.InjectedMessage != "" && !r.requestsContain(exp.InjectedMessage) {
+		fail("no llm request carried a message containing %q", exp.InjectedMessage)
+	}
+
+	return failures
+}
+
+func (r *Report) anyEventContains(sub string) bool {
+	for _, ev := range r.Events {
+		if strings.Contains(ev.Content, sub) || strings.Contains(ev.Error, sub) {
+			return true
+		}
+		if ev.ToolCall != nil && strings.Contains(ev.ToolCall.Output, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Report) requestsContain(sub string) bool {
+	for _, req := range r.Requests {
+		for i := range req.Messages {
+			if strings.Contains(req.Messages[i].TextContent(), sub) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// typeTrace 事件类型的紧凑轨迹, 连续的 text_delta 折叠为一个
+func (r *Report) typeTrace() string {
+	var parts []string
+	for _, ev := range r.Events {
+		t := string(ev.Type)
+		if len(parts) > 0 && t == string(entity.EventTextDelta) && parts[len(parts)-1] == t {
+			continue
+		}
+		parts = append(parts, t)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
@@ -0,0 +1,173 @@
+// Package chaos 提供 agent loop 的故障注入测试工具。
+//
+// 通过 YAML 场景脚本化一个假的 LLMClient 和 ToolExecutor:
+// 第 N 步返回 429、某个工具超时、流式响应卡住等,
+// 然后对 AgentLoop 发出的 AgentEvent 序列做断言。
+// 用于为 retry / compaction / loop detection 行为编写回归测试。
+package chaos
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario 一个完整的故障注入场景
+type Scenario struct {
+	Name        string                `yaml:"name"`
+	Description string                `yaml:"description"`
+	Prompt      string                `yaml:"prompt"`
+	System      string                `yaml:"system"`
+	Timeout     Duration              `yaml:"timeout"` // 整个场景的上限 (默认 10s)
+	Config      ConfigOverrides       `yaml:"config"`
+	LLM         []LLMStep             `yaml:"llm"`        // 按调用顺序消费, 每次 GenerateStream 取一步
+	Tools       map[string][]ToolStep `yaml:"tools"`      // 按工具名分组, 每次调用取一步, 用完后重复最后一步
+	ToolKinds   map[string]string     `yaml:"tool_kinds"` // 工具 Kind 覆盖 (默认 execute; read/search 等不计入 loop detection)
+	Expect      Expectations          `yaml:"expect"`
+}
+
+// ConfigOverrides 覆盖 AgentLoopConfig 中与故障路径相关的字段 (零值 = 使用 chaos 默认值)
+type ConfigOverrides struct {
+	MaxRetries          int      `yaml:"max_retries"`
+	RetryBaseWait       Duration `yaml:"retry_base_wait"`
+	StreamStallRetries  *int     `yaml:"stream_stall_retries"` // 0 有意义 (关闭续写), nil = 默认
+	StreamAcceptChars   int      `yaml:"stream_accept_chars"`
+	ToolTimeout         Duration `yaml:"tool_timeout"`
+	CompactKeepLast     int      `yaml:"compact_keep_last"`
+	ContextMaxTokens    int      `yaml:"context_max_tokens"`
+	MaxTokenBudget      int64    `yaml:"max_token_budget"`
+	LoopWindowSize      int      `yaml:"loop_window_size"`
+	LoopDetectThreshold int      `yaml:"loop_detect_threshold"`
+	LoopNameThreshold   int      `yaml:"loop_name_threshold"`
+	MaxParallelTools    int      `yaml:"max_parallel_tools"`
+}
+
+// LLMStep 脚本化的一次 LLM 调用
+type LLMStep struct {
+	Text      string         `yaml:"text"`       // 流式输出的文本 (按 chunk 拆分发送)
+	Chunks    []string       `yaml:"chunks"`     // 显式指定 delta 切片 (优先于 Text)
+	ToolCalls []ScriptedCall `yaml:"tool_calls"` // 本步返回的工具调用
+	Error     string         `yaml:"error"`      // 非空时返回该错误 (如 "status 429: rate limited")
+	Delay     Duration       `yaml:"delay"`      // 首个 delta 前的延迟
+	Stall     Duration       `yaml:"stall"`      // 输出部分 delta 后卡住的时长; 卡住期间尊重 ctx 取消
+	Tokens    int            `yaml:"tokens"`     // 本步上报的 token 用量
+}
+
+// ScriptedCall 脚本化的工具调用
+type ScriptedCall struct {
+	ID   string                 `yaml:"id"`
+	Name string                 `yaml:"name"`
+	Args map[string]interface{} `yaml:"args"`
+}
+
+// ToolStep 脚本化的一次工具执行
+type ToolStep struct {
+	Output string   `yaml:"output"`
+	Error  string   `yaml:"error"` // 非空时返回 Success=false 的结果
+	Fail   string   `yaml:"fail"`  // 非空时 Execute 直接返回 Go error
+	Delay  Duration `yaml:"delay"` // 执行耗时; 超过 tool_timeout 即模拟超时
+	Hang   bool     `yaml:"hang"`  // 一直阻塞直到 ctx 取消
+}
+
+// Expectations 对事件流和最终结果的断言
+type Expectations struct {
+	Sequence        []string       `yaml:"sequence"`         // 事件类型的有序子序列 (不要求连续)
+	Counts          map[string]int `yaml:"counts"`           // 事件类型的精确次数
+	MinCounts       map[string]int `yaml:"min_counts"`       // 事件类型的最少次数
+	Contains        []string       `yaml:"contains"`         // 任意事件 Content/Error 中须出现的子串
+	NotContains     []string       `yaml:"not_contains"`     // 所有事件中都不得出现的子串
+	FinalContains   string         `yaml:"final_contains"`   // 最终回复须包含的子串
+	LLMCalls        int            `yaml:"llm_calls"`        // LLM 调用次数 (0 = 不检查)
+	ToolCalls       map[string]int `yaml:"tool_calls"`       // 每个工具的执行次数
+	MaxDuration     Duration       `yaml:"max_duration"`     // 场景运行耗时上限
+	InjectedMessage string         `yaml:"injected_message"` // 后续 LLM 请求中须出现的系统/用户消息子串 (如 loop 反思提示)
+}
+
+// Duration 支持 YAML 中 "250ms" / "2s" 形式的时长
+type Duration time.Duration
+
+// UnmarshalYAML 解析 Go duration 字符串
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+	if s == "" {
+		*d = 0
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// D 返回 time.Duration
+func (d Duration) D() time.Duration { return time.Duration(d) }
+
+// ParseScenario 从 YAML 字节解析场景
+func ParseScenario(data []byte) (*Scenario, error) {
+	var sc Scenario
+	if err := yaml.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("parse scenario: %w", err)
+	}
+	if sc.Prompt == "" {
+		sc.Prompt = "run scenario"
+	}
+	if sc.Timeout == 0 {
+		sc.Timeout = Duration(10 * time.Second)
+	}
+	for i, step := range sc.LLM {
+		for j, call := range step.ToolCalls {
+			if call.Name == "" {
+				return nil, fmt.Errorf("scenario %q: llm[%d].tool_calls[%d] missing name", sc.Name, i, j)
+			}
+		}
+	}
+	return &sc, nil
+}
+
+// LoadScenario 从文件加载场景, 未命名时使用文件名
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sc, err := ParseScenario(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if sc.Name == "" {
+		sc.Name = filepath.Base(path)
+	}
+	return sc, nil
+}
+
+// LoadScenarios 加载目录下所有 *.yaml / *.yml 场景, 按文件名排序
+func LoadScenarios(dir string) ([]*Scenario, error) {
+	var paths []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+	sort.Strings(paths)
+
+	scenarios := make([]*Scenario, 0, len(paths))
+	for _, p := range paths {
+		sc, err := LoadScenario(p)
+		if err != nil {
+			return nil, err
+		}
+		scenarios = append(scenarios, sc)
+	}
+	return scenarios, nil
+}
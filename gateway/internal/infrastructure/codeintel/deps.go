@@ -0,0 +1,263 @@
+package codeintel
+
+import (
+	"bufio"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxDepsPerFile caps how many dependency files a single source file can expand to,
+// so a Go file importing a huge internal package doesn't fan out unbounded.
+const maxDepsPerFile = 24
+
+var (
+	pyImportRe   = regexp.MustCompile(`^\s*import\s+([\w.]+)`)
+	pyFromRe     = regexp.MustCompile(`^\s*from\s+(\.*[\w.]*)\s+import\s+([\w, ]+)`)
+	jsImportRe   = regexp.MustCompile(`(?:from|import)\s*['"](\.{1,2}/[^'"]+)['"]`)
+	jsRequireRe  = regexp.MustCompile(`require\(\s*['"](\.{1,2}/[^'"]+)['"]\s*\)`)
+	rustModRe    = regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?mod\s+(\w+)\s*;`)
+	jsExtensions = []string{"", ".ts", ".tsx", ".js", ".jsx", ".mjs", "/index.ts", "/index.tsx", "/index.js"}
+)
+
+// DirectDependencies resolves the project-local files that path directly imports.
+// Only first-level dependencies are returned; stdlib and third-party imports are skipped.
+//
+//   - Go: imports under the enclosing go.mod module → non-test .go files of that package
+//   - Python: relative and project-absolute `import` / `from ... import`
+//   - JS/TS: relative `import ... from './x'` and `require('./x')`
+//   - Rust: `mod foo;` declarations
+func DirectDependencies(path string) []string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil
+	}
+
+	var deps []string
+	switch detectLanguage(abs) {
+	case "go":
+		deps = goDependencies(abs)
+	case "python":
+		deps = pythonDependencies(abs)
+	case "javascript", "typescript":
+		deps = jsDependencies(abs)
+	case "rust":
+		deps = rustDependencies(abs)
+	}
+
+	seen := map[string]bool{abs: true}
+	out := make([]string, 0, len(deps))
+	for _, d := range deps {
+		if seen[d] {
+			continue
+		}
+		seen[d] = true
+		out = append(out, d)
+		if len(out) >= maxDepsPerFile {
+			break
+		}
+	}
+	return out
+}
+
+func goDependencies(path string) []string {
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ImportsOnly)
+	if err != nil {
+		return nil
+	}
+	root, module := findGoModule(filepath.Dir(path))
+	if module == "" {
+		return nil
+	}
+
+	var deps []string
+	for _, imp := range f.Imports {
+		ip, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || (ip != module && !strings.HasPrefix(ip, module+"/")) {
+			continue
+		}
+		dir := filepath.Join(root, strings.TrimPrefix(ip, module))
+		deps = append(deps, goPackageFiles(dir)...)
+	}
+	return deps
+}
+
+// findGoModule walks up from dir to the nearest go.mod and returns its directory and module path.
+func findGoModule(dir string) (string, string) {
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				line = strings.TrimSpace(line)
+				if strings.HasPrefix(line, "module ") {
+					return dir, strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`)
+				}
+			}
+			return dir, ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+func goPackageFiles(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	return files
+}
+
+func pythonDependencies(path string) []string {
+	dir := filepath.Dir(path)
+	var deps []string
+	scanLines(path, func(line string) {
+		if m := pyFromRe.FindStringSubmatch(line); m != nil {
+			mod := m[1]
+			if strings.HasPrefix(mod, ".") {
+				base := dir
+				dots := len(mod) - len(strings.TrimLeft(mod, "."))
+				for i := 1; i < dots; i++ {
+					base = filepath.Dir(base)
+				}
+				rest := strings.TrimLeft(mod, ".")
+				if rest == "" {
+					// from . import a, b → sibling modules
+					for _, name := range strings.Split(m[2], ",") {
+						if p := resolvePyModule(base, strings.TrimSpace(name)); p != "" {
+							deps = append(deps, p)
+						}
+					}
+					return
+				}
+				if p := resolvePyModule(base, rest); p != "" {
+					deps = append(deps, p)
+				}
+				return
+			}
+			if p := resolvePyAbsolute(dir, mod); p != "" {
+				deps = append(deps, p)
+			}
+			return
+		}
+		if m := pyImportRe.FindStringSubmatch(line); m != nil {
+			if p := resolvePyAbsolute(dir, m[1]); p != "" {
+				deps = append(deps, p)
+			}
+		}
+	})
+	return deps
+}
+
+// resolvePyAbsolute looks for a dotted module relative to dir and a few parent directories,
+// which covers the common "run from project root" layout without needing sys.path.
+func resolvePyAbsolute(dir, mod string) string {
+	for i := 0; i < 4; i++ {
+		if p := resolvePyModule(dir, mod); p != "" {
+			return p
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return ""
+}
+
+func resolvePyModule(base, mod string) string {
+	if mod == "" {
+		return ""
+	}
+	rel := filepath.Join(strings.Split(mod, ".")...)
+	for _, candidate := range []string{
+		filepath.Join(base, rel+".py"),
+		filepath.Join(base, rel, "__init__.py"),
+	} {
+		if isRegularFile(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func jsDependencies(path string) []string {
+	dir := filepath.Dir(path)
+	var deps []string
+	scanLines(path, func(line string) {
+		for _, re := range []*regexp.Regexp{jsImportRe, jsRequireRe} {
+			for _, m := range re.FindAllStringSubmatch(line, -1) {
+				base := filepath.Join(dir, m[1])
+				for _, ext := range jsExtensions {
+					if isRegularFile(base + ext) {
+						deps = append(deps, base+ext)
+						break
+					}
+				}
+			}
+		}
+	})
+	return deps
+}
+
+func rustDependencies(path string) []string {
+	dir := filepath.Dir(path)
+	// Non-root modules (foo.rs) declare children under foo/
+	switch filepath.Base(path) {
+	case "main.rs", "lib.rs", "mod.rs":
+	default:
+		dir = filepath.Join(dir, strings.TrimSuffix(filepath.Base(path), ".rs"))
+	}
+
+	var deps []string
+	scanLines(path, func(line string) {
+		m := rustModRe.FindStringSubmatch(line)
+		if m == nil {
+			return
+		}
+		for _, candidate := range []string{
+			filepath.Join(dir, m[1]+".rs"),
+			filepath.Join(dir, m[1], "mod.rs"),
+		} {
+			if isRegularFile(candidate) {
+				deps = append(deps, candidate)
+				break
+			}
+		}
+	})
+	return deps
+}
+
+func scanLines(path string, fn func(line string)) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fn(scanner.Text())
+	}
+}
+
+func isRegularFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
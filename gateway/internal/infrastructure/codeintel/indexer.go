@@ -161,6 +161,13 @@ func (idx *Indexer) GetFileIndex(path string) (*FileIndex, bool) {
 	return fi, ok
 }
 
+// RemoveFile drops a file from the index (deleted or no longer parseable)
+func (idx *Indexer) RemoveFile(path string) {
+	idx.mu.Lock()
+	delete(idx.index, path)
+	idx.mu.Unlock()
+}
+
 // SearchSymbols finds symbols matching a query
 func (idx *Indexer) SearchSymbols(query string) []Symbol {
 	idx.mu.Lock()
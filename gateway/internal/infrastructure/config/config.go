@@ -18,8 +18,32 @@ type Config struct {
 	Log       LogConfig       `mapstructure:"log"`
 	Agent     AgentConfig     `mapstructure:"agent"`
 	Heartbeat HeartbeatConfig `mapstructure:"heartbeat"`
+	Scheduler SchedulerConfig `mapstructure:"scheduler"`
 	Memory    MemoryConfig    `mapstructure:"memory"`
+	Jobs      JobsConfig      `mapstructure:"jobs"`
+	Sandbox   SandboxConfig   `mapstructure:"sandbox"`
+	GitHub    GitHubConfig    `mapstructure:"github"`
+	Sync      SyncConfig      `mapstructure:"sync"`
+	Tunnel    TunnelConfig    `mapstructure:"tunnel"`
+	Retention RetentionConfig `mapstructure:"retention"`
+	Janitor   JanitorConfig   `mapstructure:"janitor"`
 	PythonEnv string          `mapstructure:"python_env"` // 全局 Python 环境路径 (conda/venv 根目录)
+	Locale    string          `mapstructure:"locale"`     // 界面语言 zh|en (空 = TG 默认 zh, CLI 跟随 $LANG)
+
+	// Notifications 外部通知: 运行完成、定时任务结果、预算告警发送到邮件 / webhook / ntfy / Pushover
+	Notifications NotificationsConfig `mapstructure:"notifications"`
+
+	// Policy 组织策略包: 从 HTTPS 地址拉取签名的策略 (安全名单、守卫规则、禁用模型、预算上限), 优先于本地配置
+	Policy PolicyConfig `mapstructure:"policy"`
+
+	// TTS 语音回复: /tts on 的会话把最终回复 (或其摘要) 合成为语音消息
+	TTS TTSConfig `mapstructure:"tts"`
+
+	// Channels 渠道级覆盖 (telegram | cli | http | api): 默认模型与防护栏, 运行时按消息来源选用
+	Channels map[string]ChannelConfig `mapstructure:"channels"`
+
+	// Profile 本次加载叠加的 profile 名, 空 = 未使用 profile (见 profile.go)
+	Profile string `mapstructure:"-"`
 }
 
 // GatewayConfig 网关配置
@@ -27,6 +51,51 @@ type GatewayConfig struct {
 	Host string `mapstructure:"host"`
 	Port int    `mapstructure:"port"`
 	Mode string `mapstructure:"mode"` // local, production
+	// AdminToken 启用 /admin 管理面板 (Bearer / ?token= 登录); 为空则不提供面板
+	AdminToken string `mapstructure:"admin_token"`
+	// APITokens 非空时 /api/ 与 /v1/ 需要其中之一作为 Bearer 令牌 (SDK / 其他网关的 remote_agent 调用)
+	APITokens []APITokenConfig `mapstructure:"api_tokens"`
+	// APIAllowIPs 非空时只接受这些来源地址 (IP 或 CIDR) 访问 /api/ 与 /v1/
+	APIAllowIPs []string `mapstructure:"api_allow_ips"`
+	// OIDC 企业单点登录, 保护 /admin 与 /api/、/v1/; issuer 为空则关闭
+	OIDC OIDCConfig `mapstructure:"oidc"`
+	// Share 运行记录的只读分享链接 (/share/<token>)
+	Share ShareConfig `mapstructure:"share"`
+}
+
+// ShareConfig 分享链接: 把已完成运行 (转录、diff、工具时间线) 渲染为脱敏后的
+// HTML 快照, 通过不可猜测的 URL 公开访问, 到期后删除。创建链接的 API 需要 api_tokens 或 oidc
+type ShareConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	BaseURL string        `mapstructure:"base_url"` // 对外地址, 如 https://bot.example.com; 空 = 按请求 Host 生成
+	TTL     time.Duration `mapstructure:"ttl"`      // 默认有效期
+	MaxTTL  time.Duration `mapstructure:"max_ttl"`  // 可申请的最长有效期
+	Dir     string        `mapstructure:"dir"`      // 快照目录, 默认 ~/.ngoclaw/shares
+	Keep    int           `mapstructure:"keep"`     // 内存中保留的可分享运行数
+}
+
+// OIDCConfig OIDC 登录 (授权码流程)。IdP 组按 roles 映射为 admin / operator / viewer:
+// viewer 只读, operator 另可发起运行、处理审批与广播, admin 另可修改配置
+type OIDCConfig struct {
+	Issuer       string   `mapstructure:"issuer"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url"` // https://<host>/auth/callback, 需在 IdP 中登记
+	Scopes       []string `mapstructure:"scopes"`
+	GroupsClaim  string   `mapstructure:"groups_claim"` // ID token / userinfo 中的组字段
+	// Roles 角色 → IdP 组; 用户取所在组中最高的角色
+	Roles map[string][]string `mapstructure:"roles"`
+	// DefaultRole 不在任何映射组中的用户的角色; 为空则拒绝登录
+	DefaultRole string `mapstructure:"default_role"`
+	// SessionSecret 会话 cookie 签名密钥; 为空时每次启动随机生成, 重启后需重新登录
+	SessionSecret string        `mapstructure:"session_secret"`
+	SessionTTL    time.Duration `mapstructure:"session_ttl"`
+}
+
+// APITokenConfig 一个 API 调用方; name 用于日志和 transcript 来源 (api:<name>)
+type APITokenConfig struct {
+	Name  string `mapstructure:"name"`
+	Token string `mapstructure:"token"`
 }
 
 
@@ -35,11 +104,29 @@ type GatewayConfig struct {
 type TelegramConfig struct {
 	BotToken       string   `mapstructure:"bot_token"`
 	AllowIDs       []int64  `mapstructure:"allow_ids"`
+	// 可执行管理员命令 (/config、/bash、/security 等) 的用户; 为空时使用 allow_ids, 两者都为空则不限制
+	AdminIDs       []int64  `mapstructure:"admin_ids"`
 	Mode           string   `mapstructure:"mode"` // polling, webhook
+	// webhook 模式: 更新由网关 /webhooks/telegram 接收. 设置 webhook_url 时启动时向
+	// Telegram 注册该地址; 留空则由 `ngoclaw tunnel` 或手动 setWebhook 注册
+	WebhookURL    string `mapstructure:"webhook_url"`
+	WebhookSecret string `mapstructure:"webhook_secret"` // 校验 X-Telegram-Bot-Api-Secret-Token
 	// 群组策略
 	DMPolicy       string   `mapstructure:"dm_policy"`        // open, allowlist, disabled
 	GroupPolicy    string   `mapstructure:"group_policy"`     // open, allowlist, disabled
 	GroupAllowFrom []string `mapstructure:"group_allow_from"` // 允许的群组 ID 列表
+	// 超过 3 段的长回复额外附上完整 reply.md 文档
+	LongReplyDocument bool `mapstructure:"long_reply_document"`
+	// 运行超过该时长时发送进度摘要 + 停止按钮, 之后每隔同样时长刷新; 0 = 关闭
+	LongRunNotice time.Duration `mapstructure:"long_run_notice"`
+	// 运行超过该时长且期间用户没有任何操作时, 完成后回复原消息提醒查看结果; 0 = 关闭
+	IdleNotice time.Duration `mapstructure:"idle_notice"`
+	// 运行中收到新消息: interrupt (默认) 中止当前运行并重新开始; steer 在下一步把消息作为补充指令注入
+	BusyMode string `mapstructure:"busy_mode"`
+	// 控制群: 接收敏感事件的实时通知 (配置修改、审批拒绝/超时、预算耗尽、模型提供商故障、重启); 0 = 关闭
+	ControlChat int64 `mapstructure:"control_chat"`
+	// 通知的事件类型: config, approval, budget, provider, restart; 为空 = 全部
+	ControlEvents []string `mapstructure:"control_events"`
 }
 
 // DatabaseConfig 数据库配置
@@ -50,8 +137,33 @@ type DatabaseConfig struct {
 
 // LogConfig 日志配置
 type LogConfig struct {
-	Level  string `mapstructure:"level"`
-	Format string `mapstructure:"format"`
+	Level       string              `mapstructure:"level"`
+	Format      string              `mapstructure:"format"`
+	Transcripts TranscriptLogConfig `mapstructure:"transcripts"`
+	// Journal 运行日志: 逐步记录发给模型的完整请求、响应与工具结果, 供 ngoclaw replay 回放
+	Journal JournalLogConfig `mapstructure:"journal"`
+	// AgentLog 修改过文件的运行追加到工作区的 .ngoclaw/AGENT_LOG.md (请求、文件、验证结果)
+	AgentLog AgentLogConfig `mapstructure:"agent_log"`
+}
+
+// AgentLogConfig 工作区内的 agent 变更日志
+type AgentLogConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// TranscriptLogConfig 运行转录日志: 按天写入可读的 Markdown (与 zap 日志分开)
+type TranscriptLogConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	Dir           string `mapstructure:"dir"`            // 默认 ~/.ngoclaw/transcripts
+	MaxSizeMB     int    `mapstructure:"max_size_mb"`    // 单文件上限, 超过后轮转为 YYYY-MM-DD.N.md
+	RetentionDays int    `mapstructure:"retention_days"` // 保留天数, 0 = 永久
+}
+
+// JournalLogConfig 运行日志 (每次运行一个 JSONL 文件)
+type JournalLogConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	Dir           string `mapstructure:"dir"`            // 默认 ~/.ngoclaw/journal
+	RetentionDays int    `mapstructure:"retention_days"` // 保留天数, 0 = 永久
 }
 
 // AgentConfig Agent 配置
@@ -70,6 +182,9 @@ type AgentConfig struct {
 	// Nil values / omitted keys use auto-detected defaults from resolveModelPolicy.
 	ModelPolicies map[string]ModelPolicyConfig `mapstructure:"model_policies"`
 
+	// soul.md / prompts 中 {{name}} 占位符的默认值 (如团队名、部署环境、回复语言); 各会话可用 /setvar 覆盖
+	PromptVars map[string]string `mapstructure:"prompt_vars"`
+
 	// 运行时、防护栏、工具、安全、压缩、MCP 配置
 	Runtime    RuntimeConfig    `mapstructure:"runtime"`
 	Guardrails GuardrailsConfig `mapstructure:"guardrails"`
@@ -77,30 +192,58 @@ type AgentConfig struct {
 	Security   SecurityConfig   `mapstructure:"security"`
 	Compaction CompactionConfig `mapstructure:"compaction"`
 	MCP        MCPConfig        `mapstructure:"mcp"`
+	Routing    RoutingConfig    `mapstructure:"routing"`   // 按任务意图/上下文大小自动选模型
+	Draft      DraftConfig      `mapstructure:"draft"`     // 便宜模型起草, 昂贵模型只审核
+	Output     OutputConfig     `mapstructure:"output"`    // 最终回复投递前的后处理
+	Links      LinksConfig      `mapstructure:"links"`     // 消息中链接的预读取
+	Mentions   MentionsConfig   `mapstructure:"mentions"`  // 消息中 @文件 / #符号 的预加载
+	Review     ReviewConfig     `mapstructure:"review"`    // 交付前由便宜模型自检最终回复
+	CarryOver  CarryOverConfig  `mapstructure:"carry_over"` // /new 时把上一会话的要点带入新会话
+	Budgets    []BudgetPoolConfig `mapstructure:"budgets"` // 跨会话共享的用量上限
 	GRPCPort   int              `mapstructure:"grpc_port"` // gRPC agent server port (default 50051)
 }
 
 // ModelPolicyConfig holds YAML-configurable per-model policy overrides.
 // All fields are pointers so nil = "don't override, use auto-detected value".
 type ModelPolicyConfig struct {
-	RepairToolPairing   *bool   `mapstructure:"repair_tool_pairing"`
-	EnforceTurnOrdering *bool   `mapstructure:"enforce_turn_ordering"`
-	ReasoningFormat     *string `mapstructure:"reasoning_format"`
-	ProgressInterval    *int    `mapstructure:"progress_interval"`
-	ProgressEscalation  *bool   `mapstructure:"progress_escalation"`
-	PromptStyle         *string `mapstructure:"prompt_style"`
-	SystemRoleSupport   *bool   `mapstructure:"system_role_support"`
-	ThinkingTagHint     *bool   `mapstructure:"thinking_tag_hint"`
+	RepairToolPairing   *bool    `mapstructure:"repair_tool_pairing"`
+	EnforceTurnOrdering *bool    `mapstructure:"enforce_turn_ordering"`
+	ReasoningFormat     *string  `mapstructure:"reasoning_format"`
+	ToolHistory         *string  `mapstructure:"tool_history"` // 切换模型时历史工具调用的处理: native | flatten
+	ProgressInterval    *int     `mapstructure:"progress_interval"`
+	ProgressEscalation  *bool    `mapstructure:"progress_escalation"`
+	PromptStyle         *string  `mapstructure:"prompt_style"`
+	SystemRoleSupport   *bool    `mapstructure:"system_role_support"`
+	ThinkingTagHint     *bool    `mapstructure:"thinking_tag_hint"`
+	ContextWindow       *int     `mapstructure:"context_window"`   // 上下文窗口 (tokens), 覆盖内置的已知模型值
+	ThinkingControl     *string  `mapstructure:"thinking_control"` // /think 映射的请求参数: none | effort | budget | toggle
+	InputPrice          *float64 `mapstructure:"input_price"`      // 每百万输入 token 价格 (美元), 用于请求前成本预估
+	OutputPrice         *float64 `mapstructure:"output_price"`     // 每百万输出 token 价格 (美元)
+	// 每步最多执行的工具调用数, 多出的调用不执行并提示模型下一步重新发起; 0 = 不限
+	MaxParallelToolCalls *int `mapstructure:"max_parallel_tool_calls"`
+	// 同一步中的编辑类工具按调用顺序逐个执行 (其他工具仍并行)
+	SerialEditTools *bool `mapstructure:"serial_edit_tools"`
 }
 
 // LLMProviderConfig configures a Go-native LLM provider (used by llm.Router)
 type LLMProviderConfig struct {
 	Name     string   `mapstructure:"name"`
-	Type     string   `mapstructure:"type"`     // "openai" (default) | "anthropic" | "gemini"
+	Type     string   `mapstructure:"type"`     // "openai" (default) | "anthropic" | "gemini" | "llamacpp"
 	BaseURL  string   `mapstructure:"base_url"`
 	APIKey   string   `mapstructure:"api_key"`
 	Models   []string `mapstructure:"models"`
 	Priority int      `mapstructure:"priority"`
+
+	// 出站网络 (仅作用于该 provider)
+	Proxy              string `mapstructure:"proxy"`                // 空=遵循 HTTPS_PROXY/NO_PROXY; "direct"=直连; http(s)://, socks5:// 代理
+	CAFile             string `mapstructure:"ca_file"`              // 额外信任的 PEM CA 证书 (企业 TLS 代理 / 自签名)
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"` // 跳过证书校验, 仅供测试
+
+	// 本地 GGUF 模型 (仅 type=llamacpp, 需以 -tags llamacpp 构建)
+	ModelPath   string `mapstructure:"model_path"`   // GGUF 文件路径
+	ContextSize int    `mapstructure:"context_size"` // 上下文长度 (token), 0=4096
+	GPULayers   int    `mapstructure:"gpu_layers"`   // 卸载到 GPU 的层数, 0=纯 CPU, -1=全部
+	Threads     int    `mapstructure:"threads"`      // 推理线程数, 0=CPU 核数
 }
 
 // ModelConfig 模型配置
@@ -109,29 +252,52 @@ type ModelConfig struct {
 	Alias       string `mapstructure:"alias"`       // 如 "Flash"
 	Provider    string `mapstructure:"provider"`    // 如 "Antigravity"
 	Description string `mapstructure:"description"` // 描述
+	// Capabilities 能力标记, 用于 /models 徽章: tools | vision | reasoning
+	Capabilities []string `mapstructure:"capabilities"`
 }
 
 // RuntimeConfig Agent 运行时参数 (全部可通过 config.yaml 调整)
 type RuntimeConfig struct {
-	ToolTimeout       time.Duration `mapstructure:"tool_timeout"`        // 单个工具执行超时
-	RunTimeout        time.Duration `mapstructure:"run_timeout"`         // 单次 Run 最大时长
-	SubAgentTimeout   time.Duration `mapstructure:"sub_agent_timeout"`   // 子 Agent 超时
-	SubAgentMaxSteps  int           `mapstructure:"sub_agent_max_steps"` // 子 Agent 最大步数
-	MaxTokenBudget    int64         `mapstructure:"max_token_budget"`    // Token 预算上限
-	ConcurrentTools   bool          `mapstructure:"concurrent_tools"`    // 是否并发执行工具
-	MaxRetries        int           `mapstructure:"max_retries"`         // LLM 调用最大重试次数 (default: 3)
-	RetryBaseWait     time.Duration `mapstructure:"retry_base_wait"`     // 重试基础等待时间 (default: 2s, 指数退避)
+	ToolTimeout        time.Duration `mapstructure:"tool_timeout"`         // 单个工具执行超时
+	RunTimeout         time.Duration `mapstructure:"run_timeout"`          // 单次 Run 最大时长
+	SubAgentTimeout    time.Duration `mapstructure:"sub_agent_timeout"`    // 子 Agent 超时
+	SubAgentMaxSteps   int           `mapstructure:"sub_agent_max_steps"`  // 子 Agent 最大步数
+	MaxTokenBudget     int64         `mapstructure:"max_token_budget"`     // Token 预算上限
+	ConcurrentTools    bool          `mapstructure:"concurrent_tools"`     // 是否并发执行工具
+	MaxRetries         int           `mapstructure:"max_retries"`          // LLM 调用最大重试次数 (default: 3)
+	RetryBaseWait      time.Duration `mapstructure:"retry_base_wait"`      // 重试基础等待时间 (default: 2s, 指数退避)
+	StreamStallRetries int           `mapstructure:"stream_stall_retries"` // 流式输出中途断开时从断点续写的次数, 不占用 max_retries (default: 2, 0 = 不续写)
+	StreamAcceptChars  int           `mapstructure:"stream_accept_chars"`  // 断开前已输出至少这么多字符时直接采用部分回答 (default: 4000)
+	ReadPrefetch       bool          `mapstructure:"read_prefetch"`        // read_file 后台预读直接依赖 (default: true)
+	SlowFirstToken     time.Duration `mapstructure:"slow_first_token"`     // 流式调用超过此时长没有首 token 时提示用户并降低该 provider 优先级 (default: 15s, 0 = 关闭)
+	StreamStall        time.Duration `mapstructure:"stream_stall"`         // 输出开始后停顿超过此时长同上 (default: 30s, 0 = 关闭)
+	FilterRetry        bool          `mapstructure:"filter_retry"`         // 回答被 provider 内容过滤拦截时, 移除外部获取的内容后重试一次 (default: true)
 }
 
 // GuardrailsConfig 防护栏配置
 type GuardrailsConfig struct {
-	ContextMaxTokens    int     `mapstructure:"context_max_tokens"`    // 上下文窗口大小
+	ContextMaxTokens    int     `mapstructure:"context_max_tokens"`    // 上下文窗口大小 (未知模型的兜底值, 已知模型按型号自动识别)
 	ContextWarnRatio    float64 `mapstructure:"context_warn_ratio"`    // 警告阈值 (0.7 = 70%)
 	ContextHardRatio    float64 `mapstructure:"context_hard_ratio"`    // 强制压缩阈值
 	LoopDetectWindow    int     `mapstructure:"loop_detect_window"`    // 循环检测滑动窗口
 	LoopDetectThreshold int     `mapstructure:"loop_detect_threshold"` // 精确匹配重复检测阈值
 	LoopNameThreshold   int     `mapstructure:"loop_name_threshold"`   // 同名 tool 连续调用反思阈值 (default: 8)
 	CostGuardEnabled    bool    `mapstructure:"cost_guard_enabled"`    // 启用成本保护
+	PreflightTokens     int     `mapstructure:"preflight_tokens"`      // 预估输入超过此 token 数的请求先发警告, ask 模式下需确认 (0 = 关闭)
+}
+
+// ChannelConfig 一个渠道的覆盖项, 未设置 (0 / 空) 的项沿用 agent.default_model 与 agent.guardrails
+type ChannelConfig struct {
+	DefaultModel string           `mapstructure:"default_model"`
+	Guardrails   GuardrailsConfig `mapstructure:"guardrails"`
+}
+
+// ChannelModel 渠道的默认模型: channels.<渠道>.default_model, 未设置时为 agent.default_model
+func (c *Config) ChannelModel(channel string) string {
+	if m := c.Channels[channel].DefaultModel; m != "" {
+		return m
+	}
+	return c.Agent.DefaultModel
 }
 
 // SecurityConfig 工具安全策略配置
@@ -145,11 +311,194 @@ type SecurityConfig struct {
 	TrustedTools    []string      `mapstructure:"trusted_tools"`    // 始终免确认的工具名列表
 	TrustedCommands []string      `mapstructure:"trusted_commands"` // 免确认的命令前缀
 	ApprovalTimeout time.Duration `mapstructure:"approval_timeout"` // 确认超时（默认 5m）
+
+	// RiskAnalysis: 解析 bash 命令行并按风险调整审批要求
+	//   critical (rm -rf /, curl|sh, dd of=/dev/sda ...) — 任何模式下都需确认
+	//   high     (sudo, chmod 777, 写系统路径 ...)        — 覆盖 trusted_commands, 需确认
+	//   safe     (纯只读命令)                            — ask_dangerous 下免确认
+	RiskAnalysis bool `mapstructure:"risk_analysis"`
+
+	// FallbackApproval: 无 Telegram chatID 时（HTTP API / gRPC 等）的审批通道
+	//   http    — 挂起到 /api/v1/approvals 等待处理（可选 webhook 通知）；
+	//             需要 api_tokens / oidc（或只用 /admin 面板的 admin_token），否则按 deny 处理
+	//   console — 在 gateway 终端提示 y/N
+	//   deny    — 直接拒绝
+	//   auto    — 自动批准（旧行为，不推荐）
+	FallbackApproval string `mapstructure:"fallback_approval"`
+	ApprovalWebhook  string `mapstructure:"approval_webhook"` // 新审批请求 POST 通知地址
 }
 
 // ToolsConfig 工具注册表配置
 type ToolsConfig struct {
-	Registry []ToolRegConfig `mapstructure:"registry"`
+	Registry  []ToolRegConfig  `mapstructure:"registry"`
+	Mock      ToolMockConfig   `mapstructure:"mock"`
+	Docs      DocsLookupConfig `mapstructure:"docs"`
+	Remote    RemoteConfig     `mapstructure:"remote"`
+	Peers     PeersConfig      `mapstructure:"peers"`
+	SQL       SQLConfig        `mapstructure:"sql"`
+	Typecheck TypecheckConfig  `mapstructure:"typecheck"`
+	Tests     TestsConfig      `mapstructure:"tests"`
+	Deps      DepsConfig       `mapstructure:"deps"`
+	Index     IndexConfig      `mapstructure:"index"`
+	Warm      WarmConfig       `mapstructure:"warm"`
+	Terminal  TerminalConfig   `mapstructure:"terminal"`
+	Summarize SummarizeConfig  `mapstructure:"summarize"`
+	Vision    VisionConfig     `mapstructure:"vision"`
+	Documents DocumentsConfig  `mapstructure:"documents"`
+	Subset    ToolSubsetConfig `mapstructure:"subset"`
+}
+
+// ToolSubsetConfig 每步只发送最相关的 top_k 个工具定义 (按意图、消息内容、近期使用排序), 其余可由模型通过 request_tool 调入
+type ToolSubsetConfig struct {
+	Enabled bool     `mapstructure:"enabled"`
+	TopK    int      `mapstructure:"top_k"`  // 每步发送的工具数 (不含 request_tool), 默认 12; 工具总数不超过它时不裁剪
+	Always  []string `mapstructure:"always"` // 总是发送的工具
+}
+
+// DocumentsConfig TG 发来的文档 (PDF/DOCX/文本): 抽取文本、分块, 建立本 chat 的临时检索索引, query_document 工具按需检索
+type DocumentsConfig struct {
+	Disabled   bool          `mapstructure:"disabled"`     // 关闭文档索引与 query_document 工具
+	MaxBytes   int           `mapstructure:"max_bytes"`    // 文档大小上限, 默认 20MB
+	ChunkChars int           `mapstructure:"chunk_chars"`  // 每块字符数, 默认 1500
+	TTL        time.Duration `mapstructure:"ttl"`          // 多久未使用后丢弃, 默认 24h
+	MaxPerChat int           `mapstructure:"max_per_chat"` // 每个 chat 保留的文档数, 默认 5
+}
+
+// VisionConfig analyze_image 工具: 用视觉模型读取工作区图片 (报错截图、设计稿、架构图), 主模型不支持图片时也可用
+type VisionConfig struct {
+	Model     string `mapstructure:"model"`      // 视觉模型; 空 = agent.models 中第一个标记 vision 的模型, 都没有则不注册工具
+	MaxBytes  int    `mapstructure:"max_bytes"`  // 图片大小上限, 默认 10MB
+	MaxTokens int    `mapstructure:"max_tokens"` // 回答长度上限, 默认 1500
+}
+
+// SummarizeConfig 大工具结果摘要: 超过阈值的结果全文存档, 模型只看到廉价模型写的摘要
+type SummarizeConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	Model           string        `mapstructure:"model"`            // 摘要模型 (建议用便宜的), 空 = agent.default_model
+	ThresholdTokens int           `mapstructure:"threshold_tokens"` // 超过多少 token 的结果才摘要, 默认 2000
+	MaxTokens       int           `mapstructure:"max_tokens"`       // 摘要长度上限, 默认 800
+	Wait            time.Duration `mapstructure:"wait"`             // 下一步最多等摘要多久, 超时先发原文, 默认 20s
+	SkipTools       []string      `mapstructure:"skip_tools"`       // 不摘要的工具, 默认 read_file 与编辑类工具
+}
+
+// TerminalConfig terminal 工具 (持久 PTY 会话, 按 chat + 会话名区分)
+type TerminalConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`      // 默认 true
+	MaxSessions int           `mapstructure:"max_sessions"` // 同时存在的终端上限, 默认 8
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"` // 空闲多久后关闭, 默认 30m
+}
+
+// IndexConfig 工作区索引 (网关启动时构建, 文件变更时增量更新; repo_map / grep_search 直接读取)
+type IndexConfig struct {
+	Enabled    bool `mapstructure:"enabled"`    // 默认 true
+	MaxFiles   int  `mapstructure:"max_files"`  // 索引文件上限, 默认 20000
+	Embeddings bool `mapstructure:"embeddings"` // 构建嵌入索引 (grep_search mode=semantic), 使用 memory.ollama_url / embed_model
+}
+
+// WarmConfig 构建缓存预热: 网关启动时和依赖文件变更后为工作区项目预构建依赖
+type WarmConfig struct {
+	Enabled bool          `mapstructure:"enabled"` // 默认 false
+	Go      bool          `mapstructure:"go"`      // go mod download + 编译包与测试, 默认 true
+	Node    bool          `mapstructure:"node"`    // npm / pnpm / yarn install, 默认 true
+	Python  bool          `mapstructure:"python"`  // 工作区 .venv 中 pip install, 默认 true
+	Timeout time.Duration `mapstructure:"timeout"` // 单个项目超时, 默认 10m
+}
+
+// TypecheckConfig typecheck 工具配置
+type TypecheckConfig struct {
+	AutoAfterEdit bool          `mapstructure:"auto_after_edit"` // 编辑类工具成功后自动检查, 结果附在工具结果后
+	Timeout       time.Duration `mapstructure:"timeout"`         // 自动检查超时, 默认 2m
+}
+
+// TestsConfig run_tests 工具配置
+type TestsConfig struct {
+	Timeout  time.Duration `mapstructure:"timeout"`  // 单次测试命令超时, 默认 10m
+	Baseline bool          `mapstructure:"baseline"` // 有未提交改动时在 HEAD 上再跑一遍, 给出覆盖率变化, 默认 true
+}
+
+// DepsConfig update_deps 工具配置 (依赖检查与升级)
+type DepsConfig struct {
+	GoProxy     string        `mapstructure:"goproxy"`      // Go 模块代理, 默认 https://proxy.golang.org
+	NPMRegistry string        `mapstructure:"npm_registry"` // npm 仓库, 默认 https://registry.npmjs.org
+	PyPI        string        `mapstructure:"pypi"`         // PyPI, 默认 https://pypi.org
+	Timeout     time.Duration `mapstructure:"timeout"`      // 安装与验证命令各自的超时, 默认 10m
+}
+
+// RemoteConfig remote_file / remote_exec 工具配置 (经 SSH 操作登记的远程主机)
+type RemoteConfig struct {
+	Hosts          []RemoteHostConfig `mapstructure:"hosts"`
+	KnownHosts     string             `mapstructure:"known_hosts"`     // 默认 ~/.ssh/known_hosts
+	ConnectTimeout time.Duration      `mapstructure:"connect_timeout"` // 默认 10s
+	CommandTimeout time.Duration      `mapstructure:"command_timeout"` // remote_exec 默认超时, 默认 60s
+}
+
+// PeersConfig remote_agent 工具: 可转发任务的其他 NGOClaw 网关
+type PeersConfig struct {
+	Gateways []PeerConfig `mapstructure:"gateways"`
+	MaxHops  int          `mapstructure:"max_hops"` // 任务最多经过几个网关, 默认 2 (防止互相转发成环)
+}
+
+// PeerConfig 一个对端网关; 模型只能通过 name 访问登记过的网关
+type PeerConfig struct {
+	Name        string        `mapstructure:"name"`
+	URL         string        `mapstructure:"url"`   // 对端 HTTP 地址, 如 https://lab.internal:18790
+	Token       string        `mapstructure:"token"` // 对端 gateway.api_tokens 中的令牌
+	Model       string        `mapstructure:"model"` // 空 = 对端默认模型
+	Description string        `mapstructure:"description"`
+	Timeout     time.Duration `mapstructure:"timeout"` // 单次任务超时, 默认 30m
+
+	// 出站网络, 同 provider
+	Proxy              string `mapstructure:"proxy"`
+	CAFile             string `mapstructure:"ca_file"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+// RemoteHostConfig 单台远程主机; 模型只能通过 alias 访问登记过的主机
+type RemoteHostConfig struct {
+	Alias       string `mapstructure:"alias"`
+	Host        string `mapstructure:"host"`
+	Port        int    `mapstructure:"port"` // 默认 22
+	User        string `mapstructure:"user"`
+	KeyPath     string `mapstructure:"key_path"` // 为空时使用 ssh-agent
+	WorkDir     string `mapstructure:"work_dir"`
+	Description string `mapstructure:"description"`
+	ReadOnly    bool   `mapstructure:"read_only"` // 只允许 read/list/download
+	// InsecureIgnoreHostKey 跳过 known_hosts 校验, 仅用于测试环境
+	InsecureIgnoreHostKey bool `mapstructure:"insecure_ignore_host_key"`
+}
+
+// SQLConfig sql_query 工具配置 (登记的数据库连接)
+type SQLConfig struct {
+	Connections []SQLConnectionConfig `mapstructure:"connections"`
+	MaxRows     int                   `mapstructure:"max_rows"`  // 单次返回的最大行数, 默认 100
+	MaxChars    int                   `mapstructure:"max_chars"` // 结果表格的最大字符数, 默认 8000
+	Timeout     time.Duration         `mapstructure:"timeout"`   // 单条语句超时, 默认 30s
+}
+
+// SQLConnectionConfig 单个数据库连接; 模型只能通过 name 访问登记过的连接
+type SQLConnectionConfig struct {
+	Name        string `mapstructure:"name"`
+	Driver      string `mapstructure:"driver"` // postgres | mysql | sqlite
+	DSN         string `mapstructure:"dsn"`
+	Mode        string `mapstructure:"mode"` // read_only (默认) | read_write
+	Description string `mapstructure:"description"`
+}
+
+// DocsLookupConfig docs_lookup 工具配置 (库文档检索, Context7 兼容)
+type DocsLookupConfig struct {
+	// MCPEndpoint 非空时经 MCP docs server (resolve-library-id / get-library-docs) 查询
+	MCPEndpoint string `mapstructure:"mcp_endpoint"`
+	APIURL      string `mapstructure:"api_url"`    // 直连 HTTP API, 默认 https://context7.com/api/v1
+	APIKey      string `mapstructure:"api_key"`    // 可选, 提高限流额度
+	MaxTokens   int    `mapstructure:"max_tokens"` // 单次返回文档的 token 上限
+	Disabled    bool   `mapstructure:"disabled"`
+}
+
+// ToolMockConfig 工具 mock 模式 (离线开发: 工具调用由录制的 fixture 返回)
+type ToolMockConfig struct {
+	// Mode: off (默认) | replay | record; 也可用 NGOCLAW_TOOLS_MOCK=1 开启 replay
+	Mode     string `mapstructure:"mode"`
+	Fixtures string `mapstructure:"fixtures"` // JSONL fixture 文件, 默认 ~/.ngoclaw/tool_fixtures.jsonl
 }
 
 // ToolRegConfig 单个工具注册配置
@@ -164,6 +513,20 @@ type ToolRegConfig struct {
 	Enabled    bool                `mapstructure:"enabled"`     // 是否启用
 	Timeout    time.Duration       `mapstructure:"timeout"`     // 可选，覆盖全局 tool_timeout
 	Aliases    map[string][]string `mapstructure:"aliases"`     // provider → 别名列表
+
+	// backend=command: 参数声明, 生成给模型的 JSON Schema; ArgsFormat 中以 {name} 引用
+	Description string            `mapstructure:"description"`
+	Kind        string            `mapstructure:"kind"` // read | execute (默认) | edit ..., 影响审批与并发
+	Parameters  []ToolParamConfig `mapstructure:"parameters"`
+}
+
+// ToolParamConfig 命令型工具的参数声明
+type ToolParamConfig struct {
+	Name        string   `mapstructure:"name"`
+	Type        string   `mapstructure:"type"` // string (默认) | integer | number | boolean | array
+	Description string   `mapstructure:"description"`
+	Required    bool     `mapstructure:"required"`
+	Enum        []string `mapstructure:"enum"`
 }
 
 // CompactionConfig 压缩参数配置
@@ -175,6 +538,105 @@ type CompactionConfig struct {
 	PreFlushToMemory bool `mapstructure:"pre_flush_to_memory"` // 压缩前写关键事实到向量库
 }
 
+// RoutingConfig 模型自动路由: 按顺序匹配规则, 第一条命中的规则决定本次运行的模型
+type RoutingConfig struct {
+	Enabled bool              `mapstructure:"enabled"` // 默认开关, 各会话可用 /route 覆盖
+	Rules   []RouteRuleConfig `mapstructure:"rules"`
+}
+
+// RouteRuleConfig 路由规则; 未设置的条件不参与匹配
+type RouteRuleConfig struct {
+	Name      string   `mapstructure:"name"`
+	Intent    []string `mapstructure:"intent"`     // general | coding | research | finance | system | creative
+	Images    bool     `mapstructure:"images"`     // 仅匹配带图片的消息
+	MinTokens int      `mapstructure:"min_tokens"` // 上下文 (历史 + 消息) 估算 token 下限
+	MaxTokens int      `mapstructure:"max_tokens"` // 上限, 0 = 不限
+	Model     string   `mapstructure:"model"`
+}
+
+// DraftConfig 草稿模式: 昂贵模型的请求先由便宜模型起草回答, 昂贵模型只审核或修改草稿;
+// 草稿需要调用工具或被否决时回到单模型回答
+type DraftConfig struct {
+	Enabled   bool     `mapstructure:"enabled"`    // 默认开关, 各会话可用 /draft 覆盖
+	Model     string   `mapstructure:"model"`      // 起草用的便宜模型
+	Models    []string `mapstructure:"models"`     // 启用草稿的昂贵模型 (ID 前缀), 空 = 起草模型以外的所有模型
+	MaxTokens int      `mapstructure:"max_tokens"` // 草稿长度上限, 0 = 1500
+}
+
+// LinksConfig 用户消息中的链接在运行开始前抓取, 正文 (过长时为摘要) 随消息一起交给模型
+type LinksConfig struct {
+	Mode          string        `mapstructure:"mode"`           // auto (默认) | off
+	MaxLinks      int           `mapstructure:"max_links"`      // 每条消息最多读取的链接数
+	MaxChars      int           `mapstructure:"max_chars"`      // 每个链接附带的最大字符数, 超出截断
+	SummarizeOver int           `mapstructure:"summarize_over"` // 正文超过此字符数时用摘要模型压缩; 0 = 只截断
+	SummaryModel  string        `mapstructure:"summary_model"`  // 摘要模型, 空 = tools.summarize.model, 再空 = 默认模型
+	Timeout       time.Duration `mapstructure:"timeout"`        // 全部链接的抓取+摘要总时限, 超时的链接跳过
+	AllowDomains  []string      `mapstructure:"allow_domains"`  // 非空 = 只读取这些域名 (含子域名)
+	DenyDomains   []string      `mapstructure:"deny_domains"`   // 不读取的域名 (含子域名)
+	AllowPrivate  bool          `mapstructure:"allow_private"`  // 允许访问内网/本机地址 (默认拒绝)
+}
+
+// MentionsConfig 用户消息中的 @path/to/file.go 与 #Symbol: 运行前在工作区中解析,
+// 文件内容与符号定义随消息交给模型, 消息中的提及改写为解析到的路径与位置
+type MentionsConfig struct {
+	Mode        string `mapstructure:"mode"`         // auto (默认) | off
+	MaxMentions int    `mapstructure:"max_mentions"` // 每条消息最多解析的提及数
+	MaxChars    int    `mapstructure:"max_chars"`    // 每个文件 / 定义附带的最大字符数, 超出截断
+}
+
+// ReviewConfig 运行结束后的自检: 便宜模型对照原始请求和工具输出检查最终回复并打分 (1-10),
+// 低分附加注意事项, 很低的分数退回修正一次; 分数记入运行记录 (transcript / journal)
+type ReviewConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`
+	Model        string `mapstructure:"model"`          // 自检模型, 空 = tools.summarize.model, 再空 = 默认模型
+	RetryBelow   int    `mapstructure:"retry_below"`    // 低于此分退回修正一次
+	CaveatBelow  int    `mapstructure:"caveat_below"`   // 低于此分在回复后附加注意事项
+	ToolRunsOnly bool   `mapstructure:"tool_runs_only"` // 只检查调用过工具的运行
+}
+
+// CarryOverConfig /new 的会话要点延续: 总结上一会话中值得保留的事实 (项目、偏好、任务进度) 作为新会话的开头; /new --clean 完全清空
+type CarryOverConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`
+	Model     string `mapstructure:"model"`      // 总结模型, 空 = tools.summarize.model, 再空 = 会话当前模型
+	MaxTokens int    `mapstructure:"max_tokens"` // 摘要长度上限, 默认 200
+}
+
+// BudgetPoolConfig 预算池: 用量跨运行、会话和重启累计 (存数据库), 运行开始前检查, 用完时拒绝新运行直到窗口重置
+type BudgetPoolConfig struct {
+	Name   string  `mapstructure:"name"`   // 池名, 用于 /usage quota 和存储; 空 = scope-window
+	Scope  string  `mapstructure:"scope"`  // global (默认, 整个部署) | user (每个用户) | chat (每个会话)
+	Window string  `mapstructure:"window"` // monthly (默认, 每月 1 日重置) | daily (每天零点重置)
+	Tokens int64   `mapstructure:"tokens"` // token 上限, 0 = 不限
+	USD    float64 `mapstructure:"usd"`    // 费用上限 (美元, 按 model_policies 的 input_price/output_price 估算), 0 = 不限
+}
+
+// OutputConfig 最终回复的后处理: 按顺序执行, 在 TG/CLI/HTTP/API 投递前生效
+type OutputConfig struct {
+	Processors []OutputProcessorConfig `mapstructure:"processors"`
+}
+
+// OutputProcessorConfig 后处理器; 按 type 使用对应字段
+type OutputProcessorConfig struct {
+	Name     string   `mapstructure:"name"`     // 日志中的名称, 默认取 type
+	Type     string   `mapstructure:"type"`     // replace | hosts | footer | links | command | plugin | postprocess.RegisterFactory 注册的类型
+	Channels []string `mapstructure:"channels"` // telegram | cli | http | api, 空 = 全部
+	OnError  string   `mapstructure:"on_error"` // pass (默认, 原样投递) | withhold (不投递)
+
+	Pattern     string   `mapstructure:"pattern"`     // replace: 正则
+	Replacement string   `mapstructure:"replacement"` // replace / hosts: 替换文本
+	Hosts       []string `mapstructure:"hosts"`       // hosts: 域名, 含子域名及指向它们的 URL
+	Text        string   `mapstructure:"text"`        // footer: 追加的声明, 已包含时不重复
+	From        string   `mapstructure:"from"`        // links: URL 前缀改写
+	To          string   `mapstructure:"to"`
+	Style       string   `mapstructure:"style"` // links: "" 保留 Markdown 链接 | plain: [文字](url) → 文字 (url)
+
+	Command []string      `mapstructure:"command"` // command: 从 stdin 读回复, stdout 输出新回复
+	Path    string        `mapstructure:"path"`    // plugin: Go plugin (.so) 路径
+	Timeout time.Duration `mapstructure:"timeout"` // command / plugin, 默认 10s
+
+	Options map[string]interface{} `mapstructure:"options"` // 自定义类型的参数
+}
+
 // MCPConfig MCP 服务器配置
 type MCPConfig struct {
 	Servers []MCPServerConfig `mapstructure:"servers"`
@@ -193,6 +655,15 @@ type HeartbeatConfig struct {
 	FilePath string `mapstructure:"file_path"` // HEARTBEAT.md 路径
 	Interval int    `mapstructure:"interval"`  // 检查间隔(分钟)
 	ChatID   int64  `mapstructure:"chat_id"`   // 目标 Telegram ChatID
+	CatchUp  string `mapstructure:"catch_up"`  // 覆盖 scheduler.catch_up
+}
+
+// SchedulerConfig 定时任务 (/cron) 与心跳的调度配置
+type SchedulerConfig struct {
+	// CatchUp 网关停机期间错过的运行: skip 跳过 | once 启动后补跑一次 (默认) | all 逐次补跑
+	CatchUp string `mapstructure:"catch_up"`
+	// MaxCatchUp all 模式最多补跑的次数
+	MaxCatchUp int `mapstructure:"max_catch_up"`
 }
 
 // MemoryConfig 向量记忆配置
@@ -204,15 +675,217 @@ type MemoryConfig struct {
 	StoreType  string `mapstructure:"store_type"`   // lancedb | memory
 }
 
-// Load 加载配置
+// JobsConfig 异步 agent 任务队列配置
+type JobsConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	Backend       string        `mapstructure:"backend"`    // redis | memory
+	RedisAddr     string        `mapstructure:"redis_addr"` // host:port
+	RedisPassword string        `mapstructure:"redis_password"`
+	RedisDB       int           `mapstructure:"redis_db"`
+	Prefix        string        `mapstructure:"prefix"`       // 流 key 前缀 (<prefix>:jobs / <prefix>:progress)
+	Consumer      string        `mapstructure:"consumer"`     // consumer 名称, 需跨重启稳定 (默认 hostname)
+	Concurrency   int           `mapstructure:"concurrency"`  // 同时执行的任务数
+	ReclaimIdle   time.Duration `mapstructure:"reclaim_idle"` // 其他实例的任务空闲多久后接管
+	JobTimeout    time.Duration `mapstructure:"job_timeout"`  // 单个任务超时
+	MaxAttempts   int           `mapstructure:"max_attempts"` // 最多投递次数
+}
+
+// GitHubConfig GitHub webhook 集成: PR / issue 中 @mention 时运行 agent 并回帖
+type GitHubConfig struct {
+	Enabled        bool               `mapstructure:"enabled"`
+	WebhookSecret  string             `mapstructure:"webhook_secret"`       // 与 GitHub webhook 设置中的 secret 一致
+	AppID          int64              `mapstructure:"app_id"`               // GitHub App ID (用于签发 installation token)
+	PrivateKeyPath string             `mapstructure:"private_key_path"`     // GitHub App 私钥 (.pem)
+	Token          string             `mapstructure:"token"`                // 不使用 App 时的 PAT, 设置后忽略 app_id
+	APIURL         string             `mapstructure:"api_url"`              // GitHub Enterprise: https://<host>/api/v3
+	Mention        string             `mapstructure:"mention"`              // 触发词, 如 @ngoclaw
+	Model          string             `mapstructure:"model"`                // 空 = agent.default_model
+	Concurrency    int                `mapstructure:"concurrency"`          // 同时处理的提及数
+	Timeout        time.Duration      `mapstructure:"timeout"`              // 单次运行超时
+	MaxDiffKB      int                `mapstructure:"max_diff_kb"`          // 放入 prompt 的 PR diff 上限
+	Associations   []string           `mapstructure:"allowed_associations"` // 可触发的作者身份 (OWNER/MEMBER/COLLABORATOR...)
+	Repos          []GitHubRepoConfig `mapstructure:"repos"`                // 仓库允许列表, 为空时拒绝所有
+}
+
+// GitHubRepoConfig 允许响应的仓库
+type GitHubRepoConfig struct {
+	Name         string `mapstructure:"name"`         // owner/repo, 或 owner/* 匹配该账号下所有仓库
+	Model        string `mapstructure:"model"`        // 覆盖 github.model
+	Instructions string `mapstructure:"instructions"` // 追加到系统提示词 (如 review 规范)
+}
+
+// SyncConfig ngoclaw sync: 从 git 仓库同步团队共享的 soul.md / prompts / skills
+type SyncConfig struct {
+	Repo  string   `mapstructure:"repo"`  // git URL 或本地路径
+	Ref   string   `mapstructure:"ref"`   // 固定版本: tag / commit / 分支, 空 = 远端默认分支
+	Paths []string `mapstructure:"paths"` // 仓库内同步的路径 (相对仓库根, 对应 ~/.ngoclaw 下同名路径)
+}
+
+// TunnelConfig ngoclaw tunnel: 开发时通过 SSH 反向隧道把本地网关暴露到公网,
+// 并自动把 Telegram / GitHub webhook 指向隧道地址
+type TunnelConfig struct {
+	Provider   string `mapstructure:"provider"`    // localhost.run (默认) / pinggy / ssh (自建服务器)
+	Server     string `mapstructure:"server"`      // provider=ssh: host:port
+	User       string `mapstructure:"user"`        // provider=ssh: 登录用户
+	KeyFile    string `mapstructure:"key_file"`    // SSH 私钥, 空 = ssh-agent / 免密
+	RemotePort int    `mapstructure:"remote_port"` // provider=ssh: 服务器上监听的端口
+	PublicURL  string `mapstructure:"public_url"`  // provider=ssh: 该端口对外的 https 地址
+	KnownHosts string `mapstructure:"known_hosts"` // 首次连接记录主机密钥, 之后不匹配时拒绝连接
+}
+
+// RetentionConfig 数据保留策略: 按数据类别设置保留天数, 可按会话覆盖, 后台任务定期清理过期数据
+type RetentionConfig struct {
+	ScrubInterval time.Duration              `mapstructure:"scrub_interval"` // 清理间隔, 默认 1h; 0 = 不自动清理
+	Default       RetentionPolicy            `mapstructure:"default"`
+	Chats         map[string]RetentionPolicy `mapstructure:"chats"` // chat ID (或 HTTP conversation ID) → 覆盖策略
+}
+
+// NotificationsConfig 外部通知目标
+type NotificationsConfig struct {
+	Targets []NotifyTargetConfig `mapstructure:"targets"`
+}
+
+// NotifyTargetConfig 一个通知目标; 按 type 使用对应字段
+type NotifyTargetConfig struct {
+	Name   string   `mapstructure:"name"`   // 日志中的名称, 默认取 type
+	Type   string   `mapstructure:"type"`   // email | webhook | ntfy | pushover
+	Events []string `mapstructure:"events"` // run.completed | run.failed | cron.result | budget.exhausted, 空 = 全部
+	// Title / Body 标题与正文模板 (Go text/template, 字段见 notify.Event); 空 = 默认格式
+	Title string `mapstructure:"title"`
+	Body  string `mapstructure:"body"`
+
+	URL      string            `mapstructure:"url"`      // webhook: 地址; ntfy: 主题地址 (https://ntfy.sh/<topic>)
+	Headers  map[string]string `mapstructure:"headers"`  // webhook: 额外请求头
+	Token    string            `mapstructure:"token"`    // ntfy: 访问令牌; pushover: 应用令牌
+	User     string            `mapstructure:"user"`     // pushover: 用户 key
+	Priority string            `mapstructure:"priority"` // ntfy: min|low|default|high|urgent; pushover: -2..2
+
+	SMTP SMTPConfig `mapstructure:"smtp"` // email
+	To   []string   `mapstructure:"to"`   // email: 收件人
+
+	Timeout time.Duration `mapstructure:"timeout"` // 单次投递超时, 默认 10s
+}
+
+// SMTPConfig 发信服务器; 端口 465 使用 TLS 直连, 其他端口在服务器支持时升级 STARTTLS
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"` // 默认 587
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+}
+
+// PolicyConfig 组织策略包. 启动时及每隔 interval 拉取一次, ed25519 签名校验通过后生效;
+// 拉取失败时沿用上一次校验通过的策略 (内存或 cache_path)
+type PolicyConfig struct {
+	URL       string        `mapstructure:"url"`        // 策略包地址 (必须 https), 空 = 不使用组织策略
+	PublicKey string        `mapstructure:"public_key"` // 签名公钥 (base64, ngoclaw policy keygen 生成)
+	Interval  time.Duration `mapstructure:"interval"`   // 刷新间隔, 默认 15m
+	Required  bool          `mapstructure:"required"`   // 启动时既拉取不到也没有缓存的有效策略则拒绝启动
+	CachePath string        `mapstructure:"cache_path"` // 上一次有效策略包的缓存, 默认 ~/.ngoclaw/policy.json
+}
+
+// TTSConfig 语音回复. /tts on 的会话在文字回复之后再收到一条 OGG/Opus 语音消息;
+// provider、朗读字数上限与摘要开关可由会话的 /tts provider|limit|summary 覆盖
+type TTSConfig struct {
+	Provider     string          `mapstructure:"provider"`      // 默认 provider: openai | edge
+	Limit        int             `mapstructure:"limit"`         // 朗读的最大字符数, 默认 1500 (会话可设 100-4096)
+	SummaryModel string          `mapstructure:"summary_model"` // /tts summary on 时压缩长回复的模型, 空 = agent.tools.summarize.model
+	Timeout      time.Duration   `mapstructure:"timeout"`       // 单条回复的合成超时, 默认 60s
+	OpenAI       TTSOpenAIConfig `mapstructure:"openai"`
+	Edge         TTSEdgeConfig   `mapstructure:"edge"`
+}
+
+// TTSOpenAIConfig OpenAI /audio/speech (及兼容接口), 直接返回 OGG/Opus
+type TTSOpenAIConfig struct {
+	APIKey       string `mapstructure:"api_key"`      // 空 = 不可用
+	BaseURL      string `mapstructure:"base_url"`     // 默认 https://api.openai.com/v1
+	Model        string `mapstructure:"model"`        // 默认 gpt-4o-mini-tts
+	Voice        string `mapstructure:"voice"`        // 默认 alloy
+	Instructions string `mapstructure:"instructions"` // 语气与语速说明 (仅 gpt-4o-mini-tts)
+
+	// 出站网络, 与 agent.providers 的同名设置相同
+	Proxy              string `mapstructure:"proxy"`                // 空=遵循 HTTPS_PROXY/NO_PROXY; "direct"=直连; http(s)://, socks5:// 代理
+	CAFile             string `mapstructure:"ca_file"`              // 额外信任的 PEM CA 证书 (企业 TLS 代理 / 自签名)
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"` // 跳过证书校验, 仅供测试
+}
+
+// TTSEdgeConfig Microsoft Edge 在线语音, 通过 edge-tts 命令行 (pip install edge-tts) 合成, ffmpeg 转为 OGG/Opus
+type TTSEdgeConfig struct {
+	Command string `mapstructure:"command"` // edge-tts 可执行文件, 默认 edge-tts; 不在 PATH 中则不可用
+	Voice   string `mapstructure:"voice"`   // 默认 zh-CN-XiaoxiaoNeural (edge-tts --list-voices)
+	Rate    string `mapstructure:"rate"`    // 语速, 如 +10%
+}
+
+// JanitorConfig 泄漏资源的定期清理: 无人等待的审批、退出或空闲的语言服务器、
+// 沙箱命令和终端残留的进程、临时文件、过期的分享快照与转录
+type JanitorConfig struct {
+	Interval       time.Duration `mapstructure:"interval"`         // 清理间隔, 默认 10m; 0 = 关闭
+	ApprovalMaxAge time.Duration `mapstructure:"approval_max_age"` // 未处理审批请求的最长保留, 默认 10m
+	LSPIdle        time.Duration `mapstructure:"lsp_idle"`         // 语言服务器空闲多久后关闭, 默认 30m; 0 = 只清理已退出的
+	OrphanGrace    time.Duration `mapstructure:"orphan_grace"`     // 命令 / 终端结束后残留进程的宽限期, 默认 1h; 0 = 不清理
+	TempMaxAge     time.Duration `mapstructure:"temp_max_age"`     // 临时文件与目录超过多久未修改即删除, 默认 24h; 0 = 不清理
+}
+
+// RetentionPolicy 各类数据的保留天数, 0 = 永久保留。
+// 会话覆盖中 0 表示沿用 default, 负数表示该会话永久保留。
+type RetentionPolicy struct {
+	Messages    int `mapstructure:"messages"`    // 消息与反馈 (messages / feedback 表)
+	Transcripts int `mapstructure:"transcripts"` // 运行转录
+	Artifacts   int `mapstructure:"artifacts"`   // research 报告等产物
+	Memory      int `mapstructure:"memory"`      // 长期记忆事实与每日日志
+}
+
+// PolicyFor 返回会话的有效策略 (覆盖项合并 default 后, 负数归一为 0 = 永久)
+func (c RetentionConfig) PolicyFor(chat string) RetentionPolicy {
+	p := c.Default
+	o, ok := c.Chats[chat]
+	if !ok {
+		return p
+	}
+	merge := func(dst *int, v int) {
+		switch {
+		case v < 0:
+			*dst = 0
+		case v > 0:
+			*dst = v
+		}
+	}
+	merge(&p.Messages, o.Messages)
+	merge(&p.Transcripts, o.Transcripts)
+	merge(&p.Artifacts, o.Artifacts)
+	merge(&p.Memory, o.Memory)
+	return p
+}
+
+// SandboxConfig 工具命令沙箱配置
+type SandboxConfig struct {
+	Limits SandboxLimitsConfig `mapstructure:"limits"`
+}
+
+// SandboxLimitsConfig 沙箱资源限制 (0 = 不限制)
+type SandboxLimitsConfig struct {
+	CPUSeconds     int  `mapstructure:"cpu_seconds"`     // 单条命令 CPU 时间 (RLIMIT_CPU)
+	MemoryMB       int  `mapstructure:"memory_mb"`       // 堆内存 (RLIMIT_DATA)
+	MaxProcesses   int  `mapstructure:"max_processes"`   // 进程数 (RLIMIT_NPROC, 按用户计数, 需高于该用户常驻进程数)
+	MaxOutputKB    int  `mapstructure:"max_output_kb"`   // stdout / stderr 各自保留上限, 超出截断
+	IsolateNetwork bool `mapstructure:"isolate_network"` // Linux: 命令在独立网络命名空间运行, 无法联网
+}
+
+// Load 加载配置, 叠加 ActiveProfile 选定的 profile
 func Load() (*Config, error) {
+	return LoadProfile(ActiveProfile())
+}
+
+// LoadProfile 加载配置并叠加指定 profile ("" = 不使用 profile)
+func LoadProfile(profile string) (*Config, error) {
 	v := viper.New()
 
 	// 设置默认值
 	setDefaults(v)
 
 	// ─── 分层配置加载 (与 Claude Code / Gemini CLI 一致) ───
-	// 优先级 (低 → 高): 默认值 → 全局 ~/.ngoclaw/ → 项目本地 → 环境变量
+	// 优先级 (低 → 高): 默认值 → 全局 ~/.ngoclaw/ → 项目本地 → profile → 环境变量
 	v.SetConfigName("config")
 	v.SetConfigType("yaml")
 
@@ -242,14 +915,23 @@ func Load() (*Config, error) {
 	// 叠加兼容的 openclaw.json (仅补充 providers/model/telegram)
 	_ = loadOpenClawConfig(v)
 
+	// Layer 3: profile ~/.ngoclaw/profiles/<name>/config.yaml (显式选择, 覆盖前两层)
+	if profile != "" {
+		if err := mergeProfile(v, profile); err != nil {
+			return nil, err
+		}
+	}
+
 	// 环境变量覆盖
 	v.SetEnvPrefix("NGOCLAW")
 	v.AutomaticEnv()
+	_ = v.BindEnv("agent.tools.mock.mode", "NGOCLAW_TOOLS_MOCK")
 
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	cfg.Profile = profile
 
 	return &cfg, nil
 }
@@ -260,6 +942,19 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("gateway.host", "0.0.0.0")
 	v.SetDefault("gateway.port", 18790)
 	v.SetDefault("gateway.mode", "local")
+	v.SetDefault("gateway.oidc.scopes", []string{"openid", "profile", "email"})
+	v.SetDefault("gateway.oidc.groups_claim", "groups")
+	v.SetDefault("gateway.oidc.session_ttl", "12h")
+	v.SetDefault("gateway.share.enabled", true)
+	v.SetDefault("gateway.share.ttl", "168h")
+	v.SetDefault("gateway.share.max_ttl", "720h")
+	v.SetDefault("gateway.share.keep", 200)
+
+	// Telegram 默认值
+	v.SetDefault("telegram.long_reply_document", true)
+	v.SetDefault("telegram.long_run_notice", "5m")
+	v.SetDefault("telegram.idle_notice", "10m")
+	v.SetDefault("telegram.busy_mode", "interrupt")
 
 
 	// Database 默认值
@@ -269,6 +964,12 @@ func setDefaults(v *viper.Viper) {
 	// Log 默认值
 	v.SetDefault("log.level", "info")
 	v.SetDefault("log.format", "console")
+	v.SetDefault("log.transcripts.enabled", false)
+	v.SetDefault("log.transcripts.max_size_mb", 10)
+	v.SetDefault("log.transcripts.retention_days", 30)
+	v.SetDefault("log.journal.enabled", false)
+	v.SetDefault("log.journal.retention_days", 7)
+	v.SetDefault("log.agent_log.enabled", true)
 
 	// Agent Runtime 默认值
 	v.SetDefault("agent.runtime.tool_timeout", "60s")
@@ -279,6 +980,72 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("agent.runtime.concurrent_tools", true)
 	v.SetDefault("agent.runtime.max_retries", 3)
 	v.SetDefault("agent.runtime.retry_base_wait", "2s")
+	v.SetDefault("agent.runtime.stream_stall_retries", 2)
+	v.SetDefault("agent.runtime.read_prefetch", true)
+	v.SetDefault("agent.runtime.slow_first_token", "15s")
+	v.SetDefault("agent.runtime.stream_stall", "30s")
+	v.SetDefault("agent.runtime.filter_retry", true)
+
+	// 定时任务补跑默认值
+	v.SetDefault("scheduler.catch_up", "once")
+	v.SetDefault("scheduler.max_catch_up", 10)
+
+	// 异步任务队列默认值 (默认关闭)
+	v.SetDefault("jobs.enabled", false)
+	v.SetDefault("jobs.backend", "redis")
+	v.SetDefault("jobs.redis_addr", "127.0.0.1:6379")
+	v.SetDefault("jobs.prefix", "ngoclaw")
+	v.SetDefault("jobs.concurrency", 2)
+	v.SetDefault("jobs.reclaim_idle", "10m")
+	v.SetDefault("jobs.job_timeout", "30m")
+	v.SetDefault("jobs.max_attempts", 3)
+
+	// GitHub webhook 默认值 (默认关闭)
+	v.SetDefault("github.enabled", false)
+	v.SetDefault("github.api_url", "https://api.github.com")
+	v.SetDefault("github.mention", "@ngoclaw")
+	v.SetDefault("github.concurrency", 2)
+	v.SetDefault("github.timeout", "15m")
+	v.SetDefault("github.max_diff_kb", 200)
+	v.SetDefault("github.allowed_associations", []string{"OWNER", "MEMBER", "COLLABORATOR"})
+
+	// Sync 默认值
+	v.SetDefault("sync.paths", []string{"soul.md", "prompts", "skills"})
+
+	// 组织策略包
+	v.SetDefault("policy.interval", "15m")
+	v.SetDefault("policy.cache_path", filepath.Join(os.Getenv("HOME"), ".ngoclaw", "policy.json"))
+
+	// 语音回复
+	v.SetDefault("tts.provider", "openai")
+	v.SetDefault("tts.limit", 1500)
+	v.SetDefault("tts.timeout", "60s")
+	v.SetDefault("tts.openai.base_url", "https://api.openai.com/v1")
+	v.SetDefault("tts.openai.model", "gpt-4o-mini-tts")
+	v.SetDefault("tts.openai.voice", "alloy")
+	v.SetDefault("tts.edge.command", "edge-tts")
+	v.SetDefault("tts.edge.voice", "zh-CN-XiaoxiaoNeural")
+
+	// Tunnel 默认值
+	v.SetDefault("tunnel.provider", "localhost.run")
+	v.SetDefault("tunnel.known_hosts", "~/.ssh/known_hosts")
+
+	// 数据保留: 默认永久保留, 仅配置了天数的类别会被清理
+	v.SetDefault("retention.scrub_interval", "1h")
+
+	// 泄漏资源清理
+	v.SetDefault("janitor.interval", "10m")
+	v.SetDefault("janitor.approval_max_age", "10m")
+	v.SetDefault("janitor.lsp_idle", "30m")
+	v.SetDefault("janitor.orphan_grace", "1h")
+	v.SetDefault("janitor.temp_max_age", "24h")
+
+	// 沙箱资源限制
+	v.SetDefault("sandbox.limits.cpu_seconds", 600)
+	v.SetDefault("sandbox.limits.memory_mb", 4096)
+	v.SetDefault("sandbox.limits.max_processes", 0)
+	v.SetDefault("sandbox.limits.max_output_kb", 1024)
+	v.SetDefault("sandbox.limits.isolate_network", false)
 
 	// Guardrails 默认值
 	v.SetDefault("agent.guardrails.context_max_tokens", 180000)
@@ -287,6 +1054,7 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("agent.guardrails.loop_detect_window", 10)
 	v.SetDefault("agent.guardrails.loop_detect_threshold", 5)
 	v.SetDefault("agent.guardrails.cost_guard_enabled", true)
+	v.SetDefault("agent.guardrails.preflight_tokens", 200000)
 
 	// Compaction 默认值
 	v.SetDefault("agent.compaction.message_threshold", 30)
@@ -295,12 +1063,69 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("agent.compaction.summary_max_tokens", 1000)
 	v.SetDefault("agent.compaction.pre_flush_to_memory", true)
 
+	// 工具定义裁剪默认值
+	v.SetDefault("agent.tools.subset.enabled", false)
+	v.SetDefault("agent.tools.subset.top_k", 12)
+	v.SetDefault("agent.tools.subset.always", []string{"bash", "read_file", "write_file", "edit_file", "list_dir"})
+
+	// 自检默认值
+	v.SetDefault("agent.review.enabled", false)
+	v.SetDefault("agent.review.retry_below", 4)
+	v.SetDefault("agent.review.caveat_below", 7)
+
+	// 链接预读取默认值
+	v.SetDefault("agent.links.mode", "auto")
+	v.SetDefault("agent.links.max_links", 3)
+	v.SetDefault("agent.links.max_chars", 6000)
+	v.SetDefault("agent.links.summarize_over", 12000)
+	v.SetDefault("agent.links.timeout", "20s")
+	v.SetDefault("agent.mentions.mode", "auto")
+	v.SetDefault("agent.mentions.max_mentions", 5)
+	v.SetDefault("agent.mentions.max_chars", 12000)
+
+	// Tool mock 默认值
+	v.SetDefault("agent.tools.mock.mode", "off")
+	v.SetDefault("agent.tools.mock.fixtures", filepath.Join(os.Getenv("HOME"), ".ngoclaw", "tool_fixtures.jsonl"))
+
+	// Docs lookup 默认值
+	v.SetDefault("agent.tools.docs.api_url", "https://context7.com/api/v1")
+	v.SetDefault("agent.tools.docs.max_tokens", 4000)
+	v.SetDefault("agent.tools.remote.connect_timeout", "10s")
+	v.SetDefault("agent.tools.remote.command_timeout", "60s")
+	v.SetDefault("agent.tools.sql.max_rows", 100)
+	v.SetDefault("agent.tools.sql.max_chars", 8000)
+	v.SetDefault("agent.tools.sql.timeout", "30s")
+	v.SetDefault("agent.tools.typecheck.timeout", "2m")
+	v.SetDefault("agent.tools.summarize.threshold_tokens", 2000)
+	v.SetDefault("agent.tools.summarize.max_tokens", 800)
+	v.SetDefault("agent.tools.summarize.wait", "20s")
+	v.SetDefault("agent.tools.vision.max_bytes", 10<<20)
+	v.SetDefault("agent.tools.vision.max_tokens", 1500)
+	v.SetDefault("agent.tools.documents.max_bytes", 20<<20)
+	v.SetDefault("agent.tools.documents.chunk_chars", 1500)
+	v.SetDefault("agent.tools.documents.ttl", "24h")
+	v.SetDefault("agent.tools.documents.max_per_chat", 5)
+	v.SetDefault("agent.tools.peers.max_hops", 2)
+	v.SetDefault("agent.tools.tests.timeout", "10m")
+	v.SetDefault("agent.tools.tests.baseline", true)
+	v.SetDefault("agent.tools.index.enabled", true)
+	v.SetDefault("agent.tools.index.max_files", 20000)
+	v.SetDefault("agent.tools.warm.go", true)
+	v.SetDefault("agent.tools.warm.node", true)
+	v.SetDefault("agent.tools.warm.python", true)
+	v.SetDefault("agent.tools.warm.timeout", "10m")
+	v.SetDefault("agent.tools.terminal.enabled", true)
+	v.SetDefault("agent.tools.terminal.max_sessions", 8)
+	v.SetDefault("agent.tools.terminal.idle_timeout", "30m")
+
 	// Security 默认值
 	v.SetDefault("agent.security.approval_mode", "ask_dangerous")
-	v.SetDefault("agent.security.dangerous_tools", []string{"shell_exec", "write_file", "delete_file", "python_exec"})
+	v.SetDefault("agent.security.dangerous_tools", []string{"bash", "shell_exec", "write_file", "delete_file", "python_exec", "remote_exec", "remote_file", "sql_query", "terminal", "run_tests", "remote_agent", "update_deps"})
 	v.SetDefault("agent.security.trusted_tools", []string{"read_file", "list_files", "web_search", "think"})
 	v.SetDefault("agent.security.trusted_commands", []string{"ls", "cat", "head", "tail", "grep", "find", "wc", "echo", "pwd", "which", "file", "stat"})
 	v.SetDefault("agent.security.approval_timeout", "5m")
+	v.SetDefault("agent.security.risk_analysis", true)
+	v.SetDefault("agent.security.fallback_approval", "http")
 }
 
 // loadOpenClawConfig 加载兼容的 openclaw.json 配置
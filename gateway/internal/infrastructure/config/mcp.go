@@ -16,6 +16,27 @@ type MCPServerEntry struct {
 	Name     string `json:"name"`
 	Endpoint string `json:"endpoint"`
 	Enabled  bool   `json:"enabled"`
+	// Transport is "http" (streamable HTTP, the default) or "sse" (the older
+	// HTTP+SSE transport: a GET event stream plus a POST endpoint).
+	Transport string `json:"transport,omitempty"`
+	// Namespace prefixes the server's tool names ("<namespace>_<tool>").
+	// Defaults to Name.
+	Namespace string `json:"namespace,omitempty"`
+	// Headers are sent with every request. Values may reference environment
+	// variables as $VAR or ${VAR}.
+	Headers map[string]string `json:"headers,omitempty"`
+	OAuth   *MCPOAuthConfig   `json:"oauth,omitempty"`
+}
+
+// MCPOAuthConfig is the bearer token for an MCP server: either a fixed
+// access token or an OAuth client-credentials grant. Values may reference
+// environment variables as $VAR or ${VAR}.
+type MCPOAuthConfig struct {
+	Token        string   `json:"token,omitempty"`
+	TokenURL     string   `json:"token_url,omitempty"`
+	ClientID     string   `json:"client_id,omitempty"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
 }
 
 // LoadMCPConfig loads MCP configuration from ~/.ngoclaw/mcp.json.
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ProfileEnv 选择本次运行的 profile (ngoclaw --profile 也经由它传递)
+const ProfileEnv = "NGOCLAW_PROFILE"
+
+// DefaultProfile 表示不叠加任何 profile
+const DefaultProfile = "default"
+
+var profileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ProfilesDir 返回 ~/.ngoclaw/profiles; 每个 profile 是其中的 <name>/config.yaml
+func ProfilesDir() string {
+	return filepath.Join(HomeDir(), "profiles")
+}
+
+// profileFile 记录 /profile 选定的 profile, 未指定 --profile / NGOCLAW_PROFILE 时使用
+func profileFile() string {
+	return filepath.Join(HomeDir(), "profile")
+}
+
+// ActiveProfile 返回本次运行使用的 profile, 优先级: NGOCLAW_PROFILE (含 --profile)
+// → ~/.ngoclaw/profile → 无. 无 profile 时返回 "".
+func ActiveProfile() string {
+	name := strings.TrimSpace(os.Getenv(ProfileEnv))
+	if name == "" {
+		data, _ := os.ReadFile(profileFile())
+		name = strings.TrimSpace(string(data))
+	}
+	if name == DefaultProfile {
+		return ""
+	}
+	return name
+}
+
+// SetSavedProfile 持久化默认 profile (/profile 切换时写入); "" 或 default 清除
+func SetSavedProfile(name string) error {
+	if name == "" || name == DefaultProfile {
+		if err := os.Remove(profileFile()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(HomeDir(), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(profileFile(), []byte(name+"\n"), 0644)
+}
+
+// ListProfiles 返回 ~/.ngoclaw/profiles 下含 config.yaml 的 profile 名 (排序后)
+func ListProfiles() ([]string, error) {
+	entries, err := os.ReadDir(ProfilesDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() || !profileName.MatchString(e.Name()) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(ProfilesDir(), e.Name(), "config.yaml")); err == nil {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ProfilePath 返回 profile 的配置文件路径, profile 不存在时报错
+func ProfilePath(name string) (string, error) {
+	if !profileName.MatchString(name) {
+		return "", fmt.Errorf("invalid profile name %q", name)
+	}
+	path := filepath.Join(ProfilesDir(), name, "config.yaml")
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("profile %q not found (expected %s)", name, path)
+		}
+		return "", err
+	}
+	return path, nil
+}
+
+// mergeProfile 把 profile 的 config.yaml 叠加到 v 上. map 逐键合并, 列表整体替换
+// (profile 中的 agent.providers 会取代全局的 provider 列表).
+func mergeProfile(v *viper.Viper, name string) error {
+	path, err := ProfilePath(name)
+	if err != nil {
+		return err
+	}
+	pv := viper.New()
+	pv.SetConfigFile(path)
+	if err := pv.ReadInConfig(); err != nil {
+		return fmt.Errorf("failed to read profile %q: %w", name, err)
+	}
+	return v.MergeConfigMap(pv.AllSettings())
+}
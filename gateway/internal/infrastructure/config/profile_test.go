@@ -0,0 +1,104 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(ProfileEnv, "")
+
+	writeFile(t, filepath.Join(home, ".ngoclaw", "config.yaml"), `
+agent:
+  default_model: cloud/big
+  workspace: /work
+  providers:
+    - name: cloud
+      type: openai
+    - name: backup
+      type: openai
+  security:
+    approval_mode: ask_dangerous
+`)
+	writeFile(t, filepath.Join(home, ".ngoclaw", "profiles", "offline", "config.yaml"), `
+agent:
+  default_model: ollama/qwen3
+  providers:
+    - name: ollama
+      type: ollama
+  security:
+    approval_mode: ask_all
+`)
+	writeFile(t, filepath.Join(home, ".ngoclaw", "profiles", "notes.txt"), "not a profile")
+
+	names, err := ListProfiles()
+	if err != nil || !reflect.DeepEqual(names, []string{"offline"}) {
+		t.Fatalf("ListProfiles = %v, %v", names, err)
+	}
+
+	cfg, err := LoadProfile("offline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Profile != "offline" || cfg.Agent.DefaultModel != "ollama/qwen3" || cfg.Agent.Security.ApprovalMode != "ask_all" {
+		t.Errorf("profile values not applied: %+v", cfg.Agent)
+	}
+	// 列表整体替换, 未覆盖的键保留全局值
+	if len(cfg.Agent.Providers) != 1 || cfg.Agent.Providers[0].Name != "ollama" {
+		t.Errorf("providers = %+v, want only ollama", cfg.Agent.Providers)
+	}
+	if cfg.Agent.Workspace != "/work" {
+		t.Errorf("workspace = %q, want global value", cfg.Agent.Workspace)
+	}
+
+	if _, err := LoadProfile("work"); err == nil {
+		t.Error("LoadProfile(missing) should fail")
+	}
+	if _, err := LoadProfile("../x"); err == nil {
+		t.Error("LoadProfile(../x) should fail")
+	}
+}
+
+func TestActiveProfile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(ProfileEnv, "")
+
+	if got := ActiveProfile(); got != "" {
+		t.Errorf("no profile: got %q", got)
+	}
+	if err := SetSavedProfile("home"); err != nil {
+		t.Fatal(err)
+	}
+	if got := ActiveProfile(); got != "home" {
+		t.Errorf("saved profile: got %q, want home", got)
+	}
+	t.Setenv(ProfileEnv, "work")
+	if got := ActiveProfile(); got != "work" {
+		t.Errorf("env overrides saved: got %q, want work", got)
+	}
+	t.Setenv(ProfileEnv, DefaultProfile)
+	if got := ActiveProfile(); got != "" {
+		t.Errorf("default disables profiles: got %q", got)
+	}
+	t.Setenv(ProfileEnv, "")
+	if err := SetSavedProfile(DefaultProfile); err != nil {
+		t.Fatal(err)
+	}
+	if got := ActiveProfile(); got != "" {
+		t.Errorf("cleared saved profile: got %q", got)
+	}
+}
@@ -0,0 +1,120 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+)
+
+// 仓库自带的示例套件必须始终可加载
+func TestLoadSuite_Examples(t *testing.T) {
+	tasks, err := LoadSuite("../../../evals")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) == 0 {
+		t.Fatal("no example tasks")
+	}
+	for _, task := range tasks {
+		if dir := task.FixtureDir(); dir != "" {
+			if _, err := os.Stat(dir); err != nil {
+				t.Errorf("%s: fixture: %v", task.Name, err)
+			}
+		}
+	}
+}
+
+func TestParseTask_RejectsBadAssertion(t *testing.T) {
+	for name, yml := range map[string]string{
+		"unknown type":   "prompt: x\nassertions: [{type: file_is_nice, path: a}]",
+		"missing path":   "prompt: x\nassertions: [{type: file_contains, contains: a}]",
+		"bad regex":      "prompt: x\nassertions: [{type: output_contains, matches: '('}]",
+		"missing prompt": "assertions: []",
+	} {
+		if _, err := ParseTask([]byte(yml)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ws := t.TempDir()
+	os.WriteFile(filepath.Join(ws, "main.go"), []byte("package main\n\nfunc add(a, b int) int { return a + b }\n"), 0o644)
+
+	task, err := ParseTask([]byte(`
+prompt: fix it
+assertions:
+  - {type: file_contains, path: main.go, contains: "a + b"}
+  - {type: file_not_contains, path: main.go, matches: 'a\s*-\s*b'}
+  - {type: file_missing, path: notes.txt}
+  - {type: output_contains, contains: fixed}
+  - {type: command, run: "grep -q add main.go"}
+  - {type: tool_used, tool: edit_file}
+  - {type: tool_not_used, tool: web_search}
+  - {type: max_steps, max: 3}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &Outcome{
+		Result:    &service.AgentResult{FinalContent: "fixed the subtraction", TotalSteps: 3},
+		ToolCalls: map[string]int{"read_file": 1, "edit_file": 1},
+	}
+	if failures := Check(context.Background(), task, ws, out); len(failures) != 0 {
+		t.Fatalf("unexpected failures: %v", failures)
+	}
+
+	out.Result.TotalSteps = 5
+	out.ToolCalls["web_search"] = 2
+	failures := Check(context.Background(), task, ws, out)
+	if len(failures) != 2 {
+		t.Fatalf("failures = %v, want max_steps and tool_not_used", failures)
+	}
+}
+
+func TestScorecard_DetectsRegressions(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	rec := func(run, model, prompt, task string, passed bool, at time.Time) Record {
+		return Record{RunID: run, Time: at, Model: model, PromptVersion: prompt, Task: task, Passed: passed, Steps: 4}
+	}
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	AppendRecords(path, []Record{
+		rec("r1", "m1", "aaaa", "a", true, t0),
+		rec("r1", "m1", "aaaa", "b", false, t0),
+		rec("r1", "m2", "aaaa", "a", true, t0),
+	})
+	AppendRecords(path, []Record{
+		rec("r2", "m1", "bbbb", "a", false, t0.Add(time.Hour)),
+		rec("r2", "m1", "bbbb", "b", true, t0.Add(time.Hour)),
+	})
+
+	records, err := LoadRecords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := BuildScorecard(records)
+	if len(rows) != 3 {
+		t.Fatalf("rows = %d, want 3", len(rows))
+	}
+	if rows[2].RunID != "r2" || rows[2].Passed != 1 || rows[2].Total != 2 || rows[2].AvgSteps != 4 {
+		t.Errorf("last row = %+v", rows[2])
+	}
+
+	regressed, fixed := Diff(rows[0], rows[2])
+	if len(regressed) != 1 || regressed[0] != "a" || len(fixed) != 1 || fixed[0] != "b" {
+		t.Errorf("regressed=%v fixed=%v", regressed, fixed)
+	}
+
+	var buf bytes.Buffer
+	WriteScorecard(&buf, rows)
+	if !strings.Contains(buf.String(), "✗ a  ✓ b") {
+		t.Errorf("scorecard missing change column:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,305 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"go.uber.org/zap"
+)
+
+// commandTimeout setup 命令与 command / tests_pass 断言的执行上限
+const commandTimeout = 5 * time.Minute
+
+// WorkDirSetter 可切换工作目录的工具沙箱 (*sandbox.ProcessSandbox)
+type WorkDirSetter interface {
+	GetWorkDir() string
+	SetWorkDir(dir string) error
+}
+
+// Runner 在真实的 agent loop 和工具上执行任务.
+// 任务会切换进程工作目录, 因此必须串行执行.
+type Runner struct {
+	Loop          *service.AgentLoop
+	SystemPrompt  func(task *Task, model, workspace string) string // 组装系统提示词 (可为 nil)
+	Sandbox       WorkDirSetter                                    // 工具沙箱, 运行期间工作目录切到任务工作区 (可为 nil)
+	KeepWorkspace bool                                             // 保留工作区以便排查失败
+	Logger        *zap.Logger
+}
+
+// Outcome 一次 (任务, 模型) 运行的结果
+type Outcome struct {
+	Task      string
+	Model     string
+	Workspace string
+	Result    *service.AgentResult
+	ToolCalls map[string]int
+	Error     string   // 工作区准备失败或 agent 以错误结束
+	Failures  []string // 未通过的断言
+	Duration  time.Duration
+}
+
+// Passed 运行无错误且所有断言通过
+func (o *Outcome) Passed() bool {
+	return o.Error == "" && len(o.Failures) == 0
+}
+
+// Run 准备工作区、运行 agent 并检查断言
+func (r *Runner) Run(ctx context.Context, task *Task, model string) *Outcome {
+	logger := r.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	out := &Outcome{Task: task.Name, Model: model, ToolCalls: make(map[string]int)}
+
+	ws, err := prepareWorkspace(ctx, task)
+	if ws != "" {
+		out.Workspace = ws
+		if !r.KeepWorkspace {
+			defer os.RemoveAll(ws)
+		}
+	}
+	if err != nil {
+		out.Error = err.Error()
+		return out
+	}
+
+	prevDir, err := os.Getwd()
+	if err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	if err := os.Chdir(ws); err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	defer os.Chdir(prevDir)
+	if r.Sandbox != nil {
+		prevWorkDir := r.Sandbox.GetWorkDir()
+		if err := r.Sandbox.SetWorkDir(ws); err != nil {
+			out.Error = fmt.Sprintf("set work dir: %v", err)
+			return out
+		}
+		defer r.Sandbox.SetWorkDir(prevWorkDir)
+	}
+
+	system := ""
+	if r.SystemPrompt != nil {
+		system = r.SystemPrompt(task, model, ws)
+	}
+	if task.System != "" {
+		system += "\n\n" + task.System
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, task.Timeout.D())
+	defer cancel()
+
+	logger.Info("Running eval task", zap.String("task", task.Name), zap.String("model", model))
+	start := time.Now()
+	result, eventCh := r.Loop.Run(runCtx, system, task.Prompt, nil, model)
+	for ev := range eventCh {
+		switch ev.Type {
+		case entity.EventToolCall:
+			if ev.ToolCall != nil {
+				out.ToolCalls[ev.ToolCall.Name]++
+			}
+		case entity.EventError:
+			out.Error = ev.Error
+		}
+	}
+	out.Duration = time.Since(start)
+	out.Result = result
+	if out.Error == "" && runCtx.Err() != nil {
+		out.Error = fmt.Sprintf("timed out after %s", task.Timeout.D())
+	}
+
+	out.Failures = Check(ctx, task, ws, out)
+	return out
+}
+
+// prepareWorkspace 创建临时工作区, 复制夹具并执行 setup 命令
+func prepareWorkspace(ctx context.Context, task *Task) (string, error) {
+	ws, err := os.MkdirTemp("", "ngoclaw-eval-")
+	if err != nil {
+		return "", err
+	}
+	if src := task.FixtureDir(); src != "" {
+		if err := copyDir(src, ws); err != nil {
+			return ws, fmt.Errorf("copy fixture: %w", err)
+		}
+	}
+	for _, cmd := range task.Setup {
+		if output, err := runCommand(ctx, ws, cmd); err != nil {
+			return ws, fmt.Errorf("setup %q: %v\n%s", cmd, err, output)
+		}
+	}
+	return ws, nil
+}
+
+// Check 检查所有断言, 返回未通过项的说明 (空 = 全部通过)
+func Check(ctx context.Context, task *Task, workspace string, out *Outcome) []string {
+	var failures []string
+	fail := func(format string, args ...interface{}) {
+		failures = append(failures, fmt.Sprintf(format, args...))
+	}
+
+	final := ""
+	steps := 0
+	if out.Result != nil {
+		final = out.Result.FinalContent
+		steps = out.Result.TotalSteps
+	}
+
+	for _, a := range task.Assertions {
+		switch a.Type {
+		case "file_contains", "file_not_contains":
+			data, err := os.ReadFile(filepath.Join(workspace, a.Path))
+			if err != nil {
+				if a.Type == "file_contains" || !os.IsNotExist(err) {
+					fail("%s %s: %v", a.Type, a.Path, err)
+				}
+				continue
+			}
+			found := a.match(string(data))
+			if a.Type == "file_contains" && !found {
+				fail("%s does not contain %s", a.Path, a.describe())
+			}
+			if a.Type == "file_not_contains" && found {
+				fail("%s still contains %s", a.Path, a.describe())
+			}
+
+		case "file_exists", "file_missing":
+			_, err := os.Stat(filepath.Join(workspace, a.Path))
+			if a.Type == "file_exists" && err != nil {
+				fail("%s does not exist", a.Path)
+			}
+			if a.Type == "file_missing" && err == nil {
+				fail("%s should not exist", a.Path)
+			}
+
+		case "output_contains":
+			if !a.match(final) {
+				fail("final answer does not contain %s", a.describe())
+			}
+
+		case "command", "tests_pass":
+			run := a.Run
+			if run == "" {
+				run = detectTestCommand(workspace)
+			}
+			if output, err := runCommand(ctx, workspace, run); err != nil {
+				fail("%q failed: %v\n%s", run, err, tail(output, 20))
+			}
+
+		case "tool_used":
+			if out.ToolCalls[a.Tool] == 0 {
+				fail("tool %s was not used", a.Tool)
+			}
+
+		case "tool_not_used":
+			if n := out.ToolCalls[a.Tool]; n > 0 {
+				fail("tool %s was used %d times", a.Tool, n)
+			}
+
+		case "max_steps":
+			if steps > a.Max {
+				fail("took %d steps, want <= %d", steps, a.Max)
+			}
+		}
+	}
+	return failures
+}
+
+func (a Assertion) match(s string) bool {
+	if a.Matches != "" {
+		return regexp.MustCompile(a.Matches).MatchString(s)
+	}
+	return strings.Contains(s, a.Contains)
+}
+
+func (a Assertion) describe() string {
+	if a.Matches != "" {
+		return fmt.Sprintf("/%s/", a.Matches)
+	}
+	return fmt.Sprintf("%q", a.Contains)
+}
+
+// detectTestCommand 按工作区中的项目文件推断测试命令
+func detectTestCommand(workspace string) string {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(workspace, name))
+		return err == nil
+	}
+	switch {
+	case exists("go.mod"):
+		return "go test ./..."
+	case exists("package.json"):
+		return "npm test --silent"
+	case exists("Cargo.toml"):
+		return "cargo test --quiet"
+	default:
+		return "python3 -m pytest -q"
+	}
+}
+
+func runCommand(ctx context.Context, dir, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "bash", "-c", command)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// tail 保留输出的最后 n 行
+func tail(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// copyDir 递归复制目录 (保留文件权限, 跳过符号链接以外的特殊文件)
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0o700)
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(target, data, info.Mode().Perm())
+		}
+		return nil
+	})
+}
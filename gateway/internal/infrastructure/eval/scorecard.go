@@ -0,0 +1,277 @@
+package eval
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Record 一次 (任务, 模型) 运行的持久化结果, 每行一条追加到 results.jsonl
+type Record struct {
+	RunID         string         `json:"run_id"`
+	Time          time.Time      `json:"time"`
+	Label         string         `json:"label,omitempty"` // 用户指定的版本标签 (如 "soul-v2")
+	Model         string         `json:"model"`
+	PromptVersion string         `json:"prompt_version"` // 提示词文件内容的指纹
+	Task          string         `json:"task"`
+	Passed        bool           `json:"passed"`
+	Failures      []string       `json:"failures,omitempty"`
+	Error         string         `json:"error,omitempty"`
+	Steps         int            `json:"steps"`
+	Tokens        int            `json:"tokens"`
+	DurationMS    int64          `json:"duration_ms"`
+	ToolCalls     map[string]int `json:"tool_calls,omitempty"`
+}
+
+// NewRecord 由运行结果生成记录
+func NewRecord(runID string, at time.Time, label, promptVersion string, o *Outcome) Record {
+	rec := Record{
+		RunID:         runID,
+		Time:          at,
+		Label:         label,
+		Model:         o.Model,
+		PromptVersion: promptVersion,
+		Task:          o.Task,
+		Passed:        o.Passed(),
+		Failures:      o.Failures,
+		Error:         o.Error,
+		DurationMS:    o.Duration.Milliseconds(),
+		ToolCalls:     o.ToolCalls,
+	}
+	if o.Result != nil {
+		rec.Steps = o.Result.TotalSteps
+		rec.Tokens = o.Result.TotalTokens
+	}
+	return rec
+}
+
+// DefaultResultsPath ~/.ngoclaw/evals/results.jsonl
+func DefaultResultsPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ngoclaw", "evals", "results.jsonl")
+}
+
+// AppendRecords 追加记录到 JSONL 文件
+func AppendRecords(path string, records []Record) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadRecords 读取 JSONL 记录 (文件不存在时返回空)
+func LoadRecords(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// ScoreRow 一次 run 中一个 (模型, 提示词版本) 组合的成绩
+type ScoreRow struct {
+	RunID         string
+	Time          time.Time
+	Label         string
+	Model         string
+	PromptVersion string
+	Passed        int
+	Total         int
+	AvgSteps      float64
+	AvgTokens     float64
+	Duration      time.Duration
+	Results       map[string]bool // task → passed
+}
+
+// PassRate 通过率 (0-1)
+func (r *ScoreRow) PassRate() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(r.Passed) / float64(r.Total)
+}
+
+// BuildScorecard 按 (run, 模型, 提示词版本) 聚合记录, 按时间排序
+func BuildScorecard(records []Record) []*ScoreRow {
+	type key struct{ run, model, prompt string }
+	rows := make(map[key]*ScoreRow)
+	var order []key
+
+	for _, rec := range records {
+		k := key{rec.RunID, rec.Model, rec.PromptVersion}
+		row, ok := rows[k]
+		if !ok {
+			row = &ScoreRow{
+				RunID:         rec.RunID,
+				Time:          rec.Time,
+				Label:         rec.Label,
+				Model:         rec.Model,
+				PromptVersion: rec.PromptVersion,
+				Results:       make(map[string]bool),
+			}
+			rows[k] = row
+			order = append(order, k)
+		}
+		row.Total++
+		if rec.Passed {
+			row.Passed++
+		}
+		row.AvgSteps += float64(rec.Steps)
+		row.AvgTokens += float64(rec.Tokens)
+		row.Duration += time.Duration(rec.DurationMS) * time.Millisecond
+		row.Results[rec.Task] = rec.Passed
+	}
+
+	out := make([]*ScoreRow, 0, len(order))
+	for _, k := range order {
+		row := rows[k]
+		row.AvgSteps /= float64(row.Total)
+		row.AvgTokens /= float64(row.Total)
+		out = append(out, row)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].Time.Equal(out[j].Time) {
+			return out[i].Time.Before(out[j].Time)
+		}
+		return out[i].Model < out[j].Model
+	})
+	return out
+}
+
+// Diff 与同一模型的上一次成绩相比: 新失败 (回归) 与新通过的任务
+func Diff(prev, cur *ScoreRow) (regressed, fixed []string) {
+	for task, ok := range cur.Results {
+		before, seen := prev.Results[task]
+		if !seen {
+			continue
+		}
+		switch {
+		case before && !ok:
+			regressed = append(regressed, task)
+		case !before && ok:
+			fixed = append(fixed, task)
+		}
+	}
+	sort.Strings(regressed)
+	sort.Strings(fixed)
+	return regressed, fixed
+}
+
+// WriteScorecard 输出成绩单: 每行一个 (run, 模型, 提示词版本),
+// 并标出相对同一模型上一次 run 的回归与修复
+func WriteScorecard(w io.Writer, rows []*ScoreRow) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tMODEL\tPROMPT\tLABEL\tPASS\tSTEPS\tTOKENS\tTIME SPENT\tCHANGES")
+
+	last := make(map[string]*ScoreRow)
+	for _, row := range rows {
+		changes := ""
+		if prev, ok := last[row.Model]; ok {
+			regressed, fixed := Diff(prev, row)
+			var parts []string
+			if len(regressed) > 0 {
+				parts = append(parts, "✗ "+strings.Join(regressed, ", "))
+			}
+			if len(fixed) > 0 {
+				parts = append(parts, "✓ "+strings.Join(fixed, ", "))
+			}
+			changes = strings.Join(parts, "  ")
+		}
+		last[row.Model] = row
+
+		label := row.Label
+		if label == "" {
+			label = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d/%d (%.0f%%)\t%.1f\t%.0f\t%s\t%s\n",
+			row.Time.Local().Format("2006-01-02 15:04"),
+			row.Model, row.PromptVersion, label,
+			row.Passed, row.Total, row.PassRate()*100,
+			row.AvgSteps, row.AvgTokens,
+			row.Duration.Round(time.Second),
+			changes,
+		)
+	}
+	tw.Flush()
+}
+
+// WriteTaskMatrix 输出任务 × 列 (模型@提示词版本) 的通过矩阵, 每列取该组合最近一次 run
+func WriteTaskMatrix(w io.Writer, rows []*ScoreRow) {
+	latest := make(map[string]*ScoreRow)
+	var columns []string
+	for _, row := range rows {
+		col := row.Model + "@" + row.PromptVersion
+		if _, ok := latest[col]; !ok {
+			columns = append(columns, col)
+		}
+		latest[col] = row
+	}
+
+	taskSet := make(map[string]bool)
+	for _, row := range latest {
+		for task := range row.Results {
+			taskSet[task] = true
+		}
+	}
+	tasks := make([]string, 0, len(taskSet))
+	for task := range taskSet {
+		tasks = append(tasks, task)
+	}
+	sort.Strings(tasks)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "TASK\t%s\n", strings.Join(columns, "\t"))
+	for _, task := range tasks {
+		cells := make([]string, len(columns))
+		for i, col := range columns {
+			passed, ok := latest[col].Results[task]
+			switch {
+			case !ok:
+				cells[i] = "-"
+			case passed:
+				cells[i] = "✓"
+			default:
+				cells[i] = "✗"
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", task, strings.Join(cells, "\t"))
+	}
+	tw.Flush()
+}
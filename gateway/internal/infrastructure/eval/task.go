@@ -0,0 +1,206 @@
+// Package eval 提供声明式的 agent 评测套件。
+//
+// 每个任务是一个 YAML 文件: 提示词 + 夹具工作区 + 断言 (文件内容、测试通过、
+// 是否调用某工具等)。`ngoclaw eval run` 在真实模型和工具上逐个执行任务,
+// 结果追加到 JSONL 记录中, `ngoclaw eval report` 按模型 / 提示词版本汇总
+// 成绩单, 用于发现提示词或模型切换带来的回归。
+//
+// 与 chaos 包的区别: chaos 脚本化 LLM 和工具以测试 agent loop 本身;
+// eval 使用真实 LLM 和工具, 衡量的是模型 + 提示词的效果。
+package eval
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Task 一个评测任务
+type Task struct {
+	Name        string      `yaml:"name"`
+	Description string      `yaml:"description"`
+	Tags        []string    `yaml:"tags"`
+	Prompt      string      `yaml:"prompt"`
+	System      string      `yaml:"system"`  // 追加到系统提示词
+	Fixture     string      `yaml:"fixture"` // 夹具目录 (相对任务文件), 复制为任务的工作区
+	Setup       []string    `yaml:"setup"`   // agent 运行前在工作区执行的命令 (如 git init)
+	Timeout     Duration    `yaml:"timeout"` // agent 运行上限 (默认 5m)
+	Assertions  []Assertion `yaml:"assertions"`
+
+	dir string // 任务文件所在目录, 用于解析 fixture
+}
+
+// Assertion 运行结束后对工作区和 agent 行为的一项检查
+type Assertion struct {
+	// file_contains | file_not_contains | file_exists | file_missing |
+	// output_contains | command | tests_pass | tool_used | tool_not_used | max_steps
+	Type     string `yaml:"type"`
+	Path     string `yaml:"path"`     // file_* 的目标文件 (相对工作区)
+	Contains string `yaml:"contains"` // 子串
+	Matches  string `yaml:"matches"`  // 正则 (与 contains 二选一)
+	Run      string `yaml:"run"`      // command / tests_pass 执行的命令, 退出码 0 为通过
+	Tool     string `yaml:"tool"`     // tool_used / tool_not_used 的工具名
+	Max      int    `yaml:"max"`      // max_steps 上限
+}
+
+// FixtureDir 返回夹具目录的绝对路径 (未配置时为空)
+func (t *Task) FixtureDir() string {
+	if t.Fixture == "" {
+		return ""
+	}
+	if filepath.IsAbs(t.Fixture) {
+		return t.Fixture
+	}
+	return filepath.Join(t.dir, t.Fixture)
+}
+
+// HasTag 任务是否带有指定标签
+func (t *Task) HasTag(tag string) bool {
+	for _, tg := range t.Tags {
+		if tg == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Duration 支持 YAML 中 "90s" / "5m" 形式的时长
+type Duration time.Duration
+
+// UnmarshalYAML 解析 Go duration 字符串
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+	if s == "" {
+		*d = 0
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// D 返回 time.Duration
+func (d Duration) D() time.Duration { return time.Duration(d) }
+
+// ParseTask 从 YAML 字节解析任务并校验断言
+func ParseTask(data []byte) (*Task, error) {
+	var t Task
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("parse task: %w", err)
+	}
+	if t.Prompt == "" {
+		return nil, fmt.Errorf("task %q: prompt is required", t.Name)
+	}
+	if t.Timeout == 0 {
+		t.Timeout = Duration(5 * time.Minute)
+	}
+	for i, a := range t.Assertions {
+		if err := a.validate(); err != nil {
+			return nil, fmt.Errorf("task %q: assertions[%d]: %w", t.Name, i, err)
+		}
+	}
+	return &t, nil
+}
+
+func (a Assertion) validate() error {
+	switch a.Type {
+	case "file_contains", "file_not_contains":
+		if a.Path == "" {
+			return fmt.Errorf("%s needs path", a.Type)
+		}
+		if a.Contains == "" && a.Matches == "" {
+			return fmt.Errorf("%s needs contains or matches", a.Type)
+		}
+	case "file_exists", "file_missing":
+		if a.Path == "" {
+			return fmt.Errorf("%s needs path", a.Type)
+		}
+	case "output_contains":
+		if a.Contains == "" && a.Matches == "" {
+			return fmt.Errorf("%s needs contains or matches", a.Type)
+		}
+	case "command":
+		if a.Run == "" {
+			return fmt.Errorf("command needs run")
+		}
+	case "tests_pass":
+		// run 为空时按工作区自动选择测试命令
+	case "tool_used", "tool_not_used":
+		if a.Tool == "" {
+			return fmt.Errorf("%s needs tool", a.Type)
+		}
+	case "max_steps":
+		if a.Max <= 0 {
+			return fmt.Errorf("max_steps needs max > 0")
+		}
+	default:
+		return fmt.Errorf("unknown assertion type %q", a.Type)
+	}
+	if a.Matches != "" {
+		if _, err := regexp.Compile(a.Matches); err != nil {
+			return fmt.Errorf("invalid matches pattern: %w", err)
+		}
+	}
+	return nil
+}
+
+// LoadTask 从文件加载任务, 未命名时使用文件名
+func LoadTask(path string) (*Task, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	t, err := ParseTask(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if t.Name == "" {
+		t.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	// 运行时会切换到任务工作区, 夹具路径需提前解析为绝对路径
+	t.dir, err = filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// LoadSuite 加载目录下所有 *.yaml / *.yml 任务, 按文件名排序 (夹具放在子目录中)
+func LoadSuite(dir string) ([]*Task, error) {
+	var paths []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+	sort.Strings(paths)
+
+	tasks := make([]*Task, 0, len(paths))
+	seen := make(map[string]string)
+	for _, p := range paths {
+		t, err := LoadTask(p)
+		if err != nil {
+			return nil, err
+		}
+		if prev, ok := seen[t.Name]; ok {
+			return nil, fmt.Errorf("duplicate task name %q in %s and %s", t.Name, prev, p)
+		}
+		seen[t.Name] = p
+		tasks = append(tasks, t)
+	}
+	return tasks, nil
+}
@@ -2,6 +2,7 @@ package eventbus
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
@@ -113,7 +114,7 @@ func (b *InMemoryBus) Publish(ctx context.Context, event Event) {
 	}
 }
 
-// Subscribe 订阅事件
+// Subscribe 订阅事件; eventType 可为具体类型、"*" 或主题前缀 "run.*"
 func (b *InMemoryBus) Subscribe(eventType string, handler Handler) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -190,9 +191,11 @@ func (b *InMemoryBus) dispatchEvent(ctx context.Context, event Event) {
 		handlers = append(handlers, h...)
 	}
 
-	// 获取通配符处理器
-	if h, ok := b.handlers["*"]; ok {
-		handlers = append(handlers, h...)
+	// 获取通配符处理器: "*" 匹配全部, "run.*" 匹配 run. 开头的主题
+	for pattern, h := range b.handlers {
+		if pattern == "*" || (strings.HasSuffix(pattern, ".*") && strings.HasPrefix(event.Type(), strings.TrimSuffix(pattern, "*"))) {
+			handlers = append(handlers, h...)
+		}
 	}
 	b.mu.RUnlock()
 
@@ -75,6 +75,29 @@ func TestInMemoryBus_WildcardSubscriber(t *testing.T) {
 	}
 }
 
+// === Topic prefix subscriber ===
+
+func TestInMemoryBus_TopicPrefixSubscriber(t *testing.T) {
+	bus := NewInMemoryBus(testLogger(), 100)
+	defer bus.Close()
+
+	var received atomic.Int32
+	bus.Subscribe("tool.*", func(ctx context.Context, ev Event) {
+		received.Add(1)
+	})
+
+	bus.Publish(context.Background(), NewEvent("tool.call", nil))
+	bus.Publish(context.Background(), NewEvent("tool.result", nil))
+	bus.Publish(context.Background(), NewEvent("tools", nil))
+	bus.Publish(context.Background(), NewEvent("run.started", nil))
+
+	time.Sleep(50 * time.Millisecond)
+
+	if got := received.Load(); got != 2 {
+		t.Errorf("tool.* should receive only tool.<x> events, got %d", got)
+	}
+}
+
 // === Multiple subscribers ===
 
 func TestInMemoryBus_MultipleSubscribers(t *testing.T) {
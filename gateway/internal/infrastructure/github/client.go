@@ -0,0 +1,241 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAPIURL github.com 的 REST API 地址 (GitHub Enterprise 为 https://<host>/api/v3)
+const DefaultAPIURL = "https://api.github.com"
+
+// ClientConfig GitHub API 客户端配置. 使用 GitHub App (AppID + PrivateKey)
+// 时按 installation 签发短期 token; 仅设置 Token 时所有请求使用该 PAT.
+type ClientConfig struct {
+	APIURL         string
+	AppID          int64
+	PrivateKeyPath string
+	Token          string
+	Timeout        time.Duration
+}
+
+// Client GitHub REST API 客户端 (仅包含 webhook 集成所需的接口)
+type Client struct {
+	apiURL string
+	appID  int64
+	key    *rsa.PrivateKey
+	token  string
+	http   *http.Client
+
+	mu     sync.Mutex
+	tokens map[int64]installationToken
+}
+
+type installationToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// NewClient 创建客户端并加载 App 私钥
+func NewClient(cfg ClientConfig) (*Client, error) {
+	c := &Client{
+		apiURL: strings.TrimRight(cfg.APIURL, "/"),
+		appID:  cfg.AppID,
+		token:  cfg.Token,
+		http:   &http.Client{Timeout: cfg.Timeout},
+		tokens: make(map[int64]installationToken),
+	}
+	if c.apiURL == "" {
+		c.apiURL = DefaultAPIURL
+	}
+	if c.http.Timeout <= 0 {
+		c.http.Timeout = 30 * time.Second
+	}
+
+	if c.token != "" {
+		return c, nil
+	}
+	if cfg.AppID == 0 || cfg.PrivateKeyPath == "" {
+		return nil, errors.New("github: either token or app_id + private_key_path is required")
+	}
+	pemBytes, err := os.ReadFile(cfg.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("github: read private key: %w", err)
+	}
+	key, err := parsePrivateKey(pemBytes)
+	if err != nil {
+		return nil, fmt.Errorf("github: %w", err)
+	}
+	c.key = key
+	return c, nil
+}
+
+func parsePrivateKey(pemBytes []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not RSA")
+	}
+	return key, nil
+}
+
+// PullRequestDiff 获取 PR 的统一 diff, 超过 maxBytes 时截断 (maxBytes <= 0 不限制)
+func (c *Client) PullRequestDiff(ctx context.Context, installationID int64, repo string, number int, maxBytes int) (diff string, truncated bool, err error) {
+	path := fmt.Sprintf("/repos/%s/pulls/%d", repo, number)
+	resp, err := c.do(ctx, installationID, http.MethodGet, path, "application/vnd.github.v3.diff", nil)
+	if err != nil {
+		return "", false, err
+	}
+	defer resp.Body.Close()
+
+	r := io.Reader(resp.Body)
+	if maxBytes > 0 {
+		r = io.LimitReader(resp.Body, int64(maxBytes)+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", false, fmt.Errorf("github: read diff: %w", err)
+	}
+	if maxBytes > 0 && len(data) > maxBytes {
+		return string(data[:maxBytes]), true, nil
+	}
+	return string(data), false, nil
+}
+
+// CreateComment 在 issue / PR 下发表评论
+func (c *Client) CreateComment(ctx context.Context, installationID int64, repo string, number int, body string) error {
+	payload, _ := json.Marshal(map[string]string{"body": body})
+	path := fmt.Sprintf("/repos/%s/issues/%d/comments", repo, number)
+	resp, err := c.do(ctx, installationID, http.MethodPost, path, "application/vnd.github+json", payload)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do 发送已认证请求; 非 2xx 响应转换为错误
+func (c *Client) do(ctx context.Context, installationID int64, method, path, accept string, body []byte) (*http.Response, error) {
+	token, err := c.accessToken(ctx, installationID)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.apiURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", accept)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("github: %s %s: %w", method, path, err)
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("github: %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+// accessToken 返回 PAT, 或签发 (并缓存) installation token
+func (c *Client) accessToken(ctx context.Context, installationID int64) (string, error) {
+	if c.token != "" {
+		return c.token, nil
+	}
+	if installationID == 0 {
+		return "", errors.New("github: webhook has no installation id (is the app installed on this repository?)")
+	}
+
+	c.mu.Lock()
+	cached, ok := c.tokens[installationID]
+	c.mu.Unlock()
+	// 提前 5 分钟刷新, 避免长时间运行的任务在回帖时 token 过期
+	if ok && time.Until(cached.expiresAt) > 5*time.Minute {
+		return cached.token, nil
+	}
+
+	jwt, err := c.appJWT(time.Now())
+	if err != nil {
+		return "", err
+	}
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", c.apiURL, installationID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("github: create installation token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("github: create installation token: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var out struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("github: decode installation token: %w", err)
+	}
+
+	c.mu.Lock()
+	c.tokens[installationID] = installationToken{token: out.Token, expiresAt: out.ExpiresAt}
+	c.mu.Unlock()
+	return out.Token, nil
+}
+
+// appJWT 生成 GitHub App 身份的 RS256 JWT (有效期 10 分钟, iat 回拨 60s 容忍时钟偏差)
+func (c *Client) appJWT(now time.Time) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, _ := json.Marshal(map[string]int64{
+		"iat": now.Add(-60 * time.Second).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": c.appID,
+	})
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, c.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("github: sign app jwt: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
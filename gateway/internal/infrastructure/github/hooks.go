@@ -0,0 +1,79 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// 仓库 webhook 管理 (ngoclaw tunnel 为开发隧道临时创建 webhook).
+// 需要对仓库有 admin 权限的 PAT (github.token); installation token 通常无此权限.
+
+// Hook 仓库 webhook
+type Hook struct {
+	ID     int64    `json:"id"`
+	Active bool     `json:"active"`
+	Events []string `json:"events"`
+	Config struct {
+		URL string `json:"url"`
+	} `json:"config"`
+}
+
+// WebhookPath 网关上接收 GitHub 推送的路径
+const WebhookPath = "/webhooks/github"
+
+// HookEvents ngoclaw 处理的 webhook 事件 (见 ParseMention)
+var HookEvents = []string{"issue_comment", "pull_request"}
+
+// ListHooks 列出仓库的 webhook
+func (c *Client) ListHooks(ctx context.Context, repo string) ([]Hook, error) {
+	resp, err := c.do(ctx, 0, http.MethodGet, fmt.Sprintf("/repos/%s/hooks?per_page=100", repo), "application/vnd.github+json", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var hooks []Hook
+	if err := json.NewDecoder(resp.Body).Decode(&hooks); err != nil {
+		return nil, fmt.Errorf("github: decode hooks: %w", err)
+	}
+	return hooks, nil
+}
+
+// CreateHook 为仓库创建推送 HookEvents 的 JSON webhook, 返回其 ID
+func (c *Client) CreateHook(ctx context.Context, repo, url, secret string) (int64, error) {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"name":   "web",
+		"active": true,
+		"events": HookEvents,
+		"config": hookConfig(url, secret),
+	})
+	resp, err := c.do(ctx, 0, http.MethodPost, fmt.Sprintf("/repos/%s/hooks", repo), "application/vnd.github+json", payload)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	var hook Hook
+	if err := json.NewDecoder(resp.Body).Decode(&hook); err != nil {
+		return 0, fmt.Errorf("github: decode hook: %w", err)
+	}
+	return hook.ID, nil
+}
+
+// DeleteHook 删除 webhook
+func (c *Client) DeleteHook(ctx context.Context, repo string, id int64) error {
+	resp, err := c.do(ctx, 0, http.MethodDelete, fmt.Sprintf("/repos/%s/hooks/%d", repo, id), "application/vnd.github+json", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func hookConfig(url, secret string) map[string]string {
+	cfg := map[string]string{"url": url, "content_type": "json"}
+	if secret != "" {
+		cfg["secret"] = secret
+	}
+	return cfg
+}
@@ -0,0 +1,166 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ReplyMarker 附加在机器人回复末尾的隐藏标记, 用于忽略自己发出的评论 (防止回复中
+// 引用了 @mention 时触发循环)
+const ReplyMarker = "<!-- ngoclaw -->"
+
+// ErrNotAllowed 仓库或用户不在允许列表中
+var ErrNotAllowed = errors.New("repository or user not allowed")
+
+// VerifySignature 校验 X-Hub-Signature-256 (sha256=<hex HMAC>)
+func VerifySignature(secret string, body []byte, header string) bool {
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// Mention 一次需要响应的 @提及
+type Mention struct {
+	Event          string // issue_comment | pull_request
+	Repo           string // owner/name
+	Number         int
+	IsPullRequest  bool
+	InstallationID int64
+	Author         string
+	Association    string // OWNER / MEMBER / COLLABORATOR / CONTRIBUTOR / NONE ...
+	Title          string
+	Body           string // issue / PR 正文
+	Comment        string // 触发的评论 (pull_request 事件为空)
+	URL            string
+}
+
+type user struct {
+	Login string `json:"login"`
+	Type  string `json:"type"`
+}
+
+type issue struct {
+	Number            int       `json:"number"`
+	Title             string    `json:"title"`
+	Body              string    `json:"body"`
+	HTMLURL           string    `json:"html_url"`
+	User              user      `json:"user"`
+	AuthorAssociation string    `json:"author_association"`
+	PullRequest       *struct{} `json:"pull_request"`
+}
+
+type eventPayload struct {
+	Action     string `json:"action"`
+	Sender     user   `json:"sender"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Installation struct {
+		ID int64 `json:"id"`
+	} `json:"installation"`
+
+	// issue_comment
+	Issue   *issue `json:"issue"`
+	Comment *struct {
+		Body              string `json:"body"`
+		HTMLURL           string `json:"html_url"`
+		User              user   `json:"user"`
+		AuthorAssociation string `json:"author_association"`
+	} `json:"comment"`
+
+	// pull_request
+	PullRequest *issue `json:"pull_request"`
+}
+
+// ParseMention 解析 webhook 事件, 仅在新评论 / 新 PR 中 @handle 时返回 Mention;
+// 其他事件、动作、机器人发出的内容返回 nil.
+func ParseMention(event string, body []byte, handle string) (*Mention, error) {
+	if event != "issue_comment" && event != "pull_request" {
+		return nil, nil
+	}
+	var p eventPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("decode %s payload: %w", event, err)
+	}
+	if p.Sender.Type == "Bot" {
+		return nil, nil
+	}
+
+	m := &Mention{
+		Event:          event,
+		Repo:           p.Repository.FullName,
+		InstallationID: p.Installation.ID,
+	}
+	switch event {
+	case "issue_comment":
+		if p.Action != "created" || p.Issue == nil || p.Comment == nil {
+			return nil, nil
+		}
+		if strings.Contains(p.Comment.Body, ReplyMarker) || !mentions(p.Comment.Body, handle) {
+			return nil, nil
+		}
+		m.Number = p.Issue.Number
+		m.IsPullRequest = p.Issue.PullRequest != nil
+		m.Title = p.Issue.Title
+		m.Body = p.Issue.Body
+		m.Comment = p.Comment.Body
+		m.Author = p.Comment.User.Login
+		m.Association = p.Comment.AuthorAssociation
+		m.URL = p.Comment.HTMLURL
+
+	case "pull_request":
+		// 只在打开 / 转为 ready 时响应正文中的提及, 编辑不重复触发
+		if p.Action != "opened" && p.Action != "ready_for_review" || p.PullRequest == nil {
+			return nil, nil
+		}
+		if !mentions(p.PullRequest.Body, handle) {
+			return nil, nil
+		}
+		m.Number = p.PullRequest.Number
+		m.IsPullRequest = true
+		m.Title = p.PullRequest.Title
+		m.Body = p.PullRequest.Body
+		m.Author = p.PullRequest.User.Login
+		m.Association = p.PullRequest.AuthorAssociation
+		m.URL = p.PullRequest.HTMLURL
+	}
+	return m, nil
+}
+
+// mentions 判断文本是否 @handle (不区分大小写, 需完整匹配用户名, @ngoclaw-dev 不算)
+func mentions(text, handle string) bool {
+	handle = strings.ToLower(strings.TrimPrefix(handle, "@"))
+	if handle == "" {
+		return false
+	}
+	lower := strings.ToLower(text)
+	needle := "@" + handle
+	for i := 0; ; {
+		j := strings.Index(lower[i:], needle)
+		if j < 0 {
+			return false
+		}
+		end := i + j + len(needle)
+		if end == len(lower) || !isLoginChar(lower[end]) {
+			return true
+		}
+		i = end
+	}
+}
+
+func isLoginChar(b byte) bool {
+	return b == '-' || b >= 'a' && b <= 'z' || b >= '0' && b <= '9'
+}
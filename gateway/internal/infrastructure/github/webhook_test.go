@@ -0,0 +1,84 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"action":"created"}`)
+	if !VerifySignature("s3cret", body, sign("s3cret", body)) {
+		t.Error("valid signature rejected")
+	}
+	for name, header := range map[string]string{
+		"wrong secret": sign("other", body),
+		"sha1 header":  "sha1=" + strings.TrimPrefix(sign("s3cret", body), "sha256="),
+		"not hex":      "sha256=zz",
+		"missing":      "",
+	} {
+		if VerifySignature("s3cret", body, header) {
+			t.Errorf("%s: signature accepted", name)
+		}
+	}
+}
+
+const commentEvent = `{
+  "action": "created",
+  "sender": {"login": "alice", "type": "User"},
+  "repository": {"full_name": "acme/api"},
+  "installation": {"id": 42},
+  "issue": {"number": 7, "title": "Add retries", "body": "Adds retry logic", "pull_request": {}},
+  "comment": {"body": "%s", "user": {"login": "alice"}, "author_association": "MEMBER", "html_url": "https://github.com/acme/api/pull/7#c1"}
+}`
+
+func TestParseMention_IssueComment(t *testing.T) {
+	body := []byte(strings.Replace(commentEvent, "%s", "@NGOClaw please review", 1))
+	m, err := ParseMention("issue_comment", body, "@ngoclaw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == nil {
+		t.Fatal("mention not detected")
+	}
+	if m.Repo != "acme/api" || m.Number != 7 || !m.IsPullRequest || m.InstallationID != 42 || m.Association != "MEMBER" {
+		t.Errorf("mention = %+v", m)
+	}
+
+	for name, comment := range map[string]string{
+		"no mention":    "looks good",
+		"longer handle": "cc @ngoclaw-dev",
+		"own reply":     "@ngoclaw said hi " + strings.ReplaceAll(ReplyMarker, `"`, `\"`),
+	} {
+		body := []byte(strings.Replace(commentEvent, "%s", comment, 1))
+		if m, _ := ParseMention("issue_comment", body, "@ngoclaw"); m != nil {
+			t.Errorf("%s: unexpected mention %+v", name, m)
+		}
+	}
+}
+
+func TestParseMention_PullRequest(t *testing.T) {
+	event := `{"action": "%s", "sender": {"type": "User"}, "repository": {"full_name": "acme/api"},
+	  "pull_request": {"number": 9, "title": "Fix", "body": "@ngoclaw review", "user": {"login": "bob"}, "author_association": "OWNER"}}`
+
+	m, err := ParseMention("pull_request", []byte(strings.Replace(event, "%s", "opened", 1)), "ngoclaw")
+	if err != nil || m == nil {
+		t.Fatalf("opened PR: m=%v err=%v", m, err)
+	}
+	if !m.IsPullRequest || m.Number != 9 || m.Comment != "" {
+		t.Errorf("mention = %+v", m)
+	}
+
+	// 编辑正文不重复触发
+	if m, _ := ParseMention("pull_request", []byte(strings.Replace(event, "%s", "edited", 1)), "ngoclaw"); m != nil {
+		t.Errorf("edited PR triggered mention")
+	}
+}
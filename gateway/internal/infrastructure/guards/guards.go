@@ -0,0 +1,122 @@
+// Package guards 加载项目的工具调用守卫规则 (.ngoclaw/guards.yaml).
+//
+// 规则由项目维护者编写, 在 SecurityHook 中先于审批策略执行: 按路径 glob、
+// 命令正则、工具名匹配, 动作为 allow / deny / require_approval, 模型无法绕过.
+package guards
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+)
+
+// FileName 规则文件相对项目根目录的路径
+const FileName = ".ngoclaw/guards.yaml"
+
+// fileFormat guards.yaml 的结构
+type fileFormat struct {
+	Rules []service.GuardRule `yaml:"rules"`
+}
+
+// Parse 解析并编译规则文件. 未知字段视为错误: 拼错的条件 (如把 paths
+// 写成 path) 会报错, 不会被静默忽略而让规则失效
+func Parse(data []byte, root string) (*service.GuardRules, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var f fileFormat
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return service.CompileGuardRules(f.Rules, root)
+}
+
+// File 项目的规则文件. 每次查询时检查修改时间, 文件变化后自动重新加载
+type File struct {
+	path   string
+	root   string
+	logger *zap.Logger
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	rules   *service.GuardRules
+	loaded  bool  // 曾成功加载 (或确认文件不存在)
+	err     error // 从未成功加载时的错误
+}
+
+// Open 返回 root 项目的规则文件 (文件可以不存在, 创建后即生效)
+func Open(root string, logger *zap.Logger) *File {
+	return &File{
+		path:   filepath.Join(root, FileName),
+		root:   root,
+		logger: logger,
+	}
+}
+
+// Path 规则文件路径
+func (f *File) Path() string {
+	return f.path
+}
+
+// Rules 返回当前规则; 没有规则文件时为 nil.
+// 文件修改后无效时保留上一版规则并记录错误; 从未成功加载过 (启动时就无效)
+// 则返回错误, 由 SecurityHook 拒绝所有工具调用, 直到文件被修正
+func (f *File) Rules() (*service.GuardRules, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return f.failed(err)
+		}
+		if f.rules != nil {
+			f.logger.Info("Guard rules file removed", zap.String("path", f.path))
+		}
+		f.rules, f.loaded, f.err = nil, true, nil
+		f.modTime, f.size = time.Time{}, 0
+		return nil, nil
+	}
+	if f.loaded && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.rules, nil
+	}
+	if f.err != nil && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return nil, f.err
+	}
+	f.modTime, f.size = info.ModTime(), info.Size()
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return f.failed(err)
+	}
+	rules, err := Parse(data, f.root)
+	if err != nil {
+		return f.failed(err)
+	}
+	f.rules, f.loaded, f.err = rules, true, nil
+	f.logger.Info("Guard rules loaded",
+		zap.String("path", f.path),
+		zap.Int("rules", rules.Len()),
+	)
+	return rules, nil
+}
+
+// failed 处理加载失败: 有上一版规则时继续使用, 否则返回错误
+func (f *File) failed(err error) (*service.GuardRules, error) {
+	f.logger.Error("Invalid guard rules file", zap.String("path", f.path), zap.Error(err))
+	if f.loaded {
+		return f.rules, nil
+	}
+	f.err = fmt.Errorf("guard rules file %s is invalid, every tool call is blocked until it is fixed: %w", f.path, err)
+	return nil, f.err
+}
@@ -0,0 +1,67 @@
+package guards
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestParse_UnknownField(t *testing.T) {
+	if _, err := Parse([]byte("rules:\n  - name: x\n    path: [\"*.pem\"]\n    action: deny\n"), "/repo"); err == nil {
+		t.Error("misspelled condition accepted")
+	}
+	rules, err := Parse(nil, "/repo")
+	if err != nil || rules.Len() != 0 {
+		t.Errorf("empty file: rules=%v err=%v", rules, err)
+	}
+}
+
+func TestFile_Reload(t *testing.T) {
+	root := t.TempDir()
+	f := Open(root, zap.NewNop())
+
+	rules, err := f.Rules()
+	if err != nil || rules != nil {
+		t.Fatalf("missing file: rules=%v err=%v", rules, err)
+	}
+
+	write := func(content string, mod time.Time) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(f.Path()), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(f.Path(), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(f.Path(), mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+
+	write("rules:\n  - {name: keys, paths: [\"*.pem\"], action: deny}\n", now)
+	rules, err = f.Rules()
+	if err != nil || rules.Len() != 1 {
+		t.Fatalf("load: rules=%v err=%v", rules, err)
+	}
+
+	// an invalid edit keeps the last valid rules
+	write("rules:\n  - {name: keys, action: block}\n", now.Add(time.Second))
+	rules, err = f.Rules()
+	if err != nil || rules.Len() != 1 {
+		t.Errorf("invalid edit: rules=%v err=%v", rules, err)
+	}
+
+	// invalid from the start fails closed
+	g := Open(root, zap.NewNop())
+	if _, err := g.Rules(); err == nil {
+		t.Error("invalid file at startup returned no error")
+	}
+	write("rules: []\n", now.Add(2*time.Second))
+	if rules, err := g.Rules(); err != nil || rules.Len() != 0 {
+		t.Errorf("fixed file: rules=%v err=%v", rules, err)
+	}
+}
@@ -0,0 +1,205 @@
+// Package janitor periodically reaps resources that runs leak when they are
+// cancelled, crash or are simply abandoned: approval requests nobody waits
+// for any more, dead or idle language servers, processes left behind by
+// sandboxed commands and terminals, stale temp files, expired share
+// snapshots and transcripts past their retention.
+//
+// The janitor itself knows nothing about these resources; each owner
+// exposes a reap method and the application registers it under a kind.
+// Cumulative counts per kind are kept for the admin dashboard.
+package janitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ReapFunc 清理一类资源, 返回本次清理的数量
+type ReapFunc func(ctx context.Context) (int, error)
+
+// KindStats 一类资源的累计清理数据
+type KindStats struct {
+	Kind      string    `json:"kind"`
+	Removed   int64     `json:"removed"` // 累计清理数量
+	Last      int       `json:"last"`    // 最近一次清理数量
+	LastAt    time.Time `json:"last_at,omitempty"`
+	Errors    int64     `json:"errors"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// Stats 清理统计 (自启动以来)
+type Stats struct {
+	Interval  time.Duration `json:"interval"`
+	Sweeps    int64         `json:"sweeps"`
+	LastSweep time.Time     `json:"last_sweep,omitempty"`
+	Kinds     []KindStats   `json:"kinds"`
+}
+
+// Report 一次清理各类资源的数量
+type Report map[string]int
+
+// Total 清理总数
+func (r Report) Total() int {
+	n := 0
+	for _, v := range r {
+		n += v
+	}
+	return n
+}
+
+type reaper struct {
+	kind string
+	reap ReapFunc
+}
+
+// Janitor 定期执行注册的清理函数
+type Janitor struct {
+	interval time.Duration
+	logger   *zap.Logger
+	now      func() time.Time
+
+	mu      sync.Mutex
+	reapers []reaper
+	stats   Stats
+	kinds   map[string]*KindStats
+
+	sweepMu sync.Mutex // 串行化清理
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// New 创建清理器 (调用 Start 后开始定时清理)
+func New(interval time.Duration, logger *zap.Logger) *Janitor {
+	return &Janitor{
+		interval: interval,
+		logger:   logger.With(zap.String("component", "janitor")),
+		now:      time.Now,
+		stats:    Stats{Interval: interval},
+		kinds:    make(map[string]*KindStats),
+	}
+}
+
+// Add 注册一类资源的清理函数, 按注册顺序执行
+func (j *Janitor) Add(kind string, reap ReapFunc) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.reapers = append(j.reapers, reaper{kind: kind, reap: reap})
+	if _, ok := j.kinds[kind]; !ok {
+		j.kinds[kind] = &KindStats{Kind: kind}
+	}
+}
+
+// Start 每个间隔清理一次 (启动时不立即清理, 上次运行留下的资源由第一次清理处理);
+// 间隔为 0 时不启动
+func (j *Janitor) Start() {
+	if j.interval <= 0 {
+		return
+	}
+	j.stop = make(chan struct{})
+	j.done = make(chan struct{})
+	go func() {
+		defer close(j.done)
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-j.stop:
+				return
+			case <-ticker.C:
+				j.sweepLogged()
+			}
+		}
+	}()
+	j.logger.Info("Janitor started", zap.Duration("interval", j.interval))
+}
+
+// Stop 停止定时清理并等待进行中的清理结束
+func (j *Janitor) Stop() {
+	if j.stop == nil {
+		return
+	}
+	close(j.stop)
+	<-j.done
+	j.stop = nil
+}
+
+func (j *Janitor) sweepLogged() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	report, err := j.Sweep(ctx)
+	if err != nil {
+		j.logger.Warn("Janitor sweep incomplete", zap.Error(err))
+	}
+	if report.Total() > 0 {
+		fields := make([]zap.Field, 0, len(report))
+		for kind, n := range report {
+			if n > 0 {
+				fields = append(fields, zap.Int(kind, n))
+			}
+		}
+		j.logger.Info("Janitor reaped leaked resources", fields...)
+	}
+}
+
+// Sweep 依次执行全部清理函数. 单个失败不影响其他类别, 错误合并返回
+func (j *Janitor) Sweep(ctx context.Context) (Report, error) {
+	j.sweepMu.Lock()
+	defer j.sweepMu.Unlock()
+
+	j.mu.Lock()
+	reapers := append([]reaper(nil), j.reapers...)
+	j.mu.Unlock()
+
+	report := make(Report, len(reapers))
+	var errs []error
+	for _, r := range reapers {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		n, err := r.reap(ctx)
+		report[r.kind] += n
+
+		j.mu.Lock()
+		ks := j.kinds[r.kind]
+		ks.Removed += int64(n)
+		ks.Last = n
+		ks.LastAt = j.now()
+		if err != nil {
+			ks.Errors++
+			ks.LastError = err.Error()
+		}
+		j.mu.Unlock()
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.kind, err))
+		}
+	}
+
+	j.mu.Lock()
+	j.stats.Sweeps++
+	j.stats.LastSweep = j.now()
+	j.mu.Unlock()
+	return report, errors.Join(errs...)
+}
+
+// Stats 返回累计清理统计, 类别按注册顺序
+func (j *Janitor) Stats() Stats {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	s := j.stats
+	s.Kinds = make([]KindStats, 0, len(j.reapers))
+	seen := make(map[string]bool, len(j.reapers))
+	for _, r := range j.reapers {
+		if !seen[r.kind] {
+			seen[r.kind] = true
+			s.Kinds = append(s.Kinds, *j.kinds[r.kind])
+		}
+	}
+	return s
+}
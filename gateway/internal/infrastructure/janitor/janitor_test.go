@@ -0,0 +1,82 @@
+package janitor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestJanitor_SweepCountsPerKind(t *testing.T) {
+	j := New(time.Minute, zap.NewNop())
+	leaked := 3
+	j.Add("approvals", func(context.Context) (int, error) {
+		n := leaked
+		leaked = 0
+		return n, nil
+	})
+	j.Add("temp_files", func(context.Context) (int, error) { return 1, errors.New("permission denied") })
+
+	report, err := j.Sweep(context.Background())
+	if err == nil {
+		t.Error("reaper error was not returned")
+	}
+	if report["approvals"] != 3 || report["temp_files"] != 1 || report.Total() != 4 {
+		t.Errorf("report = %v", report)
+	}
+	if _, err := j.Sweep(context.Background()); err == nil {
+		t.Error("second sweep should fail again")
+	}
+
+	stats := j.Stats()
+	if stats.Sweeps != 2 || len(stats.Kinds) != 2 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if k := stats.Kinds[0]; k.Kind != "approvals" || k.Removed != 3 || k.Last != 0 || k.Errors != 0 {
+		t.Errorf("approvals = %+v", k)
+	}
+	if k := stats.Kinds[1]; k.Kind != "temp_files" || k.Removed != 2 || k.Errors != 2 || k.LastError != "permission denied" {
+		t.Errorf("temp_files = %+v", k)
+	}
+}
+
+func TestRemoveStale(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+	mk := func(rel string, mtime time.Time) {
+		path := filepath.Join(dir, rel)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, []byte("x"), 0o644)
+		os.Chtimes(path, mtime, mtime)
+		os.Chtimes(filepath.Dir(path), mtime, mtime)
+	}
+	mk("ngoclaw-eval-1/main.go", old) // stale directory
+	mk("ngoclaw-eval-2/old.go", old)  // directory still in use: a file inside is fresh
+	mk("ngoclaw-eval-2/sub/new.go", now)
+	mk("ngoclaw-baseline-3", old) // stale file
+	mk("other-4", old)            // not ours
+
+	n, err := RemoveStale(dir, []string{"ngoclaw-eval-*", "ngoclaw-baseline-*"}, 24*time.Hour, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("removed %d, want 2", n)
+	}
+	for name, want := range map[string]bool{
+		"ngoclaw-eval-1": false, "ngoclaw-eval-2": true, "ngoclaw-baseline-3": false, "other-4": true,
+	} {
+		if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", name, err == nil, want)
+		}
+	}
+
+	if n, err := RemoveStale(filepath.Join(dir, "missing"), []string{"*"}, time.Hour, now); n != 0 || err != nil {
+		t.Errorf("missing dir: %d, %v", n, err)
+	}
+}
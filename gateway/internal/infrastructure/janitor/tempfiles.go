@@ -0,0 +1,67 @@
+package janitor
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RemoveStale 删除 dir 中匹配 patterns (filepath.Match 语法) 且超过 maxAge
+// 没有修改的文件和目录. 目录按其中最新的修改时间判断, 仍在使用的工作目录
+// 不会被删除. 返回删除的条目数; dir 不存在时不算错误
+func RemoveStale(dir string, patterns []string, maxAge time.Duration, now time.Time) (int, error) {
+	if maxAge <= 0 {
+		return 0, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	cutoff := now.Add(-maxAge)
+	n := 0
+	var errs []error
+	for _, e := range entries {
+		if !matchAny(patterns, e.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if !lastModified(path).Before(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		n++
+	}
+	return n, errors.Join(errs...)
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// lastModified 文件的修改时间, 或目录树中最新的修改时间
+func lastModified(path string) time.Time {
+	var latest time.Time
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return latest
+}
@@ -0,0 +1,95 @@
+// Package jobqueue runs agent tasks submitted asynchronously by external
+// systems.
+//
+// Producers enqueue a Job (directly on the backing stream, or through
+// POST /api/v1/jobs); a Pool claims jobs with bounded worker concurrency, runs
+// them through the agent loop, publishes progress events back to the queue and
+// POSTs the final result to the job's callback URL. Jobs are only acknowledged
+// once finished, so jobs held by a crashed or restarted gateway are re-claimed.
+//
+// Backends: Redis Streams (consumer groups, survives restarts) and an
+// in-process memory queue for tests and single-node setups. Other brokers
+// (NATS JetStream, ...) plug in by implementing Queue.
+package jobqueue
+
+import (
+	"context"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+)
+
+// Job 一个异步 agent 任务
+type Job struct {
+	ID           string            `json:"id"`
+	Prompt       string            `json:"prompt"`
+	SystemPrompt string            `json:"system_prompt,omitempty"` // 追加到组装后的系统提示词
+	Model        string            `json:"model,omitempty"`
+	CallbackURL  string            `json:"callback_url,omitempty"` // 完成/失败后 POST JobResult
+	Metadata     map[string]string `json:"metadata,omitempty"`     // 原样回传给回调
+	EnqueuedAt   time.Time         `json:"enqueued_at"`
+}
+
+// Delivery 一次投递; Attempt 从 1 开始, 被重新认领时递增
+type Delivery struct {
+	Job     *Job
+	Attempt int
+
+	ref string // 后端内部引用 (Redis stream entry ID)
+}
+
+// Progress 发布到队列的进度事件
+type Progress struct {
+	JobID     string    `json:"job_id"`
+	Type      string    `json:"type"` // started | tool_call | tool_result | thinking | step_done | completed | failed
+	Content   string    `json:"content,omitempty"`
+	Tool      string    `json:"tool,omitempty"`
+	Step      int       `json:"step,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// JobResult 任务最终结果 (回调 body 与 completed/failed 进度事件内容)
+type JobResult struct {
+	JobID       string             `json:"job_id"`
+	Status      string             `json:"status"` // completed | failed
+	Content     string             `json:"content,omitempty"`
+	Error       string             `json:"error,omitempty"`
+	TotalSteps  int                `json:"total_steps,omitempty"`
+	TotalTokens int                `json:"total_tokens,omitempty"`
+	ModelUsed   string             `json:"model_used,omitempty"`
+	Tests       *entity.TestReport `json:"tests,omitempty"` // 最后一次 run_tests 的结果与覆盖率变化
+	Attempt     int                `json:"attempt"`
+	Metadata    map[string]string  `json:"metadata,omitempty"`
+	FinishedAt  time.Time          `json:"finished_at"`
+}
+
+// Progress / result status values
+const (
+	StatusStarted   = "started"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Queue 任务队列后端
+type Queue interface {
+	// Enqueue 提交任务, 返回任务 ID (Job.ID 为空时生成)
+	Enqueue(ctx context.Context, job *Job) (string, error)
+
+	// Claim 为 consumer 认领一个新任务, 最多阻塞 block; 无任务时返回 nil, nil
+	Claim(ctx context.Context, consumer string, block time.Duration) (*Delivery, error)
+
+	// Reclaim 认领其他 consumer 空闲超过 minIdle 的未确认任务;
+	// includeOwn 为 true 时同时认领本 consumer 名下的全部未确认任务 (仅在启动时使用, 即重启前遗留的任务)
+	Reclaim(ctx context.Context, consumer string, minIdle time.Duration, includeOwn bool) ([]*Delivery, error)
+
+	// Touch 刷新任务的空闲计时, 防止长任务被其他实例误认领
+	Touch(ctx context.Context, consumer string, d *Delivery) error
+
+	// Ack 确认任务已结束 (成功或放弃)
+	Ack(ctx context.Context, d *Delivery) error
+
+	// Publish 发布进度事件
+	Publish(ctx context.Context, p *Progress) error
+
+	Close() error
+}
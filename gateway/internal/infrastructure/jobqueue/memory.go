@@ -0,0 +1,157 @@
+package jobqueue
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryQueue 进程内队列, 用于测试和单机部署; 进程重启后任务丢失
+type MemoryQueue struct {
+	mu       sync.Mutex
+	ready    []*memEntry
+	pending  map[string]*memEntry
+	progress []Progress
+	notify   chan struct{}
+}
+
+type memEntry struct {
+	job         *Job
+	consumer    string
+	deliveredAt time.Time
+	deliveries  int
+}
+
+// NewMemoryQueue 创建进程内队列
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{
+		pending: make(map[string]*memEntry),
+		notify:  make(chan struct{}, 1),
+	}
+}
+
+// Enqueue 提交任务
+func (q *MemoryQueue) Enqueue(ctx context.Context, job *Job) (string, error) {
+	if job.ID == "" {
+		job.ID = uuid.NewString()
+	}
+	if job.EnqueuedAt.IsZero() {
+		job.EnqueuedAt = time.Now()
+	}
+	q.mu.Lock()
+	q.ready = append(q.ready, &memEntry{job: job})
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return job.ID, nil
+}
+
+// Claim 认领一个新任务
+func (q *MemoryQueue) Claim(ctx context.Context, consumer string, block time.Duration) (*Delivery, error) {
+	timer := time.NewTimer(block)
+	defer timer.Stop()
+	for {
+		if d := q.pop(consumer); d != nil {
+			return d, nil
+		}
+		select {
+		case <-q.notify:
+		case <-timer.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (q *MemoryQueue) pop(consumer string) *Delivery {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.ready) == 0 {
+		return nil
+	}
+	e := q.ready[0]
+	q.ready = q.ready[1:]
+	e.consumer = consumer
+	e.deliveredAt = time.Now()
+	e.deliveries++
+	q.pending[e.job.ID] = e
+	return &Delivery{Job: e.job, Attempt: e.deliveries, ref: e.job.ID}
+}
+
+// Reclaim 认领空闲的未确认任务
+func (q *MemoryQueue) Reclaim(ctx context.Context, consumer string, minIdle time.Duration, includeOwn bool) ([]*Delivery, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var out []*Delivery
+	now := time.Now()
+	for id, e := range q.pending {
+		if e.consumer == consumer {
+			if !includeOwn {
+				continue
+			}
+		} else if now.Sub(e.deliveredAt) < minIdle {
+			continue
+		}
+		e.consumer = consumer
+		e.deliveredAt = now
+		e.deliveries++
+		out = append(out, &Delivery{Job: e.job, Attempt: e.deliveries, ref: id})
+	}
+	return out, nil
+}
+
+// Touch 刷新空闲计时
+func (q *MemoryQueue) Touch(ctx context.Context, consumer string, d *Delivery) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if e, ok := q.pending[d.ref]; ok {
+		e.consumer = consumer
+		e.deliveredAt = time.Now()
+	}
+	return nil
+}
+
+// Ack 确认任务
+func (q *MemoryQueue) Ack(ctx context.Context, d *Delivery) error {
+	q.mu.Lock()
+	delete(q.pending, d.ref)
+	q.mu.Unlock()
+	return nil
+}
+
+// Publish 记录进度事件
+func (q *MemoryQueue) Publish(ctx context.Context, p *Progress) error {
+	q.mu.Lock()
+	q.progress = append(q.progress, *p)
+	q.mu.Unlock()
+	return nil
+}
+
+// ProgressFor 返回某任务的全部进度事件
+func (q *MemoryQueue) ProgressFor(jobID string) []Progress {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var out []Progress
+	for _, p := range q.progress {
+		if p.JobID == jobID {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// PendingCount 已认领未确认的任务数
+func (q *MemoryQueue) PendingCount() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// Close 无资源需要释放
+func (q *MemoryQueue) Close() error { return nil }
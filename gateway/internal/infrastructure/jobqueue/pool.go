@@ -0,0 +1,356 @@
+package jobqueue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"go.uber.org/zap"
+)
+
+// RunOutput 单个任务的 agent 运行结果
+type RunOutput struct {
+	Content     string
+	TotalSteps  int
+	TotalTokens int
+	ModelUsed   string
+	Tests       *entity.TestReport
+}
+
+// Runner 执行一个任务; emit 接收 agent loop 事件用于进度上报
+type Runner interface {
+	RunJob(ctx context.Context, job *Job, emit func(entity.AgentEvent)) (*RunOutput, error)
+}
+
+// PoolConfig 工作池配置
+type PoolConfig struct {
+	Consumer    string        // consumer 名称, 需跨重启保持稳定 (默认 hostname)
+	Concurrency int           // 同时执行的任务数 (默认 2)
+	ReclaimIdle time.Duration // 其他实例的任务空闲多久后接管 (默认 10m)
+	JobTimeout  time.Duration // 单个任务超时 (默认 30m)
+	MaxAttempts int           // 最多投递次数, 超过后标记失败 (默认 3)
+}
+
+// Pool 从队列认领任务并以有限并发执行
+type Pool struct {
+	queue  Queue
+	runner Runner
+	cfg    PoolConfig
+	client *http.Client
+	logger *zap.Logger
+
+	sem    chan struct{}
+	wg     sync.WaitGroup
+	cancel context.CancelCauseFunc
+
+	mu      sync.Mutex
+	running map[string]*Delivery
+}
+
+// NewPool 创建工作池
+func NewPool(queue Queue, runner Runner, cfg PoolConfig, logger *zap.Logger) *Pool {
+	if cfg.Consumer == "" {
+		host, _ := os.Hostname()
+		if host == "" {
+			host = "gateway"
+		}
+		cfg.Consumer = host
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 2
+	}
+	if cfg.ReclaimIdle <= 0 {
+		cfg.ReclaimIdle = 10 * time.Minute
+	}
+	if cfg.JobTimeout <= 0 {
+		cfg.JobTimeout = 30 * time.Minute
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+	return &Pool{
+		queue:   queue,
+		runner:  runner,
+		cfg:     cfg,
+		client:  &http.Client{Timeout: 15 * time.Second},
+		logger:  logger.With(zap.String("component", "job-pool"), zap.String("consumer", cfg.Consumer)),
+		sem:     make(chan struct{}, cfg.Concurrency),
+		running: make(map[string]*Delivery),
+	}
+}
+
+// Start 先接管重启前遗留的任务, 再开始认领新任务; 非阻塞
+func (p *Pool) Start(ctx context.Context) {
+	ctx, p.cancel = context.WithCancelCause(ctx)
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.reclaim(ctx, true)
+		p.claimLoop(ctx)
+	}()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.maintenanceLoop(ctx)
+	}()
+
+	p.logger.Info("Job worker pool started",
+		zap.Int("concurrency", p.cfg.Concurrency),
+		zap.Duration("reclaim_idle", p.cfg.ReclaimIdle),
+	)
+}
+
+// Stop 停止认领并取消执行中的任务; 未确认的任务留在队列中, 下次启动时接管
+func (p *Pool) Stop() {
+	if p.cancel != nil {
+		p.cancel(service.NewAbortError(service.AbortShutdown, "job worker stopping"))
+	}
+	p.wg.Wait()
+	p.queue.Close()
+}
+
+func (p *Pool) claimLoop(ctx context.Context) {
+	for {
+		// 先占用并发槽位再认领, 避免认领了却无法执行
+		select {
+		case p.sem <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
+		d, err := p.queue.Claim(ctx, p.cfg.Consumer, 5*time.Second)
+		if err != nil || d == nil {
+			<-p.sem
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				p.logger.Warn("Claim job failed", zap.Error(err))
+				sleepOrDone(ctx, 2*time.Second)
+			}
+			continue
+		}
+		p.dispatch(ctx, d)
+	}
+}
+
+// maintenanceLoop 定期刷新执行中任务的空闲计时, 并接管其他实例遗留的任务
+func (p *Pool) maintenanceLoop(ctx context.Context) {
+	touchEvery := p.cfg.ReclaimIdle / 3
+	ticker := time.NewTicker(touchEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.touchRunning(ctx)
+			p.reclaim(ctx, false)
+		}
+	}
+}
+
+func (p *Pool) touchRunning(ctx context.Context) {
+	p.mu.Lock()
+	deliveries := make([]*Delivery, 0, len(p.running))
+	for _, d := range p.running {
+		deliveries = append(deliveries, d)
+	}
+	p.mu.Unlock()
+
+	for _, d := range deliveries {
+		if err := p.queue.Touch(ctx, p.cfg.Consumer, d); err != nil {
+			p.logger.Warn("Touch job failed", zap.String("job_id", d.Job.ID), zap.Error(err))
+		}
+	}
+}
+
+func (p *Pool) reclaim(ctx context.Context, includeOwn bool) {
+	deliveries, err := p.queue.Reclaim(ctx, p.cfg.Consumer, p.cfg.ReclaimIdle, includeOwn)
+	if err != nil {
+		p.logger.Warn("Reclaim pending jobs failed", zap.Error(err))
+	}
+	if len(deliveries) > 0 {
+		p.logger.Info("Reclaimed pending jobs", zap.Int("count", len(deliveries)), zap.Bool("own", includeOwn))
+	}
+	for _, d := range deliveries {
+		select {
+		case p.sem <- struct{}{}:
+			p.dispatch(ctx, d)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatch 在已占用的槽位上执行任务
+func (p *Pool) dispatch(ctx context.Context, d *Delivery) {
+	p.mu.Lock()
+	p.running[d.Job.ID] = d
+	p.mu.Unlock()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer func() { <-p.sem }()
+		defer func() {
+			p.mu.Lock()
+			delete(p.running, d.Job.ID)
+			p.mu.Unlock()
+		}()
+		p.execute(ctx, d)
+	}()
+}
+
+func (p *Pool) execute(ctx context.Context, d *Delivery) {
+	job := d.Job
+	log := p.logger.With(zap.String("job_id", job.ID), zap.Int("attempt", d.Attempt))
+
+	if d.Attempt > p.cfg.MaxAttempts {
+		log.Warn("Job exceeded max attempts, giving up")
+		p.finish(ctx, d, nil, fmt.Errorf("gave up after %d attempts", p.cfg.MaxAttempts))
+		return
+	}
+
+	log.Info("Job started")
+	p.publish(ctx, &Progress{JobID: job.ID, Type: StatusStarted, Content: fmt.Sprintf("attempt %d", d.Attempt)})
+
+	runCtx, cancel := context.WithTimeout(ctx, p.cfg.JobTimeout)
+	defer cancel()
+
+	out, err := p.runner.RunJob(runCtx, job, func(ev entity.AgentEvent) {
+		if pr := progressFromEvent(job.ID, ev); pr != nil {
+			p.publish(ctx, pr)
+		}
+	})
+
+	if ctx.Err() != nil {
+		// 网关正在停止: 不确认, 重启后由本 consumer 接管重跑
+		log.Info("Job interrupted by shutdown, left pending")
+		return
+	}
+	p.finish(ctx, d, out, err)
+}
+
+// finish 发布最终状态、回调并确认
+func (p *Pool) finish(ctx context.Context, d *Delivery, out *RunOutput, runErr error) {
+	job := d.Job
+	res := &JobResult{
+		JobID:      job.ID,
+		Status:     StatusCompleted,
+		Attempt:    d.Attempt,
+		Metadata:   job.Metadata,
+		FinishedAt: time.Now(),
+	}
+	if out != nil {
+		res.Content = out.Content
+		res.TotalSteps = out.TotalSteps
+		res.TotalTokens = out.TotalTokens
+		res.ModelUsed = out.ModelUsed
+		res.Tests = out.Tests
+	}
+	if runErr != nil {
+		res.Status = StatusFailed
+		res.Error = runErr.Error()
+	}
+
+	payload, _ := json.Marshal(res)
+	p.publish(ctx, &Progress{JobID: job.ID, Type: res.Status, Content: string(payload)})
+
+	if job.CallbackURL != "" {
+		if err := p.callback(ctx, job.CallbackURL, payload); err != nil {
+			p.logger.Warn("Job callback failed",
+				zap.String("job_id", job.ID),
+				zap.String("url", job.CallbackURL),
+				zap.Error(err),
+			)
+		}
+	}
+
+	if err := p.queue.Ack(ctx, d); err != nil {
+		p.logger.Error("Ack job failed", zap.String("job_id", job.ID), zap.Error(err))
+		return
+	}
+	p.logger.Info("Job finished",
+		zap.String("job_id", job.ID),
+		zap.String("status", res.Status),
+		zap.Int("steps", res.TotalSteps),
+	)
+}
+
+func (p *Pool) publish(ctx context.Context, pr *Progress) {
+	if pr.Timestamp.IsZero() {
+		pr.Timestamp = time.Now()
+	}
+	if err := p.queue.Publish(ctx, pr); err != nil {
+		p.logger.Debug("Publish job progress failed", zap.String("job_id", pr.JobID), zap.Error(err))
+	}
+}
+
+func (p *Pool) callback(ctx context.Context, url string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("callback returned %s", resp.Status)
+	}
+	return nil
+}
+
+// progressFromEvent 将 agent 事件转换为进度事件; 文本增量不上报, 避免刷屏
+func progressFromEvent(jobID string, ev entity.AgentEvent) *Progress {
+	pr := &Progress{JobID: jobID, Type: string(ev.Type), Timestamp: ev.Timestamp}
+	switch ev.Type {
+	case entity.EventToolCall:
+		if ev.ToolCall != nil {
+			pr.Tool = ev.ToolCall.Name
+		}
+	case entity.EventToolResult:
+		if ev.ToolCall != nil {
+			pr.Tool = ev.ToolCall.Name
+			pr.Content = truncate(ev.ToolCall.Output, 500)
+		}
+	case entity.EventThinking:
+		pr.Content = truncate(ev.Content, 500)
+	case entity.EventStepDone:
+		if ev.StepInfo != nil {
+			pr.Step = ev.StepInfo.Step
+		}
+	case entity.EventError:
+		pr.Content = ev.Error
+	default:
+		return nil
+	}
+	return pr
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
+
+func sleepOrDone(ctx context.Context, d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,170 @@
+package jobqueue
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+type fakeRunner struct {
+	active  atomic.Int32
+	maxSeen atomic.Int32
+	block   chan struct{} // nil = 不阻塞
+}
+
+func (r *fakeRunner) RunJob(ctx context.Context, job *Job, emit func(entity.AgentEvent)) (*RunOutput, error) {
+	n := r.active.Add(1)
+	defer r.active.Add(-1)
+	for {
+		m := r.maxSeen.Load()
+		if n <= m || r.maxSeen.CompareAndSwap(m, n) {
+			break
+		}
+	}
+
+	emit(entity.AgentEvent{Type: entity.EventToolCall, ToolCall: &entity.ToolCallEvent{Name: "bash"}})
+	emit(entity.AgentEvent{Type: entity.EventTextDelta, Content: "ignored"})
+
+	if r.block != nil {
+		select {
+		case <-r.block:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	} else {
+		time.Sleep(20 * time.Millisecond)
+	}
+	return &RunOutput{Content: "done: " + job.Prompt, TotalSteps: 1}, nil
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPool_RunsJobsWithConcurrencyLimitAndCallback(t *testing.T) {
+	var mu sync.Mutex
+	var results []JobResult
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var res JobResult
+		json.NewDecoder(r.Body).Decode(&res)
+		mu.Lock()
+		results = append(results, res)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	q := NewMemoryQueue()
+	for _, prompt := range []string{"a", "b", "c", "d", "e"} {
+		q.Enqueue(context.Background(), &Job{Prompt: prompt, CallbackURL: srv.URL})
+	}
+
+	runner := &fakeRunner{}
+	pool := NewPool(q, runner, PoolConfig{Consumer: "w1", Concurrency: 2}, zap.NewNop())
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(results) == 5
+	})
+
+	if got := runner.maxSeen.Load(); got > 2 {
+		t.Errorf("max concurrent jobs = %d, want <= 2", got)
+	}
+	if q.PendingCount() != 0 {
+		t.Errorf("pending = %d, want 0 after ack", q.PendingCount())
+	}
+
+	mu.Lock()
+	first := results[0]
+	mu.Unlock()
+	if first.Status != StatusCompleted || first.Content == "" {
+		t.Errorf("unexpected callback result: %+v", first)
+	}
+
+	var types []string
+	for _, p := range q.ProgressFor(first.JobID) {
+		types = append(types, p.Type)
+	}
+	want := []string{StatusStarted, "tool_call", StatusCompleted}
+	if len(types) != len(want) {
+		t.Fatalf("progress types = %v, want %v (text deltas are not reported)", types, want)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("progress types = %v, want %v", types, want)
+		}
+	}
+}
+
+func TestPool_ReclaimsPendingJobsAfterRestart(t *testing.T) {
+	q := NewMemoryQueue()
+	id, _ := q.Enqueue(context.Background(), &Job{Prompt: "long"})
+
+	// 第一个实例认领后被停止, 任务未确认
+	blocked := &fakeRunner{block: make(chan struct{})}
+	pool := NewPool(q, blocked, PoolConfig{Consumer: "w1", Concurrency: 1}, zap.NewNop())
+	pool.Start(context.Background())
+	waitFor(t, func() bool { return blocked.active.Load() == 1 })
+	pool.Stop()
+
+	if q.PendingCount() != 1 {
+		t.Fatalf("pending = %d, want 1 after shutdown", q.PendingCount())
+	}
+
+	// 同名 consumer 重启后接管
+	runner := &fakeRunner{}
+	pool = NewPool(q, runner, PoolConfig{Consumer: "w1", Concurrency: 1}, zap.NewNop())
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	waitFor(t, func() bool { return q.PendingCount() == 0 })
+
+	progress := q.ProgressFor(id)
+	last := progress[len(progress)-1]
+	if last.Type != StatusCompleted {
+		t.Fatalf("last progress = %+v, want completed", last)
+	}
+	var res JobResult
+	json.Unmarshal([]byte(last.Content), &res)
+	if res.Attempt != 2 {
+		t.Errorf("attempt = %d, want 2", res.Attempt)
+	}
+}
+
+func TestPool_GivesUpAfterMaxAttempts(t *testing.T) {
+	q := NewMemoryQueue()
+	id, _ := q.Enqueue(context.Background(), &Job{Prompt: "poison"})
+	d, _ := q.Claim(context.Background(), "w1", time.Millisecond)
+	q.Reclaim(context.Background(), "w1", 0, true) // attempt 2
+	if d == nil {
+		t.Fatal("claim returned nil")
+	}
+
+	runner := &fakeRunner{}
+	pool := NewPool(q, runner, PoolConfig{Consumer: "w1", MaxAttempts: 2}, zap.NewNop())
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	waitFor(t, func() bool { return q.PendingCount() == 0 })
+	progress := q.ProgressFor(id)
+	if last := progress[len(progress)-1]; last.Type != StatusFailed {
+		t.Fatalf("last progress = %+v, want failed", last)
+	}
+}
@@ -0,0 +1,264 @@
+package jobqueue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	reclaimBatch      = 100
+	progressMaxLen    = "10000"
+	redisFieldJob     = "job"
+	redisFieldJobID   = "job_id"
+	redisFieldType    = "type"
+	redisFieldPayload = "data"
+)
+
+// RedisConfig Redis Streams 后端配置
+type RedisConfig struct {
+	Addr     string // host:port
+	Password string
+	DB       int
+	Prefix   string // key 前缀, 默认 "ngoclaw"
+}
+
+// RedisQueue 基于 Redis Streams + consumer group 的任务队列。
+//
+//	<prefix>:jobs       任务流 (XADD job=<json>)
+//	<prefix>:progress   进度事件流 (job_id / type / data)
+//	<prefix>-workers    consumer group
+//
+// 任务在 Ack 前一直留在 group 的 PEL 中, 网关重启后可重新认领。
+type RedisQueue struct {
+	cmd      *respClient // 普通命令
+	blocking *respClient // XREADGROUP BLOCK 独占连接, 避免阻塞其他命令
+
+	stream   string
+	progress string
+	group    string
+}
+
+// NewRedisQueue 连接 Redis 并确保 consumer group 存在
+func NewRedisQueue(cfg RedisConfig) (*RedisQueue, error) {
+	if cfg.Addr == "" {
+		cfg.Addr = "127.0.0.1:6379"
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = "ngoclaw"
+	}
+	q := &RedisQueue{
+		cmd:      newRESPClient(cfg.Addr, cfg.Password, cfg.DB),
+		blocking: newRESPClient(cfg.Addr, cfg.Password, cfg.DB),
+		stream:   cfg.Prefix + ":jobs",
+		progress: cfg.Prefix + ":progress",
+		group:    cfg.Prefix + "-workers",
+	}
+	if err := q.ensureGroup(); err != nil {
+		q.Close()
+		return nil, err
+	}
+	return q, nil
+}
+
+// ensureGroup 创建 consumer group; 从流起点消费, 网关首次启动前入队的任务也会被处理
+func (q *RedisQueue) ensureGroup() error {
+	_, err := q.cmd.Do(0, "XGROUP", "CREATE", q.stream, q.group, "0", "MKSTREAM")
+	if err != nil && !strings.Contains(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("create consumer group: %w", err)
+	}
+	return nil
+}
+
+// Enqueue 提交任务
+func (q *RedisQueue) Enqueue(ctx context.Context, job *Job) (string, error) {
+	if job.ID == "" {
+		job.ID = uuid.NewString()
+	}
+	if job.EnqueuedAt.IsZero() {
+		job.EnqueuedAt = time.Now()
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		return "", err
+	}
+	if _, err := q.cmd.Do(0, "XADD", q.stream, "*", redisFieldJob, string(data)); err != nil {
+		return "", fmt.Errorf("enqueue job: %w", err)
+	}
+	return job.ID, nil
+}
+
+// Claim 认领一个新任务
+func (q *RedisQueue) Claim(ctx context.Context, consumer string, block time.Duration) (*Delivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ms := strconv.FormatInt(block.Milliseconds(), 10)
+	reply, err := q.blocking.Do(block, "XREADGROUP", "GROUP", q.group, consumer,
+		"COUNT", "1", "BLOCK", ms, "STREAMS", q.stream, ">")
+	if err != nil && strings.Contains(err.Error(), "NOGROUP") {
+		// 流被删除后重建 group
+		if gerr := q.ensureGroup(); gerr != nil {
+			return nil, gerr
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, nil // BLOCK 超时
+	}
+
+	// [[stream, [[id, [field, value, ...]]]]]
+	streams, _ := reply.([]interface{})
+	for _, s := range streams {
+		pair, _ := s.([]interface{})
+		if len(pair) != 2 {
+			continue
+		}
+		entries, _ := pair[1].([]interface{})
+		for _, e := range entries {
+			id, job, err := parseEntry(e)
+			if err != nil {
+				// 格式错误的任务无法执行, 按条目 ID 确认并删除; 连 ID 都取不到时
+				// 留在 PEL 中, 由 Reclaim 用 XPENDING 给出的 ID 清理
+				if id != "" {
+					q.discard(id)
+				}
+				return nil, err
+			}
+			if job != nil {
+				return &Delivery{Job: job, Attempt: 1, ref: id}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// Reclaim 认领空闲的未确认任务
+func (q *RedisQueue) Reclaim(ctx context.Context, consumer string, minIdle time.Duration, includeOwn bool) ([]*Delivery, error) {
+	// XPENDING key group - + count → [[id, consumer, idle_ms, deliveries], ...]
+	reply, err := q.cmd.Do(0, "XPENDING", q.stream, q.group, "-", "+", strconv.Itoa(reclaimBatch))
+	if err != nil {
+		return nil, fmt.Errorf("xpending: %w", err)
+	}
+	rows, _ := reply.([]interface{})
+
+	var out []*Delivery
+	for _, r := range rows {
+		if ctx.Err() != nil {
+			return out, ctx.Err()
+		}
+		row, _ := r.([]interface{})
+		if len(row) != 4 {
+			continue
+		}
+		id, _ := row[0].(string)
+		owner, _ := row[1].(string)
+		idle, _ := row[2].(int64)
+		count, _ := row[3].(int64)
+
+		claimIdle := minIdle
+		if owner == consumer {
+			if !includeOwn {
+				continue
+			}
+			claimIdle = 0
+		} else if time.Duration(idle)*time.Millisecond < minIdle {
+			continue
+		}
+
+		claimed, err := q.cmd.Do(0, "XCLAIM", q.stream, q.group, consumer,
+			strconv.FormatInt(claimIdle.Milliseconds(), 10), id)
+		if err != nil {
+			return out, fmt.Errorf("xclaim %s: %w", id, err)
+		}
+		entries, _ := claimed.([]interface{})
+		for _, e := range entries {
+			if e == nil {
+				// 条目已被删除 (XDEL/trim), 仅清理 PEL
+				q.cmd.Do(0, "XACK", q.stream, q.group, id)
+				continue
+			}
+			eid, job, err := parseEntry(e)
+			if err != nil || job == nil {
+				q.discard(id) // XPENDING 的 ID, 条目本身可能连 ID 都解析不出
+				continue
+			}
+			out = append(out, &Delivery{Job: job, Attempt: int(count) + 1, ref: eid})
+		}
+	}
+	return out, nil
+}
+
+// Touch 刷新空闲计时 (JUSTID 不增加投递计数)
+func (q *RedisQueue) Touch(ctx context.Context, consumer string, d *Delivery) error {
+	_, err := q.cmd.Do(0, "XCLAIM", q.stream, q.group, consumer, "0", d.ref, "JUSTID")
+	return err
+}
+
+// Ack 确认并删除任务条目
+func (q *RedisQueue) Ack(ctx context.Context, d *Delivery) error {
+	if _, err := q.cmd.Do(0, "XACK", q.stream, q.group, d.ref); err != nil {
+		return err
+	}
+	_, err := q.cmd.Do(0, "XDEL", q.stream, d.ref)
+	return err
+}
+
+// discard 确认并删除无法执行的条目
+func (q *RedisQueue) discard(id string) {
+	q.cmd.Do(0, "XACK", q.stream, q.group, id)
+	q.cmd.Do(0, "XDEL", q.stream, id)
+}
+
+// Publish 发布进度事件 (流长度近似上限 10000)
+func (q *RedisQueue) Publish(ctx context.Context, p *Progress) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	_, err = q.cmd.Do(0, "XADD", q.progress, "MAXLEN", "~", progressMaxLen, "*",
+		redisFieldJobID, p.JobID,
+		redisFieldType, p.Type,
+		redisFieldPayload, string(data),
+	)
+	return err
+}
+
+// Close 关闭连接
+func (q *RedisQueue) Close() error {
+	q.blocking.Close()
+	return q.cmd.Close()
+}
+
+// parseEntry 解析 [id, [field, value, ...]] 形式的流条目
+func parseEntry(e interface{}) (string, *Job, error) {
+	entry, _ := e.([]interface{})
+	if len(entry) != 2 {
+		return "", nil, fmt.Errorf("malformed stream entry")
+	}
+	id, _ := entry[0].(string)
+	fields, _ := entry[1].([]interface{})
+	for i := 0; i+1 < len(fields); i += 2 {
+		if name, _ := fields[i].(string); name != redisFieldJob {
+			continue
+		}
+		raw, _ := fields[i+1].(string)
+		var job Job
+		if err := json.Unmarshal([]byte(raw), &job); err != nil {
+			return id, nil, fmt.Errorf("decode job %s: %w", id, err)
+		}
+		if job.ID == "" {
+			job.ID = id
+		}
+		return id, &job, nil
+	}
+	return id, nil, fmt.Errorf("stream entry %s has no %q field", id, redisFieldJob)
+}
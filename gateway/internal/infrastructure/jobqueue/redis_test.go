@@ -0,0 +1,168 @@
+package jobqueue
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis answers the stream commands RedisQueue sends with canned
+// replies (raw RESP), and records every command it receives.
+type fakeRedis struct {
+	ln      net.Listener
+	mu      sync.Mutex
+	cmds    []string
+	replies map[string]string // command name → RESP reply
+}
+
+func newFakeRedis(t *testing.T, replies map[string]string) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{ln: ln, replies: replies}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	for {
+		reply, err := readReply(rd) // commands are arrays of bulk strings
+		if err != nil {
+			return
+		}
+		items, _ := reply.([]interface{})
+		args := make([]string, len(items))
+		for i, it := range items {
+			args[i], _ = it.(string)
+		}
+		f.mu.Lock()
+		f.cmds = append(f.cmds, strings.Join(args, " "))
+		f.mu.Unlock()
+		out, ok := f.replies[args[0]]
+		if !ok {
+			out = ":1\r\n"
+		}
+		if _, err := conn.Write([]byte(out)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) commands(name string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []string
+	for _, c := range f.cmds {
+		if strings.HasPrefix(c, name+" ") {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// bulk encodes a RESP bulk string.
+func bulk(s string) string { return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n" }
+
+// streamReply encodes an XREADGROUP reply holding one entry.
+func streamReply(entry string) string {
+	return "*1\r\n*2\r\n" + bulk("ngoclaw:jobs") + "*1\r\n" + entry
+}
+
+func TestRedisQueue_ClaimDiscardsCorruptEntry(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		entry   string
+		discard string // id acked and deleted, "" = none
+	}{
+		{
+			name:    "undecodable job",
+			entry:   "*2\r\n" + bulk("1700000000000-0") + "*2\r\n" + bulk("job") + bulk("{not json"),
+			discard: "1700000000000-0",
+		},
+		{
+			name:    "no job field",
+			entry:   "*2\r\n" + bulk("1700000000001-0") + "*2\r\n" + bulk("prompt") + bulk("hi"),
+			discard: "1700000000001-0",
+		},
+		{
+			// No usable id: nothing is acked now; Reclaim cleans it up later
+			name:  "malformed entry",
+			entry: "*1\r\n" + bulk("garbage"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeRedis(t, map[string]string{
+				"XGROUP":     "+OK\r\n",
+				"XREADGROUP": streamReply(tc.entry),
+			})
+			q, err := NewRedisQueue(RedisConfig{Addr: f.ln.Addr().String()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer q.Close()
+
+			d, err := q.Claim(context.Background(), "gw-1", 10*time.Millisecond)
+			if err == nil || d != nil {
+				t.Fatalf("Claim = %+v, %v; want an error", d, err)
+			}
+			acks, dels := f.commands("XACK"), f.commands("XDEL")
+			for _, c := range append(acks, dels...) {
+				if strings.HasSuffix(c, " ") {
+					t.Errorf("command with an empty id: %q", c)
+				}
+			}
+			if tc.discard == "" {
+				if len(acks)+len(dels) != 0 {
+					t.Errorf("acked/deleted without an id: %v %v", acks, dels)
+				}
+				return
+			}
+			wantAck := fmt.Sprintf("XACK ngoclaw:jobs ngoclaw-workers %s", tc.discard)
+			wantDel := fmt.Sprintf("XDEL ngoclaw:jobs %s", tc.discard)
+			if len(acks) != 1 || acks[0] != wantAck || len(dels) != 1 || dels[0] != wantDel {
+				t.Errorf("acks = %v, dels = %v; want %q and %q", acks, dels, wantAck, wantDel)
+			}
+		})
+	}
+}
+
+func TestRedisQueue_ReclaimDiscardsCorruptEntryByPendingID(t *testing.T) {
+	f := newFakeRedis(t, map[string]string{
+		"XGROUP":   "+OK\r\n",
+		"XPENDING": "*1\r\n*4\r\n" + bulk("1700000000002-0") + bulk("gw-0") + ":600000\r\n:1\r\n",
+		"XCLAIM":   "*1\r\n*1\r\n" + bulk("garbage"),
+	})
+	q, err := NewRedisQueue(RedisConfig{Addr: f.ln.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	out, err := q.Reclaim(context.Background(), "gw-1", time.Minute, false)
+	if err != nil || len(out) != 0 {
+		t.Fatalf("Reclaim = %v, %v", out, err)
+	}
+	acks := f.commands("XACK")
+	if len(acks) != 1 || acks[0] != "XACK ngoclaw:jobs ngoclaw-workers 1700000000002-0" {
+		t.Errorf("acks = %v", acks)
+	}
+}
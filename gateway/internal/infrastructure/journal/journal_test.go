@@ -0,0 +1,108 @@
+package journal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"go.uber.org/zap"
+)
+
+// writeSampleRun journals a two-step run whose second step was compacted:
+// it keeps the system prompt and replaces the rest.
+func writeSampleRun(w *Writer, id, source string) {
+	call := entity.ToolCallInfo{ID: "c1", Name: "bash", Arguments: map[string]interface{}{"command": "ls"}}
+	for _, rec := range []*service.JournalRecord{
+		{Type: service.JournalRun, Source: source, Model: "p/m", UserMessage: "list files"},
+		{Type: service.JournalStep, Step: 1, Model: "p/m", Messages: []service.LLMMessage{
+			{Role: "system", Content: "sys"}, {Role: "user", Content: "list files"},
+		}, Tools: []string{"bash"}},
+		{Type: service.JournalResponse, Step: 1, Response: &service.LLMResponse{ToolCalls: []entity.ToolCallInfo{call}}},
+		{Type: service.JournalTool, Step: 1, ToolCall: &call, Output: "a.go", Success: true},
+		{Type: service.JournalStep, Step: 2, Model: "p/m", Base: 1, Messages: []service.LLMMessage{
+			{Role: "user", Content: "summary"}, {Role: "tool", Content: "a.go", ToolCallID: "c1"},
+		}},
+		{Type: service.JournalResponse, Step: 2, Response: &service.LLMResponse{Content: "a.go"}},
+		{Type: service.JournalEnd, Final: "a.go", Steps: 2, Tokens: 40},
+	} {
+		w.WriteJournal(id, rec)
+	}
+}
+
+func TestWriterAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWriter(Config{Dir: dir}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeSampleRun(w, "0a1b2c3d", "telegram:7")
+
+	run, err := Load(dir, "0a1b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.ID != "0a1b2c3d" || run.Source != "telegram:7" || run.Status() != "ok" || len(run.Steps) != 2 {
+		t.Fatalf("run = %+v", run)
+	}
+	s1, s2 := run.Step(1), run.Step(2)
+	if len(s1.Results) != 1 || s1.Results[0].Output != "a.go" || len(s1.Response.ToolCalls) != 1 {
+		t.Errorf("step 1 = %+v", s1)
+	}
+	// Step 2 is rebuilt from the unchanged system prompt plus its own messages
+	if len(s2.Messages) != 3 || s2.Messages[0].Content != "sys" || s2.Messages[1].Content != "summary" || s2.New != 1 {
+		t.Errorf("step 2 messages = %+v, new = %d", s2.Messages, s2.New)
+	}
+
+	if _, err := Load(dir, "ffff"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing run: err = %v", err)
+	}
+	writeSampleRun(w, "0a1bffff", "cli")
+	if _, err := Load(dir, "0a1b"); err == nil || !strings.Contains(err.Error(), "matches 2 runs") {
+		t.Errorf("ambiguous prefix: err = %v", err)
+	}
+}
+
+func TestReadUnfinishedRun(t *testing.T) {
+	journal := `{"type":"run","time":"2026-03-02T09:30:00Z","user_message":"hi"}
+{"type":"step","time":"2026-03-02T09:30:01Z","step":1,"messages":[{"role":"user","content":"hi"}]}
+{"type":"response","time":"2026-03-02T09:3`
+	run, err := Read(strings.NewReader(journal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Status() != "unfinished" || len(run.Steps) != 1 || run.Steps[0].Response != nil {
+		t.Errorf("run = %+v", run)
+	}
+	if _, err := Read(strings.NewReader(`{"type":"step","step":1}` + "\n")); err == nil {
+		t.Error("journal without a header should fail")
+	}
+}
+
+func TestPurgeAndPrune(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWriter(Config{Dir: dir, RetentionDays: 7}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeSampleRun(w, "run1", "telegram:7")
+	writeSampleRun(w, "run2", "telegram:8")
+
+	n, err := w.Purge(func(source string, _ time.Time) bool { return source == "telegram:7" })
+	if err != nil || n != 1 {
+		t.Fatalf("Purge = %d, %v", n, err)
+	}
+	if runs, _ := List(dir, 0); len(runs) != 1 || runs[0].ID != "run2" {
+		t.Errorf("runs after purge = %+v", runs)
+	}
+
+	old := time.Now().AddDate(0, 0, -8)
+	os.Chtimes(filepath.Join(dir, "run2.jsonl"), old, old)
+	if n := w.Prune(); n != 1 {
+		t.Errorf("Prune = %d, want 1", n)
+	}
+}
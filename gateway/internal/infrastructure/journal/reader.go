@@ -0,0 +1,199 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+)
+
+// Run is a journal read back: the run header, each step with its full
+// request, and how the run ended.
+type Run struct {
+	ID          string
+	Source      string
+	Model       string
+	UserMessage string
+	StartedAt   time.Time
+	Steps       []*Step
+	End         *service.JournalRecord // nil = unfinished (still running, or the process died)
+}
+
+// Step is one model call of a run.
+type Step struct {
+	N           int
+	Time        time.Time
+	Model       string
+	Messages    []service.LLMMessage // the full request, as sent
+	New         int                  // Messages[New:] were added since the previous step
+	Tools       []string
+	Temperature float64
+	MaxTokens   int
+	Response    *service.LLMResponse // nil = no answer (error, abort, or still running)
+	Error       string
+	Results     []*service.JournalRecord // tool results, in call order
+}
+
+// Status is "ok", "error", the abort reason, or "unfinished".
+func (r *Run) Status() string {
+	switch {
+	case r.End == nil:
+		return "unfinished"
+	case r.End.Abort != service.AbortNone:
+		return string(r.End.Abort)
+	case strings.HasPrefix(r.End.Final, "Error:"):
+		return "error"
+	}
+	return "ok"
+}
+
+// Step returns step n, or nil.
+func (r *Run) Step(n int) *Step {
+	for _, s := range r.Steps {
+		if s.N == n {
+			return s
+		}
+	}
+	return nil
+}
+
+// ErrNotFound is returned by Load when no journal matches the run ID.
+var ErrNotFound = errors.New("run not found")
+
+// Load reads the journal of a run from dir. id may be a unique prefix of
+// the run ID (as shown by List).
+func Load(dir, id string) (*Run, error) {
+	path := filepath.Join(dir, id+fileExt)
+	if _, err := os.Stat(path); err != nil {
+		matches, _ := filepath.Glob(filepath.Join(dir, id+"*"+fileExt))
+		switch len(matches) {
+		case 0:
+			return nil, fmt.Errorf("%s: %w", id, ErrNotFound)
+		case 1:
+			path = matches[0]
+		default:
+			return nil, fmt.Errorf("%s matches %d runs, give more of the ID", id, len(matches))
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	run, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	run.ID = strings.TrimSuffix(filepath.Base(path), fileExt)
+	return run, nil
+}
+
+// Read decodes a journal, rebuilding each step's full request from the
+// changes recorded since the previous one.
+func Read(r io.Reader) (*Run, error) {
+	run := &Run{}
+	var prev []service.LLMMessage
+	var cur *Step
+	header := false
+	br := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, err := br.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			var rec service.JournalRecord
+			if jerr := json.Unmarshal(line, &rec); jerr != nil {
+				// a run killed mid-write leaves a partial last line
+				if err == io.EOF {
+					break
+				}
+				return nil, fmt.Errorf("line %d: %w", lineNo, jerr)
+			}
+			switch rec.Type {
+			case service.JournalRun:
+				header = true
+				run.Source, run.Model, run.UserMessage, run.StartedAt = rec.Source, rec.Model, rec.UserMessage, rec.Time
+			case service.JournalStep:
+				base := min(rec.Base, len(prev))
+				msgs := append(append([]service.LLMMessage(nil), prev[:base]...), rec.Messages...)
+				cur = &Step{
+					N:           rec.Step,
+					Time:        rec.Time,
+					Model:       rec.Model,
+					Messages:    msgs,
+					New:         base,
+					Tools:       rec.Tools,
+					Temperature: rec.Temperature,
+					MaxTokens:   rec.MaxTokens,
+				}
+				run.Steps = append(run.Steps, cur)
+				prev = msgs
+			case service.JournalResponse:
+				if cur != nil && cur.N == rec.Step {
+					cur.Response, cur.Error = rec.Response, rec.Error
+				}
+			case service.JournalTool:
+				if cur != nil && cur.N == rec.Step {
+					cur.Results = append(cur.Results, &rec)
+				}
+			case service.JournalEnd:
+				end := rec
+				run.End = &end
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !header {
+		return nil, errors.New("no run header")
+	}
+	return run, nil
+}
+
+// List reads the newest limit journals in dir (0 = all), newest first.
+// Files that cannot be read are skipped.
+func List(dir string, limit int) ([]*Run, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	type file struct {
+		name string
+		mod  time.Time
+	}
+	var files []file
+	for _, e := range entries {
+		info, err := e.Info()
+		if e.IsDir() || !strings.HasSuffix(e.Name(), fileExt) || err != nil {
+			continue
+		}
+		files = append(files, file{e.Name(), info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].mod.After(files[j].mod) })
+
+	var runs []*Run
+	for _, f := range files {
+		if limit > 0 && len(runs) == limit {
+			break
+		}
+		run, err := Load(dir, strings.TrimSuffix(f.name, fileExt))
+		if err != nil {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
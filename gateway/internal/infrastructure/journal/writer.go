@@ -0,0 +1,187 @@
+// Package journal stores run journals: one JSONL file per agent run
+// (~/.ngoclaw/journal/<run-id>.jsonl) with every request exactly as sent to
+// the model, the responses and the tool results, so a past run can be
+// stepped through or resumed from any step (ngoclaw replay).
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"go.uber.org/zap"
+)
+
+const (
+	fileExt   = ".jsonl"
+	dayLayout = "2006-01-02"
+)
+
+// Config 运行日志配置
+type Config struct {
+	Dir           string // 默认 ~/.ngoclaw/journal
+	RetentionDays int    // 保留天数, 0 = 永久保留
+}
+
+// Writer appends journal records to the run's file.
+// It implements service.JournalSink.
+type Writer struct {
+	cfg    Config
+	logger *zap.Logger
+	now    func() time.Time
+
+	mu         sync.Mutex
+	lastPruned string // day of the last retention sweep
+}
+
+var _ service.JournalSink = (*Writer)(nil)
+
+// DefaultDir is where journals are written when Config.Dir is empty.
+func DefaultDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".ngoclaw", "journal")
+}
+
+// NewWriter creates the journal directory and returns a writer.
+func NewWriter(cfg Config, logger *zap.Logger) (*Writer, error) {
+	if cfg.Dir == "" {
+		cfg.Dir = DefaultDir()
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create journal dir: %w", err)
+	}
+	return &Writer{
+		cfg:    cfg,
+		logger: logger.With(zap.String("component", "journal")),
+		now:    time.Now,
+	}, nil
+}
+
+// Dir returns the directory journals are written to.
+func (w *Writer) Dir() string { return w.cfg.Dir }
+
+// WriteJournal appends rec to the run's file. Records are written as they
+// happen, so the journal of a run that crashed ends at its last step.
+func (w *Writer) WriteJournal(runID string, rec *service.JournalRecord) {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		w.logger.Warn("Encode journal record failed", zap.String("run", runID), zap.Error(err))
+		return
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if day := w.now().Format(dayLayout); day != w.lastPruned {
+		w.prune()
+		w.lastPruned = day
+	}
+
+	path := filepath.Join(w.cfg.Dir, runID+fileExt)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		w.logger.Warn("Open journal failed", zap.String("path", path), zap.Error(err))
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(line); err != nil {
+		w.logger.Warn("Write journal failed", zap.String("path", path), zap.Error(err))
+	}
+}
+
+// Prune deletes journals not written to for RetentionDays and returns how
+// many were removed. Writes prune once a day; the janitor calls Prune so
+// old files also go away while no runs are written.
+func (w *Writer) Prune() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.prune()
+}
+
+// prune deletes expired journals. The caller holds w.mu.
+func (w *Writer) prune() int {
+	if w.cfg.RetentionDays <= 0 {
+		return 0
+	}
+	cutoff := w.now().AddDate(0, 0, -w.cfg.RetentionDays)
+	entries, err := os.ReadDir(w.cfg.Dir)
+	if err != nil {
+		return 0
+	}
+	n := 0
+	for _, e := range entries {
+		info, err := e.Info()
+		if e.IsDir() || !strings.HasSuffix(e.Name(), fileExt) || err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(w.cfg.Dir, e.Name())); err == nil {
+			w.logger.Debug("Pruned old journal", zap.String("file", e.Name()))
+			n++
+		}
+	}
+	return n
+}
+
+// Purge removes the journals for which match returns true, serialized with
+// writes. See PurgeDir.
+func (w *Writer) Purge(match func(source string, startedAt time.Time) bool) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return PurgeDir(w.cfg.Dir, match)
+}
+
+// PurgeDir removes the journals in dir whose run header matches
+// match(source, startedAt) (retention policies, /forgetme). Returns the
+// number of journals removed.
+func PurgeDir(dir string, match func(source string, startedAt time.Time) bool) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	removed := 0
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), fileExt) {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		head, err := readHeader(path)
+		if err != nil || !match(head.Source, head.Time) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// readHeader decodes the run record on the first line of a journal.
+func readHeader(path string) (*service.JournalRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	line, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return nil, err
+	}
+	var rec service.JournalRecord
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return nil, err
+	}
+	if rec.Type != service.JournalRun {
+		return nil, fmt.Errorf("%s: no run header", filepath.Base(path))
+	}
+	return &rec, nil
+}
@@ -0,0 +1,234 @@
+// Package linkfetch downloads pages linked in chat messages and reduces
+// them to readable text for the agent's context.
+package linkfetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"golang.org/x/net/html"
+)
+
+// maxBodyBytes caps how much of a response is read.
+const maxBodyBytes = 4 << 20
+
+// errPrivateAddress is returned when a link resolves to a local address.
+var errPrivateAddress = errors.New("address is private or local")
+
+// Config controls the fetcher.
+type Config struct {
+	Timeout      time.Duration // per request (0 = 15s)
+	AllowPrivate bool          // allow loopback / private / link-local targets
+	UserAgent    string
+}
+
+// Fetcher implements service.LinkFetcher over HTTP.
+type Fetcher struct {
+	client    *http.Client
+	userAgent string
+}
+
+// New creates a fetcher. Unless AllowPrivate is set, connections to
+// loopback, private and link-local addresses are refused at dial time, so
+// neither the link nor a redirect can reach the gateway's own network.
+func New(cfg Config) *Fetcher {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 15 * time.Second
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = "Mozilla/5.0 (compatible; NGOClaw)"
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !cfg.AllowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return errPrivateAddress
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil // a proxy would dial on our behalf and bypass the check
+	return &Fetcher{
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 5 {
+					return errors.New("too many redirects")
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return fmt.Errorf("redirect to %s", req.URL.Scheme)
+				}
+				return nil
+			},
+		},
+		userAgent: cfg.UserAgent,
+	}
+}
+
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsMulticast() || ip.IsInterfaceLocalMulticast())
+}
+
+// githubBlobRe matches file pages on github.com, whose raw text is more
+// useful than the rendered page.
+var githubBlobRe = regexp.MustCompile(`^https://github\.com/([^/]+)/([^/]+)/blob/(.+)$`)
+
+// rewrite maps a link to the URL that serves its content best.
+func rewrite(rawURL string) string {
+	if m := githubBlobRe.FindStringSubmatch(rawURL); m != nil {
+		return "https://raw.githubusercontent.com/" + m[1] + "/" + m[2] + "/" + m[3]
+	}
+	return rawURL
+}
+
+// FetchLink downloads the link and returns its title and readable text.
+// HTML is reduced to its text; plain text, markdown, JSON and other text
+// types pass through; binary content is refused.
+func (f *Fetcher) FetchLink(ctx context.Context, rawURL string) (*service.LinkContent, error) {
+	u, err := url.Parse(rewrite(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("not an http(s) URL")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	req.Header.Set("Accept", "text/html,text/plain,text/markdown,application/json;q=0.9,*/*;q=0.5")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		if errors.Is(err, errPrivateAddress) {
+			return nil, errPrivateAddress
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	page := &service.LinkContent{URL: resp.Request.URL.String()}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "" {
+		mediaType = http.DetectContentType(body)
+		mediaType, _, _ = mime.ParseMediaType(mediaType)
+	}
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		page.Title, page.Text = extractHTML(string(body))
+	case strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" ||
+		strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") || mediaType == "application/xml":
+		page.Text = string(body)
+	default:
+		return nil, fmt.Errorf("unsupported content type %s", mediaType)
+	}
+	return page, nil
+}
+
+// skipElements hold no readable content.
+var skipElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "svg": true,
+	"nav": true, "header": true, "footer": true, "aside": true, "form": true, "iframe": true,
+}
+
+// blockElements start a new line in the extracted text.
+var blockElements = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "pre": true, "section": true, "article": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "blockquote": true, "table": true,
+}
+
+var blankLinesRe = regexp.MustCompile(`\n{3,}`)
+
+// extractHTML returns the page title and its visible text. Text inside
+// <main> or <article> is preferred when the page has one, which drops most
+// of the site chrome.
+func extractHTML(src string) (title, text string) {
+	doc, err := html.Parse(strings.NewReader(src))
+	if err != nil {
+		return "", ""
+	}
+	root := doc
+	var find func(n *html.Node)
+	find = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "title":
+				if title == "" && n.FirstChild != nil {
+					title = strings.TrimSpace(n.FirstChild.Data)
+				}
+			case "main", "article":
+				if root == doc {
+					root = n
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			find(c)
+		}
+	}
+	find(doc)
+
+	var sb strings.Builder
+	var walk func(n *html.Node, pre bool)
+	walk = func(n *html.Node, pre bool) {
+		switch n.Type {
+		case html.TextNode:
+			if pre {
+				sb.WriteString(n.Data)
+			} else if s := strings.Join(strings.Fields(n.Data), " "); s != "" {
+				if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "\n") {
+					sb.WriteByte(' ')
+				}
+				sb.WriteString(s)
+			}
+			return
+		case html.ElementNode:
+			if skipElements[n.Data] || n.Data == "title" {
+				return
+			}
+			pre = pre || n.Data == "pre"
+		}
+		block := n.Type == html.ElementNode && blockElements[n.Data]
+		if block {
+			sb.WriteByte('\n')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, pre)
+		}
+		if block {
+			sb.WriteByte('\n')
+		}
+	}
+	walk(root, false)
+
+	lines := strings.Split(sb.String(), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \t")
+	}
+	text = blankLinesRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return title, strings.TrimSpace(text)
+}
@@ -0,0 +1,76 @@
+package linkfetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExtractHTML(t *testing.T) {
+	page := `<html><head><title> Effective Go </title><style>body{}</style></head><body>
+<nav><a href="/">Home</a> · <a href="/doc">Docs</a></nav>
+<main><h1>Formatting</h1><p>Use   <code>gofmt</code>
+ on every file.</p><pre>func main() {
+	fmt.Println("hi")
+}</pre><script>track()</script></main>
+<footer>© Google</footer></body></html>`
+	title, text := extractHTML(page)
+	if title != "Effective Go" {
+		t.Errorf("title = %q", title)
+	}
+	want := "Formatting\n\nUse gofmt on every file.\n\nfunc main() {\n\tfmt.Println(\"hi\")\n}"
+	if text != want {
+		t.Errorf("text =\n%q\nwant\n%q", text, want)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	if got := rewrite("https://github.com/golang/go/blob/master/README.md"); got != "https://raw.githubusercontent.com/golang/go/master/README.md" {
+		t.Errorf("blob = %s", got)
+	}
+	if got := rewrite("https://github.com/golang/go/issues/1"); got != "https://github.com/golang/go/issues/1" {
+		t.Errorf("issue = %s", got)
+	}
+}
+
+func TestFetchLink(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<title>P</title><p>hello</p>"))
+		case "/notes.md":
+			w.Header().Set("Content-Type", "text/markdown")
+			w.Write([]byte("# Notes\n\n- one"))
+		case "/image.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("\x89PNG"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	// httptest listens on loopback, which the default fetcher refuses
+	if _, err := New(Config{}).FetchLink(context.Background(), srv.URL+"/page"); !errors.Is(err, errPrivateAddress) {
+		t.Fatalf("loopback err = %v", err)
+	}
+
+	f := New(Config{AllowPrivate: true})
+	page, err := f.FetchLink(context.Background(), srv.URL+"/page")
+	if err != nil || page.Title != "P" || page.Text != "hello" || page.URL != srv.URL+"/page" {
+		t.Errorf("html = %+v, %v", page, err)
+	}
+	if page, err := f.FetchLink(context.Background(), srv.URL+"/notes.md"); err != nil || page.Text != "# Notes\n\n- one" {
+		t.Errorf("markdown = %+v, %v", page, err)
+	}
+	if _, err := f.FetchLink(context.Background(), srv.URL+"/image.png"); err == nil || !strings.Contains(err.Error(), "image/png") {
+		t.Errorf("binary err = %v", err)
+	}
+	if _, err := f.FetchLink(context.Background(), srv.URL+"/gone"); err == nil || err.Error() != "HTTP 404" {
+		t.Errorf("404 err = %v", err)
+	}
+}
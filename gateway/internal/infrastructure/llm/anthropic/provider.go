@@ -3,14 +3,12 @@ package anthropic
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
@@ -43,25 +41,12 @@ func New(cfg llm.ProviderConfig, logger *zap.Logger) *Provider {
 		baseURL = "https://api.anthropic.com"
 	}
 
-	transport := &http.Transport{
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
-		TLSHandshakeTimeout:   15 * time.Second,
-		ResponseHeaderTimeout: 300 * time.Second,
-		IdleConnTimeout:       90 * time.Second,
-		MaxIdleConns:          10,
-		MaxIdleConnsPerHost:   5,
-		TLSClientConfig:       &tls.Config{MinVersion: tls.VersionTLS12},
-	}
-
 	return &Provider{
 		name:    cfg.Name,
 		baseURL: baseURL,
 		apiKey:  cfg.APIKey,
 		models:  cfg.Models,
-		client:  &http.Client{Transport: transport},
+		client:  &http.Client{Transport: cfg.HTTPTransport()},
 		logger:  logger.With(zap.String("provider", cfg.Name), zap.String("type", "anthropic")),
 	}
 }
@@ -115,7 +100,7 @@ func (p *Provider) Generate(ctx context.Context, req *service.LLMRequest) (*serv
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Anthropic API error %d: %s", resp.StatusCode, string(respBody))
+		return nil, llm.NewAPIError("Anthropic API error", resp, respBody)
 	}
 
 	return p.parseAPIResponse(respBody)
@@ -147,7 +132,7 @@ func (p *Provider) GenerateStream(ctx context.Context, req *service.LLMRequest,
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Anthropic API error %d: %s", resp.StatusCode, string(respBody))
+		return nil, llm.NewAPIError("Anthropic API error", resp, respBody)
 	}
 
 	// Context cancellation watchdog
@@ -169,6 +154,28 @@ func (p *Provider) GenerateStream(ctx context.Context, req *service.LLMRequest,
 
 // --- Internal ---
 
+// userBlocks converts a user message to content blocks; image parts become
+// base64 (inline data) or url image blocks.
+func userBlocks(msg service.LLMMessage) []ContentBlock {
+	if len(msg.Parts) == 0 {
+		return []ContentBlock{{Type: "text", Text: msg.Content}}
+	}
+	var blocks []ContentBlock
+	for _, part := range msg.Parts {
+		switch part.Type {
+		case "text":
+			blocks = append(blocks, ContentBlock{Type: "text", Text: part.Text})
+		case "image":
+			source := &ImageSource{Type: "url", URL: part.MediaURL}
+			if len(part.Data) > 0 {
+				source = &ImageSource{Type: "base64", MediaType: part.MimeType, Data: base64.StdEncoding.EncodeToString(part.Data)}
+			}
+			blocks = append(blocks, ContentBlock{Type: "image", Source: source})
+		}
+	}
+	return blocks
+}
+
 func (p *Provider) setHeaders(req *http.Request) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-api-key", p.apiKey)
@@ -185,11 +192,28 @@ func (p *Provider) buildAPIRequest(req *service.LLMRequest) *Request {
 		Model:       model,
 		MaxTokens:   req.MaxTokens,
 		Temperature: req.Temperature,
+		TopP:        req.TopP,
 	}
 	if apiReq.MaxTokens == 0 {
 		apiReq.MaxTokens = 8192 // Anthropic requires explicit max_tokens
 	}
 
+	// Extended thinking (ThinkingBudget from /think). A tool turn in progress
+	// must start with its signed thinking block; if history lost it (compaction,
+	// level switched mid-run) thinking stays off for this call.
+	thinking := req.ThinkingBudget > 0 && !unsignedToolTurn(req.Messages)
+	if thinking {
+		apiReq.Thinking = &Thinking{Type: "enabled", BudgetTokens: req.ThinkingBudget}
+		if apiReq.MaxTokens <= req.ThinkingBudget {
+			apiReq.MaxTokens = req.ThinkingBudget + 8192 // budget_tokens must be below max_tokens
+		}
+		// Thinking is incompatible with a custom temperature and top_p < 0.95
+		apiReq.Temperature = 0
+		if apiReq.TopP < 0.95 {
+			apiReq.TopP = 0
+		}
+	}
+
 	// Extract system prompt from messages
 	var messages []Message
 	for _, msg := range req.Messages {
@@ -199,6 +223,9 @@ func (p *Provider) buildAPIRequest(req *service.LLMRequest) *Request {
 
 		case "assistant":
 			var blocks []ContentBlock
+			if thinking {
+				blocks = append(blocks, thinkingBlocks(msg.Thinking)...)
+			}
 			if msg.Content != "" {
 				blocks = append(blocks, ContentBlock{Type: "text", Text: msg.Content})
 			}
@@ -228,7 +255,7 @@ func (p *Provider) buildAPIRequest(req *service.LLMRequest) *Request {
 		default: // user
 			messages = append(messages, Message{
 				Role:    "user",
-				Content: []ContentBlock{{Type: "text", Text: msg.Content}},
+				Content: userBlocks(msg),
 			})
 		}
 	}
@@ -253,8 +280,9 @@ func (p *Provider) parseAPIResponse(body []byte) (*service.LLMResponse, error) {
 	}
 
 	resp := &service.LLMResponse{
-		ModelUsed:  apiResp.Model,
-		TokensUsed: apiResp.Usage.Total(),
+		ModelUsed:    apiResp.Model,
+		TokensUsed:   apiResp.Usage.Total(),
+		FinishReason: normalizeStopReason(apiResp.StopReason),
 	}
 
 	// Extract text and tool calls from content blocks
@@ -268,8 +296,42 @@ func (p *Provider) parseAPIResponse(body []byte) (*service.LLMResponse, error) {
 				Name:      block.Name,
 				Arguments: block.Input,
 			})
+		case "thinking":
+			resp.Thinking = append(resp.Thinking, service.ThinkingBlock{Thinking: block.Thinking, Signature: block.Signature})
+		case "redacted_thinking":
+			resp.Thinking = append(resp.Thinking, service.ThinkingBlock{Redacted: block.Data})
 		}
 	}
 
 	return resp, nil
 }
+
+// thinkingBlocks converts replayed reasoning blocks back to content blocks.
+func thinkingBlocks(thinking []service.ThinkingBlock) []ContentBlock {
+	var blocks []ContentBlock
+	for _, t := range thinking {
+		if t.Redacted != "" {
+			blocks = append(blocks, ContentBlock{Type: "redacted_thinking", Data: t.Redacted})
+			continue
+		}
+		blocks = append(blocks, ContentBlock{Type: "thinking", Thinking: t.Thinking, Signature: t.Signature})
+	}
+	return blocks
+}
+
+// unsignedToolTurn reports whether the conversation ends in a tool turn
+// (assistant tool calls followed only by tool results) whose assistant
+// message carries no thinking block.
+func unsignedToolTurn(messages []service.LLMMessage) bool {
+	for i := len(messages) - 1; i >= 0; i-- {
+		switch msg := messages[i]; msg.Role {
+		case "tool":
+			continue
+		case "assistant":
+			return len(msg.ToolCalls) > 0 && len(msg.Thinking) == 0
+		default:
+			return false
+		}
+	}
+	return false
+}
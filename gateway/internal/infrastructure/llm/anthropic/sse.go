@@ -41,7 +41,9 @@ func ParseSSEStream(ctx context.Context, reader io.Reader, deltaCh chan<- servic
 	var modelUsed string
 	var tokensUsed int
 	var finishReason string
-	toolCalls := make(map[int]*toolCallAccumulator) // index → accumulator
+	toolCalls := make(map[int]*toolCallAccumulator)  // index → accumulator
+	thinking := make(map[int]*service.ThinkingBlock) // index → thinking block (replayed with tool turns)
+	blockCount := 0                                  // content blocks seen; indices cover text blocks too
 	var currentEventType string
 
 	for scanner.Scan() {
@@ -85,11 +87,20 @@ func ParseSSEStream(ctx context.Context, reader io.Reader, deltaCh chan<- servic
 				logger.Debug("Skip unparseable content_block_start", zap.Error(err))
 				continue
 			}
-			if evt.ContentBlock != nil && evt.ContentBlock.Type == "tool_use" {
+			if evt.ContentBlock == nil {
+				continue
+			}
+			blockCount = max(blockCount, evt.Index+1)
+			switch evt.ContentBlock.Type {
+			case "tool_use":
 				toolCalls[evt.Index] = &toolCallAccumulator{
 					ID:   evt.ContentBlock.ID,
 					Name: evt.ContentBlock.Name,
 				}
+			case "thinking":
+				thinking[evt.Index] = &service.ThinkingBlock{}
+			case "redacted_thinking":
+				thinking[evt.Index] = &service.ThinkingBlock{Redacted: evt.ContentBlock.Data}
 			}
 
 		case "content_block_delta":
@@ -113,7 +124,14 @@ func ParseSSEStream(ctx context.Context, reader io.Reader, deltaCh chan<- servic
 					acc.ArgsBuilder.WriteString(evt.Delta.PartialJSON)
 				}
 			case "thinking_delta":
-				// Thinking content — skip, we strip reasoning tags
+				// Thinking content is not streamed, only kept for replay
+				if t, ok := thinking[evt.Index]; ok {
+					t.Thinking += evt.Delta.Thinking
+				}
+			case "signature_delta":
+				if t, ok := thinking[evt.Index]; ok {
+					t.Signature += evt.Delta.Signature
+				}
 			}
 
 		case "message_delta":
@@ -166,13 +184,21 @@ func ParseSSEStream(ctx context.Context, reader io.Reader, deltaCh chan<- servic
 	}
 
 	resp := &service.LLMResponse{
-		Content:    contentStr,
-		ModelUsed:  modelUsed,
-		TokensUsed: tokensUsed,
+		Content:      contentStr,
+		ModelUsed:    modelUsed,
+		TokensUsed:   tokensUsed,
+		FinishReason: normalizeStopReason(finishReason),
+	}
+
+	// Thinking blocks precede text and tool_use blocks; keep their order
+	for i := 0; i < blockCount; i++ {
+		if t, ok := thinking[i]; ok {
+			resp.Thinking = append(resp.Thinking, *t)
+		}
 	}
 
-	// Assemble tool calls
-	for i := 0; i < len(toolCalls); i++ {
+	// Assemble tool calls (block indices, so text/thinking blocks leave gaps)
+	for i := 0; i < blockCount; i++ {
 		acc, ok := toolCalls[i]
 		if !ok {
 			continue
@@ -228,3 +254,12 @@ func (t *timedReader) Read(p []byte) (int, error) {
 func isIdleTimeoutErr(err error) bool {
 	return err != nil && strings.Contains(err.Error(), "SSE read idle timeout")
 }
+
+// normalizeStopReason maps a safety refusal to the loop's content-filter
+// finish reason; other stop reasons pass through.
+func normalizeStopReason(reason string) string {
+	if reason == "refusal" {
+		return service.FinishContentFilter
+	}
+	return reason
+}
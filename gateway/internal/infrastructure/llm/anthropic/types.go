@@ -17,7 +17,15 @@ type Request struct {
 	Messages      []Message      `json:"messages"`
 	Tools         []Tool         `json:"tools,omitempty"`
 	Temperature   float64        `json:"temperature,omitempty"`
+	TopP          float64        `json:"top_p,omitempty"`
 	Stream        bool           `json:"stream,omitempty"`
+	Thinking      *Thinking      `json:"thinking,omitempty"`
+}
+
+// Thinking enables extended thinking with a token budget (< max_tokens).
+type Thinking struct {
+	Type         string `json:"type"` // "enabled"
+	BudgetTokens int    `json:"budget_tokens"`
 }
 
 // Message represents an Anthropic conversation message.
@@ -28,7 +36,7 @@ type Message struct {
 
 // ContentBlock is a polymorphic content element.
 type ContentBlock struct {
-	Type string `json:"type"` // "text" | "tool_use" | "tool_result" | "thinking"
+	Type string `json:"type"` // "text" | "image" | "tool_use" | "tool_result" | "thinking" | "redacted_thinking"
 
 	// For type "text"
 	Text string `json:"text,omitempty"`
@@ -43,7 +51,22 @@ type ContentBlock struct {
 	Content   string `json:"content,omitempty"` // text result from tool
 
 	// For type "thinking" (extended thinking)
-	Thinking string `json:"thinking,omitempty"`
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+
+	// For type "redacted_thinking"
+	Data string `json:"data,omitempty"`
+
+	// For type "image"
+	Source *ImageSource `json:"source,omitempty"`
+}
+
+// ImageSource is the payload of an image block: inline base64 data or a URL.
+type ImageSource struct {
+	Type      string `json:"type"` // "base64" | "url"
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
 }
 
 // Tool is an Anthropic tool definition.
@@ -60,7 +83,7 @@ type Response struct {
 	Role         string         `json:"role"` // "assistant"
 	Content      []ContentBlock `json:"content"`
 	Model        string         `json:"model"`
-	StopReason   string         `json:"stop_reason"` // "end_turn" | "tool_use" | "max_tokens"
+	StopReason   string         `json:"stop_reason"` // "end_turn" | "tool_use" | "max_tokens" | "refusal"
 	Usage        Usage          `json:"usage"`
 }
 
@@ -98,10 +121,11 @@ type StreamEvent struct {
 
 // DeltaBlock represents incremental content in a stream.
 type DeltaBlock struct {
-	Type       string `json:"type"` // "text_delta" | "input_json_delta" | "thinking_delta"
+	Type       string `json:"type"` // "text_delta" | "input_json_delta" | "thinking_delta" | "signature_delta"
 	Text       string `json:"text,omitempty"`
 	PartialJSON string `json:"partial_json,omitempty"`
 	Thinking   string `json:"thinking,omitempty"`
+	Signature  string `json:"signature,omitempty"`
 
 	// For message_delta event
 	StopReason string `json:"stop_reason,omitempty"`
@@ -0,0 +1,214 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+)
+
+const (
+	// maxRetryAfter caps the pause taken from a Retry-After header, so a
+	// bogus value cannot park every run for hours.
+	maxRetryAfter = 5 * time.Minute
+	// backoffSpacing staggers queued calls once a window clears, so they
+	// don't all hit the provider in the same instant and trip it again.
+	backoffSpacing = 250 * time.Millisecond
+)
+
+// APIError is a non-200 response from a provider API.
+// RetryAfter is the server's Retry-After hint (0 = none given).
+type APIError struct {
+	Prefix     string // e.g. "Anthropic API error"
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+// Error keeps the "<prefix> <status>: <body>" format that error
+// classification matches on.
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %d: %s", e.Prefix, e.StatusCode, e.Body)
+}
+
+// RateLimited reports whether the provider rejected the call for rate limiting.
+func (e *APIError) RateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// NewAPIError builds the error for a non-200 provider response.
+func NewAPIError(prefix string, resp *http.Response, body []byte) *APIError {
+	return &APIError{
+		Prefix:     prefix,
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// parseRetryAfter reads a Retry-After header in either form: delay seconds
+// or an HTTP date.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	var d time.Duration
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		d = time.Duration(secs * float64(time.Second))
+	} else if t, err := http.ParseTime(v); err == nil {
+		d = t.Sub(now)
+	}
+	if d < 0 {
+		return 0
+	}
+	if d > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return d
+}
+
+// Backoff is the rate-limit state shared by all runs going through a Router.
+// When one call gets a 429 with Retry-After, every other call to the same
+// provider waits in a FIFO queue until the window clears instead of
+// hammering it. Queued calls are released backoffSpacing apart.
+type Backoff struct {
+	mu    sync.Mutex
+	gates map[string]*backoffGate // provider name → gate
+	now   func() time.Time
+}
+
+type backoffGate struct {
+	until    time.Time     // calls resume at this time
+	released time.Time     // when the last queued call was let through
+	queue    []*int        // waiting calls, in arrival order
+	changed  chan struct{} // closed (and replaced) whenever until or queue changes
+}
+
+// NewBackoff creates an empty backoff coordinator.
+func NewBackoff() *Backoff {
+	return &Backoff{gates: make(map[string]*backoffGate), now: time.Now}
+}
+
+// Trip pauses calls to provider for d. An existing longer window is kept.
+func (b *Backoff) Trip(provider string, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	g := b.gate(provider)
+	if until := b.now().Add(d); until.After(g.until) {
+		g.until = until
+		g.signal()
+	}
+}
+
+// Until returns when calls to provider may resume (zero = not paused).
+func (b *Backoff) Until(provider string) time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if g, ok := b.gates[provider]; ok && b.now().Before(g.until) {
+		return g.until
+	}
+	return time.Time{}
+}
+
+// Wait blocks until a call to provider may be sent. While waiting, notify
+// (optional) is called with the call's queue position whenever it changes.
+func (b *Backoff) Wait(ctx context.Context, provider string, notify func(entity.QueueInfo)) error {
+	b.mu.Lock()
+	g, ok := b.gates[provider]
+	if !ok || (len(g.queue) == 0 && !b.now().Before(g.until)) {
+		b.mu.Unlock()
+		return nil
+	}
+	ticket := new(int)
+	g.queue = append(g.queue, ticket)
+
+	lastPos := 0
+	for {
+		now := b.now()
+		pos := g.position(ticket)
+		wait := g.releaseAt(pos).Sub(now)
+		if wait <= 0 {
+			if pos == 1 {
+				g.released = now
+				g.remove(ticket)
+				b.mu.Unlock()
+				return nil
+			}
+			// the call ahead hasn't woken up yet
+			wait = backoffSpacing
+		}
+		changed := g.changed
+		b.mu.Unlock()
+
+		if notify != nil && pos != lastPos {
+			notify(entity.QueueInfo{Provider: provider, Position: pos, Wait: wait})
+			lastPos = pos
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			b.mu.Lock()
+			g.remove(ticket)
+			b.mu.Unlock()
+			return ctx.Err()
+		case <-changed:
+			timer.Stop()
+		case <-timer.C:
+		}
+		b.mu.Lock()
+	}
+}
+
+// gate returns the provider's gate, creating it. Callers hold b.mu.
+func (b *Backoff) gate(provider string) *backoffGate {
+	g, ok := b.gates[provider]
+	if !ok {
+		g = &backoffGate{changed: make(chan struct{})}
+		b.gates[provider] = g
+	}
+	return g
+}
+
+func (g *backoffGate) signal() {
+	close(g.changed)
+	g.changed = make(chan struct{})
+}
+
+// releaseAt is when the call at queue position pos may go.
+func (g *backoffGate) releaseAt(pos int) time.Time {
+	at := g.until
+	if next := g.released.Add(backoffSpacing); next.After(at) {
+		at = next
+	}
+	return at.Add(time.Duration(pos-1) * backoffSpacing)
+}
+
+func (g *backoffGate) position(ticket *int) int {
+	for i, t := range g.queue {
+		if t == ticket {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func (g *backoffGate) remove(ticket *int) {
+	for i, t := range g.queue {
+		if t == ticket {
+			g.queue = append(g.queue[:i], g.queue[i+1:]...)
+			g.signal()
+			return
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"go.uber.org/zap"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"1.5", 1500 * time.Millisecond},
+		{now.Add(20 * time.Second).Format(http.TimeFormat), 20 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"86400", maxRetryAfter},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.in, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBackoff_QueuesCallsInOrder(t *testing.T) {
+	b := NewBackoff()
+	if err := b.Wait(context.Background(), "claude", nil); err != nil {
+		t.Fatalf("unpaused provider blocked: %v", err)
+	}
+
+	b.Trip("claude", 100*time.Millisecond)
+	var (
+		mu        sync.Mutex
+		order     []int
+		positions = map[int][]int{}
+		wg        sync.WaitGroup
+	)
+	start := time.Now()
+	for i := 1; i <= 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := b.Wait(context.Background(), "claude", func(q entity.QueueInfo) {
+				mu.Lock()
+				positions[i] = append(positions[i], q.Position)
+				mu.Unlock()
+			})
+			if err != nil {
+				t.Error(err)
+			}
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+		}(i)
+		time.Sleep(10 * time.Millisecond) // fix arrival order
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond+2*backoffSpacing {
+		t.Errorf("queue drained in %v, want the window plus spacing", elapsed)
+	}
+	if len(order) != 3 || order[0] != 1 || order[1] != 2 || order[2] != 3 {
+		t.Errorf("release order = %v", order)
+	}
+	if p := positions[3]; len(p) == 0 || p[0] != 3 || p[len(p)-1] != 1 {
+		t.Errorf("third call positions = %v, want 3 … 1", p)
+	}
+	if err := b.Wait(context.Background(), "other", nil); err != nil {
+		t.Errorf("other provider blocked: %v", err)
+	}
+}
+
+func TestBackoff_WaitHonorsContext(t *testing.T) {
+	b := NewBackoff()
+	b.Trip("claude", time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx, "claude", nil); err != context.DeadlineExceeded {
+		t.Fatalf("err = %v", err)
+	}
+	if len(b.gates["claude"].queue) != 0 {
+		t.Error("cancelled call left in the queue")
+	}
+}
+
+// rateLimitedProvider answers 429 with Retry-After once, then succeeds.
+type rateLimitedProvider struct {
+	calls atomic.Int32
+}
+
+func (p *rateLimitedProvider) Name() string                     { return "claude" }
+func (p *rateLimitedProvider) Models() []string                 { return []string{"claude-sonnet"} }
+func (p *rateLimitedProvider) SupportsModel(string) bool        { return true }
+func (p *rateLimitedProvider) IsAvailable(context.Context) bool { return true }
+
+func (p *rateLimitedProvider) Generate(ctx context.Context, req *service.LLMRequest) (*service.LLMResponse, error) {
+	if p.calls.Add(1) == 1 {
+		return nil, &APIError{Prefix: "Anthropic API error", StatusCode: 429, Body: "rate limited", RetryAfter: 150 * time.Millisecond}
+	}
+	return &service.LLMResponse{Content: "ok", ModelUsed: req.Model}, nil
+}
+
+func (p *rateLimitedProvider) GenerateStream(ctx context.Context, req *service.LLMRequest, deltaCh chan<- service.StreamChunk) (*service.LLMResponse, error) {
+	return p.Generate(ctx, req)
+}
+
+func TestRouter_RetryAfterPausesOtherRuns(t *testing.T) {
+	r := NewRouter(zap.NewNop())
+	r.AddProvider(&rateLimitedProvider{})
+	req := &service.LLMRequest{Model: "claude-sonnet"}
+
+	if _, err := r.Generate(context.Background(), req); err == nil {
+		t.Fatal("429 not returned")
+	}
+	if st := r.ListProviders(context.Background()); st[0].RetryAfterSec <= 0 {
+		t.Errorf("status = %+v, want a rate-limit window", st[0])
+	}
+
+	// a concurrent run is held back and told its queue position
+	deltaCh := make(chan service.StreamChunk, 8)
+	start := time.Now()
+	resp, err := r.GenerateStream(context.Background(), req, deltaCh)
+	close(deltaCh)
+	if err != nil || resp.Content != "ok" {
+		t.Fatalf("resp = %+v, err = %v", resp, err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("call went out after %v, before Retry-After", elapsed)
+	}
+	chunk, ok := <-deltaCh
+	if !ok || chunk.Queue == nil || chunk.Queue.Provider != "claude" || chunk.Queue.Position != 1 {
+		t.Errorf("queue chunk = %+v", chunk)
+	}
+}
@@ -1,8 +1,13 @@
 package llm
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"go.uber.org/zap"
 )
 
 func TestCircuitBreaker_ClosedByDefault(t *testing.T) {
@@ -128,3 +133,55 @@ func TestCircuitBreaker_StateStrings(t *testing.T) {
 		}
 	}
 }
+
+// flakyProvider fails while down is set.
+type flakyProvider struct {
+	down bool
+}
+
+func (p *flakyProvider) Name() string                     { return "flaky" }
+func (p *flakyProvider) Models() []string                 { return []string{"m"} }
+func (p *flakyProvider) SupportsModel(string) bool        { return true }
+func (p *flakyProvider) IsAvailable(context.Context) bool { return true }
+
+func (p *flakyProvider) Generate(ctx context.Context, req *service.LLMRequest) (*service.LLMResponse, error) {
+	if p.down {
+		return nil, errors.New("502 bad gateway")
+	}
+	return &service.LLMResponse{Content: "ok"}, nil
+}
+
+func (p *flakyProvider) GenerateStream(ctx context.Context, req *service.LLMRequest, deltaCh chan<- service.StreamChunk) (*service.LLMResponse, error) {
+	return p.Generate(ctx, req)
+}
+
+func TestRouter_CircuitListener(t *testing.T) {
+	p := &flakyProvider{down: true}
+	r := NewRouter(zap.NewNop())
+	r.AddProvider(p)
+	r.breakers["flaky"] = NewCircuitBreaker(2, 10*time.Millisecond)
+
+	var events []CircuitState
+	r.SetCircuitListener(func(provider string, state CircuitState, err error) {
+		if provider != "flaky" || (state == CircuitOpen && err == nil) {
+			t.Errorf("listener(%q, %v, %v)", provider, state, err)
+		}
+		events = append(events, state)
+	})
+
+	ctx := context.Background()
+	req := &service.LLMRequest{Model: "m"}
+	r.Generate(ctx, req)
+	r.Generate(ctx, req) // opens
+	time.Sleep(15 * time.Millisecond)
+	r.Generate(ctx, req) // failed probe re-opens, not reported again
+	time.Sleep(15 * time.Millisecond)
+	p.down = false
+	if _, err := r.Generate(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 || events[0] != CircuitOpen || events[1] != CircuitClosed {
+		t.Errorf("events = %v, want [open closed]", events)
+	}
+}
@@ -3,14 +3,12 @@ package gemini
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
@@ -41,25 +39,12 @@ func New(cfg llm.ProviderConfig, logger *zap.Logger) *Provider {
 		baseURL = "https://generativelanguage.googleapis.com"
 	}
 
-	transport := &http.Transport{
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
-		TLSHandshakeTimeout:   15 * time.Second,
-		ResponseHeaderTimeout: 300 * time.Second,
-		IdleConnTimeout:       90 * time.Second,
-		MaxIdleConns:          10,
-		MaxIdleConnsPerHost:   5,
-		TLSClientConfig:       &tls.Config{MinVersion: tls.VersionTLS12},
-	}
-
 	return &Provider{
 		name:    cfg.Name,
 		baseURL: baseURL,
 		apiKey:  cfg.APIKey,
 		models:  cfg.Models,
-		client:  &http.Client{Transport: transport},
+		client:  &http.Client{Transport: cfg.HTTPTransport()},
 		logger:  logger.With(zap.String("provider", cfg.Name), zap.String("type", "gemini")),
 	}
 }
@@ -115,7 +100,7 @@ func (p *Provider) Generate(ctx context.Context, req *service.LLMRequest) (*serv
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Gemini API error %d: %s", resp.StatusCode, string(respBody))
+		return nil, llm.NewAPIError("Gemini API error", resp, respBody)
 	}
 
 	return p.parseAPIResponse(respBody)
@@ -148,7 +133,7 @@ func (p *Provider) GenerateStream(ctx context.Context, req *service.LLMRequest,
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Gemini API error %d: %s", resp.StatusCode, string(respBody))
+		return nil, llm.NewAPIError("Gemini API error", resp, respBody)
 	}
 
 	streamDone := make(chan struct{})
@@ -180,9 +165,22 @@ func (p *Provider) buildAPIRequest(req *service.LLMRequest) *Request {
 	apiReq := &Request{
 		GenerationConfig: &GenerationConfig{
 			Temperature:     req.Temperature,
+			TopP:            req.TopP,
 			MaxOutputTokens: req.MaxTokens,
 		},
 	}
+	// Thinking budget: /think via ModelPolicy.ApplyThinking, else a bare
+	// reasoning effort (/params) on a model without a thinking policy
+	switch {
+	case req.ThinkingBudget > 0:
+		apiReq.GenerationConfig.ThinkingConfig = &ThinkingConfig{ThinkingBudget: req.ThinkingBudget}
+	case req.EnableThinking != nil && !*req.EnableThinking:
+		apiReq.GenerationConfig.ThinkingConfig = &ThinkingConfig{ThinkingBudget: 0}
+	default:
+		if budget, ok := service.ThinkingBudgets[req.ReasoningEffort]; ok {
+			apiReq.GenerationConfig.ThinkingConfig = &ThinkingConfig{ThinkingBudget: budget}
+		}
+	}
 
 	// Convert messages to Gemini contents
 	for _, msg := range req.Messages {
@@ -225,7 +223,7 @@ func (p *Provider) buildAPIRequest(req *service.LLMRequest) *Request {
 		default: // user
 			apiReq.Contents = append(apiReq.Contents, Content{
 				Role:  "user",
-				Parts: []Part{{Text: msg.Content}},
+				Parts: userParts(msg),
 			})
 		}
 	}
@@ -246,6 +244,28 @@ func (p *Provider) buildAPIRequest(req *service.LLMRequest) *Request {
 	return apiReq
 }
 
+// userParts converts a user message to parts; image parts become inline
+// data (base64) or file data (URL).
+func userParts(msg service.LLMMessage) []Part {
+	if len(msg.Parts) == 0 {
+		return []Part{{Text: msg.Content}}
+	}
+	var parts []Part
+	for _, part := range msg.Parts {
+		switch part.Type {
+		case "text":
+			parts = append(parts, Part{Text: part.Text})
+		case "image":
+			if len(part.Data) > 0 {
+				parts = append(parts, Part{InlineData: &Blob{MimeType: part.MimeType, Data: base64.StdEncoding.EncodeToString(part.Data)}})
+			} else {
+				parts = append(parts, Part{FileData: &FileData{MimeType: part.MimeType, FileURI: part.MediaURL}})
+			}
+		}
+	}
+	return parts
+}
+
 func (p *Provider) parseAPIResponse(body []byte) (*service.LLMResponse, error) {
 	var apiResp Response
 	if err := json.Unmarshal(body, &apiResp); err != nil {
@@ -253,12 +273,17 @@ func (p *Provider) parseAPIResponse(body []byte) (*service.LLMResponse, error) {
 	}
 
 	if len(apiResp.Candidates) == 0 {
+		if fb := apiResp.PromptFeedback; fb != nil && fb.BlockReason != "" {
+			// The prompt was blocked: an answer the loop handles, not an error
+			return &service.LLMResponse{ModelUsed: apiResp.ModelVersion, FinishReason: service.FinishContentFilter}, nil
+		}
 		return nil, fmt.Errorf("empty Gemini response: no candidates")
 	}
 
 	candidate := apiResp.Candidates[0]
 	resp := &service.LLMResponse{
-		ModelUsed: apiResp.ModelVersion,
+		ModelUsed:    apiResp.ModelVersion,
+		FinishReason: normalizeFinishReason(candidate.FinishReason),
 	}
 	if apiResp.UsageMetadata != nil {
 		resp.TokensUsed = apiResp.UsageMetadata.Total()
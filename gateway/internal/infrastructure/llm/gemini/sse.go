@@ -61,6 +61,12 @@ func ParseSSEStream(ctx context.Context, reader io.Reader, deltaCh chan<- servic
 			tokensUsed = resp.UsageMetadata.Total()
 		}
 
+		if resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != "" {
+			finishReason = service.FinishContentFilter // the prompt itself was blocked
+			deltaCh <- service.StreamChunk{FinishReason: finishReason}
+			break
+		}
+
 		if len(resp.Candidates) == 0 {
 			continue
 		}
@@ -111,10 +117,11 @@ func ParseSSEStream(ctx context.Context, reader io.Reader, deltaCh chan<- servic
 	}
 
 	resp := &service.LLMResponse{
-		Content:    contentStr,
-		ModelUsed:  modelUsed,
-		TokensUsed: tokensUsed,
-		ToolCalls:  toolCalls,
+		Content:      contentStr,
+		ModelUsed:    modelUsed,
+		TokensUsed:   tokensUsed,
+		ToolCalls:    toolCalls,
+		FinishReason: normalizeFinishReason(finishReason),
 	}
 
 	return resp, nil
@@ -150,3 +157,13 @@ func (t *timedReader) Read(p []byte) (int, error) {
 func isIdleTimeoutErr(err error) bool {
 	return err != nil && strings.Contains(err.Error(), "SSE read idle timeout")
 }
+
+// normalizeFinishReason maps safety blocks (of the answer or of the prompt)
+// to the loop's content-filter finish reason; other reasons pass through.
+func normalizeFinishReason(reason string) string {
+	switch reason {
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII", "IMAGE_SAFETY":
+		return service.FinishContentFilter
+	}
+	return reason
+}
@@ -34,10 +34,26 @@ type Part struct {
 	// For function response (user providing tool result)
 	FunctionResponse *FunctionResponse `json:"functionResponse,omitempty"`
 
+	// For images: inline base64 data or a file URI
+	InlineData *Blob     `json:"inlineData,omitempty"`
+	FileData   *FileData `json:"fileData,omitempty"`
+
 	// For thinking content (Gemini 2.5+ thinking)
 	Thought   *bool  `json:"thought,omitempty"`
 }
 
+// Blob is inline media data.
+type Blob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"` // base64
+}
+
+// FileData references media by URI.
+type FileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
 // FunctionCall represents a model's request to call a function.
 type FunctionCall struct {
 	Name string                 `json:"name"`
@@ -64,22 +80,36 @@ type FunctionDeclarationSpec struct {
 
 // GenerationConfig controls generation parameters.
 type GenerationConfig struct {
-	Temperature     float64 `json:"temperature,omitempty"`
-	MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
-	CandidateCount  int     `json:"candidateCount,omitempty"`
+	Temperature     float64         `json:"temperature,omitempty"`
+	TopP            float64         `json:"topP,omitempty"`
+	MaxOutputTokens int             `json:"maxOutputTokens,omitempty"`
+	CandidateCount  int             `json:"candidateCount,omitempty"`
+	ThinkingConfig  *ThinkingConfig `json:"thinkingConfig,omitempty"`
+}
+
+// ThinkingConfig sets the thinking token budget (Gemini 2.5+).
+type ThinkingConfig struct {
+	ThinkingBudget int `json:"thinkingBudget"`
 }
 
 // Response is the Gemini generateContent response format.
 type Response struct {
-	Candidates    []Candidate    `json:"candidates"`
-	UsageMetadata *UsageMetadata `json:"usageMetadata,omitempty"`
-	ModelVersion  string         `json:"modelVersion,omitempty"`
+	Candidates     []Candidate     `json:"candidates"`
+	UsageMetadata  *UsageMetadata  `json:"usageMetadata,omitempty"`
+	ModelVersion   string          `json:"modelVersion,omitempty"`
+	PromptFeedback *PromptFeedback `json:"promptFeedback,omitempty"`
+}
+
+// PromptFeedback is set when the prompt itself was blocked; the response
+// then has no candidates.
+type PromptFeedback struct {
+	BlockReason string `json:"blockReason,omitempty"` // "SAFETY" | "BLOCKLIST" | "PROHIBITED_CONTENT" | "OTHER"
 }
 
 // Candidate is a single response candidate.
 type Candidate struct {
 	Content       Content `json:"content"`
-	FinishReason  string  `json:"finishReason,omitempty"` // "STOP" | "MAX_TOKENS" | "SAFETY"
+	FinishReason  string  `json:"finishReason,omitempty"` // "STOP" | "MAX_TOKENS" | "SAFETY" | "RECITATION" | ...
 }
 
 // UsageMetadata reports token consumption.
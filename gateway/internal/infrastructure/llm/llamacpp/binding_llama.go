@@ -0,0 +1,225 @@
+//go:build llamacpp && cgo
+
+package llamacpp
+
+/*
+#cgo LDFLAGS: -lllama
+#include <stdlib.h>
+#include <llama.h>
+*/
+import "C"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"unicode/utf8"
+	"unsafe"
+
+	llm "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/llm"
+)
+
+// compiled reports whether the llama.cpp binding is part of this build.
+const compiled = true
+
+var backendOnce sync.Once
+
+type llamaEngine struct {
+	model    *C.struct_llama_model
+	vocab    *C.struct_llama_vocab
+	template *C.char // model chat template, nil = ChatML
+	nCtx     int
+	threads  int
+}
+
+func openEngine(cfg llm.LlamaConfig) (engine, error) {
+	backendOnce.Do(func() { C.llama_backend_init() })
+
+	params := C.llama_model_default_params()
+	params.n_gpu_layers = C.int32_t(cfg.GPULayers)
+	if cfg.GPULayers < 0 {
+		params.n_gpu_layers = 999 // offload every layer
+	}
+	path := C.CString(cfg.ModelPath)
+	defer C.free(unsafe.Pointer(path))
+	model := C.llama_model_load_from_file(path, params)
+	if model == nil {
+		return nil, fmt.Errorf("load GGUF model %s failed", cfg.ModelPath)
+	}
+
+	e := &llamaEngine{
+		model:    model,
+		vocab:    C.llama_model_get_vocab(model),
+		template: C.llama_model_chat_template(model, nil),
+		nCtx:     cfg.ContextSize,
+		threads:  cfg.Threads,
+	}
+	if e.nCtx <= 0 {
+		e.nCtx = 4096
+	}
+	if e.threads <= 0 {
+		e.threads = runtime.NumCPU()
+	}
+	return e, nil
+}
+
+func (e *llamaEngine) ApplyTemplate(messages []chatMessage) (string, error) {
+	if e.template == nil {
+		return chatML(messages), nil
+	}
+	n := len(messages)
+	chat := unsafe.Slice((*C.struct_llama_chat_message)(C.malloc(C.size_t(n)*C.size_t(unsafe.Sizeof(C.struct_llama_chat_message{})))), n)
+	defer C.free(unsafe.Pointer(&chat[0]))
+	for i, m := range messages {
+		chat[i].role = C.CString(m.Role)
+		chat[i].content = C.CString(m.Content)
+	}
+	defer func() {
+		for i := range chat {
+			C.free(unsafe.Pointer(chat[i].role))
+			C.free(unsafe.Pointer(chat[i].content))
+		}
+	}()
+
+	size := 0
+	for _, m := range messages {
+		size += len(m.Role) + len(m.Content) + 16
+	}
+	for attempt := 0; attempt < 2; attempt++ {
+		buf := (*C.char)(C.malloc(C.size_t(size)))
+		got := int(C.llama_chat_apply_template(e.template, &chat[0], C.size_t(n), C.bool(true), buf, C.int32_t(size)))
+		if got < 0 {
+			C.free(unsafe.Pointer(buf))
+			// Template not supported by llama.cpp's built-in renderer
+			return chatML(messages), nil
+		}
+		if got <= size {
+			out := C.GoStringN(buf, C.int(got))
+			C.free(unsafe.Pointer(buf))
+			return out, nil
+		}
+		C.free(unsafe.Pointer(buf))
+		size = got
+	}
+	return "", errors.New("chat template output size changed")
+}
+
+func (e *llamaEngine) Complete(ctx context.Context, prompt string, opts sampling, emit func(string)) (completion, error) {
+	var out completion
+
+	cparams := C.llama_context_default_params()
+	cparams.n_ctx = C.uint32_t(e.nCtx)
+	cparams.n_threads = C.int32_t(e.threads)
+	cparams.n_threads_batch = C.int32_t(e.threads)
+	lctx := C.llama_init_from_model(e.model, cparams)
+	if lctx == nil {
+		return out, errors.New("create llama context failed")
+	}
+	defer C.llama_free(lctx)
+
+	// Tokens live in C memory: llama_batch_get_one keeps the pointer
+	tokens := unsafe.Slice((*C.llama_token)(C.malloc(C.size_t(e.nCtx)*C.size_t(unsafe.Sizeof(C.llama_token(0))))), e.nCtx)
+	defer C.free(unsafe.Pointer(&tokens[0]))
+
+	cprompt := C.CString(prompt)
+	defer C.free(unsafe.Pointer(cprompt))
+	n := int(C.llama_tokenize(e.vocab, cprompt, C.int32_t(len(prompt)), &tokens[0], C.int32_t(e.nCtx), C.bool(true), C.bool(true)))
+	if n < 0 {
+		return out, fmt.Errorf("prompt is %d tokens, context_size is %d", -n, e.nCtx)
+	}
+	out.PromptTokens = n
+
+	// Decode the prompt in batches of n_batch tokens
+	batchSize := int(C.llama_n_batch(lctx))
+	for i := 0; i < n; i += batchSize {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+		size := min(batchSize, n-i)
+		if rc := C.llama_decode(lctx, C.llama_batch_get_one(&tokens[i], C.int32_t(size))); rc != 0 {
+			return out, fmt.Errorf("decode prompt: llama_decode returned %d", int(rc))
+		}
+	}
+
+	sampler := newSampler(opts)
+	defer C.llama_sampler_free(sampler)
+
+	limit := e.nCtx - n
+	if opts.MaxTokens > 0 && opts.MaxTokens < limit {
+		limit = opts.MaxTokens
+	}
+	var pending []byte // bytes of an incomplete UTF-8 sequence
+	var text []byte
+	piece := (*C.char)(C.malloc(256))
+	defer C.free(unsafe.Pointer(piece))
+	out.Stopped = "length"
+	for out.OutputTokens < limit {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+		tok := C.llama_sampler_sample(sampler, lctx, -1)
+		if C.llama_vocab_is_eog(e.vocab, tok) {
+			out.Stopped = "stop"
+			break
+		}
+		out.OutputTokens++
+
+		np := int(C.llama_token_to_piece(e.vocab, tok, piece, 256, 0, C.bool(false)))
+		if np > 0 {
+			pending = append(pending, C.GoBytes(unsafe.Pointer(piece), C.int(np))...)
+			if valid := validPrefix(pending); valid > 0 {
+				text = append(text, pending[:valid]...)
+				emit(string(pending[:valid]))
+				pending = pending[valid:]
+			}
+		}
+
+		tokens[0] = tok
+		if rc := C.llama_decode(lctx, C.llama_batch_get_one(&tokens[0], 1)); rc != 0 {
+			return out, fmt.Errorf("decode: llama_decode returned %d", int(rc))
+		}
+	}
+	if len(pending) > 0 {
+		text = append(text, pending...)
+		emit(string(pending))
+	}
+	out.Text = string(text)
+	return out, nil
+}
+
+// newSampler builds top-p → temperature → random sampling, or greedy
+// sampling at temperature 0.
+func newSampler(opts sampling) *C.struct_llama_sampler {
+	chain := C.llama_sampler_chain_init(C.llama_sampler_chain_default_params())
+	if opts.Temperature <= 0 {
+		C.llama_sampler_chain_add(chain, C.llama_sampler_init_greedy())
+		return chain
+	}
+	if opts.TopP > 0 && opts.TopP < 1 {
+		C.llama_sampler_chain_add(chain, C.llama_sampler_init_top_p(C.float(opts.TopP), 1))
+	}
+	C.llama_sampler_chain_add(chain, C.llama_sampler_init_temp(C.float(opts.Temperature)))
+	C.llama_sampler_chain_add(chain, C.llama_sampler_init_dist(C.LLAMA_DEFAULT_SEED))
+	return chain
+}
+
+// validPrefix returns the length of b up to the last complete UTF-8
+// sequence; a token may end in the middle of a multi-byte character.
+// Bytes that never form a character are passed through.
+func validPrefix(b []byte) int {
+	for i := len(b); i > 0 && i > len(b)-utf8.UTFMax; i-- {
+		if utf8.Valid(b[:i]) {
+			return i
+		}
+	}
+	if len(b) >= utf8.UTFMax {
+		return len(b)
+	}
+	return 0
+}
+
+func (e *llamaEngine) Close() {
+	C.llama_model_free(e.model)
+}
@@ -0,0 +1,16 @@
+//go:build !llamacpp || !cgo
+
+package llamacpp
+
+import (
+	"errors"
+
+	llm "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/llm"
+)
+
+// compiled reports whether the llama.cpp binding is part of this build.
+const compiled = false
+
+func openEngine(llm.LlamaConfig) (engine, error) {
+	return nil, errors.New("llama.cpp support is not compiled in; rebuild with CGO_ENABLED=1 go build -tags llamacpp (needs llama.h and libllama)")
+}
@@ -0,0 +1,167 @@
+package llamacpp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+)
+
+// toolProtocol is appended to the system prompt when tools are offered. The
+// format is the one service.ParseToolCallsFromText reads back.
+const toolProtocol = `
+
+## Tools
+
+You can call tools. To call one, reply with a block of this exact form and nothing after it:
+
+` + "```tool_call" + `
+{"name": "<tool name>", "arguments": {<arguments as JSON>}}
+` + "```" + `
+
+Several blocks may follow each other to call several tools. Each result comes back in the next user message. Available tools:
+`
+
+// chatMessages flattens the request into role/content pairs for the
+// model's chat template: tools are described in the system prompt, past
+// tool calls are replayed as tool_call blocks and tool results become user
+// messages.
+func chatMessages(req *service.LLMRequest) []chatMessage {
+	var system strings.Builder
+	var out []chatMessage
+	for _, m := range req.Messages {
+		content := messageText(m)
+		switch m.Role {
+		case "system":
+			if system.Len() > 0 {
+				system.WriteString("\n\n")
+			}
+			system.WriteString(content)
+		case "assistant":
+			var sb strings.Builder
+			sb.WriteString(content)
+			for _, tc := range m.ToolCalls {
+				args, _ := json.Marshal(tc.Arguments)
+				if sb.Len() > 0 {
+					sb.WriteString("\n")
+				}
+				fmt.Fprintf(&sb, "```tool_call\n{\"name\": %q, \"arguments\": %s}\n```", tc.Name, args)
+			}
+			out = append(out, chatMessage{Role: "assistant", Content: sb.String()})
+		case "tool":
+			name := m.Name
+			if name == "" {
+				name = "tool"
+			}
+			out = appendUser(out, fmt.Sprintf("Result of %s:\n%s", name, content))
+		default:
+			out = appendUser(out, content)
+		}
+	}
+
+	if len(req.Tools) > 0 {
+		system.WriteString(toolProtocol)
+		for _, d := range req.Tools {
+			params, _ := json.Marshal(d.Parameters)
+			fmt.Fprintf(&system, "\n- %s: %s\n  Parameters: %s", d.Name, d.Description, params)
+		}
+	}
+	if system.Len() > 0 {
+		out = append([]chatMessage{{Role: "system", Content: strings.TrimSpace(system.String())}}, out...)
+	}
+	return out
+}
+
+// appendUser merges consecutive user messages (parallel tool results), which
+// many chat templates reject.
+func appendUser(out []chatMessage, content string) []chatMessage {
+	if n := len(out); n > 0 && out[n-1].Role == "user" {
+		out[n-1].Content += "\n\n" + content
+		return out
+	}
+	return append(out, chatMessage{Role: "user", Content: content})
+}
+
+// messageText returns the text of a message. Images and other media cannot
+// be passed to a text-only model and are noted by URL.
+func messageText(m service.LLMMessage) string {
+	if len(m.Parts) == 0 {
+		return m.Content
+	}
+	var parts []string
+	for _, p := range m.Parts {
+		if p.Type == "text" {
+			parts = append(parts, p.Text)
+		} else if p.MediaURL != "" {
+			parts = append(parts, fmt.Sprintf("[%s: %s]", p.Type, p.MediaURL))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// chatML renders messages in the ChatML format, used when the model has no
+// chat template of its own.
+func chatML(messages []chatMessage) string {
+	var sb strings.Builder
+	for _, m := range messages {
+		fmt.Fprintf(&sb, "<|im_start|>%s\n%s<|im_end|>\n", m.Role, m.Content)
+	}
+	sb.WriteString("<|im_start|>assistant\n")
+	return sb.String()
+}
+
+// toolCallMarkers start a text tool call.
+var toolCallMarkers = []string{"```tool_call", "[TOOL_CALL]"}
+
+// toolCallFilter streams generated text until a tool call starts. Text that
+// could be the beginning of a marker is held until it is decided; from the
+// first marker on nothing more is streamed, and the calls are sent once
+// parsed.
+type toolCallFilter struct {
+	emit    func(string)
+	pending string
+	held    bool
+	sent    int // bytes streamed so far
+}
+
+func (f *toolCallFilter) write(text string) {
+	if f.held {
+		return
+	}
+	f.pending += text
+	for _, m := range toolCallMarkers {
+		if i := strings.Index(f.pending, m); i >= 0 {
+			f.send(f.pending[:i])
+			f.pending = ""
+			f.held = true
+			return
+		}
+	}
+	keep := 0
+	for _, m := range toolCallMarkers {
+		for n := min(len(m)-1, len(f.pending)); n > keep; n-- {
+			if strings.HasSuffix(f.pending, m[:n]) {
+				keep = n
+				break
+			}
+		}
+	}
+	f.send(f.pending[:len(f.pending)-keep])
+	f.pending = f.pending[len(f.pending)-keep:]
+}
+
+// finish streams whatever was held back of the full output once it turned
+// out to contain no tool call (a partial marker, or a malformed block).
+func (f *toolCallFilter) finish(full string) {
+	if f.sent < len(full) {
+		f.send(full[f.sent:])
+	}
+}
+
+func (f *toolCallFilter) send(text string) {
+	if text != "" {
+		f.emit(text)
+		f.sent += len(text)
+	}
+}
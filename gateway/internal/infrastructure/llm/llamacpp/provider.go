@@ -0,0 +1,224 @@
+// Package llamacpp runs GGUF models in-process through llama.cpp, for
+// offline single-binary deployments without an Ollama or vLLM server.
+//
+// The cgo binding is compiled only with the llamacpp build tag and a
+// llama.cpp installation (llama.h and libllama):
+//
+//	CGO_ENABLED=1 go build -tags llamacpp ./cmd/cli
+//
+// Other builds register the provider type but report it unavailable.
+// Local models rarely support native function calling, so tools are offered
+// through the text protocol of service.ParseToolCallsFromText.
+package llamacpp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	llm "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/llm"
+	"go.uber.org/zap"
+)
+
+func init() {
+	llm.RegisterFactory("llamacpp", func(cfg llm.ProviderConfig, logger *zap.Logger) llm.Provider {
+		return New(cfg, logger)
+	})
+}
+
+// engine is a loaded model. Complete decodes prompt and samples until an
+// end-of-generation token, opts.MaxTokens or ctx is done, passing each
+// piece of text to emit as it is produced.
+type engine interface {
+	ApplyTemplate(messages []chatMessage) (string, error)
+	Complete(ctx context.Context, prompt string, opts sampling, emit func(string)) (completion, error)
+	Close()
+}
+
+type chatMessage struct {
+	Role    string
+	Content string
+}
+
+type sampling struct {
+	Temperature float64
+	TopP        float64
+	MaxTokens   int // 0 = until the context is full
+}
+
+type completion struct {
+	Text         string
+	PromptTokens int
+	OutputTokens int
+	Stopped      string // "stop" (end of generation) or "length"
+}
+
+// Provider serves one GGUF model. The model is loaded on first use and kept;
+// generations run one at a time.
+type Provider struct {
+	name   string
+	models []string
+	config llm.LlamaConfig
+	open   func(llm.LlamaConfig) (engine, error)
+	logger *zap.Logger
+
+	mu     sync.Mutex
+	engine engine
+	calls  atomic.Int64 // tool call ID sequence
+}
+
+// New creates a llama.cpp provider. Models defaults to the GGUF file name
+// without its extension.
+func New(cfg llm.ProviderConfig, logger *zap.Logger) *Provider {
+	models := cfg.Models
+	if len(models) == 0 && cfg.Llama.ModelPath != "" {
+		models = []string{strings.TrimSuffix(filepath.Base(cfg.Llama.ModelPath), filepath.Ext(cfg.Llama.ModelPath))}
+	}
+	return &Provider{
+		name:   cfg.Name,
+		models: models,
+		config: cfg.Llama,
+		open:   openEngine,
+		logger: logger.With(zap.String("provider", cfg.Name), zap.String("type", "llamacpp")),
+	}
+}
+
+var _ llm.Provider = (*Provider)(nil)
+
+func (p *Provider) Name() string     { return p.name }
+func (p *Provider) Models() []string { return p.models }
+
+func (p *Provider) SupportsModel(model string) bool {
+	if len(p.models) == 0 {
+		return true
+	}
+	model = stripPrefix(model)
+	for _, m := range p.models {
+		if m == model {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAvailable reports whether the binding is compiled in and the model file
+// exists.
+func (p *Provider) IsAvailable(ctx context.Context) bool {
+	if !compiled || p.config.ModelPath == "" {
+		return false
+	}
+	_, err := os.Stat(p.config.ModelPath)
+	return err == nil
+}
+
+// Generate implements service.LLMClient (non-streaming).
+func (p *Provider) Generate(ctx context.Context, req *service.LLMRequest) (*service.LLMResponse, error) {
+	return p.generate(ctx, req, nil)
+}
+
+// GenerateStream implements service.LLMClient. Text streams as it is
+// sampled; once a tool call block starts, the rest is held back and parsed
+// into tool calls when generation ends.
+func (p *Provider) GenerateStream(ctx context.Context, req *service.LLMRequest, deltaCh chan<- service.StreamChunk) (*service.LLMResponse, error) {
+	return p.generate(ctx, req, deltaCh)
+}
+
+func (p *Provider) generate(ctx context.Context, req *service.LLMRequest, deltaCh chan<- service.StreamChunk) (*service.LLMResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	eng, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+	prompt, err := eng.ApplyTemplate(chatMessages(req))
+	if err != nil {
+		return nil, fmt.Errorf("apply chat template: %w", err)
+	}
+
+	send := func(chunk service.StreamChunk) {
+		if deltaCh == nil {
+			return
+		}
+		select {
+		case deltaCh <- chunk:
+		case <-ctx.Done():
+		}
+	}
+	filter := &toolCallFilter{emit: func(text string) { send(service.StreamChunk{DeltaText: text}) }}
+
+	out, err := eng.Complete(ctx, prompt, sampling{
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		MaxTokens:   req.MaxTokens,
+	}, filter.write)
+	if err != nil {
+		return nil, err
+	}
+
+	content, calls := service.ParseToolCallsFromText(out.Text)
+	if len(calls) == 0 {
+		filter.finish(out.Text)
+	}
+	resp := &service.LLMResponse{
+		Content:    content,
+		ModelUsed:  stripPrefix(req.Model),
+		TokensUsed: out.PromptTokens + out.OutputTokens,
+	}
+	for i := range calls {
+		calls[i].ID = fmt.Sprintf("call_%d", p.calls.Add(1))
+		resp.ToolCalls = append(resp.ToolCalls, calls[i])
+		send(service.StreamChunk{DeltaToolCall: &calls[i]})
+	}
+	finish := out.Stopped
+	if len(calls) > 0 {
+		finish = "tool_calls"
+	}
+	send(service.StreamChunk{FinishReason: finish})
+	return resp, nil
+}
+
+// load opens the model on first use. A failed load is retried on the next
+// call, so fixing the file does not need a restart.
+func (p *Provider) load() (engine, error) {
+	if p.engine != nil {
+		return p.engine, nil
+	}
+	if p.config.ModelPath == "" {
+		return nil, fmt.Errorf("llamacpp provider %s: model_path is not set", p.name)
+	}
+	eng, err := p.open(p.config)
+	if err != nil {
+		return nil, fmt.Errorf("llamacpp provider %s: %w", p.name, err)
+	}
+	p.logger.Info("GGUF model loaded",
+		zap.String("path", p.config.ModelPath),
+		zap.Int("context_size", p.config.ContextSize),
+		zap.Int("gpu_layers", p.config.GPULayers),
+	)
+	p.engine = eng
+	return eng, nil
+}
+
+// Close frees the loaded model.
+func (p *Provider) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.engine != nil {
+		p.engine.Close()
+		p.engine = nil
+	}
+}
+
+// stripPrefix removes a provider prefix ("local/qwen2.5-7b" → "qwen2.5-7b").
+func stripPrefix(model string) string {
+	if idx := strings.Index(model, "/"); idx >= 0 {
+		return model[idx+1:]
+	}
+	return model
+}
@@ -0,0 +1,114 @@
+package llamacpp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	llm "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/llm"
+	"go.uber.org/zap"
+)
+
+// fakeEngine emits its reply in small pieces, as token pieces arrive.
+type fakeEngine struct {
+	reply  string
+	prompt string
+}
+
+func (e *fakeEngine) ApplyTemplate(messages []chatMessage) (string, error) {
+	return chatML(messages), nil
+}
+
+func (e *fakeEngine) Complete(ctx context.Context, prompt string, opts sampling, emit func(string)) (completion, error) {
+	e.prompt = prompt
+	for i := 0; i < len(e.reply); i += 3 {
+		emit(e.reply[i:min(i+3, len(e.reply))])
+	}
+	return completion{Text: e.reply, PromptTokens: 100, OutputTokens: 20, Stopped: "stop"}, nil
+}
+
+func (e *fakeEngine) Close() {}
+
+func newTestProvider(eng *fakeEngine) *Provider {
+	p := New(llm.ProviderConfig{Name: "local", Llama: llm.LlamaConfig{ModelPath: "/models/qwen2.5-7b-instruct.Q4_K_M.gguf"}}, zap.NewNop())
+	p.open = func(llm.LlamaConfig) (engine, error) { return eng, nil }
+	return p
+}
+
+func collect(t *testing.T, p *Provider, req *service.LLMRequest) (*service.LLMResponse, string, []entity.ToolCallInfo, string) {
+	t.Helper()
+	ch := make(chan service.StreamChunk, 256)
+	resp, err := p.GenerateStream(context.Background(), req, ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(ch)
+	var text strings.Builder
+	var calls []entity.ToolCallInfo
+	var finish string
+	for c := range ch {
+		text.WriteString(c.DeltaText)
+		if c.DeltaToolCall != nil {
+			calls = append(calls, *c.DeltaToolCall)
+		}
+		if c.FinishReason != "" {
+			finish = c.FinishReason
+		}
+	}
+	return resp, text.String(), calls, finish
+}
+
+func TestGenerateStream_ToolCall(t *testing.T) {
+	eng := &fakeEngine{reply: "Let me check.\n```tool_call\n{\"name\": \"bash\", \"arguments\": {\"command\": \"ls\"}}\n```"}
+	p := newTestProvider(eng)
+	if got := p.Models(); len(got) != 1 || got[0] != "qwen2.5-7b-instruct.Q4_K_M" {
+		t.Errorf("Models = %v", got)
+	}
+
+	resp, text, calls, finish := collect(t, p, &service.LLMRequest{
+		Model: "local/qwen2.5-7b-instruct.Q4_K_M",
+		Messages: []service.LLMMessage{
+			{Role: "system", Content: "You are helpful."},
+			{Role: "user", Content: "list files"},
+			{Role: "assistant", ToolCalls: []entity.ToolCallInfo{{ID: "c1", Name: "bash", Arguments: map[string]interface{}{"command": "pwd"}}}},
+			{Role: "tool", Name: "bash", ToolCallID: "c1", Content: "/work"},
+		},
+		Tools: []domaintool.Definition{{Name: "bash", Description: "Run a shell command."}},
+	})
+	if text != "Let me check.\n" {
+		t.Errorf("streamed text = %q", text)
+	}
+	if len(calls) != 1 || calls[0].Name != "bash" || calls[0].Arguments["command"] != "ls" || calls[0].ID != "call_1" {
+		t.Errorf("calls = %+v", calls)
+	}
+	if finish != "tool_calls" || len(resp.ToolCalls) != 1 || resp.TokensUsed != 120 || resp.ModelUsed != "qwen2.5-7b-instruct.Q4_K_M" {
+		t.Errorf("finish = %q, resp = %+v", finish, resp)
+	}
+	for _, want := range []string{
+		"<|im_start|>system\nYou are helpful.\n\n## Tools",
+		"- bash: Run a shell command.",
+		"<|im_start|>assistant\n```tool_call\n{\"name\": \"bash\", \"arguments\": {\"command\":\"pwd\"}}\n```<|im_end|>",
+		"<|im_start|>user\nResult of bash:\n/work<|im_end|>",
+	} {
+		if !strings.Contains(eng.prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, eng.prompt)
+		}
+	}
+}
+
+func TestGenerateStream_PlainText(t *testing.T) {
+	// A partial marker at the end is held back, then released
+	eng := &fakeEngine{reply: "Use ``` fences: ```tool"}
+	_, text, calls, finish := collect(t, newTestProvider(eng), &service.LLMRequest{
+		Messages: []service.LLMMessage{{Role: "user", Content: "hi"}},
+	})
+	if text != eng.reply || len(calls) != 0 || finish != "stop" {
+		t.Errorf("text = %q, calls = %v, finish = %q", text, calls, finish)
+	}
+	if strings.Contains(eng.prompt, "## Tools") {
+		t.Error("tool protocol sent without tools")
+	}
+}
@@ -0,0 +1,256 @@
+package llm
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/repository"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"go.uber.org/zap"
+)
+
+// maxLatencySamples bounds the rolling latency window per provider+model.
+const maxLatencySamples = 200
+
+// ModelStatsTracker aggregates per provider+model request counts, tokens,
+// latency percentiles and error categories for capacity planning.
+//
+// Stats live in memory and are periodically flushed to the repository;
+// on startup Load restores the previous totals so numbers survive restarts.
+type ModelStatsTracker struct {
+	repo   repository.ModelStatsRepository // optional, nil = memory only
+	logger *zap.Logger
+
+	mu    sync.Mutex
+	stats map[string]*entity.ModelStats // provider|model → stats
+	dirty map[string]bool
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// NewModelStatsTracker creates a tracker. repo may be nil.
+func NewModelStatsTracker(repo repository.ModelStatsRepository, logger *zap.Logger) *ModelStatsTracker {
+	return &ModelStatsTracker{
+		repo:   repo,
+		logger: logger.With(zap.String("component", "model-stats")),
+		stats:  make(map[string]*entity.ModelStats),
+		dirty:  make(map[string]bool),
+	}
+}
+
+// Load restores persisted stats from the repository.
+func (t *ModelStatsTracker) Load(ctx context.Context) error {
+	if t.repo == nil {
+		return nil
+	}
+	rows, err := t.repo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, s := range rows {
+		if len(s.LatencySamples) > maxLatencySamples {
+			s.LatencySamples = s.LatencySamples[len(s.LatencySamples)-maxLatencySamples:]
+		}
+		if len(s.FirstTokenSamples) > maxLatencySamples {
+			s.FirstTokenSamples = s.FirstTokenSamples[len(s.FirstTokenSamples)-maxLatencySamples:]
+		}
+		t.stats[s.Key()] = s
+	}
+	return nil
+}
+
+// Record adds one request outcome.
+func (t *ModelStatsTracker) Record(provider, model string, latency time.Duration, tokens int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := provider + "|" + model
+	s, ok := t.stats[key]
+	if !ok {
+		s = &entity.ModelStats{Provider: provider, Model: model}
+		t.stats[key] = s
+	}
+
+	s.Requests++
+	s.Tokens += int64(tokens)
+	if err != nil {
+		s.Failures++
+		if s.Errors == nil {
+			s.Errors = make(map[string]int64)
+		}
+		s.Errors[service.ClassifyError(err, provider, model).Kind.String()]++
+	}
+
+	s.LatencySamples = append(s.LatencySamples, latency.Milliseconds())
+	if len(s.LatencySamples) > maxLatencySamples {
+		s.LatencySamples = s.LatencySamples[len(s.LatencySamples)-maxLatencySamples:]
+	}
+	s.LatencyP50Ms = percentile(s.LatencySamples, 50)
+	s.LatencyP95Ms = percentile(s.LatencySamples, 95)
+	s.UpdatedAt = time.Now()
+	t.dirty[key] = true
+
+	t.logger.Debug("LLM request recorded",
+		zap.String("provider", provider),
+		zap.String("model", model),
+		zap.Duration("latency", latency),
+		zap.Int("tokens", tokens),
+		zap.Bool("failed", err != nil),
+	)
+}
+
+// RecordStream adds the first-token latency and slow-stream counts of one
+// streamed call. It complements Record, which already counted the request.
+// firstToken 0 (no output) adds no sample.
+func (t *ModelStatsTracker) RecordStream(provider, model string, firstToken time.Duration, slowStart bool, stalls int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := provider + "|" + model
+	s, ok := t.stats[key]
+	if !ok {
+		s = &entity.ModelStats{Provider: provider, Model: model}
+		t.stats[key] = s
+	}
+
+	if firstToken > 0 {
+		s.FirstTokenSamples = append(s.FirstTokenSamples, firstToken.Milliseconds())
+		if len(s.FirstTokenSamples) > maxLatencySamples {
+			s.FirstTokenSamples = s.FirstTokenSamples[len(s.FirstTokenSamples)-maxLatencySamples:]
+		}
+		s.FirstTokenP50Ms = percentile(s.FirstTokenSamples, 50)
+		s.FirstTokenP95Ms = percentile(s.FirstTokenSamples, 95)
+	}
+	if slowStart {
+		s.SlowStarts++
+	}
+	s.Stalls += int64(stalls)
+	s.UpdatedAt = time.Now()
+	t.dirty[key] = true
+}
+
+// Snapshot returns a copy of all stats, sorted by provider then model.
+func (t *ModelStatsTracker) Snapshot() []entity.ModelStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make([]entity.ModelStats, 0, len(t.stats))
+	for _, s := range t.stats {
+		cp := *s
+		cp.LatencySamples = nil
+		cp.FirstTokenSamples = nil
+		if s.Errors != nil {
+			cp.Errors = make(map[string]int64, len(s.Errors))
+			for k, v := range s.Errors {
+				cp.Errors[k] = v
+			}
+		}
+		result = append(result, cp)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Provider != result[j].Provider {
+			return result[i].Provider < result[j].Provider
+		}
+		return result[i].Model < result[j].Model
+	})
+	return result
+}
+
+// Flush persists stats changed since the last flush.
+func (t *ModelStatsTracker) Flush(ctx context.Context) error {
+	if t.repo == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	pending := make([]*entity.ModelStats, 0, len(t.dirty))
+	for key := range t.dirty {
+		cp := *t.stats[key]
+		cp.LatencySamples = append([]int64(nil), cp.LatencySamples...)
+		cp.FirstTokenSamples = append([]int64(nil), cp.FirstTokenSamples...)
+		if cp.Errors != nil {
+			errs := make(map[string]int64, len(cp.Errors))
+			for k, v := range cp.Errors {
+				errs[k] = v
+			}
+			cp.Errors = errs
+		}
+		pending = append(pending, &cp)
+	}
+	t.dirty = make(map[string]bool)
+	t.mu.Unlock()
+
+	var firstErr error
+	for _, s := range pending {
+		if err := t.repo.Save(ctx, s); err != nil {
+			t.mu.Lock()
+			t.dirty[s.Key()] = true
+			t.mu.Unlock()
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// Start launches the periodic flush loop.
+func (t *ModelStatsTracker) Start(interval time.Duration) {
+	if t.repo == nil || t.stopCh != nil {
+		return
+	}
+	t.stopCh = make(chan struct{})
+	t.doneCh = make(chan struct{})
+
+	go func() {
+		defer close(t.doneCh)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := t.Flush(context.Background()); err != nil {
+					t.logger.Warn("Failed to flush model stats", zap.Error(err))
+				}
+			case <-t.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts the flush loop and performs a final flush.
+func (t *ModelStatsTracker) Stop() {
+	if t.stopCh != nil {
+		close(t.stopCh)
+		<-t.doneCh
+		t.stopCh = nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := t.Flush(ctx); err != nil {
+		t.logger.Warn("Failed to flush model stats on shutdown", zap.Error(err))
+	}
+}
+
+// percentile returns the p-th percentile (nearest-rank) of samples.
+func percentile(samples []int64, p int) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := append([]int64(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return float64(sorted[rank-1])
+}
@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestPercentile(t *testing.T) {
+	samples := make([]int64, 0, 100)
+	for i := 100; i >= 1; i-- {
+		samples = append(samples, int64(i))
+	}
+
+	if got := percentile(samples, 50); got != 50 {
+		t.Errorf("p50 = %v, want 50", got)
+	}
+	if got := percentile(samples, 95); got != 95 {
+		t.Errorf("p95 = %v, want 95", got)
+	}
+	if got := percentile(nil, 95); got != 0 {
+		t.Errorf("p95 of empty = %v, want 0", got)
+	}
+	if got := percentile([]int64{7}, 50); got != 7 {
+		t.Errorf("p50 of single = %v, want 7", got)
+	}
+}
+
+func TestModelStatsTracker_Record(t *testing.T) {
+	tr := NewModelStatsTracker(nil, zap.NewNop())
+
+	tr.Record("openai", "gpt-4o", 100*time.Millisecond, 50, nil)
+	tr.Record("openai", "gpt-4o", 300*time.Millisecond, 70, nil)
+	tr.Record("openai", "gpt-4o", 200*time.Millisecond, 0, errors.New("status 429: rate limit"))
+	tr.Record("anthropic", "claude", 50*time.Millisecond, 10, nil)
+
+	snap := tr.Snapshot()
+	if len(snap) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(snap))
+	}
+	if snap[0].Provider != "anthropic" {
+		t.Errorf("expected sorted by provider, got %s first", snap[0].Provider)
+	}
+
+	s := snap[1]
+	if s.Requests != 3 || s.Failures != 1 || s.Tokens != 120 {
+		t.Errorf("unexpected counters: %+v", s)
+	}
+	if s.LatencyP50Ms != 200 {
+		t.Errorf("p50 = %v, want 200", s.LatencyP50Ms)
+	}
+	if s.LatencyP95Ms != 300 {
+		t.Errorf("p95 = %v, want 300", s.LatencyP95Ms)
+	}
+	if s.Errors["transient"] != 1 {
+		t.Errorf("expected 1 transient error, got %v", s.Errors)
+	}
+	if s.LatencySamples != nil {
+		t.Error("snapshot should not expose latency samples")
+	}
+}
+
+func TestModelStatsTracker_WindowBounded(t *testing.T) {
+	tr := NewModelStatsTracker(nil, zap.NewNop())
+	for i := 0; i < maxLatencySamples+50; i++ {
+		tr.Record("p", "m", time.Millisecond, 0, nil)
+	}
+	if n := len(tr.stats["p|m"].LatencySamples); n != maxLatencySamples {
+		t.Errorf("window size = %d, want %d", n, maxLatencySamples)
+	}
+}
@@ -3,14 +3,12 @@ package openai
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
@@ -42,26 +40,13 @@ func New(cfg llm.ProviderConfig, logger *zap.Logger) *Provider {
 		baseURL = "https://api.openai.com/v1"
 	}
 
-	transport := &http.Transport{
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
-		TLSHandshakeTimeout:   15 * time.Second,
-		ResponseHeaderTimeout: 300 * time.Second,
-		IdleConnTimeout:       90 * time.Second,
-		MaxIdleConns:          10,
-		MaxIdleConnsPerHost:   5,
-		TLSClientConfig:       &tls.Config{MinVersion: tls.VersionTLS12},
-	}
-
 	return &Provider{
 		name:    cfg.Name,
 		baseURL: baseURL,
 		apiKey:  cfg.APIKey,
 		models:  cfg.Models,
 		client: &http.Client{
-			Transport: transport,
+			Transport: cfg.HTTPTransport(),
 		},
 		logger: logger.With(zap.String("provider", cfg.Name), zap.String("type", "openai")),
 	}
@@ -118,7 +103,7 @@ func (p *Provider) Generate(ctx context.Context, req *service.LLMRequest) (*serv
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(respBody))
+		return nil, llm.NewAPIError("API error", resp, respBody)
 	}
 
 	return p.parseAPIResponse(respBody)
@@ -156,7 +141,7 @@ func (p *Provider) GenerateStream(ctx context.Context, req *service.LLMRequest,
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(respBody))
+		return nil, llm.NewAPIError("API error", resp, respBody)
 	}
 
 	// Context cancellation body-close watchdog
@@ -186,15 +171,24 @@ func (p *Provider) buildAPIRequest(req *service.LLMRequest) *Request {
 	}
 
 	apiReq := &Request{
-		Model:       model,
-		Temperature: req.Temperature,
-		MaxTokens:   req.MaxTokens,
+		Model:           model,
+		Temperature:     req.Temperature,
+		TopP:            req.TopP,
+		MaxTokens:       req.MaxTokens,
+		ReasoningEffort: req.ReasoningEffort,
+	}
+	// enable_thinking / thinking_budget are DashScope extensions (Qwen3,
+	// ThinkingControl "toggle")
+	if req.EnableThinking != nil {
+		apiReq.EnableThinking = req.EnableThinking
+		apiReq.ThinkingBudget = req.ThinkingBudget
 	}
 
 	for _, msg := range req.Messages {
 		apiMsg := Message{
 			Role:       msg.Role,
 			Content:    msg.Content,
+			Parts:      contentParts(msg.Parts),
 			ToolCallID: msg.ToolCallID,
 			Name:       msg.Name,
 		}
@@ -227,6 +221,25 @@ func (p *Provider) buildAPIRequest(req *service.LLMRequest) *Request {
 	return apiReq
 }
 
+// contentParts converts multimodal parts to text / image_url parts; inline
+// image data is sent as a data URI.
+func contentParts(parts []service.ContentPart) []ContentPart {
+	var out []ContentPart
+	for _, part := range parts {
+		switch part.Type {
+		case "text":
+			out = append(out, ContentPart{Type: "text", Text: part.Text})
+		case "image":
+			url := part.MediaURL
+			if len(part.Data) > 0 {
+				url = "data:" + part.MimeType + ";base64," + base64.StdEncoding.EncodeToString(part.Data)
+			}
+			out = append(out, ContentPart{Type: "image_url", ImageURL: &ImageURL{URL: url}})
+		}
+	}
+	return out
+}
+
 func (p *Provider) parseAPIResponse(body []byte) (*service.LLMResponse, error) {
 	var apiResp Response
 	if err := json.Unmarshal(body, &apiResp); err != nil {
@@ -239,9 +252,10 @@ func (p *Provider) parseAPIResponse(body []byte) (*service.LLMResponse, error) {
 
 	choice := apiResp.Choices[0]
 	resp := &service.LLMResponse{
-		Content:    choice.Message.Content,
-		ModelUsed:  apiResp.Model,
-		TokensUsed: apiResp.Usage.Total(),
+		Content:      choice.Message.Content,
+		ModelUsed:    apiResp.Model,
+		TokensUsed:   apiResp.Usage.Total(),
+		FinishReason: normalizeFinishReason(choice.FinishReason, choice.Message.Refusal != ""),
 	}
 
 	for _, tc := range choice.Message.ToolCalls {
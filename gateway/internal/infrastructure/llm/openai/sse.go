@@ -42,6 +42,7 @@ func ParseSSEStream(ctx context.Context, reader io.Reader, deltaCh chan<- servic
 	var modelUsed string
 	var tokensUsed int
 	var finishReason string
+	refused := false
 
 	for scanner.Scan() {
 		select {
@@ -87,6 +88,10 @@ func ParseSSEStream(ctx context.Context, reader io.Reader, deltaCh chan<- servic
 			finishReason = *choice.FinishReason
 		}
 
+		if delta.Refusal != "" {
+			refused = true
+		}
+
 		// Text delta
 		if delta.Content != "" {
 			contentBuilder.WriteString(delta.Content)
@@ -150,9 +155,10 @@ func ParseSSEStream(ctx context.Context, reader io.Reader, deltaCh chan<- servic
 	}
 
 	resp := &service.LLMResponse{
-		Content:    contentStr,
-		ModelUsed:  modelUsed,
-		TokensUsed: tokensUsed,
+		Content:      contentStr,
+		ModelUsed:    modelUsed,
+		TokensUsed:   tokensUsed,
+		FinishReason: normalizeFinishReason(finishReason, refused),
 	}
 
 	// Assemble accumulated tool calls
@@ -224,3 +230,12 @@ func TruncateForLog(s string, maxLen int) string {
 	}
 	return s[:maxLen] + "..."
 }
+
+// normalizeFinishReason maps filtered output and refusals to the loop's
+// content-filter finish reason; other reasons pass through.
+func normalizeFinishReason(reason string, refused bool) string {
+	if refused || reason == "content_filter" {
+		return service.FinishContentFilter
+	}
+	return reason
+}
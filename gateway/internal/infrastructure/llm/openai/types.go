@@ -7,11 +7,15 @@ import "encoding/json"
 // Compatible with: OpenAI, Bailian (Qwen), MiniMax, DeepSeek, Ollama, vLLM, etc.
 
 type Request struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	MaxTokens   int       `json:"max_tokens,omitempty"`
-	Temperature float64   `json:"temperature,omitempty"`
-	Tools       []Tool    `json:"tools,omitempty"`
+	Model           string    `json:"model"`
+	Messages        []Message `json:"messages"`
+	MaxTokens       int       `json:"max_tokens,omitempty"`
+	Temperature     float64   `json:"temperature,omitempty"`
+	TopP            float64   `json:"top_p,omitempty"`
+	ReasoningEffort string    `json:"reasoning_effort,omitempty"` // o-series / reasoning models
+	EnableThinking  *bool     `json:"enable_thinking,omitempty"`  // Qwen3 (DashScope) thinking switch
+	ThinkingBudget  int       `json:"thinking_budget,omitempty"`  // Qwen3 (DashScope) thinking tokens
+	Tools           []Tool    `json:"tools,omitempty"`
 }
 
 type Message struct {
@@ -20,6 +24,34 @@ type Message struct {
 	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
 	ToolCallID string     `json:"tool_call_id,omitempty"`
 	Name       string     `json:"name,omitempty"`
+	Refusal    string     `json:"refusal,omitempty"` // set instead of Content when the model declines for safety reasons
+
+	// Parts is multimodal content; when set it is sent as the content array
+	// instead of Content.
+	Parts []ContentPart `json:"-"`
+}
+
+// MarshalJSON sends Parts (if any) as the "content" array.
+func (m Message) MarshalJSON() ([]byte, error) {
+	type plain Message
+	if len(m.Parts) == 0 {
+		return json.Marshal(plain(m))
+	}
+	return json.Marshal(struct {
+		plain
+		Content []ContentPart `json:"content"`
+	}{plain(m), m.Parts})
+}
+
+// ContentPart is one element of a multimodal content array.
+type ContentPart struct {
+	Type     string    `json:"type"` // "text" | "image_url"
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+type ImageURL struct {
+	URL string `json:"url"` // https:// or data: URI
 }
 
 type Tool struct {
@@ -96,6 +128,7 @@ type StreamChoice struct {
 type StreamDelta struct {
 	Role      string     `json:"role,omitempty"`
 	Content   string     `json:"content,omitempty"`
+	Refusal   string     `json:"refusal,omitempty"`
 	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
 }
 
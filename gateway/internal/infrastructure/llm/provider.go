@@ -3,6 +3,7 @@ package llm
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"sync"
 
 	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
@@ -30,11 +31,37 @@ type Provider interface {
 // ProviderConfig holds configuration for an LLM provider.
 type ProviderConfig struct {
 	Name     string   `json:"name"`
-	Type     string   `json:"type"`      // "openai" (default) | "anthropic" | "gemini"
+	Type     string   `json:"type"`      // "openai" (default) | "anthropic" | "gemini" | "llamacpp"
 	BaseURL  string   `json:"base_url"`
 	APIKey   string   `json:"api_key"`
 	Models   []string `json:"models"`
 	Priority int      `json:"priority"` // Lower = higher priority
+
+	// Network: proxy / CA bundle / TLS settings for this provider only
+	Network NetworkConfig `json:"network"`
+	// Llama: in-process GGUF model settings (Type "llamacpp" only)
+	Llama LlamaConfig `json:"llama"`
+	// Transport is built from Network by CreateProvider
+	Transport *http.Transport `json:"-"`
+}
+
+// LlamaConfig configures a GGUF model loaded in-process through llama.cpp.
+type LlamaConfig struct {
+	ModelPath   string `json:"model_path"`
+	ContextSize int    `json:"context_size"` // tokens, 0 = 4096
+	GPULayers   int    `json:"gpu_layers"`   // layers offloaded to the GPU, 0 = CPU only, -1 = all
+	Threads     int    `json:"threads"`      // 0 = number of CPUs
+}
+
+// HTTPTransport returns the transport prepared by CreateProvider, or a
+// default one (environment proxy, system roots) when the provider is
+// constructed directly.
+func (c ProviderConfig) HTTPTransport() *http.Transport {
+	if c.Transport != nil {
+		return c.Transport
+	}
+	t, _ := NewTransport(NetworkConfig{})
+	return t
 }
 
 // --- Provider Factory Registry ---
@@ -79,5 +106,13 @@ func CreateProvider(cfg ProviderConfig, logger *zap.Logger) (Provider, error) {
 		return nil, fmt.Errorf("unknown provider type %q (available: %v)", t, available)
 	}
 
+	if cfg.Transport == nil {
+		transport, err := NewTransport(cfg.Network)
+		if err != nil {
+			return nil, fmt.Errorf("provider %s: %w", cfg.Name, err)
+		}
+		cfg.Transport = transport
+	}
+
 	return factory(cfg, logger), nil
 }
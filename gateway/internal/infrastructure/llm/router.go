@@ -2,10 +2,12 @@ package llm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
 	"go.uber.org/zap"
 )
@@ -17,22 +19,41 @@ type Router struct {
 	providers []Provider
 	stats     map[string]*providerStats   // provider name → stats
 	breakers  map[string]*CircuitBreaker // provider name → circuit breaker
+	tracker   *ModelStatsTracker         // optional per provider+model stats
+	backoff   *Backoff                   // Retry-After windows shared by all runs
+	stream    StreamThresholds           // slow first token / stall detection
+	onCircuit CircuitListener            // optional, told when a circuit opens or closes
+	allow     func(model string) error   // optional, refuses requests to blocked models
 	mu        sync.RWMutex
 	logger    *zap.Logger
 }
 
+// congestionWindow is how long a provider that streamed slowly is tried
+// after its alternatives.
+const congestionWindow = 2 * time.Minute
+
 // providerStats tracks per-provider performance metrics.
 type providerStats struct {
-	TotalCalls   int64
-	FailureCount int64
-	LastLatency  time.Duration
+	TotalCalls     int64
+	FailureCount   int64
+	LastLatency    time.Duration
+	LastFirstToken time.Duration
+	SlowStarts     int64
+	Stalls         int64
+	CongestedUntil time.Time // set by a slow start or stall
 }
 
+// CircuitListener is told when a provider's circuit opens (it failed
+// repeatedly and is skipped; err is the last failure) or closes again after
+// a successful probe.
+type CircuitListener func(provider string, state CircuitState, err error)
+
 // NewRouter creates a new LLM router
 func NewRouter(logger *zap.Logger) *Router {
 	return &Router{
 		stats:    make(map[string]*providerStats),
 		breakers: make(map[string]*CircuitBreaker),
+		backoff:  NewBackoff(),
 		logger:   logger.With(zap.String("component", "llm-router")),
 	}
 }
@@ -40,6 +61,49 @@ func NewRouter(logger *zap.Logger) *Router {
 // Compile-time interface check: Router implements service.LLMClient
 var _ service.LLMClient = (*Router)(nil)
 
+// SetStatsTracker enables per provider+model request/latency/token tracking.
+func (r *Router) SetStatsTracker(t *ModelStatsTracker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tracker = t
+}
+
+// SetStreamThresholds sets when a streamed call counts as slow. Slow calls
+// send a Slow chunk to the run and push the provider behind its alternatives
+// for a while.
+func (r *Router) SetStreamThresholds(t StreamThresholds) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stream = t
+}
+
+// SetCircuitListener registers fn for provider outages and recoveries.
+func (r *Router) SetCircuitListener(fn CircuitListener) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onCircuit = fn
+}
+
+// SetModelFilter installs fn to vet every request's model before it is
+// routed; a non-nil error refuses the request (e.g. a model blocked by the
+// organization policy).
+func (r *Router) SetModelFilter(fn func(model string) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.allow = fn
+}
+
+// checkModel applies the model filter.
+func (r *Router) checkModel(model string) error {
+	r.mu.RLock()
+	allow := r.allow
+	r.mu.RUnlock()
+	if allow == nil {
+		return nil
+	}
+	return allow(model)
+}
+
 // AddProvider adds a provider to the router.
 // Providers are tried in insertion order (higher priority first, then fallback).
 func (r *Router) AddProvider(p Provider) {
@@ -57,10 +121,10 @@ func (r *Router) AddProvider(p Provider) {
 // Generate implements service.LLMClient.
 // It routes to the first available provider that supports the requested model.
 func (r *Router) Generate(ctx context.Context, req *service.LLMRequest) (*service.LLMResponse, error) {
-	r.mu.RLock()
-	providers := make([]Provider, len(r.providers))
-	copy(providers, r.providers)
-	r.mu.RUnlock()
+	if err := r.checkModel(req.Model); err != nil {
+		return nil, err
+	}
+	providers, _ := r.candidates(req.Model)
 
 	var lastErr error
 
@@ -84,6 +148,10 @@ func (r *Router) Generate(ctx context.Context, req *service.LLMRequest) (*servic
 			continue
 		}
 
+		if err := r.backoff.Wait(ctx, p.Name(), nil); err != nil {
+			return nil, err
+		}
+
 		r.logger.Debug("Routing to provider",
 			zap.String("provider", p.Name()),
 			zap.String("model", req.Model),
@@ -93,20 +161,11 @@ func (r *Router) Generate(ctx context.Context, req *service.LLMRequest) (*servic
 		resp, err := p.Generate(ctx, req)
 		latency := time.Since(start)
 
-		r.mu.Lock()
-		if s, ok := r.stats[p.Name()]; ok {
-			s.TotalCalls++
-			s.LastLatency = latency
-			if err != nil {
-				s.FailureCount++
-			}
-		}
-		r.mu.Unlock()
+		r.recordCall(p.Name(), req.Model, latency, resp, err)
 
 		if err != nil {
-			if cb, ok := r.breakers[p.Name()]; ok {
-				cb.RecordFailure()
-			}
+			r.noteRateLimit(p.Name(), err)
+			r.recordBreaker(p.Name(), err)
 			lastErr = err
 			r.logger.Warn("Provider failed, trying next",
 				zap.String("provider", p.Name()),
@@ -116,9 +175,7 @@ func (r *Router) Generate(ctx context.Context, req *service.LLMRequest) (*servic
 			continue
 		}
 
-		if cb, ok := r.breakers[p.Name()]; ok {
-			cb.RecordSuccess()
-		}
+		r.recordBreaker(p.Name(), nil)
 
 		r.logger.Debug("Provider succeeded",
 			zap.String("provider", p.Name()),
@@ -139,10 +196,10 @@ func (r *Router) Generate(ctx context.Context, req *service.LLMRequest) (*servic
 // GenerateStream implements service.LLMClient.
 // Routes to the first available streaming-capable provider.
 func (r *Router) GenerateStream(ctx context.Context, req *service.LLMRequest, deltaCh chan<- service.StreamChunk) (*service.LLMResponse, error) {
-	r.mu.RLock()
-	providers := make([]Provider, len(r.providers))
-	copy(providers, r.providers)
-	r.mu.RUnlock()
+	if err := r.checkModel(req.Model); err != nil {
+		return nil, err
+	}
+	providers, thresholds := r.candidates(req.Model)
 
 	var lastErr error
 
@@ -163,29 +220,39 @@ func (r *Router) GenerateStream(ctx context.Context, req *service.LLMRequest, de
 			continue
 		}
 
+		// Queue positions go out as stream chunks so the run can show them
+		if err := r.backoff.Wait(ctx, p.Name(), func(q entity.QueueInfo) {
+			select {
+			case deltaCh <- service.StreamChunk{Queue: &q}:
+			case <-ctx.Done():
+			}
+		}); err != nil {
+			return nil, err
+		}
+
 		r.logger.Debug("Streaming via provider",
 			zap.String("provider", p.Name()),
 			zap.String("model", req.Model),
 		)
 
+		// Providers write to in; the watch forwards to deltaCh and adds a
+		// Slow chunk whenever the stream goes quiet for too long.
+		in := make(chan service.StreamChunk, 16)
+		watch := &streamWatch{provider: p.Name(), model: req.Model, thresholds: thresholds, out: deltaCh}
+		done := watch.run(ctx, in)
+
 		start := time.Now()
-		resp, err := p.GenerateStream(ctx, req, deltaCh)
+		resp, err := p.GenerateStream(ctx, req, in)
+		close(in)
+		<-done
 		latency := time.Since(start)
 
-		r.mu.Lock()
-		if s, ok := r.stats[p.Name()]; ok {
-			s.TotalCalls++
-			s.LastLatency = latency
-			if err != nil {
-				s.FailureCount++
-			}
-		}
-		r.mu.Unlock()
+		r.recordCall(p.Name(), req.Model, latency, resp, err)
+		r.recordStream(p.Name(), req.Model, watch.stats)
 
 		if err != nil {
-			if cb, ok := r.breakers[p.Name()]; ok {
-				cb.RecordFailure()
-			}
+			r.noteRateLimit(p.Name(), err)
+			r.recordBreaker(p.Name(), err)
 			lastErr = err
 			r.logger.Warn("Streaming provider failed, trying next",
 				zap.String("provider", p.Name()),
@@ -195,9 +262,7 @@ func (r *Router) GenerateStream(ctx context.Context, req *service.LLMRequest, de
 			continue
 		}
 
-		if cb, ok := r.breakers[p.Name()]; ok {
-			cb.RecordSuccess()
-		}
+		r.recordBreaker(p.Name(), nil)
 
 		return resp, nil
 	}
@@ -209,6 +274,147 @@ func (r *Router) GenerateStream(ctx context.Context, req *service.LLMRequest, de
 	return nil, fmt.Errorf("no streaming provider available for model '%s'", req.Model)
 }
 
+// candidates returns the providers in try order: congested providers that
+// serve model go after the ones that are not.
+func (r *Router) candidates(model string) ([]Provider, StreamThresholds) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	ordered := make([]Provider, 0, len(r.providers))
+	var congested []Provider
+	for _, p := range r.providers {
+		if s, ok := r.stats[p.Name()]; ok && p.SupportsModel(model) && now.Before(s.CongestedUntil) {
+			congested = append(congested, p)
+			continue
+		}
+		ordered = append(ordered, p)
+	}
+	if len(congested) > 0 && len(ordered) > 0 {
+		r.logger.Debug("Trying congested providers last",
+			zap.String("model", model),
+			zap.Int("congested", len(congested)),
+		)
+	}
+	return append(ordered, congested...), r.stream
+}
+
+// Probe sends a minimal 1-token request to model and reports the round-trip
+// latency. The call goes through normal routing, so it also feeds the stats.
+func (r *Router) Probe(ctx context.Context, model string) (time.Duration, error) {
+	start := time.Now()
+	_, err := r.Generate(ctx, &service.LLMRequest{
+		Model:     model,
+		MaxTokens: 1,
+		Messages:  []service.LLMMessage{{Role: "user", Content: "ping"}},
+	})
+	return time.Since(start), err
+}
+
+// noteRateLimit pauses every call to provider when err is a 429 that came
+// with a Retry-After hint.
+func (r *Router) noteRateLimit(provider string, err error) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.RateLimited() || apiErr.RetryAfter <= 0 {
+		return
+	}
+	r.backoff.Trip(provider, apiErr.RetryAfter)
+	r.logger.Warn("Provider rate limited, pausing all calls",
+		zap.String("provider", provider),
+		zap.Duration("retry_after", apiErr.RetryAfter),
+	)
+}
+
+// recordCall updates provider stats and the optional model stats tracker.
+func (r *Router) recordCall(provider, model string, latency time.Duration, resp *service.LLMResponse, err error) {
+	r.mu.Lock()
+	if s, ok := r.stats[provider]; ok {
+		s.TotalCalls++
+		s.LastLatency = latency
+		if err != nil {
+			s.FailureCount++
+		}
+	}
+	tracker := r.tracker
+	r.mu.Unlock()
+
+	if tracker != nil {
+		tokens := 0
+		if err == nil && resp != nil {
+			tokens = resp.TokensUsed
+		}
+		tracker.Record(provider, model, latency, tokens, err)
+	}
+}
+
+// recordStream updates first-token and stall stats after a streamed call
+// and marks the provider congested when it was slow.
+func (r *Router) recordStream(provider, model string, st streamStats) {
+	r.mu.Lock()
+	if s, ok := r.stats[provider]; ok {
+		if st.FirstToken > 0 {
+			s.LastFirstToken = st.FirstToken
+		}
+		if st.SlowStart {
+			s.SlowStarts++
+		}
+		s.Stalls += int64(st.Stalls)
+		switch {
+		case st.slow():
+			s.CongestedUntil = time.Now().Add(congestionWindow)
+		case st.FirstToken > 0:
+			s.CongestedUntil = time.Time{} // a prompt answer clears it early
+		}
+	}
+	tracker := r.tracker
+	r.mu.Unlock()
+
+	if st.slow() {
+		r.logger.Warn("Provider streaming slowly",
+			zap.String("provider", provider),
+			zap.String("model", model),
+			zap.Duration("first_token", st.FirstToken),
+			zap.Bool("slow_start", st.SlowStart),
+			zap.Int("stalls", st.Stalls),
+		)
+	}
+	if tracker != nil {
+		tracker.RecordStream(provider, model, st.FirstToken, st.SlowStart, st.Stalls)
+	}
+}
+
+// recordBreaker feeds a call's outcome to the provider's circuit breaker and
+// reports outages (closed → open) and recoveries (→ closed) to the listener.
+// A failed half-open probe re-opens the circuit without a second report.
+func (r *Router) recordBreaker(provider string, err error) {
+	cb, ok := r.breakers[provider]
+	if !ok {
+		return
+	}
+	before := cb.State()
+	if err != nil {
+		cb.RecordFailure()
+	} else {
+		cb.RecordSuccess()
+	}
+	after := cb.State()
+	if !(before == CircuitClosed && after == CircuitOpen) && !(before != CircuitClosed && after == CircuitClosed) {
+		return
+	}
+
+	r.mu.RLock()
+	fn := r.onCircuit
+	r.mu.RUnlock()
+	if after == CircuitOpen {
+		r.logger.Warn("Provider circuit opened", zap.String("provider", provider), zap.Error(err))
+	} else {
+		r.logger.Info("Provider circuit closed", zap.String("provider", provider))
+	}
+	if fn != nil {
+		fn(provider, after, err)
+	}
+}
+
 // ListProviders returns names, status, and performance stats of all registered providers
 func (r *Router) ListProviders(ctx context.Context) []ProviderStatus {
 	r.mu.RLock()
@@ -225,10 +431,17 @@ func (r *Router) ListProviders(ctx context.Context) []ProviderStatus {
 			ps.TotalCalls = s.TotalCalls
 			ps.FailureCount = s.FailureCount
 			ps.LastLatencyMs = float64(s.LastLatency) / float64(time.Millisecond)
+			ps.FirstTokenMs = float64(s.LastFirstToken) / float64(time.Millisecond)
+			ps.SlowStarts = s.SlowStarts
+			ps.Stalls = s.Stalls
+			ps.Congested = time.Now().Before(s.CongestedUntil)
 		}
 		if cb, ok := r.breakers[p.Name()]; ok {
 			ps.CircuitState = cb.State().String()
 		}
+		if until := r.backoff.Until(p.Name()); !until.IsZero() {
+			ps.RetryAfterSec = time.Until(until).Seconds()
+		}
 		result = append(result, ps)
 	}
 	return result
@@ -242,5 +455,10 @@ type ProviderStatus struct {
 	TotalCalls    int64    `json:"total_calls"`
 	FailureCount  int64    `json:"failure_count"`
 	LastLatencyMs float64  `json:"last_latency_ms"`
+	FirstTokenMs  float64  `json:"first_token_ms"` // last streamed call; 0 = none yet
+	SlowStarts    int64    `json:"slow_starts"`
+	Stalls        int64    `json:"stalls"`
+	Congested     bool     `json:"congested,omitempty"` // tried after alternatives for now
 	CircuitState  string   `json:"circuit_state"`
+	RetryAfterSec float64  `json:"retry_after_sec,omitempty"` // rate-limit window left; 0 = not paused
 }
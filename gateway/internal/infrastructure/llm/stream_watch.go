@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"context"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+)
+
+// streamWatchTick is how often a watched stream is checked for silence.
+var streamWatchTick = time.Second
+
+// StreamThresholds bound how long a stream may stay silent before the run is
+// told the model is slow. Zero disables the respective check.
+type StreamThresholds struct {
+	SlowFirstToken time.Duration // request sent, no output yet
+	Stall          time.Duration // output started, then paused
+}
+
+// streamStats is the first-token latency and stall count of one streamed call.
+type streamStats struct {
+	FirstToken time.Duration // 0 = no output at all
+	SlowStart  bool
+	Stalls     int
+}
+
+// slow reports whether the call hit either threshold.
+func (s streamStats) slow() bool {
+	return s.SlowStart || s.Stalls > 0
+}
+
+// streamWatch sits between a provider and the run's delta channel. It times
+// the first output and gaps between outputs, and once per silent stretch
+// sends a Slow chunk so the UI can say "model is slow / provider congested"
+// instead of showing nothing.
+type streamWatch struct {
+	provider   string
+	model      string
+	thresholds StreamThresholds
+	out        chan<- service.StreamChunk
+
+	start time.Time
+	stats streamStats
+}
+
+// run forwards in to out until in is closed. The returned channel is closed
+// once forwarding has finished; w.stats is final after that.
+func (w *streamWatch) run(ctx context.Context, in <-chan service.StreamChunk) <-chan struct{} {
+	done := make(chan struct{})
+	w.start = time.Now()
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(streamWatchTick)
+		defer ticker.Stop()
+		last := w.start
+		warned := false // already reported the current silence
+		for {
+			select {
+			case chunk, ok := <-in:
+				if !ok {
+					return
+				}
+				if chunk.DeltaText != "" || chunk.DeltaToolCall != nil || chunk.FinishReason != "" {
+					now := time.Now()
+					if w.stats.FirstToken == 0 {
+						w.stats.FirstToken = now.Sub(w.start)
+					}
+					last = now
+					warned = false
+				}
+				w.send(ctx, chunk)
+			case now := <-ticker.C:
+				if warned {
+					continue
+				}
+				silent := now.Sub(last)
+				first := w.stats.FirstToken == 0
+				switch {
+				case first && w.thresholds.SlowFirstToken > 0 && silent >= w.thresholds.SlowFirstToken:
+					w.stats.SlowStart = true
+				case !first && w.thresholds.Stall > 0 && silent >= w.thresholds.Stall:
+					w.stats.Stalls++
+				default:
+					continue
+				}
+				warned = true
+				w.send(ctx, service.StreamChunk{Slow: &entity.SlowInfo{
+					Provider: w.provider,
+					Model:    w.model,
+					Waited:   silent.Round(time.Second),
+					Stalled:  !first,
+				}})
+			}
+		}
+	}()
+	return done
+}
+
+func (w *streamWatch) send(ctx context.Context, chunk service.StreamChunk) {
+	select {
+	case w.out <- chunk:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,120 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"go.uber.org/zap"
+)
+
+// slowProvider streams "hi" after delay, then "there" after pause.
+type slowProvider struct {
+	name         string
+	delay, pause time.Duration
+}
+
+func (p *slowProvider) Name() string                     { return p.name }
+func (p *slowProvider) Models() []string                 { return []string{"m"} }
+func (p *slowProvider) SupportsModel(string) bool        { return true }
+func (p *slowProvider) IsAvailable(context.Context) bool { return true }
+
+func (p *slowProvider) Generate(ctx context.Context, req *service.LLMRequest) (*service.LLMResponse, error) {
+	return &service.LLMResponse{Content: "hi there", ModelUsed: req.Model}, nil
+}
+
+func (p *slowProvider) GenerateStream(ctx context.Context, req *service.LLMRequest, deltaCh chan<- service.StreamChunk) (*service.LLMResponse, error) {
+	time.Sleep(p.delay)
+	deltaCh <- service.StreamChunk{DeltaText: "hi "}
+	time.Sleep(p.pause)
+	deltaCh <- service.StreamChunk{DeltaText: "there", FinishReason: "stop"}
+	return &service.LLMResponse{Content: "hi there", ModelUsed: req.Model}, nil
+}
+
+func withWatchTick(t *testing.T, d time.Duration) {
+	old := streamWatchTick
+	streamWatchTick = d
+	t.Cleanup(func() { streamWatchTick = old })
+}
+
+func collect(ch chan service.StreamChunk) []service.StreamChunk {
+	close(ch)
+	var chunks []service.StreamChunk
+	for c := range ch {
+		chunks = append(chunks, c)
+	}
+	return chunks
+}
+
+func TestRouter_SlowFirstTokenNotifiesAndDemotes(t *testing.T) {
+	withWatchTick(t, 5*time.Millisecond)
+	r := NewRouter(zap.NewNop())
+	tracker := NewModelStatsTracker(nil, zap.NewNop())
+	r.SetStatsTracker(tracker)
+	r.SetStreamThresholds(StreamThresholds{SlowFirstToken: 30 * time.Millisecond, Stall: time.Minute})
+	r.AddProvider(&slowProvider{name: "slow", delay: 100 * time.Millisecond})
+	r.AddProvider(&slowProvider{name: "fast"})
+
+	deltaCh := make(chan service.StreamChunk, 16)
+	if _, err := r.GenerateStream(context.Background(), &service.LLMRequest{Model: "m"}, deltaCh); err != nil {
+		t.Fatal(err)
+	}
+	chunks := collect(deltaCh)
+	if len(chunks) != 3 {
+		t.Fatalf("chunks = %+v, want Slow + 2 deltas", chunks)
+	}
+	slow := chunks[0].Slow
+	if slow == nil || slow.Provider != "slow" || slow.Stalled {
+		t.Errorf("first chunk = %+v, want a slow start notice", chunks[0])
+	}
+	if chunks[1].DeltaText != "hi " || chunks[2].DeltaText != "there" {
+		t.Errorf("deltas out of order: %+v", chunks[1:])
+	}
+
+	st := r.ListProviders(context.Background())
+	if !st[0].Congested || st[0].SlowStarts != 1 || st[0].FirstTokenMs < 100 {
+		t.Errorf("slow provider status = %+v", st[0])
+	}
+	if snap := tracker.Snapshot(); len(snap) != 1 || snap[0].SlowStarts != 1 || snap[0].FirstTokenP50Ms < 100 {
+		t.Errorf("model stats = %+v", snap)
+	}
+
+	// the congested provider is now tried after the healthy one
+	deltaCh = make(chan service.StreamChunk, 16)
+	if _, err := r.GenerateStream(context.Background(), &service.LLMRequest{Model: "m"}, deltaCh); err != nil {
+		t.Fatal(err)
+	}
+	collect(deltaCh)
+	st = r.ListProviders(context.Background())
+	if st[0].TotalCalls != 1 || st[1].TotalCalls != 1 {
+		t.Errorf("calls = %d / %d, want the fast provider to take the second call", st[0].TotalCalls, st[1].TotalCalls)
+	}
+}
+
+func TestRouter_StallNotifiesOnce(t *testing.T) {
+	withWatchTick(t, 5*time.Millisecond)
+	r := NewRouter(zap.NewNop())
+	r.SetStreamThresholds(StreamThresholds{SlowFirstToken: time.Minute, Stall: 20 * time.Millisecond})
+	r.AddProvider(&slowProvider{name: "p", pause: 100 * time.Millisecond})
+
+	deltaCh := make(chan service.StreamChunk, 16)
+	if _, err := r.GenerateStream(context.Background(), &service.LLMRequest{Model: "m"}, deltaCh); err != nil {
+		t.Fatal(err)
+	}
+	var slows int
+	for _, c := range collect(deltaCh) {
+		if c.Slow != nil {
+			slows++
+			if !c.Slow.Stalled {
+				t.Errorf("slow chunk = %+v, want a stall", c.Slow)
+			}
+		}
+	}
+	if slows != 1 {
+		t.Errorf("got %d slow notices for one pause, want 1", slows)
+	}
+	if st := r.ListProviders(context.Background()); st[0].Stalls != 1 || st[0].SlowStarts != 0 {
+		t.Errorf("status = %+v", st[0])
+	}
+}
@@ -0,0 +1,113 @@
+package llm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// NetworkConfig holds per-provider outbound network settings.
+type NetworkConfig struct {
+	// Proxy for this provider:
+	//   ""                 — honor HTTPS_PROXY / HTTP_PROXY / NO_PROXY
+	//   "direct" | "none"  — always connect directly (e.g. local Ollama)
+	//   http://, https://, socks5://, socks5h:// URL — explicit proxy
+	Proxy string `json:"proxy,omitempty"`
+	// CAFile is a PEM bundle trusted in addition to the system roots
+	// (corporate TLS-inspecting proxies, self-signed vLLM endpoints).
+	CAFile string `json:"ca_file,omitempty"`
+	// InsecureSkipVerify disables certificate verification. Testing only.
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
+}
+
+// proxySchemes are the proxy URL schemes net/http can dial.
+var proxySchemes = map[string]bool{"http": true, "https": true, "socks5": true, "socks5h": true}
+
+// NewTransport builds the HTTP transport shared by all provider types,
+// applying the proxy, CA bundle and TLS settings from network.
+func NewTransport(network NetworkConfig) (*http.Transport, error) {
+	proxy, err := proxyFunc(network.Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsCfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: network.InsecureSkipVerify,
+	}
+	if network.CAFile != "" {
+		pool, err := loadCAFile(network.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	return &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   15 * time.Second,
+		ResponseHeaderTimeout: 300 * time.Second,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConns:          10,
+		MaxIdleConnsPerHost:   5,
+		TLSClientConfig:       tlsCfg,
+	}, nil
+}
+
+func proxyFunc(raw string) (func(*http.Request) (*url.URL, error), error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case "direct", "none":
+		return nil, nil
+	}
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" || !proxySchemes[u.Scheme] {
+		return nil, fmt.Errorf("invalid proxy %q (want http://, https://, socks5:// or socks5h:// URL, or \"direct\")", redactURL(raw))
+	}
+	return http.ProxyURL(u), nil
+}
+
+func loadCAFile(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read ca_file: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("ca_file %s: no PEM certificates found", path)
+	}
+	return pool, nil
+}
+
+// redactURL hides the password of a proxy URL for logs and errors.
+func redactURL(raw string) string {
+	if u, err := url.Parse(raw); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return raw
+}
+
+// ProxyLabel describes the effective proxy setting for logs.
+func (n NetworkConfig) ProxyLabel() string {
+	switch strings.ToLower(strings.TrimSpace(n.Proxy)) {
+	case "":
+		return "env"
+	case "direct", "none":
+		return "direct"
+	}
+	return redactURL(n.Proxy)
+}
@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestNewTransport_ProxyModes(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "http://env-proxy:8080")
+	req, _ := http.NewRequest("GET", "https://api.openai.com/v1/models", nil)
+
+	cases := []struct {
+		proxy string
+		want  string // "" = direct
+	}{
+		{"", "http://env-proxy:8080"},
+		{"direct", ""},
+		{"none", ""},
+		{"socks5://127.0.0.1:1080", "socks5://127.0.0.1:1080"},
+		{"http://user:pw@corp:3128", "http://user:pw@corp:3128"},
+	}
+	for _, c := range cases {
+		tr, err := NewTransport(NetworkConfig{Proxy: c.proxy})
+		if err != nil {
+			t.Fatalf("%q: %v", c.proxy, err)
+		}
+		got := ""
+		if tr.Proxy != nil {
+			u, err := tr.Proxy(req)
+			if err != nil {
+				t.Fatalf("%q: %v", c.proxy, err)
+			}
+			if u != nil {
+				got = u.String()
+			}
+		}
+		if got != c.want {
+			t.Errorf("proxy %q: got %q, want %q", c.proxy, got, c.want)
+		}
+	}
+
+	for _, bad := range []string{"ftp://x:21", "proxy:3128", "http://"} {
+		if _, err := NewTransport(NetworkConfig{Proxy: bad}); err == nil {
+			t.Errorf("proxy %q: expected error", bad)
+		}
+	}
+	_, err := NewTransport(NetworkConfig{Proxy: "gopher://u:secret@x"})
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("proxy password leaked or no error: %v", err)
+	}
+}
+
+func TestNewTransport_RoutesThroughProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		io.WriteString(w, "ok")
+	}))
+	defer proxy.Close()
+
+	tr, err := NewTransport(NetworkConfig{Proxy: proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: tr}).Get("http://llm.internal/v1/models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if proxied != "http://llm.internal/v1/models" {
+		t.Fatalf("request not sent via proxy: %q", proxied)
+	}
+}
+
+func TestNewTransport_CAFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	// System roots only: self-signed server is rejected
+	tr, _ := NewTransport(NetworkConfig{Proxy: "direct"})
+	if _, err := (&http.Client{Transport: tr}).Get(srv.URL); err == nil {
+		t.Fatal("expected certificate error without ca_file")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, pemBytes, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tr, err := NewTransport(NetworkConfig{Proxy: "direct", CAFile: caFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("ca_file not trusted: %v", err)
+	}
+	resp.Body.Close()
+
+	tr, _ = NewTransport(NetworkConfig{Proxy: "direct", InsecureSkipVerify: true})
+	resp, err = (&http.Client{Transport: tr}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("insecure_skip_verify: %v", err)
+	}
+	resp.Body.Close()
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	os.WriteFile(empty, []byte("not a cert"), 0o600)
+	if _, err := NewTransport(NetworkConfig{CAFile: empty}); err == nil {
+		t.Error("expected error for ca_file without certificates")
+	}
+	if _, err := NewTransport(NetworkConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Error("expected error for missing ca_file")
+	}
+}
+
+func TestCreateProvider_InvalidNetwork(t *testing.T) {
+	var got ProviderConfig
+	RegisterFactory("transport-test", func(cfg ProviderConfig, _ *zap.Logger) Provider {
+		got = cfg
+		return nil
+	})
+	if _, err := CreateProvider(ProviderConfig{Name: "p", Type: "transport-test", Network: NetworkConfig{Proxy: "ftp://x"}}, nil); err == nil {
+		t.Fatal("expected error for invalid proxy")
+	}
+	if _, err := CreateProvider(ProviderConfig{Name: "p", Type: "transport-test", Network: NetworkConfig{Proxy: "direct"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got.Transport == nil || got.Transport.Proxy != nil {
+		t.Fatal("factory should receive the prepared direct transport")
+	}
+}
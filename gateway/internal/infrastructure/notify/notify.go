@@ -0,0 +1,234 @@
+// Package notify delivers gateway events (finished runs, cron results,
+// budget alerts) to the outbound targets configured in notifications.targets:
+// email over SMTP, generic webhooks, ntfy and Pushover.
+//
+// Every target renders its title and body from Go text/template strings
+// over an Event, so the payload can be shaped for the receiving system:
+//
+//	body: '{"text": {{json .Title}}, "detail": {{json (truncate 500 .Text)}}}'
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+// Event types a target can subscribe to.
+const (
+	EventRunCompleted    = "run.completed"
+	EventRunFailed       = "run.failed"
+	EventCronResult      = "cron.result"
+	EventBudgetExhausted = "budget.exhausted"
+)
+
+var eventTypes = map[string]bool{
+	EventRunCompleted:    true,
+	EventRunFailed:       true,
+	EventCronResult:      true,
+	EventBudgetExhausted: true,
+}
+
+// defaultTimeout bounds a delivery to a target without a timeout.
+const defaultTimeout = 10 * time.Second
+
+// Event is what is sent to the targets, and the data of their templates.
+type Event struct {
+	Type    string    `json:"type"`
+	Title   string    `json:"title"`
+	Text    string    `json:"text"`
+	Source  string    `json:"source,omitempty"`
+	ChatID  int64     `json:"chat_id,omitempty"`
+	Model   string    `json:"model,omitempty"`
+	Tokens  int       `json:"tokens,omitempty"`
+	Steps   int       `json:"steps,omitempty"`
+	Command string    `json:"command,omitempty"`
+	Error   string    `json:"error,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// message is an event rendered for one target.
+type message struct {
+	Title string
+	Body  string
+}
+
+// sender delivers a rendered message.
+type sender interface {
+	send(ctx context.Context, ev Event, msg message) error
+}
+
+type target struct {
+	name    string
+	events  map[string]bool // nil = all
+	title   *template.Template
+	body    *template.Template
+	timeout time.Duration
+	sender  sender
+}
+
+// Notifier fans events out to the configured targets. Deliveries run in the
+// background, so a slow or unreachable target never delays a run.
+type Notifier struct {
+	targets []*target
+	logger  *zap.Logger
+	wg      sync.WaitGroup
+}
+
+var funcs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"truncate": func(n int, s string) string {
+		if r := []rune(s); len(r) > n {
+			return string(r[:n]) + "…"
+		}
+		return s
+	},
+}
+
+// New creates the notifier for the notifications config. It returns nil
+// when no targets are configured, and an error for the first invalid entry.
+func New(cfg config.NotificationsConfig, logger *zap.Logger) (*Notifier, error) {
+	if len(cfg.Targets) == 0 {
+		return nil, nil
+	}
+	n := &Notifier{logger: logger}
+	for i, tc := range cfg.Targets {
+		if tc.Name == "" {
+			tc.Name = tc.Type
+		}
+		t, err := newTarget(tc)
+		if err != nil {
+			return nil, fmt.Errorf("notification target #%d (%s): %w", i+1, tc.Name, err)
+		}
+		n.targets = append(n.targets, t)
+	}
+	return n, nil
+}
+
+func newTarget(cfg config.NotifyTargetConfig) (*target, error) {
+	t := &target{name: cfg.Name, timeout: cfg.Timeout}
+	if t.timeout <= 0 {
+		t.timeout = defaultTimeout
+	}
+	for _, e := range cfg.Events {
+		if !eventTypes[e] {
+			return nil, fmt.Errorf("unknown event %q", e)
+		}
+		if t.events == nil {
+			t.events = make(map[string]bool)
+		}
+		t.events[e] = true
+	}
+	var err error
+	if t.title, err = parseTemplate("title", cfg.Title, "{{.Title}}"); err != nil {
+		return nil, err
+	}
+	if t.body, err = parseTemplate("body", cfg.Body, defaultBody(cfg.Type)); err != nil {
+		return nil, err
+	}
+	switch cfg.Type {
+	case "email":
+		t.sender, err = newEmail(cfg)
+	case "webhook":
+		t.sender, err = newWebhook(cfg)
+	case "ntfy":
+		t.sender, err = newNtfy(cfg)
+	case "pushover":
+		t.sender, err = newPushover(cfg)
+	default:
+		err = fmt.Errorf("unknown type %q (want email, webhook, ntfy or pushover)", cfg.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func parseTemplate(name, text, fallback string) (*template.Template, error) {
+	if text == "" {
+		text = fallback
+	}
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// defaultBody is the body of a target without a body template: the event
+// as JSON for webhooks, its text for the others.
+func defaultBody(typeName string) string {
+	if typeName == "webhook" {
+		return "{{json .}}"
+	}
+	return "{{.Text}}"
+}
+
+func render(tmpl *template.Template, ev Event) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, ev); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Notify sends ev to the targets subscribed to its type. It returns
+// immediately; failures are logged.
+func (n *Notifier) Notify(ctx context.Context, ev Event) {
+	if n == nil {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	ctx = context.WithoutCancel(ctx)
+	for _, t := range n.targets {
+		if t.events != nil && !t.events[ev.Type] {
+			continue
+		}
+		n.wg.Add(1)
+		go func(t *target) {
+			defer n.wg.Done()
+			if err := t.deliver(ctx, ev); err != nil {
+				n.logger.Warn("Notification failed",
+					zap.String("target", t.name),
+					zap.String("event", ev.Type),
+					zap.Error(err),
+				)
+			}
+		}(t)
+	}
+}
+
+func (t *target) deliver(ctx context.Context, ev Event) error {
+	var msg message
+	var err error
+	if msg.Title, err = render(t.title, ev); err != nil {
+		return fmt.Errorf("title template: %w", err)
+	}
+	if msg.Body, err = render(t.body, ev); err != nil {
+		return fmt.Errorf("body template: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.sender.send(ctx, ev, msg)
+}
+
+// Close waits for the deliveries in flight; each is bounded by its
+// target's timeout.
+func (n *Notifier) Close() {
+	if n != nil {
+		n.wg.Wait()
+	}
+}
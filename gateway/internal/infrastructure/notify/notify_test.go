@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+type request struct {
+	path   string
+	header http.Header
+	body   string
+}
+
+func recorder(t *testing.T) (*httptest.Server, func() []request) {
+	var mu sync.Mutex
+	var reqs []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		reqs = append(reqs, request{r.URL.Path, r.Header, string(body)})
+		mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []request {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]request(nil), reqs...)
+	}
+}
+
+func TestNotifierTargets(t *testing.T) {
+	srv, requests := recorder(t)
+	n, err := New(config.NotificationsConfig{Targets: []config.NotifyTargetConfig{
+		{Type: "webhook", URL: srv.URL + "/hook", Headers: map[string]string{"X-Token": "s3cret"}},
+		{Type: "webhook", Name: "slack", URL: srv.URL + "/slack", Events: []string{EventCronResult},
+			Body: `{"text": {{json (printf "%s: %s" .Command (truncate 5 .Text))}}}`},
+		{Type: "ntfy", URL: srv.URL + "/alerts", Token: "tk", Priority: "high", Events: []string{EventBudgetExhausted}},
+		{Type: "pushover", URL: srv.URL + "/pushover", Token: "app", User: "me", Events: []string{EventRunFailed}},
+	}}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	n.Notify(ctx, Event{Type: EventCronResult, Title: "Cron", Text: "all systems nominal", Command: "/status"})
+	n.Notify(ctx, Event{Type: EventBudgetExhausted, Title: "Budget exhausted", Text: "daily tokens 100%"})
+	n.Notify(ctx, Event{Type: EventRunFailed, Title: "Run failed", Text: "boom"})
+	n.Close()
+
+	got := map[string][]request{}
+	for _, r := range requests() {
+		got[r.path] = append(got[r.path], r)
+	}
+	if hook := got["/hook"]; len(hook) != 3 || hook[0].header.Get("X-Token") != "s3cret" ||
+		hook[0].header.Get("Content-Type") != "application/json" || !strings.Contains(hook[0].body+hook[1].body+hook[2].body, `"type":"budget.exhausted"`) {
+		t.Errorf("webhook = %+v", hook)
+	}
+	if slack := got["/slack"]; len(slack) != 1 || slack[0].body != `{"text": "/status: all s…"}` {
+		t.Errorf("templated webhook = %+v", slack)
+	}
+	if alerts := got["/alerts"]; len(alerts) != 1 || alerts[0].body != "daily tokens 100%" ||
+		alerts[0].header.Get("Title") != "Budget exhausted" || alerts[0].header.Get("Priority") != "high" ||
+		alerts[0].header.Get("Authorization") != "Bearer tk" {
+		t.Errorf("ntfy = %+v", alerts)
+	}
+	if po := got["/pushover"]; len(po) != 1 {
+		t.Errorf("pushover = %+v", po)
+	} else if form, _ := url.ParseQuery(po[0].body); form.Get("token") != "app" || form.Get("user") != "me" ||
+		form.Get("title") != "Run failed" || form.Get("message") != "boom" {
+		t.Errorf("pushover form = %v", form)
+	}
+}
+
+func TestNewRejectsInvalidTargets(t *testing.T) {
+	if n, err := New(config.NotificationsConfig{}, zap.NewNop()); n != nil || err != nil {
+		t.Errorf("empty config = %v, %v", n, err)
+	}
+	for _, tc := range []config.NotifyTargetConfig{
+		{Type: "sms"},
+		{Type: "webhook"},
+		{Type: "webhook", URL: "http://x", Events: []string{"run.done"}},
+		{Type: "webhook", URL: "http://x", Body: "{{.Nope"},
+		{Type: "ntfy", URL: "http://x", Priority: "loud"},
+		{Type: "pushover", Token: "t"},
+		{Type: "email", SMTP: config.SMTPConfig{Host: "mail"}},
+	} {
+		if _, err := New(config.NotificationsConfig{Targets: []config.NotifyTargetConfig{tc}}, zap.NewNop()); err == nil {
+			t.Errorf("%+v: want error", tc)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+)
+
+// pushoverURL is the Pushover message API; url in the target overrides it.
+const pushoverURL = "https://api.pushover.net/1/messages.json"
+
+// post sends an HTTP POST and turns non-2xx responses into errors.
+func post(ctx context.Context, rawURL, contentType, body string, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// webhook POSTs the body (by default the event as JSON) to a URL.
+type webhook struct {
+	url     string
+	headers map[string]string
+}
+
+func newWebhook(cfg config.NotifyTargetConfig) (sender, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("webhook requires url")
+	}
+	return &webhook{url: cfg.URL, headers: cfg.Headers}, nil
+}
+
+func (w *webhook) send(ctx context.Context, _ Event, msg message) error {
+	contentType := "application/json"
+	if ct, ok := w.headers["Content-Type"]; ok {
+		contentType = ct
+	}
+	return post(ctx, w.url, contentType, msg.Body, w.headers)
+}
+
+// ntfy publishes to an ntfy topic URL (ntfy.sh or self-hosted).
+type ntfy struct {
+	url      string
+	token    string
+	priority string
+}
+
+func newNtfy(cfg config.NotifyTargetConfig) (sender, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("ntfy requires url (https://ntfy.sh/<topic>)")
+	}
+	switch cfg.Priority {
+	case "", "min", "low", "default", "high", "urgent", "1", "2", "3", "4", "5":
+	default:
+		return nil, fmt.Errorf("invalid ntfy priority %q", cfg.Priority)
+	}
+	return &ntfy{url: cfg.URL, token: cfg.Token, priority: cfg.Priority}, nil
+}
+
+func (n *ntfy) send(ctx context.Context, ev Event, msg message) error {
+	headers := map[string]string{"Title": mime.QEncoding.Encode("utf-8", msg.Title), "Tags": ev.Type}
+	if n.priority != "" {
+		headers["Priority"] = n.priority
+	}
+	if n.token != "" {
+		headers["Authorization"] = "Bearer " + n.token
+	}
+	return post(ctx, n.url, "text/plain; charset=utf-8", msg.Body, headers)
+}
+
+// pushover sends through the Pushover message API.
+type pushover struct {
+	url      string
+	token    string
+	user     string
+	priority string
+}
+
+func newPushover(cfg config.NotifyTargetConfig) (sender, error) {
+	if cfg.Token == "" || cfg.User == "" {
+		return nil, errors.New("pushover requires token and user")
+	}
+	if cfg.Priority != "" {
+		if p, err := strconv.Atoi(cfg.Priority); err != nil || p < -2 || p > 1 {
+			// 2 (emergency) needs retry/expire parameters this sender does not set
+			return nil, fmt.Errorf("invalid pushover priority %q (want -2..1)", cfg.Priority)
+		}
+	}
+	p := &pushover{url: cfg.URL, token: cfg.Token, user: cfg.User, priority: cfg.Priority}
+	if p.url == "" {
+		p.url = pushoverURL
+	}
+	return p, nil
+}
+
+func (p *pushover) send(ctx context.Context, _ Event, msg message) error {
+	form := url.Values{
+		"token":   {p.token},
+		"user":    {p.user},
+		"title":   {msg.Title},
+		"message": {msg.Body},
+	}
+	if p.priority != "" {
+		form.Set("priority", p.priority)
+	}
+	return post(ctx, p.url, "application/x-www-form-urlencoded", form.Encode(), nil)
+}
+
+// email sends a plain-text mail over SMTP.
+type email struct {
+	smtp config.SMTPConfig
+	to   []string
+}
+
+func newEmail(cfg config.NotifyTargetConfig) (sender, error) {
+	if cfg.SMTP.Host == "" || cfg.SMTP.From == "" || len(cfg.To) == 0 {
+		return nil, errors.New("email requires smtp.host, smtp.from and to")
+	}
+	e := &email{smtp: cfg.SMTP, to: cfg.To}
+	if e.smtp.Port == 0 {
+		e.smtp.Port = 587
+	}
+	return e, nil
+}
+
+func (e *email) send(ctx context.Context, ev Event, msg message) error {
+	addr := net.JoinHostPort(e.smtp.Host, strconv.Itoa(e.smtp.Port))
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	tlsConfig := &tls.Config{ServerName: e.smtp.Host}
+	if e.smtp.Port == 465 {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	c, err := smtp.NewClient(conn, e.smtp.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok && e.smtp.Port != 465 {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if e.smtp.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.smtp.Username, e.smtp.Password, e.smtp.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(e.smtp.From); err != nil {
+		return err
+	}
+	for _, to := range e.to {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("rcpt %s: %w", to, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, e.compose(ev, msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (e *email) compose(ev Event, msg message) string {
+	var b strings.Builder
+	header := func(k, v string) { fmt.Fprintf(&b, "%s: %s\r\n", k, v) }
+	header("From", e.smtp.From)
+	header("To", strings.Join(e.to, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Title))
+	header("Date", ev.Time.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "8bit")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(toLF(msg.Body), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.String()
+}
+
+func toLF(s string) string {
+	return strings.ReplaceAll(s, "\r\n", "\n")
+}
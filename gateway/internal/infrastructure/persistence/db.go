@@ -84,5 +84,10 @@ func autoMigrate(db *gorm.DB) error {
 	return db.AutoMigrate(
 		&models.MessageModel{},
 		&models.AgentModel{},
+		&models.ModelStatsModel{},
+		&models.ChatSettingsModel{},
+		&models.FeedbackModel{},
+		&models.ScheduleModel{},
+		&models.BudgetUsageModel{},
 	)
 }
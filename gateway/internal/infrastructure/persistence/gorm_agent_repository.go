@@ -42,7 +42,7 @@ func (r *GormAgentRepository) FindByID(ctx context.Context, id string) (*entity.
 // FindAll 查找所有代理
 func (r *GormAgentRepository) FindAll(ctx context.Context) ([]*entity.Agent, error) {
 	var modelList []models.AgentModel
-	if err := r.db.WithContext(ctx).Find(&modelList).Error; err != nil {
+	if err := r.db.WithContext(ctx).Order("created_at").Find(&modelList).Error; err != nil {
 		return nil, domainErrors.NewInternalError("failed to find agents: " + err.Error())
 	}
 
@@ -85,9 +85,9 @@ func (r *GormAgentRepository) Save(ctx context.Context, agent *entity.Agent) err
 	return nil
 }
 
-// Delete 删除代理
+// Delete 删除代理 (物理删除, 以便之后用同名重新创建)
 func (r *GormAgentRepository) Delete(ctx context.Context, id string) error {
-	result := r.db.WithContext(ctx).Delete(&models.AgentModel{}, "id = ?", id)
+	result := r.db.WithContext(ctx).Unscoped().Delete(&models.AgentModel{}, "id = ?", id)
 	if result.Error != nil {
 		return domainErrors.NewInternalError("failed to delete agent: " + result.Error.Error())
 	}
@@ -116,6 +116,20 @@ func (r *GormAgentRepository) toModel(agent *entity.Agent) (*models.AgentModel,
 	// 假设我们需要存储技能配置，暂时存为空列表JSON
 	skillsJSON, _ := json.Marshal([]string{})
 
+	toolsJSON := ""
+	if tools := agent.Tools(); len(tools) > 0 {
+		data, err := json.Marshal(tools)
+		if err != nil {
+			return nil, domainErrors.NewInternalError("failed to encode agent tools: " + err.Error())
+		}
+		toolsJSON = string(data)
+	}
+
+	createdAt := agent.CreatedAt()
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
 	return &models.AgentModel{
 		ID:            agent.ID(),
 		Name:          agent.Name(),
@@ -124,9 +138,10 @@ func (r *GormAgentRepository) toModel(agent *entity.Agent) (*models.AgentModel,
 		MaxTokens:     config.MaxTokens(),
 		Temperature:   config.Temperature(),
 		TopP:          config.TopP(),
-		SystemPrompt:  "", // ModelConfig 没有暴露 SystemPrompt getter?
-		Workspace:     "", // Agent 结构体中有 Workspace 字段但无 getter?
-		CreatedAt:     time.Now(), // 应该从 entity 获取，但 entity 没有暴露 CreatedAt getter
+		SystemPrompt:  agent.Persona(),
+		Tools:         toolsJSON,
+		Workspace:     agent.Workspace(),
+		CreatedAt:     createdAt,
 		UpdatedAt:     time.Now(),
 		Skills:        string(skillsJSON),
 	}, nil
@@ -159,10 +174,19 @@ func (r *GormAgentRepository) toEntity(model *models.AgentModel) (*entity.Agent,
 		skills = make([]entity.Skill, 0)
 	}
 
+	var tools []string
+	if model.Tools != "" {
+		if err := json.Unmarshal([]byte(model.Tools), &tools); err != nil {
+			return nil, domainErrors.NewInternalError("failed to decode agent tools: " + err.Error())
+		}
+	}
+
 	agent := entity.ReconstructAgent(
 		model.ID,
 		model.Name,
 		config,
+		model.SystemPrompt,
+		tools,
 		skills,
 		model.Workspace,
 		model.CreatedAt,
@@ -0,0 +1,74 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/repository"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/persistence/models"
+	domainErrors "github.com/ngoclaw/ngoclaw/gateway/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormBudgetRepository GORM 实现的预算池用量仓储
+type GormBudgetRepository struct {
+	db *gorm.DB
+}
+
+// NewGormBudgetRepository 创建 GORM 预算池用量仓储
+func NewGormBudgetRepository(db *gorm.DB) repository.BudgetRepository {
+	return &GormBudgetRepository{
+		db: db,
+	}
+}
+
+// Add 累加用量; 并发运行同时记录时由数据库完成加法, 不会丢失更新
+func (r *GormBudgetRepository) Add(ctx context.Context, u *entity.BudgetUsage) error {
+	updatedAt := u.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = time.Now()
+	}
+	row := &models.BudgetUsageModel{
+		Pool:      u.Pool,
+		Subject:   u.Subject,
+		Period:    u.Period,
+		Tokens:    u.Tokens,
+		CostUSD:   u.CostUSD,
+		UpdatedAt: updatedAt,
+	}
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "pool"}, {Name: "subject"}, {Name: "period"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"tokens":     gorm.Expr("budget_usage.tokens + excluded.tokens"),
+			"cost_usd":   gorm.Expr("budget_usage.cost_usd + excluded.cost_usd"),
+			"updated_at": gorm.Expr("excluded.updated_at"),
+		}),
+	}).Create(row).Error
+	if err != nil {
+		return domainErrors.NewInternalError("failed to record budget usage: " + err.Error())
+	}
+	return nil
+}
+
+// Get 加载一个周期的用量
+func (r *GormBudgetRepository) Get(ctx context.Context, pool, subject, period string) (*entity.BudgetUsage, error) {
+	usage := &entity.BudgetUsage{Pool: pool, Subject: subject, Period: period}
+	var rows []models.BudgetUsageModel
+	err := r.db.WithContext(ctx).
+		Where("pool = ? AND subject = ? AND period = ?", pool, subject, period).
+		Limit(1).Find(&rows).Error
+	if err != nil {
+		return nil, domainErrors.NewInternalError("failed to load budget usage: " + err.Error())
+	}
+	// 本周期还没有用量 (每次运行前都会查询, 用 Find 避免 record not found 日志)
+	if len(rows) == 0 {
+		return usage, nil
+	}
+	row := rows[0]
+	usage.Tokens = row.Tokens
+	usage.CostUSD = row.CostUSD
+	usage.UpdatedAt = row.UpdatedAt
+	return usage, nil
+}
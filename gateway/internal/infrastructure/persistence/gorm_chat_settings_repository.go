@@ -0,0 +1,125 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/repository"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/persistence/models"
+	domainErrors "github.com/ngoclaw/ngoclaw/gateway/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// GormChatSettingsRepository GORM 实现的会话偏好仓储
+type GormChatSettingsRepository struct {
+	db *gorm.DB
+}
+
+// NewGormChatSettingsRepository 创建 GORM 会话偏好仓储
+func NewGormChatSettingsRepository(db *gorm.DB) repository.ChatSettingsRepository {
+	return &GormChatSettingsRepository{
+		db: db,
+	}
+}
+
+// FindAll 加载全部会话偏好
+func (r *GormChatSettingsRepository) FindAll(ctx context.Context) ([]*entity.ChatSettings, error) {
+	var rows []models.ChatSettingsModel
+	if err := r.db.WithContext(ctx).Find(&rows).Error; err != nil {
+		return nil, domainErrors.NewInternalError("failed to load chat settings: " + err.Error())
+	}
+
+	result := make([]*entity.ChatSettings, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, &entity.ChatSettings{
+			ChatID:          row.ChatID,
+			UserID:          row.UserID,
+			Model:           row.Model,
+			Think:           row.Think,
+			Verbose:         row.Verbose,
+			Reasoning:       row.Reasoning,
+			Locale:          row.Locale,
+			UsageMode:       row.UsageMode,
+			Activation:      row.Activation,
+			SendPolicy:      row.SendPolicy,
+			SecurityProfile: row.SecurityProfile,
+			Route:           row.Route,
+			Draft:           row.Draft,
+			Agent:           row.Agent,
+			PromptVars:      decodePromptVars(row.PromptVars),
+			TTSEnabled:      row.TTSEnabled,
+			TTSProvider:     row.TTSProvider,
+			TTSLimit:        row.TTSLimit,
+			TTSSummary:      row.TTSSummary,
+			Temperature:     row.Temperature,
+			TopP:            row.TopP,
+			MaxOutputTokens: row.MaxOutputTokens,
+			ReasoningEffort: row.ReasoningEffort,
+			UpdatedAt:       row.UpdatedAt,
+		})
+	}
+	return result, nil
+}
+
+// Save 保存会话偏好
+func (r *GormChatSettingsRepository) Save(ctx context.Context, s *entity.ChatSettings) error {
+	row := &models.ChatSettingsModel{
+		ChatID:          s.ChatID,
+		UserID:          s.UserID,
+		Model:           s.Model,
+		Think:           s.Think,
+		Verbose:         s.Verbose,
+		Reasoning:       s.Reasoning,
+		Locale:          s.Locale,
+		UsageMode:       s.UsageMode,
+		Activation:      s.Activation,
+		SendPolicy:      s.SendPolicy,
+		SecurityProfile: s.SecurityProfile,
+		Route:           s.Route,
+		Draft:           s.Draft,
+		Agent:           s.Agent,
+		PromptVars:      encodePromptVars(s.PromptVars),
+		TTSEnabled:      s.TTSEnabled,
+		TTSProvider:     s.TTSProvider,
+		TTSLimit:        s.TTSLimit,
+		TTSSummary:      s.TTSSummary,
+		Temperature:     s.Temperature,
+		TopP:            s.TopP,
+		MaxOutputTokens: s.MaxOutputTokens,
+		ReasoningEffort: s.ReasoningEffort,
+		UpdatedAt:       s.UpdatedAt,
+	}
+	if err := r.db.WithContext(ctx).Save(row).Error; err != nil {
+		return domainErrors.NewInternalError("failed to save chat settings: " + err.Error())
+	}
+	return nil
+}
+
+// Delete 删除会话偏好
+func (r *GormChatSettingsRepository) Delete(ctx context.Context, chatID int64) error {
+	if err := r.db.WithContext(ctx).Delete(&models.ChatSettingsModel{}, "chat_id = ?", chatID).Error; err != nil {
+		return domainErrors.NewInternalError("failed to delete chat settings: " + err.Error())
+	}
+	return nil
+}
+
+// encodePromptVars 提示词变量存为 JSON 文本, 空 map 存空串
+func encodePromptVars(vars map[string]string) string {
+	if len(vars) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(vars)
+	return string(data)
+}
+
+func decodePromptVars(raw string) map[string]string {
+	if raw == "" {
+		return nil
+	}
+	var vars map[string]string
+	if err := json.Unmarshal([]byte(raw), &vars); err != nil {
+		return nil
+	}
+	return vars
+}
@@ -0,0 +1,116 @@
+package persistence
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/repository"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/persistence/models"
+	domainErrors "github.com/ngoclaw/ngoclaw/gateway/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// GormFeedbackRepository GORM 实现的用户反馈仓储
+type GormFeedbackRepository struct {
+	db *gorm.DB
+}
+
+// NewGormFeedbackRepository 创建 GORM 用户反馈仓储
+func NewGormFeedbackRepository(db *gorm.DB) repository.FeedbackRepository {
+	return &GormFeedbackRepository{
+		db: db,
+	}
+}
+
+// Save 保存反馈
+func (r *GormFeedbackRepository) Save(ctx context.Context, f *entity.Feedback) error {
+	row := &models.FeedbackModel{
+		Source:    f.Source,
+		ChatID:    f.ChatID,
+		UserID:    f.UserID,
+		Model:     f.Model,
+		Prompt:    f.Prompt,
+		Context:   f.Context,
+		Answer:    f.Answer,
+		Label:     f.Label,
+		Comment:   f.Comment,
+		MessageID: f.MessageID,
+		CreatedAt: f.CreatedAt,
+	}
+	if err := r.db.WithContext(ctx).Create(row).Error; err != nil {
+		return domainErrors.NewInternalError("failed to save feedback: " + err.Error())
+	}
+	f.ID = row.ID
+	return nil
+}
+
+// List 按时间顺序加载反馈
+func (r *GormFeedbackRepository) List(ctx context.Context, filter repository.FeedbackFilter) ([]*entity.Feedback, error) {
+	query := r.db.WithContext(ctx).Order("created_at, id")
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if filter.Label != "" {
+		query = query.Where("label = ?", filter.Label)
+	}
+
+	var rows []models.FeedbackModel
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, domainErrors.NewInternalError("failed to load feedback: " + err.Error())
+	}
+
+	result := make([]*entity.Feedback, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, &entity.Feedback{
+			ID:        row.ID,
+			Source:    row.Source,
+			ChatID:    row.ChatID,
+			UserID:    row.UserID,
+			Model:     row.Model,
+			Prompt:    row.Prompt,
+			Context:   row.Context,
+			Answer:    row.Answer,
+			Label:     row.Label,
+			Comment:   row.Comment,
+			MessageID: row.MessageID,
+			CreatedAt: row.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+// Purge 删除符合条件的反馈; 非数字的会话键不对应任何 chat
+func (r *GormFeedbackRepository) Purge(ctx context.Context, filter repository.PurgeFilter) (int64, error) {
+	query := r.db.WithContext(ctx).Session(&gorm.Session{AllowGlobalUpdate: true})
+	if !filter.Before.IsZero() {
+		query = query.Where("created_at < ?", filter.Before)
+	}
+	if len(filter.Chats) > 0 {
+		ids := parseChatIDs(filter.Chats)
+		if len(ids) == 0 {
+			return 0, nil
+		}
+		query = query.Where("chat_id IN ?", ids)
+	}
+	if ids := parseChatIDs(filter.Except); len(ids) > 0 {
+		query = query.Where("chat_id NOT IN ?", ids)
+	}
+
+	result := query.Delete(&models.FeedbackModel{})
+	if result.Error != nil {
+		return 0, domainErrors.NewInternalError("failed to purge feedback: " + result.Error.Error())
+	}
+	return result.RowsAffected, nil
+}
+
+// parseChatIDs 把会话键转换为 Telegram chat ID, 跳过无法解析的键
+func parseChatIDs(keys []string) []int64 {
+	ids := make([]int64, 0, len(keys))
+	for _, k := range keys {
+		if id, err := strconv.ParseInt(k, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -106,6 +106,26 @@ func (r *GormMessageRepository) Count(ctx context.Context, conversationID string
 	return count, nil
 }
 
+// Purge 永久删除符合条件的消息 (绕过软删除, 内容不留在库中)
+func (r *GormMessageRepository) Purge(ctx context.Context, filter repository.PurgeFilter) (int64, error) {
+	query := r.db.WithContext(ctx).Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped()
+	if !filter.Before.IsZero() {
+		query = query.Where("created_at < ?", filter.Before)
+	}
+	if len(filter.Chats) > 0 {
+		query = query.Where("conversation_id IN ?", filter.Chats)
+	}
+	if len(filter.Except) > 0 {
+		query = query.Where("conversation_id NOT IN ?", filter.Except)
+	}
+
+	result := query.Delete(&models.MessageModel{})
+	if result.Error != nil {
+		return 0, domainErrors.NewInternalError("failed to purge messages: " + result.Error.Error())
+	}
+	return result.RowsAffected, nil
+}
+
 // 转换方法
 
 func (r *GormMessageRepository) toModel(entity *entity.Message) (*models.MessageModel, error) {
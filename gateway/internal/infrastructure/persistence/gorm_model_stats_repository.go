@@ -0,0 +1,99 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/repository"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/persistence/models"
+	domainErrors "github.com/ngoclaw/ngoclaw/gateway/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// GormModelStatsRepository GORM 实现的模型统计仓储
+type GormModelStatsRepository struct {
+	db *gorm.DB
+}
+
+// NewGormModelStatsRepository 创建 GORM 模型统计仓储
+func NewGormModelStatsRepository(db *gorm.DB) repository.ModelStatsRepository {
+	return &GormModelStatsRepository{
+		db: db,
+	}
+}
+
+// FindAll 加载全部统计
+func (r *GormModelStatsRepository) FindAll(ctx context.Context) ([]*entity.ModelStats, error) {
+	var rows []models.ModelStatsModel
+	if err := r.db.WithContext(ctx).Find(&rows).Error; err != nil {
+		return nil, domainErrors.NewInternalError("failed to load model stats: " + err.Error())
+	}
+
+	result := make([]*entity.ModelStats, 0, len(rows))
+	for _, row := range rows {
+		stats := &entity.ModelStats{
+			Provider:        row.Provider,
+			Model:           row.Model,
+			Requests:        row.Requests,
+			Failures:        row.Failures,
+			Tokens:          row.Tokens,
+			LatencyP50Ms:    row.LatencyP50Ms,
+			LatencyP95Ms:    row.LatencyP95Ms,
+			FirstTokenP50Ms: row.FirstTokenP50Ms,
+			FirstTokenP95Ms: row.FirstTokenP95Ms,
+			SlowStarts:      row.SlowStarts,
+			Stalls:          row.Stalls,
+			UpdatedAt:       row.UpdatedAt,
+		}
+		if row.Errors != "" {
+			_ = json.Unmarshal([]byte(row.Errors), &stats.Errors)
+		}
+		if row.LatencySamples != "" {
+			_ = json.Unmarshal([]byte(row.LatencySamples), &stats.LatencySamples)
+		}
+		if row.FirstTokenSamples != "" {
+			_ = json.Unmarshal([]byte(row.FirstTokenSamples), &stats.FirstTokenSamples)
+		}
+		result = append(result, stats)
+	}
+	return result, nil
+}
+
+// Save 保存统计
+func (r *GormModelStatsRepository) Save(ctx context.Context, stats *entity.ModelStats) error {
+	errorsJSON, err := json.Marshal(stats.Errors)
+	if err != nil {
+		return domainErrors.NewInternalError("failed to marshal error counts: " + err.Error())
+	}
+	samplesJSON, err := json.Marshal(stats.LatencySamples)
+	if err != nil {
+		return domainErrors.NewInternalError("failed to marshal latency samples: " + err.Error())
+	}
+	firstTokenJSON, err := json.Marshal(stats.FirstTokenSamples)
+	if err != nil {
+		return domainErrors.NewInternalError("failed to marshal first-token samples: " + err.Error())
+	}
+
+	row := &models.ModelStatsModel{
+		Provider:          stats.Provider,
+		Model:             stats.Model,
+		Requests:          stats.Requests,
+		Failures:          stats.Failures,
+		Tokens:            stats.Tokens,
+		LatencyP50Ms:      stats.LatencyP50Ms,
+		LatencyP95Ms:      stats.LatencyP95Ms,
+		FirstTokenP50Ms:   stats.FirstTokenP50Ms,
+		FirstTokenP95Ms:   stats.FirstTokenP95Ms,
+		SlowStarts:        stats.SlowStarts,
+		Stalls:            stats.Stalls,
+		Errors:            string(errorsJSON),
+		LatencySamples:    string(samplesJSON),
+		FirstTokenSamples: string(firstTokenJSON),
+		UpdatedAt:         stats.UpdatedAt,
+	}
+	if err := r.db.WithContext(ctx).Save(row).Error; err != nil {
+		return domainErrors.NewInternalError("failed to save model stats: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/repository"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/persistence/models"
+	domainErrors "github.com/ngoclaw/ngoclaw/gateway/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// GormScheduleRepository GORM 实现的调度状态仓储
+type GormScheduleRepository struct {
+	db *gorm.DB
+}
+
+// NewGormScheduleRepository 创建 GORM 调度状态仓储
+func NewGormScheduleRepository(db *gorm.DB) repository.ScheduleRepository {
+	return &GormScheduleRepository{
+		db: db,
+	}
+}
+
+// List 加载指定类型的全部调度状态
+func (r *GormScheduleRepository) List(ctx context.Context, kind string) ([]*entity.Schedule, error) {
+	var rows []models.ScheduleModel
+	if err := r.db.WithContext(ctx).Where("kind = ?", kind).Order("created_at, id").Find(&rows).Error; err != nil {
+		return nil, domainErrors.NewInternalError("failed to load schedules: " + err.Error())
+	}
+	result := make([]*entity.Schedule, 0, len(rows))
+	for i := range rows {
+		result = append(result, scheduleFromModel(&rows[i]))
+	}
+	return result, nil
+}
+
+// Get 按 ID 加载调度状态
+func (r *GormScheduleRepository) Get(ctx context.Context, id string) (*entity.Schedule, error) {
+	var row models.ScheduleModel
+	err := r.db.WithContext(ctx).First(&row, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, domainErrors.NewInternalError("failed to load schedule: " + err.Error())
+	}
+	return scheduleFromModel(&row), nil
+}
+
+// Save 保存调度状态
+func (r *GormScheduleRepository) Save(ctx context.Context, s *entity.Schedule) error {
+	row := &models.ScheduleModel{
+		ID:         s.ID,
+		Kind:       s.Kind,
+		ChatID:     s.ChatID,
+		Expr:       s.Expr,
+		Command:    s.Command,
+		CatchUp:    s.CatchUp,
+		Enabled:    s.Enabled,
+		LastRun:    optionalTime(s.LastRun),
+		LastStatus: s.LastStatus,
+		LastError:  s.LastError,
+		NextRun:    optionalTime(s.NextRun),
+		Missed:     s.Missed,
+		CreatedAt:  s.CreatedAt,
+	}
+	if err := r.db.WithContext(ctx).Save(row).Error; err != nil {
+		return domainErrors.NewInternalError("failed to save schedule: " + err.Error())
+	}
+	return nil
+}
+
+// Delete 删除调度状态
+func (r *GormScheduleRepository) Delete(ctx context.Context, id string) error {
+	if err := r.db.WithContext(ctx).Delete(&models.ScheduleModel{}, "id = ?", id).Error; err != nil {
+		return domainErrors.NewInternalError("failed to delete schedule: " + err.Error())
+	}
+	return nil
+}
+
+func scheduleFromModel(row *models.ScheduleModel) *entity.Schedule {
+	s := &entity.Schedule{
+		ID:         row.ID,
+		Kind:       row.Kind,
+		ChatID:     row.ChatID,
+		Expr:       row.Expr,
+		Command:    row.Command,
+		CatchUp:    row.CatchUp,
+		Enabled:    row.Enabled,
+		LastStatus: row.LastStatus,
+		LastError:  row.LastError,
+		Missed:     row.Missed,
+		CreatedAt:  row.CreatedAt,
+	}
+	if row.LastRun != nil {
+		s.LastRun = *row.LastRun
+	}
+	if row.NextRun != nil {
+		s.NextRun = *row.NextRun
+	}
+	return s
+}
+
+// optionalTime 零值时间存为 NULL
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
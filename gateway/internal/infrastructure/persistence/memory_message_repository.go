@@ -2,6 +2,7 @@ package persistence
 
 import (
 	"context"
+	"slices"
 	"sync"
 
 	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
@@ -122,3 +123,31 @@ func (r *MemoryMessageRepository) Count(ctx context.Context, conversationID stri
 	}
 	return int64(len(messageIDs)), nil
 }
+
+// Purge 删除符合条件的消息
+func (r *MemoryMessageRepository) Purge(ctx context.Context, filter repository.PurgeFilter) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var removed int64
+	for convID, messageIDs := range r.convMessages {
+		if len(filter.Chats) > 0 && !slices.Contains(filter.Chats, convID) {
+			continue
+		}
+		if slices.Contains(filter.Except, convID) {
+			continue
+		}
+		kept := messageIDs[:0]
+		for _, id := range messageIDs {
+			msg := r.messages[id]
+			if msg != nil && !filter.Before.IsZero() && !msg.Timestamp().Before(filter.Before) {
+				kept = append(kept, id)
+				continue
+			}
+			delete(r.messages, id)
+			removed++
+		}
+		r.convMessages[convID] = kept
+	}
+	return removed, nil
+}
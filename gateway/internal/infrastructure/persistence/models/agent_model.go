@@ -15,7 +15,8 @@ type AgentModel struct {
 	MaxTokens      int
 	Temperature    float64
 	TopP           float64
-	SystemPrompt   string         `gorm:"type:text"`
+	SystemPrompt   string         `gorm:"type:text"` // 人设提示词
+	Tools          string         `gorm:"type:text"` // JSON encoded list of allowed tool names; empty = all
 	Workspace      string         `gorm:"size:255"`
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// BudgetUsageModel 数据库预算池用量 (每个 pool+subject+周期一行)
+type BudgetUsageModel struct {
+	Pool      string `gorm:"primaryKey;size:64"`
+	Subject   string `gorm:"primaryKey;size:128"`
+	Period    string `gorm:"primaryKey;size:16"` // 2006-01-02 或 2006-01
+	Tokens    int64
+	CostUSD   float64
+	UpdatedAt time.Time
+}
+
+// TableName 指定表名
+func (BudgetUsageModel) TableName() string {
+	return "budget_usage"
+}
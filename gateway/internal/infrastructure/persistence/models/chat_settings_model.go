@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// ChatSettingsModel 数据库会话偏好
+type ChatSettingsModel struct {
+	ChatID          int64 `gorm:"primaryKey;autoIncrement:false"`
+	UserID          int64
+	Model           string `gorm:"size:128"`
+	Think           string `gorm:"size:16"`
+	Verbose         bool
+	Reasoning       string `gorm:"size:16"`
+	Locale          string `gorm:"size:8"`
+	UsageMode       string `gorm:"size:16"`
+	Activation      string `gorm:"size:16"`
+	SendPolicy      string `gorm:"size:16"`
+	SecurityProfile string `gorm:"size:32"`
+	Route           string `gorm:"size:8"`
+	Draft           string `gorm:"size:8"`
+	Agent           string `gorm:"size:64"`
+	PromptVars      string `gorm:"type:text"` // JSON 对象, /setvar 设置的提示词变量
+	TTSEnabled      bool
+	TTSProvider     string `gorm:"size:32"`
+	TTSLimit        int
+	TTSSummary      bool
+	Temperature     float64
+	TopP            float64
+	MaxOutputTokens int
+	ReasoningEffort string    `gorm:"size:16"`
+	UpdatedAt       time.Time `gorm:"index"`
+}
+
+// TableName 指定表名
+func (ChatSettingsModel) TableName() string {
+	return "chat_settings"
+}
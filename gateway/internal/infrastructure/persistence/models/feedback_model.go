@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// FeedbackModel 数据库用户反馈
+type FeedbackModel struct {
+	ID        uint   `gorm:"primaryKey"`
+	Source    string `gorm:"size:32"`
+	ChatID    int64  `gorm:"index"`
+	UserID    int64
+	Model     string `gorm:"size:128"`
+	Prompt    string `gorm:"type:text"`
+	Context   string `gorm:"type:text"`
+	Answer    string `gorm:"type:text"`
+	Label     string `gorm:"size:16;index"`
+	Comment   string `gorm:"type:text"`
+	MessageID int
+	CreatedAt time.Time `gorm:"index"`
+}
+
+// TableName 指定表名
+func (FeedbackModel) TableName() string {
+	return "feedback"
+}
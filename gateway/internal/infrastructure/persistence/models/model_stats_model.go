@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// ModelStatsModel 数据库模型调用统计
+type ModelStatsModel struct {
+	Provider          string `gorm:"primaryKey;size:64"`
+	Model             string `gorm:"primaryKey;size:128"`
+	Requests          int64
+	Failures          int64
+	Tokens            int64
+	LatencyP50Ms      float64
+	LatencyP95Ms      float64
+	FirstTokenP50Ms   float64
+	FirstTokenP95Ms   float64
+	SlowStarts        int64
+	Stalls            int64
+	Errors            string `gorm:"type:text"` // JSON encoded map[category]count
+	LatencySamples    string `gorm:"type:text"` // JSON encoded recent latencies (ms)
+	FirstTokenSamples string `gorm:"type:text"` // JSON encoded recent first-token latencies (ms)
+	UpdatedAt         time.Time
+}
+
+// TableName 指定表名
+func (ModelStatsModel) TableName() string {
+	return "model_stats"
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// ScheduleModel 数据库定时任务 / 心跳调度状态
+type ScheduleModel struct {
+	ID         string `gorm:"primaryKey;size:64"`
+	Kind       string `gorm:"size:16;index"`
+	ChatID     int64  `gorm:"index"`
+	Expr       string `gorm:"size:128"`
+	Command    string `gorm:"type:text"`
+	CatchUp    string `gorm:"size:8"`
+	Enabled    bool
+	LastRun    *time.Time
+	LastStatus string `gorm:"size:16"`
+	LastError  string `gorm:"type:text"`
+	NextRun    *time.Time
+	Missed     int
+	CreatedAt  time.Time
+}
+
+// TableName 指定表名
+func (ScheduleModel) TableName() string {
+	return "schedules"
+}
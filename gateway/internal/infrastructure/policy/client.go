@@ -0,0 +1,250 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+)
+
+const (
+	defaultInterval = 15 * time.Minute
+	fetchTimeout    = 30 * time.Second
+	maxBundleBytes  = 4 << 20
+)
+
+// Client fetches, verifies and caches the policy bundle, and hands every
+// new version to the apply callback.
+type Client struct {
+	url      string
+	key      ed25519.PublicKey
+	interval time.Duration
+	cache    string // "" = no cache
+	root     string
+	http     *http.Client
+	apply    func(*service.OrgPolicy)
+	logger   *zap.Logger
+
+	mu      sync.Mutex
+	current *service.OrgPolicy
+	payload []byte // payload of current
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New creates the client for cfg, or returns nil when no policy URL is
+// configured. root is the project root guard rule paths are relative to.
+func New(cfg config.PolicyConfig, root string, apply func(*service.OrgPolicy), logger *zap.Logger) (*Client, error) {
+	if cfg.URL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("policy.url must be an https URL, got %q", cfg.URL)
+	}
+	if cfg.PublicKey == "" {
+		return nil, errors.New("policy.public_key is required with policy.url")
+	}
+	key, err := ParsePublicKey(cfg.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("policy.public_key: %w", err)
+	}
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	return &Client{
+		url:      cfg.URL,
+		key:      key,
+		interval: interval,
+		cache:    cfg.CachePath,
+		root:     root,
+		http:     &http.Client{Timeout: fetchTimeout},
+		apply:    apply,
+		logger:   logger.With(zap.String("component", "policy")),
+	}, nil
+}
+
+// Current returns the policy in force, nil before Load succeeded.
+func (c *Client) Current() *service.OrgPolicy {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.current
+}
+
+// Load establishes the policy at startup: the bundle at the URL, or the
+// cached one when the URL cannot be reached or serves an invalid bundle.
+// It fails only when neither is available.
+func (c *Client) Load(ctx context.Context) error {
+	if p, payload, err := c.readCache(); err == nil {
+		c.set(p, payload)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		c.logger.Warn("Ignoring cached policy bundle", zap.String("path", c.cache), zap.Error(err))
+	}
+	if _, err := c.Refresh(ctx); err != nil {
+		p := c.Current()
+		if p == nil {
+			return err
+		}
+		c.logger.Warn("Policy bundle unavailable, enforcing the cached one",
+			zap.String("version", p.Version),
+			zap.Error(err),
+		)
+	}
+	p := c.Current()
+	c.apply(p)
+	c.logger.Info("Organization policy loaded", zap.String("version", p.Version))
+	return nil
+}
+
+// Refresh fetches the bundle and, if it verifies and differs from the one
+// in force, makes it current and caches it. It reports whether the policy
+// changed; the caller applies it.
+func (c *Client) Refresh(ctx context.Context) (bool, error) {
+	data, err := c.fetch(ctx)
+	if err != nil {
+		return false, err
+	}
+	payload, err := Verify(data, c.key)
+	if err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	cur, same := c.current, bytes.Equal(payload, c.payload)
+	c.mu.Unlock()
+	if same {
+		return false, nil
+	}
+	p, err := Parse(payload, c.root)
+	if err != nil {
+		return false, fmt.Errorf("invalid policy bundle: %w", err)
+	}
+	// A replayed older bundle must not undo a newer policy
+	if cur != nil && p.Issued.Before(cur.Issued) {
+		return false, fmt.Errorf("policy bundle issued %s is older than the one in force (%s)",
+			p.Issued.Format(time.RFC3339), cur.Issued.Format(time.RFC3339))
+	}
+	c.set(p, payload)
+	if err := c.writeCache(data); err != nil {
+		c.logger.Warn("Failed to cache policy bundle", zap.String("path", c.cache), zap.Error(err))
+	}
+	return true, nil
+}
+
+// Start refreshes the policy every interval until Stop.
+func (c *Client) Start() {
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.stop:
+				return
+			case <-ticker.C:
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+			changed, err := c.Refresh(ctx)
+			cancel()
+			switch {
+			case err != nil:
+				c.logger.Warn("Policy refresh failed, keeping the current policy", zap.Error(err))
+			case changed:
+				p := c.Current()
+				c.apply(p)
+				c.logger.Info("Organization policy updated", zap.String("version", p.Version))
+			}
+		}
+	}()
+}
+
+// Stop ends the refresh loop started by Start.
+func (c *Client) Stop() {
+	if c == nil || c.stop == nil {
+		return
+	}
+	close(c.stop)
+	<-c.done
+}
+
+func (c *Client) set(p *service.OrgPolicy, payload []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current = p
+	c.payload = payload
+}
+
+func (c *Client) fetch(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch policy bundle: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch policy bundle: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBundleBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("fetch policy bundle: %w", err)
+	}
+	if len(data) > maxBundleBytes {
+		return nil, fmt.Errorf("policy bundle exceeds %d bytes", maxBundleBytes)
+	}
+	return data, nil
+}
+
+// readCache loads the cached envelope, verified again: the file is only as
+// trustworthy as its signature.
+func (c *Client) readCache() (*service.OrgPolicy, []byte, error) {
+	if c.cache == "" {
+		return nil, nil, fs.ErrNotExist
+	}
+	data, err := os.ReadFile(c.cache)
+	if err != nil {
+		return nil, nil, err
+	}
+	payload, err := Verify(data, c.key)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := Parse(payload, c.root)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, payload, nil
+}
+
+func (c *Client) writeCache(data []byte) error {
+	if c.cache == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.cache), 0o700); err != nil {
+		return err
+	}
+	tmp := c.cache + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.cache)
+}
@@ -0,0 +1,156 @@
+// Package policy loads the organization policy bundle: security lists,
+// guard rules, blocked models and budget caps that an organization pushes
+// to every gateway and that take precedence over the local config.
+//
+// A bundle is a JSON envelope holding a YAML (or JSON) payload and its
+// ed25519 signature:
+//
+//	{"payload": "<base64>", "signature": "<base64>"}
+//
+// Only bundles signed with the key in policy.public_key are applied. The
+// bundle is fetched from policy.url at startup and every policy.interval;
+// the last verified bundle is cached on disk so a gateway that cannot reach
+// the URL keeps enforcing it.
+package policy
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+)
+
+// Envelope is a signed bundle as served at policy.url.
+type Envelope struct {
+	Payload   []byte `json:"payload"`
+	Signature []byte `json:"signature"`
+}
+
+// ErrBadSignature is returned for a bundle not signed with the configured key.
+var ErrBadSignature = errors.New("policy bundle signature does not verify")
+
+// Sign wraps payload in an envelope signed with key.
+func Sign(payload []byte, key ed25519.PrivateKey) ([]byte, error) {
+	return json.MarshalIndent(Envelope{Payload: payload, Signature: ed25519.Sign(key, payload)}, "", "  ")
+}
+
+// Verify checks the envelope's signature against key and returns the payload.
+func Verify(data []byte, key ed25519.PublicKey) ([]byte, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("policy bundle is not a signed envelope: %w", err)
+	}
+	if len(env.Payload) == 0 || len(env.Signature) == 0 {
+		return nil, errors.New("policy bundle is missing its payload or signature")
+	}
+	if !ed25519.Verify(key, env.Payload, env.Signature) {
+		return nil, ErrBadSignature
+	}
+	return env.Payload, nil
+}
+
+// ParsePublicKey decodes a base64 ed25519 public key (policy.public_key).
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key must be %d base64-encoded bytes", ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+// ParsePrivateKey decodes a base64 ed25519 private key, or its 32-byte seed.
+func ParsePrivateKey(s string) (ed25519.PrivateKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("private key is not base64: %w", err)
+	case len(b) == ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(b), nil
+	case len(b) == ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(b), nil
+	}
+	return nil, fmt.Errorf("private key must be %d or %d bytes, got %d", ed25519.SeedSize, ed25519.PrivateKeySize, len(b))
+}
+
+// payloadFormat is the bundle payload.
+type payloadFormat struct {
+	Version  string    `yaml:"version"`
+	Issued   time.Time `yaml:"issued"`
+	Security struct {
+		ApprovalMode    string   `yaml:"approval_mode"`
+		DangerousTools  []string `yaml:"dangerous_tools"`
+		TrustedTools    []string `yaml:"trusted_tools"`    // replaces the local list when present
+		TrustedCommands []string `yaml:"trusted_commands"` // replaces the local list when present
+		RiskAnalysis    bool     `yaml:"risk_analysis"`
+	} `yaml:"security"`
+	Guards        []service.GuardRule `yaml:"guards"`
+	BlockedModels []string            `yaml:"blocked_models"`
+	Budgets       []budgetFormat      `yaml:"budgets"`
+}
+
+type budgetFormat struct {
+	Name   string  `yaml:"name"`
+	Scope  string  `yaml:"scope"`  // global | user | chat, default global
+	Window string  `yaml:"window"` // daily | monthly, default monthly
+	Tokens int64   `yaml:"tokens"`
+	USD    float64 `yaml:"usd"`
+}
+
+// Parse decodes and validates a bundle payload. Path globs in guard rules
+// are resolved against root, the project root. Unknown fields are errors,
+// so a misspelled setting cannot silently fail to apply.
+func Parse(payload []byte, root string) (*service.OrgPolicy, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(payload))
+	dec.KnownFields(true)
+	var f payloadFormat
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	guards, err := service.CompileGuardRules(f.Guards, root)
+	if err != nil {
+		return nil, fmt.Errorf("guards: %w", err)
+	}
+	p := &service.OrgPolicy{
+		Version:         f.Version,
+		Issued:          f.Issued,
+		ApprovalMode:    f.Security.ApprovalMode,
+		DangerousTools:  f.Security.DangerousTools,
+		TrustedTools:    f.Security.TrustedTools,
+		TrustedCommands: f.Security.TrustedCommands,
+		RiskAnalysis:    f.Security.RiskAnalysis,
+		Guards:          guards,
+		BlockedModels:   f.BlockedModels,
+	}
+	for _, b := range f.Budgets {
+		pool := service.BudgetPool{
+			Name:   b.Name,
+			Scope:  service.BudgetScope(strings.ToLower(b.Scope)),
+			Window: service.BudgetWindow(strings.ToLower(b.Window)),
+			Tokens: b.Tokens,
+			USD:    b.USD,
+		}
+		if pool.Scope == "" {
+			pool.Scope = service.BudgetGlobal
+		}
+		if pool.Window == "" {
+			pool.Window = service.BudgetMonthly
+		}
+		if pool.Name == "" {
+			pool.Name = string(pool.Scope) + "-" + string(pool.Window)
+		}
+		p.Budgets = append(p.Budgets, pool)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
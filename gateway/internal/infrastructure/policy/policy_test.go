@@ -0,0 +1,172 @@
+package policy
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+)
+
+const testPayload = `version: "2026-10"
+issued: 2026-10-01T00:00:00Z
+security:
+  approval_mode: ask_dangerous
+  dangerous_tools: [web_fetch]
+  trusted_commands: []
+guards:
+  - name: no-secrets
+    paths: ["secrets/**"]
+    action: deny
+blocked_models: ["openai/gpt-4*"]
+budgets:
+  - name: per-user
+    scope: user
+    window: daily
+    tokens: 200000
+`
+
+// bundleServer serves whatever bundle is set, or 503 when it is empty.
+type bundleServer struct {
+	mu     sync.Mutex
+	bundle []byte
+}
+
+func (s *bundleServer) set(b []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bundle = b
+}
+
+func (s *bundleServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.bundle == nil {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+		return
+	}
+	w.Write(s.bundle)
+}
+
+func sign(t *testing.T, payload string, key ed25519.PrivateKey) []byte {
+	t.Helper()
+	b, err := Sign([]byte(payload), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func newTestClient(t *testing.T, pub ed25519.PublicKey, cache string) (*Client, *bundleServer, *[]*service.OrgPolicy) {
+	t.Helper()
+	bs := &bundleServer{}
+	srv := httptest.NewTLSServer(bs)
+	t.Cleanup(srv.Close)
+	var applied []*service.OrgPolicy
+	c, err := New(config.PolicyConfig{
+		URL:       srv.URL + "/policy.json",
+		PublicKey: base64.StdEncoding.EncodeToString(pub),
+		CachePath: cache,
+	}, "/repo", func(p *service.OrgPolicy) { applied = append(applied, p) }, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.http = srv.Client()
+	return c, bs, &applied
+}
+
+func TestClientLoadAndRefresh(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	cache := filepath.Join(t.TempDir(), "policy.json")
+	c, bs, applied := newTestClient(t, pub, cache)
+	ctx := context.Background()
+
+	bs.set(sign(t, testPayload, priv))
+	if err := c.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	p := c.Current()
+	if len(*applied) != 1 || (*applied)[0] != p {
+		t.Fatalf("applied = %v", *applied)
+	}
+	if p.Version != "2026-10" || p.ApprovalMode != "ask_dangerous" || p.TrustedCommands == nil || p.TrustedTools != nil ||
+		!p.ModelBlocked("openai/gpt-4o") || p.Guards.Len() != 1 ||
+		len(p.Budgets) != 1 || p.Budgets[0].Scope != service.BudgetUser || p.Budgets[0].Tokens != 200000 {
+		t.Errorf("policy = %+v", p)
+	}
+	if changed, err := c.Refresh(ctx); changed || err != nil {
+		t.Errorf("unchanged bundle: changed=%v err=%v", changed, err)
+	}
+
+	// tampered payload, wrong key, older bundle: the current policy stays
+	tampered := strings.Replace(string(sign(t, testPayload, priv)), `"payload": "`, `"payload": "A`, 1)
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+	older := strings.Replace(testPayload, "2026-10-01", "2026-09-01", 1)
+	for name, bundle := range map[string][]byte{
+		"tampered":  []byte(tampered),
+		"wrong key": sign(t, strings.Replace(testPayload, "ask_dangerous", "auto", 1), otherKey),
+		"older":     sign(t, older, priv),
+		"invalid":   sign(t, "security:\n  approval_mode: yolo\n", priv),
+	} {
+		bs.set(bundle)
+		if changed, err := c.Refresh(ctx); changed || err == nil {
+			t.Errorf("%s: changed=%v err=%v", name, changed, err)
+		}
+		if c.Current() != p {
+			t.Errorf("%s replaced the policy", name)
+		}
+	}
+
+	newer := strings.Replace(testPayload, "2026-10-01", "2026-10-02", 1)
+	bs.set(sign(t, strings.Replace(newer, "ask_dangerous", "ask_all", 1), priv))
+	if changed, err := c.Refresh(ctx); !changed || err != nil || c.Current().ApprovalMode != "ask_all" {
+		t.Errorf("newer bundle: changed=%v err=%v", changed, err)
+	}
+
+	// restart while the URL is down: the cached bundle is enforced
+	c2, bs2, applied2 := newTestClient(t, pub, cache)
+	bs2.set(nil)
+	if err := c2.Load(ctx); err != nil || len(*applied2) != 1 || c2.Current().ApprovalMode != "ask_all" {
+		t.Errorf("cached load: err=%v policy=%+v", err, c2.Current())
+	}
+	// no cache and no URL: Load fails
+	c3, _, _ := newTestClient(t, pub, filepath.Join(t.TempDir(), "none.json"))
+	if err := c3.Load(ctx); err == nil {
+		t.Error("load without any bundle succeeded")
+	}
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+	key := base64.StdEncoding.EncodeToString(pub)
+	if c, err := New(config.PolicyConfig{}, "", nil, zap.NewNop()); c != nil || err != nil {
+		t.Errorf("no url = %v, %v", c, err)
+	}
+	for _, cfg := range []config.PolicyConfig{
+		{URL: "http://policy.example.com/bundle.json", PublicKey: key},
+		{URL: "https://policy.example.com/bundle.json"},
+		{URL: "https://policy.example.com/bundle.json", PublicKey: "c2hvcnQ="},
+	} {
+		if _, err := New(cfg, "", nil, zap.NewNop()); err == nil {
+			t.Errorf("%+v: want error", cfg)
+		}
+	}
+}
+
+func TestParseRejectsUnknownFields(t *testing.T) {
+	if _, err := Parse([]byte("blocked_model: [x]\n"), "/repo"); err == nil {
+		t.Error("misspelled field accepted")
+	}
+	if _, err := Parse([]byte("guards:\n  - name: x\n    action: deny\n"), "/repo"); err == nil {
+		t.Error("guard rule without conditions accepted")
+	}
+}
@@ -0,0 +1,84 @@
+package postprocess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	goplugin "plugin"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+)
+
+// pluginProcessor 加载 Go plugin (go build -buildmode=plugin). 插件导出:
+//
+//	func Process(content string, meta map[string]string) (string, error)
+//	func Init(options map[string]interface{}) error // 可选, 以 options 调用一次
+//
+// meta 含 channel 和 model. 签名只用标准类型, 插件无需引用网关的包,
+// 但仍须与网关使用同一 Go 版本构建.
+type pluginProcessor struct {
+	name    string
+	process func(string, map[string]string) (string, error)
+	timeout time.Duration
+}
+
+func newPlugin(cfg config.OutputProcessorConfig) (service.OutputProcessor, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("plugin requires path")
+	}
+	p, err := goplugin.Open(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup("Process")
+	if err != nil {
+		return nil, err
+	}
+	process, ok := sym.(func(string, map[string]string) (string, error))
+	if !ok {
+		return nil, fmt.Errorf("%s: Process has type %T, want func(string, map[string]string) (string, error)", cfg.Path, sym)
+	}
+	if sym, err := p.Lookup("Init"); err == nil {
+		initFn, ok := sym.(func(map[string]interface{}) error)
+		if !ok {
+			return nil, fmt.Errorf("%s: Init has type %T, want func(map[string]interface{}) error", cfg.Path, sym)
+		}
+		if err := initFn(cfg.Options); err != nil {
+			return nil, fmt.Errorf("%s: Init: %w", cfg.Path, err)
+		}
+	}
+	return &pluginProcessor{name: cfg.Name, process: process, timeout: cfg.Timeout}, nil
+}
+
+func (p *pluginProcessor) Name() string { return p.name }
+
+// Process 插件函数无法被取消, 超时后放弃结果 (goroutine 会继续运行到结束)
+func (p *pluginProcessor) Process(ctx context.Context, content string, info service.OutputInfo) (string, error) {
+	type result struct {
+		out string
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{err: fmt.Errorf("plugin panic: %v", r)}
+			}
+		}()
+		out, err := p.process(content, map[string]string{"channel": info.Channel, "model": info.Model})
+		done <- result{out, err}
+	}()
+
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.out, r.err
+	case <-timer.C:
+		return "", fmt.Errorf("timed out after %s", p.timeout)
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
@@ -0,0 +1,87 @@
+// Package postprocess builds the output processors configured in
+// agent.output.processors into a service.OutputPipeline.
+//
+// Custom processors are added either without recompiling, as an external
+// command (type: command) or a Go plugin (type: plugin), or compiled into the
+// binary with RegisterFactory:
+//
+//	func init() {
+//		postprocess.RegisterFactory("legal_footer", func(cfg config.OutputProcessorConfig) (service.OutputProcessor, error) {
+//			return &legalFooter{}, nil
+//		})
+//	}
+package postprocess
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+// defaultTimeout bounds command and plugin processors without a timeout.
+const defaultTimeout = 10 * time.Second
+
+// Factory creates a processor from its config entry.
+type Factory func(cfg config.OutputProcessorConfig) (service.OutputProcessor, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{
+		"replace": newReplace,
+		"hosts":   newHosts,
+		"footer":  newFooter,
+		"links":   newLinks,
+		"command": newCommand,
+		"plugin":  newPlugin,
+	}
+)
+
+// RegisterFactory registers a processor type. Call it from init(); a later
+// registration replaces an earlier one with the same type.
+func RegisterFactory(typeName string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[typeName] = factory
+}
+
+// Build creates the pipeline for agent.output. It returns nil when no
+// processors are configured, and an error for the first invalid entry so a
+// broken policy is never silently skipped.
+func Build(cfg config.OutputConfig, logger *zap.Logger) (*service.OutputPipeline, error) {
+	if len(cfg.Processors) == 0 {
+		return nil, nil
+	}
+	pipeline := service.NewOutputPipeline(logger)
+	for i, pc := range cfg.Processors {
+		factoriesMu.RLock()
+		factory, ok := factories[pc.Type]
+		factoriesMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("output processor #%d: unknown type %q", i+1, pc.Type)
+		}
+		if pc.Name == "" {
+			pc.Name = pc.Type
+		}
+		if pc.Timeout <= 0 {
+			pc.Timeout = defaultTimeout
+		}
+		proc, err := factory(pc)
+		if err != nil {
+			return nil, fmt.Errorf("output processor %s: %w", pc.Name, err)
+		}
+		var withhold bool
+		switch pc.OnError {
+		case "", "pass":
+		case "withhold":
+			withhold = true
+		default:
+			return nil, fmt.Errorf("output processor %s: on_error must be pass or withhold, got %q", pc.Name, pc.OnError)
+		}
+		pipeline.Use(service.OutputStage{Processor: proc, Channels: pc.Channels, Withhold: withhold})
+	}
+	return pipeline, nil
+}
@@ -0,0 +1,105 @@
+package postprocess
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+func build(t *testing.T, procs ...config.OutputProcessorConfig) *service.OutputPipeline {
+	t.Helper()
+	p, err := Build(config.OutputConfig{Processors: procs}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestBuiltinProcessors(t *testing.T) {
+	p := build(t,
+		config.OutputProcessorConfig{Type: "hosts", Hosts: []string{"corp.example.com"}},
+		config.OutputProcessorConfig{Type: "links", From: "https://wiki.example.org/", To: "https://docs.example.org/", Style: "plain"},
+		config.OutputProcessorConfig{Type: "replace", Pattern: `\bTICKET-(\d+)`, Replacement: "#$1"},
+		config.OutputProcessorConfig{Type: "footer", Text: "_AI-generated, verify before use._", Channels: []string{"telegram"}},
+	)
+	in := "Deployed to build01.corp.example.com (see https://ci.corp.example.com:8443/job/42, not notcorp.example.com).\n" +
+		"Details: [runbook](https://wiki.example.org/runbook) and TICKET-17.\n"
+
+	got, err := p.Process(context.Background(), in, service.OutputInfo{Channel: "telegram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Deployed to [internal] (see [internal], not notcorp.example.com).\n" +
+		"Details: runbook (https://docs.example.org/runbook) and #17.\n\n" +
+		"_AI-generated, verify before use._"
+	if got != want {
+		t.Errorf("telegram:\n got %q\nwant %q", got, want)
+	}
+
+	// footer 只对 telegram 生效, 且不会重复追加
+	got, _ = p.Process(context.Background(), "ok", service.OutputInfo{Channel: "cli"})
+	if got != "ok" {
+		t.Errorf("cli = %q, want no footer", got)
+	}
+	if !p.Active("cli") {
+		t.Error("cli should be active: hosts/links/replace apply to all channels")
+	}
+	again, _ := p.Process(context.Background(), want, service.OutputInfo{Channel: "telegram"})
+	if again != want {
+		t.Errorf("footer appended twice: %q", again)
+	}
+}
+
+func TestCommandProcessor(t *testing.T) {
+	p := build(t, config.OutputProcessorConfig{
+		Type:    "command",
+		Command: []string{"sh", "-c", `tr a-z A-Z; printf ' [%s]' "$NGOCLAW_OUTPUT_CHANNEL"`},
+	})
+	got, err := p.Process(context.Background(), "hello", service.OutputInfo{Channel: "http"})
+	if err != nil || got != "HELLO [http]" {
+		t.Errorf("command = %q, %v", got, err)
+	}
+}
+
+func TestOnError(t *testing.T) {
+	failing := []string{"sh", "-c", "echo policy service down >&2; exit 3"}
+
+	// 默认 pass: 跳过失败的处理器, 其余照常执行
+	p := build(t,
+		config.OutputProcessorConfig{Type: "command", Command: failing},
+		config.OutputProcessorConfig{Type: "footer", Text: "-- footer"},
+	)
+	got, err := p.Process(context.Background(), "answer", service.OutputInfo{Channel: "cli"})
+	if err != nil || got != "answer\n\n-- footer" {
+		t.Errorf("pass = %q, %v", got, err)
+	}
+
+	p = build(t, config.OutputProcessorConfig{Name: "dlp", Type: "command", Command: failing, OnError: "withhold"})
+	got, err = p.Process(context.Background(), "secret", service.OutputInfo{Channel: "cli"})
+	if !errors.Is(err, service.ErrOutputWithheld) || got != "" {
+		t.Errorf("withhold = %q, %v", got, err)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	for _, pc := range []config.OutputProcessorConfig{
+		{Type: "nope"},
+		{Type: "replace", Pattern: "("},
+		{Type: "footer"},
+		{Type: "links", Style: "html"},
+		{Type: "hosts", Hosts: []string{"*."}},
+		{Type: "footer", Text: "x", OnError: "ignore"},
+		{Type: "plugin", Path: "/nonexistent/filter.so"},
+	} {
+		if _, err := Build(config.OutputConfig{Processors: []config.OutputProcessorConfig{pc}}, zap.NewNop()); err == nil {
+			t.Errorf("Build(%+v) should fail", pc)
+		}
+	}
+	if p, err := Build(config.OutputConfig{}, zap.NewNop()); p != nil || err != nil {
+		t.Errorf("empty config = %v, %v; want nil pipeline", p, err)
+	}
+}
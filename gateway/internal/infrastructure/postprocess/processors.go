@@ -0,0 +1,173 @@
+package postprocess
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+)
+
+// replaceProcessor 正则替换, replacement 支持 $1 引用
+type replaceProcessor struct {
+	name        string
+	re          *regexp.Regexp
+	replacement string
+}
+
+func newReplace(cfg config.OutputProcessorConfig) (service.OutputProcessor, error) {
+	if cfg.Pattern == "" {
+		return nil, errors.New("replace requires pattern")
+	}
+	re, err := regexp.Compile(cfg.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return &replaceProcessor{name: cfg.Name, re: re, replacement: cfg.Replacement}, nil
+}
+
+func (p *replaceProcessor) Name() string { return p.name }
+
+func (p *replaceProcessor) Process(_ context.Context, content string, _ service.OutputInfo) (string, error) {
+	return p.re.ReplaceAllString(content, p.replacement), nil
+}
+
+// newHosts 屏蔽内部域名: 域名本身、其子域名以及指向它们的整个 URL 都替换掉,
+// 避免留下 https://[internal]/path 这样仍能看出内部路径的文本
+func newHosts(cfg config.OutputProcessorConfig) (service.OutputProcessor, error) {
+	if len(cfg.Hosts) == 0 {
+		return nil, errors.New("hosts requires at least one host")
+	}
+	alts := make([]string, 0, len(cfg.Hosts))
+	for _, h := range cfg.Hosts {
+		name := strings.Trim(strings.TrimPrefix(strings.TrimSpace(h), "*."), ".")
+		if name == "" || strings.ContainsAny(name, "*/: ") {
+			return nil, fmt.Errorf("invalid host %q", h)
+		}
+		alts = append(alts, regexp.QuoteMeta(name))
+	}
+	// URL 路径不含结尾的标点, 以免吞掉句末的逗号、句号
+	re := regexp.MustCompile(`(?i)\b(?:[a-z][a-z0-9+.-]*://)?(?:[a-z0-9-]+\.)*(?:` + strings.Join(alts, "|") + `)\b(?::\d+)?(?:/(?:[^\s)\]>"'<]*[^\s)\]>"'<.,;:!?])?)?`)
+	replacement := cfg.Replacement
+	if replacement == "" {
+		replacement = "[internal]"
+	}
+	return &replaceProcessor{name: cfg.Name, re: re, replacement: replacement}, nil
+}
+
+// footerProcessor 追加声明; 回复已包含该文本时不重复追加
+type footerProcessor struct {
+	name string
+	text string
+}
+
+func newFooter(cfg config.OutputProcessorConfig) (service.OutputProcessor, error) {
+	text := strings.TrimSpace(cfg.Text)
+	if text == "" {
+		return nil, errors.New("footer requires text")
+	}
+	return &footerProcessor{name: cfg.Name, text: text}, nil
+}
+
+func (p *footerProcessor) Name() string { return p.name }
+
+func (p *footerProcessor) Process(_ context.Context, content string, _ service.OutputInfo) (string, error) {
+	if strings.Contains(content, p.text) {
+		return content, nil
+	}
+	return strings.TrimRight(content, " \t\n") + "\n\n" + p.text, nil
+}
+
+var markdownLink = regexp.MustCompile(`\[([^\]\n]+)\]\(([^)\s]+)\)`)
+
+// linksProcessor 改写 URL 前缀 (如内网 wiki → 公开镜像), 可选把 Markdown 链接转成纯文本
+type linksProcessor struct {
+	name     string
+	from, to string
+	plain    bool
+}
+
+func newLinks(cfg config.OutputProcessorConfig) (service.OutputProcessor, error) {
+	p := &linksProcessor{name: cfg.Name, from: cfg.From, to: cfg.To}
+	switch cfg.Style {
+	case "":
+	case "plain":
+		p.plain = true
+	default:
+		return nil, fmt.Errorf("links style must be empty or plain, got %q", cfg.Style)
+	}
+	if p.from == "" && !p.plain {
+		return nil, errors.New("links requires from or style: plain")
+	}
+	return p, nil
+}
+
+func (p *linksProcessor) Name() string { return p.name }
+
+func (p *linksProcessor) Process(_ context.Context, content string, _ service.OutputInfo) (string, error) {
+	if p.from != "" {
+		content = strings.ReplaceAll(content, p.from, p.to)
+	}
+	if p.plain {
+		content = markdownLink.ReplaceAllStringFunc(content, func(m string) string {
+			sub := markdownLink.FindStringSubmatch(m)
+			if sub[1] == sub[2] {
+				return sub[2]
+			}
+			return sub[1] + " (" + sub[2] + ")"
+		})
+	}
+	return content, nil
+}
+
+// commandProcessor 外部命令: 回复经 stdin 传入, stdout 作为新回复.
+// 渠道与模型通过 NGOCLAW_OUTPUT_CHANNEL / NGOCLAW_OUTPUT_MODEL 传递, 非零退出视为失败.
+type commandProcessor struct {
+	name    string
+	argv    []string
+	timeout time.Duration
+}
+
+func newCommand(cfg config.OutputProcessorConfig) (service.OutputProcessor, error) {
+	if len(cfg.Command) == 0 {
+		return nil, errors.New("command requires command")
+	}
+	return &commandProcessor{name: cfg.Name, argv: cfg.Command, timeout: cfg.Timeout}, nil
+}
+
+func (p *commandProcessor) Name() string { return p.name }
+
+func (p *commandProcessor) Process(ctx context.Context, content string, info service.OutputInfo) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p.argv[0], p.argv[1:]...)
+	cmd.Stdin = strings.NewReader(content)
+	cmd.Env = append(os.Environ(),
+		"NGOCLAW_OUTPUT_CHANNEL="+info.Channel,
+		"NGOCLAW_OUTPUT_MODEL="+info.Model,
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("timed out after %s", p.timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	if strings.TrimSpace(stdout.String()) == "" {
+		return "", errors.New("command produced no output")
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
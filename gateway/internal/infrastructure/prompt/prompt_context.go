@@ -18,6 +18,10 @@ type PromptContext struct {
 	// Populated from tool.Definition.Description at runtime.
 	ToolSummaries map[string]string
 
+	// ToolCategories maps tool name → category (read, edit, execute, mcp ...).
+	// When set, the Tooling section groups tools by category.
+	ToolCategories map[string]string
+
 	// ModelName is the current LLM model identifier (e.g. "bailian/qwen3-max")
 	ModelName string
 
@@ -30,11 +34,29 @@ type PromptContext struct {
 	// UserRules is optional user-defined rules from config.yaml
 	UserRules string
 
+	// Vars are per-chat values for {{name}} placeholders in prompt files
+	// (set via /setvar); they override the engine defaults (agent.prompt_vars).
+	Vars map[string]string
+
+	// AgentName and Persona describe the named agent the chat switched to
+	// (/agent switch). The persona follows the soul; empty adds nothing.
+	AgentName string
+	Persona   string
+
+	// Language is the language the agent must reply in ("zh", "en"): the
+	// chat's /lang choice or the detected language of the user's messages.
+	// Empty adds no language directive.
+	Language string
+
 	// MaxTokenBudget is the maximum tokens to allocate for system prompt.
 	// Components are loaded by priority until budget is exhausted.
 	// 0 means unlimited.
 	MaxTokenBudget int
 
+	// SkipMemory leaves out daily logs / MEMORY.md, so the prompt does not
+	// depend on what happened in earlier sessions (used by evals).
+	SkipMemory bool
+
 	// DetectedIntent is auto-populated by AnalyzeIntent()
 	DetectedIntent TaskIntent
 
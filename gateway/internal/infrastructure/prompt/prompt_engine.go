@@ -1,9 +1,12 @@
 package prompt
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -44,6 +47,9 @@ type PromptEngine struct {
 	logger    *zap.Logger
 	mu        sync.RWMutex
 
+	// Default values for {{name}} placeholders (agent.prompt_vars), see prompt_vars.go
+	vars map[string]string
+
 	// Assembly cache: avoids re-assembling identical prompts within the same session.
 	// Key: "channel|model|intent|focusLen|userRulesLen"
 	// Invalidated on Reload() and Discover().
@@ -247,13 +253,15 @@ func (e *PromptEngine) Discover() error {
 // Assembly order:
 //  1. Core SOUL (always first — highest attention)
 //  2. Channel SOUL (if exists)
+//     Agent persona (if the chat switched to a named agent)
 //  3. Runtime environment block (OS, time, model, workspace)
 //  4. Matched variant (model-specific rules)
 //  5. Shared components + channel components (merged, sorted by priority)
 //  6. Long-term memory
 //  7. Focus chain
 //  8. User rules (from config)
-//  9. Token budget truncation if needed
+//  9. Response language directive
+//  10. Token budget truncation if needed
 func (e *PromptEngine) Assemble(ctx PromptContext) string {
 	// Auto-detect intent from user message
 	if ctx.DetectedIntent == IntentGeneral && ctx.UserMessage != "" {
@@ -271,18 +279,26 @@ func (e *PromptEngine) Assemble(ctx PromptContext) string {
 
 	var sections []string
 
+	// {{name}} placeholders in prompt files: per-chat values override config defaults
+	vars := mergeVars(e.vars, ctx.Vars)
+
 	// 1. Core SOUL — always first
 	if e.soul != "" {
-		sections = append(sections, e.soul)
+		sections = append(sections, ExpandVars(e.soul, vars))
 	}
 
 	// 2. Channel SOUL — appends to core soul
 	if ctx.Channel != "" {
 		if channelSoul, ok := e.channelSouls[ctx.Channel]; ok {
-			sections = append(sections, channelSoul)
+			sections = append(sections, ExpandVars(channelSoul, vars))
 		}
 	}
 
+	// 2b. Agent persona — refines the soul for the chat's named agent
+	if persona := strings.TrimSpace(ctx.Persona); persona != "" {
+		sections = append(sections, "## Agent Persona\n\nYou are acting as the agent \""+ctx.AgentName+"\".\n\n"+ExpandVars(persona, vars))
+	}
+
 	// 3. Runtime environment block
 	runtimeBlock := BuildRuntimeBlock(RuntimeBlockOptions{
 		Channel:   ctx.Channel,
@@ -299,7 +315,7 @@ func (e *PromptEngine) Assemble(ctx PromptContext) string {
 	// 4. Model variant
 	variant := e.matchVariant(ctx.ModelName)
 	if variant != nil {
-		sections = append(sections, variant.Content)
+		sections = append(sections, ExpandVars(variant.Content, vars))
 	}
 
 	// 5. Merge shared components + channel components
@@ -336,12 +352,14 @@ func (e *PromptEngine) Assemble(ctx PromptContext) string {
 	})
 
 	for _, comp := range merged {
-		sections = append(sections, comp.Content)
+		sections = append(sections, ExpandVars(comp.Content, vars))
 	}
 
 	// 6. Long-term Memory
-	if memContent := e.loadMemoryFiles(ctx); memContent != "" {
-		sections = append(sections, memContent)
+	if !ctx.SkipMemory {
+		if memContent := e.loadMemoryFiles(ctx); memContent != "" {
+			sections = append(sections, memContent)
+		}
 	}
 
 	// 7. Focus Chain
@@ -354,6 +372,11 @@ func (e *PromptEngine) Assemble(ctx PromptContext) string {
 		sections = append(sections, "## User Custom Rules\n"+ctx.UserRules)
 	}
 
+	// 8b. Response language — last, so it wins over the language of the soul and components
+	if langSection := buildLanguageSection(ctx.Language); langSection != "" {
+		sections = append(sections, langSection)
+	}
+
 	// 9. Assemble with separators
 	result := strings.Join(sections, "\n\n---\n\n")
 
@@ -385,15 +408,28 @@ func buildToolingSection(ctx PromptContext) string {
 
 	// Section 1: Tool availability table
 	sb.WriteString("## Tooling\n\n")
-	sb.WriteString("Tool availability (filtered by policy). Names are case-sensitive.\n\n")
-
-	for _, name := range ctx.RegisteredTools {
-		if summary, ok := ctx.ToolSummaries[name]; ok && summary != "" {
-			// Truncate to first sentence for brevity
-			brief := firstSentence(summary)
-			sb.WriteString("- " + name + ": " + brief + "\n")
-		} else {
-			sb.WriteString("- " + name + "\n")
+	if len(ctx.ToolCategories) == 0 {
+		sb.WriteString("Tool availability (filtered by policy). Names are case-sensitive.\n\n")
+		writeToolList(&sb, ctx.RegisteredTools, ctx.ToolSummaries)
+	} else {
+		sb.WriteString("Tool availability (filtered by policy), grouped by category. Names are case-sensitive.\n")
+		byCategory := make(map[string][]string)
+		for _, name := range ctx.RegisteredTools {
+			byCategory[ctx.ToolCategories[name]] = append(byCategory[ctx.ToolCategories[name]], name)
+		}
+		order := append([]string{}, toolpkg.ToolCategoryOrder...)
+		for cat := range byCategory {
+			if !slices.Contains(order, cat) {
+				order = append(order, cat)
+			}
+		}
+		for _, cat := range order {
+			names := byCategory[cat]
+			if len(names) == 0 {
+				continue
+			}
+			sb.WriteString("\n### " + categoryTitle(cat) + "\n")
+			writeToolList(&sb, names, ctx.ToolSummaries)
 		}
 	}
 
@@ -410,6 +446,50 @@ func buildToolingSection(ctx PromptContext) string {
 	return sb.String()
 }
 
+// buildLanguageSection generates the "## Response Language" directive, so
+// the reply does not drift into the language of the prompt, tool output or
+// earlier turns.
+func buildLanguageSection(lang string) string {
+	var name string
+	switch lang {
+	case "zh":
+		name = "Simplified Chinese (简体中文)"
+	case "en":
+		name = "English"
+	default:
+		return ""
+	}
+	return "## Response Language\n\n" +
+		"Always reply in " + name + ": answers, progress updates, questions and summaries, " +
+		"even when these instructions, tool output or earlier messages use another language. " +
+		"Keep code, commands, file paths, identifiers and quoted output unchanged. " +
+		"Switch only when the user explicitly asks for another language."
+}
+
+// writeToolList writes one "- name: first sentence" line per tool.
+func writeToolList(sb *strings.Builder, names []string, summaries map[string]string) {
+	for _, name := range names {
+		if summary, ok := summaries[name]; ok && summary != "" {
+			// Truncate to first sentence for brevity
+			brief := firstSentence(summary)
+			sb.WriteString("- " + name + ": " + brief + "\n")
+		} else {
+			sb.WriteString("- " + name + "\n")
+		}
+	}
+}
+
+// categoryTitle turns a tool category into a heading ("read" → "Read", "mcp" → "MCP").
+func categoryTitle(cat string) string {
+	switch cat {
+	case "":
+		return "Other"
+	case "mcp":
+		return "MCP"
+	}
+	return strings.ToUpper(cat[:1]) + cat[1:]
+}
+
 // firstSentence extracts the first sentence from a description string.
 // Truncates at first period, newline, or 80 chars, whichever comes first.
 func firstSentence(s string) string {
@@ -604,6 +684,64 @@ func (e *PromptEngine) HasSoul() bool {
 	return e.soul != ""
 }
 
+// Version returns a short fingerprint of the loaded prompt files (souls,
+// components, variants). Runtime sections (time, workspace, memory) are
+// excluded so that the same prompt files always give the same version —
+// used by evals to compare results across prompt edits.
+func (e *PromptEngine) Version() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	h := sha256.New()
+	write := func(parts ...string) {
+		for _, p := range parts {
+			h.Write([]byte(p))
+			h.Write([]byte{0})
+		}
+	}
+	write("soul", e.soul)
+
+	channels := make([]string, 0, len(e.channelSouls)+len(e.channelComps))
+	seen := make(map[string]bool)
+	for ch := range e.channelSouls {
+		channels = append(channels, ch)
+		seen[ch] = true
+	}
+	for ch := range e.channelComps {
+		if !seen[ch] {
+			channels = append(channels, ch)
+		}
+	}
+	sort.Strings(channels)
+	for _, ch := range channels {
+		write("channel", ch, e.channelSouls[ch])
+		for _, comp := range sortedComponents(e.channelComps[ch]) {
+			write(comp.Name, comp.Content)
+		}
+	}
+
+	for _, comp := range sortedComponents(e.components) {
+		write("component", comp.Name, comp.Content)
+	}
+
+	prefixes := make([]string, 0, len(e.variants))
+	for prefix := range e.variants {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		write("variant", prefix, e.variants[prefix].Content)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:8]
+}
+
+func sortedComponents(comps []*PromptComponent) []*PromptComponent {
+	sorted := append([]*PromptComponent(nil), comps...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
 // Reload reloads all prompt files from disk (hot-reload support)
 func (e *PromptEngine) Reload() error {
 	e.logger.Info("Reloading prompt engine")
@@ -0,0 +1,453 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// LintSeverity ranks a lint finding.
+type LintSeverity string
+
+const (
+	LintError   LintSeverity = "error"   // the prompt will not load as the author intended
+	LintWarning LintSeverity = "warning" // probably unintended, worth a look
+)
+
+// Lint rule identifiers (stable, used in machine-readable output).
+const (
+	LintRuleDuplicateName = "duplicate_name"
+	LintRuleUnknownTool   = "unknown_tool"
+	LintRuleOverBudget    = "over_budget"
+	LintRuleContradiction = "contradiction"
+)
+
+// LintIssue is one finding of PromptEngine.Lint.
+type LintIssue struct {
+	Severity  LintSeverity `json:"severity"`
+	Rule      string       `json:"rule"`
+	Component string       `json:"component"`
+	Layer     string       `json:"layer"`
+	File      string       `json:"file"`
+	Message   string       `json:"message"`
+}
+
+// LintOptions configures PromptEngine.Lint.
+type LintOptions struct {
+	// RegisteredTools are the tool names known to the runtime. Nil skips the
+	// unknown_tool rule (e.g. when tools could not be initialised).
+	RegisteredTools []string
+
+	// MaxSectionTokens flags a single soul/component/variant above this
+	// estimate (0 = DefaultLintSectionTokens).
+	MaxSectionTokens int
+
+	// MaxTotalTokens flags a channel whose static prompt (souls + all
+	// components + largest variant) exceeds this estimate (0 = DefaultLintTotalTokens).
+	MaxTotalTokens int
+}
+
+const (
+	DefaultLintSectionTokens = 2000
+	DefaultLintTotalTokens   = 12000
+)
+
+// lintSection is one prompt file as seen by the linter.
+type lintSection struct {
+	comp  *PromptComponent
+	layer string // system, workspace, channel:<name>
+	kind  string // soul, component, variant
+}
+
+// Lint checks the prompt files of every layer without loading them into the
+// engine. Unlike Discover it keeps overridden components, so duplicate names
+// across layers can be reported. Issues are sorted errors first.
+func (e *PromptEngine) Lint(opts LintOptions) []LintIssue {
+	if opts.MaxSectionTokens <= 0 {
+		opts.MaxSectionTokens = DefaultLintSectionTokens
+	}
+	if opts.MaxTotalTokens <= 0 {
+		opts.MaxTotalTokens = DefaultLintTotalTokens
+	}
+
+	sections := e.lintSections()
+
+	var issues []LintIssue
+	issues = append(issues, lintDuplicates(sections)...)
+	if opts.RegisteredTools != nil {
+		issues = append(issues, lintRequiredTools(sections, opts.RegisteredTools)...)
+	}
+	issues = append(issues, lintBudget(sections, opts)...)
+	issues = append(issues, lintContradictions(sections)...)
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Severity != issues[j].Severity {
+			return issues[i].Severity == LintError
+		}
+		return issues[i].File < issues[j].File
+	})
+	return issues
+}
+
+// lintSections reads souls, components and variants of all layers in
+// Discover order. Directories are not created.
+func (e *PromptEngine) lintSections() []lintSection {
+	var sections []lintSection
+
+	addSoul := func(path, layer string) {
+		data, err := os.ReadFile(path)
+		if err != nil || strings.TrimSpace(string(data)) == "" {
+			return
+		}
+		sections = append(sections, lintSection{
+			comp:  &PromptComponent{Name: "soul", Content: strings.TrimSpace(string(data)), FilePath: path},
+			layer: layer,
+			kind:  "soul",
+		})
+	}
+	addDir := func(dir, layer, kind string) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+				continue
+			}
+			comp, err := ParsePromptFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				continue
+			}
+			if kind == "variant" {
+				// Variants are matched by file name, not frontmatter name
+				comp.Name = strings.TrimSuffix(entry.Name(), ".md")
+			}
+			sections = append(sections, lintSection{comp: comp, layer: layer, kind: kind})
+		}
+	}
+
+	layers := []struct{ name, dir string }{{"system", e.systemDir}}
+	if e.wsDir != "" {
+		layers = append(layers, struct{ name, dir string }{"workspace", e.wsDir})
+	}
+	for _, l := range layers {
+		addSoul(filepath.Join(l.dir, "soul.md"), l.name)
+		addDir(filepath.Join(l.dir, "prompts"), l.name, "component")
+		addDir(filepath.Join(l.dir, "prompts", "variants"), l.name, "variant")
+	}
+	for _, channel := range []string{"cli", "telegram"} {
+		layer := "channel:" + channel
+		addSoul(filepath.Join(e.systemDir, channel, "soul.md"), layer)
+		addDir(filepath.Join(e.systemDir, channel, "prompts"), layer, "component")
+	}
+	return sections
+}
+
+func lintIssue(s lintSection, sev LintSeverity, rule, msg string) LintIssue {
+	return LintIssue{
+		Severity:  sev,
+		Rule:      rule,
+		Component: s.comp.Name,
+		Layer:     s.layer,
+		File:      s.comp.FilePath,
+		Message:   msg,
+	}
+}
+
+// lintDuplicates reports components sharing a name. Within one layer only
+// one of them survives (directory order decides), so that is an error;
+// across layers the later layer replaces the earlier one, which is the
+// override mechanism but easy to trigger by accident.
+func lintDuplicates(sections []lintSection) []LintIssue {
+	type key struct{ kind, name string }
+	seen := make(map[key][]lintSection)
+	for _, s := range sections {
+		if s.kind == "soul" {
+			continue
+		}
+		k := key{s.kind, s.comp.Name}
+		seen[k] = append(seen[k], s)
+	}
+
+	var issues []LintIssue
+	for _, s := range sections {
+		prev := seen[key{s.kind, s.comp.Name}]
+		for _, p := range prev {
+			if p.comp == s.comp {
+				break // only compare against earlier sections
+			}
+			switch {
+			case p.layer == s.layer && strings.HasPrefix(s.layer, "channel:"):
+				// Channel components are not merged by name: both end up in the prompt
+				issues = append(issues, lintIssue(s, LintError, LintRuleDuplicateName,
+					"name \""+s.comp.Name+"\" is also used by "+p.comp.FilePath+"; both are loaded"))
+			case p.layer == s.layer:
+				issues = append(issues, lintIssue(s, LintError, LintRuleDuplicateName,
+					"name \""+s.comp.Name+"\" is also used by "+p.comp.FilePath+" in the same layer; only one of them is loaded"))
+			default:
+				issues = append(issues, lintIssue(s, LintWarning, LintRuleDuplicateName,
+					s.kind+" \""+s.comp.Name+"\" overrides "+p.comp.FilePath+" ("+p.layer+")"))
+			}
+		}
+	}
+	return issues
+}
+
+// lintRequiredTools reports requires entries naming tools that are not
+// registered. A component that needs an unknown tool never loads.
+func lintRequiredTools(sections []lintSection, registered []string) []LintIssue {
+	known := make(map[string]bool, len(registered))
+	for _, t := range registered {
+		known[t] = true
+	}
+
+	var issues []LintIssue
+	for _, s := range sections {
+		req := s.comp.Requires
+		if req == nil {
+			continue
+		}
+		for _, t := range req.Tools {
+			if !known[t] {
+				issues = append(issues, lintIssue(s, LintError, LintRuleUnknownTool,
+					"requires.tools references unregistered tool \""+t+"\"; the component never loads"))
+			}
+		}
+		var unknown []string
+		for _, t := range req.AnyTool {
+			if !known[t] {
+				unknown = append(unknown, t)
+			}
+		}
+		switch {
+		case len(unknown) == 0:
+		case len(unknown) == len(req.AnyTool):
+			issues = append(issues, lintIssue(s, LintError, LintRuleUnknownTool,
+				"requires.any_tool lists only unregistered tools ("+strings.Join(unknown, ", ")+"); the component never loads"))
+		default:
+			issues = append(issues, lintIssue(s, LintWarning, LintRuleUnknownTool,
+				"requires.any_tool references unregistered tools: "+strings.Join(unknown, ", ")))
+		}
+	}
+	return issues
+}
+
+// estimatePromptTokens uses the same conservative ratio as Assemble's
+// budget truncation (1 token ≈ 3 chars).
+func estimatePromptTokens(s string) int {
+	return len(s) / 3
+}
+
+// lintBudget reports oversized sections and channels whose static prompt
+// would already exceed the total budget before memory and runtime blocks.
+func lintBudget(sections []lintSection, opts LintOptions) []LintIssue {
+	var issues []LintIssue
+	for _, s := range sections {
+		if tokens := estimatePromptTokens(s.comp.Content); tokens > opts.MaxSectionTokens {
+			issues = append(issues, lintIssue(s, LintWarning, LintRuleOverBudget,
+				s.kind+" is ~"+strconv.Itoa(tokens)+" tokens, over the "+strconv.Itoa(opts.MaxSectionTokens)+" section budget"))
+		}
+	}
+
+	for _, channel := range []string{"cli", "telegram"} {
+		effective := effectiveSections(sections, channel)
+		total, largestVariant := 0, 0
+		for _, s := range effective {
+			tokens := estimatePromptTokens(s.comp.Content)
+			if s.kind == "variant" {
+				if tokens > largestVariant {
+					largestVariant = tokens
+				}
+				continue
+			}
+			total += tokens
+		}
+		total += largestVariant
+		if total > opts.MaxTotalTokens {
+			issues = append(issues, LintIssue{
+				Severity: LintWarning,
+				Rule:     LintRuleOverBudget,
+				Layer:    "channel:" + channel,
+				Message:  "static prompt for " + channel + " is ~" + strconv.Itoa(total) + " tokens, over the " + strconv.Itoa(opts.MaxTotalTokens) + " total budget",
+			})
+		}
+	}
+	return issues
+}
+
+// effectiveSections applies the Discover/Assemble merge rules for one
+// channel: the workspace soul replaces the system soul, later layers replace
+// same-name components, channel components replace shared ones.
+func effectiveSections(sections []lintSection, channel string) []lintSection {
+	channelLayer := "channel:" + channel
+	var soul *lintSection
+	byName := make(map[string]lintSection)
+	var order []string
+	var extra []lintSection
+
+	for i, s := range sections {
+		if strings.HasPrefix(s.layer, "channel:") && s.layer != channelLayer {
+			continue
+		}
+		switch {
+		case s.kind == "soul" && s.layer == channelLayer:
+			extra = append(extra, s) // channel soul is appended, not replacing
+		case s.kind == "soul":
+			soul = &sections[i]
+		default:
+			k := s.kind + "/" + s.comp.Name
+			if _, ok := byName[k]; !ok {
+				order = append(order, k)
+			}
+			byName[k] = s
+		}
+	}
+
+	var out []lintSection
+	if soul != nil {
+		out = append(out, *soul)
+	}
+	out = append(out, extra...)
+	for _, k := range order {
+		out = append(out, byName[k])
+	}
+	return out
+}
+
+// ─── Contradiction heuristic ───
+
+var (
+	positiveDirective   = regexp.MustCompile(`\b(?:always|must(?: always)?|should always)\s+([a-z][a-z0-9_\- ']*)`)
+	negativeDirective   = regexp.MustCompile(`\b(?:never|must not|mustn't|do not|don't|should not|shouldn't|avoid)\s+([a-z][a-z0-9_\- ']*)`)
+	positiveDirectiveZH = regexp.MustCompile(`(?:必须|总是|务必|一律)\s*([\p{Han}A-Za-z0-9_]+)`)
+	negativeDirectiveZH = regexp.MustCompile(`(?:不要|禁止|切勿|不得|不能|避免|严禁)\s*([\p{Han}A-Za-z0-9_]+)`)
+)
+
+// directiveWords is how many leading words of a directive are compared;
+// enough to tell "use emoji" from "use tables", short enough to match
+// "never use emoji in replies" with "always use emoji".
+const directiveWords = 2
+
+// directiveRunesZH is the CJK equivalent (no word boundaries).
+const directiveRunesZH = 4
+
+type directive struct {
+	positive bool
+	key      string
+	line     string
+}
+
+// extractDirectives finds "always X" / "never X" style lines.
+func extractDirectives(content string) []directive {
+	var out []directive
+	for _, line := range strings.Split(content, "\n") {
+		lower := strings.ToLower(strings.TrimSpace(line))
+		if lower == "" {
+			continue
+		}
+		for _, m := range negativeDirective.FindAllStringSubmatch(lower, -1) {
+			out = append(out, directive{positive: false, key: directiveKey(m[1]), line: strings.TrimSpace(line)})
+		}
+		for _, m := range negativeDirectiveZH.FindAllStringSubmatch(lower, -1) {
+			out = append(out, directive{positive: false, key: directiveKeyZH(m[1]), line: strings.TrimSpace(line)})
+		}
+		// Strip negatives first so "must not use X" is not read as "must ..."
+		stripped := negativeDirective.ReplaceAllString(lower, "")
+		for _, m := range positiveDirective.FindAllStringSubmatch(stripped, -1) {
+			out = append(out, directive{positive: true, key: directiveKey(m[1]), line: strings.TrimSpace(line)})
+		}
+		for _, m := range positiveDirectiveZH.FindAllStringSubmatch(lower, -1) {
+			out = append(out, directive{positive: true, key: directiveKeyZH(m[1]), line: strings.TrimSpace(line)})
+		}
+	}
+	return out
+}
+
+func directiveKey(phrase string) string {
+	words := strings.FieldsFunc(phrase, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-'
+	})
+	if len(words) > directiveWords {
+		words = words[:directiveWords]
+	}
+	return strings.Join(words, " ")
+}
+
+func directiveKeyZH(phrase string) string {
+	runes := []rune(phrase)
+	if len(runes) > directiveRunesZH {
+		runes = runes[:directiveRunesZH]
+	}
+	return string(runes)
+}
+
+// lintContradictions pairs "always X" with "never X" between sections that
+// can be loaded together (a section and its override never are). This is a
+// heuristic: it only catches directives phrased with the same leading words.
+func lintContradictions(sections []lintSection) []LintIssue {
+	type located struct {
+		directive
+		section lintSection
+	}
+	var all []located
+	for _, s := range sections {
+		for _, d := range extractDirectives(s.comp.Content) {
+			if d.key != "" {
+				all = append(all, located{d, s})
+			}
+		}
+	}
+
+	var issues []LintIssue
+	reported := make(map[string]bool)
+	for _, pos := range all {
+		if !pos.positive {
+			continue
+		}
+		for _, neg := range all {
+			if neg.positive || neg.key != pos.key || !coLoadable(pos.section, neg.section) {
+				continue
+			}
+			id := pos.section.comp.FilePath + "|" + neg.section.comp.FilePath + "|" + pos.key
+			if reported[id] {
+				continue
+			}
+			reported[id] = true
+			where := "in " + neg.section.comp.FilePath
+			if neg.section.comp == pos.section.comp {
+				where = "in the same file"
+			}
+			issues = append(issues, lintIssue(pos.section, LintWarning, LintRuleContradiction,
+				"\""+pos.line+"\" conflicts with \""+neg.line+"\" "+where))
+		}
+	}
+	return issues
+}
+
+// coLoadable reports whether two sections can end up in the same prompt.
+func coLoadable(a, b lintSection) bool {
+	if a.comp == b.comp {
+		return true
+	}
+	// Different channels are never assembled together
+	if strings.HasPrefix(a.layer, "channel:") && strings.HasPrefix(b.layer, "channel:") && a.layer != b.layer {
+		return false
+	}
+	// Variants exclude each other
+	if a.kind == "variant" && b.kind == "variant" {
+		return false
+	}
+	// The workspace soul replaces the system soul; channel souls are appended
+	if a.kind == "soul" && b.kind == "soul" {
+		return strings.HasPrefix(a.layer, "channel:") || strings.HasPrefix(b.layer, "channel:")
+	}
+	// A same-name override replaces the other section
+	if a.kind == b.kind && a.comp.Name == b.comp.Name {
+		return false
+	}
+	return true
+}
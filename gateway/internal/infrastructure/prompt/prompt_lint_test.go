@@ -0,0 +1,75 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func writePromptFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPromptEngineLint(t *testing.T) {
+	sys := t.TempDir()
+	ws := t.TempDir()
+
+	writePromptFile(t, filepath.Join(sys, "prompts", "style.md"), "---\nname: style\n---\nAlways use emoji in replies.")
+	writePromptFile(t, filepath.Join(ws, "prompts", "style.md"), "---\nname: style\n---\nNever use emoji.")
+	writePromptFile(t, filepath.Join(sys, "prompts", "browser.md"), "---\nname: browser\nrequires:\n  tools: [browser_navigate]\n---\nUse the browser.")
+	writePromptFile(t, filepath.Join(sys, "prompts", "tone.md"), "---\nname: tone\n---\nNever use emoji, keep it plain.\n不要使用表格。")
+	writePromptFile(t, filepath.Join(sys, "telegram", "prompts", "format.md"), "必须使用表格展示数据。")
+	writePromptFile(t, filepath.Join(sys, "prompts", "big.md"), strings.Repeat("word ", 1300))
+
+	e := &PromptEngine{systemDir: sys, wsDir: ws, logger: zap.NewNop()}
+	issues := e.Lint(LintOptions{RegisteredTools: []string{"bash"}})
+
+	has := func(rule, component, substr string) bool {
+		for _, is := range issues {
+			if is.Rule == rule && is.Component == component && strings.Contains(is.Message, substr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !has(LintRuleDuplicateName, "style", "overrides") {
+		t.Error("expected cross-layer duplicate for style")
+	}
+	if !has(LintRuleUnknownTool, "browser", "browser_navigate") {
+		t.Error("expected unknown tool for browser")
+	}
+	if !has(LintRuleOverBudget, "big", "section budget") {
+		t.Error("expected over-budget section for big")
+	}
+	// system style (always use emoji) conflicts with tone (never use emoji)...
+	if !has(LintRuleContradiction, "style", "Never use emoji, keep it plain.") {
+		t.Errorf("expected style/tone contradiction, got %+v", issues)
+	}
+	// ...but not with the workspace style that replaces it
+	if has(LintRuleContradiction, "style", "\"Never use emoji.\"") {
+		t.Error("overridden component must not be reported as contradicting its override")
+	}
+	if !has(LintRuleContradiction, "format", "不要使用表格") {
+		t.Errorf("expected CJK contradiction, got %+v", issues)
+	}
+	if issues[0].Severity != LintError {
+		t.Errorf("expected errors first, got %+v", issues[0])
+	}
+
+	// Without a tool list the unknown_tool rule is skipped
+	for _, is := range e.Lint(LintOptions{}) {
+		if is.Rule == LintRuleUnknownTool {
+			t.Errorf("unexpected %+v", is)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package prompt
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Prompt variables: soul.md, channel souls, components and variants may use
+// {{name}} or {{name|default}} (same syntax as templates). Values come from
+// agent.prompt_vars and, per chat, from /setvar. Names are case-insensitive.
+// A placeholder with neither a value nor a default is left as written, so
+// literal braces in prompt files survive.
+
+// MaxPromptVarValue is the longest value accepted for one variable.
+const MaxPromptVarValue = 500
+
+var promptVarNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_\-]{0,31}$`)
+
+// NormalizeVarName validates a variable name and returns its lookup key.
+func NormalizeVarName(name string) (string, error) {
+	if !promptVarNameRe.MatchString(name) {
+		return "", fmt.Errorf("invalid variable name %q: use letters, digits, _ or -, up to 32 characters", name)
+	}
+	return strings.ToLower(name), nil
+}
+
+// SetVars sets the default variable values (agent.prompt_vars).
+// Per-request values in PromptContext.Vars take precedence.
+func (e *PromptEngine) SetVars(vars map[string]string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.vars = make(map[string]string, len(vars))
+	for k, v := range vars {
+		e.vars[strings.ToLower(k)] = v
+	}
+}
+
+// Vars returns a copy of the default variable values.
+func (e *PromptEngine) Vars() map[string]string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return mergeVars(e.vars, nil)
+}
+
+// mergeVars returns base overridden by over, with lower-cased keys.
+func mergeVars(base, over map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(over))
+	for k, v := range base {
+		merged[strings.ToLower(k)] = v
+	}
+	for k, v := range over {
+		merged[strings.ToLower(k)] = v
+	}
+	return merged
+}
+
+// ExpandVars substitutes {{name}} / {{name|default}} placeholders in s.
+func ExpandVars(s string, vars map[string]string) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+	return templateVarRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := templateVarRe.FindStringSubmatch(m)
+		if v, ok := vars[strings.ToLower(sub[1])]; ok {
+			return v
+		}
+		if strings.Contains(m, "|") {
+			return strings.TrimSpace(sub[2])
+		}
+		return m
+	})
+}
@@ -0,0 +1,50 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestExpandVars(t *testing.T) {
+	vars := map[string]string{"team": "Payments", "env": "staging"}
+	got := ExpandVars("You help {{Team}} on {{ env }}. Reply in {{lang|English}}. Keep {{unknown}} and {{.Go}}.", vars)
+	want := "You help Payments on staging. Reply in English. Keep {{unknown}} and {{.Go}}."
+	if got != want {
+		t.Errorf("ExpandVars = %q, want %q", got, want)
+	}
+
+	if _, err := NormalizeVarName("deploy env"); err == nil {
+		t.Error("name with a space accepted")
+	}
+	if name, err := NormalizeVarName("Deploy_Env"); err != nil || name != "deploy_env" {
+		t.Errorf("NormalizeVarName = %q, %v", name, err)
+	}
+}
+
+func TestAssembleExpandsVars(t *testing.T) {
+	sys := t.TempDir()
+	os.MkdirAll(filepath.Join(sys, "prompts"), 0755)
+	os.WriteFile(filepath.Join(sys, "soul.md"), []byte("You are the assistant of team {{team}}."), 0644)
+	os.WriteFile(filepath.Join(sys, "prompts", "deploy.md"), []byte("Deploy only to {{env|dev}}."), 0644)
+
+	e := &PromptEngine{systemDir: sys, logger: zap.NewNop()}
+	if err := e.Discover(); err != nil {
+		t.Fatal(err)
+	}
+	e.SetVars(map[string]string{"team": "Platform", "env": "staging"})
+
+	out := e.Assemble(PromptContext{Channel: "telegram", SkipMemory: true})
+	if !strings.Contains(out, "team Platform.") || !strings.Contains(out, "Deploy only to staging.") {
+		t.Errorf("config defaults not applied:\n%s", out)
+	}
+
+	// 会话变量覆盖配置默认值
+	out = e.Assemble(PromptContext{Channel: "telegram", SkipMemory: true, Vars: map[string]string{"team": "Payments"}})
+	if !strings.Contains(out, "team Payments.") || !strings.Contains(out, "Deploy only to staging.") {
+		t.Errorf("chat vars not applied:\n%s", out)
+	}
+}
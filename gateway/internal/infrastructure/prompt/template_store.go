@@ -0,0 +1,206 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// templateVarRe matches {{name}} and {{name|default value}}.
+var templateVarRe = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_\-]*)\s*(?:\|([^}]*))?\}\}`)
+
+// Template is a saved user prompt with {{variables}}, loaded from
+// ~/.ngoclaw/templates/<name>.md. An optional frontmatter may set
+// "description:"; otherwise the first non-empty body line is used.
+type Template struct {
+	Name        string
+	Description string
+	Body        string
+	Variables   []string          // in order of first appearance
+	Defaults    map[string]string // from {{name|default}}
+}
+
+// TemplateStore lists and loads templates from a directory.
+// Files are re-read on every call so edits apply without restart.
+type TemplateStore struct {
+	dir string
+}
+
+// NewTemplateStore creates a store; dir defaults to ~/.ngoclaw/templates.
+func NewTemplateStore(dir string) *TemplateStore {
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".ngoclaw", "templates")
+	}
+	return &TemplateStore{dir: dir}
+}
+
+// Dir returns the templates directory.
+func (s *TemplateStore) Dir() string {
+	return s.dir
+}
+
+// List returns all templates sorted by name. A missing directory yields none.
+func (s *TemplateStore) List() ([]*Template, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var result []*Template
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".md") {
+			continue
+		}
+		t, err := loadTemplate(filepath.Join(s.dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// Get loads a template by name.
+func (s *TemplateStore) Get(name string) (*Template, error) {
+	name = strings.TrimSuffix(strings.TrimSpace(name), ".md")
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("invalid template name %q", name)
+	}
+	t, err := loadTemplate(filepath.Join(s.dir, name+".md"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("template %q not found in %s", name, s.dir)
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+func loadTemplate(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	content := strings.ReplaceAll(string(data), "\r\n", "\n")
+	t := &Template{Name: fileBaseName(path), Defaults: make(map[string]string)}
+
+	if strings.HasPrefix(content, "---\n") {
+		if end := strings.Index(content[4:], "\n---"); end >= 0 {
+			for _, line := range strings.Split(content[4:4+end], "\n") {
+				if k, v, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(k) == "description" {
+					t.Description = strings.Trim(strings.TrimSpace(v), `"'`)
+				}
+			}
+			content = strings.TrimPrefix(content[4+end+4:], "\n")
+		}
+	}
+	t.Body = strings.TrimSpace(content)
+
+	if t.Description == "" {
+		for _, line := range strings.Split(t.Body, "\n") {
+			if line = strings.TrimSpace(strings.TrimLeft(line, "# ")); line != "" {
+				t.Description = line
+				break
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, m := range templateVarRe.FindAllStringSubmatch(t.Body, -1) {
+		name := m[1]
+		if !seen[name] {
+			seen[name] = true
+			t.Variables = append(t.Variables, name)
+		}
+		if m[2] != "" {
+			if _, ok := t.Defaults[name]; !ok {
+				t.Defaults[name] = strings.TrimSpace(m[2])
+			}
+		}
+	}
+	return t, nil
+}
+
+// Missing returns the variables that have neither a value nor a default.
+func (t *Template) Missing(vars map[string]string) []string {
+	var missing []string
+	for _, v := range t.Variables {
+		if _, ok := vars[v]; ok {
+			continue
+		}
+		if _, ok := t.Defaults[v]; ok {
+			continue
+		}
+		missing = append(missing, v)
+	}
+	return missing
+}
+
+// Render substitutes variables; unknown ones fall back to defaults or stay empty.
+func (t *Template) Render(vars map[string]string) string {
+	return templateVarRe.ReplaceAllStringFunc(t.Body, func(m string) string {
+		sub := templateVarRe.FindStringSubmatch(m)
+		if v, ok := vars[sub[1]]; ok {
+			return v
+		}
+		if d, ok := t.Defaults[sub[1]]; ok {
+			return d
+		}
+		return ""
+	})
+}
+
+// ParseTemplateArgs parses `key=value` pairs (values may be "quoted").
+// Bare words without '=' are returned separately.
+func ParseTemplateArgs(raw string) (vars map[string]string, rest []string) {
+	vars = make(map[string]string)
+	for _, tok := range splitQuoted(raw) {
+		if k, v, ok := strings.Cut(tok, "="); ok && k != "" {
+			vars[k] = strings.Trim(v, `"'`)
+			continue
+		}
+		rest = append(rest, tok)
+	}
+	return vars, rest
+}
+
+// splitQuoted splits on whitespace, keeping "quoted strings" (with spaces) together.
+func splitQuoted(s string) []string {
+	var (
+		out   []string
+		cur   strings.Builder
+		quote rune
+	)
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			cur.WriteRune(r)
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			cur.WriteRune(r)
+		case r == ' ' || r == '\t' || r == '\n':
+			if cur.Len() > 0 {
+				out = append(out, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		out = append(out, cur.String())
+	}
+	return out
+}
@@ -0,0 +1,52 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTemplateStore(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "deploy-checklist.md"), []byte(`---
+description: Pre-deploy checks
+---
+Run the deploy checklist for {{env}} on branch {{branch|main}}.
+Notify {{owner}} when {{env}} is done.`), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0644)
+
+	store := NewTemplateStore(dir)
+	list, err := store.List()
+	if err != nil || len(list) != 1 {
+		t.Fatalf("List() = %v, %v", list, err)
+	}
+
+	tpl, err := store.Get("deploy-checklist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tpl.Description != "Pre-deploy checks" {
+		t.Errorf("description = %q", tpl.Description)
+	}
+	if !reflect.DeepEqual(tpl.Variables, []string{"env", "branch", "owner"}) {
+		t.Errorf("variables = %v", tpl.Variables)
+	}
+
+	vars, _ := ParseTemplateArgs(`env=prod owner="ops team"`)
+	if missing := tpl.Missing(vars); len(missing) != 0 {
+		t.Errorf("unexpected missing %v", missing)
+	}
+	want := "Run the deploy checklist for prod on branch main.\nNotify ops team when prod is done."
+	if got := tpl.Render(vars); got != want {
+		t.Errorf("Render() = %q", got)
+	}
+
+	if missing := tpl.Missing(map[string]string{"env": "prod"}); !reflect.DeepEqual(missing, []string{"owner"}) {
+		t.Errorf("missing = %v", missing)
+	}
+
+	if _, err := store.Get("../etc/passwd"); err == nil {
+		t.Error("path traversal should be rejected")
+	}
+}
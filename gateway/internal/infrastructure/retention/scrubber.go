@@ -0,0 +1,358 @@
+// Package retention enforces data-retention policies (retention.* config):
+// per-chat TTLs for stored messages, transcripts, artifacts and long-term
+// memory, a periodic scrub job, and the full purge of one chat (/forgetme).
+package retention
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/repository"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	toolpkg "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tool"
+	"go.uber.org/zap"
+)
+
+// telegramSource prefixes the transcript source of Telegram runs ("telegram:<chatID>").
+const telegramSource = "telegram:"
+
+// TranscriptPurger removes transcript runs matching a predicate (transcript.Writer.Purge / PurgeDir).
+type TranscriptPurger func(match func(source string, startedAt time.Time) bool) (int, error)
+
+// Stores 需要清理的数据源; 为空的字段跳过。
+// 长期记忆 (memory.json 与每日日志) 总是参与清理。
+type Stores struct {
+	Messages    repository.MessageRepository
+	Feedback    repository.FeedbackRepository
+	Transcripts TranscriptPurger
+	ArtifactDir string // research 报告目录, 按 chat 分子目录 (toolpkg.ChatArtifactDir)
+}
+
+// Report 一次清理删除的记录数 (按数据类别)
+type Report struct {
+	Messages    int64
+	Feedback    int64
+	Transcripts int
+	Artifacts   int
+	MemoryFacts int
+	MemoryLogs  int
+}
+
+// Total 删除的记录总数
+func (r Report) Total() int {
+	return int(r.Messages+r.Feedback) + r.Transcripts + r.Artifacts + r.MemoryFacts + r.MemoryLogs
+}
+
+// Scrubber 按保留策略清理过期数据, 并执行 /forgetme 的整 chat 清除
+type Scrubber struct {
+	cfg    config.RetentionConfig
+	stores Stores
+	logger *zap.Logger
+	now    func() time.Time
+
+	mu   sync.Mutex // 串行化清理 (定时任务与 /forgetme)
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewScrubber 创建清理器
+func NewScrubber(cfg config.RetentionConfig, stores Stores, logger *zap.Logger) *Scrubber {
+	return &Scrubber{
+		cfg:    cfg,
+		stores: stores,
+		logger: logger.With(zap.String("component", "retention")),
+		now:    time.Now,
+	}
+}
+
+// Start 立即执行一次清理, 之后每 ScrubInterval 执行一次; 间隔为 0 或没有
+// 任何保留天数时不启动
+func (s *Scrubber) Start() {
+	if s.cfg.ScrubInterval <= 0 || !s.hasPolicy() {
+		return
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.cfg.ScrubInterval)
+		defer ticker.Stop()
+		for {
+			s.sweepLogged()
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	s.logger.Info("Retention scrubber started", zap.Duration("interval", s.cfg.ScrubInterval))
+}
+
+// Stop 停止定时清理并等待进行中的清理结束
+func (s *Scrubber) Stop() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+	s.stop = nil
+}
+
+func (s *Scrubber) sweepLogged() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	report, err := s.Sweep(ctx)
+	if err != nil {
+		s.logger.Warn("Retention sweep incomplete", zap.Error(err))
+	}
+	if report.Total() > 0 {
+		s.logger.Info("Retention sweep removed expired data", reportFields(report)...)
+	}
+}
+
+// hasPolicy 是否配置了任何保留天数
+func (s *Scrubber) hasPolicy() bool {
+	policies := []config.RetentionPolicy{s.cfg.Default}
+	for _, p := range s.cfg.Chats {
+		policies = append(policies, p)
+	}
+	for _, p := range policies {
+		if p.Messages > 0 || p.Transcripts > 0 || p.Artifacts > 0 || p.Memory > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Sweep 删除超出保留期的数据。各数据源独立清理, 单个失败不影响其他数据源,
+// 错误合并返回。
+func (s *Scrubber) Sweep(ctx context.Context) (Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var report Report
+	var errs []error
+	now := s.now()
+	cutoff := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+	expired := func(chat string, days func(config.RetentionPolicy) int, t time.Time) bool {
+		d := days(s.cfg.PolicyFor(chat))
+		return d > 0 && t.Before(cutoff(d))
+	}
+
+	// 消息与反馈: 默认策略覆盖没有单独策略的会话, 其余会话逐个按自身策略清理
+	overridden := make([]string, 0, len(s.cfg.Chats))
+	for chat := range s.cfg.Chats {
+		overridden = append(overridden, chat)
+	}
+	filters := []repository.PurgeFilter{}
+	if d := s.cfg.Default.Messages; d > 0 {
+		filters = append(filters, repository.PurgeFilter{Before: cutoff(d), Except: overridden})
+	}
+	for _, chat := range overridden {
+		if d := s.cfg.PolicyFor(chat).Messages; d > 0 {
+			filters = append(filters, repository.PurgeFilter{Before: cutoff(d), Chats: []string{chat}})
+		}
+	}
+	for _, f := range filters {
+		if s.stores.Messages != nil {
+			n, err := s.stores.Messages.Purge(ctx, f)
+			report.Messages += n
+			errs = append(errs, err)
+		}
+		if s.stores.Feedback != nil {
+			n, err := s.stores.Feedback.Purge(ctx, f)
+			report.Feedback += n
+			errs = append(errs, err)
+		}
+	}
+
+	if s.stores.Transcripts != nil {
+		n, err := s.stores.Transcripts(func(source string, started time.Time) bool {
+			return expired(transcriptChat(source), transcriptDays, started)
+		})
+		report.Transcripts = n
+		errs = append(errs, err)
+	}
+
+	if s.stores.ArtifactDir != "" {
+		n, err := s.sweepArtifacts(func(chat string, mod time.Time) bool {
+			return expired(chat, artifactDays, mod)
+		})
+		report.Artifacts = n
+		errs = append(errs, err)
+	}
+
+	facts, err := toolpkg.PurgeMemoryFacts(func(f toolpkg.MemoryFact) bool {
+		created, err := time.Parse(time.RFC3339, f.CreatedAt)
+		return err == nil && expired(factChat(f), memoryDays, created)
+	}, "retention")
+	report.MemoryFacts = len(facts)
+	errs = append(errs, err)
+
+	logs, err := toolpkg.PurgeDailyLogs(func(day time.Time, text string) bool {
+		chat := ""
+		if id, ok := toolpkg.DailyLogChat(text); ok {
+			chat = strconv.FormatInt(id, 10)
+		}
+		// 每日日志只有日期, 整天超出保留期才删除
+		return expired(chat, memoryDays, day.AddDate(0, 0, 1))
+	})
+	report.MemoryLogs = logs
+	errs = append(errs, err)
+
+	return report, errors.Join(errs...)
+}
+
+// ForgetChat 删除与 chatID 相关的全部持久化数据: 消息、反馈、转录、产物、
+// 该 chat 中记下的长期记忆与会话记忆日志
+func (s *Scrubber) ForgetChat(ctx context.Context, chatID int64) (Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var report Report
+	var errs []error
+	chat := strconv.FormatInt(chatID, 10)
+	only := repository.PurgeFilter{Chats: []string{chat}}
+
+	if s.stores.Messages != nil {
+		n, err := s.stores.Messages.Purge(ctx, only)
+		report.Messages = n
+		errs = append(errs, err)
+	}
+	if s.stores.Feedback != nil {
+		n, err := s.stores.Feedback.Purge(ctx, only)
+		report.Feedback = n
+		errs = append(errs, err)
+	}
+	if s.stores.Transcripts != nil {
+		n, err := s.stores.Transcripts(func(source string, _ time.Time) bool {
+			return source == telegramSource+chat
+		})
+		report.Transcripts = n
+		errs = append(errs, err)
+	}
+	if s.stores.ArtifactDir != "" {
+		dir := toolpkg.ChatArtifactDir(s.stores.ArtifactDir, chatID)
+		report.Artifacts = countFiles(dir)
+		if err := os.RemoveAll(dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	facts, err := toolpkg.PurgeMemoryFacts(func(f toolpkg.MemoryFact) bool {
+		return f.ChatID == chatID
+	}, "forgetme:"+chat)
+	report.MemoryFacts = len(facts)
+	errs = append(errs, err)
+
+	logs, err := toolpkg.PurgeDailyLogs(func(_ time.Time, text string) bool {
+		id, ok := toolpkg.DailyLogChat(text)
+		return ok && id == chatID
+	})
+	report.MemoryLogs = logs
+	errs = append(errs, err)
+
+	err = errors.Join(errs...)
+	s.logger.Info("Chat data purged", append(reportFields(report), zap.Int64("chat_id", chatID), zap.Error(err))...)
+	return report, err
+}
+
+// sweepArtifacts 删除过期产物文件: 根目录下的文件按默认策略, chat-<id>
+// 子目录按该 chat 的策略; 清空的子目录一并删除
+func (s *Scrubber) sweepArtifacts(expired func(chat string, mod time.Time) bool) (int, error) {
+	root := s.stores.ArtifactDir
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	removed := 0
+	var errs []error
+	remove := func(path, chat string) {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() || !expired(chat, info.ModTime()) {
+			return
+		}
+		if err := os.Remove(path); err != nil {
+			errs = append(errs, err)
+			return
+		}
+		removed++
+	}
+	for _, e := range entries {
+		path := filepath.Join(root, e.Name())
+		chat, isChat := strings.CutPrefix(e.Name(), "chat-")
+		if !e.IsDir() {
+			remove(path, "")
+			continue
+		}
+		if !isChat {
+			continue
+		}
+		files, err := os.ReadDir(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, f := range files {
+			remove(filepath.Join(path, f.Name()), chat)
+		}
+		if rest, _ := os.ReadDir(path); len(rest) == 0 {
+			os.Remove(path)
+		}
+	}
+	return removed, errors.Join(errs...)
+}
+
+// transcriptChat 转录来源对应的会话键: telegram:<id> → <id>, 其他来源原样返回
+func transcriptChat(source string) string {
+	if chat, ok := strings.CutPrefix(source, telegramSource); ok {
+		return chat
+	}
+	return source
+}
+
+// factChat 记忆事实所属会话键; 未关联 chat 的事实使用默认策略
+func factChat(f toolpkg.MemoryFact) string {
+	if f.ChatID == 0 {
+		return ""
+	}
+	return strconv.FormatInt(f.ChatID, 10)
+}
+
+func transcriptDays(p config.RetentionPolicy) int { return p.Transcripts }
+func artifactDays(p config.RetentionPolicy) int   { return p.Artifacts }
+func memoryDays(p config.RetentionPolicy) int     { return p.Memory }
+
+// countFiles 统计目录下的文件数 (不存在时为 0)
+func countFiles(dir string) int {
+	n := 0
+	filepath.WalkDir(dir, func(_ string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			n++
+		}
+		return nil
+	})
+	return n
+}
+
+func reportFields(r Report) []zap.Field {
+	return []zap.Field{
+		zap.Int64("messages", r.Messages),
+		zap.Int64("feedback", r.Feedback),
+		zap.Int("transcripts", r.Transcripts),
+		zap.Int("artifacts", r.Artifacts),
+		zap.Int("memory_facts", r.MemoryFacts),
+		zap.Int("memory_logs", r.MemoryLogs),
+	}
+}
@@ -0,0 +1,202 @@
+package retention
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/repository"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/valueobject"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/persistence"
+	toolpkg "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tool"
+	"go.uber.org/zap"
+)
+
+// fixture 两个 chat (1 使用默认策略, 2 永久保留) 的消息、转录、产物与记忆
+type fixture struct {
+	messages    repository.MessageRepository
+	artifacts   string
+	transcripts map[string]bool // source → 仍存在
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	ctx := context.Background()
+
+	f := &fixture{
+		messages:    persistence.NewMemoryMessageRepository(),
+		artifacts:   t.TempDir(),
+		transcripts: map[string]bool{"telegram:1": true, "telegram:2": true},
+	}
+	for _, chat := range []string{"1", "2"} {
+		msg, err := entity.NewMessage("m"+chat, chat,
+			valueobject.NewMessageContent("hi", valueobject.ContentTypeText),
+			valueobject.NewUser(chat, "u", "telegram"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.messages.Save(ctx, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, path := range []string{
+		filepath.Join(f.artifacts, "shared.md"),
+		filepath.Join(toolpkg.ChatArtifactDir(f.artifacts, 1), "a.md"),
+		filepath.Join(toolpkg.ChatArtifactDir(f.artifacts, 2), "b.md"),
+	} {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte("dossier"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, chat := range []int64{1, 2} {
+		if _, err := toolpkg.AddMemoryFact("fact from "+strings.Repeat("x", int(chat)), "fact", "test", chat); err != nil {
+			t.Fatal(err)
+		}
+	}
+	toolpkg.AppendDailyLog("[session] Chat 1 (2 msgs)\n  user: hello")
+	toolpkg.AppendDailyLog("[session] Chat 2 (2 msgs)\n  user: bonjour")
+	return f
+}
+
+func (f *fixture) stores() Stores {
+	return Stores{
+		Messages:    f.messages,
+		ArtifactDir: f.artifacts,
+		Transcripts: func(match func(string, time.Time) bool) (int, error) {
+			n := 0
+			for source, alive := range f.transcripts {
+				if alive && match(source, time.Now()) {
+					f.transcripts[source] = false
+					n++
+				}
+			}
+			return n, nil
+		},
+	}
+}
+
+func (f *fixture) countMessages(t *testing.T, chat string) int {
+	t.Helper()
+	msgs, err := f.messages.FindByConversationID(context.Background(), chat, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(msgs)
+}
+
+func factChats(t *testing.T) []int64 {
+	t.Helper()
+	store, err := toolpkg.LoadMemoryStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chats []int64
+	for _, f := range store.Facts {
+		chats = append(chats, f.ChatID)
+	}
+	return chats
+}
+
+func dailyLogs(t *testing.T) string {
+	t.Helper()
+	home, _ := os.UserHomeDir()
+	files, _ := filepath.Glob(filepath.Join(home, ".ngoclaw", "memory", "*.md"))
+	var all strings.Builder
+	for _, path := range files {
+		data, _ := os.ReadFile(path)
+		all.Write(data)
+	}
+	return all.String()
+}
+
+func TestScrubber_SweepAppliesPerChatPolicy(t *testing.T) {
+	f := newFixture(t)
+	cfg := config.RetentionConfig{
+		Default: config.RetentionPolicy{Messages: 30, Transcripts: 30, Artifacts: 30, Memory: 30},
+		Chats: map[string]config.RetentionPolicy{
+			"2": {Messages: -1, Transcripts: -1, Artifacts: -1, Memory: -1},
+		},
+	}
+	s := NewScrubber(cfg, f.stores(), zap.NewNop())
+
+	// 当前时间的数据都在保留期内
+	report, err := s.Sweep(context.Background())
+	if err != nil || report.Total() != 0 {
+		t.Fatalf("fresh data swept: %+v %v", report, err)
+	}
+
+	s.now = func() time.Time { return time.Now().AddDate(0, 0, 40) }
+	report, err = s.Sweep(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 日志中的 "[memory] …" 行不带 chat, 按默认策略清理 (事实本身仍保留)
+	want := Report{Messages: 1, Transcripts: 1, Artifacts: 2, MemoryFacts: 1, MemoryLogs: 2}
+	if report != want {
+		t.Fatalf("report = %+v, want %+v", report, want)
+	}
+
+	if f.countMessages(t, "1") != 0 || f.countMessages(t, "2") != 1 {
+		t.Error("messages not purged per policy")
+	}
+	if f.transcripts["telegram:1"] || !f.transcripts["telegram:2"] {
+		t.Errorf("transcripts = %v", f.transcripts)
+	}
+	if _, err := os.Stat(filepath.Join(f.artifacts, "shared.md")); !os.IsNotExist(err) {
+		t.Error("default-policy artifact kept")
+	}
+	if _, err := os.Stat(toolpkg.ChatArtifactDir(f.artifacts, 1)); !os.IsNotExist(err) {
+		t.Error("emptied chat artifact dir kept")
+	}
+	if _, err := os.Stat(filepath.Join(toolpkg.ChatArtifactDir(f.artifacts, 2), "b.md")); err != nil {
+		t.Error("keep-forever artifact removed")
+	}
+	if chats := factChats(t); len(chats) != 1 || chats[0] != 2 {
+		t.Errorf("remaining fact chats = %v", chats)
+	}
+	logs := dailyLogs(t)
+	if strings.Contains(logs, "Chat 1 ") || strings.Contains(logs, "fact from x\n") || !strings.Contains(logs, "Chat 2 ") {
+		t.Errorf("daily logs after sweep:\n%s", logs)
+	}
+}
+
+func TestScrubber_ForgetChat(t *testing.T) {
+	f := newFixture(t)
+	s := NewScrubber(config.RetentionConfig{}, f.stores(), zap.NewNop())
+
+	report, err := s.ForgetChat(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Report{Messages: 1, Transcripts: 1, Artifacts: 1, MemoryFacts: 1, MemoryLogs: 1}
+	if report != want {
+		t.Fatalf("report = %+v, want %+v", report, want)
+	}
+
+	if f.countMessages(t, "1") != 0 || f.countMessages(t, "2") != 1 {
+		t.Error("ForgetChat touched other chats' messages")
+	}
+	if f.transcripts["telegram:1"] || !f.transcripts["telegram:2"] {
+		t.Errorf("transcripts = %v", f.transcripts)
+	}
+	if _, err := os.Stat(toolpkg.ChatArtifactDir(f.artifacts, 1)); !os.IsNotExist(err) {
+		t.Error("chat artifacts kept")
+	}
+	if _, err := os.Stat(filepath.Join(f.artifacts, "shared.md")); err != nil {
+		t.Error("unattributed artifact removed")
+	}
+	if chats := factChats(t); len(chats) != 1 || chats[0] != 2 {
+		t.Errorf("remaining fact chats = %v", chats)
+	}
+	logs := dailyLogs(t)
+	if strings.Contains(logs, "hello") || !strings.Contains(logs, "bonjour") {
+		t.Errorf("daily logs after forget:\n%s", logs)
+	}
+}
@@ -0,0 +1,79 @@
+package sandbox
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// wrapWithLimits 通过 bash 的 ulimit 为子进程设置 rlimit, 再 exec 目标命令.
+// Go 的 exec 无法直接为子进程设置 rlimit, 而在 gateway 进程上 Setrlimit
+// 会影响自身, 所以借助一层 shell: 限制只作用于该命令及其子孙进程.
+// 未设置任何限制时原样返回.
+func (s *ProcessSandbox) wrapWithLimits(cmdPath string, args []string) (string, []string) {
+	script := limitScript(s.config)
+	if script == "" {
+		return cmdPath, args
+	}
+	shell, err := exec.LookPath("bash")
+	if err != nil {
+		s.logger.Warn("bash not found, running without resource limits")
+		return cmdPath, args
+	}
+	return shell, append([]string{"-c", script + `exec "$0" "$@"`, cmdPath}, args...)
+}
+
+// limitScript 生成 ulimit 前缀. 每项失败 (如硬限制已更低) 时忽略并继续,
+// 此时已有的更严格限制依然生效.
+func limitScript(cfg *Config) string {
+	var b strings.Builder
+	add := func(flag string, value int64) {
+		if value > 0 {
+			fmt.Fprintf(&b, "ulimit %s %d 2>/dev/null; ", flag, value)
+		}
+	}
+	add("-t", int64(cfg.CPUSeconds))   // RLIMIT_CPU, 秒
+	add("-d", cfg.MemoryLimit/1024)    // RLIMIT_DATA, KB (堆 + 私有匿名映射)
+	add("-u", int64(cfg.MaxProcesses)) // RLIMIT_NPROC, 按用户计数
+	return b.String()
+}
+
+// cappedBuffer 只保留前 max 字节的输出, 其余丢弃并计数.
+// Write 始终报告全部写入成功, 避免子进程因 EPIPE 提前退出.
+type cappedBuffer struct {
+	buf     bytes.Buffer
+	max     int
+	dropped int64
+}
+
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	if c.max <= 0 {
+		return c.buf.Write(p)
+	}
+	room := c.max - c.buf.Len()
+	if room <= 0 {
+		c.dropped += int64(len(p))
+		return len(p), nil
+	}
+	if len(p) > room {
+		c.buf.Write(p[:room])
+		c.dropped += int64(len(p) - room)
+		return len(p), nil
+	}
+	return c.buf.Write(p)
+}
+
+// Truncated 是否有输出被丢弃
+func (c *cappedBuffer) Truncated() bool {
+	return c.dropped > 0
+}
+
+// String 返回保留的输出, 截断时追加标记
+func (c *cappedBuffer) String() string {
+	if c.dropped == 0 {
+		return c.buf.String()
+	}
+	return c.buf.String() + "\n... [output truncated: " + strconv.FormatInt(c.dropped, 10) + " bytes omitted]"
+}
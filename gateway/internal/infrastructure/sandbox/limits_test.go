@@ -0,0 +1,60 @@
+package sandbox
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func newTestSandbox(t *testing.T, mutate func(*Config)) *ProcessSandbox {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.WorkDir = t.TempDir()
+	cfg.TempDir = t.TempDir()
+	cfg.Timeout = 10 * time.Second
+	mutate(cfg)
+	sbx, err := NewProcessSandbox(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sbx
+}
+
+func TestExecute_AppliesRlimits(t *testing.T) {
+	sbx := newTestSandbox(t, func(c *Config) {
+		c.CPUSeconds = 7
+		c.MemoryLimit = 256 << 20
+	})
+
+	res, err := sbx.ExecuteShell(context.Background(), "ulimit -t; ulimit -d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(res.Stdout); len(got) != 2 || got[0] != "7" || got[1] != "262144" {
+		t.Errorf("limits inside sandbox = %q, want [7 262144]", res.Stdout)
+	}
+}
+
+func TestExecute_CapsOutput(t *testing.T) {
+	sbx := newTestSandbox(t, func(c *Config) { c.MaxOutput = 100 })
+
+	res, err := sbx.ExecuteShell(context.Background(), "head -c 5000 /dev/zero | tr '\\0' x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Truncated {
+		t.Error("expected Truncated")
+	}
+	if !strings.HasPrefix(res.Stdout, strings.Repeat("x", 100)+"\n... [output truncated: 4900 bytes omitted]") {
+		t.Errorf("stdout = %q", res.Stdout)
+	}
+}
+
+func TestLimitScript_Empty(t *testing.T) {
+	if got := limitScript(&Config{}); got != "" {
+		t.Errorf("limitScript with no limits = %q, want empty", got)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"os"
+	"syscall"
+)
+
+const networkIsolationSupported = true
+
+// isolateNetwork 让子进程进入新的 user + network namespace: 只有 loopback,
+// 无法访问外网. 以当前 uid/gid 自映射, 不需要 root, 但要求内核允许
+// 非特权 user namespace (kernel.unprivileged_userns_clone=1).
+func isolateNetwork(attr *syscall.SysProcAttr) {
+	attr.Cloneflags |= syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET
+	attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+	attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+	attr.GidMappingsEnableSetgroups = false
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package sandbox
+
+import "syscall"
+
+// 网络命名空间隔离仅支持 Linux, 其他平台 EnableNetwork=false 不生效
+const networkIsolationSupported = false
+
+func isolateNetwork(attr *syscall.SysProcAttr) {}
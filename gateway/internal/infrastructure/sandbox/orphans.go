@@ -0,0 +1,86 @@
+package sandbox
+
+import (
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// orphanKey 命令的进程组或终端的会话
+type orphanKey struct {
+	id      int
+	session bool
+}
+
+// trackGroup 记录命令结束后仍有成员的进程组 (后台作业、nohup、守护进程等),
+// 由 ReapOrphans 在宽限期后清理
+func (s *ProcessSandbox) trackGroup(pgid int) {
+	if pgid <= 0 || syscall.Kill(-pgid, 0) != nil {
+		return
+	}
+	s.trackOrphan(orphanKey{id: pgid})
+}
+
+// trackSession 记录 shell 已退出但仍有进程的终端会话
+func (s *ProcessSandbox) trackSession(sid int) {
+	if sid <= 0 || len(sessionProcesses(sid)) == 0 {
+		return
+	}
+	s.trackOrphan(orphanKey{id: sid, session: true})
+}
+
+func (s *ProcessSandbox) trackOrphan(k orphanKey) {
+	s.orphanMu.Lock()
+	defer s.orphanMu.Unlock()
+	if s.orphans == nil {
+		s.orphans = make(map[orphanKey]time.Time)
+	}
+	if _, ok := s.orphans[k]; !ok {
+		s.orphans[k] = time.Now()
+	}
+}
+
+// ReapOrphans 杀死命令 / 终端结束超过 grace 后仍在运行的进程, 返回清理的
+// 进程组与会话数. 已经没有进程的记录直接丢弃, 避免进程号复用后误杀
+func (s *ProcessSandbox) ReapOrphans(grace time.Duration) int {
+	s.orphanMu.Lock()
+	defer s.orphanMu.Unlock()
+
+	n := 0
+	now := time.Now()
+	for k, ended := range s.orphans {
+		var pids []int
+		if k.session {
+			pids = sessionProcesses(k.id)
+			if len(pids) == 0 {
+				delete(s.orphans, k)
+				continue
+			}
+		} else if syscall.Kill(-k.id, 0) != nil {
+			delete(s.orphans, k)
+			continue
+		}
+		if now.Sub(ended) < grace {
+			continue
+		}
+
+		kind := "process_group"
+		if k.session {
+			kind = "terminal_session"
+			for _, pid := range pids {
+				_ = syscall.Kill(pid, syscall.SIGKILL)
+			}
+		} else {
+			_ = syscall.Kill(-k.id, syscall.SIGKILL)
+		}
+		s.logger.Info("Killed orphaned sandbox processes",
+			zap.String("kind", kind),
+			zap.Int("id", k.id),
+			zap.Duration("since_exit", now.Sub(ended)),
+		)
+		delete(s.orphans, k)
+		n++
+	}
+	return n
+}
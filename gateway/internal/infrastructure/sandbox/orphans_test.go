@@ -0,0 +1,56 @@
+package sandbox
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReapOrphans_KillsLeftoverProcessGroup(t *testing.T) {
+	sbx := newTestSandbox(t, func(*Config) {})
+
+	// 后台进程在命令结束后继续运行 (输出重定向, 否则 Run 会等它关闭 stdout)
+	res, err := sbx.ExecuteShell(context.Background(), "sleep 30 >/dev/null 2>&1 & echo $!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(res.Stdout))
+	if err != nil {
+		t.Fatalf("pid: %q", res.Stdout)
+	}
+	t.Cleanup(func() { syscall.Kill(pid, syscall.SIGKILL) })
+
+	if n := sbx.ReapOrphans(time.Hour); n != 0 {
+		t.Errorf("reaped %d within the grace period", n)
+	}
+	if syscall.Kill(pid, 0) != nil {
+		t.Fatal("background process died before the grace period ended")
+	}
+	if n := sbx.ReapOrphans(0); n != 1 {
+		t.Errorf("reaped %d, want 1", n)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for syscall.Kill(pid, 0) == nil {
+		// 父进程 (bash) 已退出, sleep 被 init 或子进程收割者回收
+		if time.Now().After(deadline) {
+			t.Fatal("orphaned process still running")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if n := sbx.ReapOrphans(0); n != 0 {
+		t.Errorf("second reap killed %d", n)
+	}
+}
+
+func TestReapOrphans_IgnoresFinishedCommands(t *testing.T) {
+	sbx := newTestSandbox(t, func(*Config) {})
+	if _, err := sbx.ExecuteShell(context.Background(), "true"); err != nil {
+		t.Fatal(err)
+	}
+	if n := sbx.ReapOrphans(0); n != 0 {
+		t.Errorf("reaped %d for a command without leftovers", n)
+	}
+}
@@ -1,13 +1,14 @@
 package sandbox
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -19,8 +20,11 @@ type Config struct {
 	WorkDir       string        // 工作目录
 	Timeout       time.Duration // 执行超时
 	AllowedBins   []string      // 允许的二进制文件
-	MemoryLimit   int64         // 内存限制 (bytes)
-	EnableNetwork bool          // 是否允许网络访问
+	MemoryLimit   int64         // 内存限制 (bytes, RLIMIT_DATA), 0 = 不限制
+	CPUSeconds    int           // CPU 时间上限 (秒, RLIMIT_CPU), 0 = 不限制
+	MaxProcesses  int           // 进程数上限 (RLIMIT_NPROC, 按用户计数), 0 = 不限制
+	MaxOutput     int           // stdout / stderr 各自保留的最大字节数, 0 = 不限制
+	EnableNetwork bool          // 是否允许网络访问 (false 时在 Linux 上隔离网络命名空间)
 	TempDir       string        // 临时文件目录
 	PythonEnv     string        // 全局 Python 环境路径 (conda env / venv 根目录)
 }
@@ -57,7 +61,9 @@ func DefaultConfig() *Config {
 			"systemctl", "journalctl", "docker", "ping", "ip", "ss",
 			"tar", "gzip", "unzip", "rsync",
 		},
-		MemoryLimit:   512 * 1024 * 1024, // 512MB
+		MemoryLimit:   4 << 30, // 4GB, 足够编译大型项目
+		CPUSeconds:    600,
+		MaxOutput:     1 << 20, // 1MB
 		EnableNetwork: true,
 		TempDir:       "/tmp/ngoclaw-sandbox-tmp",
 	}
@@ -67,6 +73,9 @@ func DefaultConfig() *Config {
 type ProcessSandbox struct {
 	config *Config
 	logger *zap.Logger
+
+	orphanMu sync.Mutex
+	orphans  map[orphanKey]time.Time // 结束后仍有进程的进程组 / 会话 → 结束时间, 见 orphans.go
 }
 
 // NewProcessSandbox 创建进程沙箱
@@ -94,11 +103,28 @@ type Result struct {
 	ExitCode int
 	Duration time.Duration
 	Killed   bool // 是否被超时杀死
+	// Truncated 输出超过 MaxOutput 被截断 (Stdout/Stderr 末尾带截断标记)
+	Truncated bool
+}
+
+// ExecOptions 单次执行的可选参数
+type ExecOptions struct {
+	Timeout time.Duration // <= 0 时使用配置的默认超时
+	Stdin   string        // 写入子进程 stdin 的内容
 }
 
 // Execute 执行命令
 func (s *ProcessSandbox) Execute(ctx context.Context, command string, args []string) (*Result, error) {
+	return s.ExecuteWith(ctx, ExecOptions{}, command, args)
+}
+
+// ExecuteWith 按 opts 执行命令 (自定义超时、stdin)
+func (s *ProcessSandbox) ExecuteWith(ctx context.Context, opts ExecOptions, command string, args []string) (*Result, error) {
 	startTime := time.Now()
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = s.config.Timeout
+	}
 
 	// 验证命令是否被允许
 	if !s.isAllowed(command) {
@@ -112,11 +138,12 @@ func (s *ProcessSandbox) Execute(ctx context.Context, command string, args []str
 	}
 
 	// 创建带超时的上下文
-	execCtx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	execCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	// 创建命令
-	cmd := exec.CommandContext(execCtx, cmdPath, args...)
+	// 创建命令 (有资源限制时经 bash ulimit 包装)
+	execPath, execArgs := s.wrapWithLimits(cmdPath, args)
+	cmd := exec.CommandContext(execCtx, execPath, execArgs...)
 	cmd.Dir = s.config.WorkDir
 
 	// 设置环境变量
@@ -125,10 +152,23 @@ func (s *ProcessSandbox) Execute(ctx context.Context, command string, args []str
 	// 设置进程属性 (Linux 进程隔离)
 	cmd.SysProcAttr = s.buildSysProcAttr()
 
-	// 捕获输出
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	// 取消/超时时杀死整个进程组 (子进程在独立进程组, 收不到终端的 Ctrl+C)
+	cmd.Cancel = func() error {
+		if cmd.Process == nil {
+			return nil
+		}
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = 2 * time.Second
+
+	// 捕获输出 (超出上限的部分丢弃)
+	stdout := &cappedBuffer{max: s.config.MaxOutput}
+	stderr := &cappedBuffer{max: s.config.MaxOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if opts.Stdin != "" {
+		cmd.Stdin = strings.NewReader(opts.Stdin)
+	}
 
 	// 执行
 	s.logger.Info("Executing sandboxed command",
@@ -138,11 +178,21 @@ func (s *ProcessSandbox) Execute(ctx context.Context, command string, args []str
 	)
 
 	err = cmd.Run()
+	if cmd.Process != nil {
+		s.trackGroup(cmd.Process.Pid)
+	}
 
 	result := &Result{
-		Stdout:   stdout.String(),
-		Stderr:   stderr.String(),
-		Duration: time.Since(startTime),
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		Duration:  time.Since(startTime),
+		Truncated: stdout.Truncated() || stderr.Truncated(),
+	}
+	if result.Truncated {
+		s.logger.Warn("Command output truncated",
+			zap.String("command", command),
+			zap.Int("max_output", s.config.MaxOutput),
+		)
 	}
 
 	// 检查是否超时
@@ -151,15 +201,35 @@ func (s *ProcessSandbox) Execute(ctx context.Context, command string, args []str
 		result.ExitCode = -1
 		s.logger.Warn("Command killed due to timeout",
 			zap.String("command", command),
-			zap.Duration("timeout", s.config.Timeout),
+			zap.Duration("timeout", timeout),
+		)
+		return result, fmt.Errorf("command timed out after %v", timeout)
+	}
+
+	// 检查是否被调用方取消 (用户中断)
+	if ctx.Err() == context.Canceled {
+		result.Killed = true
+		result.ExitCode = -1
+		s.logger.Info("Command killed due to cancellation",
+			zap.String("command", command),
 		)
-		return result, fmt.Errorf("command timed out after %v", s.config.Timeout)
+		return result, fmt.Errorf("command cancelled")
 	}
 
 	// 获取退出码
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			result.ExitCode = exitErr.ExitCode()
+			// 超过 RLIMIT_CPU 的进程收到 SIGXCPU
+			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() && status.Signal() == syscall.SIGXCPU {
+				result.Killed = true
+				s.logger.Warn("Command killed by CPU limit",
+					zap.String("command", command),
+					zap.Int("cpu_seconds", s.config.CPUSeconds),
+				)
+			}
+		} else if s.isolatesNetwork() && errors.Is(err, syscall.EPERM) {
+			return result, fmt.Errorf("network isolation unavailable (unprivileged user namespaces disabled?): %w", err)
 		} else {
 			return result, fmt.Errorf("execution failed: %w", err)
 		}
@@ -265,11 +335,20 @@ func (s *ProcessSandbox) buildEnvironment() []string {
 
 // buildSysProcAttr 构建进程属性
 func (s *ProcessSandbox) buildSysProcAttr() *syscall.SysProcAttr {
-	return &syscall.SysProcAttr{
+	attr := &syscall.SysProcAttr{
 		// 创建新的进程组
 		Setpgid: true,
 		Pgid:    0,
 	}
+	if s.isolatesNetwork() {
+		isolateNetwork(attr)
+	}
+	return attr
+}
+
+// isolatesNetwork 子进程是否运行在独立网络命名空间
+func (s *ProcessSandbox) isolatesNetwork() bool {
+	return !s.config.EnableNetwork && networkIsolationSupported
 }
 
 // SetWorkDir 设置工作目录
@@ -292,6 +371,11 @@ func (s *ProcessSandbox) GetWorkDir() string {
 	return s.config.WorkDir
 }
 
+// TempDir 返回临时文件目录 (子进程的 TMPDIR)
+func (s *ProcessSandbox) TempDir() string {
+	return s.config.TempDir
+}
+
 // AddAllowedBin 添加允许的二进制
 func (s *ProcessSandbox) AddAllowedBin(bin string) {
 	s.config.AllowedBins = append(s.config.AllowedBins, bin)
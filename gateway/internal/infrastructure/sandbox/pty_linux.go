@@ -0,0 +1,90 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY 分配一对伪终端. slave 关闭回显 (命令不会出现在输出里), 窗口设为
+// 200x50, 避免长行被终端宽度折断.
+func openPTY() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 经 SyscallConn 操作 fd: master.Fd() 会把文件切回阻塞模式, Close 就无法打断 Read
+	var n int
+	var ctlErr error
+	rc, err := master.SyscallConn()
+	if err == nil {
+		err = rc.Control(func(fd uintptr) {
+			if ctlErr = unix.IoctlSetPointerInt(int(fd), unix.TIOCSPTLCK, 0); ctlErr != nil {
+				return
+			}
+			n, ctlErr = unix.IoctlGetInt(int(fd), unix.TIOCGPTN)
+		})
+	}
+	if err == nil {
+		err = ctlErr
+	}
+	if err != nil {
+		master.Close()
+		return nil, nil, fmt.Errorf("unlock pty: %w", err)
+	}
+
+	slave, err = os.OpenFile("/dev/pts/"+strconv.Itoa(n), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	fd := int(slave.Fd())
+	if t, err := unix.IoctlGetTermios(fd, unix.TCGETS); err == nil {
+		t.Lflag &^= unix.ECHO
+		_ = unix.IoctlSetTermios(fd, unix.TCSETS, t)
+	}
+	_ = unix.IoctlSetWinsize(fd, unix.TIOCSWINSZ, &unix.Winsize{Row: 50, Col: 200})
+	return master, slave, nil
+}
+
+// foregroundProcessGroup 返回终端前台进程组 (正在运行的命令), 失败时返回 0
+func foregroundProcessGroup(master *os.File) int {
+	rc, err := master.SyscallConn()
+	if err != nil {
+		return 0
+	}
+	pgrp := 0
+	_ = rc.Control(func(fd uintptr) {
+		pgrp, _ = unix.IoctlGetInt(int(fd), unix.TIOCGPGRP)
+	})
+	return pgrp
+}
+
+// processCwd 读取进程的当前目录
+func processCwd(pid int) string {
+	dir, _ := os.Readlink("/proc/" + strconv.Itoa(pid) + "/cwd")
+	return dir
+}
+
+// sessionProcesses 列出会话 sid 中的进程
+func sessionProcesses(sid int) []int {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+	var pids []int
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		if s, err := unix.Getsid(pid); err == nil && s == sid {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
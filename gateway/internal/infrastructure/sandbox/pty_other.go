@@ -0,0 +1,18 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"errors"
+	"os"
+)
+
+func openPTY() (master, slave *os.File, err error) {
+	return nil, nil, errors.New("terminal sessions require Linux")
+}
+
+func foregroundProcessGroup(master *os.File) int { return 0 }
+
+func processCwd(pid int) string { return "" }
+
+func sessionProcesses(sid int) []int { return nil }
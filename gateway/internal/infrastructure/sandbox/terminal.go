@@ -0,0 +1,387 @@
+package sandbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// maxPendingOutput 未读取输出的积压上限, 超出时丢弃最早的部分
+const maxPendingOutput = 1 << 20
+
+var (
+	// ErrTerminalBusy 上一条命令还没有回到提示符
+	ErrTerminalBusy = errors.New("a command is still running in this terminal")
+	// ErrTerminalIdle 没有正在运行的命令可以接收输入
+	ErrTerminalIdle = errors.New("no command is running in this terminal")
+	// ErrTerminalExited shell 已退出
+	ErrTerminalExited = errors.New("terminal has exited")
+)
+
+// ansiSeq 匹配 CSI / OSC 控制序列与单字符转义
+var ansiSeq = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)|\x1b[()][0-9A-Za-z]|\x1b[=>78]`)
+
+// Terminal 运行在伪终端中的持久交互式 bash.
+// 与 ExecuteShell 的一次性 bash -c 不同, 连续的命令共享 cwd、环境变量、
+// virtualenv 激活等状态, 也可以驱动 REPL 与交互式安装程序.
+//
+// 命令是否结束靠提示符判断: PS1 被设为带随机标记和 $? 的一行, 出现即表示
+// 回到 shell, 同时得到退出码. 同一 Terminal 的操作需由调用方串行化.
+type Terminal struct {
+	cmd    *exec.Cmd
+	pty    *os.File
+	prompt *regexp.Regexp
+	logger *zap.Logger
+
+	mu         sync.Mutex
+	buf        []byte // 未读取的输出 (提示符标记已剔除)
+	dropped    int
+	lastOutput time.Time
+	busy       bool // 命令运行中, 尚未回到提示符
+	exitCode   int
+	exited     bool
+	notify     chan struct{}
+
+	readerDone chan struct{}
+	closeOnce  sync.Once
+	onExit     func() // shell 退出后调用 (登记会话中残留的进程)
+}
+
+// TermOutput 一次等待收集到的输出
+type TermOutput struct {
+	Output   string // 去除控制序列与提示符标记后的输出
+	Done     bool   // 已回到 shell 提示符
+	ExitCode int    // Done 时为最近一条命令的退出码
+	Matched  bool   // WaitOptions.Pattern 已匹配
+	Exited   bool   // shell 已退出, 会话不可再用
+	Dropped  int    // 积压超过上限被丢弃的字节数
+}
+
+// WaitOptions 控制 Wait 何时返回. 回到提示符或 shell 退出时总是立即返回.
+type WaitOptions struct {
+	Timeout time.Duration  // 最长等待
+	Settle  time.Duration  // > 0 时, 有输出且静默这么久即返回 (交互式程序在等输入)
+	Pattern *regexp.Regexp // 输出匹配时返回
+}
+
+// StartTerminal 在伪终端中启动交互式 bash, 环境变量、网络隔离与资源限制同 Execute.
+// workDir 为空时使用沙箱工作目录.
+func (s *ProcessSandbox) StartTerminal(ctx context.Context, workDir string) (*Terminal, error) {
+	if !s.isAllowed("bash") {
+		return nil, fmt.Errorf("command 'bash' is not allowed")
+	}
+	bashPath, err := exec.LookPath("bash")
+	if err != nil {
+		return nil, fmt.Errorf("command not found: bash")
+	}
+	if workDir == "" {
+		workDir = s.config.WorkDir
+	}
+
+	master, slave, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+
+	tag := make([]byte, 6)
+	_, _ = rand.Read(tag)
+	marker := "__ngc_" + hex.EncodeToString(tag)
+
+	// 提示符经 rcfile 设置: 资源限制的 bash -c 包装层是非交互 shell, 会丢掉环境中的 PS1.
+	// 交互式 bash 默认开启 ! 历史展开, 会改写 echo "hi!" 之类的命令, 一并关闭.
+	rc, err := os.CreateTemp(s.config.TempDir, "terminal-rc-*")
+	if err != nil {
+		master.Close()
+		slave.Close()
+		return nil, fmt.Errorf("failed to create terminal rcfile: %w", err)
+	}
+	defer os.Remove(rc.Name())
+	fmt.Fprintf(rc, "PS1='\\n%s_$?__'\nPS2=\nunset HISTFILE\nset +H\n", marker)
+	rc.Close()
+
+	// --noediting: 不用 readline, 输入按行交给内核行规程 (回显已关闭)
+	execPath, execArgs := s.wrapWithLimits(bashPath, []string{"--rcfile", rc.Name(), "--noediting", "-i"})
+	cmd := exec.Command(execPath, execArgs...)
+	cmd.Dir = workDir
+	cmd.Env = append(s.buildEnvironment(), "TERM=dumb", "PAGER=cat", "GIT_PAGER=cat")
+	// 独立会话并以 pty 为控制终端: 作业控制与 Ctrl-C (SIGINT 发给前台进程组) 才能生效.
+	// setsid 后不能再 setpgid, 会话首进程本身就是进程组长.
+	attr := s.buildSysProcAttr()
+	attr.Setpgid = false
+	attr.Setsid = true
+	attr.Setctty = true
+	attr.Ctty = 0
+	cmd.SysProcAttr = attr
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+
+	err = cmd.Start()
+	slave.Close()
+	if err != nil {
+		master.Close()
+		if s.isolatesNetwork() && errors.Is(err, syscall.EPERM) {
+			return nil, fmt.Errorf("network isolation unavailable (unprivileged user namespaces disabled?): %w", err)
+		}
+		return nil, fmt.Errorf("start terminal: %w", err)
+	}
+
+	t := &Terminal{
+		cmd:        cmd,
+		pty:        master,
+		prompt:     regexp.MustCompile(`\r?\n?` + marker + `_(\d+)__`),
+		logger:     s.logger,
+		busy:       true, // 等第一个提示符
+		notify:     make(chan struct{}, 1),
+		readerDone: make(chan struct{}),
+		onExit:     func() { s.trackSession(cmd.Process.Pid) },
+	}
+	go t.readLoop()
+	go t.waitLoop()
+
+	s.logger.Info("Terminal started",
+		zap.Int("pid", cmd.Process.Pid),
+		zap.String("work_dir", workDir),
+	)
+
+	out := t.Wait(ctx, WaitOptions{Timeout: 10 * time.Second})
+	if !out.Done {
+		t.Close()
+		if out.Exited {
+			return nil, fmt.Errorf("terminal exited during startup: %s", strings.TrimSpace(out.Output))
+		}
+		return nil, fmt.Errorf("terminal did not show a prompt: %s", strings.TrimSpace(out.Output))
+	}
+	return t, nil
+}
+
+// Run 执行一行 shell 命令. 多行命令经 eval 作为一个整体执行, 只产生一个提示符.
+// 输出需随后用 Wait 收集.
+func (t *Terminal) Run(command string) error {
+	t.mu.Lock()
+	switch {
+	case t.exited:
+		t.mu.Unlock()
+		return ErrTerminalExited
+	case t.busy:
+		t.mu.Unlock()
+		return ErrTerminalBusy
+	}
+	t.busy = true
+	t.mu.Unlock()
+
+	command = strings.TrimRight(command, "\n")
+	if strings.Contains(command, "\n") {
+		command = "eval '" + strings.ReplaceAll(command, "'", `'\''`) + "'"
+	}
+	return t.write(command + "\n")
+}
+
+// Input 向正在运行的命令 (REPL、安装程序的提问等) 写入文本.
+// shell 空闲时拒绝, 新命令必须经 Run 执行.
+func (t *Terminal) Input(text string) error {
+	t.mu.Lock()
+	switch {
+	case t.exited:
+		t.mu.Unlock()
+		return ErrTerminalExited
+	case !t.busy:
+		t.mu.Unlock()
+		return ErrTerminalIdle
+	}
+	t.mu.Unlock()
+	return t.write(text)
+}
+
+// Interrupt 发送 Ctrl-C, 由终端行规程转为 SIGINT 发给前台进程组
+func (t *Terminal) Interrupt() error {
+	return t.write("\x03")
+}
+
+func (t *Terminal) write(s string) error {
+	if _, err := t.pty.WriteString(s); err != nil {
+		return fmt.Errorf("write terminal: %w", err)
+	}
+	return nil
+}
+
+// Wait 等待并取走到目前为止的输出, 返回条件见 WaitOptions
+func (t *Terminal) Wait(ctx context.Context, opts WaitOptions) TermOutput {
+	deadline := time.NewTimer(opts.Timeout)
+	defer deadline.Stop()
+	var tick <-chan time.Time
+	if opts.Settle > 0 {
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		t.mu.Lock()
+		matched := opts.Pattern != nil && len(t.buf) > 0 && opts.Pattern.MatchString(cleanTerminalOutput(t.buf))
+		settled := opts.Settle > 0 && len(t.buf) > 0 && time.Since(t.lastOutput) >= opts.Settle
+		if !t.busy || t.exited || matched || settled {
+			out := t.takeLocked()
+			out.Matched = matched
+			t.mu.Unlock()
+			return out
+		}
+		t.mu.Unlock()
+
+		select {
+		case <-t.notify:
+		case <-tick:
+		case <-deadline.C:
+			return t.take()
+		case <-ctx.Done():
+			return t.take()
+		}
+	}
+}
+
+func (t *Terminal) take() TermOutput {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.takeLocked()
+}
+
+func (t *Terminal) takeLocked() TermOutput {
+	out := TermOutput{
+		Output:  cleanTerminalOutput(t.buf),
+		Done:    !t.busy && !t.exited,
+		Exited:  t.exited,
+		Dropped: t.dropped,
+	}
+	if out.Done {
+		out.ExitCode = t.exitCode
+	}
+	t.buf, t.dropped = nil, 0
+	return out
+}
+
+// Busy 报告是否有命令在运行
+func (t *Terminal) Busy() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.busy && !t.exited
+}
+
+// Exited 报告 shell 是否已退出
+func (t *Terminal) Exited() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.exited
+}
+
+// Pid 返回 shell 进程号
+func (t *Terminal) Pid() int {
+	return t.cmd.Process.Pid
+}
+
+// Cwd 返回 shell 当前目录 (仅 Linux, 取不到时为空)
+func (t *Terminal) Cwd() string {
+	return processCwd(t.cmd.Process.Pid)
+}
+
+// Close 结束 shell 及其中运行的命令
+func (t *Terminal) Close() error {
+	t.closeOnce.Do(func() {
+		pid := t.cmd.Process.Pid
+		if pg := foregroundProcessGroup(t.pty); pg > 0 && pg != pid {
+			_ = syscall.Kill(-pg, syscall.SIGKILL)
+		}
+		// 交互式 bash 收到 SIGHUP 时会转发给自己的作业再退出
+		_ = syscall.Kill(-pid, syscall.SIGHUP)
+		select {
+		case <-t.readerDone:
+		case <-time.After(2 * time.Second):
+			_ = syscall.Kill(-pid, syscall.SIGKILL)
+		}
+		t.pty.Close()
+		t.logger.Info("Terminal closed", zap.Int("pid", pid))
+	})
+	return nil
+}
+
+// readLoop 持续读取 pty 输出; 提示符标记在这里剔除并更新 busy / exitCode
+func (t *Terminal) readLoop() {
+	defer close(t.readerDone)
+	chunk := make([]byte, 32<<10)
+	for {
+		n, err := t.pty.Read(chunk)
+		if n > 0 {
+			t.mu.Lock()
+			t.buf = append(t.buf, chunk[:n]...)
+			if locs := t.prompt.FindAllSubmatchIndex(t.buf, -1); len(locs) > 0 {
+				last := locs[len(locs)-1]
+				t.exitCode, _ = strconv.Atoi(string(t.buf[last[2]:last[3]]))
+				t.busy = false
+				t.buf = t.prompt.ReplaceAll(t.buf, nil)
+			}
+			if over := len(t.buf) - maxPendingOutput; over > 0 {
+				t.buf = append(t.buf[:0], t.buf[over:]...)
+				t.dropped += over
+			}
+			t.lastOutput = time.Now()
+			t.mu.Unlock()
+			t.signal()
+		}
+		if err != nil {
+			// 所有 slave 端关闭后 Linux 返回 EIO
+			return
+		}
+	}
+}
+
+// waitLoop 回收 shell 进程并标记会话结束
+func (t *Terminal) waitLoop() {
+	_ = t.cmd.Wait()
+	// 留一点时间读完 shell 退出前的输出
+	select {
+	case <-t.readerDone:
+	case <-time.After(200 * time.Millisecond):
+	}
+	t.mu.Lock()
+	t.exited = true
+	t.mu.Unlock()
+	t.signal()
+	if t.onExit != nil {
+		t.onExit()
+	}
+}
+
+func (t *Terminal) signal() {
+	select {
+	case t.notify <- struct{}{}:
+	default:
+	}
+}
+
+// cleanTerminalOutput 去掉控制序列, 并按终端语义处理 \r:
+// 进度条等用 \r 覆盖的行只保留最后一次的内容.
+func cleanTerminalOutput(b []byte) string {
+	s := ansiSeq.ReplaceAllString(string(b), "")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	if !strings.Contains(s, "\r") {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if idx := strings.LastIndex(strings.TrimRight(line, "\r"), "\r"); idx >= 0 {
+			lines[i] = line[idx+1:]
+		} else {
+			lines[i] = strings.TrimRight(line, "\r")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,159 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"regexp"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func startTestTerminal(t *testing.T) *Terminal {
+	t.Helper()
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	sbx := newTestSandbox(t, func(c *Config) {})
+	term, err := sbx.StartTerminal(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { term.Close() })
+	return term
+}
+
+func runTerminal(t *testing.T, term *Terminal, command string) TermOutput {
+	t.Helper()
+	if err := term.Run(command); err != nil {
+		t.Fatal(err)
+	}
+	return term.Wait(context.Background(), WaitOptions{Timeout: 10 * time.Second})
+}
+
+func TestTerminal_KeepsStateBetweenCommands(t *testing.T) {
+	term := startTestTerminal(t)
+
+	runTerminal(t, term, "mkdir -p sub && cd sub && export GREETING='hi!'")
+	out := runTerminal(t, term, `echo "$GREETING from $(basename "$PWD")"`)
+	if !out.Done || out.ExitCode != 0 {
+		t.Fatalf("out = %+v, want finished with exit 0", out)
+	}
+	if got := strings.TrimSpace(out.Output); got != "hi! from sub" {
+		t.Errorf("output = %q, want %q", got, "hi! from sub")
+	}
+	if cwd := term.Cwd(); !strings.HasSuffix(cwd, "/sub") {
+		t.Errorf("Cwd() = %q, want .../sub", cwd)
+	}
+
+	out = runTerminal(t, term, "false")
+	if !out.Done || out.ExitCode != 1 {
+		t.Errorf("exit code = %d (done %v), want 1", out.ExitCode, out.Done)
+	}
+
+	out = runTerminal(t, term, "for i in 1 2; do\n  echo line $i\ndone")
+	if got := strings.TrimSpace(out.Output); got != "line 1\nline 2" {
+		t.Errorf("multi-line output = %q", got)
+	}
+	if err := term.Input("x\n"); !errors.Is(err, ErrTerminalIdle) {
+		t.Errorf("Input on idle shell = %v, want ErrTerminalIdle", err)
+	}
+}
+
+func TestTerminal_InteractiveInputAndInterrupt(t *testing.T) {
+	term := startTestTerminal(t)
+	ctx := context.Background()
+
+	if err := term.Run(`read -p "name? " name; echo "hello $name"`); err != nil {
+		t.Fatal(err)
+	}
+	out := term.Wait(ctx, WaitOptions{Timeout: 10 * time.Second, Pattern: regexp.MustCompile(`name\? $`)})
+	if !out.Matched || out.Done {
+		t.Fatalf("out = %+v, want prompt matched while running", out)
+	}
+	if err := term.Run("echo again"); !errors.Is(err, ErrTerminalBusy) {
+		t.Errorf("Run while busy = %v, want ErrTerminalBusy", err)
+	}
+	if err := term.Input("ngoclaw\n"); err != nil {
+		t.Fatal(err)
+	}
+	out = term.Wait(ctx, WaitOptions{Timeout: 10 * time.Second})
+	if !out.Done || strings.TrimSpace(out.Output) != "hello ngoclaw" {
+		t.Errorf("out = %+v, want finished with greeting", out)
+	}
+
+	if err := term.Run("sleep 30"); err != nil {
+		t.Fatal(err)
+	}
+	if out := term.Wait(ctx, WaitOptions{Timeout: 300 * time.Millisecond}); out.Done {
+		t.Fatal("sleep finished early")
+	}
+	if err := term.Interrupt(); err != nil {
+		t.Fatal(err)
+	}
+	out = term.Wait(ctx, WaitOptions{Timeout: 5 * time.Second})
+	if !out.Done || out.ExitCode != 130 {
+		t.Errorf("after Ctrl-C: %+v, want finished with exit 130", out)
+	}
+}
+
+func TestTerminal_ExitEndsSession(t *testing.T) {
+	term := startTestTerminal(t)
+	out := runTerminal(t, term, "exit 3")
+	if !out.Exited || out.Done {
+		t.Errorf("out = %+v, want exited", out)
+	}
+	if err := term.Run("true"); !errors.Is(err, ErrTerminalExited) {
+		t.Errorf("Run after exit = %v, want ErrTerminalExited", err)
+	}
+}
+
+func TestCleanTerminalOutput(t *testing.T) {
+	in := "\x1b[1;32mok\x1b[0m\r\n 10%\r 50%\r100%\r\ndone"
+	if got, want := cleanTerminalOutput([]byte(in)), "ok\n100%\ndone"; got != want {
+		t.Errorf("cleanTerminalOutput = %q, want %q", got, want)
+	}
+}
+
+func TestTerminal_ReapsProcessesLeftInSession(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	sbx := newTestSandbox(t, func(c *Config) {})
+	term, err := sbx.StartTerminal(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sid := term.Pid()
+
+	// disown 的作业不会收到 shell 转发的 SIGHUP, shell 退出后仍留在会话中
+	runTerminal(t, term, "nohup sleep 30 >/dev/null 2>&1 & disown")
+	term.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for !term.Exited() && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	left := sessionProcesses(sid)
+	if len(left) == 0 {
+		t.Skip("job did not survive the shell")
+	}
+	t.Cleanup(func() {
+		for _, pid := range left {
+			syscall.Kill(pid, syscall.SIGKILL)
+		}
+	})
+
+	if n := sbx.ReapOrphans(0); n != 1 {
+		t.Fatalf("reaped %d, want 1", n)
+	}
+	for len(sessionProcesses(sid)) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("session processes still running")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
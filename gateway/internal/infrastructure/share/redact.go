@@ -0,0 +1,71 @@
+package share
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const mask = "[REDACTED]"
+
+// minSecretLen keeps short config values ("dev", "1234") from blanking
+// ordinary words all over a page.
+const minSecretLen = 6
+
+// secretPatterns match well-known credential formats. Submatches are kept
+// (the key, scheme or URL user), the rest is masked. Keys must end in the
+// secret word, as in config redaction, so "max_tokens: 128000" stays.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{16,}`),
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{30,}`),
+	regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{30,}`),
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+	regexp.MustCompile(`\b\d{8,10}:[A-Za-z0-9_-]{35}\b`), // Telegram bot token
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`),
+	regexp.MustCompile(`(?i)(\b(?:bearer|basic)\s+)[A-Za-z0-9._~+/=-]{12,}`),
+	regexp.MustCompile(`(?i)(\b[a-z0-9_.-]*(?:api[_-]?key|token|secret|password|passwd)["']?\s*[:=]\s*["']?)[^\s"',;&]{6,}`),
+	regexp.MustCompile(`(://[^/:@\s]+:)[^/@\s]+(@)`),
+}
+
+// Redactor masks credentials in text: the gateway's own configured secrets
+// plus common token formats.
+type Redactor struct {
+	literals []string // longest first, so a secret containing another is masked whole
+}
+
+// NewRedactor returns a redactor for the given secret values.
+func NewRedactor(secrets []string) *Redactor {
+	seen := make(map[string]bool)
+	r := &Redactor{}
+	for _, s := range secrets {
+		if len(s) >= minSecretLen && !seen[s] {
+			seen[s] = true
+			r.literals = append(r.literals, s)
+		}
+	}
+	sort.Slice(r.literals, func(i, j int) bool { return len(r.literals[i]) > len(r.literals[j]) })
+	return r
+}
+
+// Redact returns s with secrets replaced by [REDACTED].
+func (r *Redactor) Redact(s string) string {
+	for _, lit := range r.literals {
+		s = strings.ReplaceAll(s, lit, mask)
+	}
+	for _, re := range secretPatterns {
+		s = re.ReplaceAllStringFunc(s, func(m string) string {
+			sub := re.FindStringSubmatch(m)
+			switch len(sub) {
+			case 2:
+				return sub[1] + mask
+			case 3:
+				return sub[1] + mask + sub[2]
+			}
+			return mask
+		})
+	}
+	return s
+}
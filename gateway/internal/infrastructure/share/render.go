@@ -0,0 +1,265 @@
+package share
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+)
+
+const (
+	maxTextChars   = 20000
+	maxOutputChars = 4000
+	maxDiffLines   = 400
+)
+
+// page is the data behind pageTemplate; every string is already redacted.
+type page struct {
+	Title     string
+	Source    string
+	Model     string
+	Started   string
+	Duration  string
+	Steps     int
+	Tokens    int
+	TraceID   string
+	Expires   string
+	Request   string
+	Timeline  []item
+	Diffs     []diff
+	Tests     string
+	TestsOK   bool
+	Error     string
+	Generated string
+}
+
+type item struct {
+	Kind     string // assistant | user | tool
+	Text     string
+	Tool     string
+	Args     string
+	OK       bool
+	Duration string
+}
+
+type diff struct {
+	Path  string
+	Lines []diffLine
+	More  int // lines cut at maxDiffLines
+}
+
+type diffLine struct {
+	Class string // add | del | ctx | hunk
+	Text  string
+}
+
+// Render builds the standalone HTML page for a run. redact is applied to
+// every piece of run text before it reaches the template.
+func Render(t *service.RunTranscript, redact func(string) string, expires, now time.Time) ([]byte, error) {
+	p := page{
+		Title:     "Run " + t.StartedAt.Format("2006-01-02 15:04"),
+		Source:    redact(t.Source),
+		Model:     t.Model,
+		Started:   t.StartedAt.Format("2006-01-02 15:04:05 MST"),
+		Duration:  t.FinishedAt.Sub(t.StartedAt).Round(100 * time.Millisecond).String(),
+		Steps:     t.Steps,
+		Tokens:    t.Tokens,
+		TraceID:   t.TraceID,
+		Expires:   expires.Format("2006-01-02 15:04 MST"),
+		Request:   redact(clip(t.UserMessage, maxTextChars)),
+		Error:     redact(t.Error),
+		Generated: now.Format("2006-01-02 15:04 MST"),
+	}
+	if t.Tests != nil {
+		p.Tests = redact(t.Tests.Summary())
+		p.TestsOK = t.Tests.Passed
+	}
+
+	for _, e := range t.Entries {
+		switch e.Kind {
+		case "assistant", "user":
+			if text := strings.TrimSpace(e.Text); text != "" {
+				p.Timeline = append(p.Timeline, item{Kind: e.Kind, Text: redact(clip(text, maxTextChars))})
+			}
+		case "tool":
+			p.Timeline = append(p.Timeline, item{
+				Kind:     "tool",
+				Tool:     e.Tool,
+				Args:     redact(formatArgs(e.Args)),
+				Text:     redact(clip(strings.TrimSpace(e.Text), maxOutputChars)),
+				OK:       e.Success,
+				Duration: e.Duration.Round(time.Millisecond).String(),
+			})
+			if e.Success {
+				p.Diffs = append(p.Diffs, diffsFor(e.Tool, e.Args, redact)...)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pageTemplate.Execute(&buf, p); err != nil {
+		return nil, fmt.Errorf("render share page: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// diffsFor reconstructs the change an edit tool call made from its arguments.
+func diffsFor(tool string, args map[string]interface{}, redact func(string) string) []diff {
+	str := func(k string) string { s, _ := args[k].(string); return redact(s) }
+	switch tool {
+	case "edit_file":
+		d := diff{Path: str("path")}
+		for _, l := range splitLines(str("old_text")) {
+			d.Lines = append(d.Lines, diffLine{"del", "-" + l})
+		}
+		for _, l := range splitLines(str("new_text")) {
+			d.Lines = append(d.Lines, diffLine{"add", "+" + l})
+		}
+		return []diff{d.capped()}
+	case "write_file":
+		d := diff{Path: str("path") + " (written)"}
+		for _, l := range splitLines(str("content")) {
+			d.Lines = append(d.Lines, diffLine{"add", "+" + l})
+		}
+		return []diff{d.capped()}
+	case "apply_patch":
+		return parsePatch(str("patch"))
+	}
+	return nil
+}
+
+// parsePatch splits a unified diff into one diff per file.
+func parsePatch(patch string) []diff {
+	var out []diff
+	var cur *diff
+	for _, l := range splitLines(patch) {
+		switch {
+		case strings.HasPrefix(l, "--- "):
+			continue
+		case strings.HasPrefix(l, "+++ "):
+			path := strings.TrimPrefix(strings.TrimSpace(l[4:]), "b/")
+			out = append(out, diff{Path: path})
+			cur = &out[len(out)-1]
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		class := "ctx"
+		switch {
+		case strings.HasPrefix(l, "@@"):
+			class = "hunk"
+		case strings.HasPrefix(l, "+"):
+			class = "add"
+		case strings.HasPrefix(l, "-"):
+			class = "del"
+		}
+		cur.Lines = append(cur.Lines, diffLine{class, l})
+	}
+	for i := range out {
+		out[i] = out[i].capped()
+	}
+	return out
+}
+
+func (d diff) capped() diff {
+	if len(d.Lines) > maxDiffLines {
+		d.More = len(d.Lines) - maxDiffLines
+		d.Lines = d.Lines[:maxDiffLines]
+	}
+	return d
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// formatArgs renders tool arguments as key=value, keys sorted. Edit payloads
+// are shown in the Changes section instead.
+func formatArgs(args map[string]interface{}) string {
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		var v string
+		switch k {
+		case "old_text", "new_text", "content", "patch":
+			v = fmt.Sprintf("(%d lines)", len(splitLines(fmt.Sprint(args[k]))))
+		default:
+			if s, ok := args[k].(string); ok {
+				v = s
+			} else {
+				raw, _ := json.Marshal(args[k])
+				v = string(raw)
+			}
+		}
+		parts = append(parts, k+"="+clip(v, 500))
+	}
+	return strings.Join(parts, " ")
+}
+
+func clip(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + fmt.Sprintf("… (%d more characters)", len(r)-n)
+}
+
+var pageTemplate = template.Must(template.New("share").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<meta name="referrer" content="no-referrer">
+<title>{{.Title}} · NGOClaw</title>
+<style>
+body{font:14px/1.5 -apple-system,BlinkMacSystemFont,"Segoe UI",sans-serif;max-width:960px;margin:2em auto;padding:0 1em;color:#1f2328;background:#fff}
+h1{font-size:1.4em;margin-bottom:.2em}h2{font-size:1.1em;margin-top:2em;border-bottom:1px solid #d0d7de;padding-bottom:.3em}
+.meta{color:#59636e;font-size:.9em}.meta span{margin-right:1.2em}
+pre{white-space:pre-wrap;word-break:break-word;background:#f6f8fa;border-radius:6px;padding:.8em;margin:.4em 0;font:12px/1.45 ui-monospace,SFMono-Regular,Menlo,monospace}
+.msg{border-left:3px solid #d0d7de;padding-left:.8em;margin:1em 0}.msg.user{border-color:#0969da}.msg.assistant{border-color:#8250df}
+.who{font-weight:600;font-size:.85em;color:#59636e}
+.tool{margin:.6em 0;font:12px ui-monospace,SFMono-Regular,Menlo,monospace}.tool summary{cursor:pointer}
+.ok{color:#1a7f37}.fail{color:#cf222e}.args{color:#59636e}
+.diff pre{padding:0}.diff div{padding:0 .8em}.add{background:#dafbe1}.del{background:#ffebe9}.hunk{color:#0969da;background:#ddf4ff}
+.banner{background:#fff8c5;border:1px solid #d4a72c;border-radius:6px;padding:.5em .8em;font-size:.85em}
+footer{margin:3em 0 1em;color:#59636e;font-size:.8em}
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="meta">
+{{if .Source}}<span>{{.Source}}</span>{{end}}{{if .Model}}<span>{{.Model}}</span>{{end}}<span>{{.Started}}</span><span>{{.Duration}}</span><span>{{.Steps}} steps</span><span>{{.Tokens}} tokens</span>
+</div>
+<p class="banner">Read-only snapshot. Secrets are redacted automatically; the link expires {{.Expires}}.</p>
+
+<h2>Request</h2>
+<pre>{{.Request}}</pre>
+
+<h2>Timeline</h2>
+{{range .Timeline}}{{if eq .Kind "tool"}}<details class="tool"><summary><span class="{{if .OK}}ok{{else}}fail{{end}}">{{if .OK}}✓{{else}}✗{{end}}</span> <b>{{.Tool}}</b> <span class="args">{{.Args}}</span> · {{.Duration}}</summary>{{if .Text}}<pre>{{.Text}}</pre>{{end}}</details>
+{{else}}<div class="msg {{.Kind}}"><div class="who">{{if eq .Kind "user"}}User (mid-run){{else}}Assistant{{end}}</div><pre>{{.Text}}</pre></div>
+{{end}}{{else}}<p class="meta">No steps recorded.</p>
+{{end}}
+{{if .Diffs}}<h2>Changes</h2>
+{{range .Diffs}}<div class="diff"><b>{{.Path}}</b><pre>{{range .Lines}}<div class="{{.Class}}">{{.Text}}</div>{{end}}{{if .More}}<div class="hunk">… {{.More}} more lines</div>{{end}}</pre></div>
+{{end}}{{end}}
+{{if .Tests}}<h2>Tests</h2><p class="{{if .TestsOK}}ok{{else}}fail{{end}}">{{.Tests}}</p>{{end}}
+{{if .Error}}<h2>Error</h2><pre class="fail">{{.Error}}</pre>{{end}}
+<footer>NGOClaw · trace {{.TraceID}} · generated {{.Generated}}</footer>
+</body>
+</html>
+`))
@@ -0,0 +1,317 @@
+// Package share publishes read-only snapshots of completed agent runs
+// (transcript, diffs, tool timeline) as standalone HTML pages behind
+// unguessable, expiring links, for pasting into issue trackers.
+//
+// Recent runs are kept in memory as they finish (the Store is a
+// service.TranscriptSink). Creating a link renders the run once, with
+// secrets redacted, and stores the page under ~/.ngoclaw/shares; the page
+// itself never changes afterwards.
+package share
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrRunNotFound the run is unknown or no longer kept in memory.
+	ErrRunNotFound = errors.New("run not found (only recent runs since the last restart can be shared)")
+	// ErrNotFound the link does not exist, was revoked or has expired.
+	ErrNotFound = errors.New("share link not found or expired")
+)
+
+// tokenPattern 32 random bytes, base64url: also keeps tokens safe as file names.
+var tokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{43}$`)
+
+// Config 分享链接配置
+type Config struct {
+	Dir     string        // 默认 ~/.ngoclaw/shares
+	TTL     time.Duration // 默认有效期 (默认 7 天)
+	MaxTTL  time.Duration // 最长有效期 (默认 30 天)
+	Keep    int           // 内存中保留的最近运行数 (默认 200)
+	Secrets []string      // 额外脱敏的字面值 (配置中的令牌、密钥)
+}
+
+// Link a created share link.
+type Link struct {
+	Token     string    `json:"token"`
+	TraceID   string    `json:"trace_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// snapshot is the stored form of a link: the rendered page plus what
+// retention needs to find it.
+type snapshot struct {
+	Link
+	Source    string    `json:"source"`
+	StartedAt time.Time `json:"started_at"`
+	CreatedAt time.Time `json:"created_at"`
+	HTML      string    `json:"html"`
+}
+
+// Store keeps recent runs and the share snapshots.
+type Store struct {
+	cfg      Config
+	redactor *Redactor
+	logger   *zap.Logger
+	now      func() time.Time
+
+	mu   sync.Mutex
+	runs []*service.RunTranscript // oldest first, at most cfg.Keep
+}
+
+var _ service.TranscriptSink = (*Store)(nil)
+
+// DefaultDir is where snapshots are stored when Config.Dir is empty.
+func DefaultDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".ngoclaw", "shares")
+}
+
+// NewStore creates the snapshot directory and returns a store.
+func NewStore(cfg Config, logger *zap.Logger) (*Store, error) {
+	if cfg.Dir == "" {
+		cfg.Dir = DefaultDir()
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 7 * 24 * time.Hour
+	}
+	if cfg.MaxTTL < cfg.TTL {
+		cfg.MaxTTL = max(cfg.TTL, 30*24*time.Hour)
+	}
+	if cfg.Keep <= 0 {
+		cfg.Keep = 200
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create share dir: %w", err)
+	}
+	return &Store{
+		cfg:      cfg,
+		redactor: NewRedactor(cfg.Secrets),
+		logger:   logger.With(zap.String("component", "share")),
+		now:      time.Now,
+	}, nil
+}
+
+// WriteTranscript remembers a finished run so it can be shared.
+func (s *Store) WriteTranscript(t *service.RunTranscript) {
+	if t.TraceID == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs = append(s.runs, t)
+	if n := len(s.runs) - s.cfg.Keep; n > 0 {
+		s.runs = append(s.runs[:0:0], s.runs[n:]...)
+	}
+}
+
+// Last returns the trace ID of the most recent run from source, or "".
+func (s *Store) Last(source string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.runs) - 1; i >= 0; i-- {
+		if s.runs[i].Source == source {
+			return s.runs[i].TraceID
+		}
+	}
+	return ""
+}
+
+// RunInfo describes a kept run, for picking one to share.
+type RunInfo struct {
+	TraceID   string    `json:"trace_id"`
+	Source    string    `json:"source"`
+	Model     string    `json:"model"`
+	Request   string    `json:"request"` // first line, redacted
+	StartedAt time.Time `json:"started_at"`
+}
+
+// Recent lists up to n kept runs, newest first; source "" = all sources.
+func (s *Store) Recent(source string, n int) []RunInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []RunInfo
+	for i := len(s.runs) - 1; i >= 0 && len(out) < n; i-- {
+		t := s.runs[i]
+		if source != "" && t.Source != source {
+			continue
+		}
+		req, _, _ := strings.Cut(strings.TrimSpace(t.UserMessage), "\n")
+		out = append(out, RunInfo{
+			TraceID:   t.TraceID,
+			Source:    t.Source,
+			Model:     t.Model,
+			Request:   s.redactor.Redact(clip(req, 120)),
+			StartedAt: t.StartedAt,
+		})
+	}
+	return out
+}
+
+func (s *Store) run(traceID string) *service.RunTranscript {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.runs) - 1; i >= 0; i-- {
+		if s.runs[i].TraceID == traceID {
+			return s.runs[i]
+		}
+	}
+	return nil
+}
+
+// Create renders the run and publishes it for ttl (0 = default, capped at
+// the configured maximum).
+func (s *Store) Create(traceID string, ttl time.Duration) (*Link, error) {
+	t := s.run(traceID)
+	if t == nil {
+		return nil, ErrRunNotFound
+	}
+	if ttl <= 0 {
+		ttl = s.cfg.TTL
+	}
+	ttl = min(ttl, s.cfg.MaxTTL)
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	now := s.now()
+	link := Link{
+		Token:     base64.RawURLEncoding.EncodeToString(raw),
+		TraceID:   traceID,
+		ExpiresAt: now.Add(ttl),
+	}
+	page, err := Render(t, s.redactor.Redact, link.ExpiresAt, now)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(snapshot{Link: link, Source: t.Source, StartedAt: t.StartedAt, CreatedAt: now, HTML: string(page)})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(s.path(link.Token), data, 0o600); err != nil {
+		return nil, fmt.Errorf("write share: %w", err)
+	}
+	s.Sweep()
+	return &link, nil
+}
+
+// Page returns the HTML of a live link.
+func (s *Store) Page(token string) ([]byte, error) {
+	if !tokenPattern.MatchString(token) {
+		return nil, ErrNotFound
+	}
+	snap, err := s.load(s.path(token))
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	if !s.now().Before(snap.ExpiresAt) {
+		_ = os.Remove(s.path(token))
+		return nil, ErrNotFound
+	}
+	return []byte(snap.HTML), nil
+}
+
+// Revoke deletes a link before it expires.
+func (s *Store) Revoke(token string) error {
+	if !tokenPattern.MatchString(token) {
+		return ErrNotFound
+	}
+	if err := os.Remove(s.path(token)); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Purge deletes snapshots and forgets kept runs for which match(source,
+// startedAt) is true (retention policies, /forgetme). Returns the number of
+// snapshots removed.
+func (s *Store) Purge(match func(source string, startedAt time.Time) bool) (int, error) {
+	s.mu.Lock()
+	kept := s.runs[:0]
+	for _, t := range s.runs {
+		if !match(t.Source, t.StartedAt) {
+			kept = append(kept, t)
+		}
+	}
+	clear(s.runs[len(kept):])
+	s.runs = kept
+	s.mu.Unlock()
+
+	n := 0
+	var errs []error
+	s.each(func(path string, snap *snapshot) {
+		if match(snap.Source, snap.StartedAt) {
+			if err := os.Remove(path); err != nil {
+				errs = append(errs, err)
+				return
+			}
+			n++
+		}
+	})
+	return n, errors.Join(errs...)
+}
+
+// Sweep deletes expired snapshots and returns how many were removed. It
+// runs on every Create and periodically from the janitor.
+func (s *Store) Sweep() int {
+	now := s.now()
+	n := 0
+	s.each(func(path string, snap *snapshot) {
+		if !now.Before(snap.ExpiresAt) {
+			if err := os.Remove(path); err != nil {
+				s.logger.Warn("Failed to delete expired share", zap.String("path", path), zap.Error(err))
+				return
+			}
+			n++
+		}
+	})
+	return n
+}
+
+func (s *Store) each(fn func(path string, snap *snapshot)) {
+	entries, err := os.ReadDir(s.cfg.Dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(s.cfg.Dir, e.Name())
+		if snap, err := s.load(path); err == nil {
+			fn(path, snap)
+		}
+	}
+}
+
+func (s *Store) load(path string) (*snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+func (s *Store) path(token string) string {
+	return filepath.Join(s.cfg.Dir, token+".json")
+}
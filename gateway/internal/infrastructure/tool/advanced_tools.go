@@ -3,9 +3,11 @@ package tool
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
 	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/sandbox"
 	"go.uber.org/zap"
@@ -15,6 +17,7 @@ import (
 // Reference: OpenCode edit.ts (20KB) — supports single and multi-chunk edits.
 type EditFileTool struct {
 	sandbox *sandbox.ProcessSandbox
+	guard   *FileGuard
 	logger  *zap.Logger
 }
 
@@ -22,6 +25,21 @@ func NewEditFileTool(sandbox *sandbox.ProcessSandbox, logger *zap.Logger) *EditF
 	return &EditFileTool{sandbox: sandbox, logger: logger}
 }
 
+// SetFileGuard 启用并发编辑冲突检测
+func (t *EditFileTool) SetFileGuard(g *FileGuard) {
+	t.guard = g
+}
+
+// editTarget 读取原文件时的快照, 写回前用于确认文件未被并发修改
+type editTarget struct {
+	path     string
+	absPath  string // FileGuard 记录的路径
+	realPath string // 实际读写的文件 (已解析符号链接)
+	info     os.FileInfo
+	raw      []byte     // 原文件字节
+	format   textFormat // 写回时保持的编码与换行
+}
+
 func (t *EditFileTool) Name() string        { return "edit_file" }
 func (t *EditFileTool) Kind() domaintool.Kind { return domaintool.KindEdit }
 func (t *EditFileTool) Description() string {
@@ -64,13 +82,38 @@ func (t *EditFileTool) Execute(ctx context.Context, args map[string]interface{})
 		return &domaintool.Result{Success: false, Error: "path and old_text are required"}, nil
 	}
 
-	// Read original file
-	readResult, err := t.sandbox.ExecuteShell(ctx, fmt.Sprintf("cat '%s'", path))
+	realPath, err := resolveWritePath(path, t.sandbox.GetWorkDir())
 	if err != nil {
-		return &domaintool.Result{Success: false, Error: readResult.Stderr}, nil
+		return &domaintool.Result{Success: false, Error: err.Error()}, nil
+	}
+
+	// 本 run 上次读取后文件被他人改动: 要求模型先重新读取
+	target := editTarget{path: path, absPath: resolveReadPath(path, t.sandbox.GetWorkDir()), realPath: realPath}
+	if t.guard != nil {
+		if err := t.guard.Verify(ctx, target.absPath); err != nil {
+			return &domaintool.Result{Success: false, Error: err.Error()}, nil
+		}
 	}
 
-	original := readResult.Stdout
+	// 直接读取文件 (不经过 shell): 保留末尾换行、CRLF 与 BOM
+	if target.info, err = os.Stat(realPath); err != nil {
+		return &domaintool.Result{Success: false, Error: err.Error()}, nil
+	}
+	if target.info.IsDir() {
+		return &domaintool.Result{Success: false, Error: fmt.Sprintf("%s is a directory", path)}, nil
+	}
+	if target.raw, err = os.ReadFile(realPath); err != nil {
+		return &domaintool.Result{Success: false, Error: err.Error()}, nil
+	}
+	original, format, err := decodeText(target.raw)
+	if err != nil {
+		return &domaintool.Result{Success: false, Error: fmt.Sprintf("%s: %v; edit it with bash instead", path, err)}, nil
+	}
+	target.format = format
+	// CRLF 文件按 LF 文本编辑, 写回时再转换
+	if format.CRLF {
+		oldText, newText = toLF(oldText), toLF(newText)
+	}
 
 	// Phase 1: Exact match
 	if strings.Contains(original, oldText) {
@@ -83,7 +126,7 @@ func (t *EditFileTool) Execute(ctx context.Context, args map[string]interface{})
 		}
 
 		modified := strings.Replace(original, oldText, newText, 1)
-		return t.writeFile(ctx, path, modified, oldText, newText, "exact")
+		return t.writeFile(ctx, target, original, modified, oldText, newText, "exact")
 	}
 
 	// Phase 2: Fuzzy self-repair — normalize whitespace and retry
@@ -119,7 +162,7 @@ func (t *EditFileTool) Execute(ctx context.Context, args map[string]interface{})
 			zap.Int("line_start", matchStart+1),
 			zap.Int("line_end", matchEnd),
 		)
-		return t.writeFile(ctx, path, result, oldText, newText, "fuzzy")
+		return t.writeFile(ctx, target, original, result, oldText, newText, "fuzzy")
 	}
 
 	// Phase 3: No match — provide context for LLM retry
@@ -135,23 +178,38 @@ func (t *EditFileTool) Execute(ctx context.Context, args map[string]interface{})
 	}, nil
 }
 
-// writeFile writes modified content back to file
-func (t *EditFileTool) writeFile(ctx context.Context, path, content, oldText, newText, matchType string) (*domaintool.Result, error) {
-	writeCmd := fmt.Sprintf("cat > '%s' << 'NGOCLAW_EDIT_EOF'\n%s\nNGOCLAW_EDIT_EOF", path, content)
-	writeResult, err := t.sandbox.ExecuteShell(ctx, writeCmd)
-	if err != nil {
-		return &domaintool.Result{Success: false, Error: writeResult.Stderr}, nil
+// writeFile writes the modified text back in the file's original encoding
+// and line endings. Writing in place keeps the file's mode, owner and links.
+func (t *EditFileTool) writeFile(ctx context.Context, target editTarget, original, content, oldText, newText, matchType string) (*domaintool.Result, error) {
+	path := target.path
+
+	// 读取与写回之间文件被改动 (其他进程 / 外部编辑器): 放弃写回, 避免覆盖
+	if now, err := os.Stat(target.realPath); err != nil || now.Size() != target.info.Size() || !now.ModTime().Equal(target.info.ModTime()) {
+		conflict := &EditConflictError{Path: path, Reason: "changed while the edit was being applied"}
+		return &domaintool.Result{Success: false, Error: conflict.Error()}, nil
+	}
+
+	data := encodeText(content, target.format)
+	if err := os.WriteFile(target.realPath, data, target.info.Mode().Perm()); err != nil {
+		return &domaintool.Result{Success: false, Error: err.Error()}, nil
+	}
+
+	if t.guard != nil {
+		t.guard.Observe(ctx, target.absPath, "", false)
+		t.guard.RecordEdit(ctx, target.absPath)
 	}
 
 	msg := fmt.Sprintf("Successfully edited %s (replaced 1 occurrence, match: %s)", path, matchType)
 	return &domaintool.Result{
 		Output:  msg,
 		Success: true,
-		Metadata: map[string]interface{}{
-			"path":        path,
-			"match_type":  matchType,
-			"chars_added": len(newText) - len(oldText),
-		},
+		Metadata: fileEditMetadata(map[string]interface{}{
+			"path":         path,
+			"match_type":   matchType,
+			"chars_added":  len(newText) - len(oldText),
+			"encoding":     target.format.Encoding,
+			"line_endings": target.format.LineEndings(),
+		}, newTextFileEdit(target.absPath, &original, target.raw, content, data)),
 	}, nil
 }
 
@@ -314,6 +372,7 @@ func (t *GlobTool) Execute(ctx context.Context, args map[string]interface{}) (*d
 // Reference: OpenCode apply_patch.ts (9KB)
 type ApplyPatchTool struct {
 	sandbox *sandbox.ProcessSandbox
+	guard   *FileGuard
 	logger  *zap.Logger
 }
 
@@ -321,6 +380,11 @@ func NewApplyPatchTool(sandbox *sandbox.ProcessSandbox, logger *zap.Logger) *App
 	return &ApplyPatchTool{sandbox: sandbox, logger: logger}
 }
 
+// SetFileGuard 记录补丁修改的文件 (typecheck 增量检查用)
+func (t *ApplyPatchTool) SetFileGuard(g *FileGuard) {
+	t.guard = g
+}
+
 func (t *ApplyPatchTool) Name() string        { return "apply_patch" }
 func (t *ApplyPatchTool) Kind() domaintool.Kind { return domaintool.KindEdit }
 func (t *ApplyPatchTool) Description() string {
@@ -330,7 +394,9 @@ func (t *ApplyPatchTool) Description() string {
 @@ -line,count +line,count @@
  context line
 -removed line
-+added line`
++added line
+
+Use --- /dev/null to create a file and +++ /dev/null to delete one. The patch is all-or-nothing: every hunk is checked against the current files first, and if any hunk does not match, no file is changed.`
 }
 
 func (t *ApplyPatchTool) Schema() map[string]interface{} {
@@ -351,22 +417,85 @@ func (t *ApplyPatchTool) Execute(ctx context.Context, args map[string]interface{
 	if patch == "" {
 		return &domaintool.Result{Success: false, Error: "patch is required"}, nil
 	}
+	files, err := parsePatch(patch)
+	if err != nil {
+		return &domaintool.Result{Success: false, Error: "invalid patch: " + err.Error()}, nil
+	}
+	fail := func(err error) (*domaintool.Result, error) {
+		return &domaintool.Result{Success: false, Error: "patch not applied, no file was changed: " + err.Error()}, nil
+	}
 
-	// Write patch to temp file and apply
-	cmd := fmt.Sprintf("echo '%s' | patch -p1 --no-backup-if-mismatch 2>&1",
-		strings.ReplaceAll(patch, "'", "'\\''"))
+	// 补丁涉及的文件一并加锁, 并检查本 run 读过之后是否被他人改动
+	tx := newPatchTx(t.sandbox.GetWorkDir())
+	if t.guard != nil {
+		var paths []string
+		for _, f := range files {
+			for _, p := range []string{f.OldPath, f.NewPath} {
+				if p != "" {
+					paths = append(paths, t.guard.Resolve(p))
+				}
+			}
+		}
+		unlock, err := t.guard.Lock(ctx, paths...)
+		if err != nil {
+			return fail(err)
+		}
+		defer unlock()
+		for _, p := range paths {
+			if err := t.guard.Verify(ctx, p); err != nil {
+				return fail(err)
+			}
+		}
+	}
 
-	result, err := t.sandbox.ExecuteShell(ctx, cmd)
-	if err != nil {
-		return &domaintool.Result{
-			Success: false,
-			Error:   fmt.Sprintf("Patch failed: %s", result.Stderr),
-		}, nil
+	// 先在内存中校验并应用全部 hunk, 再统一写入; 写入失败时从备份恢复
+	for _, f := range files {
+		if err := tx.add(f); err != nil {
+			return fail(err)
+		}
+	}
+	if err := tx.commit(); err != nil {
+		return fail(err)
+	}
+
+	var edits []*entity.FileEdit
+	for _, c := range tx.changes {
+		if !c.changed() {
+			continue
+		}
+		edits = append(edits, c.edit())
+		if t.guard != nil {
+			t.guard.Observe(ctx, c.abs, "", false)
+			t.guard.RecordEdit(ctx, c.abs)
+		}
+	}
+
+	var sb strings.Builder
+	added, removed := 0, 0
+	fmt.Fprintf(&sb, "Applied patch to %d file(s):\n", len(tx.stats))
+	for _, st := range tx.stats {
+		added += st.Added
+		removed += st.Removed
+		name := st.Path
+		if st.From != "" {
+			name = st.From + " → " + st.Path
+		}
+		fmt.Fprintf(&sb, "  %s %s: %d hunk(s), +%d -%d\n", st.Op, name, len(st.Hunks), st.Added, st.Removed)
+		for i, h := range st.Hunks {
+			if h.Offset != 0 {
+				fmt.Fprintf(&sb, "    hunk %d applied at line %d (offset %+d lines)\n", i+1, h.Line, h.Offset)
+			}
+		}
 	}
 
 	return &domaintool.Result{
-		Output:  result.Stdout,
-		Success: result.ExitCode == 0,
+		Output:  sb.String(),
+		Success: true,
+		Metadata: fileEditMetadata(map[string]interface{}{
+			"files":   tx.stats,
+			"added":   added,
+			"removed": removed,
+		}, edits...),
 	}, nil
 }
 
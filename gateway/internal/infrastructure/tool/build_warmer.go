@@ -0,0 +1,283 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/sandbox"
+	"go.uber.org/zap"
+)
+
+const (
+	// warmSearchDepth 查找项目的最大目录深度 (工作区根为 0)
+	warmSearchDepth = 3
+	// warmDebounce 依赖文件变更的合并窗口 (go mod tidy、npm install 会连续写多个文件)
+	warmDebounce = 3 * time.Second
+)
+
+// WarmConfig 构建缓存预热配置
+type WarmConfig struct {
+	Go      bool          // go mod download + 编译包与测试
+	Node    bool          // 依赖有变化时 npm / pnpm / yarn install
+	Python  bool          // 工作区 .venv 中 pip install
+	Timeout time.Duration // 单个项目, 默认 10m
+}
+
+// warmProject 工作区中的一个项目
+type warmProject struct {
+	lang string // go | node | python
+	dir  string
+}
+
+// BuildWarmer 构建缓存预热.
+//
+// 网关启动时在后台为工作区中的项目预构建依赖: Go 模块下载依赖并编译全部包和
+// 测试 (填充构建缓存, 之后的 typecheck / run_tests 只编译改动的包), Node 项目
+// 安装依赖, Python 项目在工作区的 .venv 中安装依赖. 之后监听依赖文件
+// (go.mod、package.json、锁文件、requirements*.txt 等), 变更后重新预热该项目.
+// 预热一次只运行一个, 失败 (如代码本身编译不过) 只记日志.
+type BuildWarmer struct {
+	root    string
+	sandbox *sandbox.ProcessSandbox
+	cfg     WarmConfig
+	logger  *zap.Logger
+	// debounce 依赖文件变更的合并窗口
+	debounce time.Duration
+
+	mu       sync.Mutex
+	projects map[string][]warmProject // 项目目录 → 项目 (同一目录可能既是 Go 又是 Node 项目)
+	queue    chan warmProject
+	watcher  *fsnotify.Watcher
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewBuildWarmer 创建构建缓存预热器 (调用 Start 后开始)
+func NewBuildWarmer(root string, sb *sandbox.ProcessSandbox, cfg WarmConfig, logger *zap.Logger) *BuildWarmer {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Minute
+	}
+	return &BuildWarmer{
+		root:     filepath.Clean(root),
+		sandbox:  sb,
+		cfg:      cfg,
+		logger:   logger.With(zap.String("component", "build_warmer")),
+		debounce: warmDebounce,
+		projects: make(map[string][]warmProject),
+	}
+}
+
+// Start 查找项目, 排队首次预热并开始监听依赖文件
+func (w *BuildWarmer) Start() error {
+	if w.sandbox == nil {
+		return fmt.Errorf("build warmer requires the sandbox")
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create watcher: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	w.watcher = watcher
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	projects := findWarmProjects(w.root, w.cfg)
+	w.queue = make(chan warmProject, len(projects)+16)
+	for _, p := range projects {
+		if len(w.projects[p.dir]) == 0 {
+			if err := watcher.Add(p.dir); err != nil {
+				w.logger.Warn("Cannot watch project", zap.String("dir", p.dir), zap.Error(err))
+			}
+		}
+		w.projects[p.dir] = append(w.projects[p.dir], p)
+		w.queue <- p
+	}
+	w.logger.Info("Build warmer started", zap.String("root", w.root), zap.Int("projects", len(projects)))
+
+	go w.watch(ctx)
+	go w.run(ctx)
+	return nil
+}
+
+// Close 停止监听, 取消进行中的预热
+func (w *BuildWarmer) Close() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	w.watcher.Close()
+	<-w.done
+}
+
+// watch 合并依赖文件变更, 窗口结束后排队预热对应项目
+func (w *BuildWarmer) watch(ctx context.Context) {
+	pending := make(map[string]struct{})
+	var flush <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod || !isDependencyFile(filepath.Base(event.Name)) {
+				continue
+			}
+			pending[filepath.Dir(event.Name)] = struct{}{}
+			if flush == nil {
+				flush = time.After(w.debounce)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.logger.Warn("Build warmer watcher error", zap.Error(err))
+		case <-flush:
+			flush = nil
+			for dir := range pending {
+				w.mu.Lock()
+				projects := w.projects[dir]
+				w.mu.Unlock()
+				for _, p := range projects {
+					select {
+					case w.queue <- p:
+					default: // 队列已满, 前面的预热会覆盖这次变更
+					}
+				}
+			}
+			pending = make(map[string]struct{})
+		}
+	}
+}
+
+// run 逐个执行预热
+func (w *BuildWarmer) run(ctx context.Context) {
+	defer close(w.done)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case p := <-w.queue:
+			w.warm(ctx, p)
+		}
+	}
+}
+
+func (w *BuildWarmer) warm(ctx context.Context, p warmProject) {
+	script := warmScript(p)
+	if script == "" {
+		return
+	}
+	start := time.Now()
+	res, err := w.sandbox.ExecuteWith(ctx, sandbox.ExecOptions{Timeout: w.cfg.Timeout}, "bash",
+		[]string{"-c", "cd " + shellQuote(p.dir) + " && " + script + " 2>&1"})
+	rel, _ := filepath.Rel(w.root, p.dir)
+	fields := []zap.Field{
+		zap.String("project", rel),
+		zap.String("lang", p.lang),
+		zap.Duration("elapsed", time.Since(start)),
+	}
+	switch {
+	case ctx.Err() != nil:
+		return
+	case err != nil:
+		w.logger.Warn("Build cache warm-up failed", append(fields, zap.Error(err))...)
+	case res.ExitCode != 0:
+		out := strings.TrimSpace(res.Stdout + res.Stderr)
+		if len(out) > 500 {
+			out = "…" + out[len(out)-500:]
+		}
+		w.logger.Warn("Build cache warm-up failed", append(fields, zap.Int("exit_code", res.ExitCode), zap.String("output", out))...)
+	default:
+		w.logger.Info("Build cache warmed", fields...)
+	}
+}
+
+// warmScript 项目的预热命令. Go 项目只有依赖下载失败才算预热失败, 代码编译不过不影响缓存
+func warmScript(p warmProject) string {
+	switch p.lang {
+	case "go":
+		// go test -run '^$' 编译包和测试但不运行; vet 结果同样进缓存 (typecheck 使用 go vet)
+		return "go mod download && { go test -run '^$' ./... >/dev/null; go vet ./... >/dev/null; true; }"
+	case "node":
+		switch {
+		case fileExists(filepath.Join(p.dir, "pnpm-lock.yaml")):
+			return "pnpm install --frozen-lockfile"
+		case fileExists(filepath.Join(p.dir, "yarn.lock")):
+			return "yarn install --frozen-lockfile"
+		default:
+			return "npm install --no-audit --no-fund"
+		}
+	case "python":
+		pip := shellQuote(filepath.Join(p.dir, ".venv", "bin", "python")) + " -m pip install -q"
+		var steps []string
+		if fileExists(filepath.Join(p.dir, "requirements.txt")) {
+			steps = append(steps, pip+" -r requirements.txt")
+		}
+		if fileExists(filepath.Join(p.dir, "requirements-dev.txt")) {
+			steps = append(steps, pip+" -r requirements-dev.txt")
+		}
+		if len(steps) == 0 && fileExists(filepath.Join(p.dir, "pyproject.toml")) {
+			steps = append(steps, pip+" -e .")
+		}
+		return strings.Join(steps, " && ")
+	}
+	return ""
+}
+
+// findWarmProjects 在工作区中查找可预热的项目, 按目录排序
+func findWarmProjects(root string, cfg WarmConfig) []warmProject {
+	var out []warmProject
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		if path != root && skipRepoMapDir(info.Name()) {
+			return filepath.SkipDir
+		}
+		if rel, _ := filepath.Rel(root, path); rel != "." && strings.Count(rel, string(filepath.Separator)) >= warmSearchDepth {
+			return filepath.SkipDir
+		}
+		has := func(name string) bool { return fileExists(filepath.Join(path, name)) }
+		if cfg.Go && has("go.mod") {
+			out = append(out, warmProject{lang: "go", dir: path})
+		}
+		if cfg.Node && has("package.json") {
+			out = append(out, warmProject{lang: "node", dir: path})
+		}
+		// 只装进工作区自己的虚拟环境, 不动系统 Python
+		if cfg.Python && has(".venv/bin/python") && (has("requirements.txt") || has("requirements-dev.txt") || has("pyproject.toml")) {
+			out = append(out, warmProject{lang: "python", dir: path})
+		}
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].dir < out[j].dir })
+	return out
+}
+
+// isDependencyFile 变更后需要重新预热的文件
+func isDependencyFile(name string) bool {
+	switch name {
+	case "go.mod", "go.sum", "go.work",
+		"package.json", "package-lock.json", "pnpm-lock.yaml", "yarn.lock",
+		"requirements.txt", "requirements-dev.txt", "pyproject.toml":
+		return true
+	}
+	return false
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,137 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+func TestFindWarmProjects(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"go.mod":                            "module example.com/root\n",
+		"web/package.json":                  "{}",
+		"web/yarn.lock":                     "",
+		"web/node_modules/dep/package.json": "{}",
+		"api/.venv/bin/python":              "",
+		"api/requirements.txt":              "",
+		"scripts/requirements.txt":          "",
+		"a/b/c/go.mod":                      "module example.com/c\n",
+		"a/b/c/d/go.mod":                    "module example.com/deep\n",
+		".cache/pkg/go.mod":                 "module example.com/cache\n",
+	})
+
+	all := WarmConfig{Go: true, Node: true, Python: true}
+	var got []string
+	for _, p := range findWarmProjects(root, all) {
+		rel, _ := filepath.Rel(root, p.dir)
+		got = append(got, p.lang+":"+rel)
+	}
+	want := []string{"go:.", "go:a/b/c", "python:api", "node:web"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("projects = %v, want %v", got, want)
+	}
+
+	// 关闭的语言不查找
+	if ps := findWarmProjects(root, WarmConfig{Node: true}); len(ps) != 1 || ps[0].lang != "node" {
+		t.Fatalf("node only = %+v", ps)
+	}
+}
+
+func TestWarmScript(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"pnpm/pnpm-lock.yaml":     "",
+		"npm/package.json":        "{}",
+		"py/requirements.txt":     "",
+		"py/requirements-dev.txt": "",
+		"pyproj/pyproject.toml":   "",
+	})
+
+	cases := []struct {
+		p    warmProject
+		want []string
+	}{
+		{warmProject{"go", root}, []string{"go mod download", "go test -run '^$' ./..."}},
+		{warmProject{"node", filepath.Join(root, "pnpm")}, []string{"pnpm install --frozen-lockfile"}},
+		{warmProject{"node", filepath.Join(root, "npm")}, []string{"npm install"}},
+		{warmProject{"python", filepath.Join(root, "py")}, []string{"-r requirements.txt", "-r requirements-dev.txt"}},
+		{warmProject{"python", filepath.Join(root, "pyproj")}, []string{".venv/bin/python", "-m pip install -q -e ."}},
+	}
+	for _, tc := range cases {
+		script := warmScript(tc.p)
+		for _, w := range tc.want {
+			if !strings.Contains(script, w) {
+				t.Errorf("%s %s: script %q missing %q", tc.p.lang, filepath.Base(tc.p.dir), script, w)
+			}
+		}
+	}
+}
+
+func TestBuildWarmer_RequeuesOnDependencyChange(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"go.mod":       "module example.com/root\n",
+		"package.json": "{}",
+	})
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+	if err := watcher.Add(root); err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewBuildWarmer(root, nil, WarmConfig{Go: true, Node: true}, zap.NewNop())
+	w.debounce = 50 * time.Millisecond
+	w.watcher = watcher
+	w.queue = make(chan warmProject, 8)
+	for _, p := range findWarmProjects(root, w.cfg) {
+		w.projects[p.dir] = append(w.projects[p.dir], p)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.watch(ctx)
+
+	// 源文件变更不触发
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// 多次依赖文件写入合并为一次预热
+	for i := 0; i < 3; i++ {
+		if err := os.WriteFile(filepath.Join(root, "go.sum"), []byte(strings.Repeat("x", i)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	langs := map[string]int{}
+	timeout := time.After(2 * time.Second)
+	for len(langs) < 2 {
+		select {
+		case p := <-w.queue:
+			langs[p.lang]++
+		case <-timeout:
+			t.Fatalf("queued = %v, want go and node", langs)
+		}
+	}
+	select {
+	case p := <-w.queue:
+		t.Fatalf("unexpected extra warm-up: %+v", p)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestBuildWarmer_StartRequiresSandbox(t *testing.T) {
+	w := NewBuildWarmer(t.TempDir(), nil, WarmConfig{Go: true}, zap.NewNop())
+	if err := w.Start(); err == nil {
+		t.Fatal("Start without sandbox should fail")
+	}
+	w.Close() // 未启动时 Close 不阻塞
+}
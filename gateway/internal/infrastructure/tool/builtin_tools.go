@@ -2,7 +2,10 @@ package tool
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
@@ -181,8 +184,10 @@ func truncateLine(line string, maxLen int) string {
 
 // ReadFileTool 读取文件工具
 type ReadFileTool struct {
-	sandbox *sandbox.ProcessSandbox
-	logger  *zap.Logger
+	sandbox  *sandbox.ProcessSandbox
+	prefetch *ReadPrefetcher // nil = 不启用依赖预读
+	guard    *FileGuard      // nil = 不记录读取版本
+	logger   *zap.Logger
 }
 
 // NewReadFileTool 创建读取文件工具
@@ -193,6 +198,16 @@ func NewReadFileTool(sandbox *sandbox.ProcessSandbox, logger *zap.Logger) *ReadF
 	}
 }
 
+// SetPrefetcher 启用依赖预读缓存
+func (t *ReadFileTool) SetPrefetcher(p *ReadPrefetcher) {
+	t.prefetch = p
+}
+
+// SetFileGuard 记录每个 run 读到的文件版本, 供写入时检测冲突
+func (t *ReadFileTool) SetFileGuard(g *FileGuard) {
+	t.guard = g
+}
+
 // Name 返回工具名称
 func (t *ReadFileTool) Name() string {
 	return "read_file"
@@ -237,11 +252,39 @@ func (t *ReadFileTool) Execute(ctx context.Context, args map[string]interface{})
 		}, fmt.Errorf("path is required")
 	}
 
-	// 构建命令
-	var cmd string
 	startLine, hasStart := args["start_line"].(float64)
 	endLine, hasEnd := args["end_line"].(float64)
 
+	// 预读缓存命中: 直接按行范围截取, 跳过 shell
+	var absPath string
+	if t.prefetch != nil && t.sandbox != nil {
+		absPath = resolveReadPath(path, t.sandbox.GetWorkDir())
+		if content, ok := t.prefetch.Get(absPath); ok {
+			if t.guard != nil {
+				t.guard.Observe(ctx, absPath, content, true)
+			}
+			if hasStart {
+				end := 0
+				if hasEnd {
+					end = int(endLine)
+				}
+				content = sliceLines(content, int(startLine), end)
+			}
+			return &Result{
+				Output:  content,
+				Success: true,
+				Metadata: map[string]interface{}{
+					"path":       path,
+					"cache_hit":  true,
+					"prefetched": t.prefetch.PrefetchDeps(absPath),
+				},
+			}, nil
+		}
+	}
+
+	// 构建命令
+	var cmd string
+
 	if hasStart && hasEnd {
 		// 使用 sed 提取指定行范围
 		cmd = fmt.Sprintf("sed -n '%d,%dp' '%s'", int(startLine), int(endLine), path)
@@ -253,6 +296,12 @@ func (t *ReadFileTool) Execute(ctx context.Context, args map[string]interface{})
 		cmd = fmt.Sprintf("cat '%s'", path)
 	}
 
+	// 读取前记录 stat, 避免读取期间文件被改写导致缓存内容与 mtime 不一致
+	var info os.FileInfo
+	if absPath != "" && !hasStart {
+		info, _ = os.Stat(absPath)
+	}
+
 	result, err := t.sandbox.ExecuteShell(ctx, cmd)
 	if err != nil {
 		errMsg := err.Error()
@@ -262,18 +311,33 @@ func (t *ReadFileTool) Execute(ctx context.Context, args map[string]interface{})
 		return &Result{Success: false, Error: errMsg}, nil
 	}
 
+	if t.guard != nil {
+		t.guard.Observe(ctx, t.guard.Resolve(path), result.Stdout, !hasStart && !result.Truncated)
+	}
+
+	metadata := map[string]interface{}{
+		"path": path,
+	}
+	if absPath != "" {
+		// 整文件读取时回填缓存 (截断的输出不缓存), 并在后台预读其直接依赖
+		if info != nil && !result.Truncated {
+			t.prefetch.Put(absPath, result.Stdout, info)
+		}
+		metadata["cache_hit"] = false
+		metadata["prefetched"] = t.prefetch.PrefetchDeps(absPath)
+	}
+
 	return &Result{
-		Output:  result.Stdout,
-		Success: true,
-		Metadata: map[string]interface{}{
-			"path": path,
-		},
+		Output:   result.Stdout,
+		Success:  true,
+		Metadata: metadata,
 	}, nil
 }
 
 // WriteFileTool 写入文件工具
 type WriteFileTool struct {
 	sandbox *sandbox.ProcessSandbox
+	guard   *FileGuard
 	logger  *zap.Logger
 }
 
@@ -285,6 +349,11 @@ func NewWriteFileTool(sandbox *sandbox.ProcessSandbox, logger *zap.Logger) *Writ
 	}
 }
 
+// SetFileGuard 启用并发编辑冲突检测
+func (t *WriteFileTool) SetFileGuard(g *FileGuard) {
+	t.guard = g
+}
+
 // Name 返回工具名称
 func (t *WriteFileTool) Name() string {
 	return "write_file"
@@ -294,7 +363,7 @@ func (t *WriteFileTool) Kind() domaintool.Kind { return domaintool.KindEdit }
 
 // Description 返回工具描述
 func (t *WriteFileTool) Description() string {
-	return "Write content to a file. Creates the file if it doesn't exist, or overwrites it if it does."
+	return "Write content to a file inside the workspace. Creates the file (and missing parent directories) if it doesn't exist, or overwrites it if it does; an overwritten file keeps its permissions, encoding and line endings."
 }
 
 // Schema 返回参数 JSON Schema
@@ -333,25 +402,68 @@ func (t *WriteFileTool) Execute(ctx context.Context, args map[string]interface{}
 		}, fmt.Errorf("content is required")
 	}
 
-	// 使用 cat 配合 heredoc 写入文件
-	cmd := fmt.Sprintf("cat > '%s' << 'NGOCLAW_EOF'\n%s\nNGOCLAW_EOF", path, content)
-
-	result, err := t.sandbox.ExecuteShell(ctx, cmd)
+	realPath, err := resolveWritePath(path, t.sandbox.GetWorkDir())
 	if err != nil {
-		errMsg := err.Error()
-		if result != nil {
-			errMsg = result.Stderr
+		return &Result{Success: false, Error: err.Error()}, nil
+	}
+	absPath := resolveReadPath(path, t.sandbox.GetWorkDir())
+
+	// 本 run 读过该文件且之后被他人改动: 整体覆盖会丢掉对方的修改
+	if t.guard != nil {
+		if err := t.guard.Verify(ctx, absPath); err != nil {
+			return &Result{Success: false, Error: err.Error()}, nil
 		}
-		return &Result{Success: false, Error: errMsg}, nil
+	}
+
+	// 覆盖已有文件时保持其权限、编码 (BOM / UTF-16) 与换行风格
+	format := textFormat{Encoding: encodingUTF8}
+	var mode os.FileMode = newFileMode
+	var before *string
+	beforeRaw, err := os.ReadFile(realPath)
+	switch {
+	case err == nil:
+		text, f, decodeErr := decodeText(beforeRaw)
+		if decodeErr != nil {
+			text = string(beforeRaw) // 覆盖二进制文件: 按 UTF-8 写入
+		} else {
+			format = f
+		}
+		before = &text
+		if info, err := os.Stat(realPath); err == nil {
+			mode = info.Mode().Perm()
+		}
+	case errors.Is(err, os.ErrNotExist):
+		beforeRaw = nil
+		if err := os.MkdirAll(filepath.Dir(realPath), 0o755); err != nil {
+			return &Result{Success: false, Error: err.Error()}, nil
+		}
+	default:
+		return &Result{Success: false, Error: err.Error()}, nil
+	}
+
+	data := encodeText(content, format)
+	if err := os.WriteFile(realPath, data, mode); err != nil {
+		return &Result{Success: false, Error: err.Error()}, nil
+	}
+	after := content
+	if format.CRLF {
+		after = toLF(content)
+	}
+
+	if t.guard != nil {
+		t.guard.Observe(ctx, absPath, "", false)
+		t.guard.RecordEdit(ctx, absPath)
 	}
 
 	return &Result{
 		Output:  fmt.Sprintf("Successfully wrote to %s", path),
 		Success: true,
-		Metadata: map[string]interface{}{
+		Metadata: fileEditMetadata(map[string]interface{}{
 			"path":          path,
-			"bytes_written": len(content),
-		},
+			"bytes_written": len(data),
+			"encoding":      format.Encoding,
+			"line_endings":  format.LineEndings(),
+		}, newTextFileEdit(absPath, before, beforeRaw, after, data)),
 	}, nil
 }
 
@@ -440,6 +552,7 @@ func (t *ListDirTool) Execute(ctx context.Context, args map[string]interface{})
 type SearchTool struct {
 	sandbox *sandbox.ProcessSandbox
 	logger  *zap.Logger
+	index   *WorkspaceIndex // symbol / semantic 模式 (nil = 仅文本搜索)
 }
 
 // NewSearchTool 创建搜索工具
@@ -450,6 +563,11 @@ func NewSearchTool(sandbox *sandbox.ProcessSandbox, logger *zap.Logger) *SearchT
 	}
 }
 
+// SetWorkspaceIndex 启用 symbol / semantic 搜索模式
+func (t *SearchTool) SetWorkspaceIndex(index *WorkspaceIndex) {
+	t.index = index
+}
+
 // Name 返回工具名称
 func (t *SearchTool) Name() string {
 	return "grep_search"
@@ -459,28 +577,41 @@ func (t *SearchTool) Kind() domaintool.Kind { return domaintool.KindSearch }
 
 // Description 返回工具描述
 func (t *SearchTool) Description() string {
+	if t.index != nil {
+		return "Search for patterns in files using grep. Supports regular expressions. " +
+			"mode=symbol looks up functions/types/methods by name in the workspace index; " +
+			"mode=semantic finds code by meaning (when embeddings are enabled)."
+	}
 	return "Search for patterns in files using grep. Supports regular expressions."
 }
 
 // Schema 返回参数 JSON Schema
 func (t *SearchTool) Schema() map[string]interface{} {
-	return map[string]interface{}{
-		"type": "object",
-		"properties": map[string]interface{}{
-			"pattern": map[string]interface{}{
-				"type":        "string",
-				"description": "The pattern to search for",
-			},
-			"path": map[string]interface{}{
-				"type":        "string",
-				"description": "The file or directory to search in",
-			},
-			"recursive": map[string]interface{}{
-				"type":        "boolean",
-				"description": "Search recursively in directories",
-			},
+	props := map[string]interface{}{
+		"pattern": map[string]interface{}{
+			"type":        "string",
+			"description": "The pattern to search for (symbol name or natural-language query in symbol / semantic mode)",
 		},
-		"required": []string{"pattern", "path"},
+		"path": map[string]interface{}{
+			"type":        "string",
+			"description": "The file or directory to search in",
+		},
+		"recursive": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Search recursively in directories",
+		},
+	}
+	if t.index != nil {
+		props["mode"] = map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{"text", "symbol", "semantic"},
+			"description": "text (grep, default) | symbol (definitions by name) | semantic (code by meaning)",
+		}
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+		"required":   []string{"pattern", "path"},
 	}
 }
 
@@ -499,6 +630,14 @@ func (t *SearchTool) Execute(ctx context.Context, args map[string]interface{}) (
 		path = "."
 	}
 
+	switch mode, _ := args["mode"].(string); mode {
+	case "", "text":
+	case "symbol", "semantic":
+		return t.searchIndex(ctx, mode, pattern, path)
+	default:
+		return &Result{Success: false, Error: fmt.Sprintf("unknown mode %q (text | symbol | semantic)", mode)}, nil
+	}
+
 	recursive, _ := args["recursive"].(bool)
 
 	var cmd string
@@ -534,3 +673,74 @@ func (t *SearchTool) Execute(ctx context.Context, args map[string]interface{}) (
 		},
 	}, nil
 }
+
+// searchIndex symbol / semantic 模式: 直接读取工作区索引
+func (t *SearchTool) searchIndex(ctx context.Context, mode, query, path string) (*Result, error) {
+	if !filepath.IsAbs(path) {
+		base := ""
+		if t.sandbox != nil {
+			base = t.sandbox.GetWorkDir()
+		}
+		if base == "" && t.index != nil {
+			base = t.index.Root()
+		}
+		path = filepath.Join(base, path)
+	}
+	if !t.index.Covers(path) {
+		return &Result{
+			Success: false,
+			Error:   fmt.Sprintf("%s search needs the workspace index, which is disabled, still building, or does not cover %s; use mode=text", mode, path),
+		}, nil
+	}
+	root := t.index.Root()
+	rel := func(file string) string {
+		if r, err := filepath.Rel(root, file); err == nil {
+			return r
+		}
+		return file
+	}
+
+	var sb strings.Builder
+	count := 0
+	if mode == "symbol" {
+		for _, sym := range t.index.FindSymbols(query, path, 50) {
+			sig := sym.Signature
+			if sig == "" {
+				sig = sym.Name
+			}
+			fmt.Fprintf(&sb, "%s:%d: [%s] %s\n", rel(sym.File), sym.Line, sym.Kind, sig)
+			count++
+		}
+	} else {
+		hits, err := t.index.SemanticSearch(ctx, query, path, 10)
+		if err != nil {
+			return &Result{Success: false, Error: err.Error()}, nil
+		}
+		for _, hit := range hits {
+			fmt.Fprintf(&sb, "%s:%d-%d (score %.2f)\n", rel(hit.File), hit.StartLine, hit.EndLine, hit.Score)
+			lines := strings.SplitN(hit.Text, "\n", 6)
+			if len(lines) > 5 {
+				lines = append(lines[:5], "...")
+			}
+			for _, line := range lines {
+				sb.WriteString("    " + line + "\n")
+			}
+			count++
+		}
+	}
+
+	output := sb.String()
+	if output == "" {
+		output = "No matches found"
+	}
+	return &Result{
+		Output:  output,
+		Success: true,
+		Metadata: map[string]interface{}{
+			"pattern": query,
+			"path":    path,
+			"mode":    mode,
+			"matches": count,
+		},
+	}, nil
+}
@@ -0,0 +1,397 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/sandbox"
+	"go.uber.org/zap"
+)
+
+// placeholderRe 匹配参数模板中的 {name} 占位符
+var placeholderRe = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// CommandToolSpec 配置声明的命令型工具 (agent.tools.registry 中 backend=command)
+type CommandToolSpec struct {
+	Name        string
+	Description string
+	Kind        domaintool.Kind // 默认 execute
+	Command     string          // 可执行文件, 可带固定参数, 如 "terraform plan"
+	ArgsFormat  string          // 参数模板, 如 "get {resource} -n {namespace}"
+	Parameters  []CommandToolParam
+	Timeout     time.Duration // 0 = 使用沙箱默认超时
+}
+
+// CommandToolParam 命令型工具的一个参数
+type CommandToolParam struct {
+	Name        string
+	Type        string // string (默认) | integer | number | boolean | array
+	Description string
+	Required    bool
+	Enum        []string
+}
+
+// CommandTool 把一条外部命令包装成一等工具, 支持两种调用约定:
+//
+//   - args_format 模式: 模板先按空白 (支持引号) 切分成 argv, 再逐个 token 替换
+//     占位符, 命令经沙箱直接 exec 而不经过 shell — 参数值不会被二次切分或解释,
+//     也就无法注入 ; | $() 之类的 shell 语法. stdout 即工具输出.
+//   - stdin 模式 (未设置 args_format): 参数以 JSON 写入 stdin, stdout 输出
+//     {"success": bool, "result": ..., "error": "..."}; 非 JSON 输出原样返回.
+type CommandTool struct {
+	spec    CommandToolSpec
+	binary  string
+	tokens  []string // Command 的固定参数 + ArgsFormat
+	params  map[string]CommandToolParam
+	schema  map[string]interface{} // 覆盖内置工具且未声明参数时沿用内置 schema
+	sandbox *sandbox.ProcessSandbox
+	logger  *zap.Logger
+}
+
+// NewCommandTool 校验配置并创建命令型工具
+func NewCommandTool(spec CommandToolSpec, sb *sandbox.ProcessSandbox, logger *zap.Logger) (*CommandTool, error) {
+	if spec.Name == "" {
+		return nil, fmt.Errorf("command tool: name is required")
+	}
+	head, err := splitArgs(spec.Command)
+	if err != nil || len(head) == 0 {
+		return nil, fmt.Errorf("command tool %s: invalid command %q", spec.Name, spec.Command)
+	}
+	rest, err := splitArgs(spec.ArgsFormat)
+	if err != nil {
+		return nil, fmt.Errorf("command tool %s: invalid args_format: %w", spec.Name, err)
+	}
+	if placeholderRe.MatchString(head[0]) {
+		return nil, fmt.Errorf("command tool %s: the executable cannot be a placeholder", spec.Name)
+	}
+
+	params := make(map[string]CommandToolParam, len(spec.Parameters))
+	for _, p := range spec.Parameters {
+		if p.Type == "" {
+			p.Type = "string"
+		}
+		switch p.Type {
+		case "string", "integer", "number", "boolean", "array":
+		default:
+			return nil, fmt.Errorf("command tool %s: parameter %s has unsupported type %q", spec.Name, p.Name, p.Type)
+		}
+		params[p.Name] = p
+	}
+
+	tokens := append(head[1:], rest...)
+	for i, tok := range tokens {
+		matches := placeholderRe.FindAllStringSubmatch(tok, -1)
+		for _, m := range matches {
+			if _, ok := params[m[1]]; !ok {
+				return nil, fmt.Errorf("command tool %s: placeholder {%s} has no matching parameter", spec.Name, m[1])
+			}
+		}
+		if len(matches) == 0 {
+			tokens[i] = expandHome(tok)
+		}
+	}
+
+	if spec.Kind == "" {
+		spec.Kind = domaintool.KindExecute
+	}
+	return &CommandTool{
+		spec:    spec,
+		binary:  expandHome(head[0]),
+		tokens:  tokens,
+		params:  params,
+		sandbox: sb,
+		logger:  logger,
+	}, nil
+}
+
+// Binary 返回要执行的可执行文件 (注册时加入沙箱白名单)
+func (t *CommandTool) Binary() string { return t.binary }
+
+// InheritFrom 覆盖同名内置工具时, 未在配置中声明的描述与参数沿用内置定义
+func (t *CommandTool) InheritFrom(builtin domaintool.Tool) {
+	if t.spec.Description == "" {
+		t.spec.Description = builtin.Description()
+	}
+	if len(t.spec.Parameters) == 0 {
+		t.schema = builtin.Schema()
+	}
+}
+
+func (t *CommandTool) Name() string          { return t.spec.Name }
+func (t *CommandTool) Kind() domaintool.Kind { return t.spec.Kind }
+
+func (t *CommandTool) Description() string {
+	if t.spec.Description != "" {
+		return t.spec.Description
+	}
+	return fmt.Sprintf("Run `%s %s`.", t.binary, strings.Join(t.tokens, " "))
+}
+
+func (t *CommandTool) Schema() map[string]interface{} {
+	if t.schema != nil {
+		return t.schema
+	}
+	props := make(map[string]interface{}, len(t.spec.Parameters))
+	required := []string{}
+	for _, p := range t.spec.Parameters {
+		prop := map[string]interface{}{"type": t.params[p.Name].Type}
+		if p.Description != "" {
+			prop["description"] = p.Description
+		}
+		if len(p.Enum) > 0 {
+			prop["enum"] = p.Enum
+		}
+		if prop["type"] == "array" {
+			prop["items"] = map[string]interface{}{"type": "string"}
+		}
+		props[p.Name] = prop
+		if p.Required {
+			required = append(required, p.Name)
+		}
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+		"required":   required,
+	}
+}
+
+func (t *CommandTool) Execute(ctx context.Context, args map[string]interface{}) (*Result, error) {
+	opts := sandbox.ExecOptions{Timeout: t.spec.Timeout}
+	argv := t.tokens
+	if t.spec.ArgsFormat != "" {
+		var err error
+		if argv, err = t.buildArgs(args); err != nil {
+			return &Result{Success: false, Error: err.Error()}, nil
+		}
+	} else {
+		input, err := json.Marshal(args)
+		if err != nil {
+			return &Result{Success: false, Error: fmt.Sprintf("encode arguments: %v", err)}, nil
+		}
+		opts.Stdin = string(input)
+	}
+
+	t.logger.Info("Command tool", zap.String("tool", t.spec.Name), zap.String("binary", t.binary), zap.Strings("args", argv))
+
+	result, err := t.sandbox.ExecuteWith(ctx, opts, t.binary, argv)
+	if err != nil {
+		msg := err.Error()
+		if result != nil && result.Stderr != "" {
+			msg += "\n" + result.Stderr
+		}
+		return &Result{Success: false, Error: msg}, nil
+	}
+
+	metadata := map[string]interface{}{
+		"command":   strings.Join(append([]string{t.binary}, argv...), " "),
+		"exit_code": result.ExitCode,
+	}
+	if result.ExitCode != 0 {
+		return &Result{
+			Output:   result.Stdout,
+			Success:  false,
+			Error:    fmt.Sprintf("%s exited with code %d\n%s", t.binary, result.ExitCode, strings.TrimSpace(result.Stderr)),
+			Metadata: metadata,
+		}, nil
+	}
+
+	if t.spec.ArgsFormat == "" {
+		if res, ok := parseJSONResult(result.Stdout); ok {
+			res.Metadata = metadata
+			return res, nil
+		}
+	}
+
+	output := result.Stdout
+	if strings.TrimSpace(output) == "" {
+		// 部分 CLI 只往 stderr 写结果 (进度、摘要)
+		output = result.Stderr
+	}
+	return &Result{Output: output, Success: true, Metadata: metadata}, nil
+}
+
+// parseJSONResult 解析 stdin 模式脚本的 {"success", "result", "error"} 输出
+func parseJSONResult(stdout string) (*Result, bool) {
+	var out struct {
+		Success *bool           `json:"success"`
+		Result  json.RawMessage `json:"result"`
+		Error   string          `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(stdout)), &out); err != nil || out.Success == nil {
+		return nil, false
+	}
+	var text string
+	if err := json.Unmarshal(out.Result, &text); err != nil {
+		text = string(out.Result)
+	}
+	return &Result{Output: text, Success: *out.Success, Error: out.Error}, true
+}
+
+// expandHome 展开开头的 ~/ (命令不经过 shell, 需要自行展开)
+func expandHome(s string) string {
+	if !strings.HasPrefix(s, "~/") {
+		return s
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return s
+	}
+	return filepath.Join(home, s[2:])
+}
+
+// buildArgs 按模板生成 argv.
+//   - 整个 token 为 {name}: 未提供时省略; array 展开为多个参数
+//   - token 内嵌占位符 (如 --namespace={ns}): 任一占位符未提供则整个 token 省略
+//
+// 作为独立参数的值不能以 "-" 开头, 防止模型借参数值注入额外选项.
+func (t *CommandTool) buildArgs(args map[string]interface{}) ([]string, error) {
+	values := make(map[string][]string, len(t.params))
+	for name, p := range t.params {
+		raw, ok := args[name]
+		if !ok || raw == nil {
+			if p.Required {
+				return nil, fmt.Errorf("%s is required", name)
+			}
+			continue
+		}
+		vals, err := formatParam(p, raw)
+		if err != nil {
+			return nil, err
+		}
+		if len(vals) > 0 {
+			values[name] = vals
+		}
+	}
+
+	var argv []string
+	for _, tok := range t.tokens {
+		if m := placeholderRe.FindStringSubmatch(tok); m != nil && m[0] == tok {
+			for _, v := range values[m[1]] {
+				if strings.HasPrefix(v, "-") {
+					return nil, fmt.Errorf("%s: value %q must not start with '-'", m[1], v)
+				}
+				argv = append(argv, v)
+			}
+			continue
+		}
+
+		missing := false
+		out := placeholderRe.ReplaceAllStringFunc(tok, func(ph string) string {
+			vals, ok := values[ph[1:len(ph)-1]]
+			if !ok {
+				missing = true
+				return ""
+			}
+			return strings.Join(vals, ",")
+		})
+		if !missing {
+			argv = append(argv, out)
+		}
+	}
+	return argv, nil
+}
+
+// formatParam 校验并把参数值转换为字符串; 空字符串视为未提供
+func formatParam(p CommandToolParam, raw interface{}) ([]string, error) {
+	var vals []string
+	switch p.Type {
+	case "array":
+		items, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s must be an array", p.Name)
+		}
+		for _, it := range items {
+			s, ok := it.(string)
+			if !ok {
+				s = fmt.Sprint(it)
+			}
+			vals = append(vals, s)
+		}
+	case "integer":
+		f, ok := raw.(float64)
+		if !ok || f != float64(int64(f)) {
+			return nil, fmt.Errorf("%s must be an integer", p.Name)
+		}
+		vals = []string{strconv.FormatInt(int64(f), 10)}
+	case "number":
+		f, ok := raw.(float64)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a number", p.Name)
+		}
+		vals = []string{strconv.FormatFloat(f, 'f', -1, 64)}
+	case "boolean":
+		b, ok := raw.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a boolean", p.Name)
+		}
+		vals = []string{strconv.FormatBool(b)}
+	default:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a string", p.Name)
+		}
+		if s == "" {
+			return nil, nil
+		}
+		vals = []string{s}
+	}
+
+	for _, v := range vals {
+		if strings.ContainsRune(v, 0) {
+			return nil, fmt.Errorf("%s contains a NUL byte", p.Name)
+		}
+		if len(p.Enum) > 0 && !slices.Contains(p.Enum, v) {
+			return nil, fmt.Errorf("%s must be one of: %s", p.Name, strings.Join(p.Enum, ", "))
+		}
+	}
+	return vals, nil
+}
+
+// splitArgs 按空白切分模板, 支持单/双引号包裹含空格的片段
+func splitArgs(s string) ([]string, error) {
+	var (
+		out   []string
+		cur   strings.Builder
+		quote rune
+		inTok bool
+	)
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inTok = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inTok {
+				out = append(out, cur.String())
+				cur.Reset()
+				inTok = false
+			}
+		default:
+			cur.WriteRune(r)
+			inTok = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if inTok {
+		out = append(out, cur.String())
+	}
+	return out, nil
+}
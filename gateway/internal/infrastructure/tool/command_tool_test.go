@@ -0,0 +1,105 @@
+package tool
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/sandbox"
+	"go.uber.org/zap"
+)
+
+func kubectlSpec() CommandToolSpec {
+	return CommandToolSpec{
+		Name:       "kubectl_get",
+		Command:    "kubectl get",
+		ArgsFormat: "{resource} {name} --namespace={namespace} -o {output} {labels}",
+		Parameters: []CommandToolParam{
+			{Name: "resource", Required: true, Enum: []string{"pods", "deployments"}},
+			{Name: "name"},
+			{Name: "namespace"},
+			{Name: "output"},
+			{Name: "labels", Type: "array"},
+		},
+	}
+}
+
+func TestCommandTool_BuildArgs(t *testing.T) {
+	ct, err := NewCommandTool(kubectlSpec(), nil, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ct.buildArgs(map[string]interface{}{
+		"resource":  "pods",
+		"namespace": "prod; rm -rf /",
+		"output":    "wide",
+		"labels":    []interface{}{"app=web", "tier=fe"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// name 未提供 → 省略; 值原样作为单个参数, 不会被 shell 解释
+	want := []string{"get", "pods", "--namespace=prod; rm -rf /", "-o", "wide", "app=web", "tier=fe"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("argv = %q, want %q", got, want)
+	}
+
+	for name, args := range map[string]map[string]interface{}{
+		"missing required": {},
+		"not in enum":      {"resource": "secrets"},
+		"option injection": {"resource": "pods", "name": "--kubeconfig=/tmp/evil"},
+		"wrong type":       {"resource": "pods", "labels": "app=web"},
+	} {
+		if _, err := ct.buildArgs(args); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestNewCommandTool_RejectsUnknownPlaceholder(t *testing.T) {
+	spec := kubectlSpec()
+	spec.ArgsFormat += " {context}"
+	if _, err := NewCommandTool(spec, nil, zap.NewNop()); err == nil {
+		t.Error("expected error for placeholder without parameter")
+	}
+}
+
+func TestCommandTool_Execute(t *testing.T) {
+	cfg := sandbox.DefaultConfig()
+	cfg.WorkDir = t.TempDir()
+	cfg.TempDir = t.TempDir()
+	sb, err := sandbox.NewProcessSandbox(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// args_format 模式: stdout 即输出
+	echo, err := NewCommandTool(CommandToolSpec{
+		Name:       "greet",
+		Command:    "echo hello",
+		ArgsFormat: "{who}",
+		Parameters: []CommandToolParam{{Name: "who", Required: true}},
+	}, sb, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, _ := echo.Execute(context.Background(), map[string]interface{}{"who": "$(whoami)"})
+	if !res.Success || strings.TrimSpace(res.Output) != "hello $(whoami)" {
+		t.Errorf("greet = %+v", res)
+	}
+
+	// stdin 模式: 参数以 JSON 写入 stdin, 解析 {"success","result"} 输出
+	script, err := NewCommandTool(CommandToolSpec{
+		Name:    "lookup",
+		Command: `sh -c 'grep -q "\"q\":\"x\"" && echo "{\"success\": true, \"result\": \"found\"}"'`,
+	}, sb, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, _ = script.Execute(context.Background(), map[string]interface{}{"q": "x"})
+	if !res.Success || res.Output != "found" {
+		t.Errorf("lookup = %+v", res)
+	}
+}
@@ -0,0 +1,389 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"go.uber.org/zap"
+)
+
+// maxCommitDiffChars bounds the staged diff sent to the LLM.
+const maxCommitDiffChars = 12000
+
+// conventionalRe matches "type(scope)!: subject" / "type: subject".
+var conventionalRe = regexp.MustCompile(`^([a-z]+)(\(([^)]+)\))?!?: (.+)$`)
+
+// CommitSuggestion is a generated conventional commit message.
+type CommitSuggestion struct {
+	Type    string   `json:"type"`    // feat, fix, docs, test, refactor, chore, ...
+	Scope   string   `json:"scope"`   // detected from staged paths, may be empty
+	Subject string   `json:"subject"` // imperative summary, no trailing period
+	Body    string   `json:"body,omitempty"`
+	Files   []string `json:"files"`
+}
+
+// Header returns "type(scope): subject".
+func (s *CommitSuggestion) Header() string {
+	if s.Scope != "" {
+		return fmt.Sprintf("%s(%s): %s", s.Type, s.Scope, s.Subject)
+	}
+	return fmt.Sprintf("%s: %s", s.Type, s.Subject)
+}
+
+// Message returns the full commit message.
+func (s *CommitSuggestion) Message() string {
+	if s.Body == "" {
+		return s.Header()
+	}
+	return s.Header() + "\n\n" + s.Body
+}
+
+// CommitSuggester inspects the staged diff and proposes a commit message.
+// With no LLM client it falls back to path-based heuristics.
+type CommitSuggester struct {
+	llm    service.LLMClient
+	model  string
+	logger *zap.Logger
+}
+
+// NewCommitSuggester creates a suggester. llm may be nil.
+func NewCommitSuggester(llm service.LLMClient, model string, logger *zap.Logger) *CommitSuggester {
+	return &CommitSuggester{llm: llm, model: model, logger: logger}
+}
+
+// Suggest generates a message for the changes staged in repoPath.
+func (c *CommitSuggester) Suggest(ctx context.Context, repoPath string) (*CommitSuggestion, error) {
+	nameOnly, err := runGit(ctx, repoPath, "diff", "--staged", "--name-only")
+	if err != nil {
+		return nil, err
+	}
+	files := splitLines(nameOnly)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("nothing staged (use git add first)")
+	}
+
+	s := &CommitSuggestion{
+		Type:  detectCommitType(files),
+		Scope: detectCommitScope(files),
+		Files: files,
+	}
+
+	if c.llm != nil {
+		diff, err := runGit(ctx, repoPath, "diff", "--staged", "--stat", "--patch")
+		if err != nil {
+			return nil, err
+		}
+		if err := c.fillFromLLM(ctx, s, diff); err != nil {
+			c.logger.Warn("LLM commit message failed, using heuristic", zap.Error(err))
+		}
+	}
+
+	if s.Subject == "" {
+		s.Subject = heuristicSubject(files)
+	}
+	return s, nil
+}
+
+func (c *CommitSuggester) fillFromLLM(ctx context.Context, s *CommitSuggestion, diff string) error {
+	if len(diff) > maxCommitDiffChars {
+		diff = diff[:maxCommitDiffChars] + "\n... (diff truncated)"
+	}
+
+	system := "You write git commit messages in the Conventional Commits format.\n" +
+		"Reply with the message only: a header line `type(scope): subject` " +
+		"(imperative mood, lowercase type, no trailing period, at most 72 chars), " +
+		"optionally followed by a blank line and a short body explaining why.\n" +
+		"Allowed types: feat, fix, docs, test, refactor, perf, build, ci, chore, style."
+	user := fmt.Sprintf("Suggested type: %s\nSuggested scope: %s\n\nStaged diff:\n%s", s.Type, s.Scope, diff)
+
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	resp, err := c.llm.Generate(ctx, &service.LLMRequest{
+		Model: c.model,
+		Messages: []service.LLMMessage{
+			{Role: "system", Content: system},
+			{Role: "user", Content: user},
+		},
+		MaxTokens:   400,
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return err
+	}
+
+	return parseCommitMessage(s, service.StripReasoningTags(resp.Content))
+}
+
+// parseCommitMessage fills s from an LLM reply, keeping detected type/scope
+// when the reply omits them.
+func parseCommitMessage(s *CommitSuggestion, reply string) error {
+	reply = strings.TrimSpace(strings.Trim(strings.TrimSpace(reply), "`"))
+	if reply == "" {
+		return fmt.Errorf("empty reply")
+	}
+
+	header, body, _ := strings.Cut(reply, "\n")
+	header = strings.TrimSpace(header)
+
+	if m := conventionalRe.FindStringSubmatch(header); m != nil {
+		s.Type = m[1]
+		if m[3] != "" {
+			s.Scope = m[3]
+		}
+		s.Subject = m[4]
+	} else {
+		s.Subject = header
+	}
+	s.Subject = strings.TrimSuffix(strings.TrimSpace(s.Subject), ".")
+	s.Body = strings.TrimSpace(body)
+	return nil
+}
+
+// ParseCommitHeader applies a user-edited header line, keeping detected
+// type/scope when the line is not in conventional form.
+func ParseCommitHeader(s *CommitSuggestion, header string) error {
+	body := s.Body
+	if err := parseCommitMessage(s, header); err != nil {
+		return err
+	}
+	s.Body = body
+	return nil
+}
+
+// detectCommitType guesses the conventional type from staged paths.
+func detectCommitType(files []string) string {
+	docs, tests := 0, 0
+	for _, f := range files {
+		base := path.Base(f)
+		switch {
+		case strings.HasSuffix(f, ".md") || strings.HasPrefix(f, "docs/"):
+			docs++
+		case strings.HasSuffix(base, "_test.go") || strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") || strings.HasPrefix(base, "test_"):
+			tests++
+		}
+	}
+	switch {
+	case docs == len(files):
+		return "docs"
+	case tests == len(files):
+		return "test"
+	case docs+tests == len(files):
+		return "chore"
+	}
+	return "feat"
+}
+
+// detectCommitScope returns the deepest directory name shared by all staged
+// files (e.g. "telegram" for gateway/internal/interfaces/telegram/*).
+func detectCommitScope(files []string) string {
+	if len(files) == 0 {
+		return ""
+	}
+	common := strings.Split(path.Dir(files[0]), "/")
+	for _, f := range files[1:] {
+		parts := strings.Split(path.Dir(f), "/")
+		n := 0
+		for n < len(common) && n < len(parts) && common[n] == parts[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	for i := len(common) - 1; i >= 0; i-- {
+		switch common[i] {
+		case ".", "", "internal", "pkg", "src", "lib", "cmd":
+			continue
+		}
+		return common[i]
+	}
+	return ""
+}
+
+func heuristicSubject(files []string) string {
+	if len(files) == 1 {
+		return "update " + path.Base(files[0])
+	}
+	return fmt.Sprintf("update %d files", len(files))
+}
+
+// changelogSections maps commit types to Keep a Changelog headings.
+var changelogSections = map[string]string{
+	"feat":     "Added",
+	"fix":      "Fixed",
+	"perf":     "Changed",
+	"refactor": "Changed",
+	"docs":     "Changed",
+}
+
+// UpdateChangelog adds the suggestion under "## [Unreleased]" in
+// repoPath/CHANGELOG.md, creating the file or section when missing.
+// Types without a changelog section (test, chore, ...) are skipped.
+func UpdateChangelog(repoPath string, s *CommitSuggestion) (bool, error) {
+	section, ok := changelogSections[s.Type]
+	if !ok {
+		return false, nil
+	}
+
+	file := filepath.Join(repoPath, "CHANGELOG.md")
+	data, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	content := string(data)
+	if content == "" {
+		content = "# Changelog\n\n"
+	}
+
+	entry := "- " + s.Subject
+	if s.Scope != "" {
+		entry = fmt.Sprintf("- **%s**: %s", s.Scope, s.Subject)
+	}
+
+	lines := strings.Split(content, "\n")
+	unreleased := -1
+	for i, l := range lines {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(l)), "## [unreleased]") {
+			unreleased = i
+			break
+		}
+	}
+	if unreleased < 0 {
+		// Insert before the first release heading, or at the end.
+		insertAt := len(lines)
+		for i, l := range lines {
+			if strings.HasPrefix(l, "## ") {
+				insertAt = i
+				break
+			}
+		}
+		block := []string{"## [Unreleased]", "", "### " + section, entry, ""}
+		lines = append(lines[:insertAt], append(block, lines[insertAt:]...)...)
+	} else {
+		end := len(lines)
+		for i := unreleased + 1; i < len(lines); i++ {
+			if strings.HasPrefix(lines[i], "## ") {
+				end = i
+				break
+			}
+		}
+		heading := -1
+		for i := unreleased + 1; i < end; i++ {
+			if strings.TrimSpace(lines[i]) == "### "+section {
+				heading = i
+				break
+			}
+		}
+		if heading >= 0 {
+			lines = append(lines[:heading+1], append([]string{entry}, lines[heading+1:]...)...)
+		} else {
+			block := []string{"### " + section, entry, ""}
+			at := unreleased + 1
+			if at < len(lines) && strings.TrimSpace(lines[at]) == "" {
+				at++
+			}
+			lines = append(lines[:at], append(block, lines[at:]...)...)
+		}
+	}
+
+	return true, os.WriteFile(file, []byte(strings.Join(lines, "\n")), 0644)
+}
+
+func runGit(ctx context.Context, repoPath string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = repoPath
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+func splitLines(s string) []string {
+	var out []string
+	for _, l := range strings.Split(s, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// ─── suggest_commit tool ───
+
+// SuggestCommitTool proposes a conventional commit message for staged changes.
+// It never commits: the agent shows the suggestion, asks the user, then uses git commit.
+type SuggestCommitTool struct {
+	suggester *CommitSuggester
+	logger    *zap.Logger
+}
+
+// NewSuggestCommitTool creates the suggest_commit tool. llm may be nil.
+func NewSuggestCommitTool(llm service.LLMClient, model string, logger *zap.Logger) *SuggestCommitTool {
+	return &SuggestCommitTool{
+		suggester: NewCommitSuggester(llm, model, logger),
+		logger:    logger,
+	}
+}
+
+func (t *SuggestCommitTool) Name() string          { return "suggest_commit" }
+func (t *SuggestCommitTool) Kind() domaintool.Kind { return domaintool.KindRead }
+
+func (t *SuggestCommitTool) Description() string {
+	return "Inspect the staged git diff and suggest a Conventional Commits message with detected scope, " +
+		"plus the matching CHANGELOG.md entry. Does not commit: show the suggestion to the user, " +
+		"and only after they confirm, commit with the git tool (action=commit)."
+}
+
+func (t *SuggestCommitTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"repo_path": map[string]interface{}{
+				"type":        "string",
+				"description": "Path to the git repository (default: current directory)",
+			},
+		},
+	}
+}
+
+func (t *SuggestCommitTool) Execute(ctx context.Context, args map[string]interface{}) (*Result, error) {
+	repoPath := "."
+	if rp, ok := args["repo_path"].(string); ok && rp != "" {
+		repoPath = rp
+	}
+
+	s, err := t.suggester.Suggest(ctx, repoPath)
+	if err != nil {
+		return &Result{Success: false, Error: err.Error()}, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Suggested commit message:\n\n")
+	sb.WriteString(s.Message())
+	sb.WriteString(fmt.Sprintf("\n\nStaged files (%d):\n", len(s.Files)))
+	for _, f := range s.Files {
+		sb.WriteString("  " + f + "\n")
+	}
+	if section, ok := changelogSections[s.Type]; ok {
+		sb.WriteString(fmt.Sprintf("\nCHANGELOG.md entry (### %s): %s\n", section, s.Subject))
+	}
+
+	return &Result{
+		Output:  sb.String(),
+		Success: true,
+		Metadata: map[string]interface{}{
+			"type":    s.Type,
+			"scope":   s.Scope,
+			"message": s.Message(),
+		},
+	}, nil
+}
@@ -0,0 +1,81 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectCommitScope(t *testing.T) {
+	tests := []struct {
+		files []string
+		want  string
+	}{
+		{[]string{"gateway/internal/interfaces/telegram/adapter.go", "gateway/internal/interfaces/telegram/commands.go"}, "telegram"},
+		{[]string{"gateway/internal/interfaces/telegram/a.go", "gateway/internal/interfaces/cli/b.go"}, "interfaces"},
+		{[]string{"gateway/pkg/i18n/i18n.go"}, "i18n"},
+		{[]string{"README.md", "docs/x.md"}, ""},
+	}
+	for _, tt := range tests {
+		if got := detectCommitScope(tt.files); got != tt.want {
+			t.Errorf("detectCommitScope(%v) = %q, want %q", tt.files, got, tt.want)
+		}
+	}
+}
+
+func TestDetectCommitType(t *testing.T) {
+	if got := detectCommitType([]string{"docs/USER_MANUAL.md"}); got != "docs" {
+		t.Errorf("got %q, want docs", got)
+	}
+	if got := detectCommitType([]string{"a/b_test.go"}); got != "test" {
+		t.Errorf("got %q, want test", got)
+	}
+	if got := detectCommitType([]string{"a/b.go", "a/b_test.go"}); got != "feat" {
+		t.Errorf("got %q, want feat", got)
+	}
+}
+
+func TestParseCommitMessage(t *testing.T) {
+	s := &CommitSuggestion{Type: "feat", Scope: "cli"}
+	if err := parseCommitMessage(s, "```\nfix(router): retry on 429.\n\nBody line.\n```"); err != nil {
+		t.Fatal(err)
+	}
+	if s.Header() != "fix(router): retry on 429" {
+		t.Errorf("header = %q", s.Header())
+	}
+	if s.Body != "Body line." {
+		t.Errorf("body = %q", s.Body)
+	}
+
+	s = &CommitSuggestion{Type: "feat", Scope: "cli"}
+	_ = parseCommitMessage(s, "add commit command")
+	if s.Header() != "feat(cli): add commit command" {
+		t.Errorf("non-conventional reply should keep detected type/scope, got %q", s.Header())
+	}
+}
+
+func TestUpdateChangelog(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "CHANGELOG.md")
+	_ = os.WriteFile(file, []byte("# Changelog\n\n## [1.0.0]\n\n- initial\n"), 0644)
+
+	ok, err := UpdateChangelog(dir, &CommitSuggestion{Type: "feat", Scope: "cli", Subject: "add commit command"})
+	if err != nil || !ok {
+		t.Fatalf("update: ok=%v err=%v", ok, err)
+	}
+	ok, _ = UpdateChangelog(dir, &CommitSuggestion{Type: "feat", Subject: "second"})
+	if !ok {
+		t.Fatal("second update skipped")
+	}
+	if ok, _ := UpdateChangelog(dir, &CommitSuggestion{Type: "test", Subject: "skip me"}); ok {
+		t.Error("test commits should not touch the changelog")
+	}
+
+	data, _ := os.ReadFile(file)
+	got := string(data)
+	want := "## [Unreleased]\n\n### Added\n- second\n- **cli**: add commit command\n\n## [1.0.0]"
+	if !strings.Contains(got, want) {
+		t.Errorf("unexpected changelog:\n%s", got)
+	}
+}
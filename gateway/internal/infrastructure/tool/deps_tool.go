@@ -0,0 +1,1037 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/sandbox"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultGoProxy     = "https://proxy.golang.org"
+	defaultNPMRegistry = "https://registry.npmjs.org"
+	defaultPyPI        = "https://pypi.org"
+
+	// depsLookupWorkers bounds concurrent registry requests per call.
+	depsLookupWorkers = 8
+	// depsOutputTail is how much install / verification output the model sees on failure.
+	depsOutputTail = 4000
+	// goMaxMajorProbe bounds how many /vN module paths are probed past the current major.
+	goMaxMajorProbe = 5
+)
+
+// DepsConfig configures the update_deps tool.
+type DepsConfig struct {
+	GoProxy     string        // module proxy (default https://proxy.golang.org)
+	NPMRegistry string        // npm registry (default https://registry.npmjs.org)
+	PyPI        string        // PyPI JSON API base (default https://pypi.org)
+	Timeout     time.Duration // per install / verification command, default 10m
+}
+
+// Bump classes, from least to most likely to break.
+const (
+	bumpPatch = "patch"
+	bumpMinor = "minor"
+	bumpMajor = "major"
+)
+
+var bumpRank = map[string]int{bumpPatch: 1, bumpMinor: 2, bumpMajor: 3}
+
+// depVersion is a release version reduced to major.minor.patch.
+// Pre-releases, pseudo-versions and post-releases don't parse.
+type depVersion struct {
+	major, minor, patch int
+}
+
+// parseDepVersion parses "v1.2.3", "1.2" or "2024.1". A Go "+incompatible"
+// suffix is ignored.
+func parseDepVersion(s string) (depVersion, bool) {
+	s = strings.TrimPrefix(strings.TrimSuffix(s, "+incompatible"), "v")
+	parts := strings.Split(s, ".")
+	if s == "" || len(parts) > 3 {
+		return depVersion{}, false
+	}
+	var n [3]int
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil || v < 0 {
+			return depVersion{}, false
+		}
+		n[i] = v
+	}
+	return depVersion{n[0], n[1], n[2]}, true
+}
+
+func (v depVersion) less(o depVersion) bool {
+	if v.major != o.major {
+		return v.major < o.major
+	}
+	if v.minor != o.minor {
+		return v.minor < o.minor
+	}
+	return v.patch < o.patch
+}
+
+// classifyBump says how big the step from cur to next is. Below 1.0 a minor
+// bump counts as major: semver allows breaking changes there.
+func classifyBump(cur, next depVersion) string {
+	switch {
+	case next.major != cur.major, cur.major == 0 && next.minor != cur.minor:
+		return bumpMajor
+	case next.minor != cur.minor:
+		return bumpMinor
+	default:
+		return bumpPatch
+	}
+}
+
+// depCandidate is a newer release of a dependency.
+type depCandidate struct {
+	Version string // as the registry spells it
+	Module  string // Go: module path when it differs (/vN major versions)
+	Bump    string
+}
+
+// dependency is one entry of a manifest.
+type dependency struct {
+	Name    string
+	Current string // version in the manifest
+	Spec    string // npm range as written (^1.2.3), for rewriting
+	Dev     bool   // npm devDependencies
+	Note    string // why it can't be updated (pseudo-version, unpinned, ...)
+
+	Candidates map[string]*depCandidate // bump class → newest release of that class
+	Err        string                   // registry lookup failure
+}
+
+// best returns the newest candidate whose bump is at most level.
+func (d *dependency) best(level string) *depCandidate {
+	var best *depCandidate
+	for _, c := range d.Candidates {
+		if bumpRank[c.Bump] <= bumpRank[level] && (best == nil || bumpRank[c.Bump] > bumpRank[best.Bump]) {
+			best = c
+		}
+	}
+	return best
+}
+
+// manifest is a dependency file of one ecosystem.
+type manifest struct {
+	eco  string // go | npm | pip
+	path string
+	deps []*dependency
+}
+
+func (m *manifest) dir() string { return filepath.Dir(m.path) }
+
+// UpdateDepsTool checks a project's dependencies against their registries,
+// classifies the available updates (patch / minor / major), applies the
+// chosen bumps, installs them and runs the verification command, all in one
+// call. Supports go.mod (module proxy), package.json (npm) and
+// requirements.txt (PyPI, pinned == lines only).
+type UpdateDepsTool struct {
+	sandbox *sandbox.ProcessSandbox
+	guard   *FileGuard
+	cfg     DepsConfig
+	client  *http.Client
+	logger  *zap.Logger
+}
+
+// NewUpdateDepsTool creates the update_deps tool.
+func NewUpdateDepsTool(sb *sandbox.ProcessSandbox, guard *FileGuard, cfg DepsConfig, logger *zap.Logger) *UpdateDepsTool {
+	if cfg.GoProxy == "" {
+		cfg.GoProxy = defaultGoProxy
+	}
+	if cfg.NPMRegistry == "" {
+		cfg.NPMRegistry = defaultNPMRegistry
+	}
+	if cfg.PyPI == "" {
+		cfg.PyPI = defaultPyPI
+	}
+	cfg.GoProxy = strings.TrimRight(cfg.GoProxy, "/")
+	cfg.NPMRegistry = strings.TrimRight(cfg.NPMRegistry, "/")
+	cfg.PyPI = strings.TrimRight(cfg.PyPI, "/")
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Minute
+	}
+	return &UpdateDepsTool{
+		sandbox: sb,
+		guard:   guard,
+		cfg:     cfg,
+		client:  &http.Client{Timeout: 20 * time.Second},
+		logger:  logger,
+	}
+}
+
+func (t *UpdateDepsTool) Name() string          { return "update_deps" }
+func (t *UpdateDepsTool) Kind() domaintool.Kind { return domaintool.KindExecute }
+
+func (t *UpdateDepsTool) Description() string {
+	return "Check and update project dependencies in one call (go.mod, package.json, requirements.txt). " +
+		"action=check lists outdated dependencies with the newest patch, minor and major release of each. " +
+		"action=apply bumps the selected dependencies up to `level`, installs them (go get / npm install / pip install) " +
+		"and runs the verification command (default: build and tests), reporting any breakage. " +
+		"Use this instead of querying registries and editing manifests by hand."
+}
+
+func (t *UpdateDepsTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"check", "apply"},
+				"description": "check (default) lists available updates; apply bumps, installs and verifies",
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Project directory or manifest file. Default: the working directory",
+			},
+			"level": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{bumpPatch, bumpMinor, bumpMajor},
+				"description": "apply: largest bump to take. Default: minor. Below 1.0 a minor bump counts as major",
+			},
+			"packages": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "apply: only these dependencies. Default: every outdated one within level",
+			},
+			"verify": map[string]interface{}{
+				"type": "string",
+				"description": "apply: shell command run after installing. Default: go build ./... && go test ./... / npm test / " +
+					"python3 -m pytest -q. \"none\" skips verification",
+			},
+			"include_indirect": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Go: also check // indirect requirements. Default: false",
+			},
+			"revert_on_failure": map[string]interface{}{
+				"type":        "boolean",
+				"description": "apply: restore the manifest and lock files if installing or verification fails. Default: false",
+			},
+		},
+	}
+}
+
+// Timeout covers the install and the verification command, for the agent
+// loop's per-tool timeout.
+func (t *UpdateDepsTool) Timeout() time.Duration {
+	return 2*t.cfg.Timeout + time.Minute
+}
+
+func (t *UpdateDepsTool) Execute(ctx context.Context, args map[string]interface{}) (*Result, error) {
+	action, _ := args["action"].(string)
+	if action == "" {
+		action = "check"
+	}
+	if action != "check" && action != "apply" {
+		return &Result{Success: false, Error: fmt.Sprintf("update_deps: unknown action %q (check | apply)", action)}, nil
+	}
+	if action == "apply" && t.sandbox == nil {
+		return &Result{Success: false, Error: "update_deps: apply requires the sandbox"}, nil
+	}
+	level, _ := args["level"].(string)
+	if level == "" {
+		level = bumpMinor
+	}
+	if bumpRank[level] == 0 {
+		return &Result{Success: false, Error: fmt.Sprintf("update_deps: unknown level %q (patch | minor | major)", level)}, nil
+	}
+	indirect, _ := args["include_indirect"].(bool)
+
+	path, _ := args["path"].(string)
+	if path != "" {
+		path = t.resolve(path)
+	} else if path = t.workDir(); path == "" {
+		return &Result{Success: false, Error: "update_deps: pass path (no working directory)"}, nil
+	}
+	manifests, err := findManifests(path, indirect)
+	if err != nil {
+		return &Result{Success: false, Error: "update_deps: " + err.Error()}, nil
+	}
+
+	var all []*dependency
+	for _, m := range manifests {
+		all = append(all, m.deps...)
+	}
+	t.lookupAll(ctx, manifests)
+	t.logger.Info("Checked dependencies",
+		zap.String("path", path),
+		zap.Int("manifests", len(manifests)),
+		zap.Int("dependencies", len(all)),
+	)
+
+	var sb strings.Builder
+	if action == "check" {
+		for _, m := range manifests {
+			sb.WriteString(formatDepsCheck(m, t.rel(m.path)))
+		}
+		return &Result{Output: strings.TrimRight(sb.String(), "\n"), Success: true}, nil
+	}
+
+	var only map[string]bool
+	if raw, ok := args["packages"].([]interface{}); ok && len(raw) > 0 {
+		only = make(map[string]bool)
+		for _, p := range raw {
+			if s, ok := p.(string); ok {
+				only[s] = true
+			}
+		}
+	}
+	if missing := missingPackages(manifests, only); len(missing) > 0 {
+		sb.WriteString("not a dependency: " + strings.Join(missing, ", ") + "\n\n")
+	}
+	verify, _ := args["verify"].(string)
+	revert, _ := args["revert_on_failure"].(bool)
+
+	success := true
+	for _, m := range manifests {
+		out, ok := t.apply(ctx, m, level, only, verify, revert)
+		sb.WriteString(out)
+		success = success && ok
+	}
+	output := strings.TrimRight(sb.String(), "\n")
+	result := &Result{Output: output, Success: success}
+	if !success {
+		result.Error = output
+	}
+	return result, nil
+}
+
+func (t *UpdateDepsTool) workDir() string {
+	if t.sandbox != nil {
+		return t.sandbox.GetWorkDir()
+	}
+	return ""
+}
+
+func (t *UpdateDepsTool) resolve(path string) string {
+	if t.guard != nil {
+		return t.guard.Resolve(path)
+	}
+	return resolveReadPath(path, t.workDir())
+}
+
+func (t *UpdateDepsTool) rel(path string) string {
+	if wd := t.workDir(); wd != "" {
+		if r, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(r, "..") {
+			return r
+		}
+	}
+	return path
+}
+
+// ─── Manifests ───
+
+// manifestNames are the dependency files update_deps understands.
+var manifestNames = []string{"go.mod", "package.json", "requirements.txt"}
+
+// findManifests parses the manifest at path, or every manifest in the
+// directory path (falling back to the nearest parent that has one).
+func findManifests(path string, indirect bool) ([]*manifest, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	if !info.IsDir() {
+		files = []string{path}
+	} else {
+		for dir := path; len(files) == 0; {
+			for _, name := range manifestNames {
+				if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+					files = append(files, filepath.Join(dir, name))
+				}
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no go.mod, package.json or requirements.txt in %s", path)
+		}
+	}
+
+	var out []*manifest
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		m := &manifest{path: f}
+		switch name := filepath.Base(f); {
+		case name == "go.mod":
+			m.eco, m.deps = "go", parseGoMod(string(data), indirect)
+		case name == "package.json":
+			m.eco = "npm"
+			if m.deps, err = parsePackageJSON(data); err != nil {
+				return nil, fmt.Errorf("%s: %w", f, err)
+			}
+		case strings.HasSuffix(name, ".txt"):
+			m.eco, m.deps = "pip", parseRequirements(string(data))
+		default:
+			return nil, fmt.Errorf("%s: not a go.mod, package.json or requirements file", f)
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// parseGoMod returns the requirements of a go.mod, single-line and block form.
+func parseGoMod(data string, indirect bool) []*dependency {
+	var deps []*dependency
+	inBlock := false
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "require (":
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "require "))
+		case !inBlock:
+			continue
+		}
+		isIndirect := strings.Contains(line, "// indirect")
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 || (isIndirect && !indirect) {
+			continue
+		}
+		d := &dependency{Name: fields[0], Current: fields[1]}
+		if _, ok := parseDepVersion(d.Current); !ok {
+			d.Note = "pseudo-version or pre-release"
+		}
+		deps = append(deps, d)
+	}
+	return deps
+}
+
+// npmRangePrefixes are the range operators kept when rewriting a spec.
+var npmRangePrefixes = []string{"^", "~", ">=", "="}
+
+// parsePackageJSON returns dependencies and devDependencies. Ranges other
+// than a plain, ^ or ~ version (tags, git URLs, workspace:) are noted and skipped.
+func parsePackageJSON(data []byte) ([]*dependency, error) {
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+	var deps []*dependency
+	add := func(specs map[string]string, dev bool) {
+		for _, name := range sortedStringKeys(specs) {
+			spec := specs[name]
+			d := &dependency{Name: name, Spec: spec, Dev: dev}
+			v := spec
+			for _, p := range npmRangePrefixes {
+				v = strings.TrimPrefix(v, p)
+			}
+			if _, ok := parseDepVersion(v); ok {
+				d.Current = v
+			} else {
+				d.Current, d.Note = spec, "not a plain version range"
+			}
+			deps = append(deps, d)
+		}
+	}
+	add(pkg.Dependencies, false)
+	add(pkg.DevDependencies, true)
+	return deps, nil
+}
+
+// requirementRe matches "name[extras]==version" at the start of a requirements line.
+var requirementRe = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)(\[[^\]]*\])?\s*(==|>=|~=|<=|>|<|!=)?\s*([^\s;#,]*)`)
+
+// parseRequirements returns the packages of a requirements file. Only exact
+// pins (==) can be updated; the rest are listed with a note.
+func parseRequirements(data string) []*dependency {
+	var deps []*dependency
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		m := requirementRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		d := &dependency{Name: m[1], Current: m[4]}
+		switch {
+		case m[3] != "==":
+			d.Note = "not pinned with =="
+		case strings.Contains(line, ","):
+			d.Note = "version range"
+		default:
+			if _, ok := parseDepVersion(d.Current); !ok {
+				d.Note = "pre-release or non-numeric version"
+			}
+		}
+		deps = append(deps, d)
+	}
+	return deps
+}
+
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ─── Registry Lookups ───
+
+// lookupAll queries the registries for every updatable dependency.
+func (t *UpdateDepsTool) lookupAll(ctx context.Context, manifests []*manifest) {
+	type job struct {
+		eco string
+		dep *dependency
+	}
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+	for i := 0; i < depsLookupWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				if err := t.lookup(ctx, j.eco, j.dep); err != nil {
+					j.dep.Err = err.Error()
+				}
+			}
+		}()
+	}
+	for _, m := range manifests {
+		for _, d := range m.deps {
+			if d.Note == "" {
+				jobs <- job{m.eco, d}
+			}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// lookup fills d.Candidates with the newest release of each bump class.
+func (t *UpdateDepsTool) lookup(ctx context.Context, eco string, d *dependency) error {
+	cur, _ := parseDepVersion(d.Current)
+	var versions []string
+	var err error
+	switch eco {
+	case "go":
+		if versions, err = t.goVersions(ctx, d.Name); err != nil {
+			return err
+		}
+		if len(versions) == 0 {
+			return fmt.Errorf("not in the module proxy (private module? set agent.tools.deps.goproxy)")
+		}
+	case "npm":
+		if versions, err = t.npmVersions(ctx, d.Name); err != nil {
+			return err
+		}
+	default:
+		if versions, err = t.pypiVersions(ctx, d.Name); err != nil {
+			return err
+		}
+	}
+	d.Candidates = newerReleases(cur, versions, "")
+
+	// Go major versions from v2 on live at a different module path
+	if eco == "go" {
+		base, major := splitGoMajor(d.Name)
+		if base == "" || cur.major == 0 {
+			return nil
+		}
+		for next := major + 1; next <= major+goMaxMajorProbe; next++ {
+			module := fmt.Sprintf("%s/v%d", base, next)
+			versions, err := t.goVersions(ctx, module)
+			if err != nil || len(versions) == 0 {
+				break
+			}
+			if c := newerReleases(cur, versions, module)[bumpMajor]; c != nil {
+				d.Candidates[bumpMajor] = c
+			}
+		}
+	}
+	return nil
+}
+
+// newerReleases picks the newest release above cur for each bump class.
+func newerReleases(cur depVersion, versions []string, module string) map[string]*depCandidate {
+	best := make(map[string]*depCandidate)
+	bestV := make(map[string]depVersion)
+	for _, raw := range versions {
+		v, ok := parseDepVersion(raw)
+		if !ok || !cur.less(v) {
+			continue
+		}
+		bump := classifyBump(cur, v)
+		if old, ok := bestV[bump]; !ok || old.less(v) {
+			bestV[bump] = v
+			best[bump] = &depCandidate{Version: raw, Module: module, Bump: bump}
+		}
+	}
+	return best
+}
+
+// splitGoMajor splits "example.com/m/v3" into ("example.com/m", 3); paths
+// without a suffix are major 1. gopkg.in paths are not probed.
+func splitGoMajor(path string) (string, int) {
+	if strings.HasPrefix(path, "gopkg.in/") {
+		return "", 0
+	}
+	if i := strings.LastIndex(path, "/v"); i > 0 {
+		if n, err := strconv.Atoi(path[i+2:]); err == nil && n >= 2 {
+			return path[:i], n
+		}
+	}
+	return path, 1
+}
+
+// escapeModulePath applies the module proxy's case encoding (Upper → !upper).
+func escapeModulePath(path string) string {
+	var sb strings.Builder
+	for _, r := range path {
+		if r >= 'A' && r <= 'Z' {
+			sb.WriteByte('!')
+			r += 'a' - 'A'
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func (t *UpdateDepsTool) goVersions(ctx context.Context, module string) ([]string, error) {
+	body, status, err := t.get(ctx, t.cfg.GoProxy+"/"+escapeModulePath(module)+"/@v/list", "")
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNotFound || status == http.StatusGone {
+		return nil, nil
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("module proxy: HTTP %d", status)
+	}
+	return strings.Fields(string(body)), nil
+}
+
+func (t *UpdateDepsTool) npmVersions(ctx context.Context, name string) ([]string, error) {
+	body, status, err := t.get(ctx, t.cfg.NPMRegistry+"/"+url.PathEscape(name),
+		"application/vnd.npm.install-v1+json")
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("npm registry: HTTP %d", status)
+	}
+	var doc struct {
+		Versions map[string]json.RawMessage `json:"versions"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("npm registry: %w", err)
+	}
+	versions := make([]string, 0, len(doc.Versions))
+	for v := range doc.Versions {
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+func (t *UpdateDepsTool) pypiVersions(ctx context.Context, name string) ([]string, error) {
+	body, status, err := t.get(ctx, t.cfg.PyPI+"/pypi/"+url.PathEscape(name)+"/json", "")
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("PyPI: HTTP %d", status)
+	}
+	var doc struct {
+		Releases map[string][]json.RawMessage `json:"releases"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("PyPI: %w", err)
+	}
+	var versions []string
+	for v, files := range doc.Releases {
+		if len(files) > 0 { // releases without files were deleted
+			versions = append(versions, v)
+		}
+	}
+	return versions, nil
+}
+
+func (t *UpdateDepsTool) get(ctx context.Context, rawURL, accept string) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	return body, resp.StatusCode, err
+}
+
+// ─── Apply ───
+
+// depBump is one planned update.
+type depBump struct {
+	dep *dependency
+	to  *depCandidate
+}
+
+// apply bumps, installs and verifies one manifest. Returns the report and
+// whether everything succeeded.
+func (t *UpdateDepsTool) apply(ctx context.Context, m *manifest, level string, only map[string]bool, verify string, revert bool) (string, bool) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s (%s)\n", t.rel(m.path), m.eco)
+
+	var bumps []depBump
+	var skipped []string
+	for _, d := range m.deps {
+		if only != nil && !only[d.Name] {
+			continue
+		}
+		c := d.best(level)
+		if c != nil && c.Module != "" {
+			skipped = append(skipped, fmt.Sprintf("%s: %s %s is a new module path, update the imports by hand", d.Name, c.Module, c.Version))
+			c = d.best(bumpMinor)
+		}
+		switch {
+		case c != nil:
+			bumps = append(bumps, depBump{d, c})
+		case only != nil:
+			skipped = append(skipped, fmt.Sprintf("%s: %s", d.Name, skipReason(d, level)))
+		case len(d.Candidates) > 0 && d.Note == "":
+			skipped = append(skipped, fmt.Sprintf("%s: %s", d.Name, skipReason(d, level)))
+		}
+	}
+	if len(bumps) == 0 {
+		sb.WriteString(fmt.Sprintf("  nothing to update up to %s\n", level))
+		for _, s := range skipped {
+			sb.WriteString("  skipped " + s + "\n")
+		}
+		return sb.String() + "\n", true
+	}
+
+	snapshot := snapshotFiles(m)
+	var install string
+	var err error
+	switch m.eco {
+	case "go":
+		specs := make([]string, len(bumps))
+		for i, b := range bumps {
+			specs[i] = b.dep.Name + "@" + b.to.Version
+		}
+		install = "go get " + strings.Join(specs, " ") + " && go mod tidy"
+	case "npm":
+		err = rewriteFile(m.path, func(s string) string { return bumpPackageJSON(s, bumps) })
+		install = "npm install --no-audit --no-fund"
+	default:
+		err = rewriteFile(m.path, func(s string) string { return bumpRequirements(s, bumps) })
+		install = "python3 -m pip install -q -r " + shellQuote(filepath.Base(m.path))
+	}
+	if err != nil {
+		return sb.String() + "  " + err.Error() + "\n\n", false
+	}
+
+	sb.WriteString(fmt.Sprintf("  bumped %d (up to %s):\n", len(bumps), level))
+	for _, b := range bumps {
+		sb.WriteString(fmt.Sprintf("    %s %s → %s (%s)\n", b.dep.Name, b.dep.Current, b.to.Version, b.to.Bump))
+	}
+	for _, s := range skipped {
+		sb.WriteString("  skipped " + s + "\n")
+	}
+
+	ok := t.step(ctx, &sb, m.dir(), install)
+	if ok && verify != "none" {
+		if verify == "" {
+			verify = defaultVerifyCommand(m)
+		}
+		if verify != "" {
+			ok = t.step(ctx, &sb, m.dir(), verify)
+		}
+	}
+	if t.guard != nil {
+		t.guard.RecordEdit(ctx, snapshot.paths()...)
+	}
+	t.logger.Info("Applied dependency updates",
+		zap.String("manifest", m.path),
+		zap.Int("bumps", len(bumps)),
+		zap.Bool("ok", ok),
+	)
+	switch {
+	case ok:
+		sb.WriteString("  ✓ updated and verified\n")
+	case revert:
+		if err := snapshot.restore(); err != nil {
+			sb.WriteString("  ✗ failed, restoring the files also failed: " + err.Error() + "\n")
+		} else {
+			sb.WriteString("  ✗ failed, restored " + strings.Join(snapshot.names(), ", ") + "\n")
+		}
+	default:
+		sb.WriteString("  ✗ failed: the bumps are still applied. Fix the breakage, narrow `packages` or lower `level`, " +
+			"or rerun with revert_on_failure\n")
+	}
+	return sb.String() + "\n", ok
+}
+
+// step runs one shell command in dir and appends its outcome to sb.
+func (t *UpdateDepsTool) step(ctx context.Context, sb *strings.Builder, dir, command string) bool {
+	res, err := t.sandbox.ExecuteWith(ctx, sandbox.ExecOptions{Timeout: t.cfg.Timeout}, "bash",
+		[]string{"-c", "cd " + shellQuote(dir) + " && " + command + " 2>&1"})
+	if err != nil {
+		sb.WriteString(fmt.Sprintf("  $ %s ✗\n    %v\n", command, err))
+		return false
+	}
+	if res.ExitCode == 0 {
+		sb.WriteString(fmt.Sprintf("  $ %s ✓\n", command))
+		return true
+	}
+	output := tailString(strings.TrimSpace(res.Stdout+res.Stderr), depsOutputTail)
+	sb.WriteString(fmt.Sprintf("  $ %s ✗ (exit %d)\n%s\n", command, res.ExitCode, indentLines(output, "    ")))
+	return false
+}
+
+// defaultVerifyCommand builds and tests the project; "" = nothing to run.
+func defaultVerifyCommand(m *manifest) string {
+	switch m.eco {
+	case "go":
+		return "go build ./... && go test ./..."
+	case "npm":
+		data, _ := os.ReadFile(m.path)
+		var pkg struct {
+			Scripts map[string]string `json:"scripts"`
+		}
+		if json.Unmarshal(data, &pkg) == nil && pkg.Scripts["test"] != "" {
+			return "npm test"
+		}
+		return ""
+	default:
+		return "python3 -m pytest -q"
+	}
+}
+
+func skipReason(d *dependency, level string) string {
+	switch {
+	case d.Note != "":
+		return d.Note
+	case d.Err != "":
+		return "lookup failed: " + d.Err
+	case len(d.Candidates) > 0:
+		return "only larger updates than " + level
+	}
+	return "up to date"
+}
+
+// missingPackages returns the requested packages no manifest depends on.
+func missingPackages(manifests []*manifest, only map[string]bool) []string {
+	var missing []string
+	for name := range only {
+		found := false
+		for _, m := range manifests {
+			for _, d := range m.deps {
+				found = found || d.Name == name
+			}
+		}
+		if !found {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// bumpPackageJSON rewrites the version specs of the bumped packages, keeping
+// their range operator and the file's formatting.
+func bumpPackageJSON(data string, bumps []depBump) string {
+	for _, b := range bumps {
+		prefix := strings.TrimSuffix(b.dep.Spec, b.dep.Current)
+		re := regexp.MustCompile(`("` + regexp.QuoteMeta(b.dep.Name) + `"\s*:\s*")` + regexp.QuoteMeta(b.dep.Spec) + `"`)
+		data = re.ReplaceAllString(data, "${1}"+prefix+strings.TrimPrefix(b.to.Version, "v")+`"`)
+	}
+	return data
+}
+
+// bumpRequirements rewrites the pins of the bumped packages, keeping extras,
+// markers and comments.
+func bumpRequirements(data string, bumps []depBump) string {
+	to := make(map[string]string)
+	for _, b := range bumps {
+		to[normalizePyName(b.dep.Name)] = b.to.Version
+	}
+	lines := strings.Split(data, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		m := requirementRe.FindStringSubmatchIndex(trimmed)
+		if m == nil || m[6] < 0 || trimmed[m[6]:m[7]] != "==" {
+			continue
+		}
+		if v, ok := to[normalizePyName(trimmed[m[2]:m[3]])]; ok {
+			indent := line[:len(line)-len(trimmed)]
+			lines[i] = indent + trimmed[:m[8]] + v + trimmed[m[9]:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// normalizePyName compares package names the way pip does (PEP 503).
+func normalizePyName(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "-", ".", "-").Replace(name))
+}
+
+func rewriteFile(path string, edit func(string) string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(edit(string(data))), info.Mode().Perm())
+}
+
+func indentLines(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
+
+// fileSnapshot holds the manifest and lock files of a project before an
+// update, so a failed update can be rolled back.
+type fileSnapshot map[string][]byte // path → content, nil = did not exist
+
+// lockFiles are the files besides the manifest that an install rewrites.
+var lockFiles = map[string][]string{
+	"go":  {"go.sum"},
+	"npm": {"package-lock.json"},
+}
+
+func snapshotFiles(m *manifest) fileSnapshot {
+	s := fileSnapshot{}
+	paths := []string{m.path}
+	for _, name := range lockFiles[m.eco] {
+		paths = append(paths, filepath.Join(m.dir(), name))
+	}
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			data = nil
+		}
+		s[p] = data
+	}
+	return s
+}
+
+func (s fileSnapshot) paths() []string {
+	paths := make([]string, 0, len(s))
+	for p := range s {
+		if _, err := os.Stat(p); err == nil {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (s fileSnapshot) names() []string {
+	var names []string
+	for p, data := range s {
+		if data != nil {
+			names = append(names, filepath.Base(p))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s fileSnapshot) restore() error {
+	for p, data := range s {
+		var err error
+		if data == nil {
+			err = os.Remove(p)
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		} else {
+			err = os.WriteFile(p, data, 0o644)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatDepsCheck lists the outdated dependencies of a manifest.
+func formatDepsCheck(m *manifest, name string) string {
+	var sb strings.Builder
+	var outdated, current, skipped, failed []*dependency
+	for _, d := range m.deps {
+		switch {
+		case d.Note != "":
+			skipped = append(skipped, d)
+		case d.Err != "":
+			failed = append(failed, d)
+		case len(d.Candidates) > 0:
+			outdated = append(outdated, d)
+		default:
+			current = append(current, d)
+		}
+	}
+	fmt.Fprintf(&sb, "%s (%s): %d dependencies, %d outdated, %d up to date\n",
+		name, m.eco, len(m.deps), len(outdated), len(current))
+	for _, d := range outdated {
+		var parts []string
+		for _, bump := range []string{bumpPatch, bumpMinor, bumpMajor} {
+			if c := d.Candidates[bump]; c != nil {
+				part := bump + " " + c.Version
+				if c.Module != "" {
+					part += " (" + c.Module + ")"
+				}
+				parts = append(parts, part)
+			}
+		}
+		dev := ""
+		if d.Dev {
+			dev = " [dev]"
+		}
+		fmt.Fprintf(&sb, "  %s%s %s → %s\n", d.Name, dev, d.Current, strings.Join(parts, " · "))
+	}
+	for _, d := range failed {
+		fmt.Fprintf(&sb, "  %s %s: lookup failed: %s\n", d.Name, d.Current, d.Err)
+	}
+	for _, d := range skipped {
+		fmt.Fprintf(&sb, "  %s %s: skipped (%s)\n", d.Name, d.Current, d.Note)
+	}
+	return sb.String() + "\n"
+}
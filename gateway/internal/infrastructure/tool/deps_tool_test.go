@@ -0,0 +1,156 @@
+package tool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestClassifyBump(t *testing.T) {
+	tests := []struct {
+		cur, next, want string
+	}{
+		{"v1.2.3", "v1.2.9", bumpPatch},
+		{"v1.2.3", "v1.4.0", bumpMinor},
+		{"v1.2.3", "v2.0.0+incompatible", bumpMajor},
+		{"0.3.1", "0.3.2", bumpPatch},
+		{"0.3.1", "0.4.0", bumpMajor},
+		{"2.31", "2.32.3", bumpMinor},
+	}
+	for _, tt := range tests {
+		cur, ok1 := parseDepVersion(tt.cur)
+		next, ok2 := parseDepVersion(tt.next)
+		if !ok1 || !ok2 {
+			t.Fatalf("parse %s / %s failed", tt.cur, tt.next)
+		}
+		if got := classifyBump(cur, next); got != tt.want {
+			t.Errorf("%s → %s = %s, want %s", tt.cur, tt.next, got, tt.want)
+		}
+	}
+	for _, s := range []string{"v0.0.0-20240222234643-814bf88cf225", "1.0.0-rc.1", "2.0rc1", "1.2.3.4", ""} {
+		if _, ok := parseDepVersion(s); ok {
+			t.Errorf("parseDepVersion(%q) should fail", s)
+		}
+	}
+}
+
+func TestParseManifests(t *testing.T) {
+	gomod := "module example.com/app\n\ngo 1.22\n\nrequire github.com/single/dep v1.0.0\n\nrequire (\n" +
+		"\tgithub.com/a/b v1.2.3\n\tgithub.com/c/d/v2 v2.1.0 // indirect\n\tgolang.org/x/exp v0.0.0-20240222234643-814bf88cf225\n)\n"
+	deps := parseGoMod(gomod, false)
+	if len(deps) != 3 || deps[0].Name != "github.com/single/dep" || deps[1].Current != "v1.2.3" || deps[2].Note == "" {
+		t.Errorf("go.mod deps = %+v", deps)
+	}
+	if deps := parseGoMod(gomod, true); len(deps) != 4 {
+		t.Errorf("with indirect = %d deps, want 4", len(deps))
+	}
+
+	deps, err := parsePackageJSON([]byte(`{"dependencies":{"react":"^18.2.0","left":"github:x/left"},"devDependencies":{"vitest":"~1.1.0"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deps) != 3 || deps[1].Current != "18.2.0" || deps[0].Note == "" || !deps[2].Dev || deps[2].Current != "1.1.0" {
+		t.Errorf("package.json deps = %+v", deps)
+	}
+
+	deps = parseRequirements("# pinned\nrequests==2.31.0\nuvicorn[standard]==0.23.2 ; python_version>'3.8'\nflask>=2.0\n-r other.txt\nnumpy==1.26.0rc1\n")
+	if len(deps) != 4 || deps[1].Name != "uvicorn" || deps[1].Current != "0.23.2" || deps[0].Note != "" ||
+		deps[2].Note == "" || deps[3].Note == "" {
+		t.Errorf("requirements deps = %+v", deps)
+	}
+}
+
+func TestBumpManifests(t *testing.T) {
+	pkg := "{\n  \"dependencies\": {\n    \"react\": \"^18.2.0\",\n    \"react-dom\": \"^18.2.0\"\n  }\n}\n"
+	got := bumpPackageJSON(pkg, []depBump{{
+		dep: &dependency{Name: "react", Spec: "^18.2.0", Current: "18.2.0"},
+		to:  &depCandidate{Version: "18.3.1"},
+	}})
+	if !strings.Contains(got, `"react": "^18.3.1"`) || !strings.Contains(got, `"react-dom": "^18.2.0"`) {
+		t.Errorf("package.json = %s", got)
+	}
+
+	reqs := "Requests==2.31.0  # http\n  uvicorn[standard]==0.23.2 ; python_version>'3.8'\nflask==2.0.0\n"
+	got = bumpRequirements(reqs, []depBump{
+		{dep: &dependency{Name: "requests"}, to: &depCandidate{Version: "2.32.3"}},
+		{dep: &dependency{Name: "uvicorn"}, to: &depCandidate{Version: "0.30.1"}},
+	})
+	want := "Requests==2.32.3  # http\n  uvicorn[standard]==0.30.1 ; python_version>'3.8'\nflask==2.0.0\n"
+	if got != want {
+		t.Errorf("requirements =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFileSnapshotRestore(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"go.mod": "module m\n"})
+	m := &manifest{eco: "go", path: filepath.Join(dir, "go.mod")}
+	snap := snapshotFiles(m)
+
+	os.WriteFile(m.path, []byte("module m\n\nrequire x v1.0.0\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "go.sum"), []byte("x v1.0.0 h1:..."), 0o644)
+	if err := snap.restore(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(m.path); string(data) != "module m\n" {
+		t.Errorf("go.mod = %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go.sum")); !os.IsNotExist(err) {
+		t.Error("go.sum created by the update should be removed")
+	}
+}
+
+func TestUpdateDepsCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/github.com/!burnt!sushi/toml/@v/list":
+			w.Write([]byte("v1.2.0\nv1.2.1\nv1.3.2\nv1.4.0-rc.1\n"))
+		case "/github.com/!burnt!sushi/toml/v2/@v/list":
+			w.Write([]byte("v2.0.0\nv2.1.0\n"))
+		case "/react":
+			if r.Header.Get("Accept") != "application/vnd.npm.install-v1+json" {
+				t.Errorf("npm Accept = %q", r.Header.Get("Accept"))
+			}
+			w.Write([]byte(`{"versions":{"18.2.0":{},"18.3.1":{},"19.0.0":{},"19.1.0-canary":{}}}`))
+		case "/pypi/requests/json":
+			w.Write([]byte(`{"releases":{"2.31.0":[{}],"2.31.1":[{}],"2.32.3":[{}],"2.33.0":[]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"go.mod":           "module example.com/app\n\nrequire (\n\tgithub.com/BurntSushi/toml v1.2.0\n\tgithub.com/gone/away v1.0.0\n)\n",
+		"package.json":     `{"dependencies":{"react":"^18.2.0"}}`,
+		"requirements.txt": "requests==2.31.0\n",
+	})
+	tool := NewUpdateDepsTool(nil, nil, DepsConfig{GoProxy: srv.URL, NPMRegistry: srv.URL, PyPI: srv.URL}, zap.NewNop())
+
+	res, err := tool.Execute(context.Background(), map[string]interface{}{"path": dir})
+	if err != nil || !res.Success {
+		t.Fatalf("Execute = %+v, %v", res, err)
+	}
+	for _, want := range []string{
+		"github.com/BurntSushi/toml v1.2.0 → patch v1.2.1 · minor v1.3.2 · major v2.1.0 (github.com/BurntSushi/toml/v2)",
+		"github.com/gone/away v1.0.0: lookup failed: not in the module proxy",
+		"react 18.2.0 → minor 18.3.1 · major 19.0.0",
+		"requests 2.31.0 → patch 2.31.1 · minor 2.32.3",
+	} {
+		if !strings.Contains(res.Output, want) {
+			t.Errorf("missing %q in:\n%s", want, res.Output)
+		}
+	}
+
+	res, _ = tool.Execute(context.Background(), map[string]interface{}{"path": dir, "action": "apply"})
+	if res.Success || !strings.Contains(res.Error, "requires the sandbox") {
+		t.Errorf("apply without sandbox = %+v", res)
+	}
+}
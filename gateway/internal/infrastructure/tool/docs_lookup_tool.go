@@ -0,0 +1,299 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultDocsAPIURL    = "https://context7.com/api/v1"
+	defaultDocsMaxTokens = 4000
+	maxDocsTokens        = 10000
+	minDocsTokens        = 500
+)
+
+// docsLibraryIDRe matches Context7-style library IDs: /org/project[/version].
+var docsLibraryIDRe = regexp.MustCompile(`/[A-Za-z0-9_.\-]+/[A-Za-z0-9_.\-]+(?:/[A-Za-z0-9_.\-]+)?`)
+
+// DocsLookupConfig selects the documentation backend for docs_lookup.
+type DocsLookupConfig struct {
+	MCPEndpoint string // non-empty = query an MCP docs server instead of the HTTP API
+	APIURL      string // Context7-compatible HTTP API (default https://context7.com/api/v1)
+	APIKey      string // optional bearer token
+	MaxTokens   int    // default snippet budget per call
+}
+
+// docsLibrary is one library candidate returned by the resolver.
+type docsLibrary struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// DocsLookupTool resolves a library name + topic to current documentation
+// snippets, so the model can check fast-moving APIs instead of guessing:
+//  1. resolve — library name → library ID (/org/project), cached per process
+//  2. fetch — ID (+ optional version) + topic → version-accurate snippets
+//
+// Either backend works: an MCP docs server exposing resolve-library-id /
+// get-library-docs, or the Context7 HTTP API directly.
+type DocsLookupTool struct {
+	cfg    DocsLookupConfig
+	mcp    *MCPAdapter
+	client *http.Client
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	resolved map[string]string // lower(library) → library ID
+}
+
+// NewDocsLookupTool creates the docs_lookup tool.
+func NewDocsLookupTool(cfg DocsLookupConfig, logger *zap.Logger) *DocsLookupTool {
+	if cfg.APIURL == "" {
+		cfg.APIURL = defaultDocsAPIURL
+	}
+	cfg.APIURL = strings.TrimRight(cfg.APIURL, "/")
+	if cfg.MaxTokens <= 0 {
+		cfg.MaxTokens = defaultDocsMaxTokens
+	}
+	t := &DocsLookupTool{
+		cfg:      cfg,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		logger:   logger,
+		resolved: make(map[string]string),
+	}
+	if cfg.MCPEndpoint != "" {
+		t.mcp = NewMCPAdapter("docs", cfg.MCPEndpoint, logger)
+	}
+	return t
+}
+
+func (t *DocsLookupTool) Name() string          { return "docs_lookup" }
+func (t *DocsLookupTool) Kind() domaintool.Kind { return domaintool.KindSearch }
+
+func (t *DocsLookupTool) Description() string {
+	return "Look up current, version-accurate documentation for a library or framework. " +
+		"Give the library name (e.g. 'next.js', 'langchain') and a topic (e.g. 'app router caching'); " +
+		"returns code snippets and API excerpts from the official docs. " +
+		"Use BEFORE writing code against a fast-moving or unfamiliar API instead of relying on memory."
+}
+
+func (t *DocsLookupTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"library": map[string]interface{}{
+				"type":        "string",
+				"description": "Library name (e.g. 'react', 'fastapi') or an exact library ID like '/vercel/next.js'",
+			},
+			"topic": map[string]interface{}{
+				"type":        "string",
+				"description": "What to look up, e.g. 'middleware', 'streaming responses'",
+			},
+			"version": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional version, e.g. 'v14.3.0' (default: latest)",
+			},
+			"tokens": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("Max documentation tokens to return (default %d, max %d)", t.cfg.MaxTokens, maxDocsTokens),
+			},
+		},
+		"required": []string{"library"},
+	}
+}
+
+func (t *DocsLookupTool) Execute(ctx context.Context, args map[string]interface{}) (*domaintool.Result, error) {
+	library, _ := args["library"].(string)
+	library = strings.TrimSpace(library)
+	if library == "" {
+		return &domaintool.Result{Success: false, Error: "library is required"}, nil
+	}
+	topic, _ := args["topic"].(string)
+	topic = strings.TrimSpace(topic)
+	version, _ := args["version"].(string)
+	version = strings.TrimSpace(version)
+
+	tokens := t.cfg.MaxTokens
+	if n, ok := args["tokens"].(float64); ok && n > 0 {
+		tokens = int(n)
+	}
+	if tokens > maxDocsTokens {
+		tokens = maxDocsTokens
+	}
+	if tokens < minDocsTokens {
+		tokens = minDocsTokens
+	}
+
+	id, err := t.resolve(ctx, library)
+	if err != nil {
+		return &domaintool.Result{Success: false, Error: err.Error()}, nil
+	}
+	if version != "" && strings.Count(id, "/") == 2 {
+		id += "/" + version
+	}
+
+	t.logger.Info("Docs lookup",
+		zap.String("library", library),
+		zap.String("id", id),
+		zap.String("topic", topic),
+		zap.Int("tokens", tokens),
+	)
+
+	docs, err := t.fetch(ctx, id, topic, tokens)
+	if err != nil {
+		return &domaintool.Result{Success: false, Error: err.Error()}, nil
+	}
+	docs = strings.TrimSpace(docs)
+	if docs == "" {
+		return &domaintool.Result{
+			Success: false,
+			Error:   fmt.Sprintf("no documentation found for %s (topic %q)", id, topic),
+		}, nil
+	}
+
+	return &domaintool.Result{
+		Output:  fmt.Sprintf("Documentation for %s:\n\n%s", id, docs),
+		Success: true,
+		Metadata: map[string]interface{}{
+			"library_id": id,
+			"topic":      topic,
+		},
+	}, nil
+}
+
+// resolve maps a library name to its library ID. Names that already look
+// like an ID are used as-is.
+func (t *DocsLookupTool) resolve(ctx context.Context, library string) (string, error) {
+	if strings.HasPrefix(library, "/") {
+		return library, nil
+	}
+	key := strings.ToLower(library)
+
+	t.mu.Lock()
+	id, ok := t.resolved[key]
+	t.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	if t.mcp != nil {
+		out, err := t.mcp.CallTool(ctx, "resolve-library-id", map[string]interface{}{"libraryName": library})
+		if err != nil {
+			return "", err
+		}
+		id = docsLibraryIDRe.FindString(out)
+	} else {
+		libs, err := t.search(ctx, library)
+		if err != nil {
+			return "", err
+		}
+		if best := pickDocsLibrary(libs, library); best != nil {
+			id = best.ID
+		}
+	}
+	if id == "" {
+		return "", fmt.Errorf("no documentation source found for library %q", library)
+	}
+
+	t.mu.Lock()
+	t.resolved[key] = id
+	t.mu.Unlock()
+	return id, nil
+}
+
+// fetch returns documentation snippets for a resolved library ID.
+func (t *DocsLookupTool) fetch(ctx context.Context, id, topic string, tokens int) (string, error) {
+	if t.mcp != nil {
+		params := map[string]interface{}{
+			"context7CompatibleLibraryID": id,
+			"tokens":                      tokens,
+		}
+		if topic != "" {
+			params["topic"] = topic
+		}
+		return t.mcp.CallTool(ctx, "get-library-docs", params)
+	}
+
+	q := url.Values{}
+	q.Set("type", "txt")
+	q.Set("tokens", strconv.Itoa(tokens))
+	if topic != "" {
+		q.Set("topic", topic)
+	}
+	body, err := t.get(ctx, t.cfg.APIURL+id+"?"+q.Encode())
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// search queries the HTTP API for libraries matching name.
+func (t *DocsLookupTool) search(ctx context.Context, name string) ([]docsLibrary, error) {
+	body, err := t.get(ctx, t.cfg.APIURL+"/search?query="+url.QueryEscape(name))
+	if err != nil || body == nil {
+		return nil, err
+	}
+	var result struct {
+		Results []docsLibrary `json:"results"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse docs search response: %w", err)
+	}
+	return result.Results, nil
+}
+
+func (t *DocsLookupTool) get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if t.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.cfg.APIKey)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("docs request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read docs response: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, nil
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, fmt.Errorf("docs API rate limited, set agent.tools.docs.api_key or retry later")
+	case resp.StatusCode >= 300:
+		return nil, fmt.Errorf("docs API returned HTTP %d: %s", resp.StatusCode, truncateStr(string(body), 200))
+	}
+	return body, nil
+}
+
+// pickDocsLibrary prefers an exact title or project-name match, otherwise
+// the first (highest ranked) result.
+func pickDocsLibrary(libs []docsLibrary, name string) *docsLibrary {
+	if len(libs) == 0 {
+		return nil
+	}
+	for i := range libs {
+		project := libs[i].ID[strings.LastIndex(libs[i].ID, "/")+1:]
+		if strings.EqualFold(libs[i].Title, name) || strings.EqualFold(project, name) {
+			return &libs[i]
+		}
+	}
+	return &libs[0]
+}
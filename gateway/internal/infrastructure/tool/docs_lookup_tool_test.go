@@ -0,0 +1,113 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestDocsLookupHTTP(t *testing.T) {
+	searches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search":
+			searches++
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"results": []map[string]string{
+					{"id": "/someone/next.js-examples", "title": "Next.js Examples"},
+					{"id": "/vercel/next.js", "title": "Next.js"},
+				},
+			})
+		case "/vercel/next.js/v14.3.0":
+			if r.URL.Query().Get("topic") != "caching" || r.URL.Query().Get("tokens") != "4000" {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			w.Write([]byte("TITLE: revalidatePath\nCODE: revalidatePath('/blog')"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tool := NewDocsLookupTool(DocsLookupConfig{APIURL: srv.URL}, zap.NewNop())
+	res, err := tool.Execute(context.Background(), map[string]interface{}{
+		"library": "next.js", "topic": "caching", "version": "v14.3.0",
+	})
+	if err != nil || !res.Success {
+		t.Fatalf("lookup failed: %v %+v", err, res)
+	}
+	if !strings.Contains(res.Output, "revalidatePath") || res.Metadata["library_id"] != "/vercel/next.js/v14.3.0" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	// Second lookup reuses the resolved ID; unknown topic docs come back empty.
+	res, _ = tool.Execute(context.Background(), map[string]interface{}{"library": "Next.js", "topic": "x"})
+	if searches != 1 {
+		t.Errorf("expected resolved ID to be cached, got %d searches", searches)
+	}
+	if res.Success || !strings.Contains(res.Error, "no documentation found") {
+		t.Errorf("expected not-found error, got %+v", res)
+	}
+}
+
+func TestDocsLookupMCP(t *testing.T) {
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     int    `json:"id"`
+			Method string `json:"method"`
+			Params struct {
+				Name      string                 `json:"name"`
+				Arguments map[string]interface{} `json:"arguments"`
+			} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Method != "tools/call" {
+			// initialize 握手与通知
+			json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": map[string]interface{}{}})
+			return
+		}
+		calls = append(calls, req.Params.Name)
+
+		text := "- Title: FastAPI\n- Context7-compatible library ID: /tiangolo/fastapi\n"
+		if req.Params.Name == "get-library-docs" {
+			if req.Params.Arguments["context7CompatibleLibraryID"] != "/tiangolo/fastapi" {
+				t.Errorf("unexpected args %v", req.Params.Arguments)
+			}
+			text = "app = FastAPI(lifespan=lifespan)"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result":  map[string]interface{}{"content": []map[string]string{{"type": "text", "text": text}}},
+		})
+	}))
+	defer srv.Close()
+
+	tool := NewDocsLookupTool(DocsLookupConfig{MCPEndpoint: srv.URL}, zap.NewNop())
+	res, err := tool.Execute(context.Background(), map[string]interface{}{"library": "fastapi", "topic": "lifespan"})
+	if err != nil || !res.Success || !strings.Contains(res.Output, "lifespan=lifespan") {
+		t.Fatalf("lookup failed: %v %+v", err, res)
+	}
+	if strings.Join(calls, ",") != "resolve-library-id,get-library-docs" {
+		t.Errorf("unexpected MCP calls %v", calls)
+	}
+}
+
+func TestPickDocsLibrary(t *testing.T) {
+	libs := []docsLibrary{{ID: "/a/react-native", Title: "React Native"}, {ID: "/facebook/react", Title: "React.dev"}}
+	if got := pickDocsLibrary(libs, "react"); got.ID != "/facebook/react" {
+		t.Errorf("expected project-name match, got %s", got.ID)
+	}
+	if got := pickDocsLibrary(libs, "reakt"); got.ID != "/a/react-native" {
+		t.Errorf("expected first result fallback, got %s", got.ID)
+	}
+	if pickDocsLibrary(nil, "x") != nil {
+		t.Error("expected nil for no results")
+	}
+}
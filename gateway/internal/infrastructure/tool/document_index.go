@@ -0,0 +1,503 @@
+package tool
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultDocumentMaxBytes   = 20 << 20
+	defaultDocumentChunkChars = 1500
+	defaultDocumentTTL        = 24 * time.Hour
+	defaultDocumentsPerChat   = 5
+	documentChunkOverlap      = 200
+	maxDocumentChunks         = 5000
+	pdftotextTimeout          = 2 * time.Minute
+)
+
+// ErrUnsupportedDocument is returned by Ingest for files that are not PDF,
+// DOCX or text; callers leave such attachments alone.
+var ErrUnsupportedDocument = errors.New("unsupported document type")
+
+// DocumentIndexConfig configures the per-chat document index.
+type DocumentIndexConfig struct {
+	MaxBytes   int           // largest accepted file (default 20 MB, the Bot API download limit)
+	ChunkChars int           // target passage size in characters (default 1500)
+	TTL        time.Duration // documents unused this long are dropped (default 24h)
+	MaxPerChat int           // documents kept per chat, oldest dropped first (default 5)
+}
+
+// DocumentInfo describes an indexed document.
+type DocumentInfo struct {
+	Name   string
+	Pages  int // 0 = no page information (DOCX, text)
+	Chunks int
+	Tokens int // rough estimate of the full text
+	Intro  string
+}
+
+// DocumentHit is one passage returned by Search.
+type DocumentHit struct {
+	Document string
+	Page     int
+	Chunk    int
+	Chunks   int
+	Text     string
+	Score    float64
+}
+
+type documentChunk struct {
+	page   int
+	text   string
+	terms  map[string]int
+	length int
+}
+
+type indexedDocument struct {
+	info     DocumentInfo
+	chunks   []documentChunk
+	lastUsed time.Time
+}
+
+// DocumentIndex keeps the documents users sent in each chat as a temporary,
+// in-memory retrieval index. Text is split into overlapping passages and
+// ranked with BM25, so follow-up questions pull only the relevant passages
+// into context (query_document) instead of the whole file.
+type DocumentIndex struct {
+	cfg    DocumentIndexConfig
+	logger *zap.Logger
+
+	mu    sync.Mutex
+	chats map[int64][]*indexedDocument
+	now   func() time.Time
+}
+
+// NewDocumentIndex creates an empty document index.
+func NewDocumentIndex(cfg DocumentIndexConfig, logger *zap.Logger) *DocumentIndex {
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = defaultDocumentMaxBytes
+	}
+	if cfg.ChunkChars <= 0 {
+		cfg.ChunkChars = defaultDocumentChunkChars
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultDocumentTTL
+	}
+	if cfg.MaxPerChat <= 0 {
+		cfg.MaxPerChat = defaultDocumentsPerChat
+	}
+	return &DocumentIndex{
+		cfg:    cfg,
+		logger: logger,
+		chats:  make(map[int64][]*indexedDocument),
+		now:    time.Now,
+	}
+}
+
+// Ingest extracts the text of a PDF, DOCX or text file and indexes it for
+// chatID. A document with the same name replaces the earlier one.
+func (x *DocumentIndex) Ingest(ctx context.Context, chatID int64, name, mimeType string, data []byte) (*DocumentInfo, error) {
+	if len(data) > x.cfg.MaxBytes {
+		return nil, fmt.Errorf("%s is %d bytes, limit is %d", name, len(data), x.cfg.MaxBytes)
+	}
+	text, err := ExtractDocumentText(ctx, name, mimeType, data)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("%s contains no extractable text (scanned PDF?)", name)
+	}
+	return x.Add(chatID, name, text), nil
+}
+
+// Add indexes already extracted text. Pages are separated by form feeds
+// (as pdftotext writes them).
+func (x *DocumentIndex) Add(chatID int64, name, text string) *DocumentInfo {
+	doc := &indexedDocument{lastUsed: x.now()}
+	for _, c := range chunkDocument(text, x.cfg.ChunkChars) {
+		terms := documentTerms(c.text)
+		counts := make(map[string]int, len(terms))
+		for _, t := range terms {
+			counts[t]++
+		}
+		doc.chunks = append(doc.chunks, documentChunk{page: c.page, text: c.text, terms: counts, length: len(terms)})
+		if len(doc.chunks) == maxDocumentChunks {
+			break
+		}
+	}
+	pages := 0
+	if strings.Contains(text, "\f") {
+		pages = strings.Count(strings.TrimRight(text, "\f\n "), "\f") + 1
+	}
+	doc.info = DocumentInfo{
+		Name:   name,
+		Pages:  pages,
+		Chunks: len(doc.chunks),
+		Tokens: utf8.RuneCountInString(text) / 3,
+		Intro:  documentIntro(text, 400),
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.pruneLocked(chatID)
+	docs := x.chats[chatID][:0:0]
+	for _, d := range x.chats[chatID] {
+		if d.info.Name != name {
+			docs = append(docs, d)
+		}
+	}
+	docs = append(docs, doc)
+	if len(docs) > x.cfg.MaxPerChat {
+		docs = docs[len(docs)-x.cfg.MaxPerChat:]
+	}
+	x.chats[chatID] = docs
+
+	x.logger.Info("Document indexed",
+		zap.Int64("chat_id", chatID),
+		zap.String("name", name),
+		zap.Int("pages", pages),
+		zap.Int("chunks", len(doc.chunks)),
+	)
+	info := doc.info
+	return &info
+}
+
+// Documents lists the chat's indexed documents, oldest first.
+func (x *DocumentIndex) Documents(chatID int64) []DocumentInfo {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.pruneLocked(chatID)
+	infos := make([]DocumentInfo, 0, len(x.chats[chatID]))
+	for _, d := range x.chats[chatID] {
+		infos = append(infos, d.info)
+	}
+	return infos
+}
+
+// Clear drops the chat's documents (/new).
+func (x *DocumentIndex) Clear(chatID int64) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	delete(x.chats, chatID)
+}
+
+// Search ranks the passages of the chat's documents against query. document
+// limits the search to documents whose name contains it (case-insensitive).
+func (x *DocumentIndex) Search(chatID int64, query, document string, limit int) []DocumentHit {
+	queryTerms := uniqueStrings(documentTerms(query))
+	if len(queryTerms) == 0 || limit <= 0 {
+		return nil
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.pruneLocked(chatID)
+
+	var docs []*indexedDocument
+	for _, d := range x.chats[chatID] {
+		if document == "" || strings.Contains(strings.ToLower(d.info.Name), strings.ToLower(document)) {
+			docs = append(docs, d)
+			d.lastUsed = x.now()
+		}
+	}
+
+	// BM25 over all passages of the selected documents
+	const k1, b = 1.2, 0.75
+	total, totalLen := 0, 0
+	df := make(map[string]int)
+	for _, d := range docs {
+		for _, c := range d.chunks {
+			total++
+			totalLen += c.length
+			for t := range c.terms {
+				df[t]++
+			}
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	avgLen := float64(totalLen) / float64(total)
+
+	var hits []DocumentHit
+	for _, d := range docs {
+		for i, c := range d.chunks {
+			score := 0.0
+			for _, t := range queryTerms {
+				tf := float64(c.terms[t])
+				if tf == 0 {
+					continue
+				}
+				idf := math.Log(1 + (float64(total)-float64(df[t])+0.5)/(float64(df[t])+0.5))
+				score += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(c.length)/avgLen))
+			}
+			if score > 0 {
+				hits = append(hits, DocumentHit{
+					Document: d.info.Name,
+					Page:     c.page,
+					Chunk:    i + 1,
+					Chunks:   len(d.chunks),
+					Text:     c.text,
+					Score:    score,
+				})
+			}
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// pruneLocked drops the chat's documents that have not been used within TTL.
+func (x *DocumentIndex) pruneLocked(chatID int64) {
+	docs := x.chats[chatID]
+	kept := docs[:0]
+	for _, d := range docs {
+		if x.now().Sub(d.lastUsed) < x.cfg.TTL {
+			kept = append(kept, d)
+		}
+	}
+	if len(kept) == 0 {
+		delete(x.chats, chatID)
+		return
+	}
+	x.chats[chatID] = kept
+}
+
+// ExtractDocumentText returns the plain text of a PDF (via pdftotext, pages
+// separated by form feeds), DOCX or text file.
+func ExtractDocumentText(ctx context.Context, name, mimeType string, data []byte) (string, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	switch {
+	case mimeType == "application/pdf" || ext == ".pdf":
+		return extractPDFText(ctx, data)
+	case mimeType == "application/vnd.openxmlformats-officedocument.wordprocessingml.document" || ext == ".docx":
+		return extractDOCXText(data)
+	case isTextDocument(mimeType, ext) && utf8.Valid(data):
+		return string(data), nil
+	}
+	return "", ErrUnsupportedDocument
+}
+
+// isTextDocument reports whether a file can be indexed as plain text.
+func isTextDocument(mimeType, ext string) bool {
+	for _, prefix := range []string{"text/", "application/json", "application/xml", "application/x-yaml", "application/yaml"} {
+		if strings.HasPrefix(mimeType, prefix) {
+			return true
+		}
+	}
+	switch ext {
+	case ".txt", ".md", ".markdown", ".csv", ".tsv", ".log", ".json", ".xml", ".yaml", ".yml", ".html", ".htm", ".rst":
+		return true
+	}
+	return false
+}
+
+// extractPDFText runs pdftotext (poppler-utils) on a temp copy of the PDF.
+func extractPDFText(ctx context.Context, data []byte) (string, error) {
+	bin, err := exec.LookPath("pdftotext")
+	if err != nil {
+		return "", fmt.Errorf("pdftotext not found: install poppler-utils to read PDF documents")
+	}
+	f, err := os.CreateTemp("", "ngoclaw-doc-*.pdf")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pdftotextTimeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin, "-enc", "UTF-8", f.Name(), "-")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("pdftotext: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// extractDOCXText reads the paragraphs of word/document.xml.
+func extractDOCXText(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("not a valid DOCX file: %w", err)
+	}
+	var body io.ReadCloser
+	for _, f := range zr.File {
+		if f.Name == "word/document.xml" {
+			if body, err = f.Open(); err != nil {
+				return "", err
+			}
+			break
+		}
+	}
+	if body == nil {
+		return "", fmt.Errorf("not a valid DOCX file: word/document.xml missing")
+	}
+	defer body.Close()
+
+	var sb strings.Builder
+	dec := xml.NewDecoder(body)
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("DOCX: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				sb.WriteByte('\t')
+			case "br", "cr":
+				sb.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				sb.WriteString("\n\n")
+			}
+		case xml.CharData:
+			if inText {
+				sb.Write(t)
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+type rawChunk struct {
+	page int
+	text string
+}
+
+// chunkDocument splits text into passages of about size characters along
+// paragraph boundaries. Each passage repeats the end of the previous one so
+// an answer spanning a boundary is still found. Pages are separated by form
+// feeds and always start a new passage, so a passage's page number (0 when
+// there are none) is where its new text is.
+func chunkDocument(text string, size int) []rawChunk {
+	paged := strings.Contains(text, "\f")
+	overlap := min(documentChunkOverlap, size/4)
+	var chunks []rawChunk
+	var cur []rune
+	fresh, page := 0, 0 // runes added since the last passage, page they are on
+	emit := func() {
+		chunks = append(chunks, rawChunk{page: page, text: strings.TrimSpace(string(cur))})
+		if len(cur) > overlap {
+			cur = append([]rune(nil), cur[len(cur)-overlap:]...)
+		}
+		fresh = 0
+	}
+
+	for i, pageText := range strings.Split(text, "\f") {
+		pageNo := 0
+		if paged {
+			pageNo = i + 1
+		}
+		if fresh > 0 && pageNo != page {
+			emit()
+		}
+		for _, para := range strings.Split(pageText, "\n\n") {
+			runes := []rune(strings.TrimSpace(para))
+			for len(runes) > 0 {
+				if fresh > 0 && len(cur)+len(runes) > size {
+					emit()
+				}
+				// Paragraphs longer than a passage are cut at the size limit
+				n := min(len(runes), size)
+				if fresh == 0 {
+					page = pageNo
+				}
+				if len(cur) > 0 {
+					cur = append(cur, '\n', '\n')
+				}
+				cur = append(cur, runes[:n]...)
+				fresh += n
+				runes = runes[n:]
+			}
+		}
+	}
+	if fresh > 0 {
+		emit()
+	}
+	return chunks
+}
+
+// documentTerms tokenizes text for ranking: lower-cased letter/digit words
+// of two or more characters, and overlapping character pairs for Han text
+// (which has no spaces between words).
+func documentTerms(text string) []string {
+	var terms []string
+	var word []rune
+	var prevHan rune
+	flushWord := func() {
+		if len(word) >= 2 {
+			terms = append(terms, string(word))
+		}
+		word = word[:0]
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			if prevHan != 0 {
+				terms = append(terms, string([]rune{prevHan, r}))
+			} else {
+				terms = append(terms, string(r))
+			}
+			prevHan = r
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, r)
+		default:
+			flushWord()
+		}
+		prevHan = 0
+	}
+	flushWord()
+	return terms
+}
+
+// documentIntro returns the first max characters of text on one line.
+func documentIntro(text string, max int) string {
+	s := strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max]) + "…"
+}
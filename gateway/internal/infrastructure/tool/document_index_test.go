@@ -0,0 +1,188 @@
+package tool
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestChunkDocument(t *testing.T) {
+	para := strings.Repeat("word ", 60) // 300 chars
+	text := para + "\n\n" + para + "\n\n" + para + "\f" + para + "\n\n" + strings.Repeat("x", 1200)
+
+	chunks := chunkDocument(text, 700)
+	if len(chunks) < 3 {
+		t.Fatalf("chunks = %d, want at least 3", len(chunks))
+	}
+	if chunks[0].page != 1 || chunks[len(chunks)-1].page != 2 {
+		t.Errorf("pages = %d..%d, want 1..2", chunks[0].page, chunks[len(chunks)-1].page)
+	}
+	for i := 1; i < len(chunks); i++ {
+		prev := []rune(chunks[i-1].text)
+		tail := string(prev[len(prev)-50:])
+		if !strings.Contains(chunks[i].text, tail) {
+			t.Errorf("chunk %d does not overlap the end of chunk %d", i, i-1)
+		}
+	}
+	for _, c := range chunks {
+		if n := len([]rune(c.text)); n > 700+documentChunkOverlap+2 {
+			t.Errorf("chunk of %d runes exceeds size plus overlap", n)
+		}
+	}
+
+	if got := chunkDocument("one\n\ntwo", 1500); len(got) != 1 || got[0].page != 0 || got[0].text != "one\n\ntwo" {
+		t.Errorf("small unpaged text = %+v", got)
+	}
+}
+
+func TestDocumentIndexSearch(t *testing.T) {
+	idx := NewDocumentIndex(DocumentIndexConfig{ChunkChars: 200}, zap.NewNop())
+	contract := "Parties and definitions of the agreement.\n\n" +
+		"Payment is due within 30 days of the invoice date.\f" +
+		"Either party may terminate this agreement with 90 days written notice.\n\n" +
+		"Governing law is the law of Singapore."
+	info := idx.Add(1, "contract.pdf", contract)
+	if info.Pages != 2 || info.Chunks == 0 {
+		t.Fatalf("info = %+v", info)
+	}
+	idx.Add(1, "合同.docx", "本合同自双方签字之日起生效。\n\n乙方应在收到发票后三十日内付款。\n\n任何一方提前九十日书面通知即可解除合同。")
+	idx.Add(2, "other.txt", "terminate notice elsewhere")
+
+	hits := idx.Search(1, "terminate notice", "", 3)
+	if len(hits) == 0 || hits[0].Document != "contract.pdf" || hits[0].Page != 2 ||
+		!strings.Contains(hits[0].Text, "90 days written notice") {
+		t.Fatalf("hits = %+v", hits)
+	}
+
+	hits = idx.Search(1, "解除合同", "", 1)
+	if len(hits) != 1 || hits[0].Document != "合同.docx" || !strings.Contains(hits[0].Text, "书面通知") {
+		t.Fatalf("CJK hits = %+v", hits)
+	}
+
+	if hits := idx.Search(1, "payment", "合同", 5); len(hits) != 0 {
+		t.Errorf("document filter ignored: %+v", hits)
+	}
+	if hits := idx.Search(1, "zebra", "", 5); len(hits) != 0 {
+		t.Errorf("unrelated query matched: %+v", hits)
+	}
+
+	// Re-sending a file replaces it
+	idx.Add(1, "contract.pdf", "Amended: no termination clause.")
+	if docs := idx.Documents(1); len(docs) != 2 || docs[1].Name != "contract.pdf" || docs[1].Pages != 0 {
+		t.Errorf("documents after replace = %+v", docs)
+	}
+}
+
+func TestDocumentIndexEviction(t *testing.T) {
+	idx := NewDocumentIndex(DocumentIndexConfig{MaxPerChat: 2, TTL: time.Hour}, zap.NewNop())
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	idx.now = func() time.Time { return now }
+
+	idx.Add(1, "a.txt", "alpha")
+	idx.Add(1, "b.txt", "bravo")
+	idx.Add(1, "c.txt", "charlie")
+	if docs := idx.Documents(1); len(docs) != 2 || docs[0].Name != "b.txt" {
+		t.Fatalf("documents = %+v, want b.txt and c.txt", docs)
+	}
+
+	// Searching keeps a document alive; the other expires
+	now = now.Add(40 * time.Minute)
+	idx.Search(1, "bravo", "b.txt", 1)
+	now = now.Add(40 * time.Minute)
+	if docs := idx.Documents(1); len(docs) != 1 || docs[0].Name != "b.txt" {
+		t.Fatalf("documents after TTL = %+v, want b.txt", docs)
+	}
+
+	idx.Clear(1)
+	if docs := idx.Documents(1); len(docs) != 0 {
+		t.Errorf("documents after Clear = %+v", docs)
+	}
+}
+
+func TestExtractDocumentText(t *testing.T) {
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("word/document.xml")
+	w.Write([]byte(`<?xml version="1.0"?><w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+		`<w:p><w:r><w:t>Hello</w:t></w:r><w:r><w:tab/><w:t xml:space="preserve">world </w:t></w:r></w:p>` +
+		`<w:p><w:r><w:t>Second</w:t><w:br/><w:t>line</w:t></w:r></w:p></w:body></w:document>`))
+	zw.Close()
+	text, err := ExtractDocumentText(ctx, "report.docx", "", buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "Hello\tworld") || !strings.Contains(text, "Second\nline") {
+		t.Errorf("docx text = %q", text)
+	}
+
+	if text, err := ExtractDocumentText(ctx, "notes.md", "text/markdown", []byte("# Notes")); err != nil || text != "# Notes" {
+		t.Errorf("markdown = %q, %v", text, err)
+	}
+	for _, f := range []struct{ name, mime string }{
+		{"photo.jpg", "image/jpeg"},
+		{"archive.zip", "application/zip"},
+	} {
+		if _, err := ExtractDocumentText(ctx, f.name, f.mime, []byte("\x00\x01")); !errors.Is(err, ErrUnsupportedDocument) {
+			t.Errorf("%s: err = %v, want ErrUnsupportedDocument", f.name, err)
+		}
+	}
+	if _, err := ExtractDocumentText(ctx, "bad.docx", "", []byte("not a zip")); err == nil {
+		t.Error("corrupt docx should fail")
+	}
+}
+
+func TestExtractPDFText(t *testing.T) {
+	if _, err := exec.LookPath("pdftotext"); err != nil {
+		t.Skip("pdftotext not installed")
+	}
+	pdf := "%PDF-1.4\n1 0 obj<</Type/Catalog/Pages 2 0 R>>endobj\n" +
+		"2 0 obj<</Type/Pages/Kids[3 0 R]/Count 1>>endobj\n" +
+		"3 0 obj<</Type/Page/Parent 2 0 R/MediaBox[0 0 300 144]/Contents 4 0 R/Resources<</Font<</F1 5 0 R>>>>>>endobj\n" +
+		"4 0 obj<</Length 44>>stream\nBT /F1 18 Tf 20 80 Td (Quarterly report) Tj ET\nendstream endobj\n" +
+		"5 0 obj<</Type/Font/Subtype/Type1/BaseFont/Helvetica>>endobj\n" +
+		"trailer<</Root 1 0 R>>\n%%EOF"
+	text, err := ExtractDocumentText(context.Background(), "report.pdf", "application/pdf", []byte(pdf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "Quarterly report") {
+		t.Errorf("pdf text = %q", text)
+	}
+}
+
+func TestQueryDocumentTool(t *testing.T) {
+	idx := NewDocumentIndex(DocumentIndexConfig{}, zap.NewNop())
+	tool := NewQueryDocumentTool(idx, zap.NewNop())
+	ctx := WithChatID(context.Background(), 7)
+
+	res, _ := tool.Execute(ctx, map[string]interface{}{"query": "refund"})
+	if res.Success || !strings.Contains(res.Error, "no documents") {
+		t.Fatalf("empty chat = %+v", res)
+	}
+
+	idx.Add(7, "policy.pdf", "Shipping takes five days.\fRefunds are issued within 14 days.")
+	res, _ = tool.Execute(ctx, map[string]interface{}{"query": "refunds", "limit": float64(3)})
+	if !res.Success || !strings.Contains(res.Output, "[1] policy.pdf, page") || !strings.Contains(res.Output, "14 days") {
+		t.Errorf("output = %q", res.Output)
+	}
+
+	res, _ = tool.Execute(ctx, map[string]interface{}{"query": "warranty"})
+	if !res.Success || !strings.Contains(res.Output, "policy.pdf") {
+		t.Errorf("no-match output = %+v", res)
+	}
+
+	// Other chats don't see the document
+	res, _ = tool.Execute(WithChatID(context.Background(), 8), map[string]interface{}{"query": "refunds"})
+	if res.Success {
+		t.Errorf("document leaked to another chat: %+v", res)
+	}
+}
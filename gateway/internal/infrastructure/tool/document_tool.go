@@ -0,0 +1,107 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultDocumentHits = 5
+	maxDocumentHits     = 10
+)
+
+// QueryDocumentTool searches the documents the user sent in this chat
+// (indexed by DocumentIndex) and returns the best-matching passages.
+type QueryDocumentTool struct {
+	index  *DocumentIndex
+	logger *zap.Logger
+}
+
+// NewQueryDocumentTool creates the query_document tool.
+func NewQueryDocumentTool(index *DocumentIndex, logger *zap.Logger) *QueryDocumentTool {
+	return &QueryDocumentTool{index: index, logger: logger}
+}
+
+func (t *QueryDocumentTool) Name() string          { return "query_document" }
+func (t *QueryDocumentTool) Kind() domaintool.Kind { return domaintool.KindSearch }
+
+func (t *QueryDocumentTool) Description() string {
+	return "Search the documents (PDF, DOCX, text) the user sent in this chat and get the most relevant passages with page numbers. " +
+		"Use it to answer questions about a document instead of guessing from its first page; " +
+		"search again with other keywords or synonyms if the passages don't answer the question."
+}
+
+func (t *QueryDocumentTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Keywords to look for, in the language of the document, e.g. 'termination notice period'",
+			},
+			"document": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional: search only documents whose file name contains this",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("Passages to return (default %d, max %d)", defaultDocumentHits, maxDocumentHits),
+			},
+		},
+		"required": []string{"query"},
+	}
+}
+
+func (t *QueryDocumentTool) Execute(ctx context.Context, args map[string]interface{}) (*domaintool.Result, error) {
+	query, _ := args["query"].(string)
+	document, _ := args["document"].(string)
+	if strings.TrimSpace(query) == "" {
+		return &domaintool.Result{Success: false, Error: "query is required"}, nil
+	}
+	limit := defaultDocumentHits
+	if v, ok := args["limit"].(float64); ok && v > 0 {
+		limit = min(int(v), maxDocumentHits)
+	}
+
+	chatID := chatIDFromContext(ctx)
+	docs := t.index.Documents(chatID)
+	if len(docs) == 0 {
+		return &domaintool.Result{
+			Success: false,
+			Error:   "no documents in this chat; ask the user to send the PDF, DOCX or text file",
+		}, nil
+	}
+
+	hits := t.index.Search(chatID, query, document, limit)
+	if len(hits) == 0 {
+		names := make([]string, len(docs))
+		for i, d := range docs {
+			names[i] = d.Name
+		}
+		return &domaintool.Result{
+			Success: true,
+			Output:  fmt.Sprintf("No passages match %q. Documents in this chat: %s", query, strings.Join(names, ", ")),
+		}, nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d passages for %q:\n", len(hits), query)
+	for i, h := range hits {
+		loc := fmt.Sprintf("passage %d/%d", h.Chunk, h.Chunks)
+		if h.Page > 0 {
+			loc = fmt.Sprintf("page %d, %s", h.Page, loc)
+		}
+		fmt.Fprintf(&sb, "\n[%d] %s, %s\n%s\n", i+1, h.Document, loc, h.Text)
+	}
+	return &domaintool.Result{
+		Success: true,
+		Output:  sb.String(),
+		Metadata: map[string]interface{}{
+			"hits": len(hits),
+		},
+	}, nil
+}
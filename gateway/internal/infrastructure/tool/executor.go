@@ -18,6 +18,7 @@ type Executor struct {
 	skillExec     SkillExecutor
 	logger        *zap.Logger
 	execContext   domaintool.ExecutionContext
+	fileGuard     *FileGuard
 }
 
 // NewExecutor 创建工具执行器
@@ -38,6 +39,11 @@ func NewExecutor(
 	}
 }
 
+// SetFileGuard 启用写类工具的按文件加锁
+func (e *Executor) SetFileGuard(g *FileGuard) {
+	e.fileGuard = g
+}
+
 // ToolCall 工具调用 (与 runner 包中的定义兼容)
 type ToolCall struct {
 	ID        string
@@ -91,12 +97,35 @@ func (e *Executor) Execute(ctx context.Context, call ToolCall) (*ToolResult, err
 		}, nil
 	}
 
+	// 参数校验: 不合法直接返回结构化错误, 不执行工具
+	if invalid := domaintool.CheckArgs(tool, call.Arguments); invalid != nil {
+		e.logger.Warn("Tool arguments rejected by schema",
+			zap.String("tool", call.Name),
+			zap.Any("invalid_args", invalid.Metadata["invalid_args"]),
+		)
+		return &ToolResult{
+			ToolCallID: call.ID,
+			Output:     invalid.Output,
+			Success:    false,
+			Error:      fmt.Errorf("invalid arguments for %s", call.Name),
+		}, nil
+	}
+
 	e.logger.Info("Executing tool",
 		zap.String("tool", call.Name),
 		zap.String("call_id", call.ID),
 		zap.String("context", e.execContext.String()),
 	)
 
+	// 同一文件的写操作串行执行
+	if e.fileGuard != nil {
+		unlock, err := e.fileGuard.LockCall(ctx, tool.Kind(), call.Arguments)
+		if err != nil {
+			return &ToolResult{ToolCallID: call.ID, Output: err.Error(), Success: false, Error: err}, nil
+		}
+		defer unlock()
+	}
+
 	// 执行工具
 	result, err := tool.Execute(ctx, call.Arguments)
 	
@@ -0,0 +1,228 @@
+package tool
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strings"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+)
+
+// FileEditsKey is the Result.Metadata key under which edit-kind tools
+// report the files they changed ([]*entity.FileEdit). The agent loop turns
+// each entry into an EventFileEdit.
+const FileEditsKey = "file_edits"
+
+const (
+	// editContextLines is the context kept around each change, as in diff -u.
+	editContextLines = 3
+	// maxEditLines caps the hunk lines of one file; larger changes are
+	// reported with Truncated and no hunks.
+	maxEditLines = 2000
+	// maxDiffCells bounds the LCS table; beyond it the changed middle of the
+	// file is reported as one replacement.
+	maxDiffCells = 1 << 20
+)
+
+// newFileEdit describes the change from before to after (nil = the file
+// did not exist / no longer exists).
+func newFileEdit(absPath string, before, after *string) *entity.FileEdit {
+	edit := &entity.FileEdit{Path: absPath}
+	var a, b []string
+	if before != nil {
+		edit.BeforeHash = contentHash(*before)
+		a = diffLines(*before)
+	}
+	if after != nil {
+		edit.AfterHash = contentHash(*after)
+		b = diffLines(*after)
+	}
+	hunks := diffHunks(a, b)
+	n := 0
+	for _, h := range hunks {
+		n += len(h.Lines)
+	}
+	if n > maxEditLines {
+		edit.Truncated = true
+	} else {
+		edit.Hunks = hunks
+	}
+	return edit
+}
+
+// readWorkspaceFile reads a file as an edit tool sees it (relative paths
+// resolve against the sandbox working directory). It returns the absolute
+// path and the content, nil when the file does not exist or is unreadable.
+func readWorkspaceFile(path, workDir string) (string, *string) {
+	abs := resolveReadPath(path, workDir)
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return abs, nil
+	}
+	s := string(data)
+	return abs, &s
+}
+
+// fileEditMetadata adds the edits to a result's metadata, skipping edits
+// that changed nothing.
+func fileEditMetadata(meta map[string]interface{}, edits ...*entity.FileEdit) map[string]interface{} {
+	var changed []*entity.FileEdit
+	for _, e := range edits {
+		if e != nil && e.BeforeHash != e.AfterHash {
+			changed = append(changed, e)
+		}
+	}
+	if len(changed) == 0 {
+		return meta
+	}
+	if meta == nil {
+		meta = make(map[string]interface{})
+	}
+	meta[FileEditsKey] = changed
+	return meta
+}
+
+func contentHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func diffLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+type diffOp struct {
+	kind byte // ' ', '-', '+'
+	line string
+}
+
+// diffHunks returns the unified-diff hunks turning a into b.
+func diffHunks(a, b []string) []entity.EditHunk {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, l := range a[:pre] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	ops = append(ops, editScript(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for _, l := range a[len(a)-suf:] {
+		ops = append(ops, diffOp{' ', l})
+	}
+
+	// Line numbers before each op
+	oldNo, newNo := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for i, op := range ops {
+		oldNo[i+1], newNo[i+1] = oldNo[i], newNo[i]
+		if op.kind != '+' {
+			oldNo[i+1]++
+		}
+		if op.kind != '-' {
+			newNo[i+1]++
+		}
+	}
+
+	var hunks []entity.EditHunk
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		start := max(0, i-editContextLines)
+		end := i
+		for {
+			for end < len(ops) && ops[end].kind != ' ' {
+				end++
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			// Changes separated by little context share a hunk
+			if next < len(ops) && next-end <= 2*editContextLines {
+				end = next
+				continue
+			}
+			break
+		}
+		stop := min(len(ops), end+editContextLines)
+
+		h := entity.EditHunk{
+			OldLines: oldNo[stop] - oldNo[start],
+			NewLines: newNo[stop] - newNo[start],
+		}
+		h.OldStart, h.NewStart = oldNo[start], newNo[start]
+		if h.OldLines > 0 {
+			h.OldStart++
+		}
+		if h.NewLines > 0 {
+			h.NewStart++
+		}
+		for _, op := range ops[start:stop] {
+			h.Lines = append(h.Lines, string(op.kind)+op.line)
+		}
+		hunks = append(hunks, h)
+		i = stop
+	}
+	return hunks
+}
+
+// editScript returns a minimal line edit script from a to b (LCS), or a
+// plain replacement when the inputs are too large to compare.
+func editScript(a, b []string) []diffOp {
+	ops := make([]diffOp, 0, len(a)+len(b))
+	if len(a)*len(b) > maxDiffCells {
+		for _, l := range a {
+			ops = append(ops, diffOp{'-', l})
+		}
+		for _, l := range b {
+			ops = append(ops, diffOp{'+', l})
+		}
+		return ops
+	}
+
+	// lcs[i][j] = LCS length of a[i:] and b[j:]
+	w := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*w)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*w+j] = lcs[(i+1)*w+j+1] + 1
+			} else {
+				lcs[i*w+j] = max(lcs[(i+1)*w+j], lcs[i*w+j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[(i+1)*w+j] >= lcs[i*w+j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
@@ -0,0 +1,136 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/sandbox"
+	"go.uber.org/zap"
+)
+
+func TestDiffHunks(t *testing.T) {
+	lines := func(n int) []string {
+		var out []string
+		for i := 1; i <= n; i++ {
+			out = append(out, "l"+string(rune('a'+i-1)))
+		}
+		return out
+	}
+	a := lines(20)
+	b := append([]string(nil), a...)
+	b[1] = "changed"                                          // line 2
+	b = append(b[:15], append([]string{"new"}, b[15:]...)...) // insert after line 15
+
+	hunks := diffHunks(a, b)
+	if len(hunks) != 2 {
+		t.Fatalf("hunks = %+v", hunks)
+	}
+	h := hunks[0]
+	if h.OldStart != 1 || h.OldLines != 5 || h.NewStart != 1 || h.NewLines != 5 ||
+		strings.Join(h.Lines, "|") != " la|-lb|+changed| lc| ld| le" {
+		t.Errorf("hunk 1 = %+v", h)
+	}
+	h = hunks[1]
+	if h.OldStart != 13 || h.OldLines != 6 || h.NewStart != 13 || h.NewLines != 7 || h.Lines[3] != "+new" {
+		t.Errorf("hunk 2 = %+v", h)
+	}
+
+	// Nearby changes share a hunk; a new file is one all-"+" hunk starting at 0
+	if hunks := diffHunks(a, append([]string{"x"}, append(a[:5:5], a[6:]...)...)); len(hunks) != 1 {
+		t.Errorf("nearby changes = %d hunks", len(hunks))
+	}
+	h = diffHunks(nil, []string{"a", "b"})[0]
+	if h.OldStart != 0 || h.OldLines != 0 || h.NewStart != 1 || h.NewLines != 2 {
+		t.Errorf("created = %+v", h)
+	}
+}
+
+func TestEditToolsReportFileEdits(t *testing.T) {
+	work := t.TempDir()
+	cfg := sandbox.DefaultConfig()
+	cfg.WorkDir = work
+	cfg.TempDir = t.TempDir()
+	sb, err := sandbox.NewProcessSandbox(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	path := filepath.Join(work, "main.go")
+
+	res, _ := NewWriteFileTool(sb, zap.NewNop()).Execute(ctx, map[string]interface{}{
+		"path": "main.go", "content": "package main\n\nfunc main() {}",
+	})
+	edits, _ := res.Metadata[FileEditsKey].([]*entity.FileEdit)
+	if !res.Success || len(edits) != 1 || edits[0].Path != path || edits[0].BeforeHash != "" || len(edits[0].Hunks) != 1 {
+		t.Fatalf("write_file = %+v, edits = %+v", res, edits)
+	}
+	data, _ := os.ReadFile(path)
+	if edits[0].AfterHash != contentHash(string(data)) {
+		t.Error("after_hash does not match the file")
+	}
+
+	res, _ = NewEditFileTool(sb, zap.NewNop()).Execute(ctx, map[string]interface{}{
+		"path": "main.go", "old_text": "func main() {}", "new_text": "func main() {\n\tprintln(1)\n}",
+	})
+	edits, _ = res.Metadata[FileEditsKey].([]*entity.FileEdit)
+	if !res.Success || len(edits) != 1 || edits[0].BeforeHash != contentHash(string(data)) {
+		t.Fatalf("edit_file = %+v, edits = %+v", res, edits)
+	}
+	if got := strings.Join(edits[0].Hunks[0].Lines, "|"); !strings.Contains(got, "-func main() {}|+func main() {|+\tprintln(1)|+}") {
+		t.Errorf("edit_file hunk = %s", got)
+	}
+}
+
+func TestEditToolsPreserveFileFormat(t *testing.T) {
+	work := t.TempDir()
+	cfg := sandbox.DefaultConfig()
+	cfg.WorkDir = work
+	cfg.TempDir = t.TempDir()
+	sb, err := sandbox.NewProcessSandbox(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	path := filepath.Join(work, "run.bat")
+	os.WriteFile(path, []byte("\xEF\xBB\xBF@echo off\r\necho NGOCLAW_EDIT_EOF\r\n\r\n"), 0o750)
+
+	res, _ := NewEditFileTool(sb, zap.NewNop()).Execute(ctx, map[string]interface{}{
+		"path": "run.bat", "old_text": "echo NGOCLAW_EDIT_EOF\n", "new_text": "echo one\necho two\n",
+	})
+	if !res.Success || res.Metadata["encoding"] != encodingUTF8BOM || res.Metadata["line_endings"] != "crlf" {
+		t.Fatalf("edit_file = %+v", res)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "\xEF\xBB\xBF@echo off\r\necho one\r\necho two\r\n\r\n" {
+		t.Errorf("content = %q", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o750 {
+		t.Errorf("mode = %v", info.Mode())
+	}
+	edits, _ := res.Metadata[FileEditsKey].([]*entity.FileEdit)
+	if len(edits) != 1 || edits[0].AfterHash != contentHash(string(data)) || strings.Contains(strings.Join(edits[0].Hunks[0].Lines, ""), "\r") {
+		t.Errorf("edits = %+v", edits)
+	}
+
+	// write_file keeps the format of the file it overwrites and writes the content as given
+	res, _ = NewWriteFileTool(sb, zap.NewNop()).Execute(ctx, map[string]interface{}{"path": "run.bat", "content": "@echo on\nexit"})
+	if data, _ := os.ReadFile(path); !res.Success || string(data) != "\xEF\xBB\xBF@echo on\r\nexit" {
+		t.Errorf("write_file content = %q", data)
+	}
+
+	for _, p := range []string{"../escape.txt", filepath.Join(t.TempDir(), "x.txt")} {
+		res, _ = NewWriteFileTool(sb, zap.NewNop()).Execute(ctx, map[string]interface{}{"path": p, "content": "x"})
+		if res.Success || !strings.Contains(res.Error, "outside the workspace") {
+			t.Errorf("write_file %s = %+v", p, res)
+		}
+	}
+	os.Symlink(t.TempDir(), filepath.Join(work, "link"))
+	res, _ = NewEditFileTool(sb, zap.NewNop()).Execute(ctx, map[string]interface{}{"path": "link/a.txt", "old_text": "a", "new_text": "b"})
+	if res.Success || !strings.Contains(res.Error, "outside the workspace") {
+		t.Errorf("edit_file through symlink = %+v", res)
+	}
+}
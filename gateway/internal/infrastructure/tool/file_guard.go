@@ -0,0 +1,266 @@
+package tool
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+)
+
+// fileVersionTTL 超过该时长未更新的读取记录会被清理
+const fileVersionTTL = 6 * time.Hour
+
+// FileGuard 协调并发 run 对同一工作区文件的修改.
+//
+//   - 建议锁: 执行器在运行写类工具 (KindEdit) 前按 path 参数加锁, 同一文件的
+//     写操作在进程内串行, 两个会话 (或 cron 与用户) 不会交错读改写.
+//   - 版本检查: 每个 run (按 trace ID 区分) 读写文件后记录 mtime/size/hash,
+//     再次写入前若文件已被其他会话或命令改动, 返回 EditConflictError,
+//     提示模型重新读取后再修改, 而不是静默覆盖.
+type FileGuard struct {
+	workDir func() string
+
+	mu       sync.Mutex
+	locks    map[string]*fileLock
+	versions map[versionKey]fileVersion
+	edits    map[string]*runEdits // run → 本 run 写过的文件 (typecheck 增量检查用)
+}
+
+type runEdits struct {
+	paths map[string]bool
+	seen  time.Time
+}
+
+type fileLock struct {
+	ch   chan struct{} // 容量 1 的信号量, 支持 ctx 取消等待
+	refs int
+}
+
+type versionKey struct {
+	run  string
+	path string
+}
+
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+	hash    string // 已知完整内容时的 sha256, 否则为空
+	seen    time.Time
+}
+
+// EditConflictError 文件在本 run 上次读取后被他人修改
+type EditConflictError struct {
+	Path   string
+	Reason string
+}
+
+func (e *EditConflictError) Error() string {
+	return fmt.Sprintf("edit conflict: %s %s since this run last read it (another session or command touched it). "+
+		"Re-read the file with read_file and re-apply your change on top of the current content.", e.Path, e.Reason)
+}
+
+// NewFileGuard 创建文件协调器. workDir 用于把相对路径解析为绝对路径 (可为 nil).
+func NewFileGuard(workDir func() string) *FileGuard {
+	return &FileGuard{
+		workDir:  workDir,
+		locks:    make(map[string]*fileLock),
+		versions: make(map[versionKey]fileVersion),
+		edits:    make(map[string]*runEdits),
+	}
+}
+
+// Resolve 将工具参数中的路径解析为绝对路径 (锁与版本记录的 key)
+func (g *FileGuard) Resolve(path string) string {
+	dir := ""
+	if g.workDir != nil {
+		dir = g.workDir()
+	}
+	return resolveReadPath(path, dir)
+}
+
+// LockCall 为写类工具调用加锁; 非写类工具或无 path 参数时返回空操作的解锁函数
+func (g *FileGuard) LockCall(ctx context.Context, kind domaintool.Kind, args map[string]interface{}) (func(), error) {
+	if kind != domaintool.KindEdit {
+		return func() {}, nil
+	}
+	path, _ := args["path"].(string)
+	if path == "" {
+		return func() {}, nil
+	}
+	return g.Lock(ctx, g.Resolve(path))
+}
+
+// Lock 按路径获取建议锁 (多个路径按字典序获取, 避免死锁), ctx 取消时放弃等待
+func (g *FileGuard) Lock(ctx context.Context, paths ...string) (func(), error) {
+	sorted := append([]string(nil), paths...)
+	sort.Strings(sorted)
+
+	var held []func()
+	unlockAll := func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			held[i]()
+		}
+	}
+	for i, p := range sorted {
+		if i > 0 && p == sorted[i-1] {
+			continue
+		}
+		unlock, err := g.lockOne(ctx, p)
+		if err != nil {
+			unlockAll()
+			return nil, err
+		}
+		held = append(held, unlock)
+	}
+	return unlockAll, nil
+}
+
+func (g *FileGuard) lockOne(ctx context.Context, path string) (func(), error) {
+	g.mu.Lock()
+	l := g.locks[path]
+	if l == nil {
+		l = &fileLock{ch: make(chan struct{}, 1)}
+		g.locks[path] = l
+	}
+	l.refs++
+	g.mu.Unlock()
+
+	release := func() {
+		g.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(g.locks, path)
+		}
+		g.mu.Unlock()
+	}
+
+	select {
+	case l.ch <- struct{}{}:
+		return func() {
+			<-l.ch
+			release()
+		}, nil
+	case <-ctx.Done():
+		release()
+		return nil, fmt.Errorf("waiting for file lock on %s: %w", path, ctx.Err())
+	}
+}
+
+// Observe 记录本 run 看到的文件版本. content 为完整文件内容时传入 full=true,
+// 以便 mtime 变化但内容未变 (如 touch) 时不误报冲突.
+func (g *FileGuard) Observe(ctx context.Context, absPath, content string, full bool) {
+	run := service.TraceIDFromContext(ctx)
+	if run == "" {
+		return
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return
+	}
+	v := fileVersion{modTime: info.ModTime(), size: info.Size(), seen: time.Now()}
+	if full {
+		v.hash = hashContent([]byte(content))
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.versions[versionKey{run: run, path: absPath}] = v
+	if len(g.versions) > 512 {
+		cutoff := time.Now().Add(-fileVersionTTL)
+		for k, old := range g.versions {
+			if old.seen.Before(cutoff) {
+				delete(g.versions, k)
+			}
+		}
+	}
+}
+
+// Verify 检查文件自本 run 上次读写后是否被改动. 本 run 从未见过该文件时不检查.
+func (g *FileGuard) Verify(ctx context.Context, absPath string) error {
+	run := service.TraceIDFromContext(ctx)
+	if run == "" {
+		return nil
+	}
+	g.mu.Lock()
+	v, ok := g.versions[versionKey{run: run, path: absPath}]
+	g.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &EditConflictError{Path: absPath, Reason: "was deleted"}
+		}
+		return nil
+	}
+	if info.Size() == v.size && info.ModTime().Equal(v.modTime) {
+		return nil
+	}
+	if info.Size() == v.size && v.hash != "" {
+		if data, err := os.ReadFile(absPath); err == nil && hashContent(data) == v.hash {
+			return nil
+		}
+	}
+	return &EditConflictError{Path: absPath, Reason: "was modified"}
+}
+
+func hashContent(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// RecordEdit 记录本 run 修改过的文件 (write_file / edit_file / apply_patch 成功后调用)
+func (g *FileGuard) RecordEdit(ctx context.Context, absPaths ...string) {
+	run := service.TraceIDFromContext(ctx)
+	if run == "" || len(absPaths) == 0 {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	e := g.edits[run]
+	if e == nil {
+		if len(g.edits) > 64 {
+			cutoff := time.Now().Add(-fileVersionTTL)
+			for k, old := range g.edits {
+				if old.seen.Before(cutoff) {
+					delete(g.edits, k)
+				}
+			}
+		}
+		e = &runEdits{paths: make(map[string]bool)}
+		g.edits[run] = e
+	}
+	for _, p := range absPaths {
+		e.paths[p] = true
+	}
+	e.seen = time.Now()
+}
+
+// EditedFiles 返回本 run 修改过的文件 (绝对路径, 已排序)
+func (g *FileGuard) EditedFiles(ctx context.Context) []string {
+	run := service.TraceIDFromContext(ctx)
+	if run == "" {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	e := g.edits[run]
+	if e == nil {
+		return nil
+	}
+	paths := make([]string, 0, len(e.paths))
+	for p := range e.paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,99 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+)
+
+func TestFileGuard_DetectsEditBySomeoneElse(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	os.WriteFile(path, []byte("package main\n"), 0o644)
+
+	g := NewFileGuard(func() string { return dir })
+	runA := service.WithTraceID(context.Background(), "run-a")
+	runB := service.WithTraceID(context.Background(), "run-b")
+
+	// A 读取, 未改动时可以写
+	g.Observe(runA, g.Resolve("main.go"), "package main\n", true)
+	if err := g.Verify(runA, path); err != nil {
+		t.Fatalf("unchanged file reported as conflict: %v", err)
+	}
+
+	// touch 只改 mtime, 内容相同不算冲突
+	later := time.Now().Add(2 * time.Second)
+	os.Chtimes(path, later, later)
+	if err := g.Verify(runA, path); err != nil {
+		t.Fatalf("touch reported as conflict: %v", err)
+	}
+
+	// B 修改了文件: A 再写应报冲突, B 自己不受影响
+	os.WriteFile(path, []byte("package main\n\nfunc main() {}\n"), 0o644)
+	g.Observe(runB, path, "", false)
+
+	var conflict *EditConflictError
+	if err := g.Verify(runA, path); !errors.As(err, &conflict) {
+		t.Fatalf("expected EditConflictError, got %v", err)
+	}
+	if err := g.Verify(runB, path); err != nil {
+		t.Errorf("writer's own run reported conflict: %v", err)
+	}
+
+	// A 重新读取后冲突解除
+	g.Observe(runA, path, "package main\n\nfunc main() {}\n", true)
+	if err := g.Verify(runA, path); err != nil {
+		t.Errorf("conflict persisted after re-read: %v", err)
+	}
+}
+
+func TestFileGuard_LockCallSerializesWritesPerFile(t *testing.T) {
+	g := NewFileGuard(func() string { return "/ws" })
+	args := map[string]interface{}{"path": "a.txt"}
+
+	unlock, err := g.LockCall(context.Background(), domaintool.KindEdit, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 同一文件 (绝对路径与相对路径等价) 的第二个写操作需等待
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := g.LockCall(ctx, domaintool.KindEdit, map[string]interface{}{"path": "/ws/a.txt"}); err == nil {
+		t.Fatal("second writer acquired the lock while the first held it")
+	}
+
+	// 其他文件与读操作不受影响
+	other, err := g.LockCall(context.Background(), domaintool.KindEdit, map[string]interface{}{"path": "b.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other()
+	read, _ := g.LockCall(context.Background(), domaintool.KindRead, args)
+	read()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		next, err := g.LockCall(context.Background(), domaintool.KindEdit, args)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		next()
+	}()
+	unlock()
+	wg.Wait()
+
+	if len(g.locks) != 0 {
+		t.Errorf("lock table not cleaned up: %d entries", len(g.locks))
+	}
+}
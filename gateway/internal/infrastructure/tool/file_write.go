@@ -0,0 +1,176 @@
+package tool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+)
+
+// Encodings edit_file and write_file preserve when rewriting a file.
+const (
+	encodingUTF8    = "utf-8"
+	encodingUTF8BOM = "utf-8-bom"
+	encodingUTF16LE = "utf-16le"
+	encodingUTF16BE = "utf-16be"
+)
+
+// newFileMode is the mode of files created by write_file; existing files
+// keep theirs.
+const newFileMode = 0o644
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// textFormat is how a text file is stored on disk. The edit tools work on
+// the decoded text, with LF line endings when the file uses CRLF
+// throughout, and write it back in the same format.
+type textFormat struct {
+	Encoding string
+	CRLF     bool
+}
+
+// LineEndings names the line endings for tool metadata.
+func (f textFormat) LineEndings() string {
+	if f.CRLF {
+		return "crlf"
+	}
+	return "lf"
+}
+
+// decodeText returns the text of data and its format. Files that are
+// neither UTF-8 nor UTF-16 with a BOM are rejected, since writing them back
+// as UTF-8 would corrupt them.
+func decodeText(data []byte) (string, textFormat, error) {
+	f := textFormat{Encoding: encodingUTF8}
+	var s string
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		f.Encoding = encodingUTF8BOM
+		s = string(data[len(bomUTF8):])
+	case bytes.HasPrefix(data, bomUTF16LE), bytes.HasPrefix(data, bomUTF16BE):
+		var order binary.ByteOrder = binary.LittleEndian
+		f.Encoding = encodingUTF16LE
+		if bytes.HasPrefix(data, bomUTF16BE) {
+			order, f.Encoding = binary.BigEndian, encodingUTF16BE
+		}
+		body := data[2:]
+		if len(body)%2 != 0 {
+			return "", f, errors.New("truncated UTF-16 file")
+		}
+		units := make([]uint16, len(body)/2)
+		for i := range units {
+			units[i] = order.Uint16(body[2*i:])
+		}
+		s = string(utf16.Decode(units))
+	default:
+		s = string(data)
+	}
+	if !utf8.ValidString(s) || strings.IndexByte(s, 0) >= 0 {
+		return "", f, errors.New("not a text file (binary, or an encoding other than UTF-8/UTF-16)")
+	}
+	// Files mixing CRLF and LF are kept byte for byte outside the edit
+	if crlf := strings.Count(s, "\r\n"); crlf > 0 && crlf == strings.Count(s, "\n") {
+		f.CRLF = true
+		s = strings.ReplaceAll(s, "\r\n", "\n")
+	}
+	return s, f, nil
+}
+
+// encodeText is the inverse of decodeText.
+func encodeText(s string, f textFormat) []byte {
+	if f.CRLF {
+		s = strings.ReplaceAll(toLF(s), "\n", "\r\n")
+	}
+	switch f.Encoding {
+	case encodingUTF8BOM:
+		return append(append([]byte(nil), bomUTF8...), s...)
+	case encodingUTF16LE, encodingUTF16BE:
+		var order binary.ByteOrder = binary.LittleEndian
+		bom := bomUTF16LE
+		if f.Encoding == encodingUTF16BE {
+			order, bom = binary.BigEndian, bomUTF16BE
+		}
+		units := utf16.Encode([]rune(s))
+		out := make([]byte, len(bom)+2*len(units))
+		copy(out, bom)
+		for i, u := range units {
+			order.PutUint16(out[len(bom)+2*i:], u)
+		}
+		return out
+	default:
+		return []byte(s)
+	}
+}
+
+func toLF(s string) string {
+	return strings.ReplaceAll(s, "\r\n", "\n")
+}
+
+// resolveWritePath returns the file edit tools read and write for path:
+// relative paths resolve against the workspace, and symlinks are followed.
+// Paths that leave the workspace, directly or through a symlink, are
+// rejected; a file that does not exist yet is checked by its directory.
+func resolveWritePath(path, workDir string) (string, error) {
+	abs := resolveReadPath(path, workDir)
+	real, err := evalExisting(abs)
+	if err != nil {
+		return "", err
+	}
+	if workDir == "" {
+		return real, nil
+	}
+	root, err := filepath.EvalSymlinks(workDir)
+	if err != nil {
+		return "", fmt.Errorf("workspace: %w", err)
+	}
+	if !withinDir(root, real) {
+		return "", fmt.Errorf("%s is outside the workspace (%s)", path, workDir)
+	}
+	return real, nil
+}
+
+// evalExisting resolves the symlinks of the longest existing prefix of path.
+func evalExisting(path string) (string, error) {
+	real, err := filepath.EvalSymlinks(path)
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		return real, err
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path, nil
+	}
+	realParent, err := evalExisting(parent)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(realParent, filepath.Base(path)), nil
+}
+
+// withinDir reports whether path is root or below it.
+func withinDir(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// newTextFileEdit is newFileEdit for a file rewritten by the edit tools:
+// the hunks show the decoded text, the hashes are of the bytes on disk.
+func newTextFileEdit(absPath string, beforeText *string, beforeRaw []byte, afterText string, afterRaw []byte) *entity.FileEdit {
+	edit := newFileEdit(absPath, beforeText, &afterText)
+	edit.BeforeHash = ""
+	if beforeRaw != nil {
+		edit.BeforeHash = contentHash(string(beforeRaw))
+	}
+	edit.AfterHash = contentHash(string(afterRaw))
+	return edit
+}
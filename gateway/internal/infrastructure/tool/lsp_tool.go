@@ -12,6 +12,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
 	"go.uber.org/zap"
@@ -19,8 +20,13 @@ import (
 
 // LSPTool wraps language servers (gopls, typescript-language-server, pylsp, rust-analyzer)
 // and exposes go-to-definition, find-references, hover, diagnostics, symbols via the Tool interface.
+//
+// Each file is served from its project root, the nearest directory above it
+// with a root marker (go.mod, tsconfig.json, Cargo.toml, ...). Every
+// language+root pair gets its own server, so a monorepo with several
+// modules gets correct diagnostics for each.
 type LSPTool struct {
-	servers       map[string]*lspServer // language -> running server
+	servers       map[string]*lspServer // serverKey(language, root) -> running server
 	mu            sync.Mutex
 	workspaceRoot string
 	logger        *zap.Logger
@@ -28,8 +34,11 @@ type LSPTool struct {
 
 // lspServer represents a running language server process.
 type lspServer struct {
+	lang             string
+	root             string // project root sent as rootUri / workspace folder
 	cmd              *exec.Cmd
 	stdin            io.WriteCloser
+	writeMu          sync.Mutex // serializes writes to stdin
 	reader           *bufio.Reader
 	reqID            int64 // atomic counter
 	mu               sync.Mutex
@@ -38,6 +47,32 @@ type lspServer struct {
 	diagMu           sync.RWMutex              // protects diagnosticsCache
 	pendingResp      chan *jsonrpcResponse      // responses forwarded by bg reader
 	stopBg           chan struct{}              // signal to stop background reader
+	exited           chan struct{}              // closed once the process has been waited for
+	lastUsed         atomic.Int64               // unix nanos of the last tool call
+}
+
+// write sends one JSON-RPC message to the server.
+func (s *lspServer) write(id int64, method string, params interface{}) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return writeJSONRPC(s.stdin, id, method, params)
+}
+
+// reply answers a request the server sent to us.
+func (s *lspServer) reply(id int64, result interface{}) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return writeJSONRPCResult(s.stdin, id, result)
+}
+
+// alive reports whether the server process is still running.
+func (s *lspServer) alive() bool {
+	select {
+	case <-s.exited:
+		return false
+	default:
+		return true
+	}
 }
 
 // NewLSPTool creates an LSP tool with a workspace root.
@@ -115,8 +150,8 @@ func (t *LSPTool) Execute(ctx context.Context, args map[string]interface{}) (*Re
 		return &Result{Output: fmt.Sprintf("unsupported file type: %s", filepath.Ext(filePath)), Success: false}, nil
 	}
 
-	// Get or start language server
-	srv, err := t.getOrStartServer(ctx, lang)
+	// Get or start the language server for the file's project root
+	srv, err := t.getOrStartServer(ctx, lang, t.projectRoot(filePath, lang))
 	if err != nil {
 		return &Result{
 			Output:  fmt.Sprintf("failed to start language server for %s: %s", lang, err.Error()),
@@ -158,19 +193,62 @@ func (t *LSPTool) Shutdown() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	for lang, srv := range t.servers {
-		t.logger.Info("Shutting down language server", zap.String("lang", lang))
-		// Stop background reader
-		close(srv.stopBg)
+	for _, srv := range t.servers {
+		t.logger.Info("Shutting down language server",
+			zap.String("lang", srv.lang),
+			zap.String("root", srv.root),
+		)
+		t.stopServer(srv)
+	}
+	t.servers = make(map[string]*lspServer)
+}
+
+// ReapServers drops language servers whose process has exited and shuts
+// down those unused for longer than idle (0 = keep idle servers). The next
+// call for that language and root starts a fresh server. Returns the number removed.
+func (t *LSPTool) ReapServers(idle time.Duration) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := 0
+	for key, srv := range t.servers {
+		unused := time.Since(time.Unix(0, srv.lastUsed.Load()))
+		switch {
+		case !srv.alive():
+			t.logger.Info("Removing dead language server",
+				zap.String("lang", srv.lang),
+				zap.String("root", srv.root),
+			)
+		case idle > 0 && unused > idle:
+			t.logger.Info("Shutting down idle language server",
+				zap.String("lang", srv.lang),
+				zap.String("root", srv.root),
+				zap.Duration("idle", unused),
+			)
+		default:
+			continue
+		}
+		t.stopServer(srv)
+		delete(t.servers, key)
+		n++
+	}
+	return n
+}
+
+// stopServer asks the server to exit and kills it (best-effort).
+// The caller holds t.mu and removes srv from t.servers.
+func (t *LSPTool) stopServer(srv *lspServer) {
+	// Stop background reader
+	close(srv.stopBg)
+	if srv.alive() {
 		// Send shutdown request (best-effort)
 		srv.mu.Lock()
 		id := atomic.AddInt64(&srv.reqID, 1)
-		_ = writeJSONRPC(srv.stdin, id, "shutdown", nil)
-		_ = writeJSONRPC(srv.stdin, 0, "exit", nil)
+		_ = srv.write(id, "shutdown", nil)
+		_ = srv.write(0, "exit", nil)
 		srv.mu.Unlock()
-		_ = srv.cmd.Process.Kill()
 	}
-	t.servers = make(map[string]*lspServer)
+	_ = srv.cmd.Process.Kill()
 }
 
 // --- LSP operations ---
@@ -263,17 +341,46 @@ func (t *LSPTool) doCompletion(srv *lspServer, uri string, line, col int) (*Resu
 
 // --- Server lifecycle ---
 
-func (t *LSPTool) getOrStartServer(ctx context.Context, lang string) (*lspServer, error) {
+// rootMarkers are the files that mark a project root, per language.
+var rootMarkers = map[string][]string{
+	"go":         {"go.mod"},
+	"typescript": {"tsconfig.json", "package.json"},
+	"javascript": {"jsconfig.json", "tsconfig.json", "package.json"},
+	"python":     {"pyproject.toml", "setup.py", "setup.cfg"},
+	"rust":       {"Cargo.toml"},
+}
+
+// projectRoot returns the nearest directory above filePath that holds a
+// root marker for lang, or the workspace root when there is none.
+func (t *LSPTool) projectRoot(filePath, lang string) string {
+	if root := findUpAny(filepath.Dir(filePath), rootMarkers[lang]); root != "" {
+		return root
+	}
+	if t.workspaceRoot != "" {
+		return t.workspaceRoot
+	}
+	return filepath.Dir(filePath)
+}
+
+// serverKey identifies the server for one language in one project root.
+func serverKey(lang, root string) string {
+	return lang + ":" + root
+}
+
+func (t *LSPTool) getOrStartServer(ctx context.Context, lang, root string) (*lspServer, error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if srv, ok := t.servers[lang]; ok {
+	key := serverKey(lang, root)
+	if srv, ok := t.servers[key]; ok {
 		// Check process is still alive
-		if srv.cmd.ProcessState == nil {
+		if srv.alive() {
+			srv.lastUsed.Store(time.Now().UnixNano())
 			return srv, nil
 		}
 		// Process exited, remove and restart
-		delete(t.servers, lang)
+		close(srv.stopBg)
+		delete(t.servers, key)
 	}
 
 	cmdName, cmdArgs := languageServerCommand(lang)
@@ -286,7 +393,10 @@ func (t *LSPTool) getOrStartServer(ctx context.Context, lang string) (*lspServer
 		return nil, fmt.Errorf("language server binary not found: %s (install with: %s)", cmdName, installHint(lang))
 	}
 
-	cmd := exec.CommandContext(ctx, cmdName, cmdArgs...)
+	// Not bound to ctx: the server outlives the tool call that started it
+	// and is stopped by Shutdown or ReapServers.
+	cmd := exec.Command(cmdName, cmdArgs...)
+	cmd.Dir = root
 	cmd.Env = append(os.Environ(), "GOPATH="+os.Getenv("GOPATH"))
 
 	stdin, err := cmd.StdinPipe()
@@ -307,6 +417,8 @@ func (t *LSPTool) getOrStartServer(ctx context.Context, lang string) (*lspServer
 	}
 
 	srv := &lspServer{
+		lang:             lang,
+		root:             root,
 		cmd:              cmd,
 		stdin:            stdin,
 		reader:           bufio.NewReaderSize(stdout, 1024*1024), // 1MB buffer
@@ -314,13 +426,22 @@ func (t *LSPTool) getOrStartServer(ctx context.Context, lang string) (*lspServer
 		diagnosticsCache: make(map[string]json.RawMessage),
 		pendingResp:      make(chan *jsonrpcResponse, 64),
 		stopBg:           make(chan struct{}),
+		exited:           make(chan struct{}),
 	}
+	srv.lastUsed.Store(time.Now().UnixNano())
+
+	// Reap the process when it exits so dead servers are detected
+	go func() {
+		_ = cmd.Wait()
+		close(srv.exited)
+	}()
 
 	// Start background reader that continuously consumes notifications
 	go t.backgroundReader(srv)
 
 	t.logger.Info("Started language server",
 		zap.String("lang", lang),
+		zap.String("root", root),
 		zap.String("cmd", cmdName),
 		zap.Int("pid", cmd.Process.Pid),
 	)
@@ -331,15 +452,25 @@ func (t *LSPTool) getOrStartServer(ctx context.Context, lang string) (*lspServer
 		return nil, fmt.Errorf("initialize handshake failed: %w", err)
 	}
 
-	t.servers[lang] = srv
+	t.servers[key] = srv
 	return srv, nil
 }
 
+// workspaceFolders is the folder list sent in initialize and returned for
+// workspace/workspaceFolders: the server's own project root.
+func (s *lspServer) workspaceFolders() []map[string]string {
+	return []map[string]string{{"uri": pathToURI(s.root), "name": filepath.Base(s.root)}}
+}
+
 func (t *LSPTool) initialize(srv *lspServer) error {
 	initParams := map[string]interface{}{
-		"processId": os.Getpid(),
-		"rootUri":   pathToURI(t.workspaceRoot),
+		"processId":        os.Getpid(),
+		"rootUri":          pathToURI(srv.root),
+		"workspaceFolders": srv.workspaceFolders(),
 		"capabilities": map[string]interface{}{
+			"workspace": map[string]interface{}{
+				"workspaceFolders": true,
+			},
 			"textDocument": map[string]interface{}{
 				"definition":     map[string]interface{}{},
 				"references":     map[string]interface{}{},
@@ -357,9 +488,7 @@ func (t *LSPTool) initialize(srv *lspServer) error {
 	}
 
 	// Send initialized notification
-	srv.mu.Lock()
-	defer srv.mu.Unlock()
-	return writeJSONRPC(srv.stdin, 0, "initialized", map[string]interface{}{})
+	return srv.write(0, "initialized", map[string]interface{}{})
 }
 
 func (t *LSPTool) ensureOpened(srv *lspServer, filePath, lang string) error {
@@ -386,7 +515,7 @@ func (t *LSPTool) ensureOpened(srv *lspServer, filePath, lang string) error {
 		},
 	}
 
-	if err := writeJSONRPC(srv.stdin, 0, "textDocument/didOpen", params); err != nil {
+	if err := srv.write(0, "textDocument/didOpen", params); err != nil {
 		return err
 	}
 	srv.opened[uri] = true
@@ -435,6 +564,24 @@ func writeJSONRPC(w io.Writer, id int64, method string, params interface{}) erro
 	return err
 }
 
+// writeJSONRPCResult writes the response to a request sent by the server.
+func writeJSONRPCResult(w io.Writer, id int64, result interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"result":  result,
+	})
+	if err != nil {
+		return err
+	}
+	header := fmt.Sprintf("Content-Length: %d\r\n\r\n", len(body))
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
 func readJSONRPC(r *bufio.Reader) (*jsonrpcResponse, error) {
 	// Read headers
 	var contentLen int
@@ -474,7 +621,7 @@ func (t *LSPTool) sendRequest(srv *lspServer, method string, params interface{})
 	defer srv.mu.Unlock()
 
 	id := atomic.AddInt64(&srv.reqID, 1)
-	if err := writeJSONRPC(srv.stdin, id, method, params); err != nil {
+	if err := srv.write(id, method, params); err != nil {
 		return nil, fmt.Errorf("write request: %w", err)
 	}
 
@@ -523,6 +670,12 @@ func (t *LSPTool) backgroundReader(srv *lspServer) {
 			continue
 		}
 
+		// Requests from the server (workspace/workspaceFolders, ...) carry a method
+		if resp.Method != "" {
+			t.handleServerRequest(srv, resp)
+			continue
+		}
+
 		// Forward response to request handler
 		select {
 		case srv.pendingResp <- resp:
@@ -562,6 +715,26 @@ func (t *LSPTool) handleNotification(srv *lspServer, resp *jsonrpcResponse) {
 	}
 }
 
+// handleServerRequest answers a request sent by the language server. Only
+// workspace folders are known; everything else gets an empty result so the
+// server does not wait on us.
+func (t *LSPTool) handleServerRequest(srv *lspServer, req *jsonrpcResponse) {
+	var result interface{}
+	switch req.Method {
+	case "workspace/workspaceFolders":
+		result = srv.workspaceFolders()
+	case "workspace/configuration":
+		var params struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		result = make([]interface{}, len(params.Items)) // no settings for any section
+	}
+	if err := srv.reply(req.ID, result); err != nil && t.logger != nil {
+		t.logger.Debug("LSP reply failed", zap.String("method", req.Method), zap.Error(err))
+	}
+}
+
 // --- Formatting helpers ---
 
 func (t *LSPTool) formatLocations(label string, raw json.RawMessage) (*Result, error) {
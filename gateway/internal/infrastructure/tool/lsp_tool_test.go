@@ -4,7 +4,12 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"os/exec"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"go.uber.org/zap"
 )
 
 func TestDetectLanguage(t *testing.T) {
@@ -176,3 +181,111 @@ func TestFormatLocations_Array(t *testing.T) {
 		t.Error("output should not be empty")
 	}
 }
+
+// startFakeLSPServer runs a process standing in for a language server.
+func startFakeLSPServer(t *testing.T) *lspServer {
+	t.Helper()
+	cmd := exec.Command("sleep", "30")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skip("sleep not available")
+	}
+	srv := &lspServer{cmd: cmd, stdin: stdin, stopBg: make(chan struct{}), exited: make(chan struct{})}
+	srv.lastUsed.Store(time.Now().UnixNano())
+	go func() {
+		_ = cmd.Wait()
+		close(srv.exited)
+	}()
+	t.Cleanup(func() { _ = cmd.Process.Kill() })
+	return srv
+}
+
+func TestLSPTool_ReapServers(t *testing.T) {
+	lsp := NewLSPTool(t.TempDir(), zap.NewNop())
+	active, idle, dead := startFakeLSPServer(t), startFakeLSPServer(t), startFakeLSPServer(t)
+	idle.lastUsed.Store(time.Now().Add(-2 * time.Hour).UnixNano())
+	_ = dead.cmd.Process.Kill()
+	<-dead.exited
+	lsp.servers = map[string]*lspServer{"go": active, "python": idle, "rust": dead}
+
+	if n := lsp.ReapServers(0); n != 1 {
+		t.Errorf("idle disabled: reaped %d, want only the dead server", n)
+	}
+	if n := lsp.ReapServers(time.Hour); n != 1 {
+		t.Errorf("reaped %d, want the idle server", n)
+	}
+	if _, ok := lsp.servers["go"]; !ok || len(lsp.servers) != 1 {
+		t.Errorf("servers left = %v, want only go", lsp.servers)
+	}
+	select {
+	case <-idle.exited:
+	case <-time.After(5 * time.Second):
+		t.Error("idle server process was not stopped")
+	}
+}
+
+func TestLSPTool_ProjectRoot(t *testing.T) {
+	ws := t.TempDir()
+	writeTree(t, ws, map[string]string{
+		"svc/go.mod":            "module example.com/svc\n",
+		"svc/internal/a/a.go":   "package a\n",
+		"tools/go.mod":          "module example.com/tools\n",
+		"tools/main.go":         "package main\n",
+		"web/tsconfig.json":     "{}",
+		"web/src/app.ts":        "",
+		"scripts/run.py":        "",
+		"web/src/legacy/old.py": "",
+	})
+	lsp := NewLSPTool(ws, zap.NewNop())
+
+	tests := []struct {
+		file, lang, want string
+	}{
+		{"svc/internal/a/a.go", "go", "svc"},
+		{"tools/main.go", "go", "tools"},
+		{"web/src/app.ts", "typescript", "web"},
+		{"scripts/run.py", "python", "."}, // no marker: workspace root
+		{"web/src/legacy/old.py", "python", "."},
+	}
+	for _, tt := range tests {
+		got := lsp.projectRoot(filepath.Join(ws, tt.file), tt.lang)
+		if want := filepath.Join(ws, tt.want); got != want {
+			t.Errorf("projectRoot(%s) = %s, want %s", tt.file, got, want)
+		}
+	}
+	if serverKey("go", filepath.Join(ws, "svc")) == serverKey("go", filepath.Join(ws, "tools")) {
+		t.Error("two module roots share a server")
+	}
+}
+
+type bufferCloser struct{ bytes.Buffer }
+
+func (*bufferCloser) Close() error { return nil }
+
+func TestLSPTool_HandleServerRequest(t *testing.T) {
+	out := &bufferCloser{}
+	srv := &lspServer{root: "/repo/svc", stdin: out}
+	lsp := NewLSPTool("/repo", zap.NewNop())
+
+	lsp.handleServerRequest(srv, &jsonrpcResponse{ID: 7, Method: "workspace/workspaceFolders"})
+	lsp.handleServerRequest(srv, &jsonrpcResponse{ID: 8, Method: "workspace/configuration", Params: json.RawMessage(`{"items":[{"section":"gopls"},{}]}`)})
+
+	r := bufio.NewReader(&out.Buffer)
+	folders, err := readJSONRPC(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if folders.ID != 7 || string(folders.Result) != `[{"name":"svc","uri":"file:///repo/svc"}]` {
+		t.Errorf("workspaceFolders reply = %d %s", folders.ID, folders.Result)
+	}
+	cfg, err := readJSONRPC(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ID != 8 || string(cfg.Result) != `[null,null]` {
+		t.Errorf("configuration reply = %d %s", cfg.ID, cfg.Result)
+	}
+}
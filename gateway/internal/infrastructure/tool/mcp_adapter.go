@@ -1,15 +1,15 @@
 package tool
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
 	"go.uber.org/zap"
 )
 
@@ -20,26 +20,47 @@ type MCPToolDef struct {
 	InputSchema map[string]interface{} `json:"inputSchema"`
 }
 
-// MCPAdapter 将外部 MCP Server 的工具接入 ToolExecutor
+// MCPAdapter 将外部 MCP Server 的工具接入 ToolExecutor.
+//
+// 首次调用时连接并完成 initialize 握手; 会话过期或事件流断开后自动重连,
+// 重连成功及收到 notifications/tools/list_changed 时调用 onToolsChanged.
 type MCPAdapter struct {
-	name     string // MCP Server 名称
-	endpoint string // MCP Server 地址
-	client   *http.Client
-	logger   *zap.Logger
-	tools    []MCPToolDef
-	mu       sync.RWMutex
+	name      string // MCP Server 名称
+	namespace string // 工具名前缀, 空 = name
+	endpoint  string // MCP Server 地址
+	transport mcpTransport
+	logger    *zap.Logger
+	tools     []MCPToolDef
+	mu        sync.RWMutex
+
+	connMu         sync.Mutex
+	connected      bool
+	reconnecting   bool
+	closed         bool
+	onToolsChanged func()
+	registered     []string // 已注册到 registry 的工具名
 }
 
-// NewMCPAdapter 创建 MCP 适配器
+// NewMCPAdapter 创建 MCP 适配器 (streamable HTTP, 无认证)
 func NewMCPAdapter(name, endpoint string, logger *zap.Logger) *MCPAdapter {
-	return &MCPAdapter{
-		name:     name,
-		endpoint: endpoint,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		logger: logger,
+	a, _ := NewMCPAdapterFromEntry(config.MCPServerEntry{Name: name, Endpoint: endpoint}, logger)
+	return a
+}
+
+// NewMCPAdapterFromEntry 按 mcp.json 中的条目创建 MCP 适配器
+func NewMCPAdapterFromEntry(entry config.MCPServerEntry, logger *zap.Logger) (*MCPAdapter, error) {
+	a := &MCPAdapter{
+		name:      entry.Name,
+		namespace: entry.Namespace,
+		endpoint:  entry.Endpoint,
+		logger:    logger,
+	}
+	transport, err := newMCPTransport(entry, mcpHooks{onMessage: a.handleMessage, onClose: a.handleClose})
+	if err != nil {
+		return nil, err
 	}
+	a.transport = transport
+	return a, nil
 }
 
 // ─────────────────── JSON-RPC 2.0 ───────────────────
@@ -51,18 +72,37 @@ type jsonRPCRequest struct {
 	Params  interface{} `json:"params,omitempty"`
 }
 
-type jsonRPCResponse struct {
+type jsonRPCNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// jsonRPCMessage 服务器发来的消息: 响应 (ID + Result/Error), 通知 (Method)
+// 或服务器发起的请求 (ID + Method)
+type jsonRPCMessage struct {
 	JSONRPC string          `json:"jsonrpc"`
-	ID      int             `json:"id"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
 	Result  json.RawMessage `json:"result,omitempty"`
 	Error   *jsonRPCError   `json:"error,omitempty"`
 }
 
+func (m *jsonRPCMessage) isResponseTo(id int) bool {
+	var got int
+	return m.Method == "" && json.Unmarshal(m.ID, &got) == nil && got == id
+}
+
 type jsonRPCError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 }
 
+func (e *jsonRPCError) Error() string {
+	return fmt.Sprintf("MCP RPC error %d: %s", e.Code, e.Message)
+}
+
 // ─────────────────── 核心方法 ───────────────────
 
 // DiscoverTools 连接 MCP Server, 发现可用工具
@@ -147,6 +187,38 @@ func (a *MCPAdapter) Name() string {
 	return a.name
 }
 
+// Namespace 返回工具名前缀
+func (a *MCPAdapter) Namespace() string {
+	if a.namespace == "" {
+		return a.name
+	}
+	return a.namespace
+}
+
+// Close 断开连接, 不再重连
+func (a *MCPAdapter) Close() {
+	a.connMu.Lock()
+	a.closed = true
+	a.connected = false
+	a.connMu.Unlock()
+	if a.transport != nil {
+		a.transport.close()
+	}
+}
+
+// registeredTools 返回已注册到 registry 的工具名
+func (a *MCPAdapter) registeredTools() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return append([]string(nil), a.registered...)
+}
+
+func (a *MCPAdapter) setRegistered(names []string) {
+	a.mu.Lock()
+	a.registered = names
+	a.mu.Unlock()
+}
+
 // ─────────────────── JSON-RPC 传输层 ───────────────────
 
 var rpcIDCounter int
@@ -159,44 +231,153 @@ func nextRPCID() int {
 	return rpcIDCounter
 }
 
+// mcpProtocolVersion 客户端请求的 MCP 协议版本
+const mcpProtocolVersion = "2025-03-26"
+
+// call 发送请求; 连接失效时重连并重试一次
 func (a *MCPAdapter) call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
-	reqBody := jsonRPCRequest{
+	if err := a.ensureConnected(ctx); err != nil {
+		return nil, err
+	}
+	result, err := a.send(ctx, method, params)
+	if errors.Is(err, errMCPDisconnected) {
+		a.markDisconnected()
+		if err := a.ensureConnected(ctx); err != nil {
+			return nil, err
+		}
+		result, err = a.send(ctx, method, params)
+	}
+	return result, err
+}
+
+func (a *MCPAdapter) send(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	resp, err := a.transport.roundTrip(ctx, jsonRPCRequest{
 		JSONRPC: "2.0",
 		ID:      nextRPCID(),
 		Method:  method,
 		Params:  params,
-	}
-
-	body, err := json.Marshal(reqBody)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal JSON-RPC request: %w", err)
+		return nil, err
 	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint, bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	if resp.Error != nil {
+		return nil, resp.Error
 	}
-	req.Header.Set("Content-Type", "application/json")
+	return resp.Result, nil
+}
 
-	resp, err := a.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("MCP HTTP request failed: %w", err)
+// ensureConnected 未连接时建立连接并完成 initialize 握手
+func (a *MCPAdapter) ensureConnected(ctx context.Context) error {
+	a.connMu.Lock()
+	defer a.connMu.Unlock()
+	if a.closed {
+		return fmt.Errorf("MCP server %s is closed", a.name)
+	}
+	if a.connected {
+		return nil
+	}
+	if err := a.transport.connect(ctx); err != nil {
+		return err
+	}
+	_, err := a.send(ctx, "initialize", map[string]interface{}{
+		"protocolVersion": mcpProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]interface{}{"name": "ngoclaw", "version": "1.0"},
+	})
+	switch {
+	case isLegacyInitError(err):
+		// 不支持 initialize 的旧式 JSON-RPC 服务器: 直接调用
+		a.logger.Debug("MCP server does not support initialize", zap.String("server", a.name), zap.Error(err))
+	case err != nil:
+		return err
+	default:
+		if err := a.transport.notify(ctx, "notifications/initialized", nil); err != nil {
+			return err
+		}
 	}
-	defer resp.Body.Close()
+	a.connected = true
+	return nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("MCP server returned status %d: %s", resp.StatusCode, string(respBody))
+// isLegacyInitError initialize 被拒绝 (RPC 错误或 400/404/405 等), 而不是
+// 连接、认证失败
+func isLegacyInitError(err error) bool {
+	var rpcErr *jsonRPCError
+	var statusErr *mcpStatusError
+	switch {
+	case errors.As(err, &rpcErr):
+		return true
+	case errors.As(err, &statusErr):
+		return statusErr.Code >= 400 && statusErr.Code < 500 &&
+			statusErr.Code != http.StatusUnauthorized && statusErr.Code != http.StatusForbidden
 	}
+	return false
+}
+
+func (a *MCPAdapter) markDisconnected() {
+	a.connMu.Lock()
+	a.connected = false
+	a.connMu.Unlock()
+}
 
-	var rpcResp jsonRPCResponse
-	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
-		return nil, fmt.Errorf("failed to decode JSON-RPC response: %w", err)
+// handleMessage 处理服务器主动发来的消息
+func (a *MCPAdapter) handleMessage(msg *jsonRPCMessage) {
+	if msg.Method == "notifications/tools/list_changed" {
+		a.logger.Info("MCP tool list changed", zap.String("server", a.name))
+		if a.onToolsChanged != nil {
+			go a.onToolsChanged()
+		}
 	}
+}
 
-	if rpcResp.Error != nil {
-		return nil, fmt.Errorf("MCP RPC error %d: %s", rpcResp.Error.Code, rpcResp.Error.Message)
+// handleClose 事件流意外断开: 后台按退避重连, 成功后重新发现工具
+func (a *MCPAdapter) handleClose(err error) {
+	a.connMu.Lock()
+	a.connected = false
+	if a.closed || a.reconnecting {
+		a.connMu.Unlock()
+		return
 	}
+	a.reconnecting = true
+	a.connMu.Unlock()
+
+	a.logger.Warn("MCP connection lost, reconnecting", zap.String("server", a.name), zap.Error(err))
+	go a.reconnectLoop()
+}
 
-	return rpcResp.Result, nil
+func (a *MCPAdapter) reconnectLoop() {
+	defer func() {
+		a.connMu.Lock()
+		a.reconnecting = false
+		a.connMu.Unlock()
+	}()
+	backoff := mcpReconnectMin
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		err := a.ensureConnected(ctx)
+		cancel()
+		if err == nil {
+			a.logger.Info("MCP server reconnected", zap.String("server", a.name))
+			if a.onToolsChanged != nil {
+				a.onToolsChanged()
+			}
+			return
+		}
+		a.connMu.Lock()
+		closed := a.closed
+		a.connMu.Unlock()
+		if closed {
+			return
+		}
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > mcpReconnectMax {
+			backoff = mcpReconnectMax
+		}
+	}
 }
+
+// 重连退避区间
+var (
+	mcpReconnectMin = time.Second
+	mcpReconnectMax = 30 * time.Second
+)
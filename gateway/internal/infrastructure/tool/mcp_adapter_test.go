@@ -0,0 +1,346 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+// fakeMCP 测试用 MCP 服务器: 实现 initialize / tools/list / tools/call
+type fakeMCP struct {
+	mu          sync.Mutex
+	tools       []string
+	initialized int
+}
+
+func (f *fakeMCP) handle(req map[string]interface{}) (result interface{}, isNotification bool) {
+	method, _ := req["method"].(string)
+	if _, ok := req["id"]; !ok {
+		return nil, true
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch method {
+	case "initialize":
+		f.initialized++
+		return map[string]interface{}{"protocolVersion": mcpProtocolVersion, "capabilities": map[string]interface{}{}}, false
+	case "tools/list":
+		var tools []map[string]interface{}
+		for _, name := range f.tools {
+			tools = append(tools, map[string]interface{}{"name": name, "description": name + " tool"})
+		}
+		return map[string]interface{}{"tools": tools}, false
+	case "tools/call":
+		params, _ := req["params"].(map[string]interface{})
+		return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": fmt.Sprintf("called %v", params["name"])}}}, false
+	}
+	return nil, false
+}
+
+func (f *fakeMCP) setTools(names ...string) {
+	f.mu.Lock()
+	f.tools = names
+	f.mu.Unlock()
+}
+
+func (f *fakeMCP) initCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.initialized
+}
+
+func rpcResult(id interface{}, result interface{}) string {
+	data, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": id, "result": result})
+	return string(data)
+}
+
+func writeSSE(w http.ResponseWriter, event, data string) {
+	if event != "" {
+		fmt.Fprintf(w, "event: %s\n", event)
+	}
+	fmt.Fprintf(w, "data: %s\n\n", data)
+	w.(http.Flusher).Flush()
+}
+
+// streamableServer streamable HTTP 服务器: 会话 ID、OAuth 校验, tools/call
+// 以 SSE 响应, GET 事件流用于推送通知
+func streamableServer(t *testing.T, mcp *fakeMCP, token string) (*httptest.Server, chan string) {
+	notify := make(chan string, 4)
+	var session atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "cid" || secret != "csecret" || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": token, "expires_in": 3600})
+	})
+	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		current := fmt.Sprintf("s%d", session.Load())
+		if r.Method == http.MethodGet {
+			if r.Header.Get("Mcp-Session-Id") != current {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.(http.Flusher).Flush()
+			for {
+				select {
+				case method := <-notify:
+					writeSSE(w, "", `{"jsonrpc":"2.0","method":"`+method+`"}`)
+				case <-r.Context().Done():
+					return
+				}
+			}
+		}
+
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode: %v", err)
+			return
+		}
+		if req["method"] == "initialize" {
+			w.Header().Set("Mcp-Session-Id", fmt.Sprintf("s%d", session.Add(1)))
+		} else if r.Header.Get("Mcp-Session-Id") != current {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		result, isNotification := mcp.handle(req)
+		if isNotification {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		if req["method"] == "tools/call" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			writeSSE(w, "", `{"jsonrpc":"2.0","method":"notifications/progress","params":{}}`)
+			writeSSE(w, "", rpcResult(req["id"], result))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, rpcResult(req["id"], result))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, notify
+}
+
+func TestMCPManager_StreamableHTTP(t *testing.T) {
+	mcp := &fakeMCP{tools: []string{"search"}}
+	srv, notify := streamableServer(t, mcp, "tok-1")
+
+	reg := domaintool.NewInMemoryRegistry()
+	m := NewMCPManager(filepath.Join(t.TempDir(), "mcp.json"), reg, zap.NewNop())
+	defer m.Close()
+	err := m.AddServer(config.MCPServerEntry{
+		Name:      "docs",
+		Endpoint:  srv.URL + "/mcp",
+		Namespace: "kb",
+		OAuth:     &config.MCPOAuthConfig{TokenURL: srv.URL + "/token", ClientID: "cid", ClientSecret: "csecret"},
+	})
+	if err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+
+	tool, ok := reg.Get("kb_search")
+	if !ok {
+		t.Fatalf("kb_search not registered: %v", reg.List())
+	}
+	res, err := tool.Execute(context.Background(), map[string]interface{}{"q": "x"})
+	if err != nil || !res.Success || res.Output != "called search" {
+		t.Fatalf("Execute = %+v, %v", res, err)
+	}
+
+	// 服务器推送 list_changed → 重新发现工具
+	mcp.setTools("search", "fetch")
+	notify <- "notifications/tools/list_changed"
+	waitFor(t, func() bool { return reg.Has("kb_fetch") })
+
+	servers := m.ListServers()
+	if len(servers) != 1 || servers[0].Transport != "http" || servers[0].Namespace != "kb" || servers[0].ToolCount != 2 {
+		t.Fatalf("ListServers = %+v", servers)
+	}
+
+	if err := m.RemoveServer("docs"); err != nil {
+		t.Fatal(err)
+	}
+	if reg.Has("kb_search") || reg.Has("kb_fetch") {
+		t.Fatal("tools still registered after RemoveServer")
+	}
+}
+
+func TestMCPAdapter_ReinitializesExpiredSession(t *testing.T) {
+	mcp := &fakeMCP{tools: []string{"search"}}
+	srv, _ := streamableServer(t, mcp, "static")
+
+	a, err := NewMCPAdapterFromEntry(config.MCPServerEntry{
+		Name:     "docs",
+		Endpoint: srv.URL + "/mcp",
+		OAuth:    &config.MCPOAuthConfig{Token: "static"},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if _, err := a.DiscoverTools(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 服务器不再认识本会话 (重启等), 请求收到 404
+	a.transport.(*mcpHTTPTransport).mu.Lock()
+	a.transport.(*mcpHTTPTransport).sessionID = "stale"
+	a.transport.(*mcpHTTPTransport).mu.Unlock()
+
+	out, err := a.CallTool(context.Background(), "search", nil)
+	if err != nil || out != "called search" {
+		t.Fatalf("CallTool = %q, %v", out, err)
+	}
+	if n := mcp.initCount(); n != 2 {
+		t.Fatalf("initialize count = %d, want 2", n)
+	}
+}
+
+func TestMCPAdapter_LegacyJSONRPCServer(t *testing.T) {
+	// 不支持 initialize 的旧式服务器
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req["method"] != "tools/list" {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%v,"error":{"code":-32601,"message":"method not found"}}`, req["id"])
+			return
+		}
+		fmt.Fprint(w, rpcResult(req["id"], map[string]interface{}{"tools": []map[string]string{{"name": "get_news"}}}))
+	}))
+	defer srv.Close()
+
+	a := NewMCPAdapter("newsnow", srv.URL, zap.NewNop())
+	defer a.Close()
+	tools, err := a.DiscoverTools(context.Background())
+	if err != nil || len(tools) != 1 {
+		t.Fatalf("DiscoverTools = %v, %v", tools, err)
+	}
+	if name := NewMCPTool(a, tools[0], zap.NewNop()).Name(); name != "newsnow_get_news" {
+		t.Fatalf("Name = %q", name)
+	}
+}
+
+// sseServer 旧版 HTTP+SSE 服务器; 关闭 drop 时断开当前事件流
+func sseServer(t *testing.T, mcp *fakeMCP) (*httptest.Server, chan struct{}) {
+	drop := make(chan struct{}, 1)
+	var mu sync.Mutex
+	streams := map[string]chan string{}
+	var n atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		id := fmt.Sprint(n.Add(1))
+		ch := make(chan string, 8)
+		mu.Lock()
+		streams[id] = ch
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/event-stream")
+		writeSSE(w, "endpoint", "/messages?session="+id)
+		for {
+			select {
+			case msg := <-ch:
+				writeSSE(w, "message", msg)
+			case <-drop:
+				return
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	mux.HandleFunc("/messages", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ch, ok := streams[r.URL.Query().Get("session")]
+		mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		w.WriteHeader(http.StatusAccepted)
+		if result, isNotification := mcp.handle(req); !isNotification {
+			ch <- rpcResult(req["id"], result)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, drop
+}
+
+func TestMCPAdapter_SSETransportReconnects(t *testing.T) {
+	oldMin := mcpReconnectMin
+	mcpReconnectMin = 10 * time.Millisecond
+	defer func() { mcpReconnectMin = oldMin }()
+
+	mcp := &fakeMCP{tools: []string{"search"}}
+	srv, drop := sseServer(t, mcp)
+
+	a, err := NewMCPAdapterFromEntry(config.MCPServerEntry{Name: "legacy", Endpoint: srv.URL + "/sse", Transport: "sse"}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	var changed atomic.Int32
+	a.onToolsChanged = func() { changed.Add(1) }
+
+	out, err := a.CallTool(context.Background(), "search", nil)
+	if err != nil || out != "called search" {
+		t.Fatalf("CallTool = %q, %v", out, err)
+	}
+
+	drop <- struct{}{}
+	waitFor(t, func() bool { return changed.Load() == 1 })
+	if n := mcp.initCount(); n != 2 {
+		t.Fatalf("initialize count = %d, want 2", n)
+	}
+	out, err = a.CallTool(context.Background(), "search", nil)
+	if err != nil || out != "called search" {
+		t.Fatalf("CallTool after reconnect = %q, %v", out, err)
+	}
+}
+
+func TestMCPManager_NamespaceConflicts(t *testing.T) {
+	mcp := &fakeMCP{tools: []string{"search"}}
+	srv, _ := streamableServer(t, mcp, "t")
+	oauth := &config.MCPOAuthConfig{Token: "t"}
+
+	reg := domaintool.NewInMemoryRegistry()
+	m := NewMCPManager(filepath.Join(t.TempDir(), "mcp.json"), reg, zap.NewNop())
+	defer m.Close()
+	if err := m.AddServer(config.MCPServerEntry{Name: "a", Endpoint: srv.URL + "/mcp", OAuth: oauth}); err != nil {
+		t.Fatal(err)
+	}
+	err := m.AddServer(config.MCPServerEntry{Name: "b", Endpoint: srv.URL + "/mcp", Namespace: "a", OAuth: oauth})
+	if err == nil || !strings.Contains(err.Error(), "namespace") {
+		t.Fatalf("duplicate namespace err = %v", err)
+	}
+}
+
+func TestMCPToolName(t *testing.T) {
+	if got := mcpToolName("my.server", "get news/v2"); got != "my_server_get_news_v2" {
+		t.Fatalf("got %q", got)
+	}
+	long1 := mcpToolName("ns", strings.Repeat("x", 80)+"a")
+	long2 := mcpToolName("ns", strings.Repeat("x", 80)+"b")
+	if len(long1) != maxToolNameLen || long1 == long2 {
+		t.Fatalf("long names = %q, %q", long1, long2)
+	}
+}
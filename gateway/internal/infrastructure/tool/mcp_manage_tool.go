@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
 	"go.uber.org/zap"
 )
 
@@ -48,7 +49,16 @@ func (t *MCPManageTool) Schema() map[string]interface{} {
 			},
 			"endpoint": map[string]interface{}{
 				"type":        "string",
-				"description": "MCP server endpoint URL (required for add, e.g. http://host:port)",
+				"description": "MCP server endpoint URL (required for add, e.g. http://host:port/mcp)",
+			},
+			"transport": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"http", "sse"},
+				"description": "Transport for add: http (streamable HTTP, default) or sse (older HTTP+SSE servers)",
+			},
+			"namespace": map[string]interface{}{
+				"type":        "string",
+				"description": "Tool name prefix for add (default: name)",
 			},
 		},
 		"required": []string{"action"},
@@ -62,7 +72,14 @@ func (t *MCPManageTool) Execute(ctx context.Context, args map[string]interface{}
 
 	switch strings.ToLower(action) {
 	case "add":
-		return t.executeAdd(name, endpoint)
+		transport, _ := args["transport"].(string)
+		namespace, _ := args["namespace"].(string)
+		return t.executeAdd(config.MCPServerEntry{
+			Name:      name,
+			Endpoint:  endpoint,
+			Transport: transport,
+			Namespace: namespace,
+		})
 	case "remove":
 		return t.executeRemove(name)
 	case "list":
@@ -77,7 +94,8 @@ func (t *MCPManageTool) Execute(ctx context.Context, args map[string]interface{}
 	}
 }
 
-func (t *MCPManageTool) executeAdd(name, endpoint string) (*domaintool.Result, error) {
+func (t *MCPManageTool) executeAdd(entry config.MCPServerEntry) (*domaintool.Result, error) {
+	name, endpoint := entry.Name, entry.Endpoint
 	if name == "" || endpoint == "" {
 		return &domaintool.Result{
 			Output:  "Both 'name' and 'endpoint' are required for add action",
@@ -85,7 +103,7 @@ func (t *MCPManageTool) executeAdd(name, endpoint string) (*domaintool.Result, e
 		}, nil
 	}
 
-	if err := t.manager.AddServer(name, endpoint); err != nil {
+	if err := t.manager.AddServer(entry); err != nil {
 		return &domaintool.Result{
 			Output:  fmt.Sprintf("Failed to add MCP server '%s': %s", name, err),
 			Success: false,
@@ -95,20 +113,20 @@ func (t *MCPManageTool) executeAdd(name, endpoint string) (*domaintool.Result, e
 
 	// Get tool count
 	servers := t.manager.ListServers()
-	var toolCount int
+	var info MCPServerInfo
 	for _, s := range servers {
 		if s.Name == name {
-			toolCount = s.ToolCount
+			info = s
 			break
 		}
 	}
 
 	return &domaintool.Result{
 		Output: fmt.Sprintf("MCP server '%s' added successfully.\n"+
-			"Endpoint: %s\n"+
-			"Tools discovered: %d\n"+
+			"Endpoint: %s (%s)\n"+
+			"Tools discovered: %d (named %s_<tool>)\n"+
 			"Config saved to: ~/.ngoclaw/mcp.json",
-			name, endpoint, toolCount),
+			name, endpoint, info.Transport, info.ToolCount, info.Namespace),
 		Success: true,
 	}, nil
 }
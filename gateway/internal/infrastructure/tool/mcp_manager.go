@@ -17,6 +17,8 @@ import (
 type MCPServerInfo struct {
 	Name      string `json:"name"`
 	Endpoint  string `json:"endpoint"`
+	Transport string `json:"transport"`
+	Namespace string `json:"namespace"`
 	Enabled   bool   `json:"enabled"`
 	ToolCount int    `json:"tool_count"`
 }
@@ -29,6 +31,7 @@ type MCPManager struct {
 	registry   domaintool.Registry
 	logger     *zap.Logger
 	mu         sync.RWMutex
+	refreshMu  sync.Mutex // 串行化工具重新发现 (list_changed 通知可能并发到达)
 }
 
 // NewMCPManager creates a manager and loads existing servers from mcp.json.
@@ -60,7 +63,7 @@ func (m *MCPManager) InitFromConfig() {
 			continue
 		}
 
-		if err := m.addAndDiscover(ctx, srv.Name, srv.Endpoint); err != nil {
+		if err := m.addAndDiscover(ctx, srv); err != nil {
 			m.logger.Error("MCP server init failed",
 				zap.String("name", srv.Name),
 				zap.String("endpoint", srv.Endpoint),
@@ -72,23 +75,24 @@ func (m *MCPManager) InitFromConfig() {
 
 // AddServer adds a new MCP server, discovers its tools, registers them,
 // and persists the configuration to mcp.json. Hot-pluggable, no restart needed.
-func (m *MCPManager) AddServer(name, endpoint string) error {
+func (m *MCPManager) AddServer(entry config.MCPServerEntry) error {
 	m.mu.Lock()
-	if _, exists := m.adapters[name]; exists {
+	if _, exists := m.adapters[entry.Name]; exists {
 		m.mu.Unlock()
-		return fmt.Errorf("MCP server '%s' already exists", name)
+		return fmt.Errorf("MCP server '%s' already exists", entry.Name)
 	}
 	m.mu.Unlock()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
-	if err := m.addAndDiscover(ctx, name, endpoint); err != nil {
+	entry.Enabled = true
+	if err := m.addAndDiscover(ctx, entry); err != nil {
 		return err
 	}
 
 	// Persist
-	return m.persistAdd(name, endpoint)
+	return m.persistAdd(entry)
 }
 
 // RemoveServer unregisters all tools from a server and removes it from config.
@@ -101,8 +105,7 @@ func (m *MCPManager) RemoveServer(name string) error {
 	}
 
 	// Unregister all tools from this server
-	for _, def := range adapter.GetTools() {
-		toolName := fmt.Sprintf("%s_%s", name, def.Name)
+	for _, toolName := range adapter.registeredTools() {
 		if err := m.registry.Unregister(toolName); err != nil {
 			m.logger.Warn("Failed to unregister MCP tool",
 				zap.String("tool", toolName),
@@ -112,6 +115,7 @@ func (m *MCPManager) RemoveServer(name string) error {
 	}
 	delete(m.adapters, name)
 	m.mu.Unlock()
+	adapter.Close()
 
 	m.logger.Info("MCP server removed", zap.String("name", name))
 
@@ -132,8 +136,9 @@ func (m *MCPManager) ListServers() []MCPServerInfo {
 			infos = append(infos, MCPServerInfo{
 				Name:      name,
 				Endpoint:  adapter.endpoint,
+				Namespace: adapter.Namespace(),
 				Enabled:   true,
-				ToolCount: len(adapter.GetTools()),
+				ToolCount: len(adapter.registeredTools()),
 			})
 		}
 		return infos
@@ -142,20 +147,32 @@ func (m *MCPManager) ListServers() []MCPServerInfo {
 	var infos []MCPServerInfo
 	for _, srv := range cfg.Servers {
 		info := MCPServerInfo{
-			Name:     srv.Name,
-			Endpoint: srv.Endpoint,
-			Enabled:  srv.Enabled,
+			Name:      srv.Name,
+			Endpoint:  srv.Endpoint,
+			Transport: srv.Transport,
+			Namespace: srv.Namespace,
+			Enabled:   srv.Enabled,
+		}
+		if info.Transport == "" {
+			info.Transport = "http"
+		}
+		if info.Namespace == "" {
+			info.Namespace = srv.Name
 		}
 		if adapter, ok := m.adapters[srv.Name]; ok {
-			info.ToolCount = len(adapter.GetTools())
+			info.ToolCount = len(adapter.registeredTools())
 		}
 		infos = append(infos, info)
 	}
 	return infos
 }
 
-// RefreshServer re-discovers tools for an existing server.
+// RefreshServer re-discovers tools for an existing server. Also called
+// when the server reports a tool list change or after a reconnect.
 func (m *MCPManager) RefreshServer(name string) error {
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+
 	m.mu.RLock()
 	adapter, exists := m.adapters[name]
 	m.mu.RUnlock()
@@ -165,10 +182,10 @@ func (m *MCPManager) RefreshServer(name string) error {
 	}
 
 	// Unregister old tools
-	for _, def := range adapter.GetTools() {
-		toolName := fmt.Sprintf("%s_%s", name, def.Name)
+	for _, toolName := range adapter.registeredTools() {
 		_ = m.registry.Unregister(toolName)
 	}
+	adapter.setRegistered(nil)
 
 	// Re-discover
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
@@ -186,27 +203,70 @@ func (m *MCPManager) RefreshServer(name string) error {
 	return nil
 }
 
+// Close disconnects all MCP servers.
+func (m *MCPManager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, adapter := range m.adapters {
+		adapter.Close()
+	}
+}
+
 // ── internal ──
 
-func (m *MCPManager) addAndDiscover(ctx context.Context, name, endpoint string) error {
-	adapter := NewMCPAdapter(name, endpoint, m.logger)
+func (m *MCPManager) addAndDiscover(ctx context.Context, entry config.MCPServerEntry) error {
+	if err := m.checkNamespace(entry); err != nil {
+		return err
+	}
+	adapter, err := NewMCPAdapterFromEntry(entry, m.logger)
+	if err != nil {
+		return err
+	}
+	name := entry.Name
+	adapter.onToolsChanged = func() {
+		if err := m.RefreshServer(name); err != nil {
+			m.logger.Warn("MCP tool refresh failed", zap.String("name", name), zap.Error(err))
+		}
+	}
+
+	m.refreshMu.Lock()
 	count, err := RegisterMCPTools(ctx, adapter, m.registry, m.logger)
 	if err != nil {
+		m.refreshMu.Unlock()
+		adapter.Close()
 		return fmt.Errorf("MCP discovery failed for %s: %w", name, err)
 	}
-
 	m.mu.Lock()
 	m.adapters[name] = adapter
 	m.mu.Unlock()
+	m.refreshMu.Unlock()
 
 	m.logger.Info("MCP server added",
 		zap.String("name", name),
-		zap.String("endpoint", endpoint),
+		zap.String("endpoint", entry.Endpoint),
+		zap.String("namespace", adapter.Namespace()),
 		zap.Int("tools", count),
 	)
 	return nil
 }
 
+// checkNamespace rejects a server whose tool-name prefix is already used by
+// another server, so their tools cannot shadow each other.
+func (m *MCPManager) checkNamespace(entry config.MCPServerEntry) error {
+	ns := entry.Namespace
+	if ns == "" {
+		ns = entry.Name
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for name, adapter := range m.adapters {
+		if name != entry.Name && adapter.Namespace() == ns {
+			return fmt.Errorf("MCP namespace '%s' is already used by server '%s'", ns, name)
+		}
+	}
+	return nil
+}
+
 func (m *MCPManager) loadConfig() (*config.MCPFileConfig, error) {
 	data, err := os.ReadFile(m.configPath)
 	if err != nil {
@@ -222,13 +282,9 @@ func (m *MCPManager) loadConfig() (*config.MCPFileConfig, error) {
 	return &cfg, nil
 }
 
-func (m *MCPManager) persistAdd(name, endpoint string) error {
+func (m *MCPManager) persistAdd(entry config.MCPServerEntry) error {
 	cfg := m.readOrCreateConfig()
-	cfg.Servers = append(cfg.Servers, config.MCPServerEntry{
-		Name:     name,
-		Endpoint: endpoint,
-		Enabled:  true,
-	})
+	cfg.Servers = append(cfg.Servers, entry)
 	return config.SaveMCPConfig(m.configPath, cfg)
 }
 
@@ -2,7 +2,10 @@ package tool
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"strings"
 
 	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
 	"go.uber.org/zap"
@@ -30,8 +33,29 @@ func NewMCPTool(adapter *MCPAdapter, def MCPToolDef, logger *zap.Logger) *MCPToo
 var _ domaintool.Tool = (*MCPTool)(nil)
 
 func (t *MCPTool) Name() string {
-	// Prefix with MCP server name to avoid collisions (e.g. "newsnow_get_news")
-	return fmt.Sprintf("%s_%s", t.adapter.Name(), t.toolDef.Name)
+	// Prefix with the server's namespace to avoid collisions (e.g. "newsnow_get_news")
+	return mcpToolName(t.adapter.Namespace(), t.toolDef.Name)
+}
+
+// maxToolNameLen is the longest function name the LLM APIs accept.
+const maxToolNameLen = 64
+
+// mcpToolName builds "<namespace>_<tool>", replacing characters that are not
+// allowed in function names. Names over 64 characters are shortened with a
+// hash suffix so that they stay distinct.
+func mcpToolName(namespace, tool string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return '_'
+	}, namespace+"_"+tool)
+	if len(name) > maxToolNameLen {
+		sum := sha256.Sum256([]byte(name))
+		name = name[:maxToolNameLen-9] + "_" + hex.EncodeToString(sum[:4])
+	}
+	return name
 }
 
 func (t *MCPTool) Description() string {
@@ -75,13 +99,15 @@ func (t *MCPTool) Execute(ctx context.Context, args map[string]interface{}) (*do
 
 // RegisterMCPTools discovers tools from an MCPAdapter and registers them
 // into the provided tool registry. Returns the count of registered tools.
+// Tools whose name is already taken (a builtin or another server's tool)
+// are skipped; set a namespace for the server to resolve the conflict.
 func RegisterMCPTools(ctx context.Context, adapter *MCPAdapter, registry domaintool.Registry, logger *zap.Logger) (int, error) {
 	tools, err := adapter.DiscoverTools(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("MCP discovery failed for %s: %w", adapter.Name(), err)
 	}
 
-	registered := 0
+	var names []string
 	for _, def := range tools {
 		mcpTool := NewMCPTool(adapter, def, logger)
 		if err := registry.Register(mcpTool); err != nil {
@@ -92,12 +118,13 @@ func RegisterMCPTools(ctx context.Context, adapter *MCPAdapter, registry domaint
 			)
 			continue
 		}
-		registered++
+		names = append(names, mcpTool.Name())
 		logger.Info("Registered MCP tool",
 			zap.String("name", mcpTool.Name()),
 			zap.String("description", def.Description),
 		)
 	}
+	adapter.setRegistered(names)
 
-	return registered, nil
+	return len(names), nil
 }
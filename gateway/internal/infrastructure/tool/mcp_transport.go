@@ -0,0 +1,598 @@
+package tool
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+)
+
+// errMCPDisconnected 连接或会话已失效, 需要重新连接并初始化
+var errMCPDisconnected = errors.New("MCP connection lost")
+
+// mcpStatusError MCP 服务器返回的非 2xx 状态
+type mcpStatusError struct {
+	Code int
+	Body string
+}
+
+func (e *mcpStatusError) Error() string {
+	return fmt.Sprintf("MCP server returned status %d: %s", e.Code, e.Body)
+}
+
+// mcpTransport MCP 传输层.
+//
+// 服务器主动发来的消息 (通知、请求) 交给 onMessage; 长连接意外断开时调用
+// onClose, 由 MCPAdapter 负责重连.
+type mcpTransport interface {
+	// connect 建立连接 (sse: 打开事件流并等待 POST 地址; http: 开始新会话)
+	connect(ctx context.Context) error
+	// roundTrip 发送请求并等待对应 id 的响应
+	roundTrip(ctx context.Context, req jsonRPCRequest) (*jsonRPCMessage, error)
+	// notify 发送通知 (无响应)
+	notify(ctx context.Context, method string, params interface{}) error
+	close()
+}
+
+// mcpHooks 传输层回调
+type mcpHooks struct {
+	onMessage func(*jsonRPCMessage)
+	onClose   func(error)
+}
+
+// newMCPTransport 按 entry.Transport 创建传输层
+func newMCPTransport(entry config.MCPServerEntry, hooks mcpHooks) (mcpTransport, error) {
+	auth := newMCPAuth(entry)
+	switch strings.ToLower(entry.Transport) {
+	case "", "http", "streamable-http", "streamable_http":
+		return &mcpHTTPTransport{
+			endpoint: entry.Endpoint,
+			client:   &http.Client{Timeout: 30 * time.Second},
+			stream:   &http.Client{},
+			auth:     auth,
+			hooks:    hooks,
+		}, nil
+	case "sse":
+		return &mcpSSETransport{
+			endpoint: entry.Endpoint,
+			client:   &http.Client{Timeout: 30 * time.Second},
+			stream:   &http.Client{},
+			auth:     auth,
+			hooks:    hooks,
+			pending:  make(map[int]chan *jsonRPCMessage),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown MCP transport %q (want http or sse)", entry.Transport)
+	}
+}
+
+// ─────────────────── Streamable HTTP ───────────────────
+
+// mcpHTTPTransport streamable HTTP 传输: 每条消息一个 POST, 响应是 JSON 或
+// SSE 流; 服务器通过 Mcp-Session-Id 维持会话, 并可通过 GET 事件流推送通知.
+// 不返回会话 ID 的旧式 JSON-RPC over HTTP 服务器同样适用.
+type mcpHTTPTransport struct {
+	endpoint string
+	client   *http.Client // 普通请求, 有超时
+	stream   *http.Client // GET 事件流, 无超时
+	auth     *mcpAuth
+	hooks    mcpHooks
+
+	mu           sync.Mutex
+	sessionID    string
+	listenCancel context.CancelFunc
+}
+
+func (t *mcpHTTPTransport) connect(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sessionID = ""
+	if t.listenCancel != nil {
+		t.listenCancel()
+		t.listenCancel = nil
+	}
+	return nil
+}
+
+// listen 初始化完成后打开 GET 事件流接收服务器通知; 服务器不支持时直接返回
+func (t *mcpHTTPTransport) listen() {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.mu.Lock()
+	if t.listenCancel != nil {
+		t.listenCancel()
+	}
+	t.listenCancel = cancel
+	session := t.sessionID
+	t.mu.Unlock()
+
+	go func() {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.endpoint, nil)
+		if err != nil {
+			return
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		if session != "" {
+			req.Header.Set("Mcp-Session-Id", session)
+		}
+		resp, err := t.auth.do(ctx, t.stream, req)
+		if err != nil {
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || !isEventStream(resp) {
+			return // 405 等: 服务器不提供通知流
+		}
+		err = readSSE(resp.Body, func(_, data string) bool {
+			if msg := parseMCPMessage(data); msg != nil {
+				t.hooks.onMessage(msg)
+			}
+			return true
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = io.EOF
+		}
+		t.hooks.onClose(err)
+	}()
+}
+
+func (t *mcpHTTPTransport) roundTrip(ctx context.Context, req jsonRPCRequest) (*jsonRPCMessage, error) {
+	resp, err := t.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if req.Method == "initialize" {
+		t.mu.Lock()
+		t.sessionID = resp.Header.Get("Mcp-Session-Id")
+		t.mu.Unlock()
+	}
+
+	if isEventStream(resp) {
+		var out *jsonRPCMessage
+		err := readSSE(resp.Body, func(_, data string) bool {
+			msg := parseMCPMessage(data)
+			if msg == nil {
+				return true
+			}
+			if msg.isResponseTo(req.ID) {
+				out = msg
+				return false
+			}
+			t.hooks.onMessage(msg)
+			return true
+		})
+		if out != nil {
+			return out, nil
+		}
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("MCP event stream ended before response: %w", err)
+	}
+
+	var msg jsonRPCMessage
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON-RPC response: %w", err)
+	}
+	return &msg, nil
+}
+
+func (t *mcpHTTPTransport) notify(ctx context.Context, method string, params interface{}) error {
+	resp, err := t.post(ctx, jsonRPCNotification{JSONRPC: "2.0", Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if method == "notifications/initialized" {
+		t.listen()
+	}
+	return nil
+}
+
+// post 发送一条 JSON-RPC 消息, 返回 2xx 响应
+func (t *mcpHTTPTransport) post(ctx context.Context, msg interface{}) (*http.Response, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON-RPC message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	t.mu.Lock()
+	session := t.sessionID
+	t.mu.Unlock()
+	if session != "" {
+		req.Header.Set("Mcp-Session-Id", session)
+	}
+
+	resp, err := t.auth.do(ctx, t.client, req)
+	if err != nil {
+		return nil, fmt.Errorf("MCP HTTP request failed: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound && session != "" {
+		// 会话过期 (服务器重启等), 需要重新初始化
+		resp.Body.Close()
+		return nil, fmt.Errorf("MCP session expired: %w", errMCPDisconnected)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &mcpStatusError{Code: resp.StatusCode, Body: string(respBody)}
+	}
+	return resp, nil
+}
+
+func (t *mcpHTTPTransport) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.listenCancel != nil {
+		t.listenCancel()
+		t.listenCancel = nil
+	}
+}
+
+// ─────────────────── HTTP+SSE (2024-11-05) ───────────────────
+
+// mcpSSETransport 旧版 HTTP+SSE 传输: GET 打开事件流, 第一个 "endpoint" 事件
+// 给出 POST 地址; 请求 POST 到该地址, 响应和通知都从事件流返回.
+type mcpSSETransport struct {
+	endpoint string
+	client   *http.Client
+	stream   *http.Client
+	auth     *mcpAuth
+	hooks    mcpHooks
+
+	mu      sync.Mutex
+	postURL string
+	cancel  context.CancelFunc
+	pending map[int]chan *jsonRPCMessage
+}
+
+func (t *mcpSSETransport) connect(ctx context.Context) error {
+	t.close()
+
+	streamCtx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, t.endpoint, nil)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := t.auth.do(ctx, t.stream, req)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("MCP SSE connect failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || !isEventStream(resp) {
+		defer resp.Body.Close()
+		cancel()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("MCP SSE connect returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	endpoint := make(chan string, 1)
+	go func() {
+		defer resp.Body.Close()
+		err := readSSE(resp.Body, func(event, data string) bool {
+			if event == "endpoint" {
+				select {
+				case endpoint <- strings.TrimSpace(data):
+				default:
+				}
+				return true
+			}
+			msg := parseMCPMessage(data)
+			if msg == nil {
+				return true
+			}
+			if msg.Method == "" {
+				t.deliver(msg)
+			} else {
+				t.hooks.onMessage(msg)
+			}
+			return true
+		})
+		if streamCtx.Err() != nil {
+			return // 主动关闭
+		}
+		t.mu.Lock()
+		t.failPendingLocked()
+		t.postURL = ""
+		t.mu.Unlock()
+		if err == nil {
+			err = io.EOF
+		}
+		t.hooks.onClose(err)
+	}()
+
+	select {
+	case ep := <-endpoint:
+		base, _ := url.Parse(t.endpoint)
+		ref, err := url.Parse(ep)
+		if err != nil || base == nil {
+			cancel()
+			return fmt.Errorf("invalid MCP SSE endpoint event %q", ep)
+		}
+		t.mu.Lock()
+		t.postURL = base.ResolveReference(ref).String()
+		t.cancel = cancel
+		t.mu.Unlock()
+		return nil
+	case <-ctx.Done():
+		cancel()
+		return fmt.Errorf("MCP SSE server sent no endpoint event: %w", ctx.Err())
+	}
+}
+
+// deliver 把响应交给等待中的请求
+func (t *mcpSSETransport) deliver(msg *jsonRPCMessage) {
+	var id int
+	if json.Unmarshal(msg.ID, &id) != nil {
+		return
+	}
+	t.mu.Lock()
+	ch, ok := t.pending[id]
+	delete(t.pending, id)
+	t.mu.Unlock()
+	if ok {
+		ch <- msg
+	}
+}
+
+func (t *mcpSSETransport) failPendingLocked() {
+	for id, ch := range t.pending {
+		close(ch)
+		delete(t.pending, id)
+	}
+}
+
+func (t *mcpSSETransport) roundTrip(ctx context.Context, req jsonRPCRequest) (*jsonRPCMessage, error) {
+	ch := make(chan *jsonRPCMessage, 1)
+	t.mu.Lock()
+	t.pending[req.ID] = ch
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, req.ID)
+		t.mu.Unlock()
+	}()
+
+	if err := t.post(ctx, req); err != nil {
+		return nil, err
+	}
+	select {
+	case msg, ok := <-ch:
+		if !ok {
+			return nil, errMCPDisconnected
+		}
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (t *mcpSSETransport) notify(ctx context.Context, method string, params interface{}) error {
+	return t.post(ctx, jsonRPCNotification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (t *mcpSSETransport) post(ctx context.Context, msg interface{}) error {
+	t.mu.Lock()
+	postURL := t.postURL
+	t.mu.Unlock()
+	if postURL == "" {
+		return errMCPDisconnected
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON-RPC message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, postURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.auth.do(ctx, t.client, req)
+	if err != nil {
+		return fmt.Errorf("MCP HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("MCP session expired: %w", errMCPDisconnected)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &mcpStatusError{Code: resp.StatusCode, Body: string(respBody)}
+	}
+	return nil
+}
+
+func (t *mcpSSETransport) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+	t.postURL = ""
+	t.failPendingLocked()
+}
+
+// ─────────────────── 认证 ───────────────────
+
+// mcpAuth 请求头与 bearer token. 配置了 token_url 时用 OAuth client
+// credentials 获取 token 并缓存到过期前; 收到 401 时换新 token 重试一次.
+type mcpAuth struct {
+	headers map[string]string
+	oauth   *config.MCPOAuthConfig
+	client  *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func newMCPAuth(entry config.MCPServerEntry) *mcpAuth {
+	a := &mcpAuth{
+		headers: entry.Headers,
+		oauth:   entry.OAuth,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+	if a.oauth != nil && a.oauth.TokenURL == "" {
+		a.token = os.ExpandEnv(a.oauth.Token)
+	}
+	return a
+}
+
+// do 附加认证后发送请求. 只用于无请求体或可重放请求体的请求
+func (a *mcpAuth) do(ctx context.Context, client *http.Client, req *http.Request) (*http.Response, error) {
+	if err := a.apply(ctx, req); err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !a.refreshable() {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	a.mu.Lock()
+	a.token = ""
+	a.mu.Unlock()
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	if err := a.apply(ctx, retry); err != nil {
+		return nil, err
+	}
+	return client.Do(retry)
+}
+
+func (a *mcpAuth) apply(ctx context.Context, req *http.Request) error {
+	for k, v := range a.headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+	token, err := a.bearer(ctx)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+func (a *mcpAuth) refreshable() bool {
+	return a.oauth != nil && a.oauth.TokenURL != ""
+}
+
+// bearer 当前 access token, 需要时通过 client credentials 获取
+func (a *mcpAuth) bearer(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.refreshable() || (a.token != "" && time.Now().Before(a.expiry)) {
+		return a.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(a.oauth.Scopes) > 0 {
+		form.Set("scope", strings.Join(a.oauth.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.oauth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(os.ExpandEnv(a.oauth.ClientID)), url.QueryEscape(os.ExpandEnv(a.oauth.ClientSecret)))
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("MCP OAuth token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("MCP OAuth token endpoint returned status %d: %s", resp.StatusCode, string(body))
+	}
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil || tok.AccessToken == "" {
+		return "", fmt.Errorf("MCP OAuth token endpoint returned no access_token")
+	}
+	a.token = tok.AccessToken
+	a.expiry = time.Now().Add(time.Hour)
+	if tok.ExpiresIn > 0 {
+		// 提前 30 秒刷新, 避免请求途中过期
+		a.expiry = time.Now().Add(time.Duration(tok.ExpiresIn)*time.Second - 30*time.Second)
+	}
+	return a.token, nil
+}
+
+// ─────────────────── SSE ───────────────────
+
+func isEventStream(resp *http.Response) bool {
+	return strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+}
+
+// readSSE 逐个读取 SSE 事件, fn 返回 false 时停止
+func readSSE(r io.Reader, fn func(event, data string) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 8*1024*1024)
+	var event string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				if !fn(event, strings.Join(data, "\n")) {
+					return nil
+				}
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, ":"):
+			// 注释 / keep-alive
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		fn(event, strings.Join(data, "\n"))
+	}
+	return nil
+}
+
+func parseMCPMessage(data string) *jsonRPCMessage {
+	var msg jsonRPCMessage
+	if err := json.Unmarshal([]byte(data), &msg); err != nil {
+		return nil
+	}
+	return &msg
+}
@@ -0,0 +1,237 @@
+// Copyright 2026 NGOClaw Authors. All rights reserved.
+package tool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryMu serializes every read-modify-write of memory.json and the daily
+// logs (save_memory tool and the /memory commands).
+var memoryMu sync.Mutex
+
+// ErrFactNotFound is returned when no fact has the requested ID.
+var ErrFactNotFound = errors.New("memory fact not found")
+
+// memoryAuditFile records every user edit of long-term memory, one JSON object per line.
+const memoryAuditFile = "memory_audit.jsonl"
+
+// dailyLogMemoryTag marks daily log lines written for curated facts, so edits
+// and deletes can find them again.
+const dailyLogMemoryTag = "[memory] "
+
+// MemoryAuditEntry is one line of ~/.ngoclaw/memory_audit.jsonl.
+type MemoryAuditEntry struct {
+	Time   string `json:"time"`
+	Action string `json:"action"` // add|edit|delete
+	FactID string `json:"factId"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+	Actor  string `json:"actor"` // e.g. "tg:123456"
+}
+
+// FindMemoryFact returns the fact with the given ID.
+func FindMemoryFact(id string) (MemoryFact, error) {
+	store, err := LoadMemoryStore()
+	if err != nil {
+		return MemoryFact{}, err
+	}
+	if i := factIndex(store, id); i >= 0 {
+		return store.Facts[i], nil
+	}
+	return MemoryFact{}, ErrFactNotFound
+}
+
+// AddMemoryFact stores a user-provided fact in memory.json and the daily log,
+// which is what the prompt engine injects. chatID attributes the fact for
+// retention policies and /forgetme (0 = not tied to a chat).
+func AddMemoryFact(content, category, actor string, chatID int64) (MemoryFact, error) {
+	content = sanitizeFact(content)
+	if content == "" {
+		return MemoryFact{}, errors.New("fact is empty")
+	}
+	if !ValidCategories[category] {
+		category = "knowledge"
+	}
+
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+
+	store, err := LoadMemoryStore()
+	if err != nil {
+		return MemoryFact{}, err
+	}
+	fact := MemoryFact{
+		ID:         uuid.New().String()[:8],
+		Content:    content,
+		Category:   category,
+		Confidence: 1.0, // stated by the user
+		Source:     "user",
+		ChatID:     chatID,
+		CreatedAt:  time.Now().Format(time.RFC3339),
+	}
+	store.Facts = append(store.Facts, fact)
+	if err := SaveMemoryStore(store); err != nil {
+		return MemoryFact{}, err
+	}
+	if err := AppendDailyLog(dailyLogMemoryTag + content); err != nil {
+		return fact, err
+	}
+	return fact, appendMemoryAudit(MemoryAuditEntry{Action: "add", FactID: fact.ID, After: content, Actor: actor})
+}
+
+// EditMemoryFact replaces the content of a fact, including its daily log
+// lines. Returns the fact as it was before the edit.
+func EditMemoryFact(id, content, actor string) (MemoryFact, error) {
+	content = sanitizeFact(content)
+	if content == "" {
+		return MemoryFact{}, errors.New("fact is empty")
+	}
+
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+
+	store, err := LoadMemoryStore()
+	if err != nil {
+		return MemoryFact{}, err
+	}
+	i := factIndex(store, id)
+	if i < 0 {
+		return MemoryFact{}, ErrFactNotFound
+	}
+	before := store.Facts[i]
+	store.Facts[i].Content = content
+	if err := SaveMemoryStore(store); err != nil {
+		return before, err
+	}
+	if err := rewriteDailyLogs(before.Content, content); err != nil {
+		return before, err
+	}
+	return before, appendMemoryAudit(MemoryAuditEntry{Action: "edit", FactID: id, Before: before.Content, After: content, Actor: actor})
+}
+
+// DeleteMemoryFact removes a fact and its daily log lines. Returns the deleted fact.
+func DeleteMemoryFact(id, actor string) (MemoryFact, error) {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+
+	store, err := LoadMemoryStore()
+	if err != nil {
+		return MemoryFact{}, err
+	}
+	i := factIndex(store, id)
+	if i < 0 {
+		return MemoryFact{}, ErrFactNotFound
+	}
+	fact := store.Facts[i]
+	store.Facts = append(store.Facts[:i], store.Facts[i+1:]...)
+	if err := SaveMemoryStore(store); err != nil {
+		return fact, err
+	}
+	if err := rewriteDailyLogs(fact.Content, ""); err != nil {
+		return fact, err
+	}
+	return fact, appendMemoryAudit(MemoryAuditEntry{Action: "delete", FactID: id, Before: fact.Content, Actor: actor})
+}
+
+// ReadMemoryAudit returns the last n audit entries, oldest first.
+func ReadMemoryAudit(n int) ([]MemoryAuditEntry, error) {
+	data, err := os.ReadFile(getMemoryAuditPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var entries []MemoryAuditEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e MemoryAuditEntry
+		if line == "" || json.Unmarshal([]byte(line), &e) != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if n > 0 && len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	return entries, nil
+}
+
+// getMemoryAuditPath returns ~/.ngoclaw/memory_audit.jsonl
+func getMemoryAuditPath() string {
+	return filepath.Join(filepath.Dir(getMemoryJSONPath()), memoryAuditFile)
+}
+
+func appendMemoryAudit(e MemoryAuditEntry) error {
+	e.Time = time.Now().Format(time.RFC3339)
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(getMemoryAuditPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open memory audit: %w", err)
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// dailyLogLine matches "- [15:04] entry" lines written by AppendDailyLog.
+var dailyLogLine = regexp.MustCompile(`^- \[\d{2}:\d{2}\] (.*)$`)
+
+// rewriteDailyLogs replaces daily log entries whose text is exactly oldText
+// (with or without the [memory] tag) by newText, or drops them when newText
+// is empty. Session transcripts and other entries are left alone.
+func rewriteDailyLogs(oldText, newText string) error {
+	files, _ := filepath.Glob(filepath.Join(getDailyLogDir(), "????-??-??.md"))
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		lines := strings.SplitAfter(string(data), "\n")
+		changed := false
+		kept := lines[:0]
+		for _, line := range lines {
+			m := dailyLogLine.FindStringSubmatch(strings.TrimRight(line, "\n"))
+			if m == nil || strings.TrimPrefix(m[1], dailyLogMemoryTag) != oldText {
+				kept = append(kept, line)
+				continue
+			}
+			changed = true
+			if newText != "" {
+				kept = append(kept, line[:len("- [15:04] ")]+dailyLogMemoryTag+newText+"\n")
+			}
+		}
+		if changed {
+			if err := os.WriteFile(path, []byte(strings.Join(kept, "")), 0644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func factIndex(store *MemoryStore, id string) int {
+	for i, f := range store.Facts {
+		if f.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// sanitizeFact collapses whitespace and strips a leading bullet, like save_memory.
+func sanitizeFact(s string) string {
+	return strings.TrimLeft(strings.Join(strings.Fields(s), " "), "- ")
+}
@@ -0,0 +1,72 @@
+package tool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryFactLifecycle(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	// An unrelated log entry must survive edits and deletes.
+	if err := AppendDailyLog("[session] Chat 1 (2 msgs)"); err != nil {
+		t.Fatal(err)
+	}
+
+	fact, err := AddMemoryFact("  prefers   tabs ", "preference", "tg:1", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fact.Content != "prefers tabs" || fact.Category != "preference" || fact.Source != "user" {
+		t.Fatalf("unexpected fact %+v", fact)
+	}
+	logPath := filepath.Join(getDailyLogDir(), time.Now().Format("2006-01-02")+".md")
+	readLog := func() string {
+		data, _ := os.ReadFile(logPath)
+		return string(data)
+	}
+	if !strings.Contains(readLog(), "] [memory] prefers tabs\n") {
+		t.Fatalf("fact not in daily log:\n%s", readLog())
+	}
+
+	before, err := EditMemoryFact(fact.ID, "prefers spaces", "tg:1")
+	if err != nil || before.Content != "prefers tabs" {
+		t.Fatalf("edit: %+v %v", before, err)
+	}
+	if got, _ := FindMemoryFact(fact.ID); got.Content != "prefers spaces" {
+		t.Fatalf("memory.json not updated: %+v", got)
+	}
+	if log := readLog(); strings.Contains(log, "prefers tabs") || !strings.Contains(log, "[memory] prefers spaces") {
+		t.Fatalf("daily log not rewritten:\n%s", log)
+	}
+
+	if _, err := DeleteMemoryFact(fact.ID, "tg:1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FindMemoryFact(fact.ID); !errors.Is(err, ErrFactNotFound) {
+		t.Fatalf("fact still present: %v", err)
+	}
+	if log := readLog(); strings.Contains(log, "prefers") || !strings.Contains(log, "[session] Chat 1") {
+		t.Fatalf("daily log after delete:\n%s", log)
+	}
+
+	audit, err := ReadMemoryAudit(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actions []string
+	for _, e := range audit {
+		actions = append(actions, e.Action)
+	}
+	if strings.Join(actions, ",") != "add,edit,delete" || audit[1].Before != "prefers tabs" || audit[1].After != "prefers spaces" {
+		t.Fatalf("audit trail %+v", audit)
+	}
+
+	if _, err := EditMemoryFact("missing", "x", "tg:1"); !errors.Is(err, ErrFactNotFound) {
+		t.Fatalf("edit of missing fact: %v", err)
+	}
+}
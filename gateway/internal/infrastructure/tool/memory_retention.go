@@ -0,0 +1,144 @@
+// Copyright 2026 NGOClaw Authors. All rights reserved.
+package tool
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sessionLogChat matches the header of a session memory block ("[session] Chat 123 (4 msgs)").
+var sessionLogChat = regexp.MustCompile(`^\[session\] Chat (-?\d+) `)
+
+// DailyLogChat reports the chat a daily log entry belongs to. Only session
+// memory blocks carry a chat; other entries return false.
+func DailyLogChat(text string) (int64, bool) {
+	m := sessionLogChat.FindStringSubmatch(text)
+	if m == nil {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(m[1], 10, 64)
+	return id, err == nil
+}
+
+// PurgeMemoryFacts removes the facts for which match returns true, together
+// with their daily log lines and audit history (retention policies,
+// /forgetme). The audit log keeps one "purge" line per fact, without content.
+func PurgeMemoryFacts(match func(MemoryFact) bool, actor string) ([]MemoryFact, error) {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+
+	store, err := LoadMemoryStore()
+	if err != nil {
+		return nil, err
+	}
+	var purged []MemoryFact
+	kept := store.Facts[:0]
+	for _, f := range store.Facts {
+		if match(f) {
+			purged = append(purged, f)
+			continue
+		}
+		kept = append(kept, f)
+	}
+	if len(purged) == 0 {
+		return nil, nil
+	}
+	store.Facts = kept
+	if err := SaveMemoryStore(store); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]bool, len(purged))
+	for _, f := range purged {
+		ids[f.ID] = true
+		if err := rewriteDailyLogs(f.Content, ""); err != nil {
+			return purged, err
+		}
+	}
+	if err := dropMemoryAudit(ids); err != nil {
+		return purged, err
+	}
+	for _, f := range purged {
+		if err := appendMemoryAudit(MemoryAuditEntry{Action: "purge", FactID: f.ID, Actor: actor}); err != nil {
+			return purged, err
+		}
+	}
+	return purged, nil
+}
+
+// PurgeDailyLogs removes the daily log entries for which match(day, text)
+// returns true. An entry is a "- [15:04] text" line plus its indented
+// continuation lines (session memory blocks). Files left empty are deleted.
+// Returns the number of entries removed.
+func PurgeDailyLogs(match func(day time.Time, text string) bool) (int, error) {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+
+	files, _ := filepath.Glob(filepath.Join(getDailyLogDir(), "????-??-??.md"))
+	removed := 0
+	for _, path := range files {
+		day, err := time.ParseInLocation("2006-01-02", strings.TrimSuffix(filepath.Base(path), ".md"), time.Local)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return removed, err
+		}
+
+		var kept strings.Builder
+		n, dropping := 0, false
+		for _, line := range strings.SplitAfter(string(data), "\n") {
+			if m := dailyLogLine.FindStringSubmatch(strings.TrimRight(line, "\n")); m != nil {
+				dropping = match(day, m[1])
+				if dropping {
+					n++
+				}
+			} else if strings.TrimSpace(line) == "" {
+				dropping = false
+			}
+			if !dropping {
+				kept.WriteString(line)
+			}
+		}
+		if n == 0 {
+			continue
+		}
+		removed += n
+		if strings.TrimSpace(kept.String()) == "" {
+			err = os.Remove(path)
+		} else {
+			err = os.WriteFile(path, []byte(kept.String()), 0644)
+		}
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// dropMemoryAudit rewrites the audit log without the entries of the given facts.
+func dropMemoryAudit(ids map[string]bool) error {
+	path := getMemoryAuditPath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var kept strings.Builder
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		var e MemoryAuditEntry
+		if json.Unmarshal([]byte(line), &e) == nil && ids[e.FactID] {
+			continue
+		}
+		kept.WriteString(line)
+	}
+	return os.WriteFile(path, []byte(kept.String()), 0644)
+}
@@ -9,7 +9,6 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -34,6 +33,7 @@ type MemoryFact struct {
 	Category   string  `json:"category"`   // preference|knowledge|context|behavior|goal
 	Confidence float64 `json:"confidence"` // 0.0-1.0
 	Source     string  `json:"source,omitempty"` // "user"|"compaction"|"agent"
+	ChatID     int64   `json:"chatId,omitempty"` // chat the fact was learned in (retention, /forgetme)
 	CreatedAt  string  `json:"createdAt"`
 }
 
@@ -49,7 +49,6 @@ var ValidCategories = map[string]bool{
 // SaveMemoryTool allows the agent to persist important facts to ~/.ngoclaw/memory.json
 // Upgraded from Markdown to structured JSON with category, confidence, and deduplication.
 type SaveMemoryTool struct {
-	mu     sync.Mutex
 	logger *zap.Logger
 }
 
@@ -100,8 +99,7 @@ func (t *SaveMemoryTool) Execute(ctx context.Context, args map[string]interface{
 		return &Result{Output: "Error: 'fact' parameter is required", Success: false}, nil
 	}
 
-	sanitized := strings.Join(strings.Fields(fact), " ")
-	sanitized = strings.TrimLeft(sanitized, "- ")
+	sanitized := sanitizeFact(fact)
 
 	category := "knowledge"
 	if cat, ok := args["category"].(string); ok && ValidCategories[cat] {
@@ -113,8 +111,8 @@ func (t *SaveMemoryTool) Execute(ctx context.Context, args map[string]interface{
 		confidence = conf
 	}
 
-	t.mu.Lock()
-	defer t.mu.Unlock()
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
 
 	store, err := LoadMemoryStore()
 	if err != nil {
@@ -149,6 +147,7 @@ func (t *SaveMemoryTool) Execute(ctx context.Context, args map[string]interface{
 		Category:   category,
 		Confidence: confidence,
 		Source:     "agent",
+		ChatID:     chatIDFromContext(ctx),
 		CreatedAt:  time.Now().Format(time.RFC3339),
 	}
 	store.Facts = append(store.Facts, newFact)
@@ -0,0 +1,244 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/codeintel"
+)
+
+const (
+	mentionMaxFileBytes  = 1 << 20     // content read per mentioned file
+	mentionScanMaxFiles  = 50000       // files walked without the index
+	mentionScanTTL       = time.Minute // symbol scan reused for this long
+	mentionMaxCandidates = 5           // ambiguous matches listed
+	mentionDefaultLines  = 40          // definition lines when the end is unknown
+	mentionMaxDefLines   = 400         // definition lines at most
+)
+
+// WorkspaceMentions resolves @file and #symbol mentions in chat messages
+// (service.MentionResolver) against the workspace. It uses the workspace
+// index when it covers the root, and otherwise walks the tree; the symbol
+// table built by a walk is reused for mentionScanTTL.
+type WorkspaceMentions struct {
+	root   string
+	index  *WorkspaceIndex // nil = no index
+	logger *zap.Logger
+
+	mu      sync.Mutex
+	scanned time.Time
+	symbols *codeintel.Indexer
+}
+
+var _ service.MentionResolver = (*WorkspaceMentions)(nil)
+
+// NewWorkspaceMentions creates a resolver for the workspace at root.
+func NewWorkspaceMentions(root string, index *WorkspaceIndex, logger *zap.Logger) *WorkspaceMentions {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return &WorkspaceMentions{root: filepath.Clean(root), index: index, logger: logger}
+}
+
+// ResolveFile implements service.MentionResolver.
+func (m *WorkspaceMentions) ResolveFile(ctx context.Context, ref string) (*service.MentionedFile, []string, error) {
+	ref = filepath.Clean(filepath.FromSlash(ref))
+	path := ref
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.root, ref)
+	}
+	if underDir(path, m.root) {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return m.readFile(path)
+		}
+	}
+	if filepath.IsAbs(ref) || strings.HasPrefix(ref, "..") {
+		return nil, nil, nil // outside the workspace
+	}
+
+	// A path suffix or bare file name: "agent_loop.go", "service/agent_loop.go"
+	suffix := string(filepath.Separator) + ref
+	var matches []string
+	for _, file := range m.files(ctx) {
+		if strings.HasSuffix(file, suffix) {
+			matches = append(matches, file)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, nil, nil
+	case 1:
+		return m.readFile(matches[0])
+	}
+	sort.Strings(matches)
+	candidates := make([]string, 0, mentionMaxCandidates)
+	for _, file := range matches[:min(len(matches), mentionMaxCandidates)] {
+		candidates = append(candidates, m.rel(file))
+	}
+	if len(matches) > mentionMaxCandidates {
+		candidates = append(candidates, "...")
+	}
+	return nil, candidates, nil
+}
+
+// ResolveSymbol implements service.MentionResolver. name matches a symbol
+// name exactly; "Type.Method" matches a method of Type.
+func (m *WorkspaceMentions) ResolveSymbol(ctx context.Context, name string, limit int) ([]service.MentionedSymbol, error) {
+	parent, method, isMethod := strings.Cut(name, ".")
+	if isMethod {
+		name = method
+	}
+	var found []codeintel.Symbol
+	if m.index.Covers(m.root) {
+		found = m.index.FindSymbols(name, m.root, 0)
+	} else {
+		found = m.scanSymbols().SearchSymbols(name)
+	}
+
+	var defs []service.MentionedSymbol
+	for _, sym := range found {
+		if sym.Name != name || (isMethod && strings.TrimPrefix(sym.Parent, "*") != parent) {
+			continue
+		}
+		def := service.MentionedSymbol{
+			Name:    sym.Name,
+			Kind:    sym.Kind,
+			Path:    m.rel(sym.File),
+			AbsPath: sym.File,
+			Line:    sym.Line,
+		}
+		if sym.Parent != "" {
+			def.Name = strings.TrimPrefix(sym.Parent, "*") + "." + sym.Name
+		}
+		if !m.inside(sym.File) {
+			continue
+		}
+		def.Source = definitionSource(sym)
+		if def.Source == "" {
+			continue
+		}
+		defs = append(defs, def)
+	}
+	// Stable order: the index returns matches by file; a walk does not
+	sort.SliceStable(defs, func(i, j int) bool {
+		if defs[i].Path != defs[j].Path {
+			return defs[i].Path < defs[j].Path
+		}
+		return defs[i].Line < defs[j].Line
+	})
+	if limit > 0 && len(defs) > limit {
+		defs = defs[:limit]
+	}
+	return defs, nil
+}
+
+// files returns the absolute paths of the workspace files.
+func (m *WorkspaceMentions) files(ctx context.Context) []string {
+	if m.index.Covers(m.root) {
+		return m.index.Files(m.root, math.MaxInt)
+	}
+	var files []string
+	filepath.WalkDir(m.root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || ctx.Err() != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != m.root && skipRepoMapDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		files = append(files, path)
+		if len(files) >= mentionScanMaxFiles {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return files
+}
+
+// scanSymbols returns the symbol table of a recent walk of the workspace.
+func (m *WorkspaceMentions) scanSymbols() *codeintel.Indexer {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.symbols == nil || time.Since(m.scanned) > mentionScanTTL {
+		m.symbols = codeintel.NewIndexer(m.logger)
+		m.symbols.IndexDirectory(m.root, nil)
+		m.scanned = time.Now()
+	}
+	return m.symbols
+}
+
+// readFile reads a text file, up to mentionMaxFileBytes. Binary files and
+// symlinks that lead out of the workspace resolve to nothing.
+func (m *WorkspaceMentions) readFile(path string) (*service.MentionedFile, []string, error) {
+	if !m.inside(path) {
+		return nil, nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, mentionMaxFileBytes))
+	if err != nil {
+		return nil, nil, err
+	}
+	if bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+		return nil, nil, nil
+	}
+	return &service.MentionedFile{Path: m.rel(path), AbsPath: path, Content: string(data)}, nil, nil
+}
+
+// inside reports whether path, with its symlinks resolved, is in the
+// workspace. The path string alone is not enough: a link in the workspace
+// may point anywhere.
+func (m *WorkspaceMentions) inside(path string) bool {
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	root, err := filepath.EvalSymlinks(m.root)
+	return err == nil && withinDir(root, real)
+}
+
+func (m *WorkspaceMentions) rel(path string) string {
+	if r, err := filepath.Rel(m.root, path); err == nil {
+		return filepath.ToSlash(r)
+	}
+	return path
+}
+
+// definitionSource returns the lines of sym's definition, with its doc
+// comment when the parser kept one.
+func definitionSource(sym codeintel.Symbol) string {
+	data, err := os.ReadFile(sym.File)
+	if err != nil || sym.Line <= 0 {
+		return ""
+	}
+	lines := strings.Split(string(data), "\n")
+	if sym.Line > len(lines) {
+		return ""
+	}
+	end := sym.EndLine
+	if end < sym.Line {
+		end = sym.Line + mentionDefaultLines - 1
+	}
+	end = min(end, len(lines), sym.Line+mentionMaxDefLines-1)
+	source := strings.Join(lines[sym.Line-1:end], "\n")
+	if doc := strings.TrimSpace(sym.DocComment); doc != "" && sym.Language == "go" {
+		source = "// " + strings.ReplaceAll(doc, "\n", "\n// ") + "\n" + source
+	}
+	return source
+}
@@ -0,0 +1,88 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestWorkspaceMentions(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"cmd/a/main.go":           "package main\n\nfunc main() {}\n",
+		"cmd/b/main.go":           "package main\n\nfunc main() {}\n",
+		"internal/store/store.go": "package store\n\n// Store keeps rows.\ntype Store struct {\n\trows int\n}\n\n// Get returns a row.\nfunc (s *Store) Get() int {\n\treturn s.rows\n}\n\nfunc Get() {}\n",
+		"node_modules/x/util.go":  "package x\n",
+		"logo.png":                "\x89PNG\x00\x00",
+	})
+	ctx := context.Background()
+
+	for name, index := range map[string]*WorkspaceIndex{"scan": nil, "index": startIndex(t, root)} {
+		m := NewWorkspaceMentions(root, index, zap.NewNop())
+
+		for ref, want := range map[string]string{
+			"internal/store/store.go": "internal/store/store.go",
+			"store.go":                "internal/store/store.go",
+			"./store/store.go":        "internal/store/store.go",
+		} {
+			file, _, err := m.ResolveFile(ctx, ref)
+			if err != nil || file == nil || file.Path != want || !strings.Contains(file.Content, "type Store struct") {
+				t.Errorf("%s: ResolveFile(%q) = %+v, %v", name, ref, file, err)
+			}
+		}
+		if _, candidates, _ := m.ResolveFile(ctx, "main.go"); strings.Join(candidates, ",") != "cmd/a/main.go,cmd/b/main.go" {
+			t.Errorf("%s: ambiguous candidates = %v", name, candidates)
+		}
+		for _, ref := range []string{"util.go", "logo.png", "../etc/passwd", "/etc/passwd", "missing.go"} {
+			if file, candidates, _ := m.ResolveFile(ctx, ref); file != nil || candidates != nil {
+				t.Errorf("%s: ResolveFile(%q) = %+v, %v", name, ref, file, candidates)
+			}
+		}
+
+		defs, err := m.ResolveSymbol(ctx, "Store.Get", 0)
+		if err != nil || len(defs) != 1 || defs[0].Name != "Store.Get" || defs[0].Line != 9 ||
+			defs[0].Source != "// Get returns a row.\nfunc (s *Store) Get() int {\n\treturn s.rows\n}" {
+			t.Errorf("%s: method = %+v, %v", name, defs, err)
+		}
+		if defs, _ := m.ResolveSymbol(ctx, "Get", 0); len(defs) != 2 || defs[0].Line != 9 || defs[1].Line != 13 {
+			t.Errorf("%s: Get = %+v", name, defs)
+		}
+		if defs, _ := m.ResolveSymbol(ctx, "Stor", 0); len(defs) != 0 {
+			t.Errorf("%s: partial name resolved: %+v", name, defs)
+		}
+	}
+}
+
+func TestWorkspaceMentions_SymlinkEscape(t *testing.T) {
+	root, outside := t.TempDir(), t.TempDir()
+	writeTree(t, root, map[string]string{"app.go": "package app\n"})
+	writeTree(t, outside, map[string]string{"secret.go": "package secret\n\nconst Token = \"s3cret\"\n"})
+	if err := os.Symlink(filepath.Join(outside, "secret.go"), filepath.Join(root, "leak.go")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "ext")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "app.go"), filepath.Join(root, "alias.go")); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	m := NewWorkspaceMentions(root, nil, zap.NewNop())
+
+	for _, ref := range []string{"leak.go", "./leak.go", "ext/secret.go"} {
+		if file, _, err := m.ResolveFile(ctx, ref); file != nil || err != nil {
+			t.Errorf("ResolveFile(%q) read outside the workspace: %+v, %v", ref, file, err)
+		}
+	}
+	if defs, _ := m.ResolveSymbol(ctx, "Token", 0); len(defs) != 0 {
+		t.Errorf("symbol outside the workspace: %+v", defs)
+	}
+	// A link that stays in the workspace still resolves
+	if file, _, _ := m.ResolveFile(ctx, "alias.go"); file == nil || file.Content != "package app\n" {
+		t.Errorf("in-workspace link = %+v", file)
+	}
+}
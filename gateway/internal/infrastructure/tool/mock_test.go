@@ -0,0 +1,139 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"go.uber.org/zap"
+)
+
+// countingTool 记录真实执行次数, 验证 replay 模式不会触达真实工具
+type countingTool struct {
+	calls int
+}
+
+func (t *countingTool) Name() string          { return "read_file" }
+func (t *countingTool) Description() string   { return "read a file" }
+func (t *countingTool) Kind() domaintool.Kind { return domaintool.KindRead }
+func (t *countingTool) Schema() map[string]interface{} {
+	return map[string]interface{}{"type": "object"}
+}
+func (t *countingTool) Execute(ctx context.Context, args map[string]interface{}) (*Result, error) {
+	t.calls++
+	return &Result{Output: "real:" + args["path"].(string), Success: true}, nil
+}
+
+func newMockTestRegistry(t *testing.T, fixtures, mode string) (*MockRegistry, *countingTool, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fixtures.jsonl")
+	if fixtures != "" {
+		if err := os.WriteFile(path, []byte(fixtures), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store, err := LoadMockStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := domaintool.NewInMemoryRegistry()
+	rt := &countingTool{}
+	inner.Register(rt)
+	return NewMockRegistry(inner, store, mode, zap.NewNop()), rt, path
+}
+
+func execMock(t *testing.T, r *MockRegistry, args map[string]interface{}) *Result {
+	t.Helper()
+	tl, ok := r.Get("read_file")
+	if !ok {
+		t.Fatal("tool not found")
+	}
+	res, err := tl.Execute(context.Background(), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestMockRegistry_Replay(t *testing.T) {
+	r, rt, _ := newMockTestRegistry(t, `
+// 手写的默认结果
+{"tool":"read_file","output":"default","success":true}
+{"tool":"read_file","args":{"path":"a.go"},"output":"package a","success":true}
+{"tool":"read_file","args":{"path":"a.go","limit":10},"output":"package a (10)","success":true}
+{"tool":"read_file","args":{"path":"gone.go"},"output":"","success":false,"error":"no such file"}
+`, MockReplay)
+
+	cases := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"path": "a.go"}, "package a"},
+		{map[string]interface{}{"limit": 10, "path": "a.go"}, "package a (10)"},
+		{map[string]interface{}{"path": "a.go", "offset": 5}, "package a"}, // 子集匹配取最具体
+		{map[string]interface{}{"path": "b.go"}, "default"},
+	}
+	for _, c := range cases {
+		if got := execMock(t, r, c.args).Output; got != c.want {
+			t.Errorf("%v: output = %q, want %q", c.args, got, c.want)
+		}
+	}
+	if res := execMock(t, r, map[string]interface{}{"path": "gone.go"}); res.Success || res.Error != "no such file" {
+		t.Errorf("failure fixture not replayed: %+v", res)
+	}
+	if rt.calls != 0 {
+		t.Errorf("real tool executed %d times in replay mode", rt.calls)
+	}
+	if tl, _ := r.Get("read_file"); tl.Kind() != domaintool.KindRead {
+		t.Error("wrapped tool lost its kind")
+	}
+}
+
+func TestMockRegistry_UnknownCallIsCanned(t *testing.T) {
+	r, rt, _ := newMockTestRegistry(t, "", MockReplay)
+	res := execMock(t, r, map[string]interface{}{"path": "x.go"})
+	if !res.Success || !strings.HasPrefix(res.Output, "[mocked] read_file") {
+		t.Errorf("unexpected canned result: %+v", res)
+	}
+	if rt.calls != 0 {
+		t.Error("real tool executed")
+	}
+}
+
+func TestMockRegistry_RecordThenReplay(t *testing.T) {
+	r, rt, path := newMockTestRegistry(t, "", MockRecord)
+	if got := execMock(t, r, map[string]interface{}{"path": "c.go"}).Output; got != "real:c.go" {
+		t.Fatalf("record output = %q", got)
+	}
+	if rt.calls != 1 {
+		t.Fatalf("real calls = %d, want 1", rt.calls)
+	}
+
+	store, err := LoadMockStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := domaintool.NewInMemoryRegistry()
+	inner.Register(rt)
+	replay := NewMockRegistry(inner, store, MockReplay, zap.NewNop())
+	if got := execMock(t, replay, map[string]interface{}{"path": "c.go"}).Output; got != "real:c.go" {
+		t.Errorf("replayed output = %q", got)
+	}
+	if rt.calls != 1 {
+		t.Errorf("real tool executed during replay")
+	}
+}
+
+func TestParseMockMode(t *testing.T) {
+	for in, want := range map[string]string{"": MockOff, "0": MockOff, "1": MockReplay, "TRUE": MockReplay, "record": MockRecord} {
+		if got, err := ParseMockMode(in); err != nil || got != want {
+			t.Errorf("ParseMockMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseMockMode("sometimes"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
@@ -0,0 +1,48 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// OutputArchive stores the full text of tool results that the summarization
+// middleware condensed. Files live next to the research dossiers (under
+// ChatArtifactDir when run from a chat), so retention and /forgetme cover them.
+type OutputArchive struct {
+	dir string
+}
+
+// NewOutputArchive creates an archive rooted at dir (default DefaultArtifactDir).
+func NewOutputArchive(dir string) *OutputArchive {
+	if dir == "" {
+		dir = DefaultArtifactDir()
+	}
+	return &OutputArchive{dir: dir}
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// Save writes content to <dir>[/chat-<id>]/tool-<time>-<tool>-<call>.txt and returns the path.
+func (a *OutputArchive) Save(ctx context.Context, tool, callID, content string) (string, error) {
+	dir := a.dir
+	if chatID := chatIDFromContext(ctx); chatID != 0 {
+		dir = ChatArtifactDir(dir, chatID)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("tool-%s-%s-%s.txt",
+		time.Now().Format("20060102-150405"),
+		unsafeFileChars.ReplaceAllString(tool, "_"),
+		unsafeFileChars.ReplaceAllString(callID, "_"),
+	)
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
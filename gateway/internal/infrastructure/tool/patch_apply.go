@@ -0,0 +1,570 @@
+package tool
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+)
+
+// apply_patch applies unified diffs in Go, as a transaction: the patch is
+// parsed and every hunk is checked against the current file contents before
+// anything is written; the files are then replaced one by one and, if any
+// write fails or a file changed underneath, restored from their backups. A
+// patch applies completely or not at all.
+
+// filePatch is the part of a unified diff that changes one file.
+type filePatch struct {
+	OldPath string // "" = /dev/null: the file is created
+	NewPath string // "" = /dev/null: the file is deleted
+	Hunks   []patchHunk
+}
+
+// patchHunk is one "@@ -a,b +c,d @@" section.
+type patchHunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Lines              []string // " context", "-removed", "+added"
+	NewNoEOL           bool     // "\ No newline at end of file" on the new side
+}
+
+func (h patchHunk) header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// oldLines returns the lines the hunk expects in the file.
+func (h patchHunk) oldLines() []string {
+	var old []string
+	for _, l := range h.Lines {
+		if l[0] != '+' {
+			old = append(old, l[1:])
+		}
+	}
+	return old
+}
+
+// patchHunkStat reports where one hunk was applied.
+type patchHunkStat struct {
+	Line    int `json:"line"`   // first line of the hunk in the patched file
+	Offset  int `json:"offset"` // lines away from the position in the header
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+// patchFileStat is the per-file summary apply_patch returns in metadata.
+type patchFileStat struct {
+	Path    string          `json:"path"`
+	Op      string          `json:"op"`             // modified | created | deleted | renamed
+	From    string          `json:"from,omitempty"` // renamed from
+	Added   int             `json:"added"`
+	Removed int             `json:"removed"`
+	Hunks   []patchHunkStat `json:"hunks"`
+}
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// parsePatch parses a unified diff (plain or git-style). Lines outside the
+// file headers and hunks, such as "diff --git" and "index" lines or
+// commentary, are ignored. Hunk line counts are taken from the body, so a
+// hunk whose header miscounts its lines still parses.
+func parsePatch(patch string) ([]*filePatch, error) {
+	lines := strings.Split(toLF(patch), "\n")
+	var files []*filePatch
+	var cur *filePatch
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "GIT binary patch"), strings.HasPrefix(line, "Binary files "):
+			return nil, errors.New("binary patches are not supported")
+		case isFileHeader(lines, i):
+			cur = &filePatch{OldPath: patchFileName(line[4:]), NewPath: patchFileName(lines[i+1][4:])}
+			if cur.OldPath == "" && cur.NewPath == "" {
+				return nil, fmt.Errorf("line %d: both file names are /dev/null", i+1)
+			}
+			files = append(files, cur)
+			i++
+		case strings.HasPrefix(line, "@@ "):
+			if cur == nil {
+				return nil, fmt.Errorf("line %d: hunk before the first ---/+++ file header", i+1)
+			}
+			h, next, err := parseHunk(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			cur.Hunks = append(cur.Hunks, h)
+			i = next - 1
+		}
+	}
+	if len(files) == 0 {
+		return nil, errors.New("no file headers (--- a/path, +++ b/path) found")
+	}
+	for _, f := range files {
+		if len(f.Hunks) == 0 {
+			return nil, fmt.Errorf("%s: no hunks", f.name())
+		}
+	}
+	return files, nil
+}
+
+func (f *filePatch) name() string {
+	if f.NewPath != "" {
+		return f.NewPath
+	}
+	return f.OldPath
+}
+
+func isFileHeader(lines []string, i int) bool {
+	return strings.HasPrefix(lines[i], "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ")
+}
+
+// parseHunk parses the hunk whose header is lines[i] and returns the index
+// of the line after it. The body ends once the header's line counts are
+// reached and no further diff lines follow, or at the next header.
+func parseHunk(lines []string, i int) (patchHunk, int, error) {
+	m := hunkHeaderRe.FindStringSubmatch(lines[i])
+	if m == nil {
+		return patchHunk{}, 0, fmt.Errorf("line %d: malformed hunk header %q", i+1, lines[i])
+	}
+	count := func(s string) int {
+		if s == "" {
+			return 1
+		}
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	h := patchHunk{OldStart: count(m[1]), NewStart: count(m[3])}
+	wantOld, wantNew := count(m[2]), count(m[4])
+
+	j := i + 1
+	blank := 0 // trailing body lines that were empty in the patch
+body:
+	for ; j < len(lines); j++ {
+		line := lines[j]
+		if strings.HasPrefix(line, "@@ ") || strings.HasPrefix(line, "diff ") || isFileHeader(lines, j) {
+			break
+		}
+		satisfied := h.OldLines >= wantOld && h.NewLines >= wantNew
+		switch {
+		case line == "":
+			// A blank context line whose leading space was stripped
+			if satisfied {
+				break body
+			}
+			line = " "
+			blank++
+		case line[0] == '\\':
+			if len(h.Lines) > 0 && h.Lines[len(h.Lines)-1][0] != '-' {
+				h.NewNoEOL = true
+			}
+			continue
+		case line[0] == ' ' || line[0] == '-' || line[0] == '+':
+			blank = 0
+		default:
+			break body
+		}
+		h.Lines = append(h.Lines, line)
+		if line[0] != '+' {
+			h.OldLines++
+		}
+		if line[0] != '-' {
+			h.NewLines++
+		}
+	}
+	// Empty lines that ran into the next file or the end of the patch
+	// separated it, they were not context
+	if h.OldLines < wantOld || h.NewLines < wantNew {
+		h.Lines = h.Lines[:len(h.Lines)-blank]
+		h.OldLines -= blank
+		h.NewLines -= blank
+	}
+	if len(h.Lines) == 0 {
+		return patchHunk{}, 0, fmt.Errorf("line %d: empty hunk", i+1)
+	}
+	return h, j, nil
+}
+
+// patchFileName returns the path a ---/+++ line names, without the a/ b/
+// prefix (as the guard rules read it), or "" for /dev/null.
+func patchFileName(s string) string {
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i] // timestamp
+	}
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, `"`) {
+		if u, err := strconv.Unquote(s); err == nil {
+			s = u
+		}
+	}
+	if s == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		s = s[2:]
+	}
+	return s
+}
+
+// applyHunks applies the hunks of one file to its text, in order. As with
+// patch(1), a hunk that does not match at the line its header names is
+// looked for at the nearest line above or below, after the previous hunk;
+// lines may differ in trailing whitespace only.
+func applyHunks(name, text string, hunks []patchHunk) (string, []patchHunkStat, error) {
+	lines := diffLines(text)
+	eol := text == "" || strings.HasSuffix(text, "\n")
+	var out []string
+	var stats []patchHunkStat
+	pos, shift := 0, 0
+	for i, h := range hunks {
+		old := h.oldLines()
+		want := h.OldStart - 1
+		if len(old) == 0 {
+			want = h.OldStart // pure insertion after line OldStart
+		}
+		at := findHunk(lines, old, want+shift, pos)
+		if at < 0 {
+			return "", nil, fmt.Errorf("%s: hunk %d (%s) does not match the file%s", name, i+1, h.header(), hunkMismatch(lines, old, want+shift))
+		}
+		shift = at - want
+		out = append(out, lines[pos:at]...)
+		stat := patchHunkStat{Line: len(out) + 1, Offset: shift}
+		// Context lines keep the file's text, which may differ from the
+		// hunk's in trailing whitespace
+		k := at
+		for _, l := range h.Lines {
+			switch l[0] {
+			case ' ':
+				out = append(out, lines[k])
+				k++
+			case '-':
+				stat.Removed++
+				k++
+			case '+':
+				out = append(out, l[1:])
+				stat.Added++
+			}
+		}
+		stats = append(stats, stat)
+		pos = k
+		if pos == len(lines) {
+			eol = !h.NewNoEOL
+		}
+	}
+	out = append(out, lines[pos:]...)
+	if len(out) == 0 {
+		return "", stats, nil
+	}
+	result := strings.Join(out, "\n")
+	if eol {
+		result += "\n"
+	}
+	return result, stats, nil
+}
+
+// findHunk returns where old occurs in lines nearest to want, at or after
+// from, or -1. Exact matches win over matches that differ in trailing
+// whitespace.
+func findHunk(lines, old []string, want, from int) int {
+	last := len(lines) - len(old)
+	if last < from {
+		return -1
+	}
+	if len(old) == 0 {
+		return min(max(want, from), len(lines))
+	}
+	for _, eq := range []func(a, b string) bool{
+		func(a, b string) bool { return a == b },
+		func(a, b string) bool { return strings.TrimRight(a, " \t") == strings.TrimRight(b, " \t") },
+	} {
+		matches := func(at int) bool {
+			for k, l := range old {
+				if !eq(lines[at+k], l) {
+					return false
+				}
+			}
+			return true
+		}
+		for d := 0; want-d >= from || want+d <= last; d++ {
+			if at := want - d; at >= from && at <= last && matches(at) {
+				return at
+			}
+			if at := want + d; d > 0 && at >= from && at <= last && matches(at) {
+				return at
+			}
+		}
+	}
+	return -1
+}
+
+// hunkMismatch describes the first line at which a hunk differs from the
+// file at the position its header names, so the model can fix the patch.
+func hunkMismatch(lines, old []string, at int) string {
+	if at < 0 || at >= len(lines) {
+		return fmt.Sprintf(": the file has %d lines", len(lines))
+	}
+	for k, l := range old {
+		if at+k >= len(lines) {
+			return fmt.Sprintf(": line %d is past the end of the file (%d lines)", at+k+1, len(lines))
+		}
+		if lines[at+k] != l {
+			return fmt.Sprintf(" (nor at any other line); at line %d the hunk expects %q, the file has %q", at+k+1, l, lines[at+k])
+		}
+	}
+	return " (nor at any other line after the previous hunk)"
+}
+
+// patchChange is the new state of one file, computed before anything is
+// written. The original content is kept as the backup.
+type patchChange struct {
+	name   string // as named in the patch
+	abs    string // guard and edit-event key
+	real   string // the file written, symlinks followed
+	backup []byte // content before the patch; nil = the file did not exist
+	mode   os.FileMode
+	format textFormat
+	before *string // decoded text before the patch
+	after  *string // decoded text after the patch; nil = the file is removed
+}
+
+// patchTx is a patch validated against the workspace, ready to commit.
+type patchTx struct {
+	workDir string
+	changes []*patchChange
+	byPath  map[string]*patchChange
+	stats   []patchFileStat
+}
+
+func newPatchTx(workDir string) *patchTx {
+	return &patchTx{workDir: workDir, byPath: make(map[string]*patchChange)}
+}
+
+// file returns the pending change to the file name refers to, reading the
+// file the first time.
+func (tx *patchTx) file(name string) (*patchChange, error) {
+	real, err := resolveWritePath(name, tx.workDir)
+	if err != nil {
+		return nil, err
+	}
+	if c := tx.byPath[real]; c != nil {
+		return c, nil
+	}
+	c := &patchChange{
+		name:   name,
+		abs:    resolveReadPath(name, tx.workDir),
+		real:   real,
+		mode:   newFileMode,
+		format: textFormat{Encoding: encodingUTF8},
+	}
+	raw, err := os.ReadFile(real)
+	switch {
+	case err == nil:
+		text, f, err := decodeText(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if info, err := os.Stat(real); err == nil {
+			c.mode = info.Mode().Perm()
+		}
+		c.backup, c.format, c.before, c.after = raw, f, &text, &text
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	}
+	tx.byPath[real] = c
+	tx.changes = append(tx.changes, c)
+	return c, nil
+}
+
+// add applies one file's hunks to the pending state of the workspace.
+func (tx *patchTx) add(f *filePatch) error {
+	stat := patchFileStat{Path: f.name()}
+	var src, dst *patchChange
+	var err error
+	if f.OldPath != "" {
+		if src, err = tx.file(f.OldPath); err != nil {
+			return err
+		}
+		if src.after == nil {
+			return fmt.Errorf("%s: file does not exist", f.OldPath)
+		}
+	}
+	if f.NewPath != "" && f.NewPath != f.OldPath {
+		if dst, err = tx.file(f.NewPath); err != nil {
+			return err
+		}
+		if dst.after != nil {
+			return fmt.Errorf("%s: file already exists", f.NewPath)
+		}
+	}
+
+	text := ""
+	if src != nil {
+		text = *src.after
+	}
+	result, hunks, err := applyHunks(stat.Path, text, f.Hunks)
+	if err != nil {
+		return err
+	}
+	stat.Hunks = hunks
+	for _, h := range hunks {
+		stat.Added += h.Added
+		stat.Removed += h.Removed
+	}
+
+	switch {
+	case src == nil:
+		stat.Op = "created"
+		dst.after = &result
+	case f.NewPath == "":
+		if result != "" {
+			return fmt.Errorf("%s: the patch deletes the file but does not remove all of its content", f.OldPath)
+		}
+		stat.Op = "deleted"
+		src.after = nil
+	case dst != nil:
+		stat.Op, stat.From = "renamed", f.OldPath
+		dst.after, dst.mode, dst.format = &result, src.mode, src.format
+		src.after = nil
+	default:
+		stat.Op = "modified"
+		src.after = &result
+	}
+	tx.stats = append(tx.stats, stat)
+	return nil
+}
+
+// commit writes the changes in order. When a write fails, or a file no
+// longer has the content the hunks were checked against, the files already
+// written are restored from their backups.
+func (tx *patchTx) commit() error {
+	var done []*patchChange
+	var dirs []string // directories created for new files, outermost first
+	rollback := func(cause error) error {
+		var failed []string
+		for i := len(done) - 1; i >= 0; i-- {
+			if err := done[i].restore(); err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %v", done[i].name, err))
+			}
+		}
+		for i := len(dirs) - 1; i >= 0; i-- {
+			os.Remove(dirs[i]) // only if still empty
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("%w; restoring the files already patched also failed (%s)", cause, strings.Join(failed, "; "))
+		}
+		return cause
+	}
+
+	for _, c := range tx.changes {
+		if !c.changed() {
+			continue
+		}
+		current, err := os.ReadFile(c.real)
+		switch {
+		case err == nil && c.backup == nil:
+			return rollback(fmt.Errorf("%s: file was created by someone else meanwhile", c.name))
+		case err == nil && !bytes.Equal(current, c.backup):
+			return rollback(fmt.Errorf("%s: file was modified by someone else meanwhile", c.name))
+		case err != nil && c.backup != nil:
+			return rollback(fmt.Errorf("%s: %w", c.name, err))
+		}
+		if c.after != nil && c.backup == nil {
+			created, err := makeDirs(filepath.Dir(c.real))
+			dirs = append(dirs, created...)
+			if err != nil {
+				return rollback(fmt.Errorf("%s: %w", c.name, err))
+			}
+		}
+		done = append(done, c)
+		if err := c.write(); err != nil {
+			return rollback(fmt.Errorf("%s: %w", c.name, err))
+		}
+	}
+	return nil
+}
+
+// changed reports whether the patch changes the file's bytes on disk.
+func (c *patchChange) changed() bool {
+	if c.after == nil || c.backup == nil {
+		return (c.after == nil) != (c.backup == nil)
+	}
+	return !bytes.Equal(c.data(), c.backup)
+}
+
+func (c *patchChange) data() []byte {
+	return encodeText(*c.after, c.format)
+}
+
+func (c *patchChange) write() error {
+	if c.after == nil {
+		return os.Remove(c.real)
+	}
+	return writeFileAtomic(c.real, c.data(), c.mode)
+}
+
+func (c *patchChange) restore() error {
+	if c.backup == nil {
+		if err := os.Remove(c.real); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	return writeFileAtomic(c.real, c.backup, c.mode)
+}
+
+// edit describes the change for the file edit events.
+func (c *patchChange) edit() *entity.FileEdit {
+	if c.after == nil {
+		edit := newFileEdit(c.abs, c.before, nil)
+		edit.BeforeHash = contentHash(string(c.backup))
+		return edit
+	}
+	return newTextFileEdit(c.abs, c.before, c.backup, *c.after, c.data())
+}
+
+// writeFileAtomic replaces path through a temporary file in the same
+// directory, so a failed write never leaves the file half written.
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".patch-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// makeDirs creates dir and its missing parents, returning the directories
+// it created, outermost first.
+func makeDirs(dir string) ([]string, error) {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil || filepath.Dir(d) == d {
+			break
+		}
+		missing = append([]string{d}, missing...)
+	}
+	var created []string
+	for _, d := range missing {
+		if err := os.Mkdir(d, 0o755); err != nil && !errors.Is(err, os.ErrExist) {
+			return created, err
+		}
+		created = append(created, d)
+	}
+	return created, nil
+}
@@ -0,0 +1,171 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/sandbox"
+	"go.uber.org/zap"
+)
+
+func TestParsePatch(t *testing.T) {
+	patch := "diff --git a/pkg/x.go b/pkg/x.go\nindex 1..2 100644\n" +
+		"--- a/pkg/x.go\t2024-01-01\n+++ b/pkg/x.go\t2024-01-02\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n\n" +
+		"--- a/old.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-x\n" +
+		"--- /dev/null\n+++ b/new/y.py\n@@ -0,0 +1,2 @@\n+y\n+z\n\\ No newline at end of file\n"
+	files, err := parsePatch(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.OldPath+">"+f.NewPath)
+	}
+	if got := strings.Join(names, ","); got != "pkg/x.go>pkg/x.go,old.txt>,>new/y.py" {
+		t.Fatalf("files = %s", got)
+	}
+	// The blank line is the hunk's third context line, its space stripped
+	if h := files[0].Hunks[0]; h.OldLines != 3 || h.NewLines != 3 || strings.Join(h.Lines, "|") != " a|-b|+B| " {
+		t.Errorf("hunk = %+v", h)
+	}
+	if h := files[2].Hunks[0]; h.NewLines != 2 || !h.NewNoEOL {
+		t.Errorf("new file hunk = %+v", h)
+	}
+
+	for _, bad := range []string{"", "just text", "@@ -1 +1 @@\n-a\n+b\n", "--- a/x\n+++ b/x\n", "--- a/x\n+++ b/x\n@@ -1 +1 @@\nGIT binary patch\n"} {
+		if _, err := parsePatch(bad); err == nil {
+			t.Errorf("parsePatch(%q) should fail", bad)
+		}
+	}
+}
+
+func TestApplyHunks(t *testing.T) {
+	text := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	// Line numbers are off by two, and the context has trailing spaces
+	files, _ := parsePatch("--- a/f\n+++ b/f\n@@ -1,3 +1,3 @@\n c \n-d\n+D\n e\n@@ -5,2 +5,3 @@\n g\n h\n+i\n")
+	got, stats, err := applyHunks("f", text, files[0].Hunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "a\nb\nc\nD\ne\nf\ng\nh\ni\n" {
+		t.Errorf("result = %q", got)
+	}
+	if len(stats) != 2 || stats[0] != (patchHunkStat{Line: 3, Offset: 2, Added: 1, Removed: 1}) ||
+		stats[1] != (patchHunkStat{Line: 7, Offset: 2, Added: 1}) {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	// The last line loses its newline
+	files, _ = parsePatch("--- a/f\n+++ b/f\n@@ -8 +8 @@\n-h\n+H\n\\ No newline at end of file\n")
+	if got, _, _ := applyHunks("f", text, files[0].Hunks); got != "a\nb\nc\nd\ne\nf\ng\nH" {
+		t.Errorf("no newline = %q", got)
+	}
+
+	files, _ = parsePatch("--- a/f\n+++ b/f\n@@ -2,2 +2,2 @@\n b\n-x\n+y\n")
+	if _, _, err := applyHunks("f", text, files[0].Hunks); err == nil || !strings.Contains(err.Error(), `at line 3 the hunk expects "x", the file has "c"`) {
+		t.Errorf("conflict error = %v", err)
+	}
+}
+
+func newPatchTestTool(t *testing.T) (*ApplyPatchTool, string) {
+	work := t.TempDir()
+	cfg := sandbox.DefaultConfig()
+	cfg.WorkDir = work
+	cfg.TempDir = t.TempDir()
+	sb, err := sandbox.NewProcessSandbox(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewApplyPatchTool(sb, zap.NewNop()), work
+}
+
+func TestApplyPatchTool_AllOrNothing(t *testing.T) {
+	tool, work := newPatchTestTool(t)
+	writeTree(t, work, map[string]string{
+		"a.txt":   "one\ntwo\nthree\n",
+		"b.txt":   "alpha\nbeta\n",
+		"old.txt": "gone\n",
+	})
+	ctx := context.Background()
+
+	// The second file does not match: nothing changes, not even a.txt
+	res, _ := tool.Execute(ctx, map[string]interface{}{"patch": "--- a/a.txt\n+++ b/a.txt\n@@ -2 +2 @@\n-two\n+TWO\n" +
+		"--- a/b.txt\n+++ b/b.txt\n@@ -1 +1 @@\n-gamma\n+GAMMA\n" +
+		"--- /dev/null\n+++ b/dir/new.txt\n@@ -0,0 +1 @@\n+new\n"})
+	if res.Success || !strings.Contains(res.Error, "b.txt: hunk 1") {
+		t.Fatalf("mismatch = %+v", res)
+	}
+	if data, _ := os.ReadFile(filepath.Join(work, "a.txt")); string(data) != "one\ntwo\nthree\n" {
+		t.Errorf("a.txt changed: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(work, "dir")); !os.IsNotExist(err) {
+		t.Error("dir was created")
+	}
+
+	res, _ = tool.Execute(ctx, map[string]interface{}{"patch": "--- a/a.txt\n+++ b/a.txt\n@@ -2 +2 @@\n-two\n+TWO\n" +
+		"--- a/old.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-gone\n" +
+		"--- a/b.txt\n+++ b/c.txt\n@@ -2 +2 @@\n-beta\n+BETA\n" +
+		"--- /dev/null\n+++ b/dir/new.txt\n@@ -0,0 +1 @@\n+new\n"})
+	if !res.Success {
+		t.Fatalf("apply = %+v", res)
+	}
+	for name, want := range map[string]string{"a.txt": "one\nTWO\nthree\n", "c.txt": "alpha\nBETA\n", "dir/new.txt": "new\n"} {
+		if data, _ := os.ReadFile(filepath.Join(work, name)); string(data) != want {
+			t.Errorf("%s = %q", name, data)
+		}
+	}
+	for _, name := range []string{"old.txt", "b.txt"} {
+		if _, err := os.Stat(filepath.Join(work, name)); !os.IsNotExist(err) {
+			t.Errorf("%s still exists", name)
+		}
+	}
+	stats, _ := res.Metadata["files"].([]patchFileStat)
+	var ops []string
+	for _, s := range stats {
+		ops = append(ops, s.Op+" "+s.Path)
+	}
+	if strings.Join(ops, ",") != "modified a.txt,deleted old.txt,renamed c.txt,created dir/new.txt" ||
+		res.Metadata["added"] != 3 || res.Metadata["removed"] != 3 {
+		t.Errorf("metadata = %+v", res.Metadata)
+	}
+	if edits, _ := res.Metadata[FileEditsKey].([]*entity.FileEdit); len(edits) != 5 {
+		t.Errorf("file edits = %d", len(edits))
+	}
+
+	res, _ = tool.Execute(ctx, map[string]interface{}{"patch": "--- a/../escape.txt\n+++ b/../escape.txt\n@@ -0,0 +1 @@\n+x\n"})
+	if res.Success || !strings.Contains(res.Error, "outside the workspace") {
+		t.Errorf("escape = %+v", res)
+	}
+}
+
+func TestPatchTx_RollsBackWhenAFileChangesMeanwhile(t *testing.T) {
+	work := t.TempDir()
+	writeTree(t, work, map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+	files, _ := parsePatch("--- /dev/null\n+++ b/sub/new.txt\n@@ -0,0 +1 @@\n+n\n" +
+		"--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-a\n+A\n--- a/b.txt\n+++ b/b.txt\n@@ -1 +1 @@\n-b\n+B\n")
+	tx := newPatchTx(work)
+	for _, f := range files {
+		if err := tx.add(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Someone edits b.txt after the hunks were checked
+	os.WriteFile(filepath.Join(work, "b.txt"), []byte("b\nmore\n"), 0o644)
+
+	if err := tx.commit(); err == nil || !strings.Contains(err.Error(), "b.txt: file was modified") {
+		t.Fatalf("commit = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(work, "a.txt")); string(data) != "a\n" {
+		t.Errorf("a.txt not restored: %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(work, "b.txt")); string(data) != "b\nmore\n" {
+		t.Errorf("b.txt overwritten: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(work, "sub")); !os.IsNotExist(err) {
+		t.Error("created file and directory not removed")
+	}
+}
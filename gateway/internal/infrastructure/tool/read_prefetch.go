@@ -0,0 +1,223 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/codeintel"
+	"go.uber.org/zap"
+)
+
+const (
+	prefetchMaxEntries  = 256
+	prefetchMaxFileSize = 512 * 1024
+	prefetchWorkers     = 4
+)
+
+// ReadPrefetcher 多文件预读缓存。
+//
+// read_file 读取文件 A 后, 后台并行把 A 的直接依赖 (codeintel.DirectDependencies 解析的 import)
+// 读入内存; 后续对这些文件的 read_file 直接命中缓存, 减少大型重构时 agent loop 的墙钟时间。
+// 命中前会比对 mtime + size, 文件被 write_file / edit_file 改过即视为失效。
+type ReadPrefetcher struct {
+	mu       sync.Mutex
+	entries  map[string]*prefetchEntry
+	inflight map[string]bool
+	sem      chan struct{}
+	logger   *zap.Logger
+
+	hits       atomic.Int64
+	misses     atomic.Int64
+	prefetched atomic.Int64
+}
+
+type prefetchEntry struct {
+	content  string
+	modTime  time.Time
+	size     int64
+	lastUsed time.Time
+}
+
+// PrefetchStats 预读缓存统计
+type PrefetchStats struct {
+	Entries    int   `json:"entries"`
+	Hits       int64 `json:"hits"`
+	Misses     int64 `json:"misses"`
+	Prefetched int64 `json:"prefetched"`
+}
+
+// NewReadPrefetcher 创建预读缓存
+func NewReadPrefetcher(logger *zap.Logger) *ReadPrefetcher {
+	return &ReadPrefetcher{
+		entries:  make(map[string]*prefetchEntry),
+		inflight: make(map[string]bool),
+		sem:      make(chan struct{}, prefetchWorkers),
+		logger:   logger.With(zap.String("component", "read_prefetch")),
+	}
+}
+
+// Get 返回缓存的文件内容; 文件已变更或未缓存时返回 false
+func (p *ReadPrefetcher) Get(path string) (string, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		p.misses.Add(1)
+		return "", false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.entries[path]
+	if !ok || !e.modTime.Equal(info.ModTime()) || e.size != info.Size() {
+		if ok {
+			delete(p.entries, path)
+		}
+		p.misses.Add(1)
+		return "", false
+	}
+	e.lastUsed = time.Now()
+	p.hits.Add(1)
+	return e.content, true
+}
+
+// Invalidate 丢弃某个文件的缓存
+func (p *ReadPrefetcher) Invalidate(path string) {
+	p.mu.Lock()
+	delete(p.entries, path)
+	p.mu.Unlock()
+}
+
+// PrefetchDeps 在后台预读 path 的直接依赖, 返回排入队列的文件数
+func (p *ReadPrefetcher) PrefetchDeps(path string) int {
+	deps := codeintel.DirectDependencies(path)
+	queued := 0
+	for _, dep := range deps {
+		if p.claim(dep) {
+			queued++
+			go p.load(dep)
+		}
+	}
+	if queued > 0 {
+		p.logger.Debug("Prefetching dependencies",
+			zap.String("file", path),
+			zap.Int("queued", queued),
+		)
+	}
+	return queued
+}
+
+// claim 标记文件为加载中; 已缓存且未变更或正在加载时返回 false
+func (p *ReadPrefetcher) claim(path string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inflight[path] {
+		return false
+	}
+	if e, ok := p.entries[path]; ok {
+		if info, err := os.Stat(path); err == nil && e.modTime.Equal(info.ModTime()) && e.size == info.Size() {
+			return false
+		}
+	}
+	p.inflight[path] = true
+	return true
+}
+
+func (p *ReadPrefetcher) load(path string) {
+	p.sem <- struct{}{}
+	defer func() { <-p.sem }()
+	defer func() {
+		p.mu.Lock()
+		delete(p.inflight, path)
+		p.mu.Unlock()
+	}()
+
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > prefetchMaxFileSize {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	p.Put(path, string(data), info)
+	p.prefetched.Add(1)
+}
+
+// Put 写入缓存 (read_file 实际读取后也会回填, 以便下次命中)
+func (p *ReadPrefetcher) Put(path, content string, info os.FileInfo) {
+	if info == nil || info.Size() > prefetchMaxFileSize {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.entries) >= prefetchMaxEntries {
+		p.evictOldest()
+	}
+	p.entries[path] = &prefetchEntry{
+		content:  content,
+		modTime:  info.ModTime(),
+		size:     info.Size(),
+		lastUsed: time.Now(),
+	}
+}
+
+// evictOldest 淘汰最久未使用的条目 (调用方持有锁)
+func (p *ReadPrefetcher) evictOldest() {
+	var oldest string
+	var oldestAt time.Time
+	for path, e := range p.entries {
+		if oldest == "" || e.lastUsed.Before(oldestAt) {
+			oldest, oldestAt = path, e.lastUsed
+		}
+	}
+	delete(p.entries, oldest)
+}
+
+// Stats 返回缓存统计
+func (p *ReadPrefetcher) Stats() PrefetchStats {
+	p.mu.Lock()
+	n := len(p.entries)
+	p.mu.Unlock()
+	return PrefetchStats{
+		Entries:    n,
+		Hits:       p.hits.Load(),
+		Misses:     p.misses.Load(),
+		Prefetched: p.prefetched.Load(),
+	}
+}
+
+// resolveReadPath 把 read_file 的 path 参数解析为绝对路径 (相对路径基于沙箱工作目录)
+func resolveReadPath(path, workDir string) string {
+	if !filepath.IsAbs(path) && workDir != "" {
+		path = filepath.Join(workDir, path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return abs
+}
+
+// sliceLines 按 sed -n 'a,bp' / tail -n +a 的语义截取行范围 (1-indexed, end<=0 表示到文件末尾)
+func sliceLines(content string, start, end int) string {
+	lines := strings.SplitAfter(content, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if start < 1 {
+		start = 1
+	}
+	if end > 0 && end < start {
+		end = start // sed 对倒序范围只输出起始行
+	}
+	if end <= 0 || end > len(lines) {
+		end = len(lines)
+	}
+	if start > end {
+		return ""
+	}
+	return strings.Join(lines[start-1:end], "")
+}
@@ -0,0 +1,83 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadPrefetcher_GoImports(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/demo\n\ngo 1.22\n")
+	writeTestFile(t, filepath.Join(root, "main.go"), `package main
+
+import (
+	"fmt"
+
+	"example.com/demo/util"
+)
+
+func main() { fmt.Println(util.Name) }
+`)
+	utilFile := filepath.Join(root, "util", "util.go")
+	writeTestFile(t, utilFile, "package util\n\nconst Name = \"demo\"\n")
+	writeTestFile(t, filepath.Join(root, "util", "util_test.go"), "package util\n")
+
+	p := NewReadPrefetcher(zap.NewNop())
+	if n := p.PrefetchDeps(filepath.Join(root, "main.go")); n != 1 {
+		t.Fatalf("queued = %d, want 1 (test files and stdlib skipped)", n)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for p.Stats().Prefetched == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	content, ok := p.Get(utilFile)
+	if !ok || content != "package util\n\nconst Name = \"demo\"\n" {
+		t.Fatalf("Get(util.go) = %q, %v", content, ok)
+	}
+
+	// 文件变更后缓存失效
+	writeTestFile(t, utilFile, "package util\n\nconst Name = \"changed\"\n")
+	if _, ok := p.Get(utilFile); ok {
+		t.Fatal("expected miss after file changed")
+	}
+
+	st := p.Stats()
+	if st.Hits != 1 || st.Misses != 1 {
+		t.Errorf("stats = %+v, want 1 hit / 1 miss", st)
+	}
+}
+
+func TestSliceLines(t *testing.T) {
+	content := "a\nb\nc\nd"
+	cases := []struct {
+		start, end int
+		want       string
+	}{
+		{2, 3, "b\nc\n"},
+		{3, 0, "c\nd"},
+		{1, 99, content},
+		{3, 2, "c\n"},
+		{9, 0, ""},
+	}
+	for _, c := range cases {
+		if got := sliceLines(content, c.start, c.end); got != c.want {
+			t.Errorf("sliceLines(%d,%d) = %q, want %q", c.start, c.end, got, c.want)
+		}
+	}
+}
@@ -30,8 +30,39 @@ type ToolLayerDeps struct {
 	ResearchLLMKey string // API key
 	ResearchLLMModel string // Model name (e.g. qwen-plus)
 
+	// Library documentation lookup (nil = docs_lookup not registered)
+	Docs *DocsLookupConfig
+
+	// Persistent PTY sessions (nil = terminal not registered).
+	// The caller owns its lifecycle (Close).
+	Terminals *TerminalManager
+
+	// Remote hosts over SSH (nil = remote_file / remote_exec not registered)
+	Remote *RemoteConfig
+
+	// Peer NGOClaw gateways (nil = remote_agent not registered)
+	RemoteAgent *RemoteAgentConfig
+
+	// Database connections (nil = sql_query not registered)
+	SQL *SQLConfig
+
+	// Vision model for workspace images (nil = analyze_image not registered)
+	Vision *VisionConfig
+
 	// Code Intelligence
-	Workspace string // LSP workspace root
+	Workspace    string     // LSP workspace root
+	LSP          *LSPTool   // nil = created here for Workspace; otherwise the caller owns its lifecycle (Shutdown)
+	ReadPrefetch bool       // read_file prefetches direct imports into a warm cache
+	Tests        TestConfig // run_tests timeout and coverage baseline
+	Deps         DepsConfig // update_deps registries and command timeout
+
+	// Workspace index shared by repo_map and grep_search (nil = scan on every call).
+	// The caller owns its lifecycle (Start / Close).
+	WorkspaceIndex *WorkspaceIndex
+
+	// Concurrent edits (nil = no conflict detection). The same guard must be
+	// given to the tool executor so it can serialize writes per file.
+	FileGuard *FileGuard
 
 	// MCP
 	MCPManager *MCPManager // nil = no MCP support
@@ -41,6 +72,12 @@ type ToolLayerDeps struct {
 
 	// Sub-Agent (nil = sub_agent tool not registered)
 	SubAgent *SubAgentDeps
+
+	// Past runs and artifacts (nil = search_past_runs / read_artifact not registered)
+	Archive *RunArchive
+
+	// Config-driven command tools (agent.tools.registry, backend=command)
+	CommandTools []CommandToolSpec
 }
 
 // SubAgentDeps holds dependencies for the sub_agent tool.
@@ -57,37 +94,76 @@ type SubAgentDeps struct {
 //
 // Registration order:
 //  1. Core file operations (bash, read, write, edit, list, grep, glob)
-//  2. Advanced (apply_patch, web_fetch)
-//  3. Web & data (web_search, stock_analysis)
+//  2. Advanced (apply_patch, web_fetch, terminal, remote_file, remote_exec)
+//  3. Web & data (web_search, stock_analysis, docs_lookup, sql_query)
 //  4. Browser (navigate, screenshot, click, type)
-//  5. Code intelligence (repo_map, git, lint_fix, lsp)
-//  6. Agent capabilities (save_memory, update_plan, sub_agent)
+//  5. Code intelligence (repo_map, lsp, suggest_commit, git, lint_fix, typecheck, run_tests, update_deps)
+//  6. Agent capabilities (save_memory, update_plan, search_past_runs, read_artifact, sub_agent, research, analyze_image)
 //  7. MCP management (mcp_manage + dynamic MCP server tools)
+//  8. Command tools declared in config (agent.tools.registry)
 func RegisterAllTools(deps ToolLayerDeps) int {
 	var tools []domaintool.Tool
 
 	// ── 1. Core File Operations ──
+	readTool := NewReadFileTool(deps.Sandbox, deps.Logger)
+	if deps.ReadPrefetch && deps.Sandbox != nil {
+		readTool.SetPrefetcher(NewReadPrefetcher(deps.Logger))
+	}
+	writeTool := NewWriteFileTool(deps.Sandbox, deps.Logger)
+	editTool := NewEditFileTool(deps.Sandbox, deps.Logger)
+	if deps.FileGuard != nil {
+		readTool.SetFileGuard(deps.FileGuard)
+		writeTool.SetFileGuard(deps.FileGuard)
+		editTool.SetFileGuard(deps.FileGuard)
+	}
+	searchTool := NewSearchTool(deps.Sandbox, deps.Logger)
+	if deps.WorkspaceIndex != nil {
+		searchTool.SetWorkspaceIndex(deps.WorkspaceIndex)
+	}
 	tools = append(tools,
 		NewBashTool(deps.Sandbox, deps.Logger),
-		NewReadFileTool(deps.Sandbox, deps.Logger),
-		NewWriteFileTool(deps.Sandbox, deps.Logger),
-		NewEditFileTool(deps.Sandbox, deps.Logger),
+		readTool,
+		writeTool,
+		editTool,
 		NewListDirTool(deps.Sandbox, deps.Logger),
-		NewSearchTool(deps.Sandbox, deps.Logger),
+		searchTool,
 		NewGlobTool(deps.Sandbox, deps.Logger),
 	)
 
 	// ── 2. Advanced ──
+	patchTool := NewApplyPatchTool(deps.Sandbox, deps.Logger)
+	if deps.FileGuard != nil {
+		patchTool.SetFileGuard(deps.FileGuard)
+	}
 	tools = append(tools,
-		NewApplyPatchTool(deps.Sandbox, deps.Logger),
+		patchTool,
 		NewWebFetchTool(deps.Sandbox, deps.Logger),
 	)
+	if deps.Terminals != nil {
+		tools = append(tools, NewTerminalTool(deps.Terminals, deps.Logger))
+	}
+	if deps.Remote != nil && len(deps.Remote.Hosts) > 0 {
+		hosts := NewRemoteHosts(*deps.Remote, deps.Logger)
+		tools = append(tools,
+			NewRemoteFileTool(hosts, deps.Logger),
+			NewRemoteExecTool(hosts, deps.Logger),
+		)
+	}
+	if deps.RemoteAgent != nil && len(deps.RemoteAgent.Peers) > 0 {
+		tools = append(tools, NewRemoteAgentTool(*deps.RemoteAgent, deps.Logger))
+	}
 
 	// ── 3. Web & Data ──
 	tools = append(tools,
 		NewWebSearchTool(deps.PythonEnv, deps.SkillsDir, deps.ResearchLLMURL, deps.ResearchLLMKey, deps.ResearchLLMModel, deps.Logger),
 		NewStockAnalysisTool(deps.PythonEnv, deps.SkillsDir, deps.Logger),
 	)
+	if deps.Docs != nil {
+		tools = append(tools, NewDocsLookupTool(*deps.Docs, deps.Logger))
+	}
+	if deps.SQL != nil && len(deps.SQL.Connections) > 0 {
+		tools = append(tools, NewSQLQueryTool(*deps.SQL, deps.Logger))
+	}
 
 	// ── 4. Browser (gRPC delegate) ──
 	tools = append(tools,
@@ -98,18 +174,35 @@ func RegisterAllTools(deps ToolLayerDeps) int {
 	)
 
 	// ── 5. Code Intelligence ──
-	tools = append(tools, NewRepoMapTool(deps.Logger))
+	repoMap := NewRepoMapTool(deps.Logger)
+	if deps.WorkspaceIndex != nil {
+		repoMap.SetWorkspaceIndex(deps.WorkspaceIndex)
+	}
+	tools = append(tools, repoMap)
 
 	workspace := deps.Workspace
 	if workspace == "" {
 		workspace, _ = os.Getwd()
 	}
-	tools = append(tools, NewLSPTool(workspace, deps.Logger))
+	lsp := deps.LSP
+	if lsp == nil {
+		lsp = NewLSPTool(workspace, deps.Logger)
+	}
+	tools = append(tools, lsp)
+
+	if deps.SubAgent != nil {
+		tools = append(tools, NewSuggestCommitTool(deps.SubAgent.LLMClient, deps.SubAgent.DefaultModel, deps.Logger))
+	} else {
+		tools = append(tools, NewSuggestCommitTool(nil, "", deps.Logger))
+	}
 
 	if deps.Sandbox != nil {
 		tools = append(tools,
 			NewGitTool(deps.Sandbox, deps.Logger),
 			NewLintFixTool(deps.Sandbox, deps.Logger),
+			NewTypecheckTool(deps.Sandbox, deps.FileGuard, deps.Logger),
+			NewTestTool(deps.Sandbox, deps.FileGuard, deps.Tests, deps.Logger),
+			NewUpdateDepsTool(deps.Sandbox, deps.FileGuard, deps.Deps, deps.Logger),
 		)
 	}
 
@@ -118,6 +211,12 @@ func RegisterAllTools(deps ToolLayerDeps) int {
 		NewSaveMemoryTool(deps.Logger),
 		NewUpdatePlanTool(deps.Logger),
 	)
+	if deps.Archive != nil {
+		tools = append(tools,
+			NewSearchPastRunsTool(deps.Archive, deps.Logger),
+			NewReadArtifactTool(deps.Archive, deps.Logger),
+		)
+	}
 
 	// ── 6b. Media (TG only) ──
 	if deps.MediaSender != nil {
@@ -138,6 +237,16 @@ func RegisterAllTools(deps ToolLayerDeps) int {
 			sa.Timeout,
 			deps.Logger,
 		))
+		tools = append(tools, NewResearchTool(
+			sa.LLMClient,
+			sa.ToolExecutor,
+			sa.DefaultModel,
+			"",
+			deps.Logger,
+		))
+	}
+	if deps.Vision != nil {
+		tools = append(tools, NewAnalyzeImageTool(*deps.Vision, deps.Logger))
 	}
 
 	// ── 7. MCP Management ──
@@ -145,6 +254,35 @@ func RegisterAllTools(deps ToolLayerDeps) int {
 		tools = append(tools, NewMCPManageTool(deps.MCPManager, deps.Logger))
 	}
 
+	// ── 8. Config-driven command tools (kubectl, terraform plan, ...) ──
+	for _, spec := range deps.CommandTools {
+		if deps.Sandbox == nil {
+			deps.Logger.Warn("Sandbox unavailable, skipping command tool", zap.String("tool", spec.Name))
+			continue
+		}
+		ct, err := NewCommandTool(spec, deps.Sandbox, deps.Logger)
+		if err != nil {
+			deps.Logger.Warn("Invalid command tool config", zap.Error(err))
+			continue
+		}
+		deps.Sandbox.AddAllowedBin(ct.Binary())
+
+		// 同名配置工具替换内置实现 (如用自己的脚本接管 web_search)
+		replaced := false
+		for i, existing := range tools {
+			if existing.Name() == ct.Name() {
+				ct.InheritFrom(existing)
+				tools[i] = ct
+				replaced = true
+				deps.Logger.Info("Command tool overrides built-in", zap.String("tool", ct.Name()))
+				break
+			}
+		}
+		if !replaced {
+			tools = append(tools, ct)
+		}
+	}
+
 	// ── Register everything ──
 	registered := 0
 	for _, t := range tools {
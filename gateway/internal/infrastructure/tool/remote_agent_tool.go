@@ -0,0 +1,241 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/client"
+	"go.uber.org/zap"
+)
+
+// PeerGateway 一个可以接收转发任务的 NGOClaw 网关 (如内网机器、GPU 主机)
+type PeerGateway struct {
+	Name        string        // 工具参数里使用的名字
+	URL         string        // 对端 HTTP 地址, 如 https://lab.internal:18790
+	Token       string        // 对端 gateway.api_tokens 中的令牌, 以 Bearer 发送
+	Model       string        // 对端使用的模型, 空 = 对端默认模型
+	Description string        // 给模型看的用途说明
+	Timeout     time.Duration // 单次任务超时, 默认 30m
+	HTTPClient  *http.Client  // 代理 / CA 设置; nil = 默认客户端
+}
+
+// RemoteAgentConfig remote_agent 工具配置; 只能转发给登记过的网关
+type RemoteAgentConfig struct {
+	Peers   []PeerGateway
+	MaxHops int // 任务最多经过几个网关 (A→B 为 1 跳), 默认 2
+}
+
+// RemoteAgentTool 把任务转发给另一个 NGOClaw 网关的 /api/v1/agent 执行。
+// 对端的工具调用实时转发到本次运行的事件流 (名字带 "<peer>:" 前缀),
+// 最终回复作为工具结果返回。对端按自己的安全策略审批工具调用。
+type RemoteAgentTool struct {
+	peers   map[string]*PeerGateway
+	names   []string
+	maxHops int
+	logger  *zap.Logger
+}
+
+// NewRemoteAgentTool 创建 remote_agent 工具
+func NewRemoteAgentTool(cfg RemoteAgentConfig, logger *zap.Logger) *RemoteAgentTool {
+	if cfg.MaxHops <= 0 {
+		cfg.MaxHops = 2
+	}
+	t := &RemoteAgentTool{
+		peers:   make(map[string]*PeerGateway, len(cfg.Peers)),
+		maxHops: cfg.MaxHops,
+		logger:  logger,
+	}
+	for i := range cfg.Peers {
+		p := &cfg.Peers[i]
+		if p.Timeout <= 0 {
+			p.Timeout = 30 * time.Minute
+		}
+		t.peers[p.Name] = p
+		t.names = append(t.names, p.Name)
+	}
+	return t
+}
+
+func (t *RemoteAgentTool) Name() string          { return "remote_agent" }
+func (t *RemoteAgentTool) Kind() domaintool.Kind { return domaintool.KindExecute }
+
+func (t *RemoteAgentTool) Description() string {
+	var sb strings.Builder
+	sb.WriteString(`Forward a task to another NGOClaw gateway and wait for its answer. The peer runs its own agent with its own tools,
+files and network access (e.g. inside a secure network or on a more powerful machine), so describe the task completely:
+it does not see this conversation. Use it only for work that must happen on the peer.
+Registered peers:`)
+	for _, name := range t.names {
+		p := t.peers[name]
+		sb.WriteString("\n- " + p.Name)
+		if p.Description != "" {
+			sb.WriteString(": " + p.Description)
+		}
+	}
+	return sb.String()
+}
+
+func (t *RemoteAgentTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"peer": map[string]interface{}{
+				"type":        "string",
+				"description": "Peer gateway name",
+				"enum":        t.names,
+			},
+			"task": map[string]interface{}{
+				"type":        "string",
+				"description": "Self-contained task for the peer's agent",
+			},
+		},
+		"required": []string{"peer", "task"},
+	}
+}
+
+// Timeout 转发任务可能运行很久, 供 agent loop 设置单工具超时
+func (t *RemoteAgentTool) Timeout() time.Duration {
+	var max time.Duration
+	for _, p := range t.peers {
+		if p.Timeout > max {
+			max = p.Timeout
+		}
+	}
+	return max + time.Minute
+}
+
+func (t *RemoteAgentTool) Execute(ctx context.Context, args map[string]interface{}) (*Result, error) {
+	name, _ := args["peer"].(string)
+	peer, ok := t.peers[name]
+	if !ok {
+		return &Result{Success: false, Error: fmt.Sprintf("unknown peer %q (registered: %s)", name, strings.Join(t.names, ", "))}, nil
+	}
+	task, _ := args["task"].(string)
+	if strings.TrimSpace(task) == "" {
+		return &Result{Success: false, Error: "task is required"}, nil
+	}
+	hops := service.PeerHopsFromContext(ctx)
+	if hops >= t.maxHops {
+		return &Result{Success: false, Error: fmt.Sprintf("this task already passed through %d gateways (max_hops %d); do it here instead of forwarding", hops, t.maxHops)}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, peer.Timeout)
+	defer cancel()
+
+	c := client.New(client.Config{
+		BaseURL:    peer.URL,
+		Token:      peer.Token,
+		Header:     http.Header{service.PeerHopsHeader: []string{strconv.Itoa(hops + 1)}},
+		HTTPClient: peer.HTTPClient,
+	})
+	t.logger.Info("Forwarding task to peer gateway",
+		zap.String("peer", peer.Name),
+		zap.Int("hops", hops+1),
+		zap.Int("task_len", len(task)),
+	)
+
+	var toolsUsed []string
+	start := time.Now()
+	res, err := c.RunTask(ctx, client.TaskRequest{
+		Message:   task,
+		Model:     peer.Model,
+		SessionID: "peer:" + service.TraceIDFromContext(ctx),
+	}, func(ev client.Event) {
+		if ev.Tool == nil || (ev.Type != client.EventToolCall && ev.Type != client.EventToolResult) {
+			return
+		}
+		if ev.Type == client.EventToolResult {
+			toolsUsed = append(toolsUsed, ev.Tool.Name)
+		}
+		t.relay(ctx, peer.Name, ev)
+	})
+
+	t.logger.Info("Peer gateway task finished",
+		zap.String("peer", peer.Name),
+		zap.Duration("duration", time.Since(start)),
+		zap.Int("tools", len(toolsUsed)),
+		zap.Error(err),
+	)
+
+	var runErr *client.RunError
+	switch {
+	case err == nil:
+	case errors.As(err, &runErr) && res != nil:
+		// 对端运行失败或中止, 带上已有的部分回复
+		return &Result{
+			Output:  formatPeerResult(peer.Name, res, toolsUsed),
+			Success: false,
+			Error:   fmt.Sprintf("peer %s: %s\n\n%s", peer.Name, runErr.Message, formatPeerResult(peer.Name, res, toolsUsed)),
+		}, nil
+	default:
+		return &Result{Success: false, Error: fmt.Sprintf("peer %s: %s", peer.Name, describePeerError(ctx, err, peer.Timeout))}, nil
+	}
+
+	return &Result{
+		Output:  formatPeerResult(peer.Name, res, toolsUsed),
+		Success: true,
+		Metadata: map[string]interface{}{
+			"peer":   peer.Name,
+			"steps":  res.TotalSteps,
+			"tokens": res.TotalTokens,
+			"model":  res.ModelUsed,
+		},
+	}, nil
+}
+
+// relay 把对端的工具事件转发到本次运行的事件流, 名字与 ID 加上对端前缀以免和本地调用混淆
+func (t *RemoteAgentTool) relay(ctx context.Context, peer string, ev client.Event) {
+	typ := entity.EventToolCall
+	if ev.Type == client.EventToolResult {
+		typ = entity.EventToolResult
+	}
+	service.EmitToolEvent(ctx, entity.AgentEvent{
+		Type: typ,
+		ToolCall: &entity.ToolCallEvent{
+			ID:        peer + ":" + ev.Tool.ID,
+			Name:      peer + ":" + ev.Tool.Name,
+			Arguments: ev.Tool.Arguments,
+			Output:    ev.Tool.Output,
+			Display:   ev.Tool.Display,
+			Success:   ev.Tool.Success,
+			Duration:  ev.Tool.Duration,
+		},
+	})
+}
+
+func formatPeerResult(peer string, res *client.Result, toolsUsed []string) string {
+	var sb strings.Builder
+	sb.WriteString("=== Result from " + peer + " ===\n\n")
+	if strings.TrimSpace(res.Content) == "" {
+		sb.WriteString("(no answer)")
+	} else {
+		sb.WriteString(res.Content)
+	}
+	sb.WriteString("\n\n--- Execution Summary ---\n")
+	sb.WriteString(fmt.Sprintf("Steps: %d | Tokens: %d | Model: %s\n", res.TotalSteps, res.TotalTokens, res.ModelUsed))
+	if len(toolsUsed) > 0 {
+		sb.WriteString(fmt.Sprintf("Tools used: %s\n", strings.Join(uniqueStrings(toolsUsed), ", ")))
+	}
+	return sb.String()
+}
+
+func describePeerError(ctx context.Context, err error, timeout time.Duration) string {
+	var apiErr *client.APIError
+	switch {
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized:
+		return "the peer rejected our token (check its gateway.api_tokens)"
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden:
+		return "the peer does not accept requests from this address (check its gateway.api_allow_ips)"
+	case ctx.Err() == context.DeadlineExceeded:
+		return fmt.Sprintf("timed out after %s", timeout)
+	}
+	return err.Error()
+}
@@ -0,0 +1,84 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"go.uber.org/zap"
+)
+
+func TestRemoteAgentTool(t *testing.T) {
+	var mu sync.Mutex
+	var hops, auth, model string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Message string `json:"message"`
+			Model   string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		hops, auth, model = r.Header.Get(service.PeerHopsHeader), r.Header.Get("Authorization"), req.Model
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, e := range [][2]string{
+			{"tool_call", `{"id":"c1","name":"bash","arguments":{"command":"nvidia-smi"},"success":false}`},
+			{"tool_result", `{"id":"c1","name":"bash","arguments":{"command":"nvidia-smi"},"output":"2x A100","success":true}`},
+			{"text_delta", `{"content":"two GPUs"}`},
+		} {
+			fmt.Fprintf(w, "event: %s\ndata: {\"event\":%q,\"data\":%s}\n\n", e[0], e[0], e[1])
+		}
+		if req.Message == "fail" {
+			fmt.Fprint(w, "event: error\ndata: {\"event\":\"error\",\"data\":{\"error\":\"budget exceeded\"}}\n\n")
+		}
+		fmt.Fprint(w, "event: done\ndata: {\"content\":\"The lab box has two A100 GPUs.\",\"total_steps\":2,\"total_tokens\":900,\"model_used\":\"lab/qwen\"}\n\n")
+	}))
+	defer srv.Close()
+
+	tool := NewRemoteAgentTool(RemoteAgentConfig{Peers: []PeerGateway{
+		{Name: "lab", URL: srv.URL, Token: "s3cret", Model: "lab/qwen", Description: "GPU box"},
+	}}, zap.NewNop())
+	if !strings.Contains(tool.Description(), "- lab: GPU box") {
+		t.Errorf("description = %q", tool.Description())
+	}
+
+	var events []entity.AgentEvent
+	ctx := service.WithToolEvents(context.Background(), func(ev entity.AgentEvent) { events = append(events, ev) })
+	res, err := tool.Execute(ctx, map[string]interface{}{"peer": "lab", "task": "which GPUs do you have?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || !strings.Contains(res.Output, "two A100 GPUs") || !strings.Contains(res.Output, "Tools used: bash") {
+		t.Errorf("result = %+v", res)
+	}
+	if hops != "1" || auth != "Bearer s3cret" || model != "lab/qwen" {
+		t.Errorf("request hops=%q auth=%q model=%q", hops, auth, model)
+	}
+	// 对端的工具事件带前缀转发, 文本增量不转发
+	if len(events) != 2 || events[0].Type != entity.EventToolCall || events[0].ToolCall.Name != "lab:bash" ||
+		events[1].ToolCall.ID != "lab:c1" || events[1].ToolCall.Output != "2x A100" {
+		t.Errorf("events = %+v", events)
+	}
+
+	res, _ = tool.Execute(context.Background(), map[string]interface{}{"peer": "lab", "task": "fail"})
+	if res.Success || !strings.Contains(res.Error, "budget exceeded") || !strings.Contains(res.Error, "two A100 GPUs") {
+		t.Errorf("failed run = %+v", res)
+	}
+
+	res, _ = tool.Execute(service.WithPeerHops(context.Background(), 2), map[string]interface{}{"peer": "lab", "task": "x"})
+	if res.Success || !strings.Contains(res.Error, "max_hops 2") {
+		t.Errorf("hop limit = %+v", res)
+	}
+	res, _ = tool.Execute(context.Background(), map[string]interface{}{"peer": "prod", "task": "x"})
+	if res.Success || !strings.Contains(res.Error, "unknown peer") {
+		t.Errorf("unknown peer = %+v", res)
+	}
+}
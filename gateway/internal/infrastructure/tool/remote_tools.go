@@ -0,0 +1,680 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// RemoteHost 一台登记过的远程主机 (agent.tools.remote.hosts)
+type RemoteHost struct {
+	Alias       string // 工具参数里使用的名字, 如 "prod-web"
+	Addr        string // host:port
+	User        string
+	KeyPath     string // 私钥路径; 为空时使用 ssh-agent (SSH_AUTH_SOCK)
+	WorkDir     string // 相对路径的基准目录, 为空则为登录目录
+	Description string // 给模型看的用途说明
+	ReadOnly    bool   // 只允许 read/list/download, 禁止写入和 remote_exec
+	// InsecureIgnoreHostKey 跳过 known_hosts 校验 (仅限测试环境)
+	InsecureIgnoreHostKey bool
+}
+
+// RemoteConfig remote_file / remote_exec 工具配置
+type RemoteConfig struct {
+	Hosts          []RemoteHost
+	KnownHosts     string        // 默认 ~/.ssh/known_hosts
+	ConnectTimeout time.Duration // 默认 10s
+	CommandTimeout time.Duration // remote_exec 默认超时, 默认 60s
+	LocalDir       func() string // upload/download 本地相对路径的基准目录, nil = 进程工作目录
+}
+
+const (
+	remoteReadLimit     = 256 * 1024       // read 返回给模型的最大字节数
+	remoteTransferLimit = 20 * 1024 * 1024 // upload/download/edit 的最大文件大小
+	remoteOutputLimit   = 512 * 1024       // remote_exec 的 stdout/stderr 上限
+)
+
+// RemoteHosts 管理到各远程主机的 SSH 连接 (按 alias 复用, 断开后自动重连)
+type RemoteHosts struct {
+	cfg     RemoteConfig
+	hosts   map[string]*RemoteHost
+	clients map[string]*ssh.Client
+	mu      sync.Mutex
+	logger  *zap.Logger
+}
+
+// NewRemoteHosts 创建远程主机连接池; 连接在首次使用时建立
+func NewRemoteHosts(cfg RemoteConfig, logger *zap.Logger) *RemoteHosts {
+	if cfg.ConnectTimeout <= 0 {
+		cfg.ConnectTimeout = 10 * time.Second
+	}
+	if cfg.CommandTimeout <= 0 {
+		cfg.CommandTimeout = 60 * time.Second
+	}
+	if cfg.KnownHosts == "" {
+		cfg.KnownHosts = "~/.ssh/known_hosts"
+	}
+	hosts := make(map[string]*RemoteHost, len(cfg.Hosts))
+	for i := range cfg.Hosts {
+		h := &cfg.Hosts[i]
+		if h.Addr != "" && !strings.Contains(h.Addr, ":") {
+			h.Addr = net.JoinHostPort(h.Addr, "22")
+		}
+		hosts[h.Alias] = h
+	}
+	return &RemoteHosts{
+		cfg:     cfg,
+		hosts:   hosts,
+		clients: make(map[string]*ssh.Client),
+		logger:  logger,
+	}
+}
+
+// Close 关闭所有连接
+func (r *RemoteHosts) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for alias, c := range r.clients {
+		c.Close()
+		delete(r.clients, alias)
+	}
+}
+
+// aliases 返回登记的主机名 (配置顺序)
+func (r *RemoteHosts) aliases() []string {
+	names := make([]string, 0, len(r.cfg.Hosts))
+	for _, h := range r.cfg.Hosts {
+		names = append(names, h.Alias)
+	}
+	return names
+}
+
+// describeHosts 生成工具描述里的主机列表
+func (r *RemoteHosts) describeHosts() string {
+	var sb strings.Builder
+	sb.WriteString("Registered hosts:")
+	for _, h := range r.cfg.Hosts {
+		sb.WriteString("\n- " + h.Alias + " (" + h.User + "@" + h.Addr)
+		if h.ReadOnly {
+			sb.WriteString(", read-only")
+		}
+		sb.WriteString(")")
+		if h.Description != "" {
+			sb.WriteString(": " + h.Description)
+		}
+	}
+	return sb.String()
+}
+
+func (r *RemoteHosts) lookup(args map[string]interface{}) (*RemoteHost, error) {
+	alias, _ := args["host"].(string)
+	h, ok := r.hosts[alias]
+	if !ok {
+		return nil, fmt.Errorf("unknown host %q (registered: %s)", alias, strings.Join(r.aliases(), ", "))
+	}
+	return h, nil
+}
+
+// client 返回到主机的连接, 必要时拨号
+func (r *RemoteHosts) client(ctx context.Context, h *RemoteHost) (*ssh.Client, error) {
+	r.mu.Lock()
+	if c, ok := r.clients[h.Alias]; ok {
+		r.mu.Unlock()
+		return c, nil
+	}
+	r.mu.Unlock()
+
+	auth, err := remoteAuth(h)
+	if err != nil {
+		return nil, err
+	}
+	hostKey, err := r.hostKeyCallback(h)
+	if err != nil {
+		return nil, err
+	}
+	sshCfg := &ssh.ClientConfig{
+		User:            h.User,
+		Auth:            auth,
+		HostKeyCallback: hostKey,
+		Timeout:         r.cfg.ConnectTimeout,
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, r.cfg.ConnectTimeout)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(dialCtx, "tcp", h.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("connect %s (%s): %w", h.Alias, h.Addr, err)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, h.Addr, sshCfg)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ssh handshake with %s: %w", h.Alias, err)
+	}
+	c := ssh.NewClient(sshConn, chans, reqs)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.clients[h.Alias]; ok {
+		c.Close() // 并发拨号, 保留先建立的连接
+		return existing, nil
+	}
+	r.clients[h.Alias] = c
+	r.logger.Info("Remote host connected", zap.String("host", h.Alias), zap.String("addr", h.Addr))
+	return c, nil
+}
+
+// drop 丢弃失效的连接, 下次使用时重连
+func (r *RemoteHosts) drop(h *RemoteHost, c *ssh.Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.clients[h.Alias] == c {
+		delete(r.clients, h.Alias)
+	}
+	c.Close()
+}
+
+func (r *RemoteHosts) hostKeyCallback(h *RemoteHost) (ssh.HostKeyCallback, error) {
+	if h.InsecureIgnoreHostKey {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	cb, err := knownhosts.New(expandHome(r.cfg.KnownHosts))
+	if err != nil {
+		return nil, fmt.Errorf("load known_hosts %s: %w (run `ssh %s@%s` once to trust the host)", r.cfg.KnownHosts, err, h.User, h.Addr)
+	}
+	return cb, nil
+}
+
+// remoteAuth 私钥优先, 否则使用 ssh-agent
+func remoteAuth(h *RemoteHost) ([]ssh.AuthMethod, error) {
+	if h.KeyPath != "" {
+		key, err := os.ReadFile(expandHome(h.KeyPath))
+		if err != nil {
+			return nil, fmt.Errorf("read key for %s: %w", h.Alias, err)
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			return nil, fmt.Errorf("key %s for %s is passphrase-protected; add it to ssh-agent and leave key_path empty", h.KeyPath, h.Alias)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse key for %s: %w", h.Alias, err)
+		}
+		return []ssh.AuthMethod{ssh.PublicKeys(signer)}, nil
+	}
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		conn, err := net.Dial("unix", sock)
+		if err == nil {
+			return []ssh.AuthMethod{ssh.PublicKeysCallback(agent.NewClient(conn).Signers)}, nil
+		}
+	}
+	return nil, fmt.Errorf("host %s has no key_path and no ssh-agent is available", h.Alias)
+}
+
+// remoteRunResult 一次远程命令的结果
+type remoteRunResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Killed   bool // 超时或取消后被终止
+	Duration time.Duration
+}
+
+// run 在主机上执行 shell 命令; 设置了 WorkDir 时先 cd 过去
+func (r *RemoteHosts) run(ctx context.Context, h *RemoteHost, command string, stdin io.Reader, limit int) (*remoteRunResult, error) {
+	if h.WorkDir != "" {
+		command = "cd " + shellQuote(h.WorkDir) + " && " + command
+	}
+
+	c, err := r.client(ctx, h)
+	if err != nil {
+		return nil, err
+	}
+	session, err := c.NewSession()
+	if err != nil {
+		// 连接已断开: 重连一次
+		r.drop(h, c)
+		if c, err = r.client(ctx, h); err != nil {
+			return nil, err
+		}
+		if session, err = c.NewSession(); err != nil {
+			r.drop(h, c)
+			return nil, fmt.Errorf("open session on %s: %w", h.Alias, err)
+		}
+	}
+	defer session.Close()
+
+	stdout := &cappedBuffer{limit: limit}
+	stderr := &cappedBuffer{limit: remoteOutputLimit}
+	session.Stdout = stdout
+	session.Stderr = stderr
+	if stdin != nil {
+		session.Stdin = stdin
+	}
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- session.Run(command) }()
+
+	res := &remoteRunResult{}
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		session.Signal(ssh.SIGKILL)
+		session.Close()
+		res.Killed = true
+		res.ExitCode = 124
+		err = nil
+	}
+	res.Duration = time.Since(start)
+	res.Stdout = stdout.String()
+	res.Stderr = stderr.String()
+	if stdout.truncated {
+		res.Stdout += "\n[output truncated]"
+	}
+
+	var exitErr *ssh.ExitError
+	var missingErr *ssh.ExitMissingError
+	switch {
+	case res.Killed:
+	case err == nil:
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitStatus()
+	case errors.As(err, &missingErr):
+		res.ExitCode = -1
+	default:
+		r.drop(h, c)
+		return nil, fmt.Errorf("run on %s: %w", h.Alias, err)
+	}
+	return res, nil
+}
+
+// cappedBuffer 超过 limit 后丢弃后续输出
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room < len(p) {
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *cappedBuffer) String() string { return b.buf.String() }
+
+// remoteFailure 把非零退出码转成带 exit_code 的失败结果 (与 bash 工具一致)
+func remoteFailure(h *RemoteHost, res *remoteRunResult, msg string) *Result {
+	if res.Killed {
+		msg = "timed out after " + res.Duration.Round(time.Second).String()
+	}
+	if msg == "" {
+		msg = strings.TrimSpace(res.Stderr)
+	}
+	if msg == "" {
+		msg = "exit code " + strconv.Itoa(res.ExitCode)
+	}
+	return &Result{
+		Success: false,
+		Output:  res.Stdout,
+		Error:   h.Alias + ": " + msg,
+		Metadata: map[string]interface{}{
+			"host":      h.Alias,
+			"exit_code": res.ExitCode,
+			"killed":    res.Killed,
+		},
+	}
+}
+
+// ─── remote_exec ───
+
+// RemoteExecTool 在登记的远程主机上执行命令
+type RemoteExecTool struct {
+	hosts  *RemoteHosts
+	logger *zap.Logger
+}
+
+// NewRemoteExecTool 创建 remote_exec 工具
+func NewRemoteExecTool(hosts *RemoteHosts, logger *zap.Logger) *RemoteExecTool {
+	return &RemoteExecTool{hosts: hosts, logger: logger}
+}
+
+func (t *RemoteExecTool) Name() string          { return "remote_exec" }
+func (t *RemoteExecTool) Kind() domaintool.Kind { return domaintool.KindExecute }
+
+func (t *RemoteExecTool) Description() string {
+	return `Run a shell command on a registered remote server over SSH (no need for ssh in bash).
+Connections are reused; relative paths start in the host's configured work_dir.
+Exit code 124 means the command timed out. Avoid interactive commands (top, vim, tail -f).
+` + t.hosts.describeHosts()
+}
+
+func (t *RemoteExecTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"host": map[string]interface{}{
+				"type":        "string",
+				"description": "Host alias",
+				"enum":        t.hosts.aliases(),
+			},
+			"command": map[string]interface{}{
+				"type":        "string",
+				"description": "Shell command to run on the host",
+			},
+			"timeout": map[string]interface{}{
+				"type":        "integer",
+				"description": "Timeout in seconds (default 60)",
+			},
+		},
+		"required": []string{"host", "command"},
+	}
+}
+
+func (t *RemoteExecTool) Execute(ctx context.Context, args map[string]interface{}) (*Result, error) {
+	h, err := t.hosts.lookup(args)
+	if err != nil {
+		return &Result{Success: false, Error: err.Error()}, nil
+	}
+	if h.ReadOnly {
+		return &Result{Success: false, Error: h.Alias + " is read-only: remote_exec is not allowed"}, nil
+	}
+	command, _ := args["command"].(string)
+	if strings.TrimSpace(command) == "" {
+		return &Result{Success: false, Error: "command is required"}, nil
+	}
+
+	timeout := t.hosts.cfg.CommandTimeout
+	if secs := intArg(args, "timeout", 0); secs > 0 {
+		timeout = time.Duration(secs) * time.Second
+	}
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	t.logger.Info("Executing remote command",
+		zap.String("host", h.Alias),
+		zap.String("command", command),
+	)
+
+	res, err := t.hosts.run(runCtx, h, command, nil, remoteOutputLimit)
+	if err != nil {
+		return &Result{Success: false, Error: err.Error()}, nil
+	}
+	if res.Killed || res.ExitCode != 0 {
+		out := remoteFailure(h, res, "")
+		if res.Stderr != "" {
+			out.Output = strings.TrimSpace(res.Stdout + "\n[stderr]\n" + res.Stderr)
+		}
+		return out, nil
+	}
+
+	output := res.Stdout
+	if res.Stderr != "" {
+		output += "\n[stderr]\n" + res.Stderr
+	}
+	return &Result{
+		Success: true,
+		Output:  output,
+		Metadata: map[string]interface{}{
+			"host":      h.Alias,
+			"exit_code": 0,
+			"duration":  res.Duration.String(),
+		},
+	}, nil
+}
+
+// ─── remote_file ───
+
+// RemoteFileTool 读写远程主机上的文件, 并在本地与远程之间传输文件
+type RemoteFileTool struct {
+	hosts  *RemoteHosts
+	logger *zap.Logger
+}
+
+// NewRemoteFileTool 创建 remote_file 工具
+func NewRemoteFileTool(hosts *RemoteHosts, logger *zap.Logger) *RemoteFileTool {
+	return &RemoteFileTool{hosts: hosts, logger: logger}
+}
+
+func (t *RemoteFileTool) Name() string          { return "remote_file" }
+func (t *RemoteFileTool) Kind() domaintool.Kind { return domaintool.KindEdit }
+
+func (t *RemoteFileTool) Description() string {
+	return `Read, write or edit files on a registered remote server over SSH, and copy files between this machine and the server.
+Actions:
+- read: return the file content (first 256KB)
+- list: list a directory (ls -la)
+- write: create or overwrite the file with content
+- edit: replace old_text with new_text; old_text must occur exactly once
+- upload: copy local_path (this machine) to path (remote)
+- download: copy path (remote) to local_path (this machine)
+` + t.hosts.describeHosts()
+}
+
+func (t *RemoteFileTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"host": map[string]interface{}{
+				"type":        "string",
+				"description": "Host alias",
+				"enum":        t.hosts.aliases(),
+			},
+			"action": map[string]interface{}{
+				"type": "string",
+				"enum": remoteFileActions,
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Remote path (relative paths start in the host's work_dir)",
+			},
+			"content": map[string]interface{}{
+				"type":        "string",
+				"description": "File content for write",
+			},
+			"old_text": map[string]interface{}{
+				"type":        "string",
+				"description": "Exact text to replace (edit)",
+			},
+			"new_text": map[string]interface{}{
+				"type":        "string",
+				"description": "Replacement text (edit)",
+			},
+			"local_path": map[string]interface{}{
+				"type":        "string",
+				"description": "Local file for upload/download",
+			},
+		},
+		"required": []string{"host", "action", "path"},
+	}
+}
+
+// remoteFileActions remote_file 支持的操作
+var remoteFileActions = []string{"read", "list", "write", "edit", "upload", "download"}
+
+// remoteFileReadOnly 不修改远程主机的操作 (read_only 主机只允许这些)
+func remoteFileReadOnly(action string) bool {
+	switch action {
+	case "read", "list", "download":
+		return true
+	}
+	return false
+}
+
+func (t *RemoteFileTool) Execute(ctx context.Context, args map[string]interface{}) (*Result, error) {
+	h, err := t.hosts.lookup(args)
+	if err != nil {
+		return &Result{Success: false, Error: err.Error()}, nil
+	}
+	action, _ := args["action"].(string)
+	remotePath, _ := args["path"].(string)
+	if remotePath == "" {
+		return &Result{Success: false, Error: "path is required"}, nil
+	}
+	if h.ReadOnly && !remoteFileReadOnly(action) {
+		return &Result{Success: false, Error: h.Alias + " is read-only: " + action + " is not allowed"}, nil
+	}
+
+	t.logger.Info("Remote file operation",
+		zap.String("host", h.Alias),
+		zap.String("action", action),
+		zap.String("path", remotePath),
+	)
+
+	switch action {
+	case "read":
+		return t.read(ctx, h, remotePath)
+	case "list":
+		res, err := t.hosts.run(ctx, h, "ls -la -- "+shellQuote(remotePath), nil, remoteReadLimit)
+		if err != nil {
+			return &Result{Success: false, Error: err.Error()}, nil
+		}
+		if res.ExitCode != 0 {
+			return remoteFailure(h, res, ""), nil
+		}
+		return &Result{Success: true, Output: res.Stdout}, nil
+	case "write":
+		content, _ := args["content"].(string)
+		return t.write(ctx, h, remotePath, []byte(content))
+	case "edit":
+		return t.edit(ctx, h, remotePath, args)
+	case "upload":
+		local, err := t.localPath(args)
+		if err != nil {
+			return &Result{Success: false, Error: err.Error()}, nil
+		}
+		info, err := os.Stat(local)
+		if err != nil {
+			return &Result{Success: false, Error: err.Error()}, nil
+		}
+		if info.Size() > remoteTransferLimit {
+			return &Result{Success: false, Error: fmt.Sprintf("%s is %d bytes, over the %d byte transfer limit", local, info.Size(), remoteTransferLimit)}, nil
+		}
+		data, err := os.ReadFile(local)
+		if err != nil {
+			return &Result{Success: false, Error: err.Error()}, nil
+		}
+		return t.write(ctx, h, remotePath, data)
+	case "download":
+		local, err := t.localPath(args)
+		if err != nil {
+			return &Result{Success: false, Error: err.Error()}, nil
+		}
+		data, res, err := t.cat(ctx, h, remotePath, remoteTransferLimit)
+		if err != nil {
+			return &Result{Success: false, Error: err.Error()}, nil
+		}
+		if res.ExitCode != 0 {
+			return remoteFailure(h, res, ""), nil
+		}
+		if err := os.MkdirAll(filepath.Dir(local), 0755); err != nil {
+			return &Result{Success: false, Error: err.Error()}, nil
+		}
+		if err := os.WriteFile(local, data, 0644); err != nil {
+			return &Result{Success: false, Error: err.Error()}, nil
+		}
+		return &Result{Success: true, Output: fmt.Sprintf("Downloaded %s:%s → %s (%d bytes)", h.Alias, remotePath, local, len(data))}, nil
+	}
+	return &Result{Success: false, Error: fmt.Sprintf("unknown action %q (want one of %s)", action, strings.Join(remoteFileActions, ", "))}, nil
+}
+
+// cat 读取远程文件, 超过 limit 报错 (而不是静默截断)
+func (t *RemoteFileTool) cat(ctx context.Context, h *RemoteHost, remotePath string, limit int) ([]byte, *remoteRunResult, error) {
+	res, err := t.hosts.run(ctx, h, "head -c "+strconv.Itoa(limit+1)+" -- "+shellQuote(remotePath), nil, limit+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(res.Stdout) > limit {
+		return nil, nil, fmt.Errorf("%s:%s is larger than %d bytes", h.Alias, remotePath, limit)
+	}
+	return []byte(res.Stdout), res, nil
+}
+
+func (t *RemoteFileTool) read(ctx context.Context, h *RemoteHost, remotePath string) (*Result, error) {
+	res, err := t.hosts.run(ctx, h, "head -c "+strconv.Itoa(remoteReadLimit+1)+" -- "+shellQuote(remotePath), nil, remoteReadLimit+1)
+	if err != nil {
+		return &Result{Success: false, Error: err.Error()}, nil
+	}
+	if res.ExitCode != 0 {
+		return remoteFailure(h, res, ""), nil
+	}
+	content := res.Stdout
+	if len(content) > remoteReadLimit {
+		content = content[:remoteReadLimit] + "\n[truncated at 256KB — use remote_exec with sed -n or grep for the rest]"
+	}
+	return &Result{Success: true, Output: content, Metadata: map[string]interface{}{"host": h.Alias}}, nil
+}
+
+func (t *RemoteFileTool) write(ctx context.Context, h *RemoteHost, remotePath string, data []byte) (*Result, error) {
+	command := "cat > " + shellQuote(remotePath)
+	if dir := path.Dir(remotePath); dir != "." && dir != "/" {
+		command = "mkdir -p -- " + shellQuote(dir) + " && " + command
+	}
+	res, err := t.hosts.run(ctx, h, command, bytes.NewReader(data), remoteReadLimit)
+	if err != nil {
+		return &Result{Success: false, Error: err.Error()}, nil
+	}
+	if res.ExitCode != 0 {
+		return remoteFailure(h, res, ""), nil
+	}
+	return &Result{Success: true, Output: fmt.Sprintf("Wrote %d bytes to %s:%s", len(data), h.Alias, remotePath)}, nil
+}
+
+func (t *RemoteFileTool) edit(ctx context.Context, h *RemoteHost, remotePath string, args map[string]interface{}) (*Result, error) {
+	oldText, _ := args["old_text"].(string)
+	newText, _ := args["new_text"].(string)
+	if oldText == "" {
+		return &Result{Success: false, Error: "old_text is required for edit"}, nil
+	}
+	data, res, err := t.cat(ctx, h, remotePath, remoteTransferLimit)
+	if err != nil {
+		return &Result{Success: false, Error: err.Error()}, nil
+	}
+	if res.ExitCode != 0 {
+		return remoteFailure(h, res, ""), nil
+	}
+	content := string(data)
+	switch n := strings.Count(content, oldText); n {
+	case 1:
+	case 0:
+		return &Result{Success: false, Error: "old_text not found in " + h.Alias + ":" + remotePath + ". Make sure it matches exactly, including whitespace."}, nil
+	default:
+		return &Result{Success: false, Error: fmt.Sprintf("old_text found %d times in file. It must be unique. Provide more context to make it unique.", n)}, nil
+	}
+	return t.write(ctx, h, remotePath, []byte(strings.Replace(content, oldText, newText, 1)))
+}
+
+func (t *RemoteFileTool) localPath(args map[string]interface{}) (string, error) {
+	local, _ := args["local_path"].(string)
+	if local == "" {
+		return "", fmt.Errorf("local_path is required for upload/download")
+	}
+	local = expandHome(local)
+	if !filepath.IsAbs(local) && t.hosts.cfg.LocalDir != nil {
+		if base := t.hosts.cfg.LocalDir(); base != "" {
+			local = filepath.Join(base, local)
+		}
+	}
+	return filepath.Abs(local)
+}
@@ -0,0 +1,197 @@
+package tool
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+)
+
+// startTestSSHServer 启动一个进程内 SSH 服务器, exec 请求交给本地 sh -c 执行
+func startTestSSHServer(t *testing.T) (addr string, keyPath string) {
+	t.Helper()
+
+	_, hostPriv, _ := ed25519.GenerateKey(rand.Reader)
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientPub, clientPriv, _ := ed25519.GenerateKey(rand.Reader)
+	authorized, _ := ssh.NewPublicKey(clientPub)
+
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath = filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("unknown key")
+		},
+	}
+	cfg.AddHostKey(hostSigner)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestSSH(conn, cfg)
+		}
+	}()
+	return ln.Addr().String(), keyPath
+}
+
+func serveTestSSH(conn net.Conn, cfg *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, cfg)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for nc := range chans {
+		if nc.ChannelType() != "session" {
+			nc.Reject(ssh.UnknownChannelType, "session only")
+			continue
+		}
+		ch, requests, err := nc.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			defer ch.Close()
+			for req := range requests {
+				if req.Type != "exec" {
+					req.Reply(false, nil)
+					continue
+				}
+				n := binary.BigEndian.Uint32(req.Payload)
+				req.Reply(true, nil)
+
+				cmd := exec.Command("sh", "-c", string(req.Payload[4:4+n]))
+				cmd.Stdin = ch
+				cmd.Stdout = ch
+				cmd.Stderr = ch.Stderr()
+				code := 0
+				if err := cmd.Run(); err != nil {
+					code = 1
+					var exitErr *exec.ExitError
+					if errors.As(err, &exitErr) {
+						code = exitErr.ExitCode()
+					}
+				}
+				status := make([]byte, 4)
+				binary.BigEndian.PutUint32(status, uint32(code))
+				ch.SendRequest("exit-status", false, status)
+				return
+			}
+		}()
+	}
+}
+
+func newTestRemoteHosts(t *testing.T) (*RemoteHosts, string) {
+	addr, keyPath := startTestSSHServer(t)
+	workDir := t.TempDir()
+	hosts := NewRemoteHosts(RemoteConfig{
+		Hosts: []RemoteHost{
+			{Alias: "box", Addr: addr, User: "test", KeyPath: keyPath, WorkDir: workDir, InsecureIgnoreHostKey: true},
+			{Alias: "ro", Addr: addr, User: "test", KeyPath: keyPath, WorkDir: workDir, ReadOnly: true, InsecureIgnoreHostKey: true},
+		},
+	}, zap.NewNop())
+	t.Cleanup(hosts.Close)
+	return hosts, workDir
+}
+
+func TestRemoteExecTool(t *testing.T) {
+	hosts, workDir := newTestRemoteHosts(t)
+	tool := NewRemoteExecTool(hosts, zap.NewNop())
+	ctx := context.Background()
+
+	res, _ := tool.Execute(ctx, map[string]interface{}{"host": "box", "command": "pwd"})
+	if !res.Success || strings.TrimSpace(res.Output) != workDir {
+		t.Fatalf("pwd: %+v", res)
+	}
+
+	res, _ = tool.Execute(ctx, map[string]interface{}{"host": "box", "command": "echo oops >&2; exit 3"})
+	if res.Success || res.Metadata["exit_code"] != 3 || !strings.Contains(res.Error, "oops") {
+		t.Fatalf("failing command: %+v", res)
+	}
+
+	res, _ = tool.Execute(ctx, map[string]interface{}{"host": "ro", "command": "touch x"})
+	if res.Success || !strings.Contains(res.Error, "read-only") {
+		t.Fatalf("read-only host ran command: %+v", res)
+	}
+
+	res, _ = tool.Execute(ctx, map[string]interface{}{"host": "nope", "command": "true"})
+	if res.Success || !strings.Contains(res.Error, "registered: box, ro") {
+		t.Fatalf("unknown host: %+v", res)
+	}
+}
+
+func TestRemoteFileTool(t *testing.T) {
+	hosts, workDir := newTestRemoteHosts(t)
+	tool := NewRemoteFileTool(hosts, zap.NewNop())
+	ctx := context.Background()
+
+	res, _ := tool.Execute(ctx, map[string]interface{}{"host": "box", "action": "write", "path": "conf/app.ini", "content": "port = 80\nname = it's\n"})
+	if !res.Success {
+		t.Fatalf("write: %+v", res)
+	}
+
+	res, _ = tool.Execute(ctx, map[string]interface{}{"host": "box", "action": "edit", "path": "conf/app.ini", "old_text": "port = 80", "new_text": "port = 8080"})
+	if !res.Success {
+		t.Fatalf("edit: %+v", res)
+	}
+	data, _ := os.ReadFile(filepath.Join(workDir, "conf", "app.ini"))
+	if string(data) != "port = 8080\nname = it's\n" {
+		t.Fatalf("remote content = %q", data)
+	}
+
+	res, _ = tool.Execute(ctx, map[string]interface{}{"host": "ro", "action": "read", "path": "conf/app.ini"})
+	if !res.Success || res.Output != string(data) {
+		t.Fatalf("read: %+v", res)
+	}
+
+	res, _ = tool.Execute(ctx, map[string]interface{}{"host": "ro", "action": "write", "path": "x", "content": "y"})
+	if res.Success || !strings.Contains(res.Error, "read-only") {
+		t.Fatalf("read-only host accepted write: %+v", res)
+	}
+
+	local := filepath.Join(t.TempDir(), "copy.ini")
+	res, _ = tool.Execute(ctx, map[string]interface{}{"host": "box", "action": "download", "path": "conf/app.ini", "local_path": local})
+	if !res.Success {
+		t.Fatalf("download: %+v", res)
+	}
+	if got, _ := os.ReadFile(local); string(got) != string(data) {
+		t.Fatalf("downloaded %q", got)
+	}
+
+	res, _ = tool.Execute(ctx, map[string]interface{}{"host": "box", "action": "read", "path": "missing.txt"})
+	if res.Success {
+		t.Fatalf("read of missing file succeeded: %+v", res)
+	}
+}
@@ -17,14 +17,22 @@ import (
 
 // RepoMapTool generates a structural map of a codebase (functions, classes, interfaces).
 // Uses Go's built-in AST parser for .go files, regex-based grep for Python/JS/TS.
+// With a workspace index attached, paths inside the workspace are served from
+// the index instead of walking and parsing on every call.
 type RepoMapTool struct {
 	logger *zap.Logger
+	index  *WorkspaceIndex
 }
 
 func NewRepoMapTool(logger *zap.Logger) *RepoMapTool {
 	return &RepoMapTool{logger: logger}
 }
 
+// SetWorkspaceIndex serves repo maps from the workspace index once it is ready.
+func (t *RepoMapTool) SetWorkspaceIndex(index *WorkspaceIndex) {
+	t.index = index
+}
+
 func (t *RepoMapTool) Name() string        { return "repo_map" }
 func (t *RepoMapTool) Kind() domaintool.Kind { return domaintool.KindRead }
 
@@ -98,7 +106,22 @@ func (t *RepoMapTool) Execute(ctx context.Context, args map[string]interface{})
 	var files []string
 	baseDepth := strings.Count(filepath.Clean(rootPath), string(os.PathSeparator))
 
-	if err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+	absRoot, _ := filepath.Abs(rootPath)
+	indexed := t.index.Covers(absRoot)
+	if indexed {
+		for _, file := range t.index.Files(absRoot, maxDepth) {
+			if !matchLanguage(filepath.Ext(file), lang) {
+				continue
+			}
+			if filterPattern != "" {
+				if matched, _ := filepath.Match(filterPattern, filepath.Base(file)); !matched {
+					continue
+				}
+			}
+			rel, _ := filepath.Rel(absRoot, file)
+			files = append(files, filepath.Join(rootPath, rel))
+		}
+	} else if err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			t.logger.Warn("Error accessing path during walk",
 				zap.String("path", path),
@@ -108,8 +131,7 @@ func (t *RepoMapTool) Execute(ctx context.Context, args map[string]interface{})
 		}
 		// Skip hidden dirs and common noise
 		if info.IsDir() {
-			base := filepath.Base(path)
-			if strings.HasPrefix(base, ".") || base == "node_modules" || base == "vendor" || base == "__pycache__" {
+			if skipRepoMapDir(filepath.Base(path)) {
 				return filepath.SkipDir
 			}
 			depth := strings.Count(filepath.Clean(path), string(os.PathSeparator)) - baseDepth
@@ -152,18 +174,13 @@ func (t *RepoMapTool) Execute(ctx context.Context, args map[string]interface{})
 
 	for _, file := range files {
 		relPath, _ := filepath.Rel(rootPath, file)
-		ext := filepath.Ext(file)
-
 		var symbols []string
-		switch ext {
-		case ".go":
-			symbols = parseGoFile(file)
-		case ".py":
-			symbols = parsePythonFile(file)
-		case ".js", ".ts", ".jsx", ".tsx":
-			symbols = parseJSFile(file)
-		default:
-			symbols = parseGenericFile(file)
+		cached := false
+		if indexed {
+			symbols, cached = t.index.MapSymbols(filepath.Join(absRoot, relPath))
+		}
+		if !cached {
+			symbols = repoMapSymbols(file)
 		}
 
 		if len(symbols) > 0 {
@@ -185,10 +202,31 @@ func (t *RepoMapTool) Execute(ctx context.Context, args map[string]interface{})
 		Success: true,
 		Metadata: map[string]interface{}{
 			"files_scanned": len(files),
+			"indexed":       indexed,
 		},
 	}, nil
 }
 
+// skipRepoMapDir reports whether a directory is noise for the repo map
+// (hidden dirs, dependency and cache directories).
+func skipRepoMapDir(base string) bool {
+	return strings.HasPrefix(base, ".") || base == "node_modules" || base == "vendor" || base == "__pycache__"
+}
+
+// repoMapSymbols extracts the symbol lines for one file, dispatching on extension.
+func repoMapSymbols(file string) []string {
+	switch filepath.Ext(file) {
+	case ".go":
+		return parseGoFile(file)
+	case ".py":
+		return parsePythonFile(file)
+	case ".js", ".ts", ".jsx", ".tsx":
+		return parseJSFile(file)
+	default:
+		return parseGenericFile(file)
+	}
+}
+
 // matchLanguage checks if a file extension matches the requested language filter.
 func matchLanguage(ext, lang string) bool {
 	switch lang {
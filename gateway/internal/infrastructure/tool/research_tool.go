@@ -0,0 +1,417 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultResearchBudget = 3 * time.Minute
+	maxResearchBudget     = 10 * time.Minute
+	maxResearchAngles     = 5
+)
+
+// researchURLRe extracts http(s) URLs from sub-agent findings.
+var researchURLRe = regexp.MustCompile(`https?://[^\s<>"'\)\]]+`)
+
+// researchTools is the tool subset research sub-agents may use.
+var researchTools = map[string]bool{"web_search": true, "web_fetch": true}
+
+// ResearchTool runs a time-boxed multi-angle web research:
+//  1. plan — split the topic into a few search angles
+//  2. gather — one web_search/web_fetch sub-agent per angle, in parallel
+//  3. cite — deduplicate source URLs and number them
+//  4. synthesize — answer with [n] citations, save the dossier as an artifact
+type ResearchTool struct {
+	llm          service.LLMClient
+	tools        service.ToolExecutor
+	defaultModel string
+	artifactDir  string
+	logger       *zap.Logger
+}
+
+// DefaultArtifactDir is where research dossiers are written (~/.ngoclaw/research).
+func DefaultArtifactDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ngoclaw", "research")
+}
+
+// ChatArtifactDir is the subdirectory of root holding the artifacts produced
+// in a chat, so retention policies and /forgetme can find them.
+func ChatArtifactDir(root string, chatID int64) string {
+	return filepath.Join(root, fmt.Sprintf("chat-%d", chatID))
+}
+
+// NewResearchTool creates the research tool. Dossiers are written to artifactDir
+// (default ~/.ngoclaw/research), under ChatArtifactDir when run from a chat.
+func NewResearchTool(llm service.LLMClient, tools service.ToolExecutor, defaultModel, artifactDir string, logger *zap.Logger) *ResearchTool {
+	if artifactDir == "" {
+		artifactDir = DefaultArtifactDir()
+	}
+	return &ResearchTool{
+		llm:          llm,
+		tools:        tools,
+		defaultModel: defaultModel,
+		artifactDir:  artifactDir,
+		logger:       logger,
+	}
+}
+
+func (t *ResearchTool) Name() string          { return "research" }
+func (t *ResearchTool) Kind() domaintool.Kind { return domaintool.KindSearch }
+
+func (t *ResearchTool) Description() string {
+	return "Time-boxed research on a topic: searches several angles in parallel, deduplicates sources, " +
+		"and returns an answer with numbered citations and URLs. The dossier is saved as a markdown artifact. " +
+		"Use when the user asks to research/investigate/compare something or sends /research <topic>; " +
+		"prefer plain web_search for a single quick lookup."
+}
+
+func (t *ResearchTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"topic": map[string]interface{}{
+				"type":        "string",
+				"description": "The research question or topic",
+			},
+			"angles": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Optional search angles / sub-questions (default: planned automatically)",
+			},
+			"time_budget": map[string]interface{}{
+				"type":        "integer",
+				"description": "Time budget in seconds (default 180, max 600)",
+			},
+		},
+		"required": []string{"topic"},
+	}
+}
+
+// ResearchPrompt builds the agent instruction used by the /research command.
+func ResearchPrompt(topic string) string {
+	return "Use the research tool to research the following topic, then present its answer " +
+		"with the numbered citations and source URLs intact.\n\nTopic: " + topic
+}
+
+// researchFinding is one angle's raw sub-agent output.
+type researchFinding struct {
+	Angle  string
+	Output string
+	Err    error
+}
+
+func (t *ResearchTool) Execute(ctx context.Context, args map[string]interface{}) (*domaintool.Result, error) {
+	topic, _ := args["topic"].(string)
+	topic = strings.TrimSpace(topic)
+	if topic == "" {
+		return &domaintool.Result{Success: false, Error: "topic is required"}, nil
+	}
+
+	budget := defaultResearchBudget
+	if s, ok := args["time_budget"].(float64); ok && s > 0 {
+		budget = time.Duration(s) * time.Second
+		if budget > maxResearchBudget {
+			budget = maxResearchBudget
+		}
+	}
+
+	var angles []string
+	if raw, ok := args["angles"].([]interface{}); ok {
+		for _, a := range raw {
+			if s, ok := a.(string); ok && strings.TrimSpace(s) != "" {
+				angles = append(angles, strings.TrimSpace(s))
+			}
+		}
+	}
+
+	start := time.Now()
+	// Gathering gets ~75% of the budget; the rest is reserved for synthesis.
+	gatherCtx, cancelGather := context.WithTimeout(ctx, budget*3/4)
+	defer cancelGather()
+
+	if len(angles) == 0 {
+		angles = t.planAngles(gatherCtx, topic)
+	}
+	if len(angles) > maxResearchAngles {
+		angles = angles[:maxResearchAngles]
+	}
+
+	t.logger.Info("Research started",
+		zap.String("topic", truncateStr(topic, 100)),
+		zap.Int("angles", len(angles)),
+		zap.Duration("budget", budget),
+	)
+
+	findings := t.gather(gatherCtx, topic, angles)
+	sources, cited := citeFindings(findings)
+
+	synthCtx, cancelSynth := context.WithTimeout(ctx, budget-time.Since(start)+30*time.Second)
+	defer cancelSynth()
+	answer, err := t.synthesize(synthCtx, topic, cited, sources)
+	if err != nil {
+		t.logger.Warn("Research synthesis failed, returning raw findings", zap.Error(err))
+		answer = strings.Join(cited, "\n\n")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strings.TrimSpace(answer))
+	if len(sources) > 0 {
+		sb.WriteString("\n\nSources:\n")
+		for i, s := range sources {
+			sb.WriteString(fmt.Sprintf("[%d] %s\n", i+1, s))
+		}
+	}
+	report := sb.String()
+
+	artifact, err := t.saveDossier(chatIDFromContext(ctx), topic, angles, findings, report)
+	if err != nil {
+		t.logger.Warn("Failed to save research dossier", zap.Error(err))
+	} else {
+		report += fmt.Sprintf("\nDossier saved: %s\n", artifact)
+	}
+
+	return &domaintool.Result{
+		Output:  report,
+		Success: true,
+		Metadata: map[string]interface{}{
+			"angles":   angles,
+			"sources":  len(sources),
+			"artifact": artifact,
+			"elapsed":  time.Since(start).String(),
+		},
+	}, nil
+}
+
+// planAngles asks the LLM for 2-4 complementary search queries.
+func (t *ResearchTool) planAngles(ctx context.Context, topic string) []string {
+	resp, err := t.llm.Generate(ctx, &service.LLMRequest{
+		Model: t.defaultModel,
+		Messages: []service.LLMMessage{
+			{Role: "system", Content: "Split the research topic into 2-4 complementary web search queries. " +
+				"Reply with one query per line, nothing else."},
+			{Role: "user", Content: topic},
+		},
+		MaxTokens:   300,
+		Temperature: 0.3,
+	})
+	if err != nil {
+		t.logger.Debug("Research planning failed, using topic as single angle", zap.Error(err))
+		return []string{topic}
+	}
+
+	var angles []string
+	for _, line := range strings.Split(service.StripReasoningTags(resp.Content), "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "-*0123456789.) "))
+		if line != "" {
+			angles = append(angles, line)
+		}
+	}
+	if len(angles) == 0 {
+		return []string{topic}
+	}
+	return angles
+}
+
+// gather runs one restricted sub-agent per angle in parallel.
+func (t *ResearchTool) gather(ctx context.Context, topic string, angles []string) []researchFinding {
+	findings := make([]researchFinding, len(angles))
+	executor := &filteredExecutor{inner: t.tools, allow: researchTools}
+
+	system := "You are a research assistant. Use web_search (deep mode when useful) and web_fetch " +
+		"to answer the query. Report concise factual findings as bullet points. " +
+		"Every bullet MUST end with its source URL in the form (source: https://...). " +
+		"Do not include facts you could not attribute to a URL."
+
+	var wg sync.WaitGroup
+	for i, angle := range angles {
+		wg.Add(1)
+		go func(i int, angle string) {
+			defer wg.Done()
+			cfg := service.AgentLoopConfig{
+				DoomLoopThreshold:  3,
+				MaxOutputChars:     16000,
+				Temperature:        0.3,
+				StreamStallRetries: service.DefaultStreamStallRetries,
+				Model:              t.defaultModel,
+			}
+			loop := service.NewAgentLoop(t.llm, executor, cfg, t.logger.Named("research"))
+			subCtx := context.WithValue(ctx, depthKey{}, 1)
+			task := fmt.Sprintf("Overall topic: %s\nYour query: %s", topic, angle)
+			result, eventCh := loop.Run(subCtx, system, task, nil, "")
+			for range eventCh {
+			}
+			findings[i] = researchFinding{Angle: angle, Output: result.FinalContent}
+			if strings.TrimSpace(result.FinalContent) == "" && ctx.Err() != nil {
+				findings[i].Err = ctx.Err()
+			}
+		}(i, angle)
+	}
+	wg.Wait()
+	return findings
+}
+
+// synthesize writes the final answer from cited findings.
+func (t *ResearchTool) synthesize(ctx context.Context, topic string, cited []string, sources []string) (string, error) {
+	if len(cited) == 0 {
+		return "", fmt.Errorf("no findings gathered")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Topic: " + topic + "\n\nFindings (citation numbers refer to the source list):\n\n")
+	sb.WriteString(strings.Join(cited, "\n\n"))
+	sb.WriteString("\n\nSources:\n")
+	for i, s := range sources {
+		sb.WriteString(fmt.Sprintf("[%d] %s\n", i+1, s))
+	}
+
+	resp, err := t.llm.Generate(ctx, &service.LLMRequest{
+		Model: t.defaultModel,
+		Messages: []service.LLMMessage{
+			{Role: "system", Content: "Write a well-structured answer to the topic using ONLY the findings. " +
+				"Cite sources inline with their numbers like [1] or [2][3]. Point out disagreements between sources. " +
+				"Do not invent citations and do not append a source list — it is added automatically. " +
+				"Answer in the language of the topic."},
+			{Role: "user", Content: sb.String()},
+		},
+		MaxTokens:   2000,
+		Temperature: 0.3,
+	})
+	if err != nil {
+		return "", err
+	}
+	return service.StripReasoningTags(resp.Content), nil
+}
+
+// saveDossier stores the report and raw findings as a markdown artifact.
+func (t *ResearchTool) saveDossier(chatID int64, topic string, angles []string, findings []researchFinding, report string) (string, error) {
+	dir := t.artifactDir
+	if chatID != 0 {
+		dir = ChatArtifactDir(dir, chatID)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Research: " + topic + "\n\n")
+	sb.WriteString("_" + time.Now().Format("2006-01-02 15:04") + "_\n\n")
+	sb.WriteString(report)
+	sb.WriteString("\n\n---\n\n## Raw findings\n")
+	for i, f := range findings {
+		sb.WriteString(fmt.Sprintf("\n### %d. %s\n\n", i+1, angles[i]))
+		if f.Err != nil {
+			sb.WriteString(fmt.Sprintf("_error: %v_\n", f.Err))
+		}
+		sb.WriteString(f.Output + "\n")
+	}
+
+	name := fmt.Sprintf("%s-%s.md", time.Now().Format("20060102-150405"), researchSlug(topic))
+	file := filepath.Join(dir, name)
+	return file, os.WriteFile(file, []byte(sb.String()), 0644)
+}
+
+// citeFindings deduplicates URLs across findings and replaces them with [n].
+func citeFindings(findings []researchFinding) (sources []string, cited []string) {
+	index := make(map[string]int)
+	for _, f := range findings {
+		if strings.TrimSpace(f.Output) == "" {
+			continue
+		}
+		text := researchURLRe.ReplaceAllStringFunc(f.Output, func(raw string) string {
+			raw = strings.TrimRight(raw, ".,;:")
+			key := normalizeSourceURL(raw)
+			n, ok := index[key]
+			if !ok {
+				sources = append(sources, raw)
+				n = len(sources)
+				index[key] = n
+			}
+			return fmt.Sprintf("[%d]", n)
+		})
+		cited = append(cited, fmt.Sprintf("## %s\n%s", f.Angle, strings.TrimSpace(text)))
+	}
+	return sources, cited
+}
+
+// normalizeSourceURL canonicalizes a URL for deduplication: lowercase host,
+// no "www.", fragment, tracking params or trailing slash.
+func normalizeSourceURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.Scheme = "https"
+	u.Host = strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	u.Fragment = ""
+	q := u.Query()
+	for k := range q {
+		if strings.HasPrefix(k, "utm_") || k == "ref" || k == "fbclid" || k == "gclid" {
+			q.Del(k)
+		}
+	}
+	u.RawQuery = q.Encode()
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return u.String()
+}
+
+func researchSlug(topic string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(topic) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '_':
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "-") {
+				b.WriteRune('-')
+			}
+		}
+		if b.Len() >= 40 {
+			break
+		}
+	}
+	slug := strings.Trim(b.String(), "-")
+	if slug == "" {
+		slug = "topic"
+	}
+	return slug
+}
+
+// filteredExecutor exposes only an allow-listed subset of tools.
+type filteredExecutor struct {
+	inner service.ToolExecutor
+	allow map[string]bool
+}
+
+func (e *filteredExecutor) Execute(ctx context.Context, name string, args map[string]interface{}) (*domaintool.Result, error) {
+	if !e.allow[name] {
+		return &domaintool.Result{Success: false, Error: fmt.Sprintf("tool %s is not available in research mode", name)}, nil
+	}
+	return e.inner.Execute(ctx, name, args)
+}
+
+func (e *filteredExecutor) GetDefinitions() []domaintool.Definition {
+	var defs []domaintool.Definition
+	for _, d := range e.inner.GetDefinitions() {
+		if e.allow[d.Name] {
+			defs = append(defs, d)
+		}
+	}
+	return defs
+}
+
+func (e *filteredExecutor) GetToolKind(name string) domaintool.Kind {
+	return e.inner.GetToolKind(name)
+}
@@ -0,0 +1,48 @@
+package tool
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeSourceURL(t *testing.T) {
+	a := normalizeSourceURL("https://www.Example.com/post/?utm_source=x#top")
+	b := normalizeSourceURL("http://example.com/post")
+	if a != b {
+		t.Errorf("expected equal, got %q vs %q", a, b)
+	}
+	if normalizeSourceURL("https://example.com/a?id=1") == normalizeSourceURL("https://example.com/a?id=2") {
+		t.Error("meaningful query params must be kept")
+	}
+}
+
+func TestCiteFindings(t *testing.T) {
+	findings := []researchFinding{
+		{Angle: "pricing", Output: "- A costs $5 (source: https://a.com/pricing).\n- B costs $7 (source: https://b.com)"},
+		{Angle: "reviews", Output: "- A is fast (source: https://www.a.com/pricing/)"},
+		{Angle: "empty", Output: "  "},
+	}
+
+	sources, cited := citeFindings(findings)
+	if len(sources) != 2 {
+		t.Fatalf("expected 2 deduplicated sources, got %v", sources)
+	}
+	if len(cited) != 2 {
+		t.Fatalf("empty findings should be skipped, got %d", len(cited))
+	}
+	if !strings.Contains(cited[0], "(source: [1])") || !strings.Contains(cited[0], "(source: [2])") {
+		t.Errorf("unexpected citation rewrite: %s", cited[0])
+	}
+	if !strings.Contains(cited[1], "(source: [1])") {
+		t.Errorf("duplicate URL should reuse [1]: %s", cited[1])
+	}
+}
+
+func TestResearchSlug(t *testing.T) {
+	if got := researchSlug("Go vs Rust: async runtimes!"); got != "go-vs-rust-async-runtimes" {
+		t.Errorf("slug = %q", got)
+	}
+	if got := researchSlug("量子计算"); got != "topic" {
+		t.Errorf("slug = %q", got)
+	}
+}
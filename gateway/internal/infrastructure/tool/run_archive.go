@@ -0,0 +1,421 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/journal"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/transcript"
+)
+
+const (
+	// maxArchiveFileBytes skips artifacts too large to be notes or reports.
+	maxArchiveFileBytes = 1 << 20
+	// maxArchiveJournals bounds the run journals scanned per search.
+	maxArchiveJournals = 500
+	// maxRunOutputChars caps each tool output when a run is opened.
+	maxRunOutputChars = 4000
+)
+
+// RunArchiveConfig locates what search_past_runs searches.
+type RunArchiveConfig struct {
+	ArtifactDir   string // research dossiers and archived tool outputs (default DefaultArtifactDir)
+	TranscriptDir string // default transcript.DefaultDir()
+	JournalDir    string // default journal.DefaultDir()
+}
+
+// ArchiveHit is one search result. Ref opens it with RunArchive.Open:
+// "artifact:<path under the artifact dir>", "transcript:<file>/<15:04:05>"
+// or "run:<run id>".
+type ArchiveHit struct {
+	Ref     string
+	Kind    string // artifact, transcript or run
+	Title   string
+	Time    time.Time
+	Snippet string
+
+	score int
+}
+
+// RunArchive full-text searches earlier work: artifacts, transcripts and
+// run journals. With a chat ID, only that chat's runs and artifacts are
+// visible; chat ID 0 (CLI, jobs) sees everything.
+type RunArchive struct {
+	cfg RunArchiveConfig
+}
+
+// NewRunArchive creates an archive over the configured directories.
+func NewRunArchive(cfg RunArchiveConfig) *RunArchive {
+	if cfg.ArtifactDir == "" {
+		cfg.ArtifactDir = DefaultArtifactDir()
+	}
+	if cfg.TranscriptDir == "" {
+		cfg.TranscriptDir = transcript.DefaultDir()
+	}
+	if cfg.JournalDir == "" {
+		cfg.JournalDir = journal.DefaultDir()
+	}
+	return &RunArchive{cfg: cfg}
+}
+
+// Search returns the best matches for query, newest first among equal
+// scores. A document matches when it contains at least half of the query
+// words; more words, more occurrences and the exact phrase rank higher.
+func (a *RunArchive) Search(query string, chatID int64, limit int) ([]ArchiveHit, error) {
+	m := newArchiveMatcher(query)
+	if m == nil {
+		return nil, fmt.Errorf("query has no searchable words")
+	}
+	if limit <= 0 {
+		limit = 8
+	}
+
+	var hits []ArchiveHit
+	add := func(h ArchiveHit, text string) {
+		if score, at := m.match(text); score > 0 {
+			h.score = score
+			h.Snippet = snippet(text, at)
+			hits = append(hits, h)
+		}
+	}
+
+	// Run journals hold the full tool outputs; the transcript of the same
+	// run is skipped in their favor
+	journaled := make(map[string]bool)
+	runs, err := journal.List(a.cfg.JournalDir, maxArchiveJournals)
+	if err != nil {
+		return nil, err
+	}
+	for _, run := range runs {
+		if !sourceVisible(run.Source, chatID) {
+			continue
+		}
+		journaled[runKey(run.Source, run.StartedAt)] = true
+		add(ArchiveHit{
+			Ref:   "run:" + run.ID,
+			Kind:  "run",
+			Title: firstLineOf(run.UserMessage, 80),
+			Time:  run.StartedAt,
+		}, runText(run))
+	}
+
+	truns, err := transcript.ReadRuns(a.cfg.TranscriptDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, run := range truns {
+		if !sourceVisible(run.Source, chatID) || journaled[runKey(run.Source, run.StartedAt)] ||
+			journaled[runKey(run.Source, run.StartedAt.Add(time.Second))] {
+			continue
+		}
+		add(ArchiveHit{
+			Ref:   "transcript:" + run.Ref(),
+			Kind:  "transcript",
+			Title: transcriptTitle(run.Text),
+			Time:  run.StartedAt,
+		}, run.Text)
+	}
+
+	root := a.artifactRoot(chatID)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > maxArchiveFileBytes {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || strings.IndexByte(string(data), 0) >= 0 {
+			return nil
+		}
+		rel, _ := filepath.Rel(a.cfg.ArtifactDir, path)
+		add(ArchiveHit{
+			Ref:   "artifact:" + filepath.ToSlash(rel),
+			Kind:  "artifact",
+			Title: artifactTitle(string(data), d.Name()),
+			Time:  info.ModTime(),
+		}, string(data))
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].Time.After(hits[j].Time)
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// Open returns the content behind a search result's Ref. An absolute path
+// inside the artifact directory (as printed by research) also works.
+func (a *RunArchive) Open(ref string, chatID int64) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if filepath.IsAbs(ref) {
+		rel, err := filepath.Rel(a.cfg.ArtifactDir, ref)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return "", fmt.Errorf("%s is not in the artifact directory", ref)
+		}
+		ref = "artifact:" + filepath.ToSlash(rel)
+	}
+	kind, id, ok := strings.Cut(ref, ":")
+	if !ok || id == "" {
+		return "", fmt.Errorf("invalid ref %q (want artifact:…, transcript:… or run:…)", ref)
+	}
+
+	switch kind {
+	case "artifact":
+		path := filepath.Join(a.cfg.ArtifactDir, filepath.FromSlash(id))
+		root := a.artifactRoot(chatID)
+		if rel, err := filepath.Rel(root, path); err != nil || strings.HasPrefix(rel, "..") {
+			return "", fmt.Errorf("artifact %s not found", id)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("artifact %s not found", id)
+		}
+		return string(data), nil
+
+	case "transcript":
+		runs, err := transcript.ReadRuns(a.cfg.TranscriptDir)
+		if err != nil {
+			return "", err
+		}
+		for _, run := range runs {
+			if run.Ref() == id && sourceVisible(run.Source, chatID) {
+				return run.Text, nil
+			}
+		}
+		return "", fmt.Errorf("transcript run %s not found", id)
+
+	case "run":
+		run, err := journal.Load(a.cfg.JournalDir, id)
+		if err != nil || !sourceVisible(run.Source, chatID) {
+			return "", fmt.Errorf("run %s not found", id)
+		}
+		return formatRunReport(run), nil
+	}
+	return "", fmt.Errorf("unknown ref kind %q", kind)
+}
+
+func (a *RunArchive) artifactRoot(chatID int64) string {
+	if chatID != 0 {
+		return ChatArtifactDir(a.cfg.ArtifactDir, chatID)
+	}
+	return a.cfg.ArtifactDir
+}
+
+// sourceVisible reports whether a run with this transcript source belongs
+// to the chat (chat 0 sees every run).
+func sourceVisible(source string, chatID int64) bool {
+	return chatID == 0 || source == "telegram:"+strconv.FormatInt(chatID, 10)
+}
+
+func runKey(source string, started time.Time) string {
+	return source + "|" + strconv.FormatInt(started.Unix(), 10)
+}
+
+// runText is what a journal run is searched by: the request, the model's
+// text and tool calls, the tool outputs and the final answer.
+func runText(run *journal.Run) string {
+	var sb strings.Builder
+	sb.WriteString(run.UserMessage)
+	for _, step := range run.Steps {
+		if step.Response != nil {
+			sb.WriteString("\n")
+			sb.WriteString(step.Response.Content)
+			for _, tc := range step.Response.ToolCalls {
+				args, _ := json.Marshal(tc.Arguments)
+				fmt.Fprintf(&sb, "\n%s %s", tc.Name, args)
+			}
+		}
+		for _, res := range step.Results {
+			sb.WriteString("\n")
+			sb.WriteString(res.Output)
+		}
+	}
+	if run.End != nil {
+		sb.WriteString("\n")
+		sb.WriteString(run.End.Final)
+	}
+	return sb.String()
+}
+
+// formatRunReport renders a journal run for reading: the request, each
+// step's text and tool calls with their (capped) outputs, and the answer.
+func formatRunReport(run *journal.Run) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Run %s\n\n", run.ID)
+	fmt.Fprintf(&sb, "Started %s", run.StartedAt.Format("2006-01-02 15:04:05"))
+	if run.Source != "" {
+		fmt.Fprintf(&sb, " · %s", run.Source)
+	}
+	if run.Model != "" {
+		fmt.Fprintf(&sb, " · %s", run.Model)
+	}
+	fmt.Fprintf(&sb, " · %s\n\n## Request\n\n%s\n", run.Status(), strings.TrimSpace(run.UserMessage))
+
+	for _, step := range run.Steps {
+		if step.Response == nil {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n## Step %d\n", step.N)
+		if text := strings.TrimSpace(step.Response.Content); text != "" && len(step.Response.ToolCalls) > 0 {
+			fmt.Fprintf(&sb, "\n%s\n", text)
+		}
+		for _, tc := range step.Response.ToolCalls {
+			args, _ := json.Marshal(tc.Arguments)
+			fmt.Fprintf(&sb, "\n`%s` %s\n", tc.Name, args)
+			for _, res := range step.Results {
+				if res.ToolCall == nil || res.ToolCall.ID != tc.ID {
+					continue
+				}
+				status := "ok"
+				if !res.Success {
+					status = "failed"
+				}
+				fmt.Fprintf(&sb, "\nResult (%s):\n```\n%s\n```\n", status, clipRunes(strings.TrimSpace(res.Output), maxRunOutputChars))
+			}
+		}
+	}
+	if run.End != nil && strings.TrimSpace(run.End.Final) != "" {
+		fmt.Fprintf(&sb, "\n## Answer\n\n%s\n", strings.TrimSpace(run.End.Final))
+	}
+	return sb.String()
+}
+
+func transcriptTitle(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if rest, ok := strings.CutPrefix(line, "**User:** "); ok {
+			return firstLineOf(rest, 80)
+		}
+	}
+	line, _, _ := strings.Cut(text, "\n")
+	return strings.TrimPrefix(line, "## ")
+}
+
+func artifactTitle(content, name string) string {
+	for _, line := range strings.SplitN(content, "\n", 20) {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "# "); ok {
+			return firstLineOf(rest, 80)
+		}
+	}
+	return name
+}
+
+// archiveMatcher scores documents against the words of a query.
+type archiveMatcher struct {
+	phrase string
+	terms  []string
+}
+
+func newArchiveMatcher(query string) *archiveMatcher {
+	m := &archiveMatcher{phrase: strings.ToLower(strings.Join(strings.Fields(query), " "))}
+	seen := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(m.phrase, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-')
+	}) {
+		w = strings.Trim(w, ".-")
+		if len([]rune(w)) >= 2 && !seen[w] {
+			seen[w] = true
+			m.terms = append(m.terms, w)
+		}
+	}
+	if len(m.terms) == 0 {
+		return nil
+	}
+	return m
+}
+
+// match returns the document's score (0 = no match) and the byte offset in
+// text the snippet is centered on.
+func (m *archiveMatcher) match(text string) (int, int) {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// Case folding changed byte lengths; offsets would not line up
+		lower = asciiLower(text)
+	}
+	matched, hits, at := 0, 0, -1
+	for _, term := range m.terms {
+		n := strings.Count(lower, term)
+		if n == 0 {
+			continue
+		}
+		matched++
+		hits += min(n, 10)
+		if at < 0 {
+			at = strings.Index(lower, term)
+		}
+	}
+	if matched == 0 || matched*2 < len(m.terms) {
+		return 0, 0
+	}
+	score := matched*100 + hits
+	if len(m.terms) > 1 {
+		if i := strings.Index(lower, m.phrase); i >= 0 {
+			score += 200
+			at = i
+		}
+	}
+	return score, at
+}
+
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+// snippet returns about 240 characters of text around offset at, on one line.
+func snippet(text string, at int) string {
+	start := max(0, at-80)
+	end := min(len(text), at+160)
+	for start > 0 && !utf8RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8RuneStart(text[end]) {
+		end++
+	}
+	s := strings.Join(strings.Fields(text[start:end]), " ")
+	if start > 0 {
+		s = "…" + s
+	}
+	if end < len(text) {
+		s += "…"
+	}
+	return s
+}
+
+func utf8RuneStart(b byte) bool { return b&0xC0 != 0x80 }
+
+func firstLineOf(s string, n int) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return clipRunes(line, n)
+}
+
+func clipRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
@@ -0,0 +1,113 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/journal"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/transcript"
+	"go.uber.org/zap"
+)
+
+func TestRunArchive_SearchAndOpen(t *testing.T) {
+	root := t.TempDir()
+	cfg := RunArchiveConfig{
+		ArtifactDir:   filepath.Join(root, "artifacts"),
+		TranscriptDir: filepath.Join(root, "transcripts"),
+		JournalDir:    filepath.Join(root, "journal"),
+	}
+	started := time.Date(2026, 9, 14, 10, 0, 0, 0, time.Local)
+
+	// A journaled run of chat 42, also in the transcripts
+	jw, err := journal.NewWriter(journal.Config{Dir: cfg.JournalDir}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := entity.ToolCallInfo{ID: "c1", Name: "bash", Arguments: map[string]interface{}{"command": "tail /var/log/nginx/error.log"}}
+	for _, rec := range []*service.JournalRecord{
+		{Type: service.JournalRun, Time: started, Source: "telegram:42", Model: "p/m", UserMessage: "staging returns 502"},
+		{Type: service.JournalStep, Time: started, Step: 1, Model: "p/m", Messages: []service.LLMMessage{{Role: "user", Content: "staging returns 502"}}},
+		{Type: service.JournalResponse, Time: started, Step: 1, Response: &service.LLMResponse{ToolCalls: []entity.ToolCallInfo{call}}},
+		{Type: service.JournalTool, Time: started, Step: 1, ToolCall: &call, Output: "upstream timed out (110: Connection timed out)", Success: true},
+		{Type: service.JournalEnd, Time: started, Final: "Raised proxy_read_timeout to 120s.", Steps: 1},
+	} {
+		jw.WriteJournal("20260914-100000-ab12", rec)
+	}
+	os.MkdirAll(cfg.TranscriptDir, 0o700)
+	text := transcript.Format(&service.RunTranscript{Source: "telegram:42", UserMessage: "staging returns 502",
+		Final: "Raised proxy_read_timeout.", StartedAt: started, FinishedAt: started}) +
+		transcript.Format(&service.RunTranscript{Source: "telegram:7", UserMessage: "nginx upstream timed out again",
+			StartedAt: started.Add(time.Hour), FinishedAt: started.Add(time.Hour)})
+	os.WriteFile(filepath.Join(cfg.TranscriptDir, "2026-09-14.md"), []byte(text), 0o600)
+
+	for chat, content := range map[int64]string{42: "# Nginx timeouts\n\nupstream timed out: raise proxy_read_timeout", 7: "# Other chat\n\nnginx upstream"} {
+		dir := ChatArtifactDir(cfg.ArtifactDir, chat)
+		os.MkdirAll(dir, 0o755)
+		os.WriteFile(filepath.Join(dir, "notes.md"), []byte(content), 0o600)
+	}
+
+	a := NewRunArchive(cfg)
+	hits, err := a.Search("nginx upstream timed out", 42, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var refs []string
+	for _, h := range hits {
+		refs = append(refs, h.Ref)
+	}
+	// The transcript of the journaled run is folded into it; chat 7 is invisible
+	if strings.Join(refs, " ") != "run:20260914-100000-ab12 artifact:chat-42/notes.md" {
+		t.Fatalf("refs = %v", refs)
+	}
+	if hits[0].Title != "staging returns 502" || !strings.Contains(hits[0].Snippet, "upstream timed out") {
+		t.Errorf("run hit = %+v", hits[0])
+	}
+	if hits[1].Title != "Nginx timeouts" {
+		t.Errorf("artifact title = %q", hits[1].Title)
+	}
+	if all, _ := a.Search("nginx upstream", 0, 10); len(all) != 4 {
+		t.Errorf("chat 0 hits = %d, want 4", len(all))
+	}
+
+	report, err := a.Open(hits[0].Ref, 42)
+	if err != nil || !strings.Contains(report, "## Request\n\nstaging returns 502") ||
+		!strings.Contains(report, "(110: Connection timed out)") || !strings.Contains(report, "## Answer\n\nRaised proxy_read_timeout to 120s.") {
+		t.Errorf("run report = %q, %v", report, err)
+	}
+	if _, err := a.Open(filepath.Join(cfg.ArtifactDir, "chat-42", "notes.md"), 42); err != nil {
+		t.Errorf("open by path: %v", err)
+	}
+	if out, err := a.Open("transcript:2026-09-14/11:00:00", 0); err != nil || !strings.Contains(out, "nginx upstream timed out again") {
+		t.Errorf("open transcript = %q, %v", out, err)
+	}
+	// Other chats' runs and artifacts are not found, nor is anything outside the archive
+	for _, ref := range []string{"artifact:chat-7/notes.md", "transcript:2026-09-14/11:00:00", "artifact:../journal/20260914-100000-ab12.jsonl"} {
+		if _, err := a.Open(ref, 42); err == nil {
+			t.Errorf("Open(%s) from chat 42 should fail", ref)
+		}
+	}
+	if _, err := a.Open("artifact:../journal/20260914-100000-ab12.jsonl", 0); err == nil {
+		t.Error("Open outside the artifact dir should fail")
+	}
+}
+
+func TestReadArtifactTool_Pages(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "big.txt"), []byte(strings.Repeat("x", maxArtifactChars+10)), 0o600)
+	tool := NewReadArtifactTool(NewRunArchive(RunArchiveConfig{ArtifactDir: dir, TranscriptDir: dir, JournalDir: dir}), zap.NewNop())
+
+	res, _ := tool.Execute(context.Background(), map[string]interface{}{"ref": "artifact:big.txt"})
+	if !res.Success || !strings.Contains(res.Output, "call again with offset=20000") {
+		t.Fatalf("first page = %+v", res.Error)
+	}
+	res, _ = tool.Execute(context.Background(), map[string]interface{}{"ref": "artifact:big.txt", "offset": float64(maxArtifactChars)})
+	if res.Output != strings.Repeat("x", 10) {
+		t.Errorf("second page = %q", res.Output)
+	}
+}
@@ -0,0 +1,155 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultArchiveResults = 8
+	maxArchiveResults     = 20
+	// maxArtifactChars is what read_artifact returns per call; longer
+	// content is paged with offset.
+	maxArtifactChars = 20000
+)
+
+// SearchPastRunsTool searches earlier runs and artifacts for previous
+// solutions ("we fixed this nginx error last month").
+type SearchPastRunsTool struct {
+	archive *RunArchive
+	logger  *zap.Logger
+}
+
+// NewSearchPastRunsTool creates the search_past_runs tool.
+func NewSearchPastRunsTool(archive *RunArchive, logger *zap.Logger) *SearchPastRunsTool {
+	return &SearchPastRunsTool{archive: archive, logger: logger}
+}
+
+func (t *SearchPastRunsTool) Name() string          { return "search_past_runs" }
+func (t *SearchPastRunsTool) Kind() domaintool.Kind { return domaintool.KindSearch }
+
+func (t *SearchPastRunsTool) Description() string {
+	return "Full-text search over previous agent runs, their transcripts and saved artifacts (research reports, archived tool outputs). " +
+		"Use when a problem may have been solved before, e.g. a recurring error message or a past investigation. " +
+		"Returns references; open one with read_artifact."
+}
+
+func (t *SearchPastRunsTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Words to look for, e.g. an error message, host or file name",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("Max results (default %d, max %d)", defaultArchiveResults, maxArchiveResults),
+			},
+		},
+		"required": []string{"query"},
+	}
+}
+
+func (t *SearchPastRunsTool) Execute(ctx context.Context, args map[string]interface{}) (*Result, error) {
+	query, _ := args["query"].(string)
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return &Result{Success: false, Error: "query is required"}, nil
+	}
+	limit := defaultArchiveResults
+	if n, ok := args["limit"].(float64); ok && n > 0 {
+		limit = min(int(n), maxArchiveResults)
+	}
+
+	hits, err := t.archive.Search(query, chatIDFromContext(ctx), limit)
+	if err != nil {
+		return &Result{Success: false, Error: err.Error()}, nil
+	}
+	t.logger.Debug("Searched past runs", zap.String("query", query), zap.Int("hits", len(hits)))
+	return &Result{
+		Success:  true,
+		Output:   FormatArchiveHits(query, hits),
+		Metadata: map[string]interface{}{"hits": len(hits)},
+	}, nil
+}
+
+// FormatArchiveHits renders search results as a numbered list with refs.
+func FormatArchiveHits(query string, hits []ArchiveHit) string {
+	if len(hits) == 0 {
+		return fmt.Sprintf("No past runs or artifacts match %q.", query)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d results for %q:\n", len(hits), query)
+	for i, h := range hits {
+		fmt.Fprintf(&sb, "\n%d. [%s] %s · %s\n   ref: %s\n   %s\n",
+			i+1, h.Kind, h.Time.Format("2006-01-02 15:04"), h.Title, h.Ref, h.Snippet)
+	}
+	sb.WriteString("\nOpen one with read_artifact(ref).")
+	return sb.String()
+}
+
+// ReadArtifactTool opens a result of search_past_runs, or an artifact path
+// printed by research.
+type ReadArtifactTool struct {
+	archive *RunArchive
+	logger  *zap.Logger
+}
+
+// NewReadArtifactTool creates the read_artifact tool.
+func NewReadArtifactTool(archive *RunArchive, logger *zap.Logger) *ReadArtifactTool {
+	return &ReadArtifactTool{archive: archive, logger: logger}
+}
+
+func (t *ReadArtifactTool) Name() string          { return "read_artifact" }
+func (t *ReadArtifactTool) Kind() domaintool.Kind { return domaintool.KindRead }
+
+func (t *ReadArtifactTool) Description() string {
+	return "Read a past run, transcript or artifact by the ref returned from search_past_runs " +
+		"(artifact:…, transcript:… or run:…), or by an artifact path such as a saved research dossier. " +
+		"A run is shown with its request, tool calls, outputs and final answer."
+}
+
+func (t *ReadArtifactTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"ref": map[string]interface{}{
+				"type":        "string",
+				"description": "Ref from search_past_runs, or an artifact file path",
+			},
+			"offset": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("Character offset to continue from; each call returns up to %d characters", maxArtifactChars),
+			},
+		},
+		"required": []string{"ref"},
+	}
+}
+
+func (t *ReadArtifactTool) Execute(ctx context.Context, args map[string]interface{}) (*Result, error) {
+	ref, _ := args["ref"].(string)
+	if strings.TrimSpace(ref) == "" {
+		return &Result{Success: false, Error: "ref is required"}, nil
+	}
+	content, err := t.archive.Open(ref, chatIDFromContext(ctx))
+	if err != nil {
+		return &Result{Success: false, Error: err.Error()}, nil
+	}
+
+	runes := []rune(content)
+	offset := 0
+	if n, ok := args["offset"].(float64); ok && n > 0 {
+		offset = min(int(n), len(runes))
+	}
+	end := min(offset+maxArtifactChars, len(runes))
+	out := string(runes[offset:end])
+	if end < len(runes) {
+		out += fmt.Sprintf("\n\n[%d of %d characters shown; call again with offset=%d for more]", end-offset, len(runes), end)
+	}
+	return &Result{Success: true, Output: out}, nil
+}
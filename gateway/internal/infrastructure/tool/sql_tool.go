@@ -0,0 +1,509 @@
+package tool
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // registers "pgx"
+	_ "github.com/mattn/go-sqlite3"    // registers "sqlite3"
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"go.uber.org/zap"
+)
+
+// SQLConnection 一个登记过的数据库连接 (agent.tools.sql.connections)
+type SQLConnection struct {
+	Name        string // 工具参数里使用的名字, 如 "analytics"
+	Driver      string // postgres | mysql | sqlite
+	DSN         string
+	ReadWrite   bool   // false = 只读, 拒绝一切写语句
+	Description string // 给模型看的用途说明
+}
+
+// SQLConfig sql_query 工具配置
+type SQLConfig struct {
+	Connections []SQLConnection
+	MaxRows     int           // 返回的最大行数, 默认 100
+	MaxChars    int           // 结果表格的最大字符数, 默认 8000
+	Timeout     time.Duration // 单条语句超时, 默认 30s
+}
+
+const sqlCellLimit = 200 // 单元格最多显示的字符数
+
+// sqlDrivers maps the configured driver to the database/sql driver name.
+// MySQL needs a driver registered as "mysql" (github.com/go-sql-driver/mysql)
+// linked into the binary.
+var sqlDrivers = map[string]string{
+	"postgres": "pgx",
+	"mysql":    "mysql",
+	"sqlite":   "sqlite3",
+}
+
+// SQLQueryTool 在登记的数据库上执行 SQL
+type SQLQueryTool struct {
+	cfg    SQLConfig
+	conns  map[string]*SQLConnection
+	dbs    map[string]*sql.DB
+	mu     sync.Mutex
+	logger *zap.Logger
+}
+
+// NewSQLQueryTool 创建 sql_query 工具; 连接在首次使用时打开
+func NewSQLQueryTool(cfg SQLConfig, logger *zap.Logger) *SQLQueryTool {
+	if cfg.MaxRows <= 0 {
+		cfg.MaxRows = 100
+	}
+	if cfg.MaxChars <= 0 {
+		cfg.MaxChars = 8000
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	conns := make(map[string]*SQLConnection, len(cfg.Connections))
+	for i := range cfg.Connections {
+		c := &cfg.Connections[i]
+		if c.Driver == "sqlite3" {
+			c.Driver = "sqlite"
+		}
+		if c.Driver == "postgresql" || c.Driver == "pgx" {
+			c.Driver = "postgres"
+		}
+		conns[c.Name] = c
+	}
+	return &SQLQueryTool{
+		cfg:    cfg,
+		conns:  conns,
+		dbs:    make(map[string]*sql.DB),
+		logger: logger,
+	}
+}
+
+// Close 关闭所有连接
+func (t *SQLQueryTool) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for name, db := range t.dbs {
+		db.Close()
+		delete(t.dbs, name)
+	}
+}
+
+func (t *SQLQueryTool) Name() string          { return "sql_query" }
+func (t *SQLQueryTool) Kind() domaintool.Kind { return domaintool.KindExecute }
+
+func (t *SQLQueryTool) Description() string {
+	var sb strings.Builder
+	sb.WriteString(`Run SQL on a registered database and get the result as a markdown table.
+One statement per call. Read queries get LIMIT ` + fmt.Sprint(t.cfg.MaxRows) + ` added when they have none; narrow with WHERE / columns instead of paging through everything.
+Write statements (INSERT/UPDATE/DELETE/DDL) are never run on the first call: it returns the EXPLAIN plan. Review it, then repeat the call with confirm=true to execute (the user is asked to approve). Read-only connections reject writes.
+Registered connections:`)
+	for _, c := range t.cfg.Connections {
+		mode := "read-only"
+		if c.ReadWrite {
+			mode = "read-write"
+		}
+		sb.WriteString(fmt.Sprintf("\n- %s (%s, %s)", c.Name, c.Driver, mode))
+		if c.Description != "" {
+			sb.WriteString(": " + c.Description)
+		}
+	}
+	return sb.String()
+}
+
+func (t *SQLQueryTool) Schema() map[string]interface{} {
+	names := make([]string, 0, len(t.cfg.Connections))
+	for _, c := range t.cfg.Connections {
+		names = append(names, c.Name)
+	}
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"connection": map[string]interface{}{
+				"type":        "string",
+				"description": "Connection name",
+				"enum":        names,
+			},
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "A single SQL statement",
+			},
+			"max_rows": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("Row limit for this query (default and maximum %d)", t.cfg.MaxRows),
+			},
+			"confirm": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Execute a write statement after reviewing its EXPLAIN preview",
+			},
+		},
+		"required": []string{"connection", "query"},
+	}
+}
+
+func (t *SQLQueryTool) Execute(ctx context.Context, args map[string]interface{}) (*Result, error) {
+	name, _ := args["connection"].(string)
+	conn, ok := t.conns[name]
+	if !ok {
+		return &Result{Success: false, Error: fmt.Sprintf("unknown connection %q (registered: %s)", name, strings.Join(t.names(), ", "))}, nil
+	}
+	query, _ := args["query"].(string)
+	query = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(query), ";"))
+	if query == "" {
+		return &Result{Success: false, Error: "query is required"}, nil
+	}
+	stmt, err := classifySQL(query)
+	if err != nil {
+		return &Result{Success: false, Error: err.Error()}, nil
+	}
+	if stmt.write && !conn.ReadWrite {
+		return &Result{Success: false, Error: fmt.Sprintf("%s is read-only: %s statements are not allowed", conn.Name, stmt.keyword)}, nil
+	}
+
+	db, err := t.open(conn)
+	if err != nil {
+		return &Result{Success: false, Error: err.Error()}, nil
+	}
+	runCtx, cancel := context.WithTimeout(ctx, t.cfg.Timeout)
+	defer cancel()
+
+	meta := map[string]interface{}{"connection": conn.Name, "statement": stmt.keyword}
+
+	if !stmt.write {
+		maxRows := t.cfg.MaxRows
+		if n := intArg(args, "max_rows", 0); n > 0 && n < maxRows {
+			maxRows = n
+		}
+		if stmt.limitable && !hasTopLevelLimit(query) {
+			query = fmt.Sprintf("%s LIMIT %d", query, maxRows+1)
+			meta["limit_injected"] = true
+		}
+		table, rows, truncated, err := t.queryReadOnly(runCtx, db, query, maxRows)
+		if err != nil {
+			return &Result{Success: false, Error: fmt.Sprintf("%s: %v", conn.Name, err), Metadata: meta}, nil
+		}
+		meta["rows"], meta["truncated"] = rows, truncated
+		return &Result{Success: true, Output: table, Metadata: meta}, nil
+	}
+
+	confirm, _ := args["confirm"].(bool)
+	if !confirm {
+		plan := explainSQL(runCtx, db, conn.Driver, query, t.cfg.MaxChars)
+		meta["preview"] = true
+		return &Result{
+			Success: false,
+			Output:  plan,
+			Error: fmt.Sprintf("%s is a write statement and was NOT executed. Check the plan above, then call sql_query again with confirm=true to run it.",
+				stmt.keyword),
+			Metadata: meta,
+		}, nil
+	}
+
+	t.logger.Info("Executing SQL write",
+		zap.String("connection", conn.Name),
+		zap.String("statement", stmt.keyword),
+	)
+	res, err := db.ExecContext(runCtx, query)
+	if err != nil {
+		return &Result{Success: false, Error: fmt.Sprintf("%s: %v", conn.Name, err), Metadata: meta}, nil
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return &Result{Success: true, Output: stmt.keyword + " OK", Metadata: meta}, nil
+	}
+	meta["rows_affected"] = affected
+	return &Result{Success: true, Output: fmt.Sprintf("%s OK, %d rows affected", stmt.keyword, affected), Metadata: meta}, nil
+}
+
+func (t *SQLQueryTool) names() []string {
+	names := make([]string, 0, len(t.conns))
+	for n := range t.conns {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// open returns the pooled *sql.DB of a connection.
+func (t *SQLQueryTool) open(c *SQLConnection) (*sql.DB, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if db, ok := t.dbs[c.Name]; ok {
+		return db, nil
+	}
+	driver, ok := sqlDrivers[c.Driver]
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported driver %q (postgres, mysql, sqlite)", c.Name, c.Driver)
+	}
+	if !driverRegistered(driver) {
+		return nil, fmt.Errorf("%s: %s driver is not built into this binary", c.Name, c.Driver)
+	}
+	dsn := c.DSN
+	if c.Driver == "sqlite" && !c.ReadWrite {
+		// Second line of defence: SQLite ignores read-only transactions
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		dsn += sep + "_query_only=1"
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.Name, err)
+	}
+	db.SetMaxOpenConns(4)
+	db.SetConnMaxIdleTime(5 * time.Minute)
+	t.dbs[c.Name] = db
+	return db, nil
+}
+
+func driverRegistered(name string) bool {
+	for _, d := range sql.Drivers() {
+		if d == name {
+			return true
+		}
+	}
+	return false
+}
+
+// queryReadOnly runs a query in a read-only transaction and renders up to
+// maxRows rows as a markdown table.
+func (t *SQLQueryTool) queryReadOnly(ctx context.Context, db *sql.DB, query string, maxRows int) (string, int, bool, error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return "", 0, false, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return "", 0, false, err
+	}
+	defer rows.Close()
+	return renderSQLRows(rows, maxRows, t.cfg.MaxChars)
+}
+
+// renderSQLRows formats rows as a markdown table capped by row count and size.
+func renderSQLRows(rows *sql.Rows, maxRows, maxChars int) (string, int, bool, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return "", 0, false, err
+	}
+	if len(cols) == 0 {
+		return "(no columns)", 0, false, rows.Err()
+	}
+
+	var sb strings.Builder
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = sqlCell(c)
+	}
+	sb.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	sb.WriteString("|" + strings.Repeat(" --- |", len(cols)) + "\n")
+
+	values := make([]interface{}, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	n, truncated := 0, false
+	for rows.Next() {
+		if n >= maxRows {
+			truncated = true
+			break
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return "", n, false, err
+		}
+		cells := make([]string, len(cols))
+		for i, v := range values {
+			cells[i] = sqlCell(formatSQLValue(v))
+		}
+		line := "| " + strings.Join(cells, " | ") + " |\n"
+		if sb.Len()+len(line) > maxChars {
+			truncated = true
+			break
+		}
+		sb.WriteString(line)
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return "", n, false, err
+	}
+
+	if n == 0 {
+		sb.WriteString("\n(0 rows)")
+	} else if truncated {
+		sb.WriteString(fmt.Sprintf("\n(showing first %d rows; more rows exist — narrow the query)", n))
+	} else {
+		sb.WriteString(fmt.Sprintf("\n(%d rows)", n))
+	}
+	return sb.String(), n, truncated, nil
+}
+
+func formatSQLValue(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		if !utf8.Valid(x) {
+			return fmt.Sprintf("<%d bytes>", len(x))
+		}
+		return string(x)
+	case time.Time:
+		return x.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+// sqlCell makes a value safe for a markdown table cell.
+func sqlCell(s string) string {
+	s = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "|", `\|`).Replace(s)
+	if r := []rune(s); len(r) > sqlCellLimit {
+		s = string(r[:sqlCellLimit]) + "…"
+	}
+	return s
+}
+
+// explainSQL returns the query plan of a write statement without running it.
+func explainSQL(ctx context.Context, db *sql.DB, driver, query string, maxChars int) string {
+	prefix := "EXPLAIN "
+	if driver == "sqlite" {
+		prefix = "EXPLAIN QUERY PLAN "
+	}
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return "EXPLAIN unavailable: " + err.Error()
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, prefix+query)
+	if err != nil {
+		return "EXPLAIN unavailable (DDL and some statements have no plan): " + err.Error() + "\n\nStatement:\n" + query
+	}
+	defer rows.Close()
+	table, _, _, err := renderSQLRows(rows, 200, maxChars)
+	if err != nil {
+		return "EXPLAIN unavailable: " + err.Error()
+	}
+	return "Plan (" + strings.TrimSpace(prefix) + "):\n" + table + "\n\nStatement:\n" + query
+}
+
+// ─── Statement classification ───
+
+// sqlStatement is what the guardrails need to know about a statement.
+type sqlStatement struct {
+	keyword   string // leading keyword, upper case
+	write     bool   // may modify data or schema
+	limitable bool   // a trailing LIMIT can be appended
+}
+
+var (
+	sqlReadKeywords  = map[string]bool{"SELECT": true, "WITH": true, "VALUES": true, "TABLE": true, "SHOW": true, "EXPLAIN": true, "DESCRIBE": true, "DESC": true, "PRAGMA": true}
+	sqlLimitKeywords = map[string]bool{"SELECT": true, "WITH": true, "VALUES": true, "TABLE": true}
+	sqlWriteWord     = regexp.MustCompile(`(?i)\b(INSERT|UPDATE|DELETE|MERGE|UPSERT|CREATE|ALTER|DROP|TRUNCATE|GRANT|REVOKE|COPY|CALL|INTO)\b`)
+	sqlAnalyze       = regexp.MustCompile(`(?i)\bANALY[SZ]E\b`)
+	sqlPragmaLookup  = regexp.MustCompile(`(?i)^\s*PRAGMA\s+(\w+\.)?(table_info|table_xinfo|index_list|index_info|index_xinfo|foreign_key_list)\s*\(`)
+	sqlLockClause    = regexp.MustCompile(`(?i)\bFOR\s+(UPDATE|SHARE)\b`)
+	sqlLimitClause   = regexp.MustCompile(`(?i)\b(LIMIT\s+\d+|FETCH\s+(FIRST|NEXT)\b)`)
+)
+
+// classifySQL rejects multi-statement input and decides whether a statement
+// is a read. Anything not clearly a read counts as a write.
+func classifySQL(query string) (sqlStatement, error) {
+	code := stripSQLLiterals(query)
+	if strings.Contains(strings.TrimRight(strings.TrimSpace(code), ";"), ";") {
+		return sqlStatement{}, errors.New("only one statement per call")
+	}
+	fields := strings.Fields(code)
+	if len(fields) == 0 {
+		return sqlStatement{}, errors.New("query is empty")
+	}
+	kw := strings.ToUpper(strings.TrimLeft(fields[0], "("))
+	st := sqlStatement{keyword: kw, write: true}
+
+	switch {
+	case !sqlReadKeywords[kw]:
+	case kw == "EXPLAIN":
+		// EXPLAIN ANALYZE executes the statement
+		st.write = sqlAnalyze.MatchString(code) && sqlWriteWord.MatchString(code)
+	case kw == "PRAGMA":
+		// PRAGMA x = v and PRAGMA x(v) set values, except the schema lookups
+		st.write = strings.Contains(code, "=") || (strings.Contains(code, "(") && !sqlPragmaLookup.MatchString(code))
+	case kw == "SELECT" || kw == "WITH":
+		// Data-modifying CTEs, SELECT ... INTO and FOR UPDATE change or lock rows
+		st.write = sqlWriteWord.MatchString(code)
+	default:
+		st.write = false
+	}
+	st.limitable = !st.write && sqlLimitKeywords[kw] && !sqlLockClause.MatchString(code)
+	return st, nil
+}
+
+// hasTopLevelLimit reports whether the outermost query already has LIMIT / FETCH FIRST.
+func hasTopLevelLimit(query string) bool {
+	code := stripSQLLiterals(query)
+	depth := 0
+	var top strings.Builder
+	for _, r := range code {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		default:
+			if depth == 0 {
+				top.WriteRune(r)
+			} else {
+				top.WriteRune(' ')
+			}
+		}
+	}
+	return sqlLimitClause.MatchString(top.String())
+}
+
+// stripSQLLiterals blanks out string literals, quoted identifiers and
+// comments so keyword checks only see SQL code.
+func stripSQLLiterals(q string) string {
+	var sb strings.Builder
+	rs := []rune(q)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case r == '-' && i+1 < len(rs) && rs[i+1] == '-':
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+			sb.WriteRune(' ')
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			i += 2
+			for i+1 < len(rs) && !(rs[i] == '*' && rs[i+1] == '/') {
+				i++
+			}
+			i++
+			sb.WriteRune(' ')
+		case r == '\'' || r == '"' || r == '`':
+			for i++; i < len(rs); i++ {
+				if rs[i] == r {
+					if i+1 < len(rs) && rs[i+1] == r { // doubled quote escape
+						i++
+						continue
+					}
+					break
+				}
+			}
+			sb.WriteString(" _ ")
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,114 @@
+package tool
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func newTestSQLTool(t *testing.T) *SQLQueryTool {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "shop.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`CREATE TABLE orders (id INTEGER PRIMARY KEY, customer TEXT, note TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 30; i++ {
+		db.Exec(`INSERT INTO orders (customer, note) VALUES (?, ?)`, fmt.Sprintf("c%d", i), "a|b\nc")
+	}
+	db.Close()
+
+	tool := NewSQLQueryTool(SQLConfig{
+		Connections: []SQLConnection{
+			{Name: "ro", Driver: "sqlite", DSN: path},
+			{Name: "rw", Driver: "sqlite", DSN: path, ReadWrite: true},
+		},
+		MaxRows: 10,
+	}, zap.NewNop())
+	t.Cleanup(tool.Close)
+	return tool
+}
+
+func TestSQLQueryTool_Read(t *testing.T) {
+	tool := newTestSQLTool(t)
+	ctx := context.Background()
+
+	res, _ := tool.Execute(ctx, map[string]interface{}{"connection": "ro", "query": "SELECT id, note FROM orders ORDER BY id;"})
+	if !res.Success || res.Metadata["limit_injected"] != true || res.Metadata["rows"] != 10 || res.Metadata["truncated"] != true {
+		t.Fatalf("limited select: %+v", res)
+	}
+	if !strings.HasPrefix(res.Output, "| id | note |\n| --- | --- |\n| 1 | a\\|b c |") {
+		t.Fatalf("table:\n%s", res.Output)
+	}
+
+	res, _ = tool.Execute(ctx, map[string]interface{}{"connection": "ro", "query": "SELECT count(*) AS n FROM (SELECT id FROM orders LIMIT 5)"})
+	if !res.Success || res.Metadata["limit_injected"] != true || !strings.Contains(res.Output, "| 5 |") {
+		t.Fatalf("subquery limit is not top-level: %+v", res)
+	}
+
+	res, _ = tool.Execute(ctx, map[string]interface{}{"connection": "ro", "query": "select * from orders limit 2"})
+	if !res.Success || res.Metadata["limit_injected"] != nil || res.Metadata["rows"] != 2 {
+		t.Fatalf("existing limit: %+v", res)
+	}
+}
+
+func TestSQLQueryTool_Guardrails(t *testing.T) {
+	tool := newTestSQLTool(t)
+	ctx := context.Background()
+
+	res, _ := tool.Execute(ctx, map[string]interface{}{"connection": "ro", "query": "DELETE FROM orders", "confirm": true})
+	if res.Success || !strings.Contains(res.Error, "read-only") {
+		t.Fatalf("write on read-only connection: %+v", res)
+	}
+	res, _ = tool.Execute(ctx, map[string]interface{}{"connection": "ro", "query": "WITH x AS (SELECT 1) DELETE FROM orders"})
+	if res.Success || !strings.Contains(res.Error, "read-only") {
+		t.Fatalf("data-modifying CTE passed as read: %+v", res)
+	}
+	res, _ = tool.Execute(ctx, map[string]interface{}{"connection": "rw", "query": "SELECT 1; DROP TABLE orders"})
+	if res.Success || !strings.Contains(res.Error, "one statement") {
+		t.Fatalf("multiple statements: %+v", res)
+	}
+
+	// First call previews, confirm=true executes
+	res, _ = tool.Execute(ctx, map[string]interface{}{"connection": "rw", "query": "DELETE FROM orders WHERE id > 20"})
+	if res.Success || res.Metadata["preview"] != true || !strings.Contains(res.Output, "EXPLAIN QUERY PLAN") {
+		t.Fatalf("preview: %+v", res)
+	}
+	res, _ = tool.Execute(ctx, map[string]interface{}{"connection": "rw", "query": "SELECT count(*) FROM orders"})
+	if !strings.Contains(res.Output, "| 30 |") {
+		t.Fatalf("preview modified data: %s", res.Output)
+	}
+	res, _ = tool.Execute(ctx, map[string]interface{}{"connection": "rw", "query": "DELETE FROM orders WHERE id > 20", "confirm": true})
+	if !res.Success || res.Metadata["rows_affected"] != int64(10) {
+		t.Fatalf("confirmed delete: %+v", res)
+	}
+}
+
+func TestClassifySQL(t *testing.T) {
+	cases := map[string]bool{
+		"SELECT * FROM t WHERE note = 'DELETE FROM x'": false,
+		"select replace(name, 'a', 'b') from t":        false,
+		"-- UPDATE t\nSELECT 1":                        false,
+		"SHOW TABLES":                                  false,
+		"PRAGMA table_info(t)":                         false,
+		"PRAGMA journal_mode = WAL":                    true,
+		"EXPLAIN ANALYZE DELETE FROM t":                true,
+		"SELECT * INTO backup FROM t":                  true,
+		"UPDATE t SET a = 1":                           true,
+		"VACUUM":                                       true,
+	}
+	for q, write := range cases {
+		st, err := classifySQL(q)
+		if err != nil || st.write != write {
+			t.Errorf("%q: write=%v err=%v, want write=%v", q, st.write, err, write)
+		}
+	}
+}
@@ -110,10 +110,11 @@ func (t *SubAgentTool) Execute(ctx context.Context, args map[string]interface{})
 
 	// Create sub-agent config (subagent bounded by context.WithTimeout below)
 	cfg := service.AgentLoopConfig{
-		DoomLoopThreshold: 3,
-		MaxOutputChars:    32000,
-		Temperature:       0.7,
-		Model:             t.defaultModel,
+		DoomLoopThreshold:  3,
+		MaxOutputChars:     32000,
+		Temperature:        0.7,
+		StreamStallRetries: service.DefaultStreamStallRetries,
+		Model:              t.defaultModel,
 	}
 
 	subAgent := service.NewAgentLoop(t.llm, t.tools, cfg, t.logger.Named("sub-agent"))
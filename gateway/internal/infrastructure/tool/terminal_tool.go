@@ -0,0 +1,417 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/sandbox"
+	"go.uber.org/zap"
+)
+
+// TerminalConfig terminal 工具配置 (agent.tools.terminal)
+type TerminalConfig struct {
+	MaxSessions int           // 同时存在的终端上限 (所有会话合计), 默认 8
+	IdleTimeout time.Duration // 空闲多久后关闭, 默认 30m
+}
+
+const (
+	terminalDefaultTimeout = 30 * time.Second
+	terminalMaxTimeout     = 10 * time.Minute
+	terminalSettle         = 2 * time.Second // 输出静默这么久视为在等输入
+	terminalOutputHead     = 4 * 1024
+	terminalOutputTail     = 12 * 1024
+)
+
+// TerminalManager 持有 terminal 工具的持久终端, 按 chat + 会话名区分.
+// 调用方负责 Close.
+type TerminalManager struct {
+	sandbox *sandbox.ProcessSandbox
+	cfg     TerminalConfig
+	logger  *zap.Logger
+
+	mu       sync.Mutex
+	sessions map[terminalKey]*terminalSession
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+type terminalKey struct {
+	chatID int64
+	name   string
+}
+
+type terminalSession struct {
+	mu       sync.Mutex // 串行化同一终端上的操作
+	term     *sandbox.Terminal
+	lastUsed time.Time // 以下字段受 TerminalManager.mu 保护
+	command  string    // 最近一条 run 的命令
+}
+
+// NewTerminalManager 创建终端管理器并启动空闲回收
+func NewTerminalManager(sbx *sandbox.ProcessSandbox, cfg TerminalConfig, logger *zap.Logger) *TerminalManager {
+	if cfg.MaxSessions <= 0 {
+		cfg.MaxSessions = 8
+	}
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = 30 * time.Minute
+	}
+	m := &TerminalManager{
+		sandbox:  sbx,
+		cfg:      cfg,
+		logger:   logger,
+		sessions: make(map[terminalKey]*terminalSession),
+		stop:     make(chan struct{}),
+	}
+	go m.reapLoop()
+	return m
+}
+
+// Close 关闭全部终端
+func (m *TerminalManager) Close() {
+	m.closeOnce.Do(func() {
+		close(m.stop)
+		m.mu.Lock()
+		sessions := m.sessions
+		m.sessions = make(map[terminalKey]*terminalSession)
+		m.mu.Unlock()
+		for _, s := range sessions {
+			s.term.Close()
+		}
+	})
+}
+
+// acquire 取得会话 (create 时不存在则新建); 已退出的 shell 会被丢弃
+func (m *TerminalManager) acquire(ctx context.Context, key terminalKey, create bool) (*terminalSession, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.sessions[key]; ok {
+		if !s.term.Exited() {
+			s.lastUsed = time.Now()
+			return s, false, nil
+		}
+		delete(m.sessions, key)
+		go s.term.Close()
+	}
+	if !create {
+		return nil, false, fmt.Errorf("no terminal session %q (start one with action=run)", key.name)
+	}
+	if len(m.sessions) >= m.cfg.MaxSessions {
+		return nil, false, fmt.Errorf("too many open terminals (max %d); close one with action=close", m.cfg.MaxSessions)
+	}
+
+	term, err := m.sandbox.StartTerminal(ctx, "")
+	if err != nil {
+		return nil, false, err
+	}
+	s := &terminalSession{term: term, lastUsed: time.Now()}
+	m.sessions[key] = s
+	m.logger.Info("Terminal session opened",
+		zap.Int64("chat_id", key.chatID),
+		zap.String("session", key.name),
+		zap.Int("pid", term.Pid()),
+	)
+	return s, true, nil
+}
+
+// release 关闭并移除会话
+func (m *TerminalManager) release(key terminalKey) bool {
+	m.mu.Lock()
+	s, ok := m.sessions[key]
+	delete(m.sessions, key)
+	m.mu.Unlock()
+	if ok {
+		s.term.Close()
+	}
+	return ok
+}
+
+// list 返回某个 chat 的会话名 (排序后)
+func (m *TerminalManager) list(chatID int64) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var names []string
+	for k := range m.sessions {
+		if k.chatID == chatID {
+			names = append(names, k.name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// describe 返回会话的状态行, 不存在时为空
+func (m *TerminalManager) describe(key terminalKey) string {
+	m.mu.Lock()
+	s, ok := m.sessions[key]
+	var command string
+	if ok {
+		command = s.command
+	}
+	m.mu.Unlock()
+	if !ok {
+		return ""
+	}
+	state := "idle"
+	if s.term.Busy() {
+		state = "running"
+	}
+	line := fmt.Sprintf("- %s: %s, cwd %s", key.name, state, s.term.Cwd())
+	if command != "" {
+		line += fmt.Sprintf(", last command `%s`", truncateCmd(command, 60))
+	}
+	return line
+}
+
+// setCommand 记录会话最近一条命令 (list 中展示)
+func (m *TerminalManager) setCommand(s *terminalSession, command string) {
+	m.mu.Lock()
+	s.command = command
+	m.mu.Unlock()
+}
+
+// reapLoop 关闭空闲超时或已退出的终端; 正在被操作的终端跳过
+func (m *TerminalManager) reapLoop() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+		}
+		var idle []*terminalSession
+		m.mu.Lock()
+		for key, s := range m.sessions {
+			if !s.term.Exited() && time.Since(s.lastUsed) < m.cfg.IdleTimeout {
+				continue
+			}
+			if !s.mu.TryLock() {
+				continue
+			}
+			s.mu.Unlock()
+			delete(m.sessions, key)
+			idle = append(idle, s)
+			m.logger.Info("Closing idle terminal session",
+				zap.Int64("chat_id", key.chatID),
+				zap.String("session", key.name),
+			)
+		}
+		m.mu.Unlock()
+		for _, s := range idle {
+			s.term.Close()
+		}
+	}
+}
+
+// ─── terminal ───
+
+// TerminalTool 持久交互式终端: 状态 (cwd、环境变量、venv、REPL) 在调用之间保留
+type TerminalTool struct {
+	manager *TerminalManager
+	logger  *zap.Logger
+}
+
+// NewTerminalTool 创建 terminal 工具
+func NewTerminalTool(manager *TerminalManager, logger *zap.Logger) *TerminalTool {
+	return &TerminalTool{manager: manager, logger: logger}
+}
+
+func (t *TerminalTool) Name() string          { return "terminal" }
+func (t *TerminalTool) Kind() domaintool.Kind { return domaintool.KindExecute }
+
+func (t *TerminalTool) Description() string {
+	return fmt.Sprintf(`Persistent interactive terminal (bash in a PTY), one per session name.
+Unlike bash, state carries over between calls: cd, exported variables, activated virtualenvs, running REPLs and installers.
+Actions:
+- run: execute a shell command line. Returns when it finishes (with exit code), when its output has been quiet for 2s (e.g. a prompt waiting for input), when wait_for matches, or at timeout.
+- input: type text into the running program (REPL line, answer to a prompt). Enter is appended unless enter=false. Same return rules as run.
+- read: collect more output from a running command. Same return rules as run.
+- interrupt: send Ctrl-C to the running command.
+- close: end the session. list: show open sessions.
+Only one command runs per session at a time; use another session name for parallel work.
+Sessions idle for %s are closed. Prefer bash for one-shot commands.`, strings.TrimSuffix(t.manager.cfg.IdleTimeout.String(), "0s"))
+}
+
+func (t *TerminalTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"run", "input", "read", "interrupt", "close", "list"},
+				"description": "What to do (default run)",
+			},
+			"session": map[string]interface{}{
+				"type":        "string",
+				"description": "Session name (default \"default\")",
+			},
+			"command": map[string]interface{}{
+				"type":        "string",
+				"description": "run: shell command line. input: text to type into the running program",
+			},
+			"enter": map[string]interface{}{
+				"type":        "boolean",
+				"description": "input: press Enter after the text (default true)",
+			},
+			"wait_for": map[string]interface{}{
+				"type":        "string",
+				"description": "Regex; return as soon as the output matches (e.g. '>>> $' or 'Continue\\? \\[y/N\\]')",
+			},
+			"timeout": map[string]interface{}{
+				"type":        "integer",
+				"description": "Seconds to wait for output (default 30, max 600)",
+			},
+		},
+	}
+}
+
+func (t *TerminalTool) Execute(ctx context.Context, args map[string]interface{}) (*Result, error) {
+	action, _ := args["action"].(string)
+	if action == "" {
+		action = "run"
+	}
+	name, _ := args["session"].(string)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "default"
+	}
+	key := terminalKey{chatID: chatIDFromContext(ctx), name: name}
+
+	switch action {
+	case "list":
+		names := t.manager.list(key.chatID)
+		if len(names) == 0 {
+			return &Result{Success: true, Output: "No open terminal sessions."}, nil
+		}
+		var lines []string
+		for _, n := range names {
+			if line := t.manager.describe(terminalKey{chatID: key.chatID, name: n}); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		return &Result{Success: true, Output: strings.Join(lines, "\n")}, nil
+	case "close":
+		if !t.manager.release(key) {
+			return &Result{Success: false, Error: fmt.Sprintf("no terminal session %q", name)}, nil
+		}
+		return &Result{Success: true, Output: fmt.Sprintf("Terminal session %q closed.", name)}, nil
+	case "run", "input", "read", "interrupt":
+	default:
+		return &Result{Success: false, Error: "unknown action: " + action}, nil
+	}
+
+	opts := sandbox.WaitOptions{Timeout: terminalDefaultTimeout, Settle: terminalSettle}
+	if secs := intArg(args, "timeout", 0); secs > 0 {
+		opts.Timeout = min(time.Duration(secs)*time.Second, terminalMaxTimeout)
+	}
+	if pattern, _ := args["wait_for"].(string); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return &Result{Success: false, Error: "invalid wait_for: " + err.Error()}, nil
+		}
+		// 指定了等待条件就不按静默提前返回
+		opts.Pattern, opts.Settle = re, 0
+	}
+	command, _ := args["command"].(string)
+	if action == "run" && strings.TrimSpace(command) == "" {
+		return &Result{Success: false, Error: "command is required for action=run"}, nil
+	}
+
+	sess, created, err := t.manager.acquire(ctx, key, action == "run")
+	if err != nil {
+		return &Result{Success: false, Error: err.Error()}, nil
+	}
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	switch action {
+	case "run":
+		t.logger.Info("Terminal command",
+			zap.String("session", name),
+			zap.String("command", command),
+		)
+		if err = sess.term.Run(command); err == nil {
+			t.manager.setCommand(sess, command)
+		}
+	case "input":
+		if enter, ok := args["enter"].(bool); !ok || enter {
+			command += "\n"
+		}
+		err = sess.term.Input(command)
+	case "interrupt":
+		err = sess.term.Interrupt()
+		opts.Timeout = min(opts.Timeout, 5*time.Second)
+	}
+	switch {
+	case errors.Is(err, sandbox.ErrTerminalBusy):
+		return &Result{Success: false, Error: fmt.Sprintf(
+			"a command is still running in session %q; use action=read to wait, input to answer it, interrupt to stop it, or another session name", name)}, nil
+	case errors.Is(err, sandbox.ErrTerminalIdle):
+		return &Result{Success: false, Error: fmt.Sprintf(
+			"no command is running in session %q; use action=run for shell commands", name)}, nil
+	case err != nil:
+		return &Result{Success: false, Error: err.Error()}, nil
+	}
+
+	out := sess.term.Wait(ctx, opts)
+	if out.Exited {
+		t.manager.release(key)
+	}
+	return terminalResult(name, created, out, sess.term), nil
+}
+
+// terminalResult 组装输出与状态行
+func terminalResult(name string, created bool, out sandbox.TermOutput, term *sandbox.Terminal) *Result {
+	var sb strings.Builder
+	if created {
+		fmt.Fprintf(&sb, "[new terminal session %q]\n", name)
+	}
+	if out.Dropped > 0 {
+		fmt.Fprintf(&sb, "[... %d bytes of earlier output dropped]\n", out.Dropped)
+	}
+	if text := strings.Trim(out.Output, "\n"); text != "" {
+		sb.WriteString(clipTerminalOutput(text))
+		sb.WriteString("\n")
+	}
+
+	meta := map[string]interface{}{"session": name}
+	switch {
+	case out.Exited:
+		fmt.Fprintf(&sb, "[session %q exited]", name)
+		meta["exited"] = true
+	case out.Done:
+		cwd := term.Cwd()
+		fmt.Fprintf(&sb, "[exit %d", out.ExitCode)
+		if cwd != "" {
+			fmt.Fprintf(&sb, " · cwd %s", cwd)
+		}
+		sb.WriteString("]")
+		meta["exit_code"] = out.ExitCode
+		meta["cwd"] = cwd
+	default:
+		if out.Matched {
+			sb.WriteString("[wait_for matched] ")
+		}
+		sb.WriteString("[still running — action=read to keep waiting, input to respond, interrupt to send Ctrl-C]")
+		meta["running"] = true
+	}
+	return &Result{Success: true, Output: sb.String(), Metadata: meta}
+}
+
+// clipTerminalOutput 过长时保留开头与结尾
+func clipTerminalOutput(s string) string {
+	if len(s) <= terminalOutputHead+terminalOutputTail {
+		return s
+	}
+	head, tail := s[:terminalOutputHead], s[len(s)-terminalOutputTail:]
+	return fmt.Sprintf("%s\n[... %d bytes omitted ...]\n%s", head, len(s)-len(head)-len(tail), tail)
+}
@@ -0,0 +1,86 @@
+//go:build linux
+
+package tool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/sandbox"
+	"go.uber.org/zap"
+)
+
+func newTestTerminalTool(t *testing.T, cfg TerminalConfig) *TerminalTool {
+	t.Helper()
+	sbxCfg := sandbox.DefaultConfig()
+	sbxCfg.WorkDir = t.TempDir()
+	sbxCfg.TempDir = t.TempDir()
+	sb, err := sandbox.NewProcessSandbox(sbxCfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewTerminalManager(sb, cfg, zap.NewNop())
+	t.Cleanup(m.Close)
+	return NewTerminalTool(m, zap.NewNop())
+}
+
+func TestTerminalTool_SessionsKeepState(t *testing.T) {
+	tool := newTestTerminalTool(t, TerminalConfig{MaxSessions: 2})
+	chatA := WithChatID(context.Background(), 1)
+	chatB := WithChatID(context.Background(), 2)
+	exec := func(ctx context.Context, args map[string]interface{}) *Result {
+		t.Helper()
+		res, err := tool.Execute(ctx, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	res := exec(chatA, map[string]interface{}{"command": "cd / && export X=42"})
+	if !res.Success || !strings.Contains(res.Output, "[new terminal session") || !strings.Contains(res.Output, "[exit 0 · cwd /]") {
+		t.Fatalf("first run = %+v", res)
+	}
+	res = exec(chatA, map[string]interface{}{"command": "echo $X $PWD"})
+	if !strings.HasPrefix(res.Output, "42 /\n") || res.Metadata["exit_code"] != 0 {
+		t.Errorf("second run = %q", res.Output)
+	}
+
+	// 另一个 chat 的同名会话相互独立
+	res = exec(chatB, map[string]interface{}{"command": "echo ${X:-unset}"})
+	if !strings.Contains(res.Output, "unset") {
+		t.Errorf("chat B saw chat A's state: %q", res.Output)
+	}
+	res = exec(chatB, map[string]interface{}{"session": "other", "command": "true"})
+	if res.Success || !strings.Contains(res.Error, "too many open terminals") {
+		t.Errorf("third session = %+v, want max_sessions error", res)
+	}
+
+	// 交互: read 等待输入, input 作答
+	res = exec(chatA, map[string]interface{}{"command": `read -p "continue? " a; echo "got $a"`, "wait_for": `continue\? $`})
+	if res.Metadata["running"] != true {
+		t.Fatalf("read prompt = %+v, want still running", res)
+	}
+	res = exec(chatA, map[string]interface{}{"command": "ls"})
+	if res.Success {
+		t.Error("run while busy should fail")
+	}
+	res = exec(chatA, map[string]interface{}{"action": "input", "command": "yes"})
+	if !strings.HasPrefix(res.Output, "got yes\n") {
+		t.Errorf("input = %q", res.Output)
+	}
+
+	res = exec(chatA, map[string]interface{}{"action": "list"})
+	if !strings.Contains(res.Output, "- default: idle, cwd /") {
+		t.Errorf("list = %q", res.Output)
+	}
+	res = exec(chatA, map[string]interface{}{"action": "close"})
+	if !res.Success {
+		t.Errorf("close = %+v", res)
+	}
+	res = exec(chatA, map[string]interface{}{"action": "read"})
+	if res.Success {
+		t.Error("read after close should fail")
+	}
+}
@@ -0,0 +1,605 @@
+package tool
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/sandbox"
+	"go.uber.org/zap"
+)
+
+const (
+	// testOutputTail 测试失败时给模型看的输出尾部 (字符)
+	testOutputTail = 4000
+	// noCoverageMarker pytest-cov 未安装时命令输出的标记
+	noCoverageMarker = "__ngoclaw_no_pytest_cov__"
+)
+
+// TestConfig run_tests 工具配置
+type TestConfig struct {
+	Timeout  time.Duration // 单次测试命令超时, 默认 10m
+	Baseline bool          // 有未提交改动时, 在 HEAD 的临时 git worktree 中再跑一遍作为覆盖率基线
+}
+
+// testJob 一次测试: Go 模块下的若干包, 或一个 Python 项目的整个测试集
+type testJob struct {
+	lang    string // go | python
+	dir     string // go.mod 所在目录 / Python 项目根
+	targets []string
+}
+
+// testRun 一次测试命令的结果
+type testRun struct {
+	command  string
+	dir      string
+	passed   bool
+	failed   []string
+	coverage map[string]float64 // 包 (相对模块/项目根) → 语句覆盖率 %
+	note     string             // 覆盖率不可用等说明
+	output   string             // 失败时的输出尾部
+	skipped  string             // 非空 = 未运行的原因
+}
+
+// TestTool 运行 go test -cover / pytest --cov, 解析每个包的覆盖率, 并与 HEAD
+// 的覆盖率对比 (在临时 git worktree 中运行同样的测试), 让模型和用户看到改动是否
+// 降低了覆盖率。结构化结果 (entity.TestReport) 放在 Metadata["test_report"],
+// agent loop 把最后一次结果写入 AgentResult, 出现在运行结束的报告中。
+type TestTool struct {
+	sandbox *sandbox.ProcessSandbox
+	guard   *FileGuard
+	cfg     TestConfig
+	logger  *zap.Logger
+
+	mu        sync.Mutex
+	baselines map[string]map[string]float64 // 仓库根 + HEAD + 测试命令 → 基线覆盖率
+}
+
+// NewTestTool 创建 run_tests 工具
+func NewTestTool(sb *sandbox.ProcessSandbox, guard *FileGuard, cfg TestConfig, logger *zap.Logger) *TestTool {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Minute
+	}
+	return &TestTool{
+		sandbox:   sb,
+		guard:     guard,
+		cfg:       cfg,
+		logger:    logger,
+		baselines: make(map[string]map[string]float64),
+	}
+}
+
+func (t *TestTool) Name() string          { return "run_tests" }
+func (t *TestTool) Kind() domaintool.Kind { return domaintool.KindExecute }
+
+func (t *TestTool) Description() string {
+	return "Run the tests for the code you changed and report per-package coverage: go test -cover for touched Go packages, " +
+		"pytest --cov for Python projects. Coverage is compared with HEAD so you can see whether your changes reduced it. " +
+		"Call without arguments after edits; pass paths (files or directories) to test something else."
+}
+
+func (t *TestTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"paths": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Files or directories whose tests to run. Default: packages of files edited in this run, else the whole project",
+			},
+			"baseline": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Compare coverage with HEAD (runs the tests a second time on a clean checkout). Default: true",
+			},
+		},
+	}
+}
+
+// Timeout 测试 (含基线) 需要的时间远超普通工具, 供 agent loop 设置单工具超时
+func (t *TestTool) Timeout() time.Duration {
+	if t.cfg.Baseline {
+		return 2*t.cfg.Timeout + time.Minute
+	}
+	return t.cfg.Timeout + time.Minute
+}
+
+func (t *TestTool) Execute(ctx context.Context, args map[string]interface{}) (*Result, error) {
+	if t.sandbox == nil {
+		return &Result{Success: false, Error: "run_tests requires the sandbox"}, nil
+	}
+	var paths []string
+	if raw, ok := args["paths"].([]interface{}); ok {
+		for _, p := range raw {
+			if s, ok := p.(string); ok && s != "" {
+				paths = append(paths, t.resolve(s))
+			}
+		}
+	}
+	if len(paths) == 0 && t.guard != nil {
+		paths = t.guard.EditedFiles(ctx)
+	}
+	baseline := t.cfg.Baseline
+	if b, ok := args["baseline"].(bool); ok {
+		baseline = b
+	}
+
+	var jobs []testJob
+	if len(paths) > 0 {
+		jobs = planTests(paths)
+	} else if job, ok := projectTestJob(t.workDir()); ok {
+		jobs = []testJob{job}
+	}
+	if len(jobs) == 0 {
+		return &Result{Success: false, Error: "run_tests: no Go module or Python project found; pass paths to test"}, nil
+	}
+
+	report := &entity.TestReport{Passed: true}
+	var sb strings.Builder
+	var commands []string
+	for _, job := range jobs {
+		run := t.run(ctx, job, job.dir)
+		t.logger.Info("Run tests",
+			zap.String("command", run.command),
+			zap.String("dir", run.dir),
+			zap.Bool("passed", run.passed),
+			zap.Int("packages", len(run.coverage)),
+			zap.String("skipped", run.skipped),
+		)
+		commands = append(commands, run.command)
+		if run.skipped != "" {
+			sb.WriteString(fmt.Sprintf("$ %s  (%s)\nskipped: %s\n\n", run.command, t.rel(run.dir), run.skipped))
+			continue
+		}
+
+		var base map[string]float64
+		label := ""
+		if baseline && len(run.coverage) > 0 {
+			base, label = t.baseline(ctx, job)
+		}
+		if label != "" {
+			report.Baseline = label
+		}
+		if !run.passed {
+			report.Passed = false
+			report.Failed = append(report.Failed, run.failed...)
+		}
+		for _, pkg := range sortedKeys(run.coverage) {
+			pc := entity.PackageCoverage{Package: pkg, Coverage: run.coverage[pkg]}
+			if b, ok := base[pkg]; ok {
+				b := b
+				pc.Baseline = &b
+			}
+			report.Packages = append(report.Packages, pc)
+		}
+		sb.WriteString(formatTestRun(run, t.rel(run.dir), label, base))
+	}
+	report.Command = strings.Join(commands, "; ")
+	if len(report.Failed) == 0 && !report.Passed {
+		report.Failed = []string{"(see output)"}
+	}
+	sb.WriteString("Summary: " + report.Summary())
+
+	result := &Result{
+		Output:  sb.String(),
+		Success: report.Passed,
+		Metadata: map[string]interface{}{
+			"test_report": report,
+		},
+	}
+	if !report.Passed {
+		result.Error = sb.String()
+	}
+	return result, nil
+}
+
+func (t *TestTool) workDir() string {
+	if t.sandbox != nil {
+		return t.sandbox.GetWorkDir()
+	}
+	return ""
+}
+
+func (t *TestTool) resolve(path string) string {
+	if t.guard != nil {
+		return t.guard.Resolve(path)
+	}
+	return resolveReadPath(path, t.workDir())
+}
+
+func (t *TestTool) rel(path string) string {
+	if wd := t.workDir(); wd != "" {
+		if r, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(r, "..") {
+			return r
+		}
+	}
+	return path
+}
+
+// pythonMarkers 标识 Python 项目根的文件
+var pythonMarkers = []string{"pyproject.toml", "pytest.ini", "setup.cfg", "setup.py", "tox.ini"}
+
+// planTests 按语言与项目根分组: Go 文件归入所在包, 目录测试其下所有包; Python 跑整个项目的测试集
+func planTests(paths []string) []testJob {
+	byKey := make(map[string]*testJob)
+	var order []string
+	add := func(lang, dir, target string) {
+		key := lang + "\x00" + dir
+		job := byKey[key]
+		if job == nil {
+			job = &testJob{lang: lang, dir: dir}
+			byKey[key] = job
+			order = append(order, key)
+		}
+		if target == "" {
+			return
+		}
+		for _, existing := range job.targets {
+			if existing == target {
+				return
+			}
+		}
+		job.targets = append(job.targets, target)
+	}
+
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			continue // 已删除
+		}
+		dir, suffix := filepath.Dir(p), ""
+		if info.IsDir() {
+			dir, suffix = p, "/..."
+		}
+		if root := findUp(dir, "go.mod"); root != "" && (info.IsDir() || filepath.Ext(p) == ".go") {
+			pkg, _ := filepath.Rel(root, dir)
+			add("go", root, "./"+filepath.ToSlash(pkg)+suffix)
+			continue
+		}
+		if info.IsDir() || filepath.Ext(p) == ".py" {
+			if root := findUpAny(dir, pythonMarkers); root != "" {
+				add("python", root, "")
+			}
+		}
+	}
+
+	jobs := make([]testJob, 0, len(order))
+	for _, key := range order {
+		job := byKey[key]
+		sort.Strings(job.targets)
+		jobs = append(jobs, *job)
+	}
+	return jobs
+}
+
+// projectTestJob 没有改动文件时测试整个工作区项目
+func projectTestJob(dir string) (testJob, bool) {
+	if dir == "" {
+		return testJob{}, false
+	}
+	if root := findUp(dir, "go.mod"); root != "" {
+		return testJob{lang: "go", dir: root, targets: []string{"./..."}}, true
+	}
+	if root := findUpAny(dir, pythonMarkers); root != "" {
+		return testJob{lang: "python", dir: root}, true
+	}
+	return testJob{}, false
+}
+
+func findUpAny(dir string, markers []string) string {
+	for {
+		for _, m := range markers {
+			if _, err := os.Stat(filepath.Join(dir, m)); err == nil {
+				return dir
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// testCommand 返回给用户看的命令与实际执行的 shell 命令
+func testCommand(job testJob) (display, script string) {
+	switch job.lang {
+	case "go":
+		display = "go test -cover " + strings.Join(job.targets, " ")
+		return display, display
+	default:
+		display = "python3 -m pytest -q --cov=. --cov-report=term"
+		script = "if python3 -c 'import pytest_cov' 2>/dev/null; then " + display +
+			"; else echo " + noCoverageMarker + "; python3 -m pytest -q; fi"
+		return display, script
+	}
+}
+
+// run 在 dir (项目根或其基线 worktree 中的对应目录) 运行 job
+func (t *TestTool) run(ctx context.Context, job testJob, dir string) testRun {
+	display, script := testCommand(job)
+	run := testRun{command: display, dir: dir}
+	res, err := t.sandbox.ExecuteWith(ctx, sandbox.ExecOptions{Timeout: t.cfg.Timeout}, "bash",
+		[]string{"-c", "cd " + shellQuote(dir) + " && " + script + " 2>&1"})
+	if err != nil {
+		run.skipped = err.Error() // 超时 / 取消 / 沙箱拒绝
+		return run
+	}
+	output := res.Stdout + res.Stderr
+
+	switch job.lang {
+	case "go":
+		if strings.Contains(output, "go: command not found") {
+			run.skipped = "go not installed"
+			return run
+		}
+		run.coverage, run.failed = parseGoTestOutput(output, goModulePath(dir))
+	default:
+		if strings.Contains(output, "No module named pytest") {
+			run.skipped = "pytest not installed (pip install pytest pytest-cov)"
+			return run
+		}
+		if strings.Contains(output, noCoverageMarker) {
+			run.note = "coverage unavailable: pip install pytest-cov"
+		}
+		run.coverage, run.failed = parsePytestOutput(output)
+		if res.ExitCode == 5 {
+			res.ExitCode = 0 // 没有收集到测试
+			run.note = "no tests collected"
+		}
+	}
+	run.passed = res.ExitCode == 0
+	if !run.passed {
+		run.output = tailString(strings.TrimSpace(output), testOutputTail)
+	}
+	return run
+}
+
+// baseline 返回 HEAD 上同一测试的覆盖率与其标签 ("HEAD 1a2b3c4"); 没有未提交
+// 改动或不在 git 仓库中时返回空 (当前覆盖率就是 HEAD 的)
+func (t *TestTool) baseline(ctx context.Context, job testJob) (map[string]float64, string) {
+	git := func(args string) (string, bool) {
+		res, err := t.sandbox.ExecuteWith(ctx, sandbox.ExecOptions{Timeout: time.Minute}, "bash",
+			[]string{"-c", "git -C " + shellQuote(job.dir) + " " + args})
+		if err != nil || res.ExitCode != 0 {
+			return "", false
+		}
+		return strings.TrimSpace(res.Stdout), true
+	}
+	out, ok := git("rev-parse --show-toplevel --short HEAD")
+	lines := strings.Split(out, "\n")
+	if !ok || len(lines) != 2 {
+		return nil, ""
+	}
+	top, head := lines[0], lines[1]
+	if status, ok := git("status --porcelain -- ."); !ok || status == "" {
+		return nil, ""
+	}
+	label := "HEAD " + head
+
+	display, _ := testCommand(job)
+	key := top + "\x00" + head + "\x00" + job.dir + "\x00" + display
+	t.mu.Lock()
+	cached, ok := t.baselines[key]
+	t.mu.Unlock()
+	if ok {
+		return cached, label
+	}
+
+	tmp, err := os.MkdirTemp("", "ngoclaw-baseline-")
+	if err != nil {
+		return nil, ""
+	}
+	defer os.RemoveAll(tmp)
+	wt := filepath.Join(tmp, "head")
+	if _, ok := git("worktree add --detach --quiet " + shellQuote(wt) + " HEAD"); !ok {
+		return nil, ""
+	}
+	defer git("worktree remove --force " + shellQuote(wt))
+
+	rel, _ := filepath.Rel(top, job.dir)
+	dir := filepath.Join(wt, rel)
+	baseJob := job
+	baseJob.targets = nil
+	for _, target := range job.targets {
+		// 本次新增的包在 HEAD 中不存在, 没有基线
+		if _, err := os.Stat(filepath.Join(dir, strings.TrimSuffix(target, "/..."))); err == nil {
+			baseJob.targets = append(baseJob.targets, target)
+		}
+	}
+	if job.lang == "go" && len(baseJob.targets) == 0 {
+		return nil, label
+	}
+	run := t.run(ctx, baseJob, dir)
+	if run.skipped != "" {
+		return nil, ""
+	}
+
+	t.mu.Lock()
+	t.baselines[key] = run.coverage
+	t.mu.Unlock()
+	return run.coverage, label
+}
+
+var (
+	// "ok  	example.com/m/pkg	0.01s	coverage: 75.0% of statements" ("(cached)" 代替耗时)
+	goTestOkRe = regexp.MustCompile(`^ok\s+(\S+)\s+\S+\s+coverage: ([\d.]+)% of statements`)
+	// "FAIL	example.com/m/pkg	0.01s" / "FAIL	example.com/m/pkg [build failed]"
+	goTestFailRe = regexp.MustCompile(`^FAIL\s+(\S+)\s`)
+	// pytest-cov term 报告: "src/app/core.py   20   5   75%" (branch 模式多两列)
+	pytestCovRe = regexp.MustCompile(`^(\S+\.py)\s+(\d+)\s+(\d+)(?:\s+\d+\s+\d+)?\s+\d+(?:\.\d+)?%`)
+	// pytest 简要汇总: "FAILED tests/test_x.py::test_y - AssertionError"
+	pytestFailRe = regexp.MustCompile(`^(?:FAILED|ERROR) (\S+)`)
+)
+
+// parseGoTestOutput 解析 go test -cover 的包级结果, 包名去掉模块路径前缀
+func parseGoTestOutput(output, module string) (map[string]float64, []string) {
+	coverage := make(map[string]float64)
+	var failed []string
+	short := func(pkg string) string {
+		if module == "" {
+			return pkg
+		}
+		if pkg == module {
+			return "."
+		}
+		return strings.TrimPrefix(pkg, module+"/")
+	}
+	sc := bufio.NewScanner(strings.NewReader(output))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if m := goTestOkRe.FindStringSubmatch(line); m != nil {
+			if pct, err := strconv.ParseFloat(m[2], 64); err == nil {
+				coverage[short(m[1])] = pct
+			}
+		} else if m := goTestFailRe.FindStringSubmatch(line); m != nil {
+			failed = append(failed, short(m[1]))
+		}
+	}
+	return coverage, failed
+}
+
+// parsePytestOutput 按目录汇总 pytest-cov 的文件级覆盖率 (不含测试文件本身)
+func parsePytestOutput(output string) (map[string]float64, []string) {
+	type counts struct{ stmts, miss int }
+	byDir := make(map[string]*counts)
+	var failed []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if m := pytestFailRe.FindStringSubmatch(line); m != nil {
+			failed = append(failed, m[1])
+			continue
+		}
+		m := pytestCovRe.FindStringSubmatch(line)
+		if m == nil || isPythonTestFile(m[1]) {
+			continue
+		}
+		dir := filepath.ToSlash(filepath.Dir(m[1]))
+		c := byDir[dir]
+		if c == nil {
+			c = &counts{}
+			byDir[dir] = c
+		}
+		c.stmts += atoi(m[2])
+		c.miss += atoi(m[3])
+	}
+	coverage := make(map[string]float64, len(byDir))
+	for dir, c := range byDir {
+		if c.stmts == 0 {
+			continue
+		}
+		coverage[dir] = float64(c.stmts-c.miss) * 100 / float64(c.stmts)
+	}
+	return coverage, failed
+}
+
+func isPythonTestFile(path string) bool {
+	base := filepath.Base(path)
+	if strings.HasPrefix(base, "test_") || strings.HasSuffix(base, "_test.py") || base == "conftest.py" {
+		return true
+	}
+	for _, part := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		if part == "tests" || part == "test" {
+			return true
+		}
+	}
+	return false
+}
+
+// goModulePath 读取 go.mod 的 module 路径
+func goModulePath(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if f := strings.Fields(line); len(f) >= 2 && f[0] == "module" {
+			return strings.Trim(f[1], `"`)
+		}
+	}
+	return ""
+}
+
+func formatTestRun(run testRun, dir, label string, base map[string]float64) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("$ %s  (%s)\n", run.command, dir))
+	if run.passed {
+		sb.WriteString("PASS\n")
+	} else {
+		if len(run.failed) > 0 {
+			sb.WriteString("FAIL: " + strings.Join(run.failed, ", ") + "\n")
+		} else {
+			sb.WriteString("FAIL\n")
+		}
+		sb.WriteString(run.output + "\n")
+	}
+	if run.note != "" {
+		sb.WriteString("(" + run.note + ")\n")
+	}
+	if len(run.coverage) == 0 {
+		sb.WriteString("\n")
+		return sb.String()
+	}
+
+	if label != "" {
+		sb.WriteString(fmt.Sprintf("\nCoverage (vs %s):\n", label))
+	} else {
+		sb.WriteString("\nCoverage:\n")
+	}
+	width := 0
+	for pkg := range run.coverage {
+		if len(pkg) > width {
+			width = len(pkg)
+		}
+	}
+	for _, pkg := range sortedKeys(run.coverage) {
+		pct := run.coverage[pkg]
+		sb.WriteString(fmt.Sprintf("  %-*s %6.1f%%", width, pkg, pct))
+		if label != "" {
+			b, ok := base[pkg]
+			switch {
+			case !ok:
+				sb.WriteString("  (new)")
+			case pct-b < -0.05:
+				sb.WriteString(fmt.Sprintf("  (was %.1f%%, %+.1f) ⚠ reduced", b, pct-b))
+			case pct-b > 0.05:
+				sb.WriteString(fmt.Sprintf("  (was %.1f%%, %+.1f)", b, pct-b))
+			default:
+				sb.WriteString("  (unchanged)")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func tailString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	s = s[len(s)-n:]
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return "…\n" + s
+}
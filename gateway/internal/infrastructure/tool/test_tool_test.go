@@ -0,0 +1,172 @@
+package tool
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/sandbox"
+	"go.uber.org/zap"
+)
+
+func TestParseGoTestOutput(t *testing.T) {
+	out := "ok  \texample.com/m\t0.004s\tcoverage: 50.0% of statements\n" +
+		"ok  \texample.com/m/a\t(cached)\tcoverage: 87.5% of statements\n" +
+		"--- FAIL: TestB (0.00s)\n" +
+		"FAIL\n" +
+		"FAIL\texample.com/m/b\t0.003s\n" +
+		"?   \texample.com/m/cmd\t[no test files]\n" +
+		"FAIL\texample.com/m/c [build failed]\n"
+	cov, failed := parseGoTestOutput(out, "example.com/m")
+	if len(cov) != 2 || cov["."] != 50 || cov["a"] != 87.5 {
+		t.Errorf("coverage = %v", cov)
+	}
+	if strings.Join(failed, ",") != "b,c" {
+		t.Errorf("failed = %v", failed)
+	}
+}
+
+func TestParsePytestOutput(t *testing.T) {
+	out := `..F
+Name                 Stmts   Miss  Cover
+----------------------------------------
+app/__init__.py          0      0   100%
+app/core.py             30      6    80%
+app/util.py             10      4    60%
+lib/x.py                 8      0   100%
+tests/test_core.py      20      0   100%
+----------------------------------------
+TOTAL                   68     10    85%
+=========================== short test summary info ===========================
+FAILED tests/test_core.py::test_edge - AssertionError: boom
+1 failed, 2 passed in 0.12s
+`
+	cov, failed := parsePytestOutput(out)
+	if len(cov) != 2 || cov["app"] != 75 || cov["lib"] != 100 {
+		t.Errorf("coverage = %v", cov)
+	}
+	if len(failed) != 1 || failed[0] != "tests/test_core.py::test_edge" {
+		t.Errorf("failed = %v", failed)
+	}
+}
+
+func TestPlanTests(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"svc/go.mod":          "module example.com/svc\n",
+		"svc/a/a.go":          "package a\n",
+		"svc/a/a_test.go":     "package a\n",
+		"svc/b/b.go":          "package b\n",
+		"py/pyproject.toml":   "",
+		"py/pkg/mod.py":       "",
+		"py/tests/test_x.py":  "",
+		"notes/readme.md":     "",
+		"svc/a/testdata/x.in": "",
+	})
+	jobs := planTests([]string{
+		filepath.Join(root, "svc/a/a.go"),
+		filepath.Join(root, "svc/a/a_test.go"),
+		filepath.Join(root, "svc/b"),
+		filepath.Join(root, "py/pkg/mod.py"),
+		filepath.Join(root, "py/tests/test_x.py"),
+		filepath.Join(root, "notes/readme.md"),
+		filepath.Join(root, "svc/gone.go"),
+	})
+	if len(jobs) != 2 {
+		t.Fatalf("jobs = %+v", jobs)
+	}
+	if jobs[0].lang != "go" || strings.Join(jobs[0].targets, " ") != "./a ./b/..." {
+		t.Errorf("go job = %+v", jobs[0])
+	}
+	if jobs[1].lang != "python" || jobs[1].dir != filepath.Join(root, "py") {
+		t.Errorf("python job = %+v", jobs[1])
+	}
+}
+
+func TestTestReportSummary(t *testing.T) {
+	was := 80.0
+	r := &entity.TestReport{
+		Passed:   true,
+		Baseline: "HEAD abc1234",
+		Packages: []entity.PackageCoverage{
+			{Package: "a", Coverage: 75.5, Baseline: &was},
+			{Package: "new", Coverage: 10},
+		},
+	}
+	if got, want := r.Summary(), "tests passed · coverage ↓ a 80.0% → 75.5% (-4.5)"; got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+	r.Packages[0].Coverage = 80.02
+	if got, want := r.Summary(), "tests passed · coverage not reduced (2 packages vs HEAD abc1234)"; got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+}
+
+// TestRunTestsBaseline 在临时 git 仓库中删掉一个被测试覆盖的分支的测试,
+// 覆盖率应相对 HEAD 下降
+func TestRunTestsBaseline(t *testing.T) {
+	for _, bin := range []string{"go", "git"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skip(bin + " not installed")
+		}
+	}
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"go.mod":       "module example.com/m\n\ngo 1.21\n",
+		"calc/calc.go": "package calc\n\nfunc Sign(x int) int {\n\tif x < 0 {\n\t\treturn -1\n\t}\n\treturn 1\n}\n",
+		"calc/calc_test.go": "package calc\n\nimport \"testing\"\n\n" +
+			"func TestPos(t *testing.T) {\n\tif Sign(2) != 1 {\n\t\tt.Fatal()\n\t}\n}\n\n" +
+			"func TestNeg(t *testing.T) {\n\tif Sign(-2) != -1 {\n\t\tt.Fatal()\n\t}\n}\n",
+	})
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-qm", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	writeTree(t, root, map[string]string{
+		"calc/calc_test.go": "package calc\n\nimport \"testing\"\n\n" +
+			"func TestPos(t *testing.T) {\n\tif Sign(2) != 1 {\n\t\tt.Fatal()\n\t}\n}\n",
+	})
+
+	sbxCfg := sandbox.DefaultConfig()
+	sbxCfg.WorkDir = root
+	sbxCfg.TempDir = t.TempDir()
+	sb, err := sandbox.NewProcessSandbox(sbxCfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool := NewTestTool(sb, nil, TestConfig{Baseline: true}, zap.NewNop())
+
+	res, err := tool.Execute(context.Background(), map[string]interface{}{
+		"paths": []interface{}{"calc/calc_test.go"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, _ := res.Metadata["test_report"].(*entity.TestReport)
+	if report == nil || !res.Success || len(report.Packages) != 1 {
+		t.Fatalf("result = %+v\n%s", res, res.Output)
+	}
+	reduced := report.Reduced()
+	if len(reduced) != 1 || reduced[0].Package != "calc" || *reduced[0].Baseline != 100 {
+		t.Fatalf("reduced = %+v\n%s", reduced, res.Output)
+	}
+	if !strings.Contains(res.Output, "⚠ reduced") || !strings.HasPrefix(report.Baseline, "HEAD ") {
+		t.Errorf("output:\n%s", res.Output)
+	}
+
+	// 基线 worktree 用完即删
+	out, _ := exec.Command("git", "-C", root, "worktree", "list").Output()
+	if strings.Count(string(out), "\n") != 1 {
+		t.Errorf("leftover worktrees:\n%s", out)
+	}
+}
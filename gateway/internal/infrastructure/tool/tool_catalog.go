@@ -0,0 +1,186 @@
+package tool
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+)
+
+// ToolSpec 对外导出的工具定义 (GET /v1/tools, ngoclaw tools export)
+type ToolSpec struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Kind        string                 `json:"kind"`
+	Category    string                 `json:"category"`           // kind, 或 MCP 服务器提供的工具为 "mcp" (ToolCategory)
+	Risk        string                 `json:"risk"`               // low | medium | high (domaintool.RiskClass)
+	Approval    string                 `json:"approval,omitempty"` // never | always | per_call (service.ApprovalPolicy)
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// ToolCatalog 工具目录; Version 是内容哈希, 工具集或 schema 变化时随之改变
+type ToolCatalog struct {
+	Version  string     `json:"version"`
+	Tools    []ToolSpec `json:"tools"`
+	Disabled []string   `json:"disabled,omitempty"` // 配置中已关闭、未注册的工具
+
+	ApprovalMode string `json:"approval_mode,omitempty"` // 生成 Approval 时的 security.approval_mode
+}
+
+// ToolCategoryOrder /tools 与系统提示词中工具分组的顺序
+var ToolCategoryOrder = []string{
+	string(domaintool.KindRead),
+	string(domaintool.KindSearch),
+	string(domaintool.KindEdit),
+	string(domaintool.KindDelete),
+	string(domaintool.KindExecute),
+	string(domaintool.KindFetch),
+	"mcp",
+	string(domaintool.KindThink),
+	string(domaintool.KindCommunicate),
+}
+
+// ToolCategory 工具分组: MCP 服务器提供的工具归入 "mcp", 其余按 Kind
+func ToolCategory(t domaintool.Tool) string {
+	if _, ok := t.(*MCPTool); ok {
+		return "mcp"
+	}
+	return string(t.Kind())
+}
+
+// ToolGroup 同一分组的工具
+type ToolGroup struct {
+	Category string
+	Tools    []ToolSpec
+}
+
+// Groups 按分组返回工具 (ToolCategoryOrder 顺序, 未知分组排在最后), 组内按名称排序
+func (c *ToolCatalog) Groups() []ToolGroup {
+	byCategory := make(map[string][]ToolSpec)
+	for _, t := range c.Tools {
+		byCategory[t.Category] = append(byCategory[t.Category], t)
+	}
+	var groups []ToolGroup
+	for _, cat := range ToolCategoryOrder {
+		if tools := byCategory[cat]; len(tools) > 0 {
+			groups = append(groups, ToolGroup{Category: cat, Tools: tools})
+			delete(byCategory, cat)
+		}
+	}
+	rest := make([]string, 0, len(byCategory))
+	for cat := range byCategory {
+		rest = append(rest, cat)
+	}
+	sort.Strings(rest)
+	for _, cat := range rest {
+		groups = append(groups, ToolGroup{Category: cat, Tools: byCategory[cat]})
+	}
+	return groups
+}
+
+// DescribeTools 导出注册表中的全部工具 (按名称排序)。
+// approval 为 nil 时不填审批要求。
+func DescribeTools(reg domaintool.Registry, approval func(name string) string) *ToolCatalog {
+	defs := reg.List()
+	specs := make([]ToolSpec, 0, len(defs))
+	for _, d := range defs {
+		spec := ToolSpec{
+			Name:        d.Name,
+			Description: d.Description,
+			Parameters:  d.Parameters,
+		}
+		if t, ok := reg.Get(d.Name); ok {
+			spec.Kind = string(t.Kind())
+			spec.Category = ToolCategory(t)
+			spec.Risk = domaintool.RiskClass(t.Kind())
+		}
+		if approval != nil {
+			spec.Approval = approval(d.Name)
+		}
+		if spec.Parameters == nil {
+			spec.Parameters = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+
+	raw, _ := json.Marshal(specs)
+	sum := sha256.Sum256(raw)
+	return &ToolCatalog{Version: hex.EncodeToString(sum[:6]), Tools: specs}
+}
+
+// OpenAPI 以 OpenAPI 3.1 文档描述工具目录: 每个工具是一个 POST /tools/{name}
+// 操作, 请求体即工具参数 schema; kind / risk / approval 放在 x-ngoclaw-* 扩展字段。
+// 网关并不暴露这些路径, 文档用于审计与生成外部审批界面。
+func (c *ToolCatalog) OpenAPI() map[string]interface{} {
+	paths := make(map[string]interface{}, len(c.Tools))
+	for _, t := range c.Tools {
+		op := map[string]interface{}{
+			"operationId":    t.Name,
+			"summary":        firstSentence(t.Description),
+			"description":    t.Description,
+			"tags":           []string{t.Kind},
+			"x-ngoclaw-kind": t.Kind,
+			"x-ngoclaw-risk": t.Risk,
+			"requestBody": map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": t.Parameters},
+				},
+			},
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "Tool result",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{
+							"schema": map[string]interface{}{"$ref": "#/components/schemas/ToolResult"},
+						},
+					},
+				},
+			},
+		}
+		if t.Approval != "" {
+			op["x-ngoclaw-approval"] = t.Approval
+		}
+		paths["/tools/"+t.Name] = map[string]interface{}{"post": op}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.1.0",
+		"info": map[string]interface{}{
+			"title":       "NGOClaw agent tools",
+			"version":     c.Version,
+			"description": "Tools the agent can call. Generated from the live tool registry.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"ToolResult": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"success": map[string]interface{}{"type": "boolean"},
+						"output":  map[string]interface{}{"type": "string"},
+						"error":   map[string]interface{}{"type": "string"},
+					},
+				},
+			},
+		},
+	}
+}
+
+// firstSentence 截取描述的第一句作为 summary
+func firstSentence(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, ". "); i >= 0 {
+		return s[:i+1]
+	}
+	if i := strings.Index(s, "。"); i >= 0 {
+		return s[:i+len("。")]
+	}
+	return s
+}
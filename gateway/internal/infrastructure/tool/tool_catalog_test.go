@@ -0,0 +1,115 @@
+package tool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+)
+
+type catalogStubTool struct {
+	name   string
+	kind   domaintool.Kind
+	schema map[string]interface{}
+}
+
+func (t catalogStubTool) Name() string                   { return t.name }
+func (t catalogStubTool) Description() string            { return "Does " + t.name + ". More detail." }
+func (t catalogStubTool) Kind() domaintool.Kind          { return t.kind }
+func (t catalogStubTool) Schema() map[string]interface{} { return t.schema }
+func (t catalogStubTool) Execute(context.Context, map[string]interface{}) (*Result, error) {
+	return &Result{Success: true}, nil
+}
+
+func TestDescribeTools(t *testing.T) {
+	reg := domaintool.NewInMemoryRegistry()
+	reg.Register(catalogStubTool{name: "write_file", kind: domaintool.KindEdit,
+		schema: map[string]interface{}{"type": "object", "required": []string{"path"}}})
+	reg.Register(catalogStubTool{name: "read_file", kind: domaintool.KindRead})
+	reg.Register(catalogStubTool{name: "web_fetch", kind: domaintool.KindFetch})
+
+	catalog := DescribeTools(reg, func(name string) string {
+		if name == "write_file" {
+			return "per_call"
+		}
+		return "never"
+	})
+
+	want := []struct{ name, kind, risk, approval string }{
+		{"read_file", "read", "low", "never"},
+		{"web_fetch", "fetch", "medium", "never"},
+		{"write_file", "edit", "high", "per_call"},
+	}
+	if len(catalog.Tools) != len(want) {
+		t.Fatalf("tools = %d, want %d", len(catalog.Tools), len(want))
+	}
+	for i, w := range want {
+		got := catalog.Tools[i]
+		if got.Name != w.name || got.Kind != w.kind || got.Risk != w.risk || got.Approval != w.approval {
+			t.Errorf("tools[%d] = %+v, want %+v", i, got, w)
+		}
+		if got.Parameters["type"] != "object" {
+			t.Errorf("%s: parameters = %v", got.Name, got.Parameters)
+		}
+	}
+
+	// 版本只随内容变化
+	if again := DescribeTools(reg, nil); again.Version == catalog.Version {
+		t.Error("version should change when approval requirements change")
+	}
+	reg2 := domaintool.NewInMemoryRegistry()
+	reg2.Register(catalogStubTool{name: "web_fetch", kind: domaintool.KindFetch})
+	reg2.Register(catalogStubTool{name: "read_file", kind: domaintool.KindRead})
+	reg2.Register(catalogStubTool{name: "write_file", kind: domaintool.KindEdit,
+		schema: map[string]interface{}{"type": "object", "required": []string{"path"}}})
+	if v := DescribeTools(reg2, func(name string) string {
+		if name == "write_file" {
+			return "per_call"
+		}
+		return "never"
+	}).Version; v != catalog.Version {
+		t.Errorf("version not stable across registration order: %s vs %s", v, catalog.Version)
+	}
+}
+
+func TestToolCatalogOpenAPI(t *testing.T) {
+	reg := domaintool.NewInMemoryRegistry()
+	reg.Register(catalogStubTool{name: "shell_exec", kind: domaintool.KindExecute})
+	doc := DescribeTools(reg, func(string) string { return "always" }).OpenAPI()
+
+	if doc["openapi"] != "3.1.0" {
+		t.Errorf("openapi = %v", doc["openapi"])
+	}
+	paths := doc["paths"].(map[string]interface{})
+	op := paths["/tools/shell_exec"].(map[string]interface{})["post"].(map[string]interface{})
+	if op["x-ngoclaw-risk"] != "high" || op["x-ngoclaw-approval"] != "always" || op["x-ngoclaw-kind"] != "execute" {
+		t.Errorf("extensions = %v / %v / %v", op["x-ngoclaw-kind"], op["x-ngoclaw-risk"], op["x-ngoclaw-approval"])
+	}
+	if op["summary"] != "Does shell_exec." {
+		t.Errorf("summary = %q", op["summary"])
+	}
+}
+
+func TestToolCatalogGroups(t *testing.T) {
+	reg := domaintool.NewInMemoryRegistry()
+	reg.Register(catalogStubTool{name: "write_file", kind: domaintool.KindEdit})
+	reg.Register(catalogStubTool{name: "bash", kind: domaintool.KindExecute})
+	reg.Register(catalogStubTool{name: "read_file", kind: domaintool.KindRead})
+	reg.Register(catalogStubTool{name: "grep", kind: domaintool.KindRead})
+	reg.Register(NewMCPTool(&MCPAdapter{name: "github"}, MCPToolDef{Name: "list_issues"}, nil))
+	reg.Register(catalogStubTool{name: "custom", kind: "custom"})
+
+	var got []string
+	for _, g := range DescribeTools(reg, nil).Groups() {
+		names := make([]string, len(g.Tools))
+		for i, tool := range g.Tools {
+			names[i] = tool.Name
+		}
+		got = append(got, g.Category+":"+strings.Join(names, ","))
+	}
+	want := []string{"read:grep,read_file", "edit:write_file", "execute:bash", "mcp:github_list_issues", "custom:custom"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("groups = %v, want %v", got, want)
+	}
+}
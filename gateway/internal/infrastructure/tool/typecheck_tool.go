@@ -0,0 +1,470 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/sandbox"
+	"go.uber.org/zap"
+)
+
+const (
+	// typecheckTimeout 单个检查 (一次 go vet / tsc / ruff) 的超时
+	typecheckTimeout = 2 * time.Minute
+	// typecheckMaxDiagnostics 输出给模型的诊断条数上限
+	typecheckMaxDiagnostics = 50
+)
+
+// TypecheckDiagnostic 一条编译/类型检查诊断
+type TypecheckDiagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity"` // error | warning
+	Code     string `json:"code,omitempty"`
+	Message  string `json:"message"`
+	Checker  string `json:"checker"`
+}
+
+// typecheckJob 一次检查: 在 dir 下运行 checker, 覆盖 targets (Go 包 / Python 文件)
+type typecheckJob struct {
+	lang    string // go | typescript | python
+	dir     string
+	targets []string
+}
+
+// typecheckRun 一次检查的结果
+type typecheckRun struct {
+	command     string
+	dir         string
+	skipped     string // 非空 = 未运行的原因 (工具未安装等)
+	diagnostics []TypecheckDiagnostic
+	raw         string // 退出码非零但无法解析时的原始输出
+}
+
+// TypecheckTool 只检查本 run 改过的包/文件: Go 用 go vet (含类型检查),
+// TypeScript 用 tsc --noEmit, Python 用 ruff (无则 pyright / py_compile)。
+// 同时实现 service.Typechecker, 供 TypecheckMiddleware 在编辑后自动调用。
+type TypecheckTool struct {
+	sandbox *sandbox.ProcessSandbox
+	guard   *FileGuard
+	logger  *zap.Logger
+}
+
+// NewTypecheckTool 创建 typecheck 工具; guard 为 nil 时必须显式传 paths
+func NewTypecheckTool(sb *sandbox.ProcessSandbox, guard *FileGuard, logger *zap.Logger) *TypecheckTool {
+	return &TypecheckTool{sandbox: sb, guard: guard, logger: logger}
+}
+
+func (t *TypecheckTool) Name() string          { return "typecheck" }
+func (t *TypecheckTool) Kind() domaintool.Kind { return domaintool.KindExecute }
+
+func (t *TypecheckTool) Description() string {
+	return "Fast compile/type check of only the code you changed in this run: go vet for touched Go packages, " +
+		"tsc --noEmit for touched TypeScript projects, ruff (or pyright) for touched Python files. " +
+		"Returns file:line diagnostics. Call without arguments after edits; pass paths to check specific files."
+}
+
+func (t *TypecheckTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"paths": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Files to check. Default: every file edited in this run",
+			},
+		},
+	}
+}
+
+func (t *TypecheckTool) Execute(ctx context.Context, args map[string]interface{}) (*Result, error) {
+	var paths []string
+	if raw, ok := args["paths"].([]interface{}); ok {
+		for _, p := range raw {
+			if s, ok := p.(string); ok && s != "" {
+				paths = append(paths, t.resolve(s))
+			}
+		}
+	}
+	if len(paths) == 0 && t.guard != nil {
+		paths = t.guard.EditedFiles(ctx)
+	}
+	if len(paths) == 0 {
+		return &Result{Success: false, Error: "no files edited in this run; pass paths to check specific files"}, nil
+	}
+
+	runs := t.check(ctx, paths)
+	if len(runs) == 0 {
+		return &Result{
+			Output:  "typecheck: nothing to check (no Go, TypeScript or Python files among: " + strings.Join(t.relAll(paths), ", ") + ")",
+			Success: true,
+		}, nil
+	}
+
+	var diags []TypecheckDiagnostic
+	var checks []string
+	for _, r := range runs {
+		diags = append(diags, r.diagnostics...)
+		checks = append(checks, r.command)
+	}
+	errors := countErrors(runs)
+	return &Result{
+		Output:  t.format(runs),
+		Success: errors == 0,
+		Metadata: map[string]interface{}{
+			"diagnostics": diags,
+			"checks":      checks,
+			"errors":      errors,
+		},
+	}, nil
+}
+
+// Typecheck 实现 service.Typechecker: 检查 paths (nil = 本 run 改过的文件), 无可检查文件时返回空串
+func (t *TypecheckTool) Typecheck(ctx context.Context, paths []string) string {
+	if len(paths) == 0 && t.guard != nil {
+		paths = t.guard.EditedFiles(ctx)
+	}
+	runs := t.check(ctx, paths)
+	if len(runs) == 0 {
+		return ""
+	}
+	return t.format(runs)
+}
+
+var _ service.Typechecker = (*TypecheckTool)(nil)
+
+func (t *TypecheckTool) workDir() string {
+	if t.sandbox != nil {
+		return t.sandbox.GetWorkDir()
+	}
+	return ""
+}
+
+func (t *TypecheckTool) resolve(path string) string {
+	if t.guard != nil {
+		return t.guard.Resolve(path)
+	}
+	return resolveReadPath(path, t.workDir())
+}
+
+// rel 相对工作目录显示路径 (更短, 与模型使用的路径一致)
+func (t *TypecheckTool) rel(path string) string {
+	if wd := t.workDir(); wd != "" {
+		if r, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(r, "..") {
+			return r
+		}
+	}
+	return path
+}
+
+func (t *TypecheckTool) relAll(paths []string) []string {
+	out := make([]string, len(paths))
+	for i, p := range paths {
+		out[i] = t.rel(p)
+	}
+	return out
+}
+
+func (t *TypecheckTool) check(ctx context.Context, paths []string) []typecheckRun {
+	jobs := planTypecheck(paths)
+	runs := make([]typecheckRun, 0, len(jobs))
+	for _, job := range jobs {
+		run := t.runJob(ctx, job)
+		t.logger.Info("Typecheck",
+			zap.String("command", run.command),
+			zap.String("dir", run.dir),
+			zap.Int("diagnostics", len(run.diagnostics)),
+			zap.String("skipped", run.skipped),
+		)
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// planTypecheck 按语言与项目根分组: Go 按 go.mod 合并包, TS 按 tsconfig.json, Python 合并文件
+func planTypecheck(paths []string) []typecheckJob {
+	byKey := make(map[string]*typecheckJob)
+	var order []string
+	add := func(lang, dir, target string) {
+		key := lang + "\x00" + dir
+		job := byKey[key]
+		if job == nil {
+			job = &typecheckJob{lang: lang, dir: dir}
+			byKey[key] = job
+			order = append(order, key)
+		}
+		if target != "" {
+			for _, existing := range job.targets {
+				if existing == target {
+					return
+				}
+			}
+			job.targets = append(job.targets, target)
+		}
+	}
+
+	for _, p := range paths {
+		if _, err := os.Stat(p); err != nil {
+			continue // 已删除
+		}
+		dir := filepath.Dir(p)
+		switch filepath.Ext(p) {
+		case ".go":
+			root := findUp(dir, "go.mod")
+			if root == "" {
+				continue
+			}
+			pkg, _ := filepath.Rel(root, dir)
+			add("go", root, "./"+filepath.ToSlash(pkg))
+		case ".ts", ".tsx", ".mts", ".cts":
+			if root := findUp(dir, "tsconfig.json"); root != "" {
+				add("typescript", root, "")
+			}
+		case ".py":
+			add("python", "", p)
+		}
+	}
+
+	jobs := make([]typecheckJob, 0, len(order))
+	for _, key := range order {
+		job := byKey[key]
+		sort.Strings(job.targets)
+		jobs = append(jobs, *job)
+	}
+	return jobs
+}
+
+// findUp 从 dir 向上查找包含 marker 的目录
+func findUp(dir, marker string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+var (
+	// go vet / go build: "pkg/a.go:12:3: msg" 或 "vet: pkg/a.go:12:3: msg"
+	goDiagRe = regexp.MustCompile(`^(?:vet: )?(\S+\.go):(\d+)(?::(\d+))?: (.+)$`)
+	// tsc --pretty false: "src/a.ts(12,3): error TS2322: msg"
+	tscDiagRe = regexp.MustCompile(`^(.+?)\((\d+),(\d+)\): (error|warning) (TS\d+): (.+)$`)
+	// ruff --output-format=concise: "a.py:12:3: F401 [*] msg"
+	ruffDiagRe = regexp.MustCompile(`^(.+?\.pyi?):(\d+):(\d+): ([A-Z]+\d+) (?:\[\*\] )?(.+)$`)
+	// pyright: "  /abs/a.py:12:3 - error: msg"
+	pyrightDiagRe = regexp.MustCompile(`^\s*(.+?\.pyi?):(\d+):(\d+) - (error|warning): (.+)$`)
+	// python -m py_compile: '  File "a.py", line 12'
+	pyCompileRe = regexp.MustCompile(`File "(.+?)", line (\d+)`)
+)
+
+func (t *TypecheckTool) runJob(ctx context.Context, job typecheckJob) typecheckRun {
+	var cmd, checker string
+	run := typecheckRun{dir: job.dir}
+	switch job.lang {
+	case "go":
+		checker = "go vet"
+		run.command = "go vet " + strings.Join(job.targets, " ")
+		if _, err := exec.LookPath("go"); err != nil {
+			run.skipped = "go not installed"
+			return run
+		}
+		cmd = run.command
+	case "typescript":
+		checker = "tsc"
+		run.command = "tsc --noEmit"
+		tsc := filepath.Join(job.dir, "node_modules", ".bin", "tsc")
+		if _, err := os.Stat(tsc); err != nil {
+			if tsc, err = exec.LookPath("tsc"); err != nil {
+				run.skipped = "tsc not found (npm i -D typescript)"
+				return run
+			}
+		}
+		cmd = shellQuote(tsc) + " --noEmit --pretty false -p ."
+	case "python":
+		quoted := make([]string, len(job.targets))
+		for i, f := range job.targets {
+			quoted[i] = shellQuote(f)
+		}
+		files := strings.Join(quoted, " ")
+		switch {
+		case lookPath("ruff"):
+			checker = "ruff"
+			cmd = "ruff check --output-format=concise " + files
+		case lookPath("pyright"):
+			checker = "pyright"
+			cmd = "pyright " + files
+		default:
+			checker = "py_compile"
+			cmd = "python3 -m py_compile " + files
+		}
+		run.command = checker + " " + strings.Join(t.relAll(job.targets), " ")
+	}
+
+	if t.sandbox == nil {
+		run.skipped = "sandbox unavailable"
+		return run
+	}
+	if job.dir != "" {
+		cmd = "cd " + shellQuote(job.dir) + " && " + cmd
+	}
+	res, err := t.sandbox.ExecuteWith(ctx, sandbox.ExecOptions{Timeout: typecheckTimeout}, "bash", []string{"-c", cmd + " 2>&1"})
+	if err != nil {
+		run.skipped = err.Error() // 超时 / 取消 / 沙箱拒绝
+		return run
+	}
+
+	output := res.Stdout + res.Stderr
+	run.diagnostics = t.parseDiagnostics(checker, job.dir, output)
+	if res.ExitCode != 0 && len(run.diagnostics) == 0 {
+		run.raw = strings.TrimSpace(output)
+		if run.raw == "" {
+			run.raw = fmt.Sprintf("%s exited with code %d", checker, res.ExitCode)
+		}
+	}
+	return run
+}
+
+func lookPath(bin string) bool {
+	_, err := exec.LookPath(bin)
+	return err == nil
+}
+
+func (t *TypecheckTool) parseDiagnostics(checker, dir, output string) []TypecheckDiagnostic {
+	var diags []TypecheckDiagnostic
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		d := TypecheckDiagnostic{Checker: checker, Severity: "error"}
+		switch checker {
+		case "go vet":
+			m := goDiagRe.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			d.File, d.Line, d.Column, d.Message = m[1], atoi(m[2]), atoi(m[3]), m[4]
+		case "tsc":
+			m := tscDiagRe.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			d.File, d.Line, d.Column, d.Severity, d.Code, d.Message = m[1], atoi(m[2]), atoi(m[3]), m[4], m[5], m[6]
+		case "ruff":
+			m := ruffDiagRe.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			d.File, d.Line, d.Column, d.Code, d.Message = m[1], atoi(m[2]), atoi(m[3]), m[4], m[5]
+		case "pyright":
+			m := pyrightDiagRe.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			d.File, d.Line, d.Column, d.Severity, d.Message = m[1], atoi(m[2]), atoi(m[3]), m[4], m[5]
+		case "py_compile":
+			m := pyCompileRe.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			d.File, d.Line = m[1], atoi(m[2])
+			// 异常信息在后面几行 ("SyntaxError: ...")
+			for _, next := range lines[i+1:] {
+				if strings.Contains(next, "Error:") {
+					d.Message = strings.TrimSpace(next)
+					break
+				}
+			}
+		}
+		if !filepath.IsAbs(d.File) && dir != "" {
+			d.File = filepath.Join(dir, d.File)
+		}
+		d.File = t.rel(filepath.Clean(d.File))
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+func countErrors(runs []typecheckRun) int {
+	n := 0
+	for _, r := range runs {
+		for _, d := range r.diagnostics {
+			if d.Severity == "error" {
+				n++
+			}
+		}
+		if r.raw != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// format 给模型的文本结果: 每个检查一行状态, 随后列出诊断
+func (t *TypecheckTool) format(runs []typecheckRun) string {
+	var b strings.Builder
+	errors := countErrors(runs)
+	if errors == 0 {
+		b.WriteString("typecheck: no errors\n")
+	} else {
+		fmt.Fprintf(&b, "typecheck: %d error(s)\n", errors)
+	}
+
+	shown := 0
+	for _, r := range runs {
+		where := ""
+		if r.dir != "" {
+			where = " (in " + t.rel(r.dir) + ")"
+		}
+		switch {
+		case r.skipped != "":
+			fmt.Fprintf(&b, "- skipped %s%s: %s\n", r.command, where, r.skipped)
+			continue
+		case len(r.diagnostics) == 0 && r.raw == "":
+			fmt.Fprintf(&b, "✓ %s%s\n", r.command, where)
+			continue
+		}
+		fmt.Fprintf(&b, "✗ %s%s\n", r.command, where)
+		if r.raw != "" {
+			b.WriteString(truncateStr(r.raw, 2000) + "\n")
+		}
+		for j, d := range r.diagnostics {
+			if shown == typecheckMaxDiagnostics {
+				fmt.Fprintf(&b, "  ... %d more\n", len(r.diagnostics)-j)
+				break
+			}
+			shown++
+			b.WriteString("  " + d.File + ":" + strconv.Itoa(d.Line))
+			if d.Column > 0 {
+				b.WriteString(":" + strconv.Itoa(d.Column))
+			}
+			b.WriteString(": ")
+			if d.Severity != "error" {
+				b.WriteString(d.Severity + ": ")
+			}
+			if d.Code != "" {
+				b.WriteString(d.Code + " ")
+			}
+			b.WriteString(d.Message + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,119 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/sandbox"
+	"go.uber.org/zap"
+)
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPlanTypecheck(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"svc/go.mod":           "module example.com/svc\n",
+		"svc/a/a.go":           "package a\n",
+		"svc/a/b.go":           "package a\n",
+		"svc/cmd/main.go":      "package main\n",
+		"web/tsconfig.json":    "{}",
+		"web/src/app.ts":       "",
+		"web/src/util.ts":      "",
+		"scripts/run.py":       "",
+		"README.md":            "",
+		"loose/nomod/x.go":     "package x\n",
+		"svc/a/testdata/x.txt": "",
+	})
+	paths := []string{
+		filepath.Join(root, "svc/a/a.go"),
+		filepath.Join(root, "svc/a/b.go"),
+		filepath.Join(root, "svc/cmd/main.go"),
+		filepath.Join(root, "web/src/app.ts"),
+		filepath.Join(root, "web/src/util.ts"),
+		filepath.Join(root, "scripts/run.py"),
+		filepath.Join(root, "README.md"),
+		filepath.Join(root, "svc/a/deleted.go"),
+	}
+	jobs := planTypecheck(paths)
+	if len(jobs) != 3 {
+		t.Fatalf("jobs = %+v", jobs)
+	}
+	if jobs[0].lang != "go" || jobs[0].dir != filepath.Join(root, "svc") ||
+		strings.Join(jobs[0].targets, " ") != "./a ./cmd" {
+		t.Errorf("go job = %+v", jobs[0])
+	}
+	if jobs[1].lang != "typescript" || jobs[1].dir != filepath.Join(root, "web") {
+		t.Errorf("ts job = %+v", jobs[1])
+	}
+	if jobs[2].lang != "python" || len(jobs[2].targets) != 1 {
+		t.Errorf("python job = %+v", jobs[2])
+	}
+}
+
+func TestTypecheckTool_Go(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not installed")
+	}
+	work := t.TempDir()
+	writeTree(t, work, map[string]string{
+		"go.mod":     "module example.com/m\n\ngo 1.21\n",
+		"ok/ok.go":   "package ok\n\nfunc One() int { return 1 }\n",
+		"bad/bad.go": "package bad\n\nfunc Two() int { return \"two\" }\n",
+		"other/o.go": "package other\n\nfunc Broken() int { return \"untouched\" }\n",
+	})
+
+	cfg := sandbox.DefaultConfig()
+	cfg.WorkDir = work
+	cfg.TempDir = t.TempDir()
+	sb, err := sandbox.NewProcessSandbox(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	guard := NewFileGuard(sb.GetWorkDir)
+	tool := NewTypecheckTool(sb, guard, zap.NewNop())
+	ctx := service.WithTraceID(context.Background(), "run-1")
+
+	if res, _ := tool.Execute(ctx, map[string]interface{}{}); res.Success {
+		t.Fatal("expected error when nothing was edited")
+	}
+
+	// 只检查本 run 改过的包: other/ 的错误不应出现
+	guard.RecordEdit(ctx, filepath.Join(work, "ok/ok.go"), filepath.Join(work, "bad/bad.go"))
+	res, _ := tool.Execute(ctx, map[string]interface{}{})
+	if res.Success {
+		t.Fatalf("expected type error:\n%s", res.Output)
+	}
+	if !strings.Contains(res.Output, "bad/bad.go:3:") || strings.Contains(res.Output, "other/") {
+		t.Errorf("output:\n%s", res.Output)
+	}
+	diags, _ := res.Metadata["diagnostics"].([]TypecheckDiagnostic)
+	if len(diags) == 0 || diags[0].File != filepath.Join("bad", "bad.go") || diags[0].Line != 3 {
+		t.Errorf("diagnostics = %+v", diags)
+	}
+
+	res, _ = tool.Execute(ctx, map[string]interface{}{"paths": []interface{}{"ok/ok.go"}})
+	if !res.Success || !strings.Contains(res.Output, "✓ go vet ./ok") {
+		t.Errorf("clean package:\n%s", res.Output)
+	}
+
+	if report := tool.Typecheck(service.WithTraceID(context.Background(), "run-2"), nil); report != "" {
+		t.Errorf("other run should have nothing to check, got %q", report)
+	}
+}
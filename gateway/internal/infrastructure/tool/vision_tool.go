@@ -0,0 +1,190 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	domaintool "github.com/ngoclaw/ngoclaw/gateway/internal/domain/tool"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultVisionMaxBytes  = 10 << 20
+	defaultVisionMaxTokens = 1500
+	visionTimeout          = 2 * time.Minute
+)
+
+// visionMimeTypes are the image formats every provider accepts.
+var visionMimeTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+const visionSystemPrompt = "You describe images for a coding assistant that cannot see them. " +
+	"Transcribe all visible text exactly (error messages, stack traces, code, labels, URLs), keeping line breaks. " +
+	"For UI screenshots and design mocks, describe layout, components, colors and spacing precisely enough to implement them. " +
+	"For diagrams, describe the nodes and how they connect. Do not guess at content you cannot read."
+
+// VisionConfig configures analyze_image.
+type VisionConfig struct {
+	LLM       service.LLMClient
+	Model     string        // vision-capable model, e.g. "bailian/qwen-vl-max"
+	MaxBytes  int           // largest accepted image (default 10 MB)
+	MaxTokens int           // answer length limit (default 1500)
+	WorkDir   func() string // workspace root; relative paths resolve against it
+}
+
+// AnalyzeImageTool lets a run read a workspace image (screenshot of an error,
+// design mock, diagram) through a separate vision model, so it works even
+// when the main model has no image input.
+type AnalyzeImageTool struct {
+	cfg    VisionConfig
+	logger *zap.Logger
+}
+
+// NewAnalyzeImageTool creates the analyze_image tool.
+func NewAnalyzeImageTool(cfg VisionConfig, logger *zap.Logger) *AnalyzeImageTool {
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = defaultVisionMaxBytes
+	}
+	if cfg.MaxTokens <= 0 {
+		cfg.MaxTokens = defaultVisionMaxTokens
+	}
+	return &AnalyzeImageTool{cfg: cfg, logger: logger}
+}
+
+func (t *AnalyzeImageTool) Name() string          { return "analyze_image" }
+func (t *AnalyzeImageTool) Kind() domaintool.Kind { return domaintool.KindRead }
+
+func (t *AnalyzeImageTool) Description() string {
+	return "Look at an image file in the workspace (PNG, JPEG, GIF or WebP) with a vision model and get back a text description. " +
+		"All visible text (error messages, code, labels) is transcribed. " +
+		"Use it for screenshots of errors, UI design mocks and diagrams; ask a specific question to focus the answer."
+}
+
+func (t *AnalyzeImageTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Image path, relative to the workspace or absolute inside it",
+			},
+			"question": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional: what to look for, e.g. 'What is the error and which file does it point to?'",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+func (t *AnalyzeImageTool) Execute(ctx context.Context, args map[string]interface{}) (*domaintool.Result, error) {
+	path, _ := args["path"].(string)
+	question, _ := args["question"].(string)
+	if path == "" {
+		return &domaintool.Result{Success: false, Error: "path is required"}, nil
+	}
+
+	absPath, err := t.resolve(path)
+	if err != nil {
+		return &domaintool.Result{Success: false, Error: err.Error()}, nil
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return &domaintool.Result{Success: false, Error: err.Error()}, nil
+	}
+	if info.IsDir() {
+		return &domaintool.Result{Success: false, Error: path + " is a directory"}, nil
+	}
+	if info.Size() > int64(t.cfg.MaxBytes) {
+		return &domaintool.Result{
+			Success: false,
+			Error:   fmt.Sprintf("image is %d bytes, limit is %d", info.Size(), t.cfg.MaxBytes),
+		}, nil
+	}
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return &domaintool.Result{Success: false, Error: err.Error()}, nil
+	}
+	mime := http.DetectContentType(data)
+	if !visionMimeTypes[mime] {
+		return &domaintool.Result{
+			Success: false,
+			Error:   fmt.Sprintf("unsupported image type %s (PNG, JPEG, GIF or WebP; use read_file for SVG)", mime),
+		}, nil
+	}
+
+	prompt := "Describe this image."
+	if q := strings.TrimSpace(question); q != "" {
+		prompt = q
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, visionTimeout)
+	defer cancel()
+	resp, err := t.cfg.LLM.Generate(ctx, &service.LLMRequest{
+		Model: t.cfg.Model,
+		Messages: []service.LLMMessage{
+			{Role: "system", Content: visionSystemPrompt},
+			{Role: "user", Parts: []service.ContentPart{
+				{Type: "text", Text: prompt},
+				{Type: "image", MimeType: mime, Data: data},
+			}},
+		},
+		MaxTokens:   t.cfg.MaxTokens,
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return &domaintool.Result{Success: false, Error: fmt.Sprintf("vision model %s: %v", t.cfg.Model, err)}, nil
+	}
+
+	t.logger.Info("Image analyzed",
+		zap.String("path", path),
+		zap.String("model", resp.ModelUsed),
+		zap.Int("bytes", len(data)),
+	)
+	return &domaintool.Result{
+		Output:  strings.TrimSpace(resp.Content),
+		Success: true,
+		Metadata: map[string]interface{}{
+			"path":  path,
+			"mime":  mime,
+			"bytes": len(data),
+			"model": resp.ModelUsed,
+		},
+	}, nil
+}
+
+// resolve returns the absolute path of an image inside the workspace. Paths
+// outside it (including through symlinks) are rejected, since the image is
+// sent to an external model.
+func (t *AnalyzeImageTool) resolve(path string) (string, error) {
+	workDir := ""
+	if t.cfg.WorkDir != nil {
+		workDir = t.cfg.WorkDir()
+	}
+	absPath := resolveReadPath(path, workDir)
+	if workDir == "" {
+		return absPath, nil
+	}
+	root, err := filepath.EvalSymlinks(workDir)
+	if err != nil {
+		return "", fmt.Errorf("workspace: %w", err)
+	}
+	real, err := filepath.EvalSymlinks(absPath)
+	if err != nil {
+		return "", err
+	}
+	if !withinDir(root, real) {
+		return "", fmt.Errorf("%s is outside the workspace", path)
+	}
+	return real, nil
+}
@@ -0,0 +1,83 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"go.uber.org/zap"
+)
+
+// visionTestLLM records the last request.
+type visionTestLLM struct {
+	req *service.LLMRequest
+}
+
+func (l *visionTestLLM) Generate(ctx context.Context, req *service.LLMRequest) (*service.LLMResponse, error) {
+	l.req = req
+	return &service.LLMResponse{Content: " panic: nil map at main.go:42 ", ModelUsed: req.Model}, nil
+}
+
+func (l *visionTestLLM) GenerateStream(ctx context.Context, req *service.LLMRequest, deltaCh chan<- service.StreamChunk) (*service.LLMResponse, error) {
+	close(deltaCh)
+	return l.Generate(ctx, req)
+}
+
+func TestAnalyzeImage(t *testing.T) {
+	workspace := t.TempDir()
+	outside := t.TempDir()
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...)
+	for _, f := range []struct {
+		path string
+		data []byte
+	}{
+		{filepath.Join(workspace, "shots", "error.png"), png},
+		{filepath.Join(workspace, "notes.txt"), []byte("just text")},
+		{filepath.Join(outside, "secret.png"), png},
+	} {
+		if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(f.path, f.data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.png"), filepath.Join(workspace, "link.png")); err != nil {
+		t.Fatal(err)
+	}
+
+	llm := &visionTestLLM{}
+	tool := NewAnalyzeImageTool(VisionConfig{
+		LLM:     llm,
+		Model:   "bailian/qwen-vl-max",
+		WorkDir: func() string { return workspace },
+	}, zap.NewNop())
+
+	res, _ := tool.Execute(context.Background(), map[string]interface{}{"path": "shots/error.png", "question": "What failed?"})
+	if !res.Success || res.Output != "panic: nil map at main.go:42" {
+		t.Fatalf("result = %+v", res)
+	}
+	got := llm.req
+	parts := got.Messages[len(got.Messages)-1].Parts
+	if got.Model != "bailian/qwen-vl-max" || len(parts) != 2 || parts[0].Text != "What failed?" ||
+		parts[1].Type != "image" || parts[1].MimeType != "image/png" || len(parts[1].Data) != len(png) {
+		t.Errorf("request = %+v", got)
+	}
+
+	for path, want := range map[string]string{
+		"notes.txt":                          "unsupported image type",
+		filepath.Join(outside, "secret.png"): "outside the workspace",
+		"link.png":                           "outside the workspace",
+		"../" + filepath.Base(outside):       "outside the workspace",
+		"shots":                              "is a directory",
+	} {
+		llm.req = nil
+		res, _ := tool.Execute(context.Background(), map[string]interface{}{"path": path})
+		if res.Success || !strings.Contains(res.Error, want) || llm.req != nil {
+			t.Errorf("%s: %+v, want error %q", path, res, want)
+		}
+	}
+}
@@ -0,0 +1,598 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/codeintel"
+	"go.uber.org/zap"
+)
+
+const (
+	// workspaceIndexMaxFiles 默认索引文件上限 (超出后新文件不再入索引)
+	workspaceIndexMaxFiles = 20000
+	// workspaceIndexMaxSize 超过该大小的文件不解析符号、不做嵌入
+	workspaceIndexMaxSize = 1 << 20
+	// workspaceIndexDebounce 文件事件合并窗口 (编辑器保存常触发多次写)
+	workspaceIndexDebounce = 300 * time.Millisecond
+
+	indexChunkLines       = 60   // 嵌入分块的行数
+	indexChunkMaxChars    = 4000 // 单块文本上限
+	indexMaxChunksPerFile = 50
+	indexEmbedBatch       = 32
+)
+
+// IndexEmbedder 生成嵌入向量 (memory.EmbeddingProvider 满足此接口)
+type IndexEmbedder interface {
+	EmbedBatch(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// SemanticHit 语义检索命中的代码块
+type SemanticHit struct {
+	File      string
+	StartLine int
+	EndLine   int
+	Score     float64
+	Text      string
+}
+
+// WorkspaceIndexStats 索引状态
+type WorkspaceIndexStats struct {
+	Ready    bool
+	Files    int
+	Symbols  int
+	Chunks   int
+	Embedded int // 已完成嵌入的文件数
+}
+
+type indexedFile struct {
+	modTime  time.Time
+	size     int64
+	mapLines []string // repo_map 格式的符号行; nil 表示非源码文件 (由调用方现场解析)
+	source   bool     // 参与符号与嵌入索引的源码文件
+	chunks   []indexChunk
+	embedded bool
+}
+
+type indexChunk struct {
+	startLine int
+	endLine   int
+	text      string
+	vec       []float32
+}
+
+// WorkspaceIndex 工作区常驻索引.
+//
+// 随网关启动后台全量扫描一次, 之后由 fsnotify 监听文件变更增量失效:
+// 写入/新建的文件在合并窗口后重新解析, 删除/改名的文件移出索引.
+// repo_map 直接读取缓存的符号行, grep_search 的 symbol / semantic 模式
+// 读取符号索引与 (可选的) 嵌入索引, 不再每次遍历磁盘.
+type WorkspaceIndex struct {
+	root     string
+	maxFiles int
+	logger   *zap.Logger
+	symbols  *codeintel.Indexer
+
+	mu       sync.RWMutex
+	files    map[string]*indexedFile
+	ready    bool
+	capped   bool
+	embedder IndexEmbedder
+
+	watcher   *fsnotify.Watcher
+	embedWake chan struct{}
+	ctx       context.Context
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewWorkspaceIndex 创建工作区索引 (调用 Start 后才开始扫描与监听)
+func NewWorkspaceIndex(root string, maxFiles int, logger *zap.Logger) *WorkspaceIndex {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	if maxFiles <= 0 {
+		maxFiles = workspaceIndexMaxFiles
+	}
+	return &WorkspaceIndex{
+		root:      filepath.Clean(root),
+		maxFiles:  maxFiles,
+		logger:    logger.With(zap.String("component", "workspace_index")),
+		symbols:   codeintel.NewIndexer(logger),
+		files:     make(map[string]*indexedFile),
+		embedWake: make(chan struct{}, 1),
+	}
+}
+
+// Root 返回索引的工作区根目录
+func (w *WorkspaceIndex) Root() string { return w.root }
+
+// SetEmbedder 启用嵌入索引; 已索引的文件在后台补做嵌入
+func (w *WorkspaceIndex) SetEmbedder(e IndexEmbedder) {
+	w.mu.Lock()
+	w.embedder = e
+	w.mu.Unlock()
+	w.wakeEmbedder()
+}
+
+// Start 开始后台扫描与监听; 扫描完成前 Covers 返回 false, 工具退回实时遍历
+func (w *WorkspaceIndex) Start() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create watcher: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	w.watcher = watcher
+	w.ctx = ctx
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go w.run(ctx)
+	go w.embedLoop(ctx)
+	return nil
+}
+
+// Close 停止监听
+func (w *WorkspaceIndex) Close() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	w.watcher.Close()
+	<-w.done
+}
+
+func (w *WorkspaceIndex) run(ctx context.Context) {
+	defer close(w.done)
+
+	start := time.Now()
+	w.scan(w.root)
+	w.mu.Lock()
+	w.ready = true
+	w.mu.Unlock()
+	stats := w.Stats()
+	w.logger.Info("Workspace index ready",
+		zap.String("root", w.root),
+		zap.Int("files", stats.Files),
+		zap.Int("symbols", stats.Symbols),
+		zap.Duration("elapsed", time.Since(start)),
+	)
+	w.wakeEmbedder()
+
+	pending := make(map[string]struct{})
+	var flush <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if w.handleEvent(event, pending) && flush == nil {
+				flush = time.After(workspaceIndexDebounce)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				// 丢失了事件, 全量对账一次
+				w.logger.Warn("Workspace watcher overflowed, rescanning")
+				w.rescan()
+				continue
+			}
+			w.logger.Warn("Workspace watcher error", zap.Error(err))
+		case <-flush:
+			flush = nil
+			w.apply(pending)
+			pending = make(map[string]struct{})
+			w.wakeEmbedder()
+		}
+	}
+}
+
+// handleEvent 记录待处理路径; 新建目录立即加入监听并扫描
+func (w *WorkspaceIndex) handleEvent(event fsnotify.Event, pending map[string]struct{}) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	if event.Op.Has(fsnotify.Create) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if !skipRepoMapDir(info.Name()) {
+				w.scan(event.Name)
+			}
+			return false
+		}
+	}
+	pending[event.Name] = struct{}{}
+	return true
+}
+
+// apply 重新索引变更的文件, 移除已不存在的路径
+func (w *WorkspaceIndex) apply(paths map[string]struct{}) {
+	for path := range paths {
+		info, err := os.Stat(path)
+		switch {
+		case err != nil:
+			w.remove(path)
+		case !info.IsDir():
+			w.indexFile(path, info)
+		}
+	}
+}
+
+// scan 监听 dir 下的全部目录并索引其中文件, 返回见到的文件
+func (w *WorkspaceIndex) scan(dir string) map[string]bool {
+	seen := make(map[string]bool)
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if w.ctx.Err() != nil {
+			return filepath.SkipAll
+		}
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if path != w.root && skipRepoMapDir(info.Name()) {
+				return filepath.SkipDir
+			}
+			if err := w.watcher.Add(path); err != nil {
+				w.logger.Warn("Cannot watch directory", zap.String("dir", path), zap.Error(err))
+			}
+			return nil
+		}
+		seen[path] = true
+		w.indexFile(path, info)
+		return nil
+	})
+	return seen
+}
+
+// rescan 全量扫描并清除磁盘上已不存在的条目
+func (w *WorkspaceIndex) rescan() {
+	seen := w.scan(w.root)
+	w.mu.RLock()
+	var gone []string
+	for path := range w.files {
+		if !seen[path] {
+			gone = append(gone, path)
+		}
+	}
+	w.mu.RUnlock()
+	for _, path := range gone {
+		w.remove(path)
+	}
+}
+
+// indexFile 解析单个文件; mtime 与大小未变时跳过
+func (w *WorkspaceIndex) indexFile(path string, info os.FileInfo) *indexedFile {
+	w.mu.RLock()
+	old := w.files[path]
+	full := len(w.files) >= w.maxFiles
+	w.mu.RUnlock()
+	if old != nil && old.modTime.Equal(info.ModTime()) && old.size == info.Size() {
+		return old
+	}
+	if old == nil && full {
+		w.mu.Lock()
+		if !w.capped {
+			w.capped = true
+			w.logger.Warn("Workspace index file limit reached, new files are not indexed",
+				zap.Int("max_files", w.maxFiles))
+		}
+		w.mu.Unlock()
+		return nil
+	}
+
+	entry := &indexedFile{modTime: info.ModTime(), size: info.Size()}
+	if matchLanguage(filepath.Ext(path), "all") && info.Size() <= workspaceIndexMaxSize {
+		entry.source = true
+		entry.mapLines = repoMapSymbols(path)
+		if entry.mapLines == nil {
+			entry.mapLines = []string{}
+		}
+		if fi, err := w.symbols.IndexFile(path); err != nil || fi == nil {
+			w.symbols.RemoveFile(path)
+		}
+	} else if old != nil && old.source {
+		w.symbols.RemoveFile(path)
+	}
+
+	w.mu.Lock()
+	w.files[path] = entry
+	w.mu.Unlock()
+	return entry
+}
+
+// remove 移除文件, 或目录下的全部文件
+func (w *WorkspaceIndex) remove(path string) {
+	prefix := path + string(filepath.Separator)
+	w.mu.Lock()
+	var removed []string
+	for p := range w.files {
+		if p == path || strings.HasPrefix(p, prefix) {
+			delete(w.files, p)
+			removed = append(removed, p)
+		}
+	}
+	w.mu.Unlock()
+	for _, p := range removed {
+		w.symbols.RemoveFile(p)
+	}
+}
+
+// ─── Reads ───
+
+// Covers 索引已就绪且 dir 位于工作区内
+func (w *WorkspaceIndex) Covers(dir string) bool {
+	if w == nil {
+		return false
+	}
+	w.mu.RLock()
+	ready := w.ready
+	w.mu.RUnlock()
+	return ready && underDir(dir, w.root)
+}
+
+// Files 返回 dir 下目录深度小于 maxDepth 的已索引文件 (已排序)
+func (w *WorkspaceIndex) Files(dir string, maxDepth int) []string {
+	dir = filepath.Clean(dir)
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	var out []string
+	for path := range w.files {
+		if !underDir(path, dir) {
+			continue
+		}
+		rel, _ := filepath.Rel(dir, path)
+		if strings.Count(rel, string(filepath.Separator)) >= maxDepth {
+			continue
+		}
+		out = append(out, path)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// MapSymbols 返回 repo_map 格式的符号行; 文件在合并窗口内刚被改动时现场重新解析
+func (w *WorkspaceIndex) MapSymbols(path string) ([]string, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	entry := w.indexFile(path, info)
+	if entry == nil || entry.mapLines == nil {
+		return nil, false
+	}
+	return entry.mapLines, true
+}
+
+// FindSymbols 按名称 (不区分大小写的子串) 查找 dir 下的符号;
+// 完全匹配优先, 其次前缀匹配, 同级按文件与行号排序
+func (w *WorkspaceIndex) FindSymbols(query, dir string, limit int) []codeintel.Symbol {
+	q := strings.ToLower(query)
+	var out []codeintel.Symbol
+	for _, sym := range w.symbols.SearchSymbols(query) {
+		if dir == "" || underDir(sym.File, dir) {
+			out = append(out, sym)
+		}
+	}
+	rank := func(s codeintel.Symbol) int {
+		name := strings.ToLower(s.Name)
+		switch {
+		case name == q:
+			return 0
+		case strings.HasPrefix(name, q):
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		ri, rj := rank(out[i]), rank(out[j])
+		if ri != rj {
+			return ri < rj
+		}
+		if out[i].File != out[j].File {
+			return out[i].File < out[j].File
+		}
+		return out[i].Line < out[j].Line
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// underDir path 是否位于 dir 之内 (含 dir 本身)
+func underDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// SemanticSearch 以 query 的嵌入向量检索 dir 下最相似的代码块
+func (w *WorkspaceIndex) SemanticSearch(ctx context.Context, query, dir string, limit int) ([]SemanticHit, error) {
+	w.mu.RLock()
+	embedder := w.embedder
+	w.mu.RUnlock()
+	if embedder == nil {
+		return nil, errors.New("semantic search is not enabled (agent.tools.index.embeddings)")
+	}
+	vecs, err := embedder.EmbedBatch(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	if len(vecs) != 1 {
+		return nil, errors.New("embed query: empty response")
+	}
+	qv := vecs[0]
+
+	w.mu.RLock()
+	var hits []SemanticHit
+	for path, entry := range w.files {
+		if dir != "" && !underDir(path, dir) {
+			continue
+		}
+		for _, c := range entry.chunks {
+			hits = append(hits, SemanticHit{
+				File:      path,
+				StartLine: c.startLine,
+				EndLine:   c.endLine,
+				Score:     cosine(qv, c.vec),
+				Text:      c.text,
+			})
+		}
+	}
+	w.mu.RUnlock()
+
+	sort.Slice(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// Stats 返回索引规模
+func (w *WorkspaceIndex) Stats() WorkspaceIndexStats {
+	w.mu.RLock()
+	s := WorkspaceIndexStats{Ready: w.ready, Files: len(w.files)}
+	for _, entry := range w.files {
+		s.Chunks += len(entry.chunks)
+		if entry.embedded {
+			s.Embedded++
+		}
+	}
+	w.mu.RUnlock()
+	s.Symbols = len(w.symbols.GetSymbols())
+	return s
+}
+
+// ─── Embeddings ───
+
+func (w *WorkspaceIndex) wakeEmbedder() {
+	select {
+	case w.embedWake <- struct{}{}:
+	default:
+	}
+}
+
+// embedLoop 为尚未嵌入的源码文件分块并生成向量; 出错时等待下一次变更再重试
+func (w *WorkspaceIndex) embedLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.embedWake:
+		}
+
+		w.mu.RLock()
+		embedder := w.embedder
+		var todo []string
+		if embedder != nil {
+			for path, entry := range w.files {
+				if entry.source && !entry.embedded {
+					todo = append(todo, path)
+				}
+			}
+		}
+		w.mu.RUnlock()
+		sort.Strings(todo)
+
+		for _, path := range todo {
+			if ctx.Err() != nil {
+				return
+			}
+			if err := w.embedFile(ctx, embedder, path); err != nil {
+				w.logger.Warn("Workspace embedding failed, will retry on next change",
+					zap.String("file", path), zap.Error(err))
+				break
+			}
+		}
+	}
+}
+
+func (w *WorkspaceIndex) embedFile(ctx context.Context, embedder IndexEmbedder, path string) error {
+	w.mu.RLock()
+	entry := w.files[path]
+	w.mu.RUnlock()
+	if entry == nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		w.remove(path)
+		return nil
+	}
+	chunks := chunkSource(data)
+	rel, _ := filepath.Rel(w.root, path)
+	for start := 0; start < len(chunks); start += indexEmbedBatch {
+		end := min(start+indexEmbedBatch, len(chunks))
+		texts := make([]string, 0, end-start)
+		for _, c := range chunks[start:end] {
+			texts = append(texts, rel+"\n"+c.text)
+		}
+		vecs, err := embedder.EmbedBatch(ctx, texts)
+		if err != nil {
+			return err
+		}
+		if len(vecs) != len(texts) {
+			return fmt.Errorf("embedder returned %d vectors for %d chunks", len(vecs), len(texts))
+		}
+		for i, v := range vecs {
+			chunks[start+i].vec = v
+		}
+	}
+
+	w.mu.Lock()
+	// 嵌入期间文件被改动: 丢弃结果, 由新条目重新排队
+	if w.files[path] == entry {
+		entry.chunks = chunks
+		entry.embedded = true
+	}
+	w.mu.Unlock()
+	return nil
+}
+
+// chunkSource 按固定行数切分, 跳过空白块
+func chunkSource(data []byte) []indexChunk {
+	lines := strings.Split(string(data), "\n")
+	var chunks []indexChunk
+	for start := 0; start < len(lines) && len(chunks) < indexMaxChunksPerFile; start += indexChunkLines {
+		end := min(start+indexChunkLines, len(lines))
+		text := strings.Join(lines[start:end], "\n")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if len(text) > indexChunkMaxChars {
+			text = text[:indexChunkMaxChars]
+		}
+		chunks = append(chunks, indexChunk{startLine: start + 1, endLine: end, text: text})
+	}
+	return chunks
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,157 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// wordEmbedder 按关键词出现次数生成向量, 足以验证检索排序
+type wordEmbedder struct{ words []string }
+
+func (e wordEmbedder) EmbedBatch(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec := make([]float32, len(e.words))
+		for j, w := range e.words {
+			vec[j] = float32(strings.Count(strings.ToLower(text), w))
+		}
+		out[i] = vec
+	}
+	return out, nil
+}
+
+func startIndex(t *testing.T, root string) *WorkspaceIndex {
+	t.Helper()
+	idx := NewWorkspaceIndex(root, 0, zap.NewNop())
+	if err := idx.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(idx.Close)
+	waitFor(t, func() bool { return idx.Covers(root) })
+	return idx
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestWorkspaceIndex_WatchInvalidation(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"main.go":                  "package main\n\nfunc Serve() {}\n",
+		"node_modules/x/skip.js":   "function Skipped() {}\n",
+		"internal/store/store.go":  "package store\n\ntype Store struct{}\n",
+		"internal/store/README.md": "docs\n",
+	})
+	idx := startIndex(t, root)
+
+	if got := idx.FindSymbols("serve", root, 0); len(got) != 1 || got[0].Name != "Serve" {
+		t.Fatalf("FindSymbols(serve) = %+v", got)
+	}
+	if got := idx.FindSymbols("Skipped", root, 0); len(got) != 0 {
+		t.Errorf("node_modules should be excluded: %+v", got)
+	}
+	if lines, ok := idx.MapSymbols(filepath.Join(root, "internal/store/store.go")); !ok || len(lines) == 0 || lines[0] != "type Store struct" {
+		t.Errorf("MapSymbols = %v, %v", lines, ok)
+	}
+	if _, ok := idx.MapSymbols(filepath.Join(root, "internal/store/README.md")); ok {
+		t.Error("non-source files have no cached symbols")
+	}
+
+	// 新目录 + 新文件
+	writeTree(t, root, map[string]string{"pkg/api/handler.go": "package api\n\nfunc HandleLogin() {}\n"})
+	waitFor(t, func() bool { return len(idx.FindSymbols("HandleLogin", root, 0)) == 1 })
+
+	// 修改
+	writeTree(t, root, map[string]string{"main.go": "package main\n\nfunc Run() {}\n"})
+	waitFor(t, func() bool {
+		return len(idx.FindSymbols("Serve", root, 0)) == 0 && len(idx.FindSymbols("Run", root, 0)) == 1
+	})
+
+	// 删除目录
+	if err := os.RemoveAll(filepath.Join(root, "internal")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return len(idx.FindSymbols("Store", root, 0)) == 0 })
+	for _, f := range idx.Files(root, 8) {
+		if strings.Contains(f, "internal") {
+			t.Errorf("removed file still indexed: %s", f)
+		}
+	}
+}
+
+func TestWorkspaceIndex_RepoMapMatchesWalk(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"a.go":              "package a\n\ntype Thing interface {\n\tDo()\n}\n\nfunc New() Thing { return nil }\n",
+		"web/app.ts":        "export function render() {}\nexport class View {}\n",
+		"deep/1/2/3/4/x.py": "def far():\n    pass\n",
+		".hidden/h.go":      "package h\n\nfunc Hidden() {}\n",
+	})
+	args := map[string]interface{}{"path": root}
+
+	walked, err := NewRepoMapTool(zap.NewNop()).Execute(context.Background(), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool := NewRepoMapTool(zap.NewNop())
+	tool.SetWorkspaceIndex(startIndex(t, root))
+	indexed, err := tool.Execute(context.Background(), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if indexed.Metadata["indexed"] != true {
+		t.Fatal("repo_map did not use the index")
+	}
+	if indexed.Output != walked.Output {
+		t.Errorf("indexed output differs:\n--- walk\n%s\n--- index\n%s", walked.Output, indexed.Output)
+	}
+}
+
+func TestSearchTool_IndexModes(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"auth/login.go": "package auth\n\n// password check\nfunc CheckPassword(p string) bool { return p != \"\" }\n",
+		"db/pool.go":    "package db\n\n// connection pool\nfunc OpenPool() {}\n",
+	})
+	idx := startIndex(t, root)
+	idx.SetEmbedder(wordEmbedder{words: []string{"password", "pool", "connection"}})
+	waitFor(t, func() bool { return idx.Stats().Embedded == 2 })
+
+	search := NewSearchTool(nil, zap.NewNop())
+	search.SetWorkspaceIndex(idx)
+
+	res, _ := search.Execute(context.Background(), map[string]interface{}{
+		"pattern": "checkpass", "path": root, "mode": "symbol",
+	})
+	if !res.Success || !strings.HasPrefix(res.Output, "auth/login.go:4: [function]") {
+		t.Errorf("symbol mode = %+v", res)
+	}
+
+	res, _ = search.Execute(context.Background(), map[string]interface{}{
+		"pattern": "database connection pool", "path": root, "mode": "semantic",
+	})
+	if !res.Success || !strings.HasPrefix(res.Output, "db/pool.go:1-") {
+		t.Errorf("semantic mode = %+v", res)
+	}
+
+	res, _ = search.Execute(context.Background(), map[string]interface{}{
+		"pattern": "x", "path": "/elsewhere", "mode": "symbol",
+	})
+	if res.Success {
+		t.Error("paths outside the workspace must not be served from the index")
+	}
+}
@@ -0,0 +1,115 @@
+package transcript
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// runFooter ends every run written by Format: "_N steps · … · trace ID_" + separator.
+var runFooter = regexp.MustCompile(`(?m)^_\d+ steps · .*_\n\n---\n\n`)
+
+// Purge removes the runs for which match returns true, serialized with
+// writes. See PurgeDir.
+func (w *Writer) Purge(match func(source string, startedAt time.Time) bool) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return PurgeDir(w.cfg.Dir, match)
+}
+
+// PurgeDir removes the runs for which match(source, startedAt) is true from
+// the transcript files in dir (retention policies, /forgetme). Files left
+// without runs are deleted. Returns the number of runs removed.
+func PurgeDir(dir string, match func(source string, startedAt time.Time) bool) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	removed := 0
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".md") || len(name) < len(dayLayout) {
+			continue
+		}
+		day, err := time.ParseInLocation(dayLayout, name[:len(dayLayout)], time.Local)
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return removed, err
+		}
+
+		var kept strings.Builder
+		n := 0
+		for _, run := range splitRuns(string(data)) {
+			source, started := runHeader(run, day)
+			if match(source, started) {
+				n++
+				continue
+			}
+			kept.WriteString(run)
+		}
+		if n == 0 {
+			continue
+		}
+		removed += n
+		if strings.TrimSpace(kept.String()) == "" {
+			err = os.Remove(path)
+		} else {
+			err = replaceFile(path, kept.String())
+		}
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// splitRuns cuts a transcript file after each run footer. A trailing
+// partial run (no footer) is returned as the last element.
+func splitRuns(data string) []string {
+	var runs []string
+	start := 0
+	for _, loc := range runFooter.FindAllStringIndex(data, -1) {
+		runs = append(runs, data[start:loc[1]])
+		start = loc[1]
+	}
+	if start < len(data) {
+		runs = append(runs, data[start:])
+	}
+	return runs
+}
+
+// runHeader parses "## 15:04:05 · source · model" into the run's source and
+// start time on day. Runs without a source return "".
+func runHeader(run string, day time.Time) (string, time.Time) {
+	line, _, _ := strings.Cut(run, "\n")
+	fields := strings.Split(strings.TrimPrefix(line, "## "), " · ")
+	started := day
+	if clock, err := time.Parse("15:04:05", fields[0]); err == nil {
+		started = day.Add(time.Duration(clock.Hour())*time.Hour +
+			time.Duration(clock.Minute())*time.Minute +
+			time.Duration(clock.Second())*time.Second)
+	}
+	if len(fields) >= 3 || (len(fields) == 2 && strings.Contains(fields[1], ":")) {
+		return fields[1], started
+	}
+	return "", started
+}
+
+// replaceFile writes data to a temporary file and renames it over path.
+func replaceFile(path, data string) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(data), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
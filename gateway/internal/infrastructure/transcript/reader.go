@@ -0,0 +1,67 @@
+package transcript
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Run is one run section read back from a transcript file.
+type Run struct {
+	File      string    // file name without .md, e.g. "2025-06-01" or "2025-06-01.1"
+	Source    string    // "" for runs written without a source
+	StartedAt time.Time // to the second
+	Text      string    // the Markdown section, footer included
+}
+
+// Ref identifies the run within the transcripts: "<file>/<15:04:05>".
+func (r *Run) Ref() string {
+	return r.File + "/" + r.StartedAt.Format("15:04:05")
+}
+
+// ReadRuns returns the runs of every transcript file in dir, oldest file
+// first. Unreadable files are skipped.
+func ReadRuns(dir string) ([]*Run, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() && strings.HasSuffix(name, ".md") && len(name) >= len(dayLayout) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var runs []*Run
+	for _, name := range names {
+		day, err := time.ParseInLocation(dayLayout, name[:len(dayLayout)], time.Local)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		for _, text := range splitRuns(string(data)) {
+			if !strings.HasPrefix(text, "## ") {
+				continue
+			}
+			source, started := runHeader(text, day)
+			runs = append(runs, &Run{
+				File:      strings.TrimSuffix(name, ".md"),
+				Source:    source,
+				StartedAt: started,
+				Text:      text,
+			})
+		}
+	}
+	return runs, nil
+}
@@ -0,0 +1,252 @@
+// Package transcript writes human-readable agent run transcripts to per-day
+// Markdown files (~/.ngoclaw/transcripts/YYYY-MM-DD.md), separate from the
+// structured zap logs, so "what did the bot do yesterday" is one grep away.
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"go.uber.org/zap"
+)
+
+const (
+	dayLayout       = "2006-01-02"
+	maxArgChars     = 200
+	maxOutputChars  = 300
+	maxMessageChars = 4000
+)
+
+// Config 转录日志配置
+type Config struct {
+	Dir           string // 默认 ~/.ngoclaw/transcripts
+	MaxSizeBytes  int64  // 单个文件上限, 超过后轮转为 YYYY-MM-DD.N.md (默认 10MB)
+	RetentionDays int    // 保留天数, 0 = 永久保留
+}
+
+// Writer appends run transcripts to the current day's file.
+// It implements service.TranscriptSink.
+type Writer struct {
+	cfg    Config
+	logger *zap.Logger
+	now    func() time.Time
+
+	mu         sync.Mutex
+	lastPruned string // day of the last retention sweep
+}
+
+var _ service.TranscriptSink = (*Writer)(nil)
+
+// DefaultDir is where transcripts are written when Config.Dir is empty.
+func DefaultDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".ngoclaw", "transcripts")
+}
+
+// NewWriter creates the transcript directory and returns a writer.
+func NewWriter(cfg Config, logger *zap.Logger) (*Writer, error) {
+	if cfg.Dir == "" {
+		cfg.Dir = DefaultDir()
+	}
+	if cfg.MaxSizeBytes <= 0 {
+		cfg.MaxSizeBytes = 10 << 20
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create transcript dir: %w", err)
+	}
+	return &Writer{
+		cfg:    cfg,
+		logger: logger.With(zap.String("component", "transcript")),
+		now:    time.Now,
+	}, nil
+}
+
+// Dir returns the directory transcripts are written to.
+func (w *Writer) Dir() string { return w.cfg.Dir }
+
+// WriteTranscript appends t to today's file, rotating and pruning as needed.
+func (w *Writer) WriteTranscript(t *service.RunTranscript) {
+	entry := Format(t)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	day := w.now().Format(dayLayout)
+	if day != w.lastPruned {
+		w.prune(day)
+		w.lastPruned = day
+	}
+
+	path := filepath.Join(w.cfg.Dir, day+".md")
+	if info, err := os.Stat(path); err == nil && info.Size()+int64(len(entry)) > w.cfg.MaxSizeBytes {
+		if err := w.rotate(day); err != nil {
+			w.logger.Warn("Transcript rotation failed", zap.Error(err))
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		w.logger.Warn("Open transcript file failed", zap.String("path", path), zap.Error(err))
+		return
+	}
+	defer f.Close()
+	if _, err := f.WriteString(entry); err != nil {
+		w.logger.Warn("Write transcript failed", zap.String("path", path), zap.Error(err))
+	}
+}
+
+// rotate renames DAY.md to the next free DAY.N.md.
+func (w *Writer) rotate(day string) error {
+	for n := 1; ; n++ {
+		target := filepath.Join(w.cfg.Dir, fmt.Sprintf("%s.%d.md", day, n))
+		if _, err := os.Stat(target); os.IsNotExist(err) {
+			return os.Rename(filepath.Join(w.cfg.Dir, day+".md"), target)
+		}
+	}
+}
+
+// Prune deletes transcript files older than RetentionDays and returns how
+// many were removed. Writes prune once a day; the janitor calls Prune so
+// old files also go away while no runs are written.
+func (w *Writer) Prune() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.prune(w.now().Format(dayLayout))
+}
+
+// prune deletes transcript files older than RetentionDays. The caller holds w.mu.
+func (w *Writer) prune(today string) int {
+	if w.cfg.RetentionDays <= 0 {
+		return 0
+	}
+	now, _ := time.Parse(dayLayout, today)
+	cutoff := now.AddDate(0, 0, -w.cfg.RetentionDays)
+
+	entries, err := os.ReadDir(w.cfg.Dir)
+	if err != nil {
+		return 0
+	}
+	n := 0
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".md") || len(name) < len(dayLayout) {
+			continue
+		}
+		day, err := time.Parse(dayLayout, name[:len(dayLayout)])
+		if err != nil || !day.Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(w.cfg.Dir, name)); err == nil {
+			w.logger.Debug("Pruned old transcript", zap.String("file", name))
+			n++
+		}
+	}
+	return n
+}
+
+// Format renders one run as a Markdown section.
+func Format(t *service.RunTranscript) string {
+	var b strings.Builder
+
+	header := t.StartedAt.Format("15:04:05")
+	if t.Source != "" {
+		header += " · " + t.Source
+	}
+	if t.Model != "" {
+		header += " · " + t.Model
+	}
+	fmt.Fprintf(&b, "## %s\n\n", header)
+	fmt.Fprintf(&b, "**User:** %s\n\n", oneBlock(t.UserMessage, maxMessageChars))
+
+	for _, e := range t.Entries {
+		switch e.Kind {
+		case "assistant":
+			if text := strings.TrimSpace(e.Text); text != "" {
+				fmt.Fprintf(&b, "**Assistant:** %s\n\n", oneBlock(text, maxMessageChars))
+			}
+		case "user":
+			fmt.Fprintf(&b, "**User (mid-run):** %s\n\n", oneBlock(e.Text, maxMessageChars))
+		case "tool":
+			status := "ok"
+			if !e.Success {
+				status = "failed"
+			}
+			fmt.Fprintf(&b, "- `%s` %s — %s (%s)\n", e.Tool, formatArgs(e.Args), status, e.Duration.Round(time.Millisecond))
+			if out := firstLine(e.Text); out != "" {
+				fmt.Fprintf(&b, "  > %s\n", truncate(out, maxOutputChars))
+			}
+		}
+	}
+	if len(t.Entries) > 0 && t.Entries[len(t.Entries)-1].Kind == "tool" {
+		b.WriteString("\n")
+	}
+
+	if t.Tests != nil {
+		fmt.Fprintf(&b, "**Tests:** %s\n\n", t.Tests.Summary())
+	}
+	if t.Review != nil {
+		fmt.Fprintf(&b, "**Review:** %s\n\n", t.Review.Summary())
+	}
+	if t.Error != "" {
+		fmt.Fprintf(&b, "**Error:** %s\n\n", oneBlock(t.Error, maxOutputChars))
+	}
+	fmt.Fprintf(&b, "_%d steps · %d tokens · %s · trace %s_\n\n---\n\n",
+		t.Steps, t.Tokens, t.FinishedAt.Sub(t.StartedAt).Round(100*time.Millisecond), t.TraceID)
+	return b.String()
+}
+
+// formatArgs renders tool arguments compactly, keys sorted for stable output.
+func formatArgs(args map[string]interface{}) string {
+	if len(args) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		var v string
+		if s, ok := args[k].(string); ok {
+			v = s
+		} else {
+			raw, _ := json.Marshal(args[k])
+			v = string(raw)
+		}
+		parts = append(parts, k+"="+strings.ReplaceAll(v, "\n", "⏎"))
+	}
+	return truncate(strings.Join(parts, " "), maxArgChars)
+}
+
+// oneBlock keeps multi-line text readable inside a bullet-free paragraph.
+func oneBlock(s string, n int) string {
+	s = truncate(strings.TrimSpace(s), n)
+	if strings.Contains(s, "\n") {
+		return "\n\n" + s
+	}
+	return s
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + " …"
+	}
+	return s
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
@@ -0,0 +1,143 @@
+package transcript
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"go.uber.org/zap"
+)
+
+func sampleTranscript() *service.RunTranscript {
+	start := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	return &service.RunTranscript{
+		TraceID:     "abc123",
+		Source:      "telegram:42",
+		Model:       "p/model",
+		UserMessage: "list the repo",
+		Entries: []service.TranscriptEntry{
+			{Kind: "assistant", Text: "Let me look."},
+			{Kind: "tool", Tool: "bash", Args: map[string]interface{}{"command": "ls\n-la"}, Text: "go.mod\nmain.go", Success: true, Duration: 12 * time.Millisecond},
+			{Kind: "assistant", Text: "Two files."},
+		},
+		Final:      "Two files.",
+		Steps:      2,
+		Tokens:     321,
+		StartedAt:  start,
+		FinishedAt: start.Add(1500 * time.Millisecond),
+	}
+}
+
+func TestFormat(t *testing.T) {
+	out := Format(sampleTranscript())
+	for _, want := range []string{
+		"## 09:30:00 · telegram:42 · p/model",
+		"**User:** list the repo",
+		"**Assistant:** Let me look.",
+		"- `bash` command=ls⏎-la — ok (12ms)",
+		"  > go.mod …",
+		"**Assistant:** Two files.",
+		"_2 steps · 321 tokens · 1.5s · trace abc123_",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriter_RotatesAndPrunes(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "2026-01-01.md")
+	os.WriteFile(old, []byte("old"), 0o600)
+	keep := filepath.Join(dir, "2026-02-28.md")
+	os.WriteFile(keep, []byte("recent"), 0o600)
+
+	// room for one run per file
+	limit := int64(len(Format(sampleTranscript()))) + 1
+	w, err := NewWriter(Config{Dir: dir, MaxSizeBytes: limit, RetentionDays: 7}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.now = func() time.Time { return time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC) }
+
+	for i := 0; i < 3; i++ {
+		w.WriteTranscript(sampleTranscript())
+	}
+
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("transcript older than retention was not pruned")
+	}
+	if _, err := os.Stat(keep); err != nil {
+		t.Error("recent transcript was pruned")
+	}
+	for _, name := range []string{"2026-03-02.md", "2026-03-02.1.md", "2026-03-02.2.md"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("expected %s after rotation: %v", name, err)
+		}
+		if strings.Count(string(data), "**User:**") != 1 {
+			t.Errorf("%s should hold exactly one run", name)
+		}
+	}
+}
+
+func TestWriter_PurgeBySource(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWriter(Config{Dir: dir}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.now = func() time.Time { return time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC) }
+
+	other := sampleTranscript()
+	other.Source = "telegram:7"
+	other.UserMessage = "keep me\n\n---\n\nstill mine"
+	w.WriteTranscript(sampleTranscript())
+	w.WriteTranscript(other)
+	w.WriteTranscript(sampleTranscript())
+
+	var seen []time.Time
+	n, err := w.Purge(func(source string, started time.Time) bool {
+		seen = append(seen, started)
+		return source == "telegram:42"
+	})
+	if err != nil || n != 2 {
+		t.Fatalf("Purge = %d, %v; want 2 runs", n, err)
+	}
+	if want := time.Date(2026, 3, 2, 9, 30, 0, 0, time.Local); len(seen) != 3 || !seen[0].Equal(want) {
+		t.Errorf("start times = %v, want %v", seen, want)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "2026-03-02.md"))
+	if string(data) != Format(other) {
+		t.Errorf("remaining transcript:\n%s", data)
+	}
+
+	if n, _ := w.Purge(func(string, time.Time) bool { return true }); n != 1 {
+		t.Errorf("second Purge = %d", n)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2026-03-02.md")); !os.IsNotExist(err) {
+		t.Error("empty transcript file should be removed")
+	}
+}
+
+func TestWriter_PruneWithoutWrites(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "2026-01-01.md"), []byte("old"), 0o600)
+	os.WriteFile(filepath.Join(dir, "2026-01-01.1.md"), []byte("old"), 0o600)
+	os.WriteFile(filepath.Join(dir, "2026-03-01.md"), []byte("recent"), 0o600)
+	w, err := NewWriter(Config{Dir: dir, RetentionDays: 7}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.now = func() time.Time { return time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC) }
+
+	if n := w.Prune(); n != 2 {
+		t.Errorf("pruned %d, want 2", n)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2026-03-01.md")); err != nil {
+		t.Error("recent transcript was pruned")
+	}
+}
@@ -0,0 +1,80 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+)
+
+// Edge synthesizes speech with Microsoft Edge's online voices through the
+// edge-tts command line, which writes MP3; ffmpeg converts it to OGG/Opus.
+type Edge struct {
+	command string
+	ffmpeg  string
+	voice   string
+	rate    string
+}
+
+// NewEdge creates the provider, or fails when edge-tts or ffmpeg is not
+// installed.
+func NewEdge(cfg config.TTSEdgeConfig) (*Edge, error) {
+	if cfg.Command == "" {
+		cfg.Command = "edge-tts"
+	}
+	if cfg.Voice == "" {
+		cfg.Voice = "zh-CN-XiaoxiaoNeural"
+	}
+	command, err := exec.LookPath(cfg.Command)
+	if err != nil {
+		return nil, fmt.Errorf("%s not found (pip install edge-tts): %w", cfg.Command, err)
+	}
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg not found: %w", err)
+	}
+	return &Edge{command: command, ffmpeg: ffmpeg, voice: cfg.Voice, rate: cfg.Rate}, nil
+}
+
+// Synthesize implements Provider.
+func (p *Edge) Synthesize(ctx context.Context, text string) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "ngoclaw-tts-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	// The text goes through a file: replies can be long, and one starting
+	// with "-" would otherwise read as a flag
+	textFile := filepath.Join(dir, "reply.txt")
+	if err := os.WriteFile(textFile, []byte(text), 0o600); err != nil {
+		return nil, err
+	}
+	media := filepath.Join(dir, "reply.mp3")
+	args := []string{"--voice", p.voice, "--file", textFile, "--write-media", media}
+	if p.rate != "" {
+		args = append(args, "--rate="+p.rate)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.command, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("edge-tts: %w (%s)", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	mp3, err := os.ReadFile(media)
+	if err != nil {
+		return nil, fmt.Errorf("edge-tts: %w", err)
+	}
+	audio, err := toOggOpus(ctx, p.ffmpeg, mp3)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOgg(audio); err != nil {
+		return nil, fmt.Errorf("edge-tts: %w", err)
+	}
+	return audio, nil
+}
@@ -0,0 +1,86 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/llm"
+)
+
+// maxAudioBytes bounds the audio read from a provider; Telegram accepts
+// voice messages up to 50 MB, a 4096-character reply is far below.
+const maxAudioBytes = 20 << 20
+
+// OpenAI synthesizes speech with the OpenAI /audio/speech API, or a
+// compatible server at base_url.
+type OpenAI struct {
+	cfg    config.TTSOpenAIConfig
+	client *http.Client
+}
+
+// NewOpenAI creates the provider; empty fields take the API defaults. The
+// proxy, CA bundle and TLS settings work as for the LLM providers.
+func NewOpenAI(cfg config.TTSOpenAIConfig) (*OpenAI, error) {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://api.openai.com/v1"
+	}
+	if cfg.Model == "" {
+		cfg.Model = "gpt-4o-mini-tts"
+	}
+	if cfg.Voice == "" {
+		cfg.Voice = "alloy"
+	}
+	transport, err := llm.NewTransport(llm.NetworkConfig{
+		Proxy:              cfg.Proxy,
+		CAFile:             cfg.CAFile,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("openai tts: %w", err)
+	}
+	return &OpenAI{cfg: cfg, client: &http.Client{Transport: transport}}, nil
+}
+
+// Synthesize implements Provider. The API returns OGG/Opus for
+// response_format "opus", so no conversion is needed.
+func (p *OpenAI) Synthesize(ctx context.Context, text string) ([]byte, error) {
+	body := map[string]interface{}{
+		"model":           p.cfg.Model,
+		"input":           text,
+		"voice":           p.cfg.Voice,
+		"response_format": "opus",
+	}
+	if p.cfg.Instructions != "" {
+		body["instructions"] = p.cfg.Instructions
+	}
+	data, _ := json.Marshal(body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(p.cfg.BaseURL, "/")+"/audio/speech", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.cfg.APIKey)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("openai tts: %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	audio, err := io.ReadAll(io.LimitReader(resp.Body, maxAudioBytes))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOgg(audio); err != nil {
+		return nil, fmt.Errorf("openai tts: %w", err)
+	}
+	return audio, nil
+}
@@ -0,0 +1,99 @@
+// Package tts synthesizes voice replies (tts in config.yaml). Every
+// provider returns OGG/Opus, the format Telegram plays as a voice message:
+//
+//   - openai: the /audio/speech API of OpenAI or a compatible server,
+//     which returns OGG/Opus directly.
+//   - edge: Microsoft Edge's online voices through the edge-tts command
+//     line (pip install edge-tts), converted with ffmpeg.
+package tts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+
+	"go.uber.org/zap"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+)
+
+// Provider names.
+const (
+	ProviderOpenAI = "openai"
+	ProviderEdge   = "edge"
+)
+
+// Known lists the providers this package implements.
+var Known = []string{ProviderOpenAI, ProviderEdge}
+
+// Provider turns text into speech.
+type Provider interface {
+	// Synthesize returns text spoken as OGG/Opus audio.
+	Synthesize(ctx context.Context, text string) ([]byte, error)
+}
+
+// Providers returns the providers cfg configures, by name: openai when it
+// has an API key, edge when edge-tts and ffmpeg are installed.
+func Providers(cfg config.TTSConfig, logger *zap.Logger) map[string]Provider {
+	providers := make(map[string]Provider)
+	if cfg.OpenAI.APIKey != "" {
+		if openai, err := NewOpenAI(cfg.OpenAI); err == nil {
+			providers[ProviderOpenAI] = openai
+		} else {
+			logger.Warn("OpenAI TTS unavailable", zap.Error(err))
+		}
+	}
+	if edge, err := NewEdge(cfg.Edge); err == nil {
+		providers[ProviderEdge] = edge
+	} else if cfg.Provider == ProviderEdge {
+		logger.Warn("Edge TTS unavailable", zap.Error(err))
+	}
+	return providers
+}
+
+// Names returns the names of providers, sorted.
+func Names(providers map[string]Provider) []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// oggMagic starts every OGG stream.
+var oggMagic = []byte("OggS")
+
+// checkOgg rejects audio that is not an OGG stream, e.g. an error page
+// or an MP3 from a server that ignored the requested format.
+func checkOgg(audio []byte) error {
+	if !bytes.HasPrefix(audio, oggMagic) {
+		return fmt.Errorf("provider returned %d bytes that are not OGG audio", len(audio))
+	}
+	return nil
+}
+
+// toOggOpus converts audio in any format ffmpeg reads to OGG/Opus, with the
+// low bitrate and voice tuning Telegram's own voice messages use.
+func toOggOpus(ctx context.Context, ffmpeg string, audio []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, ffmpeg,
+		"-loglevel", "error",
+		"-i", "pipe:0",
+		"-ac", "1",
+		"-c:a", "libopus",
+		"-b:a", "32k",
+		"-application", "voip",
+		"-f", "ogg",
+		"pipe:1",
+	)
+	cmd.Stdin = bytes.NewReader(audio)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg: %w (%s)", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.Bytes(), nil
+}
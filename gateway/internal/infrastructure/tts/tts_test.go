@@ -0,0 +1,104 @@
+package tts
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+)
+
+func TestOpenAISynthesize(t *testing.T) {
+	var got map[string]interface{}
+	var auth string
+	reply := "OggS-audio"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/speech" {
+			http.NotFound(w, r)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &got)
+		io.WriteString(w, reply)
+	}))
+	defer srv.Close()
+
+	p, err := NewOpenAI(config.TTSOpenAIConfig{APIKey: "sk-test", BaseURL: srv.URL + "/v1/", Voice: "nova", Proxy: "direct"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	audio, err := p.Synthesize(context.Background(), "你好")
+	if err != nil || string(audio) != "OggS-audio" {
+		t.Fatalf("Synthesize = %q, %v", audio, err)
+	}
+	if auth != "Bearer sk-test" || got["input"] != "你好" || got["voice"] != "nova" ||
+		got["model"] != "gpt-4o-mini-tts" || got["response_format"] != "opus" {
+		t.Errorf("request = %v, auth %q", got, auth)
+	}
+
+	reply = "ID3-mp3"
+	if _, err := p.Synthesize(context.Background(), "hi"); err == nil || !strings.Contains(err.Error(), "not OGG") {
+		t.Errorf("non-OGG reply: %v", err)
+	}
+
+	// The LLM providers' network settings apply, bad ones included
+	if _, err := NewOpenAI(config.TTSOpenAIConfig{APIKey: "k", Proxy: "ftp://proxy"}); err == nil || !strings.Contains(err.Error(), "invalid proxy") {
+		t.Errorf("bad proxy: %v", err)
+	}
+	if _, err := NewOpenAI(config.TTSOpenAIConfig{APIKey: "k", CAFile: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Error("missing ca_file accepted")
+	}
+}
+
+// fakeCommand installs an executable shell script named name on PATH.
+func fakeCommand(t *testing.T, dir, name, script string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEdgeSynthesize(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	if _, err := NewEdge(config.TTSEdgeConfig{Command: "edge-tts-missing"}); err == nil {
+		t.Fatal("edge should be unavailable without edge-tts")
+	}
+
+	// edge-tts writes the text it read as the "MP3"; ffmpeg prefixes OggS
+	fakeCommand(t, bin, "edge-tts", `
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --voice) voice=$2; shift ;;
+    --file) file=$2; shift ;;
+    --write-media) out=$2; shift ;;
+  esac
+  shift
+done
+{ printf '%s:' "$voice"; cat "$file"; } > "$out"
+`)
+	fakeCommand(t, bin, "ffmpeg", `printf OggS; cat`)
+
+	p, err := NewEdge(config.TTSEdgeConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	audio, err := p.Synthesize(context.Background(), "-n 你好")
+	if err != nil || string(audio) != "OggSzh-CN-XiaoxiaoNeural:-n 你好" {
+		t.Errorf("Synthesize = %q, %v", audio, err)
+	}
+
+	providers := Providers(config.TTSConfig{OpenAI: config.TTSOpenAIConfig{APIKey: "k"}}, zap.NewNop())
+	if names := strings.Join(Names(providers), ","); names != "edge,openai" {
+		t.Errorf("providers = %s", names)
+	}
+}
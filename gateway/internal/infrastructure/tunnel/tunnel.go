@@ -0,0 +1,336 @@
+// Package tunnel exposes the local gateway on a public HTTPS URL through an
+// SSH reverse tunnel, so webhook integrations (Telegram, GitHub) can be tried
+// on a development machine without a public server. Hosted providers
+// (localhost.run, pinggy) print the URL they assign; a self-hosted SSH server
+// needs its URL configured.
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// DefaultProvider is used when Config.Provider is empty.
+const DefaultProvider = "localhost.run"
+
+const defaultTimeout = 30 * time.Second
+
+// Config selects the tunnel provider and the local address to expose.
+type Config struct {
+	Provider   string // localhost.run, pinggy or ssh
+	Server     string // host:port; overrides the provider's server
+	User       string // overrides the provider's user (pinggy: access token)
+	KeyFile    string // private key (~ expanded); empty = ssh-agent, else no key
+	RemotePort int    // provider ssh: port to listen on at the server
+	PublicURL  string // provider ssh: the URL that reaches RemotePort
+	KnownHosts string // known_hosts file (~ expanded); unknown hosts are pinned, a changed key is refused
+	Local      string // address to forward to, e.g. 127.0.0.1:18790
+	Timeout    time.Duration
+}
+
+type provider struct {
+	server string
+	user   string
+	bind   string         // remote listen address
+	url    *regexp.Regexp // public URL in the server's banner
+}
+
+var providers = map[string]provider{
+	"localhost.run": {
+		server: "localhost.run:22",
+		user:   "nokey",
+		bind:   "localhost:80",
+		url:    regexp.MustCompile(`https://[a-z0-9-]+\.lhr\.life`),
+	},
+	"pinggy": {
+		server: "a.pinggy.io:443",
+		user:   "tunnel",
+		bind:   "localhost:0",
+		url:    regexp.MustCompile(`https://[a-z0-9-]+(?:\.[a-z0-9-]+)*\.pinggy\.(?:link|online)`),
+	},
+}
+
+// resolve merges cfg into its provider's settings.
+func resolve(cfg Config) (provider, error) {
+	name := cfg.Provider
+	if name == "" {
+		name = DefaultProvider
+	}
+	var p provider
+	if name == "ssh" {
+		if cfg.Server == "" || cfg.RemotePort <= 0 || cfg.PublicURL == "" {
+			return p, errors.New("tunnel: provider ssh needs server, remote_port and public_url")
+		}
+		p.bind = fmt.Sprintf("localhost:%d", cfg.RemotePort)
+		p.user = os.Getenv("USER")
+	} else {
+		var ok bool
+		if p, ok = providers[name]; !ok {
+			return p, fmt.Errorf("tunnel: unknown provider %q (localhost.run, pinggy, ssh)", name)
+		}
+	}
+	if cfg.Server != "" {
+		p.server = cfg.Server
+	}
+	if cfg.User != "" {
+		p.user = cfg.User
+	}
+	if p.user == "" {
+		return p, errors.New("tunnel: user is required")
+	}
+	return p, nil
+}
+
+// Tunnel is an open reverse tunnel. Call Serve to start forwarding.
+type Tunnel struct {
+	URL string // public base URL, no trailing slash
+
+	local   string
+	client  *ssh.Client
+	ln      net.Listener
+	session *ssh.Session // keeps the provider's banner session open
+	once    sync.Once
+}
+
+// Open connects to the provider, requests the remote listener and waits for
+// the public URL.
+func Open(ctx context.Context, cfg Config) (*Tunnel, error) {
+	p, err := resolve(cfg)
+	if err != nil {
+		return nil, err
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	auth, err := authMethods(expandHome(cfg.KeyFile))
+	if err != nil {
+		return nil, err
+	}
+	hostKey, err := hostKeyCallback(expandHome(cfg.KnownHosts))
+	if err != nil {
+		return nil, err
+	}
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", p.server)
+	if err != nil {
+		return nil, fmt.Errorf("tunnel: dial %s: %w", p.server, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, p.server, &ssh.ClientConfig{
+		User:            p.user,
+		Auth:            auth,
+		HostKeyCallback: hostKey,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("tunnel: ssh %s@%s: %w", p.user, p.server, err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	t := &Tunnel{local: cfg.Local, client: ssh.NewClient(c, chans, reqs)}
+	if t.ln, err = t.client.Listen("tcp", p.bind); err != nil {
+		t.Close()
+		return nil, fmt.Errorf("tunnel: remote listen %s: %w", p.bind, err)
+	}
+	if cfg.PublicURL != "" {
+		t.URL = strings.TrimRight(cfg.PublicURL, "/")
+		return t, nil
+	}
+	if t.URL, err = t.awaitURL(ctx, p.url); err != nil {
+		t.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// awaitURL opens a shell session and reads the banner until the provider
+// announces the public URL. The session stays open: some providers drop the
+// tunnel when it closes.
+func (t *Tunnel) awaitURL(ctx context.Context, pattern *regexp.Regexp) (string, error) {
+	s, err := t.client.NewSession()
+	if err != nil {
+		return "", fmt.Errorf("tunnel: session: %w", err)
+	}
+	t.session = s
+	out, err := s.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := s.RequestPty("xterm", 40, 200, ssh.TerminalModes{}); err != nil {
+		return "", fmt.Errorf("tunnel: pty: %w", err)
+	}
+	if err := s.Shell(); err != nil {
+		return "", fmt.Errorf("tunnel: shell: %w", err)
+	}
+
+	found := make(chan string, 1)
+	go func() {
+		sc := bufio.NewScanner(out)
+		for sc.Scan() {
+			if u := findURL(pattern, sc.Text()); u != "" {
+				found <- u
+				break
+			}
+		}
+		_, _ = io.Copy(io.Discard, out)
+		close(found)
+	}()
+	select {
+	case u, ok := <-found:
+		if !ok {
+			return "", errors.New("tunnel: provider closed the session without a public URL")
+		}
+		return u, nil
+	case <-ctx.Done():
+		return "", fmt.Errorf("tunnel: no public URL from provider: %w", ctx.Err())
+	}
+}
+
+// findURL returns the first match of pattern in a banner line.
+func findURL(pattern *regexp.Regexp, line string) string {
+	return pattern.FindString(line)
+}
+
+// Serve forwards tunnel connections to the local address until the tunnel
+// closes.
+func (t *Tunnel) Serve() error {
+	for {
+		remote, err := t.ln.Accept()
+		if err != nil {
+			return fmt.Errorf("tunnel closed: %w", err)
+		}
+		go t.forward(remote)
+	}
+}
+
+func (t *Tunnel) forward(remote net.Conn) {
+	defer remote.Close()
+	local, err := net.DialTimeout("tcp", t.local, 5*time.Second)
+	if err != nil {
+		return
+	}
+	defer local.Close()
+	done := make(chan struct{}, 2)
+	go func() { _, _ = io.Copy(local, remote); done <- struct{}{} }()
+	go func() { _, _ = io.Copy(remote, local); done <- struct{}{} }()
+	<-done
+}
+
+// Close tears the tunnel down.
+func (t *Tunnel) Close() error {
+	var err error
+	t.once.Do(func() {
+		if t.session != nil {
+			t.session.Close()
+		}
+		if t.ln != nil {
+			t.ln.Close()
+		}
+		err = t.client.Close()
+	})
+	return err
+}
+
+// authMethods uses the key file, else ssh-agent. With neither, only the
+// "none" method is tried, which is what the hosted providers accept.
+func authMethods(keyFile string) ([]ssh.AuthMethod, error) {
+	if keyFile != "" {
+		key, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("tunnel: read key: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			return nil, fmt.Errorf("tunnel: key %s is passphrase-protected; add it to ssh-agent and leave key_file empty", keyFile)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tunnel: parse key: %w", err)
+		}
+		return []ssh.AuthMethod{ssh.PublicKeys(signer)}, nil
+	}
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			return []ssh.AuthMethod{ssh.PublicKeysCallback(agent.NewClient(conn).Signers)}, nil
+		}
+	}
+	return nil, nil
+}
+
+// defaultKnownHosts is used when Config.KnownHosts is empty.
+const defaultKnownHosts = "~/.ssh/known_hosts"
+
+// hostKeyCallback checks the server against knownHosts and refuses a changed
+// key. A host that is not listed yet is trusted on first use: its key is
+// appended to the file, so later connections must present the same key. The
+// hosted providers are rarely in known_hosts beforehand, hence no hard fail.
+func hostKeyCallback(knownHosts string) (ssh.HostKeyCallback, error) {
+	if knownHosts == "" {
+		knownHosts = expandHome(defaultKnownHosts)
+	}
+	if err := os.MkdirAll(filepath.Dir(knownHosts), 0o700); err != nil {
+		return nil, fmt.Errorf("tunnel: known_hosts: %w", err)
+	}
+	f, err := os.OpenFile(knownHosts, os.O_RDONLY|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("tunnel: known_hosts: %w", err)
+	}
+	f.Close()
+	known, err := knownhosts.New(knownHosts)
+	if err != nil {
+		return nil, fmt.Errorf("tunnel: load known_hosts %s: %w", knownHosts, err)
+	}
+	return func(host string, remote net.Addr, key ssh.PublicKey) error {
+		err := known(host, remote, key)
+		var keyErr *knownhosts.KeyError
+		switch {
+		case !errors.As(err, &keyErr):
+			return err
+		case len(keyErr.Want) == 0:
+			return pinHostKey(knownHosts, host, key)
+		default:
+			return fmt.Errorf("%w (if the server really changed its key, remove it with `ssh-keygen -f %s -R %s`)", err, knownHosts, knownhosts.Normalize(host))
+		}
+	}, nil
+}
+
+// pinHostKey records key for host in knownHosts.
+func pinHostKey(knownHosts, host string, key ssh.PublicKey) error {
+	f, err := os.OpenFile(knownHosts, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("tunnel: pin host key: %w", err)
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintln(f, knownhosts.Line([]string{knownhosts.Normalize(host)}, key)); err != nil {
+		return fmt.Errorf("tunnel: pin host key: %w", err)
+	}
+	return nil
+}
+
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
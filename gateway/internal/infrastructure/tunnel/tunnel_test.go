@@ -0,0 +1,97 @@
+package tunnel
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestResolve(t *testing.T) {
+	p, err := resolve(Config{})
+	if err != nil || p.server != "localhost.run:22" || p.user != "nokey" {
+		t.Errorf("default provider = %+v, %v", p, err)
+	}
+	p, err = resolve(Config{Provider: "pinggy", User: "tok123"})
+	if err != nil || p.server != "a.pinggy.io:443" || p.user != "tok123" || p.bind != "localhost:0" {
+		t.Errorf("pinggy with token = %+v, %v", p, err)
+	}
+	p, err = resolve(Config{Provider: "ssh", Server: "dev.example.com:22", User: "ana", RemotePort: 9000, PublicURL: "https://hooks.example.com"})
+	if err != nil || p.bind != "localhost:9000" || p.url != nil {
+		t.Errorf("ssh = %+v, %v", p, err)
+	}
+	if _, err := resolve(Config{Provider: "ssh", Server: "dev.example.com:22", User: "ana"}); err == nil {
+		t.Error("ssh without remote_port/public_url accepted")
+	}
+	if _, err := resolve(Config{Provider: "ngrok"}); err == nil || !strings.Contains(err.Error(), "unknown provider") {
+		t.Errorf("unknown provider err = %v", err)
+	}
+}
+
+func TestFindURL(t *testing.T) {
+	for _, tc := range []struct {
+		provider, banner, want string
+	}{
+		{"localhost.run", "\x1b[1m** your connection id is 1f2e, please mention it if you send me a message about an issue. **\x1b[0m", ""},
+		{"localhost.run", "To set up and manage custom domains go to https://admin.localhost.run/", ""},
+		{"localhost.run", "3c5a9e0b7d21f4.lhr.life tunneled with tls termination, https://3c5a9e0b7d21f4.lhr.life\r", "https://3c5a9e0b7d21f4.lhr.life"},
+		{"pinggy", "You are not authenticated. Your tunnel will expire in 60 minutes. Upgrade at https://dashboard.pinggy.io", ""},
+		{"pinggy", "  https://rnxyz-203-0-113-5.a.free.pinggy.link  ", "https://rnxyz-203-0-113-5.a.free.pinggy.link"},
+	} {
+		if got := findURL(providers[tc.provider].url, tc.banner); got != tc.want {
+			t.Errorf("%s %q = %q, want %q", tc.provider, tc.banner, got, tc.want)
+		}
+	}
+}
+
+func TestHostKeyCallback_TrustOnFirstUse(t *testing.T) {
+	newKey := func() ssh.PublicKey {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signer, err := ssh.NewSignerFromKey(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signer.PublicKey()
+	}
+	file := filepath.Join(t.TempDir(), "ssh", "known_hosts")
+	addr := &net.TCPAddr{IP: net.IPv4(203, 0, 113, 7), Port: 22}
+	key, other := newKey(), newKey()
+
+	cb, err := hostKeyCallback(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cb("localhost.run:22", addr, key); err != nil {
+		t.Fatalf("first connection: %v", err)
+	}
+	if data, _ := os.ReadFile(file); !strings.HasPrefix(string(data), "localhost.run ") {
+		t.Errorf("known_hosts = %q", data)
+	}
+
+	// A fresh callback reads the pinned key back
+	cb, err = hostKeyCallback(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cb("localhost.run:22", addr, key); err != nil {
+		t.Errorf("same key: %v", err)
+	}
+	if err := cb("localhost.run:22", addr, other); err == nil || !strings.Contains(err.Error(), "ssh-keygen") {
+		t.Errorf("changed key: %v", err)
+	}
+
+	if err := os.WriteFile(file, []byte("not a known_hosts line\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hostKeyCallback(file); err == nil {
+		t.Error("corrupt known_hosts accepted")
+	}
+}
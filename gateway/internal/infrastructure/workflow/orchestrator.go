@@ -0,0 +1,322 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"go.uber.org/zap"
+)
+
+// maxForwardChars 传给后续 stage 的单个输出上限 (字符), 超出部分截断
+const maxForwardChars = 8000
+
+var (
+	stageRefRe = regexp.MustCompile(`\{\{stages\.([A-Za-z0-9_-]+)\}\}`)
+	verdictRe  = regexp.MustCompile(`(?im)^\W*VERDICT:\s*(PASS|FAIL)\b`)
+)
+
+// verdictInstruction gate.verdict 时追加到 stage 提示词
+const verdictInstruction = "\n\nEnd your answer with a line `VERDICT: PASS` if the check succeeded or `VERDICT: FAIL` if it did not."
+
+// Stage 状态
+const (
+	StagePassed  = "passed"
+	StageFailed  = "failed"  // 运行完成但门槛未通过
+	StageError   = "error"   // 运行出错或超时
+	StageSkipped = "skipped" // 之前的 stage 失败
+)
+
+// Agent 运行一次 agent loop; *service.AgentLoop 满足该接口
+type Agent interface {
+	Run(ctx context.Context, systemPrompt, userMessage string, history []service.LLMMessage, modelOverride string) (*service.AgentResult, <-chan entity.AgentEvent)
+}
+
+// StageReport 一个 stage 的运行结果
+type StageReport struct {
+	Name     string
+	Model    string
+	Status   string
+	Detail   string // 门槛未通过或出错的原因
+	Output   string
+	Steps    int
+	Tokens   int
+	Duration time.Duration
+}
+
+// Report 一次流水线运行的汇总
+type Report struct {
+	Workflow  string
+	Input     string
+	Stages    []StageReport
+	StartedAt time.Time
+	Duration  time.Duration
+}
+
+// Passed 所有 stage 都通过
+func (r *Report) Passed() bool {
+	for _, s := range r.Stages {
+		if s.Status != StagePassed {
+			return false
+		}
+	}
+	return true
+}
+
+// Output 最后一个运行过的 stage 的输出
+func (r *Report) Output() string {
+	for i := len(r.Stages) - 1; i >= 0; i-- {
+		if r.Stages[i].Status != StageSkipped && r.Stages[i].Status != StageError {
+			return r.Stages[i].Output
+		}
+	}
+	return ""
+}
+
+// Summary 逐 stage 的纯文本汇总, 如
+//
+//	✅ release-check passed · 3/3 stages · 2m31s · 45210 tokens
+//	✅ 1. test — 1m2s · 12 steps
+//	❌ 2. changelog — output does not contain "## 1.4"
+//	⏭ 3. tag — skipped
+func (r *Report) Summary() string {
+	var sb strings.Builder
+	passed, tokens := 0, 0
+	for _, s := range r.Stages {
+		if s.Status == StagePassed {
+			passed++
+		}
+		tokens += s.Tokens
+	}
+	verdict := "✅ %s passed"
+	if !r.Passed() {
+		verdict = "❌ %s failed"
+	}
+	fmt.Fprintf(&sb, verdict+" · %d/%d stages · %s · %d tokens", r.Workflow, passed, len(r.Stages), r.Duration.Round(time.Second), tokens)
+	for i, s := range r.Stages {
+		fmt.Fprintf(&sb, "\n%s %d. %s — ", stageIcon(s.Status), i+1, s.Name)
+		switch s.Status {
+		case StageSkipped:
+			sb.WriteString("skipped")
+		case StagePassed:
+			fmt.Fprintf(&sb, "%s · %d steps", s.Duration.Round(time.Second), s.Steps)
+		default:
+			sb.WriteString(s.Detail)
+		}
+	}
+	return sb.String()
+}
+
+func stageIcon(status string) string {
+	switch status {
+	case StagePassed:
+		return "✅"
+	case StageSkipped:
+		return "⏭"
+	case StageError:
+		return "⚠️"
+	default:
+		return "❌"
+	}
+}
+
+// Orchestrator 逐个 stage 运行流水线, 每个 stage 是一次独立的 agent 运行
+// (不带对话历史), 只通过提示词拿到之前 stage 的输出
+type Orchestrator struct {
+	agent  Agent
+	prompt func(ctx context.Context, st *Stage, model string) string
+	skill  func(id string) (string, error)
+	logger *zap.Logger
+}
+
+// NewOrchestrator 创建编排器
+func NewOrchestrator(agent Agent, logger *zap.Logger) *Orchestrator {
+	return &Orchestrator{agent: agent, logger: logger}
+}
+
+// SetPromptBuilder 设置 stage 的基础系统提示词 (stage.system 与技能说明追加在后面)
+func (o *Orchestrator) SetPromptBuilder(fn func(ctx context.Context, st *Stage, model string) string) {
+	o.prompt = fn
+}
+
+// SetSkillLoader 设置 stage.skill 的说明读取 (SKILL.md 内容)
+func (o *Orchestrator) SetSkillLoader(fn func(id string) (string, error)) {
+	o.skill = fn
+}
+
+// Run 运行流水线. input 替换提示词中的 {{input}}; onStage (可为 nil) 在每个
+// stage 结束时调用. 门槛未通过或出错时停止 (continue_on_fail 的 stage 除外),
+// 其余 stage 记为 skipped
+func (o *Orchestrator) Run(ctx context.Context, w *Workflow, input string, onStage func(StageReport)) *Report {
+	report := &Report{Workflow: w.Name, Input: input, StartedAt: time.Now()}
+	outputs := make(map[string]string, len(w.Stages))
+	previous := ""
+	stopped := false
+
+	for i := range w.Stages {
+		st := &w.Stages[i]
+		var sr StageReport
+		if stopped || ctx.Err() != nil {
+			sr = StageReport{Name: st.Name, Status: StageSkipped}
+		} else {
+			sr = o.runStage(ctx, w, st, input, previous, outputs)
+			outputs[st.Name] = sr.Output
+			previous = sr.Output
+			if sr.Status == StageError || (sr.Status == StageFailed && !st.ContinueOnFail) {
+				stopped = true
+			}
+		}
+		report.Stages = append(report.Stages, sr)
+		if onStage != nil {
+			onStage(sr)
+		}
+	}
+	report.Duration = time.Since(report.StartedAt)
+	o.logger.Info("Workflow finished",
+		zap.String("workflow", w.Name),
+		zap.Bool("passed", report.Passed()),
+		zap.Duration("duration", report.Duration),
+	)
+	return report
+}
+
+func (o *Orchestrator) runStage(ctx context.Context, w *Workflow, st *Stage, input, previous string, outputs map[string]string) (sr StageReport) {
+	model := st.Model
+	if model == "" {
+		model = w.Model
+	}
+	sr = StageReport{Name: st.Name, Model: model}
+	start := time.Now()
+	defer func() { sr.Duration = time.Since(start) }()
+
+	systemPrompt := ""
+	if o.prompt != nil {
+		systemPrompt = o.prompt(ctx, st, model)
+	}
+	if st.Skill != "" {
+		if o.skill == nil {
+			sr.Status, sr.Detail = StageError, "skills are not available"
+			return sr
+		}
+		text, err := o.skill(st.Skill)
+		if err != nil {
+			sr.Status, sr.Detail = StageError, fmt.Sprintf("skill %s: %v", st.Skill, err)
+			return sr
+		}
+		systemPrompt = appendSection(systemPrompt, "Skill: "+st.Skill, text)
+	}
+	systemPrompt = appendSection(systemPrompt, fmt.Sprintf("Workflow %s, stage %s", w.Name, st.Name), st.System)
+
+	stageCtx, cancel := context.WithTimeout(ctx, time.Duration(st.Timeout))
+	defer cancel()
+	if len(st.Tools) > 0 {
+		stageCtx = service.WithAgentProfile(stageCtx, service.AgentProfile{Name: w.Name + "/" + st.Name, Tools: st.Tools})
+	}
+
+	o.logger.Info("Workflow stage started",
+		zap.String("workflow", w.Name),
+		zap.String("stage", st.Name),
+		zap.String("model", model),
+	)
+	result, eventCh := o.agent.Run(stageCtx, systemPrompt, stagePrompt(st, input, previous, outputs), nil, model)
+	var runErr string
+	for ev := range eventCh {
+		if ev.Type == entity.EventError {
+			runErr = ev.Error
+		}
+	}
+
+	sr.Output = strings.TrimSpace(result.FinalContent)
+	sr.Steps, sr.Tokens = result.TotalSteps, result.TotalTokens
+	if result.ModelUsed != "" {
+		sr.Model = result.ModelUsed
+	}
+	switch {
+	case stageCtx.Err() == context.DeadlineExceeded:
+		sr.Status, sr.Detail = StageError, fmt.Sprintf("timed out after %s", time.Duration(st.Timeout))
+	case runErr != "":
+		sr.Status, sr.Detail = StageError, runErr
+	case result.Filtered:
+		sr.Status, sr.Detail = StageError, "answer blocked by the provider's content filter"
+	default:
+		if ok, why := st.Gate.check(sr.Output, result.TestReport); ok {
+			sr.Status = StagePassed
+		} else {
+			sr.Status, sr.Detail = StageFailed, why
+		}
+	}
+	return sr
+}
+
+// stagePrompt 替换占位符; 提示词没有引用任何输出时附上上一 stage 的输出
+func stagePrompt(st *Stage, input, previous string, outputs map[string]string) string {
+	p := st.Prompt
+	referenced := strings.Contains(p, "{{previous}}") || stageRefRe.MatchString(p)
+	p = stageRefRe.ReplaceAllStringFunc(p, func(m string) string {
+		return clip(outputs[stageRefRe.FindStringSubmatch(m)[1]])
+	})
+	p = strings.NewReplacer("{{input}}", input, "{{previous}}", clip(previous)).Replace(p)
+	if !referenced && previous != "" {
+		p += "\n\n## Output of the previous stage\n\n" + clip(previous)
+	}
+	if st.Gate.Verdict {
+		p += verdictInstruction
+	}
+	return p
+}
+
+// check 判断输出是否满足门槛, 不满足时返回原因
+func (g Gate) check(output string, tests *entity.TestReport) (bool, string) {
+	if output == "" && !g.TestsPass {
+		return false, "no output"
+	}
+	if g.Contains != "" && !strings.Contains(output, g.Contains) {
+		return false, fmt.Sprintf("output does not contain %q", g.Contains)
+	}
+	if g.NotContains != "" && strings.Contains(output, g.NotContains) {
+		return false, fmt.Sprintf("output contains %q", g.NotContains)
+	}
+	if g.re != nil && !g.re.MatchString(output) {
+		return false, fmt.Sprintf("output does not match %q", g.Matches)
+	}
+	if g.TestsPass {
+		if tests == nil {
+			return false, "tests were not run"
+		}
+		if !tests.Passed {
+			return false, tests.Summary()
+		}
+	}
+	if g.Verdict {
+		m := verdictRe.FindAllStringSubmatch(output, -1)
+		if len(m) == 0 {
+			return false, "no VERDICT line"
+		}
+		if strings.ToUpper(m[len(m)-1][1]) != "PASS" {
+			return false, "VERDICT: FAIL"
+		}
+	}
+	return true, ""
+}
+
+func appendSection(prompt, title, text string) string {
+	if strings.TrimSpace(text) == "" {
+		return prompt
+	}
+	if prompt != "" {
+		prompt += "\n\n---\n\n"
+	}
+	return prompt + "## " + title + "\n" + strings.TrimSpace(text)
+}
+
+func clip(s string) string {
+	r := []rune(s)
+	if len(r) <= maxForwardChars {
+		return s
+	}
+	return string(r[:maxForwardChars]) + "\n[…truncated]"
+}
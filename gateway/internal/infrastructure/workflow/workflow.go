@@ -0,0 +1,200 @@
+// Package workflow 加载并运行命名流水线 (~/.ngoclaw/workflows/*.yaml).
+//
+// 一个流水线是有序的多个 stage, 每个 stage 有自己的提示词、可用工具、模型和
+// 通过/失败门槛 (gate). Orchestrator 把每个 stage 作为独立的子 agent 运行,
+// 上一 stage 的输出传给下一 stage, 门槛不通过时停止, 最后给出逐 stage 的汇总.
+// 通过 TG `/workflow run <名称>` 或 `/cron add <表达式> /workflow run <名称>` 触发.
+package workflow
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultStageTimeout stage 未配置 timeout 时的运行上限
+const DefaultStageTimeout = 10 * time.Minute
+
+// DefaultDir 返回流水线目录 ~/.ngoclaw/workflows
+func DefaultDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ngoclaw", "workflows")
+}
+
+// Workflow 一个命名流水线
+type Workflow struct {
+	Name        string  `yaml:"name"` // 默认取文件名
+	Description string  `yaml:"description"`
+	Model       string  `yaml:"model"` // stage 未指定模型时使用; 为空 = 默认模型
+	Stages      []Stage `yaml:"stages"`
+
+	Path string `yaml:"-"` // 定义文件路径
+}
+
+// Stage 流水线中的一步, 作为独立的子 agent 运行
+type Stage struct {
+	Name string `yaml:"name"`
+	// Prompt 支持占位符: {{input}} 运行时传入的参数, {{previous}} 上一 stage 的输出,
+	// {{stages.<名称>}} 指定 stage 的输出. 未引用任何输出时自动附上上一 stage 的输出
+	Prompt         string   `yaml:"prompt"`
+	System         string   `yaml:"system"` // 追加到系统提示词
+	Skill          string   `yaml:"skill"`  // 技能 ID, 其 SKILL.md 作为说明附加到系统提示词
+	Tools          []string `yaml:"tools"`  // 允许的工具; 为空 = 全部工具
+	Model          string   `yaml:"model"`
+	Timeout        Duration `yaml:"timeout"`          // 默认 10m
+	Gate           Gate     `yaml:"gate"`             // 通过条件; 为空时运行成功且有输出即通过
+	ContinueOnFail bool     `yaml:"continue_on_fail"` // 门槛不通过时继续后续 stage (汇总仍记为失败)
+}
+
+// Gate stage 的通过条件, 所有已设置的条件都满足才算通过
+type Gate struct {
+	Contains    string `yaml:"contains"`     // 输出包含该子串
+	NotContains string `yaml:"not_contains"` // 输出不包含该子串
+	Matches     string `yaml:"matches"`      // 输出匹配该正则
+	TestsPass   bool   `yaml:"tests_pass"`   // 本 stage 调用了 run_tests 且全部通过
+	// Verdict 要求模型在回答末尾给出 "VERDICT: PASS" 或 "VERDICT: FAIL", 以此判定
+	Verdict bool `yaml:"verdict"`
+
+	re *regexp.Regexp
+}
+
+// Duration 支持 YAML 中 "90s" / "5m" 形式的时长
+type Duration time.Duration
+
+// UnmarshalYAML 解析 Go duration 字符串
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+	if s == "" {
+		*d = 0
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+var stageNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Parse 解析并校验流水线定义. 未知字段视为错误, 拼错的字段 (如 gate 写成 gates)
+// 不会被静默忽略
+func Parse(data []byte) (*Workflow, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var w Workflow
+	if err := dec.Decode(&w); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse workflow: %w", err)
+	}
+	if len(w.Stages) == 0 {
+		return nil, fmt.Errorf("workflow %q: no stages", w.Name)
+	}
+	seen := make(map[string]bool)
+	for i := range w.Stages {
+		st := &w.Stages[i]
+		if st.Name == "" {
+			st.Name = fmt.Sprintf("stage%d", i+1)
+		}
+		if !stageNameRe.MatchString(st.Name) {
+			return nil, fmt.Errorf("workflow %q: stages[%d]: invalid name %q (letters, digits, - and _)", w.Name, i, st.Name)
+		}
+		if seen[st.Name] {
+			return nil, fmt.Errorf("workflow %q: duplicate stage %q", w.Name, st.Name)
+		}
+		seen[st.Name] = true
+		if strings.TrimSpace(st.Prompt) == "" {
+			return nil, fmt.Errorf("workflow %q: stage %q: prompt is required", w.Name, st.Name)
+		}
+		if st.Timeout == 0 {
+			st.Timeout = Duration(DefaultStageTimeout)
+		}
+		if st.Gate.Matches != "" {
+			re, err := regexp.Compile(st.Gate.Matches)
+			if err != nil {
+				return nil, fmt.Errorf("workflow %q: stage %q: invalid gate.matches: %w", w.Name, st.Name, err)
+			}
+			st.Gate.re = re
+		}
+		// {{stages.x}} 只能引用之前的 stage
+		for _, m := range stageRefRe.FindAllStringSubmatch(st.Prompt, -1) {
+			if !seen[m[1]] || m[1] == st.Name {
+				return nil, fmt.Errorf("workflow %q: stage %q: {{stages.%s}} does not name an earlier stage", w.Name, st.Name, m[1])
+			}
+		}
+	}
+	return &w, nil
+}
+
+// Load 从文件加载流水线, 未命名时使用文件名
+func Load(path string) (*Workflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	w, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if w.Name == "" {
+		w.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	w.Path = path
+	return w, nil
+}
+
+// LoadDir 加载目录下所有 *.yaml / *.yml 流水线, 按名称排序. 目录不存在时返回空.
+// 定义无效的文件不影响其他流水线, 其错误在 errs 中返回
+func LoadDir(dir string) (workflows []*Workflow, errs []error) {
+	var paths []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		paths = append(paths, matches...)
+	}
+	seen := make(map[string]string)
+	for _, p := range paths {
+		w, err := Load(p)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if prev, ok := seen[w.Name]; ok {
+			errs = append(errs, fmt.Errorf("duplicate workflow name %q in %s and %s", w.Name, prev, p))
+			continue
+		}
+		seen[w.Name] = p
+		workflows = append(workflows, w)
+	}
+	sort.Slice(workflows, func(i, j int) bool { return workflows[i].Name < workflows[j].Name })
+	return workflows, errs
+}
+
+// Find 按名称查找流水线 (每次从磁盘读取, 修改定义后无需重启). <name>.yaml
+// 存在时直接加载, 定义无效时返回具体错误; 否则按文件内的 name 查找
+func Find(dir, name string) (*Workflow, error) {
+	for _, ext := range []string{".yaml", ".yml"} {
+		path := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			return Load(path)
+		}
+	}
+	workflows, _ := LoadDir(dir)
+	for _, w := range workflows {
+		if w.Name == name {
+			return w, nil
+		}
+	}
+	return nil, fmt.Errorf("workflow %q not found in %s", name, dir)
+}
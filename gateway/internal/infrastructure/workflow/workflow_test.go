@@ -0,0 +1,118 @@
+package workflow
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"go.uber.org/zap"
+)
+
+const releaseCheck = `
+description: pre-release checks
+model: p/default
+stages:
+  - name: test
+    prompt: Run the tests of {{input}}.
+    tools: [run_tests, read_file]
+    gate:
+      tests_pass: true
+  - name: changelog
+    prompt: Check the changelog mentions everything in {{stages.test}}.
+    model: p/cheap
+    gate:
+      verdict: true
+  - name: tag
+    prompt: Tag the release.
+`
+
+// fakeAgent answers each stage from a table keyed by the first two prompt words
+type fakeAgent struct {
+	answers map[string]*service.AgentResult
+	prompts []string
+	models  []string
+	tools   [][]string
+}
+
+func (a *fakeAgent) Run(ctx context.Context, systemPrompt, userMessage string, history []service.LLMMessage, model string) (*service.AgentResult, <-chan entity.AgentEvent) {
+	a.prompts = append(a.prompts, userMessage)
+	a.models = append(a.models, model)
+	p, _ := service.AgentProfileFromContext(ctx)
+	a.tools = append(a.tools, p.Tools)
+	ch := make(chan entity.AgentEvent)
+	close(ch)
+	return a.answers[strings.Fields(userMessage)[0]+" "+strings.Fields(userMessage)[1]], ch
+}
+
+func TestParseRejectsInvalidDefinitions(t *testing.T) {
+	for name, def := range map[string]string{
+		"unknown field":  "stages:\n  - prompt: x\n    gates: {}\n",
+		"no stages":      "name: x\n",
+		"empty prompt":   "stages:\n  - name: a\n",
+		"forward ref":    "stages:\n  - name: a\n    prompt: use {{stages.b}}\n  - name: b\n    prompt: x\n",
+		"bad regex":      "stages:\n  - prompt: x\n    gate: {matches: \"(\"}\n",
+		"duplicate name": "stages:\n  - {name: a, prompt: x}\n  - {name: a, prompt: y}\n",
+	} {
+		if _, err := Parse([]byte(def)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestFindUsesFileName(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "release-check.yaml"), []byte(releaseCheck), 0o600)
+	os.WriteFile(filepath.Join(dir, "broken.yml"), []byte("stages: []\n"), 0o600)
+
+	w, err := Find(dir, "release-check")
+	if err != nil || w.Name != "release-check" || len(w.Stages) != 3 || w.Stages[0].Timeout != Duration(DefaultStageTimeout) {
+		t.Fatalf("Find = %+v, %v", w, err)
+	}
+	if _, err := Find(dir, "broken"); err == nil || !strings.Contains(err.Error(), "no stages") {
+		t.Errorf("broken workflow error = %v", err)
+	}
+	if all, errs := LoadDir(dir); len(all) != 1 || len(errs) != 1 {
+		t.Errorf("LoadDir = %d workflows, %d errors", len(all), len(errs))
+	}
+}
+
+func TestOrchestratorStopsAtFailedGate(t *testing.T) {
+	w, err := Parse([]byte(releaseCheck))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Name = "release-check"
+	agent := &fakeAgent{answers: map[string]*service.AgentResult{
+		"Run the":   {FinalContent: "all green", TotalSteps: 3, TestReport: &entity.TestReport{Passed: true}},
+		"Check the": {FinalContent: "1.4 is missing\nVERDICT: FAIL", TotalSteps: 1},
+	}}
+
+	var seen []string
+	report := NewOrchestrator(agent, zap.NewNop()).Run(context.Background(), w, "v1.4", func(sr StageReport) {
+		seen = append(seen, sr.Name+":"+sr.Status)
+	})
+
+	if strings.Join(seen, " ") != "test:passed changelog:failed tag:skipped" || report.Passed() {
+		t.Fatalf("stages = %v", seen)
+	}
+	if agent.prompts[0] != "Run the tests of v1.4." || !strings.Contains(agent.prompts[1], "mentions everything in all green.") ||
+		!strings.HasSuffix(agent.prompts[1], verdictInstruction) {
+		t.Errorf("prompts = %q", agent.prompts)
+	}
+	if agent.models[0] != "p/default" || agent.models[1] != "p/cheap" || len(agent.tools[0]) != 2 || agent.tools[1] != nil {
+		t.Errorf("models = %v, tools = %v", agent.models, agent.tools)
+	}
+	if report.Output() != "1.4 is missing\nVERDICT: FAIL" {
+		t.Errorf("output = %q", report.Output())
+	}
+	summary := report.Summary()
+	for _, want := range []string{"❌ release-check failed · 1/3 stages", "❌ 2. changelog — VERDICT: FAIL", "⏭ 3. tag — skipped"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary lacks %q:\n%s", want, summary)
+		}
+	}
+}
@@ -73,6 +73,8 @@ type RunAgentRequest struct {
 	SystemPrompt string `json:"system_prompt"`
 	Model        string `json:"model"`
 	SessionID    string `json:"session_id"`
+	// Filter limits the streamed events (event types, verbosity, tool output)
+	Filter *service.EventFilter `json:"filter,omitempty"`
 }
 
 // AgentEvent is the streaming response event for ExecuteAgent RPC
@@ -88,6 +90,9 @@ type AgentEvent struct {
 	Tokens    int                    `json:"tokens,omitempty"`
 	Model     string                 `json:"model,omitempty"`
 	Error     string                 `json:"error,omitempty"`
+	// Edit is set on "file_edit" events: the file, its diff hunks and the
+	// content hashes before/after, for inline decorations and accept/reject
+	Edit *entity.FileEdit `json:"edit,omitempty"`
 }
 
 // ToolDefinition describes a tool for the ListTools RPC
@@ -104,6 +109,9 @@ func (s *Server) ExecuteAgent(ctx context.Context, req *RunAgentRequest, sendEve
 	if req.Message == "" {
 		return status.Error(codes.InvalidArgument, "message is required")
 	}
+	if err := req.Filter.Validate(); err != nil {
+		return status.Error(codes.InvalidArgument, "filter: "+err.Error())
+	}
 
 	s.logger.Info("gRPC ExecuteAgent",
 		zap.String("session", req.SessionID),
@@ -113,6 +121,10 @@ func (s *Server) ExecuteAgent(ctx context.Context, req *RunAgentRequest, sendEve
 	_, eventCh := s.agentLoop.Run(ctx, req.SystemPrompt, req.Message, nil, "")
 
 	for event := range eventCh {
+		event, ok := req.Filter.Apply(event)
+		if !ok {
+			continue
+		}
 		grpcEvent := convertToGRPCEvent(event)
 		if err := sendEvent(grpcEvent); err != nil {
 			return err
@@ -168,6 +180,10 @@ func convertToGRPCEvent(event entity.AgentEvent) *AgentEvent {
 			ge.Tokens = event.StepInfo.TokensUsed
 			ge.Model = event.StepInfo.ModelUsed
 		}
+	case entity.EventFileEdit:
+		ge.Type = "file_edit"
+		ge.ToolID = event.Edit.ToolCallID
+		ge.Edit = event.Edit
 	case entity.EventError:
 		ge.Type = "error"
 		ge.Error = event.Error
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -16,6 +17,8 @@ import (
 	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/prompt"
+	toolpkg "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tool"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
 	"golang.org/x/term"
 )
 
@@ -43,10 +46,25 @@ var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "
 // REPLConfig holds CLI runtime config
 type REPLConfig struct {
 	Model      string
+	Profile    string
 	Workspace  string
 	ToolCount  int
 	NoApprove  bool
 	InitPrompt string
+	Locale     i18n.Locale
+	Language   string // reply language chosen with /lang; empty = follow the user's messages
+	Verbose    bool   // show full tool output instead of the first lines
+	// Output post-processes the final answer (agent.output). When it applies
+	// to the cli channel, text is not streamed but printed once at the end.
+	Output *service.OutputPipeline
+	// Tools describes the registered tools for /tools (nil = unavailable)
+	Tools func() *toolpkg.ToolCatalog
+	// CarryOver seeds the session started by /new with a summary of the
+	// previous one (agent.carry_over); nil = /new starts empty
+	CarryOver *service.CarryOverPolicy
+	// Mentions resolves @file / #symbol mentions in messages against the
+	// workspace (agent.mentions); nil = sent as typed
+	Mentions *service.MentionPreprocessor
 }
 
 // RunREPL starts the interactive REPL loop
@@ -58,90 +76,174 @@ func RunREPL(
 	w := termWidth()
 	banner := RenderBanner(BannerInfo{
 		Model:      cfg.Model,
+		Profile:    cfg.Profile,
 		ToolCount:  cfg.ToolCount,
 		Workspace:  cfg.Workspace,
 		ProjectLng: DetectProjectLanguage(cfg.Workspace),
 	}, w)
 	fmt.Println(banner)
 
-	// Readline for proper line editing (backspace, arrows, history)
-	rl, err := readline.NewEx(&readline.Config{
-		Prompt:          "\001\033[1;36m\002❯\001\033[0m\002 ",
-		HistoryFile:      "",
-		InterruptPrompt: "^C",
-		EOFPrompt:       "exit",
-	})
+	// Readline for line editing, persistent history (~/.ngoclaw/history),
+	// multi-line input and completion — see input.go
+	templates := prompt.NewTemplateStore("")
+	rl, err := newLineEditor(templates)
 	if err != nil {
 		return fmt.Errorf("readline init: %w", err)
 	}
 	defer rl.Close()
+	disablePaste := enableBracketedPaste()
+	defer disablePaste()
+	reader := &inputReader{rl: rl}
 
 	var history []service.LLMMessage
+	outputs := &toolOutputs{expand: cfg.Verbose}
 
-	// Handle Ctrl+C for clean exit
+	// SIGTERM: clean exit
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGTERM)
 	go func() {
 		<-sigCh
-		fmt.Printf("\n%s👋 再见%s\n", dimText, reset)
+		fmt.Printf("\n%s%s%s\n", dimText, cfg.Locale.T("cli.bye"), reset)
+		disablePaste()
 		rl.Close()
 		os.Exit(0)
 	}()
 
+	// SIGINT: abort the current run only; second Ctrl+C quits
+	interrupter := newRunInterrupter(func() {
+		fmt.Printf("%s%s%s\n", dimText, cfg.Locale.T("cli.bye"), reset)
+		disablePaste()
+		rl.Close()
+		os.Exit(130)
+	})
+	defer interrupter.Stop()
+
 	// If initial prompt provided, run it first
 	if cfg.InitPrompt != "" {
-		history = runAgent(agentLoop, promptEngine, cfg, cfg.InitPrompt, history)
+		history = runAgent(agentLoop, promptEngine, interrupter, outputs, cfg, cfg.InitPrompt, history)
 	}
 
 	// REPL loop
+	pendingQuit := false // Ctrl+C on an empty prompt arms quit; a second one exits
 	for {
-		input, err := rl.Readline()
+		input, err := reader.Read()
 		if err != nil {
 			if err == readline.ErrInterrupt {
-				fmt.Printf("%s👋 再见%s\n", dimText, reset)
+				if strings.TrimSpace(input) != "" || !pendingQuit {
+					pendingQuit = strings.TrimSpace(input) == ""
+					if pendingQuit {
+						fmt.Printf("%s%s%s\n", dimText, cfg.Locale.T("cli.quit_hint"), reset)
+					}
+					continue
+				}
+				fmt.Printf("%s%s%s\n", dimText, cfg.Locale.T("cli.bye"), reset)
 				return nil
 			}
 			if err == io.EOF {
-				fmt.Printf("\n%s👋 再见%s\n", dimText, reset)
+				fmt.Printf("\n%s%s%s\n", dimText, cfg.Locale.T("cli.bye"), reset)
 				return nil
 			}
 			return nil
 		}
+		pendingQuit = false
 		input = strings.TrimSpace(input)
 		if input == "" {
 			continue
 		}
 
-		// Slash command
-		if cmd := ParseSlashCommand(input); cmd != nil {
-			result := ExecuteCommand(cmd, cfg.Model, cfg.ToolCount)
+		// Slash command (multi-line input always goes to the agent)
+		if cmd := ParseSlashCommand(input); cmd != nil && !strings.Contains(input, "\n") {
+			if isTemplateCommand(cmd) {
+				if rendered := runTemplateCommand(rl, templates, cmd, input, cfg.Locale); rendered != "" {
+					history = runAgent(agentLoop, promptEngine, interrupter, outputs, cfg, rendered, history)
+				}
+				continue
+			}
+			if cmd.Name == "expand" {
+				runExpandCommand(outputs, cmd, cfg.Locale)
+				continue
+			}
+			if cmd.Name == "tools" {
+				if cfg.Tools == nil {
+					fmt.Println(stripHTML(cfg.Locale.T("tools.unavailable")))
+				} else {
+					category := ""
+					if len(cmd.Args) > 0 {
+						category = strings.ToLower(cmd.Args[0])
+					}
+					PrintToolCatalog(os.Stdout, cfg.Tools(), category, cfg.Locale)
+				}
+				continue
+			}
+			result := ExecuteCommand(cmd, cfg.Model, cfg.ToolCount, cfg.Locale)
 			if result.IsQuit {
-				fmt.Printf("%s👋 再见%s\n", dimText, reset)
+				fmt.Printf("%s%s%s\n", dimText, cfg.Locale.T("cli.bye"), reset)
 				return nil
 			}
+			carried := ""
 			if result.IsReset {
-				history = nil
+				history, carried = carryOver(agentLoop, cfg, history, result.Clean)
+			}
+			if result.Locale != "" {
+				cfg.Locale = result.Locale
+				cfg.Language = string(result.Locale)
+			}
+			if result.LangAuto {
+				cfg.Language = ""
 			}
 			if result.Output != "" {
 				fmt.Println(result.Output)
 			}
+			if carried != "" {
+				fmt.Printf("%s%s%s%s\n", dimText, stripHTML(cfg.Locale.Tf("new.carried_over", "")), carried, reset)
+			}
+			if result.AgentPrompt != "" {
+				history = runAgent(agentLoop, promptEngine, interrupter, outputs, cfg, result.AgentPrompt, history)
+			}
 			continue
 		}
 
 		// Agent query
-		history = runAgent(agentLoop, promptEngine, cfg, input, history)
+		history = runAgent(agentLoop, promptEngine, interrupter, outputs, cfg, input, history)
 	}
 }
 
+// carryOver returns the history of the session started by /new: empty, or
+// seeded with a summary of the previous one when agent.carry_over is on.
+func carryOver(agentLoop *service.AgentLoop, cfg REPLConfig, history []service.LLMMessage, clean bool) ([]service.LLMMessage, string) {
+	if clean || cfg.CarryOver == nil || len(history) == 0 {
+		return nil, ""
+	}
+	ctx, cancel := context.WithTimeout(service.WithChannel(context.Background(), "cli"), 30*time.Second)
+	defer cancel()
+	summary, err := agentLoop.CarryOverSummary(ctx, history, *cfg.CarryOver, cfg.Model)
+	if err != nil {
+		fmt.Printf("%s%s%s\n", yellow, stripHTML(cfg.Locale.T("new.carry_over_failed")), reset)
+		return nil, ""
+	}
+	if summary == "" {
+		return nil, ""
+	}
+	return service.CarryOverHistory(summary), summary
+}
+
 // ─── Agent Execution ───
 
 func runAgent(
 	agentLoop *service.AgentLoop,
 	promptEngine *prompt.PromptEngine,
+	interrupter *runInterrupter,
+	outputs *toolOutputs,
 	cfg REPLConfig,
 	userMessage string,
 	history []service.LLMMessage,
 ) []service.LLMMessage {
+	// Reply language: /lang, else the language of the conversation, else the interface language
+	lang := service.ReplyLanguage(cfg.Language, userMessage, history)
+	if lang == "" {
+		lang = string(cfg.Locale)
+	}
+
 	// Build system prompt
 	systemPrompt := ""
 	if promptEngine != nil {
@@ -150,23 +252,23 @@ func runAgent(
 			ModelName:   cfg.Model,
 			UserMessage: userMessage,
 			Workspace:   cfg.Workspace,
+			Language:    lang,
 		})
 	}
 
-	// Context with cancel for Ctrl+C during streaming
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	go func() {
-		ch := make(chan os.Signal, 1)
-		signal.Notify(ch, syscall.SIGINT)
-		select {
-		case <-ch:
-			cancel()
-			fmt.Printf("\n%s⏹ 已中断%s\n", yellow, reset)
-		case <-ctx.Done():
+	// Per-run context: Ctrl+C cancels this run (LLM stream + tool processes), not the REPL
+	ctx, finish := interrupter.Begin(context.Background())
+	defer finish()
+	ctx = service.WithReplyLanguage(ctx, lang)
+	ctx = service.WithChannel(ctx, "cli")
+
+	// @file / #symbol: attach the content, rewrite to the resolved references
+	if cfg.Mentions != nil {
+		if text, parts := cfg.Mentions.Prepare(ctx, userMessage); len(parts) > 0 {
+			userMessage = text
+			ctx = service.WithContextParts(ctx, parts...)
 		}
-	}()
+	}
 
 	result, eventCh := agentLoop.Run(ctx, systemPrompt, userMessage, history, "")
 
@@ -177,13 +279,22 @@ func runAgent(
 
 	// Spinner state
 	spinner := newSpinner()
+	md := newMarkdownStream(os.Stdout, w, term.IsTerminal(int(os.Stdout.Fd())))
+	outputs.begin()
+	buffered := cfg.Output.Active("cli")
 
 	for event := range eventCh {
+		if event.Type != entity.EventTextDelta && event.Type != entity.EventStepDone {
+			md.Flush() // The spinner and tool boxes redraw whole lines
+		}
 		switch event.Type {
 		case entity.EventTextDelta:
-			spinner.Stop()
-			fmt.Print(event.Content)
 			textBuf.WriteString(event.Content)
+			if buffered {
+				continue
+			}
+			spinner.Stop()
+			md.Write(event.Content)
 
 		case entity.EventThinking:
 			if event.Content != "" {
@@ -203,6 +314,8 @@ func runAgent(
 		case entity.EventToolResult:
 			spinner.Stop()
 			if event.ToolCall != nil {
+				n := outputs.add(event.ToolCall)
+				printToolOutput(event.ToolCall, n, w, outputs.expand, cfg.Locale)
 				printToolFooter(event.ToolCall, w)
 			}
 
@@ -212,20 +325,56 @@ func runAgent(
 				totalTokens = event.StepInfo.TokensUsed
 			}
 
-
-
 		case entity.EventError:
 			spinner.Stop()
-			fmt.Printf("\n%s✗ %s%s\n", redBold, event.Error, reset)
+			if ctx.Err() != nil {
+				continue // Interrupt already reported by the interrupter
+			}
+			if event.AbortReason != "" {
+				fmt.Printf("\n%s%s%s\n", yellow, cfg.Locale.T(service.AbortReason(event.AbortReason).MessageKey()), reset)
+				continue
+			}
+			kind := service.ClassifyError(errors.New(event.Error), "", cfg.Model).Kind
+			fmt.Printf("\n%s✗ %s%s\n%s%s%s\n", redBold, cfg.Locale.T(kind.MessageKey()), reset, dimText, event.Error, reset)
+
+		case entity.EventCostWarning:
+			if p := event.Preflight; p != nil {
+				spinner.Stop()
+				text := cfg.Locale.Tf("run.large_req", p.InputTokens/1000, p.Model)
+				if p.CostUSD > 0 {
+					text += cfg.Locale.Tf("run.large_cost", p.CostUSD)
+				}
+				fmt.Printf("%s%s%s\n", yellow, text, reset)
+			}
+
+		case entity.EventQueued:
+			if q := event.Queue; q != nil {
+				spinner.Stop()
+				fmt.Printf("%s%s%s\n", yellow, cfg.Locale.Tf("run.queued", q.Provider, q.Position, q.Wait.Round(time.Second)), reset)
+			}
+
+		case entity.EventSlow:
+			if sl := event.Slow; sl != nil {
+				spinner.Stop()
+				fmt.Printf("\n%s%s%s\n", yellow, cfg.Locale.Tf(sl.MessageKey(), sl.Provider, sl.Waited.Round(time.Second)), reset)
+			}
 
 		case entity.EventDone:
 			spinner.Stop()
 		}
 	}
 	spinner.Stop()
+	shown := textBuf.String()
+	if buffered {
+		shown = ""
+		if !interrupter.Interrupted() {
+			shown = printProcessed(md, cfg, result, textBuf.String())
+		}
+	}
+	md.Flush()
 
 	// Ensure trailing newline
-	if textBuf.Len() > 0 && !strings.HasSuffix(textBuf.String(), "\n") {
+	if shown != "" && !strings.HasSuffix(shown, "\n") {
 		fmt.Println()
 	}
 
@@ -237,10 +386,26 @@ func runAgent(
 		fmt.Printf("\n%s─── %d steps · %s tokens ───%s\n",
 			dimText, stepCount, fmtTokens(totalTokens), reset)
 	}
+	if result != nil && result.TestReport != nil {
+		fmt.Printf("%s🧪 %s%s\n", dimText, result.TestReport.Summary(), reset)
+	}
 
-	// Update history
+	// Interrupted: keep the partial stream in history so the next turn has context
+	if interrupter.Interrupted() {
+		partial := strings.TrimSpace(service.StripReasoningTags(textBuf.String()))
+		if partial == "" {
+			partial = "(被用户打断)"
+		}
+		return append(history,
+			service.LLMMessage{Role: "user", Content: userMessage},
+			service.LLMMessage{Role: "assistant", Content: partial + " " + service.AbortUserStop.HistoryMarker()},
+		)
+	}
+
+	// Update history; a content-filtered exchange is left out so the
+	// refusal doesn't carry into the next turn
 	finalContent := textBuf.String()
-	if finalContent != "" {
+	if finalContent != "" && (result == nil || !result.Filtered) {
 		history = append(history,
 			service.LLMMessage{Role: "user", Content: userMessage},
 			service.LLMMessage{Role: "assistant", Content: finalContent},
@@ -250,6 +415,33 @@ func runAgent(
 	return history
 }
 
+// printProcessed renders the answer through agent.output once the run is done.
+// History keeps the raw text; only what is shown is processed. Returns the
+// printed text.
+func printProcessed(md *markdownStream, cfg REPLConfig, result *service.AgentResult, streamed string) string {
+	answer := ""
+	model := cfg.Model
+	if result != nil {
+		answer = result.FinalContent
+		if result.ModelUsed != "" {
+			model = result.ModelUsed
+		}
+	}
+	if strings.TrimSpace(answer) == "" {
+		answer = service.StripReasoningTags(streamed)
+	}
+	if strings.TrimSpace(answer) == "" {
+		return ""
+	}
+	out, err := cfg.Output.Process(context.Background(), answer, service.OutputInfo{Channel: "cli", Model: model})
+	if err != nil {
+		fmt.Printf("%s%s%s\n", yellow, cfg.Locale.T("run.withheld"), reset)
+		return ""
+	}
+	md.Write(out)
+	return out
+}
+
 // ─── Tool Display (Gemini CLI style) ───
 
 // printToolHeader renders: ╭─ ⊷ tool_name description ──────
@@ -342,6 +534,7 @@ func printPlan(content string, width int) {
 func toolIcon(name string) string {
 	icons := map[string]string{
 		"bash":         "$",
+		"terminal":     "$",
 		"read_file":    "→",
 		"write_file":   "←",
 		"edit_file":    "←",
@@ -391,6 +584,7 @@ type asyncSpinner struct {
 	mu      sync.Mutex
 	running bool
 	msg     string
+	started time.Time
 	stopCh  chan struct{}
 	doneCh  chan struct{}
 }
@@ -406,6 +600,7 @@ func (s *asyncSpinner) Update(msg string) {
 	s.msg = msg
 	if !s.running {
 		s.running = true
+		s.started = time.Now()
 		s.stopCh = make(chan struct{})
 		s.doneCh = make(chan struct{})
 		go s.run()
@@ -441,6 +636,9 @@ func (s *asyncSpinner) run() {
 		case <-ticker.C:
 			s.mu.Lock()
 			msg := s.msg
+			if elapsed := time.Since(s.started); elapsed >= time.Second {
+				msg += fmt.Sprintf(" (%ds)", int(elapsed.Seconds()))
+			}
 			s.mu.Unlock()
 
 			f := spinnerFrames[frame%len(spinnerFrames)]
@@ -43,6 +43,7 @@ var logoGradient = []lipgloss.Color{
 // BannerInfo carries dynamic stats shown in the welcome banner
 type BannerInfo struct {
 	Model      string
+	Profile    string // active config profile, empty when none
 	ToolCount  int
 	MCPServers int
 	Workspace  string
@@ -100,6 +101,9 @@ func RenderBanner(info BannerInfo, width int) string {
 		labelStyle.Render("Model"),
 		valueStyle.Render(info.Model),
 	)
+	if info.Profile != "" {
+		modelLine += " " + tipStyle.Render("· profile "+info.Profile)
+	}
 	toolsLine := fmt.Sprintf("  %s %s",
 		labelStyle.Render("Tools"),
 		greenStyle.Render(fmt.Sprintf("%d loaded", info.ToolCount)),
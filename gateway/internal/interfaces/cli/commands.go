@@ -5,6 +5,9 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+
+	toolpkg "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tool"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
 )
 
 // SlashCommand represents a parsed slash command
@@ -35,82 +38,124 @@ type CommandResult struct {
 	Output  string
 	IsQuit  bool
 	IsReset bool
+	Clean   bool        // /new --clean: nothing is carried over into the new session
+	Locale  i18n.Locale // non-empty when /lang switched the interface language
+	// LangAuto is set by /lang auto: replies follow the language of the user's messages
+	LangAuto bool
+
+	// AgentPrompt, when set, is sent to the agent as the user message (e.g. /research)
+	AgentPrompt string
 }
 
 // ExecuteCommand handles slash commands and returns the result
-func ExecuteCommand(cmd *SlashCommand, model string, toolCount int) CommandResult {
+func ExecuteCommand(cmd *SlashCommand, model string, toolCount int, loc i18n.Locale) CommandResult {
 	switch cmd.Name {
 	case "help", "h":
-		return CommandResult{Output: renderHelp()}
+		return CommandResult{Output: renderHelp(loc)}
 	case "exit", "quit", "q":
 		return CommandResult{IsQuit: true}
 	case "new", "reset":
-		return CommandResult{Output: "🔄 已清空对话历史", IsReset: true}
+		clean := len(cmd.Args) > 0 && cmd.Args[0] == "--clean"
+		return CommandResult{Output: loc.T("cli.new"), IsReset: true, Clean: clean}
 	case "status", "s":
-		return CommandResult{Output: renderStatus(model, toolCount)}
+		return CommandResult{Output: renderStatus(loc, model, toolCount)}
+	case "lang", "language":
+		if len(cmd.Args) == 0 {
+			return CommandResult{Output: loc.Tf("lang.current", loc)}
+		}
+		if strings.EqualFold(cmd.Args[0], "auto") {
+			return CommandResult{Output: loc.Tf("lang.auto", loc), LangAuto: true}
+		}
+		next, ok := i18n.Parse(cmd.Args[0])
+		if !ok {
+			return CommandResult{Output: loc.T("lang.usage")}
+		}
+		return CommandResult{Output: next.Tf("lang.set", next), Locale: next}
 	case "model", "m":
 		if len(cmd.Args) == 0 {
-			return CommandResult{Output: fmt.Sprintf("当前模型: %s\n用法: /model <model_name>", model)}
+			return CommandResult{Output: loc.Tf("cli.model.current", model)}
 		}
-		return CommandResult{Output: fmt.Sprintf("✓ 模型已切换为: %s", cmd.Args[0])}
+		return CommandResult{Output: loc.Tf("cli.model.switched", cmd.Args[0])}
 	case "compact":
-		return CommandResult{Output: "🗜 上下文已压缩"}
+		return CommandResult{Output: loc.T("cli.compacted")}
 	case "think":
 		level := "medium"
 		if len(cmd.Args) > 0 {
 			level = cmd.Args[0]
 		}
-		return CommandResult{Output: fmt.Sprintf("🧠 思考级别: %s", level)}
+		return CommandResult{Output: loc.Tf("cli.think", level)}
+	case "research":
+		if len(cmd.Args) == 0 {
+			return CommandResult{Output: loc.T("cli.research.usage")}
+		}
+		return CommandResult{AgentPrompt: toolpkg.ResearchPrompt(strings.Join(cmd.Args, " "))}
 	case "version":
 		return CommandResult{Output: fmt.Sprintf("NGOClaw v%s", appVersion)}
 	default:
-		return CommandResult{Output: fmt.Sprintf("未知命令: /%s  输入 /help 查看可用命令", cmd.Name)}
+		return CommandResult{Output: loc.Tf("cli.unknown", cmd.Name)}
 	}
 }
 
-func renderHelp() string {
+// helpCommands lists the commands shown by /help (and completed with Tab)
+var helpCommands = []struct {
+	name string
+	key  string
+}{
+	{"/help", "cli.help.help"},
+	{"/model [name]", "cli.help.model"},
+	{"/new [--clean]", "cli.help.new"},
+	{"/compact", "cli.help.compact"},
+	{"/status", "cli.help.status"},
+	{"/tools [category]", "cli.help.tools"},
+	{"/think [level]", "cli.help.think"},
+	{"/lang [zh|en|auto]", "cli.help.lang"},
+	{"/research <topic>", "cli.help.research"},
+	{"/templates", "cli.help.templates"},
+	{"/t <name> [k=v]", "cli.help.t"},
+	{"/expand [n]", "cli.help.expand"},
+	{"/version", "cli.help.version"},
+	{"/exit", "cli.help.exit"},
+}
+
+// commandNames returns the command names of helpCommands without "/" and arguments
+func commandNames() []string {
+	names := make([]string, 0, len(helpCommands))
+	for _, c := range helpCommands {
+		name, _, _ := strings.Cut(strings.TrimPrefix(c.name, "/"), " ")
+		names = append(names, name)
+	}
+	return names
+}
+
+func renderHelp(loc i18n.Locale) string {
 	titleStyle := lipgloss.NewStyle().Foreground(colorCyan).Bold(true)
 	cmdStyle := lipgloss.NewStyle().Foreground(colorGreen)
 	descStyle := lipgloss.NewStyle().Foreground(colorGray)
 
-	cmds := []struct {
-		name string
-		desc string
-	}{
-		{"/help", "显示此帮助"},
-		{"/model [name]", "查看/切换模型"},
-		{"/new", "清空对话历史"},
-		{"/compact", "压缩上下文"},
-		{"/status", "当前状态"},
-		{"/think [level]", "思考级别 (off/low/medium/high)"},
-		{"/version", "版本信息"},
-		{"/exit", "退出"},
-	}
-
 	var sb strings.Builder
-	sb.WriteString(titleStyle.Render("◇ 可用命令"))
+	sb.WriteString(titleStyle.Render(loc.T("cli.help.title")))
 	sb.WriteString("\n\n")
 
-	for _, c := range cmds {
+	for _, c := range helpCommands {
 		sb.WriteString(fmt.Sprintf("  %s  %s\n",
-			cmdStyle.Render(fmt.Sprintf("%-16s", c.name)),
-			descStyle.Render(c.desc),
+			cmdStyle.Render(fmt.Sprintf("%-18s", c.name)),
+			descStyle.Render(loc.T(c.key)),
 		))
 	}
 
 	return sb.String()
 }
 
-func renderStatus(model string, toolCount int) string {
+func renderStatus(loc i18n.Locale, model string, toolCount int) string {
 	titleStyle := lipgloss.NewStyle().Foreground(colorCyan).Bold(true)
 	labelStyle := lipgloss.NewStyle().Foreground(colorGray)
 	valueStyle := lipgloss.NewStyle().Foreground(colorWhite)
 
 	var sb strings.Builder
-	sb.WriteString(titleStyle.Render("◇ 当前状态"))
+	sb.WriteString(titleStyle.Render(loc.T("cli.status.title")))
 	sb.WriteString("\n\n")
-	sb.WriteString(fmt.Sprintf("  %s %s\n", labelStyle.Render("模型:"), valueStyle.Render(model)))
-	sb.WriteString(fmt.Sprintf("  %s %s\n", labelStyle.Render("工具:"), valueStyle.Render(fmt.Sprintf("%d 已加载", toolCount))))
+	sb.WriteString(fmt.Sprintf("  %s %s\n", labelStyle.Render(loc.T("cli.status.model")), valueStyle.Render(model)))
+	sb.WriteString(fmt.Sprintf("  %s %s\n", labelStyle.Render(loc.T("cli.status.tools")), valueStyle.Render(loc.Tf("cli.status.count", toolCount))))
 
 	return sb.String()
 }
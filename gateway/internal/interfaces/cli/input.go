@@ -0,0 +1,229 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chzyer/readline"
+	"golang.org/x/term"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/prompt"
+)
+
+// ─── REPL Input ───
+//
+// Line editing is readline's (Ctrl+A/E, Alt+B/F, Ctrl+W/K/U, Ctrl+R reverse
+// search over the persistent history). On top of it:
+//   - a line ending in "\" continues on the next line (continuation prompt);
+//   - Esc+Enter / Alt+Enter inserts a newline without sending;
+//   - bracketed paste: a pasted block is inserted as text, never submitted
+//     line by line, so pasted "/commands" or newlines cannot run anything;
+//   - Tab completes /commands and /t template names.
+//
+// readline edits a single line, so newlines inside an entry are shown (and
+// stored in the history file) as newlineMarker and turned back into "\n"
+// when the entry is submitted.
+
+const newlineMarker = "↵"
+
+const (
+	replPrompt = "\001\033[1;36m\002❯\001\033[0m\002 "
+	contPrompt = "\001\033[2m\002…\001\033[0m\002 "
+)
+
+// historyLimit is the number of entries kept in ~/.ngoclaw/history.
+const historyLimit = 1000
+
+// historyPath returns the REPL history file (~/.ngoclaw/history).
+func historyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	dir := filepath.Join(home, ".ngoclaw")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "history")
+}
+
+// newLineEditor creates the readline instance used by the REPL.
+func newLineEditor(templates *prompt.TemplateStore) (*readline.Instance, error) {
+	return readline.NewEx(&readline.Config{
+		Prompt:                 replPrompt,
+		HistoryFile:            historyPath(),
+		HistoryLimit:           historyLimit,
+		HistorySearchFold:      true,
+		DisableAutoSaveHistory: true, // 整条 (多行) 输入提交后由 inputReader 保存
+		AutoComplete:           &slashCompleter{templates: templates},
+		InterruptPrompt:        "^C",
+		EOFPrompt:              "exit",
+		Stdin:                  readline.NewCancelableStdin(&pasteFilter{r: os.Stdin}),
+	})
+}
+
+// enableBracketedPaste asks the terminal to mark pasted text and returns
+// the function that turns it off again (call it on every exit path).
+func enableBracketedPaste() func() {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return func() {}
+	}
+	fmt.Print("\033[?2004h")
+	return func() { fmt.Print("\033[?2004l") }
+}
+
+// inputReader reads one REPL entry, which may span several lines.
+type inputReader struct {
+	rl *readline.Instance
+}
+
+// Read returns the next entry with real newlines. On error the partial
+// entry is returned too, so Ctrl+C on a half-typed entry only clears it.
+func (r *inputReader) Read() (string, error) {
+	var lines []string
+	defer r.rl.SetPrompt(replPrompt)
+	for {
+		line, err := r.rl.Readline()
+		if err != nil {
+			return strings.Join(append(lines, line), "\n"), err
+		}
+		if strings.HasSuffix(line, `\`) {
+			lines = append(lines, strings.TrimSuffix(line, `\`))
+			r.rl.SetPrompt(contPrompt)
+			continue
+		}
+		entry := strings.ReplaceAll(strings.Join(append(lines, line), "\n"), newlineMarker, "\n")
+		if strings.TrimSpace(entry) != "" {
+			_ = r.rl.SaveHistory(strings.ReplaceAll(entry, "\n", newlineMarker))
+		}
+		return entry, nil
+	}
+}
+
+// ─── Bracketed Paste ───
+
+var (
+	pasteStart = []byte("\033[200~")
+	pasteEnd   = []byte("\033[201~")
+)
+
+// pasteFilter sits between the terminal and readline. Inside a bracketed
+// paste it turns newlines into newlineMarker, tabs into spaces (Tab would
+// trigger completion) and drops other control characters; outside a paste
+// it maps Esc+Enter to newlineMarker. Everything else passes through.
+type pasteFilter struct {
+	r       io.Reader
+	inPaste bool
+	lastCR  bool   // pasted "\r\n" split across two reads
+	carry   []byte // escape sequence cut off at the end of the last read
+	out     []byte
+	err     error
+}
+
+func (f *pasteFilter) Read(p []byte) (int, error) {
+	for len(f.out) == 0 {
+		if f.err != nil {
+			return 0, f.err
+		}
+		buf := make([]byte, 4096)
+		n, err := f.r.Read(buf)
+		data := append(f.carry, buf[:n]...)
+		f.carry = nil
+		if err != nil {
+			f.err = err
+			f.out = data // 输入结束: 不再等待序列的剩余部分
+			break
+		}
+		f.out = f.filter(data)
+	}
+	n := copy(p, f.out)
+	f.out = f.out[n:]
+	return n, nil
+}
+
+func (f *pasteFilter) filter(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); {
+		rest := data[i:]
+		switch {
+		case bytes.HasPrefix(rest, pasteStart):
+			f.inPaste, f.lastCR = true, false
+			i += len(pasteStart)
+			continue
+		case bytes.HasPrefix(rest, pasteEnd):
+			f.inPaste = false
+			i += len(pasteEnd)
+			continue
+		case rest[0] == '\033' && (bytes.HasPrefix(pasteStart, rest) || bytes.HasPrefix(pasteEnd, rest)):
+			f.carry = append([]byte(nil), rest...)
+			return out
+		}
+
+		c := rest[0]
+		i++
+		if f.inPaste {
+			switch {
+			case c == '\n' && f.lastCR:
+			case c == '\r' || c == '\n':
+				out = append(out, newlineMarker...)
+			case c == '\t':
+				out = append(out, "    "...)
+			case c < 0x20 || c == 0x7f:
+				// 粘贴内容中的控制字符 (Ctrl+C、Enter、Esc 序列等) 一律丢弃
+			default:
+				out = append(out, c)
+			}
+			f.lastCR = c == '\r'
+			continue
+		}
+		if c == '\033' && len(rest) > 1 && (rest[1] == '\r' || rest[1] == '\n') {
+			out = append(out, newlineMarker...)
+			i++
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// ─── Completion ───
+
+// slashCompleter completes /command names, and template names after /t.
+type slashCompleter struct {
+	templates *prompt.TemplateStore
+}
+
+func (c *slashCompleter) Do(line []rune, pos int) ([][]rune, int) {
+	head := string(line[:pos])
+	if !strings.HasPrefix(head, "/") || strings.Contains(head, newlineMarker) {
+		return nil, 0
+	}
+	name, arg, hasArg := strings.Cut(head[1:], " ")
+	if !hasArg {
+		return completions(commandNames(), name)
+	}
+	if (name == "t" || name == "template") && !strings.Contains(arg, " ") && c.templates != nil {
+		list, _ := c.templates.List()
+		names := make([]string, 0, len(list))
+		for _, t := range list {
+			names = append(names, t.Name)
+		}
+		return completions(names, arg)
+	}
+	return nil, 0
+}
+
+// completions returns the suffixes of the candidates starting with prefix.
+func completions(candidates []string, prefix string) ([][]rune, int) {
+	var out [][]rune
+	for _, cand := range candidates {
+		if strings.HasPrefix(cand, prefix) {
+			out = append(out, []rune(cand[len(prefix):]+" "))
+		}
+	}
+	return out, len([]rune(prefix))
+}
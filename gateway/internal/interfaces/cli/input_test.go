@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"io"
+	"testing"
+)
+
+// chunkReader returns one chunk per Read, like a terminal delivering keystrokes.
+type chunkReader struct {
+	chunks []string
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.chunks[0])
+	r.chunks = r.chunks[1:]
+	return n, nil
+}
+
+func TestPasteFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   string
+	}{
+		{"typing passes through", []string{"hi\x1b[D\r"}, "hi\x1b[D\r"},
+		{"paste newlines and controls", []string{"\x1b[200~/exit\r\nrm\x03\tx\n\x1b[201~\r"}, "/exit↵rm    x↵\r"},
+		{"markers split across reads", []string{"\x1b[20", "0~a\r", "\n\x1b", "[201~b"}, "a↵b"},
+		{"esc enter inserts newline", []string{"a\x1b\rb\r"}, "a↵b\r"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := io.ReadAll(&pasteFilter{r: &chunkReader{chunks: tt.chunks}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlashCompleter(t *testing.T) {
+	c := &slashCompleter{}
+	got, n := c.Do([]rune("/re"), 3)
+	if n != 2 || len(got) != 1 || string(got[0]) != "search " {
+		t.Errorf("/re → %q (%d)", got, n)
+	}
+	if got, _ := c.Do([]rune("hello /re"), 9); got != nil {
+		t.Errorf("completion outside a command: %q", got)
+	}
+	if got, _ := c.Do([]rune("/model x"), 8); got != nil {
+		t.Errorf("unexpected argument completion: %q", got)
+	}
+}
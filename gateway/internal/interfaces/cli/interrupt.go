@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+)
+
+// runInterrupter routes SIGINT to the active agent run instead of the process.
+//
+// First Ctrl+C during a run cancels only that run's context (like TG /stop);
+// the LLM stream and sandboxed tool processes observe the cancellation and
+// unwind. A second Ctrl+C before the run has finished unwinding quits.
+//
+// While readline is waiting for input the terminal is in raw mode, so Ctrl+C
+// arrives as readline.ErrInterrupt rather than a signal and is handled by the
+// REPL loop itself.
+type runInterrupter struct {
+	mu          sync.Mutex
+	cancel      context.CancelCauseFunc
+	interrupted bool
+	sigCh       chan os.Signal
+	onQuit      func()
+}
+
+// newRunInterrupter installs the SIGINT handler for the REPL lifetime.
+// onQuit is invoked on the second Ctrl+C of a run and must not return.
+func newRunInterrupter(onQuit func()) *runInterrupter {
+	ri := &runInterrupter{
+		sigCh:  make(chan os.Signal, 1),
+		onQuit: onQuit,
+	}
+	signal.Notify(ri.sigCh, os.Interrupt)
+	go ri.loop()
+	return ri
+}
+
+func (ri *runInterrupter) loop() {
+	for range ri.sigCh {
+		ri.mu.Lock()
+		cancel := ri.cancel
+		already := ri.interrupted
+		if cancel != nil {
+			ri.interrupted = true
+		}
+		ri.mu.Unlock()
+
+		switch {
+		case cancel == nil:
+			// No active run (e.g. between prompt and run start) — ignore.
+		case already:
+			ri.onQuit()
+		default:
+			cancel(service.NewAbortError(service.AbortUserStop, "Ctrl+C"))
+			fmt.Printf("\n%s⏹ 已中断 (再按 Ctrl+C 退出)%s\n", yellow, reset)
+		}
+	}
+}
+
+// Begin derives a per-run context. The returned finish func must be called
+// when the run's event stream has been drained.
+func (ri *runInterrupter) Begin(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(parent)
+	ri.mu.Lock()
+	ri.cancel = cancel
+	ri.interrupted = false
+	ri.mu.Unlock()
+
+	return ctx, func() {
+		ri.mu.Lock()
+		ri.cancel = nil
+		ri.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// Interrupted reports whether the current (or just-finished) run was cancelled via Ctrl+C.
+func (ri *runInterrupter) Interrupted() bool {
+	ri.mu.Lock()
+	defer ri.mu.Unlock()
+	return ri.interrupted
+}
+
+// Stop uninstalls the SIGINT handler.
+func (ri *runInterrupter) Stop() {
+	signal.Stop(ri.sigCh)
+	close(ri.sigCh)
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+)
+
+// startTestInterrupter runs the interrupter loop on a channel the test feeds,
+// without installing a real SIGINT handler.
+func startTestInterrupter(t *testing.T) (*runInterrupter, chan struct{}) {
+	t.Helper()
+	quit := make(chan struct{}, 2)
+	ri := &runInterrupter{
+		sigCh:  make(chan os.Signal, 1),
+		onQuit: func() { quit <- struct{}{} },
+	}
+	go ri.loop()
+	t.Cleanup(func() { close(ri.sigCh) })
+	return ri, quit
+}
+
+func TestRunInterrupter_FirstCtrlCCancelsRun(t *testing.T) {
+	ri, quit := startTestInterrupter(t)
+	ctx, finish := ri.Begin(context.Background())
+	defer finish()
+
+	ri.sigCh <- os.Interrupt
+	select {
+	case <-ctx.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("run context not cancelled by the first Ctrl+C")
+	}
+	var abort *service.AbortError
+	if cause := context.Cause(ctx); !errors.As(cause, &abort) || abort.Reason != service.AbortUserStop {
+		t.Errorf("cause = %v, want a user-stop AbortError", cause)
+	}
+	if !ri.Interrupted() {
+		t.Error("Interrupted() = false after Ctrl+C")
+	}
+	select {
+	case <-quit:
+		t.Fatal("first Ctrl+C quit the REPL")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRunInterrupter_SecondCtrlCQuits(t *testing.T) {
+	ri, quit := startTestInterrupter(t)
+	ctx, finish := ri.Begin(context.Background())
+	defer finish()
+
+	ri.sigCh <- os.Interrupt
+	<-ctx.Done()
+	ri.sigCh <- os.Interrupt
+	select {
+	case <-quit:
+	case <-time.After(2 * time.Second):
+		t.Fatal("second Ctrl+C did not quit")
+	}
+
+	// A new run starts with a clean slate: one Ctrl+C only cancels it
+	finish()
+	ctx, finish = ri.Begin(context.Background())
+	defer finish()
+	if ri.Interrupted() {
+		t.Error("Interrupted() carried over to the next run")
+	}
+	ri.sigCh <- os.Interrupt
+	<-ctx.Done()
+	select {
+	case <-quit:
+		t.Fatal("first Ctrl+C of a new run quit the REPL")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRunInterrupter_IgnoredWithoutRun(t *testing.T) {
+	ri, quit := startTestInterrupter(t)
+	ri.sigCh <- os.Interrupt
+	ri.sigCh <- os.Interrupt
+	select {
+	case <-quit:
+		t.Fatal("Ctrl+C without an active run quit the REPL")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if ri.Interrupted() {
+		t.Error("Interrupted() = true without a run")
+	}
+}
@@ -0,0 +1,272 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
+)
+
+// ─── Streaming Markdown ───
+
+// markdownStream renders assistant text as EventTextDelta arrives.
+//
+// Prose is echoed token by token and restyled once its line is complete
+// (headings, bullets, quotes, inline code, bold). Lines inside fenced code
+// blocks are held until their newline and then highlighted with chroma.
+// Without color (stdout is not a terminal) text is passed through unchanged.
+type markdownStream struct {
+	out   io.Writer
+	width int
+	color bool
+
+	line   strings.Builder // current, incomplete line
+	echoed int             // bytes of line already printed raw
+
+	inCode    bool
+	fence     string // "```" or "~~~" that opened the block
+	lexer     chroma.Lexer
+	formatter chroma.Formatter
+	style     *chroma.Style
+}
+
+func newMarkdownStream(out io.Writer, width int, color bool) *markdownStream {
+	return &markdownStream{
+		out:       out,
+		width:     width,
+		color:     color,
+		formatter: formatters.Get("terminal256"),
+		style:     styles.Get("monokai"),
+	}
+}
+
+// Write consumes one text delta.
+func (m *markdownStream) Write(delta string) {
+	if !m.color {
+		fmt.Fprint(m.out, delta)
+		return
+	}
+	for {
+		i := strings.IndexByte(delta, '\n')
+		if i < 0 {
+			break
+		}
+		m.line.WriteString(delta[:i])
+		m.endLine()
+		delta = delta[i+1:]
+	}
+	m.line.WriteString(delta)
+
+	// Echo prose immediately; hold code and anything that may become a fence
+	cur := m.line.String()
+	if m.inCode || maybeFence(cur) {
+		return
+	}
+	fmt.Fprint(m.out, cur[m.echoed:])
+	m.echoed = len(cur)
+}
+
+// Flush finishes a pending partial line, e.g. before a tool call or at the
+// end of the run. An open code block stays open for the next delta.
+func (m *markdownStream) Flush() {
+	if m.color && m.line.Len() > 0 {
+		m.endLine()
+	}
+}
+
+func (m *markdownStream) endLine() {
+	line := m.line.String()
+	echoed := m.echoed
+	m.line.Reset()
+	m.echoed = 0
+
+	if echoed > 0 {
+		// Replace the raw echo when it still fits on one terminal row
+		if lipgloss.Width(line) >= m.width {
+			fmt.Fprintln(m.out)
+			return
+		}
+		fmt.Fprint(m.out, clearLn)
+	}
+
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case m.inCode && strings.HasPrefix(trimmed, m.fence) && strings.Trim(trimmed, m.fence[:1]) == "":
+		m.inCode = false
+		fmt.Fprintln(m.out, dimText+line+reset)
+	case m.inCode:
+		fmt.Fprintln(m.out, m.highlight(line))
+	case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+		m.inCode = true
+		m.fence = trimmed[:3]
+		m.lexer = codeLexer(strings.TrimSpace(strings.TrimLeft(trimmed, m.fence[:1])))
+		fmt.Fprintln(m.out, dimText+line+reset)
+	default:
+		fmt.Fprintln(m.out, m.styleLine(line))
+	}
+}
+
+// highlight colors one line of code. Lines are tokenised on their own, so
+// constructs spanning lines (block comments, raw strings) may be off.
+func (m *markdownStream) highlight(line string) string {
+	if m.lexer == nil || m.formatter == nil || m.style == nil {
+		return line
+	}
+	it, err := m.lexer.Tokenise(nil, line)
+	if err != nil {
+		return line
+	}
+	var sb strings.Builder
+	if err := m.formatter.Format(&sb, m.style, it); err != nil {
+		return line
+	}
+	return strings.TrimRight(sb.String(), "\n") + reset
+}
+
+// codeLexer picks a lexer from the fence info string ("go", "python title=x").
+func codeLexer(info string) chroma.Lexer {
+	var l chroma.Lexer
+	if lang := strings.Fields(info); len(lang) > 0 {
+		l = lexers.Get(lang[0])
+	}
+	if l == nil {
+		l = lexers.Fallback
+	}
+	return chroma.Coalesce(l)
+}
+
+// maybeFence reports whether a partial line could still turn into a code fence.
+func maybeFence(partial string) bool {
+	t := strings.TrimLeft(partial, " ")
+	if t == "" {
+		return false
+	}
+	for _, f := range []string{"```", "~~~"} {
+		if strings.HasPrefix(f, t) || strings.HasPrefix(t, f) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	mdHeading    = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	mdBullet     = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	mdQuote      = regexp.MustCompile(`^>\s?(.*)$`)
+	mdRule       = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	mdInlineCode = regexp.MustCompile("`([^`]+)`")
+	mdBold       = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+)
+
+// styleLine applies block and inline markdown styles to one prose line.
+func (m *markdownStream) styleLine(line string) string {
+	if g := mdHeading.FindStringSubmatch(line); g != nil {
+		return cyanBold + g[2] + reset
+	}
+	if mdRule.MatchString(line) {
+		w := m.width - 2
+		if w > 40 {
+			w = 40
+		}
+		return dimText + strings.Repeat("─", w) + reset
+	}
+	if g := mdQuote.FindStringSubmatch(line); g != nil {
+		return dimText + "│ " + italic + styleInline(g[1]) + reset
+	}
+	if g := mdBullet.FindStringSubmatch(line); g != nil {
+		return g[1] + cyan + "•" + reset + " " + styleInline(g[2])
+	}
+	return styleInline(line)
+}
+
+func styleInline(s string) string {
+	s = mdInlineCode.ReplaceAllString(s, cyan+"$1"+reset)
+	return mdBold.ReplaceAllString(s, bold+"$1"+reset)
+}
+
+// ─── Collapsed Tool Output ───
+
+// collapsedToolLines is how many lines of tool output are shown inline.
+const collapsedToolLines = 3
+
+// toolOutputs keeps the tool results of the last run for /expand.
+type toolOutputs struct {
+	calls  []*entity.ToolCallEvent
+	expand bool // print full output inline (--verbose)
+}
+
+// begin starts a new run.
+func (t *toolOutputs) begin() { t.calls = t.calls[:0] }
+
+// add records a finished call and returns its 1-based number.
+func (t *toolOutputs) add(tc *entity.ToolCallEvent) int {
+	t.calls = append(t.calls, tc)
+	return len(t.calls)
+}
+
+// toolOutputText is what the user sees of a tool result.
+func toolOutputText(tc *entity.ToolCallEvent) string {
+	out := tc.Display
+	if out == "" {
+		out = tc.Output
+	}
+	return strings.TrimRight(out, "\n")
+}
+
+// printToolOutput renders the output of call n under its header, collapsed
+// to a few lines unless expand is set.
+func printToolOutput(tc *entity.ToolCallEvent, n int, width int, expand bool, loc i18n.Locale) {
+	out := toolOutputText(tc)
+	if strings.TrimSpace(out) == "" {
+		return
+	}
+	lines := strings.Split(out, "\n")
+	shown := lines
+	if !expand && len(lines) > collapsedToolLines {
+		shown = lines[:collapsedToolLines]
+	}
+	maxW := width - 6
+	if maxW < 20 {
+		maxW = 20
+	}
+	for _, l := range shown {
+		if !expand {
+			if r := []rune(l); len(r) > maxW {
+				l = string(r[:maxW]) + "…"
+			}
+		}
+		fmt.Printf("%s│%s %s%s%s\n", dimText, reset, dimText, l, reset)
+	}
+	if hidden := len(lines) - len(shown); hidden > 0 {
+		fmt.Printf("%s│ %s%s\n", dimText, loc.Tf("cli.tool.collapsed", hidden, n), reset)
+	}
+}
+
+// runExpandCommand handles /expand [n]: the full output of a tool call of the last run.
+func runExpandCommand(outputs *toolOutputs, cmd *SlashCommand, loc i18n.Locale) {
+	if len(outputs.calls) == 0 {
+		fmt.Println(loc.T("cli.expand.empty"))
+		return
+	}
+	n := len(outputs.calls)
+	if len(cmd.Args) > 0 {
+		if _, err := fmt.Sscanf(cmd.Args[0], "%d", &n); err != nil || n < 1 || n > len(outputs.calls) {
+			fmt.Println(loc.Tf("cli.expand.usage", len(outputs.calls)))
+			return
+		}
+	}
+	tc := outputs.calls[n-1]
+	w := termWidth()
+	printToolHeader(tc, w)
+	printToolOutput(tc, n, w, true, loc)
+	printToolFooter(tc, w)
+}
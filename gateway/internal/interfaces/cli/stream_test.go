@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownStream_CodeBlocksAcrossDeltas(t *testing.T) {
+	var out strings.Builder
+	md := newMarkdownStream(&out, 80, true)
+	for _, delta := range []string{"## Fix\nUse `x`", " here.\n`", "``go\nfunc main", "() {}\n``", "`\ndone"} {
+		md.Write(delta)
+	}
+	md.Flush()
+	got := out.String()
+
+	if !strings.Contains(got, cyanBold+"Fix"+reset) {
+		t.Errorf("heading not styled: %q", got)
+	}
+	if !strings.Contains(got, clearLn+"Use "+cyan+"x"+reset+" here.\n") {
+		t.Errorf("streamed prose line not restyled: %q", got)
+	}
+	if !strings.Contains(got, dimText+"```go"+reset) || strings.Contains(got, "func main() {}\n") {
+		t.Errorf("code line not highlighted: %q", got)
+	}
+	if md.inCode || !strings.HasSuffix(got, "done\n") {
+		t.Errorf("code block not closed: %q", got)
+	}
+}
+
+func TestMarkdownStream_PlainPassthrough(t *testing.T) {
+	var out strings.Builder
+	md := newMarkdownStream(&out, 80, false)
+	md.Write("# a\n```go\nx")
+	md.Flush()
+	if out.String() != "# a\n```go\nx" {
+		t.Errorf("got %q", out.String())
+	}
+}
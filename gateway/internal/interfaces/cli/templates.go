@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chzyer/readline"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/prompt"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
+)
+
+// isTemplateCommand reports whether cmd is handled by runTemplateCommand.
+func isTemplateCommand(cmd *SlashCommand) bool {
+	switch cmd.Name {
+	case "templates", "t", "template":
+		return true
+	}
+	return false
+}
+
+// runTemplateCommand handles /templates and /t <name> key=value ....
+// Missing variables are prompted for on the readline instance.
+// Returns the rendered prompt to send to the agent, or "" if nothing should run.
+func runTemplateCommand(rl *readline.Instance, store *prompt.TemplateStore, cmd *SlashCommand, rawInput string, loc i18n.Locale) string {
+	if cmd.Name == "templates" {
+		printTemplateList(store, loc)
+		return ""
+	}
+
+	if len(cmd.Args) == 0 {
+		fmt.Println(stripHTML(loc.T("template.usage")))
+		return ""
+	}
+
+	tpl, err := store.Get(cmd.Args[0])
+	if err != nil {
+		fmt.Printf("%s✗ %v%s\n", red, err, reset)
+		return ""
+	}
+
+	raw := strings.TrimSpace(strings.TrimPrefix(rawInput, "/"+cmd.Name))
+	raw = strings.TrimSpace(strings.TrimPrefix(raw, cmd.Args[0]))
+	vars, _ := prompt.ParseTemplateArgs(raw)
+
+	missing := tpl.Missing(vars)
+	if len(missing) > 0 {
+		oldPrompt := rl.Config.Prompt
+		defer rl.SetPrompt(oldPrompt)
+		for _, name := range missing {
+			rl.SetPrompt(fmt.Sprintf("%s%s%s", yellow, loc.Tf("template.ask_cli", name), reset))
+			line, err := rl.Readline()
+			if err != nil {
+				return ""
+			}
+			vars[name] = strings.TrimSpace(strings.ReplaceAll(line, newlineMarker, "\n"))
+		}
+	}
+
+	rendered := tpl.Render(vars)
+	fmt.Printf("%s📋 %s%s\n", dimText, tpl.Name, reset)
+	return rendered
+}
+
+func printTemplateList(store *prompt.TemplateStore, loc i18n.Locale) {
+	list, err := store.List()
+	if err != nil {
+		fmt.Printf("%s✗ %v%s\n", red, err, reset)
+		return
+	}
+	if len(list) == 0 {
+		fmt.Println(stripHTML(loc.Tf("template.empty", store.Dir())))
+		return
+	}
+
+	fmt.Println(cyanBold + stripHTML(loc.T("template.title")) + reset)
+	for _, t := range list {
+		fmt.Printf("  %s%-20s%s %s\n", green, t.Name, reset, t.Description)
+		if len(t.Variables) > 0 {
+			fmt.Printf("  %s%-20s {{%s}}%s\n", dimText, "", strings.Join(t.Variables, "}} {{"), reset)
+		}
+	}
+	fmt.Println()
+	fmt.Println(stripHTML(loc.T("template.usage")))
+}
+
+// stripHTML removes the simple TG HTML markup shared catalog messages use.
+func stripHTML(s string) string {
+	r := strings.NewReplacer("<b>", "", "</b>", "", "<i>", "", "</i>", "", "<code>", "", "</code>", "",
+		"&lt;", "<", "&gt;", ">", "&amp;", "&")
+	return r.Replace(s)
+}
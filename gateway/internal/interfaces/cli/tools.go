@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	toolpkg "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tool"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
+)
+
+// PrintToolCatalog lists the registered tools grouped by category (/tools,
+// ngoclaw tools list). With a category, only that group is shown, with
+// descriptions. Tools that need approval are marked 🔒 (always) or ❔ (per call).
+func PrintToolCatalog(w io.Writer, catalog *toolpkg.ToolCatalog, category string, loc i18n.Locale) {
+	header := loc.Tf("tools.title", len(catalog.Tools))
+	if catalog.ApprovalMode != "" {
+		header += loc.Tf("tools.mode", catalog.ApprovalMode)
+	}
+	fmt.Fprintln(w, cyanBold+stripHTML(header)+reset)
+
+	groups := catalog.Groups()
+	if category != "" {
+		var names []string
+		for _, g := range groups {
+			names = append(names, g.Category)
+			if g.Category != category {
+				continue
+			}
+			fmt.Fprintf(w, "\n%s%s%s\n", bold, stripHTML(toolCategoryLabel(loc, g.Category)), reset)
+			for _, t := range g.Tools {
+				fmt.Fprintf(w, "  %s%-22s%s%s %s%s%s\n", green, t.Name, reset, approvalMark(t.Approval),
+					dimText, firstLine(t.Description, 100), reset)
+			}
+			fmt.Fprintln(w, "\n"+stripHTML(loc.T("tools.legend")))
+			return
+		}
+		fmt.Fprintln(w, stripHTML(loc.Tf("tools.unknown_category", category, strings.Join(names, ", "))))
+		return
+	}
+
+	for _, g := range groups {
+		fmt.Fprintf(w, "\n%s%s%s (%d)\n", bold, stripHTML(toolCategoryLabel(loc, g.Category)), reset, len(g.Tools))
+		names := make([]string, len(g.Tools))
+		for i, t := range g.Tools {
+			names[i] = green + t.Name + reset + approvalMark(t.Approval)
+		}
+		fmt.Fprintln(w, "  "+strings.Join(names, ", "))
+	}
+	if len(catalog.Disabled) > 0 {
+		fmt.Fprintf(w, "\n%s%s%s\n", yellow, loc.Tf("tools.disabled", strings.Join(catalog.Disabled, ", ")), reset)
+	}
+	fmt.Fprintln(w, "\n"+dimText+stripHTML(loc.T("tools.legend"))+reset)
+}
+
+func toolCategoryLabel(loc i18n.Locale, category string) string {
+	key := "tools.cat." + category
+	if label := loc.T(key); label != key {
+		return label
+	}
+	return category
+}
+
+func approvalMark(approval string) string {
+	switch approval {
+	case service.ApprovalAlways:
+		return " 🔒"
+	case service.ApprovalPerCall:
+		return " ❔"
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/interfaces/http/handlers"
+	"go.uber.org/zap"
+)
+
+// apiAuth 保护 /api/ 与 /v1/ (SDK 调用、其他网关的 remote_agent 转发):
+// 来源地址白名单 + Bearer 令牌。/health、/admin (自带令牌) 与 webhook (自带签名) 不受影响。
+// 启用 OIDC 时由外层 oidcAuth 处理会话, 这里只对无会话的请求校验令牌。
+type apiAuth struct {
+	tokens map[string]string // token → 调用方名字
+	nets   []*net.IPNet
+	next   http.Handler
+	logger *zap.Logger
+}
+
+// SetAPIAuth 启用 API 鉴权 (gateway.api_tokens / gateway.api_allow_ips)，需在 Start 前调用。
+// 两者都为空时不做任何限制。
+func (s *Server) SetAPIAuth(tokens map[string]string, allowIPs []string) error {
+	if len(tokens) == 0 && len(allowIPs) == 0 {
+		return nil
+	}
+	nets, err := parseAllowIPs(allowIPs)
+	if err != nil {
+		return err
+	}
+	s.server.Handler = &apiAuth{tokens: tokens, nets: nets, next: s.server.Handler, logger: s.logger}
+	s.apiTokens = len(tokens) > 0
+	s.logger.Info("API authentication enabled",
+		zap.Int("tokens", len(tokens)),
+		zap.Int("allow_ips", len(nets)),
+	)
+	return nil
+}
+
+func parseAllowIPs(entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if !strings.Contains(e, "/") {
+			ip := net.ParseIP(e)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address %q", e)
+			}
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			e = fmt.Sprintf("%s/%d", e, bits)
+		}
+		_, n, err := net.ParseCIDR(e)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q", e)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func (a *apiAuth) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/api/") && !strings.HasPrefix(r.URL.Path, "/v1/") {
+		a.next.ServeHTTP(w, r)
+		return
+	}
+	// 只看直连地址: 反向代理后面请用代理自己的访问控制
+	host, _, _ := net.SplitHostPort(r.RemoteAddr)
+	if len(a.nets) > 0 && !a.allowed(net.ParseIP(host)) {
+		a.logger.Warn("API request from address not in api_allow_ips", zap.String("remote", host), zap.String("path", r.URL.Path))
+		writeAuthError(w, http.StatusForbidden, "address not allowed")
+		return
+	}
+	// 已通过 OIDC 登录的请求不再需要令牌
+	if len(a.tokens) > 0 && handlers.IdentityFromContext(r.Context()) == nil {
+		name, ok := a.lookup(r.Header.Get("Authorization"))
+		if !ok {
+			a.logger.Warn("API request with missing or unknown token", zap.String("remote", host), zap.String("path", r.URL.Path))
+			writeAuthError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		r = r.WithContext(service.WithTranscriptSource(r.Context(), "api:"+name))
+	}
+	a.next.ServeHTTP(w, r)
+}
+
+func (a *apiAuth) allowed(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, n := range a.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup 比较所有令牌 (常数时间), 不因提前命中泄露令牌信息
+func (a *apiAuth) lookup(header string) (string, bool) {
+	got, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || got == "" {
+		return "", false
+	}
+	name, found := "", false
+	for token, n := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+			name, found = n, true
+		}
+	}
+	return name, found
+}
+
+func writeAuthError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
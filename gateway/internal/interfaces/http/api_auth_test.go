@@ -0,0 +1,116 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/approval"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/share"
+	"go.uber.org/zap"
+)
+
+func TestAPIAuth(t *testing.T) {
+	var source string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		source = service.TranscriptSourceFromContext(r.Context())
+	})
+	nets, err := parseAllowIPs([]string{"10.0.0.0/8", "192.0.2.7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := &apiAuth{tokens: map[string]string{"tok-a": "office"}, nets: nets, next: next, logger: zap.NewNop()}
+
+	for _, tc := range []struct {
+		path, remote, header string
+		want                 int
+		source               string
+	}{
+		{"/api/v1/agent", "10.1.2.3:5000", "Bearer tok-a", 200, "api:office"},
+		{"/v1/tools", "192.0.2.7:5000", "Bearer tok-a", 200, "api:office"},
+		{"/api/v1/agent", "10.1.2.3:5000", "Bearer nope", 401, ""},
+		{"/api/v1/agent", "10.1.2.3:5000", "", 401, ""},
+		{"/api/v1/agent", "192.0.2.8:5000", "Bearer tok-a", 403, ""},
+		{"/health", "203.0.113.1:5000", "", 200, ""},
+	} {
+		source = ""
+		req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+		req.RemoteAddr = tc.remote
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+		rec := httptest.NewRecorder()
+		auth.ServeHTTP(rec, req)
+		if rec.Code != tc.want || source != tc.source {
+			t.Errorf("%s from %s (%q) = %d source %q, want %d %q", tc.path, tc.remote, tc.header, rec.Code, source, tc.want, tc.source)
+		}
+	}
+
+	if _, err := parseAllowIPs([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("invalid CIDR accepted")
+	}
+}
+
+func TestSetApprovalQueue_RequiresAPIAuth(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		tokens map[string]string
+		header string
+		want   int
+	}{
+		{"no auth", nil, "", http.StatusNotFound},
+		{"token missing", map[string]string{"tok-a": "office"}, "", http.StatusUnauthorized},
+		{"token", map[string]string{"tok-a": "office"}, "Bearer tok-a", http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewServer(Config{Mode: "production"}, nil, nil, nil, nil, zap.NewNop())
+			if err := s.SetAPIAuth(tc.tokens, nil); err != nil {
+				t.Fatal(err)
+			}
+			s.SetApprovalQueue(approval.NewQueue(time.Minute, "", zap.NewNop()))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/approvals", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rec := httptest.NewRecorder()
+			s.server.Handler.ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Errorf("GET /api/v1/approvals = %d, want %d", rec.Code, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetShare_APIRequiresAuth(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		tokens map[string]string
+		want   int
+	}{
+		{"no auth", nil, http.StatusNotFound},
+		{"token", map[string]string{"tok-a": "office"}, http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewServer(Config{Mode: "production"}, nil, nil, nil, nil, zap.NewNop())
+			if err := s.SetAPIAuth(tc.tokens, nil); err != nil {
+				t.Fatal(err)
+			}
+			store, err := share.NewStore(share.Config{Dir: t.TempDir()}, zap.NewNop())
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.SetShare(store, "")
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/runs", nil)
+			req.Header.Set("Authorization", "Bearer tok-a")
+			rec := httptest.NewRecorder()
+			s.server.Handler.ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Errorf("GET /api/v1/runs = %d, want %d", rec.Code, tc.want)
+			}
+		})
+	}
+}
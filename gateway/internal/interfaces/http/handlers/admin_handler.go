@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/approval"
+	"go.uber.org/zap"
+)
+
+//go:embed admin.html
+var adminPage []byte
+
+// AdminCookie 保存面板令牌的 cookie (EventSource 无法携带 Authorization 头)
+const AdminCookie = "ngoclaw_admin"
+
+// adminKeepAlive SSE 心跳间隔, 防止反向代理断开空闲连接
+const adminKeepAlive = 25 * time.Second
+
+// AdminSource 管理面板数据来源 (由 application 层实现)
+type AdminSource interface {
+	// Overview 活跃运行、最近错误、按会话用量、provider 健康
+	Overview(ctx context.Context) interface{}
+	// Config 脱敏后的当前配置
+	Config() interface{}
+	// Approvals 所有通道 (Telegram / HTTP) 的待审批请求
+	Approvals() []AdminApproval
+	// ResolveApproval 批准或拒绝; 未知 id 返回 approval.ErrNotFound
+	ResolveApproval(ctx context.Context, id string, approved bool) error
+	// Subscribe 实时事件流; 调用 cancel 后通道关闭
+	Subscribe() (events <-chan interface{}, cancel func())
+}
+
+// AdminApproval 待审批请求
+type AdminApproval struct {
+	ID        string    `json:"id"`
+	Channel   string    `json:"channel"` // telegram | http
+	ChatID    int64     `json:"chat_id,omitempty"`
+	Tool      string    `json:"tool"`
+	Args      string    `json:"args"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AdminHandler /admin 管理面板: 嵌入的单页 + JSON / SSE 接口
+type AdminHandler struct {
+	token  string
+	source AdminSource
+	logger *zap.Logger
+}
+
+// NewAdminHandler 创建管理面板处理器
+func NewAdminHandler(token string, source AdminSource, logger *zap.Logger) *AdminHandler {
+	return &AdminHandler{
+		token:  token,
+		source: source,
+		logger: logger,
+	}
+}
+
+// Auth 校验面板令牌: Authorization: Bearer, cookie, 或一次性的 ?token= (换成 cookie 后重定向)。
+// 已通过 OIDC 登录的请求 (角色由外层检查) 直接放行。
+func (h *AdminHandler) Auth(c *gin.Context) {
+	if IdentityFromContext(c.Request.Context()) != nil {
+		c.Next()
+		return
+	}
+	if q := c.Query("token"); q != "" && c.Request.Method == http.MethodGet {
+		if !h.validToken(q) {
+			h.logger.Warn("Admin login with invalid token", zap.String("ip", c.ClientIP()))
+			h.unauthorized(c)
+			return
+		}
+		c.SetSameSite(http.SameSiteStrictMode)
+		c.SetCookie(AdminCookie, q, int((7 * 24 * time.Hour).Seconds()), "/admin", "", c.Request.TLS != nil, true)
+		c.Redirect(http.StatusSeeOther, c.Request.URL.Path)
+		c.Abort()
+		return
+	}
+
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if token == "" || token == c.GetHeader("Authorization") {
+		token, _ = c.Cookie(AdminCookie)
+	}
+	if !h.validToken(token) {
+		h.unauthorized(c)
+		return
+	}
+	c.Next()
+}
+
+func (h *AdminHandler) validToken(token string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
+
+func (h *AdminHandler) unauthorized(c *gin.Context) {
+	if strings.HasPrefix(c.Request.URL.Path, "/admin/api/") {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	c.Data(http.StatusUnauthorized, "text/plain; charset=utf-8",
+		[]byte("401 unauthorized — open /admin?token=<gateway.admin_token>\n"))
+	c.Abort()
+}
+
+// Page 面板页面
+// GET /admin
+func (h *AdminHandler) Page(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Frame-Options", "DENY")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", adminPage)
+}
+
+// Overview 运行、错误、用量、provider 快照
+// GET /admin/api/overview
+func (h *AdminHandler) Overview(c *gin.Context) {
+	c.JSON(http.StatusOK, h.source.Overview(c.Request.Context()))
+}
+
+// Config 脱敏配置
+// GET /admin/api/config
+func (h *AdminHandler) Config(c *gin.Context) {
+	c.JSON(http.StatusOK, h.source.Config())
+}
+
+// ListApprovals 待审批请求
+// GET /admin/api/approvals
+func (h *AdminHandler) ListApprovals(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"approvals": h.source.Approvals()})
+}
+
+// Approve 批准
+// POST /admin/api/approvals/:id/approve
+func (h *AdminHandler) Approve(c *gin.Context) {
+	h.resolve(c, true)
+}
+
+// Deny 拒绝
+// POST /admin/api/approvals/:id/deny
+func (h *AdminHandler) Deny(c *gin.Context) {
+	h.resolve(c, false)
+}
+
+func (h *AdminHandler) resolve(c *gin.Context, approved bool) {
+	id := c.Param("id")
+	if err := h.source.ResolveApproval(c.Request.Context(), id, approved); err != nil {
+		if errors.Is(err, approval.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.logger.Info("Approval resolved from admin dashboard",
+		zap.String("id", id),
+		zap.Bool("approved", approved),
+		zap.String("ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, gin.H{"id": id, "approved": approved})
+}
+
+// Events 实时事件流 (SSE)
+// GET /admin/api/events
+func (h *AdminHandler) Events(c *gin.Context) {
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("X-Accel-Buffering", "no")
+	c.Writer.WriteHeader(http.StatusOK)
+
+	flusher, _ := c.Writer.(http.Flusher)
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	flush()
+
+	events, cancel := h.source.Subscribe()
+	defer cancel()
+	keepAlive := time.NewTicker(adminKeepAlive)
+	defer keepAlive.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(c.Writer, ": keep-alive\n\n")
+			flush()
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(c.Writer, "data: %s\n\n", data)
+			flush()
+		}
+	}
+}
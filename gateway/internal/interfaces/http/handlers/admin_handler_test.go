@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/approval"
+	"go.uber.org/zap"
+)
+
+type fakeAdminSource struct {
+	resolved map[string]bool
+}
+
+func (f *fakeAdminSource) Overview(ctx context.Context) interface{} { return gin.H{"ok": true} }
+func (f *fakeAdminSource) Config() interface{}                      { return gin.H{} }
+func (f *fakeAdminSource) Approvals() []AdminApproval {
+	return []AdminApproval{{ID: "req_1", Channel: "telegram", Tool: "bash"}}
+}
+func (f *fakeAdminSource) ResolveApproval(ctx context.Context, id string, approved bool) error {
+	if id != "req_1" {
+		return approval.ErrNotFound
+	}
+	f.resolved[id] = approved
+	return nil
+}
+func (f *fakeAdminSource) Subscribe() (<-chan interface{}, func()) {
+	ch := make(chan interface{})
+	return ch, func() { close(ch) }
+}
+
+func newAdminRouter(src AdminSource) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	h := NewAdminHandler("s3cret", src, zap.NewNop())
+	g := r.Group("/admin", h.Auth)
+	g.GET("", h.Page)
+	g.GET("/api/overview", h.Overview)
+	g.POST("/api/approvals/:id/approve", h.Approve)
+	return r
+}
+
+func serve(r http.Handler, method, path string, setup func(*http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if setup != nil {
+		setup(req)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdminHandler_Auth(t *testing.T) {
+	r := newAdminRouter(&fakeAdminSource{resolved: map[string]bool{}})
+
+	if w := serve(r, "GET", "/admin/api/overview", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("no token: %d", w.Code)
+	}
+	if w := serve(r, "GET", "/admin/api/overview", func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer wrong")
+	}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token: %d", w.Code)
+	}
+	if w := serve(r, "GET", "/admin/api/overview", func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer s3cret")
+	}); w.Code != http.StatusOK {
+		t.Fatalf("bearer: %d", w.Code)
+	}
+
+	// ?token= is exchanged for a cookie and stripped from the URL
+	w := serve(r, "GET", "/admin?token=s3cret", nil)
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/admin" {
+		t.Fatalf("login: %d %q", w.Code, w.Header().Get("Location"))
+	}
+	cookie := w.Result().Cookies()[0]
+	if !cookie.HttpOnly || cookie.Value != "s3cret" {
+		t.Fatalf("cookie: %+v", cookie)
+	}
+	w = serve(r, "GET", "/admin", func(req *http.Request) { req.AddCookie(cookie) })
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "NGOClaw admin") {
+		t.Fatalf("page with cookie: %d", w.Code)
+	}
+	if w := serve(r, "GET", "/admin?token=nope", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("bad login: %d", w.Code)
+	}
+}
+
+func TestAdminHandler_ResolveApproval(t *testing.T) {
+	src := &fakeAdminSource{resolved: map[string]bool{}}
+	r := newAdminRouter(src)
+	auth := func(req *http.Request) { req.Header.Set("Authorization", "Bearer s3cret") }
+
+	if w := serve(r, "POST", "/admin/api/approvals/req_1/approve", auth); w.Code != http.StatusOK || !src.resolved["req_1"] {
+		t.Fatalf("approve: %d %v", w.Code, src.resolved)
+	}
+	if w := serve(r, "POST", "/admin/api/approvals/gone/approve", auth); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown id: %d", w.Code)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -19,6 +20,7 @@ type AgentHandler struct {
 	agentLoop    *service.AgentLoop
 	toolExec     service.ToolExecutor
 	promptEngine *prompt.PromptEngine
+	output       *service.OutputPipeline
 	logger       *zap.Logger
 }
 
@@ -32,6 +34,13 @@ func NewAgentHandler(agentLoop *service.AgentLoop, toolExec service.ToolExecutor
 	}
 }
 
+// SetOutputPipeline enables agent.output post-processing of the final content.
+// While it applies to the "http" channel, text_delta events are not streamed:
+// clients get the processed answer in the done event only.
+func (h *AgentHandler) SetOutputPipeline(p *service.OutputPipeline) {
+	h.output = p
+}
+
 // AgentRequest is the JSON body for POST /api/v1/agent
 type AgentRequest struct {
 	Message      string               `json:"message" binding:"required"`
@@ -39,6 +48,10 @@ type AgentRequest struct {
 	Model        string               `json:"model,omitempty"`
 	SessionID    string               `json:"session_id,omitempty"`
 	History      []service.LLMMessage `json:"history,omitempty"`
+	// Language 回复语言 (zh/en); 空 = 跟随 message 和 history 的语言
+	Language string `json:"language,omitempty"`
+	// Filter 只流式发送部分事件 (状态栏、手机等轻量客户端)
+	Filter *service.EventFilter `json:"filter,omitempty"`
 }
 
 // SSEEvent represents a single Server-Sent Event
@@ -54,6 +67,10 @@ func (h *AgentHandler) RunAgent(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := req.Filter.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "filter: " + err.Error()})
+		return
+	}
 
 	// Set SSE headers
 	c.Writer.Header().Set("Content-Type", "text/event-stream")
@@ -63,24 +80,42 @@ func (h *AgentHandler) RunAgent(c *gin.Context) {
 	c.Writer.WriteHeader(http.StatusOK)
 
 	ctx := c.Request.Context()
+	// 由其他网关 remote_agent 转发来的任务: 记录跳数, 防止互相转发成环
+	if hops, err := strconv.Atoi(c.GetHeader(service.PeerHopsHeader)); err == nil && hops > 0 {
+		ctx = service.WithPeerHops(ctx, hops)
+	}
+
+	// Reply language: explicit, else the language of the conversation
+	lang := service.ReplyLanguage(req.Language, req.Message, req.History)
+	ctx = service.WithReplyLanguage(ctx, lang)
+	ctx = service.WithChannel(ctx, "http")
 
 	// Assemble system prompt from the prompt engine
-	systemPrompt := h.assemblePrompt(req)
+	systemPrompt := h.assemblePrompt(req, lang)
 
 	h.logger.Info("Agent request received",
 		zap.String("session", req.SessionID),
 		zap.String("model", req.Model),
 		zap.Int("history_len", len(req.History)),
 		zap.Int("prompt_chars", len(systemPrompt)),
+		zap.Int("hops", service.PeerHopsFromContext(ctx)),
 	)
 
 	// Run agent loop (returns immediately, streams events)
-	result, eventCh := h.agentLoop.Run(ctx, systemPrompt, req.Message, req.History, "")
+	result, eventCh := h.agentLoop.Run(ctx, systemPrompt, req.Message, req.History, req.Model)
 
 	// Stream events as SSE
 	flusher, _ := c.Writer.(http.Flusher)
+	buffered := h.output.Active("http")
 
 	for event := range eventCh {
+		if buffered && event.Type == entity.EventTextDelta {
+			continue
+		}
+		event, ok := req.Filter.Apply(event)
+		if !ok {
+			continue
+		}
 		sseEvent := h.convertEvent(event)
 		data, _ := json.Marshal(sseEvent)
 
@@ -90,13 +125,20 @@ func (h *AgentHandler) RunAgent(c *gin.Context) {
 		}
 	}
 
+	content, err := h.output.Process(ctx, result.FinalContent, service.OutputInfo{Channel: "http", Model: result.ModelUsed})
+	if err != nil {
+		data, _ := json.Marshal(SSEEvent{Event: "error", Data: map[string]string{"error": err.Error()}})
+		fmt.Fprintf(c.Writer, "event: error\ndata: %s\n\n", data)
+	}
+
 	// Send final result
 	finalData, _ := json.Marshal(map[string]interface{}{
-		"content":      result.FinalContent,
+		"content":      content,
 		"total_steps":  result.TotalSteps,
 		"total_tokens": result.TotalTokens,
 		"model_used":   result.ModelUsed,
 		"tools_used":   result.ToolsUsed,
+		"tests":        result.TestReport,
 	})
 	fmt.Fprintf(c.Writer, "event: done\ndata: %s\n\n", finalData)
 	if flusher != nil {
@@ -106,7 +148,7 @@ func (h *AgentHandler) RunAgent(c *gin.Context) {
 
 // assemblePrompt builds the system prompt using the PromptEngine.
 // If the request includes a custom system_prompt, it's appended.
-func (h *AgentHandler) assemblePrompt(req AgentRequest) string {
+func (h *AgentHandler) assemblePrompt(req AgentRequest, lang string) string {
 	if h.promptEngine == nil {
 		// Fallback: use request's system_prompt directly
 		return req.SystemPrompt
@@ -123,6 +165,7 @@ func (h *AgentHandler) assemblePrompt(req AgentRequest) string {
 		RegisteredTools: toolNames,
 		ModelName:       req.Model,
 		UserMessage:     req.Message,
+		Language:        lang,
 	}
 
 	// Assemble from SOUL + Components + Variants
@@ -166,6 +209,8 @@ func (h *AgentHandler) convertEvent(event entity.AgentEvent) SSEEvent {
 		return SSEEvent{Event: "tool_result", Data: event.ToolCall}
 	case entity.EventStepDone:
 		return SSEEvent{Event: "step_done", Data: event.StepInfo}
+	case entity.EventFileEdit:
+		return SSEEvent{Event: "file_edit", Data: event.Edit}
 
 	case entity.EventError:
 		return SSEEvent{Event: "error", Data: map[string]string{
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/approval"
+	"go.uber.org/zap"
+)
+
+// ApprovalHandler 待审批工具调用 API (非 Telegram 调用方)
+type ApprovalHandler struct {
+	queue  *approval.Queue
+	logger *zap.Logger
+}
+
+// NewApprovalHandler 创建审批处理器
+func NewApprovalHandler(queue *approval.Queue, logger *zap.Logger) *ApprovalHandler {
+	return &ApprovalHandler{
+		queue:  queue,
+		logger: logger,
+	}
+}
+
+// List 列出待审批请求
+// GET /api/v1/approvals
+func (h *ApprovalHandler) List(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"approvals": h.queue.List(),
+	})
+}
+
+// Approve 批准请求
+// POST /api/v1/approvals/:id/approve
+func (h *ApprovalHandler) Approve(c *gin.Context) {
+	h.resolve(c, true)
+}
+
+// Deny 拒绝请求
+// POST /api/v1/approvals/:id/deny
+func (h *ApprovalHandler) Deny(c *gin.Context) {
+	h.resolve(c, false)
+}
+
+func (h *ApprovalHandler) resolve(c *gin.Context, approved bool) {
+	id := c.Param("id")
+	if err := h.queue.Resolve(id, approved); err != nil {
+		if errors.Is(err, approval.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"id":       id,
+		"approved": approved,
+	})
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/github"
+	"go.uber.org/zap"
+)
+
+// maxWebhookBody GitHub webhook 负载上限为 25MB
+const maxWebhookBody = 25 << 20
+
+// GitHubDispatcher 接收 @提及 并异步运行 agent (由 application 层实现)
+type GitHubDispatcher interface {
+	Dispatch(m *github.Mention) error
+}
+
+// GitHubHandler GitHub webhook 入口
+type GitHubHandler struct {
+	secret     string
+	mention    string
+	dispatcher GitHubDispatcher
+	logger     *zap.Logger
+}
+
+// NewGitHubHandler 创建 GitHub webhook 处理器
+func NewGitHubHandler(secret, mention string, dispatcher GitHubDispatcher, logger *zap.Logger) *GitHubHandler {
+	return &GitHubHandler{
+		secret:     secret,
+		mention:    mention,
+		dispatcher: dispatcher,
+		logger:     logger,
+	}
+}
+
+// Webhook 处理 issue_comment / pull_request 事件. 处理耗时的 agent 运行在后台进行,
+// 这里只校验签名并入列, 以便在 GitHub 的 10 秒超时内响应.
+// POST /webhooks/github
+func (h *GitHubHandler) Webhook(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBody))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !github.VerifySignature(h.secret, body, c.GetHeader("X-Hub-Signature-256")) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+		return
+	}
+
+	event := c.GetHeader("X-GitHub-Event")
+	if event == "ping" {
+		c.JSON(http.StatusOK, gin.H{"status": "pong"})
+		return
+	}
+
+	m, err := github.ParseMention(event, body, h.mention)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if m == nil {
+		c.JSON(http.StatusOK, gin.H{"status": "ignored"})
+		return
+	}
+
+	if err := h.dispatcher.Dispatch(m); err != nil {
+		if errors.Is(err, github.ErrNotAllowed) {
+			h.logger.Info("GitHub mention rejected",
+				zap.String("repo", m.Repo),
+				zap.String("author", m.Author),
+				zap.String("association", m.Association),
+			)
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("Dispatch GitHub mention failed", zap.String("repo", m.Repo), zap.Error(err))
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("GitHub mention accepted",
+		zap.String("repo", m.Repo),
+		zap.Int("number", m.Number),
+		zap.String("author", m.Author),
+	)
+	c.JSON(http.StatusAccepted, gin.H{"status": "accepted"})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/jobqueue"
+	"go.uber.org/zap"
+)
+
+// JobHandler 异步 agent 任务提交 API (无法直接写入队列的外部系统使用)
+type JobHandler struct {
+	queue  jobqueue.Queue
+	logger *zap.Logger
+}
+
+// NewJobHandler 创建任务处理器
+func NewJobHandler(queue jobqueue.Queue, logger *zap.Logger) *JobHandler {
+	return &JobHandler{
+		queue:  queue,
+		logger: logger,
+	}
+}
+
+// EnqueueRequest POST /api/v1/jobs 请求体
+type EnqueueRequest struct {
+	ID           string            `json:"id,omitempty"` // 可选, 由调用方指定以便幂等追踪
+	Prompt       string            `json:"prompt" binding:"required"`
+	SystemPrompt string            `json:"system_prompt,omitempty"`
+	Model        string            `json:"model,omitempty"`
+	CallbackURL  string            `json:"callback_url,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// Enqueue 提交任务, 立即返回任务 ID
+// POST /api/v1/jobs
+func (h *JobHandler) Enqueue(c *gin.Context) {
+	var req EnqueueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := h.queue.Enqueue(c.Request.Context(), &jobqueue.Job{
+		ID:           req.ID,
+		Prompt:       req.Prompt,
+		SystemPrompt: req.SystemPrompt,
+		Model:        req.Model,
+		CallbackURL:  req.CallbackURL,
+		Metadata:     req.Metadata,
+	})
+	if err != nil {
+		h.logger.Error("Enqueue job failed", zap.Error(err))
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id": id,
+		"status": "queued",
+	})
+}
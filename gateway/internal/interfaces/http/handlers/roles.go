@@ -0,0 +1,75 @@
+package handlers
+
+import "context"
+
+// Role 登录用户的角色 (OIDC 组映射而来), 数值越大权限越高
+type Role int
+
+const (
+	RoleNone     Role = iota
+	RoleViewer        // 只读: 面板、事件流与 GET 接口
+	RoleOperator      // 另可发起运行、处理审批、广播
+	RoleAdmin         // 另可修改配置
+)
+
+// ParseRole 解析角色名 (admin / operator / viewer), 未知返回 RoleNone
+func ParseRole(s string) Role {
+	switch s {
+	case "admin":
+		return RoleAdmin
+	case "operator":
+		return RoleOperator
+	case "viewer":
+		return RoleViewer
+	}
+	return RoleNone
+}
+
+func (r Role) String() string {
+	switch r {
+	case RoleAdmin:
+		return "admin"
+	case RoleOperator:
+		return "operator"
+	case RoleViewer:
+		return "viewer"
+	}
+	return "none"
+}
+
+// MarshalText 角色以名字输出 (JSON)
+func (r Role) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// Identity 已登录用户 (OIDC 会话)
+type Identity struct {
+	Subject string `json:"sub"`
+	Name    string `json:"name,omitempty"`
+	Email   string `json:"email,omitempty"`
+	Role    Role   `json:"role"`
+}
+
+// DisplayName 日志与 transcript 中显示的用户名: 邮箱、姓名或 sub
+func (id *Identity) DisplayName() string {
+	switch {
+	case id.Email != "":
+		return id.Email
+	case id.Name != "":
+		return id.Name
+	}
+	return id.Subject
+}
+
+type identityKey struct{}
+
+// WithIdentity 把登录用户放进请求上下文
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFromContext 请求的登录用户; 用令牌 (admin_token / api_tokens) 或未开启鉴权时为 nil
+func IdentityFromContext(ctx context.Context) *Identity {
+	id, _ := ctx.Value(identityKey{}).(*Identity)
+	return id
+}
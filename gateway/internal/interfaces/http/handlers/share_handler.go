@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/share"
+	"go.uber.org/zap"
+)
+
+// ShareHandler 运行记录分享链接: /api/v1/runs、/api/v1/shares 创建与撤销, /share/<token> 公开只读页面
+type ShareHandler struct {
+	store   *share.Store
+	baseURL string // 空 = 按请求 Host 生成
+	logger  *zap.Logger
+}
+
+// NewShareHandler 创建分享处理器
+func NewShareHandler(store *share.Store, baseURL string, logger *zap.Logger) *ShareHandler {
+	return &ShareHandler{
+		store:   store,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		logger:  logger,
+	}
+}
+
+// CreateShareRequest POST /api/v1/shares 请求体
+type CreateShareRequest struct {
+	TraceID string `json:"trace_id,omitempty"` // 空 = 调用方最近一次运行
+	TTL     string `json:"ttl,omitempty"`      // 如 "24h"; 空 = gateway.share.ttl
+}
+
+// Runs 最近可分享的运行, 新的在前
+// GET /api/v1/runs?source=api:ci&limit=20
+func (h *ShareHandler) Runs(c *gin.Context) {
+	limit := 20
+	if n, err := strconv.Atoi(c.Query("limit")); err == nil && n > 0 {
+		limit = min(n, 200)
+	}
+	c.JSON(http.StatusOK, gin.H{"runs": h.store.Recent(c.Query("source"), limit)})
+}
+
+// Create 为一次运行生成分享链接
+// POST /api/v1/shares
+func (h *ShareHandler) Create(c *gin.Context) {
+	var req CreateShareRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		d, err := time.ParseDuration(req.TTL)
+		if err != nil || d <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ttl: " + req.TTL})
+			return
+		}
+		ttl = d
+	}
+	trace := req.TraceID
+	if trace == "" {
+		source := service.TranscriptSourceFromContext(c.Request.Context())
+		if source != "" {
+			trace = h.store.Last(source)
+		}
+		if trace == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "trace_id is required (no recent run from this caller)"})
+			return
+		}
+	}
+
+	link, err := h.store.Create(trace, ttl)
+	if errors.Is(err, share.ErrRunNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Create share failed", zap.String("trace_id", trace), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.logger.Info("Share link created",
+		zap.String("trace_id", trace),
+		zap.String("source", service.TranscriptSourceFromContext(c.Request.Context())),
+		zap.Time("expires_at", link.ExpiresAt),
+	)
+	c.JSON(http.StatusCreated, gin.H{
+		"url":        h.shareURL(c.Request, link.Token),
+		"token":      link.Token,
+		"trace_id":   link.TraceID,
+		"expires_at": link.ExpiresAt,
+	})
+}
+
+// Revoke 撤销分享链接
+// DELETE /api/v1/shares/:token
+func (h *ShareHandler) Revoke(c *gin.Context) {
+	err := h.store.Revoke(c.Param("token"))
+	if errors.Is(err, share.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Page 公开的只读页面 (无需认证, 令牌即凭据)
+// GET /share/:token
+func (h *ShareHandler) Page(c *gin.Context) {
+	header := c.Writer.Header()
+	header.Set("Cache-Control", "no-store")
+	header.Set("X-Robots-Tag", "noindex, nofollow")
+	header.Set("Referrer-Policy", "no-referrer")
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+
+	page, err := h.store.Page(c.Param("token"))
+	if err != nil {
+		c.String(http.StatusNotFound, "This share link does not exist or has expired.\n")
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+}
+
+// shareURL 分享页面的完整地址
+func (h *ShareHandler) shareURL(r *http.Request, token string) string {
+	base := h.baseURL
+	if base == "" && r != nil {
+		scheme := "http"
+		if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		base = scheme + "://" + r.Host
+	}
+	return base + "/share/" + token
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+)
+
+// ModelStatsProvider 模型调用统计来源
+type ModelStatsProvider interface {
+	Snapshot() []entity.ModelStats
+}
+
+// StatsHandler 统计 API 处理器
+type StatsHandler struct {
+	models ModelStatsProvider
+}
+
+// NewStatsHandler 创建统计处理器
+func NewStatsHandler(models ModelStatsProvider) *StatsHandler {
+	return &StatsHandler{models: models}
+}
+
+// GetModelStats 按 provider+model 返回请求数、token、延迟分位与错误分类
+// GET /v1/stats/models
+func (h *StatsHandler) GetModelStats(c *gin.Context) {
+	stats := h.models.Snapshot()
+	c.JSON(http.StatusOK, gin.H{
+		"object": "list",
+		"data":   stats,
+	})
+}
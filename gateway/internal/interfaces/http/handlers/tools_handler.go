@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	toolpkg "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tool"
+)
+
+// ToolsHandler 工具目录导出处理器
+type ToolsHandler struct {
+	catalog func() *toolpkg.ToolCatalog
+}
+
+// NewToolsHandler 创建工具目录处理器 (catalog 每次请求时读取实时注册表)
+func NewToolsHandler(catalog func() *toolpkg.ToolCatalog) *ToolsHandler {
+	return &ToolsHandler{catalog: catalog}
+}
+
+// GetTools 返回工具定义: 名称、描述、参数 JSON Schema、kind、风险等级与审批要求
+//
+//	GET /v1/tools                → {"version", "tools": [...]}
+//	GET /v1/tools?format=openapi → OpenAPI 3.1 文档
+func (h *ToolsHandler) GetTools(c *gin.Context) {
+	catalog := h.catalog()
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(http.StatusOK, catalog)
+	case "openapi":
+		c.JSON(http.StatusOK, catalog.OpenAPI())
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or openapi"})
+	}
+}
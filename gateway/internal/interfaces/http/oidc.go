@@ -0,0 +1,600 @@
+package http
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/interfaces/http/handlers"
+	"go.uber.org/zap"
+)
+
+const (
+	// sessionCookie OIDC 登录后的会话 (HMAC 签名, 不在服务端存储)
+	sessionCookie = "ngoclaw_session"
+	// loginCookie 登录过程中的 state / nonce / PKCE verifier
+	loginCookie = "ngoclaw_oidc"
+	// loginTimeout 从跳转到 IdP 到回调的最长时间
+	loginTimeout = 10 * time.Minute
+)
+
+// OIDCConfig OIDC 单点登录 (授权码流程 + PKCE)
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string // https://<host>/auth/callback
+	Scopes       []string
+	GroupsClaim  string              // ID token / userinfo 中的组字段
+	Roles        map[string][]string // admin / operator / viewer → IdP 组
+	DefaultRole  string              // 不在任何映射组中的用户; 为空则拒绝登录
+	// SessionSecret 会话签名密钥; 为空时每次启动随机生成 (重启后需重新登录)
+	SessionSecret string
+	SessionTTL    time.Duration
+}
+
+// roleRules 写操作 (非 GET/HEAD) 需要的角色, 按前缀匹配, 第一条命中生效。
+// 读操作只需 viewer。
+var roleRules = []struct {
+	prefix string
+	role   handlers.Role
+}{
+	{"/admin/api/config", handlers.RoleAdmin}, // 配置修改
+	{"/api/v1/config", handlers.RoleAdmin},
+	{"/admin/", handlers.RoleOperator}, // 审批
+	{"/api/", handlers.RoleOperator},   // 运行、审批、任务、广播
+	{"/v1/", handlers.RoleOperator},
+}
+
+// requiredRole 请求需要的最低角色
+func requiredRole(method, path string) handlers.Role {
+	if method == http.MethodGet || method == http.MethodHead {
+		return handlers.RoleViewer
+	}
+	for _, r := range roleRules {
+		if strings.HasPrefix(path, r.prefix) {
+			return r.role
+		}
+	}
+	return handlers.RoleViewer
+}
+
+// oidcAuth 为 /admin、/api/、/v1/ 提供 OIDC 登录与基于角色的访问控制,
+// 并处理 /auth/login、/auth/callback、/auth/logout、/auth/me。
+// 令牌调用方 (admin_token / api_tokens) 不受影响, 继续交给原有校验。
+type oidcAuth struct {
+	cfg         OIDCConfig
+	groupRoles  map[string]handlers.Role // IdP 组 → 角色
+	defaultRole handlers.Role
+	secret      []byte
+	tokenAuth   bool // api_tokens 已启用: 无会话但带 Bearer 的 /api/ /v1/ 请求交给令牌校验
+	adminToken  bool // admin_token 已配置: 带令牌的 /admin 请求交给面板校验
+	client      *http.Client
+	next        http.Handler
+	logger      *zap.Logger
+	now         func() time.Time
+
+	mu       sync.Mutex
+	provider *oidcProvider // discovery 结果, 首次登录时获取
+}
+
+// oidcProvider .well-known/openid-configuration 中用到的字段
+type oidcProvider struct {
+	Issuer      string `json:"issuer"`
+	AuthURL     string `json:"authorization_endpoint"`
+	TokenURL    string `json:"token_endpoint"`
+	UserInfoURL string `json:"userinfo_endpoint"`
+}
+
+// oidcSession 会话 cookie 内容
+type oidcSession struct {
+	Subject string `json:"sub"`
+	Name    string `json:"name,omitempty"`
+	Email   string `json:"email,omitempty"`
+	Role    string `json:"role"`
+	Expires int64  `json:"exp"`
+}
+
+// oidcLogin 登录 cookie 内容
+type oidcLogin struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Next     string `json:"next"`
+	Expires  int64  `json:"exp"`
+}
+
+// SetOIDC 启用 OIDC 登录 (gateway.oidc)，需在 SetAPIAuth 之后、Start 前调用
+func (s *Server) SetOIDC(cfg OIDCConfig) error {
+	if cfg.Issuer == "" {
+		return nil
+	}
+	if cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return errors.New("client_id and redirect_url are required")
+	}
+	o := &oidcAuth{
+		cfg:         cfg,
+		groupRoles:  make(map[string]handlers.Role),
+		defaultRole: handlers.ParseRole(cfg.DefaultRole),
+		secret:      []byte(cfg.SessionSecret),
+		client:      &http.Client{Timeout: 15 * time.Second},
+		next:        s.server.Handler,
+		logger:      s.logger,
+		now:         time.Now,
+	}
+	if cfg.DefaultRole != "" && o.defaultRole == handlers.RoleNone {
+		return fmt.Errorf("unknown default_role %q", cfg.DefaultRole)
+	}
+	for name, groups := range cfg.Roles {
+		role := handlers.ParseRole(name)
+		if role == handlers.RoleNone {
+			return fmt.Errorf("unknown role %q (want admin, operator or viewer)", name)
+		}
+		for _, g := range groups {
+			if role > o.groupRoles[g] {
+				o.groupRoles[g] = role
+			}
+		}
+	}
+	if o.cfg.GroupsClaim == "" {
+		o.cfg.GroupsClaim = "groups"
+	}
+	if len(o.cfg.Scopes) == 0 {
+		o.cfg.Scopes = []string{"openid", "profile", "email"}
+	}
+	if o.cfg.SessionTTL <= 0 {
+		o.cfg.SessionTTL = 12 * time.Hour
+	}
+	if len(o.secret) == 0 {
+		o.secret = make([]byte, 32)
+		if _, err := rand.Read(o.secret); err != nil {
+			return err
+		}
+		s.logger.Warn("gateway.oidc.session_secret not set; sessions end when the gateway restarts")
+	}
+	if a, ok := o.next.(*apiAuth); ok && len(a.tokens) > 0 {
+		o.tokenAuth = true
+	}
+
+	s.oidc = o
+	s.server.Handler = o
+	s.logger.Info("OIDC login enabled",
+		zap.String("issuer", cfg.Issuer),
+		zap.Int("mapped_groups", len(o.groupRoles)),
+	)
+	return nil
+}
+
+func (o *oidcAuth) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	switch path {
+	case "/auth/login":
+		o.login(w, r)
+		return
+	case "/auth/callback":
+		o.callback(w, r)
+		return
+	case "/auth/logout":
+		o.setCookie(w, r, sessionCookie, "", "/", -1)
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, "logged out")
+		return
+	case "/auth/me":
+		id := o.session(r)
+		if id == nil {
+			writeAuthError(w, http.StatusUnauthorized, "not logged in")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(id)
+		return
+	}
+
+	admin := path == "/admin" || strings.HasPrefix(path, "/admin/")
+	api := strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/v1/")
+	if !admin && !api {
+		o.next.ServeHTTP(w, r)
+		return
+	}
+
+	if id := o.session(r); id != nil {
+		if need := requiredRole(r.Method, path); id.Role < need {
+			o.logger.Warn("Request denied by role",
+				zap.String("user", id.Subject),
+				zap.String("role", id.Role.String()),
+				zap.String("method", r.Method),
+				zap.String("path", path),
+			)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "requires role " + need.String(), "role": id.Role.String()})
+			return
+		}
+		ctx := handlers.WithIdentity(r.Context(), id)
+		ctx = service.WithTranscriptSource(ctx, "oidc:"+id.DisplayName())
+		o.next.ServeHTTP(w, r.WithContext(ctx))
+		return
+	}
+
+	// 没有会话: 令牌调用方照旧, 浏览器跳转登录
+	if admin {
+		if o.adminToken && hasAdminCredentials(r) {
+			o.next.ServeHTTP(w, r)
+			return
+		}
+		if strings.HasPrefix(path, "/admin/api/") || r.Method != http.MethodGet {
+			writeAuthError(w, http.StatusUnauthorized, "login required: /auth/login")
+			return
+		}
+		http.Redirect(w, r, "/auth/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+		return
+	}
+	if o.tokenAuth && r.Header.Get("Authorization") != "" {
+		o.next.ServeHTTP(w, r)
+		return
+	}
+	writeAuthError(w, http.StatusUnauthorized, "login required (/auth/login) or bearer token")
+}
+
+// hasAdminCredentials 请求带了面板令牌 (Bearer、cookie 或 ?token=), 交给 AdminHandler.Auth 校验
+func hasAdminCredentials(r *http.Request) bool {
+	if r.Header.Get("Authorization") != "" || r.URL.Query().Get("token") != "" {
+		return true
+	}
+	_, err := r.Cookie(handlers.AdminCookie)
+	return err == nil
+}
+
+// loginNext 登录后的跳转地址: 只允许站内路径, 否则为 /admin。
+// 浏览器把 "\" 当作 "/", 所以 "/\evil.com" 与 "//evil.com" 一样会跳出站外
+func loginNext(next string) string {
+	u, err := url.Parse(next)
+	if err != nil || u.Scheme != "" || u.Host != "" ||
+		!strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.Contains(next, "\\") {
+		return "/admin"
+	}
+	return next
+}
+
+// login 跳转到 IdP 授权页
+// GET /auth/login?next=/admin
+func (o *oidcAuth) login(w http.ResponseWriter, r *http.Request) {
+	p, err := o.discover(r.Context())
+	if err != nil {
+		o.logger.Error("OIDC discovery failed", zap.Error(err))
+		http.Error(w, "identity provider unavailable", http.StatusBadGateway)
+		return
+	}
+	next := loginNext(r.URL.Query().Get("next"))
+	st := oidcLogin{
+		State:    randomString(),
+		Nonce:    randomString(),
+		Verifier: randomString() + randomString(),
+		Next:     next,
+		Expires:  o.now().Add(loginTimeout).Unix(),
+	}
+	value, err := o.sign(st)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	o.setCookie(w, r, loginCookie, value, "/auth/", int(loginTimeout.Seconds()))
+
+	challenge := sha256.Sum256([]byte(st.Verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {o.cfg.ClientID},
+		"redirect_uri":          {o.cfg.RedirectURL},
+		"scope":                 {strings.Join(o.cfg.Scopes, " ")},
+		"state":                 {st.State},
+		"nonce":                 {st.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(p.AuthURL, "?") {
+		sep = "&"
+	}
+	http.Redirect(w, r, p.AuthURL+sep+q.Encode(), http.StatusFound)
+}
+
+// callback 用授权码换取 ID token, 按组映射角色并建立会话
+// GET /auth/callback?code=...&state=...
+func (o *oidcAuth) callback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		http.Error(w, "login failed: "+e+" "+q.Get("error_description"), http.StatusUnauthorized)
+		return
+	}
+	var st oidcLogin
+	c, err := r.Cookie(loginCookie)
+	if err != nil || !o.verify(c.Value, &st) || st.Expires < o.now().Unix() || st.State != q.Get("state") {
+		http.Error(w, "login expired or state mismatch, start again at /auth/login", http.StatusBadRequest)
+		return
+	}
+	o.setCookie(w, r, loginCookie, "", "/auth/", -1)
+
+	claims, err := o.exchange(r.Context(), q.Get("code"), st)
+	if err != nil {
+		o.logger.Warn("OIDC login failed", zap.Error(err), zap.String("ip", r.RemoteAddr))
+		http.Error(w, "login failed: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	sess := oidcSession{
+		Subject: claimString(claims, "sub"),
+		Name:    claimString(claims, "name"),
+		Email:   claimString(claims, "email"),
+		Expires: o.now().Add(o.cfg.SessionTTL).Unix(),
+	}
+	role := o.roleFor(claimStrings(claims, o.cfg.GroupsClaim))
+	if role == handlers.RoleNone {
+		o.logger.Warn("OIDC login denied: no role for user's groups", zap.String("user", sess.Subject), zap.String("email", sess.Email))
+		http.Error(w, "your account is not in any group mapped to a gateway role", http.StatusForbidden)
+		return
+	}
+	sess.Role = role.String()
+	value, err := o.sign(sess)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	o.setCookie(w, r, sessionCookie, value, "/", int(o.cfg.SessionTTL.Seconds()))
+	o.logger.Info("OIDC login",
+		zap.String("user", sess.Subject),
+		zap.String("email", sess.Email),
+		zap.String("role", sess.Role),
+	)
+	http.Redirect(w, r, st.Next, http.StatusSeeOther)
+}
+
+// exchange 授权码 → token, 校验 ID token 并返回合并后的 claims (ID token + userinfo)。
+// ID token 直接从 token endpoint (TLS) 取得, 按 OIDC Core 3.1.3.7 以 TLS 代替签名校验,
+// 但 iss / aud / exp / nonce 仍逐项检查。
+func (o *oidcAuth) exchange(ctx context.Context, code string, st oidcLogin) (map[string]interface{}, error) {
+	if code == "" {
+		return nil, errors.New("missing code")
+	}
+	p, err := o.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {o.cfg.RedirectURL},
+		"client_id":     {o.cfg.ClientID},
+		"code_verifier": {st.Verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if o.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(o.cfg.ClientID), url.QueryEscape(o.cfg.ClientSecret))
+	}
+	var tok struct {
+		IDToken     string `json:"id_token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := o.doJSON(req, &tok); err != nil {
+		return nil, fmt.Errorf("token exchange: %w", err)
+	}
+
+	claims, err := decodeIDToken(tok.IDToken)
+	if err != nil {
+		return nil, err
+	}
+	if iss := claimString(claims, "iss"); iss != p.Issuer {
+		return nil, fmt.Errorf("id_token issuer %q, want %q", iss, p.Issuer)
+	}
+	if !containsString(claimStrings(claims, "aud"), o.cfg.ClientID) {
+		return nil, errors.New("id_token audience does not include client_id")
+	}
+	if exp, _ := claims["exp"].(float64); int64(exp) < o.now().Unix() {
+		return nil, errors.New("id_token expired")
+	}
+	if claimString(claims, "nonce") != st.Nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+
+	// 组不在 ID token 里时再查 userinfo
+	if _, ok := claims[o.cfg.GroupsClaim]; !ok && p.UserInfoURL != "" && tok.AccessToken != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.UserInfoURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+tok.AccessToken)
+		var info map[string]interface{}
+		if err := o.doJSON(req, &info); err != nil {
+			return nil, fmt.Errorf("userinfo: %w", err)
+		}
+		if claimString(info, "sub") == claimString(claims, "sub") {
+			for k, v := range info {
+				if _, ok := claims[k]; !ok {
+					claims[k] = v
+				}
+			}
+		}
+	}
+	return claims, nil
+}
+
+// discover 读取并缓存 IdP 的 discovery 文档
+func (o *oidcAuth) discover(ctx context.Context) (*oidcProvider, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.provider != nil {
+		return o.provider, nil
+	}
+	issuer := strings.TrimSuffix(o.cfg.Issuer, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var p oidcProvider
+	if err := o.doJSON(req, &p); err != nil {
+		return nil, fmt.Errorf("discovery: %w", err)
+	}
+	if strings.TrimSuffix(p.Issuer, "/") != issuer || p.AuthURL == "" || p.TokenURL == "" {
+		return nil, fmt.Errorf("discovery: bad document for issuer %q", issuer)
+	}
+	o.provider = &p
+	return o.provider, nil
+}
+
+func (o *oidcAuth) doJSON(req *http.Request, out interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
+
+// roleFor 用户所在组映射到的最高角色, 没有命中时为 default_role
+func (o *oidcAuth) roleFor(groups []string) handlers.Role {
+	role := handlers.RoleNone
+	for _, g := range groups {
+		if r := o.groupRoles[g]; r > role {
+			role = r
+		}
+	}
+	if role == handlers.RoleNone {
+		return o.defaultRole
+	}
+	return role
+}
+
+// session 校验会话 cookie, 无效或过期返回 nil
+func (o *oidcAuth) session(r *http.Request) *handlers.Identity {
+	c, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil
+	}
+	var s oidcSession
+	if !o.verify(c.Value, &s) || s.Expires < o.now().Unix() {
+		return nil
+	}
+	role := handlers.ParseRole(s.Role)
+	if role == handlers.RoleNone {
+		return nil
+	}
+	return &handlers.Identity{Subject: s.Subject, Name: s.Name, Email: s.Email, Role: role}
+}
+
+// sign 编码为 base64(json).base64(hmac)
+func (o *oidcAuth) sign(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(o.mac(payload)), nil
+}
+
+func (o *oidcAuth) verify(value string, v interface{}) bool {
+	payload, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return false
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, o.mac(payload)) {
+		return false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	return err == nil && json.Unmarshal(data, v) == nil
+}
+
+func (o *oidcAuth) mac(payload string) []byte {
+	m := hmac.New(sha256.New, o.secret)
+	m.Write([]byte(payload))
+	return m.Sum(nil)
+}
+
+func (o *oidcAuth) setCookie(w http.ResponseWriter, r *http.Request, name, value, path string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil || strings.HasPrefix(o.cfg.RedirectURL, "https://"),
+		SameSite: http.SameSiteLaxMode, // IdP 回调是跨站的顶层跳转
+	})
+}
+
+// decodeIDToken 取出 JWT 的 payload
+func decodeIDToken(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("missing or malformed id_token")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("id_token payload: %w", err)
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return nil, fmt.Errorf("id_token payload: %w", err)
+	}
+	return claims, nil
+}
+
+func claimString(claims map[string]interface{}, key string) string {
+	s, _ := claims[key].(string)
+	return s
+}
+
+// claimStrings 字符串或字符串数组形式的 claim (aud、groups)
+func claimStrings(claims map[string]interface{}, key string) []string {
+	switch v := claims[key].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, x := range v {
+			if s, ok := x.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
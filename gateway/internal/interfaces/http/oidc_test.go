@@ -0,0 +1,224 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/interfaces/http/handlers"
+	"go.uber.org/zap"
+)
+
+// fakeIdP is a minimal OIDC provider: discovery, token endpoint (checks PKCE)
+// and an ID token carrying the groups given to issue.
+type fakeIdP struct {
+	srv    *httptest.Server
+	groups []string
+	nonce  string
+	chall  string
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	idp := &fakeIdP{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.srv.URL,
+			"authorization_endpoint": idp.srv.URL + "/authorize",
+			"token_endpoint":         idp.srv.URL + "/token",
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if id != "gateway" || secret != "s3cret" || r.FormValue("code") != "good-code" ||
+			base64.RawURLEncoding.EncodeToString(sum[:]) != idp.chall {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		claims, _ := json.Marshal(map[string]interface{}{
+			"iss":    idp.srv.URL,
+			"aud":    []string{"gateway"},
+			"sub":    "u-42",
+			"email":  "ana@example.com",
+			"exp":    time.Now().Add(time.Hour).Unix(),
+			"nonce":  idp.nonce,
+			"groups": idp.groups,
+		})
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"id_token": "e30." + base64.RawURLEncoding.EncodeToString(claims) + ".sig",
+		})
+	})
+	idp.srv = httptest.NewServer(mux)
+	t.Cleanup(idp.srv.Close)
+	return idp
+}
+
+// login runs /auth/login → IdP → /auth/callback and returns the callback response.
+func (idp *fakeIdP) login(t *testing.T, h http.Handler, groups ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	idp.groups = groups
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/auth/login?next=/admin", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("/auth/login = %d: %s", rec.Code, rec.Body)
+	}
+	authURL, _ := url.Parse(rec.Header().Get("Location"))
+	q := authURL.Query()
+	if authURL.Path != "/authorize" || q.Get("client_id") != "gateway" || q.Get("code_challenge_method") != "S256" {
+		t.Fatalf("authorize redirect = %s", authURL)
+	}
+	idp.nonce, idp.chall = q.Get("nonce"), q.Get("code_challenge")
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/callback?code=good-code&state="+url.QueryEscape(q.Get("state")), nil)
+	for _, c := range rec.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func sessionFrom(rec *httptest.ResponseRecorder) *http.Cookie {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == sessionCookie && c.MaxAge > 0 {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestOIDC_LoginAndRoles(t *testing.T) {
+	idp := newFakeIdP(t)
+	var got *handlers.Identity
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = handlers.IdentityFromContext(r.Context())
+	})
+	s := &Server{server: &http.Server{Handler: next}, logger: zap.NewNop()}
+	err := s.SetOIDC(OIDCConfig{
+		Issuer:       idp.srv.URL,
+		ClientID:     "gateway",
+		ClientSecret: "s3cret",
+		RedirectURL:  "http://gw/auth/callback",
+		Roles: map[string][]string{
+			"admin":    {"ops-leads"},
+			"operator": {"ops"},
+			"viewer":   {"eng", "ops"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := s.server.Handler
+
+	// no session: the dashboard redirects to login, the API refuses
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/auth/login?next=%2Fadmin" {
+		t.Errorf("/admin without session = %d %s", rec.Code, rec.Header().Get("Location"))
+	}
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/agent", nil)
+	req.Header.Set("Authorization", "Bearer anything")
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("/api without session or api_tokens = %d", rec.Code)
+	}
+
+	// a user in no mapped group is refused at the callback
+	if rec := idp.login(t, h, "sales"); rec.Code != http.StatusForbidden || sessionFrom(rec) != nil {
+		t.Errorf("unmapped user callback = %d", rec.Code)
+	}
+
+	for _, tc := range []struct {
+		groups        []string
+		role          handlers.Role
+		read, approve int
+	}{
+		{[]string{"eng"}, handlers.RoleViewer, 200, 403},
+		{[]string{"eng", "ops"}, handlers.RoleOperator, 200, 200},
+		{[]string{"ops-leads"}, handlers.RoleAdmin, 200, 200},
+	} {
+		rec := idp.login(t, h, tc.groups...)
+		cookie := sessionFrom(rec)
+		if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/admin" || cookie == nil {
+			t.Fatalf("callback for %v = %d %s", tc.groups, rec.Code, rec.Body)
+		}
+
+		for _, step := range []struct {
+			method, path string
+			want         int
+		}{
+			{http.MethodGet, "/admin/api/overview", tc.read},
+			{http.MethodPost, "/admin/api/approvals/a1/approve", tc.approve},
+		} {
+			got = nil
+			req := httptest.NewRequest(step.method, step.path, nil)
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != step.want {
+				t.Errorf("%v: %s %s = %d, want %d", tc.groups, step.method, step.path, rec.Code, step.want)
+			}
+			if step.want == 200 && (got == nil || got.Role != tc.role || got.Email != "ana@example.com") {
+				t.Errorf("%v: identity = %+v, want role %s", tc.groups, got, tc.role)
+			}
+		}
+	}
+
+	// a tampered session is ignored
+	cookie := sessionFrom(idp.login(t, h, "eng"))
+	_, sig, _ := strings.Cut(cookie.Value, ".")
+	forged, _ := json.Marshal(oidcSession{Subject: "u-42", Role: "admin", Expires: time.Now().Add(time.Hour).Unix()})
+	cookie.Value = base64.RawURLEncoding.EncodeToString(forged) + "." + sig
+	req = httptest.NewRequest(http.MethodGet, "/admin/api/overview", nil)
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("forged session = %d", rec.Code)
+	}
+}
+
+func TestRequiredRole(t *testing.T) {
+	for _, tc := range []struct {
+		method, path string
+		want         handlers.Role
+	}{
+		{http.MethodGet, "/admin/api/config", handlers.RoleViewer},
+		{http.MethodPut, "/admin/api/config", handlers.RoleAdmin},
+		{http.MethodPost, "/api/v1/approvals/x/deny", handlers.RoleOperator},
+		{http.MethodPost, "/v1/chat/completions", handlers.RoleOperator},
+		{http.MethodGet, "/v1/models", handlers.RoleViewer},
+	} {
+		if got := requiredRole(tc.method, tc.path); got != tc.want {
+			t.Errorf("requiredRole(%s %s) = %s, want %s", tc.method, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestLoginNext(t *testing.T) {
+	for _, tc := range []struct {
+		next, want string
+	}{
+		{"/admin/runs?id=3", "/admin/runs?id=3"},
+		{"/", "/"},
+		{"", "/admin"},
+		{"admin", "/admin"},
+		{"//evil.com", "/admin"},
+		{"/\\evil.com", "/admin"},
+		{"/\\/evil.com", "/admin"},
+		{"https://evil.com/admin", "/admin"},
+		{"/\t/evil.com", "/admin"},
+	} {
+		if got := loginNext(tc.next); got != tc.want {
+			t.Errorf("loginNext(%q) = %q, want %q", tc.next, got, tc.want)
+		}
+	}
+}
@@ -9,15 +9,23 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/application/usecase"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/approval"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/jobqueue"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/prompt"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/share"
+	toolpkg "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tool"
 	"github.com/ngoclaw/ngoclaw/gateway/internal/interfaces/http/handlers"
 	"go.uber.org/zap"
 )
 
 // Server HTTP服务器
 type Server struct {
-	server *http.Server
-	logger *zap.Logger
+	server       *http.Server
+	router       *gin.Engine
+	agentHandler *handlers.AgentHandler
+	oidc         *oidcAuth // SetOIDC 启用后非 nil
+	apiTokens    bool      // SetAPIAuth 配置了令牌
+	logger       *zap.Logger
 }
 
 // Config HTTP服务器配置
@@ -60,11 +68,123 @@ func NewServer(cfg Config, uc *usecase.ProcessMessageUseCase, agentLoop *service
 	}
 
 	return &Server{
-		server: server,
-		logger: logger,
+		server:       server,
+		router:       router,
+		agentHandler: agentHandler,
+		logger:       logger,
 	}
 }
 
+// SetOutputPipeline 为 /api/v1/agent 启用最终回复后处理 (agent.output)，需在 Start 前调用
+func (s *Server) SetOutputPipeline(p *service.OutputPipeline) {
+	if s.agentHandler != nil {
+		s.agentHandler.SetOutputPipeline(p)
+	}
+}
+
+// SetModelStats 注册模型统计接口 (GET /v1/stats/models)，需在 Start 前调用
+func (s *Server) SetModelStats(provider handlers.ModelStatsProvider) {
+	if provider == nil {
+		return
+	}
+	h := handlers.NewStatsHandler(provider)
+	s.router.GET("/v1/stats/models", h.GetModelStats)
+}
+
+// SetToolCatalog 注册工具目录导出 (GET /v1/tools, ?format=openapi)，需在 Start 前调用
+func (s *Server) SetToolCatalog(catalog func() *toolpkg.ToolCatalog) {
+	if catalog == nil {
+		return
+	}
+	h := handlers.NewToolsHandler(catalog)
+	s.router.GET("/v1/tools", h.GetTools)
+}
+
+// APIAuthEnabled /api/ 的调用方是否需要凭证 (api_tokens 或 OIDC 登录)
+func (s *Server) APIAuthEnabled() bool {
+	return s.apiTokens || s.oidc != nil
+}
+
+// SetApprovalQueue 注册待审批 API (/api/v1/approvals)，需在 SetAPIAuth、SetOIDC 之后、Start 前调用。
+// API 未启用鉴权时不注册: 否则被审批的匿名调用方可以自己批准自己的调用
+func (s *Server) SetApprovalQueue(queue *approval.Queue) {
+	if queue == nil {
+		return
+	}
+	if !s.APIAuthEnabled() {
+		s.logger.Warn("Approval API not mounted: /api/v1 has no authentication (set gateway.api_tokens or gateway.oidc)")
+		return
+	}
+	h := handlers.NewApprovalHandler(queue, s.logger)
+	g := s.router.Group("/api/v1/approvals")
+	g.GET("", h.List)
+	g.POST("/:id/approve", h.Approve)
+	g.POST("/:id/deny", h.Deny)
+}
+
+// SetJobQueue 注册异步任务提交 API (/api/v1/jobs)，需在 Start 前调用
+func (s *Server) SetJobQueue(queue jobqueue.Queue) {
+	if queue == nil {
+		return
+	}
+	h := handlers.NewJobHandler(queue, s.logger)
+	s.router.POST("/api/v1/jobs", h.Enqueue)
+}
+
+// SetGitHubWebhook 注册 GitHub webhook 入口 (/webhooks/github)，需在 Start 前调用
+func (s *Server) SetGitHubWebhook(secret, mention string, dispatcher handlers.GitHubDispatcher) {
+	if dispatcher == nil {
+		return
+	}
+	h := handlers.NewGitHubHandler(secret, mention, dispatcher, s.logger)
+	s.router.POST("/webhooks/github", h.Webhook)
+}
+
+// SetTelegramWebhook 注册 Telegram webhook 入口 (path 通常为 /webhooks/telegram)，需在 Start 前调用
+func (s *Server) SetTelegramWebhook(path string, h http.Handler) {
+	if h == nil {
+		return
+	}
+	s.router.POST(path, gin.WrapH(h))
+}
+
+// SetShare 注册分享链接 API (/api/v1/runs、/api/v1/shares) 与公开页面 /share/:token，
+// 需在 SetAPIAuth、SetOIDC 之后、Start 前调用。API 未启用鉴权时只注册公开页面
+// (链接由 Telegram /share 生成): 否则任何人都能列出运行记录并分享别人的转录
+func (s *Server) SetShare(store *share.Store, baseURL string) {
+	if store == nil {
+		return
+	}
+	h := handlers.NewShareHandler(store, baseURL, s.logger)
+	s.router.GET("/share/:token", h.Page)
+	if !s.APIAuthEnabled() {
+		s.logger.Warn("Share API not mounted: /api/v1 has no authentication (set gateway.api_tokens or gateway.oidc)")
+		return
+	}
+	s.router.GET("/api/v1/runs", h.Runs)
+	s.router.POST("/api/v1/shares", h.Create)
+	s.router.DELETE("/api/v1/shares/:token", h.Revoke)
+}
+
+// SetAdmin 注册 /admin 管理面板 (令牌为空且未启用 OIDC 则不注册)，需在 SetOIDC 之后、Start 前调用
+func (s *Server) SetAdmin(token string, source handlers.AdminSource) {
+	if (token == "" && s.oidc == nil) || source == nil {
+		return
+	}
+	if s.oidc != nil {
+		s.oidc.adminToken = token != ""
+	}
+	h := handlers.NewAdminHandler(token, source, s.logger)
+	g := s.router.Group("/admin", h.Auth)
+	g.GET("", h.Page)
+	g.GET("/api/overview", h.Overview)
+	g.GET("/api/config", h.Config)
+	g.GET("/api/events", h.Events)
+	g.GET("/api/approvals", h.ListApprovals)
+	g.POST("/api/approvals/:id/approve", h.Approve)
+	g.POST("/api/approvals/:id/deny", h.Deny)
+}
+
 // Start 启动服务器
 func (s *Server) Start(ctx context.Context) error {
 	s.logger.Info("Starting HTTP server", zap.String("address", s.server.Addr))
@@ -126,6 +246,10 @@ func ginLogger(logger *zap.Logger) gin.HandlerFunc {
 		start := time.Now()
 		path := c.Request.URL.Path
 		query := c.Request.URL.RawQuery
+		if q := c.Request.URL.Query(); q.Has("token") {
+			q.Set("token", "***") // /admin?token= 登录
+			query = q.Encode()
+		}
 
 		c.Next()
 
@@ -3,12 +3,17 @@ package telegram
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
 	"go.uber.org/zap"
 )
 
@@ -16,12 +21,16 @@ import (
 type Config struct {
 	BotToken       string
 	AllowedUserIDs []int64
-	WebhookURL     string // 可选，留空则使用 polling
+	Webhook        bool   // true = 更新由网关 WebhookPath 推送, 否则 polling
+	WebhookURL     string // 可选, webhook 模式启动时向 Telegram 注册的地址
+	WebhookSecret  string // 可选, 校验推送的 X-Telegram-Bot-Api-Secret-Token
 	Debug          bool
 	// 策略配置
 	DMPolicy       string   // open / allowlist / disabled
 	GroupPolicy    string   // open / allowlist / disabled
 	GroupAllowFrom []string // 允许的群组 ID 列表
+	// 超长回复 (多于 ReplyDocumentThreshold 段) 额外附上完整 reply.md
+	LongReplyDocument bool
 }
 
 
@@ -39,7 +48,13 @@ type Adapter struct {
 	inlineHandler   *InlineHandler
 	mu              sync.RWMutex
 	pendingApproval map[string]*ApprovalRequest
+	localeResolver  func(chatID int64) i18n.Locale
 	cancel          context.CancelFunc
+	webhookUpdates  chan tgbotapi.Update // webhook 模式: WebhookHandler → Start
+	// 每个 chat 最近一次用户操作 (消息/按钮), 用于判断用户是否已离开
+	lastActivity sync.Map // map[int64]time.Time
+	// 接收敏感事件通知的控制群, nil = 未配置
+	control *controlChat
 }
 
 // MessageHandler 消息处理器接口
@@ -64,8 +79,9 @@ type RunController interface {
 
 // ReactionHandler 表情反应处理器接口
 type ReactionHandler interface {
-	// HandleReaction 处理用户对消息的表情反应
+	// HandleReaction 处理用户对消息的表情反应 (chatID 为真实 chatID, 反应更新不带话题)
 	// action: "save_memory" | "retry" | "regenerate" | "pin"
+	// 不处理的 action / 未知消息返回 ErrReactionIgnored, 不发送确认
 	HandleReaction(ctx context.Context, chatID int64, messageID int, action string) error
 }
 
@@ -107,7 +123,15 @@ type ApprovalRequest struct {
 
 // NewAdapter 创建 Telegram 适配器
 func NewAdapter(config *Config, logger *zap.Logger) (*Adapter, error) {
-	bot, err := tgbotapi.NewBotAPI(config.BotToken)
+	// webhook 路径对公网开放, 没有 secret 时任何人都能伪造白名单用户的更新
+	if config.Webhook && config.WebhookSecret == "" {
+		return nil, fmt.Errorf("telegram.webhook_secret is required in webhook mode")
+	}
+
+	// topicClient: 论坛话题映射为独立会话键 (见 topics.go)
+	// reactionClient: 取出 tgbotapi 不支持的表情反应更新 (见 reactions.go)
+	reactions := &reactionClient{base: &topicClient{base: &http.Client{}}}
+	bot, err := tgbotapi.NewBotAPIWithClient(config.BotToken, tgbotapi.APIEndpoint, reactions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bot: %w", err)
 	}
@@ -123,7 +147,9 @@ func NewAdapter(config *Config, logger *zap.Logger) (*Adapter, error) {
 		config:          config,
 		logger:          logger,
 		pendingApproval: make(map[string]*ApprovalRequest),
+		webhookUpdates:  make(chan tgbotapi.Update, bot.Buffer),
 	}
+	reactions.handle = adapter.onReaction
 
 	// Initialize inbound buffer — handler will be set when messageHandler is wired
 	adapter.inboundBuffer = NewInboundBuffer(func(ctx context.Context, msg *IncomingMessage) {
@@ -152,6 +178,7 @@ func (a *Adapter) SetRunController(ctrl RunController) {
 func (a *Adapter) Start(ctx context.Context) error {
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
+	u.AllowedUpdates = allowedUpdates
 
 	// 创建可取消的 context
 	innerCtx, cancel := context.WithCancel(ctx)
@@ -162,6 +189,11 @@ func (a *Adapter) Start(ctx context.Context) error {
 		a.logger.Warn("Failed to setup bot commands", zap.Error(err))
 	}
 
+	if a.config.Webhook {
+		a.startWebhook(innerCtx)
+		return nil
+	}
+
 	updates := a.bot.GetUpdatesChan(u)
 
 	a.logger.Info("Starting Telegram polling")
@@ -182,28 +214,22 @@ func (a *Adapter) Start(ctx context.Context) error {
 	return nil
 }
 
-// SetupBotCommands 设置 Bot 命令菜单
+// SetupBotCommands 设置 Bot 命令菜单 (由命令定义生成, 每种界面语言一份)
 func (a *Adapter) SetupBotCommands() error {
-	commands := []tgbotapi.BotCommand{
-		{Command: "new", Description: "✨ 新对话"},
-		{Command: "stop", Description: "⏹ 停止运行"},
-		{Command: "models", Description: "🤖 切换模型"},
-		{Command: "status", Description: "📊 当前状态"},
-		{Command: "think", Description: "🧠 思考级别"},
-		{Command: "compact", Description: "⚙️ 压缩上下文"},
-		{Command: "security", Description: "🔒 安全策略"},
-		{Command: "skills", Description: "🎯 技能管理"},
-		{Command: "plan", Description: "📝 查看计划"},
-		{Command: "help", Description: "❓ 帮助"},
-	}
-
-	config := tgbotapi.NewSetMyCommands(commands...)
-	_, err := a.bot.Request(config)
-	if err != nil {
-		return fmt.Errorf("failed to set bot commands: %w", err)
+	if a.commandRegistry == nil {
+		return nil
+	}
+	for _, loc := range i18n.Supported() {
+		commands := a.commandRegistry.BotCommands(loc)
+		config := tgbotapi.NewSetMyCommands(commands...)
+		if loc != i18n.Default {
+			config = tgbotapi.NewSetMyCommandsWithScopeAndLanguage(tgbotapi.NewBotCommandScopeDefault(), string(loc), commands...)
+		}
+		if _, err := a.bot.Request(config); err != nil {
+			return fmt.Errorf("failed to set bot commands (%s): %w", loc, err)
+		}
+		a.logger.Info("Bot commands menu configured", zap.String("lang", string(loc)), zap.Int("count", len(commands)))
 	}
-
-	a.logger.Info("Bot commands menu configured", zap.Int("count", len(commands)))
 	return nil
 }
 
@@ -222,6 +248,19 @@ func (a *Adapter) CreateStagedReply(chatID int64) *StagedReply {
 }
 
 
+// SetLocaleResolver 设置按 chatID 解析界面语言的函数 (审批卡片等)
+func (a *Adapter) SetLocaleResolver(fn func(chatID int64) i18n.Locale) {
+	a.localeResolver = fn
+}
+
+// localeFor 返回指定 chat 的界面语言
+func (a *Adapter) localeFor(chatID int64) i18n.Locale {
+	if a.localeResolver != nil && chatID != 0 {
+		return a.localeResolver(chatID)
+	}
+	return i18n.Default
+}
+
 // Stop 停止适配器
 func (a *Adapter) Stop() {
 	if a.cancel != nil {
@@ -233,6 +272,9 @@ func (a *Adapter) Stop() {
 func (a *Adapter) handleUpdate(ctx context.Context, update tgbotapi.Update) {
 	// 处理回调查询 (审批按钮 / 命令回调)
 	if update.CallbackQuery != nil {
+		if update.CallbackQuery.Message != nil {
+			a.lastActivity.Store(update.CallbackQuery.Message.Chat.ID, time.Now())
+		}
 		a.handleCallback(ctx, update.CallbackQuery)
 		return
 	}
@@ -255,6 +297,7 @@ func (a *Adapter) handleUpdate(ctx context.Context, update tgbotapi.Update) {
 	if update.Message == nil {
 		return
 	}
+	a.lastActivity.Store(update.Message.Chat.ID, time.Now())
 
 	msg := update.Message
 
@@ -272,6 +315,7 @@ func (a *Adapter) handleUpdate(ctx context.Context, update tgbotapi.Update) {
 
 
 	// 先检查是否是命令
+	text := msg.Text
 	if cmd := ParseCommand(msg.Text); cmd != nil {
 		cmd.ChatID = msg.Chat.ID
 		cmd.UserID = msg.From.ID
@@ -291,13 +335,32 @@ func (a *Adapter) handleUpdate(ctx context.Context, update tgbotapi.Update) {
 				if response != nil {
 					a.SendMessage(response)
 				}
-				return
+				if cmd.AgentPrompt == "" {
+					return
+				}
+				text = cmd.AgentPrompt
 			}
 		}
 
-		a.logger.Debug("Unknown command, treating as message",
-			zap.String("command", cmd.Name),
-		)
+		if text == msg.Text {
+			a.logger.Debug("Unknown command, treating as message",
+				zap.String("command", cmd.Name),
+			)
+		}
+	}
+
+	// 等待中的交互输入 (如 /t 模板缺失变量)
+	if a.commandRegistry != nil && text == msg.Text && !strings.HasPrefix(text, "/") {
+		reply, agentPrompt, handled := a.commandRegistry.InterceptText(msg.Chat.ID, text)
+		if handled {
+			if reply != nil {
+				a.SendMessage(reply)
+			}
+			if agentPrompt == "" {
+				return
+			}
+			text = agentPrompt
+		}
 	}
 
 	// 转换消息
@@ -306,7 +369,7 @@ func (a *Adapter) handleUpdate(ctx context.Context, update tgbotapi.Update) {
 		ChatID:    msg.Chat.ID,
 		UserID:    msg.From.ID,
 		Username:  msg.From.UserName,
-		Text:      msg.Text,
+		Text:      text,
 		Timestamp: time.Unix(int64(msg.Date), 0),
 	}
 
@@ -380,31 +443,54 @@ func (a *Adapter) handleCallback(ctx context.Context, callback *tgbotapi.Callbac
 	}
 	a.mu.Unlock()
 
+	chatID := int64(0)
+	if callback.Message != nil {
+		chatID = callback.Message.Chat.ID
+	}
+	loc := a.localeFor(chatID)
+
 	if !exists {
 		// 请求已过期或已处理
-		a.bot.Send(tgbotapi.NewCallback(callback.ID, "请求已过期"))
+		a.bot.Send(tgbotapi.NewCallback(callback.ID, loc.T("approval.expired")))
 		return
 	}
 
 	approved := action == "approve"
 
 	// 回复回调
-	var callbackText string
+	callbackText := loc.T("approval.denied")
 	if approved {
-		callbackText = "✅ 已批准"
-	} else {
-		callbackText = "❌ 已拒绝"
+		callbackText = loc.T("approval.approved")
 	}
 	a.bot.Send(tgbotapi.NewCallback(callback.ID, callbackText))
 
+	var userID int64
+	if callback.From != nil {
+		userID = callback.From.ID
+	}
+	a.finishApproval(ctx, request, approved, userID)
+}
+
+// finishApproval 更新审批消息并唤醒等待的 RequestApproval (request 已从 pendingApproval 移除);
+// userID 为点击按钮的用户, 0 = Telegram 之外 (/admin 面板)
+func (a *Adapter) finishApproval(ctx context.Context, request *ApprovalRequest, approved bool, userID int64) {
+	loc := a.localeFor(request.ChatID)
+	status := loc.T("approval.denied")
+	if approved {
+		status = loc.T("approval.approved")
+	}
+
 	// 更新原消息
 	editMsg := tgbotapi.NewEditMessageText(
 		request.ChatID,
 		request.MessageID,
-		fmt.Sprintf("工具调用: `%s`\n状态: %s", request.ToolName, callbackText),
+		loc.Tf("approval.status", request.ToolName, status),
 	)
 	editMsg.ParseMode = "Markdown"
 	a.bot.Send(editMsg)
+	if !approved {
+		a.Audit(AuditApproval, request.ChatID, userID, "audit.approval_denied", request.ToolName)
+	}
 
 	// 通知等待的协程
 	if request.ResponseChan != nil {
@@ -414,10 +500,68 @@ func (a *Adapter) handleCallback(ctx context.Context, callback *tgbotapi.Callbac
 
 	// 调用审批处理器
 	if a.approvalHandler != nil {
-		a.approvalHandler.HandleApproval(ctx, requestID, approved)
+		a.approvalHandler.HandleApproval(ctx, request.ID, approved)
 	}
 }
 
+// ErrApprovalNotFound 审批请求不存在 (已处理或已超时)
+var ErrApprovalNotFound = errors.New("approval request not found")
+
+// PendingApprovals 返回等待用户点击的审批请求 (按创建时间排序, 不含响应通道)
+func (a *Adapter) PendingApprovals() []ApprovalRequest {
+	a.mu.RLock()
+	list := make([]ApprovalRequest, 0, len(a.pendingApproval))
+	for _, r := range a.pendingApproval {
+		cp := *r
+		cp.ResponseChan = nil
+		list = append(list, cp)
+	}
+	a.mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list
+}
+
+// ResolveApproval 在 Telegram 之外 (如 /admin 面板) 批准或拒绝一个审批请求
+func (a *Adapter) ResolveApproval(ctx context.Context, requestID string, approved bool) error {
+	a.mu.Lock()
+	request, exists := a.pendingApproval[requestID]
+	if exists {
+		delete(a.pendingApproval, requestID)
+	}
+	a.mu.Unlock()
+	if !exists {
+		return ErrApprovalNotFound
+	}
+	a.finishApproval(ctx, request, approved, 0)
+	return nil
+}
+
+// ReapApprovals 清理超过 maxAge 仍未处理的审批请求, 返回清理数量.
+// 运行被取消时 RequestApproval 直接返回, 请求会一直留在 pendingApproval 中
+// (/admin 面板也会一直显示); 原消息更新为已超时
+func (a *Adapter) ReapApprovals(maxAge time.Duration) int {
+	cutoff := time.Now().Add(-maxAge)
+	var stale []*ApprovalRequest
+	a.mu.Lock()
+	for id, r := range a.pendingApproval {
+		if r.CreatedAt.Before(cutoff) {
+			stale = append(stale, r)
+			delete(a.pendingApproval, id)
+		}
+	}
+	a.mu.Unlock()
+
+	for _, r := range stale {
+		loc := a.localeFor(r.ChatID)
+		editMsg := tgbotapi.NewEditMessageText(r.ChatID, r.MessageID,
+			loc.Tf("approval.status", r.ToolName, loc.T("approval.timed_out")))
+		editMsg.ParseMode = "Markdown"
+		a.bot.Send(editMsg)
+		a.Audit(AuditApproval, r.ChatID, 0, "audit.approval_timeout", r.ToolName)
+	}
+	return len(stale)
+}
+
 // handleCommandCallback 处理命令回调（内联按钮触发命令）
 func (a *Adapter) handleCommandCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
 	data := callback.Data
@@ -493,15 +637,17 @@ func (a *Adapter) RequestApproval(ctx context.Context, chatID int64, toolName st
 	}
 
 	// 构建内联键盘
+	loc := a.localeFor(chatID)
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("✅ 批准", "approve:"+requestID),
-			tgbotapi.NewInlineKeyboardButtonData("❌ 拒绝", "deny:"+requestID),
+			tgbotapi.NewInlineKeyboardButtonData(loc.T("approval.approve_btn"), "approve:"+requestID),
+			tgbotapi.NewInlineKeyboardButtonData(loc.T("approval.deny_btn"), "deny:"+requestID),
 		),
 	)
 
 	// 发送审批消息 — 人类可读格式, 不是原始 JSON
-	text := formatApprovalMessage(toolName, toolArgs)
+	// SecurityHook 附带的命令风险分析 (仅 bash 类工具) 与要求确认的项目守卫规则
+	text := formatApprovalMessage(loc, toolName, toolArgs, service.CommandRiskFromContext(ctx), service.GuardRuleFromContext(ctx))
 
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ParseMode = "Markdown"
@@ -531,9 +677,10 @@ func (a *Adapter) RequestApproval(ctx context.Context, chatID int64, toolName st
 
 		// 更新消息
 		editMsg := tgbotapi.NewEditMessageText(chatID, request.MessageID,
-			fmt.Sprintf("工具调用: `%s`\n状态: ⏰ 已超时 (自动拒绝)", toolName))
+			loc.Tf("approval.status", toolName, loc.T("approval.timed_out")))
 		editMsg.ParseMode = "Markdown"
 		a.bot.Send(editMsg)
+		a.Audit(AuditApproval, chatID, 0, "audit.approval_timeout", toolName)
 
 		return false, nil
 	case <-ctx.Done():
@@ -543,6 +690,12 @@ func (a *Adapter) RequestApproval(ctx context.Context, chatID int64, toolName st
 
 // SendMessage 发送消息
 func (a *Adapter) SendMessage(out *OutgoingMessage) error {
+	_, err := a.SendMessageWithID(out)
+	return err
+}
+
+// SendMessageWithID 发送消息并返回消息 ID (用于关联之后的表情反应)
+func (a *Adapter) SendMessageWithID(out *OutgoingMessage) (int, error) {
 	msg := tgbotapi.NewMessage(out.ChatID, out.Text)
 
 	if out.ParseMode != "" {
@@ -557,7 +710,7 @@ func (a *Adapter) SendMessage(out *OutgoingMessage) error {
 		msg.ReplyMarkup = out.ReplyMarkup
 	}
 
-	_, err := a.bot.Send(msg)
+	sent, err := a.bot.Send(msg)
 
 	// Fallback: if HTML parsing fails, retry as plain text.
 	// Safety net for edge cases where goldmark produces invalid TG HTML.
@@ -567,10 +720,18 @@ func (a *Adapter) SendMessage(out *OutgoingMessage) error {
 			zap.Error(err),
 		)
 		msg.ParseMode = ""
-		_, err = a.bot.Send(msg)
+		sent, err = a.bot.Send(msg)
 	}
 
-	return err
+	return sent.MessageID, err
+}
+
+// LastActivity 返回用户在该 chat 最近一次发消息或点按钮的时间 (未知时为零值)
+func (a *Adapter) LastActivity(chatID int64) time.Time {
+	if t, ok := a.lastActivity.Load(chatID); ok {
+		return t.(time.Time)
+	}
+	return time.Time{}
 }
 
 // SendTyping 发送打字状态
@@ -672,7 +833,7 @@ func (a *Adapter) isInGroupAllowlist(chatID int64) bool {
 	if len(a.config.GroupAllowFrom) == 0 {
 		return true // 空白名单 = 允许所有
 	}
-	chatIDStr := fmt.Sprintf("%d", chatID)
+	chatIDStr := fmt.Sprintf("%d", realChatID(chatID)) // 话题按所在群判断
 	for _, id := range a.config.GroupAllowFrom {
 		if id == chatIDStr {
 			return true
@@ -690,6 +851,45 @@ func truncate(s string, maxLen int) string {
 	return s[:maxLen] + "..."
 }
 
+// RunScheduled 以 chat 的名义执行定时任务 / 心跳的一条指令: 斜杠命令交给命令注册表
+// (无用户身份, 配置了管理员时管理员命令会被拒绝), 其他文本交给 agent. 返回的错误记为该次运行的结果.
+func (a *Adapter) RunScheduled(ctx context.Context, chatID int64, text string) error {
+	if cmd := ParseCommand(text); cmd != nil && a.commandRegistry != nil {
+		cmd.ChatID = chatID
+		response, handled, err := a.commandRegistry.Handle(ctx, cmd)
+		if err != nil {
+			a.sendError(chatID, err)
+			return err
+		}
+		if handled {
+			if response != nil {
+				a.SendMessage(response)
+			}
+			if cmd.AgentPrompt == "" {
+				return nil
+			}
+			text = cmd.AgentPrompt
+		}
+	}
+	if a.messageHandler == nil {
+		return fmt.Errorf("no message handler")
+	}
+
+	response, err := a.messageHandler.HandleMessage(ctx, &IncomingMessage{
+		ChatID:    chatID,
+		Text:      text,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		a.sendError(chatID, err)
+		return err
+	}
+	if response != nil {
+		return a.SendMessage(response)
+	}
+	return nil
+}
+
 // processBufferedMessage handles a message after it exits the inbound buffer
 func (a *Adapter) processBufferedMessage(ctx context.Context, msg *IncomingMessage) {
 	if a.messageHandler == nil {
@@ -777,8 +977,8 @@ func (a *Adapter) handleEditedMessage(ctx context.Context, msg *tgbotapi.Message
 func (a *Adapter) handleReaction(ctx context.Context, chatID int64, messageID int, emoji string) {
 	// Emoji → Action 映射
 	actionMap := map[string]string{
-		"👍": "save_memory",  // 存入记忆 (标记为高质量回答)
-		"👎": "retry",        // 重新生成 (标记为不良回答)
+		"👍": "save_memory",  // 标记为高质量回答 (记录反馈)
+		"👎": "retry",        // 标记为不良回答 (记录反馈)
 		"🔄": "regenerate",   // 重新生成 (不标记)
 		"📌": "pin",          // Pin 到上下文 (compaction 不压缩)
 		"❤":  "save_memory",  // 同 👍
@@ -804,10 +1004,14 @@ func (a *Adapter) handleReaction(ctx context.Context, chatID int64, messageID in
 
 	if a.reactionHandler != nil {
 		if err := a.reactionHandler.HandleReaction(ctx, chatID, messageID, action); err != nil {
+			if errors.Is(err, ErrReactionIgnored) {
+				return
+			}
 			a.logger.Error("Failed to handle reaction",
 				zap.String("action", action),
 				zap.Error(err),
 			)
+			return
 		}
 	}
 
@@ -815,9 +1019,9 @@ func (a *Adapter) handleReaction(ctx context.Context, chatID int64, messageID in
 	var feedback string
 	switch action {
 	case "save_memory":
-		feedback = "💾 已保存到记忆"
+		feedback = a.localeFor(chatID).T("feedback.recorded_good")
 	case "retry":
-		feedback = "🔄 正在重新生成..."
+		feedback = a.localeFor(chatID).T("feedback.recorded_bad")
 	case "regenerate":
 		feedback = "🔄 正在重新生成..."
 	case "pin":
@@ -833,27 +1037,57 @@ func (a *Adapter) handleReaction(ctx context.Context, chatID int64, messageID in
 	}
 }
 
+// formatRiskLines renders the risk level and reasons; safe/normal commands add nothing.
+func formatRiskLines(loc i18n.Locale, risk *service.CommandRisk) []string {
+	if risk == nil || risk.Level < service.RiskMedium {
+		return nil
+	}
+	lines := []string{"", loc.Tf("approval.risk", loc.T("risk.level."+risk.Level.String()))}
+	for _, r := range risk.Reasons {
+		line := "• " + loc.T("risk."+r.Code)
+		if r.Detail != "" {
+			line += ": `" + strings.ReplaceAll(truncate(r.Detail, 80), "`", "'") + "`"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
 // formatApprovalMessage creates a human-readable tool approval card.
 // Instead of dumping raw JSON, it extracts key information and presents it cleanly.
-func formatApprovalMessage(toolName string, toolArgs string) string {
+// risk (may be nil) adds the command risk level and the specific reasons,
+// guard (may be nil) the project guard rule that requires the approval.
+func formatApprovalMessage(loc i18n.Locale, toolName string, toolArgs string, risk *service.CommandRisk, guard *service.GuardRule) string {
 	// Parse the JSON args
 	var args map[string]interface{}
 	if err := json.Unmarshal([]byte(toolArgs), &args); err != nil {
 		// Fallback to raw display if not valid JSON
-		return fmt.Sprintf("🔧 *请求执行工具*\n\n工具: `%s`\n参数: %s\n\n请确认是否执行：",
-			toolName, truncate(toolArgs, 300))
+		return loc.T("approval.title") + "\n\n" +
+			loc.Tf("approval.raw", toolName, truncate(toolArgs, 300)) + "\n" +
+			loc.T("approval.confirm")
+	}
+
+	// 大额模型请求 (pre-flight 成本检查) 不是工具调用, 单独的卡片
+	if toolName == service.PreflightApprovalTool {
+		tokens, _ := args["input_tokens"].(float64)
+		lines := []string{loc.T("approval.llm_title") + "\n", loc.Tf("approval.llm_call", argStr(args, "model"), int(tokens)/1000)}
+		if cost, ok := args["cost_usd"].(float64); ok {
+			lines = append(lines, loc.Tf("approval.llm_cost", cost))
+		}
+		lines = append(lines, loc.T("approval.confirm"))
+		return strings.Join(lines, "\n")
 	}
 
 	var lines []string
-	lines = append(lines, "🔧 *请求执行工具*\n")
+	lines = append(lines, loc.T("approval.title")+"\n")
 
 	switch toolName {
-	case "bash", "bash_exec", "shell":
+	case "bash", "bash_exec", "shell", "terminal":
 		cmd := argStr(args, "command")
 		if cmd == "" {
 			cmd = argStr(args, "cmd")
 		}
-		lines = append(lines, fmt.Sprintf("执行命令:\n```\n%s\n```", truncate(cmd, 500)))
+		lines = append(lines, loc.Tf("approval.bash", truncate(cmd, 500)))
 
 	case "write_file":
 		path := argStr(args, "path")
@@ -863,27 +1097,30 @@ func formatApprovalMessage(toolName string, toolArgs string) string {
 			baseName = path[idx+1:]
 		}
 		contentLen := len([]rune(content))
-		lines = append(lines, fmt.Sprintf("写入文件: `%s` (%d 字符)", baseName, contentLen))
+		lines = append(lines, loc.Tf("approval.write_file", baseName, contentLen))
 		if contentLen > 0 {
 			preview := truncate(content, 200)
-			lines = append(lines, fmt.Sprintf("\n内容预览:\n```\n%s\n```", preview))
+			lines = append(lines, loc.Tf("approval.preview", preview))
 		}
 
 	case "read_file":
 		path := argStr(args, "path")
-		lines = append(lines, fmt.Sprintf("读取文件: `%s`", path))
+		lines = append(lines, loc.Tf("approval.read_file", path))
 
 	case "web_search", "search":
 		query := argStr(args, "query")
-		lines = append(lines, fmt.Sprintf("搜索: `%s`", query))
+		lines = append(lines, loc.Tf("approval.search", query))
 
 	case "web_fetch":
 		url := argStr(args, "url")
-		lines = append(lines, fmt.Sprintf("抓取网页: %s", truncate(url, 100)))
+		lines = append(lines, loc.Tf("approval.fetch", truncate(url, 100)))
+
+	case "sql_query":
+		lines = append(lines, loc.Tf("approval.sql", argStr(args, "connection"), truncate(argStr(args, "query"), 500)))
 
 	default:
 		// Generic: show key=value pairs, truncate long values
-		lines = append(lines, fmt.Sprintf("工具: `%s`", toolName))
+		lines = append(lines, loc.Tf("approval.tool", toolName))
 		for k, v := range args {
 			valStr := fmt.Sprintf("%v", v)
 			if len(valStr) > 100 {
@@ -893,6 +1130,13 @@ func formatApprovalMessage(toolName string, toolArgs string) string {
 		}
 	}
 
-	lines = append(lines, "\n请确认是否执行：")
+	lines = append(lines, formatRiskLines(loc, risk)...)
+	if guard != nil {
+		lines = append(lines, "", loc.Tf("approval.guard", guard.Name))
+		if guard.Message != "" {
+			lines = append(lines, strings.ReplaceAll(guard.Message, "*", ""))
+		}
+	}
+	lines = append(lines, loc.T("approval.confirm"))
 	return strings.Join(lines, "\n")
 }
@@ -0,0 +1,86 @@
+package telegram
+
+import (
+	"html"
+	"strings"
+
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
+	"go.uber.org/zap"
+)
+
+// AuditKind 控制群通知的事件类型 (telegram.control_events)
+type AuditKind string
+
+const (
+	AuditConfig   AuditKind = "config"   // /config set|unset, /debug set|unset|reset
+	AuditApproval AuditKind = "approval" // 工具审批被拒绝或超时
+	AuditBudget   AuditKind = "budget"   // 运行因预算耗尽被中止
+	AuditProvider AuditKind = "provider" // 模型提供商熔断与恢复
+	AuditRestart  AuditKind = "restart"  // /restart, /profile 切换
+)
+
+// maxAuditArg 通知中单个参数 (配置值、错误信息) 的最大长度
+const maxAuditArg = 300
+
+// controlChat 接收审计通知的控制群
+type controlChat struct {
+	chatID int64
+	kinds  map[AuditKind]bool // 空 = 全部
+}
+
+// SetControlChat 设置控制群与通知的事件类型 (空 = 全部); chatID 为 0 时关闭
+func (a *Adapter) SetControlChat(chatID int64, kinds []string) {
+	cc := &controlChat{chatID: chatID, kinds: make(map[AuditKind]bool)}
+	for _, k := range kinds {
+		cc.kinds[AuditKind(strings.ToLower(strings.TrimSpace(k)))] = true
+	}
+	a.mu.Lock()
+	a.control = cc
+	a.mu.Unlock()
+}
+
+// Audit 向控制群发送一条敏感事件通知 (异步, 不阻塞调用方).
+// key 为 i18n 文案 (按控制群语言), 字符串参数会截断并做 HTML 转义;
+// chatID/userID 为事件来源, 0 表示与具体会话无关. 来源就是控制群时不重复通知
+func (a *Adapter) Audit(kind AuditKind, chatID, userID int64, key string, args ...interface{}) {
+	a.mu.RLock()
+	cc := a.control
+	a.mu.RUnlock()
+	if !cc.wants(kind, chatID) {
+		return
+	}
+	text := formatAuditNotice(a.localeFor(cc.chatID), chatID, userID, key, args...)
+	go func() {
+		if err := a.SendMessage(&OutgoingMessage{ChatID: cc.chatID, Text: text, ParseMode: "HTML"}); err != nil {
+			a.logger.Warn("Failed to send control chat notice",
+				zap.String("kind", string(kind)),
+				zap.Error(err),
+			)
+		}
+	}()
+}
+
+// wants 判断是否向控制群通知该事件
+func (cc *controlChat) wants(kind AuditKind, chatID int64) bool {
+	if cc == nil || cc.chatID == 0 || (chatID != 0 && chatID == cc.chatID) {
+		return false
+	}
+	return len(cc.kinds) == 0 || cc.kinds[kind]
+}
+
+// formatAuditNotice 组装通知正文: 事件描述 + 来源
+func formatAuditNotice(loc i18n.Locale, chatID, userID int64, key string, args ...interface{}) string {
+	for i, arg := range args {
+		if s, ok := arg.(string); ok {
+			args[i] = html.EscapeString(truncateLabel(s, maxAuditArg))
+		}
+	}
+	text := loc.Tf(key, args...)
+	switch {
+	case chatID != 0 && userID != 0:
+		text += "\n" + loc.Tf("audit.source_user", chatID, userID)
+	case chatID != 0:
+		text += "\n" + loc.Tf("audit.source", chatID)
+	}
+	return text
+}
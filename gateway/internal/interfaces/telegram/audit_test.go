@@ -0,0 +1,48 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
+)
+
+func TestControlChatWants(t *testing.T) {
+	var none *controlChat
+	if none.wants(AuditConfig, 1) {
+		t.Error("no control chat should want nothing")
+	}
+
+	a := &Adapter{}
+	a.SetControlChat(-100, nil)
+	if !a.control.wants(AuditProvider, 0) || !a.control.wants(AuditConfig, 42) {
+		t.Error("empty event list should want every kind")
+	}
+	if a.control.wants(AuditConfig, -100) {
+		t.Error("events from the control chat itself are already visible there")
+	}
+
+	a.SetControlChat(-100, []string{" Config ", "budget"})
+	if !a.control.wants(AuditConfig, 42) || !a.control.wants(AuditBudget, 42) || a.control.wants(AuditApproval, 42) {
+		t.Errorf("filtered kinds = %v", a.control.kinds)
+	}
+
+	a.SetControlChat(0, nil)
+	if a.control.wants(AuditRestart, 42) {
+		t.Error("chat 0 disables notices")
+	}
+}
+
+func TestFormatAuditNotice(t *testing.T) {
+	loc := i18n.EN
+	got := formatAuditNotice(loc, 42, 7, "audit.config_set", "agent.default_model", "<b>x</b>")
+	if !strings.Contains(got, "<code>agent.default_model</code> = <code>&lt;b&gt;x&lt;/b&gt;</code>") ||
+		!strings.Contains(got, "chat <code>42</code> · user <code>7</code>") {
+		t.Errorf("notice = %q", got)
+	}
+
+	got = formatAuditNotice(loc, 0, 0, "audit.provider_down", "openai", strings.Repeat("e", 1000))
+	if strings.Contains(got, "chat") || len([]rune(got)) > maxAuditArg+100 {
+		t.Errorf("provider notice = %q", got)
+	}
+}
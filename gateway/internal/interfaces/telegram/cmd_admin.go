@@ -3,10 +3,14 @@ package telegram
 import (
 	"context"
 	"fmt"
+	"html"
 	"strings"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/config"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
 )
 
-// registerAdminCommands registers admin/infrastructure: config, debug, restart, allowlist, subagents, plugin, tts
+// registerAdminCommands registers admin/infrastructure: config, debug, restart, profile, allowlist, subagents, plugin, tts
 func (a *Adapter) registerAdminCommands(registry *CommandRegistry) {
 	registry.Register("config", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
 		if registry.configManager != nil && !registry.configManager.IsFeatureEnabled("config") {
@@ -62,6 +66,7 @@ func (a *Adapter) registerAdminCommands(registry *CommandRegistry) {
 			if err := registry.configManager.SetConfigValue(path, value); err != nil {
 				return &OutgoingMessage{ChatID: cmd.ChatID, Text: fmt.Sprintf("⚠️ %s", err.Error())}, nil
 			}
+			a.Audit(AuditConfig, cmd.ChatID, cmd.UserID, "audit.config_set", path, config.RedactSetting(path, value))
 			return &OutgoingMessage{
 				ChatID: cmd.ChatID,
 				Text:   fmt.Sprintf("⚙️ Config updated: %s=%s", path, value),
@@ -77,6 +82,7 @@ func (a *Adapter) registerAdminCommands(registry *CommandRegistry) {
 			if err := registry.configManager.UnsetConfigValue(path); err != nil {
 				return &OutgoingMessage{ChatID: cmd.ChatID, Text: fmt.Sprintf("⚠️ %s", err.Error())}, nil
 			}
+			a.Audit(AuditConfig, cmd.ChatID, cmd.UserID, "audit.config_unset", path)
 			return &OutgoingMessage{
 				ChatID: cmd.ChatID,
 				Text:   fmt.Sprintf("⚙️ Config updated: %s removed.", path),
@@ -134,6 +140,7 @@ func (a *Adapter) registerAdminCommands(registry *CommandRegistry) {
 			if err := registry.configManager.SetDebugOverride(path, value); err != nil {
 				return &OutgoingMessage{ChatID: cmd.ChatID, Text: fmt.Sprintf("⚠️ %s", err.Error())}, nil
 			}
+			a.Audit(AuditConfig, cmd.ChatID, cmd.UserID, "audit.debug_set", path, config.RedactSetting(path, value))
 			return &OutgoingMessage{ChatID: cmd.ChatID, Text: fmt.Sprintf("⚙️ Debug override set: %s=%s", path, value)}, nil
 		case "unset":
 			if len(cmd.Args) < 2 {
@@ -146,11 +153,13 @@ func (a *Adapter) registerAdminCommands(registry *CommandRegistry) {
 			if err := registry.configManager.UnsetDebugOverride(path); err != nil {
 				return &OutgoingMessage{ChatID: cmd.ChatID, Text: fmt.Sprintf("⚠️ %s", err.Error())}, nil
 			}
+			a.Audit(AuditConfig, cmd.ChatID, cmd.UserID, "audit.debug_unset", path)
 			return &OutgoingMessage{ChatID: cmd.ChatID, Text: fmt.Sprintf("⚙️ Debug override removed: %s", path)}, nil
 		case "reset":
 			if registry.configManager != nil {
 				registry.configManager.ResetDebugOverrides()
 			}
+			a.Audit(AuditConfig, cmd.ChatID, cmd.UserID, "audit.debug_reset")
 			return &OutgoingMessage{ChatID: cmd.ChatID, Text: "⚙️ Debug overrides cleared; using config on disk."}, nil
 		default:
 			return &OutgoingMessage{
@@ -169,12 +178,46 @@ func (a *Adapter) registerAdminCommands(registry *CommandRegistry) {
 			}, nil
 		}
 		// Signal restart (actual restart handled by process supervisor)
+		a.Audit(AuditRestart, cmd.ChatID, cmd.UserID, "audit.restart")
 		return &OutgoingMessage{
 			ChatID: cmd.ChatID,
 			Text:   "🔄 Restart requested. The gateway will restart shortly.",
 		}, nil
 	})
 
+	// /profile 命令 - 切换配置 profile (~/.ngoclaw/profiles/<name>), 保存后重启网关
+	registry.Register("profile", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		loc := registry.localeFor(cmd.ChatID)
+		ps := registry.profileSwitcher
+		if ps == nil {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.T("profile.unavailable"), ParseMode: "HTML"}, nil
+		}
+		names, err := ps.ListProfiles()
+		if err != nil {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.Tf("profile.error", html.EscapeString(err.Error())), ParseMode: "HTML"}, nil
+		}
+		if len(cmd.Args) == 0 {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: formatProfileStatus(loc, ps.ActiveProfile(), names), ParseMode: "HTML"}, nil
+		}
+		if len(cmd.Args) > 1 {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.T("profile.usage"), ParseMode: "HTML"}, nil
+		}
+
+		name := cmd.Args[0]
+		current := ps.ActiveProfile()
+		if current == "" {
+			current = "default"
+		}
+		if name == current {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.Tf("profile.same", html.EscapeString(name)), ParseMode: "HTML"}, nil
+		}
+		if err := ps.SwitchProfile(name); err != nil {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.Tf("profile.error", html.EscapeString(err.Error())), ParseMode: "HTML"}, nil
+		}
+		a.Audit(AuditRestart, cmd.ChatID, cmd.UserID, "audit.profile", name)
+		return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.Tf("profile.switching", html.EscapeString(name)), ParseMode: "HTML"}, nil
+	})
+
 	// /allowlist 命令 - 白名单管理 (对标 OpenClaw handleAllowlistCommand)
 	registry.Register("allowlist", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
 		scope := "dm"
@@ -385,6 +428,9 @@ func (a *Adapter) registerAdminCommands(registry *CommandRegistry) {
 		case "on":
 			if registry.ttsController != nil {
 				registry.ttsController.SetEnabled(cmd.ChatID, true)
+				if status := registry.ttsController.GetStatus(cmd.ChatID); status != nil && !status.ProviderReady {
+					return &OutgoingMessage{ChatID: cmd.ChatID, Text: fmt.Sprintf("🔊 TTS enabled.\n⚠️ Provider %s is not configured, no voice replies will be sent (see tts in config.yaml).", status.Provider)}, nil
+				}
 			}
 			return &OutgoingMessage{ChatID: cmd.ChatID, Text: "🔊 TTS enabled."}, nil
 		case "off":
@@ -398,7 +444,7 @@ func (a *Adapter) registerAdminCommands(registry *CommandRegistry) {
 			}
 			if len(cmd.Args) < 2 {
 				current := registry.ttsController.GetProvider(cmd.ChatID)
-				return &OutgoingMessage{ChatID: cmd.ChatID, Text: fmt.Sprintf("🎙️ TTS provider: %s\nUsage: /tts provider openai|elevenlabs|edge", current)}, nil
+				return &OutgoingMessage{ChatID: cmd.ChatID, Text: fmt.Sprintf("🎙️ TTS provider: %s\nUsage: /tts provider openai|edge", current)}, nil
 			}
 			provider := strings.ToLower(cmd.Args[1])
 			if err := registry.ttsController.SetProvider(cmd.ChatID, provider); err != nil {
@@ -452,6 +498,9 @@ func (a *Adapter) registerAdminCommands(registry *CommandRegistry) {
 			if err != nil {
 				return &OutgoingMessage{ChatID: cmd.ChatID, Text: fmt.Sprintf("❌ Error: %s", err.Error())}, nil
 			}
+			if result == "" {
+				return nil, nil // 语音已直接发送
+			}
 			return &OutgoingMessage{ChatID: cmd.ChatID, Text: result}, nil
 		case "status":
 			if registry.ttsController == nil {
@@ -498,3 +547,30 @@ func (a *Adapter) registerAdminCommands(registry *CommandRegistry) {
 	registry.Alias("sa", "subagents")
 	registry.Alias("ptt", "tts")
 }
+
+// formatProfileStatus 渲染 /profile 的当前 profile 与可选列表, "default" 表示不叠加 profile
+func formatProfileStatus(loc i18n.Locale, active string, names []string) string {
+	var sb strings.Builder
+	if active == "" {
+		sb.WriteString(loc.Tf("profile.status", loc.T("profile.none")))
+	} else {
+		sb.WriteString(loc.Tf("profile.status", "<b>"+html.EscapeString(active)+"</b>"))
+	}
+	sb.WriteString("\n\n")
+	if len(names) == 0 {
+		sb.WriteString(loc.T("profile.empty"))
+	} else {
+		sb.WriteString(loc.T("profile.list"))
+		sb.WriteString("\n")
+		for _, name := range append([]string{"default"}, names...) {
+			marker := "•"
+			if name == active || (active == "" && name == "default") {
+				marker = "✓"
+			}
+			sb.WriteString(fmt.Sprintf("%s <code>%s</code>\n", marker, html.EscapeString(name)))
+		}
+	}
+	sb.WriteString("\n")
+	sb.WriteString(loc.T("profile.usage"))
+	return sb.String()
+}
@@ -4,13 +4,39 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"html"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/repository"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/valueobject"
+	toolpkg "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tool"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
 )
 
-// registerAgentCommands registers agent/execution: skill, skills, cron, agent, bash, approve
+// registerAgentCommands registers agent/execution: skill, skills, cron, workflow, agent, bash, approve, research
 func (a *Adapter) registerAgentCommands(registry *CommandRegistry) {
+	// /research <topic> — 转交 agent, 使用 research 工具 (多角度检索 + 编号引用)
+	registry.Register("research", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		loc := registry.localeFor(cmd.ChatID)
+		topic := strings.TrimSpace(cmd.RawArgs)
+		if topic == "" {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.T("research.usage"), ParseMode: "HTML"}, nil
+		}
+		cmd.AgentPrompt = toolpkg.ResearchPrompt(topic)
+		return &OutgoingMessage{
+			ChatID:    cmd.ChatID,
+			Text:      loc.Tf("research.started", html.EscapeString(topic)),
+			ParseMode: "HTML",
+		}, nil
+	})
+
 	registry.Register("skill", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
 		if len(cmd.Args) == 0 {
 			// Build dynamic skill list
@@ -120,7 +146,8 @@ func (a *Adapter) registerAgentCommands(registry *CommandRegistry) {
 				ChatID: cmd.ChatID,
 				Text: "⏰ <b>定时任务</b>\n\n用法:\n" +
 					"• /cron list — 列出任务\n" +
-					"• /cron add &lt;表达式&gt; &lt;命令&gt; — 添加任务\n" +
+					"• /cron status — 下次运行时间与上次结果\n" +
+					"• /cron add [--catch-up=skip|once|all] &lt;表达式&gt; &lt;命令&gt; — 添加任务\n" +
 					"• /cron remove &lt;ID&gt; — 删除任务\n\n" +
 					"表达式示例:\n" +
 					"• <code>@hourly</code> — 每小时\n" +
@@ -152,6 +179,13 @@ func (a *Adapter) registerAgentCommands(registry *CommandRegistry) {
 				ParseMode: "HTML",
 			}, nil
 
+		case "status":
+			return &OutgoingMessage{
+				ChatID:    cmd.ChatID,
+				Text:      registry.cronStatus(cmd.ChatID),
+				ParseMode: "HTML",
+			}, nil
+
 		case "add":
 			if len(cmd.Args) < 3 {
 				return &OutgoingMessage{
@@ -164,7 +198,7 @@ func (a *Adapter) registerAgentCommands(registry *CommandRegistry) {
 			command := strings.Join(cmd.Args[2:], " ")
 			// Schedule via CronService
 			if registry.cronService != nil {
-				jobID, err := registry.cronService.Schedule(cmd.ChatID, cronExpr, command)
+				jobID, err := registry.cronService.Schedule(cmd.ChatID, cronExpr, command, cmd.Flags["catch-up"])
 				if err != nil {
 					return &OutgoingMessage{
 						ChatID:    cmd.ChatID,
@@ -218,94 +252,152 @@ func (a *Adapter) registerAgentCommands(registry *CommandRegistry) {
 		}
 	})
 
-	// /agent 命令 - Agent 管理
-	registry.Register("agent", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
-		if len(cmd.Args) == 0 {
-			return &OutgoingMessage{
-				ChatID: cmd.ChatID,
-				Text: "🤖 <b>Agent 管理</b>\n\n用法:\n" +
-					"• /agent list — 列出 Agent\n" +
-					"• /agent switch &lt;ID&gt; — 切换 Agent\n" +
-					"• /agent spawn &lt;名称&gt; — 创建新 Agent\n" +
-					"• /agent terminate &lt;ID&gt; — 终止 Agent",
-				ParseMode: "HTML",
-			}, nil
+	// /workflow — ~/.ngoclaw/workflows 中的命名流水线: list / run <名称> [输入]
+	registry.Register("workflow", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		loc := registry.localeFor(cmd.ChatID)
+		reply := func(text string) (*OutgoingMessage, error) {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: text, ParseMode: "HTML"}, nil
+		}
+		registry.mu.RLock()
+		runner := registry.workflows
+		registry.mu.RUnlock()
+		if runner == nil {
+			return reply(loc.T("workflow.unavailable"))
 		}
 
-		subCmd := cmd.Args[0]
+		action := "list"
+		if len(cmd.Args) > 0 {
+			action = strings.ToLower(cmd.Args[0])
+		}
+		switch action {
+		case "list":
+			return reply(formatWorkflowList(loc, runner))
+		case "run":
+			if len(cmd.Args) < 2 {
+				return reply(loc.T("workflow.usage"))
+			}
+			input := strings.TrimSpace(strings.Join(cmd.Args[2:], " "))
+			w, err := runner.Start(service.WithReplyLanguage(ctx, string(loc)), cmd.ChatID, cmd.Args[1], input)
+			if err != nil {
+				return reply(loc.Tf("workflow.error", html.EscapeString(err.Error())))
+			}
+			return reply(loc.Tf("workflow.started", html.EscapeString(w.Name), len(w.Stages)))
+		default:
+			return reply(loc.T("workflow.usage"))
+		}
+	})
+
+	// /agent 命令 - 命名代理: 人设、可用工具、模型与温度, 按会话切换
+	registry.Register("agent", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		loc := registry.localeFor(cmd.ChatID)
+		reply := func(text string) (*OutgoingMessage, error) {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: text, ParseMode: "HTML"}, nil
+		}
+		as, ok := registry.sessionManager.(AgentSettings)
+		if !ok || registry.agentRepo == nil {
+			return reply(loc.T("agent.unavailable"))
+		}
+		current := as.GetAgent(cmd.ChatID)
 
+		subCmd := "list"
+		if len(cmd.Args) > 0 {
+			subCmd = strings.ToLower(cmd.Args[0])
+		}
 		switch subCmd {
 		case "list", "ls":
-			// List agents from subagentManager
-			agentList := "• <code>default</code> — 默认助手 [当前]"
-			if registry.subagentManager != nil {
-				agents := registry.subagentManager.ListSubagents(cmd.ChatID)
-				if len(agents) > 0 {
-					var lines []string
-					for _, a := range agents {
-						lines = append(lines, fmt.Sprintf("• <code>%s</code> — %s [%s]", a.Label, a.Status, a.RunID[:8]))
-					}
-					agentList = strings.Join(lines, "\n")
-				}
+			agents, err := registry.agentRepo.FindAll(ctx)
+			if err != nil {
+				return reply(loc.Tf("agent.error", html.EscapeString(err.Error())))
 			}
-			return &OutgoingMessage{
-				ChatID:    cmd.ChatID,
-				Text:      fmt.Sprintf("🤖 <b>当前 Agent</b>\n\n%s", agentList),
-				ParseMode: "HTML",
-			}, nil
+			return reply(formatAgentList(loc, agents, current))
 
-		case "switch", "use":
+		case "show", "info", "switch", "use":
 			if len(cmd.Args) < 2 {
-				return &OutgoingMessage{
-					ChatID:    cmd.ChatID,
-					Text:      "❌ 用法: /agent switch &lt;ID&gt;",
-					ParseMode: "HTML",
-				}, nil
+				return reply(loc.T("agent.usage"))
 			}
-			agentID := cmd.Args[1]
-			return &OutgoingMessage{
-				ChatID:    cmd.ChatID,
-				Text:      fmt.Sprintf("✅ 已切换到 Agent: <code>%s</code>", agentID),
-				ParseMode: "HTML",
-			}, nil
+			agent, err := findAgent(ctx, registry.agentRepo, cmd.Args[1])
+			if err != nil {
+				return reply(loc.Tf("agent.not_found", html.EscapeString(cmd.Args[1])))
+			}
+			if subCmd == "show" || subCmd == "info" {
+				return reply(formatAgent(loc, agent))
+			}
+			if agent.ID() == entity.DefaultAgentID {
+				as.SetAgent(cmd.ChatID, "")
+				return reply(loc.T("agent.switched_default"))
+			}
+			as.SetAgent(cmd.ChatID, agent.ID())
+			return reply(loc.Tf("agent.switched", html.EscapeString(agent.Name())) + "\n\n" + formatAgent(loc, agent))
+		}
 
-		case "spawn", "create", "new":
-			if len(cmd.Args) < 2 {
-				return &OutgoingMessage{
-					ChatID:    cmd.ChatID,
-					Text:      "❌ 用法: /agent spawn &lt;名称&gt;",
-					ParseMode: "HTML",
-				}, nil
+		// 以下子命令修改所有会话共享的代理, 仅限管理员
+		if !registry.isAdmin(cmd.UserID) {
+			return reply(loc.T("agent.admin_only"))
+		}
+		if len(cmd.Args) < 2 {
+			return reply(loc.T("agent.usage"))
+		}
+		name := strings.ToLower(cmd.Args[1])
+		switch subCmd {
+		case "create", "new", "spawn":
+			if !agentNameRe.MatchString(name) {
+				return reply(loc.T("agent.bad_name"))
 			}
-			name := strings.Join(cmd.Args[1:], " ")
-			return &OutgoingMessage{
-				ChatID:    cmd.ChatID,
-				Text:      fmt.Sprintf("✅ 已创建 Agent: <code>%s</code>", name),
-				ParseMode: "HTML",
-			}, nil
+			if _, err := findAgent(ctx, registry.agentRepo, name); err == nil {
+				return reply(loc.Tf("agent.exists", name))
+			}
+			agent, err := entity.NewAgent(name, name, valueobject.NewModelConfig("", "", 0, 0, 0, true))
+			if err != nil {
+				return reply(loc.Tf("agent.error", html.EscapeString(err.Error())))
+			}
+			if err := registry.agentRepo.Save(ctx, agent); err != nil {
+				return reply(loc.Tf("agent.error", html.EscapeString(err.Error())))
+			}
+			return reply(loc.Tf("agent.created", name, name))
 
-		case "terminate", "kill", "stop":
-			if len(cmd.Args) < 2 {
-				return &OutgoingMessage{
-					ChatID:    cmd.ChatID,
-					Text:      "❌ 用法: /agent terminate &lt;ID&gt;",
-					ParseMode: "HTML",
-				}, nil
+		case "set":
+			agent, err := findAgent(ctx, registry.agentRepo, name)
+			if err != nil {
+				return reply(loc.Tf("agent.not_found", html.EscapeString(name)))
 			}
-			agentID := cmd.Args[1]
-			return &OutgoingMessage{
-				ChatID:    cmd.ChatID,
-				Text:      fmt.Sprintf("✅ 已终止 Agent: <code>%s</code>", agentID),
-				ParseMode: "HTML",
-			}, nil
+			if agent.ID() == entity.DefaultAgentID {
+				return reply(loc.T("agent.default_readonly"))
+			}
+			if len(cmd.Args) < 3 {
+				return reply(loc.T("agent.usage"))
+			}
+			field := strings.ToLower(cmd.Args[2])
+			// 值取原始参数, 保留人设中的空格与换行
+			value := strings.TrimSpace(cmd.RawArgs)
+			for _, arg := range cmd.Args[:3] {
+				value = strings.TrimSpace(strings.TrimPrefix(value, arg))
+			}
+			if msg := registry.updateAgent(loc, agent, field, value); msg != "" {
+				return reply(msg)
+			}
+			if err := registry.agentRepo.Save(ctx, agent); err != nil {
+				return reply(loc.Tf("agent.error", html.EscapeString(err.Error())))
+			}
+			return reply(loc.Tf("agent.updated", html.EscapeString(agent.Name())) + "\n\n" + formatAgent(loc, agent))
 
-		default:
-			return &OutgoingMessage{
-				ChatID:    cmd.ChatID,
-				Text:      fmt.Sprintf("❌ 未知子命令: <code>%s</code>", subCmd),
-				ParseMode: "HTML",
-			}, nil
+		case "delete", "rm", "terminate":
+			agent, err := findAgent(ctx, registry.agentRepo, name)
+			if err != nil {
+				return reply(loc.Tf("agent.not_found", html.EscapeString(name)))
+			}
+			if agent.ID() == entity.DefaultAgentID {
+				return reply(loc.T("agent.default_readonly"))
+			}
+			if err := registry.agentRepo.Delete(ctx, agent.ID()); err != nil {
+				return reply(loc.Tf("agent.error", html.EscapeString(err.Error())))
+			}
+			// 其他仍选着该代理的会话在下次运行时回到默认代理
+			if current == agent.ID() {
+				as.SetAgent(cmd.ChatID, "")
+			}
+			return reply(loc.Tf("agent.deleted", html.EscapeString(agent.Name())))
 		}
+		return reply(loc.T("agent.usage"))
 	})
 
 	// /bash 命令 - 执行 shell 命令 (对标 OpenClaw commands-bash.ts)
@@ -458,88 +550,231 @@ func (a *Adapter) registerAgentCommands(registry *CommandRegistry) {
 		}, nil
 	})
 
-	// /memory 命令 - 查看长期记忆 (reads ~/.ngoclaw/memory.json)
-	registry.Register("memory", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return &OutgoingMessage{
-				ChatID:    cmd.ChatID,
-				Text:      "❌ 无法获取 home 目录",
-				ParseMode: "HTML",
-			}, nil
-		}
+	// /config 命令 - 配置管理 (对标 OpenClaw handleConfigCommand)
+}
 
-		memPath := filepath.Join(home, ".ngoclaw", "memory.json")
-		data, err := os.ReadFile(memPath)
-		if err != nil {
-			return &OutgoingMessage{
-				ChatID:    cmd.ChatID,
-				Text:      "🧠 记忆库为空\n\n对话中使用 👍 表情或 save_memory 工具来存储记忆。",
-				ParseMode: "HTML",
-			}, nil
+// formatWorkflowList 渲染 /workflow list: 名称、说明与各 stage, 以及定义无效的文件
+func formatWorkflowList(loc i18n.Locale, runner WorkflowRunner) string {
+	workflows, errs := runner.List()
+	var sb strings.Builder
+	if len(workflows) == 0 {
+		sb.WriteString(loc.T("workflow.none"))
+	} else {
+		sb.WriteString(loc.T("workflow.title"))
+	}
+	for _, w := range workflows {
+		stages := make([]string, len(w.Stages))
+		for i, st := range w.Stages {
+			stages[i] = st.Name
 		}
+		sb.WriteString("\n\n• <code>" + html.EscapeString(w.Name) + "</code>")
+		if w.Description != "" {
+			sb.WriteString(" — " + html.EscapeString(w.Description))
+		}
+		sb.WriteString("\n  " + html.EscapeString(strings.Join(stages, " → ")))
+	}
+	for _, err := range errs {
+		sb.WriteString("\n\n" + loc.Tf("workflow.invalid", html.EscapeString(err.Error())))
+	}
+	sb.WriteString("\n\n" + loc.T("workflow.usage"))
+	return sb.String()
+}
 
-		var store struct {
-			Facts []struct {
-				Content    string  `json:"content"`
-				Category   string  `json:"category"`
-				Confidence float64 `json:"confidence"`
-				CreatedAt  string  `json:"created_at"`
-			} `json:"facts"`
+// cronStatus 渲染 /cron status: 本 chat 的定时任务和发往本 chat 的心跳
+func (r *CommandRegistry) cronStatus(chatID int64) string {
+	loc := r.localeFor(chatID)
+	var sb strings.Builder
+	sb.WriteString(loc.T("cron.status.title"))
+
+	var jobs []*CronJob
+	if r.cronService != nil {
+		jobs = r.cronService.List(chatID)
+	}
+	for _, j := range jobs {
+		sb.WriteString("\n\n" + loc.Tf("cron.status.job", html.EscapeString(j.ID), html.EscapeString(j.CronExpr), html.EscapeString(j.Command)))
+		sb.WriteString(scheduleStatusLines(loc, j.NextRun, j.LastRun, j.LastStatus, j.LastError, j.Missed, r.cronService.Policy(j).String()))
+	}
+
+	var hb *entity.Schedule
+	if r.heartbeat != nil {
+		if s := r.heartbeat.Status(); s != nil && (s.ChatID == chatID || s.ChatID == 0) {
+			hb = s
 		}
-		if err := json.Unmarshal(data, &store); err != nil {
-			return &OutgoingMessage{
-				ChatID:    cmd.ChatID,
-				Text:      fmt.Sprintf("❌ 记忆文件格式错误: %s", err.Error()),
-				ParseMode: "HTML",
-			}, nil
+	}
+	if hb != nil {
+		sb.WriteString("\n\n" + loc.Tf("cron.status.heartbeat", html.EscapeString(hb.Expr), html.EscapeString(hb.Command)))
+		if !hb.Enabled {
+			sb.WriteString("\n  " + loc.T("cron.status.disabled"))
+		} else {
+			policy := hb.CatchUp
+			if policy == "" {
+				policy = "once"
+			}
+			sb.WriteString(scheduleStatusLines(loc, hb.NextRun, hb.LastRun, hb.LastStatus, hb.LastError, hb.Missed, policy))
 		}
+	}
 
-		if len(store.Facts) == 0 {
-			return &OutgoingMessage{
-				ChatID:    cmd.ChatID,
-				Text:      "🧠 记忆库为空",
-				ParseMode: "HTML",
-			}, nil
+	if len(jobs) == 0 && hb == nil {
+		sb.WriteString("\n\n" + loc.T("cron.status.empty"))
+	}
+	return sb.String()
+}
+
+// scheduleStatusLines 下次运行、上次结果与停机期间错过的运行
+func scheduleStatusLines(loc i18n.Locale, next, last time.Time, status, lastErr string, missed int, policy string) string {
+	const layout = "2006-01-02 15:04"
+	var sb strings.Builder
+	if !next.IsZero() {
+		sb.WriteString("\n  " + loc.Tf("cron.status.next", next.Local().Format(layout), policy))
+	}
+	switch {
+	case last.IsZero():
+		sb.WriteString("\n  " + loc.T("cron.status.never"))
+	case status == entity.ScheduleFailed:
+		sb.WriteString("\n  " + loc.Tf("cron.status.last_error", last.Local().Format(layout), html.EscapeString(truncate(lastErr, 200))))
+	default:
+		sb.WriteString("\n  " + loc.Tf("cron.status.last_ok", last.Local().Format(layout)))
+	}
+	if missed > 0 {
+		key := "cron.status.missed"
+		if status == entity.ScheduleSkipped {
+			key = "cron.status.missed_skipped"
+		}
+		sb.WriteString("\n  " + loc.Tf(key, missed))
+	}
+	return sb.String()
+}
+
+// agentNameRe 可创建的代理名: 同时用作代理 ID
+var agentNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// findAgent 按 ID 或名称查找代理
+func findAgent(ctx context.Context, repo repository.AgentRepository, ref string) (*entity.Agent, error) {
+	if agent, err := repo.FindByID(ctx, ref); err == nil {
+		return agent, nil
+	}
+	return repo.FindByName(ctx, ref)
+}
+
+// updateAgent 按 /agent set 修改代理的一个字段; 返回非空时为给用户的错误提示
+func (r *CommandRegistry) updateAgent(loc i18n.Locale, agent *entity.Agent, field, value string) string {
+	cfg := agent.ModelConfig()
+	reset := value == "" || value == "default" || value == "reset" || value == "all"
+	switch field {
+	case "persona", "prompt":
+		if value == "default" || value == "reset" {
+			value = ""
+		}
+		if utf8.RuneCountInString(value) > maxAgentPersona {
+			return loc.Tf("agent.persona_too_long", maxAgentPersona)
+		}
+		agent.UpdatePersona(value)
+	case "tools":
+		var tools []string
+		if !reset {
+			for _, name := range strings.FieldsFunc(value, func(c rune) bool { return c == ',' || c == ' ' || c == '\n' }) {
+				if !r.knownTool(name) {
+					return loc.Tf("agent.unknown_tool", html.EscapeString(name))
+				}
+				tools = append(tools, name)
+			}
+		}
+		agent.RestrictTools(tools)
+	case "model":
+		provider, model := "", ""
+		if !reset {
+			id := resolveAgentModel(r.sessionManager.GetAvailableModels(), value)
+			if id == "" {
+				return loc.Tf("agent.unknown_model", html.EscapeString(value))
+			}
+			if i := strings.Index(id, "/"); i > 0 {
+				provider, model = id[:i], id[i+1:]
+			} else {
+				model = id
+			}
+		}
+		agent.UpdateModelConfig(valueobject.NewModelConfig(provider, model, cfg.MaxTokens(), cfg.Temperature(), cfg.TopP(), cfg.Stream()))
+	case "temperature", "temp":
+		var params service.ModelParams
+		if err := params.SetModelParam("temperature", value); err != nil {
+			return loc.Tf("agent.error", html.EscapeString(err.Error()))
 		}
+		agent.UpdateModelConfig(cfg.WithTemperature(params.Temperature))
+	default:
+		return loc.T("agent.usage")
+	}
+	return ""
+}
 
-		// Show last 10 memories (newest first)
-		limit := 10
-		if len(store.Facts) < limit {
-			limit = len(store.Facts)
+// maxAgentPersona 人设提示词的长度上限 (字符)
+const maxAgentPersona = 4000
+
+// knownTool 工具是否已注册; 没有工具目录时不校验
+func (r *CommandRegistry) knownTool(name string) bool {
+	if r.toolCatalog == nil {
+		return true
+	}
+	catalog := r.toolCatalog()
+	if catalog == nil {
+		return true
+	}
+	for _, t := range catalog.Tools {
+		if t.Name == name {
+			return true
 		}
+	}
+	return false
+}
 
-		var sb strings.Builder
-		sb.WriteString(fmt.Sprintf("🧠 <b>长期记忆</b> (%d 条)\n\n", len(store.Facts)))
-		for i := len(store.Facts) - 1; i >= len(store.Facts)-limit; i-- {
-			fact := store.Facts[i]
-			catIcon := "💡"
-			switch fact.Category {
-			case "preference":
-				catIcon = "⚙️"
-			case "project":
-				catIcon = "📂"
-			case "environment":
-				catIcon = "🖥️"
-			case "skill":
-				catIcon = "🎯"
-			}
-			content := fact.Content
-			if len(content) > 80 {
-				content = content[:80] + "..."
-			}
-			sb.WriteString(fmt.Sprintf("%s %s\n", catIcon, content))
-		}
-		if len(store.Facts) > limit {
-			sb.WriteString(fmt.Sprintf("\n<i>...共 %d 条记忆</i>", len(store.Facts)))
+// resolveAgentModel 按 ID 或别名 (不区分大小写) 匹配已配置的模型
+func resolveAgentModel(models []ModelInfo, input string) string {
+	for _, m := range models {
+		if m.ID == input || (m.Alias != "" && strings.EqualFold(m.Alias, input)) {
+			return m.ID
 		}
+	}
+	return ""
+}
 
-		return &OutgoingMessage{
-			ChatID:    cmd.ChatID,
-			Text:      sb.String(),
-			ParseMode: "HTML",
-		}, nil
-	})
+// formatAgentList 渲染 /agent list: 每个代理一行, 标出当前会话的选择
+func formatAgentList(loc i18n.Locale, agents []*entity.Agent, current string) string {
+	if current == "" {
+		current = entity.DefaultAgentID
+	}
+	var sb strings.Builder
+	sb.WriteString(loc.T("agent.title") + "\n\n")
+	for _, a := range agents {
+		mark := "•"
+		if a.ID() == current {
+			mark = "▶"
+		}
+		sb.WriteString(fmt.Sprintf("%s <code>%s</code> — %s\n", mark, html.EscapeString(a.ID()), html.EscapeString(a.Name())))
+	}
+	sb.WriteString("\n" + loc.T("agent.usage"))
+	return sb.String()
+}
 
-	// /config 命令 - 配置管理 (对标 OpenClaw handleConfigCommand)
+// formatAgent 渲染单个代理的配置
+func formatAgent(loc i18n.Locale, agent *entity.Agent) string {
+	if agent.ID() == entity.DefaultAgentID {
+		return loc.Tf("agent.default_info", html.EscapeString(agent.Name()))
+	}
+	p := service.AgentProfileOf(agent)
+	model := loc.T("agent.chat_model")
+	if p.Model != "" {
+		model = "<code>" + html.EscapeString(p.Model) + "</code>"
+	}
+	temperature := loc.T("agent.model_default")
+	if p.Temperature > 0 {
+		temperature = fmt.Sprintf("%g", p.Temperature)
+	}
+	tools := loc.T("agent.all_tools")
+	if len(p.Tools) > 0 {
+		tools = "<code>" + html.EscapeString(strings.Join(p.Tools, ", ")) + "</code>"
+	}
+	persona := loc.T("agent.none")
+	if p.Persona != "" {
+		persona = "<i>" + html.EscapeString(truncateLabel(p.Persona, 300)) + "</i>"
+	}
+	return loc.Tf("agent.detail", html.EscapeString(p.Name), model, temperature, tools, persona)
 }
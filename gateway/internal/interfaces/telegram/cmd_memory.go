@@ -0,0 +1,280 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	toolpkg "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tool"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
+)
+
+// memoryConfirmTTL 修改/删除确认按钮的有效期
+const memoryConfirmTTL = 10 * time.Minute
+
+// pendingMemoryOp 等待用户点击确认的记忆修改
+type pendingMemoryOp struct {
+	chatID  int64
+	action  string // edit | delete
+	factID  string
+	content string // edit 的新内容
+	expires time.Time
+}
+
+// memoryConfirmations 待确认的记忆修改 (新内容太长放不进 callback data, 按 token 暂存)
+type memoryConfirmations struct {
+	mu      sync.Mutex
+	pending map[string]pendingMemoryOp
+}
+
+func (c *memoryConfirmations) put(op pendingMemoryOp) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for token, p := range c.pending {
+		if now.After(p.expires) {
+			delete(c.pending, token)
+		}
+	}
+	op.expires = now.Add(memoryConfirmTTL)
+	token := strconv.FormatInt(now.UnixNano(), 36)
+	c.pending[token] = op
+	return token
+}
+
+// take 取出并移除 token 对应的操作; 过期或不属于该 chat 时返回 false
+func (c *memoryConfirmations) take(token string, chatID int64) (pendingMemoryOp, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	op, ok := c.pending[token]
+	if !ok || op.chatID != chatID {
+		return pendingMemoryOp{}, false
+	}
+	delete(c.pending, token)
+	return op, time.Now().Before(op.expires)
+}
+
+// registerMemoryCommands registers /memory (list, add, edit, delete, audit) and /recall
+func (a *Adapter) registerMemoryCommands(registry *CommandRegistry) {
+	confirms := &memoryConfirmations{pending: make(map[string]pendingMemoryOp)}
+
+	// /memory [add|edit|delete|audit] — 查看与整理长期记忆 (~/.ngoclaw/memory.json + 每日日志)
+	registry.Register("memory", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		loc := registry.localeFor(cmd.ChatID)
+		reply := func(text string) (*OutgoingMessage, error) {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: text, ParseMode: "HTML"}, nil
+		}
+		actor := fmt.Sprintf("tg:%d", cmd.UserID)
+
+		sub, rest := "", ""
+		if len(cmd.Args) > 0 {
+			sub = strings.ToLower(cmd.Args[0])
+			rest = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(cmd.RawArgs), cmd.Args[0]))
+		}
+		switch sub {
+		case "", "list":
+			return reply(formatMemoryList(loc))
+
+		case "add":
+			category, content := parseMemoryAdd(rest)
+			if content == "" {
+				return reply(loc.T("memory.usage"))
+			}
+			fact, err := toolpkg.AddMemoryFact(content, category, actor, cmd.ChatID)
+			if err != nil {
+				return reply(loc.Tf("memory.error", html.EscapeString(err.Error())))
+			}
+			return reply(loc.Tf("memory.added", fact.ID, fact.Category, html.EscapeString(fact.Content)))
+
+		case "edit", "delete", "del", "rm":
+			if len(cmd.Args) < 2 {
+				return reply(loc.T("memory.usage"))
+			}
+			id := cmd.Args[1]
+			fact, err := toolpkg.FindMemoryFact(id)
+			if errors.Is(err, toolpkg.ErrFactNotFound) {
+				return reply(loc.Tf("memory.not_found", html.EscapeString(id)))
+			}
+			if err != nil {
+				return reply(loc.Tf("memory.error", html.EscapeString(err.Error())))
+			}
+
+			op := pendingMemoryOp{chatID: cmd.ChatID, action: "delete", factID: id}
+			text := loc.Tf("memory.confirm_delete", id, html.EscapeString(fact.Content))
+			if sub == "edit" {
+				content := strings.TrimSpace(strings.TrimPrefix(rest, id))
+				if content == "" {
+					return reply(loc.T("memory.usage"))
+				}
+				op.action, op.content = "edit", content
+				text = loc.Tf("memory.confirm_edit", id, html.EscapeString(fact.Content), html.EscapeString(content))
+			}
+			token := confirms.put(op)
+			keyboard := BuildInlineKeyboard([][]InlineButton{{
+				{Text: loc.T("memory.confirm_btn"), CallbackData: "/memory confirm " + token},
+				{Text: loc.T("memory.cancel_btn"), CallbackData: "/memory cancel " + token},
+			}})
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: text, ParseMode: "HTML", ReplyMarkup: &keyboard}, nil
+
+		case "confirm", "cancel":
+			if len(cmd.Args) < 2 {
+				return reply(loc.T("memory.expired"))
+			}
+			op, ok := confirms.take(cmd.Args[1], cmd.ChatID)
+			if sub == "cancel" {
+				return reply(loc.T("memory.cancelled"))
+			}
+			if !ok {
+				return reply(loc.T("memory.expired"))
+			}
+			if op.action == "edit" {
+				if _, err := toolpkg.EditMemoryFact(op.factID, op.content, actor); err != nil {
+					return reply(loc.Tf("memory.error", html.EscapeString(err.Error())))
+				}
+				return reply(loc.Tf("memory.edited", op.factID, html.EscapeString(op.content)))
+			}
+			fact, err := toolpkg.DeleteMemoryFact(op.factID, actor)
+			if err != nil {
+				return reply(loc.Tf("memory.error", html.EscapeString(err.Error())))
+			}
+			return reply(loc.Tf("memory.deleted", op.factID, html.EscapeString(fact.Content)))
+
+		case "audit", "log":
+			return reply(formatMemoryAudit(loc))
+		}
+		return reply(loc.T("memory.usage"))
+	})
+
+	// /recall <关键词> — 全文检索本 chat 过往的运行、转录与产物
+	registry.Register("recall", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		loc := registry.localeFor(cmd.ChatID)
+		reply := func(text string) (*OutgoingMessage, error) {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: text, ParseMode: "HTML"}, nil
+		}
+		query := strings.TrimSpace(cmd.RawArgs)
+		if query == "" {
+			return reply(loc.T("recall.usage"))
+		}
+		registry.mu.RLock()
+		searcher := registry.pastRuns
+		registry.mu.RUnlock()
+		if searcher == nil {
+			return reply(loc.T("recall.unavailable"))
+		}
+		hits, err := searcher.Search(query, cmd.ChatID, 8)
+		if err != nil {
+			return reply(loc.Tf("recall.error", html.EscapeString(err.Error())))
+		}
+		return reply(formatRecallHits(loc, query, hits))
+	})
+}
+
+// parseMemoryAdd 解析 "/memory add [类别:] 内容"
+func parseMemoryAdd(args string) (category, content string) {
+	if head, rest, ok := strings.Cut(args, ":"); ok && toolpkg.ValidCategories[strings.ToLower(strings.TrimSpace(head))] {
+		return strings.ToLower(strings.TrimSpace(head)), strings.TrimSpace(rest)
+	}
+	return "knowledge", args
+}
+
+// formatRecallHits 渲染 /recall 结果: 类型、时间、标题、引用与摘录
+func formatRecallHits(loc i18n.Locale, query string, hits []toolpkg.ArchiveHit) string {
+	if len(hits) == 0 {
+		return loc.Tf("recall.none", html.EscapeString(query))
+	}
+	var sb strings.Builder
+	sb.WriteString(loc.Tf("recall.title", html.EscapeString(query), len(hits)) + "\n")
+	for i, h := range hits {
+		icon := "📄"
+		switch h.Kind {
+		case "run":
+			icon = "▶️"
+		case "transcript":
+			icon = "📝"
+		}
+		sb.WriteString(fmt.Sprintf("\n%d. %s %s · %s\n<code>%s</code>\n<i>%s</i>\n",
+			i+1, icon, h.Time.Format("2006-01-02 15:04"), html.EscapeString(h.Title),
+			html.EscapeString(h.Ref), html.EscapeString(h.Snippet)))
+	}
+	sb.WriteString("\n" + loc.T("recall.hint"))
+	return sb.String()
+}
+
+// formatMemoryList 列出最新 10 条记忆 (带 ID, 供 edit/delete 引用)
+func formatMemoryList(loc i18n.Locale) string {
+	store, err := toolpkg.LoadMemoryStore()
+	if err != nil {
+		return loc.Tf("memory.error", html.EscapeString(err.Error()))
+	}
+	if len(store.Facts) == 0 {
+		return loc.T("memory.empty")
+	}
+
+	limit := 10
+	if len(store.Facts) < limit {
+		limit = len(store.Facts)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(loc.Tf("memory.title", len(store.Facts)) + "\n\n")
+	for i := len(store.Facts) - 1; i >= len(store.Facts)-limit; i-- {
+		fact := store.Facts[i]
+		catIcon := "💡"
+		switch fact.Category {
+		case "preference":
+			catIcon = "⚙️"
+		case "context":
+			catIcon = "📂"
+		case "behavior":
+			catIcon = "🎯"
+		case "goal":
+			catIcon = "🏁"
+		}
+		content := []rune(fact.Content)
+		if len(content) > 80 {
+			content = append(content[:80], []rune("...")...)
+		}
+		sb.WriteString(fmt.Sprintf("%s <code>%s</code> %s\n", catIcon, fact.ID, html.EscapeString(string(content))))
+	}
+	if len(store.Facts) > limit {
+		sb.WriteString("\n" + loc.Tf("memory.more", len(store.Facts), limit))
+	}
+	sb.WriteString("\n" + loc.T("memory.hint"))
+	return sb.String()
+}
+
+// formatMemoryAudit 最近 10 条记忆修改记录
+func formatMemoryAudit(loc i18n.Locale) string {
+	entries, err := toolpkg.ReadMemoryAudit(10)
+	if err != nil {
+		return loc.Tf("memory.error", html.EscapeString(err.Error()))
+	}
+	if len(entries) == 0 {
+		return loc.T("memory.audit_empty")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(loc.Tf("memory.audit_title", len(entries)) + "\n")
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		when := e.Time
+		if t, err := time.Parse(time.RFC3339, e.Time); err == nil {
+			when = t.Format("01-02 15:04")
+		}
+		icon, text := "➕", e.After
+		switch e.Action {
+		case "edit":
+			icon = "✏️"
+		case "delete":
+			icon, text = "🗑", e.Before
+		}
+		sb.WriteString(fmt.Sprintf("\n%s %s <code>%s</code> %s <i>(%s)</i>",
+			when, icon, e.FactID, html.EscapeString(text), html.EscapeString(e.Actor)))
+	}
+	return sb.String()
+}
@@ -3,23 +3,34 @@ package telegram
 import (
 	"context"
 	"fmt"
+	"html"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
 )
 
-// registerModelCommands registers model selection: models, usage
+// modelProbeTimeout 🧪 测试按钮的单次探测超时
+const modelProbeTimeout = 20 * time.Second
+
+// registerModelCommands registers model selection: models, route, usage
 func (a *Adapter) registerModelCommands(registry *CommandRegistry) {
 	// _setmodel — internal handler for inline keyboard callbacks only (not user-facing)
 	registry.Register("_setmodel", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		loc := registry.localeFor(cmd.ChatID)
 		modelArg := strings.Join(cmd.Args, " ")
 		if modelArg == "" {
-			return &OutgoingMessage{ChatID: cmd.ChatID, Text: "⚠️ 未指定模型", ParseMode: "HTML"}, nil
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.T("models.unspecified"), ParseMode: "HTML"}, nil
 		}
 
 		if registry.sessionManager != nil {
 			if err := registry.sessionManager.SetModel(cmd.ChatID, modelArg); err != nil {
 				return &OutgoingMessage{
 					ChatID:    cmd.ChatID,
-					Text:      fmt.Sprintf("❌ 切换模型失败: %s", err.Error()),
+					Text:      loc.Tf("models.switch_failed", html.EscapeString(err.Error())),
 					ParseMode: "HTML",
 				}, nil
 			}
@@ -27,7 +38,45 @@ func (a *Adapter) registerModelCommands(registry *CommandRegistry) {
 
 		return &OutgoingMessage{
 			ChatID:    cmd.ChatID,
-			Text:      fmt.Sprintf("✅ 已切换到模型: <code>%s</code>", modelArg),
+			Text:      loc.Tf("models.switched", html.EscapeString(modelArg)),
+			ParseMode: "HTML",
+		}, nil
+	})
+
+	// _probemodel — inline keyboard 🧪 按钮: 先发 1-token 请求探测, 成功才切换
+	registry.Register("_probemodel", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		loc := registry.localeFor(cmd.ChatID)
+		modelArg := strings.Join(cmd.Args, " ")
+		if modelArg == "" {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.T("models.unspecified"), ParseMode: "HTML"}, nil
+		}
+		if registry.modelProber == nil {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.T("models.probe_unavailable"), ParseMode: "HTML"}, nil
+		}
+
+		probeCtx, cancel := context.WithTimeout(ctx, modelProbeTimeout)
+		defer cancel()
+		latency, err := registry.modelProber.Probe(probeCtx, modelArg)
+		if err != nil {
+			return &OutgoingMessage{
+				ChatID:    cmd.ChatID,
+				Text:      loc.Tf("models.probe_failed", html.EscapeString(modelArg), latency.Milliseconds(), html.EscapeString(err.Error())),
+				ParseMode: "HTML",
+			}, nil
+		}
+
+		if registry.sessionManager != nil {
+			if err := registry.sessionManager.SetModel(cmd.ChatID, modelArg); err != nil {
+				return &OutgoingMessage{
+					ChatID:    cmd.ChatID,
+					Text:      loc.Tf("models.switch_failed", html.EscapeString(err.Error())),
+					ParseMode: "HTML",
+				}, nil
+			}
+		}
+		return &OutgoingMessage{
+			ChatID:    cmd.ChatID,
+			Text:      loc.Tf("models.probe_ok", html.EscapeString(modelArg), latency.Milliseconds()),
 			ParseMode: "HTML",
 		}, nil
 	})
@@ -74,7 +123,7 @@ func (a *Adapter) registerModelCommands(registry *CommandRegistry) {
 		// 无 provider 参数：显示当前模型 + 提供商选择
 		if provider == "" {
 			keyboard := BuildProviderKeyboard(providers)
-			text := fmt.Sprintf("🤖 当前: <code>%s</code>\n\n📋 选择提供商:", currentModel)
+			text := registry.localeFor(cmd.ChatID).Tf("models.current", html.EscapeString(currentModel))
 			return &OutgoingMessage{
 				ChatID:      cmd.ChatID,
 				Text:        text,
@@ -95,10 +144,16 @@ func (a *Adapter) registerModelCommands(registry *CommandRegistry) {
 
 		const pageSize = 6
 		keyboard := BuildModelsKeyboard(provider, providerModels, currentModel, page, pageSize)
+		pageModels, _, _ := pageSlice(providerModels, page, pageSize)
+
+		var stats []entity.ModelStats
+		if registry.modelStats != nil {
+			stats = registry.modelStats.Snapshot()
+		}
 
 		return &OutgoingMessage{
 			ChatID:      cmd.ChatID,
-			Text:        fmt.Sprintf("📋 <b>%s</b> 模型:", provider),
+			Text:        formatModelsPage(registry.localeFor(cmd.ChatID), provider, pageModels, currentModel, stats),
 			ParseMode:   "HTML",
 			ReplyMarkup: &keyboard,
 		}, nil
@@ -108,6 +163,17 @@ func (a *Adapter) registerModelCommands(registry *CommandRegistry) {
 	registry.Register("usage", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
 		if len(cmd.Args) > 0 {
 			arg := strings.ToLower(cmd.Args[0])
+			if arg == "quota" {
+				loc := registry.localeFor(cmd.ChatID)
+				if registry.budgetReporter == nil {
+					return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.T("budget.none"), ParseMode: "HTML"}, nil
+				}
+				report, err := registry.budgetReporter.Report(ctx, BudgetSubject(cmd.ChatID, cmd.UserID))
+				if err != nil {
+					return nil, err
+				}
+				return &OutgoingMessage{ChatID: cmd.ChatID, Text: formatBudgetReport(loc, report), ParseMode: "HTML"}, nil
+			}
 			if arg == "cost" {
 				return &OutgoingMessage{
 					ChatID:    cmd.ChatID,
@@ -119,7 +185,7 @@ func (a *Adapter) registerModelCommands(registry *CommandRegistry) {
 			if !validModes[arg] {
 				return &OutgoingMessage{
 					ChatID:    cmd.ChatID,
-					Text:      "⚙️ 用法: /usage off|tokens|full|cost",
+					Text:      "⚙️ 用法: /usage off|tokens|full|cost|quota",
 					ParseMode: "HTML",
 				}, nil
 			}
@@ -153,7 +219,212 @@ func (a *Adapter) registerModelCommands(registry *CommandRegistry) {
 		}, nil
 	})
 
+	// /route 命令 - 按任务自动选模型 (agent.routing), 会话级开关覆盖配置默认
+	registry.Register("route", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		loc := registry.localeFor(cmd.ChatID)
+		rs, ok := registry.sessionManager.(RouteSettings)
+		if !ok || registry.modelRouter == nil || len(registry.modelRouter.Rules()) == 0 {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.T("route.unavailable"), ParseMode: "HTML"}, nil
+		}
+		if len(cmd.Args) > 0 {
+			switch mode := strings.ToLower(cmd.Args[0]); mode {
+			case "on", "off":
+				rs.SetRouteMode(cmd.ChatID, mode)
+			case "default", "reset":
+				rs.SetRouteMode(cmd.ChatID, "")
+			default:
+				return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.T("route.usage"), ParseMode: "HTML"}, nil
+			}
+		}
+		return &OutgoingMessage{
+			ChatID:    cmd.ChatID,
+			Text:      formatRouteStatus(loc, registry.modelRouter.Rules(), rs.GetRouteMode(cmd.ChatID), registry.routeDefault, rs.GetLastRoute(cmd.ChatID)),
+			ParseMode: "HTML",
+		}, nil
+	})
+
+	// /draft 命令 - 草稿模式: 便宜模型起草, 当前 (昂贵) 模型只审核
+	registry.Register("draft", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		loc := registry.localeFor(cmd.ChatID)
+		ds, ok := registry.sessionManager.(DraftSettings)
+		if !ok || registry.draftPolicy == nil {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.T("draft.unavailable"), ParseMode: "HTML"}, nil
+		}
+		if len(cmd.Args) > 0 {
+			switch mode := strings.ToLower(cmd.Args[0]); mode {
+			case "on", "off":
+				ds.SetDraftMode(cmd.ChatID, mode)
+			case "default", "reset":
+				ds.SetDraftMode(cmd.ChatID, "")
+			default:
+				return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.T("draft.usage"), ParseMode: "HTML"}, nil
+			}
+		}
+		return &OutgoingMessage{
+			ChatID:    cmd.ChatID,
+			Text:      formatDraftStatus(loc, *registry.draftPolicy, ds.GetDraftMode(cmd.ChatID), registry.draftDefault, registry.sessionManager.GetCurrentModel(cmd.ChatID)),
+			ParseMode: "HTML",
+		}, nil
+	})
+
 	// Aliases — /model redirects to /models for backward compat
 	registry.Alias("m", "models")
 	registry.Alias("model", "models")
 }
+
+// formatModelsPage 渲染 /models <provider> 的说明文字: 每个模型的能力徽章与
+// 近期调用统计 (p50 延迟、错误率), 数据来自 Router 的模型统计
+func formatModelsPage(loc i18n.Locale, provider string, models []ModelInfo, currentModel string, stats []entity.ModelStats) string {
+	// 同一模型可能经多个 provider 路由, 按模型 ID 聚合, 延迟取请求最多的一路
+	// (busiest 记录该路的请求数; agg.Requests 是各路之和, 不能用来比较)
+	byModel := make(map[string]entity.ModelStats)
+	busiest := make(map[string]int64)
+	for _, s := range stats {
+		agg, ok := byModel[s.Model]
+		if !ok || s.Requests > busiest[s.Model] {
+			agg.LatencyP50Ms = s.LatencyP50Ms
+			busiest[s.Model] = s.Requests
+		}
+		agg.Model = s.Model
+		agg.Requests += s.Requests
+		agg.Failures += s.Failures
+		byModel[s.Model] = agg
+	}
+
+	var sb strings.Builder
+	sb.WriteString(loc.Tf("models.title", html.EscapeString(provider)))
+	sb.WriteString("\n")
+	for _, m := range models {
+		marker := "•"
+		if m.ID == currentModel {
+			marker = "✓"
+		}
+		sb.WriteString(fmt.Sprintf("\n%s <b>%s</b> %s\n", marker, html.EscapeString(modelLabel(m)), capabilityBadges(m.Capabilities)))
+		sb.WriteString(fmt.Sprintf("   <code>%s</code> · ", html.EscapeString(m.ID)))
+		if s, ok := byModel[m.ID]; ok && s.Requests > 0 {
+			sb.WriteString(loc.Tf("models.stats", s.LatencyP50Ms, s.ErrorRate()*100, s.Requests))
+		} else {
+			sb.WriteString(loc.T("models.no_stats"))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n<i>" + loc.T("models.legend") + "</i>")
+	return sb.String()
+}
+
+// formatRouteStatus 渲染 /route: 当前开关、路由表与最近一次决定
+func formatRouteStatus(loc i18n.Locale, rules []service.RouteRule, mode string, enabledByDefault bool, last string) string {
+	state := mode
+	if state == "" {
+		state = "off"
+		if enabledByDefault {
+			state = "on"
+		}
+		state = loc.Tf("route.default", loc.T("route."+state))
+	} else {
+		state = loc.T("route." + state)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(loc.Tf("route.status", state))
+	sb.WriteString("\n\n" + loc.T("route.rules") + "\n")
+	for i, rule := range rules {
+		var conds []string
+		if len(rule.Intents) > 0 {
+			conds = append(conds, strings.Join(rule.Intents, "|"))
+		}
+		if rule.Images {
+			conds = append(conds, "images")
+		}
+		if rule.MinTokens > 0 {
+			conds = append(conds, fmt.Sprintf("≥%d tokens", rule.MinTokens))
+		}
+		if rule.MaxTokens > 0 {
+			conds = append(conds, fmt.Sprintf("≤%d tokens", rule.MaxTokens))
+		}
+		if len(conds) == 0 {
+			conds = append(conds, "*")
+		}
+		sb.WriteString(fmt.Sprintf("%d. <b>%s</b> · %s → <code>%s</code>\n",
+			i+1, html.EscapeString(rule.Name), html.EscapeString(strings.Join(conds, " · ")), html.EscapeString(rule.Model)))
+	}
+	if last != "" {
+		sb.WriteString("\n" + loc.Tf("route.last", html.EscapeString(last)) + "\n")
+	}
+	sb.WriteString("\n" + loc.T("route.usage"))
+	return sb.String()
+}
+
+// formatDraftStatus 渲染 /draft: 当前开关、起草模型与当前模型是否启用草稿
+func formatDraftStatus(loc i18n.Locale, p service.DraftPolicy, mode string, enabledByDefault bool, currentModel string) string {
+	state := mode
+	if state == "" {
+		state = "off"
+		if enabledByDefault {
+			state = "on"
+		}
+		state = loc.Tf("route.default", loc.T("route."+state))
+	} else {
+		state = loc.T("route." + state)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(loc.Tf("draft.status", state))
+	sb.WriteString("\n\n" + loc.Tf("draft.model", html.EscapeString(p.Model)))
+	if len(p.Models) > 0 {
+		sb.WriteString("\n" + loc.Tf("draft.models", html.EscapeString(strings.Join(p.Models, ", "))))
+	}
+	if currentModel != "" && !p.AppliesTo(currentModel) {
+		sb.WriteString("\n" + loc.Tf("draft.not_applied", html.EscapeString(currentModel)))
+	}
+	sb.WriteString("\n\n" + loc.T("draft.usage"))
+	return sb.String()
+}
+
+// BudgetSubject 返回 Telegram 消息在额度池中的归属 (用户与会话)
+func BudgetSubject(chatID, userID int64) service.BudgetSubject {
+	subject := service.BudgetSubject{Chat: strconv.FormatInt(chatID, 10)}
+	if userID != 0 {
+		subject.User = strconv.FormatInt(userID, 10)
+	}
+	return subject
+}
+
+// FormatBudgetRefusal 渲染额度用完时对新消息的答复: 哪个池、剩余额度与重置时间
+func FormatBudgetRefusal(loc i18n.Locale, status service.BudgetStatus) string {
+	return loc.Tf("budget.refused", html.EscapeString(status.Pool.Name),
+		formatBudgetUsage(loc, status), status.Resets.Format("2006-01-02 15:04"))
+}
+
+// formatBudgetReport 渲染 /usage quota: 每个适用的额度池一段
+func formatBudgetReport(loc i18n.Locale, report []service.BudgetStatus) string {
+	if len(report) == 0 {
+		return loc.T("budget.none")
+	}
+	var sb strings.Builder
+	sb.WriteString(loc.T("budget.title"))
+	for _, status := range report {
+		pool := status.Pool
+		sb.WriteString("\n\n" + loc.Tf("budget.pool", html.EscapeString(pool.Name),
+			loc.T("budget.scope."+string(pool.Scope)), loc.T("budget.window."+string(pool.Window))))
+		if status.Exhausted() {
+			sb.WriteString(" " + loc.T("budget.exhausted"))
+		}
+		sb.WriteString("\n" + formatBudgetUsage(loc, status))
+		sb.WriteString("\n" + loc.Tf("budget.resets", status.Resets.Format("2006-01-02 15:04")))
+	}
+	return sb.String()
+}
+
+// formatBudgetUsage 渲染一个池的已用/上限/剩余 (只列出配置了的上限)
+func formatBudgetUsage(loc i18n.Locale, status service.BudgetStatus) string {
+	var lines []string
+	if status.Pool.Tokens > 0 {
+		lines = append(lines, loc.Tf("budget.tokens", formatTokenCount(int(status.Tokens)),
+			formatTokenCount(int(status.Pool.Tokens)), formatTokenCount(int(status.RemainingTokens()))))
+	}
+	if status.Pool.USD > 0 {
+		lines = append(lines, loc.Tf("budget.usd", status.CostUSD, status.Pool.USD, status.RemainingUSD()))
+	}
+	return strings.Join(lines, "\n")
+}
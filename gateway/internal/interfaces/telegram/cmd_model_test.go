@@ -0,0 +1,131 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
+)
+
+func TestFormatModelsPage(t *testing.T) {
+	models := []ModelInfo{
+		{ID: "openai/gpt-4o", Alias: "4o", Capabilities: []string{"tools", "vision"}},
+		{ID: "deepseek/deepseek-chat"},
+	}
+	for _, tc := range []struct {
+		name    string
+		loc     i18n.Locale
+		stats   []entity.ModelStats
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "no stats",
+			loc:  i18n.EN,
+			want: []string{
+				"📋 <b>openai</b> models",
+				"✓ <b>4o</b> 🛠👁\n   <code>openai/gpt-4o</code> · no calls yet",
+				"• <b>deepseek-chat</b> \n   <code>deepseek/deepseek-chat</code> · no calls yet",
+			},
+		},
+		{
+			// p50 comes from the route with the most requests, not the last
+			// one whose count beats the running total
+			name: "routes aggregated",
+			loc:  i18n.EN,
+			stats: []entity.ModelStats{
+				{Provider: "a", Model: "openai/gpt-4o", Requests: 30, Failures: 3, LatencyP50Ms: 900},
+				{Provider: "b", Model: "openai/gpt-4o", Requests: 10, LatencyP50Ms: 100},
+				{Provider: "c", Model: "openai/gpt-4o", Requests: 35, LatencyP50Ms: 400},
+			},
+			want:    []string{"<code>openai/gpt-4o</code> · p50 400ms · errors 4.0% (75 calls)"},
+			notWant: []string{"p50 900ms", "p50 100ms"},
+		},
+		{
+			name:  "zero requests",
+			loc:   i18n.ZH,
+			stats: []entity.ModelStats{{Model: "deepseek/deepseek-chat"}},
+			want:  []string{"<code>deepseek/deepseek-chat</code> · 暂无调用记录"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := formatModelsPage(tc.loc, "openai", models, "openai/gpt-4o", tc.stats)
+			for _, w := range tc.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("missing %q in:\n%s", w, got)
+				}
+			}
+			for _, w := range tc.notWant {
+				if strings.Contains(got, w) {
+					t.Errorf("unexpected %q in:\n%s", w, got)
+				}
+			}
+		})
+	}
+}
+
+func TestPageSlice(t *testing.T) {
+	models := make([]ModelInfo, 7)
+	for i := range models {
+		models[i].ID = string(rune('a' + i))
+	}
+	for _, tc := range []struct {
+		page, pageSize int
+		wantIDs        string
+		wantPage       int
+		wantTotal      int
+	}{
+		{0, 3, "abc", 0, 3},
+		{2, 3, "g", 2, 3},
+		{9, 3, "g", 2, 3},
+		{-1, 3, "abc", 0, 3},
+		{0, 10, "abcdefg", 0, 1},
+	} {
+		got, page, total := pageSlice(models, tc.page, tc.pageSize)
+		var ids string
+		for _, m := range got {
+			ids += m.ID
+		}
+		if ids != tc.wantIDs || page != tc.wantPage || total != tc.wantTotal {
+			t.Errorf("pageSlice(page %d, size %d) = %q, %d, %d; want %q, %d, %d",
+				tc.page, tc.pageSize, ids, page, total, tc.wantIDs, tc.wantPage, tc.wantTotal)
+		}
+	}
+
+	if got, page, total := pageSlice(nil, 1, 3); len(got) != 0 || page != 0 || total != 0 {
+		t.Errorf("empty = %v, %d, %d", got, page, total)
+	}
+}
+
+func TestCapabilityBadges(t *testing.T) {
+	for _, tc := range []struct {
+		caps []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"tools"}, "🛠"},
+		{[]string{"reasoning", "tools", "vision"}, "🛠👁🧠"},
+		{[]string{"vision", "audio", "vision"}, "👁"},
+	} {
+		if got := capabilityBadges(tc.caps); got != tc.want {
+			t.Errorf("capabilityBadges(%v) = %q, want %q", tc.caps, got, tc.want)
+		}
+	}
+}
+
+func TestModelLabel(t *testing.T) {
+	for _, tc := range []struct {
+		model ModelInfo
+		want  string
+	}{
+		{ModelInfo{ID: "antigravity/gemini-3-flash", Alias: "Flash"}, "Flash"},
+		{ModelInfo{ID: "antigravity/gemini-3-flash"}, "gemini-3-flash"},
+		{ModelInfo{ID: "openrouter/meta/llama-3"}, "llama-3"},
+		{ModelInfo{ID: "gpt-4o"}, "gpt-4o"},
+	} {
+		if got := modelLabel(tc.model); got != tc.want {
+			t.Errorf("modelLabel(%+v) = %q, want %q", tc.model, got, tc.want)
+		}
+	}
+}
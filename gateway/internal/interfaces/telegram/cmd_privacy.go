@@ -0,0 +1,91 @@
+package telegram
+
+import (
+	"context"
+	"html"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// forgetConfirmTTL /forgetme 确认按钮的有效期
+const forgetConfirmTTL = 5 * time.Minute
+
+// forgetConfirmations 待确认的 /forgetme 请求 (chatID → token), 过期或已使用的按钮无效
+type forgetConfirmations struct {
+	mu      sync.Mutex
+	pending map[int64]pendingForget
+}
+
+type pendingForget struct {
+	token   string
+	expires time.Time
+}
+
+func (c *forgetConfirmations) put(chatID int64) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	token := strconv.FormatInt(now.UnixNano(), 36)
+	c.pending[chatID] = pendingForget{token: token, expires: now.Add(forgetConfirmTTL)}
+	return token
+}
+
+// take 取出 chat 的待确认请求; token 不匹配或已过期时返回 false
+func (c *forgetConfirmations) take(chatID int64, token string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, ok := c.pending[chatID]
+	if !ok || p.token != token {
+		return false
+	}
+	delete(c.pending, chatID)
+	return time.Now().Before(p.expires)
+}
+
+// registerPrivacyCommands registers /forgetme
+func (a *Adapter) registerPrivacyCommands(registry *CommandRegistry) {
+	confirms := &forgetConfirmations{pending: make(map[int64]pendingForget)}
+
+	// /forgetme — 删除本 chat 的全部数据 (需确认)
+	registry.Register("forgetme", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		loc := registry.localeFor(cmd.ChatID)
+		reply := func(text string) (*OutgoingMessage, error) {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: text, ParseMode: "HTML"}, nil
+		}
+		if registry.dataEraser == nil {
+			return reply(loc.T("forget.disabled"))
+		}
+
+		sub, token := "", ""
+		if len(cmd.Args) > 0 {
+			sub = cmd.Args[0]
+		}
+		if len(cmd.Args) > 1 {
+			token = cmd.Args[1]
+		}
+		switch sub {
+		case "cancel":
+			confirms.take(cmd.ChatID, token)
+			return reply(loc.T("forget.cancelled"))
+
+		case "confirm":
+			if !confirms.take(cmd.ChatID, token) {
+				return reply(loc.T("forget.expired"))
+			}
+			// 偏好 (含界面语言) 会被一并删除, 回复使用清除前的语言
+			n, err := registry.dataEraser.ForgetChat(ctx, cmd.ChatID)
+			if err != nil {
+				return reply(loc.Tf("forget.error", n, html.EscapeString(err.Error())))
+			}
+			return reply(loc.Tf("forget.done", n))
+		}
+
+		token = confirms.put(cmd.ChatID)
+		keyboard := BuildInlineKeyboard([][]InlineButton{{
+			{Text: loc.T("forget.confirm_btn"), CallbackData: "/forgetme confirm " + token},
+			{Text: loc.T("forget.cancel_btn"), CallbackData: "/forgetme cancel " + token},
+		}})
+		return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.T("forget.confirm"), ParseMode: "HTML", ReplyMarkup: &keyboard}, nil
+	})
+}
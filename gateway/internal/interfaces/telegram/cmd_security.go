@@ -19,8 +19,21 @@ type SecurityController interface {
 	TrustCommand(cmd string)
 }
 
+// SecurityProfileSettings persists the approval mode chosen via /security
+// (optional, implemented by SessionManager) so it survives restarts.
+type SecurityProfileSettings interface {
+	SetSecurityProfile(chatID int64, mode string)
+}
+
 // registerSecurityCommands registers /security, /trust, /untrust commands.
 func (a *Adapter) registerSecurityCommands(registry *CommandRegistry, ctrl SecurityController) {
+	setMode := func(chatID int64, mode string) {
+		ctrl.SetApprovalMode(mode)
+		if ps, ok := registry.sessionManager.(SecurityProfileSettings); ok {
+			ps.SetSecurityProfile(chatID, mode)
+		}
+	}
+
 	// /security [auto|ask|strict]
 	registry.Register("security", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
 		if cmd.RawArgs == "" {
@@ -30,11 +43,11 @@ func (a *Adapter) registerSecurityCommands(registry *CommandRegistry, ctrl Secur
 		mode := strings.TrimSpace(strings.ToLower(cmd.RawArgs))
 		switch mode {
 		case "auto":
-			ctrl.SetApprovalMode("auto")
+			setMode(cmd.ChatID, "auto")
 		case "ask", "ask_dangerous":
-			ctrl.SetApprovalMode("ask_dangerous")
+			setMode(cmd.ChatID, "ask_dangerous")
 		case "strict", "ask_all", "all":
-			ctrl.SetApprovalMode("ask_all")
+			setMode(cmd.ChatID, "ask_all")
 		default:
 			return &OutgoingMessage{
 				ChatID:    cmd.ChatID,
@@ -98,12 +111,8 @@ func (a *Adapter) registerSecurityCommands(registry *CommandRegistry, ctrl Secur
 	registry.Register("security_mode", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
 		mode := strings.TrimSpace(cmd.RawArgs)
 		switch mode {
-		case "auto":
-			ctrl.SetApprovalMode("auto")
-		case "ask_dangerous":
-			ctrl.SetApprovalMode("ask_dangerous")
-		case "ask_all":
-			ctrl.SetApprovalMode("ask_all")
+		case "auto", "ask_dangerous", "ask_all":
+			setMode(cmd.ChatID, mode)
 		}
 		return buildSecurityStatus(cmd.ChatID, ctrl), nil
 	})
@@ -3,10 +3,15 @@ package telegram
 import (
 	"context"
 	"fmt"
+	"html"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
 )
 
 // registerSessionCommands registers session lifecycle: start, help, new, clear, status, reset, stop, whoami, commands
@@ -19,56 +24,16 @@ func (a *Adapter) registerSessionCommands(registry *CommandRegistry) {
 		}, nil
 	})
 
-	// /help 命令
+	// /help 命令 - 由命令定义 (CommandSpec) 生成
 	registry.Register("help", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
-		helpText := `📚 <b>命令列表</b>
-
-<b>会话</b>
-/new — 新对话
-/clear — 清除历史
-/stop — 停止当前任务
-/compact — 压缩上下文
-/context — 上下文统计
-/reset — 重置会话
-
-<b>模型</b>
-/model [名称] — 查看/切换模型
-/models — 浏览可用模型
-/think [级别] — 思考级别
-/verbose [on|off] — 详细模式
-/reasoning [模式] — 推理可见性
-
-<b>状态</b>
-/status — 当前状态
-/whoami — 身份信息
-/usage [模式] — 用量统计
-/commands — 所有命令
-
-<b>配置</b>
-/config — 查看/编辑配置
-/security — 安全策略
-/trust — 信任工具
-/allowlist — 白名单管理
-/activation — 群组激活
-/sendpolicy — 发送策略
-
-<b>高级</b>
-/skills — 技能管理
-/cron — 定时任务
-/agent — 代理管理
-/subagents — 子代理
-/tts — 语音合成
-
-💡 直接发送消息即可与 AI 对话`
-
 		return &OutgoingMessage{
 			ChatID:    cmd.ChatID,
-			Text:      helpText,
+			Text:      registry.helpText(registry.localeFor(cmd.ChatID), cmd.UserID),
 			ParseMode: "HTML",
 		}, nil
 	})
 
-	// /new 命令 - 创建新会话
+	// /new 命令 - 创建新会话; 启用 agent.carry_over 时带入上一会话的要点, /new --clean 完全清空
 	registry.Register("new", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
 		// Session-memory hook (OpenClaw pattern): save old history before clearing
 		if registry.historyClearer != nil {
@@ -77,6 +42,13 @@ func (a *Adapter) registerSessionCommands(registry *CommandRegistry) {
 				saveSessionMemory(history, cmd.ChatID)
 			}
 		}
+		// 清除前总结; 失败时新会话从空白开始, 不阻止 /new
+		var carried string
+		carryFailed := false
+		if _, clean := cmd.Flags["clean"]; !clean && registry.sessionCarrier != nil {
+			summary, err := registry.sessionCarrier.CarryOver(ctx, cmd.ChatID)
+			carried, carryFailed = summary, err != nil
+		}
 
 		if registry.sessionManager != nil {
 			if err := registry.sessionManager.CreateSession(cmd.ChatID, cmd.UserID); err != nil {
@@ -97,6 +69,14 @@ func (a *Adapter) registerSessionCommands(registry *CommandRegistry) {
 		if cmd.RawArgs != "" {
 			text = "✨ 新对话已开始！\n\n正在处理您的消息..."
 		}
+		loc := registry.localeFor(cmd.ChatID)
+		switch {
+		case carried != "":
+			registry.sessionCarrier.SeedHistory(cmd.ChatID, carried)
+			text += "\n\n" + loc.Tf("new.carried_over", html.EscapeString(carried))
+		case carryFailed:
+			text += "\n\n" + loc.T("new.carry_over_failed")
+		}
 
 		return &OutgoingMessage{
 			ChatID:    cmd.ChatID,
@@ -132,24 +112,32 @@ func (a *Adapter) registerSessionCommands(registry *CommandRegistry) {
 
 	// /status 命令 (对标 OpenClaw handleStatusCommand)
 	registry.Register("status", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
-		currentModel := "未设置"
+		loc := registry.localeFor(cmd.ChatID)
+		if len(cmd.Args) > 0 && strings.ToLower(cmd.Args[0]) == "models" {
+			return &OutgoingMessage{
+				ChatID:    cmd.ChatID,
+				Text:      formatModelStats(loc, registry.modelStats),
+				ParseMode: "HTML",
+			}, nil
+		}
+
+		currentModel := loc.T("status.unset")
 		if registry.sessionManager != nil {
 			if m := registry.sessionManager.GetCurrentModel(cmd.ChatID); m != "" {
 				currentModel = m
 			}
 		}
 
-		runState := "空闲"
+		runState := loc.T("run.idle")
 		if registry.runController != nil {
-			runState = registry.runController.GetRunState(cmd.ChatID)
+			runState = loc.T("run." + registry.runController.GetRunState(cmd.ChatID))
 		}
 
-		statusText := fmt.Sprintf("📊 <b>状态</b>\n\n"+
-			"🤖 模型: <code>%s</code>\n"+
-			"⚡ 状态: %s\n"+
-			"💬 会话: <code>%d</code>\n"+
-			"\n使用 /model 切换模型",
-			currentModel, runState, cmd.ChatID)
+		statusText := loc.T("status.title") + "\n\n" +
+			loc.Tf("status.model", currentModel) + "\n" +
+			loc.Tf("status.state", runState) + "\n" +
+			loc.Tf("status.session", cmd.ChatID) + "\n" +
+			"\n" + loc.T("status.hint")
 
 		return &OutgoingMessage{
 			ChatID:    cmd.ChatID,
@@ -183,23 +171,78 @@ func (a *Adapter) registerSessionCommands(registry *CommandRegistry) {
 
 	// /stop 命令 - 停止当前运行 (对标 OpenClaw handleStopCommand)
 	registry.Register("stop", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		loc := registry.localeFor(cmd.ChatID)
 		if registry.runController != nil {
 			aborted := registry.runController.AbortRun(cmd.ChatID)
 			if aborted {
 				return &OutgoingMessage{
 					ChatID:    cmd.ChatID,
-					Text:      "⏹ 已停止",
+					Text:      loc.T("run.stopped"),
 					ParseMode: "HTML",
 				}, nil
 			}
 		}
 		return &OutgoingMessage{
 			ChatID:    cmd.ChatID,
-			Text:      "⏹ 没有正在运行的任务",
+			Text:      loc.T("run.no_active"),
 			ParseMode: "HTML",
 		}, nil
 	})
 
+	// /feedback 命令 - 对上一次回答的文字反馈
+	registry.Register("feedback", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		loc := registry.localeFor(cmd.ChatID)
+		text := strings.TrimSpace(cmd.RawArgs)
+		reply := func(key string) (*OutgoingMessage, error) {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.T(key)}, nil
+		}
+		if text == "" {
+			return reply("feedback.usage")
+		}
+		if registry.feedbackRecorder == nil {
+			return reply("feedback.disabled")
+		}
+		ok, err := registry.feedbackRecorder.RecordComment(ctx, cmd.ChatID, cmd.UserID, text)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return reply("feedback.no_run")
+		}
+		return reply("feedback.saved")
+	})
+
+	// /share [有效期] — 上一次运行的只读分享链接 (转录、diff、工具时间线, 已脱敏)
+	registry.Register("share", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		loc := registry.localeFor(cmd.ChatID)
+		reply := func(text string) (*OutgoingMessage, error) {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: text, ParseMode: "HTML"}, nil
+		}
+		if registry.runSharer == nil {
+			return reply(loc.T("share.disabled"))
+		}
+		var ttl time.Duration
+		if len(cmd.Args) > 0 {
+			d, err := time.ParseDuration(cmd.Args[0])
+			if err != nil || d <= 0 {
+				return reply(loc.Tf("share.invalid_ttl", html.EscapeString(cmd.Args[0])))
+			}
+			ttl = d
+		}
+		url, expires, err := registry.runSharer.ShareLast(cmd.ChatID, ttl)
+		if err != nil {
+			return reply(loc.Tf("share.error", html.EscapeString(err.Error())))
+		}
+		if url == "" {
+			return reply(loc.T("share.no_run"))
+		}
+		text := loc.Tf("share.created", expires.Format("2006-01-02 15:04"), html.EscapeString(url))
+		if strings.HasPrefix(url, "/") {
+			text += "\n\n" + loc.T("share.no_base_url")
+		}
+		return reply(text)
+	})
+
 	// /whoami 命令 - 显示发送者 ID
 	registry.Register("whoami", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
 		return &OutgoingMessage{
@@ -289,3 +332,40 @@ func saveSessionMemory(history []HistoryMessage, chatID int64) {
 	defer f.Close()
 	_, _ = f.WriteString(sb.String())
 }
+
+// formatModelStats 渲染 /status models 的按模型统计
+func formatModelStats(loc i18n.Locale, provider ModelStatsProvider) string {
+	var stats []entity.ModelStats
+	if provider != nil {
+		stats = provider.Snapshot()
+	}
+	if len(stats) == 0 {
+		return loc.T("status.models_empty")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(loc.T("status.models_title"))
+	sb.WriteString("\n")
+	for _, s := range stats {
+		sb.WriteString(fmt.Sprintf("\n<b>%s</b> / <code>%s</code>\n",
+			html.EscapeString(s.Provider), html.EscapeString(s.Model)))
+		sb.WriteString(loc.Tf("status.models_line", s.Requests, s.Failures, s.ErrorRate()*100, s.Tokens, s.LatencyP50Ms, s.LatencyP95Ms))
+		if s.FirstTokenP95Ms > 0 || s.SlowStarts > 0 || s.Stalls > 0 {
+			sb.WriteString("\n" + loc.Tf("status.models_ttft", s.FirstTokenP50Ms, s.FirstTokenP95Ms, s.SlowStarts, s.Stalls))
+		}
+		if len(s.Errors) > 0 {
+			kinds := make([]string, 0, len(s.Errors))
+			for k := range s.Errors {
+				kinds = append(kinds, k)
+			}
+			sort.Strings(kinds)
+			parts := make([]string, 0, len(kinds))
+			for _, k := range kinds {
+				parts = append(parts, fmt.Sprintf("%s=%d", k, s.Errors[k]))
+			}
+			sb.WriteString("\n" + loc.Tf("status.models_errors", strings.Join(parts, ", ")))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
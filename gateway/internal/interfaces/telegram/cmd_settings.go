@@ -3,17 +3,24 @@ package telegram
 import (
 	"context"
 	"fmt"
+	"html"
 	"strings"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
 )
 
-// registerSettingsCommands registers session settings: think, verbose, reasoning, activation, sendpolicy
+// registerSettingsCommands registers session settings: think, verbose, reasoning, params, activation, sendpolicy
 func (a *Adapter) registerSettingsCommands(registry *CommandRegistry) {
 	// _think_set — internal handler for inline keyboard callbacks
 	registry.Register("_think_set", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
 		if len(cmd.Args) == 0 {
 			return nil, nil
 		}
-		level := cmd.Args[0]
+		level, ok := service.ParseThinkLevel(cmd.Args[0])
+		if !ok {
+			return nil, nil
+		}
 		if registry.sessionSettings != nil {
 			registry.sessionSettings.SetThinkLevel(cmd.ChatID, level)
 		}
@@ -30,12 +37,11 @@ func (a *Adapter) registerSettingsCommands(registry *CommandRegistry) {
 		if len(cmd.Args) == 0 {
 			return buildThinkStatus(cmd.ChatID, current), nil
 		}
-		level := strings.ToLower(cmd.Args[0])
-		valid := map[string]bool{"off": true, "low": true, "medium": true, "high": true}
-		if !valid[level] {
+		level, ok := service.ParseThinkLevel(cmd.Args[0])
+		if !ok {
 			return &OutgoingMessage{
 				ChatID:    cmd.ChatID,
-				Text:      "⚙️ 用法: /think off|low|medium|high",
+				Text:      "⚙️ 用法: /think off|low|med|high",
 				ParseMode: "HTML",
 			}, nil
 		}
@@ -198,14 +204,98 @@ func (a *Adapter) registerSettingsCommands(registry *CommandRegistry) {
 		}, nil
 	})
 
+	// /params 命令 - 会话级采样参数 (temperature/top_p/max_tokens/effort), 持久化并随每次运行生效
+	registry.Register("params", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		loc := registry.localeFor(cmd.ChatID)
+		ps, ok := registry.sessionManager.(ModelParamsSettings)
+		if !ok {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.T("params.unavailable"), ParseMode: "HTML"}, nil
+		}
+
+		params := ps.GetModelParams(cmd.ChatID)
+		switch {
+		case len(cmd.Args) == 0:
+		case len(cmd.Args) == 1 && strings.EqualFold(cmd.Args[0], "reset"):
+			params = service.ModelParams{}
+			ps.SetModelParams(cmd.ChatID, params)
+		default:
+			value := ""
+			if len(cmd.Args) > 1 {
+				value = cmd.Args[1]
+			}
+			if err := params.SetModelParam(cmd.Args[0], value); err != nil {
+				return &OutgoingMessage{
+					ChatID:    cmd.ChatID,
+					Text:      loc.Tf("params.invalid", html.EscapeString(err.Error())) + "\n\n" + loc.T("params.usage"),
+					ParseMode: "HTML",
+				}, nil
+			}
+			ps.SetModelParams(cmd.ChatID, params)
+		}
+		return buildParamsStatus(cmd.ChatID, loc, params), nil
+	})
+
+	// /lang 命令 - 界面语言和回复语言; auto = 回复跟随用户消息的语言
+	registry.Register("lang", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		loc := registry.localeFor(cmd.ChatID)
+		ls, ok := registry.sessionManager.(LocaleSettings)
+		if len(cmd.Args) == 0 || !ok {
+			return &OutgoingMessage{
+				ChatID:    cmd.ChatID,
+				Text:      loc.Tf("lang.current", loc),
+				ParseMode: "HTML",
+			}, nil
+		}
+		if strings.EqualFold(cmd.Args[0], "auto") {
+			ls.SetLocale(cmd.ChatID, "")
+			loc = registry.localeFor(cmd.ChatID)
+			return &OutgoingMessage{
+				ChatID:    cmd.ChatID,
+				Text:      loc.Tf("lang.auto", loc),
+				ParseMode: "HTML",
+			}, nil
+		}
+		next, valid := i18n.Parse(cmd.Args[0])
+		if !valid {
+			return &OutgoingMessage{
+				ChatID:    cmd.ChatID,
+				Text:      loc.T("lang.usage"),
+				ParseMode: "HTML",
+			}, nil
+		}
+		ls.SetLocale(cmd.ChatID, string(next))
+		return &OutgoingMessage{
+			ChatID:    cmd.ChatID,
+			Text:      next.Tf("lang.set", next),
+			ParseMode: "HTML",
+		}, nil
+	})
 
 	// /compact 命令 - 压缩上下文
 
 	// Aliases
-	registry.Alias("t", "think")
 	registry.Alias("thinking", "think")
 	registry.Alias("v", "verbose")
 	registry.Alias("reason", "reasoning")
+	registry.Alias("language", "lang")
+	registry.Alias("param", "params")
+}
+
+// buildParamsStatus lists the chat's model parameters, unset ones as default.
+func buildParamsStatus(chatID int64, loc i18n.Locale, params service.ModelParams) *OutgoingMessage {
+	var sb strings.Builder
+	sb.WriteString(loc.T("params.title") + "\n\n")
+	for _, name := range service.ModelParamNames {
+		value := params.Get(name)
+		if value == "" {
+			value = "<i>" + loc.T("params.default") + "</i>"
+		} else {
+			value = "<code>" + value + "</code>"
+		}
+		fmt.Fprintf(&sb, "%s: %s\n", name, value)
+	}
+	sb.WriteString("\n" + loc.T("params.usage"))
+	return &OutgoingMessage{ChatID: chatID, Text: sb.String(), ParseMode: "HTML"}
 }
 
 // buildThinkStatus builds the think level message with toggleable inline keyboard.
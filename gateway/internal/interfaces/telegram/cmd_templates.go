@@ -0,0 +1,197 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/prompt"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
+)
+
+// templateFill 等待用户补全变量的模板调用
+type templateFill struct {
+	tpl     *prompt.Template
+	vars    map[string]string
+	missing []string
+}
+
+// registerTemplateCommands registers prompt templates and variables: templates, t, setvar
+func (a *Adapter) registerTemplateCommands(registry *CommandRegistry) {
+	// /templates — 列出 ~/.ngoclaw/templates/*.md
+	registry.Register("templates", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		loc := registry.localeFor(cmd.ChatID)
+		if registry.templateStore == nil {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.T("template.unavailable")}, nil
+		}
+
+		list, err := registry.templateStore.List()
+		if err != nil {
+			return nil, err
+		}
+		if len(list) == 0 {
+			return &OutgoingMessage{
+				ChatID:    cmd.ChatID,
+				Text:      loc.Tf("template.empty", html.EscapeString(registry.templateStore.Dir())),
+				ParseMode: "HTML",
+			}, nil
+		}
+
+		var sb strings.Builder
+		sb.WriteString(loc.T("template.title") + "\n")
+		for _, t := range list {
+			sb.WriteString(fmt.Sprintf("\n• <code>%s</code> — %s", html.EscapeString(t.Name), html.EscapeString(t.Description)))
+			if len(t.Variables) > 0 {
+				sb.WriteString(fmt.Sprintf("\n  <i>{{%s}}</i>", html.EscapeString(strings.Join(t.Variables, "}} {{"))))
+			}
+		}
+		sb.WriteString("\n\n" + loc.T("template.usage"))
+		return &OutgoingMessage{ChatID: cmd.ChatID, Text: sb.String(), ParseMode: "HTML"}, nil
+	})
+
+	// /t <name> key=value ... — 渲染模板并转交 agent, 缺失变量逐个询问
+	registry.Register("t", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		loc := registry.localeFor(cmd.ChatID)
+		if registry.templateStore == nil {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.T("template.unavailable")}, nil
+		}
+		if len(cmd.Args) == 0 {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.T("template.usage"), ParseMode: "HTML"}, nil
+		}
+
+		tpl, err := registry.templateStore.Get(cmd.Args[0])
+		if err != nil {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: "❌ " + err.Error()}, nil
+		}
+
+		vars, _ := prompt.ParseTemplateArgs(strings.TrimSpace(strings.TrimPrefix(cmd.RawArgs, cmd.Args[0])))
+		missing := tpl.Missing(vars)
+		if len(missing) == 0 {
+			cmd.AgentPrompt = tpl.Render(vars)
+			return nil, nil
+		}
+
+		registry.templateMu.Lock()
+		registry.pendingTemplates[cmd.ChatID] = &templateFill{tpl: tpl, vars: vars, missing: missing}
+		registry.templateMu.Unlock()
+		return &OutgoingMessage{
+			ChatID:    cmd.ChatID,
+			Text:      loc.Tf("template.ask", html.EscapeString(missing[0]), html.EscapeString(tpl.Name)),
+			ParseMode: "HTML",
+		}, nil
+	})
+	registry.Alias("template", "t")
+
+	// /setvar [名字 [值]] — 会话级提示词变量, 替换 soul.md / prompts 中的 {{名字}}
+	registry.Register("setvar", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		loc := registry.localeFor(cmd.ChatID)
+		pv, ok := registry.sessionManager.(PromptVarSettings)
+		if !ok {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.T("setvar.unavailable")}, nil
+		}
+		if len(cmd.Args) == 0 {
+			return &OutgoingMessage{
+				ChatID:    cmd.ChatID,
+				Text:      formatPromptVars(loc, pv.GetPromptVars(cmd.ChatID), registry.promptVars),
+				ParseMode: "HTML",
+			}, nil
+		}
+
+		name, err := prompt.NormalizeVarName(cmd.Args[0])
+		if err != nil {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: "❌ " + err.Error()}, nil
+		}
+		value := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(cmd.RawArgs), cmd.Args[0]))
+		current := pv.GetPromptVars(cmd.ChatID)
+		_, exists := current[name]
+		switch {
+		case value == "":
+			pv.SetPromptVar(cmd.ChatID, name, "")
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.Tf("setvar.removed", html.EscapeString(name)), ParseMode: "HTML"}, nil
+		case utf8.RuneCountInString(value) > prompt.MaxPromptVarValue:
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.Tf("setvar.too_long", prompt.MaxPromptVarValue)}, nil
+		case len(current) >= maxChatPromptVars && !exists:
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.Tf("setvar.too_many", maxChatPromptVars)}, nil
+		}
+		pv.SetPromptVar(cmd.ChatID, name, value)
+		return &OutgoingMessage{
+			ChatID:    cmd.ChatID,
+			Text:      loc.Tf("setvar.set", html.EscapeString(name), html.EscapeString(value)),
+			ParseMode: "HTML",
+		}, nil
+	})
+	registry.Alias("vars", "setvar")
+}
+
+// maxChatPromptVars 每个会话最多设置的提示词变量数
+const maxChatPromptVars = 32
+
+// formatPromptVars 渲染 /setvar 列表: 会话变量在前, 未被覆盖的配置默认值在后
+func formatPromptVars(loc i18n.Locale, chat, defaults map[string]string) string {
+	var sb strings.Builder
+	sb.WriteString(loc.T("setvar.title") + "\n")
+	if len(chat) == 0 && len(defaults) == 0 {
+		sb.WriteString("\n" + loc.T("setvar.empty") + "\n")
+	}
+	for _, name := range sortedVarNames(chat) {
+		sb.WriteString(fmt.Sprintf("\n• <code>{{%s}}</code> = %s", html.EscapeString(name), html.EscapeString(chat[name])))
+	}
+	for _, name := range sortedVarNames(defaults) {
+		if _, ok := chat[name]; ok {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n• <code>{{%s}}</code> = %s <i>%s</i>", html.EscapeString(name), html.EscapeString(defaults[name]), loc.T("setvar.config")))
+	}
+	sb.WriteString("\n\n" + loc.T("setvar.usage"))
+	return sb.String()
+}
+
+func sortedVarNames(vars map[string]string) []string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// InterceptText 处理等待中的模板变量输入。
+// handled 为 true 时消息已被消费; agentPrompt 非空时应将其转交 agent。
+func (r *CommandRegistry) InterceptText(chatID int64, text string) (reply *OutgoingMessage, agentPrompt string, handled bool) {
+	r.templateMu.Lock()
+	fill, ok := r.pendingTemplates[chatID]
+	if !ok {
+		r.templateMu.Unlock()
+		return nil, "", false
+	}
+
+	fill.vars[fill.missing[0]] = strings.TrimSpace(text)
+	fill.missing = fill.missing[1:]
+	if len(fill.missing) == 0 {
+		delete(r.pendingTemplates, chatID)
+	}
+	r.templateMu.Unlock()
+
+	if len(fill.missing) == 0 {
+		return nil, fill.tpl.Render(fill.vars), true
+	}
+
+	r.mu.RLock()
+	loc := r.localeFor(chatID)
+	r.mu.RUnlock()
+	return &OutgoingMessage{
+		ChatID:    chatID,
+		Text:      loc.Tf("template.ask", html.EscapeString(fill.missing[0]), html.EscapeString(fill.tpl.Name)),
+		ParseMode: "HTML",
+	}, "", true
+}
+
+// cancelPendingTemplate 放弃等待中的模板输入 (任何命令都会取消)
+func (r *CommandRegistry) cancelPendingTemplate(chatID int64) {
+	r.templateMu.Lock()
+	delete(r.pendingTemplates, chatID)
+	r.templateMu.Unlock()
+}
@@ -0,0 +1,118 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	toolpkg "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tool"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
+)
+
+// SetToolCatalog 设置工具目录来源 (/tools)
+func (r *CommandRegistry) SetToolCatalog(catalog func() *toolpkg.ToolCatalog) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.toolCatalog = catalog
+}
+
+// registerToolCommands registers /tools
+func (a *Adapter) registerToolCommands(registry *CommandRegistry) {
+	// /tools [类型] — 按类型列出已注册工具, 标出需要审批的和已关闭的
+	registry.Register("tools", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		loc := registry.localeFor(cmd.ChatID)
+		registry.mu.RLock()
+		describe := registry.toolCatalog
+		registry.mu.RUnlock()
+		if describe == nil {
+			return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.T("tools.unavailable")}, nil
+		}
+
+		catalog := describe()
+		category := ""
+		if len(cmd.Args) > 0 {
+			category = strings.ToLower(cmd.Args[0])
+		}
+		return &OutgoingMessage{ChatID: cmd.ChatID, Text: formatToolCatalog(loc, catalog, category), ParseMode: "HTML"}, nil
+	})
+}
+
+// formatToolCatalog 渲染 /tools: 不带类型时每组一行工具名, 带类型时列出该组工具的说明
+func formatToolCatalog(loc i18n.Locale, catalog *toolpkg.ToolCatalog, category string) string {
+	groups := catalog.Groups()
+
+	var sb strings.Builder
+	sb.WriteString(loc.Tf("tools.title", len(catalog.Tools)))
+	if catalog.ApprovalMode != "" {
+		sb.WriteString(loc.Tf("tools.mode", html.EscapeString(catalog.ApprovalMode)))
+	}
+	sb.WriteString("\n")
+
+	if category != "" {
+		var names []string
+		for _, g := range groups {
+			names = append(names, g.Category)
+			if g.Category != category {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("\n<b>%s</b>\n", categoryLabel(loc, g.Category)))
+			for _, t := range g.Tools {
+				sb.WriteString(fmt.Sprintf("• <code>%s</code>%s — %s\n",
+					html.EscapeString(t.Name), approvalBadge(t.Approval), html.EscapeString(toolSummary(t.Description))))
+			}
+			sb.WriteString("\n" + loc.T("tools.legend"))
+			return sb.String()
+		}
+		return loc.Tf("tools.unknown_category", html.EscapeString(category), strings.Join(names, ", "))
+	}
+
+	for _, g := range groups {
+		sb.WriteString(fmt.Sprintf("\n<b>%s</b> (%d)\n", categoryLabel(loc, g.Category), len(g.Tools)))
+		names := make([]string, len(g.Tools))
+		for i, t := range g.Tools {
+			names[i] = "<code>" + html.EscapeString(t.Name) + "</code>" + approvalBadge(t.Approval)
+		}
+		sb.WriteString(strings.Join(names, ", ") + "\n")
+	}
+	if len(catalog.Disabled) > 0 {
+		sb.WriteString("\n" + loc.Tf("tools.disabled", html.EscapeString(strings.Join(catalog.Disabled, ", "))) + "\n")
+	}
+	sb.WriteString("\n" + loc.T("tools.legend") + "\n" + loc.T("tools.hint"))
+	return sb.String()
+}
+
+// categoryLabel 分组标题, 没有翻译的分组直接显示名称
+func categoryLabel(loc i18n.Locale, category string) string {
+	key := "tools.cat." + category
+	if label := loc.T(key); label != key {
+		return label
+	}
+	return html.EscapeString(category)
+}
+
+// approvalBadge 审批标记: 🔒 每次都要审批, ❔ 视参数而定
+func approvalBadge(approval string) string {
+	switch approval {
+	case service.ApprovalAlways:
+		return " 🔒"
+	case service.ApprovalPerCall:
+		return " ❔"
+	}
+	return ""
+}
+
+// toolSummary 工具说明的第一句, 最长 120 字符
+func toolSummary(desc string) string {
+	if i := strings.IndexByte(desc, '\n'); i >= 0 {
+		desc = desc[:i]
+	}
+	if i := strings.Index(desc, ". "); i >= 0 {
+		desc = desc[:i+1]
+	}
+	if r := []rune(desc); len(r) > 120 {
+		desc = string(r[:119]) + "…"
+	}
+	return desc
+}
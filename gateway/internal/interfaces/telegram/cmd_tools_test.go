@@ -0,0 +1,54 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	toolpkg "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tool"
+)
+
+func TestToolsCommand(t *testing.T) {
+	catalog := &toolpkg.ToolCatalog{
+		ApprovalMode: "ask_dangerous",
+		Tools: []toolpkg.ToolSpec{
+			{Name: "read_file", Category: "read", Approval: "never", Description: "Read a file. Supports line ranges."},
+			{Name: "bash", Category: "execute", Approval: "per_call", Description: "Run a shell command."},
+			{Name: "write_file", Category: "edit", Approval: "always", Description: "Write a file."},
+			{Name: "github_list_issues", Category: "mcp", Approval: "never"},
+		},
+		Disabled: []string{"docs_lookup"},
+	}
+	r := NewCommandRegistry()
+	(&Adapter{}).RegisterBuiltinCommands(r)
+	r.SetToolCatalog(func() *toolpkg.ToolCatalog { return catalog })
+
+	msg, _, err := r.Handle(context.Background(), ParseCommand("/tools"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<code>ask_dangerous</code>",
+		"<b>📖 读取</b> (1)\n<code>read_file</code>\n",
+		"<b>✏️ 编辑</b> (1)\n<code>write_file</code> 🔒",
+		"<code>bash</code> ❔",
+		"<b>🔌 MCP</b> (1)",
+		"⛔ 已关闭: docs_lookup",
+	} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("/tools missing %q:\n%s", want, msg.Text)
+		}
+	}
+	if strings.Index(msg.Text, "编辑") > strings.Index(msg.Text, "执行") {
+		t.Errorf("edit should be listed before execute:\n%s", msg.Text)
+	}
+
+	msg, _, _ = r.Handle(context.Background(), ParseCommand("/tools READ"))
+	if !strings.Contains(msg.Text, "<code>read_file</code> — Read a file.") || strings.Contains(msg.Text, "bash") {
+		t.Errorf("/tools read:\n%s", msg.Text)
+	}
+	msg, _, _ = r.Handle(context.Background(), ParseCommand("/tools net"))
+	if !strings.Contains(msg.Text, "read, edit, execute, mcp") {
+		t.Errorf("/tools net:\n%s", msg.Text)
+	}
+}
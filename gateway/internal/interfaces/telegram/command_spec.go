@@ -0,0 +1,332 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
+)
+
+// ─── 声明式命令定义 ───
+//
+// CommandSpec 描述命令的参数、标志、权限和帮助信息。注册表在调用处理器之前
+// 按 spec 统一校验参数和权限, /help 和 Telegram 命令菜单也由 spec 生成。
+// 说明文字取自 i18n 键 cmd.<name>, 参数显示名取自 cmd.arg.<name> (缺省用原名)。
+// 未定义 spec 的命令 (插件、回调内部命令) 不做校验, 也不出现在帮助中。
+
+// CommandPermission 命令权限级别
+type CommandPermission int
+
+const (
+	PermUser  CommandPermission = iota // 所有允许使用 bot 的用户
+	PermAdmin                          // 仅管理员 (telegram.admin_ids)
+)
+
+// CommandArg 位置参数
+type CommandArg struct {
+	Name     string
+	Required bool
+	Choices  []string // 可选值 (不区分大小写); 为空 = 任意
+	Rest     bool     // 吞掉剩余全部参数 (自由文本), 只能是最后一个
+}
+
+// CommandFlag --name 或 --name=value 形式的标志, 解析后从 Args 中移除并写入 Command.Flags
+type CommandFlag struct {
+	Name  string
+	Value bool // true = 必须带 =value
+}
+
+// CommandSpec 命令定义
+type CommandSpec struct {
+	Name    string
+	Group   string // /help 分组 (cmd.group.<Group>); 空 = 不在帮助中显示
+	Args    []CommandArg
+	Flags   []CommandFlag
+	Perm    CommandPermission
+	Menu    bool           // 出现在 Telegram 命令菜单中
+	Handler CommandHandler // 可选; 为空时只为已注册的同名命令附加定义
+}
+
+// Define 注册命令定义; spec.Handler 非空时同时注册处理器
+func (r *CommandRegistry) Define(spec CommandSpec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	spec.Name = strings.ToLower(spec.Name)
+	if spec.Handler != nil {
+		r.handlers[spec.Name] = spec.Handler
+	}
+	if _, ok := r.specs[spec.Name]; !ok {
+		r.specOrder = append(r.specOrder, spec.Name)
+	}
+	r.specs[spec.Name] = &spec
+}
+
+// SetAdminIDs 设置可执行管理员命令的用户; 为空 = 不限制 (单用户部署)
+func (r *CommandRegistry) SetAdminIDs(ids []int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.adminIDs = ids
+}
+
+// isAdmin (调用方需已持有读锁)
+func (r *CommandRegistry) isAdmin(userID int64) bool {
+	if len(r.adminIDs) == 0 {
+		return true
+	}
+	for _, id := range r.adminIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCommand 按 spec 检查权限并解析参数; 不通过时返回给用户的回复 (调用方需已持有读锁)
+func (r *CommandRegistry) checkCommand(spec *CommandSpec, cmd *Command) *OutgoingMessage {
+	loc := r.localeFor(cmd.ChatID)
+	if spec.Perm == PermAdmin && !r.isAdmin(cmd.UserID) {
+		return &OutgoingMessage{ChatID: cmd.ChatID, Text: loc.Tf("cmd.denied", spec.Name), ParseMode: "HTML"}
+	}
+	if err := spec.Parse(loc, cmd); err != nil {
+		return &OutgoingMessage{
+			ChatID:    cmd.ChatID,
+			Text:      loc.Tf("cmd.invalid", html.EscapeString(err.Error()), html.EscapeString(spec.Usage(loc))),
+			ParseMode: "HTML",
+		}
+	}
+	return nil
+}
+
+// Parse 解析标志并校验位置参数, 错误信息已按 loc 本地化
+func (s *CommandSpec) Parse(loc i18n.Locale, cmd *Command) error {
+	if len(s.Flags) > 0 {
+		if err := s.parseFlags(loc, cmd); err != nil {
+			return err
+		}
+	}
+	for i, arg := range s.Args {
+		if i >= len(cmd.Args) {
+			if arg.Required {
+				return errors.New(loc.Tf("cmd.arg_missing", arg.label(loc)))
+			}
+			continue
+		}
+		if len(arg.Choices) > 0 && !arg.accepts(cmd.Args[i]) {
+			return errors.New(loc.Tf("cmd.arg_invalid", cmd.Args[i], strings.Join(arg.Choices, "|")))
+		}
+		if arg.Rest {
+			return nil
+		}
+	}
+	if len(cmd.Args) > len(s.Args) {
+		return errors.New(loc.Tf("cmd.arg_extra", strings.Join(cmd.Args[len(s.Args):], " ")))
+	}
+	return nil
+}
+
+// parseFlags 把 --name / --name=value 移入 cmd.Flags; "--" 之后的内容按原样作为参数
+func (s *CommandSpec) parseFlags(loc i18n.Locale, cmd *Command) error {
+	var args []string
+	found := false
+	for i, a := range cmd.Args {
+		if a == "--" {
+			args = append(args, cmd.Args[i+1:]...)
+			found = true
+			break
+		}
+		if !strings.HasPrefix(a, "--") {
+			args = append(args, a)
+			continue
+		}
+		name, value, hasValue := strings.Cut(a[2:], "=")
+		flag := s.flag(name)
+		switch {
+		case flag == nil:
+			return errors.New(loc.Tf("cmd.flag_unknown", a))
+		case flag.Value && (!hasValue || value == ""):
+			return errors.New(loc.Tf("cmd.flag_value", "--"+flag.Name))
+		case !flag.Value && hasValue:
+			return errors.New(loc.Tf("cmd.flag_novalue", "--"+flag.Name))
+		}
+		if cmd.Flags == nil {
+			cmd.Flags = make(map[string]string)
+		}
+		cmd.Flags[flag.Name] = value
+		found = true
+	}
+	if found {
+		cmd.Args = args
+		cmd.RawArgs = strings.Join(args, " ")
+	}
+	return nil
+}
+
+func (s *CommandSpec) flag(name string) *CommandFlag {
+	for i := range s.Flags {
+		if strings.EqualFold(s.Flags[i].Name, name) {
+			return &s.Flags[i]
+		}
+	}
+	return nil
+}
+
+func (a CommandArg) accepts(v string) bool {
+	for _, c := range a.Choices {
+		if strings.EqualFold(c, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// label 参数显示名: 可选值列表, 或 cmd.arg.<name> 的译文
+func (a CommandArg) label(loc i18n.Locale) string {
+	if len(a.Choices) > 0 {
+		return strings.Join(a.Choices, "|")
+	}
+	key := "cmd.arg." + a.Name
+	if s := loc.T(key); s != key {
+		return s
+	}
+	return a.Name
+}
+
+// Usage 返回用法行, 如 "/approve <id> <allow|deny>"、"/new [text…]"
+func (s *CommandSpec) Usage(loc i18n.Locale) string {
+	parts := []string{"/" + s.Name}
+	for _, arg := range s.Args {
+		label := arg.label(loc)
+		if arg.Rest {
+			label += "…"
+		}
+		if arg.Required {
+			parts = append(parts, "<"+label+">")
+		} else {
+			parts = append(parts, "["+label+"]")
+		}
+	}
+	for _, f := range s.Flags {
+		if f.Value {
+			parts = append(parts, "[--"+f.Name+"=…]")
+		} else {
+			parts = append(parts, "[--"+f.Name+"]")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// helpText 按分组生成 /help; 非管理员看不到管理员命令 (调用方需已持有读锁)
+func (r *CommandRegistry) helpText(loc i18n.Locale, userID int64) string {
+	var groups []string
+	lines := make(map[string][]string)
+	for _, name := range r.specOrder {
+		spec := r.specs[name]
+		if spec.Group == "" || r.handlers[name] == nil || (spec.Perm == PermAdmin && !r.isAdmin(userID)) {
+			continue
+		}
+		if _, ok := lines[spec.Group]; !ok {
+			groups = append(groups, spec.Group)
+		}
+		lines[spec.Group] = append(lines[spec.Group],
+			fmt.Sprintf("%s — %s", html.EscapeString(spec.Usage(loc)), loc.T("cmd."+name)))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(loc.T("cmd.help_title"))
+	for _, g := range groups {
+		sb.WriteString("\n\n<b>" + loc.T("cmd.group."+g) + "</b>\n")
+		sb.WriteString(strings.Join(lines[g], "\n"))
+	}
+	sb.WriteString("\n\n" + loc.T("cmd.help_tip"))
+	return sb.String()
+}
+
+// BotCommands 返回 Telegram 命令菜单 (spec.Menu), 说明按 loc 本地化
+func (r *CommandRegistry) BotCommands(loc i18n.Locale) []tgbotapi.BotCommand {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var commands []tgbotapi.BotCommand
+	for _, name := range r.specOrder {
+		if r.specs[name].Menu && r.handlers[name] != nil {
+			commands = append(commands, tgbotapi.BotCommand{Command: name, Description: loc.T("cmd." + name)})
+		}
+	}
+	return commands
+}
+
+// defineBuiltinCommands 为内置命令附加定义, 顺序即 /help 和菜单中的顺序
+func defineBuiltinCommands(registry *CommandRegistry) {
+	text := []CommandArg{{Name: "text", Rest: true}}
+	subcommand := []CommandArg{{Name: "action"}, {Name: "args", Rest: true}}
+
+	for _, spec := range []CommandSpec{
+		// 会话
+		{Name: "new", Group: "session", Args: text, Flags: []CommandFlag{{Name: "clean"}}, Menu: true},
+		{Name: "clear", Group: "session"},
+		{Name: "stop", Group: "session", Menu: true},
+		{Name: "compact", Group: "session", Args: []CommandArg{{Name: "instructions", Rest: true}}, Menu: true},
+		{Name: "context", Group: "session"},
+		{Name: "reset", Group: "session"},
+		{Name: "feedback", Group: "session", Args: text, Menu: true},
+		{Name: "share", Group: "session", Args: []CommandArg{{Name: "ttl"}}},
+		{Name: "forgetme", Group: "session", Args: []CommandArg{{Name: "action", Choices: []string{"confirm", "cancel"}}, {Name: "token"}}},
+
+		// 模型
+		{Name: "models", Group: "model", Args: []CommandArg{{Name: "provider"}, {Name: "page"}}, Menu: true},
+		{Name: "think", Group: "model", Args: []CommandArg{{Name: "level"}}, Menu: true},
+		{Name: "verbose", Group: "model", Args: []CommandArg{{Name: "mode", Choices: []string{"on", "off"}}}},
+		{Name: "reasoning", Group: "model", Args: []CommandArg{{Name: "mode", Choices: []string{"on", "off", "stream"}}}},
+		{Name: "params", Group: "model", Args: []CommandArg{{Name: "param"}, {Name: "value"}}},
+		{Name: "route", Group: "model", Args: []CommandArg{{Name: "mode", Choices: []string{"on", "off", "default", "reset"}}}},
+		{Name: "draft", Group: "model", Args: []CommandArg{{Name: "mode", Choices: []string{"on", "off", "default", "reset"}}}},
+
+		// 状态
+		{Name: "status", Group: "status", Args: []CommandArg{{Name: "view", Choices: []string{"models"}}}, Menu: true},
+		{Name: "whoami", Group: "status"},
+		{Name: "usage", Group: "status", Args: []CommandArg{{Name: "mode", Choices: []string{"off", "tokens", "full", "cost", "quota"}}}},
+		{Name: "commands", Group: "status"},
+		{Name: "tools", Group: "status", Args: []CommandArg{{Name: "category"}}, Menu: true},
+
+		// 配置
+		{Name: "config", Group: "config", Args: subcommand, Perm: PermAdmin},
+		{Name: "debug", Group: "config", Args: subcommand, Perm: PermAdmin},
+		{Name: "security", Group: "config", Args: []CommandArg{{Name: "mode", Choices: []string{"auto", "ask", "strict", "ask_dangerous", "ask_all", "all"}}}, Perm: PermAdmin, Menu: true},
+		{Name: "trust", Group: "config", Args: []CommandArg{{Name: "tool", Rest: true}}, Perm: PermAdmin},
+		{Name: "untrust", Group: "config", Args: []CommandArg{{Name: "tool", Rest: true}}, Perm: PermAdmin},
+		{Name: "allowlist", Group: "config", Args: subcommand, Perm: PermAdmin},
+		{Name: "activation", Group: "config", Args: []CommandArg{{Name: "mode", Choices: []string{"mention", "always"}}}},
+		{Name: "sendpolicy", Group: "config", Args: []CommandArg{{Name: "policy", Choices: []string{"on", "off", "inherit", "allow", "deny"}}}},
+		{Name: "lang", Group: "config", Args: []CommandArg{{Name: "lang"}}},
+		{Name: "profile", Group: "config", Args: []CommandArg{{Name: "name"}}, Perm: PermAdmin},
+		{Name: "restart", Group: "config", Perm: PermAdmin},
+
+		// 高级
+		{Name: "skills", Group: "advanced", Args: subcommand, Menu: true},
+		{Name: "skill", Group: "advanced", Args: []CommandArg{{Name: "name"}, {Name: "text", Rest: true}}},
+		{Name: "cron", Group: "advanced", Args: subcommand, Flags: []CommandFlag{{Name: "catch-up", Value: true}}},
+		{Name: "research", Group: "advanced", Args: []CommandArg{{Name: "topic", Rest: true}}},
+		{Name: "workflow", Group: "advanced", Args: subcommand},
+		{Name: "plan", Group: "advanced", Menu: true},
+		{Name: "templates", Group: "advanced"},
+		{Name: "t", Group: "advanced", Args: []CommandArg{{Name: "name"}, {Name: "vars", Rest: true}}},
+		{Name: "setvar", Group: "advanced", Args: []CommandArg{{Name: "name"}, {Name: "value", Rest: true}}},
+		{Name: "memory", Group: "advanced", Args: subcommand},
+		{Name: "recall", Group: "advanced", Args: []CommandArg{{Name: "query", Rest: true}}},
+		{Name: "agent", Group: "advanced", Args: subcommand},
+		{Name: "subagents", Group: "advanced", Args: subcommand},
+		{Name: "tts", Group: "advanced", Args: subcommand},
+		{Name: "approve", Group: "advanced", Args: []CommandArg{{Name: "id", Required: true}, {Name: "decision", Required: true, Choices: []string{"allow", "deny"}}}},
+		{Name: "bash", Group: "advanced", Args: []CommandArg{{Name: "command", Required: true, Rest: true}}, Perm: PermAdmin},
+		{Name: "plugin", Group: "advanced", Args: subcommand},
+
+		// 不在帮助中显示
+		{Name: "help", Menu: true},
+		{Name: "security_mode", Args: []CommandArg{{Name: "mode"}}, Perm: PermAdmin},
+	} {
+		registry.Define(spec)
+	}
+}
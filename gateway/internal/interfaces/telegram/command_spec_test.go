@@ -0,0 +1,155 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
+)
+
+func specTestRegistry(calls *[]*Command) *CommandRegistry {
+	r := NewCommandRegistry()
+	record := func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		*calls = append(*calls, cmd)
+		return &OutgoingMessage{ChatID: cmd.ChatID, Text: "ok"}, nil
+	}
+	r.Define(CommandSpec{
+		Name:    "approve",
+		Group:   "advanced",
+		Args:    []CommandArg{{Name: "id", Required: true}, {Name: "decision", Required: true, Choices: []string{"allow", "deny"}}},
+		Handler: record,
+	})
+	r.Define(CommandSpec{
+		Name:    "export",
+		Group:   "session",
+		Args:    []CommandArg{{Name: "text", Rest: true}},
+		Flags:   []CommandFlag{{Name: "all"}, {Name: "format", Value: true}},
+		Handler: record,
+	})
+	r.Define(CommandSpec{Name: "bash", Group: "advanced", Args: []CommandArg{{Name: "command", Rest: true}}, Perm: PermAdmin, Handler: record})
+	return r
+}
+
+func TestCommandSpec_ValidatesArguments(t *testing.T) {
+	var calls []*Command
+	r := specTestRegistry(&calls)
+
+	tests := []struct {
+		text  string
+		reply string
+	}{
+		{"/approve", "缺少参数 id"},
+		{"/approve 42 maybe", "无效的值 &#34;maybe&#34;"},
+		{"/approve 42 allow now", "多余的参数: now"},
+		{"/export --zip", "未知选项 --zip"},
+		{"/export --format", "选项 --format 需要一个值"},
+	}
+	for _, tt := range tests {
+		msg, handled, err := r.Handle(context.Background(), ParseCommand(tt.text))
+		if !handled || err != nil || !strings.Contains(msg.Text, tt.reply) {
+			t.Errorf("%s → %+v (handled=%v, err=%v), want %q", tt.text, msg, handled, err, tt.reply)
+		}
+	}
+	if len(calls) != 0 {
+		t.Fatalf("handler ran for invalid input: %d calls", len(calls))
+	}
+
+	if _, _, err := r.Handle(context.Background(), ParseCommand("/approve 42 ALLOW")); err != nil || len(calls) != 1 {
+		t.Fatalf("valid command not dispatched: err=%v calls=%d", err, len(calls))
+	}
+}
+
+func TestCommandSpec_Flags(t *testing.T) {
+	var calls []*Command
+	r := specTestRegistry(&calls)
+
+	r.Handle(context.Background(), ParseCommand("/export --all last week --format=md -- --raw"))
+	if len(calls) != 1 {
+		t.Fatalf("calls = %d", len(calls))
+	}
+	cmd := calls[0]
+	if _, ok := cmd.Flags["all"]; !ok || cmd.Flags["format"] != "md" {
+		t.Errorf("flags = %v", cmd.Flags)
+	}
+	if cmd.RawArgs != "last week --raw" || len(cmd.Args) != 3 {
+		t.Errorf("args = %q / %q", cmd.Args, cmd.RawArgs)
+	}
+}
+
+func TestCommandSpec_AdminPermission(t *testing.T) {
+	var calls []*Command
+	r := specTestRegistry(&calls)
+	r.SetAdminIDs([]int64{1})
+
+	cmd := ParseCommand("/bash ls")
+	cmd.UserID = 2
+	msg, handled, _ := r.Handle(context.Background(), cmd)
+	if !handled || len(calls) != 0 || !strings.Contains(msg.Text, "仅限管理员") {
+		t.Fatalf("non-admin ran /bash: %+v calls=%d", msg, len(calls))
+	}
+
+	cmd = ParseCommand("/bash ls")
+	cmd.UserID = 1
+	r.Handle(context.Background(), cmd)
+	if len(calls) != 1 {
+		t.Fatalf("admin was rejected")
+	}
+
+	if help := r.helpText(i18n.EN, 2); strings.Contains(help, "/bash") {
+		t.Errorf("admin command listed for a non-admin:\n%s", help)
+	}
+}
+
+func TestCommandRegistry_HandlerWinsOverAlias(t *testing.T) {
+	r := NewCommandRegistry()
+	var got string
+	r.Register("think", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		got = "think"
+		return nil, nil
+	})
+	r.Register("t", func(ctx context.Context, cmd *Command) (*OutgoingMessage, error) {
+		got = "t"
+		return nil, nil
+	})
+	r.Alias("t", "think")
+	r.Alias("thinking", "think")
+
+	r.Handle(context.Background(), ParseCommand("/t review"))
+	if got != "t" {
+		t.Errorf("/t dispatched to %q", got)
+	}
+	r.Handle(context.Background(), ParseCommand("/thinking high"))
+	if got != "think" {
+		t.Errorf("/thinking dispatched to %q", got)
+	}
+}
+
+func TestCommandSpec_HelpAndMenu(t *testing.T) {
+	var calls []*Command
+	r := specTestRegistry(&calls)
+
+	help := r.helpText(i18n.EN, 0)
+	for _, want := range []string{
+		"<b>Session</b>\n/export [text…] [--all] [--format=…] — cmd.export",
+		"/approve &lt;id&gt; &lt;allow|deny&gt; — resolve an approval request",
+		"💡",
+	} {
+		if !strings.Contains(help, want) {
+			t.Errorf("help missing %q:\n%s", want, help)
+		}
+	}
+
+	a := &Adapter{}
+	reg := NewCommandRegistry()
+	a.RegisterBuiltinCommands(reg)
+	menu := reg.BotCommands(i18n.EN)
+	if len(menu) == 0 || menu[len(menu)-1].Command != "help" || menu[len(menu)-1].Description != "help" {
+		t.Errorf("menu = %+v", menu)
+	}
+	for _, name := range reg.specOrder {
+		if reg.specs[name].Group != "" && i18n.EN.T("cmd."+name) == "cmd."+name {
+			t.Errorf("no help text for /%s", name)
+		}
+	}
+}
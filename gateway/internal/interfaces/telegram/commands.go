@@ -5,8 +5,15 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/repository"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/prompt"
 	toolpkg "github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/tool"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/infrastructure/workflow"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
 )
 
 // Command Telegram 命令
@@ -16,6 +23,11 @@ type Command struct {
 	RawArgs string   // 原始参数字符串
 	ChatID  int64
 	UserID  int64
+	Flags   map[string]string // CommandSpec 声明的 --flag[=value]; 布尔标志的值为空串
+
+	// AgentPrompt 由处理器设置: 非空时将该文本作为用户消息转交 agent
+	// (如 /research <topic>)，处理器返回的响应会先发送
+	AgentPrompt string
 }
 
 // CommandHandler 命令处理器
@@ -30,6 +42,50 @@ type SessionManager interface {
 	GetAvailableModels() []ModelInfo
 }
 
+// LocaleSettings 界面语言设置接口 (可选, 由 SessionManager 实现)
+type LocaleSettings interface {
+	GetLocale(chatID int64) string
+	SetLocale(chatID int64, locale string)
+}
+
+// ReplyLanguageSettings 回复语言接口 (可选, 由 SessionManager 实现)
+type ReplyLanguageSettings interface {
+	// GetReplyLanguage 返回 /lang 显式选择的语言, 空 = 跟随用户消息的语言
+	GetReplyLanguage(chatID int64) string
+}
+
+// ModelParamsSettings 会话采样参数接口 (可选, 由 SessionManager 实现) - 用于 /params
+type ModelParamsSettings interface {
+	GetModelParams(chatID int64) service.ModelParams
+	SetModelParams(chatID int64, params service.ModelParams)
+}
+
+// RouteSettings 会话自动选模型接口 (可选, 由 SessionManager 实现) - 用于 /route
+type RouteSettings interface {
+	GetRouteMode(chatID int64) string // "on"|"off", "" = 配置默认
+	SetRouteMode(chatID int64, mode string)
+	GetLastRoute(chatID int64) string
+	SetLastRoute(chatID int64, route string)
+}
+
+// DraftSettings 会话草稿模式接口 (可选, 由 SessionManager 实现) - 用于 /draft
+type DraftSettings interface {
+	GetDraftMode(chatID int64) string // "on"|"off", "" = 配置默认
+	SetDraftMode(chatID int64, mode string)
+}
+
+// AgentSettings 会话代理选择接口 (可选, 由 SessionManager 实现) - 用于 /agent switch
+type AgentSettings interface {
+	GetAgent(chatID int64) string // 代理 ID, "" = 默认代理
+	SetAgent(chatID int64, id string)
+}
+
+// PromptVarSettings 会话提示词变量接口 (可选, 由 SessionManager 实现) - 用于 /setvar
+type PromptVarSettings interface {
+	GetPromptVars(chatID int64) map[string]string
+	SetPromptVar(chatID int64, name, value string) // value 为空 = 删除
+}
+
 // ContextController 上下文控制器接口 - 用于 /compact 和 /context 命令
 type ContextController interface {
 	// CompactContext 压缩指定 chat 的上下文，返回 (tokensBefore, tokensAfter, error)
@@ -92,6 +148,58 @@ type HistoryClearer interface {
 	GetHistory(chatID int64) []HistoryMessage
 }
 
+// SessionCarrier /new 的会话要点延续接口 (agent.carry_over)
+type SessionCarrier interface {
+	// CarryOver 总结当前对话中值得带入新会话的事实; 没有可延续的内容时返回 ""
+	CarryOver(ctx context.Context, chatID int64) (string, error)
+	// SeedHistory 以摘要作为新会话对话历史的开头
+	SeedHistory(chatID int64, summary string)
+}
+
+// FeedbackRecorder 用户反馈记录接口 (/feedback)
+type FeedbackRecorder interface {
+	// RecordComment 把文字反馈附加到该 chat 最近一次运行; 没有可反馈的运行时返回 false
+	RecordComment(ctx context.Context, chatID, userID int64, text string) (bool, error)
+}
+
+// DataEraser 个人数据清除接口 (/forgetme)
+type DataEraser interface {
+	// ForgetChat 删除与该 chat 相关的全部数据 (对话历史、偏好、消息、转录、产物、记忆), 返回删除的记录数
+	ForgetChat(ctx context.Context, chatID int64) (int, error)
+}
+
+// HeartbeatStatusProvider 心跳调度状态 (/cron status)
+type HeartbeatStatusProvider interface {
+	// Status 持久化的心跳状态; 未运行过时为 nil
+	Status() *entity.Schedule
+}
+
+// RunSharer 运行记录分享接口 (/share)
+type RunSharer interface {
+	// ShareLast 为该 chat 最近一次运行生成只读分享链接; 没有可分享的运行时 url 为空
+	ShareLast(chatID int64, ttl time.Duration) (url string, expires time.Time, err error)
+}
+
+// BudgetReporter 额度池用量查询接口 (/usage quota)
+type BudgetReporter interface {
+	// Report 返回适用于该用户/会话的各额度池在当前窗口的用量
+	Report(ctx context.Context, subject service.BudgetSubject) ([]service.BudgetStatus, error)
+}
+
+// PastRunSearcher 过往运行与产物检索接口 (/recall)
+type PastRunSearcher interface {
+	// Search 全文检索该 chat 的运行、转录与产物, 按相关度排序
+	Search(query string, chatID int64, limit int) ([]toolpkg.ArchiveHit, error)
+}
+
+// WorkflowRunner 命名流水线接口 (/workflow)
+type WorkflowRunner interface {
+	// List 列出已定义的流水线, 定义无效的文件在 errs 中返回
+	List() ([]*workflow.Workflow, []error)
+	// Start 在后台运行流水线, 结束后把逐 stage 汇总发到该 chat
+	Start(ctx context.Context, chatID int64, name, input string) (*workflow.Workflow, error)
+}
+
 // HistoryMessage is a simplified message for the session-memory hook.
 type HistoryMessage struct {
 	Role    string // "user" | "assistant"
@@ -151,22 +259,44 @@ type TtsController interface {
 	SetLimit(chatID int64, limit int) error
 	IsSummaryEnabled(chatID int64) bool
 	SetSummaryEnabled(chatID int64, on bool)
+	// GenerateAudio 合成 text 并以语音消息发送到 chatID; 返回非空文本时作为命令回复
 	GenerateAudio(ctx context.Context, chatID int64, text string) (string, error)
 	GetStatus(chatID int64) *TtsStatus
 }
 
+// ModelStatsProvider 模型调用统计接口 (/status models)
+type ModelStatsProvider interface {
+	Snapshot() []entity.ModelStats
+}
+
 // ModelInfo 模型信息
 type ModelInfo struct {
-	ID          string // 模型 ID (如 "antigravity/gemini-3-flash")
-	Alias       string // 别名 (如 "Flash")
-	Provider    string // 提供商
-	Description string // 描述
+	ID           string   // 模型 ID (如 "antigravity/gemini-3-flash")
+	Alias        string   // 别名 (如 "Flash")
+	Provider     string   // 提供商
+	Description  string   // 描述
+	Capabilities []string // 能力标记: tools / vision / reasoning
+}
+
+// ModelProber 模型探测接口 (/models 的 "测试" 按钮), 发送 1-token 请求并返回耗时
+type ModelProber interface {
+	Probe(ctx context.Context, model string) (time.Duration, error)
+}
+
+// ProfileSwitcher 配置 profile 切换接口 (/profile), 切换后网关以新配置重启
+type ProfileSwitcher interface {
+	ActiveProfile() string
+	ListProfiles() ([]string, error)
+	SwitchProfile(name string) error
 }
 
 // CommandRegistry 命令注册表
 type CommandRegistry struct {
 	handlers          map[string]CommandHandler
 	aliases           map[string]string
+	specs             map[string]*CommandSpec
+	specOrder         []string
+	adminIDs          []int64
 	sessionManager    SessionManager
 	runController     RunController
 	contextController ContextController
@@ -179,16 +309,39 @@ type CommandRegistry struct {
 	pluginManager     PluginManager
 	ttsController     TtsController
 	skillManager      *toolpkg.SkillManager
+	toolCatalog       func() *toolpkg.ToolCatalog
 	cronService       *CronService
+	heartbeat         HeartbeatStatusProvider
 	historyClearer    HistoryClearer
+	sessionCarrier    SessionCarrier // agent.carry_over, nil = /new 从空白开始
+	feedbackRecorder  FeedbackRecorder
+	dataEraser        DataEraser
+	runSharer         RunSharer
+	budgetReporter    BudgetReporter
+	pastRuns          PastRunSearcher
+	workflows         WorkflowRunner
+	modelStats        ModelStatsProvider
+	modelProber       ModelProber
+	profileSwitcher   ProfileSwitcher
+	modelRouter       *service.ModelRouter
+	routeDefault      bool
+	draftPolicy       *service.DraftPolicy // agent.draft, nil = 未配置起草模型
+	draftDefault      bool
+	agentRepo         repository.AgentRepository // /agent 的命名代理
+	templateStore     *prompt.TemplateStore
+	promptVars        map[string]string // agent.prompt_vars, /setvar 列表中显示为配置默认值
+	pendingTemplates  map[int64]*templateFill
+	templateMu        sync.Mutex
 	mu                sync.RWMutex
 }
 
 // NewCommandRegistry 创建命令注册表
 func NewCommandRegistry() *CommandRegistry {
 	return &CommandRegistry{
-		handlers: make(map[string]CommandHandler),
-		aliases:  make(map[string]string),
+		handlers:         make(map[string]CommandHandler),
+		aliases:          make(map[string]string),
+		specs:            make(map[string]*CommandSpec),
+		pendingTemplates: make(map[int64]*templateFill),
 	}
 }
 
@@ -206,6 +359,13 @@ func (r *CommandRegistry) SetRunController(ctrl RunController) {
 	r.runController = ctrl
 }
 
+// SetProfileSwitcher 设置 profile 切换器
+func (r *CommandRegistry) SetProfileSwitcher(ps ProfileSwitcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.profileSwitcher = ps
+}
+
 // SetContextController 设置上下文控制器
 func (r *CommandRegistry) SetContextController(ctrl ContextController) {
 	r.mu.Lock()
@@ -283,6 +443,13 @@ func (r *CommandRegistry) SetCronService(cs *CronService) {
 	r.cronService = cs
 }
 
+// SetHeartbeatStatus 设置心跳状态来源 (/cron status)
+func (r *CommandRegistry) SetHeartbeatStatus(hs HeartbeatStatusProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.heartbeat = hs
+}
+
 // SetHistoryClearer 设置对话历史清除器
 func (r *CommandRegistry) SetHistoryClearer(hc HistoryClearer) {
 	r.mu.Lock()
@@ -290,6 +457,114 @@ func (r *CommandRegistry) SetHistoryClearer(hc HistoryClearer) {
 	r.historyClearer = hc
 }
 
+// SetSessionCarrier 设置 /new 的会话要点延续
+func (r *CommandRegistry) SetSessionCarrier(sc SessionCarrier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessionCarrier = sc
+}
+
+// SetFeedbackRecorder 设置用户反馈记录器 (/feedback)
+func (r *CommandRegistry) SetFeedbackRecorder(fr FeedbackRecorder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.feedbackRecorder = fr
+}
+
+// SetRunSharer 设置运行记录分享器 (/share)
+func (r *CommandRegistry) SetRunSharer(rs RunSharer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runSharer = rs
+}
+
+// SetBudgetReporter 设置额度池用量来源 (/usage quota)
+func (r *CommandRegistry) SetBudgetReporter(br BudgetReporter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.budgetReporter = br
+}
+
+// SetPastRunSearcher 设置过往运行检索 (/recall)
+func (r *CommandRegistry) SetPastRunSearcher(ps PastRunSearcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pastRuns = ps
+}
+
+// SetWorkflowRunner 设置命名流水线 (/workflow)
+func (r *CommandRegistry) SetWorkflowRunner(wr WorkflowRunner) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.workflows = wr
+}
+
+// SetDataEraser 设置个人数据清除器 (/forgetme)
+func (r *CommandRegistry) SetDataEraser(de DataEraser) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dataEraser = de
+}
+
+// SetModelStatsProvider 设置模型调用统计来源
+func (r *CommandRegistry) SetModelStatsProvider(msp ModelStatsProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.modelStats = msp
+}
+
+// SetModelProber 设置模型探测器
+func (r *CommandRegistry) SetModelProber(mp ModelProber) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.modelProber = mp
+}
+
+// SetModelRouter 设置自动选模型路由表; enabled 为未用 /route 覆盖的会话的默认开关
+func (r *CommandRegistry) SetModelRouter(router *service.ModelRouter, enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.modelRouter = router
+	r.routeDefault = enabled
+}
+
+// SetDraftPolicy 设置草稿模式; enabled 为未用 /draft 覆盖的会话的默认开关
+func (r *CommandRegistry) SetDraftPolicy(p *service.DraftPolicy, enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.draftPolicy = p
+	r.draftDefault = enabled
+}
+
+// SetAgentRepository 设置命名代理仓储 (/agent)
+func (r *CommandRegistry) SetAgentRepository(repo repository.AgentRepository) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.agentRepo = repo
+}
+
+// SetTemplateStore 设置提示词模板库
+func (r *CommandRegistry) SetTemplateStore(ts *prompt.TemplateStore) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.templateStore = ts
+}
+
+// SetPromptVarDefaults 设置配置中的提示词变量默认值 (agent.prompt_vars)
+func (r *CommandRegistry) SetPromptVarDefaults(vars map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.promptVars = vars
+}
+
+// localeFor 返回指定 chat 的界面语言 (调用方需已持有读锁或无需加锁)
+func (r *CommandRegistry) localeFor(chatID int64) i18n.Locale {
+	if ls, ok := r.sessionManager.(LocaleSettings); ok {
+		return i18n.Resolve(ls.GetLocale(chatID))
+	}
+	return i18n.Default
+}
+
 // Register 注册命令
 func (r *CommandRegistry) Register(name string, handler CommandHandler) {
 	r.mu.Lock()
@@ -311,15 +586,24 @@ func (r *CommandRegistry) Handle(ctx context.Context, cmd *Command) (*OutgoingMe
 
 	name := strings.ToLower(cmd.Name)
 
-	// 检查别名
-	if target, ok := r.aliases[name]; ok {
-		name = target
+	// 检查别名 (同名的已注册命令优先)
+	if _, ok := r.handlers[name]; !ok {
+		if target, ok := r.aliases[name]; ok {
+			name = target
+		}
 	}
 
 	handler, exists := r.handlers[name]
 	if !exists {
 		return nil, false, nil
 	}
+	r.cancelPendingTemplate(cmd.ChatID)
+
+	if spec, ok := r.specs[name]; ok {
+		if reply := r.checkCommand(spec, cmd); reply != nil {
+			return reply, true, nil
+		}
+	}
 
 	response, err := handler(ctx, cmd)
 	return response, true, err
@@ -357,10 +641,15 @@ func (a *Adapter) RegisterBuiltinCommands(registry *CommandRegistry, secCtrl ...
 	a.registerSettingsCommands(registry)
 	a.registerContextCommands(registry)
 	a.registerAgentCommands(registry)
+	a.registerMemoryCommands(registry)
+	a.registerPrivacyCommands(registry)
+	a.registerTemplateCommands(registry)
 	a.registerAdminCommands(registry)
+	a.registerToolCommands(registry)
 	if len(secCtrl) > 0 && secCtrl[0] != nil {
 		a.registerSecurityCommands(registry, secCtrl[0])
 	}
+	defineBuiltinCommands(registry)
 }
 
 
@@ -2,61 +2,80 @@ package telegram
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/repository"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"go.uber.org/zap"
 )
 
 // CronJob 定时任务
 type CronJob struct {
-	ID        string
-	ChatID    int64
-	CronExpr  string // cron 表达式
-	Command   string // 要执行的命令
-	Enabled   bool
-	LastRun   time.Time
-	NextRun   time.Time
-	CreatedAt time.Time
+	ID         string
+	ChatID     int64
+	CronExpr   string // cron 表达式
+	Command    string // 要执行的命令
+	CatchUp    string // 停机期间错过的运行如何补跑 (skip / once / all); 空 = 全局策略
+	Enabled    bool
+	LastRun    time.Time
+	LastStatus string // ok / error / skipped
+	LastError  string
+	NextRun    time.Time
+	Missed     int // 上次启动时错过的次数
+	CreatedAt  time.Time
+
+	running bool
 }
 
 // CronService 定时任务服务
 type CronService struct {
-	db       *sql.DB
+	repo     repository.ScheduleRepository
+	policy   service.CatchUpPolicy
+	logger   *zap.Logger
 	jobs     map[string]*CronJob
 	mu       sync.RWMutex
 	ctx      context.Context
 	cancel   context.CancelFunc
-	executor func(chatID int64, command string) error
+	executor func(ctx context.Context, chatID int64, command string) error
+	now      func() time.Time
 }
 
-// NewCronService 创建定时任务服务
-func NewCronService(db *sql.DB) *CronService {
+// NewCronService 创建定时任务服务; policy 为全局补跑策略 (scheduler.catch_up)
+func NewCronService(repo repository.ScheduleRepository, policy service.CatchUpPolicy, logger *zap.Logger) *CronService {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &CronService{
-		db:     db,
+		repo:   repo,
+		policy: policy,
+		logger: logger,
 		jobs:   make(map[string]*CronJob),
 		ctx:    ctx,
 		cancel: cancel,
+		now:    time.Now,
 	}
 }
 
 // SetExecutor 设置命令执行器
-func (c *CronService) SetExecutor(executor func(chatID int64, command string) error) {
+func (c *CronService) SetExecutor(executor func(ctx context.Context, chatID int64, command string) error) {
 	c.executor = executor
 }
 
-// Start 启动定时任务调度器
+// Start 加载任务, 补跑停机期间错过的运行, 然后启动调度循环
 func (c *CronService) Start() error {
 	// 加载现有任务
 	if err := c.loadJobs(); err != nil {
 		return err
 	}
 
-	// 启动调度循环
-	go c.scheduleLoop()
+	go func() {
+		c.catchUp(c.now())
+		c.scheduleLoop()
+	}()
 
 	return nil
 }
@@ -66,40 +85,91 @@ func (c *CronService) Stop() {
 	c.cancel()
 }
 
-// loadJobs 从数据库加载任务
+// loadJobs 从仓储加载任务
 func (c *CronService) loadJobs() error {
-	rows, err := c.db.Query(`
-		SELECT id, chat_id, cron_expr, command, enabled, last_run, next_run, created_at
-		FROM cron_jobs WHERE enabled = 1`)
+	schedules, err := c.repo.List(c.ctx, entity.ScheduleCron)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	for rows.Next() {
-		job := &CronJob{}
-		var lastRun, nextRun, createdAt sql.NullTime
-		err := rows.Scan(&job.ID, &job.ChatID, &job.CronExpr, &job.Command,
-			&job.Enabled, &lastRun, &nextRun, &createdAt)
-		if err != nil {
+	for _, s := range schedules {
+		if !s.Enabled {
 			continue
 		}
-		if lastRun.Valid {
-			job.LastRun = lastRun.Time
+		c.jobs[s.ID] = &CronJob{
+			ID:         s.ID,
+			ChatID:     s.ChatID,
+			CronExpr:   s.Expr,
+			Command:    s.Command,
+			CatchUp:    s.CatchUp,
+			Enabled:    s.Enabled,
+			LastRun:    s.LastRun,
+			LastStatus: s.LastStatus,
+			LastError:  s.LastError,
+			NextRun:    s.NextRun,
+			Missed:     s.Missed,
+			CreatedAt:  s.CreatedAt,
 		}
-		if nextRun.Valid {
-			job.NextRun = nextRun.Time
+	}
+
+	return nil
+}
+
+// catchUp 处理停机期间错过的运行: 按策略补跑 0 次、1 次或每次, 然后排到下一次
+func (c *CronService) catchUp(now time.Time) {
+	type pending struct {
+		job  *CronJob
+		runs int
+	}
+	var due []pending
+
+	c.mu.Lock()
+	for _, job := range c.jobs {
+		if job.NextRun.IsZero() {
+			job.NextRun = c.calculateNextRun(job.CronExpr, now)
+		}
+		missed, next := service.MissedRuns(job.NextRun, now, func(t time.Time) time.Time {
+			return c.calculateNextRun(job.CronExpr, t)
+		})
+		job.Missed = missed
+		if missed == 0 {
+			continue
 		}
-		if createdAt.Valid {
-			job.CreatedAt = createdAt.Time
+		policy := c.policy.With(job.CatchUp)
+		runs := policy.Runs(missed)
+		job.NextRun = next
+		if runs == 0 {
+			job.LastStatus = entity.ScheduleSkipped
+		} else {
+			job.running = true
+			due = append(due, pending{job, runs})
 		}
-		c.jobs[job.ID] = job
+		c.logger.Info("Cron job missed runs while the gateway was down",
+			zap.String("id", job.ID),
+			zap.Int("missed", missed),
+			zap.Int("catch_up", runs),
+			zap.String("policy", policy.String()),
+		)
 	}
+	jobs := make([]*CronJob, 0, len(c.jobs))
+	for _, job := range c.jobs {
+		jobs = append(jobs, job)
+	}
+	c.mu.Unlock()
 
-	return nil
+	for _, job := range jobs {
+		c.save(job)
+	}
+	for _, p := range due {
+		go func() {
+			for i := 0; i < p.runs && c.ctx.Err() == nil; i++ {
+				c.executeJob(p.job, i == p.runs-1)
+			}
+		}()
+	}
 }
 
 // scheduleLoop 调度循环
@@ -117,68 +187,117 @@ func (c *CronService) scheduleLoop() {
 	}
 }
 
-// runDueJobs 运行到期的任务
+// runDueJobs 运行到期的任务; 上一次还没结束的任务本轮跳过
 func (c *CronService) runDueJobs(now time.Time) {
-	c.mu.RLock()
+	c.mu.Lock()
 	var dueJobs []*CronJob
 	for _, job := range c.jobs {
-		if job.Enabled && !job.NextRun.IsZero() && now.After(job.NextRun) {
+		if job.Enabled && !job.running && !job.NextRun.IsZero() && now.After(job.NextRun) {
+			job.running = true
 			dueJobs = append(dueJobs, job)
 		}
 	}
-	c.mu.RUnlock()
+	c.mu.Unlock()
 
 	for _, job := range dueJobs {
-		go c.executeJob(job)
+		go c.executeJob(job, true)
 	}
 }
 
-// executeJob 执行单个任务
-func (c *CronService) executeJob(job *CronJob) {
-	if c.executor == nil {
-		return
+// executeJob 执行单个任务并持久化结果; last 为 false 时 (补跑中) 任务仍保持运行状态
+func (c *CronService) executeJob(job *CronJob, last bool) {
+	start := c.now()
+	var err error
+	if c.executor != nil {
+		err = c.executor(c.ctx, job.ChatID, job.Command)
 	}
-
-	// 执行命令
-	if err := c.executor(job.ChatID, job.Command); err != nil {
-		// 记录错误但继续
+	if err != nil {
+		c.logger.Warn("Cron job failed", zap.String("id", job.ID), zap.Error(err))
 	}
 
-	// 更新运行时间
+	// 更新运行结果
 	c.mu.Lock()
-	job.LastRun = time.Now()
-	job.NextRun = c.calculateNextRun(job.CronExpr, job.LastRun)
+	job.LastRun = start
+	job.LastStatus = entity.ScheduleOK
+	job.LastError = ""
+	if err != nil {
+		job.LastStatus = entity.ScheduleFailed
+		job.LastError = err.Error()
+	}
+	if !job.NextRun.After(c.now()) {
+		job.NextRun = c.calculateNextRun(job.CronExpr, c.now())
+	}
+	if last {
+		job.running = false
+	}
 	c.mu.Unlock()
 
 	// 持久化
-	c.db.Exec(`
-		UPDATE cron_jobs SET last_run = ?, next_run = ? WHERE id = ?`,
-		job.LastRun, job.NextRun, job.ID)
+	c.save(job)
 }
 
-// Schedule 添加定时任务
-func (c *CronService) Schedule(chatID int64, cronExpr, command string) (string, error) {
+// save 持久化任务状态 (已取消的任务不再写回)
+func (c *CronService) save(job *CronJob) {
+	c.mu.RLock()
+	_, exists := c.jobs[job.ID]
+	s := &entity.Schedule{
+		ID:         job.ID,
+		Kind:       entity.ScheduleCron,
+		ChatID:     job.ChatID,
+		Expr:       job.CronExpr,
+		Command:    job.Command,
+		CatchUp:    job.CatchUp,
+		Enabled:    job.Enabled,
+		LastRun:    job.LastRun,
+		LastStatus: job.LastStatus,
+		LastError:  job.LastError,
+		NextRun:    job.NextRun,
+		Missed:     job.Missed,
+		CreatedAt:  job.CreatedAt,
+	}
+	c.mu.RUnlock()
+	if !exists {
+		return
+	}
+	if err := c.repo.Save(context.Background(), s); err != nil {
+		c.logger.Warn("Failed to save cron job", zap.String("id", job.ID), zap.Error(err))
+	}
+}
+
+// Schedule 添加定时任务; catchUp 为空时使用全局补跑策略
+func (c *CronService) Schedule(chatID int64, cronExpr, command, catchUp string) (string, error) {
 	// 验证 cron 表达式
-	nextRun := c.calculateNextRun(cronExpr, time.Now())
+	nextRun := c.calculateNextRun(cronExpr, c.now())
 	if nextRun.IsZero() {
 		return "", fmt.Errorf("无效的 cron 表达式: %s", cronExpr)
 	}
+	if !service.ValidCatchUp(catchUp) {
+		return "", fmt.Errorf("无效的补跑策略: %s (skip / once / all)", catchUp)
+	}
 
 	job := &CronJob{
-		ID:        fmt.Sprintf("cron_%d_%d", chatID, time.Now().UnixNano()),
+		ID:        fmt.Sprintf("cron_%d_%d", chatID, c.now().UnixNano()),
 		ChatID:    chatID,
 		CronExpr:  cronExpr,
 		Command:   command,
+		CatchUp:   catchUp,
 		Enabled:   true,
 		NextRun:   nextRun,
-		CreatedAt: time.Now(),
+		CreatedAt: c.now(),
 	}
 
-	// 保存到数据库
-	_, err := c.db.Exec(`
-		INSERT INTO cron_jobs (id, chat_id, cron_expr, command, enabled, next_run, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		job.ID, job.ChatID, job.CronExpr, job.Command, 1, job.NextRun, job.CreatedAt)
+	// 保存到仓储
+	err := c.repo.Save(c.ctx, &entity.Schedule{
+		ID:        job.ID,
+		Kind:      entity.ScheduleCron,
+		ChatID:    job.ChatID,
+		Expr:      job.CronExpr,
+		Command:   job.Command,
+		CatchUp:   job.CatchUp,
+		Enabled:   true,
+		NextRun:   job.NextRun,
+		CreatedAt: job.CreatedAt,
+	})
 	if err != nil {
 		return "", err
 	}
@@ -197,11 +316,30 @@ func (c *CronService) Cancel(jobID string) error {
 	delete(c.jobs, jobID)
 	c.mu.Unlock()
 
-	_, err := c.db.Exec(`DELETE FROM cron_jobs WHERE id = ?`, jobID)
-	return err
+	return c.repo.Delete(c.ctx, jobID)
 }
 
-// List 列出聊天的所有定时任务
+// ForgetChat 删除该 chat 的全部定时任务 (/forgetme), 返回删除个数
+func (c *CronService) ForgetChat(ctx context.Context, chatID int64) (int, error) {
+	c.mu.Lock()
+	var ids []string
+	for id, job := range c.jobs {
+		if job.ChatID == chatID {
+			ids = append(ids, id)
+			delete(c.jobs, id)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, id := range ids {
+		if err := c.repo.Delete(ctx, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
+
+// List 列出聊天的所有定时任务, 按下次运行时间排序 (返回副本)
 func (c *CronService) List(chatID int64) []*CronJob {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -209,12 +347,19 @@ func (c *CronService) List(chatID int64) []*CronJob {
 	var result []*CronJob
 	for _, job := range c.jobs {
 		if job.ChatID == chatID {
-			result = append(result, job)
+			j := *job
+			result = append(result, &j)
 		}
 	}
+	sort.Slice(result, func(i, k int) bool { return result[i].NextRun.Before(result[k].NextRun) })
 	return result
 }
 
+// Policy 任务实际使用的补跑策略
+func (c *CronService) Policy(job *CronJob) service.CatchUpPolicy {
+	return c.policy.With(job.CatchUp)
+}
+
 // calculateNextRun 计算下次运行时间
 // 简化实现：支持 @hourly, @daily, @weekly, 或 "分钟 小时 日 月 星期" 格式
 func (c *CronService) calculateNextRun(cronExpr string, after time.Time) time.Time {
@@ -0,0 +1,137 @@
+package telegram
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"go.uber.org/zap"
+)
+
+// memSchedules in-memory repository.ScheduleRepository
+type memSchedules struct {
+	mu   sync.Mutex
+	rows map[string]entity.Schedule
+}
+
+func (r *memSchedules) List(_ context.Context, kind string) ([]*entity.Schedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*entity.Schedule
+	for _, s := range r.rows {
+		if s.Kind == kind {
+			s := s
+			out = append(out, &s)
+		}
+	}
+	return out, nil
+}
+
+func (r *memSchedules) Get(_ context.Context, id string) (*entity.Schedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.rows[id]; ok {
+		return &s, nil
+	}
+	return nil, nil
+}
+
+func (r *memSchedules) Save(_ context.Context, s *entity.Schedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rows[s.ID] = *s
+	return nil
+}
+
+func (r *memSchedules) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.rows, id)
+	return nil
+}
+
+func TestCronService_CatchUp(t *testing.T) {
+	now := time.Date(2026, 3, 5, 12, 0, 0, 0, time.Local)
+	missedSince := time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local) // @daily: 3/2, 3/3, 3/4, 3/5 missed
+
+	repo := &memSchedules{rows: map[string]entity.Schedule{
+		"skip": {ID: "skip", Kind: entity.ScheduleCron, ChatID: 1, Expr: "@daily", Command: "a", CatchUp: service.CatchUpSkip, Enabled: true, NextRun: missedSince},
+		"all":  {ID: "all", Kind: entity.ScheduleCron, ChatID: 1, Expr: "@daily", Command: "b", Enabled: true, NextRun: missedSince},
+		"ok":   {ID: "ok", Kind: entity.ScheduleCron, ChatID: 2, Expr: "@daily", Command: "c", Enabled: true, NextRun: now.Add(time.Hour)},
+	}}
+	c := NewCronService(repo, service.CatchUpPolicy{Mode: service.CatchUpAll, Max: 3}, zap.NewNop())
+	c.now = func() time.Time { return now }
+	ran := make(chan string, 10)
+	c.SetExecutor(func(_ context.Context, _ int64, command string) error {
+		ran <- command
+		return nil
+	})
+	if err := c.loadJobs(); err != nil {
+		t.Fatal(err)
+	}
+	c.catchUp(now)
+
+	for i := 0; i < 3; i++ {
+		select {
+		case cmd := <-ran:
+			if cmd != "b" {
+				t.Errorf("ran %q", cmd)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("only %d catch-up runs", i)
+		}
+	}
+	select {
+	case cmd := <-ran:
+		t.Errorf("unexpected run %q", cmd)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	wantNext := time.Date(2026, 3, 6, 0, 0, 0, 0, time.Local)
+	skip, _ := repo.Get(context.Background(), "skip")
+	if skip.LastStatus != entity.ScheduleSkipped || skip.Missed != 4 || !skip.NextRun.Equal(wantNext) {
+		t.Errorf("skip = %+v", skip)
+	}
+	waitFor(t, func() bool {
+		all, _ := repo.Get(context.Background(), "all")
+		return all.LastStatus == entity.ScheduleOK && all.Missed == 4 && all.NextRun.Equal(wantNext)
+	})
+	if ok, _ := repo.Get(context.Background(), "ok"); ok.Missed != 0 || !ok.LastRun.IsZero() {
+		t.Errorf("ok = %+v", ok)
+	}
+
+	if n, err := c.ForgetChat(context.Background(), 1); n != 2 || err != nil {
+		t.Errorf("ForgetChat = %d, %v", n, err)
+	}
+	if len(repo.rows) != 1 || len(c.List(1)) != 0 {
+		t.Errorf("left %d rows, %d jobs", len(repo.rows), len(c.List(1)))
+	}
+}
+
+func TestCronService_ScheduleRejectsUnknownCatchUp(t *testing.T) {
+	c := NewCronService(&memSchedules{rows: map[string]entity.Schedule{}}, service.CatchUpPolicy{}, zap.NewNop())
+	if _, err := c.Schedule(1, "@hourly", "x", "sometimes"); err == nil {
+		t.Error("expected error")
+	}
+	id, err := c.Schedule(1, "@hourly", "x", service.CatchUpAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jobs := c.List(1); len(jobs) != 1 || jobs[0].ID != id || c.Policy(jobs[0]).Mode != service.CatchUpAll {
+		t.Errorf("jobs = %+v", jobs)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -1,7 +1,6 @@
 package telegram
 
 import (
-	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,56 +9,8 @@ import (
 	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"go.uber.org/zap"
 )
 
-// StartWebhook 启动 Webhook 模式
-func (a *Adapter) StartWebhook(ctx context.Context, listenAddr string) error {
-	if a.config.WebhookURL == "" {
-		return fmt.Errorf("webhook URL not configured")
-	}
-
-	// 设置 Webhook
-	wh, err := tgbotapi.NewWebhook(a.config.WebhookURL)
-	if err != nil {
-		return fmt.Errorf("failed to create webhook: %w", err)
-	}
-
-	_, err = a.bot.Request(wh)
-	if err != nil {
-		return fmt.Errorf("failed to set webhook: %w", err)
-	}
-
-	a.logger.Info("Webhook set",
-		zap.String("url", a.config.WebhookURL),
-	)
-
-	// 获取更新通道
-	updates := a.bot.ListenForWebhook("/" + a.bot.Token)
-
-	// 启动 HTTP 服务器
-	go func() {
-		a.logger.Info("Starting webhook server",
-			zap.String("addr", listenAddr),
-		)
-		if err := http.ListenAndServe(listenAddr, nil); err != nil {
-			a.logger.Error("Webhook server error", zap.Error(err))
-		}
-	}()
-
-	// 处理更新
-	for {
-		select {
-		case <-ctx.Done():
-			// 删除 Webhook
-			a.bot.Request(tgbotapi.DeleteWebhookConfig{})
-			return ctx.Err()
-		case update := <-updates:
-			go a.handleUpdate(ctx, update)
-		}
-	}
-}
-
 // SendPhoto 发送图片
 func (a *Adapter) SendPhoto(chatID int64, photoPath string, caption string) error {
 	// 检查是 URL 还是本地文件
@@ -135,6 +86,17 @@ func (a *Adapter) SendDocument(chatID int64, docPath string, caption string) err
 	return err
 }
 
+// SendTextDocument 把内存中的文本作为文档发送 (无需落盘)
+func (a *Adapter) SendTextDocument(chatID int64, name, content, caption string) error {
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  name,
+		Bytes: []byte(content),
+	})
+	doc.Caption = caption
+	_, err := a.bot.Send(doc)
+	return err
+}
+
 // SendVoice 发送语音
 func (a *Adapter) SendVoice(chatID int64, voicePath string) error {
 	file, err := os.Open(voicePath)
@@ -151,6 +113,17 @@ func (a *Adapter) SendVoice(chatID int64, voicePath string) error {
 	return err
 }
 
+// SendVoiceData 发送内存中的 OGG/Opus 语音 (TTS 回复), replyToID 非 0 时回复该消息
+func (a *Adapter) SendVoiceData(chatID int64, replyToID int, audio []byte) error {
+	voice := tgbotapi.NewVoice(chatID, tgbotapi.FileBytes{
+		Name:  "reply.ogg",
+		Bytes: audio,
+	})
+	voice.ReplyToMessageID = replyToID
+	_, err := a.bot.Send(voice)
+	return err
+}
+
 // DownloadFile 下载 Telegram 文件
 func (a *Adapter) DownloadFile(fileID string, destPath string) error {
 	// 获取文件信息
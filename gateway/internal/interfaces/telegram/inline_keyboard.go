@@ -49,39 +49,26 @@ func BuildProviderKeyboard(providers []string) tgbotapi.InlineKeyboardMarkup {
 }
 
 // BuildModelsKeyboard 构建模型选择键盘
+// 每行一个模型: [名称 + 能力徽章 → 直接切换] [🧪 → 先探测再切换]
 func BuildModelsKeyboard(provider string, models []ModelInfo, currentModel string, page, pageSize int) tgbotapi.InlineKeyboardMarkup {
 	var rows [][]InlineButton
 
 	// 计算分页
-	start := page * pageSize
-	end := start + pageSize
-	if end > len(models) {
-		end = len(models)
-	}
-	pageModels := models[start:end]
-	totalPages := (len(models) + pageSize - 1) / pageSize
+	pageModels, page, totalPages := pageSlice(models, page, pageSize)
 
-	// 模型按钮 (每行 2 个)
-	for i := 0; i < len(pageModels); i += 2 {
-		row := []InlineButton{}
-		for j := 0; j < 2 && i+j < len(pageModels); j++ {
-			m := pageModels[i+j]
-			text := m.Alias
-			if text == "" {
-				// 使用 ID 的最后部分
-				parts := splitString(m.ID, "/")
-				text = parts[len(parts)-1]
-			}
-			// 标记当前模型
-			if m.ID == currentModel {
-				text = "✓ " + text
-			}
-			row = append(row, InlineButton{
-				Text:         text,
-				CallbackData: "/_setmodel " + m.ID,
-			})
+	for _, m := range pageModels {
+		text := modelLabel(m)
+		if badges := capabilityBadges(m.Capabilities); badges != "" {
+			text += " " + badges
 		}
-		rows = append(rows, row)
+		// 标记当前模型
+		if m.ID == currentModel {
+			text = "✓ " + text
+		}
+		rows = append(rows, []InlineButton{
+			{Text: text, CallbackData: "/_setmodel " + m.ID},
+			{Text: "🧪", CallbackData: "/_probemodel " + m.ID},
+		})
 	}
 
 	// 分页导航
@@ -114,6 +101,50 @@ func BuildModelsKeyboard(provider string, models []ModelInfo, currentModel strin
 	return BuildInlineKeyboard(rows)
 }
 
+// pageSlice 返回第 page 页的模型、实际页码及总页数 (page 越界时夹到首/末页)
+func pageSlice(models []ModelInfo, page, pageSize int) ([]ModelInfo, int, int) {
+	totalPages := (len(models) + pageSize - 1) / pageSize
+	if page >= totalPages {
+		page = totalPages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+	start := page * pageSize
+	end := start + pageSize
+	if end > len(models) {
+		end = len(models)
+	}
+	return models[start:end], page, totalPages
+}
+
+// modelLabel 模型显示名: 别名, 否则 ID 的最后一段
+func modelLabel(m ModelInfo) string {
+	if m.Alias != "" {
+		return m.Alias
+	}
+	parts := splitString(m.ID, "/")
+	return parts[len(parts)-1]
+}
+
+// capabilityBadges 能力徽章: tools → 🛠, vision → 👁, reasoning → 🧠
+func capabilityBadges(caps []string) string {
+	var badges string
+	for _, c := range []struct{ name, badge string }{
+		{"tools", "🛠"},
+		{"vision", "👁"},
+		{"reasoning", "🧠"},
+	} {
+		for _, have := range caps {
+			if have == c.name {
+				badges += c.badge
+				break
+			}
+		}
+	}
+	return badges
+}
+
 // BuildConfirmKeyboard 构建确认键盘
 func BuildConfirmKeyboard(confirmData, cancelData string) tgbotapi.InlineKeyboardMarkup {
 	return BuildInlineKeyboard([][]InlineButton{
@@ -1,5 +1,10 @@
 package telegram
 
+import (
+	"strings"
+	"unicode/utf8"
+)
+
 // TelegramMessageLimit Telegram 消息长度限制
 const TelegramMessageLimit = 4096
 
@@ -229,3 +234,183 @@ func (a *Adapter) SendChunkedMessage(chatID int64, text string, parseMode string
 	
 	return nil
 }
+
+// ReplyPartLimit 最终回复每段 Markdown 的长度上限; 转换后的 HTML 超限时由 SplitReplyHTML 再切
+const ReplyPartLimit = 3800
+
+// ReplyDocumentThreshold 超过该段数时额外附上完整回复文档
+const ReplyDocumentThreshold = 3
+
+// SplitReply 把超长的 Markdown 回复切成若干段, 每段单独转换为 HTML 后仍然合法.
+// 优先在代码块外的空行 (段落) 处切分, 其次在行尾; 切点落在代码块内时本段闭合 fence,
+// 下一段以相同的开头 (含语言标记, 如 ```go) 重新打开. 长度按 Telegram 计数的
+// UTF-16 码元计算.
+func SplitReply(text string, limit int) []string {
+	if telegramLen(text) <= limit {
+		return []string{text}
+	}
+
+	// 预留闭合 / 重开 fence 的空间, 超长单行硬切
+	lineLimit := limit - 32
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, splitLongLine(line, lineLimit)...)
+	}
+
+	var (
+		parts  []string
+		cur    []string
+		curLen int
+		base   int    // 新段开头重开 fence 占用的长度
+		fence  string // 当前打开的 fence 行, 空 = 不在代码块内
+
+		// 最近一个代码块外的段落边界: cur 中的行数 / 下一输入行 / 当时长度
+		paraCut, paraLine, paraLen int
+	)
+	flush := func() {
+		part := strings.Trim(strings.Join(cur, "\n"), "\n")
+		if fence != "" {
+			part += "\n```"
+		}
+		if strings.TrimSpace(part) != "" {
+			parts = append(parts, part)
+		}
+		cur, curLen, paraCut = nil, 0, 0
+		if fence != "" {
+			cur = []string{fence}
+			curLen = telegramLen(fence) + 1
+		}
+		base = curLen
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		n := telegramLen(line) + 1
+		reserve := 0
+		if fence != "" {
+			reserve = 4 // "\n```"
+		}
+		if curLen+n+reserve > limit && curLen > base {
+			if paraCut > 0 && paraLen >= limit/2 {
+				// 回退到段落边界, 其后的行在下一段重新处理
+				cur, fence, i = cur[:paraCut], "", paraLine-1
+				flush()
+				continue
+			}
+			flush()
+		}
+
+		cur = append(cur, line)
+		curLen += n
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			if fence == "" {
+				fence = trimmed
+			} else {
+				fence = ""
+				paraCut, paraLine, paraLen = len(cur), i+1, curLen
+			}
+		case fence == "" && trimmed == "":
+			paraCut, paraLine, paraLen = len(cur), i+1, curLen
+		}
+	}
+	fence = "" // 原文未闭合的代码块保持原样
+	flush()
+	return parts
+}
+
+// replyMarkerReserve 为分页标记 "\n\n📄 <i>(12/34)</i>" 预留的长度
+const replyMarkerReserve = 32
+
+// SplitReplyHTML 切分 Markdown 回复并逐段转换为 TG HTML. 转义和标签会让 HTML 比
+// Markdown 长得多 (代码里的 & < > 都会变成实体), 所以按转换后的长度加上分页标记
+// 和后缀 (只加在最后一段) 校验, 超过 TelegramMessageLimit 的段按比例缩小上限重新切分.
+func SplitReplyHTML(text, suffix string) []string {
+	budget := TelegramMessageLimit - replyMarkerReserve
+	suffixLen := 0
+	if suffix != "" {
+		suffixLen = telegramLen(suffix) + 2
+	}
+
+	var fit func(md string, extra int) []string
+	fit = func(md string, extra int) []string {
+		html := MarkdownToTelegramHTML(md)
+		n, max := telegramLen(html), budget-extra
+		if n <= max {
+			return []string{html}
+		}
+		limit := telegramLen(md) * max / n
+		if limit < 256 {
+			limit = 256
+		}
+		sub := SplitReply(md, limit)
+		if len(sub) <= 1 {
+			return []string{html}
+		}
+		var out []string
+		for i, part := range sub {
+			e := 0
+			if i == len(sub)-1 {
+				e = extra
+			}
+			out = append(out, fit(part, e)...)
+		}
+		return out
+	}
+
+	parts := SplitReply(text, ReplyPartLimit)
+	if len(parts) == 0 {
+		parts = []string{text}
+	}
+	var out []string
+	for i, part := range parts {
+		e := 0
+		if i == len(parts)-1 {
+			e = suffixLen
+		}
+		out = append(out, fit(part, e)...)
+	}
+	return out
+}
+
+// splitLongLine 把超过 max 的单行切成多行, 尽量在空格处断开
+func splitLongLine(line string, max int) []string {
+	var out []string
+	for telegramLen(line) > max {
+		cut := 0
+		size := 0
+		for i, r := range line {
+			w := 1
+			if r > 0xFFFF {
+				w = 2
+			}
+			if size+w > max {
+				cut = i
+				break
+			}
+			size += w
+		}
+		if sp := strings.LastIndexByte(line[:cut], ' '); sp > cut/2 {
+			cut = sp + 1
+		}
+		out = append(out, line[:cut])
+		line = line[cut:]
+	}
+	return append(out, line)
+}
+
+// telegramLen Telegram 按 UTF-16 码元计算消息长度
+func telegramLen(s string) int {
+	n := 0
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		n++
+		if r > 0xFFFF {
+			n++
+		}
+		s = s[size:]
+	}
+	return n
+}
@@ -0,0 +1,114 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSplitReply_ShortTextUnchanged(t *testing.T) {
+	parts := SplitReply("hello\n\nworld", ReplyPartLimit)
+	if len(parts) != 1 || parts[0] != "hello\n\nworld" {
+		t.Fatalf("parts = %q", parts)
+	}
+}
+
+func TestSplitReply_ParagraphBoundaries(t *testing.T) {
+	var paras []string
+	for i := 0; i < 12; i++ {
+		paras = append(paras, fmt.Sprintf("para %d ", i)+strings.Repeat("x", 380))
+	}
+	parts := SplitReply(strings.Join(paras, "\n\n"), 1000)
+	if len(parts) < 5 {
+		t.Fatalf("got %d parts", len(parts))
+	}
+	for i, p := range parts {
+		if telegramLen(p) > 1000 {
+			t.Errorf("part %d too long: %d", i, telegramLen(p))
+		}
+		// 每段都以完整段落开头和结尾
+		if !strings.HasPrefix(p, "para ") || !strings.HasSuffix(p, "x") {
+			t.Errorf("part %d split mid-paragraph: %q...", i, p[:20])
+		}
+	}
+	if got := strings.Join(parts, "\n\n"); got != strings.Join(paras, "\n\n") {
+		t.Error("content changed by splitting")
+	}
+}
+
+func TestSplitReply_ReopensCodeFence(t *testing.T) {
+	var code []string
+	for i := 0; i < 120; i++ {
+		code = append(code, fmt.Sprintf("fmt.Println(%d) // 第 %d 行", i, i))
+	}
+	text := "Here is the fix:\n\n```go\n" + strings.Join(code, "\n") + "\n```\n\nDone."
+	parts := SplitReply(text, 1000)
+	if len(parts) < 3 {
+		t.Fatalf("got %d parts", len(parts))
+	}
+	for i, p := range parts {
+		if telegramLen(p) > 1000 {
+			t.Errorf("part %d too long: %d", i, telegramLen(p))
+		}
+		if n := strings.Count(p, "```"); n%2 != 0 {
+			t.Errorf("part %d has unbalanced fences (%d):\n%s", i, n, p)
+		}
+		if i > 0 && i < len(parts)-1 && !strings.HasPrefix(p, "```go\n") {
+			t.Errorf("part %d does not reopen the go fence: %q", i, p[:20])
+		}
+	}
+	if !strings.HasSuffix(parts[len(parts)-1], "Done.") {
+		t.Error("trailing text lost")
+	}
+}
+
+func TestSplitReply_HardSplitsLongLine(t *testing.T) {
+	text := strings.Repeat("😀", 3000) // 每个 emoji 占 2 个 UTF-16 码元
+	parts := SplitReply(text, 1000)
+	total := 0
+	for i, p := range parts {
+		if telegramLen(p) > 1000 {
+			t.Errorf("part %d too long: %d", i, telegramLen(p))
+		}
+		total += telegramLen(p)
+	}
+	if total != 6000 {
+		t.Errorf("total length = %d, want 6000", total)
+	}
+}
+
+func TestSplitReplyHTML_EscapeHeavyCodeFits(t *testing.T) {
+	var code []string
+	for i := 0; len(strings.Join(code, "\n")) < 3700; i++ {
+		code = append(code, fmt.Sprintf("if v, ok := <-ch%d; ok && v < %d && x > 0 { s += \"&\" }", i, i))
+	}
+	text := "```go\n" + strings.Join(code, "\n") + "\n```"
+	if n := telegramLen(text); n > ReplyPartLimit {
+		t.Fatalf("markdown = %d, want one SplitReply part", n)
+	}
+	suffix := "<i>⏱ 12.3s · 4 步 · gpt-4o</i>"
+	parts := SplitReplyHTML(text, suffix)
+	if len(parts) < 2 {
+		t.Fatalf("got %d parts, want the escaped code block re-split", len(parts))
+	}
+	for i, p := range parts {
+		// DeliverWithSuffix 追加的分页标记与后缀
+		p += fmt.Sprintf("\n\n📄 <i>(%d/%d)</i>", i+1, len(parts))
+		if i == len(parts)-1 {
+			p += "\n\n" + suffix
+		}
+		if n := telegramLen(p); n > TelegramMessageLimit {
+			t.Errorf("part %d = %d over the Telegram limit", i, n)
+		}
+		if strings.Count(p, "<pre") != strings.Count(p, "</pre>") {
+			t.Errorf("part %d has an unbalanced <pre>", i)
+		}
+	}
+}
+
+func TestSplitReplyHTML_ShortReplyOnePart(t *testing.T) {
+	parts := SplitReplyHTML("**hi** & bye", "")
+	if len(parts) != 1 || parts[0] != MarkdownToTelegramHTML("**hi** & bye") {
+		t.Fatalf("parts = %q", parts)
+	}
+}
@@ -0,0 +1,167 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// 表情反应 (message_reaction 更新)。
+//
+// telegram-bot-api v5.5.1 的 Update 不含 message_reaction, 解码时会被丢弃。
+// 这里在原始 JSON 上取出反应: 轮询模式在 Bot 的 HTTP 客户端上拦截 getUpdates
+// 响应 (reactionClient), webhook 模式在自己的 handler 里解析推送体。
+// message_reaction 默认不推送, 需要在 allowed_updates 中显式订阅;
+// 群聊中 Bot 还必须是管理员才能收到。
+
+// allowedUpdates 订阅的更新类型
+var allowedUpdates = []string{"message", "edited_message", "callback_query", "inline_query", "message_reaction"}
+
+// ErrReactionIgnored ReactionHandler 返回此错误表示反应的消息不是可反馈的回复 (静默忽略)
+var ErrReactionIgnored = errors.New("reaction target is not a tracked reply")
+
+// MessageReaction 用户对一条消息新增的表情反应
+type MessageReaction struct {
+	ChatID    int64 // 真实 chatID (反应更新不带话题信息)
+	ChatType  string
+	MessageID int
+	UserID    int64    // 匿名管理员反应时为 0
+	Added     []string // 本次新增的 emoji
+}
+
+type reactionTypeJSON struct {
+	Type  string `json:"type"`
+	Emoji string `json:"emoji"`
+}
+
+type reactionUpdateJSON struct {
+	MessageReaction *struct {
+		Chat struct {
+			ID   int64  `json:"id"`
+			Type string `json:"type"`
+		} `json:"chat"`
+		MessageID int `json:"message_id"`
+		User      *struct {
+			ID int64 `json:"id"`
+		} `json:"user"`
+		OldReaction []reactionTypeJSON `json:"old_reaction"`
+		NewReaction []reactionTypeJSON `json:"new_reaction"`
+	} `json:"message_reaction"`
+}
+
+// parseReactionUpdate 解析单个 Update; 不是新增 emoji 反应时返回 ok=false
+func parseReactionUpdate(raw []byte) (MessageReaction, bool) {
+	var u reactionUpdateJSON
+	if json.Unmarshal(raw, &u) != nil || u.MessageReaction == nil {
+		return MessageReaction{}, false
+	}
+	mr := u.MessageReaction
+	old := make(map[string]bool, len(mr.OldReaction))
+	for _, r := range mr.OldReaction {
+		old[r.Emoji] = true
+	}
+	out := MessageReaction{
+		ChatID:    mr.Chat.ID,
+		ChatType:  mr.Chat.Type,
+		MessageID: mr.MessageID,
+	}
+	if mr.User != nil {
+		out.UserID = mr.User.ID
+	}
+	for _, r := range mr.NewReaction {
+		if r.Type == "emoji" && !old[r.Emoji] {
+			out.Added = append(out.Added, r.Emoji)
+		}
+	}
+	return out, len(out.Added) > 0
+}
+
+// parseReactionUpdates 从 getUpdates 响应中取出全部新增反应
+func parseReactionUpdates(body []byte) []MessageReaction {
+	if !bytes.Contains(body, []byte(`"message_reaction"`)) {
+		return nil
+	}
+	var resp struct {
+		Result []json.RawMessage `json:"result"`
+	}
+	if json.Unmarshal(body, &resp) != nil {
+		return nil
+	}
+	var out []MessageReaction
+	for _, raw := range resp.Result {
+		if r, ok := parseReactionUpdate(raw); ok {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// reactionClient 包装 Bot API HTTP 客户端, 把 getUpdates 中的反应交给 handle (轮询模式)
+type reactionClient struct {
+	base   tgbotapi.HTTPClient
+	handle func(MessageReaction)
+}
+
+// Do 实现 tgbotapi.HTTPClient
+func (c *reactionClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.base.Do(req)
+	if err != nil || c.handle == nil || !strings.HasSuffix(req.URL.Path, "/getUpdates") {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range parseReactionUpdates(body) {
+		go c.handle(r)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// webhookHandler 接收 webhook 推送: 话题改写 + 反应分发, 其余 Update 送入 updates
+func (a *Adapter) webhookHandler(updates chan<- tgbotapi.Update) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST required", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if reaction, ok := parseReactionUpdate(body); ok {
+			go a.onReaction(reaction)
+		}
+		var update tgbotapi.Update
+		if err := json.Unmarshal(rewriteTopicBody(body), &update); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		updates <- update
+	}
+}
+
+// onReaction 校验权限后按新增 emoji 分发到 handleReaction
+func (a *Adapter) onReaction(r MessageReaction) {
+	isGroup := r.ChatType == "group" || r.ChatType == "supergroup"
+	if r.UserID == 0 || !a.isAllowedChat(r.ChatID, r.UserID, isGroup) {
+		a.logger.Debug("Ignoring reaction from unauthorized chat",
+			zap.Int64("chat_id", r.ChatID),
+			zap.Int64("user_id", r.UserID),
+		)
+		return
+	}
+	for _, emoji := range r.Added {
+		a.handleReaction(context.Background(), r.ChatID, r.MessageID, emoji)
+	}
+}
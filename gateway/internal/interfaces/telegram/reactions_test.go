@@ -0,0 +1,74 @@
+package telegram
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const reactionUpdates = `{"ok":true,"result":[
+	{"update_id":1,"message_reaction":{"chat":{"id":-1001987654321,"type":"supergroup"},"message_id":77,
+		"user":{"id":42},"date":1,"old_reaction":[{"type":"emoji","emoji":"👍"}],
+		"new_reaction":[{"type":"emoji","emoji":"👍"},{"type":"emoji","emoji":"👎"},{"type":"custom_emoji","custom_emoji_id":"5"}]}},
+	{"update_id":2,"message_reaction":{"chat":{"id":12345,"type":"private"},"message_id":8,
+		"user":{"id":12345},"date":1,"old_reaction":[{"type":"emoji","emoji":"👍"}],"new_reaction":[]}},
+	{"update_id":3,"message":{"message_id":9,"chat":{"id":12345,"type":"private"},"text":"hi"}}]}`
+
+func TestParseReactionUpdates(t *testing.T) {
+	got := parseReactionUpdates([]byte(reactionUpdates))
+	// 只有新增的 emoji 反应; 撤销反应 (update 2) 不产生事件
+	if len(got) != 1 {
+		t.Fatalf("got %+v", got)
+	}
+	r := got[0]
+	if r.ChatID != -1001987654321 || r.ChatType != "supergroup" || r.MessageID != 77 || r.UserID != 42 {
+		t.Errorf("reaction = %+v", r)
+	}
+	if len(r.Added) != 1 || r.Added[0] != "👎" {
+		t.Errorf("added = %v", r.Added)
+	}
+
+	if parseReactionUpdates([]byte(`{"ok":true,"result":[{"update_id":3,"message":{"text":"hi"}}]}`)) != nil {
+		t.Error("plain updates should yield no reactions")
+	}
+}
+
+func TestReactionClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, reactionUpdates)
+	}))
+	defer srv.Close()
+
+	got := make(chan MessageReaction, 4)
+	client := &reactionClient{base: srv.Client(), handle: func(r MessageReaction) { got <- r }}
+
+	for _, method := range []string{"sendMessage", "getUpdates"} {
+		req, _ := http.NewRequest("POST", srv.URL+"/botTOKEN/"+method, strings.NewReader(""))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != reactionUpdates {
+			t.Fatalf("%s: body not passed through", method)
+		}
+	}
+
+	select {
+	case r := <-got:
+		if r.MessageID != 77 {
+			t.Errorf("reaction = %+v", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("reaction not dispatched")
+	}
+	select {
+	case r := <-got:
+		t.Errorf("unexpected extra reaction (sendMessage must not be parsed): %+v", r)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
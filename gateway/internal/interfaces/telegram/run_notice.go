@@ -0,0 +1,95 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
+)
+
+// RunNotice 长时间运行提醒: 运行超过阈值后发送一条带 ⏹ 按钮的进度摘要。
+// 与 StagedReply 的状态消息不同, 它是新消息 (会推送通知); 再次发送时删除
+// 上一条, 运行结束时 Clear 删除, 避免聊天里残留失效的停止按钮。
+type RunNotice struct {
+	adapter *Adapter
+	chatID  int64
+	msgID   int
+	mu      sync.Mutex
+}
+
+// NewRunNotice 创建长时间运行提醒
+func (a *Adapter) NewRunNotice(chatID int64) *RunNotice {
+	return &RunNotice{adapter: a, chatID: chatID}
+}
+
+// Post 发送进度摘要 (替换上一条提醒)
+func (n *RunNotice) Post(text string, loc i18n.Locale) error {
+	keyboard := BuildInlineKeyboard([][]InlineButton{{
+		{Text: loc.T("run.stop_btn"), CallbackData: "/stop"},
+	}})
+	msg := tgbotapi.NewMessage(n.chatID, text)
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = keyboard
+
+	sent, err := n.adapter.bot.Send(msg)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	prev := n.msgID
+	n.msgID = sent.MessageID
+	n.mu.Unlock()
+
+	if prev != 0 {
+		n.adapter.bot.Request(tgbotapi.NewDeleteMessage(n.chatID, prev))
+	}
+	return nil
+}
+
+// Clear 删除当前提醒
+func (n *RunNotice) Clear() {
+	n.mu.Lock()
+	msgID := n.msgID
+	n.msgID = 0
+	n.mu.Unlock()
+
+	if msgID != 0 {
+		n.adapter.bot.Request(tgbotapi.NewDeleteMessage(n.chatID, msgID))
+	}
+}
+
+// FormatRunProgress 生成进度摘要, 如 "⏳ 仍在处理，已运行 7m\n已完成步骤 1–6\n当前: 执行命令: go test"
+func FormatRunProgress(loc i18n.Locale, elapsed time.Duration, completed int, active string) string {
+	lines := []string{loc.Tf("run.long_running", FormatRunDuration(elapsed))}
+	switch completed {
+	case 0:
+		if active == "" {
+			lines = append(lines, loc.T("run.steps_none"))
+		}
+	case 1:
+		lines = append(lines, loc.T("run.step_one_done"))
+	default:
+		lines = append(lines, loc.Tf("run.steps_done", completed))
+	}
+	if active != "" {
+		lines = append(lines, loc.Tf("run.current", html.EscapeString(active)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatRunDuration 以分钟精度格式化运行时长: "45s" / "7m" / "1h05m"
+func FormatRunDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,34 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/pkg/i18n"
+)
+
+func TestFormatRunDuration(t *testing.T) {
+	cases := map[time.Duration]string{
+		42 * time.Second:               "42s",
+		7*time.Minute + 20*time.Second: "7m",
+		65 * time.Minute:               "1h05m",
+	}
+	for d, want := range cases {
+		if got := FormatRunDuration(d); got != want {
+			t.Errorf("FormatRunDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestFormatRunProgress(t *testing.T) {
+	got := FormatRunProgress(i18n.EN, 7*time.Minute, 6, "run: go test <./...>")
+	want := "⏳ Still working, running for 7m\nCompleted steps 1–6\nNow: run: go test &lt;./...&gt;"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got = FormatRunProgress(i18n.EN, 5*time.Minute, 0, "")
+	if want := "⏳ Still working, running for 5m\nStill thinking, no tools called yet"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -1,26 +1,61 @@
 package telegram
 
 import (
+	"context"
 	"fmt"
 	"sync"
+	"time"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/repository"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+	"go.uber.org/zap"
 )
 
 // DefaultSessionManager 默认会话管理器实现
+//
+// 会话偏好 (模型、思考级别、安全模式、TTS、采样参数等) 保存在内存中; 设置了 store 时
+// 每次变更都会写入数据库, 启动时由 Load 恢复, 部署重启后 /models 的选择不会丢失。
 type DefaultSessionManager struct {
-	mu           sync.RWMutex
-	sessions     map[int64]*ChatSession // chatID -> session
-	models       []ModelInfo            // 可用模型列表
-	defaultModel string                 // 新会话默认模型
+	mu            sync.RWMutex
+	sessions      map[int64]*ChatSession // chatID -> session
+	models        []ModelInfo            // 可用模型列表
+	defaultModel  string                 // 新会话默认模型
+	defaultLocale string                 // 新会话默认界面语言
+
+	store  repository.ChatSettingsRepository // 可选, nil = 仅内存
+	logger *zap.Logger
 }
 
 // ChatSession 聊天会话
 type ChatSession struct {
-	ChatID       int64
-	UserID       int64
-	CurrentModel string
-	Think        string // off/low/medium/high
-	Verbose      bool
-	Reasoning    string // off/on/stream
+	ChatID          int64
+	UserID          int64
+	CurrentModel    string
+	Think           string // off/low/medium/high
+	Verbose         bool
+	Reasoning       string // off/on/stream
+	Locale          string // zh/en, 空 = 默认语言 (回复语言跟随用户消息)
+	UsageMode       string // off/tokens/full
+	Activation      string // always/mention
+	SendPolicy      string // allow/deny/inherit
+	SecurityProfile string // /security 选择的审批模式, 空 = 配置默认
+	Route           string // /route 设置的自动选模型开关 on/off, 空 = 配置默认
+	LastRoute       string // 最近一次自动路由的决定 (仅内存, 供 /route 显示)
+	Draft           string // /draft 设置的草稿模式开关 on/off, 空 = 配置默认
+	Agent           string // /agent switch 选择的代理 ID, 空 = 默认代理
+	TTS             TTSSettings
+	Params          service.ModelParams // /params 设置的采样参数, 零值 = 默认
+	PromptVars      map[string]string   // /setvar 设置的提示词变量 (小写名字 → 值)
+	UpdatedAt       time.Time
+}
+
+// TTSSettings 会话级 TTS 偏好
+type TTSSettings struct {
+	Enabled  bool
+	Provider string
+	Limit    int
+	Summary  bool
 }
 
 // NewDefaultSessionManager 创建默认会话管理器
@@ -32,6 +67,74 @@ func NewDefaultSessionManager(defaultModel string) *DefaultSessionManager {
 		sessions:     make(map[int64]*ChatSession),
 		models:       getDefaultModels(),
 		defaultModel: defaultModel,
+		logger:       zap.NewNop(),
+	}
+}
+
+// SetStore 设置持久化仓储; 之后的每次变更都会写入
+func (m *DefaultSessionManager) SetStore(store repository.ChatSettingsRepository, logger *zap.Logger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store = store
+	if logger != nil {
+		m.logger = logger
+	}
+}
+
+// Load 从仓储恢复全部会话偏好; 应在 SetAvailableModels 之后调用,
+// 以便丢弃已不在模型列表中的模型选择
+func (m *DefaultSessionManager) Load(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.store == nil {
+		return nil
+	}
+
+	rows, err := m.store.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		session := m.newSession(row.ChatID, row.UserID)
+		session.applySettings(row)
+		if session.CurrentModel != "" && !m.knownModel(session.CurrentModel) {
+			m.logger.Info("Restored model no longer configured, using default",
+				zap.Int64("chat_id", row.ChatID),
+				zap.String("model", session.CurrentModel),
+			)
+			session.CurrentModel = ""
+		}
+		if session.CurrentModel == "" {
+			session.CurrentModel = m.defaultModel
+		}
+		m.sessions[row.ChatID] = session
+	}
+	m.logger.Info("Chat sessions restored", zap.Int("count", len(rows)))
+	return nil
+}
+
+// knownModel 检查模型是否在可用列表中 (调用方持有锁)
+func (m *DefaultSessionManager) knownModel(id string) bool {
+	if id == m.defaultModel {
+		return true
+	}
+	for _, model := range m.models {
+		if model.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// newSession 创建带默认值的会话 (调用方持有锁)
+func (m *DefaultSessionManager) newSession(chatID, userID int64) *ChatSession {
+	return &ChatSession{
+		ChatID:       chatID,
+		UserID:       userID,
+		CurrentModel: m.defaultModel,
+		Think:        "medium",
+		Verbose:      false,
+		Reasoning:    "off",
 	}
 }
 
@@ -52,68 +155,89 @@ func getDefaultModels() []ModelInfo {
 func (m *DefaultSessionManager) getOrCreateSession(chatID int64) *ChatSession {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	return m.sessionLocked(chatID)
+}
 
+// sessionLocked 获取或创建会话 (调用方持有写锁)
+func (m *DefaultSessionManager) sessionLocked(chatID int64) *ChatSession {
 	session, exists := m.sessions[chatID]
 	if !exists {
-		session = &ChatSession{
-			ChatID:       chatID,
-			CurrentModel: m.defaultModel,
-			Think:        "medium",
-			Verbose:      false,
-			Reasoning:    "off",
-		}
+		session = m.newSession(chatID, 0)
 		m.sessions[chatID] = session
 	}
 	return session
 }
 
-// CreateSession 创建新会话
-func (m *DefaultSessionManager) CreateSession(chatID int64, userID int64) error {
+// update 在锁内修改会话并持久化快照
+func (m *DefaultSessionManager) update(chatID int64, fn func(s *ChatSession)) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	session := m.sessionLocked(chatID)
+	fn(session)
+	session.UpdatedAt = time.Now()
+	snapshot := session.settings()
+	store := m.store
+	m.mu.Unlock()
+
+	m.persist(store, snapshot)
+}
 
-	// 创建新会话，重置所有状态
-	m.sessions[chatID] = &ChatSession{
-		ChatID:       chatID,
-		UserID:       userID,
-		CurrentModel: m.defaultModel,
-		Think:        "medium",
-		Verbose:      false,
-		Reasoning:    "off",
+// persist 写入仓储; 失败只记录日志, 内存中的设置仍然生效
+func (m *DefaultSessionManager) persist(store repository.ChatSettingsRepository, settings *entity.ChatSettings) {
+	if store == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := store.Save(ctx, settings); err != nil {
+		m.logger.Warn("Failed to persist chat session",
+			zap.Int64("chat_id", settings.ChatID),
+			zap.Error(err),
+		)
 	}
+}
 
+// CreateSession 创建新会话
+func (m *DefaultSessionManager) CreateSession(chatID int64, userID int64) error {
+	// 创建新会话，重置对话状态 (界面语言、安全模式、TTS、采样参数属于用户偏好, 保留)
+	m.update(chatID, func(s *ChatSession) {
+		fresh := m.newSession(chatID, userID)
+		fresh.Locale = s.Locale
+		fresh.SecurityProfile = s.SecurityProfile
+		fresh.Route = s.Route
+		fresh.Draft = s.Draft
+		fresh.Agent = s.Agent
+		fresh.PromptVars = s.PromptVars
+		fresh.TTS = s.TTS
+		fresh.Params = s.Params
+		*s = *fresh
+	})
 	return nil
 }
 
 // ClearSession 清除会话历史
 func (m *DefaultSessionManager) ClearSession(chatID int64) error {
+	// 历史由 HistoryClearer 清除; 模型、思考级别等配置全部保留
+	return nil
+}
+
+// ForgetSession 删除会话的全部偏好 (内存与仓储), 用于 /forgetme
+func (m *DefaultSessionManager) ForgetSession(ctx context.Context, chatID int64) error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	delete(m.sessions, chatID)
+	store := m.store
+	m.mu.Unlock()
 
-	// 保留配置，只清除历史（重置 session）
-	if session, exists := m.sessions[chatID]; exists {
-		// 保留当前模型设置
-		currentModel := session.CurrentModel
-		think := session.Think
-		verbose := session.Verbose
-		reasoning := session.Reasoning
-
-		m.sessions[chatID] = &ChatSession{
-			ChatID:       chatID,
-			UserID:       session.UserID,
-			CurrentModel: currentModel,
-			Think:        think,
-			Verbose:      verbose,
-			Reasoning:    reasoning,
-		}
+	if store == nil {
+		return nil
 	}
-
-	return nil
+	return store.Delete(ctx, chatID)
 }
 
 // GetCurrentModel 获取当前模型
 func (m *DefaultSessionManager) GetCurrentModel(chatID int64) string {
 	session := m.getOrCreateSession(chatID)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return session.CurrentModel
 }
 
@@ -125,9 +249,7 @@ func (m *DefaultSessionManager) SetModel(chatID int64, model string) error {
 		return fmt.Errorf("未知模型: %s", model)
 	}
 
-	session := m.getOrCreateSession(chatID)
-	session.CurrentModel = resolvedModel
-
+	m.update(chatID, func(s *ChatSession) { s.CurrentModel = resolvedModel })
 	return nil
 }
 
@@ -188,22 +310,309 @@ func (m *DefaultSessionManager) GetSession(chatID int64) *ChatSession {
 	return m.getOrCreateSession(chatID)
 }
 
+// read 在读锁内读取会话字段
+func (m *DefaultSessionManager) read(chatID int64, fn func(s *ChatSession)) {
+	session := m.getOrCreateSession(chatID)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	fn(session)
+}
+
 // SetThink 设置思考级别
 func (m *DefaultSessionManager) SetThink(chatID int64, level string) {
-	session := m.getOrCreateSession(chatID)
-	session.Think = level
+	m.SetThinkLevel(chatID, level)
+}
+
+// ---- SessionSettings ----
+
+// GetThinkLevel 获取思考级别
+func (m *DefaultSessionManager) GetThinkLevel(chatID int64) (level string) {
+	m.read(chatID, func(s *ChatSession) { level = s.Think })
+	return level
+}
+
+// SetThinkLevel 设置思考级别
+func (m *DefaultSessionManager) SetThinkLevel(chatID int64, level string) {
+	m.update(chatID, func(s *ChatSession) { s.Think = level })
+}
+
+// GetVerbose 获取详细模式
+func (m *DefaultSessionManager) GetVerbose(chatID int64) (on bool) {
+	m.read(chatID, func(s *ChatSession) { on = s.Verbose })
+	return on
 }
 
 // SetVerbose 设置详细模式
 func (m *DefaultSessionManager) SetVerbose(chatID int64, verbose bool) {
-	session := m.getOrCreateSession(chatID)
-	session.Verbose = verbose
+	m.update(chatID, func(s *ChatSession) { s.Verbose = verbose })
+}
+
+// GetReasoning 获取推理可见性
+func (m *DefaultSessionManager) GetReasoning(chatID int64) (mode string) {
+	m.read(chatID, func(s *ChatSession) { mode = s.Reasoning })
+	return mode
 }
 
 // SetReasoning 设置推理可见性
 func (m *DefaultSessionManager) SetReasoning(chatID int64, mode string) {
+	m.update(chatID, func(s *ChatSession) { s.Reasoning = mode })
+}
+
+// GetUsageMode 获取用量显示模式
+func (m *DefaultSessionManager) GetUsageMode(chatID int64) (mode string) {
+	m.read(chatID, func(s *ChatSession) { mode = s.UsageMode })
+	if mode == "" {
+		mode = "off"
+	}
+	return mode
+}
+
+// SetUsageMode 设置用量显示模式
+func (m *DefaultSessionManager) SetUsageMode(chatID int64, mode string) {
+	m.update(chatID, func(s *ChatSession) { s.UsageMode = mode })
+}
+
+// GetActivation 获取群聊激活方式
+func (m *DefaultSessionManager) GetActivation(chatID int64) (mode string) {
+	m.read(chatID, func(s *ChatSession) { mode = s.Activation })
+	return mode
+}
+
+// SetActivation 设置群聊激活方式
+func (m *DefaultSessionManager) SetActivation(chatID int64, mode string) {
+	m.update(chatID, func(s *ChatSession) { s.Activation = mode })
+}
+
+// GetSendPolicy 获取发送策略
+func (m *DefaultSessionManager) GetSendPolicy(chatID int64) (policy string) {
+	m.read(chatID, func(s *ChatSession) { policy = s.SendPolicy })
+	return policy
+}
+
+// SetSendPolicy 设置发送策略
+func (m *DefaultSessionManager) SetSendPolicy(chatID int64, policy string) {
+	m.update(chatID, func(s *ChatSession) { s.SendPolicy = policy })
+}
+
+// ---- 安全模式 / TTS / 采样参数 ----
+
+// SetSecurityProfile 记录该会话通过 /security 选择的审批模式
+func (m *DefaultSessionManager) SetSecurityProfile(chatID int64, mode string) {
+	m.update(chatID, func(s *ChatSession) { s.SecurityProfile = mode })
+}
+
+// LatestSecurityProfile 返回最近一次选择的审批模式 (启动时恢复全局安全策略用)
+func (m *DefaultSessionManager) LatestSecurityProfile() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var latest *ChatSession
+	for _, s := range m.sessions {
+		if s.SecurityProfile == "" {
+			continue
+		}
+		if latest == nil || s.UpdatedAt.After(latest.UpdatedAt) {
+			latest = s
+		}
+	}
+	if latest == nil {
+		return ""
+	}
+	return latest.SecurityProfile
+}
+
+// GetTTS 获取 TTS 偏好
+func (m *DefaultSessionManager) GetTTS(chatID int64) (tts TTSSettings) {
+	m.read(chatID, func(s *ChatSession) { tts = s.TTS })
+	return tts
+}
+
+// SetTTS 保存 TTS 偏好
+func (m *DefaultSessionManager) SetTTS(chatID int64, tts TTSSettings) {
+	m.update(chatID, func(s *ChatSession) { s.TTS = tts })
+}
+
+// GetModelParams 获取会话采样参数
+func (m *DefaultSessionManager) GetModelParams(chatID int64) (params service.ModelParams) {
+	m.read(chatID, func(s *ChatSession) { params = s.Params })
+	return params
+}
+
+// SetModelParams 保存会话采样参数
+func (m *DefaultSessionManager) SetModelParams(chatID int64, params service.ModelParams) {
+	m.update(chatID, func(s *ChatSession) { s.Params = params })
+}
+
+// GetRouteMode 获取会话的自动选模型开关 (on/off, 空 = 配置默认)
+func (m *DefaultSessionManager) GetRouteMode(chatID int64) (mode string) {
+	m.read(chatID, func(s *ChatSession) { mode = s.Route })
+	return mode
+}
+
+// SetRouteMode 保存会话的自动选模型开关
+func (m *DefaultSessionManager) SetRouteMode(chatID int64, mode string) {
+	m.update(chatID, func(s *ChatSession) { s.Route = mode })
+}
+
+// GetLastRoute 获取最近一次自动路由的决定
+func (m *DefaultSessionManager) GetLastRoute(chatID int64) (route string) {
+	m.read(chatID, func(s *ChatSession) { route = s.LastRoute })
+	return route
+}
+
+// SetLastRoute 记录最近一次自动路由的决定 (不持久化)
+func (m *DefaultSessionManager) SetLastRoute(chatID int64, route string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessionLocked(chatID).LastRoute = route
+}
+
+// GetDraftMode 获取会话的草稿模式开关 (on/off, 空 = 配置默认)
+func (m *DefaultSessionManager) GetDraftMode(chatID int64) (mode string) {
+	m.read(chatID, func(s *ChatSession) { mode = s.Draft })
+	return mode
+}
+
+// SetDraftMode 保存会话的草稿模式开关
+func (m *DefaultSessionManager) SetDraftMode(chatID int64, mode string) {
+	m.update(chatID, func(s *ChatSession) { s.Draft = mode })
+}
+
+// GetAgent 获取会话选择的代理 ID (空 = 默认代理)
+func (m *DefaultSessionManager) GetAgent(chatID int64) (id string) {
+	m.read(chatID, func(s *ChatSession) { id = s.Agent })
+	return id
+}
+
+// SetAgent 保存会话选择的代理
+func (m *DefaultSessionManager) SetAgent(chatID int64, id string) {
+	m.update(chatID, func(s *ChatSession) { s.Agent = id })
+}
+
+// GetPromptVars 获取会话的提示词变量 (副本)
+func (m *DefaultSessionManager) GetPromptVars(chatID int64) (vars map[string]string) {
+	m.read(chatID, func(s *ChatSession) { vars = copyVars(s.PromptVars) })
+	return vars
+}
+
+// SetPromptVar 设置一个提示词变量; value 为空时删除
+func (m *DefaultSessionManager) SetPromptVar(chatID int64, name, value string) {
+	m.update(chatID, func(s *ChatSession) {
+		vars := copyVars(s.PromptVars)
+		if value == "" {
+			delete(vars, name)
+		} else {
+			if vars == nil {
+				vars = make(map[string]string)
+			}
+			vars[name] = value
+		}
+		s.PromptVars = vars
+	})
+}
+
+func copyVars(vars map[string]string) map[string]string {
+	if len(vars) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(vars))
+	for k, v := range vars {
+		out[k] = v
+	}
+	return out
+}
+
+// SetDefaultLocale 设置未显式选择语言的会话所使用的默认语言
+func (m *DefaultSessionManager) SetDefaultLocale(locale string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.defaultLocale = locale
+}
+
+// GetLocale 获取界面语言 (未设置时返回默认语言)
+func (m *DefaultSessionManager) GetLocale(chatID int64) string {
 	session := m.getOrCreateSession(chatID)
-	session.Reasoning = mode
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if session.Locale != "" {
+		return session.Locale
+	}
+	return m.defaultLocale
+}
+
+// SetLocale 设置界面语言 (同时决定回复语言); 空 = 恢复默认, 回复跟随用户消息
+func (m *DefaultSessionManager) SetLocale(chatID int64, locale string) {
+	m.update(chatID, func(s *ChatSession) { s.Locale = locale })
+}
+
+// GetReplyLanguage 获取 /lang 显式选择的回复语言 (未选择时为空)
+func (m *DefaultSessionManager) GetReplyLanguage(chatID int64) (lang string) {
+	m.read(chatID, func(s *ChatSession) { lang = s.Locale })
+	return lang
+}
+
+// settings 转换为持久化实体
+func (s *ChatSession) settings() *entity.ChatSettings {
+	return &entity.ChatSettings{
+		ChatID:          s.ChatID,
+		UserID:          s.UserID,
+		Model:           s.CurrentModel,
+		Think:           s.Think,
+		Verbose:         s.Verbose,
+		Reasoning:       s.Reasoning,
+		Locale:          s.Locale,
+		UsageMode:       s.UsageMode,
+		Activation:      s.Activation,
+		SendPolicy:      s.SendPolicy,
+		SecurityProfile: s.SecurityProfile,
+		Route:           s.Route,
+		Draft:           s.Draft,
+		Agent:           s.Agent,
+		PromptVars:      copyVars(s.PromptVars),
+		TTSEnabled:      s.TTS.Enabled,
+		TTSProvider:     s.TTS.Provider,
+		TTSLimit:        s.TTS.Limit,
+		TTSSummary:      s.TTS.Summary,
+		Temperature:     s.Params.Temperature,
+		TopP:            s.Params.TopP,
+		MaxOutputTokens: s.Params.MaxOutputTokens,
+		ReasoningEffort: s.Params.ReasoningEffort,
+		UpdatedAt:       s.UpdatedAt,
+	}
+}
+
+// applySettings 用持久化实体覆盖会话字段 (空值保留默认)
+func (s *ChatSession) applySettings(row *entity.ChatSettings) {
+	s.CurrentModel = row.Model
+	if row.Think != "" {
+		s.Think = row.Think
+	}
+	s.Verbose = row.Verbose
+	if row.Reasoning != "" {
+		s.Reasoning = row.Reasoning
+	}
+	s.Locale = row.Locale
+	s.UsageMode = row.UsageMode
+	s.Activation = row.Activation
+	s.SendPolicy = row.SendPolicy
+	s.SecurityProfile = row.SecurityProfile
+	s.Route = row.Route
+	s.Draft = row.Draft
+	s.Agent = row.Agent
+	s.PromptVars = copyVars(row.PromptVars)
+	s.TTS = TTSSettings{
+		Enabled:  row.TTSEnabled,
+		Provider: row.TTSProvider,
+		Limit:    row.TTSLimit,
+		Summary:  row.TTSSummary,
+	}
+	s.Params = service.ModelParams{
+		Temperature:     row.Temperature,
+		TopP:            row.TopP,
+		MaxOutputTokens: row.MaxOutputTokens,
+		ReasoningEffort: row.ReasoningEffort,
+	}
+	s.UpdatedAt = row.UpdatedAt
 }
 
 // 辅助函数
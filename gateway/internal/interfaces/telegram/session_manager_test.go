@@ -0,0 +1,168 @@
+package telegram
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/entity"
+	"github.com/ngoclaw/ngoclaw/gateway/internal/domain/service"
+)
+
+// memorySettingsStore 内存版 ChatSettingsRepository, 模拟跨重启的数据库
+type memorySettingsStore struct {
+	mu   sync.Mutex
+	rows map[int64]entity.ChatSettings
+}
+
+func (s *memorySettingsStore) FindAll(ctx context.Context) ([]*entity.ChatSettings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*entity.ChatSettings, 0, len(s.rows))
+	for _, row := range s.rows {
+		row := row
+		out = append(out, &row)
+	}
+	return out, nil
+}
+
+func (s *memorySettingsStore) Save(ctx context.Context, settings *entity.ChatSettings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rows[settings.ChatID] = *settings
+	return nil
+}
+
+func (s *memorySettingsStore) Delete(ctx context.Context, chatID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.rows, chatID)
+	return nil
+}
+
+func TestDefaultSessionManager_RestoresSettingsAfterRestart(t *testing.T) {
+	store := &memorySettingsStore{rows: make(map[int64]entity.ChatSettings)}
+	models := []ModelInfo{
+		{ID: "p/default"},
+		{ID: "p/coder", Alias: "coder"},
+	}
+
+	first := NewDefaultSessionManager("p/default")
+	first.SetAvailableModels(models)
+	first.SetStore(store, nil)
+	if err := first.SetModel(42, "coder"); err != nil {
+		t.Fatal(err)
+	}
+	first.SetThinkLevel(42, "high")
+	first.SetSecurityProfile(42, "ask_all")
+	first.SetRouteMode(42, "off")
+	first.SetAgent(42, "reviewer")
+	first.SetLastRoute(42, "coding ~1k tokens → coding → p/coder")
+	first.SetTTS(42, TTSSettings{Enabled: true, Provider: "edge", Limit: 800})
+	first.SetPromptVar(42, "team", "infra")
+	first.SetPromptVar(42, "env", "staging")
+	first.SetPromptVar(42, "env", "")
+
+	// 模拟重启: 新的管理器从同一仓储恢复
+	second := NewDefaultSessionManager("p/default")
+	second.SetAvailableModels(models)
+	second.SetStore(store, nil)
+	if err := second.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := second.GetCurrentModel(42); got != "p/coder" {
+		t.Errorf("model = %q, want p/coder", got)
+	}
+	if got := second.GetThinkLevel(42); got != "high" {
+		t.Errorf("think = %q, want high", got)
+	}
+	if got := second.LatestSecurityProfile(); got != "ask_all" {
+		t.Errorf("security profile = %q, want ask_all", got)
+	}
+	if tts := second.GetTTS(42); !tts.Enabled || tts.Provider != "edge" || tts.Limit != 800 {
+		t.Errorf("tts = %+v", tts)
+	}
+	if got := second.GetRouteMode(42); got != "off" {
+		t.Errorf("route = %q, want off", got)
+	}
+	if got := second.GetAgent(42); got != "reviewer" {
+		t.Errorf("agent = %q, want reviewer", got)
+	}
+	if got := second.GetLastRoute(42); got != "" {
+		t.Errorf("last route restored: %q", got)
+	}
+	if got := second.GetPromptVars(42); len(got) != 1 || got["team"] != "infra" {
+		t.Errorf("prompt vars = %v, want team=infra", got)
+	}
+
+	// /new 重置模型, 但保留安全模式与 TTS 偏好
+	second.CreateSession(42, 7)
+	if got := second.GetCurrentModel(42); got != "p/default" {
+		t.Errorf("model after /new = %q, want default", got)
+	}
+	if got := store.rows[42]; got.SecurityProfile != "ask_all" || got.Route != "off" || got.Agent != "reviewer" || got.PromptVars["team"] != "infra" || !got.TTSEnabled || got.UserID != 7 {
+		t.Errorf("persisted after /new = %+v", got)
+	}
+}
+
+func TestDefaultSessionManager_DropsRemovedModelOnLoad(t *testing.T) {
+	store := &memorySettingsStore{rows: map[int64]entity.ChatSettings{
+		1: {ChatID: 1, Model: "gone/model", Think: "low"},
+	}}
+
+	m := NewDefaultSessionManager("p/default")
+	m.SetAvailableModels([]ModelInfo{{ID: "p/default"}})
+	m.SetStore(store, nil)
+	if err := m.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := m.GetCurrentModel(1); got != "p/default" {
+		t.Errorf("model = %q, want fallback to default", got)
+	}
+	if got := m.GetThinkLevel(1); got != "low" {
+		t.Errorf("think = %q, want low", got)
+	}
+}
+
+func TestDefaultSessionManager_ModelParamsSurviveRestartAndNew(t *testing.T) {
+	store := &memorySettingsStore{rows: make(map[int64]entity.ChatSettings)}
+
+	first := NewDefaultSessionManager("p/default")
+	first.SetStore(store, nil)
+	params := first.GetModelParams(9)
+	for name, value := range map[string]string{"temp": "0.2", "top_p": "0.9", "max_tokens": "2048", "effort": "HIGH"} {
+		if err := params.SetModelParam(name, value); err != nil {
+			t.Fatalf("%s=%s: %v", name, value, err)
+		}
+	}
+	for name, value := range map[string]string{"temperature": "0", "top_p": "1.5", "effort": "max", "seed": "1"} {
+		if err := params.SetModelParam(name, value); err == nil {
+			t.Errorf("%s=%s: expected error", name, value)
+		}
+	}
+	first.SetModelParams(9, params)
+
+	second := NewDefaultSessionManager("p/default")
+	second.SetStore(store, nil)
+	if err := second.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	second.CreateSession(9, 1)
+
+	want := service.ModelParams{Temperature: 0.2, TopP: 0.9, MaxOutputTokens: 2048, ReasoningEffort: "high"}
+	if got := second.GetModelParams(9); got != want {
+		t.Fatalf("params after restart + /new = %+v, want %+v", got, want)
+	}
+
+	req := &service.LLMRequest{Temperature: 0.7, MaxTokens: 100}
+	got := second.GetModelParams(9)
+	if err := got.SetModelParam("max_tokens", "reset"); err != nil {
+		t.Fatal(err)
+	}
+	got.Apply(req)
+	if req.Temperature != 0.2 || req.TopP != 0.9 || req.MaxTokens != 100 || req.ReasoningEffort != "high" {
+		t.Errorf("applied request = %+v", req)
+	}
+}
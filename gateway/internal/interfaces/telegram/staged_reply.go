@@ -8,6 +8,7 @@ import (
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
 )
 
 // StagedReply implements Antigravity-style staged output for TG cards:
@@ -34,6 +35,9 @@ type StagedReply struct {
 	activeTool  string
 	toolCount   int
 	stepInfo    string
+
+	// Message IDs of the delivered final reply (for reaction feedback)
+	delivered []int
 }
 
 // NewStagedReply creates a staged reply handler
@@ -201,33 +205,28 @@ func (s *StagedReply) Deliver(adapter *Adapter, finalText string) error {
 }
 
 // DeliverWithSuffix delivers with a suffix appended to the last chunk.
-// Converts Markdown → TG HTML before sending.
+// The Markdown is split first and each part is converted to TG HTML on its
+// own, so no part ends inside a tag or code block (see SplitReplyHTML).
+// Replies with more than ReplyDocumentThreshold parts also get the full text
+// attached as reply.md when the adapter has LongReplyDocument enabled.
 func (s *StagedReply) DeliverWithSuffix(adapter *Adapter, finalText, suffix string) error {
 	s.deleteStatus()
 
-	// Convert LLM Markdown → Telegram HTML
-	htmlText := MarkdownToTelegramHTML(finalText)
-
-	chunks := ChunkMarkdown(htmlText)
-	if len(chunks) == 0 {
-		chunks = []string{htmlText}
-	}
-
-	for i, chunk := range chunks {
-		text := chunk
-		isLast := i == len(chunks)-1
+	parts := SplitReplyHTML(finalText, suffix)
+	for i, text := range parts {
+		isLast := i == len(parts)-1
 
-		// Add pagination marker for multi-chunk messages
-		if len(chunks) > 1 {
-			text += fmt.Sprintf("\n\n📄 <i>(%d/%d)</i>", i+1, len(chunks))
+		// Add pagination marker for multi-part messages
+		if len(parts) > 1 {
+			text += fmt.Sprintf("\n\n📄 <i>(%d/%d)</i>", i+1, len(parts))
 		}
 
-		// Append suffix to the last chunk
+		// Append suffix to the last part
 		if isLast && suffix != "" {
 			text += "\n\n" + suffix
 		}
 
-		err := adapter.SendMessage(&OutgoingMessage{
+		msgID, err := adapter.SendMessageWithID(&OutgoingMessage{
 			ChatID:    s.chatID,
 			Text:      text,
 			ParseMode: s.parseMode,
@@ -235,6 +234,16 @@ func (s *StagedReply) DeliverWithSuffix(adapter *Adapter, finalText, suffix stri
 		if err != nil {
 			return err
 		}
+		s.mu.Lock()
+		s.delivered = append(s.delivered, msgID)
+		s.mu.Unlock()
+	}
+
+	if len(parts) > ReplyDocumentThreshold && adapter.config.LongReplyDocument {
+		caption := adapter.localeFor(s.chatID).Tf("reply.document", len(parts))
+		if err := adapter.SendTextDocument(s.chatID, "reply.md", finalText, caption); err != nil {
+			adapter.logger.Warn("Send full reply document failed", zap.Int64("chat_id", s.chatID), zap.Error(err))
+		}
 	}
 	return nil
 }
@@ -281,6 +290,21 @@ func (s *StagedReply) sendFinalChunked(adapter *Adapter, text string) error {
 	return nil
 }
 
+// Progress returns the number of completed tool steps and the label of the
+// tool currently running ("" when the model is thinking).
+func (s *StagedReply) Progress() (completed int, active string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.toolHistory), s.activeTool
+}
+
+// DeliveredMessageIDs returns the message IDs of the delivered final reply.
+func (s *StagedReply) DeliveredMessageIDs() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int(nil), s.delivered...)
+}
+
 // GetStatusMessageID returns the current status message ID
 func (s *StagedReply) GetStatusMessageID() int {
 	s.mu.Lock()
@@ -298,6 +322,20 @@ func toolDisplayLabel(toolName string, args map[string]interface{}) string {
 		}
 		return "执行命令"
 
+	case "terminal":
+		cmd := argStr(args, "command")
+		switch action := argStr(args, "action"); action {
+		case "", "run":
+			if cmd != "" {
+				return fmt.Sprintf("终端: %s", truncateLabel(cmd, 48))
+			}
+		case "input":
+			return fmt.Sprintf("终端输入: %s", truncateLabel(cmd, 40))
+		default:
+			return "终端: " + action
+		}
+		return "终端"
+
 	case "read_file":
 		if p := argStr(args, "path"); p != "" {
 			return fmt.Sprintf("读取: %s", filepath.Base(p))
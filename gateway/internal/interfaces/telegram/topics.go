@@ -0,0 +1,244 @@
+package telegram
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// 论坛 (Topics) 超级群: 每个话题是独立的会话。
+//
+// telegram-bot-api v5.5.1 不认识 message_thread_id, 而会话/历史/计划/模型/运行
+// 全部以 chatID 为键。这里在 Bot 的 HTTP 客户端上做双向映射:
+//   - 入站: 话题消息 (is_topic_message) 的 chat.id 改写为话题键 TopicKey(chat, thread)
+//   - 出站: chat_id 为话题键的请求还原为真实 chatID, send* 方法附带 message_thread_id
+// 上层代码看到的 "chatID" 就是会话键, 不同话题天然隔离, 回复自动落到对应话题。
+// General 话题的消息不带 is_topic_message, 仍使用群本身的 chatID。
+
+const (
+	// topicKeyBase 话题键区间起点; 真实 chat ID 绝对值不超过 2^52, 不会落入此区间
+	topicKeyBase = int64(1) << 62
+	// topicThreadBits 话题键中 thread ID 所占位数
+	topicThreadBits = 30
+	// supergroupOffset 超级群 chat ID = -(1e12 + channelID)
+	supergroupOffset = int64(1_000_000_000_000)
+)
+
+// TopicKey 返回论坛话题的会话键; 无法编码 (非超级群 / ID 超出范围) 时返回原 chatID
+func TopicKey(chatID int64, threadID int) int64 {
+	channelID := -chatID - supergroupOffset
+	if threadID <= 0 || channelID <= 0 || channelID >= 1<<(62-topicThreadBits) || threadID >= 1<<topicThreadBits {
+		return chatID
+	}
+	return -(topicKeyBase + channelID<<topicThreadBits + int64(threadID))
+}
+
+// SplitTopicKey 将会话键还原为 (真实 chatID, 话题 thread ID); 普通 chatID 返回 ok=false
+func SplitTopicKey(key int64) (chatID int64, threadID int, ok bool) {
+	if key > -topicKeyBase {
+		return key, 0, false
+	}
+	v := -key - topicKeyBase
+	channelID := v >> topicThreadBits
+	threadID = int(v & (1<<topicThreadBits - 1))
+	return -(supergroupOffset + channelID), threadID, true
+}
+
+// realChatID 会话键对应的真实 chatID (群白名单等按群判断的场景)
+func realChatID(key int64) int64 {
+	chatID, _, _ := SplitTopicKey(key)
+	return chatID
+}
+
+// topicClient 包装 Bot API HTTP 客户端, 完成话题键与 (chat_id, message_thread_id) 的互转
+type topicClient struct {
+	base *http.Client
+}
+
+// Do 实现 tgbotapi.HTTPClient
+func (c *topicClient) Do(req *http.Request) (*http.Response, error) {
+	method := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+	if err := rewriteTopicRequest(req, method); err != nil {
+		return nil, err
+	}
+	resp, err := c.base.Do(req)
+	if err != nil {
+		return resp, err
+	}
+	if err := rewriteTopicResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// topicParams 将 chat_id 话题键还原, 返回需要附加的 message_thread_id (0 = 不附加)
+func topicParams(method, chatID string) (realChat string, threadID int, changed bool) {
+	key, err := strconv.ParseInt(chatID, 10, 64)
+	if err != nil {
+		return chatID, 0, false
+	}
+	chat, thread, ok := SplitTopicKey(key)
+	if !ok {
+		return chatID, 0, false
+	}
+	// 只有发送类方法接受 message_thread_id; 编辑/删除/回调等按 message_id 定位
+	if strings.HasPrefix(method, "send") || method == "copyMessage" || method == "forwardMessage" {
+		threadID = thread
+	}
+	return strconv.FormatInt(chat, 10), threadID, true
+}
+
+func rewriteTopicRequest(req *http.Request, method string) error {
+	if req.Body == nil {
+		return nil
+	}
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return err
+		}
+		values, err := url.ParseQuery(string(body))
+		if err == nil {
+			if chat, thread, changed := topicParams(method, values.Get("chat_id")); changed {
+				values.Set("chat_id", chat)
+				if thread > 0 {
+					values.Set("message_thread_id", strconv.Itoa(thread))
+				}
+				body = []byte(values.Encode())
+			}
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+	case "multipart/form-data":
+		// 上传文件时流式改写, 不把整个文件读入内存
+		src := multipart.NewReader(req.Body, params["boundary"])
+		pr, pw := io.Pipe()
+		dst := multipart.NewWriter(pw)
+		if err := dst.SetBoundary(params["boundary"]); err != nil {
+			return err
+		}
+		orig := req.Body
+		go func() {
+			defer orig.Close()
+			pw.CloseWithError(copyTopicMultipart(method, src, dst))
+		}()
+		req.Body = pr
+		req.ContentLength = -1
+	}
+	return nil
+}
+
+func copyTopicMultipart(method string, src *multipart.Reader, dst *multipart.Writer) error {
+	for {
+		part, err := src.NextPart()
+		if err == io.EOF {
+			return dst.Close()
+		}
+		if err != nil {
+			return err
+		}
+		if part.FormName() == "chat_id" && part.FileName() == "" {
+			value, err := io.ReadAll(part)
+			if err != nil {
+				return err
+			}
+			chat, thread, _ := topicParams(method, string(value))
+			if err := dst.WriteField("chat_id", chat); err != nil {
+				return err
+			}
+			if thread > 0 {
+				if err := dst.WriteField("message_thread_id", strconv.Itoa(thread)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		w, err := dst.CreatePart(part.Header)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, part); err != nil {
+			return err
+		}
+	}
+}
+
+// rewriteTopicResponse 把响应 (getUpdates / send* 返回的消息) 中话题消息的 chat.id 换成话题键
+func rewriteTopicResponse(resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	body = rewriteTopicBody(body)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	return nil
+}
+
+// rewriteTopicBody 改写 JSON (API 响应或 webhook 推送的 Update) 中话题消息的 chat.id
+func rewriteTopicBody(body []byte) []byte {
+	if !bytes.Contains(body, []byte(`"is_topic_message":true`)) {
+		return body
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber() // chat ID 超出 float64 精度
+	var v interface{}
+	if dec.Decode(&v) == nil && rewriteTopicChats(v) {
+		if out, err := json.Marshal(v); err == nil {
+			return out
+		}
+	}
+	return body
+}
+
+// rewriteTopicChats 递归处理所有 Message 对象, 返回是否有改动
+func rewriteTopicChats(v interface{}) bool {
+	changed := false
+	switch node := v.(type) {
+	case map[string]interface{}:
+		for _, child := range node {
+			if rewriteTopicChats(child) {
+				changed = true
+			}
+		}
+		if topic, _ := node["is_topic_message"].(bool); !topic {
+			return changed
+		}
+		thread, err := jsonInt(node["message_thread_id"])
+		chat, _ := node["chat"].(map[string]interface{})
+		if err != nil || chat == nil {
+			return changed
+		}
+		chatID, err := jsonInt(chat["id"])
+		if err != nil {
+			return changed
+		}
+		if key := TopicKey(chatID, int(thread)); key != chatID {
+			chat["id"] = json.Number(strconv.FormatInt(key, 10))
+			changed = true
+		}
+	case []interface{}:
+		for _, child := range node {
+			if rewriteTopicChats(child) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func jsonInt(v interface{}) (int64, error) {
+	n, _ := v.(json.Number)
+	return strconv.ParseInt(string(n), 10, 64)
+}
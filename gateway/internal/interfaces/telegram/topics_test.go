@@ -0,0 +1,146 @@
+package telegram
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTopicKey_RoundTrip(t *testing.T) {
+	chat := int64(-1001987654321)
+	for _, thread := range []int{2, 17, 1<<topicThreadBits - 1} {
+		key := TopicKey(chat, thread)
+		if key == chat || key > -topicKeyBase {
+			t.Fatalf("thread %d: key %d not in topic range", thread, key)
+		}
+		gotChat, gotThread, ok := SplitTopicKey(key)
+		if !ok || gotChat != chat || gotThread != thread {
+			t.Fatalf("thread %d: split = %d %d %v", thread, gotChat, gotThread, ok)
+		}
+	}
+	if TopicKey(chat, 2) == TopicKey(chat, 3) || TopicKey(chat, 2) == TopicKey(-1001987654322, 2) {
+		t.Fatal("topic keys collide")
+	}
+
+	// 私聊 / 普通群 / 无话题: 保持原 chatID
+	for _, c := range []struct {
+		chat   int64
+		thread int
+	}{{12345, 7}, {-4567, 7}, {chat, 0}} {
+		if key := TopicKey(c.chat, c.thread); key != c.chat {
+			t.Errorf("TopicKey(%d, %d) = %d, want unchanged", c.chat, c.thread, key)
+		}
+		if _, _, ok := SplitTopicKey(c.chat); ok {
+			t.Errorf("SplitTopicKey(%d) reported a topic", c.chat)
+		}
+	}
+}
+
+func newTopicServer(t *testing.T, got map[string]url.Values, reply string) (*httptest.Server, *topicClient) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			if err := r.ParseForm(); err != nil {
+				t.Errorf("%s: %v", method, err)
+			}
+		}
+		got[method] = r.PostForm
+		if r.MultipartForm != nil {
+			got[method] = url.Values(r.MultipartForm.Value)
+		}
+		io.WriteString(w, reply)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &topicClient{base: srv.Client()}
+}
+
+func TestTopicClient_Request(t *testing.T) {
+	got := map[string]url.Values{}
+	srv, client := newTopicServer(t, got, `{"ok":true,"result":true}`)
+	key := TopicKey(-1001987654321, 42)
+
+	post := func(method string, values url.Values) {
+		req, _ := http.NewRequest("POST", srv.URL+"/botTOKEN/"+method, strings.NewReader(values.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	post("sendMessage", url.Values{"chat_id": {strconv.FormatInt(key, 10)}, "text": {"hi"}})
+	post("editMessageText", url.Values{"chat_id": {strconv.FormatInt(key, 10)}, "message_id": {"5"}})
+	post("sendMessage", url.Values{"chat_id": {"12345"}, "text": {"dm"}})
+
+	if v := got["sendMessage"]; v.Get("chat_id") != "12345" || v.Get("message_thread_id") != "" {
+		t.Errorf("plain chat rewritten: %v", v)
+	}
+	if v := got["editMessageText"]; v.Get("chat_id") != "-1001987654321" || v.Get("message_thread_id") != "" {
+		t.Errorf("edit: %v", v)
+	}
+
+	post("sendMessage", url.Values{"chat_id": {strconv.FormatInt(key, 10)}, "text": {"hi"}})
+	if v := got["sendMessage"]; v.Get("chat_id") != "-1001987654321" || v.Get("message_thread_id") != "42" || v.Get("text") != "hi" {
+		t.Errorf("send: %v", v)
+	}
+
+	// 文件上传 (multipart)
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("chat_id", strconv.FormatInt(key, 10))
+	fw, _ := mw.CreateFormFile("document", "a.txt")
+	fw.Write([]byte("payload"))
+	mw.Close()
+	req, _ := http.NewRequest("POST", srv.URL+"/botTOKEN/sendDocument", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if v := got["sendDocument"]; v.Get("chat_id") != "-1001987654321" || v.Get("message_thread_id") != "42" {
+		t.Errorf("upload: %v", v)
+	}
+}
+
+func TestTopicClient_Response(t *testing.T) {
+	updates := `{"ok":true,"result":[
+		{"update_id":1,"message":{"message_id":9,"message_thread_id":42,"is_topic_message":true,
+			"chat":{"id":-1001987654321,"type":"supergroup"},"text":"in topic"}},
+		{"update_id":2,"message":{"message_id":10,"chat":{"id":-1001987654321,"type":"supergroup"},"text":"general"}}]}`
+	srv, client := newTopicServer(t, map[string]url.Values{}, strings.Join(strings.Fields(updates), ""))
+
+	req, _ := http.NewRequest("POST", srv.URL+"/botTOKEN/getUpdates", strings.NewReader(""))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Result []struct {
+			Message struct {
+				Chat struct {
+					ID int64 `json:"id"`
+				} `json:"chat"`
+			} `json:"message"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.Result[0].Message.Chat.ID; got != TopicKey(-1001987654321, 42) {
+		t.Errorf("topic message chat id = %d", got)
+	}
+	if got := out.Result[1].Message.Chat.ID; got != -1001987654321 {
+		t.Errorf("general message chat id = %d", got)
+	}
+}
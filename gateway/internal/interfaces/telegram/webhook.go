@@ -0,0 +1,95 @@
+package telegram
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Webhook 模式 (telegram.mode: webhook): Telegram 把更新推送到网关 HTTP 服务器
+// 上的 WebhookPath, 不再轮询 getUpdates。推送地址由 telegram.webhook_url 在启动时
+// 注册, 或由 `ngoclaw tunnel` 指向开发隧道。
+
+// WebhookPath 网关上接收 Telegram 推送的路径
+const WebhookPath = "/webhooks/telegram"
+
+// secretHeader Telegram 随每次推送带上 setWebhook 时给出的 secret_token
+const secretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// WebhookHandler 返回挂载到 WebhookPath 的推送入口; 拒绝 secret 不符的请求。
+// 该路径不经过 API 鉴权, 未配置 WebhookSecret 时一律拒绝 (NewAdapter 也不允许)
+func (a *Adapter) WebhookHandler() http.Handler {
+	handle := a.webhookHandler(a.webhookUpdates)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if secret := a.config.WebhookSecret; secret == "" ||
+			subtle.ConstantTimeCompare([]byte(r.Header.Get(secretHeader)), []byte(secret)) != 1 {
+			http.Error(w, "invalid secret token", http.StatusForbidden)
+			return
+		}
+		handle(w, r)
+	})
+}
+
+// startWebhook 注册 webhook_url (如有), 并处理 WebhookHandler 收到的更新
+func (a *Adapter) startWebhook(ctx context.Context) {
+	if a.config.WebhookURL != "" {
+		if err := setWebhook(a.bot, a.config.WebhookURL, a.config.WebhookSecret); err != nil {
+			a.logger.Warn("Failed to register Telegram webhook", zap.Error(err))
+		} else {
+			a.logger.Info("Telegram webhook set", zap.String("url", a.config.WebhookURL))
+		}
+	} else {
+		a.logger.Info("Telegram webhook mode: waiting for updates on "+WebhookPath,
+			zap.String("hint", "set telegram.webhook_url or run `ngoclaw tunnel`"))
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				a.logger.Info("Telegram adapter stopped")
+				return
+			case update := <-a.webhookUpdates:
+				go a.handleUpdate(ctx, update)
+			}
+		}
+	}()
+}
+
+// RegisterWebhook 把 bot 的推送地址设为 url (供 ngoclaw tunnel 等独立进程使用)
+func RegisterWebhook(token, url, secret string) error {
+	bot, err := tgbotapi.NewBotAPI(token)
+	if err != nil {
+		return fmt.Errorf("failed to create bot: %w", err)
+	}
+	return setWebhook(bot, url, secret)
+}
+
+// RemoveWebhook 删除 bot 的推送地址, 之后可重新使用 polling
+func RemoveWebhook(token string) error {
+	bot, err := tgbotapi.NewBotAPI(token)
+	if err != nil {
+		return fmt.Errorf("failed to create bot: %w", err)
+	}
+	if _, err := bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// setWebhook 调用 setWebhook (v5.5.1 的 WebhookConfig 不支持 secret_token, 直接传参数)
+func setWebhook(bot *tgbotapi.BotAPI, url, secret string) error {
+	params := tgbotapi.Params{"url": url}
+	params.AddNonEmpty("secret_token", secret)
+	if err := params.AddInterface("allowed_updates", allowedUpdates); err != nil {
+		return err
+	}
+	if _, err := bot.MakeRequest("setWebhook", params); err != nil {
+		return fmt.Errorf("failed to set webhook: %w", err)
+	}
+	return nil
+}
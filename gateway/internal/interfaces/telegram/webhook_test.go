@@ -0,0 +1,71 @@
+package telegram
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+func TestWebhookHandler_SecretToken(t *testing.T) {
+	a := &Adapter{
+		config:         &Config{Webhook: true, WebhookSecret: "s3cret"},
+		logger:         zap.NewNop(),
+		webhookUpdates: make(chan tgbotapi.Update, 1),
+	}
+	h := a.WebhookHandler()
+	body := `{"update_id":7,"message":{"message_id":1,"chat":{"id":42,"type":"private"},"text":"hi"}}`
+
+	for _, tc := range []struct {
+		secret string
+		want   int
+	}{
+		{"", http.StatusForbidden},
+		{"wrong", http.StatusForbidden},
+		{"s3cret", http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodPost, WebhookPath, strings.NewReader(body))
+		if tc.secret != "" {
+			req.Header.Set(secretHeader, tc.secret)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("secret %q: status = %d, want %d", tc.secret, rec.Code, tc.want)
+		}
+	}
+
+	select {
+	case u := <-a.webhookUpdates:
+		if u.UpdateID != 7 || u.Message == nil || u.Message.Text != "hi" {
+			t.Errorf("update = %+v", u)
+		}
+	default:
+		t.Fatal("accepted update not queued")
+	}
+	if len(a.webhookUpdates) != 0 {
+		t.Error("rejected updates were queued")
+	}
+}
+
+func TestWebhookHandler_NoSecretRefusesAll(t *testing.T) {
+	a := &Adapter{
+		config:         &Config{Webhook: true},
+		logger:         zap.NewNop(),
+		webhookUpdates: make(chan tgbotapi.Update, 1),
+	}
+	body := `{"update_id":7,"message":{"message_id":1,"from":{"id":123},"chat":{"id":123,"type":"private"},"text":"rm -rf /"}}`
+	req := httptest.NewRequest(http.MethodPost, WebhookPath, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	a.WebhookHandler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || len(a.webhookUpdates) != 0 {
+		t.Errorf("unsigned update: status = %d, queued = %d", rec.Code, len(a.webhookUpdates))
+	}
+
+	if _, err := NewAdapter(&Config{BotToken: "x", Webhook: true}, zap.NewNop()); err == nil || !strings.Contains(err.Error(), "webhook_secret") {
+		t.Errorf("NewAdapter without secret: %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Approvals lists tool calls waiting for approval (GET /api/v1/approvals).
+func (c *Client) Approvals(ctx context.Context) ([]Approval, error) {
+	var out struct {
+		Approvals []Approval `json:"approvals"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/approvals", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Approvals, nil
+}
+
+// Approve lets the pending tool call run.
+func (c *Client) Approve(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/approvals/"+url.PathEscape(id)+"/approve", nil, nil)
+}
+
+// Deny rejects the pending tool call; the agent sees a denied tool result.
+func (c *Client) Deny(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/approvals/"+url.PathEscape(id)+"/deny", nil, nil)
+}
@@ -0,0 +1,152 @@
+// Package client is a Go SDK for the NGOClaw gateway HTTP API.
+//
+// It lets other Go services run agent tasks, stream their events, resolve
+// tool approvals and inspect the tool catalog without importing the
+// gateway's internal packages. The package depends only on the standard
+// library.
+//
+// Usage:
+//
+//	c := client.New(client.Config{BaseURL: "http://127.0.0.1:18789"})
+//	res, err := c.RunTask(ctx, client.TaskRequest{Message: "summarise README.md"},
+//	    func(ev client.Event) {
+//	        if ev.Type == client.EventTextDelta {
+//	            fmt.Print(ev.Content)
+//	        }
+//	    })
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Config configures a Client.
+type Config struct {
+	// BaseURL is the gateway HTTP address, e.g. http://127.0.0.1:18789.
+	BaseURL string
+
+	// Token, if set, is sent as "Authorization: Bearer <token>": one of the
+	// gateway's gateway.api_tokens, or a token for an authenticating proxy.
+	Token string
+
+	// Header is added to every request (e.g. tracing or routing headers).
+	Header http.Header
+
+	// HTTPClient defaults to a client without a timeout, since agent runs
+	// are streamed for as long as they take; bound them with the context.
+	HTTPClient *http.Client
+}
+
+// Client talks to one gateway. It is safe for concurrent use.
+type Client struct {
+	baseURL string
+	token   string
+	header  http.Header
+	http    *http.Client
+}
+
+// New creates a Client.
+func New(cfg Config) *Client {
+	hc := cfg.HTTPClient
+	if hc == nil {
+		hc = &http.Client{}
+	}
+	return &Client{
+		baseURL: strings.TrimRight(cfg.BaseURL, "/"),
+		token:   cfg.Token,
+		header:  cfg.Header.Clone(),
+		http:    hc,
+	}
+}
+
+// APIError is returned when the gateway answers with a non-2xx status.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("ngoclaw: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Health reports whether the gateway is reachable.
+func (c *Client) Health(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/health", nil, nil)
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, r)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+// send performs the request and converts non-2xx answers to *APIError.
+// The caller closes the body.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		return nil, decodeError(resp)
+	}
+	return resp, nil
+}
+
+// do sends a JSON request and decodes the JSON answer into out (if non-nil).
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("ngoclaw: decode %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+func decodeError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var body struct {
+		Error string `json:"error"`
+	}
+	msg := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &body) == nil && body.Error != "" {
+		msg = body.Error
+	}
+	return &APIError{StatusCode: resp.StatusCode, Message: msg}
+}
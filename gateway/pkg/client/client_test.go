@@ -0,0 +1,163 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// agentStream 与网关 AgentHandler 的输出格式一致
+func agentStream(w http.ResponseWriter, events [][2]string, done string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, e := range events {
+		fmt.Fprintf(w, "event: %s\ndata: {\"event\":%q,\"data\":%s}\n\n", e[0], e[0], e[1])
+	}
+	if done != "" {
+		fmt.Fprintf(w, "event: done\ndata: %s\n\n", done)
+	}
+}
+
+func TestClient_RunTask(t *testing.T) {
+	var got TaskRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/agent" || r.Header.Get("Authorization") != "Bearer tok" || r.Header.Get("X-Trace") != "t1" {
+			http.Error(w, `{"error":"unexpected request"}`, http.StatusBadRequest)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		agentStream(w, [][2]string{
+			{"thinking", `{"content":"hmm"}`},
+			{"tool_call", `{"id":"c1","name":"read_file","arguments":{"path":"README.md"},"success":false}`},
+			{"tool_result", `{"id":"c1","name":"read_file","arguments":{"path":"README.md"},"output":"# Hi","success":true}`},
+			{"step_done", `{"step":1,"tokens_used":42,"model_used":"m"}`},
+			{"text_delta", `{"content":"Hello"}`},
+			{"complete", `{"timestamp":"2026-10-16T10:00:00Z"}`},
+		}, `{"content":"Hello","total_steps":1,"total_tokens":42,"model_used":"m","tools_used":["read_file"]}`)
+	}))
+	defer srv.Close()
+
+	c := New(Config{BaseURL: srv.URL + "/", Token: "tok", Header: http.Header{"X-Trace": {"t1"}}})
+	var events []Event
+	filter := &EventFilter{Types: []EventType{EventToolCall, EventStepDone}, Verbosity: VerbosityMinimal}
+	res, err := c.RunTask(context.Background(), TaskRequest{Message: "hi", Model: "m", Filter: filter}, func(ev Event) {
+		events = append(events, ev)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Message != "hi" || got.Model != "m" || got.Filter == nil || got.Filter.Verbosity != VerbosityMinimal || len(got.Filter.Types) != 2 {
+		t.Errorf("request = %+v", got)
+	}
+	if res.Content != "Hello" || res.TotalTokens != 42 || len(res.ToolsUsed) != 1 {
+		t.Errorf("result = %+v", res)
+	}
+	if len(events) != 6 {
+		t.Fatalf("got %d events", len(events))
+	}
+	if events[0].Content != "hmm" || events[2].Tool.Output != "# Hi" || !events[2].Tool.Success ||
+		events[3].Step.TokensUsed != 42 || events[4].Type != EventTextDelta || events[5].Type != EventComplete {
+		t.Errorf("events = %+v", events)
+	}
+}
+
+func TestClient_RunTaskErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req TaskRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req.Message {
+		case "failed":
+			agentStream(w, [][2]string{{"error", `{"error":"budget exceeded"}`}}, `{"content":"partial"}`)
+		case "cut":
+			agentStream(w, [][2]string{{"text_delta", `{"content":"x"}`}}, "")
+		}
+	}))
+	defer srv.Close()
+
+	c := New(Config{BaseURL: srv.URL})
+	res, err := c.RunTask(context.Background(), TaskRequest{Message: "failed"}, nil)
+	var runErr *RunError
+	if !errors.As(err, &runErr) || runErr.Message != "budget exceeded" || res == nil || res.Content != "partial" {
+		t.Errorf("failed run: %v, %+v", err, res)
+	}
+
+	if _, err := c.RunTask(context.Background(), TaskRequest{Message: "cut"}, nil); err == nil {
+		t.Error("truncated stream returned no error")
+	}
+}
+
+func TestClient_ApprovalsAndTools(t *testing.T) {
+	resolved := map[string]string{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/approvals", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"approvals":[{"id":"a1","tool_name":"bash","args":{"command":"rm -rf build"},"risk":"high"}]}`))
+	})
+	mux.HandleFunc("POST /api/v1/approvals/{id}/{action}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "a1" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"approval not found or already resolved"}`))
+			return
+		}
+		resolved[r.PathValue("id")] = r.PathValue("action")
+		w.Write([]byte(`{"id":"a1","approved":true}`))
+	})
+	mux.HandleFunc("GET /v1/tools", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"version":"v1","tools":[{"name":"bash","description":"run","kind":"execute","risk":"high","approval":"per_call","parameters":{"type":"object"}}]}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := New(Config{BaseURL: srv.URL})
+	pending, err := c.Approvals(ctx)
+	if err != nil || len(pending) != 1 || pending[0].ToolName != "bash" || pending[0].Risk != "high" {
+		t.Fatalf("approvals = %+v, %v", pending, err)
+	}
+	if err := c.Approve(ctx, "a1"); err != nil || resolved["a1"] != "approve" {
+		t.Errorf("approve: %v %v", err, resolved)
+	}
+	var apiErr *APIError
+	if err := c.Deny(ctx, "zz"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Message != "approval not found or already resolved" {
+		t.Errorf("deny unknown: %v", err)
+	}
+
+	catalog, err := c.Tools(ctx)
+	if err != nil || catalog.Version != "v1" || catalog.Tools[0].Approval != "per_call" {
+		t.Errorf("tools = %+v, %v", catalog, err)
+	}
+}
+
+func TestSession_CarriesHistory(t *testing.T) {
+	var histories [][]Message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req TaskRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		histories = append(histories, req.History)
+		if req.Message == "fail" {
+			agentStream(w, [][2]string{{"error", `{"error":"boom"}`}}, `{"content":""}`)
+			return
+		}
+		agentStream(w, nil, fmt.Sprintf(`{"content":"re: %s","model_used":"m"}`, req.Message))
+	}))
+	defer srv.Close()
+
+	s := New(Config{BaseURL: srv.URL}).NewSession("s1")
+	ctx := context.Background()
+	for _, msg := range []string{"one", "fail", "two"} {
+		s.Send(ctx, msg, nil)
+	}
+	if len(histories[0]) != 0 || len(histories[1]) != 2 || len(histories[2]) != 2 {
+		t.Fatalf("histories sent = %+v", histories)
+	}
+	h := s.History()
+	if len(h) != 4 || h[1].Content != "re: one" || h[1].Role != "assistant" || h[3].Content != "re: two" {
+		t.Errorf("history = %+v", h)
+	}
+	s.Reset()
+	if len(s.History()) != 0 {
+		t.Error("reset kept history")
+	}
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxEventSize bounds one SSE data line (tool outputs can be large).
+const maxEventSize = 16 << 20
+
+// TaskRequest starts an agent run (POST /api/v1/agent).
+type TaskRequest struct {
+	Message      string       `json:"message"`
+	SystemPrompt string       `json:"system_prompt,omitempty"` // appended to the gateway's assembled prompt
+	Model        string       `json:"model,omitempty"`         // provider/model; empty uses the gateway default
+	SessionID    string       `json:"session_id,omitempty"`    // tags the run in gateway logs
+	History      []Message    `json:"history,omitempty"`
+	Filter       *EventFilter `json:"filter,omitempty"` // stream only some events; nil streams everything
+}
+
+// Verbosity levels for EventFilter.
+const (
+	VerbosityMinimal = "minimal" // tool names and success, steps, errors; no text, thinking or tool arguments
+	VerbosityNormal  = "normal"  // minimal plus answer text; no thinking
+	VerbosityFull    = "full"    // every event (default)
+)
+
+// EventFilter limits what the gateway streams for a run, for clients such
+// as status bars that only need progress. Error events and the final
+// result are always sent.
+type EventFilter struct {
+	Types      []EventType `json:"types,omitempty"`       // only these event types; empty = by Verbosity
+	Verbosity  string      `json:"verbosity,omitempty"`   // minimal | normal | full
+	ToolOutput *bool       `json:"tool_output,omitempty"` // include tool output; nil = only with full verbosity
+}
+
+// EventHandler receives streamed events in order, on the goroutine that
+// called RunTask.
+type EventHandler func(Event)
+
+// RunError is returned by RunTask when the run reported an error event.
+// The partial Result is still returned alongside it.
+type RunError struct {
+	Message string
+}
+
+func (e *RunError) Error() string {
+	return "ngoclaw: run failed: " + e.Message
+}
+
+// RunTask runs the agent on the gateway and blocks until it finishes,
+// passing every streamed event to onEvent (which may be nil). Cancelling
+// ctx aborts the run on the gateway.
+func (c *Client) RunTask(ctx context.Context, task TaskRequest, onEvent EventHandler) (*Result, error) {
+	if task.Message == "" {
+		return nil, fmt.Errorf("ngoclaw: task message is required")
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, "/api/v1/agent", task)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return readStream(resp.Body, onEvent)
+}
+
+// readStream consumes the SSE stream of one run. Every event except the
+// final "done" is encoded as {"event": <type>, "data": <payload>}.
+func readStream(r io.Reader, onEvent EventHandler) (*Result, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), maxEventSize)
+
+	var name string
+	var data strings.Builder
+	var runErr *RunError
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "":
+			if name == "" && data.Len() == 0 {
+				continue
+			}
+			if name == "done" {
+				var res Result
+				if err := json.Unmarshal([]byte(data.String()), &res); err != nil {
+					return nil, fmt.Errorf("ngoclaw: decode result: %w", err)
+				}
+				if runErr != nil {
+					return &res, runErr
+				}
+				return &res, nil
+			}
+			ev, err := decodeEvent(name, data.String())
+			if err != nil {
+				return nil, err
+			}
+			if ev.Type == EventError && runErr == nil {
+				runErr = &RunError{Message: ev.Error}
+			}
+			if onEvent != nil {
+				onEvent(ev)
+			}
+			name = ""
+			data.Reset()
+		case strings.HasPrefix(line, ":"):
+			// comment / keep-alive
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("ngoclaw: read stream: %w", err)
+	}
+	return nil, fmt.Errorf("ngoclaw: stream ended before the run finished: %w", io.ErrUnexpectedEOF)
+}
+
+func decodeEvent(name, data string) (Event, error) {
+	var env struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(data), &env); err != nil {
+		return Event{}, fmt.Errorf("ngoclaw: decode %s event: %w", name, err)
+	}
+	ev := Event{Type: EventType(name)}
+	var err error
+	switch ev.Type {
+	case EventThinking, EventTextDelta:
+		var p struct {
+			Content string `json:"content"`
+		}
+		err = json.Unmarshal(env.Data, &p)
+		ev.Content = p.Content
+	case EventToolCall, EventToolResult:
+		ev.Tool = &ToolEvent{}
+		err = json.Unmarshal(env.Data, ev.Tool)
+	case EventStepDone:
+		ev.Step = &StepInfo{}
+		err = json.Unmarshal(env.Data, ev.Step)
+	case EventError:
+		var p struct {
+			Error string `json:"error"`
+		}
+		err = json.Unmarshal(env.Data, &p)
+		ev.Error = p.Error
+	}
+	if err != nil {
+		return Event{}, fmt.Errorf("ngoclaw: decode %s event: %w", name, err)
+	}
+	return ev, nil
+}
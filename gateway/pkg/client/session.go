@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"sync"
+)
+
+// Session is a multi-turn conversation. The gateway's agent endpoint is
+// stateless, so the Session keeps the history and sends it with each task.
+type Session struct {
+	client *Client
+
+	// ID tags the session's runs in gateway logs.
+	ID string
+	// Model and SystemPrompt apply to every task in the session.
+	Model        string
+	SystemPrompt string
+
+	mu      sync.Mutex
+	history []Message
+}
+
+// NewSession starts an empty session.
+func (c *Client) NewSession(id string) *Session {
+	return &Session{client: c, ID: id}
+}
+
+// Send runs message as the next turn. On success the user message and the
+// agent's answer are appended to the history; failed turns are not recorded.
+// Turns of one session must not run concurrently.
+func (s *Session) Send(ctx context.Context, message string, onEvent EventHandler) (*Result, error) {
+	s.mu.Lock()
+	history := append([]Message(nil), s.history...)
+	s.mu.Unlock()
+
+	res, err := s.client.RunTask(ctx, TaskRequest{
+		Message:      message,
+		SystemPrompt: s.SystemPrompt,
+		Model:        s.Model,
+		SessionID:    s.ID,
+		History:      history,
+	}, onEvent)
+	if err != nil {
+		return res, err
+	}
+
+	s.mu.Lock()
+	s.history = append(s.history,
+		Message{Role: "user", Content: message},
+		Message{Role: "assistant", Content: res.Content, Model: res.ModelUsed},
+	)
+	s.mu.Unlock()
+	return res, nil
+}
+
+// History returns a copy of the conversation so far.
+func (s *Session) History() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.history...)
+}
+
+// SetHistory replaces the conversation, e.g. to resume a stored session.
+func (s *Session) SetHistory(history []Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = append([]Message(nil), history...)
+}
+
+// Reset clears the conversation.
+func (s *Session) Reset() {
+	s.SetHistory(nil)
+}
@@ -0,0 +1,16 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Tools returns the gateway's tool catalog (GET /v1/tools): names,
+// descriptions, parameter schemas, risk class and approval policy.
+func (c *Client) Tools(ctx context.Context) (*ToolCatalog, error) {
+	var out ToolCatalog
+	if err := c.do(ctx, http.MethodGet, "/v1/tools", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
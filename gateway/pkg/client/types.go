@@ -0,0 +1,129 @@
+package client
+
+import "time"
+
+// Message is one turn of conversation history sent with a task.
+type Message struct {
+	Role       string        `json:"role"` // system | user | assistant | tool
+	Content    string        `json:"content"`
+	Parts      []ContentPart `json:"parts,omitempty"`
+	ToolCalls  []ToolCall    `json:"tool_calls,omitempty"`
+	ToolCallID string        `json:"tool_call_id,omitempty"`
+	Name       string        `json:"name,omitempty"`
+	Model      string        `json:"model,omitempty"`
+}
+
+// ContentPart is a multimodal fragment of a Message (takes precedence over Content).
+type ContentPart struct {
+	Type     string `json:"type"` // text | image | audio | file
+	Text     string `json:"text,omitempty"`
+	MediaURL string `json:"media_url,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+	Data     []byte `json:"data,omitempty"` // inline bytes instead of MediaURL
+}
+
+// ToolCall is a tool invocation requested by an assistant Message.
+type ToolCall struct {
+	ID        string                 `json:"id"`
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// EventType names the events streamed by RunTask.
+type EventType string
+
+const (
+	EventThinking   EventType = "thinking"    // reasoning text (Content)
+	EventTextDelta  EventType = "text_delta"  // answer text (Content)
+	EventToolCall   EventType = "tool_call"   // tool about to run (Tool)
+	EventToolResult EventType = "tool_result" // tool finished (Tool, with Output and Success)
+	EventStepDone   EventType = "step_done"   // one ReAct step finished (Step)
+	EventError      EventType = "error"       // run failed or was aborted (Error)
+	EventComplete   EventType = "complete"    // run finished; the Result follows
+)
+
+// Event is one streamed agent event. Unknown event types are delivered
+// with only Type set.
+type Event struct {
+	Type    EventType
+	Content string
+	Tool    *ToolEvent
+	Step    *StepInfo
+	Error   string
+}
+
+// ToolEvent describes a tool invocation inside a run.
+type ToolEvent struct {
+	ID        string                 `json:"id"`
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+	Output    string                 `json:"output,omitempty"`
+	Display   string                 `json:"display,omitempty"`
+	Success   bool                   `json:"success"`
+	Duration  time.Duration          `json:"duration,omitempty"`
+}
+
+// StepInfo describes a finished agent step.
+type StepInfo struct {
+	Step       int    `json:"step"`
+	TokensUsed int    `json:"tokens_used"`
+	ModelUsed  string `json:"model_used"`
+	State      string `json:"state,omitempty"`
+	Route      string `json:"route,omitempty"` // auto-routing decision that picked the model
+}
+
+// Result is the outcome of a run.
+type Result struct {
+	Content     string      `json:"content"`
+	TotalSteps  int         `json:"total_steps"`
+	TotalTokens int         `json:"total_tokens"`
+	ModelUsed   string      `json:"model_used"`
+	ToolsUsed   []string    `json:"tools_used"`
+	Tests       *TestReport `json:"tests,omitempty"` // latest run_tests result, nil when no tests ran
+}
+
+// TestReport is what the run_tests tool found: failures and per-package
+// statement coverage, compared with Baseline (e.g. "HEAD 1a2b3c4") when measured.
+type TestReport struct {
+	Command  string            `json:"command"`
+	Passed   bool              `json:"passed"`
+	Failed   []string          `json:"failed,omitempty"`
+	Packages []PackageCoverage `json:"packages,omitempty"`
+	Baseline string            `json:"baseline,omitempty"`
+}
+
+// PackageCoverage is the coverage of one package, in percent. Baseline is nil
+// for new packages or when no baseline was measured.
+type PackageCoverage struct {
+	Package  string   `json:"package"`
+	Coverage float64  `json:"coverage"`
+	Baseline *float64 `json:"baseline,omitempty"`
+}
+
+// Approval is a tool call waiting for a human decision.
+type Approval struct {
+	ID          string                 `json:"id"`
+	ToolName    string                 `json:"tool_name"`
+	Args        map[string]interface{} `json:"args"`
+	CreatedAt   time.Time              `json:"created_at"`
+	ExpiresAt   time.Time              `json:"expires_at"`
+	Risk        string                 `json:"risk,omitempty"`
+	RiskReasons []string               `json:"risk_reasons,omitempty"`
+}
+
+// ToolSpec describes one tool in the gateway's catalog.
+type ToolSpec struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Kind        string                 `json:"kind,omitempty"`
+	Risk        string                 `json:"risk,omitempty"`     // low | medium | high
+	Approval    string                 `json:"approval,omitempty"` // never | always | per_call
+	Parameters  map[string]interface{} `json:"parameters"`         // JSON Schema
+}
+
+// ToolCatalog is the gateway's tool set. Version changes whenever a tool
+// or its schema changes.
+type ToolCatalog struct {
+	Version string     `json:"version"`
+	Tools   []ToolSpec `json:"tools"`
+}
@@ -0,0 +1,935 @@
+package i18n
+
+// catalogs 消息目录: locale → key → message
+var catalogs = map[Locale]map[string]string{
+	ZH: zhMessages,
+	EN: enMessages,
+}
+
+var zhMessages = map[string]string{
+	// ─── 工具审批卡片 ───
+	"approval.title":         "🔧 *请求执行工具*",
+	"approval.raw":           "工具: `%s`\n参数: %s",
+	"approval.bash":          "执行命令:\n```\n%s\n```",
+	"approval.write_file":    "写入文件: `%s` (%d 字符)",
+	"approval.preview":       "\n内容预览:\n```\n%s\n```",
+	"approval.read_file":     "读取文件: `%s`",
+	"approval.search":        "搜索: `%s`",
+	"approval.fetch":         "抓取网页: %s",
+	"approval.sql":           "在 `%s` 上执行写入语句:\n```sql\n%s\n```",
+	"approval.tool":          "工具: `%s`",
+	"approval.confirm":       "\n请确认是否执行：",
+	"approval.approve_btn":   "✅ 批准",
+	"approval.deny_btn":      "❌ 拒绝",
+	"approval.approved":      "✅ 已批准",
+	"approval.denied":        "❌ 已拒绝",
+	"approval.expired":       "请求已过期",
+	"approval.status":        "工具调用: `%s`\n状态: %s",
+	"approval.timed_out":     "⏰ 已超时 (自动拒绝)",
+	"approval.risk":          "⚠️ 风险等级: *%s*",
+	"approval.guard":         "🛡 项目守卫规则 *%s* 要求确认",
+	"approval.llm_title":     "💸 *请求发送大额模型调用*",
+	"approval.llm_call":      "模型: `%s`\n预估输入: 约 %dk token",
+	"approval.llm_cost":      "预估费用: $%.2f",
+	"risk.level.safe":        "安全",
+	"risk.level.normal":      "普通",
+	"risk.level.medium":      "中",
+	"risk.level.high":        "高",
+	"risk.level.critical":    "严重",
+	"risk.recursive_delete":  "递归删除",
+	"risk.delete_root":       "递归删除根目录/家目录/系统目录",
+	"risk.remote_exec":       "下载脚本并直接交给 shell 执行",
+	"risk.decode_exec":       "解码数据并交给 shell 执行",
+	"risk.disk_write":        "写入块设备或格式化磁盘",
+	"risk.raw_copy":          "dd 原始拷贝",
+	"risk.world_writable":    "设置为所有人可写",
+	"risk.privilege":         "提权执行",
+	"risk.system_path_write": "写入系统路径",
+	"risk.persistence":       "修改 shell 配置、SSH 密钥或定时任务",
+	"risk.network_send":      "通过网络上传数据",
+	"risk.sensitive_read":    "读取凭据或密钥",
+	"risk.exfiltration":      "读取密钥并发送到网络",
+	"risk.fork_bomb":         "fork 炸弹",
+	"risk.system_control":    "关机、重启或终止系统进程",
+	"risk.history_rewrite":   "改写或丢弃 git 历史",
+	"risk.dynamic_eval":      "执行动态拼接的代码",
+
+	// ─── 错误分类 ───
+	"error.transient":      "服务暂时不可用，请稍后重试",
+	"error.auth":           "认证失败，请检查 API Key 配置",
+	"error.bad_request":    "请求无效 (模型不存在或参数错误)",
+	"error.content_filter": "内容被模型安全策略拦截",
+	"error.budget":         "已超出预算或配额",
+	"error.cancelled":      "已取消",
+	"error.unknown":        "未知错误",
+
+	// ─── 中止原因 ───
+	"abort.user_stop":   "⏹ 已按你的要求停止",
+	"abort.interrupted": "⏹ 收到新消息，已中断上一个任务",
+	"abort.budget":      "💸 已用完本次运行的 token/时间预算，任务已停止",
+	"abort.timeout":     "⏱ 运行超时，任务已停止",
+	"abort.shutdown":    "🔄 服务正在重启，任务已停止",
+	"abort.cancelled":   "⏹ 任务已取消",
+	"abort.denied":      "💸 大额请求未获批准，任务已停止",
+
+	// ─── 运行状态 ───
+	"run.thinking":    "思考中...",
+	"run.interrupted": "⏹ 已中断",
+	"run.stopped":     "⏹ 已停止",
+	"run.no_active":   "⏹ 没有正在运行的任务",
+	"run.no_output":   "(无输出)",
+	"run.idle":        "空闲",
+	"run.running":     "运行中",
+	"run.steered":     "📝 已收到补充说明，正在调整…",
+	"run.withheld":    "🚫 回复未通过输出策略检查，已拦截",
+	"run.large_req":   "💸 大请求: 约 %dk 输入 token 发往 %s",
+	"run.large_cost":  "，预估费用 $%.2f",
+	"run.queued":      "⏳ %s 限流中，排队第 %d 位，约 %s 后发送",
+	"run.slow_start":  "🐢 %s 已 %s 未返回首个 token，模型思考较慢或服务拥堵，继续等待…",
+	"run.stalled":     "🐢 %s 输出已停顿 %s，服务可能拥堵，继续等待…",
+
+	// ─── 长时间运行提醒 ───
+	"run.long_running":  "⏳ 仍在处理，已运行 %s",
+	"run.steps_none":    "还在思考，尚未调用工具",
+	"run.step_one_done": "已完成步骤 1",
+	"run.steps_done":    "已完成步骤 1–%d",
+	"run.current":       "当前: %s",
+	"run.stop_btn":      "⏹ 停止",
+	"run.done_idle":     "✅ 任务已完成 (用时 %s)，结果见上方",
+	"run.ended_idle":    "⚠️ 任务已结束 (用时 %s)，详情见上方",
+
+	// ─── 反馈 (👍/👎 反应, /feedback) ───
+	"feedback.recorded_good": "👍 已记录为优质回答，谢谢反馈",
+	"feedback.recorded_bad":  "👎 已记录，可用 /feedback <说明> 补充哪里不对",
+	"feedback.usage":         "用法: /feedback <说明>\n附加到上一次回答的文字反馈",
+	"feedback.saved":         "📝 反馈已记录，谢谢",
+	"feedback.no_run":        "⚠️ 还没有可反馈的回答",
+	"feedback.disabled":      "⚠️ 未启用反馈记录",
+
+	// ─── /new ───
+	"new.carried_over":      "📌 已带入上一会话的要点 (/new --clean 可完全清空):\n<i>%s</i>",
+	"new.carry_over_failed": "⚠️ 未能总结上一会话，新对话从空白开始",
+
+	// ─── /status ───
+	"status.title":   "📊 <b>状态</b>",
+	"status.model":   "🤖 模型: <code>%s</code>",
+	"status.state":   "⚡ 状态: %s",
+	"status.session": "💬 会话: <code>%d</code>",
+	"status.hint":    "使用 /model 切换模型",
+	"status.unset":   "未设置",
+
+	"status.models_title":  "📈 <b>模型统计</b>",
+	"status.models_empty":  "📈 暂无模型调用记录",
+	"status.models_line":   "请求 %d · 失败 %d (%.1f%%) · tokens %d\n延迟 p50 %.0fms · p95 %.0fms",
+	"status.models_errors": "错误: %s",
+	"status.models_ttft":   "首 token p50 %.0fms · p95 %.0fms · 慢启动 %d · 停顿 %d",
+
+	// ─── /models ───
+	"models.current":           "🤖 当前: <code>%s</code>\n\n📋 选择提供商:",
+	"models.title":             "📋 <b>%s</b> 模型",
+	"models.stats":             "p50 %.0fms · 错误率 %.1f%% (%d 次)",
+	"models.no_stats":          "暂无调用记录",
+	"models.legend":            "🛠 工具 · 👁 视觉 · 🧠 推理 · 🧪 测试后切换",
+	"models.probe_ok":          "✅ <code>%s</code> 响应正常 (%dms), 已切换",
+	"models.probe_failed":      "❌ <code>%s</code> 测试失败 (%dms): %s\n未切换模型",
+	"models.probe_unavailable": "⚠️ 未配置模型探测, 请直接选择模型",
+	"models.unspecified":       "⚠️ 未指定模型",
+	"models.switched":          "✅ 已切换到模型: <code>%s</code>",
+	"models.switch_failed":     "❌ 切换模型失败: %s",
+
+	"reply.document": "📎 完整回复 (%d 段)",
+
+	"cli.status.title": "◇ 当前状态",
+	"cli.status.model": "模型:",
+	"cli.status.tools": "工具:",
+	"cli.status.count": "%d 已加载",
+
+	// ─── /lang ───
+	"lang.current": "🌐 当前语言: %s\n\n用法: /lang zh|en|auto\nzh/en 同时固定回复语言; auto 让回复跟随你消息的语言",
+	"lang.set":     "🌐 语言已切换为: %s (界面和回复)",
+	"lang.auto":    "🌐 回复将跟随你消息的语言 (界面语言: %s)",
+	"lang.usage":   "⚙️ 用法: /lang zh|en|auto",
+
+	// ─── /params ───
+	"params.title":       "🎛 <b>模型参数</b> (本会话)",
+	"params.default":     "默认",
+	"params.usage":       "用法: /params &lt;参数&gt; &lt;值|reset&gt; · /params reset\n参数: temperature (0-2], top_p (0-1], max_tokens, effort low|medium|high",
+	"params.invalid":     "❌ %s",
+	"params.unavailable": "⚙️ 当前会话不支持模型参数",
+
+	// ─── /route ───
+	"route.status":      "🧭 <b>自动选模型</b>: %s",
+	"route.on":          "开启",
+	"route.off":         "关闭",
+	"route.default":     "%s (配置默认)",
+	"route.rules":       "规则 (按顺序, 第一条命中生效; 都不命中用 /model 的模型):",
+	"route.last":        "上次: %s",
+	"route.usage":       "用法: /route on|off|default",
+	"route.unavailable": "⚙️ 未配置路由规则 (agent.routing.rules)",
+
+	// ─── /draft ───
+	"draft.status":      "📝 <b>草稿模式</b>: %s",
+	"draft.model":       "起草模型: <code>%s</code> (当前模型只审核或修改草稿)",
+	"draft.models":      "仅用于: %s",
+	"draft.not_applied": "⚠️ 当前模型 <code>%s</code> 不使用草稿",
+	"draft.usage":       "用法: /draft on|off|default",
+	"draft.unavailable": "⚙️ 未配置起草模型 (agent.draft.model)",
+	"draft.approved":    "📝 %s 起草 · %s 审核通过",
+	"draft.edited":      "📝 %s 起草 · %s 修改",
+
+	// ─── 额度池 (agent.budgets, /usage quota) ───
+	"budget.refused":        "🙏 抱歉，额度池 <b>%s</b> 已用完，暂时无法开始新的任务。\n%s\n将于 %s 重置，届时再来找我吧。",
+	"budget.title":          "💸 <b>额度</b>",
+	"budget.none":           "💸 未配置额度池 (agent.budgets)",
+	"budget.pool":           "<b>%s</b> · %s · %s",
+	"budget.tokens":         "tokens: %s / %s (剩余 %s)",
+	"budget.usd":            "费用: $%.2f / $%.2f (剩余 $%.2f)",
+	"budget.resets":         "%s 重置",
+	"budget.exhausted":      "⛔ 已用完",
+	"budget.scope.global":   "全局",
+	"budget.scope.user":     "每用户",
+	"budget.scope.chat":     "每会话",
+	"budget.window.daily":   "每日",
+	"budget.window.monthly": "每月",
+
+	// ─── /agent ───
+	"agent.title":            "🤖 <b>代理</b> (▶ = 当前会话)",
+	"agent.usage":            "用法:\n• /agent switch &lt;名称&gt; — 本会话切换代理 (default = 默认)\n• /agent show &lt;名称&gt;\n• /agent create &lt;名称&gt;\n• /agent set &lt;名称&gt; persona|tools|model|temperature &lt;值&gt;\n• /agent delete &lt;名称&gt;",
+	"agent.unavailable":      "⚙️ 代理不可用",
+	"agent.not_found":        "❌ 没有代理 <code>%s</code> (/agent list)",
+	"agent.switched":         "✅ 已切换到代理 <b>%s</b>",
+	"agent.switched_default": "✅ 已切回默认代理",
+	"agent.admin_only":       "⛔ 创建、修改和删除代理仅限管理员",
+	"agent.bad_name":         "❌ 代理名只能包含小写字母、数字、- 和 _, 最长 32 个字符",
+	"agent.exists":           "❌ 代理 <code>%s</code> 已存在",
+	"agent.created":          "✅ 已创建代理 <code>%s</code>\n用 /agent set %s persona|tools|model|temperature &lt;值&gt; 配置",
+	"agent.updated":          "✅ 已更新代理 <b>%s</b>",
+	"agent.deleted":          "🗑 已删除代理 <b>%s</b>",
+	"agent.default_readonly": "⚠️ 默认代理不可修改或删除",
+	"agent.unknown_model":    "❌ 未知模型: <code>%s</code> (/models)",
+	"agent.unknown_tool":     "❌ 未知工具: <code>%s</code> (/tools)",
+	"agent.persona_too_long": "❌ 人设过长 (最多 %d 字)",
+	"agent.error":            "❌ %s",
+	"agent.detail":           "<b>%s</b>\n模型: %s\n温度: %s\n工具: %s\n人设: %s",
+	"agent.default_info":     "<b>%s</b>\n使用会话自身的设置: /model、/params、全部工具, 无额外人设",
+	"agent.chat_model":       "会话模型 (/model)",
+	"agent.model_default":    "默认",
+	"agent.all_tools":        "全部",
+	"agent.none":             "(无)",
+
+	// ─── /profile ───
+	"profile.status":      "🗂 <b>配置 profile</b>: %s",
+	"profile.none":        "default (未启用 profile)",
+	"profile.list":        "可用 (~/.ngoclaw/profiles/&lt;名称&gt;/config.yaml):",
+	"profile.empty":       "尚无 profile。在 ~/.ngoclaw/profiles/&lt;名称&gt;/config.yaml 中创建, 只写需要覆盖的配置项。",
+	"profile.usage":       "用法: /profile [名称|default]",
+	"profile.same":        "✅ 已在使用 profile <b>%s</b>",
+	"profile.switching":   "🔄 切换到 profile <b>%s</b>, 网关即将重启…",
+	"profile.error":       "⚠️ 无法切换 profile: %s",
+	"profile.unavailable": "⚠️ 当前运行模式不支持切换 profile",
+
+	// ─── /research ───
+	"research.usage":   "🔎 用法: /research &lt;主题&gt;",
+	"research.started": "🔎 开始研究: <b>%s</b>\n多角度检索中，完成后附编号引用…",
+
+	// ─── /workflow ───
+	"workflow.usage":       "用法: /workflow list | /workflow run &lt;名称&gt; [输入]\n流水线定义在 ~/.ngoclaw/workflows/*.yaml，可用 /cron add &lt;表达式&gt; /workflow run &lt;名称&gt; 定时运行",
+	"workflow.unavailable": "🔀 流水线未启用 (需要 Telegram 运行模式)",
+	"workflow.none":        "🔀 还没有流水线",
+	"workflow.title":       "🔀 <b>流水线</b>",
+	"workflow.invalid":     "⚠️ 定义无效: %s",
+	"workflow.started":     "🔀 开始运行流水线 <b>%s</b> (%d 个阶段)，完成后发送逐阶段汇总",
+	"workflow.error":       "❌ 无法运行流水线: %s",
+
+	// ─── 模板 ───
+	"template.title":       "📋 <b>提示词模板</b>",
+	"template.empty":       "📋 暂无模板\n\n在 <code>%s</code> 下创建 <code>名称.md</code>，用 {{变量}} 标记占位符",
+	"template.usage":       "用法: /t &lt;名称&gt; key=value ...",
+	"template.ask":         "✏️ 请输入 <b>%s</b> (模板 <code>%s</code>)，或发送任意命令取消",
+	"template.unavailable": "模板功能未启用",
+	"template.ask_cli":     "%s = ",
+
+	// ─── /setvar ───
+	"setvar.title":       "🔤 <b>提示词变量</b>",
+	"setvar.empty":       "尚未设置变量",
+	"setvar.config":      "(配置默认)",
+	"setvar.set":         "✅ <code>{{%s}}</code> = %s",
+	"setvar.removed":     "🗑 已删除 <code>{{%s}}</code> (如有配置默认值则恢复)",
+	"setvar.too_long":    "❌ 值太长 (最多 %d 字)",
+	"setvar.too_many":    "❌ 变量太多 (每个会话最多 %d 个)",
+	"setvar.usage":       "用法: /setvar 名字 值 — 设置; /setvar 名字 — 删除\nsoul.md 与 prompts/*.md 中的 {{名字}} 在每次对话时替换为这里的值",
+	"setvar.unavailable": "⚙️ 当前会话不支持提示词变量",
+
+	// ─── /tools ───
+	"tools.unavailable":      "🛠 工具目录不可用",
+	"tools.title":            "🛠 <b>工具</b> (%d)",
+	"tools.mode":             " · 审批模式 <code>%s</code>",
+	"tools.legend":           "<i>🔒 每次调用都需审批 · ❔ 视参数而定 (命令风险、信任前缀、只读操作)</i>",
+	"tools.hint":             "<i>/tools &lt;类型&gt; 查看说明 · /security 切换审批模式 · /trust 免审批</i>",
+	"tools.disabled":         "⛔ 已关闭: %s",
+	"tools.unknown_category": "❌ 没有类型为 <code>%s</code> 的工具\n可用: %s",
+	"tools.cat.read":         "📖 读取",
+	"tools.cat.search":       "🔍 搜索",
+	"tools.cat.edit":         "✏️ 编辑",
+	"tools.cat.delete":       "🗑 删除",
+	"tools.cat.execute":      "⚡ 执行",
+	"tools.cat.fetch":        "🌐 网络",
+	"tools.cat.mcp":          "🔌 MCP",
+	"tools.cat.think":        "💭 思考",
+	"tools.cat.communicate":  "💬 交互",
+
+	// ─── /memory ───
+	"memory.title":          "🧠 <b>长期记忆</b> (%d 条)",
+	"memory.empty":          "🧠 记忆库为空\n\n用 /memory add &lt;内容&gt; 添加，或在对话中让 AI 调用 save_memory。",
+	"memory.more":           "<i>共 %d 条，仅显示最新 %d 条</i>",
+	"memory.hint":           "<i>/memory edit|delete &lt;id&gt; 修改 · /memory audit 修改记录</i>",
+	"memory.usage":          "用法:\n/memory — 查看\n/memory add [类别:] &lt;内容&gt;\n/memory edit &lt;id&gt; &lt;新内容&gt;\n/memory delete &lt;id&gt;\n/memory audit — 修改记录\n类别: preference, knowledge, context, behavior, goal",
+	"memory.added":          "✅ 已记住 <code>%s</code> [%s]: %s",
+	"memory.not_found":      "❌ 没有 ID 为 <code>%s</code> 的记忆",
+	"memory.confirm_edit":   "✏️ 修改记忆 <code>%s</code>?\n\n<s>%s</s>\n→ %s",
+	"memory.confirm_delete": "🗑 删除记忆 <code>%s</code>?\n\n%s",
+	"memory.confirm_btn":    "✅ 确认",
+	"memory.cancel_btn":     "❌ 取消",
+	"memory.edited":         "✅ 已修改 <code>%s</code>: %s",
+	"memory.deleted":        "🗑 已删除 <code>%s</code>: %s",
+	"memory.cancelled":      "已取消，记忆未修改",
+	"memory.expired":        "⌛ 确认已过期，请重新执行命令",
+	"memory.error":          "❌ 记忆操作失败: %s",
+	"memory.audit_title":    "📜 <b>记忆修改记录</b> (最近 %d 条)",
+	"memory.audit_empty":    "📜 暂无修改记录",
+
+	"recall.usage":       "用法: /recall &lt;关键词&gt;\n全文搜索本会话过往的运行、转录与产物 (研究报告、归档的工具输出)",
+	"recall.unavailable": "🔎 历史检索未启用",
+	"recall.none":        "🔎 没有找到与 <b>%s</b> 相关的过往运行或产物",
+	"recall.title":       "🔎 <b>%s</b> · %d 条结果",
+	"recall.hint":        "<i>让我打开某条: “读一下 run:…” (read_artifact)</i>",
+	"recall.error":       "❌ 检索失败: %s",
+
+	// ─── 数据清除 (/forgetme) ───
+	"forget.confirm":     "⚠️ <b>删除本会话的全部数据?</b>\n\n将永久删除: 对话历史、会话偏好、已保存的消息与反馈、运行转录、research 报告，以及在本会话中记下的长期记忆。此操作不可撤销。",
+	"forget.confirm_btn": "🗑 全部删除",
+	"forget.cancel_btn":  "❌ 取消",
+	"forget.cancelled":   "已取消，数据未删除",
+	"forget.expired":     "⌛ 确认已过期，请重新发送 /forgetme",
+	"forget.done":        "✅ 已删除本会话的全部数据 (%d 条记录)",
+	"forget.error":       "⚠️ 部分数据删除失败 (已删除 %d 条): %s",
+	"forget.disabled":    "⚠️ 未启用数据清除",
+
+	// ─── 分享链接 (/share) ───
+	"share.created":     "🔗 上一次运行的只读分享链接 (有效至 %s, 已自动隐藏密钥):\n%s",
+	"share.no_run":      "⚠️ 还没有可分享的运行 (只保留网关启动后的最近运行)",
+	"share.invalid_ttl": "⚠️ 无效的有效期 %s, 示例: /share 24h",
+	"share.error":       "⚠️ 生成分享链接失败: %s",
+	"share.disabled":    "⚠️ 未启用分享链接 (gateway.share.enabled)",
+	"share.no_base_url": "💡 设置 gateway.share.base_url 后这里会显示完整地址",
+
+	// ─── 定时任务状态 (/cron status) ───
+	"cron.status.title":          "⏰ <b>定时任务状态</b>",
+	"cron.status.empty":          "暂无定时任务",
+	"cron.status.job":            "• <code>%s</code> <code>%s</code> — %s",
+	"cron.status.heartbeat":      "💓 心跳 (每 %s, %s)",
+	"cron.status.disabled":       "已停用",
+	"cron.status.next":           "下次: %s · 补跑策略: %s",
+	"cron.status.never":          "尚未运行",
+	"cron.status.last_ok":        "上次: %s ✅",
+	"cron.status.last_error":     "上次: %s ❌ %s",
+	"cron.status.missed":         "上次启动时错过 %d 次运行, 已按策略补跑",
+	"cron.status.missed_skipped": "上次启动时错过 %d 次运行, 已跳过",
+
+	// ─── 控制群通知 ───
+	"audit.config_set":       "⚙️ 配置已修改: <code>%s</code> = <code>%s</code>",
+	"audit.config_unset":     "⚙️ 配置已删除: <code>%s</code>",
+	"audit.debug_set":        "🐞 调试覆盖: <code>%s</code> = <code>%s</code>",
+	"audit.debug_unset":      "🐞 调试覆盖已删除: <code>%s</code>",
+	"audit.debug_reset":      "🐞 调试覆盖已全部清除",
+	"audit.approval_denied":  "🚫 审批被拒绝: <code>%s</code>",
+	"audit.approval_timeout": "⏰ 审批超时 (自动拒绝): <code>%s</code>",
+	"audit.budget":           "💸 运行因预算耗尽被中止 (已用 %d tokens)",
+	"audit.provider_down":    "🔴 模型提供商 <b>%s</b> 连续失败, 暂时跳过: %s",
+	"audit.provider_up":      "🟢 模型提供商 <b>%s</b> 已恢复",
+	"audit.restart":          "🔄 请求重启网关",
+	"audit.profile":          "🔄 切换配置 profile 为 <b>%s</b>, 网关将重启",
+	"audit.source":           "chat <code>%d</code>",
+	"audit.source_user":      "chat <code>%d</code> · 用户 <code>%d</code>",
+
+	// ─── 命令 ───
+	"cmd.help_title":   "📚 <b>命令列表</b>",
+	"cmd.help_tip":     "💡 直接发送消息即可与 AI 对话",
+	"cmd.denied":       "⛔ /%s 仅限管理员使用",
+	"cmd.invalid":      "⚙️ %s\n用法: <code>%s</code>",
+	"cmd.arg_missing":  "缺少参数 %s",
+	"cmd.arg_invalid":  "无效的值 %q, 可用: %s",
+	"cmd.arg_extra":    "多余的参数: %s",
+	"cmd.flag_unknown": "未知选项 %s",
+	"cmd.flag_value":   "选项 %s 需要一个值 (--name=value)",
+	"cmd.flag_novalue": "选项 %s 不接受值",
+
+	"cmd.group.session":  "会话",
+	"cmd.group.model":    "模型",
+	"cmd.group.status":   "状态",
+	"cmd.group.config":   "配置",
+	"cmd.group.advanced": "高级",
+
+	"cmd.arg.text":         "文本",
+	"cmd.arg.ttl":          "有效期",
+	"cmd.arg.instructions": "指令",
+	"cmd.arg.action":       "操作",
+	"cmd.arg.args":         "参数",
+	"cmd.arg.token":        "令牌",
+	"cmd.arg.provider":     "提供商",
+	"cmd.arg.page":         "页码",
+	"cmd.arg.level":        "级别",
+	"cmd.arg.param":        "参数名",
+	"cmd.arg.value":        "值",
+	"cmd.arg.tool":         "工具",
+	"cmd.arg.lang":         "zh|en|auto",
+	"cmd.arg.name":         "名称",
+	"cmd.arg.topic":        "主题",
+	"cmd.arg.vars":         "k=v",
+	"cmd.arg.id":           "id",
+	"cmd.arg.command":      "命令",
+	"cmd.arg.category":     "类型",
+
+	"cmd.new":        "新对话",
+	"cmd.clear":      "清除历史",
+	"cmd.stop":       "停止当前任务",
+	"cmd.compact":    "压缩上下文",
+	"cmd.context":    "上下文统计",
+	"cmd.reset":      "重置会话",
+	"cmd.feedback":   "反馈上一次回答 (也可对回答点 👍/👎)",
+	"cmd.forgetme":   "删除本会话的全部数据",
+	"cmd.share":      "上一次运行的只读分享链接",
+	"cmd.models":     "浏览/切换模型 (/model)",
+	"cmd.think":      "思考级别",
+	"cmd.verbose":    "详细模式",
+	"cmd.reasoning":  "推理可见性",
+	"cmd.params":     "温度 / top_p / 输出上限 / 推理强度",
+	"cmd.route":      "按任务自动选模型",
+	"cmd.draft":      "便宜模型起草, 当前模型审核",
+	"cmd.status":     "当前状态 / 模型统计",
+	"cmd.whoami":     "身份信息",
+	"cmd.usage":      "用量统计",
+	"cmd.commands":   "所有命令",
+	"cmd.tools":      "工具列表 (按类型, 含审批要求)",
+	"cmd.config":     "查看/编辑配置",
+	"cmd.debug":      "运行时调试覆盖",
+	"cmd.security":   "安全策略",
+	"cmd.trust":      "信任工具",
+	"cmd.untrust":    "取消信任工具",
+	"cmd.allowlist":  "白名单管理",
+	"cmd.activation": "群组激活",
+	"cmd.sendpolicy": "发送策略",
+	"cmd.lang":       "界面和回复语言",
+	"cmd.profile":    "切换配置 profile (重启网关)",
+	"cmd.restart":    "重启网关",
+	"cmd.skills":     "技能管理",
+	"cmd.skill":      "执行技能",
+	"cmd.cron":       "定时任务",
+	"cmd.research":   "多来源研究 (带引用)",
+	"cmd.workflow":   "命名流水线 (多阶段)",
+	"cmd.plan":       "查看计划",
+	"cmd.templates":  "提示词模板",
+	"cmd.t":          "使用模板",
+	"cmd.setvar":     "提示词变量",
+	"cmd.memory":     "长期记忆",
+	"cmd.recall":     "搜索过往运行与产物",
+	"cmd.agent":      "切换命名代理 (人设、工具、模型)",
+	"cmd.subagents":  "子代理",
+	"cmd.tts":        "语音合成",
+	"cmd.approve":    "处理审批请求",
+	"cmd.bash":       "执行 shell 命令",
+	"cmd.plugin":     "插件命令",
+	"cmd.help":       "帮助",
+
+	"cli.help.title":     "◇ 可用命令",
+	"cli.help.help":      "显示此帮助",
+	"cli.help.model":     "查看/切换模型",
+	"cli.help.new":       "清空对话历史",
+	"cli.help.compact":   "压缩上下文",
+	"cli.help.status":    "当前状态",
+	"cli.help.tools":     "按类型列出工具与审批要求",
+	"cli.help.think":     "思考级别 (off/low/medium/high)",
+	"cli.help.lang":      "界面和回复语言 (zh/en/auto)",
+	"cli.help.research":  "多来源研究 (带编号引用)",
+	"cli.help.templates": "列出提示词模板",
+	"cli.help.t":         "使用模板 (缺失变量会提示输入)",
+	"cli.help.version":   "版本信息",
+	"cli.help.expand":    "展开上一轮工具的完整输出",
+	"cli.help.exit":      "退出",
+	"cli.tool.collapsed": "… 还有 %d 行 (/expand %d 展开)",
+	"cli.expand.empty":   "上一轮没有工具输出",
+	"cli.expand.usage":   "用法: /expand [1-%d]",
+	"cli.new":            "🔄 已清空对话历史",
+	"cli.model.current":  "当前模型: %s\n用法: /model <model_name>",
+	"cli.model.switched": "✓ 模型已切换为: %s",
+	"cli.compacted":      "🗜 上下文已压缩",
+	"cli.think":          "🧠 思考级别: %s",
+	"cli.research.usage": "用法: /research <主题>",
+	"cli.unknown":        "未知命令: /%s  输入 /help 查看可用命令",
+	"cli.quit_hint":      "(再按 Ctrl+C 退出)",
+	"cli.bye":            "👋 再见",
+}
+
+var enMessages = map[string]string{
+	// ─── Tool approval card ───
+	"approval.title":         "🔧 *Tool execution request*",
+	"approval.raw":           "Tool: `%s`\nArgs: %s",
+	"approval.bash":          "Run command:\n```\n%s\n```",
+	"approval.write_file":    "Write file: `%s` (%d chars)",
+	"approval.preview":       "\nPreview:\n```\n%s\n```",
+	"approval.read_file":     "Read file: `%s`",
+	"approval.search":        "Search: `%s`",
+	"approval.fetch":         "Fetch page: %s",
+	"approval.sql":           "Run a write statement on `%s`:\n```sql\n%s\n```",
+	"approval.tool":          "Tool: `%s`",
+	"approval.confirm":       "\nApprove this call?",
+	"approval.approve_btn":   "✅ Approve",
+	"approval.deny_btn":      "❌ Deny",
+	"approval.approved":      "✅ Approved",
+	"approval.denied":        "❌ Denied",
+	"approval.expired":       "Request expired",
+	"approval.status":        "Tool call: `%s`\nStatus: %s",
+	"approval.timed_out":     "⏰ Timed out (auto-denied)",
+	"approval.risk":          "⚠️ Risk: *%s*",
+	"approval.guard":         "🛡 Project guard rule *%s* requires approval",
+	"approval.llm_title":     "💸 *Large model request*",
+	"approval.llm_call":      "Model: `%s`\nEstimated input: ~%dk tokens",
+	"approval.llm_cost":      "Estimated cost: $%.2f",
+	"risk.level.safe":        "safe",
+	"risk.level.normal":      "normal",
+	"risk.level.medium":      "medium",
+	"risk.level.high":        "high",
+	"risk.level.critical":    "critical",
+	"risk.recursive_delete":  "recursive delete",
+	"risk.delete_root":       "recursive delete of root/home/system directory",
+	"risk.remote_exec":       "downloads a script and pipes it into a shell",
+	"risk.decode_exec":       "decodes data and pipes it into a shell",
+	"risk.disk_write":        "writes to a block device or formats a disk",
+	"risk.raw_copy":          "raw dd copy",
+	"risk.world_writable":    "makes files world-writable",
+	"risk.privilege":         "privilege escalation",
+	"risk.system_path_write": "writes to a system path",
+	"risk.persistence":       "modifies shell profile, SSH keys or cron",
+	"risk.network_send":      "uploads data over the network",
+	"risk.sensitive_read":    "reads credentials or secrets",
+	"risk.exfiltration":      "reads secrets and sends them over the network",
+	"risk.fork_bomb":         "fork bomb",
+	"risk.system_control":    "shuts down, reboots or kills system processes",
+	"risk.history_rewrite":   "rewrites or discards git history",
+	"risk.dynamic_eval":      "evaluates dynamically built code",
+
+	// ─── Error classification ───
+	"error.transient":      "Service temporarily unavailable, please retry later",
+	"error.auth":           "Authentication failed, check the API key configuration",
+	"error.bad_request":    "Invalid request (unknown model or bad parameters)",
+	"error.content_filter": "Blocked by the model's content policy",
+	"error.budget":         "Budget or quota exceeded",
+	"error.cancelled":      "Cancelled",
+	"error.unknown":        "Unknown error",
+
+	// ─── Abort reasons ───
+	"abort.user_stop":   "⏹ Stopped as requested",
+	"abort.interrupted": "⏹ Interrupted by your new message",
+	"abort.budget":      "💸 Stopped: this run's token/time budget is used up",
+	"abort.timeout":     "⏱ Stopped: the run timed out",
+	"abort.shutdown":    "🔄 Stopped: the service is restarting",
+	"abort.cancelled":   "⏹ Cancelled",
+	"abort.denied":      "💸 Stopped: the large request was not approved",
+
+	// ─── Run state ───
+	"run.thinking":    "Thinking...",
+	"run.interrupted": "⏹ Interrupted",
+	"run.stopped":     "⏹ Stopped",
+	"run.no_active":   "⏹ Nothing is running",
+	"run.no_output":   "(no output)",
+	"run.idle":        "idle",
+	"run.running":     "running",
+	"run.steered":     "📝 Got your follow-up, adjusting…",
+	"run.withheld":    "🚫 The answer was blocked by the output policy",
+	"run.large_req":   "💸 Large request: ~%dk input tokens to %s",
+	"run.large_cost":  ", est. cost $%.2f",
+	"run.queued":      "⏳ %s is rate limited, queued at #%d, sending in ~%s",
+	"run.slow_start":  "🐢 No first token from %s after %s: the model is thinking slowly or the provider is congested, still waiting…",
+	"run.stalled":     "🐢 Output from %s paused for %s, the provider may be congested, still waiting…",
+
+	// ─── Long-running runs ───
+	"run.long_running":  "⏳ Still working, running for %s",
+	"run.steps_none":    "Still thinking, no tools called yet",
+	"run.step_one_done": "Completed step 1",
+	"run.steps_done":    "Completed steps 1–%d",
+	"run.current":       "Now: %s",
+	"run.stop_btn":      "⏹ Stop",
+	"run.done_idle":     "✅ Done (took %s), the result is above",
+	"run.ended_idle":    "⚠️ The run ended (took %s), details above",
+
+	// ─── Feedback (👍/👎 reactions, /feedback) ───
+	"feedback.recorded_good": "👍 Marked as a good answer, thanks",
+	"feedback.recorded_bad":  "👎 Noted. Use /feedback <text> to say what was wrong",
+	"feedback.usage":         "Usage: /feedback <text>\nAttaches a comment to the last answer",
+	"feedback.saved":         "📝 Feedback recorded, thanks",
+	"feedback.no_run":        "⚠️ No answer to give feedback on yet",
+	"feedback.disabled":      "⚠️ Feedback recording is not enabled",
+
+	// ─── /new ───
+	"new.carried_over":      "📌 Carried over from the previous conversation (/new --clean starts empty):\n<i>%s</i>",
+	"new.carry_over_failed": "⚠️ Could not summarize the previous conversation, starting empty",
+
+	// ─── /status ───
+	"status.title":   "📊 <b>Status</b>",
+	"status.model":   "🤖 Model: <code>%s</code>",
+	"status.state":   "⚡ State: %s",
+	"status.session": "💬 Session: <code>%d</code>",
+	"status.hint":    "Use /model to switch models",
+	"status.unset":   "not set",
+
+	"status.models_title":  "📈 <b>Model stats</b>",
+	"status.models_empty":  "📈 No model calls recorded yet",
+	"status.models_line":   "requests %d · failed %d (%.1f%%) · tokens %d\nlatency p50 %.0fms · p95 %.0fms",
+	"status.models_errors": "errors: %s",
+	"status.models_ttft":   "first token p50 %.0fms · p95 %.0fms · slow starts %d · stalls %d",
+
+	// ─── /models ───
+	"models.current":           "🤖 Current: <code>%s</code>\n\n📋 Choose a provider:",
+	"models.title":             "📋 <b>%s</b> models",
+	"models.stats":             "p50 %.0fms · errors %.1f%% (%d calls)",
+	"models.no_stats":          "no calls yet",
+	"models.legend":            "🛠 tools · 👁 vision · 🧠 reasoning · 🧪 test, then switch",
+	"models.probe_ok":          "✅ <code>%s</code> answered in %dms — switched",
+	"models.probe_failed":      "❌ <code>%s</code> probe failed after %dms: %s\nModel not switched",
+	"models.probe_unavailable": "⚠️ Model probing is not configured; pick the model directly",
+	"models.unspecified":       "⚠️ No model given",
+	"models.switched":          "✅ Switched to model: <code>%s</code>",
+	"models.switch_failed":     "❌ Failed to switch model: %s",
+
+	"reply.document": "📎 Full reply (%d parts)",
+
+	"cli.status.title": "◇ Status",
+	"cli.status.model": "Model:",
+	"cli.status.tools": "Tools:",
+	"cli.status.count": "%d loaded",
+
+	// ─── /lang ───
+	"lang.current": "🌐 Current language: %s\n\nUsage: /lang zh|en|auto\nzh/en also fix the reply language; auto makes replies follow the language of your messages",
+	"lang.set":     "🌐 Language switched to: %s (interface and replies)",
+	"lang.auto":    "🌐 Replies now follow the language of your messages (interface: %s)",
+	"lang.usage":   "⚙️ Usage: /lang zh|en|auto",
+
+	// ─── /params ───
+	"params.title":       "🎛 <b>Model parameters</b> (this chat)",
+	"params.default":     "default",
+	"params.usage":       "Usage: /params &lt;name&gt; &lt;value|reset&gt; · /params reset\nNames: temperature (0-2], top_p (0-1], max_tokens, effort low|medium|high",
+	"params.invalid":     "❌ %s",
+	"params.unavailable": "⚙️ Model parameters are not available in this chat",
+
+	// ─── /route ───
+	"route.status":      "🧭 <b>Auto model routing</b>: %s",
+	"route.on":          "on",
+	"route.off":         "off",
+	"route.default":     "%s (config default)",
+	"route.rules":       "Rules (in order, first match wins; no match uses the /model choice):",
+	"route.last":        "Last: %s",
+	"route.usage":       "Usage: /route on|off|default",
+	"route.unavailable": "⚙️ No routing rules configured (agent.routing.rules)",
+
+	// ─── /draft ───
+	"draft.status":      "📝 <b>Draft mode</b>: %s",
+	"draft.model":       "Drafting model: <code>%s</code> (the current model only reviews or edits the draft)",
+	"draft.models":      "Only for: %s",
+	"draft.not_applied": "⚠️ The current model <code>%s</code> does not use drafts",
+	"draft.usage":       "Usage: /draft on|off|default",
+	"draft.unavailable": "⚙️ No drafting model configured (agent.draft.model)",
+	"draft.approved":    "📝 Drafted by %s · approved by %s",
+	"draft.edited":      "📝 Drafted by %s · edited by %s",
+
+	// ─── Budget pools (agent.budgets, /usage quota) ───
+	"budget.refused":        "🙏 Sorry, the <b>%s</b> budget is used up, so I can't start new tasks right now.\n%s\nIt resets %s — see you then.",
+	"budget.title":          "💸 <b>Quota</b>",
+	"budget.none":           "💸 No budget pools configured (agent.budgets)",
+	"budget.pool":           "<b>%s</b> · %s · %s",
+	"budget.tokens":         "tokens: %s / %s (%s left)",
+	"budget.usd":            "cost: $%.2f / $%.2f ($%.2f left)",
+	"budget.resets":         "resets %s",
+	"budget.exhausted":      "⛔ used up",
+	"budget.scope.global":   "global",
+	"budget.scope.user":     "per user",
+	"budget.scope.chat":     "per chat",
+	"budget.window.daily":   "daily",
+	"budget.window.monthly": "monthly",
+
+	// ─── /agent ───
+	"agent.title":            "🤖 <b>Agents</b> (▶ = this chat)",
+	"agent.usage":            "Usage:\n• /agent switch &lt;name&gt; — switch this chat's agent (default = the default agent)\n• /agent show &lt;name&gt;\n• /agent create &lt;name&gt;\n• /agent set &lt;name&gt; persona|tools|model|temperature &lt;value&gt;\n• /agent delete &lt;name&gt;",
+	"agent.unavailable":      "⚙️ Agents are not available",
+	"agent.not_found":        "❌ No agent <code>%s</code> (/agent list)",
+	"agent.switched":         "✅ Switched to agent <b>%s</b>",
+	"agent.switched_default": "✅ Back to the default agent",
+	"agent.admin_only":       "⛔ Only admins can create, change or delete agents",
+	"agent.bad_name":         "❌ Agent names may only contain lowercase letters, digits, - and _, up to 32 characters",
+	"agent.exists":           "❌ Agent <code>%s</code> already exists",
+	"agent.created":          "✅ Created agent <code>%s</code>\nConfigure it with /agent set %s persona|tools|model|temperature &lt;value&gt;",
+	"agent.updated":          "✅ Updated agent <b>%s</b>",
+	"agent.deleted":          "🗑 Deleted agent <b>%s</b>",
+	"agent.default_readonly": "⚠️ The default agent cannot be changed or deleted",
+	"agent.unknown_model":    "❌ Unknown model: <code>%s</code> (/models)",
+	"agent.unknown_tool":     "❌ Unknown tool: <code>%s</code> (/tools)",
+	"agent.persona_too_long": "❌ Persona too long (max %d characters)",
+	"agent.error":            "❌ %s",
+	"agent.detail":           "<b>%s</b>\nModel: %s\nTemperature: %s\nTools: %s\nPersona: %s",
+	"agent.default_info":     "<b>%s</b>\nUses the chat's own settings: /model, /params, all tools, no extra persona",
+	"agent.chat_model":       "chat model (/model)",
+	"agent.model_default":    "default",
+	"agent.all_tools":        "all",
+	"agent.none":             "(none)",
+
+	// ─── /profile ───
+	"profile.status":      "🗂 <b>Config profile</b>: %s",
+	"profile.none":        "default (no profile)",
+	"profile.list":        "Available (~/.ngoclaw/profiles/&lt;name&gt;/config.yaml):",
+	"profile.empty":       "No profiles yet. Create ~/.ngoclaw/profiles/&lt;name&gt;/config.yaml containing only the settings to override.",
+	"profile.usage":       "Usage: /profile [name|default]",
+	"profile.same":        "✅ Profile <b>%s</b> is already active",
+	"profile.switching":   "🔄 Switching to profile <b>%s</b>, the gateway is restarting…",
+	"profile.error":       "⚠️ Cannot switch profile: %s",
+	"profile.unavailable": "⚠️ Profile switching is not available in this mode",
+
+	// ─── /research ───
+	"research.usage":   "🔎 Usage: /research &lt;topic&gt;",
+	"research.started": "🔎 Researching: <b>%s</b>\nSearching several angles, answer will include numbered citations…",
+
+	// ─── /workflow ───
+	"workflow.usage":       "Usage: /workflow list | /workflow run &lt;name&gt; [input]\nWorkflows are defined in ~/.ngoclaw/workflows/*.yaml; schedule one with /cron add &lt;expr&gt; /workflow run &lt;name&gt;",
+	"workflow.unavailable": "🔀 Workflows are not enabled (they need the Telegram mode)",
+	"workflow.none":        "🔀 No workflows yet",
+	"workflow.title":       "🔀 <b>Workflows</b>",
+	"workflow.invalid":     "⚠️ Invalid definition: %s",
+	"workflow.started":     "🔀 Running workflow <b>%s</b> (%d stages); the stage-by-stage summary follows when it finishes",
+	"workflow.error":       "❌ Cannot run workflow: %s",
+
+	// ─── Templates ───
+	"template.title":       "📋 <b>Prompt templates</b>",
+	"template.empty":       "📋 No templates yet\n\nCreate <code>name.md</code> in <code>%s</code>; mark placeholders with {{variable}}",
+	"template.usage":       "Usage: /t &lt;name&gt; key=value ...",
+	"template.ask":         "✏️ Enter a value for <b>%s</b> (template <code>%s</code>), or send any command to cancel",
+	"template.unavailable": "Templates are not enabled",
+	"template.ask_cli":     "%s = ",
+
+	// ─── /setvar ───
+	"setvar.title":       "🔤 <b>Prompt variables</b>",
+	"setvar.empty":       "No variables set",
+	"setvar.config":      "(config default)",
+	"setvar.set":         "✅ <code>{{%s}}</code> = %s",
+	"setvar.removed":     "🗑 Removed <code>{{%s}}</code> (the config default applies again, if any)",
+	"setvar.too_long":    "❌ Value too long (max %d characters)",
+	"setvar.too_many":    "❌ Too many variables (max %d per chat)",
+	"setvar.usage":       "Usage: /setvar name value — set; /setvar name — remove\n{{name}} in soul.md and prompts/*.md is replaced with the value on every run",
+	"setvar.unavailable": "⚙️ Prompt variables are not available in this chat",
+
+	// ─── /tools ───
+	"tools.unavailable":      "🛠 The tool catalog is not available",
+	"tools.title":            "🛠 <b>Tools</b> (%d)",
+	"tools.mode":             " · approval mode <code>%s</code>",
+	"tools.legend":           "<i>🔒 every call needs approval · ❔ depends on the arguments (command risk, trusted prefixes, read-only actions)</i>",
+	"tools.hint":             "<i>/tools &lt;category&gt; for descriptions · /security to change the approval mode · /trust to skip approval</i>",
+	"tools.disabled":         "⛔ Disabled: %s",
+	"tools.unknown_category": "❌ No tools of category <code>%s</code>\nAvailable: %s",
+	"tools.cat.read":         "📖 Read",
+	"tools.cat.search":       "🔍 Search",
+	"tools.cat.edit":         "✏️ Edit",
+	"tools.cat.delete":       "🗑 Delete",
+	"tools.cat.execute":      "⚡ Execute",
+	"tools.cat.fetch":        "🌐 Fetch",
+	"tools.cat.mcp":          "🔌 MCP",
+	"tools.cat.think":        "💭 Think",
+	"tools.cat.communicate":  "💬 Communicate",
+
+	// ─── /memory ───
+	"memory.title":          "🧠 <b>Long-term memory</b> (%d facts)",
+	"memory.empty":          "🧠 Memory is empty\n\nAdd facts with /memory add &lt;text&gt;, or ask the AI to call save_memory.",
+	"memory.more":           "<i>%d facts in total, showing the newest %d</i>",
+	"memory.hint":           "<i>/memory edit|delete &lt;id&gt; to change · /memory audit for history</i>",
+	"memory.usage":          "Usage:\n/memory — list\n/memory add [category:] &lt;text&gt;\n/memory edit &lt;id&gt; &lt;new text&gt;\n/memory delete &lt;id&gt;\n/memory audit — change history\nCategories: preference, knowledge, context, behavior, goal",
+	"memory.added":          "✅ Remembered <code>%s</code> [%s]: %s",
+	"memory.not_found":      "❌ No memory with ID <code>%s</code>",
+	"memory.confirm_edit":   "✏️ Edit memory <code>%s</code>?\n\n<s>%s</s>\n→ %s",
+	"memory.confirm_delete": "🗑 Delete memory <code>%s</code>?\n\n%s",
+	"memory.confirm_btn":    "✅ Confirm",
+	"memory.cancel_btn":     "❌ Cancel",
+	"memory.edited":         "✅ Edited <code>%s</code>: %s",
+	"memory.deleted":        "🗑 Deleted <code>%s</code>: %s",
+	"memory.cancelled":      "Cancelled, memory unchanged",
+	"memory.expired":        "⌛ This confirmation has expired, run the command again",
+	"memory.error":          "❌ Memory update failed: %s",
+	"memory.audit_title":    "📜 <b>Memory changes</b> (last %d)",
+	"memory.audit_empty":    "📜 No memory changes yet",
+
+	"recall.usage":       "Usage: /recall &lt;words&gt;\nFull-text search over this chat's past runs, transcripts and artifacts (research reports, archived tool outputs)",
+	"recall.unavailable": "🔎 Past-run search is not enabled",
+	"recall.none":        "🔎 No past runs or artifacts match <b>%s</b>",
+	"recall.title":       "🔎 <b>%s</b> · %d results",
+	"recall.hint":        "<i>Ask me to open one: “read run:…” (read_artifact)</i>",
+	"recall.error":       "❌ Search failed: %s",
+
+	// ─── Data erasure (/forgetme) ───
+	"forget.confirm":     "⚠️ <b>Delete all data for this chat?</b>\n\nThis permanently deletes the conversation history, chat preferences, stored messages and feedback, run transcripts, research dossiers, and long-term memory learned in this chat. It cannot be undone.",
+	"forget.confirm_btn": "🗑 Delete everything",
+	"forget.cancel_btn":  "❌ Cancel",
+	"forget.cancelled":   "Cancelled, nothing was deleted",
+	"forget.expired":     "⌛ This confirmation has expired, send /forgetme again",
+	"forget.done":        "✅ All data for this chat was deleted (%d records)",
+	"forget.error":       "⚠️ Some data could not be deleted (%d records removed): %s",
+	"forget.disabled":    "⚠️ Data erasure is not enabled",
+
+	// ─── Share links (/share) ───
+	"share.created":     "🔗 Read-only link to the last run (valid until %s, secrets hidden automatically):\n%s",
+	"share.no_run":      "⚠️ No run to share yet (only recent runs since the gateway started are kept)",
+	"share.invalid_ttl": "⚠️ Invalid lifetime %s, e.g. /share 24h",
+	"share.error":       "⚠️ Could not create the share link: %s",
+	"share.disabled":    "⚠️ Share links are not enabled (gateway.share.enabled)",
+	"share.no_base_url": "💡 Set gateway.share.base_url to get a full URL here",
+
+	// ─── Scheduled jobs (/cron status) ───
+	"cron.status.title":          "⏰ <b>Scheduled jobs</b>",
+	"cron.status.empty":          "No scheduled jobs",
+	"cron.status.job":            "• <code>%s</code> <code>%s</code> — %s",
+	"cron.status.heartbeat":      "💓 Heartbeat (every %s, %s)",
+	"cron.status.disabled":       "disabled",
+	"cron.status.next":           "next: %s · catch-up: %s",
+	"cron.status.never":          "not run yet",
+	"cron.status.last_ok":        "last: %s ✅",
+	"cron.status.last_error":     "last: %s ❌ %s",
+	"cron.status.missed":         "missed %d runs while the gateway was down, caught up per policy",
+	"cron.status.missed_skipped": "missed %d runs while the gateway was down, skipped",
+
+	// ─── Control chat notices ───
+	"audit.config_set":       "⚙️ Config changed: <code>%s</code> = <code>%s</code>",
+	"audit.config_unset":     "⚙️ Config removed: <code>%s</code>",
+	"audit.debug_set":        "🐞 Debug override: <code>%s</code> = <code>%s</code>",
+	"audit.debug_unset":      "🐞 Debug override removed: <code>%s</code>",
+	"audit.debug_reset":      "🐞 All debug overrides cleared",
+	"audit.approval_denied":  "🚫 Approval denied: <code>%s</code>",
+	"audit.approval_timeout": "⏰ Approval timed out (auto-denied): <code>%s</code>",
+	"audit.budget":           "💸 Run stopped: budget exhausted (%d tokens used)",
+	"audit.provider_down":    "🔴 Provider <b>%s</b> keeps failing and is skipped for now: %s",
+	"audit.provider_up":      "🟢 Provider <b>%s</b> recovered",
+	"audit.restart":          "🔄 Gateway restart requested",
+	"audit.profile":          "🔄 Switching config profile to <b>%s</b>; the gateway restarts",
+	"audit.source":           "chat <code>%d</code>",
+	"audit.source_user":      "chat <code>%d</code> · user <code>%d</code>",
+
+	// ─── Commands ───
+	"cmd.help_title":   "📚 <b>Commands</b>",
+	"cmd.help_tip":     "💡 Just send a message to talk to the AI",
+	"cmd.denied":       "⛔ /%s is restricted to admins",
+	"cmd.invalid":      "⚙️ %s\nUsage: <code>%s</code>",
+	"cmd.arg_missing":  "missing argument %s",
+	"cmd.arg_invalid":  "invalid value %q, expected %s",
+	"cmd.arg_extra":    "unexpected arguments: %s",
+	"cmd.flag_unknown": "unknown option %s",
+	"cmd.flag_value":   "option %s needs a value (--name=value)",
+	"cmd.flag_novalue": "option %s takes no value",
+
+	"cmd.group.session":  "Session",
+	"cmd.group.model":    "Model",
+	"cmd.group.status":   "Status",
+	"cmd.group.config":   "Config",
+	"cmd.group.advanced": "Advanced",
+
+	"cmd.arg.text":         "text",
+	"cmd.arg.ttl":          "lifetime",
+	"cmd.arg.instructions": "instructions",
+	"cmd.arg.action":       "action",
+	"cmd.arg.args":         "args",
+	"cmd.arg.token":        "token",
+	"cmd.arg.provider":     "provider",
+	"cmd.arg.page":         "page",
+	"cmd.arg.level":        "level",
+	"cmd.arg.param":        "param",
+	"cmd.arg.value":        "value",
+	"cmd.arg.tool":         "tool",
+	"cmd.arg.lang":         "zh|en|auto",
+	"cmd.arg.name":         "name",
+	"cmd.arg.topic":        "topic",
+	"cmd.arg.vars":         "k=v",
+	"cmd.arg.id":           "id",
+	"cmd.arg.command":      "command",
+	"cmd.arg.category":     "category",
+
+	"cmd.new":        "new conversation",
+	"cmd.clear":      "clear history",
+	"cmd.stop":       "stop the current task",
+	"cmd.compact":    "compact context",
+	"cmd.context":    "context stats",
+	"cmd.reset":      "reset session",
+	"cmd.feedback":   "comment on the last answer (or react 👍/👎)",
+	"cmd.forgetme":   "delete all data for this chat",
+	"cmd.share":      "read-only link to the last run",
+	"cmd.models":     "browse and switch models (/model)",
+	"cmd.think":      "thinking level",
+	"cmd.verbose":    "verbose mode",
+	"cmd.reasoning":  "reasoning visibility",
+	"cmd.params":     "temperature / top_p / max tokens / reasoning effort",
+	"cmd.route":      "pick the model per task automatically",
+	"cmd.draft":      "cheap model drafts, current model reviews",
+	"cmd.status":     "current status / model stats",
+	"cmd.whoami":     "identity",
+	"cmd.usage":      "usage stats",
+	"cmd.commands":   "all commands",
+	"cmd.tools":      "tools by category, with approval needs",
+	"cmd.config":     "view/edit config",
+	"cmd.debug":      "runtime debug overrides",
+	"cmd.security":   "security policy",
+	"cmd.trust":      "trust tools",
+	"cmd.untrust":    "stop trusting tools",
+	"cmd.allowlist":  "allowlist",
+	"cmd.activation": "group activation",
+	"cmd.sendpolicy": "send policy",
+	"cmd.lang":       "interface and reply language",
+	"cmd.profile":    "switch config profile (restarts gateway)",
+	"cmd.restart":    "restart the gateway",
+	"cmd.skills":     "skills",
+	"cmd.skill":      "run a skill",
+	"cmd.cron":       "scheduled jobs",
+	"cmd.research":   "multi-source research with citations",
+	"cmd.workflow":   "named multi-stage workflows",
+	"cmd.plan":       "show the current plan",
+	"cmd.templates":  "prompt templates",
+	"cmd.t":          "run a template",
+	"cmd.setvar":     "prompt variables",
+	"cmd.memory":     "long-term memory",
+	"cmd.recall":     "search past runs and artifacts",
+	"cmd.agent":      "switch named agents (persona, tools, model)",
+	"cmd.subagents":  "sub-agents",
+	"cmd.tts":        "text to speech",
+	"cmd.approve":    "resolve an approval request",
+	"cmd.bash":       "run a shell command",
+	"cmd.plugin":     "plugin commands",
+	"cmd.help":       "help",
+
+	"cli.help.title":     "◇ Commands",
+	"cli.help.help":      "show this help",
+	"cli.help.model":     "show/switch model",
+	"cli.help.new":       "clear conversation history",
+	"cli.help.compact":   "compact context",
+	"cli.help.status":    "current status",
+	"cli.help.tools":     "list tools by category, with approval needs",
+	"cli.help.think":     "thinking level (off/low/medium/high)",
+	"cli.help.lang":      "interface and reply language (zh/en/auto)",
+	"cli.help.research":  "multi-source research with citations",
+	"cli.help.templates": "list prompt templates",
+	"cli.help.t":         "run a template (prompts for missing variables)",
+	"cli.help.version":   "version info",
+	"cli.help.expand":    "full output of a tool call from the last turn",
+	"cli.help.exit":      "quit",
+	"cli.tool.collapsed": "… %d more lines (/expand %d)",
+	"cli.expand.empty":   "No tool output in the last turn",
+	"cli.expand.usage":   "Usage: /expand [1-%d]",
+	"cli.new":            "🔄 Conversation history cleared",
+	"cli.model.current":  "Current model: %s\nUsage: /model <model_name>",
+	"cli.model.switched": "✓ Switched model to: %s",
+	"cli.compacted":      "🗜 Context compacted",
+	"cli.think":          "🧠 Thinking level: %s",
+	"cli.research.usage": "Usage: /research <topic>",
+	"cli.unknown":        "Unknown command: /%s  Type /help for the list",
+	"cli.quit_hint":      "(press Ctrl+C again to quit)",
+	"cli.bye":            "👋 Bye",
+}
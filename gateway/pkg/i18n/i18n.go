@@ -0,0 +1,79 @@
+// Package i18n provides a minimal message catalog for user-facing strings.
+//
+// Messages are looked up by dotted key (e.g. "approval.title") in the
+// catalog for the requested locale, falling back to the default locale and
+// finally to the key itself, so a missing translation never renders blank.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Locale 语言标识
+type Locale string
+
+const (
+	ZH Locale = "zh"
+	EN Locale = "en"
+
+	// Default is used when no locale is configured or the requested one is unknown.
+	Default = ZH
+)
+
+// Supported returns all locales that have a catalog.
+func Supported() []Locale {
+	return []Locale{ZH, EN}
+}
+
+// Parse normalizes a locale string ("zh-CN", "en_US.UTF-8", "EN") to a
+// supported Locale. ok is false when the input is empty or unsupported.
+func Parse(s string) (Locale, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return "", false
+	}
+	if idx := strings.IndexAny(s, "-_."); idx > 0 {
+		s = s[:idx]
+	}
+	switch Locale(s) {
+	case ZH, EN:
+		return Locale(s), true
+	}
+	return "", false
+}
+
+// Resolve picks the first supported locale from candidates, or Default.
+func Resolve(candidates ...string) Locale {
+	for _, c := range candidates {
+		if loc, ok := Parse(c); ok {
+			return loc
+		}
+	}
+	return Default
+}
+
+// FromEnv resolves the locale from the POSIX environment (LC_ALL → LC_MESSAGES → LANG).
+// Used by the CLI when no locale is configured.
+func FromEnv() Locale {
+	return Resolve(os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG"))
+}
+
+// T returns the message for key in this locale.
+func (l Locale) T(key string) string {
+	if msgs, ok := catalogs[l]; ok {
+		if msg, ok := msgs[key]; ok {
+			return msg
+		}
+	}
+	if msg, ok := catalogs[Default][key]; ok {
+		return msg
+	}
+	return key
+}
+
+// Tf formats the message for key with args (fmt.Sprintf semantics).
+func (l Locale) Tf(key string, args ...interface{}) string {
+	return fmt.Sprintf(l.T(key), args...)
+}
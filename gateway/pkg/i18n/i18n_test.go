@@ -0,0 +1,63 @@
+package i18n
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	cases := map[string]Locale{
+		"zh":          ZH,
+		"zh-CN":       ZH,
+		"zh_TW.UTF-8": ZH,
+		"EN":          EN,
+		"en_US.UTF-8": EN,
+	}
+	for in, want := range cases {
+		got, ok := Parse(in)
+		if !ok || got != want {
+			t.Errorf("Parse(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	for _, in := range []string{"", "fr", "C", "POSIX"} {
+		if _, ok := Parse(in); ok {
+			t.Errorf("Parse(%q) should be unsupported", in)
+		}
+	}
+}
+
+func TestResolveFallsBackToDefault(t *testing.T) {
+	if got := Resolve("", "de_DE"); got != Default {
+		t.Errorf("Resolve = %q, want %q", got, Default)
+	}
+	if got := Resolve("", "en_GB"); got != EN {
+		t.Errorf("Resolve = %q, want en", got)
+	}
+}
+
+func TestCatalogsHaveSameKeys(t *testing.T) {
+	for _, loc := range Supported() {
+		for key := range catalogs[Default] {
+			if _, ok := catalogs[loc][key]; !ok {
+				t.Errorf("locale %s missing key %q", loc, key)
+			}
+		}
+		for key := range catalogs[loc] {
+			if _, ok := catalogs[Default][key]; !ok {
+				t.Errorf("locale %s has key %q not in default catalog", loc, key)
+			}
+		}
+	}
+}
+
+func TestTFallback(t *testing.T) {
+	if got := EN.T("approval.approved"); got != "✅ Approved" {
+		t.Errorf("EN.T = %q", got)
+	}
+	if got := Locale("fr").T("approval.approved"); got != ZH.T("approval.approved") {
+		t.Errorf("unknown locale should fall back to default, got %q", got)
+	}
+	if got := EN.T("no.such.key"); got != "no.such.key" {
+		t.Errorf("missing key should return key, got %q", got)
+	}
+	if got := EN.Tf("status.session", 42); got != "💬 Session: <code>42</code>" {
+		t.Errorf("Tf = %q", got)
+	}
+}